@@ -3,7 +3,10 @@ package apikey
 import (
 	"context"
 	"net/http"
+	"slices"
 	"strings"
+
+	apierrors "github.com/CedrosPay/server/internal/errors"
 )
 
 // Tier represents the API key tier level.
@@ -32,11 +35,19 @@ type Config struct {
 
 	// Enabled controls whether API key authentication is active.
 	Enabled bool
+
+	// KeyScopes limits API keys to scopes. A scoped key may only call Routes with one of its
+	// scopes; keys not listed may call any route.
+	KeyScopes map[string][]Scope
+
+	// Routes assigns the scopes scoped keys need.
+	Routes []RouteScope
 }
 
 // Middleware validates API keys and stores tier information in request context.
 // If no API key is provided or key is invalid, request proceeds with TierFree (default rate limits apply).
 // If valid API key is provided, tier is stored in context for rate limit exemptions.
+// Requests made with a scoped key to a route outside its scopes are rejected with 403.
 func Middleware(cfg Config) func(http.Handler) http.Handler {
 	if !cfg.Enabled || len(cfg.APIKeys) == 0 {
 		// API key system disabled - all requests are free tier
@@ -48,6 +59,7 @@ func Middleware(cfg Config) func(http.Handler) http.Handler {
 		}
 	}
 
+	routes := newRouteScopes(cfg.Routes)
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			tier := TierFree // Default tier
@@ -60,6 +72,14 @@ func Middleware(cfg Config) func(http.Handler) http.Handler {
 				// Lookup tier for this API key
 				if keyTier, ok := cfg.APIKeys[apiKey]; ok {
 					tier = keyTier
+
+					if scopes, scoped := cfg.KeyScopes[apiKey]; scoped {
+						scope, ok := routes.find(r)
+						if !ok || !slices.Contains(scopes, scope) {
+							apierrors.WriteSimpleError(w, apierrors.ErrCodeInsufficientScope, "API key is not permitted to call this endpoint")
+							return
+						}
+					}
 				}
 				// Invalid API keys are treated as free tier (no error returned)
 			}
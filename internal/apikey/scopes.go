@@ -0,0 +1,69 @@
+package apikey
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Scope is a permission an API key can be limited to.
+type Scope string
+
+const (
+	ScopeQuotesRead    Scope = "quotes:read"    // Quotes, product listings, coupon validation, and preflight checks
+	ScopePaymentsWrite Scope = "payments:write" // Payment verification, checkout sessions, and entitlement checks
+	ScopeRefundsWrite  Scope = "refunds:write"  // Refund requests and notes
+	ScopeRefundsAdmin  Scope = "refunds:admin"  // Refund approval and denial, pending refunds, and refund audit trails
+	ScopeWebhooksAdmin Scope = "webhooks:admin" // Failed webhook retries
+)
+
+// Scopes lists every scope.
+var Scopes = []Scope{ScopeQuotesRead, ScopePaymentsWrite, ScopeRefundsWrite, ScopeRefundsAdmin, ScopeWebhooksAdmin}
+
+// ValidScope reports whether scope is one of Scopes.
+func ValidScope(scope string) bool {
+	return slices.Contains(Scopes, Scope(scope))
+}
+
+// RouteScope is the scope a scoped API key needs to call a route.
+type RouteScope struct {
+	Method string // Empty for any method
+	Route  string // chi route pattern, e.g. "/paywall/v1/quote"
+	Scope  Scope
+}
+
+// routeScopes finds the scope of a request's route.
+type routeScopes struct {
+	routes *chi.Mux
+	scopes map[routeScopeKey]Scope
+}
+
+type routeScopeKey struct{ method, route string }
+
+func newRouteScopes(routes []RouteScope) routeScopes {
+	rs := routeScopes{routes: chi.NewRouter(), scopes: make(map[routeScopeKey]Scope, len(routes))}
+	noop := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+	for _, route := range routes {
+		if route.Method == "" {
+			rs.routes.Handle(route.Route, noop)
+		} else {
+			rs.routes.Method(route.Method, route.Route, noop)
+		}
+		rs.scopes[routeScopeKey{route.Method, route.Route}] = route.Scope
+	}
+	return rs
+}
+
+// find returns the scope needed to call r's route, or false for routes without one.
+func (rs routeScopes) find(r *http.Request) (Scope, bool) {
+	route := rs.routes.Find(chi.NewRouteContext(), r.Method, r.URL.Path)
+	if route == "" {
+		return "", false
+	}
+	if scope, ok := rs.scopes[routeScopeKey{r.Method, route}]; ok {
+		return scope, true
+	}
+	scope, ok := rs.scopes[routeScopeKey{"", route}]
+	return scope, ok
+}
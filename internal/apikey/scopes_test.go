@@ -0,0 +1,61 @@
+package apikey
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMiddleware_Scopes(t *testing.T) {
+	cfg := Config{
+		Enabled: true,
+		APIKeys: map[string]Tier{
+			"partner_quotes": TierPartner,
+			"partner_all":    TierPartner,
+		},
+		KeyScopes: map[string][]Scope{
+			"partner_quotes": {ScopeQuotesRead},
+		},
+		Routes: []RouteScope{
+			{Method: http.MethodPost, Route: "/paywall/v1/quote", Scope: ScopeQuotesRead},
+			{Route: "/paywall/v1/products", Scope: ScopeQuotesRead},
+			{Method: http.MethodPost, Route: "/paywall/v1/refunds/approve", Scope: ScopeRefundsAdmin},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		apiKey     string
+		method     string
+		path       string
+		wantStatus int
+	}{
+		{"scoped key within scope", "partner_quotes", http.MethodPost, "/paywall/v1/quote", http.StatusOK},
+		{"scoped key on any-method route", "partner_quotes", http.MethodGet, "/paywall/v1/products", http.StatusOK},
+		{"scoped key outside scope", "partner_quotes", http.MethodPost, "/paywall/v1/refunds/approve", http.StatusForbidden},
+		{"scoped key on other method", "partner_quotes", http.MethodGet, "/paywall/v1/quote", http.StatusForbidden},
+		{"scoped key on unscoped route", "partner_quotes", http.MethodGet, "/cedros-health", http.StatusForbidden},
+		{"unscoped key", "partner_all", http.MethodPost, "/paywall/v1/refunds/approve", http.StatusOK},
+		{"invalid key", "nope", http.MethodPost, "/paywall/v1/refunds/approve", http.StatusOK},
+		{"no key", "", http.MethodGet, "/cedros-health", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.apiKey != "" {
+				req.Header.Set("X-API-Key", tt.apiKey)
+			}
+			rec := httptest.NewRecorder()
+
+			Middleware(cfg)(handler).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Expected %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"net/http"
+	"slices"
 
 	"github.com/gagliardetto/solana-go"
 )
@@ -65,6 +66,12 @@ func (sv *SignatureVerifier) VerifySignature(headers VerificationHeaders) error
 // expectedSigner is the configured admin wallet address (e.g., payment address).
 // expectedMessage is the message format the client should have signed.
 func (sv *SignatureVerifier) VerifyAdminRequest(r *http.Request, expectedSigner string, expectedMessage string) error {
+	return sv.VerifyAdminRequestFrom(r, []string{expectedSigner}, expectedMessage)
+}
+
+// VerifyAdminRequestFrom verifies a request is signed by any of several admin wallets,
+// e.g. the members of a multisig payment address.
+func (sv *SignatureVerifier) VerifyAdminRequestFrom(r *http.Request, admins []string, expectedMessage string) error {
 	headers, err := sv.ExtractHeaders(r)
 	if err != nil {
 		return err
@@ -77,7 +84,7 @@ func (sv *SignatureVerifier) VerifyAdminRequest(r *http.Request, expectedSigner
 	}
 
 	// Now that signature is verified, check signer identity
-	if headers.Signer != expectedSigner {
+	if !slices.Contains(admins, headers.Signer) {
 		return fmt.Errorf("unauthorized: only payment address can perform this action")
 	}
 
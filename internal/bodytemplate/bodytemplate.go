@@ -0,0 +1,154 @@
+// Package bodytemplate parses the Go templates merchants use to shape webhook payloads, with
+// functions for the usual payload chores: JSON quoting, dates, arithmetic, base64, and HMAC
+// signatures.
+package bodytemplate
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"text/template"
+	"time"
+)
+
+// Parse parses text as a body template with Funcs available.
+func Parse(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(Funcs()).Parse(text)
+}
+
+// Funcs returns the functions available in body templates:
+//
+//	json v               v as JSON, e.g. a quoted and escaped string
+//	formatTime layout t  t (a time.Time) in a Go time layout such as "2006-01-02"
+//	unix t               t as Unix seconds
+//	add, sub, mul a b    integer arithmetic, or float if either is a float
+//	div a b              float division, e.g. {{printf "%.2f" (div .FiatAmountCents 100)}}
+//	base64 s             s base64-encoded
+//	hmacSHA256 key s     hex HMAC-SHA256 of s
+func Funcs() template.FuncMap {
+	return template.FuncMap{
+		"json":       toJSON,
+		"formatTime": formatTime,
+		"unix":       unix,
+		"add":        arith(func(a, b int64) int64 { return a + b }, func(a, b float64) float64 { return a + b }),
+		"sub":        arith(func(a, b int64) int64 { return a - b }, func(a, b float64) float64 { return a - b }),
+		"mul":        arith(func(a, b int64) int64 { return a * b }, func(a, b float64) float64 { return a * b }),
+		"div":        div,
+		"base64":     func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+		"hmacSHA256": hmacSHA256,
+	}
+}
+
+func toJSON(v any) (string, error) {
+	out, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// asTime accepts time.Time and *time.Time, so optional event times can be formatted too; a nil
+// or zero time formats as empty.
+func asTime(t any) (time.Time, error) {
+	switch v := t.(type) {
+	case time.Time:
+		return v, nil
+	case *time.Time:
+		if v == nil {
+			return time.Time{}, nil
+		}
+		return *v, nil
+	default:
+		return time.Time{}, fmt.Errorf("expected a time, got %T", t)
+	}
+}
+
+func formatTime(layout string, t any) (string, error) {
+	tm, err := asTime(t)
+	if err != nil || tm.IsZero() {
+		return "", err
+	}
+	return tm.Format(layout), nil
+}
+
+func unix(t any) (int64, error) {
+	tm, err := asTime(t)
+	if err != nil || tm.IsZero() {
+		return 0, err
+	}
+	return tm.Unix(), nil
+}
+
+// number converts a template value to an int64 or, for floats, a float64.
+func number(v any) (i int64, f float64, isInt bool, err error) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), 0, true, nil
+	case int32:
+		return int64(n), 0, true, nil
+	case int64:
+		return n, 0, true, nil
+	case uint32:
+		return int64(n), 0, true, nil
+	case uint64:
+		return int64(n), 0, true, nil
+	case float32:
+		return 0, float64(n), false, nil
+	case float64:
+		return 0, n, false, nil
+	default:
+		return 0, 0, false, fmt.Errorf("expected a number, got %T", v)
+	}
+}
+
+func asFloat(v any) (float64, error) {
+	i, f, isInt, err := number(v)
+	if isInt {
+		return float64(i), err
+	}
+	return f, err
+}
+
+func arith(ints func(a, b int64) int64, floats func(a, b float64) float64) func(a, b any) (any, error) {
+	return func(a, b any) (any, error) {
+		ai, _, aInt, err := number(a)
+		if err != nil {
+			return nil, err
+		}
+		bi, _, bInt, err := number(b)
+		if err != nil {
+			return nil, err
+		}
+		if aInt && bInt {
+			return ints(ai, bi), nil
+		}
+		af, _ := asFloat(a)
+		bf, _ := asFloat(b)
+		return floats(af, bf), nil
+	}
+}
+
+func div(a, b any) (float64, error) {
+	af, err := asFloat(a)
+	if err != nil {
+		return 0, err
+	}
+	bf, err := asFloat(b)
+	if err != nil {
+		return 0, err
+	}
+	if bf == 0 {
+		return 0, errors.New("division by zero")
+	}
+	return af / bf, nil
+}
+
+func hmacSHA256(key, message string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(message))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,61 @@
+package bodytemplate
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFuncs(t *testing.T) {
+	paidAt := time.Date(2025, 11, 7, 12, 30, 0, 0, time.UTC)
+	data := map[string]any{
+		"Resource":    `say "hi"`,
+		"Cents":       int64(1999),
+		"Atomic":      int64(10500000),
+		"Rate":        1.5,
+		"PaidAt":      paidAt,
+		"GracePeriod": (*time.Time)(nil),
+	}
+
+	tests := []struct {
+		name    string
+		tmpl    string
+		want    string
+		wantErr string
+	}{
+		{name: "json quoting", tmpl: `{"text":{{json .Resource}}}`, want: `{"text":"say \"hi\""}`},
+		{name: "date", tmpl: `{{formatTime "2006-01-02" .PaidAt}}`, want: "2025-11-07"},
+		{name: "unix", tmpl: `{{unix .PaidAt}}`, want: "1762518600"},
+		{name: "nil time", tmpl: `[{{formatTime "2006-01-02" .GracePeriod}}]`, want: "[]"},
+		{name: "integer math", tmpl: `{{add .Cents 1}} {{sub .Cents 999}} {{mul .Atomic 2}}`, want: "2000 1000 21000000"},
+		{name: "float math", tmpl: `{{mul .Rate 2}}`, want: "3"},
+		{name: "division", tmpl: `{{printf "%.2f" (div .Cents 100)}}`, want: "19.99"},
+		{name: "division by zero", tmpl: `{{div .Cents 0}}`, wantErr: "division by zero"},
+		{name: "not a number", tmpl: `{{add .Resource 1}}`, wantErr: "expected a number"},
+		{name: "base64", tmpl: `{{base64 "user:pass"}}`, want: "dXNlcjpwYXNz"},
+		{name: "hmac", tmpl: `{{hmacSHA256 "key" "The quick brown fox jumps over the lazy dog"}}`, want: "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := Parse("test", tt.tmpl)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			var buf bytes.Buffer
+			err = tmpl.Execute(&buf, data)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Execute error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute: %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
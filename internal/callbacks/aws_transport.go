@@ -0,0 +1,228 @@
+package callbacks
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	snstypes "github.com/aws/aws-sdk-go-v2/service/sns/types"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+
+	"github.com/CedrosPay/server/internal/config"
+)
+
+// maxAWSMessageAttributes is the SQS/SNS limit on message attributes per message.
+const maxAWSMessageAttributes = 10
+
+// awsTarget is a parsed SQS queue or SNS topic ARN.
+// Format: arn:<partition>:<sqs|sns>:<region>:<account-id>:<name>
+type awsTarget struct {
+	Service string // "sqs" or "sns"
+	Region  string
+	Account string
+	Name    string
+	ARN     string
+}
+
+// IsAWSTarget reports whether a callback destination is an SQS queue or SNS topic ARN
+// rather than an HTTP URL.
+func IsAWSTarget(destination string) bool {
+	_, err := parseAWSTarget(destination)
+	return err == nil
+}
+
+// parseAWSTarget parses an SQS or SNS ARN.
+func parseAWSTarget(arn string) (awsTarget, error) {
+	parts := strings.Split(arn, ":")
+	if len(parts) != 6 || parts[0] != "arn" {
+		return awsTarget{}, fmt.Errorf("callbacks: %q is not an AWS ARN", arn)
+	}
+	if parts[2] != "sqs" && parts[2] != "sns" {
+		return awsTarget{}, fmt.Errorf("callbacks: unsupported AWS service %q (supported: sqs, sns)", parts[2])
+	}
+	if parts[3] == "" || parts[4] == "" || parts[5] == "" {
+		return awsTarget{}, fmt.Errorf("callbacks: incomplete AWS ARN %q", arn)
+	}
+	return awsTarget{
+		Service: parts[2],
+		Region:  parts[3],
+		Account: parts[4],
+		Name:    parts[5],
+		ARN:     arn,
+	}, nil
+}
+
+// isFIFO reports whether the queue or topic requires message group and deduplication IDs.
+func (t awsTarget) isFIFO() bool {
+	return strings.HasSuffix(t.Name, ".fifo")
+}
+
+// AWSTransport delivers webhook payloads to SQS queues and SNS topics addressed by ARN.
+// Clients are created lazily per region, so a single transport can serve destinations
+// in multiple regions.
+type AWSTransport struct {
+	cfg  config.AWSDeliveryConfig
+	base aws.Config
+
+	mu         sync.Mutex
+	sqsClients map[string]*sqs.Client
+	snsClients map[string]*sns.Client
+}
+
+// NewAWSTransport loads AWS credentials (static keys if configured, otherwise the default
+// credential chain: env vars, shared config, instance/task roles).
+func NewAWSTransport(ctx context.Context, cfg config.AWSDeliveryConfig) (*AWSTransport, error) {
+	var loadOpts []func(*awsconfig.LoadOptions) error
+	if cfg.Endpoint != "" {
+		loadOpts = append(loadOpts, awsconfig.WithBaseEndpoint(cfg.Endpoint))
+	}
+	base, err := loadAWSConfig(ctx, cfg, loadOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AWSTransport{
+		cfg:        cfg,
+		base:       base,
+		sqsClients: make(map[string]*sqs.Client),
+		snsClients: make(map[string]*sns.Client),
+	}, nil
+}
+
+// loadAWSConfig resolves the region and credentials in cfg, falling back to the default chain.
+func loadAWSConfig(ctx context.Context, cfg config.AWSDeliveryConfig, loadOpts ...func(*awsconfig.LoadOptions) error) (aws.Config, error) {
+	if cfg.Region != "" {
+		loadOpts = append(loadOpts, awsconfig.WithRegion(cfg.Region))
+	}
+	if cfg.AccessKeyID != "" && cfg.SecretAccessKey != "" {
+		loadOpts = append(loadOpts, awsconfig.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(cfg.AccessKeyID, cfg.SecretAccessKey, cfg.SessionToken),
+		))
+	}
+
+	base, err := awsconfig.LoadDefaultConfig(ctx, loadOpts...)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("callbacks: load aws config: %w", err)
+	}
+	return base, nil
+}
+
+// Deliver sends the payload to the SQS queue or SNS topic identified by destination.
+// Headers are forwarded as string message attributes alongside the event type.
+// dedupID is used as the deduplication ID for FIFO queues/topics.
+func (t *AWSTransport) Deliver(ctx context.Context, destination string, payload []byte, headers map[string]string, eventType, dedupID string) error {
+	target, err := parseAWSTarget(destination)
+	if err != nil {
+		return err
+	}
+	if dedupID == "" {
+		sum := sha256.Sum256(payload)
+		dedupID = hex.EncodeToString(sum[:])
+	}
+	attrs := awsMessageAttributes(headers, eventType)
+	body := string(payload)
+
+	switch target.Service {
+	case "sqs":
+		values := make(map[string]sqstypes.MessageAttributeValue, len(attrs))
+		for k, v := range attrs {
+			values[k] = sqstypes.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(v)}
+		}
+		input := &sqs.SendMessageInput{
+			QueueUrl:          aws.String(t.queueURL(target)),
+			MessageBody:       aws.String(body),
+			MessageAttributes: values,
+		}
+		if target.isFIFO() {
+			input.MessageGroupId = aws.String("cedros-" + eventType)
+			input.MessageDeduplicationId = aws.String(dedupID)
+		}
+		if _, err := t.sqsClient(target.Region).SendMessage(ctx, input); err != nil {
+			return fmt.Errorf("send sqs message to %s: %w", target.ARN, err)
+		}
+	case "sns":
+		values := make(map[string]snstypes.MessageAttributeValue, len(attrs))
+		for k, v := range attrs {
+			values[k] = snstypes.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(v)}
+		}
+		input := &sns.PublishInput{
+			TopicArn:          aws.String(target.ARN),
+			Message:           aws.String(body),
+			MessageAttributes: values,
+		}
+		if target.isFIFO() {
+			input.MessageGroupId = aws.String("cedros-" + eventType)
+			input.MessageDeduplicationId = aws.String(dedupID)
+		}
+		if _, err := t.snsClient(target.Region).Publish(ctx, input); err != nil {
+			return fmt.Errorf("publish sns message to %s: %w", target.ARN, err)
+		}
+	}
+	return nil
+}
+
+// queueURL derives the SQS queue URL from its ARN (honoring a custom endpoint such as LocalStack).
+func (t *AWSTransport) queueURL(target awsTarget) string {
+	if t.cfg.Endpoint != "" {
+		return fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(t.cfg.Endpoint, "/"), target.Account, target.Name)
+	}
+	return fmt.Sprintf("https://sqs.%s.amazonaws.com/%s/%s", target.Region, target.Account, target.Name)
+}
+
+func (t *AWSTransport) sqsClient(region string) *sqs.Client {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if client, ok := t.sqsClients[region]; ok {
+		return client
+	}
+	client := sqs.NewFromConfig(t.base, func(o *sqs.Options) { o.Region = region })
+	t.sqsClients[region] = client
+	return client
+}
+
+func (t *AWSTransport) snsClient(region string) *sns.Client {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if client, ok := t.snsClients[region]; ok {
+		return client
+	}
+	client := sns.NewFromConfig(t.base, func(o *sns.Options) { o.Region = region })
+	t.snsClients[region] = client
+	return client
+}
+
+// awsMessageAttributes converts webhook headers to message attributes.
+// The event type is always included; remaining headers are added in sorted order
+// until the 10-attribute limit is reached. Content-Type is dropped (bodies are JSON).
+func awsMessageAttributes(headers map[string]string, eventType string) map[string]string {
+	attrs := map[string]string{"cedros-event-type": eventType}
+
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		if k == "" || strings.EqualFold(k, "content-type") {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if len(attrs) >= maxAWSMessageAttributes {
+			break
+		}
+		if headers[k] == "" {
+			continue
+		}
+		attrs[k] = headers[k]
+	}
+	return attrs
+}
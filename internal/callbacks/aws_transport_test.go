@@ -0,0 +1,132 @@
+package callbacks
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/CedrosPay/server/internal/config"
+)
+
+func TestParseAWSTarget(t *testing.T) {
+	tests := []struct {
+		name    string
+		arn     string
+		service string
+		region  string
+		fifo    bool
+		wantErr bool
+	}{
+		{name: "sqs queue", arn: "arn:aws:sqs:us-east-1:123456789012:payments", service: "sqs", region: "us-east-1"},
+		{name: "sns fifo topic", arn: "arn:aws:sns:eu-west-1:123456789012:events.fifo", service: "sns", region: "eu-west-1", fifo: true},
+		{name: "gov partition", arn: "arn:aws-us-gov:sqs:us-gov-west-1:123456789012:q", service: "sqs", region: "us-gov-west-1"},
+		{name: "https url", arn: "https://example.com/webhook", wantErr: true},
+		{name: "unsupported service", arn: "arn:aws:lambda:us-east-1:123456789012:fn", wantErr: true},
+		{name: "missing region", arn: "arn:aws:sqs::123456789012:payments", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, err := parseAWSTarget(tt.arn)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error for %q", tt.arn)
+				}
+				if IsAWSTarget(tt.arn) {
+					t.Fatalf("IsAWSTarget(%q) = true, want false", tt.arn)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if target.Service != tt.service || target.Region != tt.region || target.isFIFO() != tt.fifo {
+				t.Fatalf("got %+v (fifo=%v)", target, target.isFIFO())
+			}
+		})
+	}
+}
+
+func TestAWSMessageAttributes_Limit(t *testing.T) {
+	headers := map[string]string{"Content-Type": "application/json"}
+	for i := 0; i < 15; i++ {
+		headers[string(rune('a'+i))] = "v"
+	}
+
+	attrs := awsMessageAttributes(headers, "payment")
+	if len(attrs) != maxAWSMessageAttributes {
+		t.Fatalf("expected %d attributes, got %d", maxAWSMessageAttributes, len(attrs))
+	}
+	if attrs["cedros-event-type"] != "payment" {
+		t.Fatal("expected event type attribute")
+	}
+	if _, ok := attrs["Content-Type"]; ok {
+		t.Fatal("content-type should not be forwarded")
+	}
+}
+
+func TestAWSTargets_AllDeliveryPaths(t *testing.T) {
+	var published atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.Form.Get("Action") != "Publish" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		published.Add(1)
+		w.Header().Set("Content-Type", "text/xml")
+		_, _ = w.Write([]byte(`<PublishResponse><PublishResult><MessageId>m-1</MessageId></PublishResult></PublishResponse>`))
+	}))
+	defer server.Close()
+
+	cfg := config.CallbacksConfig{
+		PaymentSuccessURL: "arn:aws:sns:us-east-1:123456789012:payments",
+		Timeout:           config.Duration{Duration: 3 * time.Second},
+		AWS: config.AWSDeliveryConfig{
+			Region:          "us-east-1",
+			AccessKeyID:     "test",
+			SecretAccessKey: "test",
+			Endpoint:        server.URL,
+		},
+	}
+	transport, err := NewAWSTransport(context.Background(), cfg.AWS)
+	if err != nil {
+		t.Fatalf("NewAWSTransport: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		deliver func(t *testing.T)
+	}{
+		{
+			name: "retryable client refund event",
+			deliver: func(t *testing.T) {
+				client := NewRetryableClient(cfg, WithAWSTransport(transport))
+				client.RefundSucceeded(context.Background(), RefundEvent{RefundID: "refund-1"})
+				if err := client.(*RetryableClient).Drain(context.Background()); err != nil {
+					t.Fatalf("Drain: %v", err)
+				}
+			},
+		},
+		{
+			name: "send once",
+			deliver: func(t *testing.T) {
+				if err := SendOnce(context.Background(), cfg, PaymentEvent{ResourceID: "r-1"}); err != nil {
+					t.Fatalf("SendOnce: %v", err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := published.Load()
+			tt.deliver(t)
+			if got := published.Load() - before; got != 1 {
+				t.Errorf("published %d messages, want 1", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package callbacks
+
+import (
+	"context"
+
+	"github.com/CedrosPay/server/internal/eventbus"
+	"github.com/CedrosPay/server/internal/tenant"
+)
+
+// BusNotifier forwards payment, payment failure, refund, and subscription events to the in-process event bus
+// (consumed by the merchant WebSocket channel). The tenant is taken from the request context.
+type BusNotifier struct {
+	bus *eventbus.Bus
+}
+
+// NewBusNotifier creates a notifier that publishes to bus.
+func NewBusNotifier(bus *eventbus.Bus) *BusNotifier {
+	return &BusNotifier{bus: bus}
+}
+
+// PaymentSucceeded publishes a payment.succeeded event.
+func (n *BusNotifier) PaymentSucceeded(ctx context.Context, event PaymentEvent) {
+	if n == nil {
+		return
+	}
+	PreparePaymentEvent(&event)
+	n.bus.Publish(eventbus.Event{
+		ID:        event.EventID,
+		Type:      eventbus.TypePaymentSucceeded,
+		TenantID:  tenant.FromContext(ctx),
+		Timestamp: event.EventTimestamp,
+		Data:      event,
+	})
+}
+
+// RefundSucceeded publishes a refund.succeeded event.
+func (n *BusNotifier) RefundSucceeded(ctx context.Context, event RefundEvent) {
+	if n == nil {
+		return
+	}
+	PrepareRefundEvent(&event)
+	n.bus.Publish(eventbus.Event{
+		ID:        event.EventID,
+		Type:      eventbus.TypeRefundSucceeded,
+		TenantID:  tenant.FromContext(ctx),
+		Timestamp: event.EventTimestamp,
+		Data:      event,
+	})
+}
+
+// SubscriptionChanged publishes the event under its own type (subscription.created,
+// subscription.paused, and so on).
+func (n *BusNotifier) SubscriptionChanged(ctx context.Context, event SubscriptionEvent) {
+	if n == nil {
+		return
+	}
+	PrepareSubscriptionEvent(&event)
+	n.bus.Publish(eventbus.Event{
+		ID:        event.EventID,
+		Type:      event.EventType,
+		TenantID:  tenant.FromContext(ctx),
+		Timestamp: event.EventTimestamp,
+		Data:      event,
+	})
+}
+
+// RefundRequestChanged publishes the event under its own type (refund.auto_denied or
+// refund.escalated).
+func (n *BusNotifier) RefundRequestChanged(ctx context.Context, event RefundRequestEvent) {
+	if n == nil {
+		return
+	}
+	PrepareRefundRequestEvent(&event)
+	n.bus.Publish(eventbus.Event{
+		ID:        event.EventID,
+		Type:      event.EventType,
+		TenantID:  tenant.FromContext(ctx),
+		Timestamp: event.EventTimestamp,
+		Data:      event,
+	})
+}
+
+// PaymentFailed publishes a payment.failed event.
+func (n *BusNotifier) PaymentFailed(ctx context.Context, event PaymentFailedEvent) {
+	if n == nil {
+		return
+	}
+	PreparePaymentFailedEvent(&event)
+	n.bus.Publish(eventbus.Event{
+		ID:        event.EventID,
+		Type:      eventbus.TypePaymentFailed,
+		TenantID:  tenant.FromContext(ctx),
+		Timestamp: event.EventTimestamp,
+		Data:      event,
+	})
+}
+
+// WebhookFailure describes a webhook that exhausted all delivery attempts.
+type WebhookFailure struct {
+	WebhookID string `json:"webhookId,omitempty"`
+	EventID   string `json:"eventId,omitempty"`
+	EventType string `json:"eventType"` // "payment", "payment_failed", "refund", "subscription", or "refund_request"
+	URL       string `json:"url"`
+	Attempts  int    `json:"attempts"`
+	Error     string `json:"error"`
+}
+
+// publishWebhookFailure emits a webhook.failed event if a bus is configured.
+func publishWebhookFailure(bus *eventbus.Bus, tenantID string, failure WebhookFailure) {
+	bus.Publish(eventbus.Event{
+		Type:     eventbus.TypeWebhookFailed,
+		TenantID: tenantID,
+		Data:     failure,
+	})
+}
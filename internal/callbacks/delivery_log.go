@@ -0,0 +1,77 @@
+package callbacks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/rs/zerolog"
+)
+
+// DeliveryLog records webhook delivery attempts, so operators can check whether a webhook
+// fired without searching logs. storage.Store implements it.
+type DeliveryLog interface {
+	RecordWebhookDelivery(ctx context.Context, delivery storage.WebhookDelivery) error
+}
+
+// deliveryResponse is a receiver's answer to one delivery attempt. Zero when the attempt got
+// no HTTP response (network errors, SQS/SNS targets).
+type deliveryResponse struct {
+	status int
+	body   string // First storage.MaxWebhookDeliveryResponse bytes, as valid UTF-8
+}
+
+// readDeliveryResponse captures resp's status code and the start of its body.
+func readDeliveryResponse(resp *http.Response) deliveryResponse {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, storage.MaxWebhookDeliveryResponse))
+	text := strings.ToValidUTF8(strings.ReplaceAll(string(body), "\x00", ""), "")
+	return deliveryResponse{status: resp.StatusCode, body: text}
+}
+
+// deliveryRecord describes one attempt to deliver an eventType webhook to url.
+func deliveryRecord(url, eventType string, attempt int, resp deliveryResponse, latency time.Duration, err error) storage.WebhookDelivery {
+	record := storage.WebhookDelivery{
+		EventType:    eventType,
+		URL:          url,
+		Attempt:      attempt,
+		StatusCode:   resp.status,
+		LatencyMs:    latency.Milliseconds(),
+		ResponseBody: resp.body,
+		Success:      err == nil,
+		AttemptedAt:  time.Now().UTC(),
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	return record
+}
+
+// recordDelivery appends record to log. Failures are logged rather than returned, so the
+// delivery log never holds up a webhook.
+func recordDelivery(ctx context.Context, log DeliveryLog, logger zerolog.Logger, record storage.WebhookDelivery) {
+	if log == nil {
+		return
+	}
+	if err := log.RecordWebhookDelivery(ctx, record); err != nil {
+		logger.Warn().
+			Err(err).
+			Str("url", record.URL).
+			Str("eventType", record.EventType).
+			Msg("callbacks: failed to record webhook delivery")
+	}
+}
+
+// payloadEventID returns the eventId of a JSON event payload, or "" for custom bodies.
+func payloadEventID(payload []byte) string {
+	var event struct {
+		EventID string `json:"eventId"`
+	}
+	if json.Unmarshal(payload, &event) != nil {
+		return ""
+	}
+	return event.EventID
+}
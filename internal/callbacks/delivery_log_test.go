@@ -0,0 +1,114 @@
+package callbacks
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func TestRetryableClient_RecordsDeliveries(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(strings.Repeat("busy ", 500)))
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	client := NewRetryableClient(config.CallbacksConfig{
+		PaymentSuccessURL: server.URL,
+		Retry:             config.RetryConfig{Enabled: true},
+	}, WithDeliveryLog(store), WithRetryConfig(RetryConfig{
+		MaxAttempts:     3,
+		InitialInterval: time.Millisecond,
+		MaxInterval:     time.Millisecond,
+		Multiplier:      1,
+		Timeout:         time.Second,
+	}))
+
+	client.PaymentSucceeded(context.Background(), PaymentEvent{EventID: "evt_logged", ResourceID: "ebook"})
+	if err := Drain(context.Background(), client); err != nil {
+		t.Fatalf("Drain: %v", err)
+	}
+
+	deliveries, err := store.ListWebhookDeliveries(context.Background(), storage.WebhookDeliveryFilter{EventID: "evt_logged"})
+	if err != nil {
+		t.Fatalf("ListWebhookDeliveries: %v", err)
+	}
+	if len(deliveries) != 2 {
+		t.Fatalf("recorded %d deliveries, want 2", len(deliveries))
+	}
+	byAttempt := map[int]storage.WebhookDelivery{}
+	for _, d := range deliveries {
+		byAttempt[d.Attempt] = d
+	}
+
+	tests := []struct {
+		attempt     int
+		wantStatus  int
+		wantSuccess bool
+		wantBody    int
+	}{
+		{attempt: 1, wantStatus: http.StatusServiceUnavailable, wantSuccess: false, wantBody: storage.MaxWebhookDeliveryResponse},
+		{attempt: 2, wantStatus: http.StatusOK, wantSuccess: true, wantBody: len(`{"ok":true}`)},
+	}
+	for _, tt := range tests {
+		d := byAttempt[tt.attempt]
+		if d.StatusCode != tt.wantStatus || d.Success != tt.wantSuccess || len(d.ResponseBody) != tt.wantBody {
+			t.Errorf("attempt %d = status %d success %v body %d bytes, want %d %v %d",
+				tt.attempt, d.StatusCode, d.Success, len(d.ResponseBody), tt.wantStatus, tt.wantSuccess, tt.wantBody)
+		}
+		if d.URL != server.URL || d.EventType != "payment" {
+			t.Errorf("attempt %d = %s %s, want payment %s", tt.attempt, d.EventType, d.URL, server.URL)
+		}
+	}
+	if byAttempt[1].Error == "" {
+		t.Error("failed attempt recorded without an error")
+	}
+}
+
+func TestWebhookQueueWorker_RecordsDeliveries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("unknown resource"))
+	}))
+	defer server.Close()
+
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	w := NewWebhookQueueWorker(WebhookQueueWorkerOptions{
+		Store:  store,
+		Config: config.CallbacksConfig{PaymentSuccessURL: server.URL},
+	})
+	if err := w.EnqueuePaymentWebhook(context.Background(), PaymentEvent{EventID: "evt_queued", ResourceID: "ebook"}); err != nil {
+		t.Fatalf("EnqueuePaymentWebhook: %v", err)
+	}
+	w.processQueue(context.Background())
+
+	deliveries, err := store.ListWebhookDeliveries(context.Background(), storage.WebhookDeliveryFilter{URL: server.URL})
+	if err != nil {
+		t.Fatalf("ListWebhookDeliveries: %v", err)
+	}
+	if len(deliveries) != 1 {
+		t.Fatalf("recorded %d deliveries, want 1", len(deliveries))
+	}
+	d := deliveries[0]
+	if d.EventID != "evt_queued" || d.WebhookID == "" || d.Attempt != 1 {
+		t.Errorf("delivery = event %q webhook %q attempt %d, want evt_queued, a webhook ID, attempt 1", d.EventID, d.WebhookID, d.Attempt)
+	}
+	if d.StatusCode != http.StatusBadRequest || d.Success || d.ResponseBody != "unknown resource" {
+		t.Errorf("delivery = status %d success %v body %q, want a failed 400 with the response body", d.StatusCode, d.Success, d.ResponseBody)
+	}
+}
@@ -0,0 +1,244 @@
+package callbacks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"text/template"
+
+	"github.com/CedrosPay/server/internal/bodytemplate"
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+// destination is where webhooks are delivered: callbacks.payment_success_url, which receives
+// every event, or an entry of callbacks.destinations, which receives the events it lists.
+type destination struct {
+	url        string
+	headers    map[string]string
+	body       string
+	tmpl       *template.Template
+	eventTmpls map[string]*template.Template // body_templates, by event type
+	events     []string                      // Empty for every event
+	priority   string                        // high, normal, low, or empty for the event type's default
+	limit      *destinationLimit             // max_in_flight and requests_per_second
+	retry      *RetryConfig                  // The destination's own retry settings, or nil
+}
+
+// newDestination parses a destination's body templates. A template that doesn't parse is
+// skipped, so the destination falls back to its other body settings, and its error returned.
+func newDestination(url string, headers map[string]string, body, bodyTemplate string, bodyTemplates map[string]string, events []string) (destination, error) {
+	dest := destination{url: url, headers: headers, body: body, events: events}
+	var errs []error
+	if bodyTemplate != "" {
+		tmpl, err := bodytemplate.Parse("callback", bodyTemplate)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("body_template: %w", err))
+		}
+		dest.tmpl = tmpl
+	}
+	for eventType, text := range bodyTemplates {
+		tmpl, err := bodytemplate.Parse("callback_"+eventType, text)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("body_templates.%s: %w", eventType, err))
+			continue
+		}
+		if dest.eventTmpls == nil {
+			dest.eventTmpls = make(map[string]*template.Template, len(bodyTemplates))
+		}
+		dest.eventTmpls[eventType] = tmpl
+	}
+	return dest, errors.Join(errs...)
+}
+
+// destinations returns cfg's webhook destinations, payment_success_url first. Templates that
+// don't parse are skipped; config validation reports them.
+func destinations(cfg config.CallbacksConfig) []destination {
+	var dests []destination
+	if cfg.PaymentSuccessURL != "" {
+		dest, _ := newDestination(cfg.PaymentSuccessURL, cfg.Headers, cfg.Body, cfg.BodyTemplate, cfg.BodyTemplates, nil)
+		dest.priority = cfg.Priority
+		dest.limit = newDestinationLimit(cfg.MaxInFlight, cfg.RequestsPerSecond)
+		dests = append(dests, dest)
+	}
+	for _, d := range cfg.Destinations {
+		dest, _ := newDestination(d.URL, d.Headers, d.Body, d.BodyTemplate, d.BodyTemplates, d.Events)
+		dest.priority = d.Priority
+		dest.limit = newDestinationLimit(destinationLimits(cfg, d))
+		if d.Retry != nil {
+			retry := NewRetryConfig(destinationRetry(cfg, d), cfg.Timeout.Duration)
+			dest.retry = &retry
+		}
+		dests = append(dests, dest)
+	}
+	return dests
+}
+
+// destinationLimits returns d's max_in_flight and requests_per_second, falling back to the
+// callbacks-wide settings for those it leaves unset.
+func destinationLimits(cfg config.CallbacksConfig, d config.CallbackDestinationConfig) (int, float64) {
+	maxInFlight, perSecond := cfg.MaxInFlight, cfg.RequestsPerSecond
+	if d.MaxInFlight > 0 {
+		maxInFlight = d.MaxInFlight
+	}
+	if d.RequestsPerSecond > 0 {
+		perSecond = d.RequestsPerSecond
+	}
+	return maxInFlight, perSecond
+}
+
+// destinationRetry returns d's retry settings: callbacks.retry, with the fields d.retry sets
+// replacing its own.
+func destinationRetry(cfg config.CallbacksConfig, d config.CallbackDestinationConfig) config.RetryConfig {
+	retry := cfg.Retry
+	if d.Retry == nil {
+		return retry
+	}
+	if d.Retry.MaxAttempts > 0 {
+		retry.MaxAttempts = d.Retry.MaxAttempts
+	}
+	if d.Retry.InitialInterval.Duration > 0 {
+		retry.InitialInterval = d.Retry.InitialInterval
+	}
+	if d.Retry.MaxInterval.Duration > 0 {
+		retry.MaxInterval = d.Retry.MaxInterval
+	}
+	if d.Retry.Multiplier > 0 {
+		retry.Multiplier = d.Retry.Multiplier
+	}
+	if d.Retry.Jitter > 0 {
+		retry.Jitter = d.Retry.Jitter
+	}
+	if len(d.Retry.Schedule) > 0 {
+		retry.Schedule = d.Retry.Schedule
+	}
+	return retry
+}
+
+// HasAWSTarget reports whether any of cfg's webhook destinations is an SQS or SNS ARN, which
+// needs an AWSTransport.
+func HasAWSTarget(cfg config.CallbacksConfig) bool {
+	return slices.ContainsFunc(destinations(cfg), func(d destination) bool {
+		return IsAWSTarget(d.url)
+	})
+}
+
+// accepts reports whether the destination receives eventType ("payment", "refund", ...).
+func (d destination) accepts(eventType string) bool {
+	return len(d.events) == 0 || slices.Contains(d.events, eventType)
+}
+
+// queuePriority is the webhook queue priority of the destination's eventType webhooks: its
+// configured priority, or high for payment success events and normal for the rest.
+func (d destination) queuePriority(eventType string) int {
+	switch d.priority {
+	case "high":
+		return storage.WebhookPriorityHigh
+	case "low":
+		return storage.WebhookPriorityLow
+	case "normal":
+		return storage.WebhookPriorityNormal
+	}
+	if eventType == "payment" {
+		return storage.WebhookPriorityHigh
+	}
+	return storage.WebhookPriorityNormal
+}
+
+// render builds the destination's payload for an event of eventType: the event type's
+// template, its static body, its template, or the event JSON.
+func (d destination) render(eventType string, event any) ([]byte, error) {
+	tmpl := d.eventTmpls[eventType]
+	if tmpl == nil {
+		if d.body != "" {
+			return []byte(d.body), nil
+		}
+		tmpl = d.tmpl
+	}
+	if tmpl != nil {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, event); err != nil {
+			return nil, fmt.Errorf("execute template: %w", err)
+		}
+		return buf.Bytes(), nil
+	}
+	return json.Marshal(event)
+}
+
+// NewDestinationNotifier delivers webhooks to callbacks.payment_success_url and to each of
+// callbacks.destinations for the events it lists. Every destination gets its own
+// RetryableClient, so retries, DLQ entries, and failures are tracked per destination, while
+// all of them receive the same EventID for an event.
+func NewDestinationNotifier(cfg config.CallbacksConfig, opts ...RetryOption) Notifier {
+	notifiers := []Notifier{NewRetryableClient(cfg, opts...)}
+	for _, dest := range cfg.Destinations {
+		destCfg := cfg
+		destCfg.PaymentSuccessURL = dest.URL
+		destCfg.Headers = dest.Headers
+		destCfg.Body = dest.Body
+		destCfg.BodyTemplate = dest.BodyTemplate
+		destCfg.BodyTemplates = dest.BodyTemplates
+		destCfg.TLS = dest.TLS
+		destCfg.MaxInFlight, destCfg.RequestsPerSecond = destinationLimits(cfg, dest)
+		destCfg.Destinations = nil
+		destOpts := opts
+		if dest.Retry != nil {
+			destCfg.Retry = destinationRetry(cfg, dest)
+			destOpts = append(slices.Clone(opts), WithRetryConfig(NewRetryConfig(destCfg.Retry, cfg.Timeout.Duration)))
+		}
+		notifiers = append(notifiers, filterEvents(NewRetryableClient(destCfg, destOpts...), dest.Events))
+	}
+	return NewMultiNotifier(notifiers...)
+}
+
+// eventFilter passes on only the event types a destination subscribed to.
+type eventFilter struct {
+	next   Notifier
+	events []string
+}
+
+// filterEvents limits next to events; empty events pass everything.
+func filterEvents(next Notifier, events []string) Notifier {
+	if _, isNoop := next.(NoopNotifier); isNoop || len(events) == 0 {
+		return next
+	}
+	return &eventFilter{next: next, events: events}
+}
+
+func (f *eventFilter) PaymentSucceeded(ctx context.Context, event PaymentEvent) {
+	if slices.Contains(f.events, "payment") {
+		f.next.PaymentSucceeded(ctx, event)
+	}
+}
+
+func (f *eventFilter) RefundSucceeded(ctx context.Context, event RefundEvent) {
+	if slices.Contains(f.events, "refund") {
+		f.next.RefundSucceeded(ctx, event)
+	}
+}
+
+func (f *eventFilter) SubscriptionChanged(ctx context.Context, event SubscriptionEvent) {
+	if slices.Contains(f.events, "subscription") {
+		f.next.SubscriptionChanged(ctx, event)
+	}
+}
+
+func (f *eventFilter) RefundRequestChanged(ctx context.Context, event RefundRequestEvent) {
+	if slices.Contains(f.events, "refund_request") {
+		f.next.RefundRequestChanged(ctx, event)
+	}
+}
+
+func (f *eventFilter) PaymentFailed(ctx context.Context, event PaymentFailedEvent) {
+	if slices.Contains(f.events, "payment_failed") {
+		f.next.PaymentFailed(ctx, event)
+	}
+}
+
+// Drain waits for the filtered notifier's in-flight deliveries.
+func (f *eventFilter) Drain(ctx context.Context) error {
+	return Drain(ctx, f.next)
+}
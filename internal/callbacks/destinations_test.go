@@ -0,0 +1,227 @@
+package callbacks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func TestDestinationNotifier_RoutesAndRetriesPerDestination(t *testing.T) {
+	// The catch-all destination is down; the refunds destination must still get its one delivery
+	var downCalls atomic.Int32
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downCalls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+
+	var (
+		mu      sync.Mutex
+		bodies  []string
+		headers []string
+	)
+	refunds := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		headers = append(headers, r.Header.Get("X-Team"))
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer refunds.Close()
+
+	cfg := config.CallbacksConfig{
+		PaymentSuccessURL: down.URL,
+		Headers:           map[string]string{"X-Team": "payments"},
+		Timeout:           config.Duration{Duration: time.Second},
+		Retry:             config.RetryConfig{Enabled: true},
+		Destinations: []config.CallbackDestinationConfig{{
+			URL:          refunds.URL,
+			Events:       []string{"refund"},
+			Headers:      map[string]string{"X-Team": "finance"},
+			BodyTemplate: `{"refund":"{{.RefundID}}"}`,
+		}},
+	}
+	dlq := NewMemoryDLQStore()
+	n := NewDestinationNotifier(cfg,
+		WithRetryLogger(zerolog.Nop()),
+		WithDLQStore(dlq),
+		WithRetryConfig(RetryConfig{MaxAttempts: 3, InitialInterval: time.Millisecond, MaxInterval: time.Millisecond, Multiplier: 1, Timeout: time.Second}),
+	)
+
+	ctx := context.Background()
+	n.PaymentSucceeded(ctx, PaymentEvent{ResourceID: "ebook"})
+	n.RefundSucceeded(ctx, RefundEvent{RefundID: "refund_1"})
+	if err := Drain(ctx, n); err != nil {
+		t.Fatalf("Drain: %v", err)
+	}
+
+	if got := downCalls.Load(); got != 6 {
+		t.Errorf("catch-all destination calls = %d, want 3 attempts for each of 2 events", got)
+	}
+	if len(bodies) != 1 || bodies[0] != `{"refund":"refund_1"}` || headers[0] != "finance" {
+		t.Errorf("refunds destination got bodies %q with X-Team %q, want only the templated refund with its own headers", bodies, headers)
+	}
+	failed, _ := dlq.ListFailedWebhooks(ctx, 10)
+	if len(failed) != 2 || failed[0].URL != down.URL || failed[1].URL != down.URL {
+		t.Errorf("DLQ = %+v, want both events for the catch-all destination only", failed)
+	}
+}
+
+func TestWebhookQueueWorker_EnqueuesPerDestination(t *testing.T) {
+	cfg := config.CallbacksConfig{
+		PaymentSuccessURL: "https://example.com/all",
+		Headers:           map[string]string{"X-Team": "payments"},
+		Destinations: []config.CallbackDestinationConfig{
+			{URL: "https://example.com/refunds", Events: []string{"refund"}, Headers: map[string]string{"X-Team": "finance"}, Body: `{"ping":true}`},
+			{URL: "https://example.com/subscriptions", Events: []string{"subscription"}},
+			{URL: "https://example.com/analytics", Priority: "low"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		enqueue  func(w *WebhookQueueWorker) error
+		wantURLs []string
+	}{
+		{
+			name: "payment",
+			enqueue: func(w *WebhookQueueWorker) error {
+				return w.EnqueuePaymentWebhook(context.Background(), PaymentEvent{ResourceID: "ebook"})
+			},
+			wantURLs: []string{"https://example.com/all", "https://example.com/analytics"},
+		},
+		{
+			name: "refund",
+			enqueue: func(w *WebhookQueueWorker) error {
+				return w.EnqueueRefundWebhook(context.Background(), RefundEvent{RefundID: "refund_1"})
+			},
+			wantURLs: []string{"https://example.com/all", "https://example.com/analytics", "https://example.com/refunds"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewMemoryStore()
+			t.Cleanup(func() { _ = store.Close() })
+			w := NewWebhookQueueWorker(WebhookQueueWorkerOptions{Store: store, Config: cfg})
+			if err := tt.enqueue(w); err != nil {
+				t.Fatalf("enqueue: %v", err)
+			}
+
+			webhooks, err := store.DequeueWebhooks(context.Background(), 10)
+			if err != nil {
+				t.Fatalf("DequeueWebhooks: %v", err)
+			}
+			if tt.name == "payment" && webhooks[0].URL != "https://example.com/all" {
+				t.Errorf("first dequeued = %s, want the high-priority payment webhook", webhooks[0].URL)
+			}
+			sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].URL < webhooks[j].URL })
+			if len(webhooks) != len(tt.wantURLs) {
+				t.Fatalf("queued %d webhooks, want %d", len(webhooks), len(tt.wantURLs))
+			}
+			for i, webhook := range webhooks {
+				if webhook.URL != tt.wantURLs[i] || webhook.EventType != tt.name {
+					t.Errorf("webhook %d = %s %s, want %s %s", i, webhook.EventType, webhook.URL, tt.name, tt.wantURLs[i])
+				}
+				wantPriority := storage.WebhookPriorityNormal
+				switch {
+				case webhook.URL == "https://example.com/analytics":
+					wantPriority = storage.WebhookPriorityLow
+				case tt.name == "payment":
+					wantPriority = storage.WebhookPriorityHigh
+				}
+				if webhook.Priority != wantPriority {
+					t.Errorf("webhook %d priority = %d, want %d", i, webhook.Priority, wantPriority)
+				}
+				if webhook.URL == "https://example.com/refunds" && (webhook.Headers["X-Team"] != "finance" || string(webhook.Payload) != `{"ping":true}`) {
+					t.Errorf("refunds webhook = %v %s, want its own headers and body", webhook.Headers, webhook.Payload)
+				}
+			}
+		})
+	}
+}
+
+func TestDestinationRender_PerEventTemplates(t *testing.T) {
+	dest, err := newDestination("https://hooks.slack.com/services/x", nil, "", `{"text":{{json .EventType}}}`, map[string]string{
+		"refund":         `{"text":{{json (printf "Refunded %s on %s" .RefundID (formatTime "Jan 2" .RefundedAt))}}}`,
+		"payment_failed": `{{.Missing`,
+	}, nil)
+	if err == nil || !strings.Contains(err.Error(), "body_templates.payment_failed") {
+		t.Fatalf("newDestination error = %v, want the payment_failed template reported", err)
+	}
+
+	tests := []struct {
+		name      string
+		eventType string
+		event     any
+		want      string
+	}{
+		{name: "event template", eventType: "refund", event: RefundEvent{RefundID: "refund_1", RefundedAt: time.Date(2025, 11, 7, 0, 0, 0, 0, time.UTC)}, want: `{"text":"Refunded refund_1 on Nov 7"}`},
+		{name: "default template", eventType: "payment", event: PaymentEvent{EventType: "payment.succeeded"}, want: `{"text":"payment.succeeded"}`},
+		{name: "unparsable event template", eventType: "payment_failed", event: PaymentFailedEvent{EventType: "payment.failed"}, want: `{"text":"payment.failed"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := dest.render(tt.eventType, tt.event)
+			if err != nil {
+				t.Fatalf("render: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("render = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhookQueueWorker_RecordsRetrySchedule(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	cfg := config.CallbacksConfig{
+		Retry: config.RetryConfig{Enabled: true, MaxAttempts: 3, InitialInterval: config.Duration{Duration: time.Second}, MaxInterval: config.Duration{Duration: time.Minute}, Multiplier: 2},
+		Destinations: []config.CallbackDestinationConfig{
+			{URL: server.URL, Retry: &config.RetryConfig{Schedule: []config.Duration{{Duration: 10 * time.Minute}, {Duration: time.Hour}}}},
+		},
+	}
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	w := NewWebhookQueueWorker(WebhookQueueWorkerOptions{Store: store, Config: cfg, RetryConfig: NewRetryConfig(cfg.Retry, 0)})
+	if err := w.EnqueuePaymentWebhook(context.Background(), PaymentEvent{ResourceID: "ebook"}); err != nil {
+		t.Fatalf("EnqueuePaymentWebhook: %v", err)
+	}
+
+	queued, err := store.ListWebhooks(context.Background(), "", 10)
+	if err != nil || len(queued) != 1 {
+		t.Fatalf("ListWebhooks = %d webhooks, %v; want 1", len(queued), err)
+	}
+	webhook := queued[0]
+	want := []int64{(10 * time.Minute).Milliseconds(), time.Hour.Milliseconds()}
+	if webhook.MaxAttempts != 3 || !slices.Equal(webhook.RetrySchedule, want) {
+		t.Fatalf("queued webhook = %d attempts, schedule %v; want 3 attempts, %v", webhook.MaxAttempts, webhook.RetrySchedule, want)
+	}
+
+	before := time.Now()
+	w.processQueue(context.Background())
+	retried, err := store.GetWebhook(context.Background(), webhook.ID)
+	if err != nil {
+		t.Fatalf("GetWebhook: %v", err)
+	}
+	if next := retried.NextAttemptAt.Sub(before); next < 10*time.Minute || next > 11*time.Minute {
+		t.Errorf("next attempt in %v, want the scheduled 10m", next)
+	}
+}
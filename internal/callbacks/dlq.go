@@ -5,7 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
 	"sync"
+	"time"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/rs/zerolog"
 )
 
 // NoopDLQStore is a DLQ store that discards all failed webhooks.
@@ -58,18 +63,52 @@ func (m *MemoryDLQStore) DeleteFailedWebhook(ctx context.Context, id string) err
 	return nil
 }
 
-// FileDLQStore stores failed webhooks in a JSON file.
+// FileDLQStore stores failed webhooks in a JSON file. With rotation limits, entries past them
+// are moved, oldest first, into segments in a DLQArchive.
 type FileDLQStore struct {
 	mu       sync.RWMutex
 	filePath string
 	webhooks map[string]FailedWebhook
+	rotation config.DLQRotationConfig
+	archive  DLQArchive // Receives rotated entries; nil disables rotation
+	logger   zerolog.Logger
+}
+
+// FileDLQOption customizes a FileDLQStore.
+type FileDLQOption func(*FileDLQStore)
+
+// WithDLQRotation rotates entries out of the file once it would exceed cfg.MaxBytes or when
+// they are older than cfg.MaxAge. Rotation needs an archive (WithDLQArchive).
+func WithDLQRotation(cfg config.DLQRotationConfig) FileDLQOption {
+	return func(f *FileDLQStore) {
+		f.rotation = cfg
+	}
+}
+
+// WithDLQArchive sets where rotated entries are stored.
+func WithDLQArchive(archive DLQArchive) FileDLQOption {
+	return func(f *FileDLQStore) {
+		f.archive = archive
+	}
+}
+
+// WithDLQLogger sets a logger for rotation failures.
+func WithDLQLogger(logger zerolog.Logger) FileDLQOption {
+	return func(f *FileDLQStore) {
+		f.logger = logger
+	}
 }
 
-// NewFileDLQStore creates a file-based DLQ store.
-func NewFileDLQStore(filePath string) (*FileDLQStore, error) {
+// NewFileDLQStore creates a file-based DLQ store, rotating out any entries already past the
+// limits.
+func NewFileDLQStore(filePath string, opts ...FileDLQOption) (*FileDLQStore, error) {
 	store := &FileDLQStore{
 		filePath: filePath,
 		webhooks: make(map[string]FailedWebhook),
+		logger:   zerolog.Nop(),
+	}
+	for _, opt := range opts {
+		opt(store)
 	}
 
 	// Load existing data if file exists
@@ -77,17 +116,103 @@ func NewFileDLQStore(filePath string) (*FileDLQStore, error) {
 		return nil, fmt.Errorf("load DLQ file: %w", err)
 	}
 
+	rotated, err := store.rotate(context.Background(), time.Now())
+	if err != nil {
+		store.logger.Warn().Err(err).Msg("callbacks: failed to rotate DLQ")
+	}
+	if rotated > 0 {
+		if err := store.persist(); err != nil {
+			return nil, err
+		}
+	}
+
 	return store, nil
 }
 
+// Archive returns the archive holding rotated entries, or nil without rotation.
+func (f *FileDLQStore) Archive() DLQArchive {
+	return f.archive
+}
+
 func (f *FileDLQStore) SaveFailedWebhook(ctx context.Context, webhook FailedWebhook) error {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
 	f.webhooks[webhook.ID] = webhook
+	// A failed rotation keeps its entries in the file, to be rotated on the next save
+	if _, err := f.rotate(ctx, time.Now()); err != nil {
+		f.logger.Warn().Err(err).Msg("callbacks: failed to rotate DLQ")
+	}
 	return f.persist()
 }
 
+// rotate moves the entries past the rotation limits into a new archive segment and returns how
+// many it moved. Entries are kept when the archive write fails.
+func (f *FileDLQStore) rotate(ctx context.Context, now time.Time) (int, error) {
+	maxBytes, maxAge := f.rotation.MaxBytes, f.rotation.MaxAge.Duration
+	if f.archive == nil || (maxBytes <= 0 && maxAge <= 0) {
+		return 0, nil
+	}
+
+	entries := make([]FailedWebhook, 0, len(f.webhooks))
+	for _, webhook := range f.webhooks {
+		entries = append(entries, webhook)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].LastAttempt.Equal(entries[j].LastAttempt) {
+			return entries[i].LastAttempt.Before(entries[j].LastAttempt)
+		}
+		return entries[i].ID < entries[j].ID
+	})
+
+	rotated := 0
+	if maxAge > 0 {
+		cutoff := now.Add(-maxAge)
+		for rotated < len(entries) && entries[rotated].LastAttempt.Before(cutoff) {
+			rotated++
+		}
+	}
+	if maxBytes > 0 {
+		// Estimated file size: the indented entries plus the map keys and separators
+		size := 2
+		sizes := make([]int, len(entries))
+		for i, entry := range entries {
+			data, err := json.MarshalIndent(entry, "  ", "  ")
+			if err != nil {
+				return 0, fmt.Errorf("marshal DLQ entry: %w", err)
+			}
+			sizes[i] = len(data) + len(entry.ID) + 8
+			if i >= rotated {
+				size += sizes[i]
+			}
+		}
+		for rotated < len(entries) && size > maxBytes {
+			size -= sizes[rotated]
+			rotated++
+		}
+	}
+	if rotated == 0 {
+		return 0, nil
+	}
+
+	data, err := json.MarshalIndent(entries[:rotated], "", "  ")
+	if err != nil {
+		return 0, fmt.Errorf("marshal DLQ segment: %w", err)
+	}
+	segment := dlqSegmentName(now)
+	if err := f.archive.Put(ctx, segment, data); err != nil {
+		return 0, fmt.Errorf("rotate DLQ to %s: %w", segment, err)
+	}
+	for _, entry := range entries[:rotated] {
+		delete(f.webhooks, entry.ID)
+	}
+	f.logger.Info().
+		Str("segment", segment).
+		Int("entries", rotated).
+		Msg("callbacks: rotated DLQ entries")
+	return rotated, nil
+}
+
 func (f *FileDLQStore) ListFailedWebhooks(ctx context.Context, limit int) ([]FailedWebhook, error) {
 	f.mu.RLock()
 	defer f.mu.RUnlock()
@@ -0,0 +1,171 @@
+package callbacks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/CedrosPay/server/internal/config"
+)
+
+// ErrDLQSegmentNotFound is returned for a DLQ segment the archive doesn't hold.
+var ErrDLQSegmentNotFound = errors.New("callbacks: dlq segment not found")
+
+// DLQArchive holds DLQ entries rotated out of the DLQ file, one JSON segment per rotation.
+type DLQArchive interface {
+	Put(ctx context.Context, segment string, data []byte) error
+	Get(ctx context.Context, segment string) ([]byte, error)
+	// List returns the archive's segment names, oldest first
+	List(ctx context.Context) ([]string, error)
+	Delete(ctx context.Context, segment string) error
+}
+
+// dlqSegmentPattern matches segment names; anything else is rejected before it reaches a
+// path or object key.
+var dlqSegmentPattern = regexp.MustCompile(`^dlq-\d{8}T\d{6}\.\d{9}Z\.json$`)
+
+// dlqSegmentName names the segment rotated at t. Names sort in rotation order.
+func dlqSegmentName(t time.Time) string {
+	return "dlq-" + t.UTC().Format("20060102T150405.000000000Z") + ".json"
+}
+
+// checkDLQSegment rejects names that aren't DLQ segments.
+func checkDLQSegment(segment string) error {
+	if !dlqSegmentPattern.MatchString(segment) {
+		return fmt.Errorf("%w: %q is not a segment name", ErrDLQSegmentNotFound, segment)
+	}
+	return nil
+}
+
+// NewDLQArchive returns the archive for rotated DLQ entries: cfg.DLQOffload's S3 bucket or GCS
+// bucket, a directory beside cfg.DLQPath when only rotation limits are set, or nil when the
+// DLQ is never rotated.
+func NewDLQArchive(ctx context.Context, cfg config.CallbacksConfig) (DLQArchive, error) {
+	var archive DLQArchive
+	var err error
+	if target := cfg.DLQOffload.URL; target != "" {
+		scheme, rest, _ := strings.Cut(target, "://")
+		bucket, prefix, _ := strings.Cut(rest, "/")
+		switch scheme {
+		case "s3":
+			archive, err = newS3DLQArchive(ctx, bucket, prefix, cfg.DLQOffload.Endpoint, cfg.AWS)
+		case "gs":
+			archive, err = newGCSDLQArchive(ctx, bucket, prefix, cfg.DLQOffload)
+		default:
+			err = fmt.Errorf("callbacks: unsupported dlq offload target %q", target)
+		}
+	} else if cfg.DLQRotation.MaxBytes > 0 || cfg.DLQRotation.MaxAge.Duration > 0 {
+		archive, err = NewLocalDLQArchive(cfg.DLQPath+".rotated", cfg.DLQRotation.MaxFiles)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return archive, nil
+}
+
+// objectKey joins an archive prefix and a segment name.
+func objectKey(prefix, segment string) string {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return segment
+	}
+	return prefix + "/" + segment
+}
+
+// LocalDLQArchive keeps rotated DLQ segments as files in a directory, deleting the oldest
+// beyond maxFiles.
+type LocalDLQArchive struct {
+	dir      string
+	maxFiles int
+}
+
+// NewLocalDLQArchive creates dir if needed. maxFiles <= 0 keeps every segment.
+func NewLocalDLQArchive(dir string, maxFiles int) (*LocalDLQArchive, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("callbacks: create dlq archive dir: %w", err)
+	}
+	return &LocalDLQArchive{dir: dir, maxFiles: maxFiles}, nil
+}
+
+// Put writes a segment, then prunes the oldest segments beyond the file limit.
+func (a *LocalDLQArchive) Put(ctx context.Context, segment string, data []byte) error {
+	if err := checkDLQSegment(segment); err != nil {
+		return err
+	}
+	path := filepath.Join(a.dir, segment)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return fmt.Errorf("write dlq segment: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("rename dlq segment: %w", err)
+	}
+
+	if a.maxFiles <= 0 {
+		return nil
+	}
+	segments, err := a.List(ctx)
+	if err != nil {
+		return err
+	}
+	for len(segments) > a.maxFiles {
+		if err := os.Remove(filepath.Join(a.dir, segments[0])); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("prune dlq segment: %w", err)
+		}
+		segments = segments[1:]
+	}
+	return nil
+}
+
+// Get reads a segment.
+func (a *LocalDLQArchive) Get(_ context.Context, segment string) ([]byte, error) {
+	if err := checkDLQSegment(segment); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(a.dir, segment))
+	if os.IsNotExist(err) {
+		return nil, ErrDLQSegmentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read dlq segment: %w", err)
+	}
+	return data, nil
+}
+
+// List returns the segment names in the directory, oldest first.
+func (a *LocalDLQArchive) List(_ context.Context) ([]string, error) {
+	files, err := os.ReadDir(a.dir)
+	if err != nil {
+		return nil, fmt.Errorf("list dlq segments: %w", err)
+	}
+	var segments []string
+	for _, file := range files {
+		if !file.IsDir() && dlqSegmentPattern.MatchString(file.Name()) {
+			segments = append(segments, file.Name())
+		}
+	}
+	sort.Strings(segments)
+	return segments, nil
+}
+
+// Delete removes a segment.
+func (a *LocalDLQArchive) Delete(_ context.Context, segment string) error {
+	if err := checkDLQSegment(segment); err != nil {
+		return err
+	}
+	err := os.Remove(filepath.Join(a.dir, segment))
+	if os.IsNotExist(err) {
+		return ErrDLQSegmentNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("delete dlq segment: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package callbacks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/httputil"
+)
+
+const (
+	gcsScope           = "https://www.googleapis.com/auth/devstorage.read_write"
+	defaultGCSEndpoint = "https://storage.googleapis.com"
+)
+
+// gcsDLQArchive keeps rotated DLQ segments in a Google Cloud Storage bucket through the JSON API.
+type gcsDLQArchive struct {
+	bucket     string
+	prefix     string
+	endpoint   string
+	httpClient *http.Client
+}
+
+// newGCSDLQArchive resolves credentials from cfg.CredentialsFile, or Application Default
+// Credentials when empty. With cfg.Endpoint set (e.g., a GCS emulator) requests are sent
+// unauthenticated, as for the Pub/Sub emulator.
+func newGCSDLQArchive(ctx context.Context, bucket, prefix string, cfg config.DLQOffloadConfig) (*gcsDLQArchive, error) {
+	archive := &gcsDLQArchive{
+		bucket:   bucket,
+		prefix:   prefix,
+		endpoint: defaultGCSEndpoint,
+	}
+	baseClient := httputil.NewClient(30 * time.Second)
+	if cfg.Endpoint != "" {
+		archive.endpoint = strings.TrimSuffix(cfg.Endpoint, "/")
+		archive.httpClient = baseClient
+		return archive, nil
+	}
+
+	var creds *google.Credentials
+	var err error
+	if cfg.CredentialsFile != "" {
+		data, readErr := os.ReadFile(cfg.CredentialsFile)
+		if readErr != nil {
+			return nil, fmt.Errorf("callbacks: read gcs credentials: %w", readErr)
+		}
+		creds, err = google.CredentialsFromJSON(ctx, data, gcsScope)
+	} else {
+		creds, err = google.FindDefaultCredentials(ctx, gcsScope)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("callbacks: load gcs credentials: %w", err)
+	}
+
+	tokenCtx := context.WithValue(context.Background(), oauth2.HTTPClient, baseClient)
+	archive.httpClient = oauth2.NewClient(tokenCtx, creds.TokenSource)
+	return archive, nil
+}
+
+// objectURL is the JSON API URL of a segment's object.
+func (a *gcsDLQArchive) objectURL(segment string) string {
+	return fmt.Sprintf("%s/storage/v1/b/%s/o/%s", a.endpoint, url.PathEscape(a.bucket), url.PathEscape(objectKey(a.prefix, segment)))
+}
+
+// do sends a request and returns the response body.
+func (a *gcsDLQArchive) do(ctx context.Context, method, target string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("build gcs request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("gcs %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read gcs response: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrDLQSegmentNotFound
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("gcs %s returned %d: %s", method, resp.StatusCode, data[:min(len(data), 200)])
+	}
+	return data, nil
+}
+
+// Put uploads a segment.
+func (a *gcsDLQArchive) Put(ctx context.Context, segment string, data []byte) error {
+	if err := checkDLQSegment(segment); err != nil {
+		return err
+	}
+	query := url.Values{"uploadType": {"media"}, "name": {objectKey(a.prefix, segment)}}
+	target := fmt.Sprintf("%s/upload/storage/v1/b/%s/o?%s", a.endpoint, url.PathEscape(a.bucket), query.Encode())
+	_, err := a.do(ctx, http.MethodPost, target, data)
+	return err
+}
+
+// Get downloads a segment.
+func (a *gcsDLQArchive) Get(ctx context.Context, segment string) ([]byte, error) {
+	if err := checkDLQSegment(segment); err != nil {
+		return nil, err
+	}
+	return a.do(ctx, http.MethodGet, a.objectURL(segment)+"?alt=media", nil)
+}
+
+// gcsListResult is the part of an objects.list response the archive reads.
+type gcsListResult struct {
+	Items []struct {
+		Name string `json:"name"`
+	} `json:"items"`
+	NextPageToken string `json:"nextPageToken"`
+}
+
+// List returns the segments under the prefix, oldest first.
+func (a *gcsDLQArchive) List(ctx context.Context) ([]string, error) {
+	keyPrefix := objectKey(a.prefix, "")
+	var segments []string
+	token := ""
+	for {
+		query := url.Values{"prefix": {keyPrefix}}
+		if token != "" {
+			query.Set("pageToken", token)
+		}
+		target := fmt.Sprintf("%s/storage/v1/b/%s/o?%s", a.endpoint, url.PathEscape(a.bucket), query.Encode())
+		data, err := a.do(ctx, http.MethodGet, target, nil)
+		if err != nil {
+			return nil, fmt.Errorf("list dlq segments: %w", err)
+		}
+		var result gcsListResult
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, fmt.Errorf("decode gcs listing: %w", err)
+		}
+		for _, object := range result.Items {
+			if name := strings.TrimPrefix(object.Name, keyPrefix); dlqSegmentPattern.MatchString(name) {
+				segments = append(segments, name)
+			}
+		}
+		if result.NextPageToken == "" {
+			break
+		}
+		token = result.NextPageToken
+	}
+	sort.Strings(segments)
+	return segments, nil
+}
+
+// Delete removes a segment.
+func (a *gcsDLQArchive) Delete(ctx context.Context, segment string) error {
+	if err := checkDLQSegment(segment); err != nil {
+		return err
+	}
+	_, err := a.do(ctx, http.MethodDelete, a.objectURL(segment), nil)
+	return err
+}
@@ -0,0 +1,179 @@
+package callbacks
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/httputil"
+)
+
+// s3DLQArchive keeps rotated DLQ segments in an S3 bucket (or an S3-compatible store such as
+// MinIO), signing REST requests with SigV4.
+type s3DLQArchive struct {
+	bucket     string
+	prefix     string
+	endpoint   string // Path-style endpoint override; empty for virtual-hosted AWS endpoints
+	region     string
+	creds      aws.CredentialsProvider
+	signer     *v4.Signer
+	httpClient *http.Client
+}
+
+// newS3DLQArchive resolves credentials from the callbacks.aws settings.
+func newS3DLQArchive(ctx context.Context, bucket, prefix, endpoint string, awsCfg config.AWSDeliveryConfig) (*s3DLQArchive, error) {
+	base, err := loadAWSConfig(ctx, awsCfg)
+	if err != nil {
+		return nil, err
+	}
+	region := base.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	return &s3DLQArchive{
+		bucket:     bucket,
+		prefix:     prefix,
+		endpoint:   strings.TrimSuffix(endpoint, "/"),
+		region:     region,
+		creds:      base.Credentials,
+		signer:     v4.NewSigner(),
+		httpClient: httputil.NewClient(30 * time.Second),
+	}, nil
+}
+
+// objectURL is the URL of key, or of the bucket when key is empty.
+func (a *s3DLQArchive) objectURL(key string) string {
+	var escaped []string
+	for _, part := range strings.Split(key, "/") {
+		escaped = append(escaped, url.PathEscape(part))
+	}
+	path := strings.Join(escaped, "/")
+	if a.endpoint != "" {
+		return a.endpoint + "/" + a.bucket + "/" + path
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", a.bucket, a.region, path)
+}
+
+// do sends a signed request and returns the response body.
+func (a *s3DLQArchive) do(ctx context.Context, method, target string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("build s3 request: %w", err)
+	}
+	sum := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(sum[:])
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	creds, err := a.creds.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("retrieve aws credentials: %w", err)
+	}
+	if err := a.signer.SignHTTP(ctx, creds, req, payloadHash, "s3", a.region, time.Now()); err != nil {
+		return nil, fmt.Errorf("sign s3 request: %w", err)
+	}
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3 %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read s3 response: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrDLQSegmentNotFound
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("s3 %s returned %d: %s", method, resp.StatusCode, data[:min(len(data), 200)])
+	}
+	return data, nil
+}
+
+// Put uploads a segment.
+func (a *s3DLQArchive) Put(ctx context.Context, segment string, data []byte) error {
+	if err := checkDLQSegment(segment); err != nil {
+		return err
+	}
+	_, err := a.do(ctx, http.MethodPut, a.objectURL(objectKey(a.prefix, segment)), data)
+	return err
+}
+
+// Get downloads a segment.
+func (a *s3DLQArchive) Get(ctx context.Context, segment string) ([]byte, error) {
+	if err := checkDLQSegment(segment); err != nil {
+		return nil, err
+	}
+	return a.do(ctx, http.MethodGet, a.objectURL(objectKey(a.prefix, segment)), nil)
+}
+
+// s3ListResult is the part of a ListObjectsV2 response the archive reads.
+type s3ListResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List returns the segments under the prefix, oldest first.
+func (a *s3DLQArchive) List(ctx context.Context) ([]string, error) {
+	keyPrefix := objectKey(a.prefix, "")
+	var segments []string
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {keyPrefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		data, err := a.do(ctx, http.MethodGet, a.objectURL("")+"?"+query.Encode(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("list dlq segments: %w", err)
+		}
+		var result s3ListResult
+		if err := xml.Unmarshal(data, &result); err != nil {
+			return nil, fmt.Errorf("decode s3 listing: %w", err)
+		}
+		for _, object := range result.Contents {
+			if name := strings.TrimPrefix(object.Key, keyPrefix); dlqSegmentPattern.MatchString(name) {
+				segments = append(segments, name)
+			}
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			break
+		}
+		token = result.NextContinuationToken
+	}
+	sort.Strings(segments)
+	return segments, nil
+}
+
+// Delete removes a segment. S3 reports success for missing keys, so the segment is looked up
+// first.
+func (a *s3DLQArchive) Delete(ctx context.Context, segment string) error {
+	if err := checkDLQSegment(segment); err != nil {
+		return err
+	}
+	target := a.objectURL(objectKey(a.prefix, segment))
+	if _, err := a.do(ctx, http.MethodHead, target, nil); err != nil {
+		return err
+	}
+	_, err := a.do(ctx, http.MethodDelete, target, nil)
+	return err
+}
@@ -0,0 +1,337 @@
+package callbacks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func TestFileDLQStore_Rotation(t *testing.T) {
+	now := time.Now().UTC()
+	entry := func(id string, age time.Duration) FailedWebhook {
+		return FailedWebhook{
+			ID:          id,
+			URL:         "https://example.com/hook",
+			Payload:     json.RawMessage(`{"eventId":"evt_` + id + `"}`),
+			EventType:   "payment",
+			Attempts:    5,
+			LastError:   "received status 503",
+			LastAttempt: now.Add(-age),
+			CreatedAt:   now.Add(-age),
+		}
+	}
+
+	tests := []struct {
+		name         string
+		rotation     config.DLQRotationConfig
+		wantKept     []string
+		wantSegments int
+	}{
+		{name: "no limits", wantKept: []string{"a", "b", "c"}},
+		{name: "max age", rotation: config.DLQRotationConfig{MaxAge: config.Duration{Duration: 90 * time.Minute}}, wantKept: []string{"c"}, wantSegments: 2},
+		{name: "max bytes", rotation: config.DLQRotationConfig{MaxBytes: 700}, wantKept: []string{"b", "c"}, wantSegments: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			archive, err := NewLocalDLQArchive(filepath.Join(dir, "rotated"), 10)
+			if err != nil {
+				t.Fatalf("NewLocalDLQArchive: %v", err)
+			}
+			store, err := NewFileDLQStore(filepath.Join(dir, "dlq.json"), WithDLQRotation(tt.rotation), WithDLQArchive(archive))
+			if err != nil {
+				t.Fatalf("NewFileDLQStore: %v", err)
+			}
+			ctx := context.Background()
+			for _, e := range []FailedWebhook{entry("a", 3*time.Hour), entry("b", 2*time.Hour), entry("c", 0)} {
+				if err := store.SaveFailedWebhook(ctx, e); err != nil {
+					t.Fatalf("SaveFailedWebhook: %v", err)
+				}
+			}
+
+			kept, _ := store.ListFailedWebhooks(ctx, 0)
+			var ids []string
+			for _, w := range kept {
+				ids = append(ids, w.ID)
+			}
+			sort.Strings(ids)
+			if strings.Join(ids, ",") != strings.Join(tt.wantKept, ",") {
+				t.Errorf("kept = %v, want %v", ids, tt.wantKept)
+			}
+
+			segments, err := archive.List(ctx)
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+			if len(segments) != tt.wantSegments {
+				t.Fatalf("segments = %v, want %d", segments, tt.wantSegments)
+			}
+			rotated := 0
+			for _, segment := range segments {
+				data, err := archive.Get(ctx, segment)
+				if err != nil {
+					t.Fatalf("Get(%s): %v", segment, err)
+				}
+				var entries []FailedWebhook
+				if err := json.Unmarshal(data, &entries); err != nil {
+					t.Fatalf("decode %s: %v", segment, err)
+				}
+				rotated += len(entries)
+			}
+			if rotated+len(kept) != 3 {
+				t.Errorf("rotated %d + kept %d entries, want 3", rotated, len(kept))
+			}
+		})
+	}
+}
+
+func TestLocalDLQArchive(t *testing.T) {
+	ctx := context.Background()
+	archive, err := NewLocalDLQArchive(t.TempDir(), 2)
+	if err != nil {
+		t.Fatalf("NewLocalDLQArchive: %v", err)
+	}
+	start := time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)
+	for i := range 3 {
+		if err := archive.Put(ctx, dlqSegmentName(start.Add(time.Duration(i)*time.Hour)), []byte("[]")); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+	}
+	segments, _ := archive.List(ctx)
+	want := []string{dlqSegmentName(start.Add(time.Hour)), dlqSegmentName(start.Add(2 * time.Hour))}
+	if strings.Join(segments, ",") != strings.Join(want, ",") {
+		t.Errorf("segments = %v, want the newest two %v", segments, want)
+	}
+
+	for _, name := range []string{"../dlq.json", "dlq-20260115T100000.000000000Z.json"} {
+		if _, err := archive.Get(ctx, name); !errors.Is(err, ErrDLQSegmentNotFound) {
+			t.Errorf("Get(%q) err = %v, want ErrDLQSegmentNotFound", name, err)
+		}
+	}
+}
+
+// objectServer is an in-memory object store answering the S3 or GCS calls the archives make.
+type objectServer struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	auth    []string
+}
+
+func (s *objectServer) s3(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.auth = append(s.auth, r.Header.Get("Authorization"))
+	key := strings.TrimPrefix(r.URL.Path, "/dlq-bucket/")
+	switch {
+	case r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2":
+		fmt.Fprint(w, "<ListBucketResult>")
+		for k := range s.objects {
+			if strings.HasPrefix(k, r.URL.Query().Get("prefix")) {
+				fmt.Fprintf(w, "<Contents><Key>%s</Key></Contents>", k)
+			}
+		}
+		fmt.Fprint(w, "<IsTruncated>false</IsTruncated></ListBucketResult>")
+	case r.Method == http.MethodPut:
+		s.objects[key], _ = io.ReadAll(r.Body)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		data, ok := s.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(data)
+	case r.Method == http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *objectServer) gcs(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/upload/storage/v1/b/dlq-bucket/o":
+		s.objects[r.URL.Query().Get("name")], _ = io.ReadAll(r.Body)
+	case r.Method == http.MethodGet && r.URL.Path == "/storage/v1/b/dlq-bucket/o":
+		var result gcsListResult
+		for k := range s.objects {
+			if strings.HasPrefix(k, r.URL.Query().Get("prefix")) {
+				result.Items = append(result.Items, struct {
+					Name string `json:"name"`
+				}{Name: k})
+			}
+		}
+		_ = json.NewEncoder(w).Encode(result)
+	default:
+		key := strings.TrimPrefix(r.URL.Path, "/storage/v1/b/dlq-bucket/o/")
+		data, ok := s.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodDelete {
+			delete(s.objects, key)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		_, _ = w.Write(data)
+	}
+}
+
+func TestObjectStoreDLQArchives(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "none"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "none"))
+
+	tests := []struct {
+		name   string
+		scheme string
+		serve  func(*objectServer) http.HandlerFunc
+	}{
+		{name: "s3", scheme: "s3", serve: func(s *objectServer) http.HandlerFunc { return s.s3 }},
+		{name: "gcs", scheme: "gs", serve: func(s *objectServer) http.HandlerFunc { return s.gcs }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := &objectServer{objects: map[string][]byte{}}
+			server := httptest.NewServer(tt.serve(objects))
+			defer server.Close()
+
+			ctx := context.Background()
+			archive, err := NewDLQArchive(ctx, config.CallbacksConfig{
+				DLQOffload: config.DLQOffloadConfig{URL: tt.scheme + "://dlq-bucket/prod/dlq", Endpoint: server.URL},
+				AWS:        config.AWSDeliveryConfig{Region: "us-east-1"},
+			})
+			if err != nil {
+				t.Fatalf("NewDLQArchive: %v", err)
+			}
+
+			segment := dlqSegmentName(time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC))
+			if err := archive.Put(ctx, segment, []byte(`[{"id":"webhook_1"}]`)); err != nil {
+				t.Fatalf("Put: %v", err)
+			}
+			if _, ok := objects.objects["prod/dlq/"+segment]; !ok {
+				t.Fatalf("objects = %v, want prod/dlq/%s", objects.objects, segment)
+			}
+			segments, err := archive.List(ctx)
+			if err != nil || len(segments) != 1 || segments[0] != segment {
+				t.Fatalf("List = %v, %v; want [%s]", segments, err, segment)
+			}
+			data, err := archive.Get(ctx, segment)
+			if err != nil || string(data) != `[{"id":"webhook_1"}]` {
+				t.Fatalf("Get = %s, %v", data, err)
+			}
+			if err := archive.Delete(ctx, segment); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			if _, err := archive.Get(ctx, segment); !errors.Is(err, ErrDLQSegmentNotFound) {
+				t.Errorf("Get after delete err = %v, want ErrDLQSegmentNotFound", err)
+			}
+			if tt.scheme == "s3" && !strings.HasPrefix(objects.auth[0], "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") {
+				t.Errorf("Authorization = %q, want a SigV4 signature", objects.auth[0])
+			}
+		})
+	}
+}
+
+func TestDLQRedriver(t *testing.T) {
+	var mu sync.Mutex
+	received := map[string]int{}
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		received[r.URL.Path]++
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer receiver.Close()
+
+	ctx := context.Background()
+	archive, err := NewLocalDLQArchive(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("NewLocalDLQArchive: %v", err)
+	}
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	redriver := NewDLQRedriver(archive, DLQRedriverOptions{DeliveryLog: store})
+
+	segment := func(paths ...string) string {
+		var entries []FailedWebhook
+		for i, path := range paths {
+			entries = append(entries, FailedWebhook{
+				ID:        fmt.Sprintf("webhook_%d", i),
+				URL:       receiver.URL + path,
+				Payload:   json.RawMessage(fmt.Sprintf(`{"eventId":"evt_%d"}`, i)),
+				EventType: "payment",
+				Attempts:  5,
+			})
+		}
+		data, _ := json.Marshal(entries)
+		name := dlqSegmentName(time.Now())
+		if err := archive.Put(ctx, name, data); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+		return name
+	}
+
+	tests := []struct {
+		name          string
+		paths         []string
+		wantDelivered int
+		wantFailed    int
+		wantRemaining int // Entries left in the segment; -1 when it is deleted
+	}{
+		{name: "all delivered", paths: []string{"/up", "/up"}, wantDelivered: 2, wantRemaining: -1},
+		{name: "partly delivered", paths: []string{"/up", "/down"}, wantDelivered: 1, wantFailed: 1, wantRemaining: 1},
+		{name: "none delivered", paths: []string{"/down"}, wantFailed: 1, wantRemaining: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := segment(tt.paths...)
+			result, err := redriver.Redrive(ctx, name)
+			if err != nil {
+				t.Fatalf("Redrive: %v", err)
+			}
+			if result.Delivered != tt.wantDelivered || result.Failed != tt.wantFailed || len(result.Errors) != tt.wantFailed {
+				t.Errorf("result = %+v, want %d delivered, %d failed", result, tt.wantDelivered, tt.wantFailed)
+			}
+			data, err := archive.Get(ctx, name)
+			if tt.wantRemaining < 0 {
+				if !errors.Is(err, ErrDLQSegmentNotFound) {
+					t.Errorf("segment still archived after a full redrive (err = %v)", err)
+				}
+				return
+			}
+			var remaining []FailedWebhook
+			if err := json.Unmarshal(data, &remaining); err != nil {
+				t.Fatalf("decode remaining: %v", err)
+			}
+			if len(remaining) != tt.wantRemaining {
+				t.Errorf("remaining = %d entries, want %d", len(remaining), tt.wantRemaining)
+			}
+		})
+	}
+
+	if _, err := redriver.Redrive(ctx, "dlq-missing.json"); !errors.Is(err, ErrDLQSegmentNotFound) {
+		t.Errorf("Redrive(unknown) err = %v, want ErrDLQSegmentNotFound", err)
+	}
+	deliveries, _ := store.ListWebhookDeliveries(ctx, storage.WebhookDeliveryFilter{WebhookID: "webhook_0"})
+	if len(deliveries) == 0 || deliveries[0].EventID != "evt_0" || deliveries[0].Attempt != 6 {
+		t.Errorf("delivery log = %+v, want re-deliveries recorded as attempt 6", deliveries)
+	}
+}
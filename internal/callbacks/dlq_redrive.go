@@ -0,0 +1,122 @@
+package callbacks
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/rs/zerolog"
+)
+
+// DLQRedriver re-delivers webhooks from DLQ segments rotated into an archive.
+type DLQRedriver struct {
+	archive DLQArchive
+	sender  webhookSender
+	log     DeliveryLog
+	logger  zerolog.Logger
+}
+
+// DLQRedriverOptions configures a DLQRedriver.
+type DLQRedriverOptions struct {
+	Timeout     time.Duration          // Per-delivery timeout (default: 10s)
+	TLS         map[string]*tls.Config // Optional: per-destination TLS settings from LoadTLS
+	AWS         *AWSTransport          // Optional: required for entries targeting SQS/SNS ARNs
+	DeliveryLog DeliveryLog            // Optional: records each re-delivery attempt
+	Logger      zerolog.Logger
+}
+
+// NewDLQRedriver re-drives segments of archive, or returns nil without one.
+func NewDLQRedriver(archive DLQArchive, opts DLQRedriverOptions) *DLQRedriver {
+	if archive == nil {
+		return nil
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	return &DLQRedriver{
+		archive: archive,
+		sender:  newWebhookSender(opts.Timeout, opts.TLS, opts.AWS),
+		log:     opts.DeliveryLog,
+		logger:  opts.Logger,
+	}
+}
+
+// Segments returns the archived segment names, oldest first.
+func (r *DLQRedriver) Segments(ctx context.Context) ([]string, error) {
+	return r.archive.List(ctx)
+}
+
+// RedriveResult reports a segment re-drive.
+type RedriveResult struct {
+	Segment   string   `json:"segment"`
+	Delivered int      `json:"delivered"`
+	Failed    int      `json:"failed"`
+	Errors    []string `json:"errors,omitempty"` // One per failed entry, "<id>: <error>"
+}
+
+// Redrive delivers each webhook in segment once. Delivered entries leave the segment; the
+// segment is deleted once it is empty and otherwise rewritten with the entries that failed
+// again, so it can be re-driven later. Returns ErrDLQSegmentNotFound for unknown segments.
+func (r *DLQRedriver) Redrive(ctx context.Context, segment string) (RedriveResult, error) {
+	result := RedriveResult{Segment: segment}
+	data, err := r.archive.Get(ctx, segment)
+	if err != nil {
+		return result, err
+	}
+	var entries []FailedWebhook
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return result, fmt.Errorf("decode dlq segment %s: %w", segment, err)
+	}
+
+	var remaining []FailedWebhook
+	for _, entry := range entries {
+		webhook := storage.PendingWebhook{
+			ID:        entry.ID,
+			URL:       entry.URL,
+			Payload:   entry.Payload,
+			Headers:   entry.Headers,
+			EventType: entry.EventType,
+		}
+		start := time.Now()
+		resp, err := r.sender.sendWebhook(ctx, webhook)
+		record := deliveryRecord(entry.URL, entry.EventType, entry.Attempts+1, resp, time.Since(start), err)
+		record.WebhookID = entry.ID
+		record.EventID = payloadEventID(entry.Payload)
+		recordDelivery(ctx, r.log, r.logger, record)
+
+		if err != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, entry.ID+": "+err.Error())
+			entry.Attempts++
+			entry.LastError = err.Error()
+			entry.LastAttempt = time.Now().UTC()
+			remaining = append(remaining, entry)
+			continue
+		}
+		result.Delivered++
+	}
+
+	if len(remaining) == 0 {
+		if err := r.archive.Delete(ctx, segment); err != nil {
+			return result, fmt.Errorf("delete redriven dlq segment %s: %w", segment, err)
+		}
+	} else if result.Delivered > 0 {
+		data, err := json.MarshalIndent(remaining, "", "  ")
+		if err != nil {
+			return result, fmt.Errorf("marshal dlq segment: %w", err)
+		}
+		if err := r.archive.Put(ctx, segment, data); err != nil {
+			return result, fmt.Errorf("rewrite dlq segment %s: %w", segment, err)
+		}
+	}
+
+	r.logger.Info().
+		Str("segment", segment).
+		Int("delivered", result.Delivered).
+		Int("failed", result.Failed).
+		Msg("callbacks: redrove DLQ segment")
+	return result, nil
+}
@@ -0,0 +1,114 @@
+package callbacks
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// MultiNotifier fans each event out to several notifiers, e.g. HTTP webhooks plus an event bus.
+type MultiNotifier struct {
+	notifiers []Notifier
+}
+
+// NewMultiNotifier combines notifiers, skipping nil and no-op entries.
+// Returns the single notifier directly when only one remains, or NoopNotifier when none do.
+func NewMultiNotifier(notifiers ...Notifier) Notifier {
+	active := make([]Notifier, 0, len(notifiers))
+	for _, n := range notifiers {
+		if n == nil {
+			continue
+		}
+		if _, isNoop := n.(NoopNotifier); isNoop {
+			continue
+		}
+		active = append(active, n)
+	}
+
+	switch len(active) {
+	case 0:
+		return NoopNotifier{}
+	case 1:
+		return active[0]
+	default:
+		return &MultiNotifier{notifiers: active}
+	}
+}
+
+// PaymentSucceeded forwards the event to every notifier.
+// The EventID is assigned once so all sinks share the same idempotency key.
+func (m *MultiNotifier) PaymentSucceeded(ctx context.Context, event PaymentEvent) {
+	PreparePaymentEvent(&event)
+	for _, n := range m.notifiers {
+		n.PaymentSucceeded(ctx, event)
+	}
+}
+
+// RefundSucceeded forwards the event to every notifier.
+// The EventID is assigned once so all sinks share the same idempotency key.
+func (m *MultiNotifier) RefundSucceeded(ctx context.Context, event RefundEvent) {
+	PrepareRefundEvent(&event)
+	for _, n := range m.notifiers {
+		n.RefundSucceeded(ctx, event)
+	}
+}
+
+// SubscriptionChanged forwards the event to every notifier.
+// The EventID is assigned once so all sinks share the same idempotency key.
+func (m *MultiNotifier) SubscriptionChanged(ctx context.Context, event SubscriptionEvent) {
+	PrepareSubscriptionEvent(&event)
+	for _, n := range m.notifiers {
+		n.SubscriptionChanged(ctx, event)
+	}
+}
+
+// RefundRequestChanged forwards the event to every notifier.
+// The EventID is assigned once so all sinks share the same idempotency key.
+func (m *MultiNotifier) RefundRequestChanged(ctx context.Context, event RefundRequestEvent) {
+	PrepareRefundRequestEvent(&event)
+	for _, n := range m.notifiers {
+		n.RefundRequestChanged(ctx, event)
+	}
+}
+
+// PaymentFailed forwards the event to every notifier.
+// The EventID is assigned once so all sinks share the same idempotency key.
+func (m *MultiNotifier) PaymentFailed(ctx context.Context, event PaymentFailedEvent) {
+	PreparePaymentFailedEvent(&event)
+	for _, n := range m.notifiers {
+		n.PaymentFailed(ctx, event)
+	}
+}
+
+// Drain waits for every notifier that delivers asynchronously to finish.
+func (m *MultiNotifier) Drain(ctx context.Context) error {
+	var errs []error
+	for _, n := range m.notifiers {
+		errs = append(errs, Drain(ctx, n))
+	}
+	return errors.Join(errs...)
+}
+
+// Drain waits for n's in-flight deliveries to finish, bounded by ctx. Notifiers that
+// deliver synchronously or persist their queue have nothing to drain.
+func Drain(ctx context.Context, n Notifier) error {
+	if drainer, ok := n.(interface{ Drain(context.Context) error }); ok {
+		return drainer.Drain(ctx)
+	}
+	return nil
+}
+
+// waitInflight waits for the deliveries tracked by inflight to finish, or until ctx is done.
+func waitInflight(ctx context.Context, inflight *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		inflight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,96 @@
+package callbacks
+
+import (
+	"context"
+	"testing"
+)
+
+type recordingNotifier struct {
+	payments       []PaymentEvent
+	refunds        []RefundEvent
+	subscriptions  []SubscriptionEvent
+	refundRequests []RefundRequestEvent
+	failures       []PaymentFailedEvent
+}
+
+func (r *recordingNotifier) PaymentSucceeded(_ context.Context, event PaymentEvent) {
+	r.payments = append(r.payments, event)
+}
+
+func (r *recordingNotifier) RefundSucceeded(_ context.Context, event RefundEvent) {
+	r.refunds = append(r.refunds, event)
+}
+
+func (r *recordingNotifier) SubscriptionChanged(_ context.Context, event SubscriptionEvent) {
+	r.subscriptions = append(r.subscriptions, event)
+}
+
+func (r *recordingNotifier) RefundRequestChanged(_ context.Context, event RefundRequestEvent) {
+	r.refundRequests = append(r.refundRequests, event)
+}
+
+func (r *recordingNotifier) PaymentFailed(_ context.Context, event PaymentFailedEvent) {
+	r.failures = append(r.failures, event)
+}
+
+func TestNewMultiNotifier_SkipsNoopAndNil(t *testing.T) {
+	if _, ok := NewMultiNotifier(nil, NoopNotifier{}).(NoopNotifier); !ok {
+		t.Fatal("expected NoopNotifier when no active notifiers are given")
+	}
+
+	single := &recordingNotifier{}
+	if got := NewMultiNotifier(NoopNotifier{}, single); got != single {
+		t.Fatal("expected single notifier to be returned unwrapped")
+	}
+}
+
+func TestMultiNotifier_SharesEventID(t *testing.T) {
+	a, b := &recordingNotifier{}, &recordingNotifier{}
+	n := NewMultiNotifier(a, b)
+
+	n.PaymentSucceeded(context.Background(), PaymentEvent{ResourceID: "res-1"})
+	n.RefundSucceeded(context.Background(), RefundEvent{RefundID: "refund_1"})
+	n.SubscriptionChanged(context.Background(), SubscriptionEvent{EventType: SubscriptionPaused, SubscriptionID: "sub_1"})
+	n.RefundRequestChanged(context.Background(), RefundRequestEvent{EventType: RefundRequestAutoDenied, RefundID: "refund_2"})
+	n.PaymentFailed(context.Background(), PaymentFailedEvent{ResourceID: "res-1", ErrorCode: "amount_mismatch"})
+
+	if len(a.payments) != 1 || len(b.payments) != 1 {
+		t.Fatalf("expected payment fan-out to both notifiers, got %d and %d", len(a.payments), len(b.payments))
+	}
+	if a.payments[0].EventID == "" || a.payments[0].EventID != b.payments[0].EventID {
+		t.Errorf("payment event IDs differ: %q vs %q", a.payments[0].EventID, b.payments[0].EventID)
+	}
+	if len(a.refunds) != 1 || a.refunds[0].EventID != b.refunds[0].EventID {
+		t.Errorf("refund event IDs differ across notifiers")
+	}
+	if len(a.subscriptions) != 1 || a.subscriptions[0].EventID != b.subscriptions[0].EventID {
+		t.Errorf("subscription event IDs differ across notifiers")
+	}
+	if got := a.subscriptions[0].EventType; got != SubscriptionPaused {
+		t.Errorf("subscription event type = %q, want %q", got, SubscriptionPaused)
+	}
+	if len(a.refundRequests) != 1 || a.refundRequests[0].EventID != b.refundRequests[0].EventID {
+		t.Errorf("refund request event IDs differ across notifiers")
+	}
+	if len(a.failures) != 1 || a.failures[0].EventID != b.failures[0].EventID {
+		t.Errorf("payment failure event IDs differ across notifiers")
+	}
+	if got := a.failures[0].EventType; got != "payment.failed" {
+		t.Errorf("payment failure event type = %q, want payment.failed", got)
+	}
+}
+
+func TestNATSSubject(t *testing.T) {
+	tests := []struct {
+		prefix, eventType, want string
+	}{
+		{"cedros.events", "payment.succeeded", "cedros.events.payment.succeeded"},
+		{"cedros.events.", "refund.succeeded", "cedros.events.refund.succeeded"},
+		{"", "payment.succeeded", "payment.succeeded"},
+	}
+	for _, tt := range tests {
+		if got := natsSubject(tt.prefix, tt.eventType); got != tt.want {
+			t.Errorf("natsSubject(%q, %q) = %q, want %q", tt.prefix, tt.eventType, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package callbacks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/rs/zerolog"
+
+	"github.com/CedrosPay/server/internal/config"
+)
+
+// NATSNotifier publishes payment and refund events to NATS JetStream.
+// Every publish waits for a JetStream ack and is retried with backoff, so events are
+// delivered at least once. The EventID is sent as the Nats-Msg-Id header, letting the
+// stream's duplicate window drop retries that were already persisted.
+type NATSNotifier struct {
+	cfg      config.NATSConfig
+	conn     *nats.Conn
+	js       jetstream.JetStream
+	logger   zerolog.Logger
+	inflight sync.WaitGroup // Publishes still retrying, awaited by Drain
+}
+
+// NATSOption customizes the NATS notifier.
+type NATSOption func(*NATSNotifier)
+
+// WithNATSLogger sets a custom logger for publish failures.
+func WithNATSLogger(logger zerolog.Logger) NATSOption {
+	return func(n *NATSNotifier) {
+		n.logger = logger
+	}
+}
+
+// NewNATSNotifier connects to NATS and prepares the JetStream publisher.
+// If cfg.Stream is set, the stream is created (or updated) to capture "<subject_prefix>.>".
+func NewNATSNotifier(cfg config.NATSConfig, opts ...NATSOption) (*NATSNotifier, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("callbacks: nats url required")
+	}
+	if cfg.SubjectPrefix == "" {
+		cfg.SubjectPrefix = "cedros.events"
+	}
+	if cfg.PublishTimeout.Duration <= 0 {
+		cfg.PublishTimeout = config.Duration{Duration: 5 * time.Second}
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 5
+	}
+
+	n := &NATSNotifier{
+		cfg:    cfg,
+		logger: zerolog.Nop(),
+	}
+	for _, opt := range opts {
+		opt(n)
+	}
+
+	connOpts := []nats.Option{
+		nats.Name("cedros-pay"),
+		nats.MaxReconnects(-1), // Keep reconnecting - JetStream acks guard against loss
+	}
+	if cfg.CredentialsFile != "" {
+		connOpts = append(connOpts, nats.UserCredentials(cfg.CredentialsFile))
+	}
+	if cfg.Token != "" {
+		connOpts = append(connOpts, nats.Token(cfg.Token))
+	}
+
+	conn, err := nats.Connect(cfg.URL, connOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("callbacks: connect nats: %w", err)
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("callbacks: init jetstream: %w", err)
+	}
+
+	if cfg.Stream != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.PublishTimeout.Duration)
+		_, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+			Name:     cfg.Stream,
+			Subjects: []string{cfg.SubjectPrefix + ".>"},
+		})
+		cancel()
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("callbacks: ensure jetstream stream %q: %w", cfg.Stream, err)
+		}
+	}
+
+	n.conn = conn
+	n.js = js
+	return n, nil
+}
+
+// PaymentSucceeded publishes the payment event asynchronously.
+func (n *NATSNotifier) PaymentSucceeded(ctx context.Context, event PaymentEvent) {
+	if n == nil {
+		return
+	}
+	PreparePaymentEvent(&event)
+	n.publishAsync(event.EventType, event.EventID, event)
+}
+
+// RefundSucceeded publishes the refund event asynchronously.
+func (n *NATSNotifier) RefundSucceeded(ctx context.Context, event RefundEvent) {
+	if n == nil {
+		return
+	}
+	PrepareRefundEvent(&event)
+	n.publishAsync(event.EventType, event.EventID, event)
+}
+
+// SubscriptionChanged publishes the subscription event asynchronously.
+func (n *NATSNotifier) SubscriptionChanged(ctx context.Context, event SubscriptionEvent) {
+	if n == nil {
+		return
+	}
+	PrepareSubscriptionEvent(&event)
+	n.publishAsync(event.EventType, event.EventID, event)
+}
+
+// RefundRequestChanged publishes the refund request event asynchronously.
+func (n *NATSNotifier) RefundRequestChanged(ctx context.Context, event RefundRequestEvent) {
+	if n == nil {
+		return
+	}
+	PrepareRefundRequestEvent(&event)
+	n.publishAsync(event.EventType, event.EventID, event)
+}
+
+// PaymentFailed publishes the payment failure event asynchronously.
+func (n *NATSNotifier) PaymentFailed(ctx context.Context, event PaymentFailedEvent) {
+	if n == nil {
+		return
+	}
+	PreparePaymentFailedEvent(&event)
+	n.publishAsync(event.EventType, event.EventID, event)
+}
+
+// publishAsync serializes the event and publishes it in the background.
+func (n *NATSNotifier) publishAsync(eventType, eventID string, event any) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		n.logger.Error().Err(err).Str("event_id", eventID).Msg("callbacks: failed to serialize nats event")
+		return
+	}
+
+	n.inflight.Add(1)
+	go func() {
+		defer n.inflight.Done()
+		if err := n.publish(context.Background(), natsSubject(n.cfg.SubjectPrefix, eventType), eventID, payload); err != nil {
+			n.logger.Error().
+				Err(err).
+				Str("event_id", eventID).
+				Str("event_type", eventType).
+				Msg("callbacks: nats publish failed after all retries")
+		}
+	}()
+}
+
+// publish sends the payload and waits for a JetStream ack, retrying with exponential backoff.
+func (n *NATSNotifier) publish(ctx context.Context, subject, eventID string, payload []byte) error {
+	logger := n.logger.With().Str("subject", subject).Logger()
+	return publishWithRetry(ctx, "nats", n.cfg.MaxAttempts, n.cfg.PublishTimeout.Duration, logger, func(ctx context.Context) error {
+		msg := nats.NewMsg(subject)
+		msg.Data = payload
+		msg.Header.Set("Content-Type", "application/json")
+		_, err := n.js.PublishMsg(ctx, msg, jetstream.WithMsgID(eventID))
+		return err
+	})
+}
+
+// Drain waits for in-flight publishes, including their retries, to finish or until ctx is done.
+func (n *NATSNotifier) Drain(ctx context.Context) error {
+	if n == nil {
+		return nil
+	}
+	return waitInflight(ctx, &n.inflight)
+}
+
+// Close drains pending publishes and closes the NATS connection.
+func (n *NATSNotifier) Close() error {
+	if n == nil || n.conn == nil {
+		return nil
+	}
+	return n.conn.Drain()
+}
+
+// natsSubject builds the subject for an event type (e.g., "cedros.events" + "payment.succeeded").
+func natsSubject(prefix, eventType string) string {
+	prefix = strings.TrimSuffix(prefix, ".")
+	if prefix == "" {
+		return eventType
+	}
+	return prefix + "." + eventType
+}
@@ -2,8 +2,11 @@ package callbacks
 
 import (
 	"context"
+	"crypto/tls"
+	"time"
 
 	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/eventbus"
 	"github.com/CedrosPay/server/internal/metrics"
 	"github.com/CedrosPay/server/internal/storage"
 	"github.com/rs/zerolog"
@@ -24,11 +27,15 @@ type PersistentCallbackOptions struct {
 	RetryConfig RetryConfig
 	Logger      zerolog.Logger
 	Metrics     *metrics.Metrics
+	AWS         *AWSTransport          // Optional: required when a destination is an SQS/SNS ARN
+	EventBus    *eventbus.Bus          // Optional: publishes webhook.failed when retries are exhausted
+	TLS         map[string]*tls.Config // Optional: per-destination TLS settings from LoadTLS
 }
 
-// NewPersistentCallbackClient creates a callback client with persistent queue backing.
+// NewPersistentCallbackClient creates a callback client with persistent queue backing, or nil
+// without any webhook destination.
 func NewPersistentCallbackClient(opts PersistentCallbackOptions) *PersistentCallbackClient {
-	if opts.Config.PaymentSuccessURL == "" {
+	if len(destinations(opts.Config)) == 0 {
 		return nil
 	}
 
@@ -42,6 +49,9 @@ func NewPersistentCallbackClient(opts PersistentCallbackOptions) *PersistentCall
 		RetryConfig: opts.RetryConfig,
 		Logger:      opts.Logger,
 		Metrics:     opts.Metrics,
+		AWS:         opts.AWS,
+		EventBus:    opts.EventBus,
+		TLS:         opts.TLS,
 	})
 
 	// Start worker in background
@@ -81,6 +91,83 @@ func (c *PersistentCallbackClient) RefundSucceeded(ctx context.Context, event Re
 	}
 }
 
+// SubscriptionChanged queues a subscription webhook for persistent delivery.
+func (c *PersistentCallbackClient) SubscriptionChanged(ctx context.Context, event SubscriptionEvent) {
+	if c == nil || c.worker == nil {
+		return
+	}
+
+	if err := c.worker.EnqueueSubscriptionWebhook(ctx, event); err != nil {
+		c.logger.Error().
+			Err(err).
+			Str("eventID", event.EventID).
+			Msg("failed to enqueue subscription webhook")
+	}
+}
+
+// RefundRequestChanged queues a refund request webhook for persistent delivery.
+func (c *PersistentCallbackClient) RefundRequestChanged(ctx context.Context, event RefundRequestEvent) {
+	if c == nil || c.worker == nil {
+		return
+	}
+
+	if err := c.worker.EnqueueRefundRequestWebhook(ctx, event); err != nil {
+		c.logger.Error().
+			Err(err).
+			Str("eventID", event.EventID).
+			Msg("failed to enqueue refund request webhook")
+	}
+}
+
+// PaymentFailed queues a payment failure webhook for persistent delivery.
+func (c *PersistentCallbackClient) PaymentFailed(ctx context.Context, event PaymentFailedEvent) {
+	if c == nil || c.worker == nil {
+		return
+	}
+
+	if err := c.worker.EnqueuePaymentFailedWebhook(ctx, event); err != nil {
+		c.logger.Error().
+			Err(err).
+			Str("eventID", event.EventID).
+			Msg("failed to enqueue payment failure webhook")
+	}
+}
+
+// SchedulePayment queues a payment webhook for persistent delivery at the given time.
+func (c *PersistentCallbackClient) SchedulePayment(ctx context.Context, event PaymentEvent, at time.Time) error {
+	if c == nil || c.worker == nil {
+		return nil
+	}
+
+	if err := c.worker.SchedulePaymentWebhook(ctx, event, at); err != nil {
+		c.logger.Error().
+			Err(err).
+			Str("eventID", event.EventID).
+			Time("at", at).
+			Msg("failed to schedule payment webhook")
+		return err
+	}
+	return nil
+}
+
+// ScheduleSubscription queues a subscription webhook for persistent delivery at the given
+// time.
+func (c *PersistentCallbackClient) ScheduleSubscription(ctx context.Context, event SubscriptionEvent, at time.Time) error {
+	if c == nil || c.worker == nil {
+		return nil
+	}
+
+	if err := c.worker.ScheduleSubscriptionWebhook(ctx, event, at); err != nil {
+		c.logger.Error().
+			Err(err).
+			Str("eventID", event.EventID).
+			Time("at", at).
+			Msg("failed to schedule subscription webhook")
+		return err
+	}
+	return nil
+}
+
 // Close gracefully stops the webhook worker.
 func (c *PersistentCallbackClient) Close() error {
 	if c == nil || c.worker == nil {
@@ -0,0 +1,243 @@
+package callbacks
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/httputil"
+)
+
+const (
+	pubSubScope           = "https://www.googleapis.com/auth/pubsub"
+	defaultPubSubEndpoint = "https://pubsub.googleapis.com"
+)
+
+// PubSubNotifier publishes payment and refund events to a Google Cloud Pub/Sub topic.
+// Message data is the same JSON payload webhooks receive; event_id and event_type are
+// set as attributes so subscribers can filter and deduplicate without decoding the body.
+type PubSubNotifier struct {
+	cfg        config.PubSubConfig
+	publishURL string
+	httpClient *http.Client
+	logger     zerolog.Logger
+	inflight   sync.WaitGroup // Publishes still retrying, awaited by Drain
+}
+
+// PubSubOption customizes the Pub/Sub notifier.
+type PubSubOption func(*PubSubNotifier)
+
+// WithPubSubLogger sets a custom logger for publish failures.
+func WithPubSubLogger(logger zerolog.Logger) PubSubOption {
+	return func(n *PubSubNotifier) {
+		n.logger = logger
+	}
+}
+
+// NewPubSubNotifier resolves credentials and prepares the topic publisher.
+// Credentials come from cfg.CredentialsFile, or Application Default Credentials when empty.
+// When cfg.Endpoint is set (e.g., the Pub/Sub emulator) requests are sent unauthenticated.
+func NewPubSubNotifier(ctx context.Context, cfg config.PubSubConfig, opts ...PubSubOption) (*PubSubNotifier, error) {
+	if cfg.ProjectID == "" || cfg.Topic == "" {
+		return nil, errors.New("callbacks: pubsub project_id and topic required")
+	}
+	if cfg.PublishTimeout.Duration <= 0 {
+		cfg.PublishTimeout = config.Duration{Duration: 5 * time.Second}
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 5
+	}
+
+	n := &PubSubNotifier{
+		cfg:        cfg,
+		publishURL: pubSubPublishURL(cfg.Endpoint, cfg.ProjectID, cfg.Topic),
+		logger:     zerolog.Nop(),
+	}
+	for _, opt := range opts {
+		opt(n)
+	}
+
+	baseClient := httputil.NewClient(cfg.PublishTimeout.Duration)
+	if cfg.Endpoint != "" {
+		n.httpClient = baseClient
+		return n, nil
+	}
+
+	var creds *google.Credentials
+	var err error
+	if cfg.CredentialsFile != "" {
+		data, readErr := os.ReadFile(cfg.CredentialsFile)
+		if readErr != nil {
+			return nil, fmt.Errorf("callbacks: read pubsub credentials: %w", readErr)
+		}
+		creds, err = google.CredentialsFromJSON(ctx, data, pubSubScope)
+	} else {
+		creds, err = google.FindDefaultCredentials(ctx, pubSubScope)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("callbacks: load pubsub credentials: %w", err)
+	}
+
+	// Token refreshes reuse the pooled base client
+	tokenCtx := context.WithValue(context.Background(), oauth2.HTTPClient, baseClient)
+	n.httpClient = oauth2.NewClient(tokenCtx, creds.TokenSource)
+	return n, nil
+}
+
+// PaymentSucceeded publishes the payment event asynchronously.
+func (n *PubSubNotifier) PaymentSucceeded(ctx context.Context, event PaymentEvent) {
+	if n == nil {
+		return
+	}
+	PreparePaymentEvent(&event)
+	n.publishAsync(event.EventType, event.EventID, event)
+}
+
+// RefundSucceeded publishes the refund event asynchronously.
+func (n *PubSubNotifier) RefundSucceeded(ctx context.Context, event RefundEvent) {
+	if n == nil {
+		return
+	}
+	PrepareRefundEvent(&event)
+	n.publishAsync(event.EventType, event.EventID, event)
+}
+
+// SubscriptionChanged publishes the subscription event asynchronously.
+func (n *PubSubNotifier) SubscriptionChanged(ctx context.Context, event SubscriptionEvent) {
+	if n == nil {
+		return
+	}
+	PrepareSubscriptionEvent(&event)
+	n.publishAsync(event.EventType, event.EventID, event)
+}
+
+// RefundRequestChanged publishes the refund request event asynchronously.
+func (n *PubSubNotifier) RefundRequestChanged(ctx context.Context, event RefundRequestEvent) {
+	if n == nil {
+		return
+	}
+	PrepareRefundRequestEvent(&event)
+	n.publishAsync(event.EventType, event.EventID, event)
+}
+
+// PaymentFailed publishes the payment failure event asynchronously.
+func (n *PubSubNotifier) PaymentFailed(ctx context.Context, event PaymentFailedEvent) {
+	if n == nil {
+		return
+	}
+	PreparePaymentFailedEvent(&event)
+	n.publishAsync(event.EventType, event.EventID, event)
+}
+
+// publishAsync serializes the event and publishes it in the background.
+func (n *PubSubNotifier) publishAsync(eventType, eventID string, event any) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		n.logger.Error().Err(err).Str("event_id", eventID).Msg("callbacks: failed to serialize pubsub event")
+		return
+	}
+
+	body, err := pubSubPublishBody(payload, map[string]string{
+		"event_id":   eventID,
+		"event_type": eventType,
+	})
+	if err != nil {
+		n.logger.Error().Err(err).Str("event_id", eventID).Msg("callbacks: failed to build pubsub request")
+		return
+	}
+
+	n.inflight.Add(1)
+	go func() {
+		defer n.inflight.Done()
+		if err := n.publish(context.Background(), body); err != nil {
+			n.logger.Error().
+				Err(err).
+				Str("event_id", eventID).
+				Str("event_type", eventType).
+				Msg("callbacks: pubsub publish failed after all retries")
+		}
+	}()
+}
+
+// Drain waits for in-flight publishes, including their retries, to finish or until ctx is done.
+func (n *PubSubNotifier) Drain(ctx context.Context) error {
+	if n == nil {
+		return nil
+	}
+	return waitInflight(ctx, &n.inflight)
+}
+
+// Close releases the publisher's idle connections. Call Drain first so pending publishes finish.
+func (n *PubSubNotifier) Close() error {
+	if n == nil || n.httpClient == nil {
+		return nil
+	}
+	n.httpClient.CloseIdleConnections()
+	return nil
+}
+
+// publish posts the message batch to the topic, retrying with exponential backoff.
+func (n *PubSubNotifier) publish(ctx context.Context, body []byte) error {
+	logger := n.logger.With().Str("topic", n.cfg.Topic).Logger()
+	return publishWithRetry(ctx, "pubsub", n.cfg.MaxAttempts, n.cfg.PublishTimeout.Duration, logger, func(ctx context.Context) error {
+		return n.post(ctx, body)
+	})
+}
+
+func (n *PubSubNotifier) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.publishURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("received status %d from pubsub: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// pubSubPublishURL builds the topics.publish REST URL.
+func pubSubPublishURL(endpoint, projectID, topic string) string {
+	if endpoint == "" {
+		endpoint = defaultPubSubEndpoint
+	}
+	return fmt.Sprintf("%s/v1/projects/%s/topics/%s:publish", strings.TrimSuffix(endpoint, "/"), projectID, topic)
+}
+
+// pubSubPublishBody encodes a single-message topics.publish request.
+func pubSubPublishBody(data []byte, attributes map[string]string) ([]byte, error) {
+	type message struct {
+		Data       string            `json:"data"`
+		Attributes map[string]string `json:"attributes,omitempty"`
+	}
+	return json.Marshal(struct {
+		Messages []message `json:"messages"`
+	}{
+		Messages: []message{{
+			Data:       base64.StdEncoding.EncodeToString(data),
+			Attributes: attributes,
+		}},
+	})
+}
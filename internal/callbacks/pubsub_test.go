@@ -0,0 +1,126 @@
+package callbacks
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/CedrosPay/server/internal/config"
+)
+
+func TestPubSubPublishURL(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		want     string
+	}{
+		{endpoint: "", want: "https://pubsub.googleapis.com/v1/projects/proj/topics/events:publish"},
+		{endpoint: "http://localhost:8085/", want: "http://localhost:8085/v1/projects/proj/topics/events:publish"},
+	}
+	for _, tt := range tests {
+		if got := pubSubPublishURL(tt.endpoint, "proj", "events"); got != tt.want {
+			t.Errorf("pubSubPublishURL(%q) = %q, want %q", tt.endpoint, got, tt.want)
+		}
+	}
+}
+
+func TestPubSubNotifier_PublishesToEmulator(t *testing.T) {
+	received := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/projects/proj/topics/events:publish" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		received <- body
+		w.Write([]byte(`{"messageIds":["1"]}`))
+	}))
+	defer server.Close()
+
+	n, err := NewPubSubNotifier(context.Background(), config.PubSubConfig{
+		ProjectID: "proj",
+		Topic:     "events",
+		Endpoint:  server.URL,
+	})
+	if err != nil {
+		t.Fatalf("NewPubSubNotifier: %v", err)
+	}
+
+	n.PaymentSucceeded(context.Background(), PaymentEvent{ResourceID: "article-1"})
+
+	select {
+	case body := <-received:
+		var req struct {
+			Messages []struct {
+				Data       string            `json:"data"`
+				Attributes map[string]string `json:"attributes"`
+			} `json:"messages"`
+		}
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		if len(req.Messages) != 1 {
+			t.Fatalf("expected 1 message, got %d", len(req.Messages))
+		}
+		msg := req.Messages[0]
+		if msg.Attributes["event_type"] != "payment.succeeded" || msg.Attributes["event_id"] == "" {
+			t.Fatalf("unexpected attributes: %v", msg.Attributes)
+		}
+		data, _ := base64.StdEncoding.DecodeString(msg.Data)
+		var event PaymentEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			t.Fatalf("decode event: %v", err)
+		}
+		if event.ResourceID != "article-1" || event.EventID != msg.Attributes["event_id"] {
+			t.Fatalf("unexpected event: %+v", event)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for publish")
+	}
+}
+
+func TestPubSubNotifier_DrainWaitsForPublishes(t *testing.T) {
+	release := make(chan struct{})
+	published := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		published <- struct{}{}
+		w.Write([]byte(`{"messageIds":["1"]}`))
+	}))
+	defer server.Close()
+
+	n, err := NewPubSubNotifier(context.Background(), config.PubSubConfig{
+		ProjectID:      "proj",
+		Topic:          "events",
+		Endpoint:       server.URL,
+		PublishTimeout: config.Duration{Duration: 5 * time.Second},
+	})
+	if err != nil {
+		t.Fatalf("NewPubSubNotifier: %v", err)
+	}
+	n.PaymentSucceeded(context.Background(), PaymentEvent{ResourceID: "article-1"})
+
+	// Shutdown must not return while the publish is in flight
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := Drain(ctx, n); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Drain during publish = %v, want context.DeadlineExceeded", err)
+	}
+
+	close(release)
+	if err := Drain(context.Background(), n); err != nil {
+		t.Fatalf("Drain: %v", err)
+	}
+	select {
+	case <-published:
+	default:
+		t.Fatal("Drain returned before the event was published")
+	}
+	if err := n.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
@@ -3,26 +3,40 @@ package callbacks
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/eventbus"
 	"github.com/CedrosPay/server/internal/httputil"
 	"github.com/CedrosPay/server/internal/metrics"
 	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/internal/tenant"
+	"github.com/CedrosPay/server/internal/tracing"
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	webhookBatchSize     = 10  // Deliveries started per poll
+	webhookDequeueWindow = 100 // Ready webhooks considered per poll, so throttled destinations don't fill the batch
 )
 
 // WebhookQueueWorker processes webhooks from the persistent queue.
 type WebhookQueueWorker struct {
 	store        storage.Store
 	cfg          config.CallbacksConfig
+	destinations []destination
+	limits       map[string]*destinationLimit // Per destination URL; a URL without one is unlimited
 	retryCfg     RetryConfig
-	httpClient   *http.Client
+	sender       webhookSender // HTTP, mutual TLS, and SQS/SNS delivery
 	logger       zerolog.Logger
 	metrics      *metrics.Metrics
+	bus          *eventbus.Bus // Optional: receives webhook.failed events
 	stopChan     chan struct{}
 	doneChan     chan struct{}
 	pollInterval time.Duration
@@ -35,7 +49,10 @@ type WebhookQueueWorkerOptions struct {
 	RetryConfig  RetryConfig
 	Logger       zerolog.Logger
 	Metrics      *metrics.Metrics
-	PollInterval time.Duration // How often to poll for pending webhooks (default: 5s)
+	PollInterval time.Duration          // How often to poll for pending webhooks (default: 5s)
+	AWS          *AWSTransport          // Optional: required for webhooks targeting SQS/SNS ARNs
+	EventBus     *eventbus.Bus          // Optional: publishes webhook.failed when retries are exhausted
+	TLS          map[string]*tls.Config // Optional: per-destination TLS settings from LoadTLS
 }
 
 // NewWebhookQueueWorker creates a new webhook queue worker.
@@ -55,13 +72,24 @@ func NewWebhookQueueWorker(opts WebhookQueueWorkerOptions) *WebhookQueueWorker {
 		timeout = 10 * time.Second
 	}
 
+	dests := destinations(opts.Config)
+	limits := make(map[string]*destinationLimit, len(dests))
+	for _, dest := range dests {
+		if _, ok := limits[dest.url]; !ok {
+			limits[dest.url] = dest.limit
+		}
+	}
+
 	return &WebhookQueueWorker{
 		store:        opts.Store,
 		cfg:          opts.Config,
+		destinations: dests,
+		limits:       limits,
 		retryCfg:     opts.RetryConfig,
-		httpClient:   httputil.NewClient(timeout),
+		sender:       newWebhookSender(timeout, opts.TLS, opts.AWS),
 		logger:       opts.Logger,
 		metrics:      opts.Metrics,
+		bus:          opts.EventBus,
 		stopChan:     make(chan struct{}),
 		doneChan:     make(chan struct{}),
 		pollInterval: opts.PollInterval,
@@ -101,10 +129,11 @@ func (w *WebhookQueueWorker) run(ctx context.Context) {
 	}
 }
 
-// processQueue fetches and processes pending webhooks.
+// processQueue fetches pending webhooks and delivers up to webhookBatchSize of them
+// concurrently. Webhooks whose destination is at its max_in_flight or requests_per_second
+// limit stay pending until a later poll, so one slow receiver doesn't hold up the others.
 func (w *WebhookQueueWorker) processQueue(ctx context.Context) {
-	// Dequeue up to 10 webhooks per poll
-	webhooks, err := w.store.DequeueWebhooks(ctx, 10)
+	webhooks, err := w.store.DequeueWebhooks(ctx, webhookDequeueWindow)
 	if err != nil {
 		w.logger.Error().Err(err).Msg("failed to dequeue webhooks")
 		return
@@ -114,11 +143,31 @@ func (w *WebhookQueueWorker) processQueue(ctx context.Context) {
 		return
 	}
 
-	w.logger.Debug().Int("count", len(webhooks)).Msg("processing webhooks from queue")
-
+	var wg sync.WaitGroup
+	started, throttled := 0, 0
 	for _, webhook := range webhooks {
-		w.processWebhook(ctx, webhook)
+		if started == webhookBatchSize {
+			break
+		}
+		release, ok := w.limits[webhook.URL].tryAcquire()
+		if !ok {
+			throttled++
+			continue
+		}
+		started++
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer release()
+			w.processWebhook(ctx, webhook)
+		}()
 	}
+
+	w.logger.Debug().
+		Int("count", started).
+		Int("throttled", throttled).
+		Msg("processing webhooks from queue")
+	wg.Wait()
 }
 
 // processWebhook processes a single webhook delivery attempt.
@@ -135,13 +184,25 @@ func (w *WebhookQueueWorker) processWebhook(ctx context.Context, webhook storage
 
 	startTime := time.Now()
 
-	// Attempt delivery
-	reqCtx, cancel := context.WithTimeout(ctx, w.retryCfg.Timeout)
-	err := w.sendWebhook(reqCtx, webhook)
+	// Attempt delivery, continuing the trace of the request that enqueued the webhook
+	spanCtx, span := tracing.Tracer().Start(tracing.Extract(ctx, webhook.Headers), "webhook.deliver")
+	span.SetAttributes(
+		attribute.String("cedros.webhook.id", webhook.ID),
+		attribute.String("cedros.webhook.event_type", webhook.EventType),
+		attribute.Int("cedros.webhook.attempt", webhook.Attempts),
+	)
+	reqCtx, cancel := context.WithTimeout(spanCtx, w.retryCfg.Timeout)
+	resp, err := w.sender.sendWebhook(reqCtx, webhook)
 	cancel()
+	tracing.End(span, err)
 
 	duration := time.Since(startTime)
 
+	record := deliveryRecord(webhook.URL, webhook.EventType, webhook.Attempts, resp, duration, err)
+	record.WebhookID = webhook.ID
+	record.EventID = payloadEventID(webhook.Payload)
+	recordDelivery(ctx, w.store, w.logger, record)
+
 	if err == nil {
 		// Success - remove from queue
 		if markErr := w.store.MarkWebhookSuccess(ctx, webhook.ID); markErr != nil {
@@ -172,8 +233,12 @@ func (w *WebhookQueueWorker) processWebhook(ctx context.Context, webhook storage
 
 // handleWebhookFailure schedules a retry or marks webhook as permanently failed.
 func (w *WebhookQueueWorker) handleWebhookFailure(ctx context.Context, webhook storage.PendingWebhook, deliveryErr error) {
-	// Calculate next retry time using exponential backoff
+	// Next retry time from the schedule recorded at enqueue, or the worker's backoff for
+	// webhooks queued without one
 	backoffDuration := w.calculateBackoff(webhook.Attempts)
+	if n := len(webhook.RetrySchedule); n > 0 {
+		backoffDuration = time.Duration(webhook.RetrySchedule[min(webhook.Attempts, n)-1]) * time.Millisecond
+	}
 	nextAttemptAt := time.Now().Add(backoffDuration)
 
 	// Mark webhook as failed (will schedule retry or move to DLQ)
@@ -198,6 +263,15 @@ func (w *WebhookQueueWorker) handleWebhookFailure(ctx context.Context, webhook s
 			Int("attempts", webhook.Attempts).
 			Err(deliveryErr).
 			Msg("webhook failed permanently after all retries")
+
+		// Queued webhooks carry no tenant, so failures are reported to the default tenant
+		publishWebhookFailure(w.bus, tenant.DefaultTenantID, WebhookFailure{
+			WebhookID: webhook.ID,
+			EventType: webhook.EventType,
+			URL:       webhook.URL,
+			Attempts:  webhook.Attempts,
+			Error:     deliveryErr.Error(),
+		})
 	} else {
 		// Scheduled for retry
 		w.logger.Warn().
@@ -212,26 +286,42 @@ func (w *WebhookQueueWorker) handleWebhookFailure(ctx context.Context, webhook s
 
 // calculateBackoff calculates the backoff duration for the given attempt number.
 func (w *WebhookQueueWorker) calculateBackoff(attempt int) time.Duration {
-	// Start with initial interval
-	backoff := w.retryCfg.InitialInterval
-
-	// Apply exponential multiplier for each attempt
-	for i := 1; i < attempt; i++ {
-		backoff = time.Duration(float64(backoff) * w.retryCfg.Multiplier)
-		if backoff > w.retryCfg.MaxInterval {
-			backoff = w.retryCfg.MaxInterval
-			break
-		}
-	}
+	return w.retryCfg.backoff(attempt)
+}
+
+// webhookSender delivers stored webhooks: queued ones, and DLQ entries being re-driven.
+type webhookSender struct {
+	httpClient *http.Client
+	tlsClients map[string]*http.Client // Destinations with their own TLS settings, by URL
+	aws        *AWSTransport           // Optional: delivers webhooks whose URL is an SQS/SNS ARN
+}
 
-	return backoff
+// newWebhookSender builds a sender whose requests time out after timeout.
+func newWebhookSender(timeout time.Duration, tlsConfigs map[string]*tls.Config, aws *AWSTransport) webhookSender {
+	tlsClients := make(map[string]*http.Client, len(tlsConfigs))
+	for url, tlsConfig := range tlsConfigs {
+		tlsClients[url] = httputil.NewTLSClient(timeout, tlsConfig)
+	}
+	return webhookSender{
+		httpClient: httputil.NewClient(timeout),
+		tlsClients: tlsClients,
+		aws:        aws,
+	}
 }
 
-// sendWebhook performs the actual HTTP request to deliver the webhook.
-func (w *WebhookQueueWorker) sendWebhook(ctx context.Context, webhook storage.PendingWebhook) error {
+// sendWebhook delivers the webhook over HTTP, or to SQS/SNS when its URL is an AWS ARN.
+func (w webhookSender) sendWebhook(ctx context.Context, webhook storage.PendingWebhook) (deliveryResponse, error) {
+	if IsAWSTarget(webhook.URL) {
+		if w.aws == nil {
+			return deliveryResponse{}, fmt.Errorf("no aws transport configured for %s", webhook.URL)
+		}
+		// Webhook ID is stable across retries, so FIFO targets deduplicate redeliveries
+		return deliveryResponse{}, w.aws.Deliver(ctx, webhook.URL, webhook.Payload, webhook.Headers, webhook.EventType, webhook.ID)
+	}
+
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(webhook.Payload))
 	if err != nil {
-		return fmt.Errorf("build request: %w", err)
+		return deliveryResponse{}, fmt.Errorf("build request: %w", err)
 	}
 
 	// Set headers
@@ -247,91 +337,127 @@ func (w *WebhookQueueWorker) sendWebhook(ctx context.Context, webhook storage.Pe
 		req.Header.Set("Content-Type", "application/json")
 	}
 
-	resp, err := w.httpClient.Do(req)
+	client := w.httpClient
+	if tlsClient := w.tlsClients[webhook.URL]; tlsClient != nil {
+		client = tlsClient
+	}
+	resp, err := client.Do(req)
 	if err != nil {
-		return fmt.Errorf("send request: %w", err)
+		return deliveryResponse{}, fmt.Errorf("send request: %w", err)
 	}
 	defer resp.Body.Close()
 
+	answer := readDeliveryResponse(resp)
 	if resp.StatusCode >= 400 {
-		return fmt.Errorf("received status %d from %s", resp.StatusCode, webhook.URL)
+		return answer, fmt.Errorf("received status %d from %s", resp.StatusCode, webhook.URL)
 	}
 
-	return nil
+	return answer, nil
 }
 
-// EnqueuePaymentWebhook adds a payment webhook to the persistent queue.
+// EnqueuePaymentWebhook adds a payment webhook to the persistent queue for each destination.
 func (w *WebhookQueueWorker) EnqueuePaymentWebhook(ctx context.Context, event PaymentEvent) error {
 	// Prepare idempotency fields
 	PreparePaymentEvent(&event)
+	return w.enqueue(ctx, "payment", event.EventID, event, time.Now())
+}
 
-	// Serialize payload
-	payload, err := json.Marshal(event)
-	if err != nil {
-		return fmt.Errorf("marshal payment event: %w", err)
-	}
-
-	// Create pending webhook
-	webhook := storage.PendingWebhook{
-		URL:           w.cfg.PaymentSuccessURL,
-		Payload:       json.RawMessage(payload),
-		Headers:       w.cfg.Headers,
-		EventType:     "payment",
-		Status:        storage.WebhookStatusPending,
-		Attempts:      0,
-		MaxAttempts:   w.retryCfg.MaxAttempts,
-		NextAttemptAt: time.Now().UTC(),
-		CreatedAt:     time.Now().UTC(),
-	}
-
-	// Enqueue to storage
-	webhookID, err := w.store.EnqueueWebhook(ctx, webhook)
-	if err != nil {
-		return fmt.Errorf("enqueue webhook: %w", err)
-	}
+// EnqueueRefundWebhook adds a refund webhook to the persistent queue for each destination.
+func (w *WebhookQueueWorker) EnqueueRefundWebhook(ctx context.Context, event RefundEvent) error {
+	// Prepare idempotency fields
+	PrepareRefundEvent(&event)
+	return w.enqueue(ctx, "refund", event.EventID, event, time.Now())
+}
 
-	w.logger.Debug().
-		Str("webhookID", webhookID).
-		Str("eventID", event.EventID).
-		Msg("payment webhook enqueued")
+// EnqueueSubscriptionWebhook adds a subscription webhook to the persistent queue for each
+// destination.
+func (w *WebhookQueueWorker) EnqueueSubscriptionWebhook(ctx context.Context, event SubscriptionEvent) error {
+	// Prepare idempotency fields
+	PrepareSubscriptionEvent(&event)
+	return w.enqueue(ctx, "subscription", event.EventID, event, time.Now())
+}
 
-	return nil
+// EnqueueRefundRequestWebhook adds a refund request webhook to the persistent queue for each
+// destination.
+func (w *WebhookQueueWorker) EnqueueRefundRequestWebhook(ctx context.Context, event RefundRequestEvent) error {
+	// Prepare idempotency fields
+	PrepareRefundRequestEvent(&event)
+	return w.enqueue(ctx, "refund_request", event.EventID, event, time.Now())
 }
 
-// EnqueueRefundWebhook adds a refund webhook to the persistent queue.
-func (w *WebhookQueueWorker) EnqueueRefundWebhook(ctx context.Context, event RefundEvent) error {
+// EnqueuePaymentFailedWebhook adds a payment failure webhook to the persistent queue for each
+// destination.
+func (w *WebhookQueueWorker) EnqueuePaymentFailedWebhook(ctx context.Context, event PaymentFailedEvent) error {
 	// Prepare idempotency fields
-	PrepareRefundEvent(&event)
+	PreparePaymentFailedEvent(&event)
+	return w.enqueue(ctx, "payment_failed", event.EventID, event, time.Now())
+}
 
-	// Serialize payload
-	payload, err := json.Marshal(event)
-	if err != nil {
-		return fmt.Errorf("marshal refund event: %w", err)
-	}
+// SchedulePaymentWebhook adds a payment webhook to the persistent queue for each destination,
+// held back until at.
+func (w *WebhookQueueWorker) SchedulePaymentWebhook(ctx context.Context, event PaymentEvent, at time.Time) error {
+	PreparePaymentEvent(&event)
+	return w.enqueue(ctx, "payment", event.EventID, event, at)
+}
 
-	// Create pending webhook
-	webhook := storage.PendingWebhook{
-		URL:           w.cfg.PaymentSuccessURL,
-		Payload:       json.RawMessage(payload),
-		Headers:       w.cfg.Headers,
-		EventType:     "refund",
-		Status:        storage.WebhookStatusPending,
-		Attempts:      0,
-		MaxAttempts:   w.retryCfg.MaxAttempts,
-		NextAttemptAt: time.Now().UTC(),
-		CreatedAt:     time.Now().UTC(),
-	}
+// ScheduleSubscriptionWebhook adds a subscription webhook to the persistent queue for each
+// destination, held back until at.
+func (w *WebhookQueueWorker) ScheduleSubscriptionWebhook(ctx context.Context, event SubscriptionEvent, at time.Time) error {
+	PrepareSubscriptionEvent(&event)
+	return w.enqueue(ctx, "subscription", event.EventID, event, at)
+}
 
-	// Enqueue to storage
-	webhookID, err := w.store.EnqueueWebhook(ctx, webhook)
-	if err != nil {
-		return fmt.Errorf("enqueue webhook: %w", err)
+// enqueue adds one webhook per destination subscribed to eventType, first attempted at at (or
+// right away if that has passed). Each is its own queue entry, so its attempts and backoff
+// don't depend on the other destinations.
+func (w *WebhookQueueWorker) enqueue(ctx context.Context, eventType, eventID string, event any, at time.Time) error {
+	now := time.Now().UTC()
+	at = at.UTC()
+	if at.Before(now) {
+		at = now
 	}
+	for _, dest := range w.destinations {
+		if !dest.accepts(eventType) {
+			continue
+		}
+		payload, err := dest.render(eventType, event)
+		if err != nil {
+			return fmt.Errorf("render %s event for %s: %w", eventType, dest.url, err)
+		}
 
-	w.logger.Debug().
-		Str("webhookID", webhookID).
-		Str("eventID", event.EventID).
-		Msg("refund webhook enqueued")
+		retry := w.retryCfg
+		if dest.retry != nil {
+			retry = *dest.retry
+		}
+		var schedule []int64
+		for _, delay := range retry.schedule() {
+			schedule = append(schedule, delay.Milliseconds())
+		}
 
+		webhook := storage.PendingWebhook{
+			URL:           dest.url,
+			Payload:       json.RawMessage(payload),
+			Headers:       tracing.Inject(ctx, dest.headers),
+			EventType:     eventType,
+			Priority:      dest.queuePriority(eventType),
+			Status:        storage.WebhookStatusPending,
+			Attempts:      0,
+			MaxAttempts:   retry.MaxAttempts,
+			RetrySchedule: schedule,
+			NextAttemptAt: at,
+			CreatedAt:     now,
+		}
+		webhookID, err := w.store.EnqueueWebhook(ctx, webhook)
+		if err != nil {
+			return fmt.Errorf("enqueue webhook: %w", err)
+		}
+
+		w.logger.Debug().
+			Str("webhookID", webhookID).
+			Str("eventID", eventID).
+			Str("eventType", eventType).
+			Time("nextAttemptAt", at).
+			Msg("webhook enqueued")
+	}
 	return nil
 }
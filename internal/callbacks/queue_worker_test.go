@@ -0,0 +1,58 @@
+package callbacks
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func TestWebhookQueueWorker_ScheduleSubscriptionWebhook(t *testing.T) {
+	tests := []struct {
+		name      string
+		delay     time.Duration
+		wantReady bool
+	}{
+		{name: "future time is held back", delay: 72 * time.Hour, wantReady: false},
+		{name: "past time delivers now", delay: -time.Hour, wantReady: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cfg := config.CallbacksConfig{Destinations: []config.CallbackDestinationConfig{{URL: "https://example.com/hooks"}}}
+			store := storage.NewMemoryStore()
+			t.Cleanup(func() { _ = store.Close() })
+			w := NewWebhookQueueWorker(WebhookQueueWorkerOptions{Store: store, Config: cfg, RetryConfig: DefaultRetryConfig()})
+
+			at := time.Now().Add(tt.delay)
+			event := SubscriptionEvent{EventID: "evt_reminder", EventType: SubscriptionRenewalReminder, SubscriptionID: "sub_1"}
+			if err := w.ScheduleSubscriptionWebhook(ctx, event, at); err != nil {
+				t.Fatalf("ScheduleSubscriptionWebhook: %v", err)
+			}
+
+			queued, err := store.ListWebhooks(ctx, "", 10)
+			if err != nil || len(queued) != 1 {
+				t.Fatalf("ListWebhooks = %d webhooks, %v; want 1", len(queued), err)
+			}
+			var payload SubscriptionEvent
+			if err := json.Unmarshal(queued[0].Payload, &payload); err != nil || payload.EventID != "evt_reminder" {
+				t.Fatalf("payload = %s, %v; want evt_reminder", queued[0].Payload, err)
+			}
+			if tt.delay > 0 && !queued[0].NextAttemptAt.Equal(at.UTC()) {
+				t.Errorf("next attempt = %v, want %v", queued[0].NextAttemptAt, at.UTC())
+			}
+
+			ready, err := store.DequeueWebhooks(ctx, 10)
+			if err != nil {
+				t.Fatalf("DequeueWebhooks: %v", err)
+			}
+			if got := len(ready) == 1; got != tt.wantReady {
+				t.Errorf("ready = %v, want %v", got, tt.wantReady)
+			}
+		})
+	}
+}
@@ -1,18 +1,20 @@
 package callbacks
 
 import (
-	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
-	"net/http"
+	"math/rand/v2"
 	"strings"
-	"text/template"
+	"sync"
 	"time"
 
 	"github.com/CedrosPay/server/internal/config"
-	"github.com/CedrosPay/server/internal/httputil"
+	"github.com/CedrosPay/server/internal/eventbus"
 	"github.com/CedrosPay/server/internal/metrics"
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/internal/tenant"
 	"github.com/rs/zerolog"
 )
 
@@ -22,7 +24,11 @@ type RetryConfig struct {
 	InitialInterval time.Duration // Initial backoff interval (default: 1s)
 	MaxInterval     time.Duration // Maximum backoff interval (default: 5m)
 	Multiplier      float64       // Backoff multiplier (default: 2.0)
+	Jitter          float64       // Fraction (0-1) of each delay that is random (default: 0)
 	Timeout         time.Duration // Per-attempt timeout (default: 10s)
+
+	// Schedule is a fixed delay before each retry, used instead of the exponential backoff
+	Schedule []time.Duration
 }
 
 // DefaultRetryConfig returns sensible defaults for webhook retries.
@@ -36,15 +42,77 @@ func DefaultRetryConfig() RetryConfig {
 	}
 }
 
+// NewRetryConfig converts retry settings from config, with attempts timing out after timeout
+// (default: 10s). A fixed schedule sets MaxAttempts to one more than its length.
+func NewRetryConfig(cfg config.RetryConfig, timeout time.Duration) RetryConfig {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	retry := RetryConfig{
+		MaxAttempts:     cfg.MaxAttempts,
+		InitialInterval: cfg.InitialInterval.Duration,
+		MaxInterval:     cfg.MaxInterval.Duration,
+		Multiplier:      cfg.Multiplier,
+		Jitter:          cfg.Jitter,
+		Timeout:         timeout,
+	}
+	for _, delay := range cfg.Schedule {
+		retry.Schedule = append(retry.Schedule, delay.Duration)
+	}
+	if len(retry.Schedule) > 0 {
+		retry.MaxAttempts = len(retry.Schedule) + 1
+	}
+	return retry
+}
+
+// backoff returns the delay before retrying after failed attempt n (from 1): the schedule's nth
+// delay (its last once attempts run past it), or InitialInterval grown by Multiplier per attempt
+// up to MaxInterval. Jitter takes a random part of the delay off.
+func (r RetryConfig) backoff(attempt int) time.Duration {
+	attempt = max(attempt, 1)
+	var delay time.Duration
+	if len(r.Schedule) > 0 {
+		delay = r.Schedule[min(attempt, len(r.Schedule))-1]
+	} else {
+		delay = r.InitialInterval
+		for i := 1; i < attempt; i++ {
+			delay = time.Duration(float64(delay) * r.Multiplier)
+			if delay > r.MaxInterval {
+				delay = r.MaxInterval
+				break
+			}
+		}
+	}
+	if r.Jitter > 0 {
+		delay -= time.Duration(r.Jitter * rand.Float64() * float64(delay))
+	}
+	return delay
+}
+
+// schedule returns the delay before each of the MaxAttempts-1 retries, with jitter applied.
+func (r RetryConfig) schedule() []time.Duration {
+	delays := make([]time.Duration, 0, max(r.MaxAttempts-1, 0))
+	for attempt := 1; attempt < r.MaxAttempts; attempt++ {
+		delays = append(delays, r.backoff(attempt))
+	}
+	return delays
+}
+
 // RetryableClient posts payment events with exponential backoff retry logic.
 type RetryableClient struct {
 	cfg        config.CallbacksConfig
 	retryCfg   RetryConfig
-	httpClient *http.Client
+	sender     webhookSender // Delivers over HTTP, or to SQS/SNS for ARNs
 	logger     zerolog.Logger
-	tmpl       *template.Template
-	dlqStore   DLQStore         // Dead Letter Queue for failed webhooks
-	metrics    *metrics.Metrics // Prometheus metrics collector
+	dest       destination            // Body settings for PaymentSuccessURL
+	dlqStore   DLQStore               // Dead Letter Queue for failed webhooks
+	metrics    *metrics.Metrics       // Prometheus metrics collector
+	aws        *AWSTransport          // Delivers to SQS/SNS when PaymentSuccessURL is an ARN
+	bus        *eventbus.Bus          // Publishes webhook.failed events for merchant dashboards
+	tls        map[string]*tls.Config // Per-destination TLS settings, by URL
+	deliveries DeliveryLog            // Records every delivery attempt
+	limit      *destinationLimit      // max_in_flight and requests_per_second for PaymentSuccessURL
+	inflight   sync.WaitGroup         // Deliveries still retrying, awaited by Drain
 }
 
 // DLQStore persists failed webhook attempts for manual retry or analysis.
@@ -60,7 +128,7 @@ type FailedWebhook struct {
 	URL         string            `json:"url"`
 	Payload     json.RawMessage   `json:"payload"`
 	Headers     map[string]string `json:"headers"`
-	EventType   string            `json:"eventType"` // "payment" or "refund"
+	EventType   string            `json:"eventType"` // "payment", "payment_failed", "refund", "subscription", or "refund_request"
 	Attempts    int               `json:"attempts"`
 	LastError   string            `json:"lastError"`
 	LastAttempt time.Time         `json:"lastAttempt"`
@@ -98,6 +166,37 @@ func WithMetrics(metrics *metrics.Metrics) RetryOption {
 	}
 }
 
+// WithAWSTransport enables delivery to SQS queues and SNS topics.
+// Required when PaymentSuccessURL is an SQS or SNS ARN.
+func WithAWSTransport(transport *AWSTransport) RetryOption {
+	return func(c *RetryableClient) {
+		c.aws = transport
+	}
+}
+
+// WithEventBus publishes a webhook.failed event when a webhook exhausts its retries.
+func WithEventBus(bus *eventbus.Bus) RetryOption {
+	return func(c *RetryableClient) {
+		c.bus = bus
+	}
+}
+
+// WithTLS applies per-destination TLS settings loaded by LoadTLS, for receivers that require
+// mutual TLS or use a private CA.
+func WithTLS(configs map[string]*tls.Config) RetryOption {
+	return func(c *RetryableClient) {
+		c.tls = configs
+	}
+}
+
+// WithDeliveryLog records every delivery attempt (status code, latency, start of the
+// response) in log.
+func WithDeliveryLog(log DeliveryLog) RetryOption {
+	return func(c *RetryableClient) {
+		c.deliveries = log
+	}
+}
+
 // NewRetryableClient constructs a callback client with retry support.
 func NewRetryableClient(cfg config.CallbacksConfig, opts ...RetryOption) Notifier {
 	if cfg.PaymentSuccessURL == "" {
@@ -110,24 +209,23 @@ func NewRetryableClient(cfg config.CallbacksConfig, opts ...RetryOption) Notifie
 	}
 
 	client := &RetryableClient{
-		cfg:        cfg,
-		retryCfg:   DefaultRetryConfig(),
-		httpClient: httputil.NewClient(timeout),
-		logger:     zerolog.Nop(), // No-op logger by default
+		cfg:      cfg,
+		retryCfg: DefaultRetryConfig(),
+		logger:   zerolog.Nop(), // No-op logger by default
+		limit:    newDestinationLimit(cfg.MaxInFlight, cfg.RequestsPerSecond),
 	}
 
 	for _, opt := range opts {
 		opt(client)
 	}
+	// Same delivery path as the persistent queue, so every destination type works for both
+	client.sender = newWebhookSender(timeout, client.tls, client.aws)
 
-	if cfg.BodyTemplate != "" {
-		tmpl, err := template.New("callback").Parse(cfg.BodyTemplate)
-		if err != nil {
-			client.logger.Error().Err(err).Msg("callbacks: failed to parse template")
-		} else {
-			client.tmpl = tmpl
-		}
+	dest, err := newDestination(cfg.PaymentSuccessURL, cfg.Headers, cfg.Body, cfg.BodyTemplate, cfg.BodyTemplates, nil)
+	if err != nil {
+		client.logger.Error().Err(err).Msg("callbacks: failed to parse template")
 	}
+	client.dest = dest
 
 	return client
 }
@@ -143,22 +241,34 @@ func (c *RetryableClient) PaymentSucceeded(ctx context.Context, event PaymentEve
 	// This ensures the same EventID is used for all retry attempts
 	PreparePaymentEvent(&event)
 
+	tenantID := tenant.FromContext(ctx)
+	// Detach from the request's cancellation but keep its trace so deliveries show up under it
+	sendCtx := context.WithoutCancel(ctx)
+	c.inflight.Add(1)
 	go func() {
-		payload, err := c.serializePayment(event)
+		defer c.inflight.Done()
+		payload, err := c.dest.render("payment", event)
 		if err != nil {
 			c.logger.Error().Err(err).Msg("callbacks: failed to serialize payment event")
 			return
 		}
 
-		if err := c.sendWithRetry(context.Background(), payload, "payment"); err != nil {
+		if err := c.sendWithRetry(sendCtx, payload, "payment", event.EventID); err != nil {
 			c.logger.Error().
 				Err(err).
 				Str("event_id", event.EventID).
 				Msg("callbacks: payment webhook failed after all retries")
 			// Save to DLQ if configured
 			if c.dlqStore != nil {
-				c.saveToDLQ(context.Background(), payload, "payment", err)
+				c.saveToDLQ(sendCtx, payload, "payment", err)
 			}
+			publishWebhookFailure(c.bus, tenantID, WebhookFailure{
+				EventID:   event.EventID,
+				EventType: "payment",
+				URL:       c.cfg.PaymentSuccessURL,
+				Attempts:  c.attemptLimit(),
+				Error:     err.Error(),
+			})
 		}
 	}()
 }
@@ -174,67 +284,179 @@ func (c *RetryableClient) RefundSucceeded(ctx context.Context, event RefundEvent
 	// This ensures the same EventID is used for all retry attempts
 	PrepareRefundEvent(&event)
 
+	tenantID := tenant.FromContext(ctx)
+	sendCtx := context.WithoutCancel(ctx)
+	c.inflight.Add(1)
 	go func() {
-		payload, err := c.serializeRefund(event)
+		defer c.inflight.Done()
+		payload, err := c.dest.render("refund", event)
 		if err != nil {
 			c.logger.Error().Err(err).Msg("callbacks: failed to serialize refund event")
 			return
 		}
 
-		if err := c.sendWithRetry(context.Background(), payload, "refund"); err != nil {
+		if err := c.sendWithRetry(sendCtx, payload, "refund", event.EventID); err != nil {
 			c.logger.Error().
 				Err(err).
 				Str("event_id", event.EventID).
 				Msg("callbacks: refund webhook failed after all retries")
 			// Save to DLQ if configured
 			if c.dlqStore != nil {
-				c.saveToDLQ(context.Background(), payload, "refund", err)
+				c.saveToDLQ(sendCtx, payload, "refund", err)
 			}
+			publishWebhookFailure(c.bus, tenantID, WebhookFailure{
+				EventID:   event.EventID,
+				EventType: "refund",
+				URL:       c.cfg.PaymentSuccessURL,
+				Attempts:  c.attemptLimit(),
+				Error:     err.Error(),
+			})
 		}
 	}()
 }
 
-// serializePayment converts a payment event to JSON payload.
-func (c *RetryableClient) serializePayment(event PaymentEvent) ([]byte, error) {
-	if c.cfg.Body != "" {
-		return []byte(c.cfg.Body), nil
+// SubscriptionChanged dispatches the subscription event asynchronously with retry logic.
+// IMPORTANT: EventID is generated once and preserved across all retry attempts for idempotency.
+func (c *RetryableClient) SubscriptionChanged(ctx context.Context, event SubscriptionEvent) {
+	if c == nil || c.cfg.PaymentSuccessURL == "" {
+		return
 	}
-	if c.tmpl != nil {
-		var buf bytes.Buffer
-		if err := c.tmpl.Execute(&buf, event); err != nil {
-			return nil, fmt.Errorf("execute template: %w", err)
+
+	PrepareSubscriptionEvent(&event)
+
+	tenantID := tenant.FromContext(ctx)
+	sendCtx := context.WithoutCancel(ctx)
+	c.inflight.Add(1)
+	go func() {
+		defer c.inflight.Done()
+		payload, err := c.dest.render("subscription", event)
+		if err != nil {
+			c.logger.Error().Err(err).Msg("callbacks: failed to serialize subscription event")
+			return
 		}
-		return buf.Bytes(), nil
+
+		if err := c.sendWithRetry(sendCtx, payload, "subscription", event.EventID); err != nil {
+			c.logger.Error().
+				Err(err).
+				Str("event_id", event.EventID).
+				Msg("callbacks: subscription webhook failed after all retries")
+			// Save to DLQ if configured
+			if c.dlqStore != nil {
+				c.saveToDLQ(sendCtx, payload, "subscription", err)
+			}
+			publishWebhookFailure(c.bus, tenantID, WebhookFailure{
+				EventID:   event.EventID,
+				EventType: "subscription",
+				URL:       c.cfg.PaymentSuccessURL,
+				Attempts:  c.attemptLimit(),
+				Error:     err.Error(),
+			})
+		}
+	}()
+}
+
+// RefundRequestChanged dispatches the refund request event asynchronously with retry logic.
+// IMPORTANT: EventID is generated once and preserved across all retry attempts for idempotency.
+func (c *RetryableClient) RefundRequestChanged(ctx context.Context, event RefundRequestEvent) {
+	if c == nil || c.cfg.PaymentSuccessURL == "" {
+		return
 	}
-	return json.Marshal(event)
+
+	PrepareRefundRequestEvent(&event)
+
+	tenantID := tenant.FromContext(ctx)
+	sendCtx := context.WithoutCancel(ctx)
+	c.inflight.Add(1)
+	go func() {
+		defer c.inflight.Done()
+		payload, err := c.dest.render("refund_request", event)
+		if err != nil {
+			c.logger.Error().Err(err).Msg("callbacks: failed to serialize refund request event")
+			return
+		}
+
+		if err := c.sendWithRetry(sendCtx, payload, "refund_request", event.EventID); err != nil {
+			c.logger.Error().
+				Err(err).
+				Str("event_id", event.EventID).
+				Msg("callbacks: refund request webhook failed after all retries")
+			// Save to DLQ if configured
+			if c.dlqStore != nil {
+				c.saveToDLQ(sendCtx, payload, "refund_request", err)
+			}
+			publishWebhookFailure(c.bus, tenantID, WebhookFailure{
+				EventID:   event.EventID,
+				EventType: "refund_request",
+				URL:       c.cfg.PaymentSuccessURL,
+				Attempts:  c.attemptLimit(),
+				Error:     err.Error(),
+			})
+		}
+	}()
 }
 
-// serializeRefund converts a refund event to JSON payload.
-func (c *RetryableClient) serializeRefund(event RefundEvent) ([]byte, error) {
-	if c.cfg.Body != "" {
-		return []byte(c.cfg.Body), nil
+// PaymentFailed dispatches the payment failure event asynchronously with retry logic.
+// IMPORTANT: EventID is generated once and preserved across all retry attempts for idempotency.
+func (c *RetryableClient) PaymentFailed(ctx context.Context, event PaymentFailedEvent) {
+	if c == nil || c.cfg.PaymentSuccessURL == "" {
+		return
 	}
-	if c.tmpl != nil {
-		var buf bytes.Buffer
-		if err := c.tmpl.Execute(&buf, event); err != nil {
-			return nil, fmt.Errorf("execute template: %w", err)
+
+	PreparePaymentFailedEvent(&event)
+
+	tenantID := tenant.FromContext(ctx)
+	sendCtx := context.WithoutCancel(ctx)
+	c.inflight.Add(1)
+	go func() {
+		defer c.inflight.Done()
+		payload, err := c.dest.render("payment_failed", event)
+		if err != nil {
+			c.logger.Error().Err(err).Msg("callbacks: failed to serialize payment failure event")
+			return
 		}
-		return buf.Bytes(), nil
+
+		if err := c.sendWithRetry(sendCtx, payload, "payment_failed", event.EventID); err != nil {
+			c.logger.Error().
+				Err(err).
+				Str("event_id", event.EventID).
+				Msg("callbacks: payment failure webhook failed after all retries")
+			// Save to DLQ if configured
+			if c.dlqStore != nil {
+				c.saveToDLQ(sendCtx, payload, "payment_failed", err)
+			}
+			publishWebhookFailure(c.bus, tenantID, WebhookFailure{
+				EventID:   event.EventID,
+				EventType: "payment_failed",
+				URL:       c.cfg.PaymentSuccessURL,
+				Attempts:  c.attemptLimit(),
+				Error:     err.Error(),
+			})
+		}
+	}()
+}
+
+// Drain waits for in-flight deliveries, including their retries, to finish or until ctx is done.
+// Deliveries still pending at the deadline are lost unless they reach the DLQ first.
+func (c *RetryableClient) Drain(ctx context.Context) error {
+	return waitInflight(ctx, &c.inflight)
+}
+
+// attemptLimit returns how many delivery attempts are made per event.
+func (c *RetryableClient) attemptLimit() int {
+	if !c.cfg.Retry.Enabled {
+		return 1
 	}
-	return json.Marshal(event)
+	return c.retryCfg.MaxAttempts
 }
 
-// sendWithRetry attempts to send the webhook with exponential backoff.
-func (c *RetryableClient) sendWithRetry(ctx context.Context, payload []byte, eventType string) error {
+// sendWithRetry attempts to send the webhook, backing off between attempts.
+func (c *RetryableClient) sendWithRetry(ctx context.Context, payload []byte, eventType, eventID string) error {
 	var lastErr error
-	interval := c.retryCfg.InitialInterval
 	startTime := time.Now()
 
 	// If retries are disabled, only attempt once
 	if !c.cfg.Retry.Enabled {
-		reqCtx, cancel := context.WithTimeout(ctx, c.retryCfg.Timeout)
-		err := c.sendHTTP(reqCtx, payload)
-		cancel()
+		err := c.attempt(ctx, payload, eventType, eventID, 1)
 		if c.metrics != nil {
 			status := "success"
 			if err != nil {
@@ -246,9 +468,7 @@ func (c *RetryableClient) sendWithRetry(ctx context.Context, payload []byte, eve
 	}
 
 	for attempt := 1; attempt <= c.retryCfg.MaxAttempts; attempt++ {
-		reqCtx, cancel := context.WithTimeout(ctx, c.retryCfg.Timeout)
-		err := c.sendHTTP(reqCtx, payload)
-		cancel()
+		err := c.attempt(ctx, payload, eventType, eventID, attempt)
 
 		if err == nil {
 			duration := time.Since(startTime)
@@ -268,22 +488,18 @@ func (c *RetryableClient) sendWithRetry(ctx context.Context, payload []byte, eve
 		}
 
 		lastErr = err
+		delay := c.retryCfg.backoff(attempt)
 		c.logger.Warn().
 			Err(err).
 			Int("attempt", attempt).
 			Int("maxAttempts", c.retryCfg.MaxAttempts).
 			Str("eventType", eventType).
-			Dur("nextRetry", interval).
+			Dur("nextRetry", delay).
 			Msg("callbacks: webhook attempt failed")
 
 		// Don't sleep after the last attempt
 		if attempt < c.retryCfg.MaxAttempts {
-			time.Sleep(interval)
-			// Exponential backoff with max cap
-			interval = time.Duration(float64(interval) * c.retryCfg.Multiplier)
-			if interval > c.retryCfg.MaxInterval {
-				interval = c.retryCfg.MaxInterval
-			}
+			time.Sleep(delay)
 		}
 	}
 
@@ -297,40 +513,38 @@ func (c *RetryableClient) sendWithRetry(ctx context.Context, payload []byte, eve
 	return fmt.Errorf("webhook failed after %d attempts: %w", c.retryCfg.MaxAttempts, lastErr)
 }
 
-// sendHTTP performs the actual HTTP request.
-func (c *RetryableClient) sendHTTP(ctx context.Context, payload []byte) error {
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.PaymentSuccessURL, bytes.NewReader(payload))
+// attempt makes one timed delivery attempt and records it in the delivery log.
+func (c *RetryableClient) attempt(ctx context.Context, payload []byte, eventType, eventID string, attempt int) error {
+	release, err := c.limit.acquire(ctx)
 	if err != nil {
-		return fmt.Errorf("build request: %w", err)
-	}
-
-	contentType := c.cfg.Headers["Content-Type"]
-	if contentType == "" {
-		contentType = "application/json"
-	}
-	req.Header.Set("Content-Type", contentType)
-
-	for k, v := range c.cfg.Headers {
-		if k == "" {
-			continue
-		}
-		if strings.EqualFold(k, "content-type") {
-			continue
-		}
-		req.Header.Set(k, v)
-	}
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("send request: %w", err)
+		return err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		return fmt.Errorf("received status %d from %s", resp.StatusCode, c.cfg.PaymentSuccessURL)
+	defer release()
+
+	reqCtx, cancel := context.WithTimeout(ctx, c.retryCfg.Timeout)
+	start := time.Now()
+	resp, err := c.send(reqCtx, payload, eventType, eventID)
+	latency := time.Since(start)
+	cancel()
+
+	if c.deliveries != nil {
+		record := deliveryRecord(c.cfg.PaymentSuccessURL, eventType, attempt, resp, latency, err)
+		record.EventID = eventID
+		recordDelivery(ctx, c.deliveries, c.logger, record)
 	}
+	return err
+}
 
-	return nil
+// send delivers the payload to PaymentSuccessURL through the shared webhook sender, which
+// routes SQS/SNS ARNs to the AWS transport.
+func (c *RetryableClient) send(ctx context.Context, payload []byte, eventType, eventID string) (deliveryResponse, error) {
+	return c.sender.sendWebhook(ctx, storage.PendingWebhook{
+		ID:        eventID, // Stable across retries, so FIFO targets deduplicate redeliveries
+		URL:       c.cfg.PaymentSuccessURL,
+		Payload:   payload,
+		Headers:   c.cfg.Headers,
+		EventType: eventType,
+	})
 }
 
 // saveToDLQ persists a failed webhook to the dead letter queue.
@@ -365,7 +579,8 @@ func (c *RetryableClient) saveToDLQ(ctx context.Context, payload []byte, eventTy
 	}
 }
 
-// generateWebhookID creates a unique identifier for failed webhooks.
+// generateWebhookID creates a unique identifier for failed webhooks. Random rather than
+// time-based, since several destinations can fail the same event at once.
 func generateWebhookID() string {
-	return fmt.Sprintf("webhook_%d", time.Now().UnixNano())
+	return "webhook_" + strings.TrimPrefix(generateEventID(), "evt_")
 }
@@ -69,6 +69,39 @@ func TestRetryableClient_SuccessFirstAttempt(t *testing.T) {
 	}
 }
 
+func TestRetryableClient_Drain(t *testing.T) {
+	release := make(chan struct{})
+	var delivered atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		delivered.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewRetryableClient(config.CallbacksConfig{
+		PaymentSuccessURL: server.URL,
+		Timeout:           config.Duration{Duration: 3 * time.Second},
+	}, WithRetryLogger(zerolog.Nop()))
+	client.PaymentSucceeded(context.Background(), PaymentEvent{ResourceID: "test-resource"})
+
+	// Delivery blocked: drain times out
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := Drain(ctx, client); err != context.DeadlineExceeded {
+		t.Fatalf("Drain = %v, want deadline exceeded", err)
+	}
+
+	// Delivery completes: drain returns once it has
+	close(release)
+	if err := Drain(context.Background(), NewMultiNotifier(client, NoopNotifier{})); err != nil {
+		t.Fatalf("Drain: %v", err)
+	}
+	if delivered.Load() != 1 {
+		t.Fatalf("delivered = %d, want 1", delivered.Load())
+	}
+}
+
 func TestRetryableClient_RetryAfterFailures(t *testing.T) {
 	// Server that fails first 2 attempts, then succeeds
 	var requestCount atomic.Int32
@@ -467,3 +500,51 @@ func TestNoopDLQStore(t *testing.T) {
 		t.Errorf("NoopDLQStore.DeleteFailedWebhook should not error, got %v", err)
 	}
 }
+
+func TestRetryConfig_Backoff(t *testing.T) {
+	exponential := RetryConfig{MaxAttempts: 5, InitialInterval: time.Second, MaxInterval: 3 * time.Second, Multiplier: 2}
+	fixed := NewRetryConfig(config.RetryConfig{
+		MaxAttempts: 10,
+		Schedule:    []config.Duration{{Duration: 10 * time.Second}, {Duration: time.Minute}, {Duration: time.Hour}},
+	}, 0)
+	if fixed.MaxAttempts != 4 || fixed.Timeout != 10*time.Second {
+		t.Fatalf("NewRetryConfig = %d attempts, %v timeout; want 4 attempts (schedule + 1), 10s", fixed.MaxAttempts, fixed.Timeout)
+	}
+
+	tests := []struct {
+		name    string
+		retry   RetryConfig
+		attempt int
+		want    time.Duration
+	}{
+		{name: "exponential first", retry: exponential, attempt: 1, want: time.Second},
+		{name: "exponential second", retry: exponential, attempt: 2, want: 2 * time.Second},
+		{name: "exponential capped", retry: exponential, attempt: 4, want: 3 * time.Second},
+		{name: "schedule first", retry: fixed, attempt: 1, want: 10 * time.Second},
+		{name: "schedule last", retry: fixed, attempt: 3, want: time.Hour},
+		{name: "past the schedule", retry: fixed, attempt: 7, want: time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.retry.backoff(tt.attempt); got != tt.want {
+				t.Errorf("backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("jitter", func(t *testing.T) {
+		jittered := fixed
+		jittered.Jitter = 0.5
+		for range 100 {
+			schedule := jittered.schedule()
+			if len(schedule) != 3 {
+				t.Fatalf("schedule = %v, want 3 delays", schedule)
+			}
+			for i, base := range fixed.Schedule {
+				if schedule[i] > base || schedule[i] < base/2 {
+					t.Fatalf("delay %d = %v, want between %v and %v", i, schedule[i], base/2, base)
+				}
+			}
+		}
+	})
+}
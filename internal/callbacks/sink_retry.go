@@ -0,0 +1,43 @@
+package callbacks
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// sinkRetryBackoff is the delay before an event sink's first publish retry; it doubles after
+// each failed attempt.
+var sinkRetryBackoff = 250 * time.Millisecond
+
+// publishWithRetry runs publish up to maxAttempts times, each bounded by timeout, with
+// exponential backoff between attempts. Event sinks (NATS, Pub/Sub) share it so their
+// delivery guarantees stay the same.
+func publishWithRetry(ctx context.Context, sink string, maxAttempts int, timeout time.Duration, logger zerolog.Logger, publish func(ctx context.Context) error) error {
+	var lastErr error
+	backoff := sinkRetryBackoff
+
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := publish(attemptCtx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+
+		lastErr = err
+		logger.Warn().
+			Err(err).
+			Int("attempt", attempt).
+			Msgf("callbacks: %s publish attempt failed", sink)
+
+		if attempt < maxAttempts {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+
+	return fmt.Errorf("%s publish failed after %d attempts: %w", sink, maxAttempts, lastErr)
+}
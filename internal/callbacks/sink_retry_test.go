@@ -0,0 +1,54 @@
+package callbacks
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestPublishWithRetry(t *testing.T) {
+	prev := sinkRetryBackoff
+	sinkRetryBackoff = time.Millisecond
+	t.Cleanup(func() { sinkRetryBackoff = prev })
+
+	tests := []struct {
+		name         string
+		failures     int
+		maxAttempts  int
+		wantErr      bool
+		wantAttempts int
+	}{
+		{name: "first attempt succeeds", failures: 0, maxAttempts: 3, wantAttempts: 1},
+		{name: "succeeds after retries", failures: 2, maxAttempts: 3, wantAttempts: 3},
+		{name: "gives up after max attempts", failures: 5, maxAttempts: 3, wantErr: true, wantAttempts: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			err := publishWithRetry(context.Background(), "test", tt.maxAttempts, time.Second, zerolog.Nop(), func(ctx context.Context) error {
+				attempts++
+				if _, ok := ctx.Deadline(); !ok {
+					t.Error("attempt context has no deadline")
+				}
+				if attempts <= tt.failures {
+					return errors.New("unavailable")
+				}
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "test publish failed after 3 attempts") {
+				t.Errorf("err = %v", err)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
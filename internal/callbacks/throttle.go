@@ -0,0 +1,78 @@
+package callbacks
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/time/rate"
+)
+
+// destinationLimit bounds deliveries to one destination URL: how many may be in flight at once
+// (max_in_flight) and how many may start per second (requests_per_second), so a slow or
+// rate-limited receiver isn't flooded. A nil *destinationLimit is unlimited.
+type destinationLimit struct {
+	slots   chan struct{} // nil when max_in_flight is unset
+	limiter *rate.Limiter // nil when requests_per_second is unset
+}
+
+// newDestinationLimit returns the limit for maxInFlight and perSecond, or nil when neither is set.
+func newDestinationLimit(maxInFlight int, perSecond float64) *destinationLimit {
+	if maxInFlight <= 0 && perSecond <= 0 {
+		return nil
+	}
+	l := &destinationLimit{}
+	if maxInFlight > 0 {
+		l.slots = make(chan struct{}, maxInFlight)
+	}
+	if perSecond > 0 {
+		l.limiter = rate.NewLimiter(rate.Limit(perSecond), max(1, int(perSecond)))
+	}
+	return l
+}
+
+// acquire waits for a delivery slot and a rate token. The returned func frees the slot once the
+// delivery is done.
+func (l *destinationLimit) acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("wait for delivery slot: %w", ctx.Err())
+		}
+	}
+	if l.limiter != nil {
+		if err := l.limiter.Wait(ctx); err != nil {
+			l.release()
+			return nil, fmt.Errorf("wait for delivery rate limit: %w", err)
+		}
+	}
+	return l.release, nil
+}
+
+// tryAcquire takes a delivery slot and a rate token if both are available now.
+func (l *destinationLimit) tryAcquire() (func(), bool) {
+	if l == nil {
+		return func() {}, true
+	}
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		default:
+			return nil, false
+		}
+	}
+	if l.limiter != nil && !l.limiter.Allow() {
+		l.release()
+		return nil, false
+	}
+	return l.release, true
+}
+
+func (l *destinationLimit) release() {
+	if l.slots != nil {
+		<-l.slots
+	}
+}
@@ -0,0 +1,124 @@
+package callbacks
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func TestDestinationLimit(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxInFlight int
+		perSecond   float64
+		wantNil     bool
+		wantTaken   int // tryAcquire calls that succeed before one fails
+	}{
+		{name: "unlimited", wantNil: true, wantTaken: 5},
+		{name: "max in flight", maxInFlight: 2, wantTaken: 2},
+		{name: "requests per second", perSecond: 1, wantTaken: 1},
+		{name: "burst of whole requests per second", perSecond: 3.5, wantTaken: 3},
+		{name: "both", maxInFlight: 1, perSecond: 10, wantTaken: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit := newDestinationLimit(tt.maxInFlight, tt.perSecond)
+			if (limit == nil) != tt.wantNil {
+				t.Fatalf("newDestinationLimit(%d, %v) = %v, want nil %v", tt.maxInFlight, tt.perSecond, limit, tt.wantNil)
+			}
+			taken := 0
+			for ; taken < 5; taken++ {
+				if _, ok := limit.tryAcquire(); !ok {
+					break
+				}
+			}
+			if taken != tt.wantTaken {
+				t.Errorf("took %d, want %d", taken, tt.wantTaken)
+			}
+		})
+	}
+
+	t.Run("release frees a slot", func(t *testing.T) {
+		limit := newDestinationLimit(1, 0)
+		release, ok := limit.tryAcquire()
+		if !ok {
+			t.Fatal("first tryAcquire failed")
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		if _, err := limit.acquire(ctx); err == nil {
+			t.Fatal("acquire succeeded with the only slot taken")
+		}
+		release()
+		if _, err := limit.acquire(context.Background()); err != nil {
+			t.Fatalf("acquire after release: %v", err)
+		}
+	})
+}
+
+func TestWebhookQueueWorker_ThrottlesPerDestination(t *testing.T) {
+	var slowCalls, slowActive, slowPeak, fastCalls atomic.Int32
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		slowCalls.Add(1)
+		active := slowActive.Add(1)
+		defer slowActive.Add(-1)
+		for {
+			peak := slowPeak.Load()
+			if active <= peak || slowPeak.CompareAndSwap(peak, active) {
+				break
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+	}))
+	defer slow.Close()
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fastCalls.Add(1)
+	}))
+	defer fast.Close()
+
+	store := storage.NewMemoryStore()
+	defer store.Close()
+	w := NewWebhookQueueWorker(WebhookQueueWorkerOptions{
+		Store: store,
+		Config: config.CallbacksConfig{
+			PaymentSuccessURL: fast.URL,
+			Destinations:      []config.CallbackDestinationConfig{{URL: slow.URL, MaxInFlight: 1}},
+		},
+	})
+	ctx := context.Background()
+	for range 3 {
+		if err := w.EnqueuePaymentWebhook(ctx, PaymentEvent{ResourceID: "ebook"}); err != nil {
+			t.Fatalf("EnqueuePaymentWebhook: %v", err)
+		}
+	}
+
+	w.processQueue(ctx)
+
+	if got := fastCalls.Load(); got != 3 {
+		t.Errorf("fast destination received %d webhooks, want 3", got)
+	}
+	if got := slowCalls.Load(); got != 1 {
+		t.Errorf("slow destination received %d webhooks, want 1 (max_in_flight)", got)
+	}
+	if got := slowPeak.Load(); got != 1 {
+		t.Errorf("slow destination peak concurrency = %d, want 1", got)
+	}
+	pending, err := store.DequeueWebhooks(ctx, 10)
+	if err != nil {
+		t.Fatalf("DequeueWebhooks: %v", err)
+	}
+	if len(pending) != 2 {
+		t.Fatalf("%d webhooks still pending, want the 2 throttled ones", len(pending))
+	}
+	for _, webhook := range pending {
+		if webhook.URL != slow.URL || webhook.Attempts != 0 {
+			t.Errorf("pending webhook = %s attempt %d, want an unattempted %s webhook", webhook.URL, webhook.Attempts, slow.URL)
+		}
+	}
+}
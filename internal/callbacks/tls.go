@@ -0,0 +1,71 @@
+package callbacks
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/CedrosPay/server/internal/config"
+)
+
+// LoadTLS reads the client certificates and CA bundles of cfg's webhook destinations, keyed by
+// destination URL. Destinations without tls settings are left out and use the system defaults.
+func LoadTLS(cfg config.CallbacksConfig) (map[string]*tls.Config, error) {
+	configs := make(map[string]*tls.Config)
+	settings := make(map[string]config.CallbackTLSConfig)
+	add := func(url string, settingsForURL config.CallbackTLSConfig) error {
+		if prev, ok := settings[url]; ok && prev != settingsForURL {
+			return fmt.Errorf("destinations for %s have different tls settings", url)
+		}
+		settings[url] = settingsForURL
+		if settingsForURL == (config.CallbackTLSConfig{}) {
+			return nil
+		}
+		tlsConfig, err := loadTLSConfig(settingsForURL)
+		if err != nil {
+			return fmt.Errorf("%s: %w", url, err)
+		}
+		configs[url] = tlsConfig
+		return nil
+	}
+	if cfg.PaymentSuccessURL != "" {
+		if err := add(cfg.PaymentSuccessURL, cfg.TLS); err != nil {
+			return nil, err
+		}
+	}
+	for _, dest := range cfg.Destinations {
+		if err := add(dest.URL, dest.TLS); err != nil {
+			return nil, err
+		}
+	}
+	return configs, nil
+}
+
+// loadTLSConfig builds the TLS settings for one destination: its client certificate, and its
+// CA bundle added to the system roots.
+func loadTLSConfig(cfg config.CallbackTLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read ca bundle: %w", err)
+		}
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("ca bundle %s has no PEM certificates", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = roots
+	}
+	return tlsConfig, nil
+}
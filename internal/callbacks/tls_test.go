@@ -0,0 +1,159 @@
+package callbacks
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/CedrosPay/server/internal/config"
+)
+
+// writeClientCert writes a self-signed client certificate and its key to dir.
+func writeClientCert(t *testing.T, dir string) (certFile, keyFile string, cert *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "cedros-webhooks"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	cert, _ = x509.ParseCertificate(der)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	certFile, keyFile = filepath.Join(dir, "client.pem"), filepath.Join(dir, "client-key.pem")
+	writePEM(t, certFile, "CERTIFICATE", der)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+	return certFile, keyFile, cert
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func TestLoadTLS_MutualTLSDelivery(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, clientCert := writeClientCert(t, dir)
+
+	// A receiver with a certificate from its own CA that only accepts our client certificate
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+	caFile := filepath.Join(dir, "ca.pem")
+	writePEM(t, caFile, "CERTIFICATE", server.Certificate().Raw)
+
+	tests := []struct {
+		name    string
+		tls     config.CallbackTLSConfig
+		wantErr bool
+	}{
+		{name: "client certificate and private ca", tls: config.CallbackTLSConfig{CertFile: certFile, KeyFile: keyFile, CAFile: caFile}},
+		{name: "private ca only", tls: config.CallbackTLSConfig{CAFile: caFile}, wantErr: true},
+		{name: "system defaults", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.CallbacksConfig{
+				Timeout:      config.Duration{Duration: time.Second},
+				Destinations: []config.CallbackDestinationConfig{{URL: server.URL, TLS: tt.tls}},
+			}
+			configs, err := LoadTLS(cfg)
+			if err != nil {
+				t.Fatalf("LoadTLS: %v", err)
+			}
+			dlq := NewMemoryDLQStore()
+			n := NewDestinationNotifier(cfg,
+				WithRetryLogger(zerolog.Nop()),
+				WithDLQStore(dlq),
+				WithTLS(configs),
+				WithRetryConfig(RetryConfig{MaxAttempts: 1, InitialInterval: time.Millisecond, MaxInterval: time.Millisecond, Multiplier: 1, Timeout: time.Second}),
+			)
+			n.PaymentSucceeded(context.Background(), PaymentEvent{ResourceID: "ebook"})
+			if err := Drain(context.Background(), n); err != nil {
+				t.Fatalf("Drain: %v", err)
+			}
+
+			failed, _ := dlq.ListFailedWebhooks(context.Background(), 10)
+			if (len(failed) > 0) != tt.wantErr {
+				t.Fatalf("failed deliveries = %+v, want failure: %v", failed, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadTLS_Errors(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, _ := writeClientCert(t, dir)
+	notPEM := filepath.Join(dir, "ca.txt")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		cfg     config.CallbacksConfig
+		wantErr string
+	}{
+		{
+			name:    "missing key",
+			cfg:     config.CallbacksConfig{PaymentSuccessURL: "https://example.com/hook", TLS: config.CallbackTLSConfig{CertFile: certFile, KeyFile: filepath.Join(dir, "missing.pem")}},
+			wantErr: "load client certificate",
+		},
+		{
+			name:    "ca bundle without certificates",
+			cfg:     config.CallbacksConfig{PaymentSuccessURL: "https://example.com/hook", TLS: config.CallbackTLSConfig{CAFile: notPEM}},
+			wantErr: "no PEM certificates",
+		},
+		{
+			name: "same url with different settings",
+			cfg: config.CallbacksConfig{
+				PaymentSuccessURL: "https://example.com/hook",
+				Destinations:      []config.CallbackDestinationConfig{{URL: "https://example.com/hook", TLS: config.CallbackTLSConfig{CertFile: certFile, KeyFile: keyFile}}},
+			},
+			wantErr: "different tls settings",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadTLS(tt.cfg); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("LoadTLS error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
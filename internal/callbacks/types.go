@@ -1,31 +1,42 @@
 package callbacks
 
 import (
-	"bytes"
 	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"net/http"
 	"time"
 
 	"github.com/CedrosPay/server/internal/config"
-	"github.com/CedrosPay/server/internal/httputil"
+	"github.com/CedrosPay/server/internal/storage"
 )
 
 // Notifier delivers payment events to user-defined callbacks.
 type Notifier interface {
 	PaymentSucceeded(ctx context.Context, event PaymentEvent)
 	RefundSucceeded(ctx context.Context, event RefundEvent)
+	SubscriptionChanged(ctx context.Context, event SubscriptionEvent)
+	RefundRequestChanged(ctx context.Context, event RefundRequestEvent)
+	PaymentFailed(ctx context.Context, event PaymentFailedEvent)
+}
+
+// Scheduler queues events for delivery at a later time instead of right away, e.g. a renewal
+// reminder a few days before a subscription's period ends. A time already past delivers now.
+type Scheduler interface {
+	SchedulePayment(ctx context.Context, event PaymentEvent, at time.Time) error
+	ScheduleSubscription(ctx context.Context, event SubscriptionEvent, at time.Time) error
 }
 
 // NoopNotifier ignores all events.
 type NoopNotifier struct{}
 
-func (NoopNotifier) PaymentSucceeded(context.Context, PaymentEvent) {}
-func (NoopNotifier) RefundSucceeded(context.Context, RefundEvent)   {}
+func (NoopNotifier) PaymentSucceeded(context.Context, PaymentEvent)           {}
+func (NoopNotifier) RefundSucceeded(context.Context, RefundEvent)             {}
+func (NoopNotifier) SubscriptionChanged(context.Context, SubscriptionEvent)   {}
+func (NoopNotifier) RefundRequestChanged(context.Context, RefundRequestEvent) {}
+func (NoopNotifier) PaymentFailed(context.Context, PaymentFailedEvent)        {}
 
 // PaymentEvent encapsulates the essential information about a completed payment.
 // IMPORTANT: EventID is the idempotency key - webhook consumers MUST use this to prevent duplicate processing.
@@ -36,18 +47,44 @@ type PaymentEvent struct {
 	EventTimestamp time.Time `json:"eventTimestamp"` // ISO8601 timestamp when event was created (UTC)
 
 	// Payment details
-	ResourceID         string            `json:"resource"`
-	Method             string            `json:"method"` // "stripe" or "x402"
-	StripeSessionID    string            `json:"stripeSessionId,omitempty"`
-	StripeCustomer     string            `json:"stripeCustomer,omitempty"`
-	FiatAmountCents    int64             `json:"fiatAmountCents,omitempty"`
-	FiatCurrency       string            `json:"fiatCurrency,omitempty"`
-	CryptoAtomicAmount int64             `json:"cryptoAtomicAmount,omitempty"`
-	CryptoToken        string            `json:"cryptoToken,omitempty"`
-	Wallet             string            `json:"wallet,omitempty"`
-	ProofSignature     string            `json:"proofSignature,omitempty"`
-	Metadata           map[string]string `json:"metadata,omitempty"`
-	PaidAt             time.Time         `json:"paidAt"`
+	ResourceID            string            `json:"resource"`
+	Method                string            `json:"method"` // "stripe" or "x402"
+	StripeSessionID       string            `json:"stripeSessionId,omitempty"`
+	StripePaymentIntentID string            `json:"stripePaymentIntentId,omitempty"` // Payment Element payments
+	StripeInvoiceID       string            `json:"stripeInvoiceId,omitempty"`       // One-off invoice payments
+	StripeCustomer        string            `json:"stripeCustomer,omitempty"`
+	FiatAmountCents       int64             `json:"fiatAmountCents,omitempty"`
+	FiatTaxCents          int64             `json:"fiatTaxCents,omitempty"` // Stripe Tax amount included in FiatAmountCents
+	FiatCurrency          string            `json:"fiatCurrency,omitempty"`
+	CryptoAtomicAmount    int64             `json:"cryptoAtomicAmount,omitempty"`
+	CryptoToken           string            `json:"cryptoToken,omitempty"`
+	Wallet                string            `json:"wallet,omitempty"`
+	ProofSignature        string            `json:"proofSignature,omitempty"`
+	Metadata              map[string]string `json:"metadata,omitempty"`
+	PaidAt                time.Time         `json:"paidAt"`
+}
+
+// PaymentFailedEvent describes a payment attempt that was rejected or did not complete, so
+// merchants can follow up on failed or abandoned payments.
+// IMPORTANT: EventID is the idempotency key - webhook consumers MUST use this to prevent duplicate processing.
+type PaymentFailedEvent struct {
+	// Idempotency and event metadata (ALWAYS present)
+	EventID        string    `json:"eventId"`        // Unique event identifier for idempotency (e.g., "evt_abc123")
+	EventType      string    `json:"eventType"`      // Always "payment.failed" for this event
+	EventTimestamp time.Time `json:"eventTimestamp"` // ISO8601 timestamp when event was created (UTC)
+
+	// Failure details
+	ResourceID            string            `json:"resource"` // Resource or cart ID
+	Method                string            `json:"method"`   // "stripe" or "x402"
+	ErrorCode             string            `json:"errorCode"`
+	ErrorMessage          string            `json:"errorMessage,omitempty"`
+	StripeSessionID       string            `json:"stripeSessionId,omitempty"`
+	StripePaymentIntentID string            `json:"stripePaymentIntentId,omitempty"`
+	StripeCustomer        string            `json:"stripeCustomer,omitempty"`
+	Wallet                string            `json:"wallet,omitempty"` // Paying wallet, when known
+	ProofSignature        string            `json:"proofSignature,omitempty"`
+	Metadata              map[string]string `json:"metadata,omitempty"`
+	FailedAt              time.Time         `json:"failedAt"`
 }
 
 // RefundEvent encapsulates the essential information about a completed refund.
@@ -71,6 +108,78 @@ type RefundEvent struct {
 	RefundedAt         time.Time         `json:"refundedAt"`
 }
 
+// Refund request event types, sent when the pending refund policy acts on a request that
+// was never approved or denied.
+const (
+	RefundRequestAutoDenied = "refund.auto_denied"
+	RefundRequestEscalated  = "refund.escalated"
+)
+
+// RefundRequestEvent describes a decision on a refund request that has not been executed.
+// IMPORTANT: EventID is the idempotency key - webhook consumers MUST use this to prevent duplicate processing.
+type RefundRequestEvent struct {
+	// Idempotency and event metadata (ALWAYS present)
+	EventID        string    `json:"eventId"`        // Unique event identifier for idempotency (e.g., "evt_abc123")
+	EventType      string    `json:"eventType"`      // "refund.auto_denied" or "refund.escalated"
+	EventTimestamp time.Time `json:"eventTimestamp"` // ISO8601 timestamp when event was created (UTC)
+
+	// Refund request details
+	RefundID           string            `json:"refundId"`
+	OriginalPurchaseID string            `json:"originalPurchaseId"`
+	RecipientWallet    string            `json:"recipientWallet"`
+	AtomicAmount       int64             `json:"atomicAmount"`
+	Token              string            `json:"token"`
+	Reason             string            `json:"reason,omitempty"` // Reason given with the request
+	Metadata           map[string]string `json:"metadata,omitempty"`
+	RequestedAt        time.Time         `json:"requestedAt"`
+	PendingSeconds     int64             `json:"pendingSeconds"` // How long the request was pending
+	ChangedAt          time.Time         `json:"changedAt"`
+}
+
+// Subscription event types, one per lifecycle step that is reported.
+const (
+	SubscriptionCreated   = "subscription.created"
+	SubscriptionRenewed   = "subscription.renewed"
+	SubscriptionCancelled = "subscription.cancelled"
+	SubscriptionPastDue   = "subscription.past_due"
+	SubscriptionPaused    = "subscription.paused"
+	SubscriptionResumed   = "subscription.resumed"
+
+	// SubscriptionGracePeriodExpiring is sent while a past-due subscription still has access,
+	// shortly before its grace period ends; its status is unchanged.
+	SubscriptionGracePeriodExpiring = "subscription.grace_period_expiring"
+
+	// SubscriptionRenewalReminder is scheduled ahead of an x402 subscription's period end,
+	// which the wallet has to renew itself; its status is unchanged.
+	SubscriptionRenewalReminder = "subscription.renewal_reminder"
+)
+
+// SubscriptionEvent describes a step in a subscription's lifecycle: its creation, a renewal,
+// or a move from one status to another.
+// IMPORTANT: EventID is the idempotency key - webhook consumers MUST use this to prevent duplicate processing.
+type SubscriptionEvent struct {
+	// Idempotency and event metadata (ALWAYS present)
+	EventID        string    `json:"eventId"`        // Unique event identifier for idempotency (e.g., "evt_abc123")
+	EventType      string    `json:"eventType"`      // One of the Subscription* event types, e.g. "subscription.created"
+	EventTimestamp time.Time `json:"eventTimestamp"` // ISO8601 timestamp when event was created (UTC)
+
+	// Subscription details
+	SubscriptionID       string            `json:"subscriptionId"`
+	ProductID            string            `json:"productId"`
+	PaymentMethod        string            `json:"paymentMethod"` // "stripe" or "x402"
+	Wallet               string            `json:"wallet,omitempty"`
+	StripeCustomerID     string            `json:"stripeCustomerId,omitempty"`
+	StripeSubscriptionID string            `json:"stripeSubscriptionId,omitempty"`
+	PreviousStatus       string            `json:"previousStatus"` // Empty for subscription.created
+	Status               string            `json:"status"`
+	CurrentPeriodStart   time.Time         `json:"currentPeriodStart"`
+	CurrentPeriodEnd     time.Time         `json:"currentPeriodEnd"`
+	CancelAtPeriodEnd    bool              `json:"cancelAtPeriodEnd"`        // Cancelled, with access until CurrentPeriodEnd
+	GracePeriodEnd       *time.Time        `json:"gracePeriodEnd,omitempty"` // When a past-due subscription loses access
+	Metadata             map[string]string `json:"metadata,omitempty"`
+	ChangedAt            time.Time         `json:"changedAt"`
+}
+
 // ErrCallbackDisabled is returned when callbacks are not configured.
 var ErrCallbackDisabled = errors.New("callbacks: disabled")
 
@@ -109,6 +218,15 @@ func PreparePaymentEvent(event *PaymentEvent) {
 	}
 }
 
+// PreparePaymentFailedEvent ensures PaymentFailedEvent has required idempotency fields set.
+// If EventID is already set, it's preserved (for retries). If not, a new one is generated.
+func PreparePaymentFailedEvent(event *PaymentFailedEvent) {
+	prepareEventFields(&event.EventID, &event.EventType, &event.EventTimestamp, "payment.failed")
+	if event.FailedAt.IsZero() {
+		event.FailedAt = time.Now().UTC()
+	}
+}
+
 // PrepareRefundEvent ensures RefundEvent has required idempotency fields set.
 // If EventID is already set, it's preserved (for retries). If not, a new one is generated.
 func PrepareRefundEvent(event *RefundEvent) {
@@ -118,7 +236,26 @@ func PrepareRefundEvent(event *RefundEvent) {
 	}
 }
 
-// SendOnce sends a payment event webhook without retry logic (for testing/CLI tools).
+// PrepareSubscriptionEvent ensures SubscriptionEvent has required idempotency fields set.
+// If EventID is already set, it's preserved (for retries). If not, a new one is generated.
+func PrepareSubscriptionEvent(event *SubscriptionEvent) {
+	prepareEventFields(&event.EventID, &event.EventType, &event.EventTimestamp, "subscription.changed")
+	if event.ChangedAt.IsZero() {
+		event.ChangedAt = time.Now().UTC()
+	}
+}
+
+// PrepareRefundRequestEvent ensures RefundRequestEvent has required idempotency fields set.
+// If EventID is already set, it's preserved (for retries). If not, a new one is generated.
+func PrepareRefundRequestEvent(event *RefundRequestEvent) {
+	prepareEventFields(&event.EventID, &event.EventType, &event.EventTimestamp, "refund.request_changed")
+	if event.ChangedAt.IsZero() {
+		event.ChangedAt = time.Now().UTC()
+	}
+}
+
+// SendOnce sends a payment event webhook without retry logic (for testing/CLI tools), over
+// HTTP or to the SQS/SNS ARN in PaymentSuccessURL.
 func SendOnce(ctx context.Context, cfg config.CallbacksConfig, event PaymentEvent) error {
 	if cfg.PaymentSuccessURL == "" {
 		return ErrCallbackDisabled
@@ -137,34 +274,24 @@ func SendOnce(ctx context.Context, cfg config.CallbacksConfig, event PaymentEven
 		timeout = 10 * time.Second
 	}
 
-	httpClient := httputil.NewClient(timeout)
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.PaymentSuccessURL, bytes.NewReader(payload))
+	tlsConfigs, err := LoadTLS(cfg)
 	if err != nil {
-		return fmt.Errorf("build request: %w", err)
-	}
-
-	contentType := cfg.Headers["Content-Type"]
-	if contentType == "" {
-		contentType = "application/json"
+		return fmt.Errorf("load tls settings: %w", err)
 	}
-	req.Header.Set("Content-Type", contentType)
-
-	for k, v := range cfg.Headers {
-		if k == "" || k == "Content-Type" {
-			continue
+	var aws *AWSTransport
+	if IsAWSTarget(cfg.PaymentSuccessURL) {
+		if aws, err = NewAWSTransport(ctx, cfg.AWS); err != nil {
+			return fmt.Errorf("init aws transport: %w", err)
 		}
-		req.Header.Set(k, v)
-	}
-
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		return fmt.Errorf("received status %d from %s", resp.StatusCode, cfg.PaymentSuccessURL)
 	}
 
-	return nil
+	sender := newWebhookSender(timeout, tlsConfigs, aws)
+	_, err = sender.sendWebhook(ctx, storage.PendingWebhook{
+		ID:        event.EventID,
+		URL:       cfg.PaymentSuccessURL,
+		Payload:   payload,
+		Headers:   cfg.Headers,
+		EventType: "payment",
+	})
+	return err
 }
@@ -0,0 +1,127 @@
+package circuitbreaker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sony/gobreaker"
+)
+
+// Breaker is a circuit breaker that remembers when it last tripped and can be reset by an
+// operator. gobreaker has no reset, so Reset swaps in a fresh breaker with the same settings.
+type Breaker struct {
+	settings gobreaker.Settings
+
+	mu         sync.Mutex
+	cb         *gobreaker.CircuitBreaker
+	generation int // Bumped by Reset, so the replaced breaker's trips are ignored
+	trips      int
+	lastTrip   time.Time
+	resetAt    time.Time
+}
+
+// Status is a breaker's state for the admin API. Counts cover the breaker's current interval
+// (or, while half-open, the trial requests since it stopped rejecting calls).
+type Status struct {
+	Name                 string     `json:"name"`
+	State                string     `json:"state"` // closed, half-open, or open
+	Requests             uint32     `json:"requests"`
+	TotalFailures        uint32     `json:"totalFailures"`
+	ConsecutiveFailures  uint32     `json:"consecutiveFailures"`
+	ConsecutiveSuccesses uint32     `json:"consecutiveSuccesses"`
+	Trips                int        `json:"trips"`               // Times the breaker opened since startup
+	LastTrip             *time.Time `json:"lastTrip"`            // Nil until the breaker first opens
+	LastReset            *time.Time `json:"lastReset,omitempty"` // Last manual reset
+}
+
+// newBreaker wraps gobreaker with settings.
+func newBreaker(settings gobreaker.Settings) *Breaker {
+	b := &Breaker{settings: settings}
+	b.replace()
+	return b
+}
+
+// replace starts a fresh gobreaker that records each trip to open. Callers hold mu, except
+// newBreaker.
+func (b *Breaker) replace() {
+	b.generation++
+	generation := b.generation
+	settings := b.settings
+	settings.OnStateChange = func(name string, from, to gobreaker.State) {
+		if b.settings.OnStateChange != nil {
+			b.settings.OnStateChange(name, from, to)
+		}
+		if to != gobreaker.StateOpen {
+			return
+		}
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if generation == b.generation {
+			b.trips++
+			b.lastTrip = time.Now().UTC()
+		}
+	}
+	b.cb = gobreaker.NewCircuitBreaker(settings)
+}
+
+// current returns the gobreaker in use.
+func (b *Breaker) current() *gobreaker.CircuitBreaker {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.cb
+}
+
+// Name returns the breaker's name.
+func (b *Breaker) Name() string {
+	return b.settings.Name
+}
+
+// Execute runs fn if the breaker allows it, returning gobreaker.ErrOpenState or
+// gobreaker.ErrTooManyRequests otherwise.
+func (b *Breaker) Execute(fn func() (interface{}, error)) (interface{}, error) {
+	return b.current().Execute(fn)
+}
+
+// State returns the breaker's current state.
+func (b *Breaker) State() gobreaker.State {
+	return b.current().State()
+}
+
+// Counts returns the breaker's counts for its current interval.
+func (b *Breaker) Counts() gobreaker.Counts {
+	return b.current().Counts()
+}
+
+// Reset closes the breaker and clears its counts. Calls already running finish against the
+// previous breaker, and their results are not counted.
+func (b *Breaker) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.replace()
+	b.resetAt = time.Now().UTC()
+}
+
+// Status reports the breaker's state, counts, and trips.
+func (b *Breaker) Status() Status {
+	b.mu.Lock()
+	cb, trips, lastTrip, resetAt := b.cb, b.trips, b.lastTrip, b.resetAt
+	b.mu.Unlock()
+
+	counts := cb.Counts()
+	status := Status{
+		Name:                 b.settings.Name,
+		State:                cb.State().String(),
+		Requests:             counts.Requests,
+		TotalFailures:        counts.TotalFailures,
+		ConsecutiveFailures:  counts.ConsecutiveFailures,
+		ConsecutiveSuccesses: counts.ConsecutiveSuccesses,
+		Trips:                trips,
+	}
+	if !lastTrip.IsZero() {
+		status.LastTrip = &lastTrip
+	}
+	if !resetAt.IsZero() {
+		status.LastReset = &resetAt
+	}
+	return status
+}
@@ -0,0 +1,58 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sony/gobreaker"
+)
+
+func TestBreakerStatusAndReset(t *testing.T) {
+	errFail := errors.New("fail")
+	fail := func() (interface{}, error) { return nil, errFail }
+	succeed := func() (interface{}, error) { return nil, nil }
+
+	tests := []struct {
+		name      string
+		calls     []func() (interface{}, error)
+		reset     bool
+		wantState string
+		wantTrips int
+		wantFails uint32
+	}{
+		{name: "closed", calls: []func() (interface{}, error){succeed, fail}, wantState: "closed", wantFails: 1},
+		{name: "tripped", calls: []func() (interface{}, error){fail, fail}, wantState: "open", wantTrips: 1},
+		{name: "reset after trip", calls: []func() (interface{}, error){fail, fail}, reset: true, wantState: "closed", wantTrips: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBreaker("test", BreakerConfig{MaxRequests: 1, Timeout: time.Hour, ConsecutiveFailures: 2}, nil)
+			for _, call := range tt.calls {
+				_, _ = b.Execute(call)
+			}
+			if tt.reset {
+				b.Reset()
+				if _, err := b.Execute(succeed); err != nil {
+					t.Fatalf("execute after reset: %v", err)
+				}
+			}
+
+			status := b.Status()
+			if status.Name != "test" || status.State != tt.wantState || status.Trips != tt.wantTrips || status.TotalFailures != tt.wantFails {
+				t.Fatalf("status = %+v, want state %s, %d trips, %d failures", status, tt.wantState, tt.wantTrips, tt.wantFails)
+			}
+			if (status.LastTrip != nil) != (tt.wantTrips > 0) {
+				t.Fatalf("lastTrip = %v, want set: %v", status.LastTrip, tt.wantTrips > 0)
+			}
+			if (status.LastReset != nil) != tt.reset {
+				t.Fatalf("lastReset = %v, want set: %v", status.LastReset, tt.reset)
+			}
+			if tt.wantState == "open" {
+				if _, err := b.Execute(succeed); !errors.Is(err, gobreaker.ErrOpenState) {
+					t.Fatalf("execute while open = %v, want ErrOpenState", err)
+				}
+			}
+		})
+	}
+}
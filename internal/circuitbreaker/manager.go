@@ -1,10 +1,10 @@
 package circuitbreaker
 
 import (
-	"fmt"
 	"time"
 
 	"github.com/CedrosPay/server/internal/config"
+	"github.com/rs/zerolog/log"
 	"github.com/sony/gobreaker"
 )
 
@@ -65,34 +65,34 @@ type BreakerConfig struct {
 // NewManagerFromConfig creates a circuit breaker manager from application config.
 func NewManagerFromConfig(cfg config.CircuitBreakerConfig) *Manager {
 	return NewManager(Config{
-		Enabled: cfg.Enabled,
-		SolanaRPC: BreakerConfig{
-			MaxRequests:         cfg.SolanaRPC.MaxRequests,
-			Interval:            cfg.SolanaRPC.Interval.Duration,
-			Timeout:             cfg.SolanaRPC.Timeout.Duration,
-			ConsecutiveFailures: cfg.SolanaRPC.ConsecutiveFailures,
-			FailureRatio:        cfg.SolanaRPC.FailureRatio,
-			MinRequests:         cfg.SolanaRPC.MinRequests,
-		},
-		StripeAPI: BreakerConfig{
-			MaxRequests:         cfg.StripeAPI.MaxRequests,
-			Interval:            cfg.StripeAPI.Interval.Duration,
-			Timeout:             cfg.StripeAPI.Timeout.Duration,
-			ConsecutiveFailures: cfg.StripeAPI.ConsecutiveFailures,
-			FailureRatio:        cfg.StripeAPI.FailureRatio,
-			MinRequests:         cfg.StripeAPI.MinRequests,
-		},
-		Webhook: BreakerConfig{
-			MaxRequests:         cfg.Webhook.MaxRequests,
-			Interval:            cfg.Webhook.Interval.Duration,
-			Timeout:             cfg.Webhook.Timeout.Duration,
-			ConsecutiveFailures: cfg.Webhook.ConsecutiveFailures,
-			FailureRatio:        cfg.Webhook.FailureRatio,
-			MinRequests:         cfg.Webhook.MinRequests,
-		},
+		Enabled:   cfg.Enabled,
+		SolanaRPC: FromServiceConfig(cfg.SolanaRPC),
+		StripeAPI: FromServiceConfig(cfg.StripeAPI),
+		Webhook:   FromServiceConfig(cfg.Webhook),
 	})
 }
 
+// FromServiceConfig converts one service's application config to a BreakerConfig.
+func FromServiceConfig(cfg config.BreakerServiceConfig) BreakerConfig {
+	return BreakerConfig{
+		MaxRequests:         cfg.MaxRequests,
+		Interval:            cfg.Interval.Duration,
+		Timeout:             cfg.Timeout.Duration,
+		ConsecutiveFailures: cfg.ConsecutiveFailures,
+		FailureRatio:        cfg.FailureRatio,
+		MinRequests:         cfg.MinRequests,
+	}
+}
+
+// NewBreaker creates a standalone circuit breaker, for isolating several instances of one
+// service from each other (e.g. individual RPC endpoints). isSuccessful decides which errors
+// do not count as failures; nil counts every error.
+func NewBreaker(name string, cfg BreakerConfig, isSuccessful func(err error) bool) *Breaker {
+	settings := toGobreakerSettings(name, cfg)
+	settings.IsSuccessful = isSuccessful
+	return newBreaker(settings)
+}
+
 // NewManager creates a circuit breaker manager with the given configuration.
 func NewManager(cfg Config) *Manager {
 	m := &Manager{
@@ -202,7 +202,11 @@ func toGobreakerSettings(name string, cfg BreakerConfig) gobreaker.Settings {
 		},
 		OnStateChange: func(name string, from gobreaker.State, to gobreaker.State) {
 			// Log state transitions for observability
-			fmt.Printf("Circuit breaker %s: %s -> %s\n", name, from.String(), to.String())
+			event := log.Info()
+			if to == gobreaker.StateOpen {
+				event = log.Warn()
+			}
+			event.Str("breaker", name).Str("from", from.String()).Str("to", to.String()).Msg("circuit_breaker.state_change")
 		},
 	}
 }
@@ -22,6 +22,7 @@ func Load(path string) (*Config, error) {
 	cfg.applyEnvOverrides()
 
 	if err := cfg.finalize(); err != nil {
+		_ = cfg.Close()
 		return nil, err
 	}
 
@@ -36,6 +37,8 @@ func defaultConfig() *Config {
 			ReadTimeout:  Duration{Duration: 15 * time.Second},
 			WriteTimeout: Duration{Duration: 15 * time.Second},
 			IdleTimeout:  Duration{Duration: 60 * time.Second},
+			DrainTimeout: Duration{Duration: 30 * time.Second},
+			FloatAmounts: true,
 		},
 		Stripe: StripeConfig{
 			Mode:           "test",
@@ -70,6 +73,32 @@ func defaultConfig() *Config {
 			},
 			DLQEnabled: false,
 			DLQPath:    "./data/webhook-dlq.json",
+			NATS: NATSConfig{
+				SubjectPrefix:  "cedros.events",
+				PublishTimeout: Duration{Duration: 5 * time.Second},
+				MaxAttempts:    5,
+			},
+			PubSub: PubSubConfig{
+				PublishTimeout: Duration{Duration: 5 * time.Second},
+				MaxAttempts:    5,
+			},
+		},
+		MerchantEvents: MerchantEventsConfig{
+			BufferSize: 64,
+		},
+		GRPC: GRPCConfig{
+			Address: ":9090",
+		},
+		Tracing: TracingConfig{
+			Protocol:    "grpc",
+			ServiceName: "cedros-pay",
+			SampleRatio: 1.0,
+		},
+		AsyncVerify: AsyncVerifyConfig{
+			Workers:   4,
+			QueueSize: 100,
+			ResultTTL: Duration{Duration: 10 * time.Minute},
+			Timeout:   Duration{Duration: 90 * time.Second},
 		},
 		Monitoring: MonitoringConfig{
 			LowBalanceThreshold: 0.01,
@@ -89,6 +118,10 @@ func defaultConfig() *Config {
 			PerIPLimit:       120,
 			PerIPWindow:      Duration{Duration: 1 * time.Minute},
 		},
+		Metering: MeteringConfig{
+			Asset:              "USDC",
+			SettlementInterval: Duration{Duration: 1 * time.Hour},
+		},
 		APIKey: APIKeyConfig{
 			Enabled: false,
 			Keys:    make(map[string]string),
@@ -97,6 +130,12 @@ func defaultConfig() *Config {
 			CartQuoteTTL:    Duration{Duration: 15 * time.Minute},
 			RefundQuoteTTL:  Duration{Duration: 15 * time.Minute},
 			CleanupInterval: Duration{Duration: 5 * time.Minute},
+
+			SlowQueryThreshold: Duration{Duration: 200 * time.Millisecond},
+			ReplayCache: ReplayCacheConfig{
+				Size:               10000,
+				ExpectedSignatures: 1000000,
+			},
 		},
 		CircuitBreaker: CircuitBreakerConfig{
 			Enabled: true,
@@ -141,7 +180,21 @@ func (c *Config) parseFile(path string) error {
 		return fmt.Errorf("read config file: %w", err)
 	}
 
-	if err := yaml.Unmarshal(data, c); err != nil {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("parse config yaml: %w", err)
+	}
+	if doc.Kind == 0 {
+		return nil // Empty file
+	}
+	if err := c.decodeSecrets(&doc); err != nil {
+		return err
+	}
+	if err := interpolate(&doc, c.resolveSecret); err != nil {
+		_ = c.Close()
+		return err
+	}
+	if err := doc.Decode(c); err != nil {
 		return fmt.Errorf("parse config yaml: %w", err)
 	}
 	return nil
@@ -2,8 +2,11 @@ package config
 
 import (
 	"os"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/CedrosPay/server/internal/money"
 )
 
 func TestLoadConfig_Defaults(t *testing.T) {
@@ -203,6 +206,474 @@ func TestMonitoringThresholdValidation(t *testing.T) {
 	}
 }
 
+func TestStripeTaxValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		stripe  StripeConfig
+		wantErr string
+	}{
+		{name: "automatic tax", stripe: StripeConfig{AutomaticTax: true, TaxBehavior: "inclusive"}},
+		{name: "automatic tax with tax rate", stripe: StripeConfig{AutomaticTax: true, TaxRateID: "txr_123"}, wantErr: "cannot be combined"},
+		{name: "unknown tax behavior", stripe: StripeConfig{TaxBehavior: "included"}, wantErr: "stripe.tax_behavior"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig()
+			cfg.Stripe = tt.stripe
+			err := cfg.validate()
+			if tt.wantErr == "" {
+				// Other sections of the default config may not validate; only Stripe is checked
+				if err != nil && contains(err.Error(), "stripe.") {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRefundPolicyValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		refunds RefundPolicyConfig
+		wantErr string
+	}{
+		{name: "disabled"},
+		{name: "escalate after a week", refunds: RefundPolicyConfig{PendingExpiryDays: 7, PendingExpiryAction: "escalate"}},
+		{name: "negative days", refunds: RefundPolicyConfig{PendingExpiryDays: -1}, wantErr: "pending_expiry_days"},
+		{name: "unknown action", refunds: RefundPolicyConfig{PendingExpiryDays: 7, PendingExpiryAction: "approve"}, wantErr: "pending_expiry_action"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig()
+			cfg.Paywall.Refunds = tt.refunds
+			err := cfg.validate()
+			if tt.wantErr == "" {
+				if err != nil && contains(err.Error(), "paywall.refunds") {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFraudRuleValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		fraud   FraudConfig
+		wantErr string
+	}{
+		{name: "disabled"},
+		{name: "block payments", fraud: FraudConfig{PaymentsPerWallet: VelocityRuleConfig{Max: 10, Window: Duration{Duration: time.Hour}}}},
+		{name: "review refunds", fraud: FraudConfig{RefundsPerWallet: VelocityRuleConfig{Max: 3, Action: "require_review"}}},
+		{name: "negative max", fraud: FraudConfig{QuotesPerIP: VelocityRuleConfig{Max: -1}}, wantErr: "quotes_per_ip.max"},
+		{name: "negative window", fraud: FraudConfig{QuotesPerIP: VelocityRuleConfig{Max: 5, Window: Duration{Duration: -time.Minute}}}, wantErr: "quotes_per_ip.window"},
+		{name: "review payments", fraud: FraudConfig{PaymentsPerWallet: VelocityRuleConfig{Max: 10, Action: "require_review"}}, wantErr: "only supported for refund requests"},
+		{name: "unknown action", fraud: FraudConfig{RefundsPerWallet: VelocityRuleConfig{Max: 3, Action: "ban"}}, wantErr: "refunds_per_wallet.action"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig()
+			cfg.Paywall.Fraud = tt.fraud
+			err := cfg.validate()
+			if tt.wantErr == "" {
+				if err != nil && contains(err.Error(), "paywall.fraud") {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGeoRestrictionValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		geo     GeoRestrictionConfig
+		wantErr string
+	}{
+		{name: "disabled", geo: GeoRestrictionConfig{BlockedCountries: []string{"nowhere"}}},
+		{name: "blocked by header", geo: GeoRestrictionConfig{Enabled: true, BlockedCountries: []string{"KP", "IR"}, CountryHeader: "CF-IPCountry"}},
+		{name: "allowed by database", geo: GeoRestrictionConfig{Enabled: true, AllowedCountries: []string{"US"}, Database: "/etc/cedros/countries.csv"}},
+		{name: "no lists", geo: GeoRestrictionConfig{Enabled: true, CountryHeader: "CF-IPCountry"}, wantErr: "allowed_countries or blocked_countries"},
+		{name: "no country source", geo: GeoRestrictionConfig{Enabled: true, BlockedCountries: []string{"KP"}}, wantErr: "country_header or database"},
+		{name: "lower-case code", geo: GeoRestrictionConfig{Enabled: true, BlockedCountries: []string{"kp"}, CountryHeader: "CF-IPCountry"}, wantErr: "geo_restriction.blocked_countries"},
+		{name: "country name", geo: GeoRestrictionConfig{Enabled: true, AllowedCountries: []string{"USA"}, CountryHeader: "CF-IPCountry"}, wantErr: "geo_restriction.allowed_countries"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig()
+			cfg.GeoRestriction = tt.geo
+			err := cfg.validate()
+			if tt.wantErr == "" {
+				if err != nil && contains(err.Error(), "geo_restriction") {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProxyValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxy   ProxyConfig
+		wantErr string
+	}{
+		{name: "disabled", proxy: ProxyConfig{Upstream: "not a url"}},
+		{name: "valid", proxy: ProxyConfig{Enabled: true, Upstream: "http://localhost:9000", Routes: map[string]string{"GET /reports/*": "premium-report", "/api/{id}": "api-call"}}},
+		{name: "relative upstream", proxy: ProxyConfig{Enabled: true, Upstream: "localhost:9000"}, wantErr: "proxy.upstream"},
+		{name: "bad pattern", proxy: ProxyConfig{Enabled: true, Upstream: "http://localhost:9000", Routes: map[string]string{"FETCH /reports": "premium-report"}}, wantErr: "unknown method"},
+		{name: "no resource", proxy: ProxyConfig{Enabled: true, Upstream: "http://localhost:9000", Routes: map[string]string{"/reports": " "}}, wantErr: "must name a resource"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig()
+			cfg.Proxy = tt.proxy
+			err := cfg.validate()
+			if tt.wantErr == "" {
+				if err != nil && contains(err.Error(), "proxy.") {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMeteringValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		metering func(*MeteringConfig)
+		wantErr  string
+	}{
+		{name: "disabled", metering: func(m *MeteringConfig) { m.Asset = "XYZ" }},
+		{name: "valid", metering: func(m *MeteringConfig) {
+			m.Enabled, m.DepositResources, m.Prices = true, []string{"api-credits"}, map[string]int64{"api-call": 1000}
+		}},
+		{name: "unknown asset", metering: func(m *MeteringConfig) {
+			m.Enabled, m.Asset, m.DepositResources = true, "XYZ", []string{"api-credits"}
+		}, wantErr: "metering.asset"},
+		{name: "no deposit resources", metering: func(m *MeteringConfig) { m.Enabled = true }, wantErr: "metering.deposit_resources"},
+		{name: "zero price", metering: func(m *MeteringConfig) {
+			m.Enabled, m.DepositResources, m.Prices = true, []string{"api-credits"}, map[string]int64{"api-call": 0}
+		}, wantErr: "metering.prices.api-call"},
+		{name: "zero interval", metering: func(m *MeteringConfig) {
+			m.Enabled, m.DepositResources, m.SettlementInterval = true, []string{"api-credits"}, Duration{}
+		}, wantErr: "metering.settlement_interval"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig()
+			tt.metering(&cfg.Metering)
+			err := cfg.validate()
+			if tt.wantErr == "" {
+				if err != nil && contains(err.Error(), "metering.") {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRateLimitBackendValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		rateLimit RateLimitConfig
+		wantErr   string
+	}{
+		{name: "memory"},
+		{name: "postgres", rateLimit: RateLimitConfig{Backend: "postgres", PostgresURL: "postgres://localhost/cedros"}},
+		{name: "postgres without URL", rateLimit: RateLimitConfig{Backend: "postgres"}, wantErr: "rate_limit.postgres_url"},
+		{name: "unknown backend", rateLimit: RateLimitConfig{Backend: "redis"}, wantErr: "rate_limit.backend"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig()
+			cfg.RateLimit = tt.rateLimit
+			err := cfg.validate()
+			if tt.wantErr == "" {
+				if err != nil && contains(err.Error(), "rate_limit") {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRateLimitEndpointValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		endpoints map[string]EndpointRateLimitConfig
+		wantErr   string
+	}{
+		{name: "none"},
+		{name: "any method", endpoints: map[string]EndpointRateLimitConfig{"/paywall/v1/payment-status/{signature}": {Limit: 600}}},
+		{name: "with method", endpoints: map[string]EndpointRateLimitConfig{"post /paywall/v1/quote": {Limit: 20, Window: Duration{Duration: time.Minute}}}},
+		{name: "no leading slash", endpoints: map[string]EndpointRateLimitConfig{"paywall/v1/quote": {Limit: 20}}, wantErr: "must start with /"},
+		{name: "unknown method", endpoints: map[string]EndpointRateLimitConfig{"FETCH /paywall/v1/quote": {Limit: 20}}, wantErr: "unknown method"},
+		{name: "no limit", endpoints: map[string]EndpointRateLimitConfig{"/paywall/v1/quote": {}}, wantErr: "limit must be positive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig()
+			cfg.RateLimit.Endpoints = tt.endpoints
+			err := cfg.validate()
+			if tt.wantErr == "" {
+				if err != nil && contains(err.Error(), "rate_limit") {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAPIKeyScopesValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		scopes  map[string][]string
+		wantErr string
+	}{
+		{name: "none"},
+		{name: "scoped", scopes: map[string][]string{"partner_key": {"quotes:read", "refunds:admin"}}},
+		{name: "unknown key", scopes: map[string][]string{"other_key": {"quotes:read"}}, wantErr: "1 key(s) missing from api_key.keys"},
+		{name: "unknown scope", scopes: map[string][]string{"partner_key": {"quotes:write"}}, wantErr: `unknown scope "quotes:write"`},
+		{name: "no scopes", scopes: map[string][]string{"partner_key": {}}, wantErr: "at least one scope"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig()
+			cfg.APIKey.Keys = map[string]string{"partner_key": "partner"}
+			cfg.APIKey.Scopes = tt.scopes
+			err := cfg.validate()
+			if tt.wantErr == "" {
+				if err != nil && contains(err.Error(), "api_key") {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCallbackDestinationsValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		dest    CallbackDestinationConfig
+		wantErr string
+	}{
+		{name: "all events", dest: CallbackDestinationConfig{URL: "https://example.com/hook"}},
+		{name: "filtered", dest: CallbackDestinationConfig{URL: "https://example.com/hook", Events: []string{"refund", "refund_request"}}},
+		{name: "missing url", dest: CallbackDestinationConfig{Events: []string{"payment"}}, wantErr: "callbacks.destinations[0].url is required"},
+		{name: "unknown event", dest: CallbackDestinationConfig{URL: "https://example.com/hook", Events: []string{"payments"}}, wantErr: `unknown event "payments"`},
+		{name: "bad template", dest: CallbackDestinationConfig{URL: "https://example.com/hook", BodyTemplate: "{{.Resource"}, wantErr: "callbacks.destinations[0].body_template"},
+		{name: "template functions", dest: CallbackDestinationConfig{URL: "https://example.com/hook", BodyTemplate: `{"amount":{{div .FiatAmountCents 100}}}`, BodyTemplates: map[string]string{"refund": `{"id":{{json .RefundID}}}`}}},
+		{name: "unknown template event", dest: CallbackDestinationConfig{URL: "https://example.com/hook", BodyTemplates: map[string]string{"refunds": "{}"}}, wantErr: `callbacks.destinations[0].body_templates: unknown event "refunds"`},
+		{name: "bad event template", dest: CallbackDestinationConfig{URL: "https://example.com/hook", BodyTemplates: map[string]string{"refund": "{{.RefundID"}}, wantErr: "callbacks.destinations[0].body_templates.refund"},
+		{name: "unknown priority", dest: CallbackDestinationConfig{URL: "https://example.com/hook", Priority: "urgent"}, wantErr: `callbacks.destinations[0].priority: unknown priority "urgent"`},
+		{name: "negative max in flight", dest: CallbackDestinationConfig{URL: "https://example.com/hook", MaxInFlight: -1}, wantErr: "callbacks.destinations[0].max_in_flight must not be negative"},
+		{name: "negative requests per second", dest: CallbackDestinationConfig{URL: "https://example.com/hook", RequestsPerSecond: -0.5}, wantErr: "callbacks.destinations[0].requests_per_second must not be negative"},
+		{name: "mutual tls", dest: CallbackDestinationConfig{URL: "https://example.com/hook", TLS: CallbackTLSConfig{CertFile: "client.pem", KeyFile: "client-key.pem", CAFile: "ca.pem"}}},
+		{name: "tls without key", dest: CallbackDestinationConfig{URL: "https://example.com/hook", TLS: CallbackTLSConfig{CertFile: "client.pem"}}, wantErr: "callbacks.destinations[0].tls.cert_file and key_file"},
+		{name: "tls over http", dest: CallbackDestinationConfig{URL: "http://example.com/hook", TLS: CallbackTLSConfig{CAFile: "ca.pem"}}, wantErr: "callbacks.destinations[0].tls requires an https url"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig()
+			cfg.Callbacks.Destinations = []CallbackDestinationConfig{tt.dest}
+			err := cfg.validate()
+			if tt.wantErr == "" {
+				if err != nil && contains(err.Error(), "callbacks.destinations") {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDLQRotationValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  bool
+		rotation DLQRotationConfig
+		offload  DLQOffloadConfig
+		wantErr  string
+	}{
+		{name: "unbounded", enabled: true},
+		{name: "limits", enabled: true, rotation: DLQRotationConfig{MaxBytes: 1 << 20, MaxAge: Duration{Duration: 720 * time.Hour}}},
+		{name: "s3", enabled: true, offload: DLQOffloadConfig{URL: "s3://cedros-dlq/prod"}},
+		{name: "gcs", enabled: true, offload: DLQOffloadConfig{URL: "gs://cedros-dlq"}},
+		{name: "negative size", enabled: true, rotation: DLQRotationConfig{MaxBytes: -1}, wantErr: "callbacks.dlq_rotation.max_bytes"},
+		{name: "negative age", enabled: true, rotation: DLQRotationConfig{MaxAge: Duration{Duration: -time.Hour}}, wantErr: "callbacks.dlq_rotation.max_age"},
+		{name: "unknown scheme", enabled: true, offload: DLQOffloadConfig{URL: "azure://dlq"}, wantErr: "callbacks.dlq_offload.url"},
+		{name: "missing bucket", enabled: true, offload: DLQOffloadConfig{URL: "s3:///prefix"}, wantErr: "callbacks.dlq_offload.url"},
+		{name: "dlq disabled", offload: DLQOffloadConfig{URL: "s3://cedros-dlq"}, wantErr: "requires callbacks.dlq_enabled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig()
+			cfg.Callbacks.DLQEnabled = tt.enabled
+			cfg.Callbacks.DLQRotation = tt.rotation
+			cfg.Callbacks.DLQOffload = tt.offload
+			err := cfg.validate()
+			if tt.wantErr == "" {
+				if err != nil && contains(err.Error(), "callbacks.dlq") {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRetryScheduleValidation(t *testing.T) {
+	schedule := func(delays ...time.Duration) []Duration {
+		var out []Duration
+		for _, d := range delays {
+			out = append(out, Duration{Duration: d})
+		}
+		return out
+	}
+	tests := []struct {
+		name      string
+		retry     RetryConfig
+		destRetry *RetryConfig
+		wantErr   string
+	}{
+		{name: "jitter", retry: RetryConfig{Jitter: 0.2}},
+		{name: "schedule", retry: RetryConfig{Schedule: schedule(time.Second, time.Minute, time.Hour)}},
+		{name: "destination schedule", destRetry: &RetryConfig{Schedule: schedule(30 * time.Second), Jitter: 1}},
+		{name: "jitter above 1", retry: RetryConfig{Jitter: 1.5}, wantErr: "callbacks.retry.jitter must be between 0 and 1"},
+		{name: "negative jitter", destRetry: &RetryConfig{Jitter: -0.1}, wantErr: "callbacks.destinations[0].retry.jitter"},
+		{name: "zero delay", retry: RetryConfig{Schedule: schedule(time.Second, 0)}, wantErr: "callbacks.retry.schedule[1] must be positive"},
+		{name: "negative destination delay", destRetry: &RetryConfig{Schedule: schedule(-time.Second)}, wantErr: "callbacks.destinations[0].retry.schedule[0]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig()
+			cfg.Callbacks.Retry.Jitter = tt.retry.Jitter
+			cfg.Callbacks.Retry.Schedule = tt.retry.Schedule
+			if tt.destRetry != nil {
+				cfg.Callbacks.Destinations = []CallbackDestinationConfig{{URL: "https://example.com/hook", Retry: tt.destRetry}}
+			}
+			err := cfg.validate()
+			if tt.wantErr == "" {
+				if err != nil && contains(err.Error(), "retry") {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScreeningValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		screening ScreeningConfig
+		wantErr   string
+	}{
+		{name: "disabled"},
+		{name: "chainalysis", screening: ScreeningConfig{Provider: "chainalysis", APIKey: "key", FailMode: "open"}},
+		{name: "missing API key", screening: ScreeningConfig{Provider: "chainalysis"}, wantErr: "paywall.screening.api_key"},
+		{name: "unknown provider", screening: ScreeningConfig{Provider: "ofac"}, wantErr: "paywall.screening.provider"},
+		{name: "unknown fail mode", screening: ScreeningConfig{Provider: "chainalysis", APIKey: "key", FailMode: "ajar"}, wantErr: "paywall.screening.fail_mode"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig()
+			cfg.Paywall.Screening = tt.screening
+			err := cfg.validate()
+			if tt.wantErr == "" {
+				if err != nil && contains(err.Error(), "paywall.screening") {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStripeConnectValidation(t *testing.T) {
+	negative, tooHigh := -1.0, 101.0
+	tests := []struct {
+		name     string
+		fee      float64
+		resource PaywallResource
+		wantErr  string
+	}{
+		{name: "connected resource", fee: 10, resource: PaywallResource{StripeConnectedAccount: "acct_123"}},
+		{name: "platform fee out of range", fee: 120, wantErr: "application_fee_percent"},
+		{name: "not an account id", resource: PaywallResource{StripeConnectedAccount: "seller"}, wantErr: "acct_"},
+		{name: "negative resource fee", resource: PaywallResource{StripeApplicationFeePercent: &negative}, wantErr: "stripe_application_fee_percent"},
+		{name: "resource fee out of range", resource: PaywallResource{StripeApplicationFeePercent: &tooHigh}, wantErr: "stripe_application_fee_percent"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig()
+			cfg.Stripe.Connect.ApplicationFeePercent = tt.fee
+			tt.resource.FiatAmountCents = 100
+			cfg.Paywall.Resources = map[string]PaywallResource{"item": tt.resource}
+			err := cfg.validate()
+			if tt.wantErr == "" {
+				// Other sections of the default config may not validate; only Stripe Connect is checked
+				if err != nil && (contains(err.Error(), "stripe") || contains(err.Error(), "acct_")) {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 // Test helpers
 
 func clearEnv() {
@@ -238,3 +709,43 @@ func containsAny(s, substr string) bool {
 	}
 	return false
 }
+
+func TestAssetsRegistration(t *testing.T) {
+	const eurcMint = "HzwqbKZw8HxMN6bF2yFZNrht3c2iXXzpKcFu7uBEDKtr"
+	tests := []struct {
+		name    string
+		asset   AssetConfig
+		wantErr string
+	}{
+		{name: "stablecoin", asset: AssetConfig{Code: "eurc", Decimals: 6, SolanaMint: eurcMint, Symbol: "€", Stablecoin: true}},
+		{name: "fiat", asset: AssetConfig{Code: "GBP", Decimals: 2, Type: "fiat", Symbol: "£"}},
+		{name: "invalid mint", asset: AssetConfig{Code: "BAD", Decimals: 6, SolanaMint: "not-a-mint"}, wantErr: "solana_mint is not a valid address"},
+		{name: "unknown type", asset: AssetConfig{Code: "BAD", Decimals: 6, Type: "erc20"}, wantErr: `type must be "spl" or "fiat"`},
+		{name: "fiat stablecoin", asset: AssetConfig{Code: "BAD", Decimals: 2, Type: "fiat", Stablecoin: true}, wantErr: "only spl assets can be stablecoins"},
+		{name: "built-in with other decimals", asset: AssetConfig{Code: "USDC", Decimals: 9, SolanaMint: "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"}, wantErr: "already registered"},
+		{name: "mint taken by another code", asset: AssetConfig{Code: "USDC2", Decimals: 6, SolanaMint: "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"}, wantErr: "already registered as USDC"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig()
+			cfg.Assets = []AssetConfig{tt.asset}
+			err := cfg.registerAssets()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				asset, err := money.GetAsset(strings.ToUpper(tt.asset.Code))
+				if err != nil || asset.Decimals != tt.asset.Decimals || asset.DisplaySymbol() != tt.asset.Symbol {
+					t.Fatalf("registered asset = %+v, %v", asset, err)
+				}
+				if tt.asset.Stablecoin && validateStablecoinMint(tt.asset.SolanaMint) != nil {
+					t.Errorf("%s is not accepted as x402.token_mint", tt.asset.SolanaMint)
+				}
+				return
+			}
+			if err == nil || !contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
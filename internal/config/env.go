@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/textproto"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -16,6 +17,8 @@ func (c *Config) applyEnvOverrides() {
 	setIfEnv(&c.Server.Address, "CEDROS_SERVER_ADDRESS")
 	setIfEnv(&c.Server.RoutePrefix, "CEDROS_ROUTE_PREFIX")
 	setIfEnv(&c.Server.AdminMetricsAPIKey, "CEDROS_ADMIN_METRICS_API_KEY")
+	setDurationIfEnv(&c.Server.DrainTimeout, "CEDROS_SERVER_DRAIN_TIMEOUT")
+	setBoolIfEnv(&c.Server.FloatAmounts, "CEDROS_SERVER_FLOAT_AMOUNTS")
 
 	// CORS allowed origins (comma-separated list)
 	if corsOrigins := os.Getenv("CORS_ALLOWED_ORIGINS"); corsOrigins != "" {
@@ -25,6 +28,49 @@ func (c *Config) applyEnvOverrides() {
 		}
 	}
 
+	// Stripe payment method types (comma-separated list)
+	if methods := os.Getenv("CEDROS_STRIPE_PAYMENT_METHODS"); methods != "" {
+		c.Stripe.PaymentMethods = strings.Split(methods, ",")
+		for i := range c.Stripe.PaymentMethods {
+			c.Stripe.PaymentMethods[i] = strings.TrimSpace(c.Stripe.PaymentMethods[i])
+		}
+	}
+
+	// Additional pooled RPC endpoints (comma-separated list)
+	if rpcURLs := os.Getenv("CEDROS_X402_RPC_URLS"); rpcURLs != "" {
+		c.X402.RPCURLs = strings.Split(rpcURLs, ",")
+		for i := range c.X402.RPCURLs {
+			c.X402.RPCURLs[i] = strings.TrimSpace(c.X402.RPCURLs[i])
+		}
+	}
+
+	// Geo-restriction (country lists are comma-separated)
+	setBoolIfEnv(&c.GeoRestriction.Enabled, "CEDROS_GEO_RESTRICTION_ENABLED")
+	if countries := os.Getenv("CEDROS_GEO_RESTRICTION_ALLOWED_COUNTRIES"); countries != "" {
+		c.GeoRestriction.AllowedCountries = strings.Split(countries, ",")
+		for i := range c.GeoRestriction.AllowedCountries {
+			c.GeoRestriction.AllowedCountries[i] = strings.TrimSpace(c.GeoRestriction.AllowedCountries[i])
+		}
+	}
+	if countries := os.Getenv("CEDROS_GEO_RESTRICTION_BLOCKED_COUNTRIES"); countries != "" {
+		c.GeoRestriction.BlockedCountries = strings.Split(countries, ",")
+		for i := range c.GeoRestriction.BlockedCountries {
+			c.GeoRestriction.BlockedCountries[i] = strings.TrimSpace(c.GeoRestriction.BlockedCountries[i])
+		}
+	}
+	setIfEnv(&c.GeoRestriction.CountryHeader, "CEDROS_GEO_RESTRICTION_COUNTRY_HEADER")
+	setIfEnv(&c.GeoRestriction.Database, "CEDROS_GEO_RESTRICTION_DATABASE")
+	setBoolIfEnv(&c.GeoRestriction.BlockUnknown, "CEDROS_GEO_RESTRICTION_BLOCK_UNKNOWN")
+
+	// Reverse-proxy mode (routes are only configurable in YAML)
+	setBoolIfEnv(&c.Proxy.Enabled, "CEDROS_PROXY_ENABLED")
+	setIfEnv(&c.Proxy.Upstream, "CEDROS_PROXY_UPSTREAM")
+
+	// Metering (deposit resources and prices are only configurable in YAML)
+	setBoolIfEnv(&c.Metering.Enabled, "CEDROS_METERING_ENABLED")
+	setIfEnv(&c.Metering.Asset, "CEDROS_METERING_ASSET")
+	setDurationIfEnv(&c.Metering.SettlementInterval, "CEDROS_METERING_SETTLEMENT_INTERVAL")
+
 	// Normalize route prefix: ensure it starts with / and doesn't end with /
 	if c.Server.RoutePrefix != "" {
 		c.Server.RoutePrefix = normalizeRoutePrefix(c.Server.RoutePrefix)
@@ -38,6 +84,10 @@ func (c *Config) applyEnvOverrides() {
 	setIfEnv(&c.Stripe.CancelURL, "CEDROS_STRIPE_CANCEL_URL")
 	setIfEnv(&c.Stripe.TaxRateID, "CEDROS_STRIPE_TAX_RATE_ID")
 	setIfEnv(&c.Stripe.Mode, "CEDROS_STRIPE_MODE")
+	setBoolIfEnv(&c.Stripe.AutomaticTax, "CEDROS_STRIPE_AUTOMATIC_TAX")
+	setIfEnv(&c.Stripe.TaxBehavior, "CEDROS_STRIPE_TAX_BEHAVIOR")
+	setFloatIfEnv(&c.Stripe.Connect.ApplicationFeePercent, "CEDROS_STRIPE_CONNECT_APPLICATION_FEE_PERCENT")
+	setDurationIfEnv(&c.Stripe.WebhookDedupTTL, "CEDROS_STRIPE_WEBHOOK_DEDUP_TTL")
 
 	// x402 config
 	setIfEnv(&c.X402.PaymentAddress, "CEDROS_X402_PAYMENT_ADDRESS")
@@ -45,14 +95,37 @@ func (c *Config) applyEnvOverrides() {
 	setIfEnv(&c.X402.Network, "CEDROS_X402_NETWORK")
 	setIfEnv(&c.X402.RPCURL, "CEDROS_X402_RPC_URL")
 	setIfEnv(&c.X402.WSURL, "CEDROS_X402_WS_URL")
+	setDurationIfEnv(&c.X402.RPCHealthCheckInterval, "CEDROS_X402_RPC_HEALTH_CHECK_INTERVAL")
 	setIfEnv(&c.X402.MemoPrefix, "CEDROS_X402_MEMO_PREFIX")
 	setBoolIfEnv(&c.X402.SkipPreflight, "CEDROS_X402_SKIP_PREFLIGHT")
+	setBoolIfEnv(&c.X402.SimulateTransactions, "CEDROS_X402_SIMULATE_TRANSACTIONS")
 	setIfEnv(&c.X402.Commitment, "CEDROS_X402_COMMITMENT")
 	setBoolIfEnv(&c.X402.GaslessEnabled, "CEDROS_X402_GASLESS_ENABLED")
+	setFloatIfEnv(&c.X402.GaslessDailyBudgetSOL, "CEDROS_X402_GASLESS_DAILY_BUDGET_SOL")
+	setBoolIfEnv(&c.X402.PriorityFee.Enabled, "CEDROS_X402_PRIORITY_FEE_ENABLED")
+	setIntIfEnv(&c.X402.PriorityFee.Percentile, "CEDROS_X402_PRIORITY_FEE_PERCENTILE")
+	setUint64IfEnv(&c.X402.PriorityFee.MinMicroLamports, "CEDROS_X402_PRIORITY_FEE_MIN_MICRO_LAMPORTS")
+	setUint64IfEnv(&c.X402.PriorityFee.MaxMicroLamports, "CEDROS_X402_PRIORITY_FEE_MAX_MICRO_LAMPORTS")
+	setDurationIfEnv(&c.X402.PriorityFee.RefreshInterval, "CEDROS_X402_PRIORITY_FEE_REFRESH_INTERVAL")
 	setBoolIfEnv(&c.X402.AutoCreateTokenAccount, "CEDROS_X402_AUTO_CREATE_TOKEN_ACCOUNT")
+	setBoolIfEnv(&c.X402.PrewarmTokenAccounts, "CEDROS_X402_PREWARM_TOKEN_ACCOUNTS")
+	setBoolIfEnv(&c.X402.StrictMemo, "CEDROS_X402_STRICT_MEMO")
+	setIfEnv(&c.X402.CartSettlementToken, "CEDROS_X402_CART_SETTLEMENT_TOKEN")
+	setIfEnv(&c.X402.RateOracle.Provider, "CEDROS_X402_RATE_ORACLE_PROVIDER")
+	setIfEnv(&c.X402.RateOracle.APIURL, "CEDROS_X402_RATE_ORACLE_API_URL")
+	setIfEnv(&c.X402.RateOracle.APIKey, "CEDROS_X402_RATE_ORACLE_API_KEY")
+	setDurationIfEnv(&c.X402.RateOracle.CacheTTL, "CEDROS_X402_RATE_ORACLE_CACHE_TTL")
+	setIfEnv(&c.X402.SquadsMultisig, "CEDROS_X402_SQUADS_MULTISIG")
+	setIntIfEnv(&c.X402.SquadsVaultIndex, "CEDROS_X402_SQUADS_VAULT_INDEX")
+	setIfEnv(&c.X402.RefundNonceAccount, "CEDROS_X402_REFUND_NONCE_ACCOUNT")
+	setDurationIfEnv(&c.X402.RefundNonceQuoteTTL, "CEDROS_X402_REFUND_NONCE_QUOTE_TTL")
+	setIntIfEnv(&c.X402.SpecVersion, "CEDROS_X402_SPEC_VERSION")
+	setBoolIfEnv(&c.X402.Facilitator, "CEDROS_X402_FACILITATOR")
 
 	// Load server wallet keys (X402_SERVER_WALLET_1, X402_SERVER_WALLET_2, ...)
-	c.X402.ServerWalletKeys = loadServerWalletKeys()
+	if keys := loadServerWalletKeys(); len(keys) > 0 {
+		c.X402.ServerWalletKeys = keys
+	}
 
 	// Paywall config
 	setIfEnv(&c.Paywall.ProductSource, "CEDROS_PAYWALL_PRODUCT_SOURCE")
@@ -62,6 +135,24 @@ func (c *Config) applyEnvOverrides() {
 	setIfEnv(&c.Paywall.MongoDBCollection, "CEDROS_PAYWALL_MONGODB_COLLECTION")
 	setDurationIfEnv(&c.Paywall.QuoteTTL, "CEDROS_PAYWALL_QUOTE_TTL")
 	setDurationIfEnv(&c.Paywall.ProductCacheTTL, "CEDROS_PAYWALL_PRODUCT_CACHE_TTL")
+	setIfEnv(&c.Paywall.Shipping.Mode, "CEDROS_PAYWALL_SHIPPING_MODE")
+	setIfEnv(&c.Paywall.Shipping.FlatAmount, "CEDROS_PAYWALL_SHIPPING_FLAT_AMOUNT")
+	setIfEnv(&c.Paywall.Tax.Mode, "CEDROS_PAYWALL_TAX_MODE")
+	setFloatIfEnv(&c.Paywall.Tax.RatePercent, "CEDROS_PAYWALL_TAX_RATE_PERCENT")
+	setIntIfEnv(&c.Paywall.Refunds.PendingExpiryDays, "CEDROS_PAYWALL_REFUNDS_PENDING_EXPIRY_DAYS")
+	setIfEnv(&c.Paywall.Refunds.PendingExpiryAction, "CEDROS_PAYWALL_REFUNDS_PENDING_EXPIRY_ACTION")
+	setDurationIfEnv(&c.Paywall.Refunds.CheckInterval, "CEDROS_PAYWALL_REFUNDS_CHECK_INTERVAL")
+	setIntIfEnv(&c.Paywall.Fraud.PaymentsPerWallet.Max, "CEDROS_PAYWALL_FRAUD_PAYMENTS_PER_WALLET_MAX")
+	setIfEnv(&c.Paywall.Fraud.PaymentsPerWallet.Action, "CEDROS_PAYWALL_FRAUD_PAYMENTS_PER_WALLET_ACTION")
+	setIntIfEnv(&c.Paywall.Fraud.RefundsPerWallet.Max, "CEDROS_PAYWALL_FRAUD_REFUNDS_PER_WALLET_MAX")
+	setIfEnv(&c.Paywall.Fraud.RefundsPerWallet.Action, "CEDROS_PAYWALL_FRAUD_REFUNDS_PER_WALLET_ACTION")
+	setIntIfEnv(&c.Paywall.Fraud.QuotesPerIP.Max, "CEDROS_PAYWALL_FRAUD_QUOTES_PER_IP_MAX")
+	setIfEnv(&c.Paywall.Fraud.QuotesPerIP.Action, "CEDROS_PAYWALL_FRAUD_QUOTES_PER_IP_ACTION")
+	setIfEnv(&c.Paywall.Screening.Provider, "CEDROS_PAYWALL_SCREENING_PROVIDER")
+	setIfEnv(&c.Paywall.Screening.APIURL, "CEDROS_PAYWALL_SCREENING_API_URL")
+	setIfEnv(&c.Paywall.Screening.APIKey, "CEDROS_PAYWALL_SCREENING_API_KEY")
+	setDurationIfEnv(&c.Paywall.Screening.CacheTTL, "CEDROS_PAYWALL_SCREENING_CACHE_TTL")
+	setIfEnv(&c.Paywall.Screening.FailMode, "CEDROS_PAYWALL_SCREENING_FAIL_MODE")
 
 	// Coupon config
 	setIfEnv(&c.Coupons.CouponSource, "COUPON_SOURCE")
@@ -77,11 +168,27 @@ func (c *Config) applyEnvOverrides() {
 
 	// Callbacks config
 	setIfEnv(&c.Callbacks.PaymentSuccessURL, "CALLBACK_PAYMENT_SUCCESS_URL")
+	setIfEnv(&c.Callbacks.TLS.CertFile, "CALLBACK_TLS_CERT_FILE")
+	setIfEnv(&c.Callbacks.TLS.KeyFile, "CALLBACK_TLS_KEY_FILE")
+	setIfEnv(&c.Callbacks.TLS.CAFile, "CALLBACK_TLS_CA_FILE")
 	if v := os.Getenv("CALLBACK_TIMEOUT"); v != "" {
 		if dur, err := time.ParseDuration(v); err == nil {
 			c.Callbacks.Timeout = Duration{Duration: dur}
 		}
 	}
+	setIfEnv(&c.Callbacks.NATS.URL, "CALLBACK_NATS_URL")
+	setIfEnv(&c.Callbacks.NATS.Stream, "CALLBACK_NATS_STREAM")
+	setIfEnv(&c.Callbacks.NATS.CredentialsFile, "CALLBACK_NATS_CREDENTIALS_FILE")
+	setIfEnv(&c.Callbacks.NATS.Token, "CALLBACK_NATS_TOKEN")
+	setIfEnv(&c.Callbacks.AWS.Region, "CALLBACK_AWS_REGION")
+	setIfEnv(&c.Callbacks.AWS.AccessKeyID, "CALLBACK_AWS_ACCESS_KEY_ID")
+	setIfEnv(&c.Callbacks.AWS.SecretAccessKey, "CALLBACK_AWS_SECRET_ACCESS_KEY")
+	setIfEnv(&c.Callbacks.AWS.SessionToken, "CALLBACK_AWS_SESSION_TOKEN")
+	setIfEnv(&c.Callbacks.AWS.Endpoint, "CALLBACK_AWS_ENDPOINT")
+	setIfEnv(&c.Callbacks.PubSub.ProjectID, "CALLBACK_PUBSUB_PROJECT_ID")
+	setIfEnv(&c.Callbacks.PubSub.Topic, "CALLBACK_PUBSUB_TOPIC")
+	setIfEnv(&c.Callbacks.PubSub.CredentialsFile, "CALLBACK_PUBSUB_CREDENTIALS_FILE")
+	setIfEnv(&c.Callbacks.PubSub.Endpoint, "CALLBACK_PUBSUB_ENDPOINT")
 	// Load callback headers (CALLBACK_HEADER_*)
 	for _, env := range os.Environ() {
 		if !strings.HasPrefix(env, "CALLBACK_HEADER_") {
@@ -144,6 +251,11 @@ func (c *Config) applyEnvOverrides() {
 	setIfEnv(&c.Storage.PostgresURL, "POSTGRES_URL")
 	setIfEnv(&c.Storage.MongoDBURL, "MONGODB_URL")
 	setIfEnv(&c.Storage.MongoDBDatabase, "MONGODB_DATABASE")
+	setDurationIfEnv(&c.Storage.SlowQueryThreshold, "CEDROS_STORAGE_SLOW_QUERY_THRESHOLD")
+	setBoolIfEnv(&c.Storage.ReplayCache.Enabled, "CEDROS_STORAGE_REPLAY_CACHE_ENABLED")
+	setIntIfEnv(&c.Storage.ReplayCache.Size, "CEDROS_STORAGE_REPLAY_CACHE_SIZE")
+	setIntIfEnv(&c.Storage.ReplayCache.ExpectedSignatures, "CEDROS_STORAGE_REPLAY_CACHE_EXPECTED_SIGNATURES")
+	setBoolIfEnv(&c.Storage.ReplayCache.SingleInstance, "CEDROS_STORAGE_REPLAY_CACHE_SINGLE_INSTANCE")
 
 	// API Key config
 	setBoolIfEnv(&c.APIKey.Enabled, "CEDROS_API_KEY_ENABLED")
@@ -168,6 +280,51 @@ func (c *Config) applyEnvOverrides() {
 		tier := strings.TrimSpace(parts[1])
 		c.APIKey.Keys[key] = tier
 	}
+
+	// Async verification config
+	setBoolIfEnv(&c.AsyncVerify.Enabled, "CEDROS_ASYNC_VERIFICATION_ENABLED")
+	setIntIfEnv(&c.AsyncVerify.Workers, "CEDROS_ASYNC_VERIFICATION_WORKERS")
+	setIntIfEnv(&c.AsyncVerify.QueueSize, "CEDROS_ASYNC_VERIFICATION_QUEUE_SIZE")
+	setDurationIfEnv(&c.AsyncVerify.ResultTTL, "CEDROS_ASYNC_VERIFICATION_RESULT_TTL")
+	setDurationIfEnv(&c.AsyncVerify.Timeout, "CEDROS_ASYNC_VERIFICATION_TIMEOUT")
+
+	// GraphQL config
+	setBoolIfEnv(&c.GraphQL.Enabled, "CEDROS_GRAPHQL_ENABLED")
+
+	// gRPC config
+	setBoolIfEnv(&c.GRPC.Enabled, "CEDROS_GRPC_ENABLED")
+	setIfEnv(&c.GRPC.Address, "CEDROS_GRPC_ADDRESS")
+	setBoolIfEnv(&c.GRPC.AllowUnauthenticated, "CEDROS_GRPC_ALLOW_UNAUTHENTICATED")
+
+	// Tracing config
+	setBoolIfEnv(&c.Tracing.Enabled, "CEDROS_TRACING_ENABLED")
+	setIfEnv(&c.Tracing.Endpoint, "CEDROS_TRACING_ENDPOINT")
+	setIfEnv(&c.Tracing.Protocol, "CEDROS_TRACING_PROTOCOL")
+	setBoolIfEnv(&c.Tracing.Insecure, "CEDROS_TRACING_INSECURE")
+	setIfEnv(&c.Tracing.ServiceName, "CEDROS_TRACING_SERVICE_NAME")
+	setFloatIfEnv(&c.Tracing.SampleRatio, "CEDROS_TRACING_SAMPLE_RATIO")
+
+	// Merchant events config
+	setBoolIfEnv(&c.MerchantEvents.Enabled, "CEDROS_MERCHANT_EVENTS_ENABLED")
+	// Load merchant event keys (CEDROS_MERCHANT_EVENTS_KEY_<TENANT>=<key>)
+	for _, env := range os.Environ() {
+		if !strings.HasPrefix(env, "CEDROS_MERCHANT_EVENTS_KEY_") {
+			continue
+		}
+		parts := strings.SplitN(env, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[1]) == "" {
+			continue
+		}
+		tenantID := strings.ToLower(strings.TrimPrefix(parts[0], "CEDROS_MERCHANT_EVENTS_KEY_"))
+		if tenantID == "" {
+			continue
+		}
+		if c.MerchantEvents.Keys == nil {
+			c.MerchantEvents.Keys = make(map[string]string)
+		}
+		// CEDROS_MERCHANT_EVENTS_KEY_ACME=s3cret -> key: "s3cret", tenant: "acme"
+		c.MerchantEvents.Keys[strings.TrimSpace(parts[1])] = tenantID
+	}
 }
 
 // setIfEnv sets a string pointer to the environment variable value if it exists.
@@ -185,6 +342,36 @@ func setBoolIfEnv(target *bool, key string) {
 	}
 }
 
+// setIntIfEnv sets an int pointer from an environment variable.
+// Invalid values are ignored.
+func setIntIfEnv(target *int, key string) {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			*target = n
+		}
+	}
+}
+
+// setUint64IfEnv sets a uint64 pointer from an environment variable.
+// Invalid values are ignored.
+func setUint64IfEnv(target *uint64, key string) {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.ParseUint(v, 10, 64); err == nil {
+			*target = n
+		}
+	}
+}
+
+// setFloatIfEnv sets a float64 pointer from an environment variable.
+// Invalid values are ignored.
+func setFloatIfEnv(target *float64, key string) {
+	if v := os.Getenv(key); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			*target = f
+		}
+	}
+}
+
 // setDurationIfEnv sets a Duration pointer from an environment variable.
 // Uses time.ParseDuration to parse values like "5m", "120s", "1h30m".
 func setDurationIfEnv(target *Duration, key string) {
@@ -26,6 +26,28 @@ func TestEnvOverrides_ServerConfig(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "CEDROS_SERVER_FLOAT_AMOUNTS disables float amounts",
+			envVars: map[string]string{
+				"CEDROS_SERVER_FLOAT_AMOUNTS": "false",
+			},
+			checkFunc: func(t *testing.T, cfg *Config) {
+				if cfg.Server.FloatAmounts {
+					t.Error("Expected float amounts disabled")
+				}
+			},
+		},
+		{
+			name: "CEDROS_SERVER_DRAIN_TIMEOUT overrides default",
+			envVars: map[string]string{
+				"CEDROS_SERVER_DRAIN_TIMEOUT": "45s",
+			},
+			checkFunc: func(t *testing.T, cfg *Config) {
+				if cfg.Server.DrainTimeout.Duration != 45*time.Second {
+					t.Errorf("Expected 45s, got %s", cfg.Server.DrainTimeout.Duration)
+				}
+			},
+		},
 		{
 			name: "CEDROS_ROUTE_PREFIX override",
 			envVars: map[string]string{
@@ -83,6 +105,114 @@ func TestEnvOverrides_X402Config(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "CEDROS_X402_SQUADS_MULTISIG and vault index",
+			envVars: map[string]string{
+				"CEDROS_X402_SQUADS_MULTISIG":    "SQDS4ep65T869zMMBKyuUq6aD6EgTu8psMjkvj52pCf",
+				"CEDROS_X402_SQUADS_VAULT_INDEX": "2",
+			},
+			checkFunc: func(t *testing.T, cfg *Config) {
+				if cfg.X402.SquadsMultisig != "SQDS4ep65T869zMMBKyuUq6aD6EgTu8psMjkvj52pCf" || cfg.X402.SquadsVaultIndex != 2 {
+					t.Errorf("Squads = %q/%d", cfg.X402.SquadsMultisig, cfg.X402.SquadsVaultIndex)
+				}
+			},
+		},
+		{
+			name: "CEDROS_X402_REFUND_NONCE_* settings",
+			envVars: map[string]string{
+				"CEDROS_X402_REFUND_NONCE_ACCOUNT":   "9xQeWvG816bUx9EPjHmaT23yvVM2ZWbrrpZb9PusVFin",
+				"CEDROS_X402_REFUND_NONCE_QUOTE_TTL": "48h",
+			},
+			checkFunc: func(t *testing.T, cfg *Config) {
+				if cfg.X402.RefundNonceAccount != "9xQeWvG816bUx9EPjHmaT23yvVM2ZWbrrpZb9PusVFin" || cfg.X402.RefundNonceQuoteTTL.Duration != 48*time.Hour {
+					t.Errorf("RefundNonce = %q/%v", cfg.X402.RefundNonceAccount, cfg.X402.RefundNonceQuoteTTL.Duration)
+				}
+			},
+		},
+		{
+			name: "CEDROS_X402_PREWARM_TOKEN_ACCOUNTS",
+			envVars: map[string]string{
+				"CEDROS_X402_PREWARM_TOKEN_ACCOUNTS": "true",
+			},
+			checkFunc: func(t *testing.T, cfg *Config) {
+				if !cfg.X402.PrewarmTokenAccounts {
+					t.Error("Expected PrewarmTokenAccounts to be enabled")
+				}
+			},
+		},
+		{
+			name: "CEDROS_X402_STRICT_MEMO",
+			envVars: map[string]string{
+				"CEDROS_X402_STRICT_MEMO": "true",
+			},
+			checkFunc: func(t *testing.T, cfg *Config) {
+				if !cfg.X402.StrictMemo {
+					t.Error("Expected StrictMemo to be enabled")
+				}
+			},
+		},
+		{
+			name: "CEDROS_X402_CART_SETTLEMENT_TOKEN",
+			envVars: map[string]string{
+				"CEDROS_X402_CART_SETTLEMENT_TOKEN": "USDT",
+			},
+			checkFunc: func(t *testing.T, cfg *Config) {
+				if cfg.X402.CartSettlementToken != "USDT" {
+					t.Errorf("CartSettlementToken = %q, want USDT", cfg.X402.CartSettlementToken)
+				}
+			},
+		},
+		{
+			name: "CEDROS_X402_GASLESS_DAILY_BUDGET_SOL",
+			envVars: map[string]string{
+				"CEDROS_X402_GASLESS_DAILY_BUDGET_SOL": "0.25",
+			},
+			checkFunc: func(t *testing.T, cfg *Config) {
+				if cfg.X402.GaslessDailyBudgetSOL != 0.25 {
+					t.Errorf("GaslessDailyBudgetSOL = %v, want 0.25", cfg.X402.GaslessDailyBudgetSOL)
+				}
+			},
+		},
+		{
+			name: "CEDROS_X402_PRIORITY_FEE_* settings",
+			envVars: map[string]string{
+				"CEDROS_X402_PRIORITY_FEE_ENABLED":            "true",
+				"CEDROS_X402_PRIORITY_FEE_PERCENTILE":         "60",
+				"CEDROS_X402_PRIORITY_FEE_MAX_MICRO_LAMPORTS": "250000",
+			},
+			checkFunc: func(t *testing.T, cfg *Config) {
+				fee := cfg.X402.PriorityFee
+				if !fee.Enabled || fee.Percentile != 60 || fee.MaxMicroLamports != 250000 {
+					t.Errorf("PriorityFee = %+v", fee)
+				}
+			},
+		},
+		{
+			name: "CEDROS_X402_RPC_URLS comma-separated",
+			envVars: map[string]string{
+				"CEDROS_X402_RPC_URLS":                  "https://a.example.com, https://b.example.com",
+				"CEDROS_X402_RPC_HEALTH_CHECK_INTERVAL": "5s",
+			},
+			checkFunc: func(t *testing.T, cfg *Config) {
+				if len(cfg.X402.RPCURLs) != 2 || cfg.X402.RPCURLs[1] != "https://b.example.com" {
+					t.Errorf("RPCURLs = %q", cfg.X402.RPCURLs)
+				}
+				if cfg.X402.RPCHealthCheckInterval.Duration != 5*time.Second {
+					t.Errorf("RPCHealthCheckInterval = %v, want 5s", cfg.X402.RPCHealthCheckInterval.Duration)
+				}
+			},
+		},
+		{
+			name: "CEDROS_X402_SIMULATE_TRANSACTIONS boolean",
+			envVars: map[string]string{
+				"CEDROS_X402_SIMULATE_TRANSACTIONS": "true",
+			},
+			checkFunc: func(t *testing.T, cfg *Config) {
+				if !cfg.X402.SimulateTransactions {
+					t.Error("SimulateTransactions should be true")
+				}
+			},
+		},
 		{
 			name: "CEDROS_X402_GASLESS_ENABLED boolean (true)",
 			envVars: map[string]string{
@@ -175,6 +305,23 @@ func TestEnvOverrides_PaywallConfig(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "Cart shipping and tax overrides",
+			envVars: map[string]string{
+				"CEDROS_PAYWALL_SHIPPING_MODE":        "flat",
+				"CEDROS_PAYWALL_SHIPPING_FLAT_AMOUNT": "4.99",
+				"CEDROS_PAYWALL_TAX_MODE":             "flat",
+				"CEDROS_PAYWALL_TAX_RATE_PERCENT":     "8.25",
+			},
+			checkFunc: func(t *testing.T, cfg *Config) {
+				if cfg.Paywall.Shipping.Mode != "flat" || cfg.Paywall.Shipping.FlatAmount != "4.99" {
+					t.Errorf("Expected flat 4.99 shipping, got %+v", cfg.Paywall.Shipping)
+				}
+				if cfg.Paywall.Tax.Mode != "flat" || cfg.Paywall.Tax.RatePercent != 8.25 {
+					t.Errorf("Expected flat 8.25%% tax, got %+v", cfg.Paywall.Tax)
+				}
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -221,6 +368,23 @@ func TestEnvOverrides_StripeConfig(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "CEDROS_STRIPE_PAYMENT_METHODS override",
+			envVars: map[string]string{
+				"CEDROS_STRIPE_PAYMENT_METHODS": "card, sepa_debit,ideal",
+			},
+			checkFunc: func(t *testing.T, cfg *Config) {
+				want := []string{"card", "sepa_debit", "ideal"}
+				if len(cfg.Stripe.PaymentMethods) != len(want) {
+					t.Fatalf("Expected %v, got %v", want, cfg.Stripe.PaymentMethods)
+				}
+				for i, method := range want {
+					if cfg.Stripe.PaymentMethods[i] != method {
+						t.Errorf("Expected %v, got %v", want, cfg.Stripe.PaymentMethods)
+					}
+				}
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -327,4 +491,121 @@ func TestEnvOverrides_APIKeyConfig(t *testing.T) {
 	}
 }
 
+func TestEnvOverrides_TracingConfig(t *testing.T) {
+	defer os.Clearenv()
+
+	tests := []struct {
+		name      string
+		envVars   map[string]string
+		checkFunc func(*testing.T, *Config)
+	}{
+		{
+			name:    "defaults",
+			envVars: map[string]string{},
+			checkFunc: func(t *testing.T, cfg *Config) {
+				if cfg.Tracing.Enabled || cfg.Tracing.Protocol != "grpc" || cfg.Tracing.SampleRatio != 1.0 {
+					t.Errorf("unexpected defaults: %+v", cfg.Tracing)
+				}
+			},
+		},
+		{
+			name: "all overrides",
+			envVars: map[string]string{
+				"CEDROS_TRACING_ENABLED":      "true",
+				"CEDROS_TRACING_ENDPOINT":     "otel-collector:4318",
+				"CEDROS_TRACING_PROTOCOL":     "http",
+				"CEDROS_TRACING_INSECURE":     "true",
+				"CEDROS_TRACING_SERVICE_NAME": "pay-api",
+				"CEDROS_TRACING_SAMPLE_RATIO": "0.25",
+			},
+			checkFunc: func(t *testing.T, cfg *Config) {
+				want := TracingConfig{
+					Enabled:     true,
+					Endpoint:    "otel-collector:4318",
+					Protocol:    "http",
+					Insecure:    true,
+					ServiceName: "pay-api",
+					SampleRatio: 0.25,
+				}
+				if cfg.Tracing.Enabled != want.Enabled || cfg.Tracing.Endpoint != want.Endpoint ||
+					cfg.Tracing.Protocol != want.Protocol || cfg.Tracing.Insecure != want.Insecure ||
+					cfg.Tracing.ServiceName != want.ServiceName || cfg.Tracing.SampleRatio != want.SampleRatio {
+					t.Errorf("got %+v, want %+v", cfg.Tracing, want)
+				}
+			},
+		},
+		{
+			name:    "invalid sample ratio ignored",
+			envVars: map[string]string{"CEDROS_TRACING_SAMPLE_RATIO": "half"},
+			checkFunc: func(t *testing.T, cfg *Config) {
+				if cfg.Tracing.SampleRatio != 1.0 {
+					t.Errorf("Expected 1.0, got %v", cfg.Tracing.SampleRatio)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Clearenv()
+			for k, v := range tt.envVars {
+				os.Setenv(k, v)
+			}
+
+			cfg := defaultConfig()
+			cfg.applyEnvOverrides()
+			tt.checkFunc(t, cfg)
+		})
+	}
+}
+
+func TestEnvOverrides_ReplayCacheConfig(t *testing.T) {
+	defer os.Clearenv()
+
+	tests := []struct {
+		name      string
+		envVars   map[string]string
+		checkFunc func(*testing.T, *Config)
+	}{
+		{
+			name:    "defaults",
+			envVars: map[string]string{},
+			checkFunc: func(t *testing.T, cfg *Config) {
+				want := ReplayCacheConfig{Size: 10000, ExpectedSignatures: 1000000}
+				if cfg.Storage.ReplayCache != want {
+					t.Errorf("got %+v, want %+v", cfg.Storage.ReplayCache, want)
+				}
+			},
+		},
+		{
+			name: "all overrides",
+			envVars: map[string]string{
+				"CEDROS_STORAGE_REPLAY_CACHE_ENABLED":             "true",
+				"CEDROS_STORAGE_REPLAY_CACHE_SIZE":                "500",
+				"CEDROS_STORAGE_REPLAY_CACHE_EXPECTED_SIGNATURES": "20000",
+				"CEDROS_STORAGE_REPLAY_CACHE_SINGLE_INSTANCE":     "true",
+			},
+			checkFunc: func(t *testing.T, cfg *Config) {
+				want := ReplayCacheConfig{Enabled: true, Size: 500, ExpectedSignatures: 20000, SingleInstance: true}
+				if cfg.Storage.ReplayCache != want {
+					t.Errorf("got %+v, want %+v", cfg.Storage.ReplayCache, want)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Clearenv()
+			for k, v := range tt.envVars {
+				os.Setenv(k, v)
+			}
+
+			cfg := defaultConfig()
+			cfg.applyEnvOverrides()
+			tt.checkFunc(t, cfg)
+		})
+	}
+}
+
 // TestLoadServerWalletKeys and TestNormalizeRoutePrefix already exist in config_test.go
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// interpolate expands ${VAR} and ${VAR:-default} placeholders in every scalar of the
+// YAML document, so secrets can stay in the environment. "$${" yields a literal "${".
+// Placeholders are expanded after parsing, so values containing YAML syntax (quotes,
+// colons, "#") can't change the document's structure. Unset variables without a default
+// are reported together.
+//
+// Placeholders of the form ${vault:path#field} are passed to resolve, which is nil while
+// the secrets section itself is being read.
+func interpolate(root *yaml.Node, resolve secretResolver) error {
+	p := &placeholders{resolve: resolve, missing: make(map[string]bool)}
+	walkScalars(root, func(n *yaml.Node) {
+		if !strings.Contains(n.Value, "${") {
+			return
+		}
+		n.Value = p.expand(n.Value)
+		// Let plain scalars re-resolve their type, so "port: ${PORT}" still decodes as an int
+		if n.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle|yaml.LiteralStyle|yaml.FoldedStyle) == 0 {
+			n.Tag = ""
+		}
+	})
+
+	if len(p.errs) > 0 {
+		return errors.Join(p.errs...)
+	}
+	if len(p.missing) > 0 {
+		names := make([]string, 0, len(p.missing))
+		for name := range p.missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("config references unset environment variables: %s", strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// secretResolver returns the value behind a secret reference such as "vault:path#field".
+type secretResolver func(ref string) (string, error)
+
+func walkScalars(n *yaml.Node, fn func(*yaml.Node)) {
+	if n.Kind == yaml.ScalarNode {
+		fn(n)
+		return
+	}
+	for _, child := range n.Content {
+		walkScalars(child, fn)
+	}
+}
+
+// placeholders collects unset variables and secret lookup failures across a document.
+type placeholders struct {
+	resolve secretResolver
+	missing map[string]bool
+	errs    []error
+}
+
+// expand replaces placeholders in s.
+func (p *placeholders) expand(s string) string {
+	var b strings.Builder
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+		if i > 0 && s[i-1] == '$' {
+			// Escaped: "$${" -> "${"
+			b.WriteString(s[:i-1])
+			b.WriteString("${")
+			s = s[i+2:]
+			continue
+		}
+		end := strings.IndexByte(s[i:], '}')
+		if end < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+		b.WriteString(s[:i])
+
+		expr := s[i+2 : i+end]
+		if strings.HasPrefix(expr, vaultRefPrefix) {
+			// Defaults don't apply: falling back silently would hide a broken secret path
+			b.WriteString(p.secret(expr))
+		} else {
+			name, fallback, hasDefault := strings.Cut(expr, ":-")
+			if value, ok := os.LookupEnv(name); ok && (value != "" || !hasDefault) {
+				b.WriteString(value)
+			} else if hasDefault {
+				b.WriteString(fallback)
+			} else {
+				p.missing[name] = true
+			}
+		}
+		s = s[i+end+1:]
+	}
+}
+
+func (p *placeholders) secret(ref string) string {
+	if p.resolve == nil {
+		p.errs = append(p.errs, fmt.Errorf("secret reference ${%s} is not allowed here", ref))
+		return ""
+	}
+	value, err := p.resolve(ref)
+	if err != nil {
+		p.errs = append(p.errs, fmt.Errorf("resolve ${%s}: %w", ref, err))
+		return ""
+	}
+	return value
+}
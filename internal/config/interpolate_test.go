@@ -0,0 +1,219 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseFileInterpolatesEnv(t *testing.T) {
+	defer os.Clearenv()
+
+	tests := []struct {
+		name      string
+		yaml      string
+		env       map[string]string
+		wantErr   string
+		checkFunc func(*testing.T, *Config)
+	}{
+		{
+			name: "quoted and plain placeholders",
+			yaml: `
+stripe:
+  secret_key: "${STRIPE_KEY}"
+storage:
+  postgres_url: postgresql://app:${DB_PASSWORD}@db:5432/pay
+x402:
+  token_decimals: ${DECIMALS}
+  skip_preflight: ${SKIP}
+server:
+  read_timeout: ${READ_TIMEOUT}
+callbacks:
+  headers:
+    Authorization: "Bearer ${WEBHOOK_TOKEN}"
+`,
+			env: map[string]string{
+				"STRIPE_KEY":    "sk_test_123",
+				"DB_PASSWORD":   "p#ss: word",
+				"DECIMALS":      "9",
+				"SKIP":          "true",
+				"READ_TIMEOUT":  "20s",
+				"WEBHOOK_TOKEN": "tok",
+			},
+			checkFunc: func(t *testing.T, cfg *Config) {
+				if cfg.Stripe.SecretKey != "sk_test_123" {
+					t.Errorf("secret_key = %q", cfg.Stripe.SecretKey)
+				}
+				// YAML syntax inside the value must not change the document structure
+				if cfg.Storage.PostgresURL != "postgresql://app:p#ss: word@db:5432/pay" {
+					t.Errorf("postgres_url = %q", cfg.Storage.PostgresURL)
+				}
+				if cfg.X402.TokenDecimals != 9 || !cfg.X402.SkipPreflight {
+					t.Errorf("typed values not decoded: decimals=%d skip=%v", cfg.X402.TokenDecimals, cfg.X402.SkipPreflight)
+				}
+				if cfg.Server.ReadTimeout.Duration != 20*time.Second {
+					t.Errorf("read_timeout = %v", cfg.Server.ReadTimeout.Duration)
+				}
+				if cfg.Callbacks.Headers["Authorization"] != "Bearer tok" {
+					t.Errorf("header = %q", cfg.Callbacks.Headers["Authorization"])
+				}
+			},
+		},
+		{
+			name: "defaults and escapes",
+			yaml: `
+stripe:
+  secret_key: "${UNSET_KEY:-sk_default}"
+  webhook_secret: "${EMPTY:-fallback}"
+  tax_rate_id: "$${LITERAL}"
+`,
+			env: map[string]string{"EMPTY": ""},
+			checkFunc: func(t *testing.T, cfg *Config) {
+				if cfg.Stripe.SecretKey != "sk_default" {
+					t.Errorf("secret_key = %q", cfg.Stripe.SecretKey)
+				}
+				if cfg.Stripe.WebhookSecret != "fallback" {
+					t.Errorf("webhook_secret = %q", cfg.Stripe.WebhookSecret)
+				}
+				if cfg.Stripe.TaxRateID != "${LITERAL}" {
+					t.Errorf("tax_rate_id = %q", cfg.Stripe.TaxRateID)
+				}
+			},
+		},
+		{
+			name: "unset variables reported together",
+			yaml: `
+stripe:
+  secret_key: "${MISSING_B}"
+  webhook_secret: "${MISSING_A}"
+`,
+			wantErr: "MISSING_A, MISSING_B",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Clearenv()
+			for k, v := range tt.env {
+				os.Setenv(k, v)
+			}
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(tt.yaml), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg := defaultConfig()
+			err := cfg.parseFile(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseFile: %v", err)
+			}
+			tt.checkFunc(t, cfg)
+		})
+	}
+}
+
+func TestParseFileResolvesVault(t *testing.T) {
+	defer os.Clearenv()
+	os.Clearenv()
+	os.Setenv("VAULT_ROOT_TOKEN", "root")
+
+	var reads int
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/auth/token/lookup-self":
+			w.Write([]byte(`{"data":{"renewable":false}}`))
+		case "/v1/secret/data/cedros":
+			reads++
+			w.Write([]byte(`{"data":{"data":{"stripe_key":"sk_vault","db_password":"hunter2","wallet":"base58key"}}}`))
+		default:
+			http.Error(w, `{"errors":[]}`, http.StatusNotFound)
+		}
+	}))
+	defer vault.Close()
+
+	write := func(body string) string {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	cfg := defaultConfig()
+	err := cfg.parseFile(write(`
+secrets:
+  vault:
+    address: ` + vault.URL + `
+    token: ${VAULT_ROOT_TOKEN}
+stripe:
+  secret_key: ${vault:secret/data/cedros#stripe_key}
+storage:
+  postgres_url: postgresql://app:${vault:secret/data/cedros#db_password}@db/pay
+x402:
+  server_wallet_keys:
+    - ${vault:secret/data/cedros#wallet}
+`))
+	if err != nil {
+		t.Fatalf("parseFile: %v", err)
+	}
+	defer cfg.Close()
+	if cfg.Stripe.SecretKey != "sk_vault" {
+		t.Errorf("secret_key = %q", cfg.Stripe.SecretKey)
+	}
+	if cfg.Storage.PostgresURL != "postgresql://app:hunter2@db/pay" {
+		t.Errorf("postgres_url = %q", cfg.Storage.PostgresURL)
+	}
+	if len(cfg.X402.ServerWalletKeys) != 1 || cfg.X402.ServerWalletKeys[0] != "base58key" {
+		t.Errorf("server_wallet_keys = %v", cfg.X402.ServerWalletKeys)
+	}
+	if reads != 1 {
+		t.Errorf("vault reads = %d, want 1", reads)
+	}
+
+	errCases := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{
+			name: "missing field",
+			yaml: `
+secrets:
+  vault: {address: ` + vault.URL + `, token: root}
+stripe:
+  secret_key: ${vault:secret/data/cedros#nope}
+`,
+			wantErr: `no field "nope"`,
+		},
+		{
+			name: "reference inside secrets section",
+			yaml: `
+secrets:
+  vault: {address: ` + vault.URL + `, token: "${vault:secret/data/cedros#token}"}
+`,
+			wantErr: "not allowed here",
+		},
+	}
+	for _, tt := range errCases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := defaultConfig().parseFile(write(tt.yaml))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/CedrosPay/server/internal/secrets"
+)
+
+const vaultRefPrefix = "vault:"
+
+// decodeSecrets reads the secrets section ahead of the rest of the document, so the
+// backends it configures can resolve references elsewhere in the file. Only environment
+// placeholders are expanded here; the section can't reference the store it configures.
+func (c *Config) decodeSecrets(doc *yaml.Node) error {
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil
+	}
+	root := doc.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != "secrets" {
+			continue
+		}
+		// Expand a copy: the full pass expands the document again, and "$${" escapes must
+		// not be unescaped twice
+		section := cloneNode(root.Content[i+1])
+		if err := interpolate(section, nil); err != nil {
+			return err
+		}
+		if err := section.Decode(&c.Secrets); err != nil {
+			return fmt.Errorf("parse config yaml: secrets: %w", err)
+		}
+	}
+	return nil
+}
+
+func cloneNode(n *yaml.Node) *yaml.Node {
+	clone := *n
+	clone.Content = make([]*yaml.Node, len(n.Content))
+	for i, child := range n.Content {
+		clone.Content[i] = cloneNode(child)
+	}
+	return &clone
+}
+
+// resolveSecret fetches a ${vault:path#field} reference, connecting to Vault on first use.
+func (c *Config) resolveSecret(ref string) (string, error) {
+	ctx := context.Background()
+	if c.vault == nil {
+		v, err := secrets.NewVault(ctx, c.Secrets.Vault.options())
+		if err != nil {
+			return "", err
+		}
+		c.vault = v
+	}
+	return c.vault.Resolve(ctx, strings.TrimPrefix(ref, vaultRefPrefix))
+}
+
+func (v VaultConfig) options() secrets.VaultOptions {
+	return secrets.VaultOptions{
+		Address:    v.Address,
+		Token:      v.Token,
+		Namespace:  v.Namespace,
+		AuthMethod: v.AuthMethod,
+		AuthMount:  v.AuthMount,
+		Role:       v.Role,
+		RoleID:     v.RoleID,
+		SecretID:   v.SecretID,
+		Timeout:    v.Timeout.Duration,
+	}
+}
+
+// Close stops renewing secret leases held on behalf of this config.
+func (c *Config) Close() error {
+	if c.vault == nil {
+		return nil
+	}
+	return c.vault.Close()
+}
@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/CedrosPay/server/internal/secrets"
 )
 
 // Duration wraps time.Duration to support string based YAML decoding.
@@ -56,8 +58,93 @@ type Config struct {
 	Callbacks      CallbacksConfig      `yaml:"callbacks"`
 	Monitoring     MonitoringConfig     `yaml:"monitoring"`
 	RateLimit      RateLimitConfig      `yaml:"rate_limit"`
+	GeoRestriction GeoRestrictionConfig `yaml:"geo_restriction"`
+	Proxy          ProxyConfig          `yaml:"proxy"`
+	Metering       MeteringConfig       `yaml:"metering"`
 	APIKey         APIKeyConfig         `yaml:"api_key"`
 	CircuitBreaker CircuitBreakerConfig `yaml:"circuit_breaker"`
+	MerchantEvents MerchantEventsConfig `yaml:"merchant_events"`
+	AsyncVerify    AsyncVerifyConfig    `yaml:"async_verification"`
+	GraphQL        GraphQLConfig        `yaml:"graphql"`
+	GRPC           GRPCConfig           `yaml:"grpc"`
+	Tracing        TracingConfig        `yaml:"tracing"`
+	Secrets        SecretsConfig        `yaml:"secrets"`
+	Assets         []AssetConfig        `yaml:"assets"`
+
+	vault *secrets.Vault // Set once a ${vault:...} reference has been resolved; released by Close
+}
+
+// AssetConfig declares a currency or token beyond the built-in ones (USD, EUR, USDC, USDT,
+// PYUSD, CASH, SOL). Assets are registered with the money package while the config loads.
+type AssetConfig struct {
+	Code           string `yaml:"code"`            // Code used in prices and APIs, e.g. "EURC"
+	Decimals       uint8  `yaml:"decimals"`        // Decimal places of the atomic unit
+	Type           string `yaml:"type"`            // "spl" or "fiat" (default: "spl")
+	SolanaMint     string `yaml:"solana_mint"`     // SPL token mint address
+	StripeCurrency string `yaml:"stripe_currency"` // Stripe currency for fiat (default: the code in lowercase)
+	Symbol         string `yaml:"symbol"`          // Display symbol (default: the code)
+	Stablecoin     bool   `yaml:"stablecoin"`      // SPL token pegged to $1, accepted as x402.token_mint
+}
+
+// SecretsConfig configures external secret stores. Any YAML value may reference one with a
+// placeholder such as ${vault:secret/data/cedros#stripe_secret_key}.
+type SecretsConfig struct {
+	Vault VaultConfig `yaml:"vault"`
+}
+
+// VaultConfig configures HashiCorp Vault. The client connects on the first ${vault:...}
+// reference; CEDROS_* environment overrides don't apply since references resolve first.
+type VaultConfig struct {
+	Address    string   `yaml:"address"`     // Vault URL (default: VAULT_ADDR)
+	Token      string   `yaml:"token"`       // Token for "token" auth (default: VAULT_TOKEN)
+	Namespace  string   `yaml:"namespace"`   // Vault Enterprise namespace (default: VAULT_NAMESPACE)
+	AuthMethod string   `yaml:"auth_method"` // "token", "kubernetes", or "approle" (default: "token")
+	AuthMount  string   `yaml:"auth_mount"`  // Auth backend mount path (default: the method name)
+	Role       string   `yaml:"role"`        // Kubernetes auth role
+	RoleID     string   `yaml:"role_id"`     // AppRole role_id
+	SecretID   string   `yaml:"secret_id"`   // AppRole secret_id
+	Timeout    Duration `yaml:"timeout"`     // Per-request timeout (default: 10s)
+}
+
+// TracingConfig configures OpenTelemetry distributed tracing exported over OTLP.
+type TracingConfig struct {
+	Enabled     bool              `yaml:"enabled"`      // Record and export spans (default: false)
+	Endpoint    string            `yaml:"endpoint"`     // Collector host:port (default: OTEL_EXPORTER_OTLP_ENDPOINT, else localhost:4317/4318)
+	Protocol    string            `yaml:"protocol"`     // "grpc" or "http" (default: "grpc")
+	Insecure    bool              `yaml:"insecure"`     // Connect to the collector without TLS (default: false)
+	Headers     map[string]string `yaml:"headers"`      // Extra exporter headers, e.g. vendor API keys
+	ServiceName string            `yaml:"service_name"` // Reported service.name (default: "cedros-pay")
+	SampleRatio float64           `yaml:"sample_ratio"` // Fraction of new traces recorded, 0-1 (default: 1.0)
+}
+
+// GRPCConfig configures the machine-to-machine gRPC API (proto/cedros/v1/paywall.proto).
+type GRPCConfig struct {
+	Enabled              bool   `yaml:"enabled"`               // Serve the gRPC API (default: false)
+	Address              string `yaml:"address"`               // Listen address (default: ":9090")
+	AllowUnauthenticated bool   `yaml:"allow_unauthenticated"` // Skip API key checks, e.g. behind a trusted mesh (default: false)
+}
+
+// GraphQLConfig configures the read-only storefront GraphQL endpoint.
+type GraphQLConfig struct {
+	Enabled bool `yaml:"enabled"` // Serve /paywall/v1/graphql (default: false)
+}
+
+// AsyncVerifyConfig configures asynchronous x402 verification. Clients opt in per request
+// and poll GET /paywall/v1/verifications/{id} while a worker pool confirms the payment.
+type AsyncVerifyConfig struct {
+	Enabled   bool     `yaml:"enabled"`    // Accept async verification requests (default: false)
+	Workers   int      `yaml:"workers"`    // Concurrent verifications (default: 4)
+	QueueSize int      `yaml:"queue_size"` // Pending jobs before requests are rejected with 503 (default: 100)
+	ResultTTL Duration `yaml:"result_ttl"` // How long finished results remain pollable (default: 10m)
+	Timeout   Duration `yaml:"timeout"`    // Per-verification deadline (default: 90s)
+}
+
+// MerchantEventsConfig configures the authenticated WebSocket channel that streams live
+// payment, refund, and webhook-failure events to merchant dashboards.
+type MerchantEventsConfig struct {
+	Enabled    bool              `yaml:"enabled"`     // Enable the merchant events WebSocket (default: false)
+	Keys       map[string]string `yaml:"keys"`        // Map of access key -> tenant ID ("default" for single-tenant)
+	BufferSize int               `yaml:"buffer_size"` // Per-connection event buffer before events are dropped (default: 64)
 }
 
 // SubscriptionsConfig holds subscription management configuration.
@@ -66,6 +153,15 @@ type SubscriptionsConfig struct {
 	Backend         string             `yaml:"backend"`          // "memory" or "postgres" (default: "memory")
 	PostgresURL     string             `yaml:"postgres_url"`     // PostgreSQL connection string (optional, uses storage.postgres_url if not set)
 	GracePeriodHours int               `yaml:"grace_period_hours"` // Default grace period after expiry (default: 0)
+
+	// Past-due grace: access continues this long after a failed renewal payment (default: 0,
+	// access ends with the period)
+	PastDueGraceHours int `yaml:"past_due_grace_hours"`
+	GraceNoticeHours  int `yaml:"grace_notice_hours"` // Send subscription.grace_period_expiring this long before the grace period ends (default: 24)
+
+	// Schedule subscription.renewal_reminder this long before an x402 subscription's period
+	// ends, through the persistent webhook queue (default: 0, no reminders)
+	RenewalReminderHours int `yaml:"renewal_reminder_hours"`
 }
 
 // ServerConfig holds HTTP server configuration.
@@ -77,6 +173,8 @@ type ServerConfig struct {
 	CORSAllowedOrigins []string `yaml:"cors_allowed_origins"`
 	RoutePrefix        string   `yaml:"route_prefix"`          // Optional prefix for all routes (e.g., "/api", "/cedros")
 	AdminMetricsAPIKey string   `yaml:"admin_metrics_api_key"` // Optional API key to protect /metrics endpoint (leave empty to disable protection)
+	DrainTimeout       Duration `yaml:"drain_timeout"`         // Max wait for in-flight payments and webhooks on shutdown (default: 30s)
+	FloatAmounts       bool     `yaml:"float_amounts"`         // Keep JSON number amounts beside their exact decimal strings (default: true)
 }
 
 // StripeConfig holds Stripe payment integration configuration.
@@ -88,6 +186,77 @@ type StripeConfig struct {
 	CancelURL      string `yaml:"cancel_url"`
 	TaxRateID      string `yaml:"tax_rate_id"`
 	Mode           string `yaml:"mode"` // live | test
+
+	// PaymentMethods are the Stripe payment method types checkouts offer (e.g. card,
+	// sepa_debit, us_bank_account, ideal, pix). "card" includes Apple Pay and Google Pay.
+	// "automatic" offers the methods enabled in the Stripe Dashboard. Empty offers card only.
+	PaymentMethods []string `yaml:"payment_methods"`
+
+	// AutomaticTax has Stripe Tax calculate tax on checkout and subscription sessions from the
+	// customer's billing address. Stripe Tax must be enabled on the account; it replaces
+	// tax_rate_id.
+	AutomaticTax bool `yaml:"automatic_tax"`
+	// TaxBehavior tells Stripe Tax whether amounts priced by CedrosPay (fiat_amount_cents)
+	// include tax: "exclusive" (default) adds tax on top, "inclusive" takes it out of the price.
+	TaxBehavior string `yaml:"tax_behavior"`
+
+	// Connect routes payments for resources with a stripe_connected_account to that account.
+	Connect StripeConnectConfig `yaml:"connect"`
+
+	// WebhookDedupTTL is how long processed webhook event IDs are remembered so Stripe's
+	// retries are acknowledged without processing the event again (default: 72h, Stripe's
+	// retry window).
+	WebhookDedupTTL Duration `yaml:"webhook_dedup_ttl"`
+}
+
+// StripeConnectConfig configures Stripe Connect destination charges: the platform's account
+// charges the customer, keeps an application fee, and transfers the rest to the seller's
+// connected account.
+type StripeConnectConfig struct {
+	// ApplicationFeePercent is the platform's cut of each payment routed to a connected
+	// account (0-100), unless a resource sets stripe_application_fee_percent.
+	ApplicationFeePercent float64 `yaml:"application_fee_percent"`
+}
+
+// Stripe tax behaviors for automatic tax.
+const (
+	StripeTaxBehaviorExclusive = "exclusive"
+	StripeTaxBehaviorInclusive = "inclusive"
+)
+
+// StripePaymentMethodsAutomatic lets Stripe choose payment methods from the Dashboard settings.
+const StripePaymentMethodsAutomatic = "automatic"
+
+// stripePaymentMethods are the payment method types accepted in payment_methods lists.
+var stripePaymentMethods = map[string]bool{
+	"card": true, "link": true, "us_bank_account": true, "sepa_debit": true, "bacs_debit": true,
+	"au_becs_debit": true, "acss_debit": true, "ideal": true, "bancontact": true, "eps": true,
+	"giropay": true, "sofort": true, "p24": true, "pix": true, "boleto": true, "oxxo": true,
+	"klarna": true, "afterpay_clearpay": true, "affirm": true, "alipay": true, "wechat_pay": true,
+	"grabpay": true, "fpx": true, "paynow": true, "promptpay": true, "konbini": true,
+	"customer_balance": true, "cashapp": true,
+}
+
+// ValidateStripePaymentMethods checks methods lists known Stripe payment method types, or is
+// just "automatic".
+func ValidateStripePaymentMethods(methods []string) error {
+	for _, method := range methods {
+		if method == StripePaymentMethodsAutomatic {
+			if len(methods) > 1 {
+				return fmt.Errorf("%q cannot be combined with other payment methods", StripePaymentMethodsAutomatic)
+			}
+			continue
+		}
+		if !stripePaymentMethods[method] {
+			return fmt.Errorf("unknown Stripe payment method %q", method)
+		}
+	}
+	return nil
+}
+
+// GetPaymentMethods returns the payment method types checkouts offer by default.
+func (s StripeConfig) GetPaymentMethods() []string {
+	return s.PaymentMethods
 }
 
 // GetSecretKey returns the Stripe secret key.
@@ -110,26 +279,77 @@ func (s StripeConfig) GetTaxRateID() string {
 	return s.TaxRateID
 }
 
+// GetAutomaticTax reports whether Stripe Tax calculates tax on checkout sessions.
+func (s StripeConfig) GetAutomaticTax() bool {
+	return s.AutomaticTax
+}
+
+// GetTaxBehavior returns the tax behavior of inline prices, defaulting to exclusive.
+func (s StripeConfig) GetTaxBehavior() string {
+	if s.TaxBehavior == "" {
+		return StripeTaxBehaviorExclusive
+	}
+	return s.TaxBehavior
+}
+
 // X402Config holds x402 protocol and Solana configuration.
 type X402Config struct {
-	PaymentAddress                string   `yaml:"payment_address"`
-	TokenMint                     string   `yaml:"token_mint"`
-	Network                       string   `yaml:"network"`
-	RPCURL                        string   `yaml:"rpc_url"`
-	WSURL                         string   `yaml:"ws_url"`
-	TokenDecimals                 uint8    `yaml:"token_decimals"`
-	MemoPrefix                    string   `yaml:"memo_prefix"`
-	AllowedTokens                 []string `yaml:"allowed_tokens"`
-	SkipPreflight                 bool     `yaml:"skip_preflight"`
-	Commitment                    string   `yaml:"commitment"`
-	GaslessEnabled                bool     `yaml:"gasless_enabled"`                   // Pay network fees for users
-	AutoCreateTokenAccount        bool     `yaml:"auto_create_token_account"`         // Auto-create missing token accounts
-	ServerWalletKeys              []string `yaml:"-"`                                 // Loaded from env (X402_SERVER_WALLET_1, X402_SERVER_WALLET_2, ...), used for both gasless and token account creation
-	TxQueueMinTimeBetween         Duration `yaml:"tx_queue_min_time_between"`         // Minimum time between transaction sends (e.g., "100ms", "1s") - set to 0 for unlimited RPC
-	TxQueueMaxInFlight            int      `yaml:"tx_queue_max_in_flight"`            // Maximum concurrent in-flight transactions (sent but waiting for confirmation) - set to 0 for unlimited
-	ComputeUnitLimit              uint32   `yaml:"compute_unit_limit"`                // Compute unit limit for transactions (default: 200000)
-	ComputeUnitPriceMicroLamports uint64   `yaml:"compute_unit_price_micro_lamports"` // Priority fee in microlamports (default: 1)
-	RoundingMode                  string   `yaml:"rounding_mode"`                     // Discount rounding: "standard" (Stripe-compatible: 0.025→0.03, 0.024→0.02) or "ceiling" (always round up)
+	PaymentAddress                string             `yaml:"payment_address"`
+	TokenMint                     string             `yaml:"token_mint"`
+	Network                       string             `yaml:"network"`
+	RPCURL                        string             `yaml:"rpc_url"`
+	RPCURLs                       []string           `yaml:"rpc_urls"`                  // Additional RPC endpoints pooled with rpc_url; calls go to the fastest healthy endpoint and fail over on errors or timeouts
+	RPCHealthCheckInterval        Duration           `yaml:"rpc_health_check_interval"` // How often pooled RPC endpoints are probed with getHealth (default: 15s)
+	WSURL                         string             `yaml:"ws_url"`
+	TokenDecimals                 uint8              `yaml:"token_decimals"`
+	MemoPrefix                    string             `yaml:"memo_prefix"`
+	StrictMemo                    bool               `yaml:"strict_memo"` // Reject payments whose memo isn't exactly the quoted memo_prefix:<resource or cart ID>; memo templates are ignored
+	AllowedTokens                 []string           `yaml:"allowed_tokens"`
+	CartSettlementToken           string             `yaml:"cart_settlement_token"` // Token every cart is priced and paid in; items listed in other tokens are converted at token_rates. Empty rejects carts mixing tokens (default: "")
+	TokenRates                    map[string]float64 `yaml:"token_rates"`           // Units of cart_settlement_token per unit of each other token, e.g. USDT: 1.0
+	SkipPreflight                 bool               `yaml:"skip_preflight"`
+	SimulateTransactions          bool               `yaml:"simulate_transactions"` // Simulate payments before sending to report insufficient balance, missing token accounts, etc. as specific errors (one extra RPC call)
+	Commitment                    string             `yaml:"commitment"`
+	GaslessEnabled                bool               `yaml:"gasless_enabled"`                   // Pay network fees for users
+	GaslessDailyBudgetSOL         float64            `yaml:"gasless_daily_budget_sol"`          // Max SOL each server wallet spends on gasless fees per UTC day; quotes fall back to non-gasless once all are spent (default: 0 = unlimited)
+	AutoCreateTokenAccount        bool               `yaml:"auto_create_token_account"`         // Auto-create missing token accounts
+	PrewarmTokenAccounts          bool               `yaml:"prewarm_token_accounts"`            // Create payment_address's missing token accounts for token_mint and allowed_tokens at startup, so first payments don't wait on creation
+	ServerWalletKeys              []string           `yaml:"server_wallet_keys"`                // Used for both gasless and token account creation. Reference a secret store (${vault:...}) rather than committing keys; X402_SERVER_WALLET_1, X402_SERVER_WALLET_2, ... override
+	TxQueueMinTimeBetween         Duration           `yaml:"tx_queue_min_time_between"`         // Minimum time between transaction sends (e.g., "100ms", "1s") - set to 0 for unlimited RPC
+	TxQueueMaxInFlight            int                `yaml:"tx_queue_max_in_flight"`            // Maximum concurrent in-flight transactions (sent but waiting for confirmation) - set to 0 for unlimited
+	ComputeUnitLimit              uint32             `yaml:"compute_unit_limit"`                // Compute unit limit for transactions (default: 200000)
+	ComputeUnitPriceMicroLamports uint64             `yaml:"compute_unit_price_micro_lamports"` // Priority fee in microlamports (default: 1); the floor when priority_fee is enabled
+	PriorityFee                   PriorityFeeConfig  `yaml:"priority_fee"`                      // Dynamic priority fee estimation for gasless transactions
+	RateOracle                    RateOracleConfig   `yaml:"rate_oracle"`                       // Exchange rates for fiat-priced resources; replaces token_rates for carts when set
+	RoundingMode                  string             `yaml:"rounding_mode"`                     // Discount rounding: "standard" (Stripe-compatible: 0.025→0.03, 0.024→0.02) or "ceiling" (always round up)
+	SquadsMultisig                string             `yaml:"squads_multisig"`                   // Squads v4 multisig account whose vault is payment_address; refunds become proposals and any member may act as admin
+	SquadsVaultIndex              int                `yaml:"squads_vault_index"`                // Index of the multisig vault used as payment_address (default: 0)
+	RefundNonceAccount            string             `yaml:"refund_nonce_account"`              // Durable nonce account (authority: payment_address) used for refund transactions so admins can sign offline and execute later
+	RefundNonceQuoteTTL           Duration           `yaml:"refund_nonce_quote_ttl"`            // How long refund quotes built on the durable nonce remain valid (default: 168h)
+	SpecVersion                   int                `yaml:"spec_version"`                      // x402 version of 402 responses: 0 = Cedros payloads, 1 = published v1 (scheme "exact", "solana" networks). Both header formats are always accepted (default: 0)
+	Facilitator                   bool               `yaml:"facilitator"`                       // Serve the x402 facilitator API (/facilitator/verify, /facilitator/settle) so other resource servers can verify and settle Solana payments here (default: false)
+}
+
+// PriorityFeeConfig sets gasless transactions' compute unit price from fees recently paid to
+// land transactions touching the same accounts, instead of compute_unit_price_micro_lamports.
+type PriorityFeeConfig struct {
+	Enabled          bool     `yaml:"enabled"`            // Estimate the priority fee per transaction (default: false)
+	Percentile       int      `yaml:"percentile"`         // Percentile of recent prioritization fees to pay (default: 75); 90 when the network is congested
+	MinMicroLamports uint64   `yaml:"min_micro_lamports"` // Lower bound per compute unit (default: compute_unit_price_micro_lamports)
+	MaxMicroLamports uint64   `yaml:"max_micro_lamports"` // Upper bound per compute unit (default: 100000)
+	RefreshInterval  Duration `yaml:"refresh_interval"`   // How long an estimate is reused before querying the RPC again (default: 10s)
+}
+
+// RateOracleConfig selects where exchange rates come from when x402 quotes convert fiat prices
+// (resources with price_from_fiat) or mixed-token carts into the payment token.
+type RateOracleConfig struct {
+	Provider   string             `yaml:"provider"`    // "fixed", "coingecko", or "pyth"; empty disables the oracle (default: "")
+	FixedRates map[string]float64 `yaml:"fixed_rates"` // For "fixed": units of TO per unit of FROM, keyed "FROM/TO", e.g. "USD/USDC": 1.0
+	APIURL     string             `yaml:"api_url"`     // Overrides the provider's API base URL
+	APIKey     string             `yaml:"api_key"`     // CoinGecko Pro API key (optional)
+	PythFeeds  map[string]string  `yaml:"pyth_feeds"`  // For "pyth": extra price feed IDs (token/USD) by token symbol
+	CacheTTL   Duration           `yaml:"cache_ttl"`   // How long a fetched rate is reused (default: 30s)
+	Timeout    Duration           `yaml:"timeout"`     // Per-request timeout to the provider (default: 5s)
 }
 
 // PaywallConfig holds paywall service configuration.
@@ -144,6 +364,83 @@ type PaywallConfig struct {
 	MongoDBCollection string                     `yaml:"mongodb_collection"`  // MongoDB collection name
 	Resources         map[string]PaywallResource `yaml:"resources"`           // Only used when ProductSource = "yaml"
 	PostgresPool      PostgresPoolConfig         `yaml:"postgres_pool"`       // PostgreSQL connection pool settings
+	Shipping          CartShippingConfig         `yaml:"shipping"`            // Shipping line added to cart quotes
+	Tax               CartTaxConfig              `yaml:"tax"`                 // Tax line added to cart quotes
+	Refunds           RefundPolicyConfig         `yaml:"refunds"`             // What happens to refund requests nobody decides on
+	Fraud             FraudConfig                `yaml:"fraud"`               // Velocity rules for payments, refund requests, and quotes
+	Screening         ScreeningConfig            `yaml:"screening"`           // Compliance screening of payers and refund recipients
+}
+
+// Velocity rule actions.
+const (
+	FraudActionBlock         = "block"          // Refuse the request
+	FraudActionFlag          = "flag"           // Allow it, tagging the payment or refund request
+	FraudActionRequireReview = "require_review" // Allow it, marking the refund request for review (refund rule only)
+)
+
+// FraudConfig holds velocity rules evaluated by the paywall service. Counts are kept in
+// memory, so like the HTTP rate limits they apply per instance.
+type FraudConfig struct {
+	PaymentsPerWallet VelocityRuleConfig `yaml:"payments_per_wallet"` // x402 payments per payer wallet (default window: 1h)
+	RefundsPerWallet  VelocityRuleConfig `yaml:"refunds_per_wallet"`  // Refund requests per recipient wallet (default window: 24h)
+	QuotesPerIP       VelocityRuleConfig `yaml:"quotes_per_ip"`       // Quotes per client IP (default window: 1h)
+}
+
+// VelocityRuleConfig trips once Max events happened within Window; the next one gets Action.
+type VelocityRuleConfig struct {
+	Max    int      `yaml:"max"`    // Events allowed per window (default: 0 = rule disabled)
+	Window Duration `yaml:"window"` // Sliding window
+	Action string   `yaml:"action"` // "block", "flag", or "require_review" (default: "block")
+}
+
+// Screening fail modes: what happens to a payment or refund when the screening provider fails.
+const (
+	ScreeningFailOpen   = "open"   // Allow it, logging the failure
+	ScreeningFailClosed = "closed" // Refuse it until the provider answers
+)
+
+// ScreeningConfig screens payer wallets before access is granted and recipient wallets before a
+// refund is approved, e.g. against a sanctions list.
+type ScreeningConfig struct {
+	Provider string   `yaml:"provider"`  // "chainalysis"; empty disables screening (default: "")
+	APIURL   string   `yaml:"api_url"`   // Overrides the provider's API base URL
+	APIKey   string   `yaml:"api_key"`   // Provider API key
+	CacheTTL Duration `yaml:"cache_ttl"` // How long a wallet's result is reused (default: 24h)
+	Timeout  Duration `yaml:"timeout"`   // Per-request timeout to the provider (default: 5s)
+	FailMode string   `yaml:"fail_mode"` // "open" or "closed" when the provider fails (default: "closed")
+}
+
+// Pending refund policy actions.
+const (
+	RefundPendingActionDeny     = "deny"
+	RefundPendingActionEscalate = "escalate"
+)
+
+// RefundPolicyConfig acts on refund requests that stay pending: after PendingExpiryDays they are
+// denied, or escalated (flagged once, with a callback) for a second look.
+type RefundPolicyConfig struct {
+	PendingExpiryDays   int      `yaml:"pending_expiry_days"`   // Days a request may stay pending (default: 0 = never expire)
+	PendingExpiryAction string   `yaml:"pending_expiry_action"` // "deny" or "escalate" (default: "deny")
+	CheckInterval       Duration `yaml:"check_interval"`        // How often pending requests are checked (default: 1h)
+}
+
+// CartShippingConfig prices a shipping line on cart quotes. Amounts are major units of the
+// cart's token. Table rates are keyed by the cart's "country" metadata.
+type CartShippingConfig struct {
+	Mode       string            `yaml:"mode"`        // "flat" or "table" (default: "" = no shipping line)
+	Label      string            `yaml:"label"`       // Line label (default: "Shipping")
+	FlatAmount string            `yaml:"flat_amount"` // Amount for every cart in flat mode, e.g. "5.00"
+	Rates      map[string]string `yaml:"rates"`       // Country code -> amount in table mode; "*" matches any other country
+}
+
+// CartTaxConfig prices a tax line on cart quotes as a percentage of the discounted item total.
+// Table rates are keyed by the cart's "country" metadata; countries without a rate are not taxed.
+type CartTaxConfig struct {
+	Mode            string             `yaml:"mode"`             // "flat" or "table" (default: "" = no tax line)
+	Label           string             `yaml:"label"`            // Line label (default: "Tax")
+	RatePercent     float64            `yaml:"rate_percent"`     // Rate for every cart in flat mode, e.g. 8.25
+	Rates           map[string]float64 `yaml:"rates"`            // Country code -> rate percent in table mode; "*" matches any other country
+	IncludeShipping bool               `yaml:"include_shipping"` // Tax the shipping line too (default: false)
 }
 
 // PaywallResource defines a single protected resource with pricing.
@@ -163,6 +460,57 @@ type PaywallResource struct {
 
 	// Subscription configuration (nil/empty = one-time purchase)
 	Subscription *SubscriptionResourceConfig `yaml:"subscription,omitempty"`
+
+	// Variants (size, tier, duration) selectable in cart items, keyed by variant ID
+	Variants map[string]ResourceVariant `yaml:"variants,omitempty"`
+
+	// Bundle lists the resources a purchase of this resource also grants access to. The
+	// resource's own price is the bundle price.
+	Bundle []string `yaml:"bundle,omitempty"`
+
+	// PriceTiers are quantity price breaks for cart items (e.g. 10+ units at a lower price)
+	PriceTiers []PriceTier `yaml:"price_tiers,omitempty"`
+
+	// FiatPrices prices the resource in other fiat currencies, keyed by lowercase currency code
+	// (e.g. "eur"). Stripe checkouts select one by explicit currency or Accept-Language.
+	FiatPrices map[string]FiatPrice `yaml:"fiat_prices,omitempty"`
+
+	// PriceFromFiat quotes x402 payments by converting the fiat price into crypto_token at the
+	// x402.rate_oracle rate when the quote is issued, instead of a fixed crypto_atomic_amount
+	PriceFromFiat bool `yaml:"price_from_fiat,omitempty"`
+
+	// StripePaymentMethods replaces stripe.payment_methods for Stripe checkouts of this resource
+	StripePaymentMethods []string `yaml:"stripe_payment_methods,omitempty"`
+
+	// StripeConnectedAccount is the seller's Stripe Connect account (acct_...) that Stripe
+	// payments for this resource are transferred to, less the platform's application fee
+	StripeConnectedAccount string `yaml:"stripe_connected_account,omitempty"`
+	// StripeApplicationFeePercent overrides stripe.connect.application_fee_percent
+	StripeApplicationFeePercent *float64 `yaml:"stripe_application_fee_percent,omitempty"`
+}
+
+// FiatPrice is a resource's price in one fiat currency.
+type FiatPrice struct {
+	AmountCents   int64  `yaml:"amount_cents"`    // In the currency's smallest unit
+	StripePriceID string `yaml:"stripe_price_id"` // Stripe price in this currency; takes precedence over amount_cents
+}
+
+// PriceTier prices every unit of a cart line at CryptoAtomicAmount once the line's quantity
+// reaches MinQuantity. The highest tier reached applies; below every tier the resource's own
+// crypto price does.
+type PriceTier struct {
+	MinQuantity        int64 `yaml:"min_quantity"`
+	CryptoAtomicAmount int64 `yaml:"crypto_atomic_amount"` // Unit price in the resource's crypto token
+}
+
+// ResourceVariant is a variant of a resource with its own prices and metadata. Unset prices
+// fall back to the resource's; amounts are in the resource's fiat currency and crypto token.
+type ResourceVariant struct {
+	Description        string            `yaml:"description"`          // Replaces the resource's description
+	StripePriceID      string            `yaml:"stripe_price_id"`      // Stripe price for cart checkouts
+	FiatAmountCents    int64             `yaml:"fiat_amount_cents"`    // 0 = resource's fiat price
+	CryptoAtomicAmount int64             `yaml:"crypto_atomic_amount"` // 0 = resource's crypto price
+	Metadata           map[string]string `yaml:"metadata"`             // Merged over the resource's metadata
 }
 
 // SubscriptionResourceConfig defines subscription billing for a YAML resource.
@@ -181,10 +529,112 @@ type CallbacksConfig struct {
 	Headers           map[string]string `yaml:"headers"`
 	Body              string            `yaml:"body"`
 	BodyTemplate      string            `yaml:"body_template"`
+	BodyTemplates     map[string]string `yaml:"body_templates"` // Per event type, overriding body and body_template
+	Priority          string            `yaml:"priority"`       // Queue priority: high, normal, or low (default: high for payment events)
 	Timeout           Duration          `yaml:"timeout"`
-	Retry             RetryConfig       `yaml:"retry"`       // Retry configuration with exponential backoff
-	DLQEnabled        bool              `yaml:"dlq_enabled"` // Enable dead letter queue for failed webhooks
-	DLQPath           string            `yaml:"dlq_path"`    // File path for DLQ storage (default: ./data/webhook-dlq.json)
+	Retry             RetryConfig       `yaml:"retry"`        // Retry configuration with exponential backoff
+	DLQEnabled        bool              `yaml:"dlq_enabled"`  // Enable dead letter queue for failed webhooks
+	DLQPath           string            `yaml:"dlq_path"`     // File path for DLQ storage (default: ./data/webhook-dlq.json)
+	DLQRotation       DLQRotationConfig `yaml:"dlq_rotation"` // Size and age limits on the DLQ file
+	DLQOffload        DLQOffloadConfig  `yaml:"dlq_offload"`  // Optional S3/GCS archive for rotated DLQ entries
+	NATS              NATSConfig        `yaml:"nats"`         // Optional NATS JetStream event sink
+	AWS               AWSDeliveryConfig `yaml:"aws"`          // Credentials for SQS/SNS webhook targets
+	PubSub            PubSubConfig      `yaml:"pubsub"`       // Optional Google Cloud Pub/Sub event sink
+
+	// Destinations receive webhooks alongside PaymentSuccessURL, each for the events it lists
+	Destinations []CallbackDestinationConfig `yaml:"destinations"`
+
+	// MaxInFlight and RequestsPerSecond limit concurrent deliveries and delivery starts per
+	// second to each destination URL, PaymentSuccessURL included (0 = unlimited)
+	MaxInFlight       int     `yaml:"max_in_flight"`
+	RequestsPerSecond float64 `yaml:"requests_per_second"`
+
+	TLS CallbackTLSConfig `yaml:"tls"` // Client certificate and CA bundle for PaymentSuccessURL
+}
+
+// CallbackDestinationConfig is one webhook destination. Each destination retries on its own,
+// so a failing endpoint doesn't hold up or repeat deliveries to the others.
+type CallbackDestinationConfig struct {
+	URL          string            `yaml:"url"`           // HTTP(S) URL, or an SQS/SNS ARN
+	Events       []string          `yaml:"events"`        // payment, payment_failed, refund, subscription, refund_request; empty for all
+	Headers      map[string]string `yaml:"headers"`       // Sent instead of callbacks.headers
+	Body         string            `yaml:"body"`          // Optional static payload
+	BodyTemplate string            `yaml:"body_template"` // Optional Go template rendered with the event
+	TLS          CallbackTLSConfig `yaml:"tls"`           // Client certificate and CA bundle for this destination
+	Priority     string            `yaml:"priority"`      // Queue priority: high, normal, or low (default: high for payment events)
+
+	// Retry overrides callbacks.retry for this destination; fields left unset keep the
+	// callbacks.retry values
+	Retry *RetryConfig `yaml:"retry"`
+
+	// MaxInFlight and RequestsPerSecond override callbacks.max_in_flight and
+	// callbacks.requests_per_second for this destination
+	MaxInFlight       int     `yaml:"max_in_flight"`
+	RequestsPerSecond float64 `yaml:"requests_per_second"`
+
+	// BodyTemplates are templates per event type (payment, refund, ...), overriding body and
+	// body_template for those events
+	BodyTemplates map[string]string `yaml:"body_templates"`
+}
+
+// CallbackTLSConfig configures TLS for webhook receivers that require mutual TLS or whose
+// certificates are signed by a private CA.
+type CallbackTLSConfig struct {
+	CertFile string `yaml:"cert_file"` // PEM client certificate presented for mutual TLS; requires key_file
+	KeyFile  string `yaml:"key_file"`  // PEM private key for cert_file
+	CAFile   string `yaml:"ca_file"`   // PEM CA bundle trusted in addition to the system roots
+}
+
+// DLQRotationConfig bounds the DLQ file. Entries past a limit are rotated out, oldest first,
+// into a segment stored in dlq_offload when set, or in a directory beside dlq_path otherwise.
+type DLQRotationConfig struct {
+	MaxBytes int      `yaml:"max_bytes"` // Rotate the oldest entries out once the file would exceed this (0 = unlimited)
+	MaxAge   Duration `yaml:"max_age"`   // Rotate out entries last attempted longer ago than this (0 = unlimited)
+	MaxFiles int      `yaml:"max_files"` // Local segments kept beside dlq_path (default: 10; unused with dlq_offload)
+}
+
+// DLQOffloadConfig archives rotated DLQ segments in object storage. S3 uses the callbacks.aws
+// credentials; GCS uses credentials_file or Application Default Credentials.
+type DLQOffloadConfig struct {
+	URL             string `yaml:"url"`              // s3://bucket/prefix or gs://bucket/prefix (empty = disabled)
+	Endpoint        string `yaml:"endpoint"`         // Optional endpoint override (e.g., MinIO or a GCS emulator)
+	CredentialsFile string `yaml:"credentials_file"` // GCS service account JSON; empty uses Application Default Credentials
+}
+
+// PubSubConfig configures publishing of payment/refund events to Google Cloud Pub/Sub.
+// Messages carry the same JSON body as webhooks, with event_id and event_type attributes.
+type PubSubConfig struct {
+	ProjectID       string   `yaml:"project_id"`       // GCP project ID (empty = disabled)
+	Topic           string   `yaml:"topic"`            // Topic name (not the full resource path)
+	CredentialsFile string   `yaml:"credentials_file"` // Service account JSON; empty uses Application Default Credentials
+	Endpoint        string   `yaml:"endpoint"`         // Optional API endpoint override (e.g., emulator at http://localhost:8085)
+	PublishTimeout  Duration `yaml:"publish_timeout"`  // Per-attempt publish timeout (default: 5s)
+	MaxAttempts     int      `yaml:"max_attempts"`     // Publish attempts before an event is dropped (default: 5)
+}
+
+// AWSDeliveryConfig configures delivery to SQS queues and SNS topics.
+// A callback destination is treated as an AWS target when it is an SQS or SNS ARN
+// (e.g., arn:aws:sqs:us-east-1:123456789012:payments). When keys are empty the
+// default AWS credential chain (env vars, shared config, instance/task role) is used.
+type AWSDeliveryConfig struct {
+	Region          string `yaml:"region"`            // Default region (the ARN's region is used per target)
+	AccessKeyID     string `yaml:"access_key_id"`     // Optional static access key
+	SecretAccessKey string `yaml:"secret_access_key"` // Optional static secret key
+	SessionToken    string `yaml:"session_token"`     // Optional session token for temporary credentials
+	Endpoint        string `yaml:"endpoint"`          // Optional endpoint override (e.g., LocalStack)
+}
+
+// NATSConfig configures publishing of payment/refund events to NATS JetStream.
+// Events are published to "<subject_prefix>.<eventType>" (e.g., cedros.events.payment.succeeded)
+// and each publish waits for a JetStream ack, giving at-least-once delivery.
+type NATSConfig struct {
+	URL             string   `yaml:"url"`              // NATS server URL(s), comma-separated (empty = disabled)
+	Stream          string   `yaml:"stream"`           // JetStream stream name; created/updated to cover the subject prefix if set
+	SubjectPrefix   string   `yaml:"subject_prefix"`   // Subject prefix for events (default: "cedros.events")
+	CredentialsFile string   `yaml:"credentials_file"` // Optional NATS user credentials (.creds) file
+	Token           string   `yaml:"token"`            // Optional NATS auth token
+	PublishTimeout  Duration `yaml:"publish_timeout"`  // Timeout waiting for a JetStream ack (default: 5s)
+	MaxAttempts     int      `yaml:"max_attempts"`     // Publish attempts before an event is dropped (default: 5)
 }
 
 // RetryConfig holds webhook retry configuration.
@@ -194,6 +644,11 @@ type RetryConfig struct {
 	InitialInterval Duration `yaml:"initial_interval"` // Initial backoff interval (default: 1s)
 	MaxInterval     Duration `yaml:"max_interval"`     // Maximum backoff interval (default: 5m)
 	Multiplier      float64  `yaml:"multiplier"`       // Backoff multiplier (default: 2.0)
+	Jitter          float64  `yaml:"jitter"`           // Fraction (0-1) of each delay that is random: 0.2 waits 80-100% of it (default: 0)
+
+	// Schedule lists the delay before each retry, replacing the exponential backoff; there are
+	// len(schedule) retries, so max_attempts is ignored
+	Schedule []Duration `yaml:"schedule"`
 }
 
 // MonitoringConfig holds balance monitoring configuration.
@@ -226,18 +681,31 @@ type StorageConfig struct {
 	RefundQuoteTTL  Duration            `yaml:"refund_quote_ttl"` // How long refund quotes remain valid (default: 15m)
 	CleanupInterval Duration            `yaml:"cleanup_interval"` // How often to clean up expired quotes (default: 5m)
 	SchemaMapping   SchemaMappingConfig `yaml:"schema_mapping"`   // Table/collection name mappings for all entities
+
+	SlowQueryThreshold Duration          `yaml:"slow_query_threshold"` // Log storage operations slower than this (default: 200ms, 0 disables)
+	ReplayCache        ReplayCacheConfig `yaml:"replay_cache"`         // In-memory front for payment replay checks
+}
+
+// ReplayCacheConfig fronts payment replay checks with an LRU of recent payments and a bloom
+// filter of seen signatures, so repeated checks skip the database.
+type ReplayCacheConfig struct {
+	Enabled            bool `yaml:"enabled"`             // Cache payment signatures in memory (default: false)
+	Size               int  `yaml:"size"`                // Recent payments kept in the LRU (default: 10000)
+	ExpectedSignatures int  `yaml:"expected_signatures"` // Signatures the bloom filter is sized for at a 1% false positive rate (default: 1000000)
+	SingleInstance     bool `yaml:"single_instance"`     // Only this instance records payments: warm the bloom filter from storage at startup and report signatures it has never seen as unprocessed without a query
 }
 
 // SchemaMappingConfig holds table/collection name mappings for custom schemas.
 type SchemaMappingConfig struct {
-	Payments     TableMappingConfig `yaml:"payments"`      // Payment transactions table/collection
-	Sessions     TableMappingConfig `yaml:"sessions"`      // Stripe sessions table/collection
-	Products     TableMappingConfig `yaml:"products"`      // Products table/collection
-	Coupons      TableMappingConfig `yaml:"coupons"`       // Coupons table/collection
-	CartQuotes   TableMappingConfig `yaml:"cart_quotes"`   // Cart quotes table/collection
-	RefundQuotes TableMappingConfig `yaml:"refund_quotes"` // Refund quotes table/collection
-	AdminNonces  TableMappingConfig `yaml:"admin_nonces"`  // Admin nonces table/collection
-	WebhookQueue TableMappingConfig `yaml:"webhook_queue"` // Webhook queue table/collection
+	Payments        TableMappingConfig `yaml:"payments"`         // Payment transactions table/collection
+	Sessions        TableMappingConfig `yaml:"sessions"`         // Stripe sessions table/collection
+	Products        TableMappingConfig `yaml:"products"`         // Products table/collection
+	Coupons         TableMappingConfig `yaml:"coupons"`          // Coupons table/collection
+	CartQuotes      TableMappingConfig `yaml:"cart_quotes"`      // Cart quotes table/collection
+	RefundQuotes    TableMappingConfig `yaml:"refund_quotes"`    // Refund quotes table/collection
+	AdminNonces     TableMappingConfig `yaml:"admin_nonces"`     // Admin nonces table/collection
+	WebhookQueue    TableMappingConfig `yaml:"webhook_queue"`    // Webhook queue table/collection
+	IdempotencyKeys TableMappingConfig `yaml:"idempotency_keys"` // Idempotency keys table
 }
 
 // TableMappingConfig defines a single table/collection mapping.
@@ -267,21 +735,22 @@ type CouponConfig struct {
 
 // Coupon defines a discount code in YAML configuration.
 type Coupon struct {
-	Code          string            `yaml:"code"`
-	DiscountType  string            `yaml:"discount_type"`  // "percentage" or "fixed"
-	DiscountValue float64           `yaml:"discount_value"` // Percentage (0-100) or fixed amount
-	Currency      string            `yaml:"currency"`       // For fixed discounts (usd, usdc, etc.)
-	Scope         string            `yaml:"scope"`          // "all" or "specific"
-	ProductIDs    []string          `yaml:"product_ids"`    // Applicable product IDs (for scope=specific)
-	PaymentMethod string            `yaml:"payment_method"` // Restrict to payment method: "stripe", "x402", or "" for any
-	AutoApply     bool              `yaml:"auto_apply"`     // If true, automatically apply to matching products
-	AppliesAt     string            `yaml:"applies_at"`     // When to display: "catalog" (product page) or "checkout" (cart only)
-	UsageLimit    *int              `yaml:"usage_limit"`    // nil = unlimited, N = max uses
-	UsageCount    int               `yaml:"usage_count"`    // Current redemption count
-	StartsAt      string            `yaml:"starts_at"`      // RFC3339 timestamp when coupon becomes valid
-	ExpiresAt     string            `yaml:"expires_at"`     // RFC3339 timestamp when coupon expires
-	Active        bool              `yaml:"active"`         // Enable/disable coupon
-	Metadata      map[string]string `yaml:"metadata"`       // Custom key-value pairs
+	Code                string            `yaml:"code"`
+	DiscountType        string            `yaml:"discount_type"`          // "percentage", "fixed", or "gift_card"
+	DiscountValue       float64           `yaml:"discount_value"`         // Percentage (0-100), fixed amount, or gift card face value (USD)
+	Currency            string            `yaml:"currency"`               // For fixed discounts (usd, usdc, etc.)
+	Scope               string            `yaml:"scope"`                  // "all" or "specific"
+	ProductIDs          []string          `yaml:"product_ids"`            // Applicable product IDs (for scope=specific)
+	PaymentMethod       string            `yaml:"payment_method"`         // Restrict to payment method: "stripe", "x402", or "" for any
+	AutoApply           bool              `yaml:"auto_apply"`             // If true, automatically apply to matching products
+	AppliesAt           string            `yaml:"applies_at"`             // When to display: "catalog" (product page) or "checkout" (cart only)
+	UsageLimit          *int              `yaml:"usage_limit"`            // nil = unlimited, N = max uses
+	UsageLimitPerWallet *int              `yaml:"usage_limit_per_wallet"` // nil = unlimited, N = max uses per wallet (x402) or email (Stripe)
+	UsageCount          int               `yaml:"usage_count"`            // Current redemption count
+	StartsAt            string            `yaml:"starts_at"`              // RFC3339 timestamp when coupon becomes valid
+	ExpiresAt           string            `yaml:"expires_at"`             // RFC3339 timestamp when coupon expires
+	Active              bool              `yaml:"active"`                 // Enable/disable coupon
+	Metadata            map[string]string `yaml:"metadata"`               // Custom key-value pairs
 }
 
 // LoggingConfig holds structured logging configuration.
@@ -308,6 +777,62 @@ type RateLimitConfig struct {
 	PerIPEnabled bool     `yaml:"per_ip_enabled"` // Enable per-IP rate limiting
 	PerIPLimit   int      `yaml:"per_ip_limit"`   // Requests allowed per IP per window
 	PerIPWindow  Duration `yaml:"per_ip_window"`  // Time window for per-IP limit
+
+	// Per-endpoint limits keyed by route pattern relative to server.route_prefix, optionally
+	// preceded by a method, e.g. "POST /paywall/v1/quote". Matching requests are limited per
+	// wallet (or IP) by the endpoint's limit instead of the per-wallet and per-IP limits.
+	Endpoints map[string]EndpointRateLimitConfig `yaml:"endpoints"`
+
+	// Where counts are kept. With "memory" every instance enforces the limits on its own, so
+	// the effective limit grows with the number of replicas.
+	Backend     string `yaml:"backend"`      // "memory" or "postgres" (default: "memory")
+	PostgresURL string `yaml:"postgres_url"` // For "postgres" (default: storage.postgres_url)
+}
+
+// EndpointRateLimitConfig is the limit for one endpoint pattern in RateLimitConfig.Endpoints.
+type EndpointRateLimitConfig struct {
+	Limit  int      `yaml:"limit"`  // Requests allowed per wallet (or IP) per window
+	Window Duration `yaml:"window"` // Time window (default: 1m)
+}
+
+// GeoRestrictionConfig blocks quote and payment endpoints by the client's country, for merchants
+// with licensing restrictions. Countries are ISO 3166-1 alpha-2 codes.
+type GeoRestrictionConfig struct {
+	Enabled          bool     `yaml:"enabled"`
+	AllowedCountries []string `yaml:"allowed_countries"` // When set, only these countries may get quotes and pay
+	BlockedCountries []string `yaml:"blocked_countries"` // Countries that may never get quotes or pay
+	CountryHeader    string   `yaml:"country_header"`    // Header with the country, set by a trusted CDN or proxy (e.g. CF-IPCountry)
+	Database         string   `yaml:"database"`          // IP-to-country CSV (start_ip,end_ip,country or cidr,country)
+	BlockUnknown     bool     `yaml:"block_unknown"`     // Block clients whose country can't be determined
+}
+
+// ProxyConfig puts an upstream origin behind the paywall: requests matching no Cedros route are
+// forwarded to Upstream, and those matching a pattern in Routes must pay for its resource first.
+type ProxyConfig struct {
+	Enabled  bool   `yaml:"enabled"`  // Enable reverse-proxy mode (default: false)
+	Upstream string `yaml:"upstream"` // Origin to forward to, e.g. "http://localhost:9000"
+
+	// Routes maps "METHOD /route" chi patterns (method optional, as in rate_limit.endpoints) to
+	// the resource a matching request pays for. Requests matching no pattern are forwarded free.
+	Routes map[string]string `yaml:"routes"`
+}
+
+// MeteringConfig charges API calls against prepaid balances instead of one payment per call.
+// Wallets (or API keys) top up by paying a deposit resource; each metered call then moves its
+// price from the balance to unsettled usage, which is recorded as one payment per account every
+// SettlementInterval.
+type MeteringConfig struct {
+	Enabled bool   `yaml:"enabled"` // Enable metering (default: false)
+	Asset   string `yaml:"asset"`   // Asset balances are held in (default: "USDC")
+
+	// DepositResources are the resources whose payment tops up a balance by the amount paid.
+	// They must be priced in Asset.
+	DepositResources []string `yaml:"deposit_resources"`
+
+	// Prices maps metered resources to their price per call in atomic units of Asset.
+	Prices map[string]int64 `yaml:"prices"`
+
+	SettlementInterval Duration `yaml:"settlement_interval"` // How often usage is recorded (default: 1h)
 }
 
 // APIKeyConfig holds API key authentication and tier configuration.
@@ -315,6 +840,11 @@ type RateLimitConfig struct {
 type APIKeyConfig struct {
 	Enabled bool              `yaml:"enabled"` // Enable API key authentication (default: false)
 	Keys    map[string]string `yaml:"keys"`    // Map of API key -> tier (free, pro, enterprise, partner)
+
+	// Scopes limits keys from Keys to the endpoints and gRPC methods of the listed scopes
+	// (quotes:read, payments:write, refunds:write, refunds:admin, webhooks:admin). Keys not
+	// listed may call anything.
+	Scopes map[string][]string `yaml:"scopes"`
 }
 
 // CircuitBreakerConfig holds circuit breaker configuration for external services.
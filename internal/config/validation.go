@@ -4,11 +4,18 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/CedrosPay/server/internal/apikey"
+	"github.com/CedrosPay/server/internal/bodytemplate"
 	"github.com/CedrosPay/server/internal/money"
+	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
 )
 
@@ -27,6 +34,9 @@ func (c *Config) finalize() error {
 	if c.Stripe.Mode == "" {
 		c.Stripe.Mode = "test"
 	}
+	if c.Stripe.WebhookDedupTTL.Duration <= 0 {
+		c.Stripe.WebhookDedupTTL = Duration{Duration: 72 * time.Hour}
+	}
 	if c.Server.Address == "" {
 		c.Server.Address = ":8080"
 	}
@@ -82,6 +92,16 @@ func (c *Config) finalize() error {
 		}
 	}
 
+	for pattern, endpoint := range c.RateLimit.Endpoints {
+		if endpoint.Window.Duration <= 0 {
+			endpoint.Window = Duration{Duration: time.Minute}
+			c.RateLimit.Endpoints[pattern] = endpoint
+		}
+	}
+	if c.RateLimit.Backend == "postgres" && c.RateLimit.PostgresURL == "" {
+		c.RateLimit.PostgresURL = c.Storage.PostgresURL
+	}
+
 	if c.Coupons.CouponSource == "postgres" {
 		if c.Coupons.PostgresURL == "" {
 			c.Coupons.PostgresURL = c.Storage.PostgresURL
@@ -113,6 +133,51 @@ func (c *Config) finalize() error {
 	if c.Callbacks.Headers == nil {
 		c.Callbacks.Headers = make(map[string]string)
 	}
+	if c.Callbacks.NATS.SubjectPrefix == "" {
+		c.Callbacks.NATS.SubjectPrefix = "cedros.events"
+	}
+	if c.Callbacks.NATS.PublishTimeout.Duration <= 0 {
+		c.Callbacks.NATS.PublishTimeout = Duration{Duration: 5 * time.Second}
+	}
+	if c.Callbacks.NATS.MaxAttempts <= 0 {
+		c.Callbacks.NATS.MaxAttempts = 5
+	}
+	if c.MerchantEvents.BufferSize <= 0 {
+		c.MerchantEvents.BufferSize = 64
+	}
+	if c.GRPC.Address == "" {
+		c.GRPC.Address = ":9090"
+	}
+	if c.Tracing.Protocol == "" {
+		c.Tracing.Protocol = "grpc"
+	}
+	if c.Tracing.ServiceName == "" {
+		c.Tracing.ServiceName = "cedros-pay"
+	}
+	if c.Tracing.SampleRatio == 0 {
+		c.Tracing.SampleRatio = 1.0
+	}
+	if c.AsyncVerify.Workers <= 0 {
+		c.AsyncVerify.Workers = 4
+	}
+	if c.AsyncVerify.QueueSize <= 0 {
+		c.AsyncVerify.QueueSize = 100
+	}
+	if c.AsyncVerify.ResultTTL.Duration <= 0 {
+		c.AsyncVerify.ResultTTL = Duration{Duration: 10 * time.Minute}
+	}
+	if c.AsyncVerify.Timeout.Duration <= 0 {
+		c.AsyncVerify.Timeout = Duration{Duration: 90 * time.Second}
+	}
+	if c.Callbacks.PubSub.PublishTimeout.Duration <= 0 {
+		c.Callbacks.PubSub.PublishTimeout = Duration{Duration: 5 * time.Second}
+	}
+	if c.Callbacks.PubSub.MaxAttempts <= 0 {
+		c.Callbacks.PubSub.MaxAttempts = 5
+	}
+	if c.Callbacks.DLQRotation.MaxFiles <= 0 {
+		c.Callbacks.DLQRotation.MaxFiles = 10
+	}
 	if c.Monitoring.LowBalanceThreshold <= 0 {
 		c.Monitoring.LowBalanceThreshold = 0.01
 	}
@@ -125,6 +190,39 @@ func (c *Config) finalize() error {
 	if c.Monitoring.Headers == nil {
 		c.Monitoring.Headers = make(map[string]string)
 	}
+	if c.X402.PriorityFee.Percentile == 0 {
+		c.X402.PriorityFee.Percentile = 75
+	}
+	if c.X402.PriorityFee.MinMicroLamports == 0 {
+		c.X402.PriorityFee.MinMicroLamports = c.X402.ComputeUnitPriceMicroLamports
+	}
+	if c.X402.PriorityFee.MaxMicroLamports == 0 {
+		c.X402.PriorityFee.MaxMicroLamports = 100000
+	}
+	if c.X402.PriorityFee.RefreshInterval.Duration <= 0 {
+		c.X402.PriorityFee.RefreshInterval = Duration{Duration: 10 * time.Second}
+	}
+	if c.X402.RPCHealthCheckInterval.Duration <= 0 {
+		c.X402.RPCHealthCheckInterval = Duration{Duration: 15 * time.Second}
+	}
+	if c.X402.RateOracle.CacheTTL.Duration <= 0 {
+		c.X402.RateOracle.CacheTTL = Duration{Duration: 30 * time.Second}
+	}
+	if c.X402.RateOracle.Timeout.Duration <= 0 {
+		c.X402.RateOracle.Timeout = Duration{Duration: 5 * time.Second}
+	}
+	if c.Paywall.Screening.CacheTTL.Duration <= 0 {
+		c.Paywall.Screening.CacheTTL = Duration{Duration: 24 * time.Hour}
+	}
+	if c.Paywall.Screening.Timeout.Duration <= 0 {
+		c.Paywall.Screening.Timeout = Duration{Duration: 5 * time.Second}
+	}
+	if c.Paywall.Screening.FailMode == "" {
+		c.Paywall.Screening.FailMode = ScreeningFailClosed
+	}
+	if c.X402.RefundNonceQuoteTTL.Duration <= 0 {
+		c.X402.RefundNonceQuoteTTL = Duration{Duration: 7 * 24 * time.Hour}
+	}
 	if c.X402.Commitment == "" {
 		c.X402.Commitment = string(rpc.CommitmentConfirmed)
 	}
@@ -172,9 +270,50 @@ func (c *Config) finalize() error {
 		c.Paywall.Resources[key] = resource
 	}
 
+	// Register configured assets first: validation looks tokens up in the money registry
+	if err := c.registerAssets(); err != nil {
+		return err
+	}
+
 	return c.validate()
 }
 
+// registerAssets adds the assets declared under assets to the money registry.
+func (c *Config) registerAssets() error {
+	for i, ac := range c.Assets {
+		asset := money.Asset{
+			Code:     strings.ToUpper(ac.Code),
+			Decimals: ac.Decimals,
+			Symbol:   ac.Symbol,
+		}
+		switch ac.Type {
+		case "", "spl":
+			if _, err := solana.PublicKeyFromBase58(ac.SolanaMint); err != nil {
+				return fmt.Errorf("assets[%d]: solana_mint is not a valid address: %v", i, err)
+			}
+			asset.Type = money.AssetTypeSPL
+			asset.Metadata.SolanaMint = ac.SolanaMint
+		case "fiat":
+			if ac.Stablecoin {
+				return fmt.Errorf("assets[%d]: only spl assets can be stablecoins", i)
+			}
+			asset.Type = money.AssetTypeFiat
+			asset.Metadata.StripeCurrency = strings.ToLower(ac.StripeCurrency)
+		default:
+			return fmt.Errorf("assets[%d]: type must be \"spl\" or \"fiat\"", i)
+		}
+
+		register := money.RegisterAsset
+		if ac.Stablecoin {
+			register = money.RegisterStablecoin
+		}
+		if err := register(asset); err != nil {
+			return fmt.Errorf("assets[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
 // validate checks that required configuration fields are set correctly.
 func (c *Config) validate() error {
 	var errs []string
@@ -183,6 +322,20 @@ func (c *Config) validate() error {
 	if c.Stripe.SecretKey == "" && c.Stripe.PublishableKey != "" {
 		errs = append(errs, "stripe.secret_key is required when publishable key is set")
 	}
+	if err := ValidateStripePaymentMethods(c.Stripe.PaymentMethods); err != nil {
+		errs = append(errs, fmt.Sprintf("stripe.payment_methods: %v", err))
+	}
+	if c.Stripe.AutomaticTax && c.Stripe.TaxRateID != "" {
+		errs = append(errs, "stripe.automatic_tax and stripe.tax_rate_id cannot be combined")
+	}
+	if fee := c.Stripe.Connect.ApplicationFeePercent; fee < 0 || fee > 100 {
+		errs = append(errs, "stripe.connect.application_fee_percent must be between 0 and 100")
+	}
+	switch c.Stripe.TaxBehavior {
+	case "", StripeTaxBehaviorExclusive, StripeTaxBehaviorInclusive:
+	default:
+		errs = append(errs, fmt.Sprintf("stripe.tax_behavior must be %q or %q", StripeTaxBehaviorExclusive, StripeTaxBehaviorInclusive))
+	}
 
 	// Paywall validation
 	// Only require resources when using YAML product source (default)
@@ -197,6 +350,130 @@ func (c *Config) validate() error {
 		if resource.FiatAmountCents <= 0 && resource.CryptoAtomicAmount <= 0 && resource.StripePriceID == "" {
 			errs = append(errs, fmt.Sprintf("paywall.resource %q must define fiat_amount_cents, crypto_atomic_amount, or stripe_price_id", name))
 		}
+		if err := ValidateStripePaymentMethods(resource.StripePaymentMethods); err != nil {
+			errs = append(errs, fmt.Sprintf("paywall.resource %q stripe_payment_methods: %v", name, err))
+		}
+		if account := resource.StripeConnectedAccount; account != "" && !strings.HasPrefix(account, "acct_") {
+			errs = append(errs, fmt.Sprintf("paywall.resource %q stripe_connected_account must be a Stripe account ID (acct_...)", name))
+		}
+		if fee := resource.StripeApplicationFeePercent; fee != nil && (*fee < 0 || *fee > 100) {
+			errs = append(errs, fmt.Sprintf("paywall.resource %q stripe_application_fee_percent must be between 0 and 100", name))
+		}
+		for id, variant := range resource.Variants {
+			if id == "" || strings.Contains(id, "/") {
+				errs = append(errs, fmt.Sprintf("paywall.resource %q has a variant with an invalid id %q", name, id))
+			}
+			if variant.FiatAmountCents < 0 || variant.CryptoAtomicAmount < 0 {
+				errs = append(errs, fmt.Sprintf("paywall.resource %q variant %q must not have negative prices", name, id))
+			}
+		}
+		seen := make(map[string]bool, len(resource.Bundle))
+		for _, member := range resource.Bundle {
+			memberResource, known := c.Paywall.Resources[member]
+			switch {
+			case member == name:
+				errs = append(errs, fmt.Sprintf("paywall.resource %q cannot bundle itself", name))
+			case seen[member]:
+				errs = append(errs, fmt.Sprintf("paywall.resource %q bundles %q more than once", name, member))
+			case !known:
+				errs = append(errs, fmt.Sprintf("paywall.resource %q bundles unknown resource %q", name, member))
+			case len(memberResource.Bundle) > 0:
+				errs = append(errs, fmt.Sprintf("paywall.resource %q bundles %q, which is itself a bundle", name, member))
+			}
+			seen[member] = true
+		}
+		tierQuantities := make(map[int64]bool, len(resource.PriceTiers))
+		for _, tier := range resource.PriceTiers {
+			if tier.MinQuantity < 1 || tier.CryptoAtomicAmount <= 0 {
+				errs = append(errs, fmt.Sprintf("paywall.resource %q price tiers need a positive min_quantity and crypto_atomic_amount", name))
+			} else if tierQuantities[tier.MinQuantity] {
+				errs = append(errs, fmt.Sprintf("paywall.resource %q has two price tiers at min_quantity %d", name, tier.MinQuantity))
+			}
+			tierQuantities[tier.MinQuantity] = true
+		}
+		if len(resource.PriceTiers) > 0 && resource.CryptoAtomicAmount <= 0 {
+			errs = append(errs, fmt.Sprintf("paywall.resource %q needs crypto_atomic_amount to use price_tiers", name))
+		}
+		for currency, price := range resource.FiatPrices {
+			if asset, err := money.GetAsset(strings.ToUpper(currency)); err != nil || !asset.IsStripeCurrency() || currency != strings.ToLower(currency) {
+				errs = append(errs, fmt.Sprintf("paywall.resource %q fiat_prices: %q is not a supported lowercase fiat currency", name, currency))
+			}
+			if price.AmountCents <= 0 && price.StripePriceID == "" {
+				errs = append(errs, fmt.Sprintf("paywall.resource %q fiat_prices.%s must define amount_cents or stripe_price_id", name, currency))
+			}
+		}
+		if resource.PriceFromFiat {
+			if resource.FiatAmountCents <= 0 {
+				errs = append(errs, fmt.Sprintf("paywall.resource %q needs fiat_amount_cents to use price_from_fiat", name))
+			}
+			if resource.CryptoAtomicAmount > 0 || len(resource.PriceTiers) > 0 {
+				errs = append(errs, fmt.Sprintf("paywall.resource %q cannot combine price_from_fiat with crypto_atomic_amount or price_tiers", name))
+			}
+			if c.X402.RateOracle.Provider == "" {
+				errs = append(errs, fmt.Sprintf("paywall.resource %q uses price_from_fiat, which requires x402.rate_oracle.provider", name))
+			}
+		}
+	}
+	switch c.Paywall.Shipping.Mode {
+	case "":
+	case "flat":
+		if !validLineAmount(c.Paywall.Shipping.FlatAmount) {
+			errs = append(errs, "paywall.shipping.flat_amount must be a non-negative decimal amount in flat mode")
+		}
+	case "table":
+		if len(c.Paywall.Shipping.Rates) == 0 {
+			errs = append(errs, "paywall.shipping.rates must define at least one country in table mode")
+		}
+		for country, amount := range c.Paywall.Shipping.Rates {
+			if !validLineAmount(amount) {
+				errs = append(errs, fmt.Sprintf("paywall.shipping.rates.%s must be a non-negative decimal amount", country))
+			}
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("paywall.shipping.mode %q must be \"flat\" or \"table\"", c.Paywall.Shipping.Mode))
+	}
+	switch c.Paywall.Tax.Mode {
+	case "":
+	case "flat":
+		if c.Paywall.Tax.RatePercent <= 0 || c.Paywall.Tax.RatePercent > 100 {
+			errs = append(errs, "paywall.tax.rate_percent must be between 0 and 100 in flat mode")
+		}
+	case "table":
+		if len(c.Paywall.Tax.Rates) == 0 {
+			errs = append(errs, "paywall.tax.rates must define at least one country in table mode")
+		}
+		for country, rate := range c.Paywall.Tax.Rates {
+			if rate < 0 || rate > 100 {
+				errs = append(errs, fmt.Sprintf("paywall.tax.rates.%s must be between 0 and 100", country))
+			}
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("paywall.tax.mode %q must be \"flat\" or \"table\"", c.Paywall.Tax.Mode))
+	}
+	if c.Paywall.Refunds.PendingExpiryDays < 0 {
+		errs = append(errs, "paywall.refunds.pending_expiry_days must not be negative")
+	}
+	switch c.Paywall.Refunds.PendingExpiryAction {
+	case "", RefundPendingActionDeny, RefundPendingActionEscalate:
+	default:
+		errs = append(errs, fmt.Sprintf("paywall.refunds.pending_expiry_action %q must be %q or %q", c.Paywall.Refunds.PendingExpiryAction, RefundPendingActionDeny, RefundPendingActionEscalate))
+	}
+	errs = append(errs, validateVelocityRule("paywall.fraud.payments_per_wallet", c.Paywall.Fraud.PaymentsPerWallet, false)...)
+	errs = append(errs, validateVelocityRule("paywall.fraud.refunds_per_wallet", c.Paywall.Fraud.RefundsPerWallet, true)...)
+	errs = append(errs, validateVelocityRule("paywall.fraud.quotes_per_ip", c.Paywall.Fraud.QuotesPerIP, false)...)
+	switch c.Paywall.Screening.Provider {
+	case "":
+	case "chainalysis":
+		if c.Paywall.Screening.APIKey == "" {
+			errs = append(errs, "paywall.screening.api_key is required for the chainalysis provider")
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("paywall.screening.provider %q must be chainalysis", c.Paywall.Screening.Provider))
+	}
+	switch c.Paywall.Screening.FailMode {
+	case "", ScreeningFailOpen, ScreeningFailClosed:
+	default:
+		errs = append(errs, fmt.Sprintf("paywall.screening.fail_mode %q must be %q or %q", c.Paywall.Screening.FailMode, ScreeningFailOpen, ScreeningFailClosed))
 	}
 
 	// x402 validation
@@ -215,8 +492,122 @@ func (c *Config) validate() error {
 	if c.X402.RPCURL == "" {
 		errs = append(errs, "x402.rpc_url is required")
 	}
-	if (c.X402.GaslessEnabled || c.X402.AutoCreateTokenAccount) && len(c.X402.ServerWalletKeys) == 0 {
-		errs = append(errs, "x402.server_wallet_keys (X402_SERVER_WALLET_1, X402_SERVER_WALLET_2, ...) is required when gasless_enabled or auto_create_token_account is enabled")
+	for i, rpcURL := range c.X402.RPCURLs {
+		if u, err := url.Parse(rpcURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Sprintf("x402.rpc_urls[%d] must be an http(s) URL", i))
+		}
+	}
+	if c.X402.SquadsMultisig != "" {
+		if _, err := solana.PublicKeyFromBase58(c.X402.SquadsMultisig); err != nil {
+			errs = append(errs, fmt.Sprintf("x402.squads_multisig is not a valid address: %v", err))
+		}
+	}
+	if c.X402.SquadsVaultIndex < 0 || c.X402.SquadsVaultIndex > 255 {
+		errs = append(errs, "x402.squads_vault_index must be between 0 and 255")
+	}
+	if c.X402.SpecVersion != 0 && c.X402.SpecVersion != 1 {
+		errs = append(errs, "x402.spec_version must be 0 or 1")
+	}
+	if c.X402.RefundNonceAccount != "" {
+		if _, err := solana.PublicKeyFromBase58(c.X402.RefundNonceAccount); err != nil {
+			errs = append(errs, fmt.Sprintf("x402.refund_nonce_account is not a valid address: %v", err))
+		}
+		if c.X402.SquadsMultisig != "" {
+			errs = append(errs, "x402.refund_nonce_account cannot be combined with x402.squads_multisig (refunds are multisig proposals)")
+		}
+	}
+	if c.X402.CartSettlementToken != "" {
+		if asset, err := money.GetAsset(c.X402.CartSettlementToken); err != nil || !asset.IsSPLToken() {
+			errs = append(errs, fmt.Sprintf("x402.cart_settlement_token %q is not a known SPL token", c.X402.CartSettlementToken))
+		}
+	}
+	for token, rate := range c.X402.TokenRates {
+		if _, err := money.GetAsset(token); err != nil {
+			errs = append(errs, fmt.Sprintf("x402.token_rates: unknown token %q", token))
+		}
+		if rate <= 0 {
+			errs = append(errs, fmt.Sprintf("x402.token_rates.%s must be positive", token))
+		}
+	}
+	switch c.X402.RateOracle.Provider {
+	case "", "coingecko", "pyth":
+	case "fixed":
+		for pair, rate := range c.X402.RateOracle.FixedRates {
+			from, to, ok := strings.Cut(pair, "/")
+			if !ok || from == "" || to == "" || rate <= 0 {
+				errs = append(errs, fmt.Sprintf("x402.rate_oracle.fixed_rates: %q must be a FROM/TO pair with a positive rate", pair))
+			}
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("x402.rate_oracle.provider %q must be fixed, coingecko, or pyth", c.X402.RateOracle.Provider))
+	}
+	if c.X402.GaslessDailyBudgetSOL < 0 {
+		errs = append(errs, "x402.gasless_daily_budget_sol must not be negative")
+	}
+	if c.X402.PriorityFee.Percentile < 1 || c.X402.PriorityFee.Percentile > 100 {
+		errs = append(errs, "x402.priority_fee.percentile must be between 1 and 100")
+	}
+	if c.X402.PriorityFee.MaxMicroLamports < c.X402.PriorityFee.MinMicroLamports {
+		errs = append(errs, "x402.priority_fee.max_micro_lamports must be at least min_micro_lamports")
+	}
+	if (c.X402.GaslessEnabled || c.X402.AutoCreateTokenAccount || c.X402.PrewarmTokenAccounts) && len(c.X402.ServerWalletKeys) == 0 {
+		errs = append(errs, "x402.server_wallet_keys (X402_SERVER_WALLET_1, X402_SERVER_WALLET_2, ...) is required when gasless_enabled, auto_create_token_account, or prewarm_token_accounts is enabled")
+	}
+
+	// Callback sink validation
+	if c.Callbacks.PubSub.ProjectID != "" && c.Callbacks.PubSub.Topic == "" {
+		errs = append(errs, "callbacks.pubsub.topic is required when callbacks.pubsub.project_id is set")
+	}
+	errs = append(errs, validateBodyTemplates("callbacks", c.Callbacks.BodyTemplates)...)
+	errs = append(errs, validateCallbackPriority("callbacks", c.Callbacks.Priority)...)
+	errs = append(errs, validateRetrySchedule("callbacks.retry", c.Callbacks.Retry)...)
+	errs = append(errs, validateDeliveryLimits("callbacks", c.Callbacks.MaxInFlight, c.Callbacks.RequestsPerSecond)...)
+	errs = append(errs, validateCallbackTLS("callbacks", c.Callbacks.PaymentSuccessURL, c.Callbacks.TLS)...)
+	errs = append(errs, validateCallbackDestinations(c.Callbacks.Destinations)...)
+	errs = append(errs, validateDLQRotation(c.Callbacks)...)
+
+	errs = append(errs, validateAPIKeyScopes(c.APIKey)...)
+	if c.GRPC.Enabled && !c.GRPC.AllowUnauthenticated && len(c.APIKey.Keys) == 0 {
+		errs = append(errs, "api_key.keys must define at least one key when grpc is enabled (or set grpc.allow_unauthenticated)")
+	}
+
+	if c.Server.DrainTimeout.Duration < 0 {
+		errs = append(errs, "server.drain_timeout must not be negative")
+	}
+
+	if c.Tracing.Protocol != "grpc" && c.Tracing.Protocol != "http" {
+		errs = append(errs, fmt.Sprintf("tracing.protocol must be \"grpc\" or \"http\", got %q", c.Tracing.Protocol))
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		errs = append(errs, "tracing.sample_ratio must be between 0 and 1")
+	}
+
+	switch c.RateLimit.Backend {
+	case "", "memory":
+	case "postgres":
+		if c.RateLimit.PostgresURL == "" {
+			errs = append(errs, "rate_limit.postgres_url (or storage.postgres_url) is required for the postgres backend")
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("rate_limit.backend %q must be memory or postgres", c.RateLimit.Backend))
+	}
+
+	errs = append(errs, validateRateLimitEndpoints(c.RateLimit.Endpoints)...)
+
+	if c.GeoRestriction.Enabled {
+		errs = append(errs, validateGeoRestriction(c.GeoRestriction)...)
+	}
+
+	if c.Proxy.Enabled {
+		errs = append(errs, validateProxy(c.Proxy)...)
+	}
+
+	if c.Metering.Enabled {
+		errs = append(errs, validateMetering(c.Metering)...)
+	}
+
+	if c.MerchantEvents.Enabled && len(c.MerchantEvents.Keys) == 0 {
+		errs = append(errs, "merchant_events.keys must define at least one key when merchant_events is enabled")
 	}
 
 	// Auto-derive WebSocket URL if not set
@@ -305,6 +696,294 @@ func ApplyPostgresPoolSettings(db *sql.DB, pool PostgresPoolConfig) {
 	db.SetConnMaxLifetime(maxLifetime)
 }
 
+// ParseRateLimitEndpoint splits a rate_limit.endpoints pattern into its method (empty for any
+// method) and route, e.g. "POST /paywall/v1/quote" into "POST" and "/paywall/v1/quote".
+func ParseRateLimitEndpoint(pattern string) (method, route string, err error) {
+	method, route, ok := strings.Cut(strings.TrimSpace(pattern), " ")
+	if !ok {
+		method, route = "", method
+	}
+	route = strings.TrimSpace(route)
+	if !strings.HasPrefix(route, "/") {
+		return "", "", fmt.Errorf("route %q must start with /", route)
+	}
+	switch method = strings.ToUpper(method); method {
+	case "", http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		return "", "", fmt.Errorf("unknown method %q", method)
+	}
+	return method, route, nil
+}
+
+// validateRateLimitEndpoints checks the rate_limit.endpoints patterns and limits.
+func validateRateLimitEndpoints(endpoints map[string]EndpointRateLimitConfig) []string {
+	patterns := make([]string, 0, len(endpoints))
+	for pattern := range endpoints {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	var errs []string
+	for _, pattern := range patterns {
+		if _, _, err := ParseRateLimitEndpoint(pattern); err != nil {
+			errs = append(errs, fmt.Sprintf("rate_limit.endpoints: %q: %v", pattern, err))
+		}
+		if endpoints[pattern].Limit <= 0 {
+			errs = append(errs, fmt.Sprintf("rate_limit.endpoints.%q.limit must be positive", pattern))
+		}
+	}
+	return errs
+}
+
+// CallbackEventTypes are the event types a callback destination can subscribe to.
+var CallbackEventTypes = []string{"payment", "payment_failed", "refund", "subscription", "refund_request"}
+
+// validateCallbackDestinations checks callbacks.destinations.
+func validateCallbackDestinations(destinations []CallbackDestinationConfig) []string {
+	var errs []string
+	for i, dest := range destinations {
+		name := fmt.Sprintf("callbacks.destinations[%d]", i)
+		if dest.URL == "" {
+			errs = append(errs, name+".url is required")
+		}
+		for _, event := range dest.Events {
+			if !slices.Contains(CallbackEventTypes, event) {
+				errs = append(errs, fmt.Sprintf("%s.events: unknown event %q (want one of %v)", name, event, CallbackEventTypes))
+			}
+		}
+		if dest.BodyTemplate != "" {
+			if _, err := bodytemplate.Parse("callback", dest.BodyTemplate); err != nil {
+				errs = append(errs, fmt.Sprintf("%s.body_template: %v", name, err))
+			}
+		}
+		errs = append(errs, validateBodyTemplates(name, dest.BodyTemplates)...)
+		errs = append(errs, validateCallbackPriority(name, dest.Priority)...)
+		if dest.Retry != nil {
+			errs = append(errs, validateRetrySchedule(name+".retry", *dest.Retry)...)
+		}
+		errs = append(errs, validateDeliveryLimits(name, dest.MaxInFlight, dest.RequestsPerSecond)...)
+		errs = append(errs, validateCallbackTLS(name, dest.URL, dest.TLS)...)
+	}
+	return errs
+}
+
+// CallbackPriorities are the webhook queue priorities a callback destination can set.
+var CallbackPriorities = []string{"high", "normal", "low"}
+
+// validateCallbackPriority checks a destination's priority, which may be empty.
+func validateCallbackPriority(name, priority string) []string {
+	if priority == "" || slices.Contains(CallbackPriorities, priority) {
+		return nil
+	}
+	return []string{fmt.Sprintf("%s.priority: unknown priority %q (want one of %v)", name, priority, CallbackPriorities)}
+}
+
+// validateRetrySchedule checks a retry config's jitter and fixed schedule.
+func validateRetrySchedule(name string, retry RetryConfig) []string {
+	var errs []string
+	if retry.Jitter < 0 || retry.Jitter > 1 {
+		errs = append(errs, fmt.Sprintf("%s.jitter must be between 0 and 1, got %v", name, retry.Jitter))
+	}
+	for i, delay := range retry.Schedule {
+		if delay.Duration <= 0 {
+			errs = append(errs, fmt.Sprintf("%s.schedule[%d] must be positive", name, i))
+		}
+	}
+	return errs
+}
+
+// validateDeliveryLimits checks a destination's max_in_flight and requests_per_second.
+func validateDeliveryLimits(name string, maxInFlight int, perSecond float64) []string {
+	var errs []string
+	if maxInFlight < 0 {
+		errs = append(errs, name+".max_in_flight must not be negative")
+	}
+	if perSecond < 0 {
+		errs = append(errs, name+".requests_per_second must not be negative")
+	}
+	return errs
+}
+
+// validateBodyTemplates checks body_templates: each key is an event type and each template parses.
+func validateBodyTemplates(name string, templates map[string]string) []string {
+	var errs []string
+	for event, text := range templates {
+		if !slices.Contains(CallbackEventTypes, event) {
+			errs = append(errs, fmt.Sprintf("%s.body_templates: unknown event %q (want one of %v)", name, event, CallbackEventTypes))
+			continue
+		}
+		if _, err := bodytemplate.Parse("callback", text); err != nil {
+			errs = append(errs, fmt.Sprintf("%s.body_templates.%s: %v", name, event, err))
+		}
+	}
+	slices.Sort(errs)
+	return errs
+}
+
+// validateDLQRotation checks the DLQ size and age limits and the offload target.
+func validateDLQRotation(cfg CallbacksConfig) []string {
+	var errs []string
+	if cfg.DLQRotation.MaxBytes < 0 {
+		errs = append(errs, "callbacks.dlq_rotation.max_bytes must not be negative")
+	}
+	if cfg.DLQRotation.MaxAge.Duration < 0 {
+		errs = append(errs, "callbacks.dlq_rotation.max_age must not be negative")
+	}
+	if target := cfg.DLQOffload.URL; target != "" {
+		scheme, bucket, _ := strings.Cut(target, "://")
+		bucket, _, _ = strings.Cut(bucket, "/")
+		if (scheme != "s3" && scheme != "gs") || bucket == "" {
+			errs = append(errs, fmt.Sprintf("callbacks.dlq_offload.url %q must be s3://bucket[/prefix] or gs://bucket[/prefix]", target))
+		}
+		if !cfg.DLQEnabled {
+			errs = append(errs, "callbacks.dlq_offload.url requires callbacks.dlq_enabled")
+		}
+	}
+	return errs
+}
+
+// validateCallbackTLS checks the tls settings of the webhook destination at url. The files
+// themselves are read when callbacks start.
+func validateCallbackTLS(name, url string, cfg CallbackTLSConfig) []string {
+	if cfg == (CallbackTLSConfig{}) {
+		return nil
+	}
+	var errs []string
+	if !strings.HasPrefix(url, "https://") {
+		errs = append(errs, name+".tls requires an https url")
+	}
+	if (cfg.CertFile == "") != (cfg.KeyFile == "") {
+		errs = append(errs, name+".tls.cert_file and key_file must be set together")
+	}
+	return errs
+}
+
+// validateAPIKeyScopes checks api_key.scopes. Errors never include the keys themselves.
+func validateAPIKeyScopes(cfg APIKeyConfig) []string {
+	var errs []string
+	unknownKeys := 0
+	for key, scopes := range cfg.Scopes {
+		if _, ok := cfg.Keys[key]; !ok {
+			unknownKeys++
+		}
+		if len(scopes) == 0 {
+			errs = append(errs, "api_key.scopes entries must list at least one scope")
+		}
+		for _, scope := range scopes {
+			if !apikey.ValidScope(scope) {
+				errs = append(errs, fmt.Sprintf("api_key.scopes: unknown scope %q (want one of %v)", scope, apikey.Scopes))
+			}
+		}
+	}
+	if unknownKeys > 0 {
+		errs = append(errs, fmt.Sprintf("api_key.scopes lists %d key(s) missing from api_key.keys", unknownKeys))
+	}
+	sort.Strings(errs)
+	return slices.Compact(errs)
+}
+
+// validateGeoRestriction checks an enabled geo_restriction block.
+func validateGeoRestriction(geo GeoRestrictionConfig) []string {
+	var errs []string
+	if len(geo.AllowedCountries) == 0 && len(geo.BlockedCountries) == 0 {
+		errs = append(errs, "geo_restriction requires allowed_countries or blocked_countries")
+	}
+	if geo.CountryHeader == "" && geo.Database == "" {
+		errs = append(errs, "geo_restriction requires country_header or database to determine the client's country")
+	}
+	for _, list := range []struct {
+		name  string
+		codes []string
+	}{
+		{"allowed_countries", geo.AllowedCountries},
+		{"blocked_countries", geo.BlockedCountries},
+	} {
+		for _, code := range list.codes {
+			if len(code) != 2 || code[0] < 'A' || code[0] > 'Z' || code[1] < 'A' || code[1] > 'Z' {
+				errs = append(errs, fmt.Sprintf("geo_restriction.%s: %q is not an upper-case ISO 3166-1 alpha-2 country code", list.name, code))
+			}
+		}
+	}
+	return errs
+}
+
+// validateProxy checks an enabled proxy block.
+func validateProxy(proxy ProxyConfig) []string {
+	var errs []string
+	if upstream, err := url.Parse(proxy.Upstream); err != nil || (upstream.Scheme != "http" && upstream.Scheme != "https") || upstream.Host == "" {
+		errs = append(errs, fmt.Sprintf("proxy.upstream %q must be an absolute http(s) URL", proxy.Upstream))
+	}
+	patterns := make([]string, 0, len(proxy.Routes))
+	for pattern := range proxy.Routes {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		if _, _, err := ParseRateLimitEndpoint(pattern); err != nil {
+			errs = append(errs, fmt.Sprintf("proxy.routes: %q: %v", pattern, err))
+		}
+		if strings.TrimSpace(proxy.Routes[pattern]) == "" {
+			errs = append(errs, fmt.Sprintf("proxy.routes: %q must name a resource", pattern))
+		}
+	}
+	return errs
+}
+
+// validateMetering checks an enabled metering block.
+func validateMetering(metering MeteringConfig) []string {
+	var errs []string
+	if _, err := money.GetAsset(metering.Asset); err != nil {
+		errs = append(errs, fmt.Sprintf("metering.asset: unknown asset %q", metering.Asset))
+	}
+	if len(metering.DepositResources) == 0 {
+		errs = append(errs, "metering.deposit_resources must list at least one resource when metering is enabled")
+	}
+	if metering.SettlementInterval.Duration <= 0 {
+		errs = append(errs, "metering.settlement_interval must be positive")
+	}
+	resources := make([]string, 0, len(metering.Prices))
+	for resource := range metering.Prices {
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+	for _, resource := range resources {
+		if metering.Prices[resource] <= 0 {
+			errs = append(errs, fmt.Sprintf("metering.prices.%s must be positive", resource))
+		}
+	}
+	return errs
+}
+
+// validateVelocityRule checks a paywall.fraud rule; only the refund rule can require review.
+func validateVelocityRule(name string, rule VelocityRuleConfig, allowReview bool) []string {
+	var errs []string
+	if rule.Max < 0 {
+		errs = append(errs, name+".max must not be negative")
+	}
+	if rule.Window.Duration < 0 {
+		errs = append(errs, name+".window must not be negative")
+	}
+	switch rule.Action {
+	case "", FraudActionBlock, FraudActionFlag:
+	case FraudActionRequireReview:
+		if !allowReview {
+			errs = append(errs, fmt.Sprintf("%s.action %q is only supported for refund requests", name, rule.Action))
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("%s.action %q must be %q, %q, or %q", name, rule.Action, FraudActionBlock, FraudActionFlag, FraudActionRequireReview))
+	}
+	return errs
+}
+
+// validLineAmount reports whether amount is a non-negative decimal such as "5" or "4.99".
+func validLineAmount(amount string) bool {
+	if _, err := strconv.ParseFloat(amount, 64); err != nil || strings.ContainsAny(amount, "eE+-") {
+		return false
+	}
+	_, err := money.FromMajor(money.MustGetAsset("USDC"), amount)
+	return err == nil
+}
+
 // validateStablecoinMint validates that the token mint address is a known stablecoin.
 // Returns an error with helpful message if the mint is not recognized.
 //
@@ -333,6 +1012,8 @@ Supported stablecoins:
   - PYUSD: 2b1kV6DkPAnxd5ixfnxCpjxmKwqjjaYmCZfHsFu24GXo
   - CASH: CASHx9KJUStyftLFWGvEVf59SGeG9sh5FfcnZMVPCASH
 
+Other stablecoins can be declared under assets with stablecoin: true.
+
 Your configured mint: %s`, err, mintAddress)
 	}
 
@@ -32,14 +32,14 @@ func (r *DisabledRepository) GetAllAutoApplyCouponsForPayment(_ context.Context,
 	return make(map[string][]Coupon), nil
 }
 
-// CreateCoupon is a no-op when coupons are disabled.
+// CreateCoupon returns ErrReadOnly when coupons are disabled.
 func (r *DisabledRepository) CreateCoupon(_ context.Context, _ Coupon) error {
-	return nil
+	return ErrReadOnly
 }
 
-// UpdateCoupon is a no-op when coupons are disabled.
+// UpdateCoupon returns ErrReadOnly when coupons are disabled.
 func (r *DisabledRepository) UpdateCoupon(_ context.Context, _ Coupon) error {
-	return nil
+	return ErrReadOnly
 }
 
 // IncrementUsage is a no-op when coupons are disabled.
@@ -47,9 +47,9 @@ func (r *DisabledRepository) IncrementUsage(_ context.Context, _ string) error {
 	return nil
 }
 
-// DeleteCoupon is a no-op when coupons are disabled.
+// DeleteCoupon returns ErrReadOnly when coupons are disabled.
 func (r *DisabledRepository) DeleteCoupon(_ context.Context, _ string) error {
-	return nil
+	return ErrReadOnly
 }
 
 // Close is a no-op when coupons are disabled.
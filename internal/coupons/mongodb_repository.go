@@ -18,23 +18,24 @@ type MongoDBRepository struct {
 
 // mongoCoupon represents the MongoDB document structure.
 type mongoCoupon struct {
-	Code          string            `bson:"_id"`
-	DiscountType  string            `bson:"discountType"`
-	DiscountValue float64           `bson:"discountValue"`
-	Currency      string            `bson:"currency"`
-	Scope         string            `bson:"scope"`
-	ProductIDs    []string          `bson:"productIds"`
-	PaymentMethod string            `bson:"paymentMethod,omitempty"` // "stripe", "x402", or "" for any
-	AutoApply     bool              `bson:"autoApply"`
-	AppliesAt     string            `bson:"appliesAt,omitempty"` // "catalog", "checkout", or "" for backward compatibility
-	UsageLimit    *int              `bson:"usageLimit"`
-	UsageCount    int               `bson:"usageCount"`
-	StartsAt      *time.Time        `bson:"startsAt,omitempty"`
-	ExpiresAt     *time.Time        `bson:"expiresAt,omitempty"`
-	Active        bool              `bson:"active"`
-	Metadata      map[string]string `bson:"metadata"`
-	CreatedAt     time.Time         `bson:"createdAt"`
-	UpdatedAt     time.Time         `bson:"updatedAt"`
+	Code                string            `bson:"_id"`
+	DiscountType        string            `bson:"discountType"`
+	DiscountValue       float64           `bson:"discountValue"`
+	Currency            string            `bson:"currency"`
+	Scope               string            `bson:"scope"`
+	ProductIDs          []string          `bson:"productIds"`
+	PaymentMethod       string            `bson:"paymentMethod,omitempty"` // "stripe", "x402", or "" for any
+	AutoApply           bool              `bson:"autoApply"`
+	AppliesAt           string            `bson:"appliesAt,omitempty"` // "catalog", "checkout", or "" for backward compatibility
+	UsageLimit          *int              `bson:"usageLimit"`
+	UsageLimitPerWallet *int              `bson:"usageLimitPerWallet,omitempty"`
+	UsageCount          int               `bson:"usageCount"`
+	StartsAt            *time.Time        `bson:"startsAt,omitempty"`
+	ExpiresAt           *time.Time        `bson:"expiresAt,omitempty"`
+	Active              bool              `bson:"active"`
+	Metadata            map[string]string `bson:"metadata"`
+	CreatedAt           time.Time         `bson:"createdAt"`
+	UpdatedAt           time.Time         `bson:"updatedAt"`
 }
 
 // NewMongoDBRepository creates a MongoDB-backed repository.
@@ -285,7 +286,7 @@ func (r *MongoDBRepository) CreateCoupon(ctx context.Context, c Coupon) error {
 	if err != nil {
 		// Check if this is a duplicate key error
 		if mongo.IsDuplicateKeyError(err) {
-			return fmt.Errorf("coupon already exists: %s", c.Code)
+			return fmt.Errorf("%w: %s", ErrCouponExists, c.Code)
 		}
 		return fmt.Errorf("insert coupon: %w", err)
 	}
@@ -293,27 +294,28 @@ func (r *MongoDBRepository) CreateCoupon(ctx context.Context, c Coupon) error {
 	return nil
 }
 
-// UpdateCoupon updates an existing coupon.
+// UpdateCoupon replaces an existing coupon's definition, keeping its usage count.
 func (r *MongoDBRepository) UpdateCoupon(ctx context.Context, c Coupon) error {
 	c.UpdatedAt = time.Now()
 
 	filter := bson.M{"_id": c.Code}
 	update := bson.M{
 		"$set": bson.M{
-			"discountType":  string(c.DiscountType),
-			"discountValue": c.DiscountValue,
-			"currency":      c.Currency,
-			"scope":         string(c.Scope),
-			"productIds":    c.ProductIDs,
-			"paymentMethod": string(c.PaymentMethod),
-			"autoApply":     c.AutoApply,
-			"usageLimit":    c.UsageLimit,
-			"usageCount":    c.UsageCount,
-			"startsAt":      c.StartsAt,
-			"expiresAt":     c.ExpiresAt,
-			"active":        c.Active,
-			"metadata":      c.Metadata,
-			"updatedAt":     c.UpdatedAt,
+			"discountType":        string(c.DiscountType),
+			"discountValue":       c.DiscountValue,
+			"currency":            c.Currency,
+			"scope":               string(c.Scope),
+			"productIds":          c.ProductIDs,
+			"paymentMethod":       string(c.PaymentMethod),
+			"autoApply":           c.AutoApply,
+			"appliesAt":           string(c.AppliesAt),
+			"usageLimit":          c.UsageLimit,
+			"usageLimitPerWallet": c.UsageLimitPerWallet,
+			"startsAt":            c.StartsAt,
+			"expiresAt":           c.ExpiresAt,
+			"active":              c.Active,
+			"metadata":            c.Metadata,
+			"updatedAt":           c.UpdatedAt,
 		},
 	}
 
@@ -381,45 +383,47 @@ func (r *MongoDBRepository) Close() error {
 // mongoToCoupon converts a MongoDB document to a Coupon.
 func mongoToCoupon(mc mongoCoupon) Coupon {
 	return Coupon{
-		Code:          mc.Code,
-		DiscountType:  DiscountType(mc.DiscountType),
-		DiscountValue: mc.DiscountValue,
-		Currency:      mc.Currency,
-		Scope:         Scope(mc.Scope),
-		ProductIDs:    mc.ProductIDs,
-		PaymentMethod: PaymentMethod(mc.PaymentMethod),
-		AutoApply:     mc.AutoApply,
-		AppliesAt:     AppliesAt(mc.AppliesAt),
-		UsageLimit:    mc.UsageLimit,
-		UsageCount:    mc.UsageCount,
-		StartsAt:      mc.StartsAt,
-		ExpiresAt:     mc.ExpiresAt,
-		Active:        mc.Active,
-		Metadata:      mc.Metadata,
-		CreatedAt:     mc.CreatedAt,
-		UpdatedAt:     mc.UpdatedAt,
+		Code:                mc.Code,
+		DiscountType:        DiscountType(mc.DiscountType),
+		DiscountValue:       mc.DiscountValue,
+		Currency:            mc.Currency,
+		Scope:               Scope(mc.Scope),
+		ProductIDs:          mc.ProductIDs,
+		PaymentMethod:       PaymentMethod(mc.PaymentMethod),
+		AutoApply:           mc.AutoApply,
+		AppliesAt:           AppliesAt(mc.AppliesAt),
+		UsageLimit:          mc.UsageLimit,
+		UsageLimitPerWallet: mc.UsageLimitPerWallet,
+		UsageCount:          mc.UsageCount,
+		StartsAt:            mc.StartsAt,
+		ExpiresAt:           mc.ExpiresAt,
+		Active:              mc.Active,
+		Metadata:            mc.Metadata,
+		CreatedAt:           mc.CreatedAt,
+		UpdatedAt:           mc.UpdatedAt,
 	}
 }
 
 // couponToMongo converts a Coupon to a MongoDB document.
 func couponToMongo(c Coupon) mongoCoupon {
 	return mongoCoupon{
-		Code:          c.Code,
-		DiscountType:  string(c.DiscountType),
-		DiscountValue: c.DiscountValue,
-		Currency:      c.Currency,
-		Scope:         string(c.Scope),
-		ProductIDs:    c.ProductIDs,
-		PaymentMethod: string(c.PaymentMethod),
-		AutoApply:     c.AutoApply,
-		AppliesAt:     string(c.AppliesAt),
-		UsageLimit:    c.UsageLimit,
-		UsageCount:    c.UsageCount,
-		StartsAt:      c.StartsAt,
-		ExpiresAt:     c.ExpiresAt,
-		Active:        c.Active,
-		Metadata:      c.Metadata,
-		CreatedAt:     c.CreatedAt,
-		UpdatedAt:     c.UpdatedAt,
+		Code:                c.Code,
+		DiscountType:        string(c.DiscountType),
+		DiscountValue:       c.DiscountValue,
+		Currency:            c.Currency,
+		Scope:               string(c.Scope),
+		ProductIDs:          c.ProductIDs,
+		PaymentMethod:       string(c.PaymentMethod),
+		AutoApply:           c.AutoApply,
+		AppliesAt:           string(c.AppliesAt),
+		UsageLimit:          c.UsageLimit,
+		UsageLimitPerWallet: c.UsageLimitPerWallet,
+		UsageCount:          c.UsageCount,
+		StartsAt:            c.StartsAt,
+		ExpiresAt:           c.ExpiresAt,
+		Active:              c.Active,
+		Metadata:            c.Metadata,
+		CreatedAt:           c.CreatedAt,
+		UpdatedAt:           c.UpdatedAt,
 	}
 }
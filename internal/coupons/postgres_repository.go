@@ -4,13 +4,17 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/CedrosPay/server/internal/config"
-	_ "github.com/lib/pq" // PostgreSQL driver
+	"github.com/lib/pq"
 )
 
+// uniqueViolation is the PostgreSQL error code for a duplicate primary key.
+const uniqueViolation = "23505"
+
 // PostgresRepository implements Repository using PostgreSQL.
 type PostgresRepository struct {
 	db        *sql.DB
@@ -55,8 +59,8 @@ func (r *PostgresRepository) WithTableName(tableName string) *PostgresRepository
 func (r *PostgresRepository) GetCoupon(ctx context.Context, code string) (Coupon, error) {
 	query := fmt.Sprintf(`
 		SELECT code, discount_type, discount_value, currency, scope, product_ids,
-		       payment_method, auto_apply, applies_at, usage_limit, usage_count, starts_at, expires_at,
-		       active, metadata, created_at, updated_at
+		       payment_method, auto_apply, applies_at, usage_limit, usage_limit_per_wallet, usage_count,
+		       starts_at, expires_at, active, metadata, created_at, updated_at
 		FROM %s
 		WHERE code = $1 AND active = true
 	`, r.tableName)
@@ -77,6 +81,7 @@ func (r *PostgresRepository) GetCoupon(ctx context.Context, code string) (Coupon
 		&c.AutoApply,
 		&appliesAt,
 		&c.UsageLimit,
+		&c.UsageLimitPerWallet,
 		&c.UsageCount,
 		&c.StartsAt,
 		&c.ExpiresAt,
@@ -120,8 +125,8 @@ func (r *PostgresRepository) GetCoupon(ctx context.Context, code string) (Coupon
 func (r *PostgresRepository) ListCoupons(ctx context.Context) ([]Coupon, error) {
 	query := fmt.Sprintf(`
 		SELECT code, discount_type, discount_value, currency, scope, product_ids,
-		       payment_method, auto_apply, applies_at, usage_limit, usage_count, starts_at, expires_at,
-		       active, metadata, created_at, updated_at
+		       payment_method, auto_apply, applies_at, usage_limit, usage_limit_per_wallet, usage_count,
+		       starts_at, expires_at, active, metadata, created_at, updated_at
 		FROM %s
 		WHERE active = true
 		ORDER BY code ASC
@@ -151,6 +156,7 @@ func (r *PostgresRepository) ListCoupons(ctx context.Context) ([]Coupon, error)
 			&c.AutoApply,
 			&appliesAt,
 			&c.UsageLimit,
+			&c.UsageLimitPerWallet,
 			&c.UsageCount,
 			&c.StartsAt,
 			&c.ExpiresAt,
@@ -207,8 +213,8 @@ func (r *PostgresRepository) GetAutoApplyCouponsForPayment(ctx context.Context,
 	// - payment_method = '' (any) OR payment_method = specified method
 	query := fmt.Sprintf(`
 		SELECT code, discount_type, discount_value, currency, scope, product_ids,
-		       payment_method, auto_apply, applies_at, usage_limit, usage_count, starts_at, expires_at,
-		       active, metadata, created_at, updated_at
+		       payment_method, auto_apply, applies_at, usage_limit, usage_limit_per_wallet, usage_count,
+		       starts_at, expires_at, active, metadata, created_at, updated_at
 		FROM %s
 		WHERE auto_apply = true
 		  AND active = true
@@ -244,6 +250,7 @@ func (r *PostgresRepository) GetAutoApplyCouponsForPayment(ctx context.Context,
 			&c.AutoApply,
 			&appliesAt,
 			&c.UsageLimit,
+			&c.UsageLimitPerWallet,
 			&c.UsageCount,
 			&c.StartsAt,
 			&c.ExpiresAt,
@@ -293,8 +300,8 @@ func (r *PostgresRepository) GetAllAutoApplyCouponsForPayment(ctx context.Contex
 	// Query all auto-apply coupons for the payment method
 	query := fmt.Sprintf(`
 		SELECT code, discount_type, discount_value, currency, scope, product_ids,
-		       payment_method, auto_apply, applies_at, usage_limit, usage_count, starts_at, expires_at,
-		       active, metadata, created_at, updated_at
+		       payment_method, auto_apply, applies_at, usage_limit, usage_limit_per_wallet, usage_count,
+		       starts_at, expires_at, active, metadata, created_at, updated_at
 		FROM %s
 		WHERE auto_apply = true
 		  AND active = true
@@ -330,6 +337,7 @@ func (r *PostgresRepository) GetAllAutoApplyCouponsForPayment(ctx context.Contex
 			&c.AutoApply,
 			&appliesAt,
 			&c.UsageLimit,
+			&c.UsageLimitPerWallet,
 			&c.UsageCount,
 			&c.StartsAt,
 			&c.ExpiresAt,
@@ -400,9 +408,9 @@ func (r *PostgresRepository) CreateCoupon(ctx context.Context, c Coupon) error {
 
 	query := fmt.Sprintf(`
 		INSERT INTO %s (code, discount_type, discount_value, currency, scope, product_ids,
-		                     payment_method, auto_apply, applies_at, usage_limit, usage_count, starts_at,
-		                     expires_at, active, metadata, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+		                     payment_method, auto_apply, applies_at, usage_limit, usage_limit_per_wallet,
+		                     usage_count, starts_at, expires_at, active, metadata, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
 	`, r.tableName)
 
 	_, err = r.db.ExecContext(ctx, query,
@@ -416,6 +424,7 @@ func (r *PostgresRepository) CreateCoupon(ctx context.Context, c Coupon) error {
 		c.AutoApply,
 		string(c.AppliesAt),
 		c.UsageLimit,
+		c.UsageLimitPerWallet,
 		c.UsageCount,
 		c.StartsAt,
 		c.ExpiresAt,
@@ -426,13 +435,17 @@ func (r *PostgresRepository) CreateCoupon(ctx context.Context, c Coupon) error {
 	)
 
 	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return fmt.Errorf("%w: %s", ErrCouponExists, c.Code)
+		}
 		return fmt.Errorf("insert coupon: %w", err)
 	}
 
 	return nil
 }
 
-// UpdateCoupon updates an existing coupon.
+// UpdateCoupon replaces an existing coupon's definition, keeping its usage count.
 func (r *PostgresRepository) UpdateCoupon(ctx context.Context, c Coupon) error {
 	c.UpdatedAt = time.Now()
 
@@ -451,8 +464,9 @@ func (r *PostgresRepository) UpdateCoupon(ctx context.Context, c Coupon) error {
 	query := fmt.Sprintf(`
 		UPDATE %s
 		SET discount_type = $2, discount_value = $3, currency = $4, scope = $5, product_ids = $6,
-		    payment_method = $7, auto_apply = $8, applies_at = $9, usage_limit = $10, usage_count = $11,
-		    starts_at = $12, expires_at = $13, active = $14, metadata = $15, updated_at = $16
+		    payment_method = $7, auto_apply = $8, applies_at = $9, usage_limit = $10,
+		    usage_limit_per_wallet = $11, starts_at = $12, expires_at = $13, active = $14,
+		    metadata = $15, updated_at = $16
 		WHERE code = $1
 	`, r.tableName)
 
@@ -467,7 +481,7 @@ func (r *PostgresRepository) UpdateCoupon(ctx context.Context, c Coupon) error {
 		c.AutoApply,
 		string(c.AppliesAt),
 		c.UsageLimit,
-		c.UsageCount,
+		c.UsageLimitPerWallet,
 		c.StartsAt,
 		c.ExpiresAt,
 		c.Active,
@@ -0,0 +1,53 @@
+package coupons
+
+import (
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+// Coupon metadata keys that make a coupon a referral code.
+const (
+	MetadataReferrerWallet        = "referrer_wallet"         // Wallet credited with purchases made with the coupon
+	MetadataReferralRewardPercent = "referral_reward_percent" // Share of each purchase owed to the referrer (0-100)
+)
+
+// Referrer returns the wallet a referral coupon attributes purchases to and the percentage of
+// each purchase owed to it. ok is false for coupons that are not valid referral codes.
+func (c Coupon) Referrer() (wallet string, rewardPercent float64, ok bool) {
+	wallet = c.Metadata[MetadataReferrerWallet]
+	if wallet == "" {
+		return "", 0, false
+	}
+	rewardPercent, err := c.referralRewardPercent()
+	if err != nil {
+		return "", 0, false
+	}
+	return wallet, rewardPercent, true
+}
+
+// ReferralReward returns the share of paid owed to the coupon's referrer. Coupons that are not
+// referral codes owe nothing.
+func (c Coupon) ReferralReward(paid money.Money) (money.Money, error) {
+	_, pct, ok := c.Referrer()
+	if !ok {
+		return money.Zero(paid.Asset), nil
+	}
+	return paid.MulBasisPoints(int64(math.Round(pct * 100)))
+}
+
+// referralRewardPercent parses the referral reward percentage; a referral code without one
+// attributes purchases but owes nothing.
+func (c Coupon) referralRewardPercent() (float64, error) {
+	raw := c.Metadata[MetadataReferralRewardPercent]
+	if raw == "" {
+		return 0, nil
+	}
+	pct, err := strconv.ParseFloat(raw, 64)
+	if err != nil || pct < 0 || pct > 100 {
+		return 0, errors.New("referral_reward_percent must be a number between 0 and 100")
+	}
+	return pct, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/CedrosPay/server/internal/config"
@@ -21,12 +22,20 @@ var ErrCouponUsageLimitReached = errors.New("coupon usage limit reached")
 // ErrCouponNotStarted is returned when coupon hasn't started yet.
 var ErrCouponNotStarted = errors.New("coupon not started yet")
 
+// ErrCouponExists is returned when creating a coupon whose code is already taken
+// (including by a deactivated coupon).
+var ErrCouponExists = errors.New("coupon already exists")
+
+// ErrReadOnly is returned by repositories that cannot be written to (YAML config or disabled).
+var ErrReadOnly = errors.New("coupon repository is read-only")
+
 // DiscountType represents how the discount is applied.
 type DiscountType string
 
 const (
 	DiscountTypePercentage DiscountType = "percentage" // Percentage off (0-100)
 	DiscountTypeFixed      DiscountType = "fixed"      // Fixed amount off
+	DiscountTypeGiftCard   DiscountType = "gift_card"  // Stored balance spent across purchases
 )
 
 // Scope represents what products the coupon applies to.
@@ -56,23 +65,24 @@ const (
 
 // Coupon represents a discount code that users can apply.
 type Coupon struct {
-	Code          string            // Coupon code (e.g., "SUMMER2024")
-	DiscountType  DiscountType      // "percentage" or "fixed"
-	DiscountValue float64           // Percentage (0-100) or fixed amount
-	Currency      string            // For fixed discounts (usd, usdc, etc.)
-	Scope         Scope             // "all" or "specific"
-	ProductIDs    []string          // Applicable product IDs (for scope=specific)
-	PaymentMethod PaymentMethod     // Restrict to specific payment method ("stripe", "x402", or "" for any)
-	AutoApply     bool              // If true, automatically apply to matching products
-	AppliesAt     AppliesAt         // When to display: "catalog" (product page) or "checkout" (cart only)
-	UsageLimit    *int              // nil = unlimited, N = max uses
-	UsageCount    int               // Current usage count
-	StartsAt      *time.Time        // When coupon becomes valid
-	ExpiresAt     *time.Time        // When coupon expires
-	Active        bool              // Enable/disable coupon
-	Metadata      map[string]string // Custom key-value pairs
-	CreatedAt     time.Time         // Creation timestamp
-	UpdatedAt     time.Time         // Last update timestamp
+	Code                string            // Coupon code (e.g., "SUMMER2024")
+	DiscountType        DiscountType      // "percentage", "fixed", or "gift_card"
+	DiscountValue       float64           // Percentage (0-100), fixed amount, or gift card face value (USD)
+	Currency            string            // For fixed discounts (usd, usdc, etc.)
+	Scope               Scope             // "all" or "specific"
+	ProductIDs          []string          // Applicable product IDs (for scope=specific)
+	PaymentMethod       PaymentMethod     // Restrict to specific payment method ("stripe", "x402", or "" for any)
+	AutoApply           bool              // If true, automatically apply to matching products
+	AppliesAt           AppliesAt         // When to display: "catalog" (product page) or "checkout" (cart only)
+	UsageLimit          *int              // nil = unlimited, N = max uses
+	UsageLimitPerWallet *int              // nil = unlimited, N = max uses per wallet (x402) or email (Stripe)
+	UsageCount          int               // Current usage count
+	StartsAt            *time.Time        // When coupon becomes valid
+	ExpiresAt           *time.Time        // When coupon expires
+	Active              bool              // Enable/disable coupon
+	Metadata            map[string]string // Custom key-value pairs
+	CreatedAt           time.Time         // Creation timestamp
+	UpdatedAt           time.Time         // Last update timestamp
 }
 
 // IsValid checks if the coupon is currently valid for use.
@@ -101,6 +111,20 @@ func (c Coupon) IsValid() error {
 	return nil
 }
 
+// WalletLimit returns how many times one wallet (or, for Stripe, one email) may use the coupon,
+// or 0 if uses per wallet are unlimited.
+func (c Coupon) WalletLimit() int {
+	if c.UsageLimitPerWallet == nil || *c.UsageLimitPerWallet <= 0 {
+		return 0
+	}
+	return *c.UsageLimitPerWallet
+}
+
+// EmailRedeemer returns the redeemer a Stripe customer's email counts coupon uses under.
+func EmailRedeemer(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
 // ValidateConfiguration checks if the coupon configuration is consistent.
 // Returns error if AppliesAt constraints are violated.
 func (c Coupon) ValidateConfiguration() error {
@@ -126,6 +150,51 @@ func (c Coupon) ValidateConfiguration() error {
 		return errors.New("auto-apply coupons must specify applies_at (catalog or checkout)")
 	}
 
+	// Gift cards are redeemed by code against a stored balance
+	if c.DiscountType == DiscountTypeGiftCard {
+		if c.AutoApply {
+			return errors.New("gift cards cannot be auto-applied")
+		}
+		if c.DiscountValue <= 0 {
+			return errors.New("gift cards must have a positive face value")
+		}
+	}
+
+	// Per-wallet limits are checked against the wallet a code is entered with, so auto-apply
+	// coupons cannot have them
+	if c.UsageLimitPerWallet != nil {
+		if *c.UsageLimitPerWallet <= 0 {
+			return errors.New("usage_limit_per_wallet must be positive")
+		}
+		if c.AutoApply {
+			return errors.New("auto-apply coupons cannot have a per-wallet usage limit")
+		}
+	}
+
+	// Quantity rules discount cart items by percentage or fixed amount per unit; buy-X-get-Y
+	// discounts the free units by a percentage (100 = free)
+	if c.HasQuantityRule() {
+		if _, err := c.quantityRule(); err != nil {
+			return err
+		}
+		if c.DiscountType == DiscountTypeGiftCard {
+			return errors.New("gift cards cannot have quantity rules")
+		}
+		if rule, _ := c.QuantityRule(); rule.Get > 0 && c.DiscountType != DiscountTypePercentage {
+			return errors.New("buy-X-get-Y coupons must be percentage discounts")
+		}
+	}
+
+	// Referral codes attribute purchases to a referrer wallet
+	if _, ok := c.Metadata[MetadataReferralRewardPercent]; ok {
+		if c.Metadata[MetadataReferrerWallet] == "" {
+			return errors.New("referral coupons must specify referrer_wallet")
+		}
+		if _, err := c.referralRewardPercent(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -147,6 +216,12 @@ func (c Coupon) AppliesToProduct(productID string) bool {
 // AppliesToPaymentMethod checks if the coupon applies to a given payment method.
 // Empty string (PaymentMethodAny) matches all payment methods.
 func (c Coupon) AppliesToPaymentMethod(method PaymentMethod) bool {
+	// Gift card balances are only spent on x402 payments, and quantity rules are only
+	// evaluated against x402 cart quotes
+	if c.DiscountType == DiscountTypeGiftCard || c.HasQuantityRule() {
+		return method == PaymentMethodX402
+	}
+
 	// Empty payment method (default) applies to all payment methods
 	if c.PaymentMethod == PaymentMethodAny {
 		return true
@@ -157,10 +232,14 @@ func (c Coupon) AppliesToPaymentMethod(method PaymentMethod) bool {
 }
 
 // ApplyDiscount calculates the price after applying this coupon.
+// Gift cards leave the price unchanged: they pay part of it from their balance at checkout.
 func (c Coupon) ApplyDiscount(originalPrice float64) float64 {
 	if c.DiscountType == DiscountTypePercentage {
 		return originalPrice * (1 - c.DiscountValue/100)
 	}
+	if c.DiscountType == DiscountTypeGiftCard {
+		return originalPrice
+	}
 	// Fixed amount discount
 	discounted := originalPrice - c.DiscountValue
 	if discounted < 0 {
@@ -187,9 +266,11 @@ type Repository interface {
 	GetAllAutoApplyCouponsForPayment(ctx context.Context, paymentMethod PaymentMethod) (map[string][]Coupon, error)
 
 	// CreateCoupon creates a new coupon.
+	// Returns ErrCouponExists if the code is taken.
 	CreateCoupon(ctx context.Context, coupon Coupon) error
 
-	// UpdateCoupon updates an existing coupon.
+	// UpdateCoupon replaces the definition of an existing coupon, active or not.
+	// The usage count is left to IncrementUsage so concurrent redemptions are not lost.
 	UpdateCoupon(ctx context.Context, coupon Coupon) error
 
 	// IncrementUsage atomically increments the usage count.
@@ -3,6 +3,8 @@ package coupons
 import (
 	"testing"
 	"time"
+
+	"github.com/CedrosPay/server/internal/money"
 )
 
 func TestCoupon_IsValid(t *testing.T) {
@@ -228,6 +230,24 @@ func TestCoupon_AppliesToPaymentMethod(t *testing.T) {
 			paymentMethod: PaymentMethodStripe,
 			want:          false,
 		},
+		{
+			name: "gift card matches x402",
+			coupon: Coupon{
+				Code:         "GIFT50",
+				DiscountType: DiscountTypeGiftCard,
+			},
+			paymentMethod: PaymentMethodX402,
+			want:          true,
+		},
+		{
+			name: "gift card does not match stripe",
+			coupon: Coupon{
+				Code:         "GIFT50",
+				DiscountType: DiscountTypeGiftCard,
+			},
+			paymentMethod: PaymentMethodStripe,
+			want:          false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -306,6 +326,16 @@ func TestCoupon_ApplyDiscount(t *testing.T) {
 			originalPrice: 99.99,
 			want:          0.0, // Free
 		},
+		{
+			name: "gift card leaves the price unchanged",
+			coupon: Coupon{
+				Code:          "GIFT50",
+				DiscountType:  DiscountTypeGiftCard,
+				DiscountValue: 50.0, // $50 balance
+			},
+			originalPrice: 30.0,
+			want:          30.0,
+		},
 	}
 
 	for _, tt := range tests {
@@ -319,3 +349,109 @@ func TestCoupon_ApplyDiscount(t *testing.T) {
 		})
 	}
 }
+
+func TestCoupon_Referrer(t *testing.T) {
+	usdc := money.MustGetAsset("USDC")
+	tests := []struct {
+		name        string
+		metadata    map[string]string
+		wantWallet  string
+		wantReward  int64 // Atomic reward on 10.00 USDC
+		wantOK      bool
+		wantInvalid bool
+	}{
+		{name: "not a referral code", metadata: map[string]string{"campaign": "summer"}},
+		{name: "referral with reward", metadata: map[string]string{MetadataReferrerWallet: "referrer", MetadataReferralRewardPercent: "12.5"}, wantWallet: "referrer", wantReward: 1250000, wantOK: true},
+		{name: "referral without reward", metadata: map[string]string{MetadataReferrerWallet: "referrer"}, wantWallet: "referrer", wantOK: true},
+		{name: "reward above 100", metadata: map[string]string{MetadataReferrerWallet: "referrer", MetadataReferralRewardPercent: "150"}, wantInvalid: true},
+		{name: "reward without referrer", metadata: map[string]string{MetadataReferralRewardPercent: "5"}, wantInvalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			coupon := Coupon{Code: "FRIEND", DiscountType: DiscountTypePercentage, DiscountValue: 10, Scope: ScopeAll, Metadata: tt.metadata}
+			wallet, _, ok := coupon.Referrer()
+			if wallet != tt.wantWallet || ok != tt.wantOK {
+				t.Errorf("Referrer() = %q, %v, want %q, %v", wallet, ok, tt.wantWallet, tt.wantOK)
+			}
+			reward, err := coupon.ReferralReward(money.New(usdc, 10000000))
+			if err != nil || reward.Atomic != tt.wantReward {
+				t.Errorf("ReferralReward() = %d, %v, want %d", reward.Atomic, err, tt.wantReward)
+			}
+			if err := coupon.ValidateConfiguration(); (err != nil) != tt.wantInvalid {
+				t.Errorf("ValidateConfiguration() error = %v, wantInvalid %v", err, tt.wantInvalid)
+			}
+		})
+	}
+}
+
+func TestCoupon_WalletLimit(t *testing.T) {
+	limit := func(n int) *int { return &n }
+	tests := []struct {
+		name        string
+		limit       *int
+		autoApply   bool
+		want        int
+		wantInvalid bool
+	}{
+		{name: "unlimited"},
+		{name: "limited", limit: limit(2), want: 2},
+		{name: "zero", limit: limit(0), wantInvalid: true},
+		{name: "auto-apply", limit: limit(1), autoApply: true, want: 1, wantInvalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			coupon := Coupon{Code: "ONCE", DiscountType: DiscountTypePercentage, DiscountValue: 10, Scope: ScopeAll, AutoApply: tt.autoApply, UsageLimitPerWallet: tt.limit}
+			if got := coupon.WalletLimit(); got != tt.want {
+				t.Errorf("WalletLimit() = %d, want %d", got, tt.want)
+			}
+			if err := coupon.ValidateConfiguration(); (err != nil) != tt.wantInvalid {
+				t.Errorf("ValidateConfiguration() error = %v, wantInvalid %v", err, tt.wantInvalid)
+			}
+		})
+	}
+}
+
+func TestCoupon_QuantityRule(t *testing.T) {
+	tests := []struct {
+		name         string
+		discountType DiscountType
+		metadata     map[string]string
+		wantOK       bool
+		wantUnits    []int64 // Units discounted of 1 through 5 bought
+		wantInvalid  bool
+	}{
+		{name: "no rule", discountType: DiscountTypePercentage, metadata: map[string]string{"campaign": "summer"}},
+		{name: "three or more", discountType: DiscountTypePercentage, metadata: map[string]string{MetadataMinQuantity: "3"}, wantOK: true, wantUnits: []int64{0, 0, 3, 4, 5}},
+		{name: "buy one get one", discountType: DiscountTypePercentage, metadata: map[string]string{MetadataBuyQuantity: "1", MetadataGetQuantity: "1"}, wantOK: true, wantUnits: []int64{0, 1, 1, 2, 2}},
+		{name: "buy two get one", discountType: DiscountTypePercentage, metadata: map[string]string{MetadataBuyQuantity: "2", MetadataGetQuantity: "1"}, wantOK: true, wantUnits: []int64{0, 0, 1, 1, 1}},
+		{name: "fixed amount from a quantity", discountType: DiscountTypeFixed, metadata: map[string]string{MetadataMinQuantity: "2"}, wantOK: true, wantUnits: []int64{0, 2, 3, 4, 5}},
+		{name: "minimum of one", discountType: DiscountTypePercentage, metadata: map[string]string{MetadataMinQuantity: "1"}, wantInvalid: true},
+		{name: "buy without get", discountType: DiscountTypePercentage, metadata: map[string]string{MetadataBuyQuantity: "1"}, wantInvalid: true},
+		{name: "minimum and buy-get", discountType: DiscountTypePercentage, metadata: map[string]string{MetadataMinQuantity: "3", MetadataBuyQuantity: "1", MetadataGetQuantity: "1"}, wantInvalid: true},
+		{name: "fixed buy-get", discountType: DiscountTypeFixed, metadata: map[string]string{MetadataBuyQuantity: "1", MetadataGetQuantity: "1"}, wantOK: true, wantUnits: []int64{0, 1, 1, 2, 2}, wantInvalid: true},
+		{name: "not a number", discountType: DiscountTypePercentage, metadata: map[string]string{MetadataMinQuantity: "three"}, wantInvalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			coupon := Coupon{Code: "RULE", DiscountType: tt.discountType, DiscountValue: 10, Scope: ScopeAll, Metadata: tt.metadata}
+			rule, ok := coupon.QuantityRule()
+			if ok != tt.wantOK {
+				t.Fatalf("QuantityRule() ok = %v, want %v", ok, tt.wantOK)
+			}
+			for i, want := range tt.wantUnits {
+				if got := rule.DiscountedUnits(int64(i + 1)); got != want {
+					t.Errorf("DiscountedUnits(%d) = %d, want %d", i+1, got, want)
+				}
+			}
+			if err := coupon.ValidateConfiguration(); (err != nil) != tt.wantInvalid {
+				t.Errorf("ValidateConfiguration() error = %v, wantInvalid %v", err, tt.wantInvalid)
+			}
+			if got := coupon.AppliesToPaymentMethod(PaymentMethodStripe); got == coupon.HasQuantityRule() {
+				t.Errorf("AppliesToPaymentMethod(stripe) = %v for a coupon with rule %v", got, coupon.HasQuantityRule())
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package coupons
+
+import (
+	"errors"
+	"strconv"
+)
+
+// Coupon metadata keys that make a coupon a quantity rule, evaluated against each cart item.
+const (
+	MetadataMinQuantity = "min_quantity" // Units of an item needed before every unit is discounted
+	MetadataBuyQuantity = "buy_quantity" // Units paid in full per buy-X-get-Y group
+	MetadataGetQuantity = "get_quantity" // Units discounted per buy-X-get-Y group
+)
+
+// QuantityRule discounts units of a cart item by how many of it are bought: every unit once
+// MinQuantity is reached ("10% off 3 or more"), or Get of every Buy+Get units ("buy one get
+// one free" is Buy 1, Get 1 at 100%).
+type QuantityRule struct {
+	MinQuantity int64
+	Buy         int64
+	Get         int64
+}
+
+// DiscountedUnits returns how many of quantity units of an item the rule discounts.
+func (r QuantityRule) DiscountedUnits(quantity int64) int64 {
+	if r.Get > 0 {
+		return quantity / (r.Buy + r.Get) * r.Get
+	}
+	if quantity >= r.MinQuantity {
+		return quantity
+	}
+	return 0
+}
+
+// QuantityRule returns the coupon's quantity rule. ok is false for coupons without a valid one,
+// which discount every unit as usual.
+func (c Coupon) QuantityRule() (rule QuantityRule, ok bool) {
+	rule, err := c.quantityRule()
+	if err != nil || (rule == QuantityRule{}) {
+		return QuantityRule{}, false
+	}
+	return rule, true
+}
+
+// HasQuantityRule reports whether the coupon is a quantity rule, configured or not. Such
+// coupons only apply to cart items, never to a single unit's price.
+func (c Coupon) HasQuantityRule() bool {
+	for _, key := range []string{MetadataMinQuantity, MetadataBuyQuantity, MetadataGetQuantity} {
+		if c.Metadata[key] != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// quantityRule parses the coupon's quantity rule metadata; the zero rule means it has none.
+func (c Coupon) quantityRule() (QuantityRule, error) {
+	var rule QuantityRule
+	fields := []struct {
+		key string
+		min int64
+		dst *int64
+	}{
+		{key: MetadataMinQuantity, min: 2, dst: &rule.MinQuantity},
+		{key: MetadataBuyQuantity, min: 1, dst: &rule.Buy},
+		{key: MetadataGetQuantity, min: 1, dst: &rule.Get},
+	}
+	for _, field := range fields {
+		raw := c.Metadata[field.key]
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || n < field.min {
+			return QuantityRule{}, errors.New(field.key + " must be a whole number of at least " + strconv.FormatInt(field.min, 10))
+		}
+		*field.dst = n
+	}
+
+	switch {
+	case (rule.Buy > 0) != (rule.Get > 0):
+		return QuantityRule{}, errors.New("buy-X-get-Y coupons must specify both buy_quantity and get_quantity")
+	case rule.MinQuantity > 0 && rule.Get > 0:
+		return QuantityRule{}, errors.New("quantity coupons cannot combine min_quantity with buy_quantity and get_quantity")
+	}
+	return rule, nil
+}
@@ -2,7 +2,7 @@ package coupons
 
 import (
 	"context"
-	"errors"
+	"sync"
 	"time"
 
 	"github.com/CedrosPay/server/internal/config"
@@ -11,12 +11,54 @@ import (
 
 // YAMLRepository implements Repository using in-memory YAML config.
 type YAMLRepository struct {
+	mu      sync.RWMutex
 	coupons map[string]config.Coupon
 }
 
 // NewYAMLRepository creates a repository from YAML config.
 func NewYAMLRepository(coupons map[string]config.Coupon) *YAMLRepository {
-	// Warn about coupons with usage_limit set
+	warnUntrackedUsageLimits(coupons)
+	return &YAMLRepository{
+		coupons: coupons,
+	}
+}
+
+// Reload replaces the configured coupons, e.g. after the config file changed.
+func (r *YAMLRepository) Reload(coupons map[string]config.Coupon) {
+	warnUntrackedUsageLimits(coupons)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.coupons = coupons
+}
+
+// ReloadYAML replaces the coupons of a YAML-backed repo, including one wrapped by
+// CachedRepository, and clears the cache. It reports false for other sources.
+func ReloadYAML(repo Repository, coupons map[string]config.Coupon) bool {
+	cached, isCached := repo.(*CachedRepository)
+	if isCached {
+		repo = cached.underlying
+	}
+	yaml, ok := repo.(*YAMLRepository)
+	if !ok {
+		return false
+	}
+	yaml.Reload(coupons)
+	if isCached {
+		cached.InvalidateCache()
+	}
+	return true
+}
+
+// current returns the coupon map in effect. Reload swaps the map rather than
+// mutating it, so callers may range over the result without holding the lock.
+func (r *YAMLRepository) current() map[string]config.Coupon {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.coupons
+}
+
+// warnUntrackedUsageLimits flags usage_limit settings, which YAML coupons can't enforce.
+func warnUntrackedUsageLimits(coupons map[string]config.Coupon) {
 	for code, coupon := range coupons {
 		if coupon.UsageLimit != nil && *coupon.UsageLimit > 0 {
 			log.Warn().
@@ -25,15 +67,11 @@ func NewYAMLRepository(coupons map[string]config.Coupon) *YAMLRepository {
 				Msg("yaml_coupon.usage_limit_not_tracked")
 		}
 	}
-
-	return &YAMLRepository{
-		coupons: coupons,
-	}
 }
 
 // GetCoupon retrieves a coupon by code.
 func (r *YAMLRepository) GetCoupon(_ context.Context, code string) (Coupon, error) {
-	cfgCoupon, ok := r.coupons[code]
+	cfgCoupon, ok := r.current()[code]
 	if !ok {
 		return Coupon{}, ErrCouponNotFound
 	}
@@ -43,9 +81,10 @@ func (r *YAMLRepository) GetCoupon(_ context.Context, code string) (Coupon, erro
 
 // ListCoupons returns all active coupons.
 func (r *YAMLRepository) ListCoupons(_ context.Context) ([]Coupon, error) {
-	coupons := make([]Coupon, 0, len(r.coupons))
+	all := r.current()
+	coupons := make([]Coupon, 0, len(all))
 
-	for code, cfgCoupon := range r.coupons {
+	for code, cfgCoupon := range all {
 		coupons = append(coupons, configToCoupon(cfgCoupon, code))
 	}
 
@@ -56,7 +95,7 @@ func (r *YAMLRepository) ListCoupons(_ context.Context) ([]Coupon, error) {
 func (r *YAMLRepository) GetAutoApplyCouponsForPayment(ctx context.Context, productID string, paymentMethod PaymentMethod) ([]Coupon, error) {
 	coupons := make([]Coupon, 0)
 
-	for code, cfgCoupon := range r.coupons {
+	for code, cfgCoupon := range r.current() {
 		coupon := configToCoupon(cfgCoupon, code)
 
 		// Filter: AutoApply must be true
@@ -89,7 +128,7 @@ func (r *YAMLRepository) GetAutoApplyCouponsForPayment(ctx context.Context, prod
 func (r *YAMLRepository) GetAllAutoApplyCouponsForPayment(_ context.Context, paymentMethod PaymentMethod) (map[string][]Coupon, error) {
 	result := make(map[string][]Coupon)
 
-	for code, cfgCoupon := range r.coupons {
+	for code, cfgCoupon := range r.current() {
 		coupon := configToCoupon(cfgCoupon, code)
 
 		// Filter: AutoApply must be true
@@ -125,22 +164,22 @@ func (r *YAMLRepository) GetAllAutoApplyCouponsForPayment(_ context.Context, pay
 
 // CreateCoupon is not supported for YAML repository (read-only).
 func (r *YAMLRepository) CreateCoupon(_ context.Context, _ Coupon) error {
-	return errors.New("yaml repository is read-only")
+	return ErrReadOnly
 }
 
 // UpdateCoupon is not supported for YAML repository (read-only).
 func (r *YAMLRepository) UpdateCoupon(_ context.Context, _ Coupon) error {
-	return errors.New("yaml repository is read-only")
+	return ErrReadOnly
 }
 
 // IncrementUsage is not supported for YAML repository (read-only).
 func (r *YAMLRepository) IncrementUsage(_ context.Context, _ string) error {
-	return errors.New("yaml repository is read-only")
+	return ErrReadOnly
 }
 
 // DeleteCoupon is not supported for YAML repository (read-only).
 func (r *YAMLRepository) DeleteCoupon(_ context.Context, _ string) error {
-	return errors.New("yaml repository is read-only")
+	return ErrReadOnly
 }
 
 // Close is a no-op for YAML repository.
@@ -151,9 +190,12 @@ func (r *YAMLRepository) Close() error {
 // configToCoupon converts a config.Coupon to a coupons.Coupon.
 func configToCoupon(cfg config.Coupon, code string) Coupon {
 	var discountType DiscountType
-	if cfg.DiscountType == "fixed" {
+	switch cfg.DiscountType {
+	case "fixed":
 		discountType = DiscountTypeFixed
-	} else {
+	case "gift_card":
+		discountType = DiscountTypeGiftCard
+	default:
 		discountType = DiscountTypePercentage
 	}
 
@@ -186,23 +228,24 @@ func configToCoupon(cfg config.Coupon, code string) Coupon {
 	}
 
 	return Coupon{
-		Code:          code,
-		DiscountType:  discountType,
-		DiscountValue: cfg.DiscountValue,
-		Currency:      cfg.Currency,
-		Scope:         scope,
-		ProductIDs:    cfg.ProductIDs,
-		PaymentMethod: paymentMethod,
-		AutoApply:     cfg.AutoApply,
-		AppliesAt:     appliesAt,
-		UsageLimit:    cfg.UsageLimit,
-		UsageCount:    cfg.UsageCount, // Use configured usage count
-		StartsAt:      parseTime(cfg.StartsAt),
-		ExpiresAt:     parseTime(cfg.ExpiresAt),
-		Active:        cfg.Active,
-		Metadata:      cfg.Metadata,
-		CreatedAt:     time.Time{}, // Zero value - YAML repos are read-only
-		UpdatedAt:     time.Time{}, // Zero value - YAML repos are read-only
+		Code:                code,
+		DiscountType:        discountType,
+		DiscountValue:       cfg.DiscountValue,
+		Currency:            cfg.Currency,
+		Scope:               scope,
+		ProductIDs:          cfg.ProductIDs,
+		PaymentMethod:       paymentMethod,
+		AutoApply:           cfg.AutoApply,
+		AppliesAt:           appliesAt,
+		UsageLimit:          cfg.UsageLimit,
+		UsageLimitPerWallet: cfg.UsageLimitPerWallet,
+		UsageCount:          cfg.UsageCount, // Use configured usage count
+		StartsAt:            parseTime(cfg.StartsAt),
+		ExpiresAt:           parseTime(cfg.ExpiresAt),
+		Active:              cfg.Active,
+		Metadata:            cfg.Metadata,
+		CreatedAt:           time.Time{}, // Zero value - YAML repos are read-only
+		UpdatedAt:           time.Time{}, // Zero value - YAML repos are read-only
 	}
 }
 
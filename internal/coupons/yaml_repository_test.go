@@ -77,6 +77,51 @@ func TestYAMLRepository_GetCoupon(t *testing.T) {
 	}
 }
 
+func TestReloadYAML(t *testing.T) {
+	ctx := context.Background()
+	initial := map[string]config.Coupon{
+		"SAVE20": {DiscountType: "percentage", DiscountValue: 20.0, Active: true},
+	}
+	updated := map[string]config.Coupon{
+		"SAVE30": {DiscountType: "percentage", DiscountValue: 30.0, Active: true},
+	}
+
+	tests := []struct {
+		name       string
+		repo       Repository
+		wantReload bool
+	}{
+		{name: "yaml", repo: NewYAMLRepository(initial), wantReload: true},
+		{name: "cached yaml", repo: NewCachedRepository(NewYAMLRepository(initial), time.Hour), wantReload: true},
+		{name: "disabled", repo: NewDisabledRepository(), wantReload: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantReload {
+				// Warm the cache so the reload has to invalidate it
+				if _, err := tt.repo.GetCoupon(ctx, "SAVE20"); err != nil {
+					t.Fatalf("GetCoupon before reload: %v", err)
+				}
+			}
+
+			if got := ReloadYAML(tt.repo, updated); got != tt.wantReload {
+				t.Fatalf("ReloadYAML = %v, want %v", got, tt.wantReload)
+			}
+			if !tt.wantReload {
+				return
+			}
+			if _, err := tt.repo.GetCoupon(ctx, "SAVE20"); err == nil {
+				t.Error("removed coupon still served after reload")
+			}
+			coupon, err := tt.repo.GetCoupon(ctx, "SAVE30")
+			if err != nil || coupon.DiscountValue != 30.0 {
+				t.Errorf("GetCoupon(SAVE30) = %+v, %v", coupon, err)
+			}
+		})
+	}
+}
+
 func TestYAMLRepository_ListCoupons(t *testing.T) {
 	coupons := map[string]config.Coupon{
 		"COUPON1": {
@@ -43,6 +43,9 @@ const (
 	// Timeout/expiration errors
 	ErrCodeQuoteExpired       ErrorCode = "quote_expired"
 	ErrCodeTransactionExpired ErrorCode = "transaction_expired"
+
+	// Metering
+	ErrCodeMeterBalanceInsufficient ErrorCode = "meter_balance_insufficient" // The meter account can't cover a metered call
 )
 
 // Validation Errors (Request input validation)
@@ -59,15 +62,23 @@ const (
 
 // Resource/State Errors (Resource not found or in wrong state)
 const (
-	ErrCodeResourceNotFound ErrorCode = "resource_not_found"
-	ErrCodeCartNotFound     ErrorCode = "cart_not_found"
-	ErrCodeRefundNotFound   ErrorCode = "refund_not_found"
-	ErrCodeProductNotFound  ErrorCode = "product_not_found"
-	ErrCodeCouponNotFound   ErrorCode = "coupon_not_found"
-	ErrCodeSessionNotFound  ErrorCode = "session_not_found"
+	ErrCodeResourceNotFound     ErrorCode = "resource_not_found"
+	ErrCodeCartNotFound         ErrorCode = "cart_not_found"
+	ErrCodeRefundNotFound       ErrorCode = "refund_not_found"
+	ErrCodeProductNotFound      ErrorCode = "product_not_found"
+	ErrCodeCouponNotFound       ErrorCode = "coupon_not_found"
+	ErrCodeSessionNotFound      ErrorCode = "session_not_found"
+	ErrCodeVerificationNotFound ErrorCode = "verification_not_found"
+	ErrCodeWalletNotFound       ErrorCode = "wallet_not_found"
+	ErrCodeCustomerNotFound     ErrorCode = "customer_not_found"
+	ErrCodeAccessRuleNotFound   ErrorCode = "access_rule_not_found"
 
 	ErrCodeCartAlreadyPaid        ErrorCode = "cart_already_paid"
 	ErrCodeRefundAlreadyProcessed ErrorCode = "refund_already_processed"
+	ErrCodeOutOfStock             ErrorCode = "out_of_stock"
+
+	ErrCodeProductAlreadyExists   ErrorCode = "product_already_exists"
+	ErrCodeProductCatalogReadOnly ErrorCode = "product_catalog_read_only" // Products come from YAML config
 )
 
 // Coupon-Specific Errors
@@ -76,6 +87,30 @@ const (
 	ErrCodeCouponUsageLimitReached  ErrorCode = "coupon_usage_limit_reached"
 	ErrCodeCouponNotApplicable      ErrorCode = "coupon_not_applicable"
 	ErrCodeCouponWrongPaymentMethod ErrorCode = "coupon_wrong_payment_method"
+
+	ErrCodeCouponAlreadyExists  ErrorCode = "coupon_already_exists"
+	ErrCodeCouponSourceReadOnly ErrorCode = "coupon_source_read_only" // Coupons come from YAML config or are disabled
+)
+
+// Idempotency Errors (Idempotency-Key header misuse)
+const (
+	ErrCodeIdempotencyKeyInUse  ErrorCode = "idempotency_key_in_use" // Original request still processing
+	ErrCodeIdempotencyKeyReused ErrorCode = "idempotency_key_reused" // Key sent again with a different body
+)
+
+// Authentication Errors (admin, merchant, and API keys)
+const (
+	ErrCodeUnauthorized      ErrorCode = "unauthorized"       // Missing or invalid admin or merchant access key
+	ErrCodeInsufficientScope ErrorCode = "insufficient_scope" // The API key's scopes don't cover the endpoint
+)
+
+// Fraud Errors (paywall.fraud velocity rules, the admin access lists, geo_restriction, and paywall.screening)
+const (
+	ErrCodeVelocityLimitExceeded ErrorCode = "velocity_limit_exceeded"
+	ErrCodeAccessDenied          ErrorCode = "access_denied"         // Wallet or client IP is on the deny list
+	ErrCodeCountryRestricted     ErrorCode = "country_restricted"    // Client's country is blocked by geo_restriction
+	ErrCodeComplianceBlocked     ErrorCode = "compliance_blocked"    // Compliance screening flagged the wallet
+	ErrCodeScreeningUnavailable  ErrorCode = "screening_unavailable" // Screening provider failed with fail_mode closed
 )
 
 // External Service Errors (Stripe, RPC, etc.)
@@ -83,13 +118,17 @@ const (
 	ErrCodeStripeError  ErrorCode = "stripe_error"
 	ErrCodeRPCError     ErrorCode = "rpc_error"
 	ErrCodeNetworkError ErrorCode = "network_error"
+
+	ErrCodeUpstreamUnavailable ErrorCode = "upstream_unavailable" // The proxy's upstream origin could not be reached
 )
 
 // Internal/System Errors
 const (
-	ErrCodeInternalError ErrorCode = "internal_error"
-	ErrCodeDatabaseError ErrorCode = "database_error"
-	ErrCodeConfigError   ErrorCode = "config_error"
+	ErrCodeInternalError      ErrorCode = "internal_error"
+	ErrCodeDatabaseError      ErrorCode = "database_error"
+	ErrCodeConfigError        ErrorCode = "config_error"
+	ErrCodeServiceUnavailable ErrorCode = "service_unavailable"
+	ErrCodeGaslessUnavailable ErrorCode = "gasless_unavailable"
 )
 
 // IsRetryable returns whether an error code represents a retryable error.
@@ -100,7 +139,10 @@ func (e ErrorCode) IsRetryable() bool {
 	case ErrCodeRPCError,
 		ErrCodeNetworkError,
 		ErrCodeStripeError,
-		ErrCodeTransactionNotConfirmed:
+		ErrCodeTransactionNotConfirmed,
+		ErrCodeIdempotencyKeyInUse,
+		ErrCodeScreeningUnavailable,
+		ErrCodeUpstreamUnavailable:
 		return true
 
 	// Validation, authorization, and permanent failures are NOT retryable
@@ -147,11 +189,18 @@ func (e ErrorCode) HTTPStatus() int {
 		ErrCodePaymentAlreadyUsed,
 		ErrCodeSignatureReused,
 		ErrCodeQuoteExpired,
-		ErrCodeTransactionExpired:
+		ErrCodeTransactionExpired,
+		ErrCodeMeterBalanceInsufficient:
 		return 402
 
+	// 401 Unauthorized - Authentication failures
+	case ErrCodeUnauthorized:
+		return 401
+
 	// 403 Forbidden - Authorization failures
-	case ErrCodeUnauthorizedRefundIssuer:
+	case ErrCodeUnauthorizedRefundIssuer,
+		ErrCodeAccessDenied,
+		ErrCodeInsufficientScope:
 		return 403
 
 	// 404 Not Found - Resource not found
@@ -160,22 +209,53 @@ func (e ErrorCode) HTTPStatus() int {
 		ErrCodeRefundNotFound,
 		ErrCodeProductNotFound,
 		ErrCodeCouponNotFound,
-		ErrCodeSessionNotFound:
+		ErrCodeSessionNotFound,
+		ErrCodeVerificationNotFound,
+		ErrCodeWalletNotFound,
+		ErrCodeCustomerNotFound,
+		ErrCodeAccessRuleNotFound:
 		return 404
 
-	// 409 Conflict - Coupon validation failures (business rule conflicts)
+	// 409 Conflict - Coupon validation failures (business rule conflicts), exhausted stock, product catalog and coupon admin conflicts, and in-flight idempotent requests
 	case ErrCodeCouponExpired,
 		ErrCodeCouponUsageLimitReached,
 		ErrCodeCouponNotApplicable,
-		ErrCodeCouponWrongPaymentMethod:
+		ErrCodeCouponWrongPaymentMethod,
+		ErrCodeCouponAlreadyExists,
+		ErrCodeCouponSourceReadOnly,
+		ErrCodeOutOfStock,
+		ErrCodeProductAlreadyExists,
+		ErrCodeProductCatalogReadOnly,
+		ErrCodeIdempotencyKeyInUse:
 		return 409
 
+	// 422 Unprocessable Entity - Idempotency key reused for a different request
+	case ErrCodeIdempotencyKeyReused:
+		return 422
+
+	// 429 Too Many Requests - A velocity rule blocked the request
+	case ErrCodeVelocityLimitExceeded:
+		return 429
+
+	// 451 Unavailable For Legal Reasons - geo_restriction blocked the client's country, or
+	// compliance screening flagged the wallet
+	case ErrCodeCountryRestricted,
+		ErrCodeComplianceBlocked:
+		return 451
+
 	// 502 Bad Gateway - External service errors
 	case ErrCodeStripeError,
 		ErrCodeRPCError,
-		ErrCodeNetworkError:
+		ErrCodeNetworkError,
+		ErrCodeUpstreamUnavailable:
 		return 502
 
+	// 503 Service Unavailable - Temporarily overloaded
+	case ErrCodeServiceUnavailable,
+		ErrCodeGaslessUnavailable,
+		ErrCodeScreeningUnavailable:
+		return 503
+
 	// 500 Internal Server Error - System/internal errors
 	default:
 		return 500
@@ -0,0 +1,181 @@
+package eventbus
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/CedrosPay/server/internal/tenant"
+)
+
+// Event types published on the bus.
+const (
+	TypePaymentSucceeded                = "payment.succeeded"
+	TypePaymentFailed                   = "payment.failed"
+	TypeRefundSucceeded                 = "refund.succeeded"
+	TypeRefundAutoDenied                = "refund.auto_denied"
+	TypeRefundEscalated                 = "refund.escalated"
+	TypeSubscriptionCreated             = "subscription.created"
+	TypeSubscriptionRenewed             = "subscription.renewed"
+	TypeSubscriptionCancelled           = "subscription.cancelled"
+	TypeSubscriptionPastDue             = "subscription.past_due"
+	TypeSubscriptionPaused              = "subscription.paused"
+	TypeSubscriptionResumed             = "subscription.resumed"
+	TypeSubscriptionGracePeriodExpiring = "subscription.grace_period_expiring"
+	TypeSubscriptionRenewalReminder     = "subscription.renewal_reminder"
+	TypeWebhookFailed                   = "webhook.failed"
+)
+
+// Event is a merchant-facing notification.
+type Event struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	TenantID  string    `json:"tenantId"`
+	Timestamp time.Time `json:"timestamp"`
+	Data      any       `json:"data"`
+}
+
+// defaultBufferSize is the per-subscriber channel capacity.
+const defaultBufferSize = 64
+
+// Bus is an in-process publish/subscribe hub. Subscribers only receive events for
+// their own tenant, optionally narrowed to specific event types.
+// Publishing never blocks: events are dropped for subscribers whose buffer is full.
+// A nil *Bus is valid and discards all events.
+type Bus struct {
+	mu         sync.RWMutex
+	subs       map[*Subscription]struct{}
+	bufferSize int
+}
+
+// New creates a bus with the given per-subscriber buffer (default: 64).
+func New(bufferSize int) *Bus {
+	if bufferSize <= 0 {
+		bufferSize = defaultBufferSize
+	}
+	return &Bus{
+		subs:       make(map[*Subscription]struct{}),
+		bufferSize: bufferSize,
+	}
+}
+
+// Subscription receives events for a single tenant.
+type Subscription struct {
+	C <-chan Event
+
+	bus      *Bus
+	ch       chan Event
+	tenantID string
+	types    map[string]struct{}
+	dropped  atomic.Int64
+	once     sync.Once
+}
+
+// Subscribe registers a subscriber for tenantID. If types is empty, all event types are delivered.
+func (b *Bus) Subscribe(tenantID string, types ...string) *Subscription {
+	if tenantID == "" {
+		tenantID = tenant.DefaultTenantID
+	}
+	size := defaultBufferSize
+	if b != nil {
+		size = b.bufferSize
+	}
+	ch := make(chan Event, size)
+	sub := &Subscription{
+		C:        ch,
+		bus:      b,
+		ch:       ch,
+		tenantID: tenantID,
+	}
+	if len(types) > 0 {
+		sub.types = make(map[string]struct{}, len(types))
+		for _, t := range types {
+			sub.types[t] = struct{}{}
+		}
+	}
+
+	if b != nil {
+		b.mu.Lock()
+		b.subs[sub] = struct{}{}
+		b.mu.Unlock()
+	}
+	return sub
+}
+
+// Publish delivers an event to matching subscribers. ID, TenantID and Timestamp are
+// filled in when empty.
+func (b *Bus) Publish(event Event) {
+	if b == nil {
+		return
+	}
+	if event.ID == "" {
+		event.ID = newEventID()
+	}
+	if event.TenantID == "" {
+		event.TenantID = tenant.DefaultTenantID
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for sub := range b.subs {
+		if !sub.matches(event) {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
+
+// SubscriberCount returns the number of active subscriptions.
+func (b *Bus) SubscriberCount() int {
+	if b == nil {
+		return 0
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs)
+}
+
+// Close unregisters the subscription and closes its channel. Safe to call more than once.
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		if s.bus != nil {
+			s.bus.mu.Lock()
+			delete(s.bus.subs, s)
+			s.bus.mu.Unlock()
+		}
+		close(s.ch)
+	})
+}
+
+// Dropped returns how many events were discarded because the subscriber fell behind.
+func (s *Subscription) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+func (s *Subscription) matches(event Event) bool {
+	if event.TenantID != s.tenantID {
+		return false
+	}
+	if s.types == nil {
+		return true
+	}
+	_, ok := s.types[event.Type]
+	return ok
+}
+
+func newEventID() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "evt_" + time.Now().UTC().Format("20060102150405.000000000")
+	}
+	return "evt_" + hex.EncodeToString(b)
+}
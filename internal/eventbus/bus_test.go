@@ -0,0 +1,74 @@
+package eventbus
+
+import (
+	"testing"
+)
+
+func TestBus_TenantAndTypeFiltering(t *testing.T) {
+	bus := New(8)
+
+	all := bus.Subscribe("acme")
+	defer all.Close()
+	failures := bus.Subscribe("acme", TypeWebhookFailed)
+	defer failures.Close()
+	other := bus.Subscribe("globex")
+	defer other.Close()
+
+	bus.Publish(Event{Type: TypePaymentSucceeded, TenantID: "acme"})
+	bus.Publish(Event{Type: TypeWebhookFailed, TenantID: "acme"})
+
+	if got := len(all.C); got != 2 {
+		t.Errorf("expected 2 events for unfiltered subscriber, got %d", got)
+	}
+	if got := len(failures.C); got != 1 {
+		t.Errorf("expected 1 event for webhook.failed subscriber, got %d", got)
+	}
+	if got := len(other.C); got != 0 {
+		t.Errorf("expected no events for another tenant, got %d", got)
+	}
+
+	event := <-all.C
+	if event.ID == "" || event.Timestamp.IsZero() {
+		t.Errorf("expected ID and timestamp to be populated: %+v", event)
+	}
+}
+
+func TestBus_DefaultTenant(t *testing.T) {
+	bus := New(8)
+	sub := bus.Subscribe("")
+	defer sub.Close()
+
+	bus.Publish(Event{Type: TypeRefundSucceeded})
+
+	if got := len(sub.C); got != 1 {
+		t.Fatalf("expected event without tenant to reach default tenant subscriber, got %d", got)
+	}
+}
+
+func TestBus_DropsWhenSubscriberIsFull(t *testing.T) {
+	bus := New(1)
+	sub := bus.Subscribe("acme")
+	defer sub.Close()
+
+	bus.Publish(Event{Type: TypePaymentSucceeded, TenantID: "acme"})
+	bus.Publish(Event{Type: TypePaymentSucceeded, TenantID: "acme"})
+
+	if sub.Dropped() != 1 {
+		t.Fatalf("expected 1 dropped event, got %d", sub.Dropped())
+	}
+}
+
+func TestSubscription_CloseUnregisters(t *testing.T) {
+	bus := New(8)
+	sub := bus.Subscribe("acme")
+	sub.Close()
+	sub.Close() // idempotent
+
+	if bus.SubscriberCount() != 0 {
+		t.Fatalf("expected no subscribers after close, got %d", bus.SubscriberCount())
+	}
+	if _, ok := <-sub.C; ok {
+		t.Fatal("expected channel to be closed")
+	}
+	bus.Publish(Event{Type: TypePaymentSucceeded, TenantID: "acme"}) // must not panic
+}
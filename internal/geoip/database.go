@@ -0,0 +1,145 @@
+package geoip
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Database maps IP ranges to ISO 3166-1 alpha-2 country codes.
+type Database struct {
+	ranges []ipRange // Sorted by start
+}
+
+type ipRange struct {
+	start, end netip.Addr
+	country    string
+}
+
+// Open loads an IP-to-country CSV file. See Parse for the formats accepted.
+func Open(path string) (*Database, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("geoip: open database: %w", err)
+	}
+	defer f.Close()
+
+	db, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("geoip: %s: %w", path, err)
+	}
+	return db, nil
+}
+
+// Parse reads an IP-to-country CSV. Each row is either start_ip,end_ip,country (the DB-IP and
+// ipinfo country formats) or cidr,country; extra columns are ignored. A header row and lines
+// starting with '#' are skipped.
+func Parse(r io.Reader) (*Database, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+
+	db := &Database{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		rng, err := parseRange(record)
+		if err != nil {
+			if line == 1 {
+				continue // Header row
+			}
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		db.ranges = append(db.ranges, rng)
+	}
+
+	sort.Slice(db.ranges, func(i, j int) bool { return db.ranges[i].start.Less(db.ranges[j].start) })
+	return db, nil
+}
+
+// parseRange reads one CSV row as start_ip,end_ip,country or cidr,country.
+func parseRange(record []string) (ipRange, error) {
+	if len(record) >= 2 && strings.Contains(record[0], "/") {
+		prefix, err := netip.ParsePrefix(record[0])
+		if err != nil {
+			return ipRange{}, err
+		}
+		prefix = prefix.Masked()
+		return newRange(prefix.Addr(), lastAddr(prefix), record[1])
+	}
+	if len(record) < 3 {
+		return ipRange{}, fmt.Errorf("want start_ip,end_ip,country or cidr,country, got %d columns", len(record))
+	}
+	start, err := netip.ParseAddr(record[0])
+	if err != nil {
+		return ipRange{}, err
+	}
+	end, err := netip.ParseAddr(record[1])
+	if err != nil {
+		return ipRange{}, err
+	}
+	return newRange(start.Unmap(), end.Unmap(), record[2])
+}
+
+func newRange(start, end netip.Addr, country string) (ipRange, error) {
+	if start.Is4() != end.Is4() || end.Less(start) {
+		return ipRange{}, fmt.Errorf("invalid range %s-%s", start, end)
+	}
+	country = NormalizeCountry(country)
+	if country == "" {
+		return ipRange{}, fmt.Errorf("invalid country code")
+	}
+	return ipRange{start: start, end: end, country: country}, nil
+}
+
+// lastAddr returns the last address in a masked prefix.
+func lastAddr(prefix netip.Prefix) netip.Addr {
+	b := prefix.Addr().AsSlice()
+	for i := prefix.Bits(); i < len(b)*8; i++ {
+		b[i/8] |= 1 << (7 - i%8)
+	}
+	addr, _ := netip.AddrFromSlice(b)
+	return addr
+}
+
+// Country returns the country addr is in, or "" if no range covers it.
+func (db *Database) Country(addr netip.Addr) string {
+	if db == nil || !addr.IsValid() {
+		return ""
+	}
+	addr = addr.Unmap()
+	// Last range starting at or before addr
+	i := sort.Search(len(db.ranges), func(i int) bool { return addr.Less(db.ranges[i].start) }) - 1
+	if i < 0 || db.ranges[i].end.Less(addr) {
+		return ""
+	}
+	return db.ranges[i].country
+}
+
+// Len returns the number of IP ranges in the database.
+func (db *Database) Len() int {
+	if db == nil {
+		return 0
+	}
+	return len(db.ranges)
+}
+
+// NormalizeCountry upper-cases a two-letter country code, returning "" for anything else.
+func NormalizeCountry(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 2 || code[0] < 'A' || code[0] > 'Z' || code[1] < 'A' || code[1] > 'Z' {
+		return ""
+	}
+	return code
+}
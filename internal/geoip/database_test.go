@@ -0,0 +1,63 @@
+package geoip
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+const testDatabase = `start_ip,end_ip,country
+# DB-IP style ranges
+1.0.0.0,1.0.0.255,AU
+81.2.69.0,81.2.69.255,gb
+2001:db8::,2001:db8::ffff,DE
+203.0.113.0/24,KP
+`
+
+func TestDatabaseCountry(t *testing.T) {
+	db, err := Parse(strings.NewReader(testDatabase))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if db.Len() != 4 {
+		t.Fatalf("Len = %d, want 4", db.Len())
+	}
+
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{ip: "1.0.0.0", want: "AU"},
+		{ip: "1.0.0.255", want: "AU"},
+		{ip: "1.0.1.0", want: ""},
+		{ip: "81.2.69.160", want: "GB"},
+		{ip: "::ffff:81.2.69.160", want: "GB"},
+		{ip: "203.0.113.99", want: "KP"},
+		{ip: "2001:db8::42", want: "DE"},
+		{ip: "2001:db8::1:0", want: ""},
+		{ip: "0.0.0.1", want: ""},
+	}
+	for _, tt := range tests {
+		if got := db.Country(netip.MustParseAddr(tt.ip)); got != tt.want {
+			t.Errorf("Country(%s) = %q, want %q", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		csv  string
+	}{
+		{name: "bad address", csv: "1.0.0.0,1.0.0.255,AU\n1.0.0.x,1.0.1.255,AU\n"},
+		{name: "reversed range", csv: "1.0.0.0,1.0.0.255,AU\n1.0.1.255,1.0.1.0,AU\n"},
+		{name: "mixed families", csv: "1.0.0.0,1.0.0.255,AU\n1.0.1.0,2001:db8::1,AU\n"},
+		{name: "bad country", csv: "1.0.0.0,1.0.0.255,AU\n1.0.1.0,1.0.1.255,Australia\n"},
+		{name: "too few columns", csv: "1.0.0.0,1.0.0.255,AU\n1.0.1.0,AU\n"},
+	}
+	for _, tt := range tests {
+		if _, err := Parse(strings.NewReader(tt.csv)); err == nil {
+			t.Errorf("%s: Parse succeeded, want error", tt.name)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package geoip
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+
+	apierrors "github.com/CedrosPay/server/internal/errors"
+	"github.com/CedrosPay/server/internal/logger"
+)
+
+// unknownCountry is the code CDNs such as Cloudflare send when they can't place an IP.
+const unknownCountry = "XX"
+
+// Config holds geo-restriction configuration.
+type Config struct {
+	Enabled bool
+
+	// AllowedCountries, when set, are the only countries requests are accepted from.
+	AllowedCountries []string
+	// BlockedCountries are never accepted from.
+	BlockedCountries []string
+
+	// CountryHeader is a request header carrying the client's country, set by a trusted CDN or
+	// proxy (e.g. CF-IPCountry). It is read before Database.
+	CountryHeader string
+	// Database resolves the client IP when the header is unset or absent (optional).
+	Database *Database
+
+	// BlockUnknown rejects requests whose country can't be determined.
+	BlockUnknown bool
+}
+
+// Middleware rejects requests from restricted countries with 451 country_restricted.
+// The client IP comes from r.RemoteAddr, so it must run after middleware.RealIP.
+func Middleware(cfg Config) func(http.Handler) http.Handler {
+	if !cfg.Enabled {
+		return func(next http.Handler) http.Handler { return next }
+	}
+
+	allowed := countrySet(cfg.AllowedCountries)
+	blocked := countrySet(cfg.BlockedCountries)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			country := cfg.country(r)
+			if country == "" {
+				if cfg.BlockUnknown {
+					reject(w, r, country)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+			if blocked[country] || (len(allowed) > 0 && !allowed[country]) {
+				reject(w, r, country)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// country returns the request's country from the header or the database, or "" if unknown.
+func (cfg Config) country(r *http.Request) string {
+	if cfg.CountryHeader != "" {
+		if country := NormalizeCountry(r.Header.Get(cfg.CountryHeader)); country != "" && country != unknownCountry {
+			return country
+		}
+	}
+	if cfg.Database == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return ""
+	}
+	return cfg.Database.Country(addr)
+}
+
+func reject(w http.ResponseWriter, r *http.Request, country string) {
+	if country == "" {
+		country = "unknown"
+	}
+	log := logger.FromContext(r.Context())
+	log.Warn().
+		Str("country", country).
+		Str("path", r.URL.Path).
+		Msg("geoip.request_blocked")
+	apierrors.WriteErrorWithDetail(w, apierrors.ErrCodeCountryRestricted, "payments are not available in your country", "country", country)
+}
+
+func countrySet(codes []string) map[string]bool {
+	set := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		if code = NormalizeCountry(code); code != "" {
+			set[code] = true
+		}
+	}
+	return set
+}
@@ -0,0 +1,55 @@
+package geoip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	db, err := Parse(strings.NewReader(testDatabase))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		cfg        Config
+		remoteAddr string
+		header     string
+		wantStatus int
+	}{
+		{name: "disabled", cfg: Config{BlockedCountries: []string{"KP"}, Database: db}, remoteAddr: "203.0.113.9:1234", wantStatus: http.StatusOK},
+		{name: "blocked by database", cfg: Config{Enabled: true, BlockedCountries: []string{"KP"}, Database: db}, remoteAddr: "203.0.113.9:1234", wantStatus: http.StatusUnavailableForLegalReasons},
+		{name: "not blocked", cfg: Config{Enabled: true, BlockedCountries: []string{"KP"}, Database: db}, remoteAddr: "1.0.0.1:1234", wantStatus: http.StatusOK},
+		{name: "blocked by header", cfg: Config{Enabled: true, BlockedCountries: []string{"KP"}, CountryHeader: "CF-IPCountry"}, remoteAddr: "1.0.0.1", header: "kp", wantStatus: http.StatusUnavailableForLegalReasons},
+		{name: "header wins over database", cfg: Config{Enabled: true, BlockedCountries: []string{"KP"}, CountryHeader: "CF-IPCountry", Database: db}, remoteAddr: "203.0.113.9", header: "US", wantStatus: http.StatusOK},
+		{name: "unknown header falls back to database", cfg: Config{Enabled: true, BlockedCountries: []string{"KP"}, CountryHeader: "CF-IPCountry", Database: db}, remoteAddr: "203.0.113.9", header: "XX", wantStatus: http.StatusUnavailableForLegalReasons},
+		{name: "allowed country", cfg: Config{Enabled: true, AllowedCountries: []string{"AU", "GB"}, Database: db}, remoteAddr: "81.2.69.1:443", wantStatus: http.StatusOK},
+		{name: "not on allow list", cfg: Config{Enabled: true, AllowedCountries: []string{"AU", "GB"}, Database: db}, remoteAddr: "[2001:db8::1]:443", wantStatus: http.StatusUnavailableForLegalReasons},
+		{name: "blocked wins over allowed", cfg: Config{Enabled: true, AllowedCountries: []string{"AU"}, BlockedCountries: []string{"AU"}, Database: db}, remoteAddr: "1.0.0.1", wantStatus: http.StatusUnavailableForLegalReasons},
+		{name: "unknown allowed", cfg: Config{Enabled: true, AllowedCountries: []string{"AU"}, Database: db}, remoteAddr: "192.0.2.1", wantStatus: http.StatusOK},
+		{name: "unknown blocked", cfg: Config{Enabled: true, AllowedCountries: []string{"AU"}, Database: db, BlockUnknown: true}, remoteAddr: "192.0.2.1", wantStatus: http.StatusUnavailableForLegalReasons},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := Middleware(tt.cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			req := httptest.NewRequest(http.MethodPost, "/paywall/v1/quote", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.header != "" {
+				req.Header.Set("CF-IPCountry", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK && !strings.Contains(rec.Body.String(), "country_restricted") {
+				t.Errorf("body = %s, want country_restricted", rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package graphqlapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/graphql-go/graphql"
+
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/products"
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/internal/subscriptions"
+)
+
+// Services are the backends the schema resolves against.
+// Subscriptions may be nil when subscriptions are disabled.
+type Services struct {
+	Paywall       *paywall.Service
+	Subscriptions *subscriptions.Service
+}
+
+// ErrAdminRequired is returned for fields that expose payment and refund records, which the
+// REST API only serves to admins.
+var ErrAdminRequired = errors.New("admin API key required")
+
+type adminKey struct{}
+
+// WithAdmin marks ctx as belonging to a request authenticated with the admin API key.
+func WithAdmin(ctx context.Context) context.Context {
+	return context.WithValue(ctx, adminKey{}, true)
+}
+
+func isAdmin(ctx context.Context) bool {
+	admin, _ := ctx.Value(adminKey{}).(bool)
+	return admin
+}
+
+// NewSchema builds the read-only storefront schema. Lookups that find nothing resolve to
+// null rather than an error, so one missing entity does not fail the whole query.
+func NewSchema(svc Services) (graphql.Schema, error) {
+	if svc.Paywall == nil {
+		return graphql.Schema{}, errors.New("graphqlapi: paywall service required")
+	}
+	r := resolver{svc: svc}
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"products": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(productType))),
+				Description: "Active products in the catalog.",
+				Resolve:     r.products,
+			},
+			"product": &graphql.Field{
+				Type:        productType,
+				Description: "A single product by ID.",
+				Args:        idArgs("id"),
+				Resolve:     r.product,
+			},
+			"quote": &graphql.Field{
+				Type:        quoteType,
+				Description: "Payment quote (Stripe and x402) for a resource, with an optional coupon applied.",
+				Args: graphql.FieldConfigArgument{
+					"resource":   &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"couponCode": &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: r.quote,
+			},
+			"cart": &graphql.Field{
+				Type:        cartType,
+				Description: "A cart quote by ID.",
+				Args:        idArgs("id"),
+				Resolve:     r.cart,
+			},
+			"payment": &graphql.Field{
+				Type:        paymentType,
+				Description: "A verified x402 payment by transaction signature. Requires the admin API key.",
+				Args: graphql.FieldConfigArgument{
+					"signature": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: r.payment,
+			},
+			"refund": &graphql.Field{
+				Type:        refundType,
+				Description: "A refund request by ID. Requires the admin API key.",
+				Args:        idArgs("id"),
+				Resolve:     r.refund,
+			},
+			"subscription": &graphql.Field{
+				Type:        subscriptionType,
+				Description: "A wallet's subscription to a product (null if none or subscriptions are disabled).",
+				Args: graphql.FieldConfigArgument{
+					"wallet":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"productId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: r.subscription,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query})
+}
+
+// Execute runs a GraphQL request against schema.
+func Execute(ctx context.Context, schema graphql.Schema, query, operationName string, variables map[string]any) *graphql.Result {
+	return graphql.Do(graphql.Params{
+		Schema:         schema,
+		RequestString:  query,
+		OperationName:  operationName,
+		VariableValues: variables,
+		Context:        ctx,
+	})
+}
+
+func idArgs(name string) graphql.FieldConfigArgument {
+	return graphql.FieldConfigArgument{
+		name: &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+	}
+}
+
+type resolver struct {
+	svc Services
+}
+
+func (r resolver) products(p graphql.ResolveParams) (any, error) {
+	list, err := r.svc.Paywall.ListProducts(p.Context)
+	if err != nil {
+		return nil, fmt.Errorf("list products: %w", err)
+	}
+	views := make([]productView, 0, len(list))
+	for _, product := range list {
+		views = append(views, newProductView(product))
+	}
+	return views, nil
+}
+
+func (r resolver) product(p graphql.ResolveParams) (any, error) {
+	product, err := r.svc.Paywall.GetProduct(p.Context, p.Args["id"].(string))
+	if errors.Is(err, products.ErrProductNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get product: %w", err)
+	}
+	view := newProductView(product)
+	return &view, nil
+}
+
+func (r resolver) quote(p graphql.ResolveParams) (any, error) {
+	couponCode, _ := p.Args["couponCode"].(string)
+	quote, err := r.svc.Paywall.GenerateQuote(p.Context, p.Args["resource"].(string), couponCode)
+	if errors.Is(err, paywall.ErrResourceNotConfigured) || errors.Is(err, products.ErrProductNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("generate quote: %w", err)
+	}
+	view := newQuoteView(quote)
+	return &view, nil
+}
+
+func (r resolver) cart(p graphql.ResolveParams) (any, error) {
+	cart, err := r.svc.Paywall.GetCartQuote(p.Context, p.Args["id"].(string))
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get cart: %w", err)
+	}
+	view := newCartView(cart)
+	return &view, nil
+}
+
+func (r resolver) payment(p graphql.ResolveParams) (any, error) {
+	if !isAdmin(p.Context) {
+		return nil, ErrAdminRequired
+	}
+	signature := p.Args["signature"].(string)
+	// GetPayment does not wrap storage.ErrNotFound, so check existence first
+	processed, err := r.svc.Paywall.HasPaymentBeenProcessed(p.Context, signature)
+	if err != nil {
+		return nil, fmt.Errorf("lookup payment: %w", err)
+	}
+	if !processed {
+		return nil, nil
+	}
+	payment, err := r.svc.Paywall.GetPayment(p.Context, signature)
+	if err != nil {
+		return nil, err
+	}
+	view := newPaymentView(payment)
+	return &view, nil
+}
+
+func (r resolver) refund(p graphql.ResolveParams) (any, error) {
+	if !isAdmin(p.Context) {
+		return nil, ErrAdminRequired
+	}
+	refund, err := r.svc.Paywall.GetRefundQuote(p.Context, p.Args["id"].(string))
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get refund: %w", err)
+	}
+	view := newRefundView(refund)
+	return &view, nil
+}
+
+func (r resolver) subscription(p graphql.ResolveParams) (any, error) {
+	if r.svc.Subscriptions == nil {
+		return nil, nil
+	}
+	sub, err := r.svc.Subscriptions.GetByWallet(p.Context, p.Args["wallet"].(string), p.Args["productId"].(string))
+	if errors.Is(err, subscriptions.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get subscription: %w", err)
+	}
+	view := newSubscriptionView(sub)
+	return &view, nil
+}
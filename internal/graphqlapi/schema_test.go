@@ -0,0 +1,224 @@
+package graphqlapi
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/money"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/products"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func testServices(t *testing.T) (Services, storage.Store) {
+	t.Helper()
+	cfg := &config.Config{
+		X402: config.X402Config{
+			PaymentAddress: "11111111111111111111111111111111",
+			TokenMint:      "So11111111111111111111111111111111111111112",
+			Network:        "mainnet-beta",
+			TokenDecimals:  6,
+		},
+		Paywall: config.PaywallConfig{
+			QuoteTTL: config.Duration{Duration: time.Minute},
+			Resources: map[string]config.PaywallResource{
+				"demo-content": {
+					ResourceID:         "demo-content",
+					Description:        "demo",
+					FiatAmountCents:    100,
+					FiatCurrency:       "USD",
+					StripePriceID:      "price_123",
+					CryptoAtomicAmount: 1000000,
+					CryptoToken:        "USDC",
+					Metadata:           map[string]string{"tier": "gold", "category": "ebook"},
+				},
+			},
+		},
+	}
+	store := storage.NewMemoryStore()
+	repo := products.NewYAMLRepository(cfg.Paywall.Resources)
+	return Services{Paywall: paywall.NewService(cfg, store, nil, nil, repo, nil, nil)}, store
+}
+
+func execute(t *testing.T, svc Services, query string, variables map[string]any) map[string]any {
+	t.Helper()
+	schema, err := NewSchema(svc)
+	if err != nil {
+		t.Fatalf("NewSchema: %v", err)
+	}
+	result := Execute(WithAdmin(context.Background()), schema, query, "", variables)
+	if result.HasErrors() {
+		t.Fatalf("query errors: %v", result.Errors)
+	}
+	// Round-trip through JSON so assertions see what clients see
+	raw, err := json.Marshal(result.Data)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var data map[string]any
+	if err := json.Unmarshal(raw, &data); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	return data
+}
+
+func TestNewSchemaRequiresPaywall(t *testing.T) {
+	if _, err := NewSchema(Services{}); err == nil {
+		t.Fatal("expected error without paywall service")
+	}
+}
+
+func TestQueries(t *testing.T) {
+	svc, store := testServices(t)
+	ctx := context.Background()
+	usdc := money.MustGetAsset("USDC")
+
+	if err := store.SaveCartQuote(ctx, storage.CartQuote{
+		ID:        "cart_abc",
+		Items:     []storage.CartItem{{ResourceID: "demo-content", Quantity: 2, Price: money.New(usdc, 1000000)}},
+		Total:     money.New(usdc, 2000000),
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}); err != nil {
+		t.Fatalf("SaveCartQuote: %v", err)
+	}
+	if err := store.RecordPayment(ctx, storage.PaymentTransaction{
+		Signature:  "sig123",
+		ResourceID: "demo-content",
+		Wallet:     "wallet1",
+		Amount:     money.New(usdc, 1000000),
+		CreatedAt:  time.Now(),
+	}); err != nil {
+		t.Fatalf("RecordPayment: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]any
+		check     func(t *testing.T, data map[string]any)
+	}{
+		{
+			name:  "products",
+			query: `{ products { id fiatPrice { atomic asset } cryptoPrice { amount } metadata { key value } } }`,
+			check: func(t *testing.T, data map[string]any) {
+				list := data["products"].([]any)
+				if len(list) != 1 {
+					t.Fatalf("expected 1 product, got %d", len(list))
+				}
+				p := list[0].(map[string]any)
+				if p["id"] != "demo-content" {
+					t.Errorf("id = %v", p["id"])
+				}
+				if fiat := p["fiatPrice"].(map[string]any); fiat["atomic"] != "100" || fiat["asset"] != "USD" {
+					t.Errorf("fiatPrice = %v", fiat)
+				}
+				if crypto := p["cryptoPrice"].(map[string]any); crypto["amount"] != "1.000000" {
+					t.Errorf("cryptoPrice = %v", crypto)
+				}
+				meta := p["metadata"].([]any)
+				if len(meta) != 2 || meta[0].(map[string]any)["key"] != "category" {
+					t.Errorf("metadata not sorted by key: %v", meta)
+				}
+			},
+		},
+		{
+			name:      "missing product is null",
+			query:     `query($id: ID!) { product(id: $id) { id } }`,
+			variables: map[string]any{"id": "nope"},
+			check: func(t *testing.T, data map[string]any) {
+				if data["product"] != nil {
+					t.Errorf("expected null, got %v", data["product"])
+				}
+			},
+		},
+		{
+			name:  "quote",
+			query: `{ quote(resource: "demo-content") { resourceId stripe { amountCents currency } crypto { maxAmountRequired network } } }`,
+			check: func(t *testing.T, data map[string]any) {
+				q := data["quote"].(map[string]any)
+				if q["resourceId"] != "demo-content" {
+					t.Errorf("resourceId = %v", q["resourceId"])
+				}
+				if stripe := q["stripe"].(map[string]any); stripe["amountCents"] != "100" {
+					t.Errorf("stripe = %v", stripe)
+				}
+				if crypto := q["crypto"].(map[string]any); crypto["maxAmountRequired"] != "1000000" {
+					t.Errorf("crypto = %v", crypto)
+				}
+			},
+		},
+		{
+			name:  "cart",
+			query: `{ cart(id: "cart_abc") { id paid items { resourceId quantity } total { atomic } } }`,
+			check: func(t *testing.T, data map[string]any) {
+				c := data["cart"].(map[string]any)
+				if c["paid"] != false {
+					t.Errorf("paid = %v", c["paid"])
+				}
+				if total := c["total"].(map[string]any); total["atomic"] != "2000000" {
+					t.Errorf("total = %v", total)
+				}
+				if items := c["items"].([]any); len(items) != 1 || items[0].(map[string]any)["quantity"] != float64(2) {
+					t.Errorf("items = %v", items)
+				}
+			},
+		},
+		{
+			name:  "payment",
+			query: `{ payment(signature: "sig123") { wallet resourceId amount { atomic } } missing: payment(signature: "other") { wallet } }`,
+			check: func(t *testing.T, data map[string]any) {
+				p := data["payment"].(map[string]any)
+				if p["wallet"] != "wallet1" {
+					t.Errorf("wallet = %v", p["wallet"])
+				}
+				if data["missing"] != nil {
+					t.Errorf("expected unknown signature to be null, got %v", data["missing"])
+				}
+			},
+		},
+		{
+			name:  "refund and subscription null",
+			query: `{ refund(id: "refund_missing") { id } subscription(wallet: "w", productId: "demo-content") { id } }`,
+			check: func(t *testing.T, data map[string]any) {
+				if data["refund"] != nil || data["subscription"] != nil {
+					t.Errorf("expected nulls, got %v", data)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.check(t, execute(t, svc, tt.query, tt.variables))
+		})
+	}
+}
+
+func TestAdminFields(t *testing.T) {
+	svc, _ := testServices(t)
+	schema, err := NewSchema(svc)
+	if err != nil {
+		t.Fatalf("NewSchema: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{name: "payment", query: `{ payment(signature: "sig123") { wallet } }`},
+		{name: "refund", query: `{ refund(id: "refund_1") { id } }`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Execute(context.Background(), schema, tt.query, "", nil)
+			if len(result.Errors) != 1 || result.Errors[0].Message != ErrAdminRequired.Error() {
+				t.Fatalf("errors = %v, want %q", result.Errors, ErrAdminRequired)
+			}
+		})
+	}
+}
@@ -0,0 +1,411 @@
+package graphqlapi
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/graphql-go/graphql"
+
+	"github.com/CedrosPay/server/internal/money"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/products"
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/internal/subscriptions"
+)
+
+// Atomic amounts are exposed as strings: GraphQL Int is 32-bit and would overflow.
+
+var moneyType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "Money",
+	Description: "A monetary amount in a specific asset.",
+	Fields: graphql.Fields{
+		"atomic": &graphql.Field{Type: graphql.NewNonNull(graphql.String), Description: "Amount in the asset's smallest unit (cents, lamports, ...)."},
+		"amount": &graphql.Field{Type: graphql.NewNonNull(graphql.String), Description: "Amount in major units (e.g. \"10.50\")."},
+		"asset":  &graphql.Field{Type: graphql.NewNonNull(graphql.String), Description: "Asset code (USD, USDC, SOL, ...)."},
+	},
+})
+
+var metadataEntryType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "MetadataEntry",
+	Fields: graphql.Fields{
+		"key":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"value": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+	},
+})
+
+var metadataListType = graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(metadataEntryType)))
+
+var productSubscriptionType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "ProductSubscription",
+	Fields: graphql.Fields{
+		"billingPeriod":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"billingInterval": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"trialDays":       &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"allowX402":       &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+	},
+})
+
+var productType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Product",
+	Fields: graphql.Fields{
+		"id":            &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+		"description":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"active":        &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+		"fiatPrice":     &graphql.Field{Type: moneyType},
+		"cryptoPrice":   &graphql.Field{Type: moneyType},
+		"stripePriceId": &graphql.Field{Type: graphql.String},
+		"subscription":  &graphql.Field{Type: productSubscriptionType},
+		"metadata":      &graphql.Field{Type: metadataListType},
+	},
+})
+
+var stripeOptionType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "StripeOption",
+	Fields: graphql.Fields{
+		"priceId":     &graphql.Field{Type: graphql.String},
+		"amountCents": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"currency":    &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"description": &graphql.Field{Type: graphql.String},
+	},
+})
+
+var cryptoQuoteType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "CryptoQuote",
+	Description: "x402 payment requirements.",
+	Fields: graphql.Fields{
+		"scheme":            &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"network":           &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"maxAmountRequired": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"resource":          &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"description":       &graphql.Field{Type: graphql.String},
+		"payTo":             &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"asset":             &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"maxTimeoutSeconds": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+	},
+})
+
+var quoteType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Quote",
+	Fields: graphql.Fields{
+		"resourceId": &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+		"expiresAt":  &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+		"stripe":     &graphql.Field{Type: stripeOptionType},
+		"crypto":     &graphql.Field{Type: cryptoQuoteType},
+	},
+})
+
+var cartItemType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "CartItem",
+	Fields: graphql.Fields{
+		"resourceId": &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+		"quantity":   &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"price":      &graphql.Field{Type: graphql.NewNonNull(moneyType), Description: "Unit price locked at quote time."},
+	},
+})
+
+var cartType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Cart",
+	Fields: graphql.Fields{
+		"id":        &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+		"items":     &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(cartItemType)))},
+		"total":     &graphql.Field{Type: graphql.NewNonNull(moneyType)},
+		"paid":      &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+		"expired":   &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+		"createdAt": &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+		"expiresAt": &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+		"metadata":  &graphql.Field{Type: metadataListType},
+	},
+})
+
+var paymentType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Payment",
+	Fields: graphql.Fields{
+		"signature":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"resourceId": &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+		"wallet":     &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"amount":     &graphql.Field{Type: graphql.NewNonNull(moneyType)},
+		"createdAt":  &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+	},
+})
+
+var refundType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Refund",
+	Fields: graphql.Fields{
+		"id":                 &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+		"originalPurchaseId": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"recipientWallet":    &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"amount":             &graphql.Field{Type: graphql.NewNonNull(moneyType), Description: "Amount to refund; lower than requestedAmount when an admin approved less."},
+		"requestedAmount":    &graphql.Field{Type: graphql.NewNonNull(moneyType)},
+		"reason":             &graphql.Field{Type: graphql.String},
+		"processed":          &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+		"signature":          &graphql.Field{Type: graphql.String},
+		"createdAt":          &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+		"processedAt":        &graphql.Field{Type: graphql.DateTime},
+	},
+})
+
+var subscriptionType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Subscription",
+	Fields: graphql.Fields{
+		"id":                 &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+		"productId":          &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+		"wallet":             &graphql.Field{Type: graphql.String},
+		"status":             &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"active":             &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean), Description: "Whether the subscription currently grants access."},
+		"paymentMethod":      &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"billingPeriod":      &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"billingInterval":    &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"currentPeriodStart": &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+		"currentPeriodEnd":   &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+		"cancelAtPeriodEnd":  &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+		"quantity":           &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Description: "Seats billed for per-seat prices."},
+	},
+})
+
+// View structs adapt domain types to the schema; the default resolver matches fields by name.
+
+type moneyView struct {
+	Atomic string
+	Amount string
+	Asset  string
+}
+
+func newMoneyView(m money.Money) moneyView {
+	return moneyView{
+		Atomic: m.ToAtomic(),
+		Amount: m.ToMajor(),
+		Asset:  m.Asset.Code,
+	}
+}
+
+func newMoneyViewPtr(m *money.Money) *moneyView {
+	if m == nil {
+		return nil
+	}
+	view := newMoneyView(*m)
+	return &view
+}
+
+type metadataEntry struct {
+	Key   string
+	Value string
+}
+
+// metadataEntries flattens a map into key-sorted entries (GraphQL has no map type).
+func metadataEntries(m map[string]string) []metadataEntry {
+	entries := make([]metadataEntry, 0, len(m))
+	for k, v := range m {
+		entries = append(entries, metadataEntry{Key: k, Value: v})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries
+}
+
+type productSubscriptionView struct {
+	BillingPeriod   string
+	BillingInterval int
+	TrialDays       int
+	AllowX402       bool
+}
+
+type productView struct {
+	ID            string
+	Description   string
+	Active        bool
+	FiatPrice     *moneyView
+	CryptoPrice   *moneyView
+	StripePriceID string
+	Subscription  *productSubscriptionView
+	Metadata      []metadataEntry
+}
+
+func newProductView(p products.Product) productView {
+	view := productView{
+		ID:            p.ID,
+		Description:   p.Description,
+		Active:        p.Active,
+		FiatPrice:     newMoneyViewPtr(p.FiatPrice),
+		CryptoPrice:   newMoneyViewPtr(p.CryptoPrice),
+		StripePriceID: p.StripePriceID,
+		Metadata:      metadataEntries(p.Metadata),
+	}
+	if p.IsSubscription() {
+		view.Subscription = &productSubscriptionView{
+			BillingPeriod:   p.Subscription.BillingPeriod,
+			BillingInterval: p.Subscription.BillingInterval,
+			TrialDays:       p.Subscription.TrialDays,
+			AllowX402:       p.Subscription.AllowX402,
+		}
+	}
+	return view
+}
+
+type stripeOptionView struct {
+	PriceID     string
+	AmountCents string
+	Currency    string
+	Description string
+}
+
+type cryptoQuoteView struct {
+	Scheme            string
+	Network           string
+	MaxAmountRequired string
+	Resource          string
+	Description       string
+	PayTo             string
+	Asset             string
+	MaxTimeoutSeconds int
+}
+
+type quoteView struct {
+	ResourceID string
+	ExpiresAt  time.Time
+	Stripe     *stripeOptionView
+	Crypto     *cryptoQuoteView
+}
+
+func newQuoteView(q paywall.Quote) quoteView {
+	view := quoteView{
+		ResourceID: q.ResourceID,
+		ExpiresAt:  q.ExpiresAt,
+	}
+	if q.Stripe != nil {
+		view.Stripe = &stripeOptionView{
+			PriceID:     q.Stripe.PriceID,
+			AmountCents: strconv.FormatInt(q.Stripe.AmountCents, 10),
+			Currency:    q.Stripe.Currency,
+			Description: q.Stripe.Description,
+		}
+	}
+	if q.Crypto != nil {
+		view.Crypto = &cryptoQuoteView{
+			Scheme:            q.Crypto.Scheme,
+			Network:           q.Crypto.Network,
+			MaxAmountRequired: q.Crypto.MaxAmountRequired,
+			Resource:          q.Crypto.Resource,
+			Description:       q.Crypto.Description,
+			PayTo:             q.Crypto.PayTo,
+			Asset:             q.Crypto.Asset,
+			MaxTimeoutSeconds: q.Crypto.MaxTimeoutSeconds,
+		}
+	}
+	return view
+}
+
+type cartItemView struct {
+	ResourceID string
+	Quantity   int64
+	Price      moneyView
+}
+
+type cartView struct {
+	ID        string
+	Items     []cartItemView
+	Total     moneyView
+	Paid      bool
+	Expired   bool
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	Metadata  []metadataEntry
+}
+
+func newCartView(c storage.CartQuote) cartView {
+	items := make([]cartItemView, 0, len(c.Items))
+	for _, item := range c.Items {
+		items = append(items, cartItemView{
+			ResourceID: item.ResourceID,
+			Quantity:   item.Quantity,
+			Price:      newMoneyView(item.Price),
+		})
+	}
+	return cartView{
+		ID:        c.ID,
+		Items:     items,
+		Total:     newMoneyView(c.Total),
+		Paid:      c.WalletPaidBy != "",
+		Expired:   c.IsExpiredAt(time.Now()),
+		CreatedAt: c.CreatedAt,
+		ExpiresAt: c.ExpiresAt,
+		Metadata:  metadataEntries(c.Metadata),
+	}
+}
+
+type paymentView struct {
+	Signature  string
+	ResourceID string
+	Wallet     string
+	Amount     moneyView
+	CreatedAt  time.Time
+}
+
+func newPaymentView(p storage.PaymentTransaction) paymentView {
+	return paymentView{
+		Signature:  p.Signature,
+		ResourceID: p.ResourceID,
+		Wallet:     p.Wallet,
+		Amount:     newMoneyView(p.Amount),
+		CreatedAt:  p.CreatedAt,
+	}
+}
+
+type refundView struct {
+	ID                 string
+	OriginalPurchaseID string
+	RecipientWallet    string
+	Amount             moneyView
+	RequestedAmount    moneyView
+	Reason             string
+	Processed          bool
+	Signature          string
+	CreatedAt          time.Time
+	ProcessedAt        *time.Time
+}
+
+func newRefundView(r storage.RefundQuote) refundView {
+	return refundView{
+		ID:                 r.ID,
+		OriginalPurchaseID: r.OriginalPurchaseID,
+		RecipientWallet:    r.RecipientWallet,
+		Amount:             newMoneyView(r.Amount),
+		RequestedAmount:    newMoneyView(r.Requested()),
+		Reason:             r.Reason,
+		Processed:          r.IsProcessed(),
+		Signature:          r.Signature,
+		CreatedAt:          r.CreatedAt,
+		ProcessedAt:        r.ProcessedAt,
+	}
+}
+
+type subscriptionView struct {
+	ID                 string
+	ProductID          string
+	Wallet             string
+	Status             string
+	Active             bool
+	PaymentMethod      string
+	BillingPeriod      string
+	BillingInterval    int
+	CurrentPeriodStart time.Time
+	CurrentPeriodEnd   time.Time
+	CancelAtPeriodEnd  bool
+	Quantity           int
+}
+
+func newSubscriptionView(s subscriptions.Subscription) subscriptionView {
+	return subscriptionView{
+		ID:                 s.ID,
+		ProductID:          s.ProductID,
+		Wallet:             s.Wallet,
+		Status:             string(s.Status),
+		Active:             s.IsActive(),
+		PaymentMethod:      string(s.PaymentMethod),
+		BillingPeriod:      string(s.BillingPeriod),
+		BillingInterval:    s.BillingInterval,
+		CurrentPeriodStart: s.CurrentPeriodStart,
+		CurrentPeriodEnd:   s.CurrentPeriodEnd,
+		CancelAtPeriodEnd:  s.CancelAtPeriodEnd,
+		Quantity:           max(s.Quantity, 1),
+	}
+}
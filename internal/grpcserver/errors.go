@@ -0,0 +1,56 @@
+package grpcserver
+
+import (
+	"errors"
+	"net/http"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	apierrors "github.com/CedrosPay/server/internal/errors"
+	"github.com/CedrosPay/server/pkg/x402"
+)
+
+// errorDomain identifies Cedros error codes in google.rpc.ErrorInfo details.
+const errorDomain = "cedrospay"
+
+// statusError builds a gRPC status for a Cedros error code. The gRPC code follows the
+// HTTP status the REST API would use, and the Cedros code is attached as ErrorInfo.Reason
+// so clients can branch on the same codes as REST clients.
+func statusError(code apierrors.ErrorCode, message string) error {
+	st := status.New(grpcCode(code), message)
+	if detailed, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: string(code),
+		Domain: errorDomain,
+	}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// verificationStatus maps an Authorize error to a status, preserving x402 verification codes.
+func verificationStatus(err error) error {
+	var vErr x402.VerificationError
+	if errors.As(err, &vErr) {
+		return statusError(vErr.Code, vErr.Message)
+	}
+	return statusError(apierrors.ErrCodeTransactionFailed, err.Error())
+}
+
+func grpcCode(code apierrors.ErrorCode) codes.Code {
+	switch code.HTTPStatus() {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusPaymentRequired, http.StatusConflict:
+		return codes.FailedPrecondition
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
@@ -0,0 +1,217 @@
+package grpcserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/CedrosPay/server/internal/apikey"
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/subscriptions"
+	"github.com/CedrosPay/server/pkg/grpc/cedrosv1"
+)
+
+// shutdownTimeout bounds how long Close waits for in-flight RPCs before cutting them off.
+const shutdownTimeout = 10 * time.Second
+
+// Server exposes the paywall over gRPC for backend-to-backend integrations.
+type Server struct {
+	cedrosv1.UnimplementedPaywallServiceServer
+
+	cfg           *config.Config
+	paywall       *paywall.Service
+	subscriptions *subscriptions.Service
+	logger        zerolog.Logger
+	grpc          *grpc.Server
+	health        *health.Server
+}
+
+// New builds a gRPC server with the paywall and health services registered.
+// subscriptionsSvc may be nil when subscriptions are disabled.
+func New(cfg *config.Config, paywallSvc *paywall.Service, subscriptionsSvc *subscriptions.Service, log zerolog.Logger) *Server {
+	s := &Server{
+		cfg:           cfg,
+		paywall:       paywallSvc,
+		subscriptions: subscriptionsSvc,
+		logger:        log,
+		health:        health.NewServer(),
+	}
+
+	s.grpc = grpc.NewServer(grpc.ChainUnaryInterceptor(
+		s.recoveryInterceptor,
+		s.loggingInterceptor,
+		s.authInterceptor,
+	))
+	cedrosv1.RegisterPaywallServiceServer(s.grpc, s)
+	healthpb.RegisterHealthServer(s.grpc, s.health)
+	s.health.SetServingStatus(cedrosv1.PaywallService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+
+	return s
+}
+
+// Serve accepts connections on lis until Close is called.
+func (s *Server) Serve(lis net.Listener) error {
+	s.logger.Info().
+		Str("address", lis.Addr().String()).
+		Msg("grpc.serving")
+	if err := s.grpc.Serve(lis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return err
+	}
+	return nil
+}
+
+// ListenAndServe listens on the configured grpc.address and serves until Close is called.
+func (s *Server) ListenAndServe() error {
+	lis, err := net.Listen("tcp", s.cfg.GRPC.Address)
+	if err != nil {
+		return err
+	}
+	return s.Serve(lis)
+}
+
+// Close marks the service as not serving and stops gracefully, forcing a stop if
+// in-flight RPCs (e.g. a long on-chain verification) outlast shutdownTimeout.
+func (s *Server) Close() error {
+	s.health.Shutdown()
+
+	done := make(chan struct{})
+	go func() {
+		s.grpc.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(shutdownTimeout):
+		s.logger.Warn().Msg("grpc.graceful_stop_timeout")
+		s.grpc.Stop()
+	}
+	return nil
+}
+
+// methodScopes maps RPCs to the scope keys with api_key.scopes need to call them.
+var methodScopes = map[string]apikey.Scope{
+	cedrosv1.PaywallService_GetQuote_FullMethodName:         apikey.ScopeQuotesRead,
+	cedrosv1.PaywallService_VerifyPayment_FullMethodName:    apikey.ScopePaymentsWrite,
+	cedrosv1.PaywallService_CheckEntitlement_FullMethodName: apikey.ScopePaymentsWrite,
+	cedrosv1.PaywallService_RequestRefund_FullMethodName:    apikey.ScopeRefundsWrite,
+	cedrosv1.PaywallService_GetRefund_FullMethodName:        apikey.ScopeRefundsAdmin,
+}
+
+type refundAdminKey struct{}
+
+// isRefundAdmin reports whether ctx belongs to a call made with the admin API key or an API key
+// with the refunds:admin scope. Only they may read refund requests, as over REST and GraphQL.
+func isRefundAdmin(ctx context.Context) bool {
+	admin, _ := ctx.Value(refundAdminKey{}).(bool)
+	return admin
+}
+
+// authInterceptor requires an API key from api_key.keys, sent as "x-api-key" metadata
+// or "authorization: Bearer <key>", and refuses keys whose api_key.scopes don't cover the
+// method. The admin API key (server.admin_metrics_api_key) may call every method. Health
+// checks are always allowed.
+func (s *Server) authInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	key := apiKeyFromMetadata(ctx)
+	configured, ok := s.lookupAPIKey(key)
+	adminKey := s.cfg.Server.AdminMetricsAPIKey
+	isAdmin := adminKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(adminKey)) == 1
+	if isAdmin || (ok && slices.Contains(s.cfg.APIKey.Scopes[configured], string(apikey.ScopeRefundsAdmin))) {
+		ctx = context.WithValue(ctx, refundAdminKey{}, true)
+	}
+	if isAdmin || s.cfg.GRPC.AllowUnauthenticated || strings.HasPrefix(info.FullMethod, "/grpc.health.v1.Health/") {
+		return handler(ctx, req)
+	}
+
+	if key == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing API key")
+	}
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "invalid API key")
+	}
+	if scopes, scoped := s.cfg.APIKey.Scopes[configured]; scoped && !slices.Contains(scopes, string(methodScopes[info.FullMethod])) {
+		return nil, status.Error(codes.PermissionDenied, "API key is not permitted to call this method")
+	}
+	return handler(ctx, req)
+}
+
+// lookupAPIKey returns the configured key matching key.
+func (s *Server) lookupAPIKey(key string) (string, bool) {
+	if key == "" {
+		return "", false
+	}
+	var match string
+	for configured := range s.cfg.APIKey.Keys {
+		// Compare against every key so timing does not reveal which prefix matched
+		if subtle.ConstantTimeCompare([]byte(key), []byte(configured)) == 1 {
+			match = configured
+		}
+	}
+	return match, match != ""
+}
+
+func apiKeyFromMetadata(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get("x-api-key"); len(values) > 0 {
+		return strings.TrimSpace(values[0])
+	}
+	if values := md.Get("authorization"); len(values) > 0 {
+		if token, ok := strings.CutPrefix(values[0], "Bearer "); ok {
+			return strings.TrimSpace(token)
+		}
+	}
+	return ""
+}
+
+// loggingInterceptor attaches a request-scoped logger to the context and logs each call.
+func (s *Server) loggingInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	log := s.logger.With().Str("grpc_method", info.FullMethod).Logger()
+	ctx = logger.WithContext(ctx, log)
+
+	resp, err := handler(ctx, req)
+
+	code := status.Code(err)
+	event := log.Info()
+	if code == codes.Internal || code == codes.Unknown {
+		event = log.Error()
+	}
+	event.
+		Str("code", code.String()).
+		Dur("duration", time.Since(start)).
+		Msg("grpc.request")
+	return resp, err
+}
+
+// recoveryInterceptor converts handler panics into Internal errors instead of crashing the process.
+func (s *Server) recoveryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Error().
+				Interface("panic", r).
+				Str("grpc_method", info.FullMethod).
+				Str("stack", string(debug.Stack())).
+				Msg("grpc.panic")
+			err = status.Error(codes.Internal, "internal error")
+		}
+	}()
+	return handler(ctx, req)
+}
@@ -0,0 +1,331 @@
+package grpcserver
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/rs/zerolog"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/money"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/products"
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/pkg/grpc/cedrosv1"
+)
+
+const (
+	testAPIKey         = "pro_test_key"
+	testScopedAPIKey   = "partner_quotes_key"
+	testRefundAdminKey = "partner_refunds_key"
+	testAdminKey       = "admin_test_key"
+	testWallet         = "11111111111111111111111111111111"
+)
+
+var testSignature = solana.Signature{1, 2, 3, 4, 5}.String()
+
+func newTestClient(t *testing.T) (cedrosv1.PaywallServiceClient, *grpc.ClientConn) {
+	t.Helper()
+	cfg := &config.Config{
+		Server: config.ServerConfig{AdminMetricsAPIKey: testAdminKey},
+		APIKey: config.APIKeyConfig{
+			Keys:   map[string]string{testAPIKey: "pro", testScopedAPIKey: "partner", testRefundAdminKey: "partner"},
+			Scopes: map[string][]string{testScopedAPIKey: {"quotes:read"}, testRefundAdminKey: {"refunds:admin"}},
+		},
+		X402: config.X402Config{
+			PaymentAddress: testWallet,
+			TokenMint:      "So11111111111111111111111111111111111111112",
+			Network:        "mainnet-beta",
+			TokenDecimals:  6,
+		},
+		Paywall: config.PaywallConfig{
+			QuoteTTL: config.Duration{Duration: time.Minute},
+			Resources: map[string]config.PaywallResource{
+				"demo-content": {
+					ResourceID:         "demo-content",
+					Description:        "demo",
+					FiatAmountCents:    100,
+					FiatCurrency:       "USD",
+					StripePriceID:      "price_123",
+					CryptoAtomicAmount: 1000000,
+					CryptoToken:        "USDC",
+				},
+			},
+		},
+	}
+
+	store := storage.NewMemoryStore()
+	if err := store.RecordPayment(context.Background(), storage.PaymentTransaction{
+		Signature:  testSignature,
+		ResourceID: "demo-content",
+		Wallet:     testWallet,
+		Amount:     money.New(money.MustGetAsset("USDC"), 1000000),
+		CreatedAt:  time.Now(),
+	}); err != nil {
+		t.Fatalf("RecordPayment: %v", err)
+	}
+	svc := paywall.NewService(cfg, store, nil, nil, products.NewYAMLRepository(cfg.Paywall.Resources), nil, nil)
+
+	srv := New(cfg, svc, nil, zerolog.Nop())
+	lis := bufconn.Listen(1 << 20)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(func() { _ = srv.Close() })
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return cedrosv1.NewPaywallServiceClient(conn), conn
+}
+
+func authed(key string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "x-api-key", key)
+}
+
+func errorReason(err error) string {
+	for _, d := range status.Convert(err).Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok && info.Domain == errorDomain {
+			return info.Reason
+		}
+	}
+	return ""
+}
+
+func TestAuthentication(t *testing.T) {
+	client, conn := newTestClient(t)
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		wantCode codes.Code
+	}{
+		{name: "missing key", ctx: context.Background(), wantCode: codes.Unauthenticated},
+		{name: "invalid key", ctx: authed("nope"), wantCode: codes.Unauthenticated},
+		{name: "x-api-key", ctx: authed(testAPIKey), wantCode: codes.OK},
+		{
+			name:     "bearer token",
+			ctx:      metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+testAPIKey),
+			wantCode: codes.OK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.GetQuote(tt.ctx, &cedrosv1.GetQuoteRequest{ResourceId: "demo-content"})
+			if got := status.Code(err); got != tt.wantCode {
+				t.Fatalf("code = %v, want %v (err: %v)", got, tt.wantCode, err)
+			}
+		})
+	}
+
+	t.Run("scoped key", func(t *testing.T) {
+		ctx := authed(testScopedAPIKey)
+		if _, err := client.GetQuote(ctx, &cedrosv1.GetQuoteRequest{ResourceId: "demo-content"}); err != nil {
+			t.Fatalf("GetQuote within scope: %v", err)
+		}
+		_, err := client.GetRefund(ctx, &cedrosv1.GetRefundRequest{RefundId: "refund_1"})
+		if got := status.Code(err); got != codes.PermissionDenied {
+			t.Fatalf("GetRefund outside scope code = %v, want PermissionDenied (err: %v)", got, err)
+		}
+	})
+
+	t.Run("health check without key", func(t *testing.T) {
+		resp, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{
+			Service: cedrosv1.PaywallService_ServiceDesc.ServiceName,
+		})
+		if err != nil {
+			t.Fatalf("Check: %v", err)
+		}
+		if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+			t.Fatalf("status = %v", resp.GetStatus())
+		}
+	})
+}
+
+func TestGetQuote(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := authed(testAPIKey)
+
+	quote, err := client.GetQuote(ctx, &cedrosv1.GetQuoteRequest{ResourceId: "demo-content"})
+	if err != nil {
+		t.Fatalf("GetQuote: %v", err)
+	}
+	if quote.GetStripe().GetAmountCents() != 100 {
+		t.Errorf("stripe amount = %d", quote.GetStripe().GetAmountCents())
+	}
+	if quote.GetCrypto().GetMaxAmountRequired() != "1000000" {
+		t.Errorf("crypto amount = %q", quote.GetCrypto().GetMaxAmountRequired())
+	}
+	if quote.GetExpiresAt().AsTime().Before(time.Now()) {
+		t.Errorf("expires_at in the past: %v", quote.GetExpiresAt().AsTime())
+	}
+
+	_, err = client.GetQuote(ctx, &cedrosv1.GetQuoteRequest{ResourceId: "missing"})
+	if status.Code(err) != codes.NotFound || errorReason(err) != "resource_not_found" {
+		t.Fatalf("unknown resource: code = %v, reason = %q", status.Code(err), errorReason(err))
+	}
+}
+
+func TestVerifyPaymentValidation(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := authed(testAPIKey)
+
+	tests := []struct {
+		name       string
+		req        *cedrosv1.VerifyPaymentRequest
+		wantReason string
+	}{
+		{name: "missing header", req: &cedrosv1.VerifyPaymentRequest{}, wantReason: "missing_field"},
+		{name: "malformed header", req: &cedrosv1.VerifyPaymentRequest{PaymentHeader: "not-base64!"}, wantReason: "invalid_payment_proof"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.VerifyPayment(ctx, tt.req)
+			if status.Code(err) != codes.InvalidArgument || errorReason(err) != tt.wantReason {
+				t.Fatalf("code = %v, reason = %q, want InvalidArgument/%s", status.Code(err), errorReason(err), tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestCheckEntitlement(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := authed(testAPIKey)
+
+	tests := []struct {
+		name         string
+		req          *cedrosv1.CheckEntitlementRequest
+		wantCode     codes.Code
+		wantEntitled bool
+	}{
+		{
+			name:         "payment signature",
+			req:          &cedrosv1.CheckEntitlementRequest{ResourceId: "demo-content", Signature: testSignature},
+			wantEntitled: true,
+		},
+		{
+			name: "signature for another wallet",
+			req:  &cedrosv1.CheckEntitlementRequest{ResourceId: "demo-content", Signature: testSignature, Wallet: "SysvarRent111111111111111111111111111111111"},
+		},
+		{
+			name: "signature for another resource",
+			req:  &cedrosv1.CheckEntitlementRequest{ResourceId: "other", Signature: testSignature},
+		},
+		{
+			name:     "missing wallet and signature",
+			req:      &cedrosv1.CheckEntitlementRequest{ResourceId: "demo-content"},
+			wantCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := client.CheckEntitlement(ctx, tt.req)
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("code = %v, want %v (err: %v)", status.Code(err), tt.wantCode, err)
+			}
+			if err == nil && resp.GetEntitled() != tt.wantEntitled {
+				t.Fatalf("entitled = %v, want %v", resp.GetEntitled(), tt.wantEntitled)
+			}
+		})
+	}
+}
+
+func TestRefunds(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := authed(testAPIKey)
+
+	rejects := []struct {
+		name       string
+		req        *cedrosv1.RequestRefundRequest
+		wantReason string
+	}{
+		{
+			name:       "unknown payment",
+			req:        &cedrosv1.RequestRefundRequest{OriginalPurchaseId: solana.Signature{9}.String(), RecipientWallet: testWallet, Amount: "1", Token: "USDC"},
+			wantReason: "resource_not_found",
+		},
+		{
+			name:       "wrong recipient",
+			req:        &cedrosv1.RequestRefundRequest{OriginalPurchaseId: testSignature, RecipientWallet: "SysvarRent111111111111111111111111111111111", Amount: "1", Token: "USDC"},
+			wantReason: "invalid_recipient",
+		},
+		{
+			name:       "exceeds payment",
+			req:        &cedrosv1.RequestRefundRequest{OriginalPurchaseId: testSignature, RecipientWallet: testWallet, Amount: "2", Token: "USDC"},
+			wantReason: "amount_mismatch",
+		},
+		{
+			name:       "exceeds payment by one atomic unit",
+			req:        &cedrosv1.RequestRefundRequest{OriginalPurchaseId: testSignature, RecipientWallet: testWallet, Amount: "1.000001", Token: "USDC"},
+			wantReason: "amount_mismatch",
+		},
+		{
+			name:       "invalid amount",
+			req:        &cedrosv1.RequestRefundRequest{OriginalPurchaseId: testSignature, RecipientWallet: testWallet, Amount: "abc", Token: "USDC"},
+			wantReason: "invalid_amount",
+		},
+		{
+			name:       "beyond token precision",
+			req:        &cedrosv1.RequestRefundRequest{OriginalPurchaseId: testSignature, RecipientWallet: testWallet, Amount: "0.0000001", Token: "USDC"},
+			wantReason: "invalid_amount",
+		},
+	}
+	for _, tt := range rejects {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.RequestRefund(ctx, tt.req)
+			if got := errorReason(err); got != tt.wantReason {
+				t.Fatalf("reason = %q, want %q (err: %v)", got, tt.wantReason, err)
+			}
+		})
+	}
+
+	refund, err := client.RequestRefund(ctx, &cedrosv1.RequestRefundRequest{
+		OriginalPurchaseId: testSignature,
+		RecipientWallet:    testWallet,
+		Amount:             "0.5",
+		Token:              "USDC",
+		Reason:             "duplicate purchase",
+	})
+	if err != nil {
+		t.Fatalf("RequestRefund: %v", err)
+	}
+	if refund.GetStatus() != "pending" || refund.GetAmount() != "0.500000" {
+		t.Fatalf("unexpected refund: %v", refund)
+	}
+
+	// Refund requests are only readable by admins, as over REST and GraphQL
+	if _, err := client.GetRefund(ctx, &cedrosv1.GetRefundRequest{RefundId: refund.GetId()}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("GetRefund without refunds:admin code = %v, want PermissionDenied (err: %v)", status.Code(err), err)
+	}
+	for _, key := range []string{testAdminKey, testRefundAdminKey} {
+		got, err := client.GetRefund(authed(key), &cedrosv1.GetRefundRequest{RefundId: refund.GetId()})
+		if err != nil {
+			t.Fatalf("GetRefund: %v", err)
+		}
+		if got.GetOriginalPurchaseId() != testSignature || got.GetReason() != "duplicate purchase" {
+			t.Fatalf("unexpected refund: %v", got)
+		}
+	}
+
+	_, err = client.GetRefund(authed(testAdminKey), &cedrosv1.GetRefundRequest{RefundId: "refund_missing"})
+	if status.Code(err) != codes.NotFound || errorReason(err) != "refund_not_found" {
+		t.Fatalf("missing refund: code = %v, reason = %q", status.Code(err), errorReason(err))
+	}
+}
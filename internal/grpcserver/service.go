@@ -0,0 +1,305 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	apierrors "github.com/CedrosPay/server/internal/errors"
+	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/money"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/products"
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/pkg/grpc/cedrosv1"
+	"github.com/CedrosPay/server/pkg/x402"
+)
+
+// GetQuote returns Stripe and x402 payment options for a resource.
+func (s *Server) GetQuote(ctx context.Context, req *cedrosv1.GetQuoteRequest) (*cedrosv1.Quote, error) {
+	if req.GetResourceId() == "" {
+		return nil, statusError(apierrors.ErrCodeMissingField, "resource_id is required")
+	}
+
+	quote, err := s.paywall.GenerateQuote(ctx, req.GetResourceId(), req.GetCouponCode())
+	if err != nil {
+		if errors.Is(err, paywall.ErrDraining) {
+			return nil, statusError(apierrors.ErrCodeServiceUnavailable, "server is shutting down")
+		}
+		if errors.Is(err, paywall.ErrResourceNotConfigured) || errors.Is(err, products.ErrProductNotFound) {
+			return nil, statusError(apierrors.ErrCodeResourceNotFound, "unknown resource")
+		}
+		return nil, statusError(apierrors.ErrCodeInternalError, "failed to generate quote")
+	}
+	return toProtoQuote(quote)
+}
+
+// VerifyPayment verifies an x402 payment proof. It mirrors POST /paywall/v1/verify for
+// regular, cart, and refund resources, blocking until the transaction is confirmed.
+func (s *Server) VerifyPayment(ctx context.Context, req *cedrosv1.VerifyPaymentRequest) (*cedrosv1.VerifyPaymentResponse, error) {
+	log := logger.FromContext(ctx)
+
+	if req.GetPaymentHeader() == "" {
+		return nil, statusError(apierrors.ErrCodeMissingField, "payment_header is required")
+	}
+	proof, err := x402.ParsePaymentProof(req.GetPaymentHeader())
+	if err != nil {
+		return nil, statusError(apierrors.ErrCodeInvalidPaymentProof, "invalid payment_header: "+err.Error())
+	}
+
+	resourceID := req.GetResourceId()
+	if resourceID == "" {
+		resourceID = proof.Resource
+	}
+	if resourceID == "" {
+		return nil, statusError(apierrors.ErrCodeMissingField, "resource_id is required (or set payload.resource in the payment proof)")
+	}
+
+	couponCode := req.GetCouponCode()
+	if couponCode == "" && proof.Metadata != nil {
+		// Support both snake_case (coupon_code) and camelCase (couponCode)
+		couponCode = proof.Metadata["coupon_code"]
+		if couponCode == "" {
+			couponCode = proof.Metadata["couponCode"]
+		}
+	}
+
+	result, err := s.paywall.Authorize(ctx, resourceID, "", req.GetPaymentHeader(), couponCode)
+	if err != nil {
+		if errors.Is(err, paywall.ErrResourceNotConfigured) || errors.Is(err, products.ErrProductNotFound) {
+			return nil, statusError(apierrors.ErrCodeResourceNotFound, "unknown resource")
+		}
+		log.Warn().
+			Err(err).
+			Str("resource", resourceID).
+			Msg("grpc.verify_payment.failed")
+		return nil, verificationStatus(err)
+	}
+	// A split cart payment that leaves part of the total unpaid is recorded but not granted
+	if !result.Granted && result.Partial == nil {
+		return nil, statusError(apierrors.ErrCodeTransactionFailed, "payment verification failed")
+	}
+
+	resp := &cedrosv1.VerifyPaymentResponse{
+		Granted:    result.Granted,
+		Method:     result.Method,
+		Wallet:     result.Wallet,
+		ResourceId: resourceID,
+	}
+	if result.Settlement != nil && result.Settlement.TxHash != nil {
+		resp.Signature = *result.Settlement.TxHash
+	}
+	return resp, nil
+}
+
+// CheckEntitlement reports whether a payment signature or wallet grants access to a resource.
+// A signature must belong to a recorded payment for the resource (and to the wallet, if given);
+// a wallet alone is checked against active subscriptions.
+func (s *Server) CheckEntitlement(ctx context.Context, req *cedrosv1.CheckEntitlementRequest) (*cedrosv1.CheckEntitlementResponse, error) {
+	resourceID := req.GetResourceId()
+	if resourceID == "" {
+		return nil, statusError(apierrors.ErrCodeMissingField, "resource_id is required")
+	}
+	if req.GetWallet() == "" && req.GetSignature() == "" {
+		return nil, statusError(apierrors.ErrCodeMissingField, "wallet or signature is required")
+	}
+
+	if signature := req.GetSignature(); signature != "" {
+		processed, err := s.paywall.HasPaymentBeenProcessed(ctx, signature)
+		if err != nil {
+			return nil, statusError(apierrors.ErrCodeDatabaseError, "failed to look up payment")
+		}
+		if processed {
+			payment, err := s.paywall.GetPayment(ctx, signature)
+			if err != nil {
+				return nil, statusError(apierrors.ErrCodeDatabaseError, "failed to look up payment")
+			}
+			if paywall.PaymentGrantsAccess(payment, resourceID) && (req.GetWallet() == "" || payment.Wallet == req.GetWallet()) {
+				return &cedrosv1.CheckEntitlementResponse{
+					Entitled:  true,
+					Method:    "payment",
+					Signature: signature,
+				}, nil
+			}
+		}
+	}
+
+	if wallet := req.GetWallet(); wallet != "" && s.subscriptions != nil {
+		hasAccess, sub, err := s.subscriptions.HasAccess(ctx, wallet, resourceID)
+		if err != nil {
+			return nil, statusError(apierrors.ErrCodeDatabaseError, "failed to look up subscription")
+		}
+		if hasAccess {
+			resp := &cedrosv1.CheckEntitlementResponse{
+				Entitled:       true,
+				Method:         "subscription",
+				SubscriptionId: sub.ID,
+			}
+			if !sub.CurrentPeriodEnd.IsZero() {
+				resp.ExpiresAt = timestamppb.New(sub.CurrentPeriodEnd)
+			}
+			return resp, nil
+		}
+	}
+
+	return &cedrosv1.CheckEntitlementResponse{Entitled: false}, nil
+}
+
+// RequestRefund creates a pending refund request for a verified payment. It applies the same
+// checks as POST /paywall/v1/refunds/request; the caller's API key stands in for the wallet
+// signature the public endpoint requires.
+func (s *Server) RequestRefund(ctx context.Context, req *cedrosv1.RequestRefundRequest) (*cedrosv1.Refund, error) {
+	if req.GetOriginalPurchaseId() == "" {
+		return nil, statusError(apierrors.ErrCodeMissingField, "original_purchase_id is required")
+	}
+	if req.GetRecipientWallet() == "" {
+		return nil, statusError(apierrors.ErrCodeMissingField, "recipient_wallet is required")
+	}
+	if req.GetToken() == "" {
+		return nil, statusError(apierrors.ErrCodeMissingField, "token is required")
+	}
+	if _, err := solana.SignatureFromBase58(req.GetOriginalPurchaseId()); err != nil {
+		return nil, statusError(apierrors.ErrCodeInvalidSignature, "original_purchase_id must be a valid Solana transaction signature")
+	}
+
+	processed, err := s.paywall.HasPaymentBeenProcessed(ctx, req.GetOriginalPurchaseId())
+	if err != nil {
+		return nil, statusError(apierrors.ErrCodeDatabaseError, "failed to look up payment")
+	}
+	if !processed {
+		return nil, statusError(apierrors.ErrCodeResourceNotFound, "payment not found")
+	}
+	payment, err := s.paywall.GetPayment(ctx, req.GetOriginalPurchaseId())
+	if err != nil {
+		return nil, statusError(apierrors.ErrCodeDatabaseError, "failed to look up payment")
+	}
+
+	if payment.Wallet != req.GetRecipientWallet() {
+		return nil, statusError(apierrors.ErrCodeInvalidRecipient, "recipient_wallet must match the wallet that made the original payment")
+	}
+	// Compared in atomic units of the payment's token, as POST /paywall/v1/refunds/request does
+	requested, err := money.FromMajor(payment.Amount.Asset, req.GetAmount())
+	if err != nil || !requested.IsPositive() {
+		return nil, statusError(apierrors.ErrCodeInvalidAmount, "amount must be a positive decimal within the token's precision")
+	}
+	if payment.Amount.LessThan(requested) {
+		return nil, statusError(apierrors.ErrCodeAmountMismatch, fmt.Sprintf("refund amount exceeds original payment amount (%s %s)", payment.Amount.ToMajor(), payment.Amount.Asset.Code))
+	}
+	if req.GetToken() != payment.Amount.Asset.Code {
+		return nil, statusError(apierrors.ErrCodeInvalidTokenMint, "refund token must match original payment token ("+payment.Amount.Asset.Code+")")
+	}
+
+	refund, err := s.paywall.CreateRefundRequest(ctx, paywall.RefundQuoteRequest{
+		OriginalPurchaseID: req.GetOriginalPurchaseId(),
+		RecipientWallet:    req.GetRecipientWallet(),
+		AmountDecimal:      requested.ToMajor(),
+		Token:              req.GetToken(),
+		Reason:             req.GetReason(),
+		Metadata:           req.GetMetadata(),
+	})
+	if err != nil {
+		return nil, statusError(apierrors.ErrCodeInvalidField, err.Error())
+	}
+	return toProtoRefund(refund), nil
+}
+
+// GetRefund returns a refund request by ID. It requires the admin API key or an API key with the
+// refunds:admin scope.
+func (s *Server) GetRefund(ctx context.Context, req *cedrosv1.GetRefundRequest) (*cedrosv1.Refund, error) {
+	if !isRefundAdmin(ctx) {
+		return nil, statusError(apierrors.ErrCodeInsufficientScope, "GetRefund requires the admin API key or the refunds:admin scope")
+	}
+	if req.GetRefundId() == "" {
+		return nil, statusError(apierrors.ErrCodeMissingField, "refund_id is required")
+	}
+	refund, err := s.paywall.GetRefundQuote(ctx, req.GetRefundId())
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, statusError(apierrors.ErrCodeRefundNotFound, "refund not found")
+		}
+		return nil, statusError(apierrors.ErrCodeDatabaseError, "failed to look up refund")
+	}
+	return toProtoRefund(refund), nil
+}
+
+func toProtoQuote(q paywall.Quote) (*cedrosv1.Quote, error) {
+	out := &cedrosv1.Quote{
+		ResourceId: q.ResourceID,
+		ExpiresAt:  timestamppb.New(q.ExpiresAt),
+	}
+	if q.Stripe != nil {
+		out.Stripe = &cedrosv1.StripeOption{
+			PriceId:     q.Stripe.PriceID,
+			AmountCents: q.Stripe.AmountCents,
+			Currency:    q.Stripe.Currency,
+			Description: q.Stripe.Description,
+		}
+	}
+	if q.Crypto != nil {
+		extra, err := toStruct(q.Crypto.Extra)
+		if err != nil {
+			return nil, statusError(apierrors.ErrCodeInternalError, "failed to encode quote")
+		}
+		out.Crypto = &cedrosv1.CryptoQuote{
+			Scheme:            q.Crypto.Scheme,
+			Network:           q.Crypto.Network,
+			MaxAmountRequired: q.Crypto.MaxAmountRequired,
+			Resource:          q.Crypto.Resource,
+			Description:       q.Crypto.Description,
+			PayTo:             q.Crypto.PayTo,
+			Asset:             q.Crypto.Asset,
+			MaxTimeoutSeconds: int32(q.Crypto.MaxTimeoutSeconds),
+			Extra:             extra,
+		}
+	}
+	return out, nil
+}
+
+// toStruct converts the quote's free-form extra field via its JSON form, matching what
+// REST clients receive.
+func toStruct(v any) (*structpb.Struct, error) {
+	if v == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	return structpb.NewStruct(fields)
+}
+
+func toProtoRefund(r storage.RefundQuote) *cedrosv1.Refund {
+	out := &cedrosv1.Refund{
+		Id:                 r.ID,
+		Status:             "pending",
+		OriginalPurchaseId: r.OriginalPurchaseID,
+		RecipientWallet:    r.RecipientWallet,
+		Amount:             r.Amount.ToMajor(),
+		Token:              r.Amount.Asset.Code,
+		Reason:             r.Reason,
+		Signature:          r.Signature,
+		CreatedAt:          timestampOrNil(r.CreatedAt),
+	}
+	if r.ProcessedAt != nil {
+		out.Status = "processed"
+		out.ProcessedAt = timestampOrNil(*r.ProcessedAt)
+	}
+	return out
+}
+
+func timestampOrNil(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
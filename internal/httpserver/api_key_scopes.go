@@ -0,0 +1,110 @@
+package httpserver
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/CedrosPay/server/internal/apikey"
+	"github.com/CedrosPay/server/internal/config"
+	apierrors "github.com/CedrosPay/server/internal/errors"
+)
+
+// apiKeyRouteScopes lists the endpoints keys with api_key.scopes may call, relative to the
+// route prefix. Scoped keys are refused on every other endpoint.
+var apiKeyRouteScopes = []apikey.RouteScope{
+	// quotes:read
+	{Method: http.MethodPost, Route: "/paywall/v1/quote", Scope: apikey.ScopeQuotesRead},
+	{Method: http.MethodPost, Route: "/paywall/v1/cart/quote", Scope: apikey.ScopeQuotesRead},
+	{Method: http.MethodPatch, Route: "/paywall/v1/cart/{cartId}", Scope: apikey.ScopeQuotesRead},
+	{Method: http.MethodPost, Route: "/paywall/v1/saved-carts/{name}/quote", Scope: apikey.ScopeQuotesRead},
+	{Method: http.MethodPost, Route: "/paywall/v1/subscription/quote", Scope: apikey.ScopeQuotesRead},
+	{Method: http.MethodGet, Route: "/paywall/v1/products", Scope: apikey.ScopeQuotesRead},
+	{Method: http.MethodPost, Route: "/paywall/v1/coupons/validate", Scope: apikey.ScopeQuotesRead},
+	{Method: http.MethodGet, Route: "/paywall/v1/preflight", Scope: apikey.ScopeQuotesRead},
+	{Method: http.MethodGet, Route: "/paywall/v1/metering/balance", Scope: apikey.ScopeQuotesRead},
+
+	// payments:write
+	{Method: http.MethodPost, Route: "/paywall/v1/verify", Scope: apikey.ScopePaymentsWrite},
+	{Method: http.MethodGet, Route: "/paywall/v1/verifications/{id}", Scope: apikey.ScopePaymentsWrite},
+	{Method: http.MethodGet, Route: "/paywall/v1/payments/{signature}/events", Scope: apikey.ScopePaymentsWrite},
+	{Method: http.MethodGet, Route: "/paywall/v1/x402-transaction/verify", Scope: apikey.ScopePaymentsWrite},
+	{Method: http.MethodPost, Route: "/paywall/v1/stripe-session", Scope: apikey.ScopePaymentsWrite},
+	{Method: http.MethodGet, Route: "/paywall/v1/stripe-session/verify", Scope: apikey.ScopePaymentsWrite},
+	{Method: http.MethodPost, Route: "/paywall/v1/stripe-payment-intent", Scope: apikey.ScopePaymentsWrite},
+	{Method: http.MethodPost, Route: "/paywall/v1/stripe-invoice", Scope: apikey.ScopePaymentsWrite},
+	{Method: http.MethodPost, Route: "/paywall/v1/cart/checkout", Scope: apikey.ScopePaymentsWrite},
+	{Method: http.MethodPost, Route: "/paywall/v1/cart/{cartId}/checkout", Scope: apikey.ScopePaymentsWrite},
+	{Method: http.MethodGet, Route: "/paywall/v1/cart/{cartId}/payments", Scope: apikey.ScopePaymentsWrite},
+	{Method: http.MethodPost, Route: "/paywall/v1/gasless-transaction", Scope: apikey.ScopePaymentsWrite},
+	{Method: http.MethodPost, Route: "/paywall/v1/subscription/stripe-session", Scope: apikey.ScopePaymentsWrite},
+	{Method: http.MethodPost, Route: "/paywall/v1/subscription/x402/activate", Scope: apikey.ScopePaymentsWrite},
+	{Method: http.MethodGet, Route: "/facilitator/supported", Scope: apikey.ScopePaymentsWrite},
+	{Method: http.MethodPost, Route: "/facilitator/verify", Scope: apikey.ScopePaymentsWrite},
+	{Method: http.MethodPost, Route: "/facilitator/settle", Scope: apikey.ScopePaymentsWrite},
+	{Method: http.MethodPost, Route: "/paywall/v1/metering/deposit", Scope: apikey.ScopePaymentsWrite},
+
+	// refunds:write
+	{Method: http.MethodPost, Route: "/paywall/v1/refunds/request", Scope: apikey.ScopeRefundsWrite},
+	{Method: http.MethodPost, Route: "/paywall/v1/refunds/notes", Scope: apikey.ScopeRefundsWrite},
+
+	// refunds:admin (refund approve, deny, and pending still require the payTo wallet's signature;
+	// the audit trail accepts the key in place of the admin API key, see adminScopeAuth)
+	{Method: http.MethodPost, Route: "/paywall/v1/nonce", Scope: apikey.ScopeRefundsAdmin},
+	{Method: http.MethodPost, Route: "/paywall/v1/refunds/approve", Scope: apikey.ScopeRefundsAdmin},
+	{Method: http.MethodPost, Route: "/paywall/v1/refunds/deny", Scope: apikey.ScopeRefundsAdmin},
+	{Method: http.MethodPost, Route: "/paywall/v1/refunds/pending", Scope: apikey.ScopeRefundsAdmin},
+	{Method: http.MethodGet, Route: "/admin/refunds/{id}/audit", Scope: apikey.ScopeRefundsAdmin},
+
+	// webhooks:admin (accepted in place of the admin API key, see adminScopeAuth)
+	{Method: http.MethodPost, Route: "/admin/webhooks/{id}/retry", Scope: apikey.ScopeWebhooksAdmin},
+}
+
+// apiKeyConfig converts the api_key config for apikey.Middleware.
+func apiKeyConfig(cfg *config.Config) apikey.Config {
+	apiKeyCfg := apikey.Config{
+		Enabled:   cfg.APIKey.Enabled,
+		APIKeys:   make(map[string]apikey.Tier),
+		KeyScopes: make(map[string][]apikey.Scope, len(cfg.APIKey.Scopes)),
+	}
+	for key, tierStr := range cfg.APIKey.Keys {
+		apiKeyCfg.APIKeys[key] = apikey.Tier(tierStr)
+	}
+	for key, scopes := range cfg.APIKey.Scopes {
+		for _, scope := range scopes {
+			apiKeyCfg.KeyScopes[key] = append(apiKeyCfg.KeyScopes[key], apikey.Scope(scope))
+		}
+	}
+	for _, route := range apiKeyRouteScopes {
+		route.Route = cfg.Server.RoutePrefix + route.Route
+		apiKeyCfg.Routes = append(apiKeyCfg.Routes, route)
+	}
+	return apiKeyCfg
+}
+
+// adminScopeAuth protects an admin endpoint with the admin API key or, in its place, an API key
+// from api_key.keys whose api_key.scopes include scope. Unscoped keys are refused, so a partner
+// only reaches the admin areas it was granted.
+func adminScopeAuth(cfg *config.Config, scope apikey.Scope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !hasAdminKey(cfg.Server.AdminMetricsAPIKey, r) && !hasKeyScope(cfg, r, scope) {
+				apierrors.WriteSimpleError(w, apierrors.ErrCodeUnauthorized, "Invalid or missing admin API key")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// hasKeyScope reports whether the request's X-API-Key is a configured key granted scope.
+func hasKeyScope(cfg *config.Config, r *http.Request, scope apikey.Scope) bool {
+	key := strings.TrimSpace(r.Header.Get("X-API-Key"))
+	if !cfg.APIKey.Enabled || key == "" {
+		return false
+	}
+	if _, ok := cfg.APIKey.Keys[key]; !ok {
+		return false
+	}
+	return slices.Contains(cfg.APIKey.Scopes[key], string(scope))
+}
@@ -0,0 +1,120 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/idempotency"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/products"
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/internal/verification"
+)
+
+func TestAPIKeyScopes(t *testing.T) {
+	cfg := &config.Config{
+		Server:      config.ServerConfig{RoutePrefix: "/api", AdminMetricsAPIKey: "admin"},
+		AsyncVerify: config.AsyncVerifyConfig{Enabled: true},
+		X402:        config.X402Config{Facilitator: true},
+		Metering:    config.MeteringConfig{Enabled: true},
+		APIKey: config.APIKeyConfig{
+			Enabled: true,
+			Keys:    map[string]string{"partner_quotes": "partner", "partner_refunds": "partner", "partner_webhooks": "partner", "plain": "pro"},
+			Scopes: map[string][]string{
+				"partner_quotes":   {"quotes:read"},
+				"partner_refunds":  {"refunds:admin"},
+				"partner_webhooks": {"webhooks:admin"},
+			},
+		},
+	}
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	svc := paywall.NewService(cfg, store, nil, nil, products.NewYAMLRepository(nil), nil, nil)
+	pool := verification.NewPool(verification.Options{})
+	t.Cleanup(func() { _ = pool.Close() })
+	idem := idempotency.NewMemoryStore()
+	t.Cleanup(idem.Stop)
+
+	router := chi.NewRouter()
+	if err := ConfigureRouter(router, cfg, svc, nil, nil, NewRPCProxyHandlers(cfg), nil, nil, idem, nil, nil, zerolog.Nop(), WithVerificationPool(pool)); err != nil {
+		t.Fatalf("ConfigureRouter: %v", err)
+	}
+
+	t.Run("scoped routes are registered", func(t *testing.T) {
+		registered := map[string]bool{}
+		if err := chi.Walk(router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+			registered[method+" "+route] = true
+			return nil
+		}); err != nil {
+			t.Fatalf("walk: %v", err)
+		}
+		for _, route := range apiKeyRouteScopes {
+			if key := route.Method + " /api" + route.Route; !registered[key] {
+				t.Errorf("%s has scope %s but is not registered", key, route.Scope)
+			}
+		}
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantScoped bool // Refused with insufficient_scope
+	}{
+		{name: "within scope", method: http.MethodGet, path: "/api/paywall/v1/products"},
+		{name: "outside scope", method: http.MethodPost, path: "/api/paywall/v1/refunds/request", wantScoped: true},
+		{name: "unscoped route", method: http.MethodGet, path: "/cedros-health", wantScoped: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader("{}"))
+			req.Header.Set("X-API-Key", "partner_quotes")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			scoped := rec.Code == http.StatusForbidden && strings.Contains(rec.Body.String(), "insufficient_scope")
+			if scoped != tt.wantScoped {
+				t.Fatalf("status = %d (%s), want refused %v", rec.Code, rec.Body.String(), tt.wantScoped)
+			}
+		})
+	}
+
+	// Admin scopes stand in for the admin API key on their own admin routes only
+	admin := []struct {
+		name           string
+		method         string
+		path           string
+		header         http.Header
+		wantAuthorized bool
+	}{
+		{name: "refund audit with refunds:admin", method: http.MethodGet, path: "/api/admin/refunds/refund_1/audit", header: http.Header{"X-Api-Key": {"partner_refunds"}}, wantAuthorized: true},
+		{name: "refund audit with admin key", method: http.MethodGet, path: "/api/admin/refunds/refund_1/audit", header: http.Header{"Authorization": {"Bearer admin"}}, wantAuthorized: true},
+		{name: "refund audit without key", method: http.MethodGet, path: "/api/admin/refunds/refund_1/audit"},
+		{name: "refund audit with unscoped key", method: http.MethodGet, path: "/api/admin/refunds/refund_1/audit", header: http.Header{"X-Api-Key": {"plain"}}},
+		{name: "refund audit with webhooks:admin", method: http.MethodGet, path: "/api/admin/refunds/refund_1/audit", header: http.Header{"X-Api-Key": {"partner_webhooks"}}},
+		{name: "webhook retry with webhooks:admin", method: http.MethodPost, path: "/api/admin/webhooks/webhook_1/retry", header: http.Header{"X-Api-Key": {"partner_webhooks"}}, wantAuthorized: true},
+		{name: "webhook retry with refunds:admin", method: http.MethodPost, path: "/api/admin/webhooks/webhook_1/retry", header: http.Header{"X-Api-Key": {"partner_refunds"}}},
+		{name: "webhook retry with unscoped key", method: http.MethodPost, path: "/api/admin/webhooks/webhook_1/retry", header: http.Header{"X-Api-Key": {"plain"}}},
+	}
+	for _, tt := range admin {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			for k, v := range tt.header {
+				req.Header[k] = v
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			authorized := rec.Code != http.StatusUnauthorized && rec.Code != http.StatusForbidden
+			if authorized != tt.wantAuthorized {
+				t.Fatalf("status = %d (%s), want authorized %v", rec.Code, rec.Body.String(), tt.wantAuthorized)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package httpserver
+
+import (
+	"errors"
+	"net/http"
+
+	apierrors "github.com/CedrosPay/server/internal/errors"
+	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/pkg/responders"
+)
+
+// accessRuleRequest puts a wallet or IP range on the allow or deny list.
+type accessRuleRequest struct {
+	Kind   string `json:"kind"`  // wallet or ip
+	Value  string `json:"value"` // Wallet address, IP address, or CIDR range
+	List   string `json:"list"`  // allow or deny
+	Reason string `json:"reason,omitempty"`
+}
+
+// accessRulesResponse lists the allow and deny lists.
+type accessRulesResponse struct {
+	Rules []storage.AccessRule `json:"rules"`
+	Count int                  `json:"count"`
+}
+
+// adminListAccessRules handles GET /admin/access-lists.
+func (h *handlers) adminListAccessRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.paywall.AccessRules(r.Context())
+	if err != nil {
+		h.writeAccessRuleError(w, r, err)
+		return
+	}
+	responders.JSON(w, http.StatusOK, accessRulesResponse{Rules: rules, Count: len(rules)})
+}
+
+// adminSaveAccessRule handles POST /admin/access-lists - puts a wallet or IP range on the allow
+// or deny list, moving it if it is already on the other one.
+func (h *handlers) adminSaveAccessRule(w http.ResponseWriter, r *http.Request) {
+	var req accessRuleRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, err.Error())
+		return
+	}
+
+	rule, err := h.paywall.SaveAccessRule(r.Context(), storage.AccessRule{Kind: req.Kind, Value: req.Value, List: req.List, Reason: req.Reason})
+	if err != nil {
+		h.writeAccessRuleError(w, r, err)
+		return
+	}
+	log := logger.FromContext(r.Context())
+	log.Info().
+		Str("kind", rule.Kind).
+		Str("value", rule.Value).
+		Str("list", rule.List).
+		Msg("access_lists.admin.saved")
+	responders.JSON(w, http.StatusOK, rule)
+}
+
+// adminDeleteAccessRule handles DELETE /admin/access-lists?kind=&value= - takes a wallet or IP
+// range off its list. The value is a query parameter because IP ranges contain '/'.
+func (h *handlers) adminDeleteAccessRule(w http.ResponseWriter, r *http.Request) {
+	kind, value := r.URL.Query().Get("kind"), r.URL.Query().Get("value")
+	if kind == "" || value == "" {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeMissingField, "kind and value are required")
+		return
+	}
+	if err := h.paywall.DeleteAccessRule(r.Context(), kind, value); err != nil {
+		h.writeAccessRuleError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handlers) writeAccessRuleError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, paywall.ErrInvalidAccessRule):
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, err.Error())
+	case errors.Is(err, storage.ErrNotFound):
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeAccessRuleNotFound, "access rule not found")
+	default:
+		log := logger.FromContext(r.Context())
+		log.Error().Err(err).Msg("access_lists.admin.request_failed")
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeDatabaseError, "failed to access the access lists")
+	}
+}
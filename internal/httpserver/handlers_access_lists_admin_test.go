@@ -0,0 +1,80 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/idempotency"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/products"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func TestAdminAccessLists(t *testing.T) {
+	cfg := &config.Config{
+		Server: config.ServerConfig{RoutePrefix: "/api", AdminMetricsAPIKey: "secret"},
+		X402:   config.X402Config{PaymentAddress: "11111111111111111111111111111111", Network: "mainnet-beta", TokenMint: "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v", TokenDecimals: 6},
+	}
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	svc := paywall.NewService(cfg, store, nil, nil, products.NewYAMLRepository(nil), nil, nil)
+	idem := idempotency.NewMemoryStore()
+	t.Cleanup(idem.Stop)
+	router := chi.NewRouter()
+	if err := ConfigureRouter(router, cfg, svc, nil, nil, NewRPCProxyHandlers(cfg), nil, nil, idem, nil, nil, zerolog.Nop()); err != nil {
+		t.Fatalf("ConfigureRouter: %v", err)
+	}
+
+	steps := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantCount  int // Checked on list requests
+	}{
+		{name: "deny wallet", method: http.MethodPost, path: "/api/admin/access-lists", body: `{"kind":"wallet","value":"So11111111111111111111111111111111111111112","list":"deny","reason":"chargebacks"}`, wantStatus: http.StatusOK},
+		{name: "deny IP", method: http.MethodPost, path: "/api/admin/access-lists", body: `{"kind":"ip","value":"203.0.113.7","list":"deny"}`, wantStatus: http.StatusOK},
+		{name: "invalid range", method: http.MethodPost, path: "/api/admin/access-lists", body: `{"kind":"ip","value":"203.0.113.0/33","list":"deny"}`, wantStatus: http.StatusBadRequest},
+		{name: "list", method: http.MethodGet, path: "/api/admin/access-lists", wantStatus: http.StatusOK, wantCount: 2},
+		{name: "delete single IP", method: http.MethodDelete, path: "/api/admin/access-lists?kind=ip&value=203.0.113.7", wantStatus: http.StatusNoContent},
+		{name: "delete again", method: http.MethodDelete, path: "/api/admin/access-lists?kind=ip&value=203.0.113.7%2F32", wantStatus: http.StatusNotFound},
+		{name: "delete without value", method: http.MethodDelete, path: "/api/admin/access-lists?kind=ip", wantStatus: http.StatusBadRequest},
+		{name: "list after delete", method: http.MethodGet, path: "/api/admin/access-lists", wantStatus: http.StatusOK, wantCount: 1},
+	}
+	for _, step := range steps {
+		req := httptest.NewRequest(step.method, step.path, strings.NewReader(step.body))
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != step.wantStatus {
+			t.Fatalf("%s: status = %d, want %d: %s", step.name, rec.Code, step.wantStatus, rec.Body.String())
+		}
+		if step.method != http.MethodGet {
+			continue
+		}
+		var resp accessRulesResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: decode: %v", step.name, err)
+		}
+		if resp.Count != step.wantCount || len(resp.Rules) != step.wantCount {
+			t.Fatalf("%s: rules = %+v, want %d", step.name, resp.Rules, step.wantCount)
+		}
+	}
+
+	entries, err := store.ListAdminAudit(context.Background(), storage.AdminAuditFilter{Action: storage.AdminAuditConfigChange})
+	if err != nil {
+		t.Fatalf("ListAdminAudit: %v", err)
+	}
+	if len(entries) < 3 {
+		t.Fatalf("audit entries = %d, want the access list writes recorded", len(entries))
+	}
+}
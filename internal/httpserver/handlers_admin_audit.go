@@ -0,0 +1,143 @@
+package httpserver
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	apierrors "github.com/CedrosPay/server/internal/errors"
+	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/pkg/responders"
+)
+
+// adminAuditResponse is a page of the admin audit log.
+type adminAuditResponse struct {
+	Entries []storage.AdminAuditEntry `json:"entries"`
+	Count   int                       `json:"count"`
+}
+
+// adminAuditLog handles GET /admin/audit - lists admin actions, newest first. Query
+// parameters: action, signer, since and until (RFC 3339 or YYYY-MM-DD), and limit (max 1000).
+func (h *handlers) adminAuditLog(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := storage.AdminAuditFilter{
+		Action: query.Get("action"),
+		Signer: query.Get("signer"),
+	}
+	if !parseAdminLogWindow(w, query, &filter.Since, &filter.Until, &filter.Limit) {
+		return
+	}
+
+	entries, err := h.paywall.ListAdminAudit(r.Context(), filter)
+	if err != nil {
+		log := logger.FromContext(r.Context())
+		log.Error().Err(err).Msg("admin_audit.list_failed")
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeDatabaseError, "failed to load admin audit log")
+		return
+	}
+	if entries == nil {
+		entries = []storage.AdminAuditEntry{}
+	}
+	responders.JSON(w, http.StatusOK, adminAuditResponse{Entries: entries, Count: len(entries)})
+}
+
+// parseAdminLogWindow reads the since, until (RFC 3339 or YYYY-MM-DD), and limit (1-1000)
+// parameters shared by the admin log listings. It writes the error response and returns false
+// when one is malformed.
+func parseAdminLogWindow(w http.ResponseWriter, query url.Values, since, until *time.Time, limit *int) bool {
+	for _, param := range []struct {
+		name string
+		dst  *time.Time
+	}{{"since", since}, {"until", until}} {
+		value := query.Get(param.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := parseAnalyticsTime(value)
+		if err != nil {
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, param.name+" must be an RFC 3339 time or YYYY-MM-DD date")
+			return false
+		}
+		*param.dst = parsed
+	}
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 1000 {
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, "limit must be between 1 and 1000")
+			return false
+		}
+		*limit = parsed
+	}
+	return true
+}
+
+// webhookDeliveriesResponse is a page of the webhook delivery log.
+type webhookDeliveriesResponse struct {
+	Deliveries []storage.WebhookDelivery `json:"deliveries"`
+	Count      int                       `json:"count"`
+}
+
+// adminWebhookDeliveries handles GET /admin/webhooks/deliveries - lists webhook delivery
+// attempts, newest first. Query parameters: url, eventType, eventId, webhookId, status
+// (success or failed), since and until (RFC 3339 or YYYY-MM-DD), and limit (max 1000).
+func (h *handlers) adminWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := storage.WebhookDeliveryFilter{
+		URL:       query.Get("url"),
+		EventType: query.Get("eventType"),
+		EventID:   query.Get("eventId"),
+		WebhookID: query.Get("webhookId"),
+	}
+	switch status := query.Get("status"); status {
+	case "":
+	case "success", "failed":
+		success := status == "success"
+		filter.Success = &success
+	default:
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, "status must be success or failed")
+		return
+	}
+	if !parseAdminLogWindow(w, query, &filter.Since, &filter.Until, &filter.Limit) {
+		return
+	}
+
+	deliveries, err := h.paywall.ListWebhookDeliveries(r.Context(), filter)
+	if err != nil {
+		log := logger.FromContext(r.Context())
+		log.Error().Err(err).Msg("webhook_deliveries.list_failed")
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeDatabaseError, "failed to load webhook deliveries")
+		return
+	}
+	if deliveries == nil {
+		deliveries = []storage.WebhookDelivery{}
+	}
+	responders.JSON(w, http.StatusOK, webhookDeliveriesResponse{Deliveries: deliveries, Count: len(deliveries)})
+}
+
+// webhookRetryResponse confirms a webhook was queued for another delivery attempt.
+type webhookRetryResponse struct {
+	WebhookID string `json:"webhookId"`
+	Message   string `json:"message"`
+}
+
+// adminRetryWebhook handles POST /admin/webhooks/{id}/retry - resets a failed webhook to
+// pending so the delivery worker sends it again.
+func (h *handlers) adminRetryWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookID := chi.URLParam(r, "id")
+	if err := h.paywall.RetryWebhook(r.Context(), webhookID); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeResourceNotFound, "webhook not found")
+			return
+		}
+		log := logger.FromContext(r.Context())
+		log.Error().Err(err).Str("webhook_id", webhookID).Msg("webhook.retry_failed")
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeDatabaseError, "failed to retry webhook")
+		return
+	}
+	responders.JSON(w, http.StatusOK, webhookRetryResponse{WebhookID: webhookID, Message: "webhook queued for retry"})
+}
@@ -0,0 +1,120 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/idempotency"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/products"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func TestAdminAuditLog(t *testing.T) {
+	cfg := &config.Config{
+		Server: config.ServerConfig{RoutePrefix: "/api", AdminMetricsAPIKey: "secret"},
+		X402:   config.X402Config{PaymentAddress: "11111111111111111111111111111111", Network: "mainnet-beta", TokenMint: "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v", TokenDecimals: 6},
+	}
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	svc := paywall.NewService(cfg, store, nil, nil, products.NewYAMLRepository(nil), nil, nil)
+	idem := idempotency.NewMemoryStore()
+	t.Cleanup(idem.Stop)
+	router := chi.NewRouter()
+	if err := ConfigureRouter(router, cfg, svc, nil, nil, NewRPCProxyHandlers(cfg), nil, nil, idem, nil, nil, zerolog.Nop()); err != nil {
+		t.Fatalf("ConfigureRouter: %v", err)
+	}
+
+	webhookID, err := store.EnqueueWebhook(context.Background(), storage.PendingWebhook{URL: "https://example.com/hook", Status: storage.WebhookStatusFailed})
+	if err != nil {
+		t.Fatalf("EnqueueWebhook: %v", err)
+	}
+
+	serve := func(method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	bearer := map[string]string{"Authorization": "Bearer secret"}
+
+	actions := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		headers    map[string]string
+		wantStatus int
+	}{
+		{name: "retry webhook", method: http.MethodPost, path: "/api/admin/webhooks/" + webhookID + "/retry", headers: bearer, wantStatus: http.StatusOK},
+		{name: "retry unknown webhook", method: http.MethodPost, path: "/api/admin/webhooks/webhook_missing/retry", headers: bearer, wantStatus: http.StatusNotFound},
+		{name: "link customer", method: http.MethodPost, path: "/api/admin/customers/link", body: `{"wallets":["wallet-1"]}`, headers: bearer, wantStatus: http.StatusOK},
+		{name: "read is not recorded", method: http.MethodGet, path: "/api/admin/customers?wallet=wallet-1", headers: bearer, wantStatus: http.StatusOK},
+		{name: "unsigned deny", method: http.MethodPost, path: "/api/paywall/v1/refunds/deny", body: `{"refundId":"refund_1"}`, headers: map[string]string{"X-Signer": "wallet-admin"}, wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range actions {
+		if rec := serve(tt.method, tt.path, tt.body, tt.headers); rec.Code != tt.wantStatus {
+			t.Fatalf("%s: status = %d, want %d: %s", tt.name, rec.Code, tt.wantStatus, rec.Body.String())
+		}
+	}
+
+	tests := []struct {
+		name        string
+		query       string
+		headers     map[string]string
+		wantStatus  int
+		wantActions []string
+	}{
+		{name: "without key", wantStatus: http.StatusUnauthorized},
+		{name: "all", headers: bearer, wantStatus: http.StatusOK, wantActions: []string{
+			storage.AdminAuditRefundDeny, storage.AdminAuditConfigChange, storage.AdminAuditWebhookRetry, storage.AdminAuditWebhookRetry,
+		}},
+		{name: "by action", query: "?action=webhook.retry&limit=1", headers: bearer, wantStatus: http.StatusOK, wantActions: []string{storage.AdminAuditWebhookRetry}},
+		{name: "by signer", query: "?signer=wallet-admin", headers: bearer, wantStatus: http.StatusOK, wantActions: []string{storage.AdminAuditRefundDeny}},
+		{name: "invalid limit", query: "?limit=0", headers: bearer, wantStatus: http.StatusBadRequest},
+		{name: "invalid since", query: "?since=yesterday", headers: bearer, wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(http.MethodGet, "/api/admin/audit"+tt.query, "", tt.headers)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+			var resp adminAuditResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if len(resp.Entries) != len(tt.wantActions) {
+				t.Fatalf("entries = %+v, want actions %v", resp.Entries, tt.wantActions)
+			}
+			for i, e := range resp.Entries {
+				if e.Action != tt.wantActions[i] {
+					t.Errorf("entry %d action = %s, want %s", i, e.Action, tt.wantActions[i])
+				}
+			}
+		})
+	}
+
+	deny, err := store.ListAdminAudit(context.Background(), storage.AdminAuditFilter{Action: storage.AdminAuditRefundDeny})
+	if err != nil || len(deny) != 1 {
+		t.Fatalf("deny entries = %+v, %v", deny, err)
+	}
+	if got := deny[0]; got.Result != storage.AdminAuditFailure || got.Status != http.StatusBadRequest ||
+		got.Path != "/api/paywall/v1/refunds/deny" || len(got.RequestHash) != 64 {
+		t.Errorf("deny entry = %+v", got)
+	}
+}
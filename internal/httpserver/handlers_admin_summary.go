@@ -0,0 +1,176 @@
+package httpserver
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	apierrors "github.com/CedrosPay/server/internal/errors"
+	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/money"
+	"github.com/CedrosPay/server/internal/rpcutil"
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/pkg/responders"
+	x402solana "github.com/CedrosPay/server/pkg/x402/solana"
+)
+
+// adminSummaryResponse rolls up what an operations dashboard shows in one response.
+type adminSummaryResponse struct {
+	GeneratedAt     time.Time                `json:"generatedAt"`
+	Payments        paymentsSummary          `json:"payments"`
+	PendingRefunds  pendingRefundsSummary    `json:"pendingRefunds"`
+	WebhookDLQ      *webhookDLQSummary       `json:"webhookDlq"`      // Null when the DLQ is disabled
+	Wallets         []walletBalance          `json:"wallets"`         // Server wallets (gasless and token account creation)
+	CircuitBreakers []rpcutil.EndpointStatus `json:"circuitBreakers"` // Solana RPC endpoints; empty with a single endpoint
+}
+
+// paymentsSummary covers payments since midnight UTC and since Monday midnight UTC.
+type paymentsSummary struct {
+	Today    paymentPeriodSummary `json:"today"`
+	ThisWeek paymentPeriodSummary `json:"thisWeek"`
+}
+
+// paymentPeriodSummary counts and totals, per asset, the payments since a point in time.
+type paymentPeriodSummary struct {
+	Since    time.Time              `json:"since"`
+	Count    int64                  `json:"count"`
+	Totals   []storage.PaymentTotal `json:"totals"`
+	TotalUSD *money.Money           `json:"totalUsd"` // All totals converted to USD; null when an asset has no rate
+}
+
+// pendingRefundsSummary is the refund review backlog.
+type pendingRefundsSummary struct {
+	Count         int   `json:"count"`
+	OldestSeconds int64 `json:"oldestSeconds"` // Age of the oldest pending request; 0 when none
+}
+
+// webhookDLQSummary is the size of the failed webhook queue.
+type webhookDLQSummary struct {
+	Size int `json:"size"`
+}
+
+// walletBalance is a server wallet's last observed SOL balance.
+type walletBalance struct {
+	Address     string    `json:"address"`
+	BalanceSOL  float64   `json:"balanceSol"`
+	Status      string    `json:"status"` // healthy, unhealthy, or critical
+	LastChecked time.Time `json:"lastChecked"`
+}
+
+// adminSummary handles GET /paywall/v1/admin/summary - payments today and this week, the
+// pending refund backlog, webhook DLQ size, server wallet balances, and RPC circuit breaker
+// states in one call.
+func (h *handlers) adminSummary(w http.ResponseWriter, r *http.Request) {
+	now := time.Now().UTC()
+	today := now.Truncate(24 * time.Hour)
+	weekStart := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7)) // Monday
+	resp := adminSummaryResponse{
+		GeneratedAt:     now,
+		Payments:        paymentsSummary{Today: paymentPeriodSummary{Since: today}, ThisWeek: paymentPeriodSummary{Since: weekStart}},
+		Wallets:         h.walletBalances(),
+		CircuitBreakers: []rpcutil.EndpointStatus{},
+	}
+	if pool, ok := h.verifier.(interface {
+		RPCEndpoints() []rpcutil.EndpointStatus
+	}); ok {
+		if endpoints := pool.RPCEndpoints(); endpoints != nil {
+			resp.CircuitBreakers = endpoints
+		}
+	}
+
+	if err := h.loadAdminSummary(r.Context(), &resp, now); err != nil {
+		log := logger.FromContext(r.Context())
+		log.Error().Err(err).Msg("admin.summary_failed")
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeDatabaseError, "failed to load admin summary")
+		return
+	}
+
+	responders.JSON(w, http.StatusOK, resp)
+}
+
+// loadAdminSummary fills in the stored parts of the summary: payments, pending refunds, and
+// the webhook DLQ.
+func (h *handlers) loadAdminSummary(ctx context.Context, resp *adminSummaryResponse, now time.Time) error {
+	for _, period := range []*paymentPeriodSummary{&resp.Payments.Today, &resp.Payments.ThisWeek} {
+		if err := h.summarizePayments(ctx, period, now); err != nil {
+			return err
+		}
+	}
+
+	pending, err := h.paywall.ListPendingRefunds(ctx)
+	if err != nil {
+		return err
+	}
+	resp.PendingRefunds.Count = len(pending)
+	for _, refund := range pending {
+		if age := int64(now.Sub(refund.CreatedAt) / time.Second); age > resp.PendingRefunds.OldestSeconds {
+			resp.PendingRefunds.OldestSeconds = age
+		}
+	}
+
+	if h.dlq != nil {
+		failed, err := h.dlq.ListFailedWebhooks(ctx, 0)
+		if err != nil {
+			return err
+		}
+		resp.WebhookDLQ = &webhookDLQSummary{Size: len(failed)}
+	}
+	return nil
+}
+
+// summarizePayments fills in the payments made from period.Since until now.
+func (h *handlers) summarizePayments(ctx context.Context, period *paymentPeriodSummary, now time.Time) error {
+	totals, err := h.paywall.PaymentTotals(ctx, period.Since, now)
+	if err != nil {
+		return err
+	}
+	period.Totals = totals
+	if period.Totals == nil {
+		period.Totals = []storage.PaymentTotal{}
+	}
+	for _, total := range totals {
+		period.Count += total.Count
+	}
+
+	usd := money.Zero(money.MustGetAsset("USD"))
+	for _, total := range totals {
+		converted, err := money.Convert(ctx, total.Amount, usd.Asset)
+		if err != nil {
+			log := logger.FromContext(ctx)
+			log.Debug().Err(err).Str("asset", total.Amount.Asset.Code).Msg("admin.summary_usd_unavailable")
+			return nil
+		}
+		if usd, err = usd.Add(converted); err != nil {
+			return nil
+		}
+	}
+	period.TotalUSD = &usd
+	return nil
+}
+
+// walletBalances returns the server wallets' last checked balances, or none when the verifier
+// has no server wallets.
+func (h *handlers) walletBalances() []walletBalance {
+	balances := []walletBalance{}
+	verifier, ok := h.verifier.(interface {
+		GetHealthChecker() *x402solana.WalletHealthChecker
+	})
+	if !ok || verifier.GetHealthChecker() == nil {
+		return balances
+	}
+	for _, wh := range verifier.GetHealthChecker().GetHealth() {
+		status := "healthy"
+		if wh.IsCritical {
+			status = "critical"
+		} else if !wh.IsHealthy {
+			status = "unhealthy"
+		}
+		balances = append(balances, walletBalance{
+			Address:     wh.PublicKey.String(),
+			BalanceSOL:  wh.Balance,
+			Status:      status,
+			LastChecked: wh.LastChecked,
+		})
+	}
+	return balances
+}
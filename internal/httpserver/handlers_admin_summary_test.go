@@ -0,0 +1,114 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/CedrosPay/server/internal/callbacks"
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/idempotency"
+	"github.com/CedrosPay/server/internal/money"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/products"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func TestAdminSummary(t *testing.T) {
+	cfg := &config.Config{
+		Server: config.ServerConfig{RoutePrefix: "/api", AdminMetricsAPIKey: "secret"},
+		X402:   config.X402Config{PaymentAddress: "11111111111111111111111111111111", Network: "mainnet-beta", TokenMint: "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v", TokenDecimals: 6},
+	}
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	svc := paywall.NewService(cfg, store, nil, nil, products.NewYAMLRepository(nil), nil, nil)
+	idem := idempotency.NewMemoryStore()
+	t.Cleanup(idem.Stop)
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	usdc := money.MustGetAsset("USDC")
+	payments := []storage.PaymentTransaction{
+		{Signature: "sig-1", ResourceID: "ebook", Wallet: "wallet-1", Amount: money.New(usdc, 1_000000), CreatedAt: now},
+		{Signature: "sig-2", ResourceID: "ebook", Wallet: "wallet-2", Amount: money.New(usdc, 2_000000), CreatedAt: now},
+		{Signature: "sig-old", ResourceID: "ebook", Wallet: "wallet-1", Amount: money.New(usdc, 9_000000), CreatedAt: now.AddDate(0, 0, -8)},
+	}
+	if err := store.RecordPayments(ctx, payments); err != nil {
+		t.Fatalf("RecordPayments: %v", err)
+	}
+	if err := store.SaveRefundQuote(ctx, storage.RefundQuote{ID: "refund_1", RecipientWallet: "wallet-1", Amount: money.New(usdc, 1_000000), CreatedAt: now.Add(-time.Hour), ExpiresAt: now.Add(time.Hour)}); err != nil {
+		t.Fatalf("SaveRefundQuote: %v", err)
+	}
+	dlq := callbacks.NewMemoryDLQStore()
+	if err := dlq.SaveFailedWebhook(ctx, callbacks.FailedWebhook{ID: "webhook_1", URL: "https://example.com/hook"}); err != nil {
+		t.Fatalf("SaveFailedWebhook: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		opts       []RouterOption
+		headers    map[string]string
+		wantStatus int
+		wantDLQ    int // -1 when the DLQ is disabled
+	}{
+		{name: "without key", wantStatus: http.StatusUnauthorized, wantDLQ: -1},
+		{name: "without DLQ", headers: map[string]string{"Authorization": "Bearer secret"}, wantStatus: http.StatusOK, wantDLQ: -1},
+		{name: "with DLQ", opts: []RouterOption{WithDLQStore(dlq)}, headers: map[string]string{"Authorization": "Bearer secret"}, wantStatus: http.StatusOK, wantDLQ: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := chi.NewRouter()
+			if err := ConfigureRouter(router, cfg, svc, nil, nil, NewRPCProxyHandlers(cfg), nil, nil, idem, nil, nil, zerolog.Nop(), tt.opts...); err != nil {
+				t.Fatalf("ConfigureRouter: %v", err)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/paywall/v1/admin/summary", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp adminSummaryResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			today := resp.Payments.Today
+			if today.Count != 2 || len(today.Totals) != 1 || today.Totals[0].Amount.Atomic != 3_000000 {
+				t.Fatalf("today = %+v, want 2 payments totalling 3 USDC", today)
+			}
+			if today.TotalUSD == nil || today.TotalUSD.Asset.Code != "USD" || today.TotalUSD.Atomic != 300 {
+				t.Fatalf("today in USD = %+v, want $3.00 at the stablecoin peg", today.TotalUSD)
+			}
+			if resp.Payments.ThisWeek.Count != 2 {
+				t.Fatalf("this week count = %d, want 2", resp.Payments.ThisWeek.Count)
+			}
+			if resp.PendingRefunds.Count != 1 || resp.PendingRefunds.OldestSeconds < 3600 {
+				t.Fatalf("pending refunds = %+v, want 1 at least an hour old", resp.PendingRefunds)
+			}
+			gotDLQ := -1
+			if resp.WebhookDLQ != nil {
+				gotDLQ = resp.WebhookDLQ.Size
+			}
+			if gotDLQ != tt.wantDLQ {
+				t.Fatalf("webhookDlq size = %d, want %d", gotDLQ, tt.wantDLQ)
+			}
+			if resp.Wallets == nil || len(resp.Wallets) != 0 || resp.CircuitBreakers == nil || len(resp.CircuitBreakers) != 0 {
+				t.Fatalf("wallets = %v, circuit breakers = %v, want empty lists", resp.Wallets, resp.CircuitBreakers)
+			}
+		})
+	}
+}
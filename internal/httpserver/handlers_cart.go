@@ -1,30 +1,40 @@
 package httpserver
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"time"
 
+	"github.com/go-chi/chi/v5"
+
+	"github.com/CedrosPay/server/internal/config"
 	"github.com/CedrosPay/server/internal/coupons"
 	apierrors "github.com/CedrosPay/server/internal/errors"
 	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/storage"
 	stripesvc "github.com/CedrosPay/server/internal/stripe"
 	"github.com/CedrosPay/server/pkg/responders"
 )
 
 // createCartCheckoutRequest captures the multi-item cart checkout request.
 type createCartCheckoutRequest struct {
-	Items         []cartItemRequest `json:"items"`
-	CustomerEmail string            `json:"customerEmail,omitempty"`
-	Metadata      map[string]string `json:"metadata,omitempty"`
-	SuccessURL    string            `json:"successUrl,omitempty"`
-	CancelURL     string            `json:"cancelUrl,omitempty"`
-	CouponCode    string            `json:"couponCode,omitempty"` // NEW: Optional coupon code
+	Items          []cartItemRequest `json:"items"`
+	CustomerEmail  string            `json:"customerEmail,omitempty"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+	SuccessURL     string            `json:"successUrl,omitempty"`
+	CancelURL      string            `json:"cancelUrl,omitempty"`
+	CouponCode     string            `json:"couponCode,omitempty"`     // NEW: Optional coupon code
+	Currency       string            `json:"currency,omitempty"`       // Optional: fiat currency for items priced by resource
+	PaymentMethods []string          `json:"paymentMethods,omitempty"` // Optional: subset of stripe.payment_methods
 }
 
 // cartItemRequest represents a single item in the cart.
 type cartItemRequest struct {
 	PriceID     string            `json:"priceId"`
 	Resource    string            `json:"resource,omitempty"` // Optional: backend resource ID for indexing
+	Variant     string            `json:"variant,omitempty"`  // Optional: variant of resource, priced with its stripe_price_id
 	Quantity    int64             `json:"quantity"`
 	Description string            `json:"description,omitempty"`
 	Metadata    map[string]string `json:"metadata,omitempty"`
@@ -62,6 +72,13 @@ func (h *handlers) createCartCheckout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Items priced by resource are charged in one currency: the requested one, or else the
+	// first Accept-Language currency they all offer
+	currency := req.Currency
+	if currency == "" {
+		currency = h.cartCurrency(r.Context(), req.Items, r.Header.Get("Accept-Language"))
+	}
+
 	// Convert to service request format and look up priceIds from resources
 	var cartItems []stripesvc.CartLineItem
 	for i, item := range req.Items {
@@ -70,7 +87,14 @@ func (h *handlers) createCartCheckout(w http.ResponseWriter, r *http.Request) {
 
 		// If no priceID provided but resource is, look it up from resource definition
 		if priceID == "" && resourceID != "" {
-			resource, err := h.paywall.ResourceDefinition(r.Context(), resourceID)
+			resource, err := h.paywall.VariantDefinition(r.Context(), resourceID, item.Variant)
+			if errors.Is(err, paywall.ErrUnknownVariant) {
+				apierrors.WriteError(w, apierrors.ErrCodeInvalidCartItem, err.Error(), map[string]interface{}{
+					"item":       i,
+					"resourceId": resourceID,
+				})
+				return
+			}
 			if err != nil {
 				log.Warn().
 					Err(err).
@@ -83,6 +107,15 @@ func (h *handlers) createCartCheckout(w http.ResponseWriter, r *http.Request) {
 				})
 				return
 			}
+			if currency != "" {
+				if resource, err = paywall.PriceInCurrency(resource, currency); err != nil {
+					apierrors.WriteError(w, apierrors.ErrCodeInvalidCartItem, err.Error(), map[string]interface{}{
+						"item":       i,
+						"resourceId": resourceID,
+					})
+					return
+				}
+			}
 			priceID = resource.StripePriceID
 		}
 
@@ -118,6 +151,7 @@ func (h *handlers) createCartCheckout(w http.ResponseWriter, r *http.Request) {
 		cartItems = append(cartItems, stripesvc.CartLineItem{
 			PriceID:     priceID,
 			Resource:    resourceID, // Now always populated if coupon is used
+			Variant:     item.Variant,
 			Quantity:    item.Quantity,
 			Description: item.Description,
 			Metadata:    item.Metadata,
@@ -165,6 +199,13 @@ func (h *handlers) createCartCheckout(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
+	if !h.checkCardCouponLimits(w, r, couponCode, req.CustomerEmail) {
+		return
+	}
+	paymentMethods, ok := h.resolvePaymentMethods(w, nil, req.PaymentMethods)
+	if !ok {
+		return
+	}
 
 	// Create cart checkout session
 	session, err := h.cartService.CreateCartCheckoutSession(r.Context(), stripesvc.CreateCartSessionRequest{
@@ -177,6 +218,7 @@ func (h *handlers) createCartCheckout(w http.ResponseWriter, r *http.Request) {
 		StripeCouponID: stripeCouponID, // For Stripe's discount system
 		OriginalAmount: 0,              // Stripe calculates from items
 		DiscountAmount: 0,              // Stripe calculates from promotion code
+		PaymentMethods: paymentMethods,
 	})
 	if err != nil {
 		// Record failed cart Stripe session creation
@@ -213,3 +255,157 @@ func (h *handlers) createCartCheckout(w http.ResponseWriter, r *http.Request) {
 		TotalItems: len(req.Items),
 	})
 }
+
+// cartCurrency returns the first currency implied by acceptLanguage that every item priced by
+// resource has a Stripe price in, or "" to use the resources' default prices.
+func (h *handlers) cartCurrency(ctx context.Context, items []cartItemRequest, acceptLanguage string) string {
+	var resources []config.PaywallResource
+	for _, item := range items {
+		if item.PriceID != "" || item.Resource == "" {
+			continue
+		}
+		resource, err := h.paywall.VariantDefinition(ctx, item.Resource, item.Variant)
+		if err != nil {
+			return "" // Reported when the item is priced
+		}
+		resources = append(resources, resource)
+	}
+	if len(resources) == 0 {
+		return ""
+	}
+
+	for _, currency := range paywall.PreferredCurrencies(acceptLanguage) {
+		offered := true
+		for _, resource := range resources {
+			priced, err := paywall.PriceInCurrency(resource, currency)
+			if err != nil || priced.StripePriceID == "" {
+				offered = false
+				break
+			}
+		}
+		if offered {
+			return currency
+		}
+	}
+	return ""
+}
+
+// cartQuoteCheckoutRequest captures the optional Stripe details for paying a cart quote by card.
+type cartQuoteCheckoutRequest struct {
+	CustomerEmail  string   `json:"customerEmail,omitempty"`
+	SuccessURL     string   `json:"successUrl,omitempty"`
+	CancelURL      string   `json:"cancelUrl,omitempty"`
+	PaymentMethods []string `json:"paymentMethods,omitempty"` // Optional: subset of stripe.payment_methods
+}
+
+// cartQuoteCheckoutResponse contains the Stripe checkout session for a cart quote.
+type cartQuoteCheckoutResponse struct {
+	SessionID   string    `json:"sessionId"`
+	URL         string    `json:"url"`
+	CartID      string    `json:"cartId"`
+	AmountTotal int64     `json:"amountTotal"` // Cents charged for the locked cart total
+	Currency    string    `json:"currency"`
+	ExpiresAt   time.Time `json:"expiresAt"` // When the session closes and the cart's stock hold lapses
+}
+
+// createCartQuoteCheckout handles POST /paywall/v1/cart/{cartId}/checkout - creates a Stripe
+// checkout session for an unpaid cart quote at its locked prices, shipping, and tax.
+func (h *handlers) createCartQuoteCheckout(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	cartID := chi.URLParam(r, "cartId")
+
+	var req cartQuoteCheckoutRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, err.Error())
+		return
+	}
+	if h.cartService == nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeServiceUnavailable, "card payments are not configured")
+		return
+	}
+	paymentMethods, ok := h.resolvePaymentMethods(w, nil, req.PaymentMethods)
+	if !ok {
+		return
+	}
+
+	expiresAt := time.Now().Add(stripesvc.QuotedCartSessionTTL)
+	checkout, err := h.paywall.PrepareCartCheckout(r.Context(), cartID, expiresAt)
+	if err != nil {
+		h.writeCartCheckoutError(w, r, cartID, err)
+		return
+	}
+	if !h.checkCardCouponLimits(w, r, checkout.Metadata["coupon_codes"], req.CustomerEmail) {
+		return
+	}
+
+	lines := make([]stripesvc.QuotedCartLine, 0, len(checkout.Lines))
+	for _, line := range checkout.Lines {
+		lines = append(lines, stripesvc.QuotedCartLine{Name: line.Name, UnitAmount: line.UnitAmount, Quantity: line.Quantity})
+	}
+	session, err := h.cartService.CreateQuotedCartSession(r.Context(), stripesvc.CreateQuotedCartSessionRequest{
+		CartID:         cartID,
+		Currency:       checkout.Currency,
+		Lines:          lines,
+		Discount:       checkout.Discount,
+		CustomerEmail:  req.CustomerEmail,
+		Metadata:       checkout.Metadata,
+		SuccessURL:     req.SuccessURL,
+		CancelURL:      req.CancelURL,
+		ExpiresAt:      expiresAt,
+		PaymentMethods: paymentMethods,
+	})
+	if err != nil {
+		if h.metrics != nil {
+			h.metrics.ObserveCartCheckout("session_creation_failed", len(checkout.Lines))
+		}
+		log.Error().
+			Err(err).
+			Str("cart_id", cartID).
+			Msg("cart.quote_checkout.session_failed")
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeStripeError, err.Error())
+		return
+	}
+	if h.metrics != nil {
+		h.metrics.ObserveCartCheckout("session_created", len(checkout.Lines))
+	}
+
+	log.Info().
+		Str("session_id", session.ID).
+		Str("cart_id", cartID).
+		Int64("amount_cents", checkout.Total).
+		Msg("cart.quote_checkout.session_created")
+
+	responders.JSON(w, http.StatusOK, cartQuoteCheckoutResponse{
+		SessionID:   session.ID,
+		URL:         session.URL,
+		CartID:      cartID,
+		AmountTotal: checkout.Total,
+		Currency:    checkout.Currency,
+		ExpiresAt:   expiresAt.UTC(),
+	})
+}
+
+// writeCartCheckoutError maps PrepareCartCheckout errors to API errors.
+func (h *handlers) writeCartCheckoutError(w http.ResponseWriter, r *http.Request, cartID string, err error) {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeCartNotFound, "cart not found")
+	case errors.Is(err, storage.ErrCartExpired):
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeQuoteExpired, "cart quote has expired")
+	case errors.Is(err, paywall.ErrCartPaid):
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeCartAlreadyPaid, "cart has already been paid")
+	case errors.Is(err, paywall.ErrCartNotCardPayable):
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidCartItem, err.Error())
+	case errors.Is(err, paywall.ErrOutOfStock):
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeOutOfStock, err.Error())
+	case errors.Is(err, paywall.ErrDraining):
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeServiceUnavailable, "server is shutting down, retry shortly")
+	default:
+		log := logger.FromContext(r.Context())
+		log.Error().
+			Err(err).
+			Str("cart_id", cartID).
+			Msg("cart.quote_checkout.prepare_failed")
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInternalError, err.Error())
+	}
+}
@@ -1,12 +1,16 @@
 package httpserver
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
+	"github.com/go-chi/chi/v5"
+
 	apierrors "github.com/CedrosPay/server/internal/errors"
 	"github.com/CedrosPay/server/internal/logger"
 	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/storage"
 	"github.com/CedrosPay/server/pkg/responders"
 )
 
@@ -36,6 +40,34 @@ func (h *handlers) requestCartQuote(w http.ResponseWriter, r *http.Request) {
 
 	// Generate cart quote
 	resp, err := h.paywall.GenerateCartQuote(r.Context(), req)
+	if errors.Is(err, paywall.ErrDraining) {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeServiceUnavailable, "server is shutting down, retry shortly")
+		return
+	}
+	if errors.Is(err, paywall.ErrOutOfStock) {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeOutOfStock, err.Error())
+		return
+	}
+	if errors.Is(err, paywall.ErrNoShippingRate) {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, err.Error())
+		return
+	}
+	if errors.Is(err, paywall.ErrUnknownVariant) {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidCartItem, err.Error())
+		return
+	}
+	if errors.Is(err, paywall.ErrCouponWalletLimit) {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeCouponUsageLimitReached, err.Error())
+		return
+	}
+	if errors.Is(err, paywall.ErrVelocityLimit) {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeVelocityLimitExceeded, err.Error())
+		return
+	}
+	if errors.Is(err, paywall.ErrAccessDenied) {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeAccessDenied, err.Error())
+		return
+	}
 	if err != nil {
 		log.Error().
 			Err(err).
@@ -64,3 +96,77 @@ func (h *handlers) requestCartQuote(w http.ResponseWriter, r *http.Request) {
 	// The quote contains the payment requirement that must be satisfied
 	responders.JSON(w, http.StatusPaymentRequired, resp)
 }
+
+// updateCartQuote handles PATCH /paywall/v1/cart/{cartId} - adds, removes, or changes the
+// quantities of items in an unpaid cart, re-pricing it and re-locking the total under the same
+// cart ID.
+func (h *handlers) updateCartQuote(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	cartID := chi.URLParam(r, "cartId")
+
+	var req paywall.CartUpdateRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, err.Error())
+		return
+	}
+
+	resp, err := h.paywall.UpdateCartQuote(r.Context(), cartID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeCartNotFound, "cart not found")
+		case errors.Is(err, storage.ErrCartExpired):
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeQuoteExpired, "cart quote has expired")
+		case errors.Is(err, paywall.ErrCartPaid):
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeCartAlreadyPaid, "cart has already been paid")
+		case errors.Is(err, paywall.ErrInvalidCartChange), errors.Is(err, paywall.ErrUnknownVariant):
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidCartItem, err.Error())
+		case errors.Is(err, paywall.ErrResourceNotConfigured):
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeResourceNotFound, err.Error())
+		case errors.Is(err, paywall.ErrOutOfStock):
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeOutOfStock, err.Error())
+		case errors.Is(err, paywall.ErrNoShippingRate):
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, err.Error())
+		case errors.Is(err, paywall.ErrDraining):
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeServiceUnavailable, "server is shutting down, retry shortly")
+		default:
+			log.Error().
+				Err(err).
+				Str("cart_id", cartID).
+				Msg("cart.update_failed")
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeInternalError, err.Error())
+		}
+		return
+	}
+
+	log.Info().
+		Str("cart_id", cartID).
+		Int("item_count", len(resp.Items)).
+		Msg("cart.quote.updated")
+	responders.JSON(w, http.StatusOK, resp)
+}
+
+// getCartPayments handles GET /paywall/v1/cart/{cartId}/payments - lists the payments made toward
+// a cart and the amount still owed, so wallets splitting a cart can see what is left to pay.
+func (h *handlers) getCartPayments(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	cartID := chi.URLParam(r, "cartId")
+
+	resp, err := h.paywall.CartPayments(r.Context(), cartID)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeCartNotFound, "cart not found")
+		case errors.Is(err, storage.ErrCartExpired):
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeQuoteExpired, "cart quote has expired")
+		default:
+			log.Error().
+				Err(err).
+				Str("cart_id", cartID).
+				Msg("cart.payments_failed")
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeInternalError, err.Error())
+		}
+		return
+	}
+	responders.JSON(w, http.StatusOK, resp)
+}
@@ -46,6 +46,16 @@ func (h *handlers) verifyCartPayment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if result.Partial != nil {
+		log.Info().
+			Str("cart_id", cartID).
+			Str("wallet", logger.TruncateAddress(result.Wallet)).
+			Str("remaining_amount", result.Partial.RemainingAmountDecimal).
+			Msg("cart.verify.partial_payment")
+		partialPaymentResponse(w, cartID, result)
+		return
+	}
+
 	if !result.Granted {
 		log.Warn().
 			Str("cart_id", cartID).
@@ -85,6 +95,16 @@ func (h *handlers) verifyCartPaymentInternal(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	if result.Partial != nil {
+		log.Info().
+			Str("cart_id", cartID).
+			Str("wallet", logger.TruncateAddress(result.Wallet)).
+			Str("remaining_amount", result.Partial.RemainingAmountDecimal).
+			Msg("cart.verify_internal.partial_payment")
+		partialPaymentResponse(w, cartID, result)
+		return
+	}
+
 	if !result.Granted {
 		log.Warn().
 			Str("cart_id", cartID).
@@ -0,0 +1,55 @@
+package httpserver
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/CedrosPay/server/internal/circuitbreaker"
+	apierrors "github.com/CedrosPay/server/internal/errors"
+	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/pkg/responders"
+)
+
+// adminCircuitBreakersResponse lists the server's circuit breakers.
+type adminCircuitBreakersResponse struct {
+	Breakers []circuitbreaker.Status `json:"breakers"`
+}
+
+// circuitBreakers returns the breakers guarding outbound calls. Only the Solana RPC endpoint
+// pool runs calls through breakers; the Stripe and webhook settings are not applied yet.
+func (h *handlers) circuitBreakers() []*circuitbreaker.Breaker {
+	if pool, ok := h.verifier.(interface {
+		RPCBreakers() []*circuitbreaker.Breaker
+	}); ok {
+		return pool.RPCBreakers()
+	}
+	return nil
+}
+
+// adminListCircuitBreakers handles GET /admin/circuit-breakers - reports each breaker's state,
+// failure counts, and last trip.
+func (h *handlers) adminListCircuitBreakers(w http.ResponseWriter, r *http.Request) {
+	resp := adminCircuitBreakersResponse{Breakers: []circuitbreaker.Status{}}
+	for _, breaker := range h.circuitBreakers() {
+		resp.Breakers = append(resp.Breakers, breaker.Status())
+	}
+	responders.JSON(w, http.StatusOK, resp)
+}
+
+// adminResetCircuitBreaker handles POST /admin/circuit-breakers/{name}/reset - closes a
+// tripped breaker and clears its counts, without restarting the server.
+func (h *handlers) adminResetCircuitBreaker(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	for _, breaker := range h.circuitBreakers() {
+		if breaker.Name() != name {
+			continue
+		}
+		breaker.Reset()
+		log := logger.FromContext(r.Context())
+		log.Info().Str("breaker", name).Msg("circuit_breaker.reset")
+		responders.JSON(w, http.StatusOK, breaker.Status())
+		return
+	}
+	apierrors.WriteSimpleError(w, apierrors.ErrCodeResourceNotFound, "circuit breaker not found")
+}
@@ -0,0 +1,115 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/CedrosPay/server/internal/circuitbreaker"
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/idempotency"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/products"
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/pkg/x402"
+)
+
+// breakerVerifier is a verifier whose RPC endpoints have circuit breakers.
+type breakerVerifier struct {
+	breakers []*circuitbreaker.Breaker
+}
+
+func (v breakerVerifier) Verify(context.Context, x402.PaymentProof, x402.Requirement) (x402.VerificationResult, error) {
+	return x402.VerificationResult{}, errors.New("not implemented")
+}
+
+func (v breakerVerifier) RPCBreakers() []*circuitbreaker.Breaker {
+	return v.breakers
+}
+
+func TestAdminCircuitBreakers(t *testing.T) {
+	cfg := &config.Config{
+		Server: config.ServerConfig{RoutePrefix: "/api", AdminMetricsAPIKey: "secret"},
+		X402:   config.X402Config{PaymentAddress: "11111111111111111111111111111111", Network: "mainnet-beta", TokenMint: "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v", TokenDecimals: 6},
+	}
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	svc := paywall.NewService(cfg, store, nil, nil, products.NewYAMLRepository(nil), nil, nil)
+	idem := idempotency.NewMemoryStore()
+	t.Cleanup(idem.Stop)
+
+	breakerCfg := circuitbreaker.BreakerConfig{MaxRequests: 1, Timeout: time.Hour, ConsecutiveFailures: 1}
+	tripped := circuitbreaker.NewBreaker("solana_rpc:a.example.com", breakerCfg, nil)
+	_, _ = tripped.Execute(func() (interface{}, error) { return nil, errors.New("timeout") })
+	healthy := circuitbreaker.NewBreaker("solana_rpc:b.example.com", breakerCfg, nil)
+	verifier := breakerVerifier{breakers: []*circuitbreaker.Breaker{tripped, healthy}}
+
+	router := chi.NewRouter()
+	if err := ConfigureRouter(router, cfg, svc, nil, verifier, NewRPCProxyHandlers(cfg), nil, nil, idem, nil, nil, zerolog.Nop()); err != nil {
+		t.Fatalf("ConfigureRouter: %v", err)
+	}
+	serve := func(method, path string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	states := func() map[string]circuitbreaker.Status {
+		rec := serve(http.MethodGet, "/api/admin/circuit-breakers", map[string]string{"Authorization": "Bearer secret"})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("list status = %d: %s", rec.Code, rec.Body.String())
+		}
+		var resp adminCircuitBreakersResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		byName := make(map[string]circuitbreaker.Status, len(resp.Breakers))
+		for _, status := range resp.Breakers {
+			byName[status.Name] = status
+		}
+		return byName
+	}
+
+	before := states()
+	if got := before["solana_rpc:a.example.com"]; got.State != "open" || got.Trips != 1 || got.LastTrip == nil {
+		t.Fatalf("tripped breaker = %+v, want open with 1 trip", got)
+	}
+	if got := before["solana_rpc:b.example.com"]; got.State != "closed" || got.Trips != 0 {
+		t.Fatalf("healthy breaker = %+v, want closed", got)
+	}
+
+	auth := map[string]string{"Authorization": "Bearer secret"}
+	tests := []struct {
+		name       string
+		breaker    string
+		headers    map[string]string
+		wantStatus int
+	}{
+		{name: "without key", breaker: "solana_rpc:a.example.com", wantStatus: http.StatusUnauthorized},
+		{name: "unknown breaker", breaker: "stripe_api", headers: auth, wantStatus: http.StatusNotFound},
+		{name: "tripped breaker", breaker: "solana_rpc:a.example.com", headers: auth, wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(http.MethodPost, "/api/admin/circuit-breakers/"+tt.breaker+"/reset", tt.headers)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+
+	after := states()
+	if got := after["solana_rpc:a.example.com"]; got.State != "closed" || got.Trips != 1 || got.LastReset == nil {
+		t.Fatalf("reset breaker = %+v, want closed with the trip kept", got)
+	}
+}
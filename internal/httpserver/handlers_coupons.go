@@ -1,11 +1,15 @@
 package httpserver
 
 import (
+	"errors"
 	"net/http"
 
+	"github.com/go-chi/chi/v5"
+
 	"github.com/CedrosPay/server/internal/coupons"
 	apierrors "github.com/CedrosPay/server/internal/errors"
 	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/paywall"
 	"github.com/CedrosPay/server/pkg/responders"
 )
 
@@ -151,3 +155,34 @@ func (h *handlers) validateCoupon(w http.ResponseWriter, r *http.Request) {
 		RemainingUses:      remainingUses,
 	})
 }
+
+// giftCardBalanceResponse is the remaining balance of a gift card.
+type giftCardBalanceResponse struct {
+	Code     string `json:"code"`
+	Balance  string `json:"balance"`  // Remaining balance in major units
+	Currency string `json:"currency"` // Always USD
+}
+
+// getGiftCardBalance handles GET /paywall/v1/gift-cards/{code} - returns a gift card's remaining balance.
+func (h *handlers) getGiftCardBalance(w http.ResponseWriter, r *http.Request) {
+	code := chi.URLParam(r, "code")
+	balance, err := h.paywall.GiftCardBalance(r.Context(), code)
+	if errors.Is(err, paywall.ErrNotGiftCard) {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeCouponNotFound, "gift card not found")
+		return
+	}
+	if err != nil {
+		log := logger.FromContext(r.Context())
+		log.Error().
+			Err(err).
+			Str("coupon_code", code).
+			Msg("gift_cards.balance_failed")
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInternalError, "failed to get gift card balance")
+		return
+	}
+	responders.JSON(w, http.StatusOK, giftCardBalanceResponse{
+		Code:     code,
+		Balance:  balance.ToMajor(),
+		Currency: balance.Asset.Code,
+	})
+}
@@ -0,0 +1,225 @@
+package httpserver
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/CedrosPay/server/internal/coupons"
+	apierrors "github.com/CedrosPay/server/internal/errors"
+	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/pkg/responders"
+)
+
+// adminCouponResponse is a coupon as managed through the admin API, including its usage.
+type adminCouponResponse struct {
+	Code                string            `json:"code"`
+	DiscountType        string            `json:"discountType"`
+	DiscountValue       float64           `json:"discountValue"`
+	Currency            string            `json:"currency,omitempty"`
+	Scope               string            `json:"scope"`
+	ProductIDs          []string          `json:"productIds,omitempty"`
+	PaymentMethod       string            `json:"paymentMethod,omitempty"`
+	AutoApply           bool              `json:"autoApply"`
+	AppliesAt           string            `json:"appliesAt,omitempty"`
+	UsageLimit          *int              `json:"usageLimit,omitempty"`
+	UsageLimitPerWallet *int              `json:"usageLimitPerWallet,omitempty"`
+	UsageCount          int               `json:"usageCount"`
+	StartsAt            *time.Time        `json:"startsAt,omitempty"`
+	ExpiresAt           *time.Time        `json:"expiresAt,omitempty"`
+	Metadata            map[string]string `json:"metadata,omitempty"`
+	Active              bool              `json:"active"`
+	CreatedAt           time.Time         `json:"createdAt"`
+	UpdatedAt           time.Time         `json:"updatedAt"`
+}
+
+// adminCouponsResponse lists the active coupons.
+type adminCouponsResponse struct {
+	Coupons []adminCouponResponse `json:"coupons"`
+}
+
+func newAdminCouponResponse(c coupons.Coupon) adminCouponResponse {
+	return adminCouponResponse{
+		Code:                c.Code,
+		DiscountType:        string(c.DiscountType),
+		DiscountValue:       c.DiscountValue,
+		Currency:            c.Currency,
+		Scope:               string(c.Scope),
+		ProductIDs:          c.ProductIDs,
+		PaymentMethod:       string(c.PaymentMethod),
+		AutoApply:           c.AutoApply,
+		AppliesAt:           string(c.AppliesAt),
+		UsageLimit:          c.UsageLimit,
+		UsageLimitPerWallet: c.UsageLimitPerWallet,
+		UsageCount:          c.UsageCount,
+		StartsAt:            c.StartsAt,
+		ExpiresAt:           c.ExpiresAt,
+		Metadata:            c.Metadata,
+		Active:              c.Active,
+		CreatedAt:           c.CreatedAt,
+		UpdatedAt:           c.UpdatedAt,
+	}
+}
+
+// adminListCoupons handles GET /admin/coupons - lists the active coupons.
+func (h *handlers) adminListCoupons(w http.ResponseWriter, r *http.Request) {
+	resp := adminCouponsResponse{Coupons: []adminCouponResponse{}}
+	if h.couponRepo != nil {
+		list, err := h.couponRepo.ListCoupons(r.Context())
+		if err != nil {
+			h.writeCouponAdminError(w, r, err)
+			return
+		}
+		for _, c := range list {
+			resp.Coupons = append(resp.Coupons, newAdminCouponResponse(c))
+		}
+	}
+	responders.JSON(w, http.StatusOK, resp)
+}
+
+// adminGetCoupon handles GET /admin/coupons/{code}.
+func (h *handlers) adminGetCoupon(w http.ResponseWriter, r *http.Request) {
+	if h.couponRepo == nil {
+		h.writeCouponAdminError(w, r, coupons.ErrCouponNotFound)
+		return
+	}
+	coupon, err := h.couponRepo.GetCoupon(r.Context(), chi.URLParam(r, "code"))
+	if err != nil {
+		h.writeCouponAdminError(w, r, err)
+		return
+	}
+	responders.JSON(w, http.StatusOK, newAdminCouponResponse(coupon))
+}
+
+// adminCreateCoupon handles POST /admin/coupons - adds an active coupon.
+func (h *handlers) adminCreateCoupon(w http.ResponseWriter, r *http.Request) {
+	var req paywall.CouponRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, err.Error())
+		return
+	}
+
+	coupon, err := h.paywall.CreateCoupon(r.Context(), req)
+	if err != nil {
+		h.writeCouponAdminError(w, r, err)
+		return
+	}
+
+	log := logger.FromContext(r.Context())
+	log.Info().Str("coupon_code", coupon.Code).Msg("coupons.admin.created")
+	responders.JSON(w, http.StatusCreated, newAdminCouponResponse(coupon))
+}
+
+// adminUpdateCoupon handles PUT /admin/coupons/{code} - replaces a coupon's definition.
+func (h *handlers) adminUpdateCoupon(w http.ResponseWriter, r *http.Request) {
+	var req paywall.CouponRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, err.Error())
+		return
+	}
+	code := chi.URLParam(r, "code")
+	if req.Code != "" && req.Code != code {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, "code in body does not match the path")
+		return
+	}
+
+	coupon, err := h.paywall.UpdateCoupon(r.Context(), code, req)
+	if err != nil {
+		h.writeCouponAdminError(w, r, err)
+		return
+	}
+
+	log := logger.FromContext(r.Context())
+	log.Info().Str("coupon_code", coupon.Code).Msg("coupons.admin.updated")
+	responders.JSON(w, http.StatusOK, newAdminCouponResponse(coupon))
+}
+
+// adminDeactivateCoupon handles DELETE /admin/coupons/{code} - stops a coupon from being
+// redeemed. The coupon and its usage count are kept so it can be reactivated with PUT.
+func (h *handlers) adminDeactivateCoupon(w http.ResponseWriter, r *http.Request) {
+	code := chi.URLParam(r, "code")
+	if err := h.paywall.DeactivateCoupon(r.Context(), code); err != nil {
+		h.writeCouponAdminError(w, r, err)
+		return
+	}
+
+	log := logger.FromContext(r.Context())
+	log.Info().Str("coupon_code", code).Msg("coupons.admin.deactivated")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// defaultCouponAnalyticsWindow is the window coupon analytics cover without a since parameter.
+const defaultCouponAnalyticsWindow = 30 * 24 * time.Hour
+
+// adminCouponAnalytics handles GET /admin/coupons/analytics - reports coupon redemptions,
+// revenue impact, and cart conversion per day or week. Query parameters: since and until
+// (RFC 3339 or YYYY-MM-DD; default the last 30 days), interval (day or week), and code.
+func (h *handlers) adminCouponAnalytics(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	req := paywall.CouponAnalyticsRequest{
+		Until:    time.Now(),
+		Interval: query.Get("interval"),
+		Code:     query.Get("code"),
+	}
+	for _, param := range []struct {
+		name string
+		dst  *time.Time
+	}{{"until", &req.Until}, {"since", &req.Since}} {
+		value := query.Get(param.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := parseAnalyticsTime(value)
+		if err != nil {
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, param.name+" must be an RFC 3339 time or YYYY-MM-DD date")
+			return
+		}
+		*param.dst = parsed
+	}
+	if req.Since.IsZero() {
+		req.Since = req.Until.Add(-defaultCouponAnalyticsWindow)
+	}
+
+	report, err := h.paywall.CouponAnalytics(r.Context(), req)
+	if errors.Is(err, paywall.ErrInvalidAnalyticsWindow) {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, err.Error())
+		return
+	}
+	if err != nil {
+		log := logger.FromContext(r.Context())
+		log.Error().Err(err).Msg("coupons.admin.analytics_failed")
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeDatabaseError, "failed to load coupon analytics")
+		return
+	}
+	responders.JSON(w, http.StatusOK, report)
+}
+
+// parseAnalyticsTime parses an RFC 3339 time or a YYYY-MM-DD date (midnight UTC).
+func parseAnalyticsTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
+
+// writeCouponAdminError maps coupon repository errors to API errors.
+func (h *handlers) writeCouponAdminError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, coupons.ErrCouponNotFound):
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeCouponNotFound, "coupon not found")
+	case errors.Is(err, coupons.ErrCouponExists):
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeCouponAlreadyExists, err.Error())
+	case errors.Is(err, coupons.ErrReadOnly):
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeCouponSourceReadOnly,
+			"coupons come from coupons.coupons; set coupons.coupon_source to postgres or mongodb to manage them")
+	case errors.Is(err, paywall.ErrInvalidCoupon):
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, err.Error())
+	default:
+		log := logger.FromContext(r.Context())
+		log.Error().Err(err).Str("coupon_code", chi.URLParam(r, "code")).Msg("coupons.admin.request_failed")
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeDatabaseError, "failed to access coupons")
+	}
+}
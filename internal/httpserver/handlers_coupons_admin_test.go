@@ -0,0 +1,83 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/coupons"
+	"github.com/CedrosPay/server/internal/idempotency"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/products"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func TestCouponAdminEndpoints(t *testing.T) {
+	cfg := &config.Config{
+		Server: config.ServerConfig{RoutePrefix: "/api", AdminMetricsAPIKey: "secret"},
+		X402:   config.X402Config{PaymentAddress: "11111111111111111111111111111111", Network: "mainnet-beta", TokenMint: "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v", TokenDecimals: 6},
+		Paywall: config.PaywallConfig{
+			Resources: map[string]config.PaywallResource{
+				"tee": {ResourceID: "tee", CryptoAtomicAmount: 1500000, CryptoToken: "USDC"},
+			},
+		},
+	}
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	couponRepo := coupons.NewYAMLRepository(map[string]config.Coupon{
+		"SAVE10": {Code: "SAVE10", DiscountType: "percentage", DiscountValue: 10, Scope: "all", Active: true},
+	})
+	svc := paywall.NewService(cfg, store, nil, nil, products.NewYAMLRepository(cfg.Paywall.Resources), couponRepo, nil)
+	idem := idempotency.NewMemoryStore()
+	t.Cleanup(idem.Stop)
+	router := chi.NewRouter()
+	if err := ConfigureRouter(router, cfg, svc, nil, nil, NewRPCProxyHandlers(cfg), nil, couponRepo, idem, nil, nil, zerolog.Nop()); err != nil {
+		t.Fatalf("ConfigureRouter: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		auth       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "without key", method: http.MethodGet, path: "/api/admin/coupons", wantStatus: http.StatusUnauthorized},
+		{name: "list", method: http.MethodGet, path: "/api/admin/coupons", auth: "Bearer secret", wantStatus: http.StatusOK, wantBody: `"code":"SAVE10","discountType":"percentage","discountValue":10`},
+		{name: "get", method: http.MethodGet, path: "/api/admin/coupons/SAVE10", auth: "Bearer secret", wantStatus: http.StatusOK, wantBody: `"usageCount":0`},
+		{name: "get unknown", method: http.MethodGet, path: "/api/admin/coupons/NOPE", auth: "Bearer secret", wantStatus: http.StatusNotFound, wantBody: "coupon_not_found"},
+		{name: "malformed body", method: http.MethodPost, path: "/api/admin/coupons", body: `{`, auth: "Bearer secret", wantStatus: http.StatusBadRequest},
+		{name: "invalid window", method: http.MethodPost, path: "/api/admin/coupons", body: `{"code":"NEW","discountType":"percentage","discountValue":5,"startsAt":"2099-02-01T00:00:00Z","expiresAt":"2099-01-01T00:00:00Z"}`, auth: "Bearer secret", wantStatus: http.StatusBadRequest, wantBody: "startsAt must be before expiresAt"},
+		{name: "unknown product", method: http.MethodPost, path: "/api/admin/coupons", body: `{"code":"NEW","discountType":"percentage","discountValue":5,"scope":"specific","productIds":["mug"]}`, auth: "Bearer secret", wantStatus: http.StatusBadRequest, wantBody: "not found"},
+		{name: "yaml source is read-only", method: http.MethodPost, path: "/api/admin/coupons", body: `{"code":"NEW","discountType":"percentage","discountValue":5,"scope":"specific","productIds":["tee"]}`, auth: "Bearer secret", wantStatus: http.StatusConflict, wantBody: "coupon_source_read_only"},
+		{name: "mismatched code", method: http.MethodPut, path: "/api/admin/coupons/SAVE10", body: `{"code":"NEW","discountType":"percentage","discountValue":5}`, auth: "Bearer secret", wantStatus: http.StatusBadRequest},
+		{name: "analytics", method: http.MethodGet, path: "/api/admin/coupons/analytics?since=2026-01-01&until=2026-01-08&interval=week", auth: "Bearer secret", wantStatus: http.StatusOK, wantBody: `"interval":"week","coupons":[]`},
+		{name: "analytics bad date", method: http.MethodGet, path: "/api/admin/coupons/analytics?since=yesterday", auth: "Bearer secret", wantStatus: http.StatusBadRequest, wantBody: "since must be"},
+		{name: "analytics inverted window", method: http.MethodGet, path: "/api/admin/coupons/analytics?since=2026-02-01&until=2026-01-01", auth: "Bearer secret", wantStatus: http.StatusBadRequest, wantBody: "since must be before until"},
+		{name: "deactivate read-only", method: http.MethodDelete, path: "/api/admin/coupons/SAVE10", auth: "Bearer secret", wantStatus: http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantBody != "" && !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body missing %q: %.300s", tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+}
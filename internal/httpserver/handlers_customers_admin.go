@@ -0,0 +1,158 @@
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	apierrors "github.com/CedrosPay/server/internal/errors"
+	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/pkg/responders"
+)
+
+// linkCustomerRequest lists identities that belong to one customer.
+type linkCustomerRequest struct {
+	Emails            []string `json:"emails,omitempty"`
+	Wallets           []string `json:"wallets,omitempty"`
+	StripeCustomerIDs []string `json:"stripeCustomerIds,omitempty"`
+}
+
+// identities returns the request's identities, or an error naming the first invalid one.
+func (req linkCustomerRequest) identities() ([]storage.CustomerIdentity, error) {
+	var identities []storage.CustomerIdentity
+	for _, group := range []struct {
+		kind   string
+		values []string
+	}{
+		{storage.IdentityEmail, req.Emails},
+		{storage.IdentityWallet, req.Wallets},
+		{storage.IdentityStripeCustomer, req.StripeCustomerIDs},
+	} {
+		for _, value := range group.values {
+			if _, err := storage.NormalizeCustomerIdentity(group.kind, value); err != nil {
+				return nil, err
+			}
+			identities = append(identities, storage.CustomerIdentity{Kind: group.kind, Value: value})
+		}
+	}
+	if len(identities) == 0 {
+		return nil, errors.New("at least one of emails, wallets, or stripeCustomerIds is required")
+	}
+	return identities, nil
+}
+
+// customerLookupParams maps the query parameters GET /admin/customers accepts to identity kinds.
+var customerLookupParams = []struct {
+	param string
+	kind  string
+}{
+	{"email", storage.IdentityEmail},
+	{"wallet", storage.IdentityWallet},
+	{"stripeCustomerId", storage.IdentityStripeCustomer},
+}
+
+// adminFindCustomer handles GET /admin/customers - looks up the customer an email, wallet, or
+// Stripe customer ID is linked to, with their payments and entitlements across payment rails.
+func (h *handlers) adminFindCustomer(w http.ResponseWriter, r *http.Request) {
+	var kind, value string
+	for _, p := range customerLookupParams {
+		v := r.URL.Query().Get(p.param)
+		if v == "" {
+			continue
+		}
+		if kind != "" {
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, "pass only one of email, wallet, or stripeCustomerId")
+			return
+		}
+		kind, value = p.kind, v
+	}
+	if kind == "" {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeMissingField, "one of email, wallet, or stripeCustomerId is required")
+		return
+	}
+	if _, err := storage.NormalizeCustomerIdentity(kind, value); err != nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, err.Error())
+		return
+	}
+
+	profile, err := h.paywall.FindCustomerProfile(r.Context(), kind, value)
+	h.writeCustomerProfile(w, r, profile, err)
+}
+
+// adminGetCustomer handles GET /admin/customers/{id}.
+func (h *handlers) adminGetCustomer(w http.ResponseWriter, r *http.Request) {
+	profile, err := h.paywall.CustomerProfile(r.Context(), chi.URLParam(r, "id"))
+	h.writeCustomerProfile(w, r, profile, err)
+}
+
+// adminLinkCustomer handles POST /admin/customers/link - records that identities belong to one
+// customer, merging the customers they were already linked to. Card payments link their email
+// and Stripe customer ID automatically; a wallet is only linked to them here, since nothing
+// proves a card payer owns a wallet.
+func (h *handlers) adminLinkCustomer(w http.ResponseWriter, r *http.Request) {
+	var req linkCustomerRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, err.Error())
+		return
+	}
+	identities, err := req.identities()
+	if err != nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, err.Error())
+		return
+	}
+
+	profile, err := h.paywall.LinkCustomer(r.Context(), identities)
+	if err == nil {
+		log := logger.FromContext(r.Context())
+		log.Info().
+			Str("customer_id", profile.ID).
+			Int("identities", len(identities)).
+			Msg("customers.admin.linked")
+	}
+	h.writeCustomerProfile(w, r, profile, err)
+}
+
+// writeCustomerProfile adds the customer's subscriptions to profile and writes it, or writes
+// the API error for err.
+func (h *handlers) writeCustomerProfile(w http.ResponseWriter, r *http.Request, profile paywall.CustomerProfile, err error) {
+	if err == nil {
+		err = h.addCustomerSubscriptions(r.Context(), &profile)
+	}
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeCustomerNotFound, "customer not found")
+	case err != nil:
+		log := logger.FromContext(r.Context())
+		log.Error().Err(err).Msg("customers.admin.request_failed")
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeDatabaseError, "failed to load customer")
+	default:
+		responders.JSON(w, http.StatusOK, profile)
+	}
+}
+
+// addCustomerSubscriptions adds the subscriptions of the customer's Stripe customer IDs and
+// wallets to profile, when subscriptions are enabled.
+func (h *handlers) addCustomerSubscriptions(ctx context.Context, profile *paywall.CustomerProfile) error {
+	if h.subscriptions == nil {
+		return nil
+	}
+	for _, id := range profile.StripeCustomerIDs {
+		subs, err := h.subscriptions.GetByStripeCustomerID(ctx, id)
+		if err != nil {
+			return err
+		}
+		profile.AddSubscriptions(subs)
+	}
+	for _, wallet := range profile.Wallets {
+		subs, err := h.subscriptions.ListByWallet(ctx, wallet)
+		if err != nil {
+			return err
+		}
+		profile.AddSubscriptions(subs)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package httpserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/idempotency"
+	"github.com/CedrosPay/server/internal/money"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/products"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func TestCustomerAdminEndpoints(t *testing.T) {
+	cfg := &config.Config{
+		Server: config.ServerConfig{RoutePrefix: "/api", AdminMetricsAPIKey: "secret"},
+		X402:   config.X402Config{PaymentAddress: "11111111111111111111111111111111", Network: "mainnet-beta", TokenMint: "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v", TokenDecimals: 6},
+	}
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	svc := paywall.NewService(cfg, store, nil, nil, products.NewYAMLRepository(nil), nil, nil)
+	idem := idempotency.NewMemoryStore()
+	t.Cleanup(idem.Stop)
+	router := chi.NewRouter()
+	if err := ConfigureRouter(router, cfg, svc, nil, nil, NewRPCProxyHandlers(cfg), nil, nil, idem, nil, nil, zerolog.Nop()); err != nil {
+		t.Fatalf("ConfigureRouter: %v", err)
+	}
+
+	// A card purchase and a crypto bundle purchase by the same person, on different rails
+	ctx := context.Background()
+	now := time.Now()
+	payments := []storage.PaymentTransaction{
+		{Signature: "stripe:cs_1", ResourceID: "tee", Wallet: "alice@example.com", Amount: money.New(money.MustGetAsset("USD"), 2000), CreatedAt: now.Add(-time.Hour), Metadata: map[string]string{"status": "stripe"}},
+		{Signature: "sig-1", ResourceID: "starter-pack", Wallet: "wallet-1", Amount: money.New(money.MustGetAsset("USDC"), 5000000), CreatedAt: now, Metadata: map[string]string{paywall.BundleItemsKey: "ebook,mug"}},
+	}
+	for _, tx := range payments {
+		if err := store.RecordPayment(ctx, tx); err != nil {
+			t.Fatalf("RecordPayment: %v", err)
+		}
+	}
+	if _, err := store.LinkCustomerIdentities(ctx, storage.PayerIdentities("alice@example.com", "", "cus_1")); err != nil {
+		t.Fatalf("LinkCustomerIdentities: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		auth       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "without key", method: http.MethodGet, path: "/api/admin/customers?email=alice@example.com", wantStatus: http.StatusUnauthorized},
+		{name: "no identity", method: http.MethodGet, path: "/api/admin/customers", auth: "Bearer secret", wantStatus: http.StatusBadRequest, wantBody: "is required"},
+		{name: "two identities", method: http.MethodGet, path: "/api/admin/customers?email=alice@example.com&wallet=wallet-1", auth: "Bearer secret", wantStatus: http.StatusBadRequest, wantBody: "only one"},
+		{name: "unlinked wallet", method: http.MethodGet, path: "/api/admin/customers?wallet=wallet-1", auth: "Bearer secret", wantStatus: http.StatusNotFound, wantBody: "customer_not_found"},
+		{name: "card customer", method: http.MethodGet, path: "/api/admin/customers?stripeCustomerId=cus_1", auth: "Bearer secret", wantStatus: http.StatusOK, wantBody: `"emails":["alice@example.com"],"wallets":[],"stripeCustomerIds":["cus_1"],"entitlements":["tee"]`},
+		{name: "invalid link", method: http.MethodPost, path: "/api/admin/customers/link", body: `{"emails":["alice"]}`, auth: "Bearer secret", wantStatus: http.StatusBadRequest, wantBody: "not an email address"},
+		{name: "empty link", method: http.MethodPost, path: "/api/admin/customers/link", body: `{}`, auth: "Bearer secret", wantStatus: http.StatusBadRequest},
+		{name: "link wallet", method: http.MethodPost, path: "/api/admin/customers/link", body: `{"emails":["Alice@Example.com"],"wallets":["wallet-1"]}`, auth: "Bearer secret", wantStatus: http.StatusOK, wantBody: `"entitlements":["ebook","mug","starter-pack","tee"]`},
+		{name: "linked wallet", method: http.MethodGet, path: "/api/admin/customers?wallet=wallet-1", auth: "Bearer secret", wantStatus: http.StatusOK, wantBody: `"signature":"sig-1","resourceId":"starter-pack","method":"x402"`},
+		{name: "unknown id", method: http.MethodGet, path: "/api/admin/customers/cust_missing", auth: "Bearer secret", wantStatus: http.StatusNotFound, wantBody: "customer_not_found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantBody != "" && !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body missing %q: %.500s", tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+
+	customer, err := store.FindCustomer(ctx, storage.IdentityWallet, "wallet-1")
+	if err != nil {
+		t.Fatalf("FindCustomer: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/customers/"+customer.ID, nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"wallets":["wallet-1"]`) {
+		t.Errorf("GET by id = %d: %.500s", rec.Code, rec.Body.String())
+	}
+}
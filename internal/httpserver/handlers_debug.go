@@ -0,0 +1,133 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/pkg/responders"
+)
+
+// RuntimeStats is the response of the admin runtime stats endpoint.
+type RuntimeStats struct {
+	Timestamp  time.Time      `json:"timestamp"`
+	Uptime     string         `json:"uptime"`
+	GoVersion  string         `json:"goVersion"`
+	NumCPU     int            `json:"numCpu"`
+	GOMAXPROCS int            `json:"gomaxprocs"`
+	Goroutines int            `json:"goroutines"`
+	Heap       HeapStats      `json:"heap"`
+	GC         GCStats        `json:"gc"`
+	Database   *DatabaseStats `json:"database,omitempty"` // SQL-backed storage only
+	TxQueue    *TxQueueStats  `json:"txQueue,omitempty"`  // When the Solana transaction queue is enabled
+}
+
+// HeapStats summarises heap usage in bytes.
+type HeapStats struct {
+	AllocBytes    uint64 `json:"allocBytes"`
+	InuseBytes    uint64 `json:"inuseBytes"`
+	IdleBytes     uint64 `json:"idleBytes"`
+	ReleasedBytes uint64 `json:"releasedBytes"`
+	SysBytes      uint64 `json:"sysBytes"`
+	Objects       uint64 `json:"objects"`
+}
+
+// GCStats summarises garbage collector activity.
+type GCStats struct {
+	NumGC        uint32     `json:"numGc"`
+	LastGC       *time.Time `json:"lastGc,omitempty"`
+	LastPauseMs  float64    `json:"lastPauseMs"`
+	PauseTotalMs float64    `json:"pauseTotalMs"`
+	NextGCBytes  uint64     `json:"nextGcBytes"`
+	CPUFraction  float64    `json:"cpuFraction"`
+}
+
+// DatabaseStats reports the storage connection pool.
+type DatabaseStats struct {
+	OpenConnections int     `json:"openConnections"`
+	InUse           int     `json:"inUse"`
+	Idle            int     `json:"idle"`
+	MaxOpen         int     `json:"maxOpen"`
+	WaitCount       int64   `json:"waitCount"`
+	WaitDurationMs  float64 `json:"waitDurationMs"`
+}
+
+// TxQueueStats reports the Solana transaction submission queue depth.
+type TxQueueStats struct {
+	Queued   int `json:"queued"`
+	InFlight int `json:"inFlight"`
+}
+
+// pprofRoutes serves net/http/pprof under any prefix. pprof.Index resolves named profiles only
+// beneath the literal /debug/pprof/ path, so named profiles are dispatched explicitly.
+func pprofRoutes(r chi.Router) {
+	r.Get("/", pprof.Index)
+	r.Get("/cmdline", pprof.Cmdline)
+	r.Get("/profile", pprof.Profile)
+	r.Get("/symbol", pprof.Symbol)
+	r.Post("/symbol", pprof.Symbol)
+	r.Get("/trace", pprof.Trace)
+	r.Get("/{profile}", func(w http.ResponseWriter, r *http.Request) {
+		pprof.Handler(chi.URLParam(r, "profile")).ServeHTTP(w, r)
+	})
+}
+
+// runtimeStats reports goroutine, heap, GC, connection pool, and transaction queue statistics.
+func (h *handlers) runtimeStats(w http.ResponseWriter, r *http.Request) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	now := time.Now()
+	stats := RuntimeStats{
+		Timestamp:  now.UTC(),
+		Uptime:     now.Sub(serverStartTime).String(),
+		GoVersion:  runtime.Version(),
+		NumCPU:     runtime.NumCPU(),
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+		Goroutines: runtime.NumGoroutine(),
+		Heap: HeapStats{
+			AllocBytes:    mem.HeapAlloc,
+			InuseBytes:    mem.HeapInuse,
+			IdleBytes:     mem.HeapIdle,
+			ReleasedBytes: mem.HeapReleased,
+			SysBytes:      mem.HeapSys,
+			Objects:       mem.HeapObjects,
+		},
+		GC: GCStats{
+			NumGC:        mem.NumGC,
+			PauseTotalMs: float64(mem.PauseTotalNs) / float64(time.Millisecond),
+			NextGCBytes:  mem.NextGC,
+			CPUFraction:  mem.GCCPUFraction,
+		},
+	}
+	if mem.NumGC > 0 {
+		last := time.Unix(0, int64(mem.LastGC)).UTC()
+		stats.GC.LastGC = &last
+		stats.GC.LastPauseMs = float64(mem.PauseNs[(mem.NumGC+255)%256]) / float64(time.Millisecond)
+	}
+
+	if h.store != nil {
+		if db, ok := storage.DBStats(h.store); ok {
+			stats.Database = &DatabaseStats{
+				OpenConnections: db.OpenConnections,
+				InUse:           db.InUse,
+				Idle:            db.Idle,
+				MaxOpen:         db.MaxOpenConnections,
+				WaitCount:       db.WaitCount,
+				WaitDurationMs:  float64(db.WaitDuration) / float64(time.Millisecond),
+			}
+		}
+	}
+
+	if queue, ok := h.verifier.(interface{ TxQueueStats() map[string]int }); ok {
+		if q := queue.TxQueueStats(); q != nil {
+			stats.TxQueue = &TxQueueStats{Queued: q["queued"], InFlight: q["in_flight"]}
+		}
+	}
+
+	responders.JSON(w, http.StatusOK, stats)
+}
@@ -0,0 +1,106 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/idempotency"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/products"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func newDebugRouter(t *testing.T, adminKey string) chi.Router {
+	t.Helper()
+	cfg := &config.Config{Server: config.ServerConfig{RoutePrefix: "/api", AdminMetricsAPIKey: adminKey}}
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	svc := paywall.NewService(cfg, store, nil, nil, products.NewYAMLRepository(nil), nil, nil)
+	idem := idempotency.NewMemoryStore()
+	t.Cleanup(idem.Stop)
+
+	router := chi.NewRouter()
+	if err := ConfigureRouter(router, cfg, svc, nil, nil, NewRPCProxyHandlers(cfg), nil, nil, idem, nil, nil, zerolog.Nop(), WithStore(store)); err != nil {
+		t.Fatalf("ConfigureRouter: %v", err)
+	}
+	return router
+}
+
+func TestDebugEndpoints(t *testing.T) {
+	router := newDebugRouter(t, "secret")
+
+	tests := []struct {
+		name       string
+		path       string
+		auth       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "runtime without key", path: "/api/debug/runtime", wantStatus: http.StatusUnauthorized},
+		{name: "runtime wrong key", path: "/api/debug/runtime", auth: "Bearer nope", wantStatus: http.StatusUnauthorized},
+		{name: "runtime", path: "/api/debug/runtime", auth: "Bearer secret", wantStatus: http.StatusOK, wantBody: `"goroutines"`},
+		{name: "pprof without key", path: "/api/debug/pprof/", wantStatus: http.StatusUnauthorized},
+		{name: "pprof index", path: "/api/debug/pprof/", auth: "Bearer secret", wantStatus: http.StatusOK, wantBody: "goroutine"},
+		{name: "named profile under prefix", path: "/api/debug/pprof/goroutine?debug=1", auth: "Bearer secret", wantStatus: http.StatusOK, wantBody: "goroutine profile:"},
+		{name: "unknown profile", path: "/api/debug/pprof/nope", auth: "Bearer secret", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantBody != "" && !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body missing %q: %.200s", tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestDebugEndpointsRequireAdminKey(t *testing.T) {
+	router := newDebugRouter(t, "")
+
+	for _, path := range []string{"/api/debug/runtime", "/api/debug/pprof/"} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("%s: status = %d, want 404 when no admin key is configured", path, rec.Code)
+		}
+	}
+}
+
+func TestRuntimeStats(t *testing.T) {
+	h := &handlers{cfg: &config.Config{}, store: storage.NewMemoryStore()}
+	defer h.store.Close()
+
+	rec := httptest.NewRecorder()
+	h.runtimeStats(rec, httptest.NewRequest(http.MethodGet, "/debug/runtime", nil))
+
+	var stats RuntimeStats
+	if err := json.Unmarshal(rec.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if stats.Goroutines == 0 || stats.Heap.SysBytes == 0 || stats.GoVersion == "" {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if stats.Database != nil {
+		t.Errorf("memory store reported pool stats: %+v", stats.Database)
+	}
+	if stats.TxQueue != nil {
+		t.Errorf("tx queue reported without a verifier: %+v", stats.TxQueue)
+	}
+}
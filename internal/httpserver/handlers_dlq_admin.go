@@ -0,0 +1,68 @@
+package httpserver
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/CedrosPay/server/internal/callbacks"
+	apierrors "github.com/CedrosPay/server/internal/errors"
+	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/pkg/responders"
+)
+
+// adminDLQSegmentsResponse lists the DLQ segments rotated into the archive.
+type adminDLQSegmentsResponse struct {
+	Segments []string `json:"segments"` // Oldest first
+	Count    int      `json:"count"`
+}
+
+// WithDLQRedriver enables the admin endpoints that list and re-drive rotated DLQ segments.
+func WithDLQRedriver(redriver *callbacks.DLQRedriver) RouterOption {
+	return func(h *handlers) {
+		h.dlqRedriver = redriver
+	}
+}
+
+// adminDLQSegments handles GET /admin/webhooks/dlq/segments - lists the DLQ segments rotated
+// out of dlq_path, oldest first.
+func (h *handlers) adminDLQSegments(w http.ResponseWriter, r *http.Request) {
+	if h.dlqRedriver == nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeServiceUnavailable, "webhook DLQ archive is not configured")
+		return
+	}
+	segments, err := h.dlqRedriver.Segments(r.Context())
+	if err != nil {
+		log := logger.FromContext(r.Context())
+		log.Error().Err(err).Msg("dlq.list_segments_failed")
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInternalError, "failed to list DLQ segments")
+		return
+	}
+	if segments == nil {
+		segments = []string{}
+	}
+	responders.JSON(w, http.StatusOK, adminDLQSegmentsResponse{Segments: segments, Count: len(segments)})
+}
+
+// adminRedriveDLQSegment handles POST /admin/webhooks/dlq/segments/{segment}/redrive - delivers
+// each webhook in a rotated segment once, keeping only the ones that fail again.
+func (h *handlers) adminRedriveDLQSegment(w http.ResponseWriter, r *http.Request) {
+	if h.dlqRedriver == nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeServiceUnavailable, "webhook DLQ archive is not configured")
+		return
+	}
+	segment := chi.URLParam(r, "segment")
+	result, err := h.dlqRedriver.Redrive(r.Context(), segment)
+	if err != nil {
+		if errors.Is(err, callbacks.ErrDLQSegmentNotFound) {
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeResourceNotFound, "DLQ segment not found")
+			return
+		}
+		log := logger.FromContext(r.Context())
+		log.Error().Err(err).Str("segment", segment).Msg("dlq.redrive_failed")
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInternalError, "failed to re-drive DLQ segment")
+		return
+	}
+	responders.JSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,130 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/CedrosPay/server/internal/callbacks"
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/idempotency"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/products"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func TestAdminDLQSegments(t *testing.T) {
+	cfg := &config.Config{
+		Server: config.ServerConfig{RoutePrefix: "/api", AdminMetricsAPIKey: "secret"},
+		X402:   config.X402Config{PaymentAddress: "11111111111111111111111111111111", Network: "mainnet-beta", TokenMint: "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v", TokenDecimals: 6},
+	}
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	svc := paywall.NewService(cfg, store, nil, nil, products.NewYAMLRepository(nil), nil, nil)
+	idem := idempotency.NewMemoryStore()
+	t.Cleanup(idem.Stop)
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(receiver.Close)
+
+	archive, err := callbacks.NewLocalDLQArchive(t.TempDir(), 10)
+	if err != nil {
+		t.Fatalf("NewLocalDLQArchive: %v", err)
+	}
+	segment := "dlq-20260101T000000.000000000Z.json"
+	entries, _ := json.Marshal([]callbacks.FailedWebhook{{
+		ID: "wh_1", URL: receiver.URL, Payload: json.RawMessage(`{"eventId":"evt_1"}`), EventType: "payment", Attempts: 5,
+		LastAttempt: time.Now().Add(-48 * time.Hour),
+	}})
+	if err := archive.Put(context.Background(), segment, entries); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	serve := func(router chi.Router, method, path string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	newRouter := func(redriver *callbacks.DLQRedriver) chi.Router {
+		router := chi.NewRouter()
+		if err := ConfigureRouter(router, cfg, svc, nil, nil, NewRPCProxyHandlers(cfg), nil, nil, idem, nil, nil, zerolog.Nop(),
+			WithStore(store), WithDLQRedriver(redriver)); err != nil {
+			t.Fatalf("ConfigureRouter: %v", err)
+		}
+		return router
+	}
+	router := newRouter(callbacks.NewDLQRedriver(archive, callbacks.DLQRedriverOptions{DeliveryLog: store}))
+	auth := map[string]string{"Authorization": "Bearer secret"}
+
+	rec := serve(router, http.MethodGet, "/api/admin/webhooks/dlq/segments", auth)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("list status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var list adminDLQSegmentsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if list.Count != 1 || list.Segments[0] != segment {
+		t.Fatalf("segments = %+v, want [%s]", list, segment)
+	}
+
+	tests := []struct {
+		name       string
+		router     chi.Router
+		segment    string
+		headers    map[string]string
+		wantStatus int
+	}{
+		{name: "without key", router: router, segment: segment, wantStatus: http.StatusUnauthorized},
+		{name: "archive not configured", router: newRouter(nil), segment: segment, headers: auth, wantStatus: http.StatusServiceUnavailable},
+		{name: "unknown segment", router: router, segment: "dlq-20250101T000000.000000000Z.json", headers: auth, wantStatus: http.StatusNotFound},
+		{name: "invalid segment name", router: router, segment: "config.yaml", headers: auth, wantStatus: http.StatusNotFound},
+		{name: "re-drive", router: router, segment: segment, headers: auth, wantStatus: http.StatusOK},
+		{name: "already re-driven", router: router, segment: segment, headers: auth, wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(tt.router, http.MethodPost, "/api/admin/webhooks/dlq/segments/"+tt.segment+"/redrive", tt.headers)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var result callbacks.RedriveResult
+			if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if result.Delivered != 1 || result.Failed != 0 {
+				t.Fatalf("result = %+v, want 1 delivered", result)
+			}
+		})
+	}
+
+	deliveries, err := store.ListWebhookDeliveries(context.Background(), storage.WebhookDeliveryFilter{EventID: "evt_1"})
+	if err != nil {
+		t.Fatalf("ListWebhookDeliveries: %v", err)
+	}
+	if len(deliveries) != 1 || deliveries[0].Attempt != 6 || !deliveries[0].Success {
+		t.Fatalf("deliveries = %+v, want one successful 6th attempt", deliveries)
+	}
+	entriesAudit, err := store.ListAdminAudit(context.Background(), storage.AdminAuditFilter{Action: storage.AdminAuditWebhookRetry})
+	if err != nil {
+		t.Fatalf("ListAdminAudit: %v", err)
+	}
+	if len(entriesAudit) == 0 {
+		t.Fatal("re-drive was not audited")
+	}
+}
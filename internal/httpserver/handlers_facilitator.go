@@ -0,0 +1,108 @@
+package httpserver
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/CedrosPay/server/internal/config"
+	apierrors "github.com/CedrosPay/server/internal/errors"
+	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/pkg/responders"
+)
+
+// facilitatorAuth admits facilitator calls carrying the admin bearer key or a configured API key
+// (scoped keys also need payments:write, which apikey.Middleware enforces). Settling spends the
+// server wallets' SOL, so the routes are closed when neither kind of key is configured.
+func facilitatorAuth(cfg *config.Config) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if hasAdminKey(cfg.Server.AdminMetricsAPIKey, r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if key := strings.TrimSpace(r.Header.Get("X-API-Key")); cfg.APIKey.Enabled && key != "" {
+				if _, ok := cfg.APIKey.Keys[key]; ok {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeUnauthorized, "facilitator calls require an API key or the admin API key")
+		})
+	}
+}
+
+// facilitatorSupportedResponse lists the payments the facilitator handles.
+type facilitatorSupportedResponse struct {
+	Kinds []paywall.FacilitatorKind `json:"kinds"`
+}
+
+// facilitatorSupported handles GET /facilitator/supported.
+func (h *handlers) facilitatorSupported(w http.ResponseWriter, r *http.Request) {
+	responders.JSON(w, http.StatusOK, facilitatorSupportedResponse{Kinds: h.paywall.FacilitatorSupported()})
+}
+
+// facilitatorVerify handles POST /facilitator/verify - checks a payment against the
+// requirements another resource server quoted, without submitting it. Rejected payments are
+// a 200 with isValid false and the reason, as x402 facilitators answer.
+func (h *handlers) facilitatorVerify(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeFacilitatorRequest(w, r)
+	if !ok {
+		return
+	}
+	resp := h.paywall.FacilitatorVerify(r.Context(), req)
+	if !resp.IsValid {
+		log := logger.FromContext(r.Context())
+		log.Info().
+			Str("reason", resp.InvalidReason).
+			Str("pay_to", logger.TruncateAddress(req.PaymentRequirements.PayTo)).
+			Msg("facilitator.verify_rejected")
+	}
+	responders.JSON(w, http.StatusOK, resp)
+}
+
+// facilitatorSettle handles POST /facilitator/settle - submits the payment, waits for
+// confirmation, and returns the transaction for the resource server's X-PAYMENT-RESPONSE.
+func (h *handlers) facilitatorSettle(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeFacilitatorRequest(w, r)
+	if !ok {
+		return
+	}
+	resp, err := h.paywall.FacilitatorSettle(r.Context(), req)
+	log := logger.FromContext(r.Context())
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("signature", logger.TruncateAddress(resp.Transaction)).
+			Str("pay_to", logger.TruncateAddress(req.PaymentRequirements.PayTo)).
+			Msg("facilitator.settle_unrecorded")
+		apierrors.WriteErrorWithDetail(w, paywall.FacilitatorErrorCode(err), err.Error(), "transaction", resp.Transaction)
+		return
+	}
+	if resp.Success {
+		log.Info().
+			Str("signature", logger.TruncateAddress(resp.Transaction)).
+			Str("pay_to", logger.TruncateAddress(req.PaymentRequirements.PayTo)).
+			Msg("facilitator.settled")
+	} else {
+		log.Warn().
+			Str("reason", resp.ErrorReason).
+			Str("pay_to", logger.TruncateAddress(req.PaymentRequirements.PayTo)).
+			Msg("facilitator.settle_failed")
+	}
+	responders.JSON(w, http.StatusOK, resp)
+}
+
+// decodeFacilitatorRequest reads a verify or settle body, writing the error when it is malformed.
+func decodeFacilitatorRequest(w http.ResponseWriter, r *http.Request) (paywall.FacilitatorRequest, bool) {
+	var req paywall.FacilitatorRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, err.Error())
+		return req, false
+	}
+	if req.PaymentHeader == "" && len(req.PaymentPayload) == 0 {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeMissingField, "paymentHeader or paymentPayload is required")
+		return req, false
+	}
+	return req, true
+}
@@ -0,0 +1,44 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/CedrosPay/server/internal/config"
+)
+
+func TestFacilitatorAuth(t *testing.T) {
+	withKeys := &config.Config{
+		Server: config.ServerConfig{AdminMetricsAPIKey: "admin-key"},
+		APIKey: config.APIKeyConfig{Enabled: true, Keys: map[string]string{"partner-key": "partner"}},
+	}
+	tests := []struct {
+		name       string
+		cfg        *config.Config
+		header     http.Header
+		wantStatus int
+	}{
+		{name: "no key", cfg: withKeys, wantStatus: http.StatusUnauthorized},
+		{name: "admin key", cfg: withKeys, header: http.Header{"Authorization": {"Bearer admin-key"}}, wantStatus: http.StatusOK},
+		{name: "api key", cfg: withKeys, header: http.Header{"X-Api-Key": {"partner-key"}}, wantStatus: http.StatusOK},
+		{name: "unknown api key", cfg: withKeys, header: http.Header{"X-Api-Key": {"other"}}, wantStatus: http.StatusUnauthorized},
+		{name: "nothing configured", cfg: &config.Config{}, header: http.Header{"Authorization": {"Bearer "}}, wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := facilitatorAuth(tt.cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			req := httptest.NewRequest(http.MethodPost, "/facilitator/settle", nil)
+			for k, v := range tt.header {
+				req.Header[k] = v
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
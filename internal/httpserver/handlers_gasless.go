@@ -2,6 +2,7 @@ package httpserver
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -18,6 +19,14 @@ import (
 	x402solana "github.com/CedrosPay/server/pkg/x402/solana"
 )
 
+// gaslessTransactionRequest asks the server to build an unsigned gasless payment transaction.
+type gaslessTransactionRequest struct {
+	ResourceID string `json:"resourceId"`
+	UserWallet string `json:"userWallet"`
+	FeePayer   string `json:"feePayer,omitempty"`   // Optional: specific server wallet to use
+	CouponCode string `json:"couponCode,omitempty"` // Optional: coupon code for discount
+}
+
 // buildGaslessTransaction constructs a complete unsigned transaction for gasless payments.
 // The frontend will partially sign this transaction (user signs transfer authority),
 // then send it back to the backend which will co-sign as fee payer and submit.
@@ -37,12 +46,7 @@ func (h *handlers) buildGaslessTransaction(w http.ResponseWriter, r *http.Reques
 	}
 
 	// Parse request
-	var req struct {
-		ResourceID string `json:"resourceId"`
-		UserWallet string `json:"userWallet"`
-		FeePayer   string `json:"feePayer,omitempty"`   // Optional: specific server wallet to use
-		CouponCode string `json:"couponCode,omitempty"` // Optional: coupon code for discount
-	}
+	var req gaslessTransactionRequest
 	if err := decodeJSON(r.Body, &req); err != nil {
 		log.Warn().
 			Err(err).
@@ -124,7 +128,7 @@ func (h *handlers) buildGaslessTransaction(w http.ResponseWriter, r *http.Reques
 		}
 		// Use atomic units directly from Money type (no float64 conversion)
 		atomicAmount = uint64(cartQuote.Total.Atomic)
-		memo = fmt.Sprintf("cart:%s", req.ResourceID)
+		memo = h.paywall.InterpolateMemo("cart:{{resource}}", req.ResourceID)
 
 		// Derive recipient token account from payment address for cart
 		ownerKey, err := solana.PublicKeyFromBase58(h.cfg.X402.PaymentAddress)
@@ -151,6 +155,11 @@ func (h *handlers) buildGaslessTransaction(w http.ResponseWriter, r *http.Reques
 			respondError(w, http.StatusNotFound, fmt.Sprintf("resource not found: %v", err))
 			return
 		}
+		// Fiat-priced resources are charged at the rate locked for the quote
+		if resource, err = h.paywall.QuotedResource(r.Context(), resource); err != nil {
+			respondError(w, http.StatusServiceUnavailable, fmt.Sprintf("price resource: %v", err))
+			return
+		}
 
 		// IMPORTANT: Apply ALL coupons (catalog + checkout) for single product gasless transactions
 		// Since there's no separate cart step, the single product IS the cart
@@ -201,6 +210,12 @@ func (h *handlers) buildGaslessTransaction(w http.ResponseWriter, r *http.Reques
 		// This ensures gasless transactions use the same price as quotes ($0.184 → $0.19)
 		cryptoMoney = cryptoMoney.RoundUpToCents()
 
+		// A gift card pays part of the price from its balance, as in the quote
+		if cryptoMoney, err = h.paywall.ApplyGiftCard(r.Context(), req.CouponCode, cryptoMoney); err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Sprintf("apply gift card: %v", err))
+			return
+		}
+
 		// Use atomic units directly from Money type (no float64 conversion)
 		atomicAmount = uint64(cryptoMoney.Atomic)
 
@@ -282,6 +297,10 @@ func (h *handlers) buildGaslessTransaction(w http.ResponseWriter, r *http.Reques
 			Str("resource_id", req.ResourceID).
 			Str("user_wallet", logger.TruncateAddress(req.UserWallet)).
 			Msg("gasless.build_failed")
+		if errors.Is(err, x402solana.ErrGaslessBudgetExhausted) {
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeGaslessUnavailable, "gasless payments are unavailable; request a new quote and pay network fees")
+			return
+		}
 		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to build transaction: %v", err))
 		return
 	}
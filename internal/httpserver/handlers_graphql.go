@@ -0,0 +1,66 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+
+	apierrors "github.com/CedrosPay/server/internal/errors"
+	"github.com/CedrosPay/server/internal/graphqlapi"
+	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/pkg/responders"
+)
+
+// maxGraphQLRequestBytes bounds request bodies; storefront queries are small.
+const maxGraphQLRequestBytes = 64 << 10
+
+type graphQLRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// graphQL executes a query against the storefront schema.
+// GET  /paywall/v1/graphql?query=...&variables=...&operationName=...
+// POST /paywall/v1/graphql {"query": "...", "variables": {...}, "operationName": "..."}
+// Query errors are reported in the response "errors" array with HTTP 200, per GraphQL over HTTP.
+// The payment and refund fields require "Authorization: Bearer {admin key}".
+func (h *handlers) graphQL(w http.ResponseWriter, r *http.Request) {
+	var req graphQLRequest
+	if r.Method == http.MethodGet {
+		q := r.URL.Query()
+		req.Query = q.Get("query")
+		req.OperationName = q.Get("operationName")
+		if raw := q.Get("variables"); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &req.Variables); err != nil {
+				apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, "variables must be a JSON object")
+				return
+			}
+		}
+	} else {
+		r.Body = http.MaxBytesReader(w, r.Body, maxGraphQLRequestBytes)
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, "Invalid GraphQL request body")
+			return
+		}
+	}
+
+	if req.Query == "" {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeMissingField, "query is required")
+		return
+	}
+
+	// Payment and refund fields are admin-only, as their REST counterparts are
+	ctx := r.Context()
+	if hasAdminKey(h.cfg.Server.AdminMetricsAPIKey, r) {
+		ctx = graphqlapi.WithAdmin(ctx)
+	}
+	result := graphqlapi.Execute(ctx, *h.graphqlSchema, req.Query, req.OperationName, req.Variables)
+	if result.HasErrors() {
+		log := logger.FromContext(r.Context())
+		log.Debug().
+			Int("error_count", len(result.Errors)).
+			Str("operation", req.OperationName).
+			Msg("graphql.query_errors")
+	}
+	responders.JSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,117 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/graphqlapi"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/products"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func TestGraphQLHandler(t *testing.T) {
+	resources := map[string]config.PaywallResource{
+		"demo-content": {ResourceID: "demo-content", Description: "demo", FiatAmountCents: 100, FiatCurrency: "USD"},
+	}
+	svc := paywall.NewService(&config.Config{}, storage.NewMemoryStore(), nil, nil, products.NewYAMLRepository(resources), nil, nil)
+	schema, err := graphqlapi.NewSchema(graphqlapi.Services{Paywall: svc})
+	if err != nil {
+		t.Fatalf("NewSchema: %v", err)
+	}
+	cfg := &config.Config{}
+	cfg.Server.AdminMetricsAPIKey = "admin-key"
+	h := &handlers{cfg: cfg, paywall: svc, graphqlSchema: &schema}
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		adminKey   string
+		wantStatus int
+		wantData   bool
+		wantErrors bool
+	}{
+		{
+			name:       "post query",
+			method:     http.MethodPost,
+			target:     "/paywall/v1/graphql",
+			body:       `{"query":"{ products { id } }"}`,
+			wantStatus: http.StatusOK,
+			wantData:   true,
+		},
+		{
+			name:       "get query with variables",
+			method:     http.MethodGet,
+			target:     "/paywall/v1/graphql?query=" + url.QueryEscape(`query($id: ID!) { product(id: $id) { id } }`) + "&variables=" + url.QueryEscape(`{"id":"demo-content"}`),
+			wantStatus: http.StatusOK,
+			wantData:   true,
+		},
+		{
+			name:       "payment without admin key",
+			method:     http.MethodPost,
+			target:     "/paywall/v1/graphql",
+			body:       `{"query":"{ payment(signature: \"sig\") { wallet } }"}`,
+			wantStatus: http.StatusOK,
+			wantData:   true,
+			wantErrors: true,
+		},
+		{
+			name:       "payment with admin key",
+			method:     http.MethodPost,
+			target:     "/paywall/v1/graphql",
+			body:       `{"query":"{ payment(signature: \"sig\") { wallet } }"}`,
+			adminKey:   "admin-key",
+			wantStatus: http.StatusOK,
+			wantData:   true,
+		},
+		{
+			name:       "missing query",
+			method:     http.MethodPost,
+			target:     "/paywall/v1/graphql",
+			body:       `{}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "malformed body",
+			method:     http.MethodPost,
+			target:     "/paywall/v1/graphql",
+			body:       `{"query":`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.adminKey != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.adminKey)
+			}
+			rec := httptest.NewRecorder()
+			h.graphQL(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if !tt.wantData {
+				return
+			}
+			var resp struct {
+				Data   map[string]any `json:"data"`
+				Errors []any          `json:"errors"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if (len(resp.Errors) > 0) != tt.wantErrors || resp.Data == nil {
+				t.Fatalf("unexpected response: %s", rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/pkg/responders"
+)
+
+const (
+	// healthCheckTimeout bounds each component check.
+	healthCheckTimeout = 2 * time.Second
+	// healthCacheTTL limits how often probes hit dependencies; k8s and load balancers
+	// typically poll every few seconds from several sources.
+	healthCacheTTL = 5 * time.Second
+	// stripeHealthTTL spaces out Stripe API calls, which count against the account rate limit.
+	stripeHealthTTL = 30 * time.Second
+)
+
+// Component health states.
+const (
+	healthOK       = "ok"
+	healthDegraded = "degraded"
+	healthDown     = "down"
+	healthDisabled = "disabled"
+)
+
+// ComponentHealth is the status of one dependency.
+type ComponentHealth struct {
+	Status    string         `json:"status"` // ok, degraded, down, or disabled
+	LatencyMs float64        `json:"latencyMs,omitempty"`
+	Error     string         `json:"error,omitempty"` // "timeout" or "unavailable"; details are logged, not exposed
+	Details   map[string]any `json:"details,omitempty"`
+}
+
+// HealthReport is the response of /healthz and /readyz.
+type HealthReport struct {
+	Status     string                     `json:"status"` // ok, degraded, or down
+	Timestamp  time.Time                  `json:"timestamp"`
+	Draining   bool                       `json:"draining,omitempty"` // Shutting down; new quotes are refused
+	Components map[string]ComponentHealth `json:"components"`
+}
+
+// healthProbe caches component checks so frequent probes don't load dependencies.
+type healthProbe struct {
+	mu        sync.Mutex
+	report    *HealthReport
+	checkedAt time.Time
+	stripe    ComponentHealth
+	stripeAt  time.Time
+}
+
+// healthz reports component health; 503 only when a required dependency is down.
+// Suitable for load balancer health checks.
+func (h *handlers) healthz(w http.ResponseWriter, r *http.Request) {
+	report := h.healthReport(r.Context())
+	status := http.StatusOK
+	if report.Status == healthDown {
+		status = http.StatusServiceUnavailable
+	}
+	responders.JSON(w, status, report)
+}
+
+// readyz reports component health; 503 unless every dependency is ok and the server
+// isn't draining, so Kubernetes readiness probes stop routing to it.
+func (h *handlers) readyz(w http.ResponseWriter, r *http.Request) {
+	report := h.healthReport(r.Context())
+	status := http.StatusOK
+	if report.Status != healthOK || report.Draining {
+		status = http.StatusServiceUnavailable
+	}
+	responders.JSON(w, status, report)
+}
+
+// healthReport returns component health plus the server's draining state.
+func (h *handlers) healthReport(ctx context.Context) HealthReport {
+	report := h.componentReport(ctx)
+	// Not cached: draining must take instances out of rotation immediately
+	report.Draining = h.paywall != nil && h.paywall.Draining()
+	return report
+}
+
+// componentReport returns the cached report or runs all component checks concurrently.
+func (h *handlers) componentReport(ctx context.Context) HealthReport {
+	if h.healthProbe != nil {
+		h.healthProbe.mu.Lock()
+		defer h.healthProbe.mu.Unlock()
+		if h.healthProbe.report != nil && time.Since(h.healthProbe.checkedAt) < healthCacheTTL {
+			return *h.healthProbe.report
+		}
+	}
+
+	// Detach from the probe's cancellation so one impatient caller can't poison the cache
+	ctx = context.WithoutCancel(ctx)
+
+	checks := map[string]func(context.Context) ComponentHealth{
+		"storage":    h.checkStorage,
+		"solana_rpc": h.checkSolanaRPC,
+		"stripe":     h.checkStripe,
+		"wallets":    h.checkWallets,
+	}
+	components := make(map[string]ComponentHealth, len(checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			defer cancel()
+			result := check(checkCtx)
+			mu.Lock()
+			components[name] = result
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	report := HealthReport{Status: healthOK, Timestamp: time.Now().UTC(), Components: components}
+	for _, c := range components {
+		switch c.Status {
+		case healthDown:
+			report.Status = healthDown
+		case healthDegraded:
+			if report.Status == healthOK {
+				report.Status = healthDegraded
+			}
+		}
+	}
+
+	if h.healthProbe != nil {
+		h.healthProbe.report = &report
+		h.healthProbe.checkedAt = time.Now()
+	}
+	return report
+}
+
+// timedCheck runs fn and converts its outcome into a component status.
+func (h *handlers) timedCheck(ctx context.Context, component string, fn func(context.Context) error) ComponentHealth {
+	start := time.Now()
+	err := fn(ctx)
+	result := ComponentHealth{Status: healthOK, LatencyMs: float64(time.Since(start).Microseconds()) / 1000}
+	if err != nil {
+		h.logger.Warn().Err(err).Str("component", component).Msg("health.check_failed")
+		result.Status = healthDown
+		result.Error = "unavailable"
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			result.Error = "timeout"
+		}
+	}
+	return result
+}
+
+func (h *handlers) checkStorage(ctx context.Context) ComponentHealth {
+	if h.store == nil {
+		return ComponentHealth{Status: healthDisabled}
+	}
+	result := h.timedCheck(ctx, "storage", func(ctx context.Context) error {
+		return storage.Ping(ctx, h.store)
+	})
+	result.Details = map[string]any{"backend": storage.BackendName(h.store)}
+	return result
+}
+
+func (h *handlers) checkSolanaRPC(ctx context.Context) ComponentHealth {
+	verifier, ok := h.verifier.(interface{ RPCClient() *rpc.Client })
+	if !ok || verifier.RPCClient() == nil {
+		return ComponentHealth{Status: healthDisabled}
+	}
+	return h.timedCheck(ctx, "solana_rpc", func(ctx context.Context) error {
+		_, err := verifier.RPCClient().GetSlot(ctx, rpc.CommitmentFinalized)
+		return err
+	})
+}
+
+func (h *handlers) checkStripe(ctx context.Context) ComponentHealth {
+	if h.stripe == nil || h.cfg.Stripe.SecretKey == "" {
+		return ComponentHealth{Status: healthDisabled}
+	}
+	// Called with healthProbe.mu held, so the Stripe cache needs no extra locking
+	if h.healthProbe != nil && !h.healthProbe.stripeAt.IsZero() && time.Since(h.healthProbe.stripeAt) < stripeHealthTTL {
+		return h.healthProbe.stripe
+	}
+	result := h.timedCheck(ctx, "stripe", h.stripe.Ping)
+	if h.healthProbe != nil {
+		h.healthProbe.stripe = result
+		h.healthProbe.stripeAt = time.Now()
+	}
+	return result
+}
+
+func (h *handlers) checkWallets(ctx context.Context) ComponentHealth {
+	if !h.cfg.X402.GaslessEnabled {
+		return ComponentHealth{Status: healthDisabled}
+	}
+	walletHealth := h.getWalletHealth()
+	if walletHealth == nil {
+		return ComponentHealth{Status: healthDisabled}
+	}
+	summary, _ := walletHealth["summary"].(map[string]int)
+	result := ComponentHealth{Status: healthOK, Details: map[string]any{
+		"healthy":   summary["healthy"],
+		"unhealthy": summary["unhealthy"],
+		"critical":  summary["critical"],
+	}}
+	// Critical wallets can't pay fees, so gasless payments fail while other flows still work
+	if summary["critical"] > 0 {
+		result.Status = healthDegraded
+	}
+	return result
+}
@@ -0,0 +1,120 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/products"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+// pingStore is a memory store whose Ping returns err.
+type pingStore struct {
+	*storage.MemoryStore
+	err   error
+	pings int
+}
+
+func (s *pingStore) Ping(context.Context) error {
+	s.pings++
+	return s.err
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	tests := []struct {
+		name        string
+		pingErr     error
+		handler     func(*handlers) http.HandlerFunc
+		wantStatus  int
+		wantOverall string
+		wantStorage string
+	}{
+		{name: "healthz ok", handler: func(h *handlers) http.HandlerFunc { return h.healthz }, wantStatus: http.StatusOK, wantOverall: healthOK, wantStorage: healthOK},
+		{name: "readyz ok", handler: func(h *handlers) http.HandlerFunc { return h.readyz }, wantStatus: http.StatusOK, wantOverall: healthOK, wantStorage: healthOK},
+		{name: "healthz storage down", pingErr: errors.New("dial tcp: connection refused"), handler: func(h *handlers) http.HandlerFunc { return h.healthz }, wantStatus: http.StatusServiceUnavailable, wantOverall: healthDown, wantStorage: healthDown},
+		{name: "readyz storage down", pingErr: errors.New("dial tcp: connection refused"), handler: func(h *handlers) http.HandlerFunc { return h.readyz }, wantStatus: http.StatusServiceUnavailable, wantOverall: healthDown, wantStorage: healthDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &pingStore{MemoryStore: storage.NewMemoryStore(), err: tt.pingErr}
+			t.Cleanup(func() { _ = store.Close() })
+			h := &handlers{cfg: &config.Config{}, store: store, logger: zerolog.Nop(), healthProbe: &healthProbe{}}
+
+			rec := httptest.NewRecorder()
+			tt.handler(h)(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+
+			var report HealthReport
+			if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if report.Status != tt.wantOverall {
+				t.Errorf("status = %q, want %q", report.Status, tt.wantOverall)
+			}
+			if got := report.Components["storage"].Status; got != tt.wantStorage {
+				t.Errorf("storage = %q, want %q", got, tt.wantStorage)
+			}
+			if tt.pingErr != nil && report.Components["storage"].Error != "unavailable" {
+				t.Errorf("storage error = %q, want redacted", report.Components["storage"].Error)
+			}
+			for _, name := range []string{"solana_rpc", "stripe", "wallets"} {
+				if got := report.Components[name].Status; got != healthDisabled {
+					t.Errorf("%s = %q, want disabled", name, got)
+				}
+			}
+		})
+	}
+}
+
+func TestHealthReportCached(t *testing.T) {
+	store := &pingStore{MemoryStore: storage.NewMemoryStore()}
+	t.Cleanup(func() { _ = store.Close() })
+	h := &handlers{cfg: &config.Config{}, store: store, logger: zerolog.Nop(), healthProbe: &healthProbe{}}
+
+	for i := 0; i < 3; i++ {
+		h.healthReport(context.Background())
+	}
+	if store.pings != 1 {
+		t.Fatalf("pings = %d, want 1", store.pings)
+	}
+}
+
+func TestReadyzDraining(t *testing.T) {
+	cfg := &config.Config{}
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	svc := paywall.NewService(cfg, store, nil, nil, products.NewYAMLRepository(nil), nil, nil)
+	h := &handlers{cfg: cfg, paywall: svc, store: store, logger: zerolog.Nop(), healthProbe: &healthProbe{}}
+
+	rec := httptest.NewRecorder()
+	h.readyz(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("before drain: status = %d", rec.Code)
+	}
+
+	// The cached component report must not hide draining
+	svc.BeginDrain()
+	rec = httptest.NewRecorder()
+	h.readyz(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), `"draining":true`) {
+		t.Fatalf("while draining: status = %d, body = %s", rec.Code, rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	h.healthz(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("healthz while draining: status = %d", rec.Code)
+	}
+}
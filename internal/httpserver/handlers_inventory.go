@@ -0,0 +1,98 @@
+package httpserver
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	apierrors "github.com/CedrosPay/server/internal/errors"
+	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/pkg/responders"
+)
+
+// setStockRequest sets the units on hand for a resource.
+type setStockRequest struct {
+	Quantity *int64 `json:"quantity"` // Units on hand (0 marks the resource sold out)
+}
+
+// stockResponse is a resource's stock level.
+type stockResponse struct {
+	Resource  string    `json:"resource"`
+	Quantity  int64     `json:"quantity"`  // Units on hand
+	Reserved  int64     `json:"reserved"`  // Units held by unpaid, unexpired carts
+	Available int64     `json:"available"` // Units new carts can still reserve
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func newStockResponse(level storage.StockLevel) stockResponse {
+	return stockResponse{
+		Resource:  level.ResourceID,
+		Quantity:  level.Quantity,
+		Reserved:  level.Reserved,
+		Available: level.Available(),
+		UpdatedAt: level.UpdatedAt,
+	}
+}
+
+// getStock handles GET /admin/inventory/{resource} - returns a tracked resource's stock level.
+func (h *handlers) getStock(w http.ResponseWriter, r *http.Request) {
+	level, err := h.paywall.GetStock(r.Context(), chi.URLParam(r, "resource"))
+	if errors.Is(err, storage.ErrNotFound) {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeResourceNotFound, "stock is not tracked for this resource")
+		return
+	}
+	if err != nil {
+		h.writeStockError(w, r, err)
+		return
+	}
+	responders.JSON(w, http.StatusOK, newStockResponse(level))
+}
+
+// setStock handles PUT /admin/inventory/{resource} - sets the units on hand, starting to
+// track stock for the resource if needed.
+func (h *handlers) setStock(w http.ResponseWriter, r *http.Request) {
+	var req setStockRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, err.Error())
+		return
+	}
+	if req.Quantity == nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeMissingField, "quantity required")
+		return
+	}
+	if *req.Quantity < 0 {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, "quantity must not be negative")
+		return
+	}
+
+	level, err := h.paywall.SetStock(r.Context(), chi.URLParam(r, "resource"), *req.Quantity)
+	if errors.Is(err, paywall.ErrResourceNotConfigured) {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeResourceNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		h.writeStockError(w, r, err)
+		return
+	}
+	responders.JSON(w, http.StatusOK, newStockResponse(level))
+}
+
+// deleteStock handles DELETE /admin/inventory/{resource} - stops tracking stock so the
+// resource can be sold without limit.
+func (h *handlers) deleteStock(w http.ResponseWriter, r *http.Request) {
+	if err := h.paywall.DeleteStock(r.Context(), chi.URLParam(r, "resource")); err != nil {
+		h.writeStockError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handlers) writeStockError(w http.ResponseWriter, r *http.Request, err error) {
+	log := logger.FromContext(r.Context())
+	log.Error().Err(err).Str("resource", chi.URLParam(r, "resource")).Msg("inventory.request_failed")
+	apierrors.WriteSimpleError(w, apierrors.ErrCodeDatabaseError, "failed to access inventory")
+}
@@ -0,0 +1,80 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/idempotency"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/products"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func TestInventoryEndpoints(t *testing.T) {
+	cfg := &config.Config{
+		Server: config.ServerConfig{RoutePrefix: "/api", AdminMetricsAPIKey: "secret"},
+		X402:   config.X402Config{PaymentAddress: "11111111111111111111111111111111", Network: "mainnet-beta", TokenDecimals: 6},
+		Paywall: config.PaywallConfig{
+			Resources: map[string]config.PaywallResource{
+				"tee": {ResourceID: "tee", CryptoAtomicAmount: 1500000, CryptoToken: "USDC"},
+			},
+		},
+	}
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	svc := paywall.NewService(cfg, store, nil, nil, products.NewYAMLRepository(cfg.Paywall.Resources), nil, nil)
+	idem := idempotency.NewMemoryStore()
+	t.Cleanup(idem.Stop)
+	router := chi.NewRouter()
+	if err := ConfigureRouter(router, cfg, svc, nil, nil, NewRPCProxyHandlers(cfg), nil, nil, idem, nil, nil, zerolog.Nop()); err != nil {
+		t.Fatalf("ConfigureRouter: %v", err)
+	}
+
+	cart := func(quantity string) string {
+		return `{"items":[{"resource":"tee","quantity":` + quantity + `}]}`
+	}
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		auth       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "without key", method: http.MethodPut, path: "/api/admin/inventory/tee", body: `{"quantity":2}`, wantStatus: http.StatusUnauthorized},
+		{name: "untracked", method: http.MethodGet, path: "/api/admin/inventory/tee", auth: "Bearer secret", wantStatus: http.StatusNotFound},
+		{name: "missing quantity", method: http.MethodPut, path: "/api/admin/inventory/tee", body: `{}`, auth: "Bearer secret", wantStatus: http.StatusBadRequest, wantBody: "quantity required"},
+		{name: "negative quantity", method: http.MethodPut, path: "/api/admin/inventory/tee", body: `{"quantity":-1}`, auth: "Bearer secret", wantStatus: http.StatusBadRequest},
+		{name: "unknown resource", method: http.MethodPut, path: "/api/admin/inventory/mug", body: `{"quantity":2}`, auth: "Bearer secret", wantStatus: http.StatusNotFound},
+		{name: "set stock", method: http.MethodPut, path: "/api/admin/inventory/tee", body: `{"quantity":2}`, auth: "Bearer secret", wantStatus: http.StatusOK, wantBody: `"available":2`},
+		{name: "reserve", method: http.MethodPost, path: "/api/paywall/v1/cart/quote", body: cart("2"), wantStatus: http.StatusPaymentRequired},
+		{name: "out of stock", method: http.MethodPost, path: "/api/paywall/v1/cart/quote", body: cart("1"), wantStatus: http.StatusConflict, wantBody: "out_of_stock"},
+		{name: "reserved units", method: http.MethodGet, path: "/api/admin/inventory/tee", auth: "Bearer secret", wantStatus: http.StatusOK, wantBody: `"reserved":2,"available":0`},
+		{name: "stop tracking", method: http.MethodDelete, path: "/api/admin/inventory/tee", auth: "Bearer secret", wantStatus: http.StatusNoContent},
+		{name: "unlimited again", method: http.MethodPost, path: "/api/paywall/v1/cart/quote", body: cart("1"), wantStatus: http.StatusPaymentRequired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantBody != "" && !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body missing %q: %.300s", tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package httpserver
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	apierrors "github.com/CedrosPay/server/internal/errors"
+	"github.com/CedrosPay/server/internal/logger"
+)
+
+const (
+	merchantEventsWriteWait  = 10 * time.Second
+	merchantEventsPongWait   = 60 * time.Second
+	merchantEventsPingPeriod = 50 * time.Second // Must be less than pong wait
+)
+
+// merchantEventsUpgrader accepts cross-origin dashboards: authentication is by access key,
+// not cookies, so cross-site WebSocket hijacking is not a concern.
+var merchantEventsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// merchantEvents upgrades to a WebSocket that streams live payment, refund, and
+// webhook-failure events for the tenant bound to the caller's access key.
+// Optional ?types=payment.succeeded,webhook.failed narrows the event types.
+func (h *handlers) merchantEvents(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := authenticateMerchant(h.cfg.MerchantEvents.Keys, r)
+	if !ok {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeUnauthorized, "Invalid or missing merchant access key")
+		return
+	}
+
+	var types []string
+	for _, t := range strings.Split(r.URL.Query().Get("types"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, t)
+		}
+	}
+
+	conn, err := merchantEventsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return // Upgrader already replied with an HTTP error
+	}
+	defer conn.Close()
+
+	sub := h.eventBus.Subscribe(tenantID, types...)
+	defer sub.Close()
+
+	log := logger.FromContext(r.Context())
+	log.Info().Str("tenant_id", tenantID).Strs("types", types).Msg("merchant_events.connected")
+	defer func() {
+		log.Info().Str("tenant_id", tenantID).Int64("dropped", sub.Dropped()).Msg("merchant_events.disconnected")
+	}()
+
+	// Read pump: the channel is server-to-client only, but reads are needed to process
+	// pongs and detect the client going away
+	conn.SetReadLimit(512)
+	_ = conn.SetReadDeadline(time.Now().Add(merchantEventsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(merchantEventsPongWait))
+	})
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(merchantEventsPingPeriod)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			_ = conn.SetWriteDeadline(time.Now().Add(merchantEventsWriteWait))
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(merchantEventsWriteWait)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// authenticateMerchant resolves the tenant for the request's access key.
+// Browsers cannot set headers on WebSocket handshakes, so ?access_token= is accepted as well
+// as "Authorization: Bearer {key}".
+func authenticateMerchant(keys map[string]string, r *http.Request) (string, bool) {
+	token := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if token == "" {
+		token = r.URL.Query().Get("access_token")
+	}
+	if token == "" {
+		return "", false
+	}
+
+	for key, tenantID := range keys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(token)) == 1 {
+			return tenantID, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,85 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
+
+	"github.com/CedrosPay/server/internal/config"
+	apierrors "github.com/CedrosPay/server/internal/errors"
+	"github.com/CedrosPay/server/internal/eventbus"
+)
+
+func newMerchantEventsServer(t *testing.T) (*httptest.Server, *eventbus.Bus) {
+	t.Helper()
+	bus := eventbus.New(8)
+	h := &handlers{
+		cfg: &config.Config{MerchantEvents: config.MerchantEventsConfig{
+			Enabled: true,
+			Keys:    map[string]string{"acme-key": "acme"},
+		}},
+		eventBus: bus,
+	}
+	router := chi.NewRouter()
+	router.Get("/paywall/v1/merchant/events", h.merchantEvents)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server, bus
+}
+
+func TestMerchantEvents_RejectsInvalidKey(t *testing.T) {
+	server, _ := newMerchantEventsServer(t)
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/paywall/v1/merchant/events?access_token=wrong"
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if err == nil {
+		t.Fatal("expected handshake to fail")
+	}
+	if resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %+v", resp)
+	}
+	var body apierrors.ErrorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode error response: %v", err)
+	}
+	if body.Error.Code != apierrors.ErrCodeUnauthorized {
+		t.Errorf("error code = %q, want %q", body.Error.Code, apierrors.ErrCodeUnauthorized)
+	}
+}
+
+func TestMerchantEvents_StreamsTenantEvents(t *testing.T) {
+	server, bus := newMerchantEventsServer(t)
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/paywall/v1/merchant/events?types=webhook.failed"
+
+	header := http.Header{"Authorization": []string{"Bearer acme-key"}}
+	conn, _, err := websocket.DefaultDialer.Dial(url, header)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	// Wait for the server-side subscription to register
+	deadline := time.Now().Add(time.Second)
+	for bus.SubscriberCount() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	bus.Publish(eventbus.Event{Type: eventbus.TypeWebhookFailed, TenantID: "globex"})
+	bus.Publish(eventbus.Event{Type: eventbus.TypePaymentSucceeded, TenantID: "acme"})
+	bus.Publish(eventbus.Event{Type: eventbus.TypeWebhookFailed, TenantID: "acme", Data: map[string]string{"url": "https://example.com"}})
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var event eventbus.Event
+	if err := conn.ReadJSON(&event); err != nil {
+		t.Fatalf("read event: %v", err)
+	}
+	if event.TenantID != "acme" || event.Type != eventbus.TypeWebhookFailed {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+}
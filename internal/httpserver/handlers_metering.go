@@ -0,0 +1,200 @@
+package httpserver
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/CedrosPay/server/internal/auth"
+	"github.com/CedrosPay/server/internal/config"
+	apierrors "github.com/CedrosPay/server/internal/errors"
+	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/pkg/responders"
+)
+
+// meterAccountResponse is a meter account's balance and usage not yet settled.
+type meterAccountResponse struct {
+	Account   string `json:"account"`
+	Balance   string `json:"balance"`   // Remaining balance in major units
+	Unsettled string `json:"unsettled"` // Charged since the last settlement, in major units
+	Calls     int64  `json:"calls"`     // Calls charged since the last settlement
+	Asset     string `json:"asset"`
+	Signature string `json:"signature,omitempty"` // Deposit payment signature, for deposits
+}
+
+func newMeterAccountResponse(meter storage.MeterAccount) meterAccountResponse {
+	return meterAccountResponse{
+		Account:   meter.Account,
+		Balance:   meter.Balance.ToMajor(),
+		Unsettled: meter.Unsettled.ToMajor(),
+		Calls:     meter.Calls,
+		Asset:     meter.Balance.Asset.Code,
+	}
+}
+
+// apiKeyMeterAccount returns the meter account of the configured API key in X-API-Key, or ""
+// when the request has none.
+func apiKeyMeterAccount(cfg *config.Config, r *http.Request) string {
+	key := strings.TrimSpace(r.Header.Get("X-API-Key"))
+	if !cfg.APIKey.Enabled || key == "" {
+		return ""
+	}
+	if _, ok := cfg.APIKey.Keys[key]; !ok {
+		return ""
+	}
+	return paywall.MeterAccountForAPIKey(key)
+}
+
+// requestMeterAccount returns the meter account a request is made for: the configured API key
+// in X-API-Key, or the wallet in X-Signer when it signs the message metering:<wallet>:<nonce>
+// with a nonce from POST /paywall/v1/nonce. The nonce is consumed, so a signed request can't be
+// replayed. It returns "" when the request identifies neither, and an error when the wallet
+// signature or nonce is bad.
+func requestMeterAccount(cfg *config.Config, paywallSvc *paywall.Service, r *http.Request) (string, error) {
+	if account := apiKeyMeterAccount(cfg, r); account != "" {
+		return account, nil
+	}
+	wallet := r.Header.Get("X-Signer")
+	if wallet == "" {
+		return "", nil
+	}
+	message := r.Header.Get("X-Message")
+	nonce, ok := strings.CutPrefix(message, "metering:"+wallet+":")
+	if !ok || nonce == "" {
+		return "", fmt.Errorf("invalid message format (expected: metering:%s:<nonce>)", wallet)
+	}
+	verifier := auth.NewSignatureVerifier()
+	if err := verifier.VerifyUserRequest(r, []string{wallet}, message); err != nil {
+		return "", err
+	}
+	// SECURITY: Consume the nonce so each signed request authenticates only once
+	if err := paywallSvc.ConsumeNonce(r.Context(), nonce); err != nil {
+		return "", fmt.Errorf("nonce validation failed: %w", err)
+	}
+	return paywall.MeterAccountForWallet(wallet), nil
+}
+
+// writeMeteringError maps metering errors to API errors.
+func writeMeteringError(w http.ResponseWriter, r *http.Request, err error, resourceID string) {
+	switch {
+	case errors.Is(err, paywall.ErrMeterBalance):
+		apierrors.WriteErrorWithDetail(w, apierrors.ErrCodeMeterBalanceInsufficient, err.Error(), "resourceId", resourceID)
+	case errors.Is(err, paywall.ErrNotMetered), errors.Is(err, paywall.ErrNotDepositResource):
+		apierrors.WriteErrorWithDetail(w, apierrors.ErrCodeInvalidResource, err.Error(), "resourceId", resourceID)
+	case errors.Is(err, paywall.ErrResourceNotConfigured):
+		apierrors.WriteErrorWithDetail(w, apierrors.ErrCodeResourceNotFound, "Resource not found", "resourceId", resourceID)
+	default:
+		log := logger.FromContext(r.Context())
+		log.Error().
+			Err(err).
+			Str("resource_id", resourceID).
+			Msg("metering.request_failed")
+		apierrors.WriteErrorWithDetail(w, apierrors.ErrCodeInternalError, "metering request failed", "resourceId", resourceID)
+	}
+}
+
+// depositMetering handles POST /paywall/v1/metering/deposit?resource= - tops up a meter account
+// by paying a deposit resource with X-PAYMENT. The configured API key in X-API-Key is credited,
+// or else the paying wallet. Without X-PAYMENT it responds 402 with the deposit's quote.
+func (h *handlers) depositMetering(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	resourceID := r.URL.Query().Get("resource")
+	if resourceID == "" {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeMissingField, "resource query parameter required")
+		return
+	}
+
+	meter, result, err := h.paywall.DepositMetered(r.Context(), apiKeyMeterAccount(h.cfg, r), resourceID, r.Header.Get("X-PAYMENT"), r.URL.Query().Get("couponCode"))
+	switch {
+	case errors.Is(err, paywall.ErrNotDepositResource), errors.Is(err, paywall.ErrResourceNotConfigured):
+		writeMeteringError(w, r, err, resourceID)
+		return
+	case err != nil && result.Granted:
+		writeMeteringError(w, r, err, resourceID)
+		return
+	case err != nil:
+		log.Warn().
+			Err(err).
+			Str("resource_id", resourceID).
+			Msg("metering.deposit_payment_failed")
+		paymentVerificationFailedResponse(w, err, resourceID, "regular")
+		return
+	case !result.Granted && result.Quote != nil && result.Quote.Crypto != nil:
+		responders.JSON(w, http.StatusPaymentRequired, h.paywall.PaymentRequired(result.Quote.Crypto))
+		return
+	case !result.Granted:
+		paymentNotGrantedResponse(w, "Deposit payment was not granted", resourceID, "regular")
+		return
+	}
+
+	response := newMeterAccountResponse(meter)
+	if result.Settlement != nil && result.Settlement.TxHash != nil {
+		response.Signature = *result.Settlement.TxHash
+	}
+	log.Info().
+		Str("resource_id", resourceID).
+		Str("meter_account", meter.Account).
+		Str("amount", result.Amount.ToMajor()).
+		Str("signature", logger.TruncateAddress(response.Signature)).
+		Msg("metering.deposited")
+
+	addSettlementHeader(w, result.Settlement)
+	responders.JSON(w, http.StatusOK, response)
+}
+
+// getMeterBalance handles GET /paywall/v1/metering/balance - returns the balance of the API key
+// in X-API-Key, or of the wallet in X-Signer signing metering:<wallet>:<nonce>.
+func (h *handlers) getMeterBalance(w http.ResponseWriter, r *http.Request) {
+	account, err := requestMeterAccount(h.cfg, h.paywall, r)
+	if err != nil || account == "" {
+		message := "X-API-Key or X-Signer required"
+		if err != nil {
+			message = err.Error()
+		}
+		apierrors.WriteErrorWithDetail(w, apierrors.ErrCodeInvalidSignature, message,
+			"hint", "sign message 'metering:<wallet>:<nonce>' with your wallet, using a nonce from POST /paywall/v1/nonce")
+		return
+	}
+	meter, err := h.paywall.MeterBalance(r.Context(), account)
+	if err != nil {
+		writeMeteringError(w, r, err, "")
+		return
+	}
+	responders.JSON(w, http.StatusOK, newMeterAccountResponse(meter))
+}
+
+// meterChargeRequest is the body of POST /admin/metering/charge. Exactly one of Wallet and
+// APIKey names the account.
+type meterChargeRequest struct {
+	Wallet   string `json:"wallet"`
+	APIKey   string `json:"apiKey"`
+	Resource string `json:"resource"`
+}
+
+// adminChargeMetering handles POST /admin/metering/charge - charges one call to a metered
+// resource, for services that meter their own endpoints instead of going through the proxy.
+func (h *handlers) adminChargeMetering(w http.ResponseWriter, r *http.Request) {
+	var req meterChargeRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, err.Error())
+		return
+	}
+	if req.Resource == "" || (req.Wallet == "") == (req.APIKey == "") {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeMissingField, "resource and one of wallet or apiKey required")
+		return
+	}
+	account := paywall.MeterAccountForWallet(req.Wallet)
+	if req.APIKey != "" {
+		account = paywall.MeterAccountForAPIKey(req.APIKey)
+	}
+
+	meter, err := h.paywall.ChargeMetered(r.Context(), account, req.Resource)
+	if err != nil {
+		writeMeteringError(w, r, err, req.Resource)
+		return
+	}
+	responders.JSON(w, http.StatusOK, newMeterAccountResponse(meter))
+}
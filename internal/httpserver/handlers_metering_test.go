@@ -0,0 +1,108 @@
+package httpserver
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/idempotency"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/products"
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/pkg/x402"
+)
+
+func TestMeteringEndpoints(t *testing.T) {
+	cfg := &config.Config{
+		Server: config.ServerConfig{RoutePrefix: "/api", AdminMetricsAPIKey: "secret"},
+		X402: config.X402Config{
+			PaymentAddress: "11111111111111111111111111111111",
+			TokenMint:      "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v",
+			Network:        "mainnet-beta",
+			AllowedTokens:  []string{"USDC"},
+			TokenDecimals:  6,
+		},
+		Paywall: config.PaywallConfig{
+			QuoteTTL: config.Duration{Duration: time.Minute},
+			Resources: map[string]config.PaywallResource{
+				"api-credits":    {ResourceID: "api-credits", CryptoAtomicAmount: 1000000, CryptoToken: "USDC", CryptoAccount: "11111111111111111111111111111111"},
+				"premium-report": {ResourceID: "premium-report", CryptoAtomicAmount: 1000000, CryptoToken: "USDC", CryptoAccount: "11111111111111111111111111111111"},
+			},
+		},
+		Metering: config.MeteringConfig{
+			Enabled:          true,
+			Asset:            "USDC",
+			DepositResources: []string{"api-credits"},
+			Prices:           map[string]int64{"api-call": 600000},
+		},
+		APIKey: config.APIKeyConfig{Enabled: true, Keys: map[string]string{"partner_key": "pro"}},
+	}
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	idem := idempotency.NewMemoryStore()
+	t.Cleanup(idem.Stop)
+	svc := paywall.NewService(cfg, store, payerVerifier{wallet: "payer-wallet"}, nil, products.NewYAMLRepository(cfg.Paywall.Resources), nil, nil)
+	router := chi.NewRouter()
+	if err := ConfigureRouter(router, cfg, svc, nil, nil, NewRPCProxyHandlers(cfg), nil, nil, idem, nil, nil, zerolog.Nop()); err != nil {
+		t.Fatalf("ConfigureRouter: %v", err)
+	}
+
+	payment := func(signature string) string {
+		payload, _ := json.Marshal(x402.PaymentPayload{
+			Scheme:  x402.SchemeSolanaSPLTransfer,
+			Network: cfg.X402.Network,
+			Payload: x402.SolanaPayload{Signature: signature, Transaction: base64.StdEncoding.EncodeToString([]byte("signed-tx"))},
+		})
+		return base64.StdEncoding.EncodeToString(payload)
+	}
+	keyAccount := paywall.MeterAccountForAPIKey("partner_key")
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		headers    map[string]string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "deposit quote", method: http.MethodPost, path: "/api/paywall/v1/metering/deposit?resource=api-credits", wantStatus: http.StatusPaymentRequired, wantBody: `"maxAmountRequired":"1000000"`},
+		{name: "deposit other resource", method: http.MethodPost, path: "/api/paywall/v1/metering/deposit?resource=premium-report", headers: map[string]string{"X-PAYMENT": payment("sig-report")}, wantStatus: http.StatusBadRequest, wantBody: "invalid_resource"},
+		{name: "deposit to wallet", method: http.MethodPost, path: "/api/paywall/v1/metering/deposit?resource=api-credits", headers: map[string]string{"X-PAYMENT": payment("sig-wallet")}, wantStatus: http.StatusOK, wantBody: `"account":"wallet:payer-wallet","balance":"1.000000"`},
+		{name: "deposit to API key", method: http.MethodPost, path: "/api/paywall/v1/metering/deposit?resource=api-credits", headers: map[string]string{"X-PAYMENT": payment("sig-key"), "X-API-Key": "partner_key"}, wantStatus: http.StatusOK, wantBody: `"account":"` + keyAccount + `"`},
+		{name: "replayed deposit", method: http.MethodPost, path: "/api/paywall/v1/metering/deposit?resource=api-credits", headers: map[string]string{"X-PAYMENT": payment("sig-key"), "X-API-Key": "partner_key"}, wantStatus: http.StatusPaymentRequired},
+		{name: "balance without account", method: http.MethodGet, path: "/api/paywall/v1/metering/balance", wantStatus: http.StatusBadRequest},
+		{name: "balance of API key", method: http.MethodGet, path: "/api/paywall/v1/metering/balance", headers: map[string]string{"X-API-Key": "partner_key"}, wantStatus: http.StatusOK, wantBody: `"balance":"1.000000"`},
+		{name: "charge without admin key", method: http.MethodPost, path: "/api/admin/metering/charge", body: `{"apiKey":"partner_key","resource":"api-call"}`, wantStatus: http.StatusUnauthorized},
+		{name: "charge needs one account", method: http.MethodPost, path: "/api/admin/metering/charge", body: `{"apiKey":"partner_key","wallet":"payer-wallet","resource":"api-call"}`, headers: map[string]string{"Authorization": "Bearer secret"}, wantStatus: http.StatusBadRequest},
+		{name: "charge unmetered resource", method: http.MethodPost, path: "/api/admin/metering/charge", body: `{"apiKey":"partner_key","resource":"premium-report"}`, headers: map[string]string{"Authorization": "Bearer secret"}, wantStatus: http.StatusBadRequest, wantBody: "invalid_resource"},
+		{name: "charge API key", method: http.MethodPost, path: "/api/admin/metering/charge", body: `{"apiKey":"partner_key","resource":"api-call"}`, headers: map[string]string{"Authorization": "Bearer secret"}, wantStatus: http.StatusOK, wantBody: `"balance":"0.400000","unsettled":"0.600000","calls":1`},
+		{name: "charge over balance", method: http.MethodPost, path: "/api/admin/metering/charge", body: `{"apiKey":"partner_key","resource":"api-call"}`, headers: map[string]string{"Authorization": "Bearer secret"}, wantStatus: http.StatusPaymentRequired, wantBody: "meter_balance_insufficient"},
+		{name: "charge wallet", method: http.MethodPost, path: "/api/admin/metering/charge", body: `{"wallet":"payer-wallet","resource":"api-call"}`, headers: map[string]string{"Authorization": "Bearer secret"}, wantStatus: http.StatusOK, wantBody: `"balance":"0.400000"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantBody != "" && !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body missing %q: %.300s", tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+}
@@ -3,10 +3,68 @@ package httpserver
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/CedrosPay/server/internal/callbacks"
+	"github.com/CedrosPay/server/internal/circuitbreaker"
+	apierrors "github.com/CedrosPay/server/internal/errors"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/internal/verification"
+	x402solana "github.com/CedrosPay/server/pkg/x402/solana"
+)
+
+// openAPIVersion is the OpenAPI release the generated document targets.
+const openAPIVersion = "3.1.0"
+
+// apiOperation documents one registered route. The document is generated from this table,
+// and TestOpenAPICoversRoutes fails if it drifts from the routes ConfigureRouter registers.
+type apiOperation struct {
+	method      string
+	path        string // Full path including route prefix
+	id          string
+	summary     string
+	description string
+	tag         string
+	params      []apiParam
+	request     any    // Zero value of the JSON request body type (nil if none)
+	response    any    // Zero value of the success response body type (nil for a generic object)
+	status      int    // Success status (default 200)
+	contentType string // Success content type (default application/json)
+	idempotent  bool   // Accepts Idempotency-Key
+	payment     bool   // Accepts an X-PAYMENT proof
+	security    []map[string][]string
+}
+
+type apiParam struct {
+	name        string
+	in          string // "path", "query", or "header"
+	description string
+	required    bool
+}
+
+// x402QuoteResponse is the 402 Payment Required body listing x402 payment requirements.
+type x402QuoteResponse struct {
+	X402Version int                   `json:"x402Version"`
+	Accepts     []paywall.CryptoQuote `json:"accepts"`
+}
+
+// graphQLResponse is the GraphQL-over-HTTP response envelope.
+type graphQLResponse struct {
+	Data   map[string]any   `json:"data"`
+	Errors []map[string]any `json:"errors,omitempty"`
+}
+
+var (
+	walletSignatureSecurity = []map[string][]string{{"walletSignature": {}, "walletMessage": {}, "walletSigner": {}}}
+	adminBearerSecurity     = []map[string][]string{{"adminBearer": {}}, {}}
+	adminBearerRequired     = []map[string][]string{{"adminBearer": {}}}
+	merchantTokenSecurity   = []map[string][]string{{"merchantToken": {}}}
 )
 
 // openAPISpec handles GET /openapi.json
-// Returns OpenAPI 3.0 specification for Cedros Pay API
+// Returns the OpenAPI 3.1 specification generated from the registered routes.
 func (h *handlers) openAPISpec(w http.ResponseWriter, r *http.Request) {
 	spec := h.buildOpenAPISpec(r)
 
@@ -19,13 +77,48 @@ func (h *handlers) openAPISpec(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// buildOpenAPISpec constructs the OpenAPI 3.0 specification
+// swaggerUIPage renders Swagger UI from the public CDN against the generated document.
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Cedros Pay API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = function () {
+      window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui", deepLinking: true });
+    };
+  </script>
+</body>
+</html>
+`
+
+// swaggerUI handles GET {prefix}/docs - interactive API documentation.
+func (h *handlers) swaggerUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	_, _ = w.Write([]byte(swaggerUIPage))
+}
+
+// buildOpenAPISpec constructs the OpenAPI 3.1 specification
 func (h *handlers) buildOpenAPISpec(r *http.Request) map[string]interface{} {
-	baseURL := h.getServiceEndpoint(r)
-	prefix := h.cfg.Server.RoutePrefix
+	registry := newSchemaRegistry()
+	errorSchema := registry.schemaFor(apierrors.ErrorResponse{})
+
+	paths := make(map[string]map[string]any)
+	for _, op := range h.apiOperations() {
+		if paths[op.path] == nil {
+			paths[op.path] = make(map[string]any)
+		}
+		paths[op.path][strings.ToLower(op.method)] = op.document(registry, errorSchema)
+	}
 
 	return map[string]interface{}{
-		"openapi": "3.0.0",
+		"openapi": openAPIVersion,
 		"info": map[string]interface{}{
 			"title":   "Cedros Pay API",
 			"version": "1.0.0",
@@ -45,273 +138,541 @@ func (h *handlers) buildOpenAPISpec(r *http.Request) map[string]interface{} {
 				"url": "https://github.com/CedrosPay/server",
 			},
 			"license": map[string]string{
-				"name": "MIT",
-				"url":  "https://github.com/CedrosPay/server/blob/main/LICENSE",
+				"name":       "MIT",
+				"identifier": "MIT",
 			},
 		},
 		"servers": []map[string]interface{}{
 			{
-				"url":         baseURL,
+				"url":         h.getServiceEndpoint(r),
 				"description": "Cedros Pay Server",
 			},
 		},
-		"paths": map[string]interface{}{
-			"/cedros-health": map[string]interface{}{
-				"get": map[string]interface{}{
-					"summary":     "Health Check",
-					"description": "Check server health and get route prefix configuration",
-					"operationId": "healthCheck",
-					"tags":        []string{"System"},
-					"responses": map[string]interface{}{
-						"200": map[string]interface{}{
-							"description": "Server is healthy",
-							"content": map[string]interface{}{
-								"application/json": map[string]interface{}{
-									"schema": map[string]interface{}{
-										"type": "object",
-										"properties": map[string]interface{}{
-											"status":      map[string]string{"type": "string", "example": "ok"},
-											"routePrefix": map[string]string{"type": "string", "example": "/api"},
-										},
-									},
-								},
-							},
-						},
-					},
+		"paths": paths,
+		"webhooks": map[string]interface{}{
+			"payment.succeeded": webhookDocument("Payment succeeded", "Sent to callbacks.payment_success_url, and to callbacks.destinations subscribed to payment events, after a Stripe or x402 payment settles.", registry.schemaFor(callbacks.PaymentEvent{})),
+			"payment.failed":    webhookDocument("Payment failed", "Sent to callbacks.payment_success_url, and to callbacks.destinations subscribed to payment_failed events, when an x402 payment is rejected or a Stripe payment is declined, fails, or its checkout session expires.", registry.schemaFor(callbacks.PaymentFailedEvent{})),
+			"refund.succeeded":  webhookDocument("Refund succeeded", "Sent to callbacks.payment_success_url, and to callbacks.destinations subscribed to refund events, after an x402 refund is executed.", registry.schemaFor(callbacks.RefundEvent{})),
+		},
+		"components": map[string]interface{}{
+			"schemas": registry.schemas,
+			"securitySchemes": map[string]interface{}{
+				"x402": map[string]interface{}{
+					"type":        "apiKey",
+					"in":          "header",
+					"name":        "X-PAYMENT",
+					"description": "x402 payment proof (base64-encoded JSON)",
+				},
+				"walletSignature": map[string]interface{}{
+					"type":        "apiKey",
+					"in":          "header",
+					"name":        "X-Signature",
+					"description": "Base64 ed25519 signature of X-Message by X-Signer",
+				},
+				"walletMessage": map[string]interface{}{
+					"type":        "apiKey",
+					"in":          "header",
+					"name":        "X-Message",
+					"description": "Signed message (e.g. request-refund:<signature>, approve-refund:<refundId>)",
+				},
+				"walletSigner": map[string]interface{}{
+					"type":        "apiKey",
+					"in":          "header",
+					"name":        "X-Signer",
+					"description": "Base58 public key of the signing wallet",
+				},
+				"adminBearer": map[string]interface{}{
+					"type":        "http",
+					"scheme":      "bearer",
+					"description": "server.admin_metrics_api_key, when configured",
+				},
+				"merchantToken": map[string]interface{}{
+					"type":        "http",
+					"scheme":      "bearer",
+					"description": "merchant_events access key (or ?access_token=)",
 				},
 			},
-			"/.well-known/payment-options": map[string]interface{}{
-				"get": map[string]interface{}{
-					"summary":     "Payment Options Discovery (RFC 8615)",
-					"description": "Web-discoverable endpoint for AI agents to find available paid resources and payment methods",
-					"operationId": "getPaymentOptions",
-					"tags":        []string{"Discovery"},
-					"responses": map[string]interface{}{
-						"200": map[string]interface{}{
-							"description": "Payment configuration and resources",
-						},
-					},
+		},
+		"tags": []map[string]string{
+			{"name": "System", "description": "Health, documentation, and metrics"},
+			{"name": "Discovery", "description": "Agent discovery endpoints"},
+			{"name": "Products", "description": "Product catalog and coupons"},
+			{"name": "Payments", "description": "x402 quotes and verification"},
+			{"name": "Facilitator", "description": "x402 verification and settlement for other resource servers"},
+			{"name": "Stripe", "description": "Stripe checkout and webhooks"},
+			{"name": "Cart", "description": "Multi-item checkout"},
+			{"name": "Refunds", "description": "Refund requests and admin review"},
+			{"name": "Subscriptions", "description": "Recurring payments"},
+			{"name": "Events", "description": "Streaming payment and merchant events"},
+		},
+	}
+}
+
+// document renders the operation as an OpenAPI operation object.
+func (op apiOperation) document(registry *schemaRegistry, errorSchema map[string]any) map[string]any {
+	status := op.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	contentType := op.contentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	responseSchema := registry.schemaFor(op.response)
+	if responseSchema == nil {
+		responseSchema = map[string]any{"type": "object"}
+	}
+	if contentType != "application/json" {
+		responseSchema = map[string]any{"type": "string"}
+	}
+
+	params := make([]map[string]any, 0, len(op.params)+2)
+	for _, p := range op.params {
+		params = append(params, map[string]any{
+			"name":        p.name,
+			"in":          p.in,
+			"description": p.description,
+			"required":    p.required || p.in == "path",
+			"schema":      map[string]any{"type": "string"},
+		})
+	}
+	if op.idempotent {
+		params = append(params, map[string]any{
+			"name":        "Idempotency-Key",
+			"in":          "header",
+			"description": "Unique key for request deduplication (24h window)",
+			"required":    false,
+			"schema":      map[string]any{"type": "string"},
+		})
+	}
+	if op.payment {
+		params = append(params, map[string]any{
+			"name":        "X-PAYMENT",
+			"in":          "header",
+			"description": "x402 payment proof (base64-encoded JSON)",
+			"required":    true,
+			"schema":      map[string]any{"type": "string"},
+		})
+	}
+
+	doc := map[string]any{
+		"operationId": op.id,
+		"summary":     op.summary,
+		"tags":        []string{op.tag},
+		"responses": map[string]any{
+			strconv.Itoa(status): map[string]any{
+				"description": http.StatusText(status),
+				"content": map[string]any{
+					contentType: map[string]any{"schema": responseSchema},
 				},
 			},
-			"/.well-known/agent.json": map[string]interface{}{
-				"get": map[string]interface{}{
-					"summary":     "Agent Card (A2A Protocol)",
-					"description": "Google Agent2Agent protocol agent card for agent discovery",
-					"operationId": "getAgentCard",
-					"tags":        []string{"Discovery"},
-					"responses": map[string]interface{}{
-						"200": map[string]interface{}{
-							"description": "Agent capabilities and configuration",
-						},
-					},
+			"default": map[string]any{
+				"description": "Error",
+				"content": map[string]any{
+					"application/json": map[string]any{"schema": errorSchema},
 				},
 			},
-			"/resources/list": map[string]interface{}{
-				"post": map[string]interface{}{
-					"summary":     "List Resources (MCP)",
-					"description": "Model Context Protocol (MCP) JSON-RPC 2.0 endpoint for resource discovery",
-					"operationId": "listResources",
-					"tags":        []string{"Discovery"},
-					"requestBody": map[string]interface{}{
-						"required": true,
-						"content": map[string]interface{}{
-							"application/json": map[string]interface{}{
-								"schema": map[string]interface{}{
-									"type": "object",
-									"properties": map[string]interface{}{
-										"jsonrpc": map[string]string{"type": "string", "example": "2.0"},
-										"id":      map[string]interface{}{"oneOf": []map[string]string{{"type": "string"}, {"type": "number"}}},
-										"method":  map[string]string{"type": "string", "example": "resources/list"},
-									},
-									"required": []string{"jsonrpc", "id", "method"},
-								},
-							},
-						},
-					},
-					"responses": map[string]interface{}{
-						"200": map[string]interface{}{
-							"description": "JSON-RPC response with resource list",
-						},
-					},
+		},
+	}
+	if op.description != "" {
+		doc["description"] = op.description
+	}
+	if len(params) > 0 {
+		doc["parameters"] = params
+	}
+	if body := registry.schemaFor(op.request); body != nil {
+		doc["requestBody"] = map[string]any{
+			"required": true,
+			"content": map[string]any{
+				"application/json": map[string]any{"schema": body},
+			},
+		}
+	}
+	if op.security != nil {
+		doc["security"] = op.security
+	}
+	return doc
+}
+
+func webhookDocument(summary, description string, schema map[string]any) map[string]any {
+	return map[string]any{
+		"post": map[string]any{
+			"summary":     summary,
+			"description": description,
+			"requestBody": map[string]any{
+				"required": true,
+				"content": map[string]any{
+					"application/json": map[string]any{"schema": schema},
 				},
 			},
-			prefix + "/paywall/v1/products": map[string]interface{}{
-				"get": map[string]interface{}{
-					"summary":     "List Products",
-					"description": "Get all available products with pricing information",
-					"operationId": "listProducts",
-					"tags":        []string{"Products"},
-					"responses": map[string]interface{}{
-						"200": map[string]interface{}{
-							"description": "List of products",
-							"content": map[string]interface{}{
-								"application/json": map[string]interface{}{
-									"schema": map[string]interface{}{
-										"type": "array",
-										"items": map[string]interface{}{
-											"$ref": "#/components/schemas/Product",
-										},
-									},
-								},
-							},
-						},
-					},
+			"responses": map[string]any{
+				"200": map[string]any{"description": "Return any 2xx status to acknowledge delivery"},
+			},
+		},
+	}
+}
+
+// apiOperations lists every route ConfigureRouter registers, including optional ones
+// only when they are enabled.
+func (h *handlers) apiOperations() []apiOperation {
+	prefix := h.cfg.Server.RoutePrefix
+	ops := []apiOperation{
+		// System and discovery (unprefixed)
+		{method: http.MethodGet, path: "/cedros-health", id: "healthCheck", summary: "Health check", description: "Server health and route prefix discovery", tag: "System"},
+		{method: http.MethodGet, path: "/healthz", id: "healthz", summary: "Liveness check", description: "Per-component dependency health; 503 only when a required dependency is down", tag: "System", response: HealthReport{}},
+		{method: http.MethodGet, path: "/readyz", id: "readyz", summary: "Readiness check", description: "Per-component dependency health; 503 unless every dependency is ok", tag: "System", response: HealthReport{}},
+		{method: http.MethodGet, path: "/.well-known/payment-options", id: "getPaymentOptions", summary: "Payment options discovery (RFC 8615)", description: "Web-discoverable listing of paid resources and payment methods for AI agents", tag: "Discovery", response: WellKnownPaymentOptions{}},
+		{method: http.MethodGet, path: "/.well-known/agent.json", id: "getAgentCard", summary: "Agent card (A2A protocol)", tag: "Discovery"},
+		{method: http.MethodGet, path: "/openapi.json", id: "getOpenAPISpec", summary: "OpenAPI document", tag: "System"},
+		{method: http.MethodGet, path: prefix + "/docs", id: "getAPIDocs", summary: "Swagger UI", tag: "System", contentType: "text/html"},
+		{method: http.MethodPost, path: "/resources/list", id: "listResources", summary: "List resources (MCP)", description: "Model Context Protocol JSON-RPC 2.0 resource discovery", tag: "Discovery", request: MCPResourcesListRequest{}, response: MCPResourcesListResponse{}},
+		{method: http.MethodGet, path: prefix + "/metrics", id: "getMetrics", summary: "Prometheus metrics", tag: "System", contentType: "text/plain", security: adminBearerSecurity},
+
+		// Streaming
+		{
+			method: http.MethodGet, path: prefix + "/paywall/v1/payments/{signature}/events", id: "streamPaymentStatus",
+			summary: "Stream payment status", description: "Server-Sent Events with x402 verification progress for a transaction signature",
+			tag: "Events", contentType: "text/event-stream",
+			params: []apiParam{{name: "signature", in: "path", description: "Transaction signature"}},
+		},
+
+		// Stripe
+		{method: http.MethodGet, path: prefix + "/webhook/stripe", id: "getStripeWebhookInfo", summary: "Stripe webhook configuration info", tag: "Stripe"},
+		{
+			method: http.MethodPost, path: prefix + "/webhook/stripe", id: "handleStripeWebhook",
+			summary: "Stripe webhook receiver", description: "Called by Stripe; the body is verified against the Stripe-Signature header",
+			tag:    "Stripe",
+			params: []apiParam{{name: "Stripe-Signature", in: "header", description: "Stripe webhook signature", required: true}},
+		},
+		{method: http.MethodGet, path: prefix + "/stripe/success", id: "stripeSuccess", summary: "Stripe checkout success page", tag: "Stripe", contentType: "text/html", params: []apiParam{{name: "session_id", in: "query", description: "Checkout session ID"}}},
+		{method: http.MethodGet, path: prefix + "/stripe/cancel", id: "stripeCancel", summary: "Stripe checkout cancel page", tag: "Stripe", contentType: "text/html"},
+		{method: http.MethodPost, path: prefix + "/paywall/v1/stripe-session", id: "createStripeSession", summary: "Create Stripe checkout session", description: "Create a Stripe checkout session for a single product", tag: "Stripe", request: createSessionRequest{}, response: createSessionResponse{}, idempotent: true},
+		{method: http.MethodGet, path: prefix + "/paywall/v1/stripe-session/verify", id: "verifyStripeSession", summary: "Verify Stripe session", description: "Verifies a checkout session, a Payment Element's PaymentIntent, or a one-off invoice was paid", tag: "Stripe", params: []apiParam{{name: "session_id", in: "query", description: "Checkout session ID"}, {name: "payment_intent", in: "query", description: "PaymentIntent ID, when session_id is not given"}, {name: "invoice", in: "query", description: "Invoice ID, when neither of the others is given"}}},
+		{method: http.MethodPost, path: prefix + "/paywall/v1/stripe-payment-intent", id: "createStripePaymentIntent", summary: "Create Stripe PaymentIntent", description: "Create a PaymentIntent for a single product, priced with card coupons, whose client secret mounts a Stripe Payment Element", tag: "Stripe", request: createPaymentIntentRequest{}, response: createPaymentIntentResponse{}, idempotent: true},
+		{method: http.MethodPost, path: prefix + "/paywall/v1/stripe-invoice", id: "createStripeInvoice", summary: "Create Stripe invoice", description: "Create, finalize, and email a one-off Stripe invoice for a product or cart quote; paying it grants access like a completed checkout", tag: "Stripe", request: createInvoiceRequest{}, response: createInvoiceResponse{}, idempotent: true},
+
+		// x402 payments
+		{method: http.MethodPost, path: prefix + "/paywall/v1/quote", id: "generateQuote", summary: "Generate x402 quote", description: "Returns payment requirements with 402 Payment Required", tag: "Payments", request: QuoteRequest{}, response: x402QuoteResponse{}, status: http.StatusPaymentRequired},
+		{
+			method: http.MethodPost, path: prefix + "/paywall/v1/verify", id: "verifyPayment",
+			summary:     "Verify x402 payment",
+			description: "Verifies the X-PAYMENT proof for a resource, cart, or refund. With ?async=true or Prefer: respond-async (when async_verification is enabled) returns 202 and a verification to poll.",
+			tag:         "Payments", payment: true,
+			params: []apiParam{{name: "async", in: "query", description: "Set to true to verify asynchronously"}},
+		},
+		{method: http.MethodGet, path: prefix + "/paywall/v1/x402-transaction/verify", id: "verifyX402Transaction", summary: "Check a verified x402 transaction", tag: "Payments", params: []apiParam{{name: "signature", in: "query", description: "Transaction signature", required: true}}},
+		{method: http.MethodPost, path: prefix + "/paywall/v1/gasless-transaction", id: "buildGaslessTransaction", summary: "Build gasless transaction", description: "Builds an unsigned transaction with a server wallet as fee payer", tag: "Payments", request: gaslessTransactionRequest{}, response: x402solana.GaslessTxResponse{}},
+		{
+			method: http.MethodGet, path: prefix + "/paywall/v1/preflight", id: "preflightPayment", summary: "Check a payer before paying",
+			description: "Reports whether the wallet has the token account, token balance, and SOL (unless gasless applies) to pay for a resource or cart",
+			tag:         "Payments", response: preflightResponse{},
+			params: []apiParam{
+				{name: "wallet", in: "query", description: "Payer wallet address", required: true},
+				{name: "resource", in: "query", description: "Resource or cart ID", required: true},
+				{name: "couponCode", in: "query", description: "Coupon code to price the resource with"},
+			},
+		},
+
+		// Cart
+		{method: http.MethodPost, path: prefix + "/paywall/v1/cart/checkout", id: "createCartCheckout", summary: "Create Stripe cart checkout", tag: "Cart", request: createCartCheckoutRequest{}, response: createCartCheckoutResponse{}, idempotent: true},
+		{method: http.MethodPost, path: prefix + "/paywall/v1/cart/quote", id: "requestCartQuote", summary: "Generate x402 cart quote", tag: "Cart", request: paywall.CartQuoteRequest{}, response: paywall.CartQuoteResponse{}, idempotent: true},
+		{
+			method: http.MethodPatch, path: prefix + "/paywall/v1/cart/{cartId}", id: "updateCartQuote",
+			summary: "Update cart items", description: "Adds, removes, or changes the quantities of items in an unpaid cart, re-pricing it and re-locking the total under the same cart ID", tag: "Cart", request: paywall.CartUpdateRequest{}, response: paywall.CartQuoteResponse{},
+			params: []apiParam{{name: "cartId", in: "path", description: "Cart ID"}},
+		},
+		{
+			method: http.MethodGet, path: prefix + "/paywall/v1/cart/{cartId}/payments", id: "getCartPayments",
+			summary: "Get cart payments", description: "Lists the payments made toward a cart and the amount still owed; split-payment carts are granted once their payments cover the total", tag: "Cart", response: paywall.CartPaymentsResponse{},
+			params: []apiParam{{name: "cartId", in: "path", description: "Cart ID"}},
+		},
+		{
+			method: http.MethodPost, path: prefix + "/paywall/v1/cart/{cartId}/checkout", id: "createCartQuoteCheckout",
+			summary: "Pay a cart quote by card", description: "Creates a Stripe checkout session for an unpaid cart at its locked prices, shipping, and tax; the completion webhook marks the cart paid", tag: "Cart", request: cartQuoteCheckoutRequest{}, response: cartQuoteCheckoutResponse{}, idempotent: true,
+			params: []apiParam{{name: "cartId", in: "path", description: "Cart ID"}},
+		},
+		{method: http.MethodGet, path: prefix + "/paywall/v1/saved-carts", id: "listSavedCarts", summary: "List saved carts", description: "Signed by the wallet (message saved-carts:<wallet>)", tag: "Cart", response: savedCartsResponse{}, security: walletSignatureSecurity},
+		{
+			method: http.MethodPut, path: prefix + "/paywall/v1/saved-carts/{name}", id: "saveSavedCart",
+			summary: "Save cart", description: "Creates or replaces a named cart (e.g. a wishlist) for the signing wallet; signed by the wallet (message saved-carts:<wallet>)", tag: "Cart", request: paywall.SavedCartRequest{}, response: storage.SavedCart{}, security: walletSignatureSecurity,
+			params: []apiParam{{name: "name", in: "path", description: "Saved cart name"}},
+		},
+		{
+			method: http.MethodGet, path: prefix + "/paywall/v1/saved-carts/{name}", id: "getSavedCart",
+			summary: "Load saved cart", description: "Signed by the wallet (message saved-carts:<wallet>)", tag: "Cart", response: storage.SavedCart{}, security: walletSignatureSecurity,
+			params: []apiParam{{name: "name", in: "path", description: "Saved cart name"}},
+		},
+		{
+			method: http.MethodDelete, path: prefix + "/paywall/v1/saved-carts/{name}", id: "deleteSavedCart",
+			summary: "Delete saved cart", description: "Signed by the wallet (message saved-carts:<wallet>)", tag: "Cart", status: http.StatusNoContent, security: walletSignatureSecurity,
+			params: []apiParam{{name: "name", in: "path", description: "Saved cart name"}},
+		},
+		{
+			method: http.MethodPost, path: prefix + "/paywall/v1/saved-carts/{name}/quote", id: "quoteSavedCart",
+			summary: "Quote saved cart", description: "Converts a saved cart into a live x402 cart quote at current prices, keeping the saved cart; signed by the wallet (message saved-carts:<wallet>)", tag: "Cart", response: paywall.CartQuoteResponse{}, idempotent: true, security: walletSignatureSecurity,
+			params: []apiParam{{name: "name", in: "path", description: "Saved cart name"}},
+		},
+
+		// Refunds
+		{method: http.MethodPost, path: prefix + "/paywall/v1/refunds/request", id: "requestRefund", summary: "Request a refund", description: "Signed by the paying wallet (message request-refund:<originalPurchaseId>) or the payTo wallet", tag: "Refunds", request: requestRefundRequest{}, idempotent: true, security: walletSignatureSecurity},
+		{method: http.MethodPost, path: prefix + "/paywall/v1/refunds/approve", id: "approveRefund", summary: "Approve refund and get x402 quote", description: "Admin only: signed by the payTo wallet. With approvedAmount (less than requested) the signed message is approve-refund:<refundId>:<approvedAmount>", tag: "Refunds", request: getRefundQuoteRequest{}, response: paywall.RefundQuoteResponse{}, security: walletSignatureSecurity},
+		{method: http.MethodPost, path: prefix + "/paywall/v1/refunds/deny", id: "denyRefund", summary: "Deny refund", description: "Admin only: signed by the payTo wallet", tag: "Refunds", request: denyRefundRequest{}, security: walletSignatureSecurity},
+		{method: http.MethodPost, path: prefix + "/paywall/v1/refunds/pending", id: "listPendingRefunds", summary: "List pending refunds", description: "Admin only: signed by the payTo wallet over a one-time nonce", tag: "Refunds", security: walletSignatureSecurity},
+		{method: http.MethodPost, path: prefix + "/paywall/v1/refunds/notes", id: "addRefundNote", summary: "Add a note to a refund request", description: "Signed by the refund's recipient wallet or a refund admin (message refund-note:<refundId>:<message>); returns the request's thread and status history", tag: "Refunds", request: addRefundNoteRequest{}, response: refundHistoryResponse{}, security: walletSignatureSecurity},
+		{method: http.MethodPost, path: prefix + "/paywall/v1/nonce", id: "generateNonce", summary: "Generate admin nonce", description: "One-time nonce for replay-protected admin requests", tag: "Refunds", request: generateNonceRequest{}},
+
+		// Products and coupons
+		{method: http.MethodGet, path: prefix + "/paywall/v1/products", id: "listProducts", summary: "List products", description: "All active products with pricing and auto-apply coupons", tag: "Products", response: ProductsListResponse{}},
+		{method: http.MethodPost, path: prefix + "/paywall/v1/coupons/validate", id: "validateCoupon", summary: "Validate coupon", tag: "Products", request: ValidateCouponRequest{}, response: ValidateCouponResponse{}},
+		{method: http.MethodGet, path: prefix + "/paywall/v1/gift-cards/{code}", id: "getGiftCardBalance", summary: "Gift card balance", description: "Remaining balance of a gift card coupon (USD)", tag: "Products", response: giftCardBalanceResponse{}},
+		{method: http.MethodGet, path: prefix + "/paywall/v1/referrals", id: "getReferralDashboard", summary: "Referral dashboard", description: "Conversions and owed rewards of the referral codes crediting the signing wallet; signed by the wallet (message referrals:<wallet>)", tag: "Products", response: paywall.ReferralDashboard{}, security: walletSignatureSecurity},
+
+		// Subscriptions
+		{
+			method: http.MethodGet, path: prefix + "/paywall/v1/subscription/status", id: "getSubscriptionStatus", summary: "Subscription status", tag: "Subscriptions", response: subscriptionStatusResponse{},
+			params: []apiParam{
+				{name: "resource", in: "query", description: "Plan/resource ID", required: true},
+				{name: "userId", in: "query", description: "Wallet address or Stripe customer ID", required: true},
+			},
+		},
+		{method: http.MethodPost, path: prefix + "/paywall/v1/subscription/stripe-session", id: "createStripeSubscription", summary: "Create Stripe subscription checkout", tag: "Subscriptions", request: createStripeSubscriptionRequest{}, response: createStripeSubscriptionResponse{}, idempotent: true},
+		{method: http.MethodPost, path: prefix + "/paywall/v1/subscription/quote", id: "getSubscriptionQuote", summary: "Generate x402 subscription quote", tag: "Subscriptions", request: subscriptionQuoteRequest{}, response: subscriptionQuoteResponse{}, status: http.StatusPaymentRequired, idempotent: true},
+		{method: http.MethodPost, path: prefix + "/paywall/v1/subscription/cancel", id: "cancelSubscription", summary: "Cancel subscription", tag: "Subscriptions", request: cancelSubscriptionRequest{}},
+		{method: http.MethodPost, path: prefix + "/paywall/v1/subscription/portal", id: "getBillingPortal", summary: "Stripe billing portal link", tag: "Subscriptions", request: getBillingPortalRequest{}},
+		{method: http.MethodPost, path: prefix + "/paywall/v1/subscription/x402/activate", id: "activateX402Subscription", summary: "Activate x402 subscription", tag: "Subscriptions", request: createX402SubscriptionRequest{}, response: createX402SubscriptionResponse{}, idempotent: true},
+		{method: http.MethodPost, path: prefix + "/paywall/v1/subscription/change", id: "changeSubscription", summary: "Upgrade or downgrade subscription", description: "x402 subscriptions are prorated; when the change costs more than the unused time on the current plan, returns 402 with a cart quote that applies the change once paid", tag: "Subscriptions", request: changeSubscriptionRequest{}, response: changeSubscriptionResponse{}, idempotent: true},
+		{method: http.MethodPost, path: prefix + "/paywall/v1/subscription/change/preview", id: "previewSubscriptionChange", summary: "Preview subscription plan change", description: "Shows the proration charge or credit of a Stripe plan change from the upcoming invoice, without applying it", tag: "Subscriptions", request: previewSubscriptionChangeRequest{}, response: previewSubscriptionChangeResponse{}},
+		{method: http.MethodPost, path: prefix + "/paywall/v1/subscription/seats", id: "updateSubscriptionSeats", summary: "Change subscription seats", description: "Changes the seat count of a per-seat Stripe subscription mid-period, prorating the difference", tag: "Subscriptions", request: updateSubscriptionSeatsRequest{}, response: updateSubscriptionSeatsResponse{}, idempotent: true},
+		{method: http.MethodPost, path: prefix + "/paywall/v1/subscription/reactivate", id: "reactivateSubscription", summary: "Reactivate subscription", tag: "Subscriptions", request: reactivateSubscriptionRequest{}},
+		{method: http.MethodPost, path: prefix + "/paywall/v1/subscription/pause", id: "pauseSubscription", summary: "Pause subscription", description: "Stops access and, for Stripe, pauses payment collection until resumed", tag: "Subscriptions", request: pauseSubscriptionRequest{}},
+		{method: http.MethodPost, path: prefix + "/paywall/v1/subscription/resume", id: "resumeSubscription", summary: "Resume paused subscription", tag: "Subscriptions", request: resumeSubscriptionRequest{}},
+	}
+
+	if h.cfg.MerchantEvents.Enabled && h.eventBus != nil {
+		ops = append(ops, apiOperation{
+			method: http.MethodGet, path: prefix + "/paywall/v1/merchant/events", id: "merchantEvents",
+			summary: "Merchant events WebSocket", description: "Upgrade to a WebSocket streaming live payment, refund, and webhook events",
+			tag: "Events", status: http.StatusSwitchingProtocols, security: merchantTokenSecurity,
+			params: []apiParam{{name: "types", in: "query", description: "Comma-separated event types to receive"}},
+		})
+	}
+	if h.graphqlSchema != nil {
+		ops = append(ops,
+			apiOperation{method: http.MethodGet, path: prefix + "/paywall/v1/graphql", id: "graphqlQuery", summary: "GraphQL query (GET)", tag: "Products", response: graphQLResponse{}, params: []apiParam{{name: "query", in: "query", description: "GraphQL query document", required: true}, {name: "variables", in: "query", description: "JSON-encoded variables"}, {name: "operationName", in: "query", description: "Operation to execute"}}},
+			apiOperation{method: http.MethodPost, path: prefix + "/paywall/v1/graphql", id: "graphqlQueryPost", summary: "GraphQL query", tag: "Products", request: graphQLRequest{}, response: graphQLResponse{}},
+		)
+	}
+	if h.cfg.AsyncVerify.Enabled && h.verifications != nil {
+		ops = append(ops, apiOperation{
+			method: http.MethodGet, path: prefix + "/paywall/v1/verifications/{id}", id: "getVerification",
+			summary: "Async verification status", tag: "Payments", response: verification.Job{},
+			params: []apiParam{{name: "id", in: "path", description: "Verification ID"}},
+		})
+	}
+	if h.cfg.X402.Facilitator {
+		ops = append(ops,
+			apiOperation{method: http.MethodGet, path: prefix + "/facilitator/supported", id: "facilitatorSupported", summary: "Facilitator payment kinds", tag: "Facilitator", response: facilitatorSupportedResponse{}},
+			apiOperation{method: http.MethodPost, path: prefix + "/facilitator/verify", id: "facilitatorVerify", summary: "Verify a payment for another resource server", description: "Checks and simulates an x402 payment against the quoted requirements without submitting it", tag: "Facilitator", request: paywall.FacilitatorRequest{}, response: paywall.FacilitatorVerifyResponse{}},
+			apiOperation{method: http.MethodPost, path: prefix + "/facilitator/settle", id: "facilitatorSettle", summary: "Settle a payment for another resource server", description: "Submits an x402 payment, waits for confirmation, and returns the transaction", tag: "Facilitator", request: paywall.FacilitatorRequest{}, response: paywall.FacilitatorSettleResponse{}},
+		)
+	}
+	if h.cfg.Metering.Enabled {
+		apiKeyParam := apiParam{name: "X-API-Key", in: "header", description: "API key whose meter account to use instead of a wallet's"}
+		ops = append(ops,
+			apiOperation{
+				method: http.MethodPost, path: prefix + "/paywall/v1/metering/deposit", id: "depositMetering",
+				summary:     "Top up a meter account",
+				description: "Pays a metering deposit resource with X-PAYMENT and credits the amount paid to the X-API-Key's meter account, or else the paying wallet's. Without X-PAYMENT returns 402 with the deposit's quote.",
+				tag:         "Payments", payment: true, response: meterAccountResponse{},
+				params: []apiParam{
+					{name: "resource", in: "query", description: "Deposit resource (metering.deposit_resources)", required: true},
+					{name: "couponCode", in: "query", description: "Coupon to apply to the deposit"},
+					apiKeyParam,
 				},
 			},
-			prefix + "/paywall/v1/stripe-session": map[string]interface{}{
-				"post": map[string]interface{}{
-					"summary":     "Create Stripe Checkout Session",
-					"description": "Create a Stripe checkout session for a single product",
-					"operationId": "createStripeSession",
-					"tags":        []string{"Stripe"},
-					"parameters": []map[string]interface{}{
-						{
-							"name":        "Idempotency-Key",
-							"in":          "header",
-							"description": "Unique key for request deduplication (24h window)",
-							"required":    false,
-							"schema":      map[string]string{"type": "string"},
-						},
-					},
-					"requestBody": map[string]interface{}{
-						"required": true,
-						"content": map[string]interface{}{
-							"application/json": map[string]interface{}{
-								"schema": map[string]interface{}{
-									"type": "object",
-									"properties": map[string]interface{}{
-										"resource":      map[string]string{"type": "string", "description": "Product ID"},
-										"customerEmail": map[string]string{"type": "string", "format": "email"},
-										"couponCode":    map[string]string{"type": "string"},
-									},
-									"required": []string{"resource"},
-								},
-							},
-						},
-					},
-					"responses": map[string]interface{}{
-						"200": map[string]interface{}{
-							"description": "Checkout session created",
-							"content": map[string]interface{}{
-								"application/json": map[string]interface{}{
-									"schema": map[string]interface{}{
-										"type": "object",
-										"properties": map[string]interface{}{
-											"sessionId": map[string]string{"type": "string"},
-											"url":       map[string]string{"type": "string", "format": "uri"},
-										},
-									},
-								},
-							},
-						},
-					},
+			apiOperation{
+				method: http.MethodGet, path: prefix + "/paywall/v1/metering/balance", id: "getMeterBalance",
+				summary:     "Meter account balance",
+				description: "Balance and unsettled usage of the X-API-Key's meter account, or of the wallet signing metering:<wallet>:<nonce>",
+				tag:         "Payments", response: meterAccountResponse{}, security: walletSignatureSecurity,
+				params: []apiParam{apiKeyParam},
+			},
+		)
+	}
+	if h.cfg.Metering.Enabled && h.cfg.Server.AdminMetricsAPIKey != "" {
+		ops = append(ops, apiOperation{
+			method: http.MethodPost, path: prefix + "/admin/metering/charge", id: "adminChargeMetering",
+			summary:     "Charge a metered call",
+			description: "Charges one call to a metered resource against a wallet's or API key's meter account, for services that meter their own endpoints. Returns 402 meter_balance_insufficient when the balance is too low.",
+			tag:         "Payments", request: meterChargeRequest{}, response: meterAccountResponse{}, security: adminBearerRequired,
+		})
+	}
+	if h.cfg.Server.AdminMetricsAPIKey != "" {
+		pprofDocs := "Go runtime profiling (net/http/pprof); inspect with `go tool pprof`"
+		ops = append(ops,
+			apiOperation{method: http.MethodGet, path: prefix + "/admin/wallets", id: "listServerWallets", summary: "List server wallets", description: "Active, draining, and retired server wallets", tag: "System", response: serverWalletsResponse{}, security: adminBearerRequired},
+			apiOperation{method: http.MethodPost, path: prefix + "/admin/wallets", id: "addServerWallet", summary: "Add server wallet", description: "Registers a server wallet without a restart", tag: "System", request: addServerWalletRequest{}, response: x402solana.WalletStatus{}, status: http.StatusCreated, security: adminBearerRequired},
+			apiOperation{
+				method: http.MethodPost, path: prefix + "/admin/wallets/{address}/retire", id: "retireServerWallet",
+				summary: "Retire server wallet", description: "Stops selecting the wallet and drains in-flight gasless transactions", tag: "System", response: x402solana.WalletStatus{}, status: http.StatusAccepted, security: adminBearerRequired,
+				params: []apiParam{{name: "address", in: "path", description: "Server wallet public key"}},
+			},
+			apiOperation{method: http.MethodPost, path: prefix + "/admin/settlements", id: "ingestSettlements", summary: "Ingest settlements", description: "Records a batch of externally verified payments, all or none, granting their wallets access", tag: "System", request: ingestSettlementsRequest{}, response: ingestSettlementsResponse{}, status: http.StatusCreated, security: adminBearerRequired},
+			apiOperation{
+				method: http.MethodGet, path: prefix + "/admin/inventory/{resource}", id: "getStock",
+				summary: "Get stock level", description: "Units on hand, held by unpaid carts, and still available", tag: "System", response: stockResponse{}, security: adminBearerRequired,
+				params: []apiParam{{name: "resource", in: "path", description: "Resource ID"}},
+			},
+			apiOperation{
+				method: http.MethodPut, path: prefix + "/admin/inventory/{resource}", id: "setStock",
+				summary: "Set stock level", description: "Sets the units on hand, starting to track stock for the resource if needed", tag: "System", request: setStockRequest{}, response: stockResponse{}, security: adminBearerRequired,
+				params: []apiParam{{name: "resource", in: "path", description: "Resource ID"}},
+			},
+			apiOperation{
+				method: http.MethodDelete, path: prefix + "/admin/inventory/{resource}", id: "deleteStock",
+				summary: "Stop tracking stock", description: "The resource can be sold without limit again", tag: "System", status: http.StatusNoContent, security: adminBearerRequired,
+				params: []apiParam{{name: "resource", in: "path", description: "Resource ID"}},
+			},
+			apiOperation{method: http.MethodGet, path: prefix + "/admin/products", id: "adminListProducts", summary: "List catalog products", description: "Active products with their stored prices", tag: "Products", response: adminProductsResponse{}, security: adminBearerRequired},
+			apiOperation{method: http.MethodPost, path: prefix + "/admin/products", id: "adminCreateProduct", summary: "Create product", description: "Adds a product to a postgres or mongodb catalog after validating its prices and token", tag: "Products", request: paywall.ProductRequest{}, response: adminProductResponse{}, status: http.StatusCreated, security: adminBearerRequired},
+			apiOperation{method: http.MethodPost, path: prefix + "/admin/products/cache/invalidate", id: "invalidateProductCache", summary: "Invalidate product cache", description: "Drops cached products so direct database edits are visible immediately", tag: "Products", response: productCacheResponse{}, security: adminBearerRequired},
+			apiOperation{
+				method: http.MethodGet, path: prefix + "/admin/products/{id}", id: "adminGetProduct",
+				summary: "Get catalog product", tag: "Products", response: adminProductResponse{}, security: adminBearerRequired,
+				params: []apiParam{{name: "id", in: "path", description: "Product ID"}},
+			},
+			apiOperation{
+				method: http.MethodPut, path: prefix + "/admin/products/{id}", id: "adminUpdateProduct",
+				summary: "Update product", description: "Replaces the product's definition; restores an archived product", tag: "Products", request: paywall.ProductRequest{}, response: adminProductResponse{}, security: adminBearerRequired,
+				params: []apiParam{{name: "id", in: "path", description: "Product ID"}},
+			},
+			apiOperation{
+				method: http.MethodDelete, path: prefix + "/admin/products/{id}", id: "adminArchiveProduct",
+				summary: "Archive product", description: "Takes the product off sale; restore it with PUT", tag: "Products", status: http.StatusNoContent, security: adminBearerRequired,
+				params: []apiParam{{name: "id", in: "path", description: "Product ID"}},
+			},
+			apiOperation{method: http.MethodGet, path: prefix + "/admin/coupons", id: "adminListCoupons", summary: "List coupons", description: "Active coupons with their usage counts", tag: "Products", response: adminCouponsResponse{}, security: adminBearerRequired},
+			apiOperation{method: http.MethodPost, path: prefix + "/admin/coupons", id: "adminCreateCoupon", summary: "Create coupon", description: "Adds a coupon to a postgres or mongodb coupon source after validating its date window and scope", tag: "Products", request: paywall.CouponRequest{}, response: adminCouponResponse{}, status: http.StatusCreated, security: adminBearerRequired},
+			apiOperation{
+				method: http.MethodGet, path: prefix + "/admin/coupons/analytics", id: "adminCouponAnalytics",
+				summary: "Coupon analytics", description: "Per-coupon redemptions, original versus discounted totals per currency, and cart conversion rates, in total and per day or week", tag: "Products", response: paywall.CouponAnalytics{}, security: adminBearerRequired,
+				params: []apiParam{
+					{name: "since", in: "query", description: "Start of the window, RFC 3339 or YYYY-MM-DD (default 30 days before until)"},
+					{name: "until", in: "query", description: "End of the window, RFC 3339 or YYYY-MM-DD (default now)"},
+					{name: "interval", in: "query", description: "Period length: day (default) or week"},
+					{name: "code", in: "query", description: "Report on a single coupon"},
 				},
 			},
-			prefix + "/paywall/{resourceId}": map[string]interface{}{
-				"get": map[string]interface{}{
-					"summary":     "Access Paywalled Resource",
-					"description": "Get paywalled content with x402 payment or Stripe session verification",
-					"operationId": "getPaywalledResource",
-					"tags":        []string{"Paywall"},
-					"parameters": []map[string]interface{}{
-						{
-							"name":        "resourceId",
-							"in":          "path",
-							"description": "Resource identifier",
-							"required":    true,
-							"schema":      map[string]string{"type": "string"},
-						},
-						{
-							"name":        "X-PAYMENT",
-							"in":          "header",
-							"description": "x402 payment proof (base64-encoded JSON)",
-							"required":    false,
-							"schema":      map[string]string{"type": "string"},
-						},
-						{
-							"name":        "X-Stripe-Session",
-							"in":          "header",
-							"description": "Stripe checkout session ID",
-							"required":    false,
-							"schema":      map[string]string{"type": "string"},
-						},
-					},
-					"responses": map[string]interface{}{
-						"200": map[string]interface{}{
-							"description": "Payment verified, resource delivered",
-						},
-						"402": map[string]interface{}{
-							"description": "Payment required (x402 quote)",
-							"content": map[string]interface{}{
-								"application/json": map[string]interface{}{
-									"schema": map[string]interface{}{
-										"$ref": "#/components/schemas/X402Quote",
-									},
-								},
-							},
-						},
-					},
+			apiOperation{
+				method: http.MethodGet, path: prefix + "/admin/coupons/{code}", id: "adminGetCoupon",
+				summary: "Get coupon", tag: "Products", response: adminCouponResponse{}, security: adminBearerRequired,
+				params: []apiParam{{name: "code", in: "path", description: "Coupon code"}},
+			},
+			apiOperation{
+				method: http.MethodPut, path: prefix + "/admin/coupons/{code}", id: "adminUpdateCoupon",
+				summary: "Update coupon", description: "Replaces the coupon's definition, keeping its usage count; reactivates a deactivated coupon", tag: "Products", request: paywall.CouponRequest{}, response: adminCouponResponse{}, security: adminBearerRequired,
+				params: []apiParam{{name: "code", in: "path", description: "Coupon code"}},
+			},
+			apiOperation{
+				method: http.MethodDelete, path: prefix + "/admin/coupons/{code}", id: "adminDeactivateCoupon",
+				summary: "Deactivate coupon", description: "Stops the coupon from being redeemed; reactivate it with PUT", tag: "Products", status: http.StatusNoContent, security: adminBearerRequired,
+				params: []apiParam{{name: "code", in: "path", description: "Coupon code"}},
+			},
+			apiOperation{
+				method: http.MethodGet, path: prefix + "/admin/customers", id: "adminFindCustomer",
+				summary: "Find customer", description: "The customer an email, wallet, or Stripe customer ID is linked to, with their entitlements, subscriptions, and recent payments across payment rails", tag: "Payments", response: paywall.CustomerProfile{}, security: adminBearerRequired,
+				params: []apiParam{
+					{name: "email", in: "query", description: "Email a card payment was made with"},
+					{name: "wallet", in: "query", description: "Wallet a crypto payment was made from"},
+					{name: "stripeCustomerId", in: "query", description: "Stripe customer ID (cus_...)"},
 				},
 			},
-		},
-		"components": map[string]interface{}{
-			"schemas": map[string]interface{}{
-				"Product": map[string]interface{}{
-					"type": "object",
-					"properties": map[string]interface{}{
-						"id":            map[string]string{"type": "string"},
-						"description":   map[string]string{"type": "string"},
-						"fiatAmount":    map[string]string{"type": "number", "format": "double"},
-						"fiatCurrency":  map[string]string{"type": "string"},
-						"stripePriceId": map[string]string{"type": "string"},
-						"cryptoAmount":  map[string]string{"type": "number", "format": "double"},
-						"cryptoToken":   map[string]string{"type": "string"},
-						"metadata":      map[string]string{"type": "object"},
-					},
+			apiOperation{method: http.MethodPost, path: prefix + "/admin/customers/link", id: "adminLinkCustomer", summary: "Link customer identities", description: "Records that emails, wallets, and Stripe customer IDs belong to one customer, merging the customers they were linked to", tag: "Payments", request: linkCustomerRequest{}, response: paywall.CustomerProfile{}, security: adminBearerRequired},
+			apiOperation{
+				method: http.MethodGet, path: prefix + "/admin/customers/{id}", id: "adminGetCustomer",
+				summary: "Get customer", tag: "Payments", response: paywall.CustomerProfile{}, security: adminBearerRequired,
+				params: []apiParam{{name: "id", in: "path", description: "Customer ID (cust_...)"}},
+			},
+			apiOperation{
+				method: http.MethodGet, path: prefix + "/admin/refunds/{id}/audit", id: "adminRefundAudit",
+				summary: "Refund request audit trail", description: "Denials, auto-denials, and escalations of a refund request, oldest first", tag: "Refunds", response: refundAuditResponse{}, security: adminBearerRequired,
+				params: []apiParam{{name: "id", in: "path", description: "Refund ID"}},
+			},
+			apiOperation{method: http.MethodGet, path: prefix + "/admin/access-lists", id: "adminListAccessRules", summary: "List access lists", description: "Wallets and IP ranges on the allow and deny lists", tag: "System", response: accessRulesResponse{}, security: adminBearerRequired},
+			apiOperation{method: http.MethodPost, path: prefix + "/admin/access-lists", id: "adminSaveAccessRule", summary: "Add access rule", description: "Puts a wallet or IP range on the allow or deny list, replacing its existing rule. Denied wallets and IPs can't get quotes or pay; allowed ones skip the deny list and velocity rules", tag: "System", request: accessRuleRequest{}, response: storage.AccessRule{}, security: adminBearerRequired},
+			apiOperation{
+				method: http.MethodDelete, path: prefix + "/admin/access-lists", id: "adminDeleteAccessRule",
+				summary: "Remove access rule", description: "Takes a wallet or IP range off its list", tag: "System", status: http.StatusNoContent, security: adminBearerRequired,
+				params: []apiParam{
+					{name: "kind", in: "query", description: "wallet or ip", required: true},
+					{name: "value", in: "query", description: "Wallet address, IP address, or CIDR range", required: true},
 				},
-				"X402Quote": map[string]interface{}{
-					"type": "object",
-					"properties": map[string]interface{}{
-						"x402Version": map[string]string{"type": "integer", "example": "0"},
-						"accepts": map[string]interface{}{
-							"type": "array",
-							"items": map[string]interface{}{
-								"type": "object",
-								"properties": map[string]interface{}{
-									"scheme":            map[string]string{"type": "string"},
-									"network":           map[string]string{"type": "string"},
-									"maxAmountRequired": map[string]string{"type": "string"},
-									"resource":          map[string]string{"type": "string"},
-									"payTo":             map[string]string{"type": "string"},
-									"maxTimeoutSeconds": map[string]string{"type": "integer"},
-									"asset":             map[string]string{"type": "string"},
-								},
-							},
-						},
-					},
+			},
+			apiOperation{
+				method: http.MethodPost, path: prefix + "/admin/webhooks/{id}/retry", id: "adminRetryWebhook",
+				summary: "Retry webhook", description: "Resets a failed webhook to pending so it is delivered again", tag: "System", response: webhookRetryResponse{}, security: adminBearerRequired,
+				params: []apiParam{{name: "id", in: "path", description: "Webhook ID"}},
+			},
+			apiOperation{
+				method: http.MethodGet, path: prefix + "/admin/audit", id: "adminAuditLog",
+				summary: "Admin audit log", description: "Append-only record of admin actions (refund approvals and denials, nonce use, webhook retries, admin API writes), newest first", tag: "System", response: adminAuditResponse{}, security: adminBearerRequired,
+				params: []apiParam{
+					{name: "action", in: "query", description: "refund.approve, refund.deny, nonce.consume, webhook.retry, or config.change"},
+					{name: "signer", in: "query", description: "Signing wallet, or admin-api-key"},
+					{name: "since", in: "query", description: "Start of the window, RFC 3339 or YYYY-MM-DD"},
+					{name: "until", in: "query", description: "End of the window, RFC 3339 or YYYY-MM-DD"},
+					{name: "limit", in: "query", description: "Maximum entries (default 100, max 1000)"},
 				},
 			},
-			"securitySchemes": map[string]interface{}{
-				"x402": map[string]interface{}{
-					"type":        "apiKey",
-					"in":          "header",
-					"name":        "X-PAYMENT",
-					"description": "x402 payment proof (base64-encoded JSON)",
+			apiOperation{
+				method: http.MethodGet, path: prefix + "/admin/webhooks/deliveries", id: "adminWebhookDeliveries",
+				summary: "Webhook delivery log", description: "Every attempt to deliver a webhook (destination, status code, latency, first 1 KB of the response), newest first", tag: "System", response: webhookDeliveriesResponse{}, security: adminBearerRequired,
+				params: []apiParam{
+					{name: "url", in: "query", description: "Destination URL or SQS/SNS ARN"},
+					{name: "eventType", in: "query", description: "payment, payment_failed, refund, subscription, or refund_request"},
+					{name: "eventId", in: "query", description: "Event idempotency key (evt_...)"},
+					{name: "webhookId", in: "query", description: "Queued webhook ID"},
+					{name: "status", in: "query", description: "success or failed"},
+					{name: "since", in: "query", description: "Start of the window, RFC 3339 or YYYY-MM-DD"},
+					{name: "until", in: "query", description: "End of the window, RFC 3339 or YYYY-MM-DD"},
+					{name: "limit", in: "query", description: "Maximum deliveries (default 100, max 1000)"},
 				},
-				"stripeSession": map[string]interface{}{
-					"type":        "apiKey",
-					"in":          "header",
-					"name":        "X-Stripe-Session",
-					"description": "Stripe checkout session ID",
+			},
+			apiOperation{method: http.MethodGet, path: prefix + "/admin/webhooks/dlq/segments", id: "adminDLQSegments", summary: "List DLQ segments", description: "Dead letter queue segments rotated out of dlq_path into the local archive or the S3/GCS offload bucket, oldest first", tag: "System", response: adminDLQSegmentsResponse{}, security: adminBearerRequired},
+			apiOperation{
+				method: http.MethodPost, path: prefix + "/admin/webhooks/dlq/segments/{segment}/redrive", id: "adminRedriveDLQSegment",
+				summary: "Re-drive DLQ segment", description: "Delivers each webhook in a rotated DLQ segment once; delivered entries leave the segment, which is deleted once empty", tag: "System", response: callbacks.RedriveResult{}, security: adminBearerRequired,
+				params: []apiParam{{name: "segment", in: "path", description: "Segment name (dlq-<timestamp>.json)"}},
+			},
+			apiOperation{
+				method: http.MethodGet, path: prefix + "/admin/rate-limits", id: "adminRateLimits",
+				summary: "Rate limiter state", description: "How much of each enabled rate limit (global, per-endpoint, per-wallet, per-IP) a client has used in the current sliding window", tag: "System", response: adminRateLimitsResponse{}, security: adminBearerRequired,
+				params: []apiParam{
+					{name: "wallet", in: "query", description: "Wallet address, as sent in X-Wallet or ?wallet="},
+					{name: "ip", in: "query", description: "Client IP address; wallet or ip is required"},
 				},
 			},
-		},
-		"tags": []map[string]string{
-			{"name": "System", "description": "System endpoints"},
-			{"name": "Discovery", "description": "Agent discovery endpoints"},
-			{"name": "Products", "description": "Product catalog"},
-			{"name": "Stripe", "description": "Stripe payment endpoints"},
-			{"name": "Paywall", "description": "Protected resource access"},
-		},
+			apiOperation{method: http.MethodGet, path: prefix + "/admin/circuit-breakers", id: "adminListCircuitBreakers", summary: "List circuit breakers", description: "State, failure counts, trips, and last trip time of each Solana RPC endpoint's circuit breaker", tag: "System", response: adminCircuitBreakersResponse{}, security: adminBearerRequired},
+			apiOperation{
+				method: http.MethodPost, path: prefix + "/admin/circuit-breakers/{name}/reset", id: "adminResetCircuitBreaker",
+				summary: "Reset circuit breaker", description: "Closes the breaker and clears its counts. Recorded in the admin audit log as config.change", tag: "System", response: circuitbreaker.Status{}, security: adminBearerRequired,
+				params: []apiParam{{name: "name", in: "path", description: "Breaker name, e.g. solana_rpc:api.mainnet-beta.solana.com"}},
+			},
+			apiOperation{method: http.MethodGet, path: prefix + "/paywall/v1/admin/summary", id: "adminSummary", summary: "Admin dashboard summary", description: "Payments today and this week per asset, pending refund backlog, webhook DLQ size, server wallet SOL balances, and RPC circuit breaker states", tag: "System", response: adminSummaryResponse{}, security: adminBearerRequired},
+			apiOperation{method: http.MethodGet, path: prefix + "/debug/runtime", id: "getRuntimeStats", summary: "Runtime statistics", description: "Goroutines, heap, GC, storage connection pool, and transaction queue depth", tag: "System", response: RuntimeStats{}, security: adminBearerRequired},
+			apiOperation{method: http.MethodGet, path: prefix + "/debug/pprof/", id: "getPprofIndex", summary: "Profile index", description: pprofDocs, tag: "System", contentType: "text/html", security: adminBearerRequired},
+			apiOperation{method: http.MethodGet, path: prefix + "/debug/pprof/cmdline", id: "getPprofCmdline", summary: "Process command line", tag: "System", contentType: "text/plain", security: adminBearerRequired},
+			apiOperation{method: http.MethodGet, path: prefix + "/debug/pprof/profile", id: "getPprofCPUProfile", summary: "CPU profile", description: pprofDocs, tag: "System", contentType: "application/octet-stream", security: adminBearerRequired, params: []apiParam{{name: "seconds", in: "query", description: "Sampling duration (default 30)"}}},
+			apiOperation{method: http.MethodGet, path: prefix + "/debug/pprof/symbol", id: "getPprofSymbol", summary: "Symbol lookup", tag: "System", contentType: "text/plain", security: adminBearerRequired},
+			apiOperation{method: http.MethodPost, path: prefix + "/debug/pprof/symbol", id: "lookupPprofSymbols", summary: "Symbol lookup", tag: "System", contentType: "text/plain", security: adminBearerRequired},
+			apiOperation{method: http.MethodGet, path: prefix + "/debug/pprof/trace", id: "getPprofTrace", summary: "Execution trace", tag: "System", contentType: "application/octet-stream", security: adminBearerRequired, params: []apiParam{{name: "seconds", in: "query", description: "Trace duration (default 1)"}}},
+			apiOperation{
+				method: http.MethodGet, path: prefix + "/debug/pprof/{profile}", id: "getPprofProfile",
+				summary: "Named profile", description: pprofDocs, tag: "System", contentType: "application/octet-stream", security: adminBearerRequired,
+				params: []apiParam{{name: "profile", in: "path", description: "heap, goroutine, allocs, block, mutex, or threadcreate"}, {name: "debug", in: "query", description: "1 or 2 for human-readable text"}},
+			},
+		)
 	}
+
+	return ops
 }
@@ -0,0 +1,192 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/eventbus"
+	"github.com/CedrosPay/server/internal/graphqlapi"
+	"github.com/CedrosPay/server/internal/idempotency"
+	"github.com/CedrosPay/server/internal/money"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/products"
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/internal/verification"
+)
+
+// TestOpenAPICoversRoutes fails when a route is registered without being documented, or
+// documented without being registered.
+func TestOpenAPICoversRoutes(t *testing.T) {
+	cfg := &config.Config{
+		Server:         config.ServerConfig{RoutePrefix: "/api", AdminMetricsAPIKey: "admin"},
+		MerchantEvents: config.MerchantEventsConfig{Enabled: true, Keys: map[string]string{"k": "default"}},
+		AsyncVerify:    config.AsyncVerifyConfig{Enabled: true},
+		GraphQL:        config.GraphQLConfig{Enabled: true},
+		X402:           config.X402Config{Facilitator: true},
+		Metering:       config.MeteringConfig{Enabled: true},
+	}
+	svc := paywall.NewService(cfg, storage.NewMemoryStore(), nil, nil, products.NewYAMLRepository(nil), nil, nil)
+	pool := verification.NewPool(verification.Options{})
+	defer pool.Close()
+	idem := idempotency.NewMemoryStore()
+	defer idem.Stop()
+
+	router := chi.NewRouter()
+	if err := ConfigureRouter(router, cfg, svc, nil, nil, NewRPCProxyHandlers(cfg), nil, nil, idem, nil, nil, zerolog.Nop(),
+		WithEventBus(eventbus.New(8)), WithVerificationPool(pool)); err != nil {
+		t.Fatalf("ConfigureRouter: %v", err)
+	}
+
+	methods := map[string][]string{}
+	if err := chi.Walk(router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		methods[route] = append(methods[route], method)
+		return nil
+	}); err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+	registered := map[string]bool{}
+	for route, ms := range methods {
+		if len(ms) > 5 {
+			// Handle() registers every method; document it as GET
+			ms = []string{http.MethodGet}
+		}
+		for _, m := range ms {
+			registered[m+" "+route] = true
+		}
+	}
+
+	schema, err := graphqlapi.NewSchema(graphqlapi.Services{Paywall: svc})
+	if err != nil {
+		t.Fatalf("NewSchema: %v", err)
+	}
+	h := &handlers{cfg: cfg, eventBus: eventbus.New(8), verifications: pool, graphqlSchema: &schema}
+	documented := map[string]bool{}
+	for _, op := range h.apiOperations() {
+		key := op.method + " " + op.path
+		if documented[key] {
+			t.Errorf("duplicate operation %s", key)
+		}
+		documented[key] = true
+	}
+
+	var missing, stale []string
+	for key := range registered {
+		if !documented[key] {
+			missing = append(missing, key)
+		}
+	}
+	for key := range documented {
+		if !registered[key] {
+			stale = append(stale, key)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(stale)
+	if len(missing) > 0 {
+		t.Errorf("routes missing from apiOperations: %s", strings.Join(missing, ", "))
+	}
+	if len(stale) > 0 {
+		t.Errorf("apiOperations not registered as routes: %s", strings.Join(stale, ", "))
+	}
+}
+
+func TestOpenAPISpecDocument(t *testing.T) {
+	h := &handlers{cfg: &config.Config{Server: config.ServerConfig{RoutePrefix: "/api"}}}
+	rec := httptest.NewRecorder()
+	h.openAPISpec(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	var spec struct {
+		OpenAPI    string                               `json:"openapi"`
+		Paths      map[string]map[string]map[string]any `json:"paths"`
+		Webhooks   map[string]any                       `json:"webhooks"`
+		Components struct {
+			Schemas map[string]map[string]any `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if spec.OpenAPI != "3.1.0" {
+		t.Errorf("openapi = %q", spec.OpenAPI)
+	}
+
+	refund := spec.Paths["/api/paywall/v1/refunds/request"]["post"]
+	if refund == nil {
+		t.Fatal("refund request operation missing")
+	}
+	body, _ := json.Marshal(refund["requestBody"])
+	if !strings.Contains(string(body), "#/components/schemas/RequestRefundRequest") {
+		t.Errorf("refund request body not referenced: %s", body)
+	}
+	if _, ok := spec.Components.Schemas["RequestRefundRequest"]; !ok {
+		t.Error("RequestRefundRequest schema missing")
+	}
+	if _, ok := spec.Components.Schemas["ErrorResponse"]; !ok {
+		t.Error("ErrorResponse schema missing")
+	}
+	if _, ok := spec.Paths["/api/paywall/v1/graphql"]; ok {
+		t.Error("graphql documented while disabled")
+	}
+	if spec.Webhooks["payment.succeeded"] == nil {
+		t.Error("payment.succeeded webhook missing")
+	}
+}
+
+func TestSchemaRegistry(t *testing.T) {
+	type inner struct {
+		Name string `json:"name"`
+	}
+	type sample struct {
+		inner
+		ID       string            `json:"id"`
+		Count    int64             `json:"count,omitempty"`
+		Price    money.Money       `json:"price"`
+		Tags     []string          `json:"tags"`
+		Meta     map[string]string `json:"meta"`
+		Created  time.Time         `json:"createdAt"`
+		Optional *bool             `json:"optional,omitempty"`
+		Skipped  string            `json:"-"`
+		internal string
+	}
+
+	reg := newSchemaRegistry()
+	ref := reg.schemaFor(sample{})
+	if ref["$ref"] != "#/components/schemas/Sample" {
+		t.Fatalf("ref = %v", ref)
+	}
+	props := reg.schemas["Sample"].(map[string]any)["properties"].(map[string]any)
+
+	tests := []struct {
+		field string
+		want  string
+	}{
+		{field: "name", want: `{"type":"string"}`},
+		{field: "id", want: `{"type":"string"}`},
+		{field: "count", want: `{"format":"int64","type":"integer"}`},
+		{field: "price", want: `{"$ref":"#/components/schemas/MoneyJSON"}`},
+		{field: "tags", want: `{"items":{"type":"string"},"type":"array"}`},
+		{field: "meta", want: `{"additionalProperties":{"type":"string"},"type":"object"}`},
+		{field: "createdAt", want: `{"format":"date-time","type":"string"}`},
+		{field: "optional", want: `{"type":"boolean"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			got, _ := json.Marshal(props[tt.field])
+			if string(got) != tt.want {
+				t.Errorf("schema = %s, want %s", got, tt.want)
+			}
+		})
+	}
+	if len(props) != len(tests) {
+		t.Errorf("expected %d properties, got %d: %v", len(tests), len(props), props)
+	}
+}
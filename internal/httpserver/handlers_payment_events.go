@@ -0,0 +1,127 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	apierrors "github.com/CedrosPay/server/internal/errors"
+	"github.com/CedrosPay/server/internal/paymentstatus"
+)
+
+const (
+	// paymentEventsMaxDuration bounds how long a status stream stays open.
+	// Solana confirmation normally completes well within this window.
+	paymentEventsMaxDuration = 2 * time.Minute
+	// paymentEventsHeartbeat keeps proxies from closing idle streams.
+	paymentEventsHeartbeat = 15 * time.Second
+)
+
+// paymentStatusEvents streams x402 verification progress for a signature via Server-Sent Events.
+// Stages: received → submitted → confirmed → granted (or failed). The stream closes after a
+// terminal stage, or after paymentEventsMaxDuration so clients can fall back to polling.
+func (h *handlers) paymentStatusEvents(w http.ResponseWriter, r *http.Request) {
+	signature := chi.URLParam(r, "signature")
+	if signature == "" {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeMissingField, "signature is required")
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInternalError, "streaming not supported")
+		return
+	}
+
+	history, updates, cancel := h.paywall.StatusTracker().Subscribe(signature)
+	defer cancel()
+
+	// No in-memory history (e.g. verified by another instance or before a restart) - fall back to storage
+	if len(history) == 0 {
+		if update, found := h.storedPaymentStatus(r.Context(), signature); found {
+			history = append(history, update)
+		}
+	}
+
+	// Streams outlive the server write timeout; extend the deadline for this response only
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(paymentEventsMaxDuration + paymentEventsHeartbeat))
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Disable nginx response buffering
+	w.WriteHeader(http.StatusOK)
+
+	for _, update := range history {
+		if err := writePaymentStatusEvent(w, update); err != nil {
+			return
+		}
+		if update.Stage.Terminal() {
+			flusher.Flush()
+			return
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(paymentEventsHeartbeat)
+	defer heartbeat.Stop()
+	deadline := time.NewTimer(paymentEventsMaxDuration)
+	defer deadline.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-deadline.C:
+			fmt.Fprint(w, "event: timeout\ndata: {}\n\n")
+			flusher.Flush()
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case update := <-updates:
+			if err := writePaymentStatusEvent(w, update); err != nil {
+				return
+			}
+			flusher.Flush()
+			if update.Stage.Terminal() {
+				return
+			}
+		}
+	}
+}
+
+// storedPaymentStatus derives the current stage from the payment record, if one exists.
+func (h *handlers) storedPaymentStatus(ctx context.Context, signature string) (paymentstatus.Update, bool) {
+	payment, err := h.paywall.GetPayment(ctx, signature)
+	if err != nil {
+		return paymentstatus.Update{}, false
+	}
+
+	stage := paymentstatus.StageGranted
+	if payment.Metadata["status"] == "verifying" {
+		stage = paymentstatus.StageReceived // Claimed before verification; it may not be sent yet
+	}
+	return paymentstatus.Update{
+		Signature:  signature,
+		Stage:      stage,
+		ResourceID: payment.ResourceID,
+		Timestamp:  payment.CreatedAt.UTC(),
+	}, true
+}
+
+// writePaymentStatusEvent writes a single SSE "status" event.
+func writePaymentStatusEvent(w http.ResponseWriter, update paymentstatus.Update) error {
+	data, err := json.Marshal(update)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: status\ndata: %s\n\n", data)
+	return err
+}
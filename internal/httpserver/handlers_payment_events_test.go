@@ -0,0 +1,82 @@
+package httpserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/paymentstatus"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func newPaymentEventsRouter(t *testing.T) (*chi.Mux, *paymentstatus.Tracker, storage.Store) {
+	t.Helper()
+	store := storage.NewMemoryStore()
+	svc := paywall.NewService(&config.Config{}, store, nil, nil, nil, nil, nil)
+	tracker := paymentstatus.NewTracker(time.Minute)
+	svc.SetStatusTracker(tracker)
+
+	h := &handlers{cfg: &config.Config{}, paywall: svc}
+	router := chi.NewRouter()
+	router.Get("/paywall/v1/payments/{signature}/events", h.paymentStatusEvents)
+	return router, tracker, store
+}
+
+func TestPaymentStatusEvents_StreamsUntilTerminal(t *testing.T) {
+	router, tracker, _ := newPaymentEventsRouter(t)
+	tracker.Publish(paymentstatus.Update{Signature: "sig1", Stage: paymentstatus.StageReceived})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/paywall/v1/payments/sig1/events", nil))
+		done <- rec
+	}()
+
+	// Give the handler time to subscribe before publishing live updates
+	time.Sleep(50 * time.Millisecond)
+	tracker.Publish(paymentstatus.Update{Signature: "sig1", Stage: paymentstatus.StageConfirmed})
+	tracker.Publish(paymentstatus.Update{Signature: "sig1", Stage: paymentstatus.StageGranted})
+
+	select {
+	case rec := <-done:
+		if ct := rec.Header().Get("Content-Type"); ct != "text/event-stream" {
+			t.Fatalf("unexpected content type %q", ct)
+		}
+		body := rec.Body.String()
+		for _, stage := range []string{"received", "confirmed", "granted"} {
+			if !strings.Contains(body, `"stage":"`+stage+`"`) {
+				t.Errorf("expected %s stage in stream, got:\n%s", stage, body)
+			}
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("stream did not close after terminal stage")
+	}
+}
+
+func TestPaymentStatusEvents_FallsBackToStoredPayment(t *testing.T) {
+	router, _, store := newPaymentEventsRouter(t)
+	if err := store.RecordPayment(context.Background(), storage.PaymentTransaction{
+		Signature:  "sig2",
+		ResourceID: "article-1",
+		CreatedAt:  time.Now(),
+		Metadata:   map[string]string{"status": "verified"},
+	}); err != nil {
+		t.Fatalf("record payment: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/paywall/v1/payments/sig2/events", nil))
+
+	body := rec.Body.String()
+	if !strings.Contains(body, `"stage":"granted"`) || !strings.Contains(body, `"resourceId":"article-1"`) {
+		t.Fatalf("expected granted event from stored payment, got:\n%s", body)
+	}
+}
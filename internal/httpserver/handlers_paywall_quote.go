@@ -18,6 +18,8 @@ import (
 type QuoteRequest struct {
 	Resource   string  `json:"resource"`
 	CouponCode *string `json:"couponCode,omitempty"`
+	// Wallet, when given, checks the coupon's per-wallet usage limit before quoting
+	Wallet string `json:"wallet,omitempty"`
 }
 
 // paywallQuote generates a payment quote without exposing resource ID in URL.
@@ -52,8 +54,29 @@ func (h *handlers) paywallQuote(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Generate quote using existing paywall service
-	quote, err := h.paywall.GenerateQuote(r.Context(), req.Resource, couponCode)
+	quote, err := h.paywall.GenerateQuoteForWallet(r.Context(), req.Resource, couponCode, req.Wallet)
 	if err != nil {
+		if errors.Is(err, paywall.ErrDraining) {
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeServiceUnavailable, "server is shutting down, retry shortly")
+			return
+		}
+		if errors.Is(err, paywall.ErrCouponWalletLimit) {
+			apierrors.WriteErrorWithDetail(w, apierrors.ErrCodeCouponUsageLimitReached, err.Error(), "resourceId", req.Resource)
+			return
+		}
+		if errors.Is(err, paywall.ErrOutOfStock) {
+			apierrors.WriteErrorWithDetail(w, apierrors.ErrCodeOutOfStock, err.Error(), "resourceId", req.Resource)
+			return
+		}
+		if errors.Is(err, paywall.ErrVelocityLimit) {
+			apierrors.WriteErrorWithDetail(w, apierrors.ErrCodeVelocityLimitExceeded, err.Error(), "resourceId", req.Resource)
+			return
+		}
+		if errors.Is(err, paywall.ErrAccessDenied) {
+			apierrors.WriteErrorWithDetail(w, apierrors.ErrCodeAccessDenied, err.Error(), "resourceId", req.Resource)
+			return
+		}
+
 		// Distinguish between resource not found vs actual errors
 		if errors.Is(err, paywall.ErrResourceNotConfigured) {
 			log.Warn().
@@ -88,23 +111,7 @@ func (h *handlers) paywallQuote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response := map[string]any{
-		"x402Version": 0,
-		"accepts": []any{
-			map[string]any{
-				"scheme":            quote.Crypto.Scheme,
-				"network":           quote.Crypto.Network,
-				"maxAmountRequired": quote.Crypto.MaxAmountRequired,
-				"resource":          quote.Crypto.Resource,
-				"description":       quote.Crypto.Description,
-				"mimeType":          quote.Crypto.MimeType,
-				"payTo":             quote.Crypto.PayTo,
-				"maxTimeoutSeconds": quote.Crypto.MaxTimeoutSeconds,
-				"asset":             quote.Crypto.Asset,
-				"extra":             quote.Crypto.Extra,
-			},
-		},
-	}
+	response := h.paywall.PaymentRequired(quote.Crypto)
 
 	// Record quote generation timing (using payment observation with settled=false)
 	quoteDuration := time.Since(quoteStart)
@@ -155,10 +162,16 @@ func (h *handlers) paywallVerify(w http.ResponseWriter, r *http.Request) {
 	resource := proof.Resource
 	resourceType := proof.ResourceType
 
+	// x402 v1 payloads carry only the transaction; those clients name the resource in the query
+	if resource == "" {
+		resource = r.URL.Query().Get("resource")
+		resourceType = r.URL.Query().Get("resourceType")
+	}
+
 	if resource == "" {
 		log.Warn().
 			Msg("paywall.verify.missing_resource")
-		apierrors.WriteSimpleError(w, apierrors.ErrCodeMissingField, "resource field required in payment payload")
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeMissingField, "resource field required in payment payload or query")
 		return
 	}
 
@@ -172,6 +185,12 @@ func (h *handlers) paywallVerify(w http.ResponseWriter, r *http.Request) {
 		Str("resource_type", resourceType).
 		Msg("paywall.verify.routing")
 
+	// Clients that opt in get a verification ID immediately and poll for the result
+	if h.verifications != nil && wantsAsyncVerify(r) && validVerifyResourceType(resourceType) {
+		h.submitAsyncVerification(w, r, resource, resourceType, paymentHeader)
+		return
+	}
+
 	// Route based on resource type
 	switch resourceType {
 	case "cart":
@@ -222,21 +241,24 @@ func parsePaymentProof(header string) (struct {
 	return result, nil
 }
 
+// couponFromPaymentHeader extracts the coupon code from payment proof metadata, if present.
+func couponFromPaymentHeader(paymentHeader string) string {
+	proof, err := x402.ParsePaymentProof(paymentHeader)
+	if err != nil || proof.Metadata == nil {
+		return ""
+	}
+	// Support both snake_case (coupon_code) and camelCase (couponCode)
+	if couponCode := proof.Metadata["coupon_code"]; couponCode != "" {
+		return couponCode
+	}
+	return proof.Metadata["couponCode"]
+}
+
 // verifyRegularPaymentInternal handles regular single-item payment verification.
 func (h *handlers) verifyRegularPaymentInternal(w http.ResponseWriter, r *http.Request, resourceID, paymentHeader string) {
 	log := logger.FromContext(r.Context())
 	// Extract coupon code from payment metadata if present
-	var couponCode string
-
-	// Parse the payment proof to extract coupon code from metadata
-	proof, err := x402.ParsePaymentProof(paymentHeader)
-	if err == nil && proof.Metadata != nil {
-		// Support both snake_case (coupon_code) and camelCase (couponCode)
-		couponCode = proof.Metadata["coupon_code"]
-		if couponCode == "" {
-			couponCode = proof.Metadata["couponCode"]
-		}
-	}
+	couponCode := couponFromPaymentHeader(paymentHeader)
 
 	// Use existing Authorize logic
 	authResult, err := h.paywall.Authorize(r.Context(), resourceID, "", paymentHeader, couponCode)
@@ -254,6 +276,22 @@ func (h *handlers) verifyRegularPaymentInternal(w http.ResponseWriter, r *http.R
 			apierrors.WriteErrorWithDetail(w, vErr.Code, vErr.Message, "resourceId", resourceID)
 			return
 		}
+		if errors.Is(err, paywall.ErrCouponWalletLimit) {
+			apierrors.WriteErrorWithDetail(w, apierrors.ErrCodeCouponUsageLimitReached, err.Error(), "resourceId", resourceID)
+			return
+		}
+		if errors.Is(err, paywall.ErrOutOfStock) {
+			apierrors.WriteErrorWithDetail(w, apierrors.ErrCodeOutOfStock, err.Error(), "resourceId", resourceID)
+			return
+		}
+		if errors.Is(err, paywall.ErrVelocityLimit) {
+			apierrors.WriteErrorWithDetail(w, apierrors.ErrCodeVelocityLimitExceeded, err.Error(), "resourceId", resourceID)
+			return
+		}
+		if errors.Is(err, paywall.ErrAccessDenied) {
+			apierrors.WriteErrorWithDetail(w, apierrors.ErrCodeAccessDenied, err.Error(), "resourceId", resourceID)
+			return
+		}
 		apierrors.WriteErrorWithDetail(w, apierrors.ErrCodeInternalError, err.Error(), "resourceId", resourceID)
 		return
 	}
@@ -0,0 +1,113 @@
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+
+	apierrors "github.com/CedrosPay/server/internal/errors"
+	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/pkg/responders"
+	x402solana "github.com/CedrosPay/server/pkg/x402/solana"
+)
+
+// payerChecker is implemented by verifiers that can check a payer's balances.
+type payerChecker interface {
+	CheckPayer(ctx context.Context, req x402solana.PayerCheckRequest) (x402solana.PayerCheck, error)
+}
+
+// preflightResponse reports whether a wallet can pay for a resource.
+type preflightResponse struct {
+	Wallet   string `json:"wallet"`
+	Resource string `json:"resource"`
+	Token    string `json:"token"`
+	x402solana.PayerCheck
+}
+
+// preflight handles GET /paywall/v1/preflight?wallet=...&resource=...[&couponCode=...] -
+// reports whether the wallet has the token account, token balance, and SOL (unless gasless
+// applies) to pay for the resource or cart, so frontends can explain a problem before the
+// user builds and signs a transaction.
+func (h *handlers) preflight(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	query := r.URL.Query()
+	walletParam := query.Get("wallet")
+	resourceID := query.Get("resource")
+
+	if walletParam == "" {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeMissingField, "wallet is required")
+		return
+	}
+	if resourceID == "" {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeMissingField, "resource is required")
+		return
+	}
+	wallet, err := solana.PublicKeyFromBase58(walletParam)
+	if err != nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidWallet, "wallet is not a valid Solana address")
+		return
+	}
+	tokenMint, err := solana.PublicKeyFromBase58(h.cfg.X402.TokenMint)
+	if err != nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeConfigError, "invalid token mint configuration")
+		return
+	}
+	checker, ok := h.verifier.(payerChecker)
+	if !ok {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeConfigError, "verifier does not support payer checks")
+		return
+	}
+
+	target, err := h.paywall.PaymentTarget(r.Context(), resourceID, query.Get("couponCode"))
+	if err != nil {
+		switch {
+		case errors.Is(err, paywall.ErrResourceNotConfigured):
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeResourceNotFound, "resource not found")
+		case errors.Is(err, storage.ErrNotFound) && strings.HasPrefix(resourceID, "cart_"):
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeCartNotFound, "cart not found")
+		case errors.Is(err, storage.ErrCartExpired):
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeQuoteExpired, "cart quote has expired")
+		case errors.Is(err, paywall.ErrNoCryptoPrice):
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, "resource cannot be paid with crypto")
+		case errors.Is(err, paywall.ErrDraining):
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeServiceUnavailable, "server is shutting down, retry shortly")
+		case errors.Is(err, paywall.ErrVelocityLimit):
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeVelocityLimitExceeded, err.Error())
+		case errors.Is(err, paywall.ErrAccessDenied):
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeAccessDenied, err.Error())
+		default:
+			log.Error().Err(err).Str("resource_id", resourceID).Msg("preflight.price_failed")
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeInternalError, "failed to price resource")
+		}
+		return
+	}
+
+	check, err := checker.CheckPayer(r.Context(), x402solana.PayerCheckRequest{
+		Wallet:           wallet,
+		TokenMint:        tokenMint,
+		Amount:           uint64(target.Amount.Atomic),
+		Gasless:          target.Gasless,
+		ComputeUnitLimit: h.cfg.X402.ComputeUnitLimit,
+		ComputeUnitPrice: h.cfg.X402.ComputeUnitPriceMicroLamports,
+	})
+	if err != nil {
+		log.Warn().
+			Err(err).
+			Str("wallet", logger.TruncateAddress(walletParam)).
+			Msg("preflight.check_failed")
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeRPCError, "failed to read wallet balances")
+		return
+	}
+
+	responders.JSON(w, http.StatusOK, preflightResponse{
+		Wallet:     wallet.String(),
+		Resource:   resourceID,
+		Token:      target.Amount.Asset.Code,
+		PayerCheck: check,
+	})
+}
@@ -0,0 +1,96 @@
+package httpserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/products"
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/pkg/x402"
+	x402solana "github.com/CedrosPay/server/pkg/x402/solana"
+)
+
+// fakePayerChecker reports a payer with a fixed token balance.
+type fakePayerChecker struct {
+	balance uint64
+	got     *x402solana.PayerCheckRequest
+}
+
+func (f fakePayerChecker) Verify(context.Context, x402.PaymentProof, x402.Requirement) (x402.VerificationResult, error) {
+	return x402.VerificationResult{}, nil
+}
+
+func (f fakePayerChecker) CheckPayer(_ context.Context, req x402solana.PayerCheckRequest) (x402solana.PayerCheck, error) {
+	*f.got = req
+	check := x402solana.PayerCheck{TokenAccountExists: true, TokenBalance: f.balance, RequiredAmount: req.Amount}
+	check.Ready = f.balance >= req.Amount
+	return check, nil
+}
+
+func TestPreflight(t *testing.T) {
+	cfg := &config.Config{
+		X402: config.X402Config{
+			PaymentAddress: "11111111111111111111111111111111",
+			TokenMint:      "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v",
+			TokenDecimals:  6,
+		},
+		Paywall: config.PaywallConfig{
+			QuoteTTL: config.Duration{Duration: time.Minute},
+			Resources: map[string]config.PaywallResource{
+				"article": {ResourceID: "article", CryptoAtomicAmount: 1500000, CryptoToken: "USDC"},
+				"fiat":    {ResourceID: "fiat", FiatAmountCents: 100, FiatCurrency: "USD"},
+			},
+		},
+	}
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	var got x402solana.PayerCheckRequest
+	checker := fakePayerChecker{balance: 2000000, got: &got}
+	h := &handlers{
+		cfg:      cfg,
+		paywall:  paywall.NewService(cfg, store, checker, nil, products.NewYAMLRepository(cfg.Paywall.Resources), nil, nil),
+		verifier: checker,
+	}
+
+	wallet := solana.NewWallet().PublicKey().String()
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "ready", query: "wallet=" + wallet + "&resource=article", wantStatus: http.StatusOK, wantBody: `"ready":true`},
+		{name: "missing wallet", query: "resource=article", wantStatus: http.StatusBadRequest, wantBody: "wallet is required"},
+		{name: "invalid wallet", query: "wallet=nope&resource=article", wantStatus: http.StatusBadRequest, wantBody: "invalid_wallet"},
+		{name: "missing resource", query: "wallet=" + wallet, wantStatus: http.StatusBadRequest, wantBody: "resource is required"},
+		{name: "unknown resource", query: "wallet=" + wallet + "&resource=missing", wantStatus: http.StatusNotFound, wantBody: "resource_not_found"},
+		{name: "fiat only", query: "wallet=" + wallet + "&resource=fiat", wantStatus: http.StatusBadRequest, wantBody: "cannot be paid with crypto"},
+		{name: "unknown cart", query: "wallet=" + wallet + "&resource=cart_missing", wantStatus: http.StatusNotFound, wantBody: "cart_not_found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/paywall/v1/preflight?"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			h.preflight(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body missing %q: %.300s", tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+
+	if got.Amount != 1500000 || got.Wallet.String() != wallet || got.Gasless {
+		t.Errorf("checked %+v, want 1.5 USDC from the wallet without gasless", got)
+	}
+}
@@ -1,34 +1,69 @@
 package httpserver
 
 import (
+	"cmp"
 	"encoding/json"
 	"math"
 	"net/http"
+	"sort"
 	"strconv"
 
 	"github.com/CedrosPay/server/internal/coupons"
 	apierrors "github.com/CedrosPay/server/internal/errors"
 	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/money"
+	"github.com/CedrosPay/server/internal/products"
 )
 
 // ProductResponse represents the JSON structure returned to the frontend.
 type ProductResponse struct {
-	ID                    string            `json:"id"`
-	Description           string            `json:"description"`
-	FiatAmount            float64           `json:"fiatAmount"`
-	EffectiveFiatAmount   float64           `json:"effectiveFiatAmount"` // Price after catalog-level auto-apply coupon for Stripe
-	FiatCurrency          string            `json:"fiatCurrency"`
-	StripePriceID         string            `json:"stripePriceId,omitempty"`
-	CryptoAmount          float64           `json:"cryptoAmount"`
-	EffectiveCryptoAmount float64           `json:"effectiveCryptoAmount"` // Price after catalog-level auto-apply coupon for x402
-	CryptoToken           string            `json:"cryptoToken"`
-	HasStripeCoupon       bool              `json:"hasStripeCoupon"`            // True if Stripe catalog-level auto-apply coupon exists
-	HasCryptoCoupon       bool              `json:"hasCryptoCoupon"`            // True if x402 catalog-level auto-apply coupon exists
-	StripeCouponCode      string            `json:"stripeCouponCode,omitempty"` // Stripe catalog-level auto-apply coupon code
-	CryptoCouponCode      string            `json:"cryptoCouponCode,omitempty"` // x402 catalog-level auto-apply coupon code
-	StripeDiscountPercent float64           `json:"stripeDiscountPercent"`      // Percentage off for Stripe (catalog-level)
-	CryptoDiscountPercent float64           `json:"cryptoDiscountPercent"`      // Percentage off for x402 (catalog-level)
-	Metadata              map[string]string `json:"metadata,omitempty"`
+	ID                    string             `json:"id"`
+	Description           string             `json:"description"`
+	FiatAmount            money.LegacyFloat  `json:"fiatAmount,omitzero"`
+	EffectiveFiatAmount   money.LegacyFloat  `json:"effectiveFiatAmount,omitzero"` // Price after catalog-level auto-apply coupon for Stripe
+	FiatCurrency          string             `json:"fiatCurrency"`
+	StripePriceID         string             `json:"stripePriceId,omitempty"`
+	CryptoAmount          money.LegacyFloat  `json:"cryptoAmount,omitzero"`
+	EffectiveCryptoAmount money.LegacyFloat  `json:"effectiveCryptoAmount,omitzero"` // Price after catalog-level auto-apply coupon for x402
+	CryptoToken           string             `json:"cryptoToken"`
+	HasStripeCoupon       bool               `json:"hasStripeCoupon"`            // True if Stripe catalog-level auto-apply coupon exists
+	HasCryptoCoupon       bool               `json:"hasCryptoCoupon"`            // True if x402 catalog-level auto-apply coupon exists
+	StripeCouponCode      string             `json:"stripeCouponCode,omitempty"` // Stripe catalog-level auto-apply coupon code
+	CryptoCouponCode      string             `json:"cryptoCouponCode,omitempty"` // x402 catalog-level auto-apply coupon code
+	StripeDiscountPercent float64            `json:"stripeDiscountPercent"`      // Percentage off for Stripe (catalog-level)
+	CryptoDiscountPercent float64            `json:"cryptoDiscountPercent"`      // Percentage off for x402 (catalog-level)
+	Metadata              map[string]string  `json:"metadata,omitempty"`
+	Variants              []ProductVariant   `json:"variants,omitempty"`      // Selectable in cart items by ID
+	Bundle                []string           `json:"bundle,omitempty"`        // Products a purchase also grants access to
+	PriceTiers            []ProductPriceTier `json:"priceTiers,omitempty"`    // Volume price breaks for cart quotes
+	FiatPrices            map[string]float64 `json:"fiatPrices,omitempty"`    // Card prices in other currencies, by currency
+	PriceFromFiat         bool               `json:"priceFromFiat,omitempty"` // x402 quotes convert fiatAmount into cryptoToken at the live rate
+	// Exact decimal strings of the amounts above
+	FiatAmountDecimal            string            `json:"fiatAmountDecimal"`
+	EffectiveFiatAmountDecimal   string            `json:"effectiveFiatAmountDecimal"`
+	CryptoAmountDecimal          string            `json:"cryptoAmountDecimal"`
+	EffectiveCryptoAmountDecimal string            `json:"effectiveCryptoAmountDecimal"`
+	FiatPricesDecimal            map[string]string `json:"fiatPricesDecimal,omitempty"`
+}
+
+// ProductPriceTier is a crypto unit price for cart lines of at least MinQuantity units.
+type ProductPriceTier struct {
+	MinQuantity         int64             `json:"minQuantity"`
+	CryptoAmount        money.LegacyFloat `json:"cryptoAmount,omitzero"`
+	CryptoAmountDecimal string            `json:"cryptoAmountDecimal"` // CryptoAmount as an exact decimal string
+}
+
+// ProductVariant is a variant of a product with its own prices (before coupons).
+type ProductVariant struct {
+	ID            string            `json:"id"`
+	Description   string            `json:"description,omitempty"`
+	FiatAmount    money.LegacyFloat `json:"fiatAmount,omitzero"`
+	StripePriceID string            `json:"stripePriceId,omitempty"`
+	CryptoAmount  money.LegacyFloat `json:"cryptoAmount,omitzero"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+	// Exact decimal strings of FiatAmount and CryptoAmount
+	FiatAmountDecimal   string `json:"fiatAmountDecimal"`
+	CryptoAmountDecimal string `json:"cryptoAmountDecimal"`
 }
 
 // ProductsListResponse wraps the product list with checkout-level coupons.
@@ -76,45 +111,82 @@ func (h *handlers) listProducts(w http.ResponseWriter, r *http.Request) {
 	for _, p := range products {
 		// Extract pricing from Money types
 		var fiatAmount, cryptoAmount float64
+		var fiatDecimal, cryptoDecimal string
 		var fiatCurrency, cryptoToken string
+		var fiatDecimals, cryptoDecimals uint8
 
 		if p.FiatPrice != nil {
-			fiatAmountStr := p.FiatPrice.ToMajor()
-			fiatAmount, _ = strconv.ParseFloat(fiatAmountStr, 64)
+			fiatDecimal = p.FiatPrice.ToMajor()
+			fiatAmount, _ = strconv.ParseFloat(fiatDecimal, 64)
 			fiatCurrency = p.FiatPrice.Asset.Code
+			fiatDecimals = p.FiatPrice.Asset.Decimals
 		}
 
 		if p.CryptoPrice != nil {
-			cryptoAmountStr := p.CryptoPrice.ToMajor()
-			cryptoAmount, _ = strconv.ParseFloat(cryptoAmountStr, 64)
+			cryptoDecimal = p.CryptoPrice.ToMajor()
+			cryptoAmount, _ = strconv.ParseFloat(cryptoDecimal, 64)
 			cryptoToken = p.CryptoPrice.Asset.Code
+			cryptoDecimals = p.CryptoPrice.Asset.Decimals
+		}
+		if p.FiatQuoteToken != "" {
+			cryptoToken = p.FiatQuoteToken
 		}
 
 		pr := ProductResponse{
 			ID:                    p.ID,
 			Description:           p.Description,
-			FiatAmount:            fiatAmount,
-			EffectiveFiatAmount:   fiatAmount,
+			FiatAmount:            money.LegacyFloat(fiatAmount),
+			EffectiveFiatAmount:   money.LegacyFloat(fiatAmount),
 			FiatCurrency:          fiatCurrency,
 			StripePriceID:         p.StripePriceID,
-			CryptoAmount:          cryptoAmount,
-			EffectiveCryptoAmount: cryptoAmount,
+			CryptoAmount:          money.LegacyFloat(cryptoAmount),
+			EffectiveCryptoAmount: money.LegacyFloat(cryptoAmount),
 			CryptoToken:           cryptoToken,
 			HasStripeCoupon:       false,
 			HasCryptoCoupon:       false,
 			StripeDiscountPercent: 0,
 			CryptoDiscountPercent: 0,
 			Metadata:              p.Metadata,
+			Variants:              productVariants(p),
+			Bundle:                p.Bundle,
+			PriceFromFiat:         p.FiatQuoteToken != "",
+
+			FiatAmountDecimal:            fiatDecimal,
+			EffectiveFiatAmountDecimal:   fiatDecimal,
+			CryptoAmountDecimal:          cryptoDecimal,
+			EffectiveCryptoAmountDecimal: cryptoDecimal,
+		}
+		for currency, price := range p.FiatPrices {
+			if price.Price == nil {
+				continue // Priced only by its Stripe price
+			}
+			if pr.FiatPricesDecimal == nil {
+				pr.FiatPricesDecimal = make(map[string]string, len(p.FiatPrices))
+				if money.LegacyFloats() {
+					pr.FiatPrices = make(map[string]float64, len(p.FiatPrices))
+				}
+			}
+			pr.FiatPricesDecimal[currency] = price.Price.ToMajor()
+			if pr.FiatPrices != nil {
+				pr.FiatPrices[currency], _ = strconv.ParseFloat(price.Price.ToMajor(), 64)
+			}
+		}
+		for _, tier := range p.PriceTiers {
+			pr.PriceTiers = append(pr.PriceTiers, ProductPriceTier{
+				MinQuantity:         tier.MinQuantity,
+				CryptoAmount:        tier.CryptoPrice.LegacyFloat(),
+				CryptoAmountDecimal: tier.CryptoPrice.ToMajor(),
+			})
 		}
 
 		// Apply Stripe coupons
 		if stripeCouponsMap != nil {
-			applyCouponsToProduct(&pr, p.ID, stripeCouponsMap, fiatAmount, true)
+			applyCouponsToProduct(&pr, p.ID, stripeCouponsMap, fiatAmount, fiatDecimals, true)
 		}
 
 		// Apply x402 coupons
 		if cryptoCouponsMap != nil {
-			applyCouponsToProduct(&pr, p.ID, cryptoCouponsMap, cryptoAmount, false)
+			applyCouponsToProduct(&pr, p.ID, cryptoCouponsMap, cryptoAmount, cryptoDecimals, false)
 		}
 
 		response = append(response, pr)
@@ -143,9 +215,37 @@ func (h *handlers) listProducts(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// applyCouponsToProduct applies coupons from the map to a product response.
+// productVariants lists p's variants sorted by ID, with prices falling back to the product's.
+func productVariants(p products.Product) []ProductVariant {
+	if len(p.Variants) == 0 {
+		return nil
+	}
+	variants := make([]ProductVariant, 0, len(p.Variants))
+	for id, v := range p.Variants {
+		variant := ProductVariant{
+			ID:            id,
+			Description:   v.Description,
+			StripePriceID: v.StripePriceID,
+			Metadata:      v.Metadata,
+		}
+		if fiat := cmp.Or(v.FiatPrice, p.FiatPrice); fiat != nil {
+			variant.FiatAmount = fiat.LegacyFloat()
+			variant.FiatAmountDecimal = fiat.ToMajor()
+		}
+		if crypto := cmp.Or(v.CryptoPrice, p.CryptoPrice); crypto != nil {
+			variant.CryptoAmount = crypto.LegacyFloat()
+			variant.CryptoAmountDecimal = crypto.ToMajor()
+		}
+		variants = append(variants, variant)
+	}
+	sort.Slice(variants, func(i, j int) bool { return variants[i].ID < variants[j].ID })
+	return variants
+}
+
+// applyCouponsToProduct applies coupons from the map to a product response. decimals is the
+// price's asset precision, for the effective price's decimal string.
 // isStripe determines which fields to update (fiat vs crypto).
-func applyCouponsToProduct(pr *ProductResponse, productID string, couponMap map[string][]coupons.Coupon, originalPrice float64, isStripe bool) {
+func applyCouponsToProduct(pr *ProductResponse, productID string, couponMap map[string][]coupons.Coupon, originalPrice float64, decimals uint8, isStripe bool) {
 	// Collect applicable coupons (product-specific + "all" scope)
 	var applicableCoupons []coupons.Coupon
 	if productCoupons, ok := couponMap[productID]; ok {
@@ -172,14 +272,17 @@ func applyCouponsToProduct(pr *ProductResponse, productID string, couponMap map[
 	effectivePrice = math.Ceil(effectivePrice*100) / 100
 
 	discountPercent := calculateDiscountPercent(originalPrice, effectivePrice)
+	effectiveDecimal := strconv.FormatFloat(effectivePrice, 'f', int(decimals), 64)
 
 	if isStripe {
-		pr.EffectiveFiatAmount = effectivePrice
+		pr.EffectiveFiatAmount = money.LegacyFloat(effectivePrice)
+		pr.EffectiveFiatAmountDecimal = effectiveDecimal
 		pr.HasStripeCoupon = true
 		pr.StripeCouponCode = bestCoupon.Code
 		pr.StripeDiscountPercent = discountPercent
 	} else {
-		pr.EffectiveCryptoAmount = effectivePrice
+		pr.EffectiveCryptoAmount = money.LegacyFloat(effectivePrice)
+		pr.EffectiveCryptoAmountDecimal = effectiveDecimal
 		pr.HasCryptoCoupon = true
 		pr.CryptoCouponCode = bestCoupon.Code
 		pr.CryptoDiscountPercent = discountPercent
@@ -0,0 +1,177 @@
+package httpserver
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	apierrors "github.com/CedrosPay/server/internal/errors"
+	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/products"
+	"github.com/CedrosPay/server/pkg/responders"
+)
+
+// adminProductResponse is a catalog product as managed through the admin API. Prices are
+// decimal strings in major units, as accepted by paywall.ProductRequest.
+type adminProductResponse struct {
+	ID             string            `json:"id"`
+	Description    string            `json:"description"`
+	FiatAmount     string            `json:"fiatAmount,omitempty"`
+	FiatCurrency   string            `json:"fiatCurrency,omitempty"`
+	StripePriceID  string            `json:"stripePriceId,omitempty"`
+	CryptoAmount   string            `json:"cryptoAmount,omitempty"`
+	CryptoToken    string            `json:"cryptoToken,omitempty"`
+	CryptoMint     string            `json:"cryptoMint,omitempty"`
+	CryptoDecimals uint8             `json:"cryptoDecimals,omitempty"`
+	CryptoAccount  string            `json:"cryptoAccount,omitempty"`
+	MemoTemplate   string            `json:"memoTemplate,omitempty"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+	Active         bool              `json:"active"`
+	CreatedAt      time.Time         `json:"createdAt"`
+	UpdatedAt      time.Time         `json:"updatedAt"`
+}
+
+// adminProductsResponse lists the active products in the catalog.
+type adminProductsResponse struct {
+	Products []adminProductResponse `json:"products"`
+}
+
+// productCacheResponse reports whether a product cache was cleared (false when
+// paywall.product_cache_ttl is 0 and nothing is cached).
+type productCacheResponse struct {
+	Invalidated bool `json:"invalidated"`
+}
+
+func newAdminProductResponse(p products.Product) adminProductResponse {
+	resp := adminProductResponse{
+		ID:            p.ID,
+		Description:   p.Description,
+		StripePriceID: p.StripePriceID,
+		CryptoAccount: p.CryptoAccount,
+		MemoTemplate:  p.MemoTemplate,
+		Metadata:      p.Metadata,
+		Active:        p.Active,
+		CreatedAt:     p.CreatedAt,
+		UpdatedAt:     p.UpdatedAt,
+	}
+	if p.FiatPrice != nil {
+		resp.FiatAmount = p.FiatPrice.ToMajor()
+		resp.FiatCurrency = p.FiatPrice.Asset.Metadata.StripeCurrency
+	}
+	if p.CryptoPrice != nil {
+		resp.CryptoAmount = p.CryptoPrice.ToMajor()
+		resp.CryptoToken = p.CryptoPrice.Asset.Code
+		resp.CryptoMint = p.CryptoPrice.Asset.Metadata.SolanaMint
+		resp.CryptoDecimals = p.CryptoPrice.Asset.Decimals
+	}
+	return resp
+}
+
+// adminListProducts handles GET /admin/products - lists the active products.
+func (h *handlers) adminListProducts(w http.ResponseWriter, r *http.Request) {
+	list, err := h.paywall.ListProducts(r.Context())
+	if err != nil {
+		h.writeProductAdminError(w, r, err)
+		return
+	}
+	resp := adminProductsResponse{Products: make([]adminProductResponse, 0, len(list))}
+	for _, p := range list {
+		resp.Products = append(resp.Products, newAdminProductResponse(p))
+	}
+	responders.JSON(w, http.StatusOK, resp)
+}
+
+// adminGetProduct handles GET /admin/products/{id}.
+func (h *handlers) adminGetProduct(w http.ResponseWriter, r *http.Request) {
+	product, err := h.paywall.GetProduct(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeProductAdminError(w, r, err)
+		return
+	}
+	responders.JSON(w, http.StatusOK, newAdminProductResponse(product))
+}
+
+// adminCreateProduct handles POST /admin/products - adds a product to the catalog.
+func (h *handlers) adminCreateProduct(w http.ResponseWriter, r *http.Request) {
+	var req paywall.ProductRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, err.Error())
+		return
+	}
+
+	product, err := h.paywall.CreateProduct(r.Context(), req)
+	if err != nil {
+		h.writeProductAdminError(w, r, err)
+		return
+	}
+
+	log := logger.FromContext(r.Context())
+	log.Info().Str("product_id", product.ID).Msg("products.admin.created")
+	responders.JSON(w, http.StatusCreated, newAdminProductResponse(product))
+}
+
+// adminUpdateProduct handles PUT /admin/products/{id} - replaces a product's definition.
+func (h *handlers) adminUpdateProduct(w http.ResponseWriter, r *http.Request) {
+	var req paywall.ProductRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, err.Error())
+		return
+	}
+	id := chi.URLParam(r, "id")
+	if req.ID != "" && req.ID != id {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, "id in body does not match the path")
+		return
+	}
+
+	product, err := h.paywall.UpdateProduct(r.Context(), id, req)
+	if err != nil {
+		h.writeProductAdminError(w, r, err)
+		return
+	}
+
+	log := logger.FromContext(r.Context())
+	log.Info().Str("product_id", product.ID).Msg("products.admin.updated")
+	responders.JSON(w, http.StatusOK, newAdminProductResponse(product))
+}
+
+// adminArchiveProduct handles DELETE /admin/products/{id} - takes a product off sale. The
+// product is kept so it can be restored with PUT.
+func (h *handlers) adminArchiveProduct(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if err := h.paywall.ArchiveProduct(r.Context(), id); err != nil {
+		h.writeProductAdminError(w, r, err)
+		return
+	}
+
+	log := logger.FromContext(r.Context())
+	log.Info().Str("product_id", id).Msg("products.admin.archived")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// adminInvalidateProductCache handles POST /admin/products/cache/invalidate - drops cached
+// product data, e.g. after editing the products table directly.
+func (h *handlers) adminInvalidateProductCache(w http.ResponseWriter, r *http.Request) {
+	responders.JSON(w, http.StatusOK, productCacheResponse{Invalidated: h.paywall.InvalidateProductCache()})
+}
+
+// writeProductAdminError maps product catalog errors to API errors.
+func (h *handlers) writeProductAdminError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, products.ErrProductNotFound):
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeProductNotFound, "product not found")
+	case errors.Is(err, products.ErrProductExists):
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeProductAlreadyExists, err.Error())
+	case errors.Is(err, products.ErrReadOnly):
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeProductCatalogReadOnly,
+			"products come from paywall.resources; set paywall.product_source to postgres or mongodb to manage them")
+	case errors.Is(err, paywall.ErrInvalidProduct):
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, err.Error())
+	default:
+		log := logger.FromContext(r.Context())
+		log.Error().Err(err).Str("product_id", chi.URLParam(r, "id")).Msg("products.admin.request_failed")
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeDatabaseError, "failed to access product catalog")
+	}
+}
@@ -0,0 +1,79 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/idempotency"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/products"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func TestProductAdminEndpoints(t *testing.T) {
+	cfg := &config.Config{
+		Server: config.ServerConfig{RoutePrefix: "/api", AdminMetricsAPIKey: "secret"},
+		X402:   config.X402Config{PaymentAddress: "11111111111111111111111111111111", Network: "mainnet-beta", TokenMint: "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v", TokenDecimals: 6},
+		Paywall: config.PaywallConfig{
+			Resources: map[string]config.PaywallResource{
+				"tee": {ResourceID: "tee", CryptoAtomicAmount: 1500000, CryptoToken: "USDC"},
+			},
+		},
+	}
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	repo := products.NewCachedRepository(products.NewYAMLRepository(cfg.Paywall.Resources), time.Minute)
+	svc := paywall.NewService(cfg, store, nil, nil, repo, nil, nil)
+	idem := idempotency.NewMemoryStore()
+	t.Cleanup(idem.Stop)
+	router := chi.NewRouter()
+	if err := ConfigureRouter(router, cfg, svc, nil, nil, NewRPCProxyHandlers(cfg), nil, nil, idem, nil, nil, zerolog.Nop()); err != nil {
+		t.Fatalf("ConfigureRouter: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		auth       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "without key", method: http.MethodGet, path: "/api/admin/products", wantStatus: http.StatusUnauthorized},
+		{name: "list", method: http.MethodGet, path: "/api/admin/products", auth: "Bearer secret", wantStatus: http.StatusOK, wantBody: `"cryptoAmount":"1.500000","cryptoToken":"USDC","cryptoMint":"EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v","cryptoDecimals":6`},
+		{name: "get", method: http.MethodGet, path: "/api/admin/products/tee", auth: "Bearer secret", wantStatus: http.StatusOK, wantBody: `"id":"tee"`},
+		{name: "get unknown", method: http.MethodGet, path: "/api/admin/products/mug", auth: "Bearer secret", wantStatus: http.StatusNotFound, wantBody: "product_not_found"},
+		{name: "malformed body", method: http.MethodPost, path: "/api/admin/products", body: `{`, auth: "Bearer secret", wantStatus: http.StatusBadRequest},
+		{name: "invalid price", method: http.MethodPost, path: "/api/admin/products", body: `{"id":"mug","cryptoAmount":"-2"}`, auth: "Bearer secret", wantStatus: http.StatusBadRequest, wantBody: "must be positive"},
+		{name: "wrong decimals", method: http.MethodPost, path: "/api/admin/products", body: `{"id":"mug","cryptoAmount":"2","cryptoToken":"USDC","cryptoDecimals":9}`, auth: "Bearer secret", wantStatus: http.StatusBadRequest, wantBody: "decimals"},
+		{name: "yaml catalog is read-only", method: http.MethodPost, path: "/api/admin/products", body: `{"id":"mug","cryptoAmount":"2"}`, auth: "Bearer secret", wantStatus: http.StatusConflict, wantBody: "product_catalog_read_only"},
+		{name: "mismatched id", method: http.MethodPut, path: "/api/admin/products/tee", body: `{"id":"mug","cryptoAmount":"2"}`, auth: "Bearer secret", wantStatus: http.StatusBadRequest},
+		{name: "archive read-only", method: http.MethodDelete, path: "/api/admin/products/tee", auth: "Bearer secret", wantStatus: http.StatusConflict},
+		{name: "invalidate cache", method: http.MethodPost, path: "/api/admin/products/cache/invalidate", auth: "Bearer secret", wantStatus: http.StatusOK, wantBody: `"invalidated":true`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantBody != "" && !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body missing %q: %.300s", tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+}
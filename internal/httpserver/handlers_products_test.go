@@ -0,0 +1,77 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/idempotency"
+	"github.com/CedrosPay/server/internal/money"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/products"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func TestListProducts_DecimalAmounts(t *testing.T) {
+	t.Cleanup(func() { money.SetLegacyFloats(true) })
+	cfg := &config.Config{
+		Server: config.ServerConfig{RoutePrefix: "/api"},
+		X402:   config.X402Config{PaymentAddress: "11111111111111111111111111111111", Network: "mainnet-beta", TokenMint: "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v", TokenDecimals: 6},
+		Paywall: config.PaywallConfig{
+			Resources: map[string]config.PaywallResource{
+				"tee": {ResourceID: "tee", FiatAmountCents: 1999, FiatCurrency: "usd", CryptoAtomicAmount: 1234567, CryptoToken: "USDC"},
+			},
+		},
+	}
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	svc := paywall.NewService(cfg, store, nil, nil, products.NewYAMLRepository(cfg.Paywall.Resources), nil, nil)
+	idem := idempotency.NewMemoryStore()
+	t.Cleanup(idem.Stop)
+	router := chi.NewRouter()
+	if err := ConfigureRouter(router, cfg, svc, nil, nil, NewRPCProxyHandlers(cfg), nil, nil, idem, nil, nil, zerolog.Nop()); err != nil {
+		t.Fatalf("ConfigureRouter: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		floats    bool
+		wantBody  []string
+		wantNoKey string
+	}{
+		{
+			name:     "floats kept for older clients",
+			floats:   true,
+			wantBody: []string{`"fiatAmount":19.99`, `"cryptoAmount":1.234567`, `"fiatAmountDecimal":"19.99"`, `"cryptoAmountDecimal":"1.234567"`},
+		},
+		{
+			name:      "floats turned off",
+			floats:    false,
+			wantBody:  []string{`"fiatAmountDecimal":"19.99"`, `"effectiveCryptoAmountDecimal":"1.234567"`},
+			wantNoKey: `"cryptoAmount":`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			money.SetLegacyFloats(tt.floats)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/paywall/v1/products", nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+			}
+			for _, want := range tt.wantBody {
+				if !strings.Contains(rec.Body.String(), want) {
+					t.Errorf("body missing %s: %s", want, rec.Body.String())
+				}
+			}
+			if tt.wantNoKey != "" && strings.Contains(rec.Body.String(), tt.wantNoKey) {
+				t.Errorf("body has %s with floats off: %s", tt.wantNoKey, rec.Body.String())
+			}
+		})
+	}
+}
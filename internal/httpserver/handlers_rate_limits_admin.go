@@ -0,0 +1,32 @@
+package httpserver
+
+import (
+	"net/http"
+
+	apierrors "github.com/CedrosPay/server/internal/errors"
+	"github.com/CedrosPay/server/internal/ratelimit"
+	"github.com/CedrosPay/server/pkg/responders"
+)
+
+// adminRateLimitsResponse is the current rate limiter state for one client.
+type adminRateLimitsResponse struct {
+	Wallet   string                   `json:"wallet,omitempty"`
+	IP       string                   `json:"ip,omitempty"`
+	Limiters []ratelimit.LimiterState `json:"limiters"`
+}
+
+// adminRateLimits handles GET /admin/rate-limits - reports how much of each rate limit a
+// client has used, for support debugging. Query parameters: wallet and ip, at least one.
+func (h *handlers) adminRateLimits(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	wallet, ip := query.Get("wallet"), query.Get("ip")
+	if wallet == "" && ip == "" {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeMissingField, "wallet or ip is required")
+		return
+	}
+	responders.JSON(w, http.StatusOK, adminRateLimitsResponse{
+		Wallet:   wallet,
+		IP:       ip,
+		Limiters: h.rateLimits.Inspect(wallet, ip),
+	})
+}
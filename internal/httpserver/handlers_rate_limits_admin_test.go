@@ -0,0 +1,89 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/idempotency"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/products"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func TestAdminRateLimits(t *testing.T) {
+	cfg := &config.Config{
+		Server: config.ServerConfig{RoutePrefix: "/api", AdminMetricsAPIKey: "secret"},
+		X402:   config.X402Config{PaymentAddress: "11111111111111111111111111111111", Network: "mainnet-beta", TokenMint: "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v", TokenDecimals: 6},
+		RateLimit: config.RateLimitConfig{
+			PerIPEnabled: true,
+			PerIPLimit:   5,
+			PerIPWindow:  config.Duration{Duration: time.Hour},
+		},
+	}
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	svc := paywall.NewService(cfg, store, nil, nil, products.NewYAMLRepository(nil), nil, nil)
+	idem := idempotency.NewMemoryStore()
+	t.Cleanup(idem.Stop)
+
+	router := chi.NewRouter()
+	if err := ConfigureRouter(router, cfg, svc, nil, nil, NewRPCProxyHandlers(cfg), nil, nil, idem, nil, nil, zerolog.Nop()); err != nil {
+		t.Fatalf("ConfigureRouter: %v", err)
+	}
+	serve := func(path, remoteAddr string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remoteAddr
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	for i := 0; i < 2; i++ {
+		serve("/api/docs", "203.0.113.9:1234", nil)
+	}
+
+	auth := map[string]string{"Authorization": "Bearer secret"}
+	tests := []struct {
+		name       string
+		query      string
+		headers    map[string]string
+		wantStatus int
+		wantUsed   int
+	}{
+		{name: "without key", query: "?ip=203.0.113.9", wantStatus: http.StatusUnauthorized},
+		{name: "without wallet or ip", headers: auth, wantStatus: http.StatusBadRequest},
+		{name: "client IP", query: "?ip=203.0.113.9", headers: auth, wantStatus: http.StatusOK, wantUsed: 2},
+		{name: "other IP", query: "?ip=198.51.100.1", headers: auth, wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve("/api/admin/rate-limits"+tt.query, "192.0.2.1:1234", tt.headers)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp adminRateLimitsResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if len(resp.Limiters) != 1 || resp.Limiters[0].Limiter != "per_ip" {
+				t.Fatalf("limiters = %+v, want only per_ip", resp.Limiters)
+			}
+			if got := resp.Limiters[0]; got.Used != tt.wantUsed || got.Remaining != 5-tt.wantUsed {
+				t.Fatalf("per_ip = %+v, want %d used of 5", got, tt.wantUsed)
+			}
+		})
+	}
+}
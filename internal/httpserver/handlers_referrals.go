@@ -0,0 +1,36 @@
+package httpserver
+
+import (
+	"net/http"
+
+	"github.com/CedrosPay/server/internal/auth"
+	apierrors "github.com/CedrosPay/server/internal/errors"
+	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/pkg/responders"
+)
+
+// getReferralDashboard handles GET /paywall/v1/referrals - summarizes the conversions and owed
+// rewards of the signing wallet's referral codes. The wallet in X-Signer must sign the message
+// referrals:<wallet>.
+func (h *handlers) getReferralDashboard(w http.ResponseWriter, r *http.Request) {
+	wallet := r.Header.Get("X-Signer")
+	verifier := auth.NewSignatureVerifier()
+	if err := verifier.VerifyUserRequest(r, []string{wallet}, "referrals:"+wallet); err != nil {
+		apierrors.WriteErrorWithDetail(w, apierrors.ErrCodeInvalidSignature,
+			err.Error(),
+			"hint", "sign message 'referrals:<wallet>' with your wallet")
+		return
+	}
+
+	dashboard, err := h.paywall.ReferralDashboard(r.Context(), wallet)
+	if err != nil {
+		log := logger.FromContext(r.Context())
+		log.Error().
+			Err(err).
+			Str("wallet", logger.TruncateAddress(wallet)).
+			Msg("referrals.dashboard_failed")
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInternalError, "failed to load referral dashboard")
+		return
+	}
+	responders.JSON(w, http.StatusOK, dashboard)
+}
@@ -1,16 +1,20 @@
 package httpserver
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
 	"time"
 
 	"github.com/gagliardetto/solana-go"
+	"github.com/go-chi/chi/v5"
 
 	"github.com/CedrosPay/server/internal/auth"
 	apierrors "github.com/CedrosPay/server/internal/errors"
 	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/money"
 	"github.com/CedrosPay/server/internal/paywall"
 	"github.com/CedrosPay/server/internal/storage"
 	"github.com/CedrosPay/server/pkg/responders"
@@ -24,6 +28,8 @@ type requestRefundRequest struct {
 	Token              string            `json:"token"`              // Token symbol (USDC, etc.)
 	Reason             string            `json:"reason,omitempty"`   // Optional refund reason
 	Metadata           map[string]string `json:"metadata,omitempty"` // Optional metadata
+	// AmountDecimal is the amount to refund as an exact decimal string; it takes precedence over Amount
+	AmountDecimal string `json:"amountDecimal,omitempty"`
 }
 
 // requestRefund handles POST /request-refund - generates x402 quote for issuing a refund.
@@ -57,7 +63,7 @@ func (h *handlers) requestRefund(w http.ResponseWriter, r *http.Request) {
 		apierrors.WriteSimpleError(w, apierrors.ErrCodeMissingField, "recipientWallet required")
 		return
 	}
-	if req.Amount <= 0 {
+	if req.AmountDecimal == "" && req.Amount <= 0 {
 		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidAmount, "amount must be positive")
 		return
 	}
@@ -103,31 +109,6 @@ func (h *handlers) requestRefund(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// SECURITY: Validate that refund amount does not exceed original payment amount
-	// This prevents users from requesting refunds larger than what they paid
-	// Convert payment.Amount (Money) to float64 for comparison
-	paymentAmountFloat, err := strconv.ParseFloat(payment.Amount.ToMajor(), 64)
-	if err != nil {
-		apierrors.WriteError(w, apierrors.ErrCodeAmountMismatch,
-			"failed to parse payment amount",
-			map[string]interface{}{
-				"hint": "internal error processing refund request",
-			})
-		return
-	}
-
-	if req.Amount > paymentAmountFloat {
-		apierrors.WriteError(w, apierrors.ErrCodeAmountMismatch,
-			"refund amount exceeds original payment amount",
-			map[string]interface{}{
-				"hint":            "refund amount must be equal to or less than the original payment",
-				"requestedAmount": req.Amount,
-				"originalAmount":  paymentAmountFloat,
-				"maxRefundable":   paymentAmountFloat,
-			})
-		return
-	}
-
 	// SECURITY: Validate that token matches the original payment token
 	// This prevents requesting a refund in a different token than the original payment
 	if req.Token != payment.Amount.Asset.Code {
@@ -141,11 +122,41 @@ func (h *handlers) requestRefund(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// SECURITY: Validate that refund amount does not exceed original payment amount
+	// This prevents users from requesting refunds larger than what they paid
+	// Compared in atomic units of the payment's token, so no precision is lost
+	major := req.AmountDecimal
+	if major == "" {
+		major = strconv.FormatFloat(req.Amount, 'f', -1, 64)
+	}
+	requested, err := money.FromMajor(payment.Amount.Asset, major)
+	if err != nil || !requested.IsPositive() {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidAmount, "amount must be a positive decimal within the token's precision")
+		return
+	}
+
+	if payment.Amount.LessThan(requested) {
+		apierrors.WriteError(w, apierrors.ErrCodeAmountMismatch,
+			"refund amount exceeds original payment amount",
+			map[string]interface{}{
+				"hint":            "refund amount must be equal to or less than the original payment",
+				"requestedAmount": requested.ToMajor(),
+				"originalAmount":  payment.Amount.ToMajor(),
+				"maxRefundable":   payment.Amount.ToMajor(),
+			})
+		return
+	}
+
 	// SECURITY: Verify the signer is either:
 	// 1. The recipient wallet (user requesting their own refund), OR
-	// 2. The payTo wallet (admin issuing refund on behalf of user)
+	// 2. The payTo wallet or one of its multisig members (admin issuing refund on behalf of user)
+	admins, err := h.paywall.RefundAdmins(r.Context())
+	if err != nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInternalError, err.Error())
+		return
+	}
 	verifier := auth.NewSignatureVerifier()
-	allowedSigners := []string{req.RecipientWallet, h.cfg.X402.PaymentAddress}
+	allowedSigners := append([]string{req.RecipientWallet}, admins...)
 	expectedMessage := "request-refund:" + req.OriginalPurchaseID
 
 	if err := verifier.VerifyUserRequest(r, allowedSigners, expectedMessage); err != nil {
@@ -161,40 +172,48 @@ func (h *handlers) requestRefund(w http.ResponseWriter, r *http.Request) {
 		OriginalPurchaseID: req.OriginalPurchaseID,
 		RecipientWallet:    req.RecipientWallet,
 		Amount:             req.Amount,
+		AmountDecimal:      requested.ToMajor(),
 		Token:              req.Token,
 		Reason:             req.Reason,
 		Metadata:           req.Metadata,
 	})
+	if errors.Is(err, paywall.ErrVelocityLimit) {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeVelocityLimitExceeded, err.Error())
+		return
+	}
 	if err != nil {
 		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, err.Error())
 		return
 	}
 
 	// Return simple confirmation, NOT an x402 quote
-	// Convert Money to float64 for response
-	refundAmountFloat, _ := strconv.ParseFloat(refund.Amount.ToMajor(), 64)
-
-	responders.JSON(w, http.StatusOK, map[string]any{
+	response := map[string]any{
 		"refundId":           refund.ID,
 		"status":             "pending",
 		"originalPurchaseId": refund.OriginalPurchaseID,
 		"recipientWallet":    refund.RecipientWallet,
-		"amount":             refundAmountFloat,
+		"amountDecimal":      refund.Amount.ToMajor(),
 		"token":              refund.Amount.Asset.Code,
 		"reason":             refund.Reason,
 		"createdAt":          refund.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		"message":            "Refund request submitted successfully. An admin will review and process your request.",
-	})
+	}
+	if money.LegacyFloats() {
+		response["amount"] = refund.Amount.LegacyFloat()
+	}
+	responders.JSON(w, http.StatusOK, response)
 }
 
 // getRefundQuoteRequest captures the request to get a fresh refund quote.
 type getRefundQuoteRequest struct {
-	RefundID string `json:"refundId"` // ID of the refund to get a fresh quote for
+	RefundID       string `json:"refundId"`                 // ID of the refund to get a fresh quote for
+	ApprovedAmount string `json:"approvedAmount,omitempty"` // Optional: approve less than requested, in major units (e.g. "7.50")
 }
 
 // getRefundQuote handles POST /paywall/v1/refunds/approve - generates fresh x402 quote for existing refund.
 // Allows admin to get new quote if original expired (blockhash becomes stale after 15 min).
-// Requires signature from payTo wallet.
+// Requires signature from payTo wallet. With approvedAmount the signed message also covers the
+// amount ("approve-refund:<refundId>:<approvedAmount>"), and the quote is for that amount.
 func (h *handlers) getRefundQuote(w http.ResponseWriter, r *http.Request) {
 	log := logger.FromContext(r.Context())
 
@@ -215,10 +234,18 @@ func (h *handlers) getRefundQuote(w http.ResponseWriter, r *http.Request) {
 
 	refundID := req.RefundID
 
-	// Verify signature from payTo wallet (admin only)
+	// Verify signature from payTo wallet or a multisig member (admin only)
+	admins, err := h.paywall.RefundAdmins(r.Context())
+	if err != nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInternalError, err.Error())
+		return
+	}
 	verifier := auth.NewSignatureVerifier()
 	expectedMessage := "approve-refund:" + refundID
-	if err := verifier.VerifyAdminRequest(r, h.cfg.X402.PaymentAddress, expectedMessage); err != nil {
+	if req.ApprovedAmount != "" {
+		expectedMessage += ":" + req.ApprovedAmount
+	}
+	if err := verifier.VerifyAdminRequestFrom(r, admins, expectedMessage); err != nil {
 		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidSignature, err.Error())
 		return
 	}
@@ -240,13 +267,52 @@ func (h *handlers) getRefundQuote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Approve a lower amount than requested before quoting
+	if req.ApprovedAmount != "" {
+		if _, err := h.paywall.AdjustRefundAmount(r.Context(), refundID, req.ApprovedAmount); err != nil {
+			if errors.Is(err, paywall.ErrRefundAmountTooHigh) {
+				apierrors.WriteErrorWithDetail(w, apierrors.ErrCodeInvalidAmount, err.Error(),
+					"requestedAmount", refund.Requested().ToMajor())
+				return
+			}
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidAmount, err.Error())
+			return
+		}
+	}
+
 	// Generate fresh quote for this refund
 	resp, err := h.paywall.RegenerateRefundQuote(r.Context(), refundID)
+	if errors.Is(err, paywall.ErrWalletFlagged) {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeComplianceBlocked, err.Error())
+		return
+	}
+	if errors.Is(err, paywall.ErrScreeningUnavailable) {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeScreeningUnavailable, err.Error())
+		return
+	}
 	if err != nil {
 		apierrors.WriteSimpleError(w, apierrors.ErrCodeInternalError, err.Error())
 		return
 	}
 
+	// A multisig vault can't sign a transfer; the approving member proposes it instead
+	if h.cfg.X402.SquadsMultisig != "" {
+		resp.Proposal, err = h.paywall.BuildRefundProposal(r.Context(), refundID, r.Header.Get("X-Signer"))
+		if err != nil {
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeInternalError, err.Error())
+			return
+		}
+	}
+
+	// With a durable nonce the admin can sign the refund offline and execute it later
+	if h.cfg.X402.RefundNonceAccount != "" {
+		resp.NonceTransaction, err = h.paywall.BuildRefundNonceTransaction(r.Context(), refundID)
+		if err != nil {
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeInternalError, err.Error())
+			return
+		}
+	}
+
 	// Record refund quote generation timing
 	quoteDuration := time.Since(quoteStart)
 	if h.metrics != nil {
@@ -280,10 +346,15 @@ func (h *handlers) denyRefund(w http.ResponseWriter, r *http.Request) {
 
 	refundID := req.RefundID
 
-	// Verify signature from payTo wallet (admin only)
+	// Verify signature from payTo wallet or a multisig member (admin only)
+	admins, err := h.paywall.RefundAdmins(r.Context())
+	if err != nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInternalError, err.Error())
+		return
+	}
 	verifier := auth.NewSignatureVerifier()
 	expectedMessage := "deny-refund:" + refundID
-	if err := verifier.VerifyAdminRequest(r, h.cfg.X402.PaymentAddress, expectedMessage); err != nil {
+	if err := verifier.VerifyAdminRequestFrom(r, admins, expectedMessage); err != nil {
 		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidSignature, err.Error())
 		return
 	}
@@ -402,8 +473,13 @@ func (h *handlers) listPendingRefunds(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// SECURITY: Now that signature is verified, check that signer is the configured payTo wallet
-	// This order ensures cryptographic verification happens before identity checks
-	if headers.Signer != h.cfg.X402.PaymentAddress {
+	// (or a member of its multisig). This order ensures cryptographic verification happens before identity checks
+	admins, err := h.paywall.RefundAdmins(r.Context())
+	if err != nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInternalError, err.Error())
+		return
+	}
+	if !slices.Contains(admins, headers.Signer) {
 		apierrors.WriteSimpleError(w, apierrors.ErrCodeUnauthorizedRefundIssuer,
 			"unauthorized: only payment address can view pending refunds")
 		return
@@ -432,3 +508,100 @@ func (h *handlers) listPendingRefunds(w http.ResponseWriter, r *http.Request) {
 		"count":   len(refunds),
 	})
 }
+
+// refundAuditResponse is the audit trail of one refund request.
+type refundAuditResponse struct {
+	RefundID string                     `json:"refundId"`
+	Entries  []storage.RefundAuditEntry `json:"entries"`
+}
+
+// adminRefundAudit handles GET /admin/refunds/{id}/audit - lists the decisions recorded for a
+// refund request, including requests that were denied and deleted.
+func (h *handlers) adminRefundAudit(w http.ResponseWriter, r *http.Request) {
+	refundID := chi.URLParam(r, "id")
+	entries, err := h.paywall.ListRefundAudit(r.Context(), refundID)
+	if err != nil {
+		log := logger.FromContext(r.Context())
+		log.Error().Err(err).Str("refund_id", refundID).Msg("refund.audit.list_failed")
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeDatabaseError, "failed to load refund audit")
+		return
+	}
+	if entries == nil {
+		entries = []storage.RefundAuditEntry{}
+	}
+	responders.JSON(w, http.StatusOK, refundAuditResponse{RefundID: refundID, Entries: entries})
+}
+
+// addRefundNoteRequest captures a note on a refund request's thread.
+type addRefundNoteRequest struct {
+	RefundID string `json:"refundId"` // ID of the refund the note is about
+	Message  string `json:"message"`  // Note text (max 2000 characters)
+}
+
+// refundHistoryResponse is the conversation thread and status history of one refund request.
+type refundHistoryResponse struct {
+	RefundID string                       `json:"refundId"`
+	History  []storage.RefundHistoryEntry `json:"history"`
+}
+
+// addRefundNote handles POST /paywall/v1/refunds/notes - adds a message to a refund request's
+// thread. The customer (recipientWallet) and refund admins can both post; the signed message
+// 'refund-note:<refundId>:<message>' covers the note text.
+func (h *handlers) addRefundNote(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	var req addRefundNoteRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		log.Warn().Err(err).Msg("refund.note.invalid_body")
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, err.Error())
+		return
+	}
+	if req.RefundID == "" || req.Message == "" {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeMissingField, "refundId and message required")
+		return
+	}
+
+	refund, err := h.paywall.GetRefundQuote(r.Context(), req.RefundID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeRefundNotFound, "refund not found")
+			return
+		}
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInternalError, err.Error())
+		return
+	}
+
+	// SECURITY: Only the refund's recipient or a refund admin may post to its thread
+	admins, err := h.paywall.RefundAdmins(r.Context())
+	if err != nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInternalError, err.Error())
+		return
+	}
+	verifier := auth.NewSignatureVerifier()
+	allowedSigners := append([]string{refund.RecipientWallet}, admins...)
+	expectedMessage := "refund-note:" + req.RefundID + ":" + req.Message
+	if err := verifier.VerifyUserRequest(r, allowedSigners, expectedMessage); err != nil {
+		apierrors.WriteErrorWithDetail(w, apierrors.ErrCodeInvalidSignature,
+			err.Error(),
+			"hint", "sign message 'refund-note:<refundId>:<message>' with your wallet")
+		return
+	}
+
+	author := "customer"
+	if slices.Contains(admins, r.Header.Get("X-Signer")) {
+		author = "admin"
+	}
+	refund, err = h.paywall.AddRefundNote(r.Context(), req.RefundID, author, req.Message)
+	if err != nil {
+		switch {
+		case errors.Is(err, paywall.ErrInvalidRefundNote):
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, err.Error())
+		case errors.Is(err, storage.ErrNotFound):
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeRefundNotFound, "refund not found")
+		default:
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeInternalError, err.Error())
+		}
+		return
+	}
+
+	responders.JSON(w, http.StatusOK, refundHistoryResponse{RefundID: refund.ID, History: refund.History})
+}
@@ -0,0 +1,167 @@
+package httpserver
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/CedrosPay/server/internal/auth"
+	apierrors "github.com/CedrosPay/server/internal/errors"
+	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/pkg/responders"
+)
+
+// savedCartsResponse lists a wallet's saved carts.
+type savedCartsResponse struct {
+	Wallet string              `json:"wallet"`
+	Carts  []storage.SavedCart `json:"carts"`
+}
+
+// savedCartWallet authenticates a saved-cart request: the wallet in X-Signer must sign the
+// message saved-carts:<wallet>. It writes the error response and returns false on failure.
+func savedCartWallet(w http.ResponseWriter, r *http.Request) (string, bool) {
+	wallet := r.Header.Get("X-Signer")
+	verifier := auth.NewSignatureVerifier()
+	if err := verifier.VerifyUserRequest(r, []string{wallet}, "saved-carts:"+wallet); err != nil {
+		apierrors.WriteErrorWithDetail(w, apierrors.ErrCodeInvalidSignature,
+			err.Error(),
+			"hint", "sign message 'saved-carts:<wallet>' with your wallet")
+		return "", false
+	}
+	return wallet, true
+}
+
+// writeSavedCartError maps saved cart errors to API errors.
+func writeSavedCartError(w http.ResponseWriter, r *http.Request, err error, name string) {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeCartNotFound, "saved cart not found")
+	case errors.Is(err, paywall.ErrInvalidSavedCart), errors.Is(err, paywall.ErrUnknownVariant):
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidCartItem, err.Error())
+	case errors.Is(err, paywall.ErrResourceNotConfigured):
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeResourceNotFound, err.Error())
+	case errors.Is(err, paywall.ErrOutOfStock):
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeOutOfStock, err.Error())
+	case errors.Is(err, paywall.ErrNoShippingRate):
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, err.Error())
+	case errors.Is(err, paywall.ErrCouponWalletLimit):
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeCouponUsageLimitReached, err.Error())
+	case errors.Is(err, paywall.ErrVelocityLimit):
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeVelocityLimitExceeded, err.Error())
+	case errors.Is(err, paywall.ErrAccessDenied):
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeAccessDenied, err.Error())
+	case errors.Is(err, paywall.ErrDraining):
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeServiceUnavailable, "server is shutting down, retry shortly")
+	default:
+		log := logger.FromContext(r.Context())
+		log.Error().
+			Err(err).
+			Str("saved_cart", name).
+			Msg("saved_cart.request_failed")
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInternalError, err.Error())
+	}
+}
+
+// listSavedCarts handles GET /paywall/v1/saved-carts - lists the signing wallet's saved carts.
+func (h *handlers) listSavedCarts(w http.ResponseWriter, r *http.Request) {
+	wallet, ok := savedCartWallet(w, r)
+	if !ok {
+		return
+	}
+	carts, err := h.paywall.ListSavedCarts(r.Context(), wallet)
+	if err != nil {
+		writeSavedCartError(w, r, err, "")
+		return
+	}
+	responders.JSON(w, http.StatusOK, savedCartsResponse{Wallet: wallet, Carts: carts})
+}
+
+// saveSavedCart handles PUT /paywall/v1/saved-carts/{name} - creates or replaces a saved cart.
+func (h *handlers) saveSavedCart(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	wallet, ok := savedCartWallet(w, r)
+	if !ok {
+		return
+	}
+	name := chi.URLParam(r, "name")
+
+	var req paywall.SavedCartRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, err.Error())
+		return
+	}
+
+	cart, err := h.paywall.SaveCart(r.Context(), wallet, name, req)
+	if err != nil {
+		writeSavedCartError(w, r, err, name)
+		return
+	}
+
+	log.Info().
+		Str("wallet", logger.TruncateAddress(wallet)).
+		Str("saved_cart", name).
+		Int("item_count", len(cart.Items)).
+		Msg("saved_cart.saved")
+	responders.JSON(w, http.StatusOK, cart)
+}
+
+// getSavedCart handles GET /paywall/v1/saved-carts/{name} - loads a saved cart.
+func (h *handlers) getSavedCart(w http.ResponseWriter, r *http.Request) {
+	wallet, ok := savedCartWallet(w, r)
+	if !ok {
+		return
+	}
+	name := chi.URLParam(r, "name")
+
+	cart, err := h.paywall.GetSavedCart(r.Context(), wallet, name)
+	if err != nil {
+		writeSavedCartError(w, r, err, name)
+		return
+	}
+	responders.JSON(w, http.StatusOK, cart)
+}
+
+// deleteSavedCart handles DELETE /paywall/v1/saved-carts/{name}.
+func (h *handlers) deleteSavedCart(w http.ResponseWriter, r *http.Request) {
+	wallet, ok := savedCartWallet(w, r)
+	if !ok {
+		return
+	}
+	name := chi.URLParam(r, "name")
+
+	if err := h.paywall.DeleteSavedCart(r.Context(), wallet, name); err != nil {
+		writeSavedCartError(w, r, err, name)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// quoteSavedCart handles POST /paywall/v1/saved-carts/{name}/quote - converts a saved cart into
+// a live cart quote at current prices, responding like POST /paywall/v1/cart/quote.
+func (h *handlers) quoteSavedCart(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	wallet, ok := savedCartWallet(w, r)
+	if !ok {
+		return
+	}
+	name := chi.URLParam(r, "name")
+
+	resp, err := h.paywall.QuoteSavedCart(r.Context(), wallet, name)
+	if err != nil {
+		writeSavedCartError(w, r, err, name)
+		return
+	}
+	if h.metrics != nil {
+		h.metrics.ObserveCartCheckout("quote", len(resp.Items))
+	}
+
+	log.Info().
+		Str("cart_id", resp.CartID).
+		Str("saved_cart", name).
+		Int("item_count", len(resp.Items)).
+		Msg("saved_cart.quoted")
+	responders.JSON(w, http.StatusPaymentRequired, resp)
+}
@@ -0,0 +1,77 @@
+package httpserver
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	apierrors "github.com/CedrosPay/server/internal/errors"
+	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/money"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/pkg/responders"
+)
+
+// settlementItem is an externally verified payment to import.
+type settlementItem struct {
+	Signature string            `json:"signature"`          // Transaction signature
+	Resource  string            `json:"resource"`           // Resource the payment bought
+	Wallet    string            `json:"wallet"`             // Payer wallet granted access
+	Amount    money.Money       `json:"amount"`             // Amount paid
+	PaidAt    time.Time         `json:"paidAt,omitempty"`   // When the payment settled (default: now)
+	Metadata  map[string]string `json:"metadata,omitempty"` // Optional metadata
+}
+
+// ingestSettlementsRequest is a batch of externally verified payments.
+type ingestSettlementsRequest struct {
+	Payments []settlementItem `json:"payments"`
+	Notify   bool             `json:"notify,omitempty"` // Send payment.succeeded callbacks for each payment
+}
+
+// ingestSettlementsResponse reports how many payments were recorded.
+type ingestSettlementsResponse struct {
+	Recorded int `json:"recorded"`
+}
+
+// ingestSettlements handles POST /admin/settlements - records a batch of payments verified
+// outside CedrosPay (e.g. by an indexer) so merchants can migrate historical sales. The batch
+// is recorded in full or not at all.
+func (h *handlers) ingestSettlements(w http.ResponseWriter, r *http.Request) {
+	var req ingestSettlementsRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, err.Error())
+		return
+	}
+	if len(req.Payments) == 0 {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeMissingField, "payments required")
+		return
+	}
+
+	settlements := make([]paywall.Settlement, 0, len(req.Payments))
+	for _, item := range req.Payments {
+		settlements = append(settlements, paywall.Settlement{
+			Signature:  item.Signature,
+			ResourceID: item.Resource,
+			Wallet:     item.Wallet,
+			Amount:     item.Amount,
+			PaidAt:     item.PaidAt,
+			Metadata:   item.Metadata,
+		})
+	}
+
+	recorded, err := h.paywall.IngestSettlements(r.Context(), settlements, req.Notify)
+	if err != nil {
+		switch {
+		case errors.Is(err, paywall.ErrInvalidSettlement):
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, err.Error())
+		case errors.Is(err, paywall.ErrSettlementRecorded):
+			apierrors.WriteSimpleError(w, apierrors.ErrCodePaymentAlreadyUsed, err.Error())
+		default:
+			log := logger.FromContext(r.Context())
+			log.Error().Err(err).Msg("settlements.ingest_failed")
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeDatabaseError, "failed to record settlements")
+		}
+		return
+	}
+	responders.JSON(w, http.StatusCreated, ingestSettlementsResponse{Recorded: len(recorded)})
+}
@@ -0,0 +1,83 @@
+package httpserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/idempotency"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/products"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func TestIngestSettlements(t *testing.T) {
+	cfg := &config.Config{
+		Server: config.ServerConfig{RoutePrefix: "/api", AdminMetricsAPIKey: "secret"},
+		Paywall: config.PaywallConfig{
+			Resources: map[string]config.PaywallResource{
+				"article": {ResourceID: "article", CryptoAtomicAmount: 1500000, CryptoToken: "USDC"},
+			},
+		},
+	}
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	svc := paywall.NewService(cfg, store, nil, nil, products.NewYAMLRepository(cfg.Paywall.Resources), nil, nil)
+	idem := idempotency.NewMemoryStore()
+	t.Cleanup(idem.Stop)
+	router := chi.NewRouter()
+	if err := ConfigureRouter(router, cfg, svc, nil, nil, NewRPCProxyHandlers(cfg), nil, nil, idem, nil, nil, zerolog.Nop()); err != nil {
+		t.Fatalf("ConfigureRouter: %v", err)
+	}
+
+	payment := func(signature, resource string) string {
+		return `{"signature":"` + signature + `","resource":"` + resource + `","wallet":"payer","amount":{"asset":"USDC","atomic":"1500000"},"paidAt":"2024-03-01T12:00:00Z"}`
+	}
+	tests := []struct {
+		name       string
+		body       string
+		auth       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "without key", body: `{"payments":[` + payment("sig-1", "article") + `]}`, wantStatus: http.StatusUnauthorized},
+		{name: "no payments", body: `{"payments":[]}`, auth: "Bearer secret", wantStatus: http.StatusBadRequest, wantBody: "payments required"},
+		{name: "unknown resource", body: `{"payments":[` + payment("sig-1", "article") + `,` + payment("sig-2", "missing") + `]}`, auth: "Bearer secret", wantStatus: http.StatusBadRequest, wantBody: "unknown resource"},
+		{name: "duplicate in batch", body: `{"payments":[` + payment("sig-1", "article") + `,` + payment("sig-1", "article") + `]}`, auth: "Bearer secret", wantStatus: http.StatusBadRequest, wantBody: "duplicate signature"},
+		{name: "missing amount", body: `{"payments":[{"signature":"sig-1","resource":"article","wallet":"payer"}]}`, auth: "Bearer secret", wantStatus: http.StatusBadRequest, wantBody: "amount must be positive"},
+		{name: "records batch", body: `{"payments":[` + payment("sig-1", "article") + `,` + payment("sig-2", "article") + `]}`, auth: "Bearer secret", wantStatus: http.StatusCreated, wantBody: `"recorded":2`},
+		{name: "already recorded", body: `{"payments":[` + payment("sig-3", "article") + `,` + payment("sig-2", "article") + `]}`, auth: "Bearer secret", wantStatus: http.StatusPaymentRequired, wantBody: "payment_already_used"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/admin/settlements", strings.NewReader(tt.body))
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantBody != "" && !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body missing %q: %.300s", tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+
+	ctx := context.Background()
+	tx, err := store.GetPayment(ctx, "sig-1")
+	if err != nil || tx.ResourceID != "article" || tx.Wallet != "payer" || tx.Metadata["source"] != "ingested" || tx.CreatedAt.Year() != 2024 {
+		t.Errorf("GetPayment(sig-1) = %+v, %v", tx, err)
+	}
+	if processed, _ := store.HasPaymentBeenProcessed(ctx, "sig-3"); processed {
+		t.Error("sig-3 was recorded by a rejected batch")
+	}
+}
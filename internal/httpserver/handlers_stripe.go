@@ -1,25 +1,32 @@
 package httpserver
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/CedrosPay/server/internal/coupons"
 	apierrors "github.com/CedrosPay/server/internal/errors"
+	"github.com/CedrosPay/server/internal/idempotency"
 	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/paywall"
 	stripesvc "github.com/CedrosPay/server/internal/stripe"
 	"github.com/CedrosPay/server/pkg/responders"
 )
 
 type createSessionRequest struct {
-	Resource      string            `json:"resource"`
-	CustomerEmail string            `json:"customerEmail"`
-	Metadata      map[string]string `json:"metadata"`
-	SuccessURL    string            `json:"successUrl"`
-	CancelURL     string            `json:"cancelUrl"`
-	CouponCode    string            `json:"couponCode"` // NEW: Optional coupon code
+	Resource       string            `json:"resource"`
+	CustomerEmail  string            `json:"customerEmail"`
+	Metadata       map[string]string `json:"metadata"`
+	SuccessURL     string            `json:"successUrl"`
+	CancelURL      string            `json:"cancelUrl"`
+	CouponCode     string            `json:"couponCode"`     // NEW: Optional coupon code
+	Currency       string            `json:"currency"`       // Optional: fiat currency; defaults by Accept-Language
+	PaymentMethods []string          `json:"paymentMethods"` // Optional: subset of the resource's payment methods
 }
 
 type createSessionResponse struct {
@@ -54,6 +61,11 @@ func (h *handlers) createStripeSession(w http.ResponseWriter, r *http.Request) {
 		apierrors.WriteErrorWithDetail(w, apierrors.ErrCodeResourceNotFound, err.Error(), "resourceId", req.Resource)
 		return
 	}
+	resource, err = paywall.SelectFiatCurrency(resource, req.Currency, r.Header.Get("Accept-Language"))
+	if err != nil {
+		apierrors.WriteErrorWithDetail(w, apierrors.ErrCodeInvalidField, err.Error(), "field", "currency")
+		return
+	}
 
 	metadata := make(map[string]string)
 	for k, v := range resource.Metadata {
@@ -62,6 +74,9 @@ func (h *handlers) createStripeSession(w http.ResponseWriter, r *http.Request) {
 	for k, v := range req.Metadata {
 		metadata[k] = v
 	}
+	if len(resource.Bundle) > 0 {
+		metadata[paywall.BundleItemsKey] = strings.Join(resource.Bundle, ",")
+	}
 
 	// Validate coupon if provided (for metadata tracking)
 	originalAmount := resource.FiatAmountCents
@@ -83,6 +98,21 @@ func (h *handlers) createStripeSession(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
+	if !h.checkCardCouponLimits(w, r, couponCode, req.CustomerEmail) {
+		return
+	}
+	paymentMethods, ok := h.resolvePaymentMethods(w, resource.StripePaymentMethods, req.PaymentMethods)
+	if !ok {
+		return
+	}
+	if err := h.paywall.CheckStock(r.Context(), req.Resource, 1); err != nil {
+		if errors.Is(err, paywall.ErrOutOfStock) {
+			apierrors.WriteErrorWithDetail(w, apierrors.ErrCodeOutOfStock, err.Error(), "resourceId", req.Resource)
+			return
+		}
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInternalError, err.Error())
+		return
+	}
 
 	session, err := h.stripe.CreateCheckoutSession(r.Context(), stripesvc.CreateSessionRequest{
 		ResourceID:     req.Resource,
@@ -98,6 +128,8 @@ func (h *handlers) createStripeSession(w http.ResponseWriter, r *http.Request) {
 		StripeCouponID: stripeCouponID, // For Stripe's discount system
 		OriginalAmount: originalAmount,
 		DiscountAmount: 0, // Stripe calculates this
+		PaymentMethods: paymentMethods,
+		Destination:    h.stripeDestination(resource.StripeConnectedAccount, resource.StripeApplicationFeePercent),
 	})
 	if err != nil {
 		// Record failed Stripe session creation
@@ -121,22 +153,78 @@ func (h *handlers) createStripeSession(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// checkCardCouponLimits enforces the per-wallet limits of a card checkout's coupons against the
+// customer's email, writing the error response and returning false when one is exceeded.
+func (h *handlers) checkCardCouponLimits(w http.ResponseWriter, r *http.Request, codes, email string) bool {
+	err := h.paywall.CheckCardCouponLimits(r.Context(), codes, email)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, paywall.ErrCouponEmailRequired):
+		apierrors.WriteErrorWithDetail(w, apierrors.ErrCodeMissingField, err.Error(), "field", "customerEmail")
+	case errors.Is(err, paywall.ErrCouponWalletLimit):
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeCouponUsageLimitReached, err.Error())
+	default:
+		log := logger.FromContext(r.Context())
+		log.Error().
+			Err(err).
+			Str("coupon_codes", codes).
+			Msg("stripe.session.coupon_limit_check_failed")
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInternalError, err.Error())
+	}
+	return false
+}
+
+// resolvePaymentMethods picks the payment methods a card checkout offers from the resource's
+// list (nil for carts) and the ones requested, writing the error response and returning false
+// when a requested method is not offered.
+func (h *handlers) resolvePaymentMethods(w http.ResponseWriter, resource, requested []string) ([]string, bool) {
+	methods, err := stripesvc.ResolvePaymentMethods(h.cfg.Stripe.PaymentMethods, resource, requested)
+	if err != nil {
+		apierrors.WriteErrorWithDetail(w, apierrors.ErrCodeInvalidField, err.Error(), "field", "paymentMethods")
+		return nil, false
+	}
+	return methods, true
+}
+
+// stripeDestination routes Stripe payments to a resource's connected account, if it has one,
+// with its application fee or else stripe.connect's.
+func (h *handlers) stripeDestination(account string, feePercent *float64) stripesvc.Destination {
+	if account == "" {
+		return stripesvc.Destination{}
+	}
+	fee := h.cfg.Stripe.Connect.ApplicationFeePercent
+	if feePercent != nil {
+		fee = *feePercent
+	}
+	return stripesvc.Destination{Account: account, FeePercent: fee}
+}
+
 // verifyStripeSession verifies that a Stripe checkout session was completed and paid.
 // This endpoint prevents payment bypass attacks where users manually enter success URLs.
+// A Payment Element's PaymentIntent is verified the same way by its payment_intent parameter,
+// which Stripe appends to the return URL, and a one-off invoice by its invoice parameter.
 func (h *handlers) verifyStripeSession(w http.ResponseWriter, r *http.Request) {
 	log := logger.FromContext(r.Context())
 
-	// Extract session_id from query parameter
+	// Extract session_id (or payment_intent, or invoice) from query parameter
 	sessionID := r.URL.Query().Get("session_id")
+	if sessionID == "" {
+		sessionID = r.URL.Query().Get("payment_intent")
+	}
+	if sessionID == "" {
+		sessionID = r.URL.Query().Get("invoice")
+	}
 
 	if sessionID == "" {
 		log.Warn().Msg("stripe.verify.missing_session_id")
-		apierrors.WriteSimpleError(w, apierrors.ErrCodeMissingField, "session_id is required")
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeMissingField, "session_id, payment_intent, or invoice is required")
 		return
 	}
 
 	// Look up payment record using Stripe signature format
-	// When webhook processes payment, it stores: signature = "stripe:{session_id}"
+	// When webhook processes payment, it stores: signature = "stripe:{session_id}" (or the
+	// PaymentIntent ID)
 	signature := fmt.Sprintf("stripe:%s", sessionID)
 	tx, err := h.paywall.GetPayment(r.Context(), signature)
 
@@ -239,10 +327,34 @@ func (h *handlers) handleStripeWebhook(w http.ResponseWriter, r *http.Request) {
 
 	log.Info().
 		Str("event_type", event.Type).
+		Str("event_id", event.ID).
 		Msg("stripe.webhook.received")
 
-	if event.Type == "checkout.session.completed" {
-		if err := h.stripe.HandleCompletion(r.Context(), event); err != nil {
+	// Stripe retries deliveries it didn't see acknowledged; answer them without re-processing
+	if h.stripeEventProcessed(r.Context(), event.ID) {
+		log.Info().
+			Str("event_type", event.Type).
+			Str("event_id", event.ID).
+			Msg("stripe.webhook.duplicate")
+		if h.metrics != nil {
+			h.metrics.ObserveWebhook("stripe", "duplicate", time.Since(webhookStart), 1, false)
+		}
+		responders.JSON(w, http.StatusOK, map[string]any{
+			"received":  true,
+			"type":      event.Type,
+			"duplicate": true,
+		})
+		return
+	}
+
+	// PaymentIntents and invoices not created by Cedros (no resource) are ignored
+	paidOutsideCheckout := event.Type == "payment_intent.succeeded" || event.Type == "invoice.paid"
+	if event.Type == "checkout.session.completed" || (paidOutsideCheckout && event.ResourceID != "") {
+		complete := h.stripe.HandleCompletion
+		if event.Metadata["cart_id"] != "" {
+			complete = h.completeCartCheckout // Session created for a cart quote
+		}
+		if err := complete(r.Context(), event); err != nil {
 			// Record webhook processing failure
 			webhookDuration := time.Since(webhookStart)
 			if h.metrics != nil {
@@ -257,6 +369,13 @@ func (h *handlers) handleStripeWebhook(w http.ResponseWriter, r *http.Request) {
 		if h.metrics != nil {
 			h.metrics.ObserveWebhook("stripe", "success", webhookDuration, 1, false)
 		}
+		h.markStripeEventProcessed(r.Context(), event)
+	} else if stripesvc.IsFailureEvent(event.Type) && event.ResourceID != "" {
+		h.stripe.HandleFailure(r.Context(), event)
+		if h.metrics != nil {
+			h.metrics.ObserveWebhook("stripe", "success", time.Since(webhookStart), 1, false)
+		}
+		h.markStripeEventProcessed(r.Context(), event)
 	}
 
 	responders.JSON(w, http.StatusOK, map[string]any{
@@ -265,6 +384,55 @@ func (h *handlers) handleStripeWebhook(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// stripeEventKeyPrefix scopes processed Stripe webhook event IDs in the idempotency store.
+const stripeEventKeyPrefix = "stripe-event:"
+
+// stripeEventProcessed reports whether a webhook event was already processed, by this or any
+// instance sharing the idempotency store.
+func (h *handlers) stripeEventProcessed(ctx context.Context, eventID string) bool {
+	if h.idempotencyStore == nil || eventID == "" {
+		return false
+	}
+	_, found := h.idempotencyStore.Get(ctx, stripeEventKeyPrefix+eventID)
+	return found
+}
+
+// markStripeEventProcessed remembers a processed webhook event for stripe.webhook_dedup_ttl.
+// Failed events are never marked, so Stripe's retries of them are processed.
+func (h *handlers) markStripeEventProcessed(ctx context.Context, event stripesvc.WebhookEvent) {
+	if h.idempotencyStore == nil || event.ID == "" {
+		return
+	}
+	ttl := h.cfg.Stripe.WebhookDedupTTL.Duration
+	if ttl <= 0 {
+		ttl = 72 * time.Hour
+	}
+	record := &idempotency.Response{StatusCode: http.StatusOK, Body: []byte(event.Type), CachedAt: time.Now()}
+	if err := h.idempotencyStore.Set(ctx, stripeEventKeyPrefix+event.ID, record, ttl); err != nil {
+		// The payment itself is recorded idempotently; a retry is only re-checked, not re-paid
+		log := logger.FromContext(ctx)
+		log.Warn().
+			Err(err).
+			Str("event_id", event.ID).
+			Msg("stripe.webhook.dedup_record_failed")
+	}
+}
+
+// completeCartCheckout marks the cart behind a completed cart quote checkout session or paid
+// cart invoice paid.
+func (h *handlers) completeCartCheckout(ctx context.Context, event stripesvc.WebhookEvent) error {
+	return h.paywall.CompleteCartCheckout(ctx, paywall.CartCheckoutPayment{
+		CartID:           event.Metadata["cart_id"],
+		SessionID:        event.SessionID,
+		InvoiceID:        event.InvoiceID,
+		Customer:         event.Customer,
+		StripeCustomerID: event.CustomerID,
+		AmountCents:      event.AmountTotal,
+		Currency:         event.Currency,
+		Metadata:         event.Metadata,
+	})
+}
+
 // stripeWebhookInfo provides information about the Stripe webhook endpoint.
 func (h *handlers) stripeWebhookInfo(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
@@ -0,0 +1,201 @@
+package httpserver
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	apierrors "github.com/CedrosPay/server/internal/errors"
+	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/paywall"
+	stripesvc "github.com/CedrosPay/server/internal/stripe"
+	"github.com/CedrosPay/server/pkg/responders"
+)
+
+// maxInvoiceDaysUntilDue caps how far out an invoice's due date (and a cart's stock hold) can be.
+const maxInvoiceDaysUntilDue = 365
+
+type createInvoiceRequest struct {
+	Resource      string            `json:"resource,omitempty"` // Resource to bill; exclusive with cartId
+	CartID        string            `json:"cartId,omitempty"`   // Cart quote to bill; exclusive with resource
+	CustomerEmail string            `json:"customerEmail"`      // Required: the invoice is emailed here
+	Metadata      map[string]string `json:"metadata,omitempty"`
+	CouponCode    string            `json:"couponCode,omitempty"`   // Resources only; carts carry their quote's coupons
+	Currency      string            `json:"currency,omitempty"`     // Resources only; defaults by Accept-Language
+	DaysUntilDue  int64             `json:"daysUntilDue,omitempty"` // Optional: 1-365, default 30
+}
+
+type createInvoiceResponse struct {
+	InvoiceID        string    `json:"invoiceId"`
+	HostedInvoiceURL string    `json:"hostedInvoiceUrl"` // Stripe-hosted page where the customer pays
+	InvoicePDF       string    `json:"invoicePdf,omitempty"`
+	AmountDue        int64     `json:"amountDue"` // In cents
+	Currency         string    `json:"currency"`
+	DueDate          time.Time `json:"dueDate"`
+}
+
+// createStripeInvoice handles POST /paywall/v1/stripe-invoice - bills a resource or cart quote
+// with a one-off Stripe invoice emailed to the customer, for buyers who pay on terms rather
+// than at checkout. The invoice.paid webhook grants access the way a completed checkout does.
+func (h *handlers) createStripeInvoice(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+
+	var req createInvoiceRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, err.Error())
+		return
+	}
+	switch {
+	case (req.Resource == "") == (req.CartID == ""):
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, "exactly one of resource or cartId is required")
+		return
+	case req.CustomerEmail == "":
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeMissingField, "customerEmail is required")
+		return
+	case req.DaysUntilDue < 0 || req.DaysUntilDue > maxInvoiceDaysUntilDue:
+		apierrors.WriteErrorWithDetail(w, apierrors.ErrCodeInvalidField, "daysUntilDue must be between 1 and 365", "field", "daysUntilDue")
+		return
+	}
+	if req.DaysUntilDue == 0 {
+		req.DaysUntilDue = stripesvc.DefaultInvoiceDaysUntilDue
+	}
+
+	var (
+		invoiceReq stripesvc.CreateInvoiceRequest
+		ok         bool
+	)
+	if req.CartID != "" {
+		invoiceReq, ok = h.cartInvoiceRequest(w, r, req)
+	} else {
+		invoiceReq, ok = h.resourceInvoiceRequest(w, r, req)
+	}
+	if !ok {
+		return
+	}
+	invoiceReq.CustomerEmail = req.CustomerEmail
+	invoiceReq.DaysUntilDue = req.DaysUntilDue
+
+	inv, err := h.stripe.CreateInvoice(r.Context(), invoiceReq)
+	if err != nil {
+		if h.metrics != nil {
+			h.metrics.ObservePaymentFailure("stripe", invoiceReq.ResourceID, "invoice_creation_failed")
+		}
+		log.Error().
+			Err(err).
+			Str("resource_id", invoiceReq.ResourceID).
+			Msg("stripe.invoice.create_failed")
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeStripeError, err.Error())
+		return
+	}
+
+	log.Info().
+		Str("resource_id", invoiceReq.ResourceID).
+		Str("invoice_id", inv.ID).
+		Int64("amount_due", inv.AmountDue).
+		Msg("stripe.invoice.created")
+	responders.JSON(w, http.StatusOK, createInvoiceResponse{
+		InvoiceID:        inv.ID,
+		HostedInvoiceURL: inv.HostedInvoiceURL,
+		InvoicePDF:       inv.InvoicePDF,
+		AmountDue:        inv.AmountDue,
+		Currency:         string(inv.Currency),
+		DueDate:          time.Unix(inv.DueDate, 0).UTC(),
+	})
+}
+
+// resourceInvoiceRequest prices a single resource for an invoice the way a PaymentIntent is
+// priced, writing the API error and returning false when it can't be billed.
+func (h *handlers) resourceInvoiceRequest(w http.ResponseWriter, r *http.Request, req createInvoiceRequest) (stripesvc.CreateInvoiceRequest, bool) {
+	resource, err := h.paywall.ResourceDefinition(r.Context(), req.Resource)
+	if err != nil {
+		apierrors.WriteErrorWithDetail(w, apierrors.ErrCodeResourceNotFound, err.Error(), "resourceId", req.Resource)
+		return stripesvc.CreateInvoiceRequest{}, false
+	}
+	resource, err = paywall.SelectFiatCurrency(resource, req.Currency, r.Header.Get("Accept-Language"))
+	if err != nil {
+		apierrors.WriteErrorWithDetail(w, apierrors.ErrCodeInvalidField, err.Error(), "field", "currency")
+		return stripesvc.CreateInvoiceRequest{}, false
+	}
+
+	price, err := h.paywall.PriceCardPayment(r.Context(), req.Resource, resource, req.CouponCode)
+	switch {
+	case errors.Is(err, paywall.ErrDraining):
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeServiceUnavailable, "server is shutting down, retry shortly")
+		return stripesvc.CreateInvoiceRequest{}, false
+	case errors.Is(err, paywall.ErrNoFiatAmount):
+		apierrors.WriteErrorWithDetail(w, apierrors.ErrCodeInvalidField, "resource is priced by a Stripe price; use a checkout session", "resourceId", req.Resource)
+		return stripesvc.CreateInvoiceRequest{}, false
+	case err != nil:
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInternalError, err.Error())
+		return stripesvc.CreateInvoiceRequest{}, false
+	case price.AmountCents <= 0:
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, "coupons cover the full price; there is nothing to charge")
+		return stripesvc.CreateInvoiceRequest{}, false
+	}
+	if !h.checkCardCouponLimits(w, r, price.CouponCode, req.CustomerEmail) {
+		return stripesvc.CreateInvoiceRequest{}, false
+	}
+
+	metadata := make(map[string]string)
+	for k, v := range resource.Metadata {
+		metadata[k] = v
+	}
+	for k, v := range req.Metadata {
+		metadata[k] = v
+	}
+	if len(resource.Bundle) > 0 {
+		metadata[paywall.BundleItemsKey] = strings.Join(resource.Bundle, ",")
+	}
+
+	description := resource.Description
+	if description == "" {
+		description = req.Resource
+	}
+	return stripesvc.CreateInvoiceRequest{
+		ResourceID:     req.Resource,
+		Currency:       strings.ToLower(resource.FiatCurrency),
+		Lines:          []stripesvc.InvoiceLine{{Description: description, UnitAmount: price.AmountCents, Quantity: 1}},
+		Metadata:       metadata,
+		Description:    resource.Description,
+		CouponCode:     price.CouponCode,
+		OriginalAmount: price.OriginalCents,
+		DiscountAmount: price.OriginalCents - price.AmountCents,
+		Destination:    h.stripeDestination(resource.StripeConnectedAccount, resource.StripeApplicationFeePercent),
+	}, true
+}
+
+// cartInvoiceRequest prices a cart quote for an invoice at its locked prices and holds its
+// stock until the invoice is due, writing the API error and returning false when it can't be
+// billed.
+func (h *handlers) cartInvoiceRequest(w http.ResponseWriter, r *http.Request, req createInvoiceRequest) (stripesvc.CreateInvoiceRequest, bool) {
+	holdUntil := time.Now().Add(time.Duration(req.DaysUntilDue) * 24 * time.Hour)
+	checkout, err := h.paywall.PrepareCartCheckout(r.Context(), req.CartID, holdUntil)
+	if err != nil {
+		h.writeCartCheckoutError(w, r, req.CartID, err)
+		return stripesvc.CreateInvoiceRequest{}, false
+	}
+	if !h.checkCardCouponLimits(w, r, checkout.Metadata["coupon_codes"], req.CustomerEmail) {
+		return stripesvc.CreateInvoiceRequest{}, false
+	}
+
+	lines := make([]stripesvc.InvoiceLine, 0, len(checkout.Lines))
+	for _, line := range checkout.Lines {
+		lines = append(lines, stripesvc.InvoiceLine{Description: line.Name, UnitAmount: line.UnitAmount, Quantity: line.Quantity})
+	}
+	metadata := make(map[string]string, len(checkout.Metadata)+len(req.Metadata))
+	for k, v := range req.Metadata {
+		metadata[k] = v
+	}
+	for k, v := range checkout.Metadata {
+		metadata[k] = v
+	}
+	return stripesvc.CreateInvoiceRequest{
+		ResourceID: req.CartID,
+		CartID:     req.CartID,
+		Currency:   checkout.Currency,
+		Lines:      lines,
+		Discount:   checkout.Discount,
+		Metadata:   metadata,
+	}, true
+}
@@ -0,0 +1,128 @@
+package httpserver
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	apierrors "github.com/CedrosPay/server/internal/errors"
+	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/paywall"
+	stripesvc "github.com/CedrosPay/server/internal/stripe"
+	"github.com/CedrosPay/server/pkg/responders"
+)
+
+type createPaymentIntentRequest struct {
+	Resource       string            `json:"resource"`
+	CustomerEmail  string            `json:"customerEmail"` // Receipt email; required by coupons limited per customer
+	Metadata       map[string]string `json:"metadata"`
+	CouponCode     string            `json:"couponCode"`
+	Currency       string            `json:"currency"`       // Optional: fiat currency; defaults by Accept-Language
+	PaymentMethods []string          `json:"paymentMethods"` // Optional: subset of the resource's payment methods
+}
+
+type createPaymentIntentResponse struct {
+	PaymentIntentID string `json:"paymentIntentId"`
+	ClientSecret    string `json:"clientSecret"` // Passed to Stripe.js to mount the Payment Element
+	PublishableKey  string `json:"publishableKey,omitempty"`
+	AmountCents     int64  `json:"amountCents"`
+	OriginalCents   int64  `json:"originalAmountCents"`
+	Currency        string `json:"currency"`
+}
+
+// createStripePaymentIntent creates a Stripe PaymentIntent for a resource, for merchants that
+// embed the Payment Element in their own UI instead of redirecting to hosted Checkout. The
+// payment_intent.succeeded webhook grants access.
+func (h *handlers) createStripePaymentIntent(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+
+	var req createPaymentIntentRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, err.Error())
+		return
+	}
+	if req.Resource == "" {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeMissingField, "resource is required")
+		return
+	}
+
+	resource, err := h.paywall.ResourceDefinition(r.Context(), req.Resource)
+	if err != nil {
+		apierrors.WriteErrorWithDetail(w, apierrors.ErrCodeResourceNotFound, err.Error(), "resourceId", req.Resource)
+		return
+	}
+	resource, err = paywall.SelectFiatCurrency(resource, req.Currency, r.Header.Get("Accept-Language"))
+	if err != nil {
+		apierrors.WriteErrorWithDetail(w, apierrors.ErrCodeInvalidField, err.Error(), "field", "currency")
+		return
+	}
+
+	price, err := h.paywall.PriceCardPayment(r.Context(), req.Resource, resource, req.CouponCode)
+	switch {
+	case errors.Is(err, paywall.ErrDraining):
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeServiceUnavailable, "server is shutting down, retry shortly")
+		return
+	case errors.Is(err, paywall.ErrNoFiatAmount):
+		apierrors.WriteErrorWithDetail(w, apierrors.ErrCodeInvalidField, "resource is priced by a Stripe price; use a checkout session", "resourceId", req.Resource)
+		return
+	case err != nil:
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInternalError, err.Error())
+		return
+	case price.AmountCents <= 0:
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, "coupons cover the full price; there is nothing to charge")
+		return
+	}
+	if !h.checkCardCouponLimits(w, r, price.CouponCode, req.CustomerEmail) {
+		return
+	}
+	paymentMethods, ok := h.resolvePaymentMethods(w, resource.StripePaymentMethods, req.PaymentMethods)
+	if !ok {
+		return
+	}
+
+	metadata := make(map[string]string)
+	for k, v := range resource.Metadata {
+		metadata[k] = v
+	}
+	for k, v := range req.Metadata {
+		metadata[k] = v
+	}
+	if len(resource.Bundle) > 0 {
+		metadata[paywall.BundleItemsKey] = strings.Join(resource.Bundle, ",")
+	}
+
+	intent, err := h.stripe.CreatePaymentIntent(r.Context(), stripesvc.CreatePaymentIntentRequest{
+		ResourceID:     req.Resource,
+		AmountCents:    price.AmountCents,
+		Currency:       strings.ToLower(resource.FiatCurrency),
+		CustomerEmail:  req.CustomerEmail,
+		Metadata:       metadata,
+		Description:    resource.Description,
+		CouponCode:     price.CouponCode,
+		OriginalAmount: price.OriginalCents,
+		DiscountAmount: price.OriginalCents - price.AmountCents,
+		PaymentMethods: paymentMethods,
+		Destination:    h.stripeDestination(resource.StripeConnectedAccount, resource.StripeApplicationFeePercent),
+	})
+	if err != nil {
+		if h.metrics != nil {
+			h.metrics.ObservePaymentFailure("stripe", req.Resource, "payment_intent_creation_failed")
+		}
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeStripeError, err.Error())
+		return
+	}
+
+	log.Info().
+		Str("resource_id", req.Resource).
+		Str("payment_intent_id", intent.ID).
+		Int64("amount_cents", price.AmountCents).
+		Msg("stripe.payment_intent.created")
+	responders.JSON(w, http.StatusOK, createPaymentIntentResponse{
+		PaymentIntentID: intent.ID,
+		ClientSecret:    intent.ClientSecret,
+		PublishableKey:  h.cfg.Stripe.PublishableKey,
+		AmountCents:     price.AmountCents,
+		OriginalCents:   price.OriginalCents,
+		Currency:        strings.ToLower(resource.FiatCurrency),
+	})
+}
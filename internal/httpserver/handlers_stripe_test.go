@@ -0,0 +1,69 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+	"github.com/stripe/stripe-go/v72/webhook"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/idempotency"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/products"
+	"github.com/CedrosPay/server/internal/storage"
+	stripesvc "github.com/CedrosPay/server/internal/stripe"
+)
+
+func TestStripeWebhookDeduplication(t *testing.T) {
+	const secret = "whsec_test"
+	cfg := &config.Config{
+		Server: config.ServerConfig{RoutePrefix: "/api"},
+		Stripe: config.StripeConfig{WebhookSecret: secret, WebhookDedupTTL: config.Duration{Duration: time.Hour}},
+		X402:   config.X402Config{PaymentAddress: "11111111111111111111111111111111", Network: "mainnet-beta", TokenMint: "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v", TokenDecimals: 6},
+	}
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	svc := paywall.NewService(cfg, store, nil, nil, products.NewYAMLRepository(cfg.Paywall.Resources), nil, nil)
+	stripeClient := stripesvc.NewClient(cfg.Stripe, store, nil, nil, nil)
+	idem := idempotency.NewMemoryStore()
+	t.Cleanup(idem.Stop)
+	router := chi.NewRouter()
+	if err := ConfigureRouter(router, cfg, svc, stripeClient, nil, NewRPCProxyHandlers(cfg), nil, nil, idem, nil, nil, zerolog.Nop()); err != nil {
+		t.Fatalf("ConfigureRouter: %v", err)
+	}
+
+	deliver := func(eventID, intentID string) string {
+		t.Helper()
+		payload := []byte(fmt.Sprintf(`{"id":%q,"object":"event","type":"payment_intent.succeeded","data":{"object":{"id":%q,"object":"payment_intent","amount_received":900,"currency":"usd","metadata":{"resource_id":"article-1"}}}}`, eventID, intentID))
+		now := time.Now()
+		req := httptest.NewRequest(http.MethodPost, "/api/webhook/stripe", strings.NewReader(string(payload)))
+		req.Header.Set("Stripe-Signature", fmt.Sprintf("t=%d,v1=%s", now.Unix(), hex.EncodeToString(webhook.ComputeSignature(now, payload, secret))))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+		}
+		return rec.Body.String()
+	}
+
+	if body := deliver("evt_1", "pi_1"); strings.Contains(body, "duplicate") {
+		t.Fatalf("first delivery = %s, want it processed", body)
+	}
+	if _, err := store.GetPayment(context.Background(), "stripe:pi_1"); err != nil {
+		t.Fatalf("GetPayment after first delivery: %v", err)
+	}
+	if body := deliver("evt_1", "pi_1"); !strings.Contains(body, `"duplicate":true`) {
+		t.Errorf("retried delivery = %s, want it acknowledged as a duplicate", body)
+	}
+	if body := deliver("evt_2", "pi_2"); strings.Contains(body, "duplicate") {
+		t.Errorf("new event = %s, want it processed", body)
+	}
+}
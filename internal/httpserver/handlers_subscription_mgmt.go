@@ -1,11 +1,15 @@
 package httpserver
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
 	apierrors "github.com/CedrosPay/server/internal/errors"
 	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/money"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/products"
 	stripesvc "github.com/CedrosPay/server/internal/stripe"
 	"github.com/CedrosPay/server/internal/subscriptions"
 	"github.com/CedrosPay/server/pkg/responders"
@@ -112,9 +116,11 @@ func (h *handlers) getBillingPortal(w http.ResponseWriter, r *http.Request) {
 
 // changeSubscriptionRequest is the request body for upgrading/downgrading a subscription.
 type changeSubscriptionRequest struct {
-	SubscriptionID    string `json:"subscriptionId"`    // ID of existing subscription
-	NewResource       string `json:"newResource"`       // New plan/resource ID
-	ProrationBehavior string `json:"prorationBehavior"` // "create_prorations" (default), "none", "always_invoice"
+	SubscriptionID    string `json:"subscriptionId"`          // ID of existing subscription
+	NewResource       string `json:"newResource"`             // New plan/resource ID
+	ProrationBehavior string `json:"prorationBehavior"`       // "create_prorations" (default), "none", "always_invoice"
+	ProrationDate     int64  `json:"prorationDate,omitempty"` // Optional: from a preview, to charge exactly the previewed amount
+	Quantity          int64  `json:"quantity,omitempty"`      // Optional: new seat count for per-seat prices
 }
 
 // changeSubscriptionResponse is the response for plan changes.
@@ -126,9 +132,27 @@ type changeSubscriptionResponse struct {
 	Status            string `json:"status"`
 	CurrentPeriodEnd  string `json:"currentPeriodEnd,omitempty"`
 	ProrationBehavior string `json:"prorationBehavior"`
+	Quantity          int    `json:"quantity"`
+}
+
+// subscriptionChangeQuoteResponse is returned with HTTP 402 when a crypto subscription's plan
+// change costs more than the unused time on its current plan. Paying the cart applies the change.
+type subscriptionChangeQuoteResponse struct {
+	SubscriptionID   string      `json:"subscriptionId"`
+	PreviousResource string      `json:"previousResource"`
+	NewResource      string      `json:"newResource"`
+	Credit           money.Money `json:"credit"`    // Unused time on the current plan
+	Charge           money.Money `json:"charge"`    // Rest of the period on the new plan
+	AmountDue        money.Money `json:"amountDue"` // Charge less credit
+	CartID           string      `json:"cartId"`
+	Requirement      interface{} `json:"requirement"` // x402 requirement for paying cart:<cartId>
+	ExpiresAt        string      `json:"expiresAt"`
 }
 
 // changeSubscription handles subscription upgrades and downgrades.
+// Crypto subscriptions are prorated: when the new plan costs more for the rest of the period
+// than the unused time on the current one, it responds 402 with a quote and the change is
+// applied once that is paid.
 // POST /paywall/v1/subscription/change
 func (h *handlers) changeSubscription(w http.ResponseWriter, r *http.Request) {
 	log := logger.FromContext(r.Context())
@@ -148,28 +172,13 @@ func (h *handlers) changeSubscription(w http.ResponseWriter, r *http.Request) {
 		apierrors.WriteSimpleError(w, apierrors.ErrCodeMissingField, "newResource is required")
 		return
 	}
-
-	if h.subscriptions == nil {
-		apierrors.WriteSimpleError(w, apierrors.ErrCodeInternalError, "subscriptions not enabled")
+	if req.Quantity < 0 {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, subscriptions.ErrInvalidQuantity.Error())
 		return
 	}
 
-	// Get the existing subscription
-	sub, err := h.subscriptions.Get(r.Context(), req.SubscriptionID)
-	if err != nil {
-		apierrors.WriteSimpleError(w, apierrors.ErrCodeResourceNotFound, "subscription not found")
-		return
-	}
-
-	// Get the new product to validate it exists and get its Stripe price ID
-	newProduct, err := h.paywall.GetProduct(r.Context(), req.NewResource)
-	if err != nil {
-		apierrors.WriteErrorWithDetail(w, apierrors.ErrCodeResourceNotFound, "new resource not found", "newResource", req.NewResource)
-		return
-	}
-
-	if !newProduct.IsSubscription() {
-		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, "new resource is not a subscription product")
+	sub, newProduct, ok := h.planChangeTarget(w, r, req.SubscriptionID, req.NewResource)
+	if !ok {
 		return
 	}
 
@@ -184,11 +193,7 @@ func (h *handlers) changeSubscription(w http.ResponseWriter, r *http.Request) {
 
 	// Handle based on payment method
 	if sub.PaymentMethod == subscriptions.PaymentMethodStripe && sub.StripeSubscriptionID != "" {
-		// Get Stripe price ID for the new product
-		newPriceID := newProduct.Subscription.StripePriceID
-		if newPriceID == "" {
-			newPriceID = newProduct.StripePriceID
-		}
+		newPriceID := subscriptionPriceID(newProduct)
 		if newPriceID == "" {
 			apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, "new resource has no Stripe price ID")
 			return
@@ -198,7 +203,9 @@ func (h *handlers) changeSubscription(w http.ResponseWriter, r *http.Request) {
 		_, err := h.stripe.UpdateSubscription(r.Context(), stripesvc.UpdateSubscriptionRequest{
 			SubscriptionID:    sub.StripeSubscriptionID,
 			NewPriceID:        newPriceID,
+			Quantity:          req.Quantity,
 			ProrationBehavior: prorationBehavior,
+			ProrationDate:     req.ProrationDate,
 			Metadata: map[string]string{
 				"previous_resource": previousResource,
 				"new_resource":      req.NewResource,
@@ -211,12 +218,47 @@ func (h *handlers) changeSubscription(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	var metadata map[string]string
+	if sub.PaymentMethod == subscriptions.PaymentMethodX402 {
+		quote, err := h.paywall.QuoteSubscriptionChange(r.Context(), sub, req.NewResource, int(req.Quantity))
+		if err != nil {
+			writeSubscriptionChangeQuoteError(w, r, req.SubscriptionID, err)
+			return
+		}
+		if quote.AmountDue() {
+			log.Info().
+				Str("subscription_id", req.SubscriptionID).
+				Str("new_resource", req.NewResource).
+				Str("amount_due", quote.Proration.AmountDue.String()).
+				Msg("subscription.change.payment_required")
+			responders.JSON(w, http.StatusPaymentRequired, subscriptionChangeQuoteResponse{
+				SubscriptionID:   sub.ID,
+				PreviousResource: previousResource,
+				NewResource:      req.NewResource,
+				Credit:           quote.Proration.Credit,
+				Charge:           quote.Proration.Charge,
+				AmountDue:        quote.Proration.AmountDue,
+				CartID:           quote.CartID,
+				Requirement:      quote.Quote,
+				ExpiresAt:        quote.ExpiresAt.UTC().Format(time.RFC3339),
+			})
+			return
+		}
+		// Nothing is due (a cheaper plan); the unused credit is forfeited
+		metadata = map[string]string{
+			"proration_credit": quote.Proration.Credit.ToMajor(),
+			"proration_charge": quote.Proration.Charge.ToMajor(),
+		}
+	}
+
 	// Update our local subscription record
 	result, err := h.subscriptions.ChangeSubscription(r.Context(), subscriptions.ChangeSubscriptionRequest{
 		SubscriptionID:     req.SubscriptionID,
 		NewProductID:       req.NewResource,
 		NewBillingPeriod:   subscriptions.BillingPeriod(newProduct.Subscription.BillingPeriod),
 		NewBillingInterval: newProduct.Subscription.BillingInterval,
+		NewQuantity:        int(req.Quantity),
+		Metadata:           metadata,
 	})
 	if err != nil {
 		log.Error().Err(err).Str("subscription_id", req.SubscriptionID).Msg("subscription.change.error")
@@ -245,6 +287,248 @@ func (h *handlers) changeSubscription(w http.ResponseWriter, r *http.Request) {
 		Status:            string(result.Subscription.Status),
 		CurrentPeriodEnd:  currentPeriodEnd,
 		ProrationBehavior: prorationBehavior,
+		Quantity:          result.Subscription.Quantity,
+	})
+}
+
+// previewSubscriptionChangeRequest is the request body for previewing a plan change.
+type previewSubscriptionChangeRequest struct {
+	SubscriptionID string `json:"subscriptionId"`     // ID of existing subscription
+	NewResource    string `json:"newResource"`        // New plan/resource ID (the current one to preview a seat change)
+	Quantity       int64  `json:"quantity,omitempty"` // Optional: new seat count for per-seat prices
+}
+
+// previewSubscriptionChangeResponse is what a plan change would cost, before confirming it.
+type previewSubscriptionChangeResponse struct {
+	SubscriptionID  string `json:"subscriptionId"`
+	CurrentResource string `json:"currentResource"`
+	NewResource     string `json:"newResource"`
+	ProrationAmount int64  `json:"prorationAmount"` // Cents; positive = charge, negative = credit
+	InvoiceTotal    int64  `json:"invoiceTotal"`    // Cents due on the next invoice after the change
+	Currency        string `json:"currency"`
+	ProrationDate   int64  `json:"prorationDate"` // Pass to /subscription/change to charge exactly this amount
+	EffectiveDate   string `json:"effectiveDate"`
+	NextBillingDate string `json:"nextBillingDate,omitempty"`
+}
+
+// previewSubscriptionChange shows the proration charge or credit of a plan change from the
+// subscription's upcoming Stripe invoice, without changing anything.
+// POST /paywall/v1/subscription/change/preview
+func (h *handlers) previewSubscriptionChange(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+
+	var req previewSubscriptionChangeRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, err.Error())
+		return
+	}
+	if req.SubscriptionID == "" {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeMissingField, "subscriptionId is required")
+		return
+	}
+	if req.NewResource == "" {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeMissingField, "newResource is required")
+		return
+	}
+	if req.Quantity < 0 {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, subscriptions.ErrInvalidQuantity.Error())
+		return
+	}
+
+	sub, newProduct, ok := h.planChangeTarget(w, r, req.SubscriptionID, req.NewResource)
+	if !ok {
+		return
+	}
+	if sub.PaymentMethod != subscriptions.PaymentMethodStripe || sub.StripeSubscriptionID == "" {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, "proration previews are only available for Stripe subscriptions")
+		return
+	}
+	newPriceID := subscriptionPriceID(newProduct)
+	if newPriceID == "" {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, "new resource has no Stripe price ID")
+		return
+	}
+
+	preview, err := h.stripe.PreviewProration(r.Context(), sub.StripeSubscriptionID, newPriceID, req.Quantity)
+	if err != nil {
+		log.Error().Err(err).Str("subscription_id", req.SubscriptionID).Msg("subscription.change_preview.stripe_error")
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeStripeError, err.Error())
+		return
+	}
+
+	resp := previewSubscriptionChangeResponse{
+		SubscriptionID:  sub.ID,
+		CurrentResource: sub.ProductID,
+		NewResource:     req.NewResource,
+		ProrationAmount: preview.ProrationAmount,
+		InvoiceTotal:    preview.InvoiceTotal,
+		Currency:        preview.Currency,
+		ProrationDate:   preview.EffectiveDate.Unix(),
+		EffectiveDate:   preview.EffectiveDate.Format(time.RFC3339),
+	}
+	if !preview.NextBillingDate.IsZero() {
+		resp.NextBillingDate = preview.NextBillingDate.Format(time.RFC3339)
+	}
+	responders.JSON(w, http.StatusOK, resp)
+}
+
+// planChangeTarget loads a subscription and the subscription product it would change to,
+// writing the API error and returning false when either is missing.
+func (h *handlers) planChangeTarget(w http.ResponseWriter, r *http.Request, subscriptionID, newResource string) (subscriptions.Subscription, products.Product, bool) {
+	if h.subscriptions == nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInternalError, "subscriptions not enabled")
+		return subscriptions.Subscription{}, products.Product{}, false
+	}
+
+	// Get the existing subscription
+	sub, err := h.subscriptions.Get(r.Context(), subscriptionID)
+	if err != nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeResourceNotFound, "subscription not found")
+		return subscriptions.Subscription{}, products.Product{}, false
+	}
+
+	// Get the new product to validate it exists and get its Stripe price ID
+	newProduct, err := h.paywall.GetProduct(r.Context(), newResource)
+	if err != nil {
+		apierrors.WriteErrorWithDetail(w, apierrors.ErrCodeResourceNotFound, "new resource not found", "newResource", newResource)
+		return subscriptions.Subscription{}, products.Product{}, false
+	}
+	if !newProduct.IsSubscription() {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, "new resource is not a subscription product")
+		return subscriptions.Subscription{}, products.Product{}, false
+	}
+	return sub, newProduct, true
+}
+
+// writeSubscriptionChangeQuoteError writes the API error for a crypto plan change that could not be priced.
+func writeSubscriptionChangeQuoteError(w http.ResponseWriter, r *http.Request, subscriptionID string, err error) {
+	switch {
+	case errors.Is(err, paywall.ErrDraining):
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeServiceUnavailable, "server is shutting down, retry shortly")
+	case errors.Is(err, paywall.ErrNoCryptoPrice),
+		errors.Is(err, subscriptions.ErrPriceAssetMismatch),
+		errors.Is(err, subscriptions.ErrInvalidQuantity):
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, err.Error())
+	default:
+		log := logger.FromContext(r.Context())
+		log.Error().
+			Err(err).
+			Str("subscription_id", subscriptionID).
+			Msg("subscription.change.quote_failed")
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInternalError, "failed to price plan change")
+	}
+}
+
+// subscriptionPriceID returns the Stripe price a subscription product bills with.
+func subscriptionPriceID(product products.Product) string {
+	if product.Subscription.StripePriceID != "" {
+		return product.Subscription.StripePriceID
+	}
+	return product.StripePriceID
+}
+
+// updateSubscriptionSeatsRequest is the request body for changing a subscription's seat count.
+type updateSubscriptionSeatsRequest struct {
+	SubscriptionID    string `json:"subscriptionId"`
+	Quantity          int64  `json:"quantity"`                // New seat count
+	ProrationBehavior string `json:"prorationBehavior"`       // "create_prorations" (default), "none", "always_invoice"
+	ProrationDate     int64  `json:"prorationDate,omitempty"` // Optional: from a preview, to charge exactly the previewed amount
+}
+
+// updateSubscriptionSeatsResponse is the response for seat changes.
+type updateSubscriptionSeatsResponse struct {
+	Success           bool   `json:"success"`
+	SubscriptionID    string `json:"subscriptionId"`
+	PreviousQuantity  int    `json:"previousQuantity"`
+	Quantity          int    `json:"quantity"`
+	Status            string `json:"status"`
+	CurrentPeriodEnd  string `json:"currentPeriodEnd,omitempty"`
+	ProrationBehavior string `json:"prorationBehavior"`
+}
+
+// updateSubscriptionSeats changes the seat count of a per-seat Stripe subscription mid-period;
+// Stripe prorates the difference for the rest of the period.
+// POST /paywall/v1/subscription/seats
+func (h *handlers) updateSubscriptionSeats(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+
+	var req updateSubscriptionSeatsRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		log.Warn().Err(err).Msg("subscription.seats.invalid_body")
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, err.Error())
+		return
+	}
+
+	if req.SubscriptionID == "" {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeMissingField, "subscriptionId is required")
+		return
+	}
+	if req.Quantity < 1 {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, subscriptions.ErrInvalidQuantity.Error())
+		return
+	}
+
+	if h.subscriptions == nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInternalError, "subscriptions not enabled")
+		return
+	}
+
+	sub, err := h.subscriptions.Get(r.Context(), req.SubscriptionID)
+	if err != nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeResourceNotFound, "subscription not found")
+		return
+	}
+	// x402 subscriptions are paid one period at a time, so there is nothing to prorate
+	if sub.PaymentMethod != subscriptions.PaymentMethodStripe || sub.StripeSubscriptionID == "" {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, "seat changes are only available for Stripe subscriptions")
+		return
+	}
+
+	prorationBehavior := req.ProrationBehavior
+	if prorationBehavior == "" {
+		prorationBehavior = "create_prorations"
+	}
+
+	_, err = h.stripe.UpdateSubscription(r.Context(), stripesvc.UpdateSubscriptionRequest{
+		SubscriptionID:    sub.StripeSubscriptionID,
+		Quantity:          req.Quantity,
+		ProrationBehavior: prorationBehavior,
+		ProrationDate:     req.ProrationDate,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("subscription_id", req.SubscriptionID).Msg("subscription.seats.stripe_error")
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeStripeError, err.Error())
+		return
+	}
+
+	updated, err := h.subscriptions.UpdateQuantity(r.Context(), req.SubscriptionID, int(req.Quantity))
+	if err != nil {
+		log.Error().Err(err).Str("subscription_id", req.SubscriptionID).Msg("subscription.seats.error")
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInternalError, "failed to update subscription")
+		return
+	}
+
+	previousQuantity := max(sub.Quantity, 1)
+	log.Info().
+		Str("subscription_id", req.SubscriptionID).
+		Int("previous_quantity", previousQuantity).
+		Int("quantity", updated.Quantity).
+		Str("proration_behavior", prorationBehavior).
+		Msg("subscription.seats_changed")
+
+	var currentPeriodEnd string
+	if !updated.CurrentPeriodEnd.IsZero() {
+		currentPeriodEnd = updated.CurrentPeriodEnd.UTC().Format(time.RFC3339)
+	}
+
+	responders.JSON(w, http.StatusOK, updateSubscriptionSeatsResponse{
+		Success:           true,
+		SubscriptionID:    updated.ID,
+		PreviousQuantity:  previousQuantity,
+		Quantity:          updated.Quantity,
+		Status:            string(updated.Status),
+		CurrentPeriodEnd:  currentPeriodEnd,
+		ProrationBehavior: prorationBehavior,
 	})
 }
 
@@ -325,3 +609,155 @@ func (h *handlers) reactivateSubscription(w http.ResponseWriter, r *http.Request
 		"currentPeriodEnd":  currentPeriodEnd,
 	})
 }
+
+// pauseSubscriptionRequest is the request body for pausing a subscription.
+type pauseSubscriptionRequest struct {
+	SubscriptionID string `json:"subscriptionId"`
+	Behavior       string `json:"behavior,omitempty"` // Stripe invoices while paused: "void" (default), "keep_as_draft", "mark_uncollectible"
+}
+
+// pauseBehaviors lists the Stripe pause_collection behaviors a pause request may ask for.
+var pauseBehaviors = map[string]bool{
+	"":                   true,
+	"void":               true,
+	"keep_as_draft":      true,
+	"mark_uncollectible": true,
+}
+
+// pauseSubscription pauses a subscription: it stops granting access and, for Stripe
+// subscriptions, payment collection is paused until it is resumed.
+// POST /paywall/v1/subscription/pause
+func (h *handlers) pauseSubscription(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+
+	var req pauseSubscriptionRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		log.Warn().Err(err).Msg("subscription.pause.invalid_body")
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, err.Error())
+		return
+	}
+
+	if req.SubscriptionID == "" {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeMissingField, "subscriptionId is required")
+		return
+	}
+	if !pauseBehaviors[req.Behavior] {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, "behavior must be void, keep_as_draft, or mark_uncollectible")
+		return
+	}
+
+	if h.subscriptions == nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInternalError, "subscriptions not enabled")
+		return
+	}
+
+	sub, err := h.subscriptions.Get(r.Context(), req.SubscriptionID)
+	if err != nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeResourceNotFound, "subscription not found")
+		return
+	}
+
+	if sub.Status != subscriptions.StatusActive && sub.Status != subscriptions.StatusTrialing {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, subscriptions.ErrNotPausable.Error())
+		return
+	}
+
+	// For Stripe subscriptions, pause collection via Stripe API first
+	if sub.PaymentMethod == subscriptions.PaymentMethodStripe && sub.StripeSubscriptionID != "" {
+		if _, err := h.stripe.PauseSubscription(r.Context(), sub.StripeSubscriptionID, req.Behavior); err != nil {
+			log.Error().Err(err).Str("subscription_id", req.SubscriptionID).Msg("subscription.pause.stripe_error")
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeStripeError, err.Error())
+			return
+		}
+	}
+
+	paused, err := h.subscriptions.Pause(r.Context(), req.SubscriptionID)
+	if err != nil {
+		log.Error().Err(err).Str("subscription_id", req.SubscriptionID).Msg("subscription.pause.error")
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInternalError, err.Error())
+		return
+	}
+
+	log.Info().
+		Str("subscription_id", req.SubscriptionID).
+		Msg("subscription.paused")
+
+	responders.JSON(w, http.StatusOK, map[string]any{
+		"success":        true,
+		"subscriptionId": paused.ID,
+		"status":         string(paused.Status),
+	})
+}
+
+// resumeSubscriptionRequest is the request body for resuming a paused subscription.
+type resumeSubscriptionRequest struct {
+	SubscriptionID string `json:"subscriptionId"`
+}
+
+// resumeSubscription resumes a paused subscription.
+// POST /paywall/v1/subscription/resume
+func (h *handlers) resumeSubscription(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+
+	var req resumeSubscriptionRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		log.Warn().Err(err).Msg("subscription.resume.invalid_body")
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, err.Error())
+		return
+	}
+
+	if req.SubscriptionID == "" {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeMissingField, "subscriptionId is required")
+		return
+	}
+
+	if h.subscriptions == nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInternalError, "subscriptions not enabled")
+		return
+	}
+
+	sub, err := h.subscriptions.Get(r.Context(), req.SubscriptionID)
+	if err != nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeResourceNotFound, "subscription not found")
+		return
+	}
+
+	if sub.Status != subscriptions.StatusPaused {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, subscriptions.ErrNotPaused.Error())
+		return
+	}
+
+	// For Stripe subscriptions, resume collection via Stripe API first
+	if sub.PaymentMethod == subscriptions.PaymentMethodStripe && sub.StripeSubscriptionID != "" {
+		if _, err := h.stripe.ResumeSubscription(r.Context(), sub.StripeSubscriptionID); err != nil {
+			log.Error().Err(err).Str("subscription_id", req.SubscriptionID).Msg("subscription.resume.stripe_error")
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeStripeError, err.Error())
+			return
+		}
+	}
+
+	resumed, err := h.subscriptions.Resume(r.Context(), req.SubscriptionID)
+	if err != nil {
+		log.Error().Err(err).Str("subscription_id", req.SubscriptionID).Msg("subscription.resume.error")
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInternalError, err.Error())
+		return
+	}
+
+	log.Info().
+		Str("subscription_id", req.SubscriptionID).
+		Msg("subscription.resumed")
+
+	// Format period end
+	var currentPeriodEnd *string
+	if !resumed.CurrentPeriodEnd.IsZero() {
+		t := resumed.CurrentPeriodEnd.UTC().Format(time.RFC3339)
+		currentPeriodEnd = &t
+	}
+
+	responders.JSON(w, http.StatusOK, map[string]any{
+		"success":          true,
+		"subscriptionId":   resumed.ID,
+		"status":           string(resumed.Status),
+		"currentPeriodEnd": currentPeriodEnd,
+	})
+}
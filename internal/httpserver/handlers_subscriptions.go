@@ -1,12 +1,14 @@
 package httpserver
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
 	apierrors "github.com/CedrosPay/server/internal/errors"
 	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/paywall"
 	stripesvc "github.com/CedrosPay/server/internal/stripe"
 	"github.com/CedrosPay/server/internal/subscriptions"
 	"github.com/CedrosPay/server/pkg/responders"
@@ -14,15 +16,17 @@ import (
 
 // createStripeSubscriptionRequest matches BACKEND_SUBSCRIPTION_API.md spec.
 type createStripeSubscriptionRequest struct {
-	Resource      string            `json:"resource"`      // Plan/resource ID (maps to productId)
-	Interval      string            `json:"interval"`      // "weekly" | "monthly" | "yearly" | "custom"
-	IntervalDays  int               `json:"intervalDays"`  // Only used when interval is "custom"
-	TrialDays     int               `json:"trialDays"`     // Override product trial days
-	CustomerEmail string            `json:"customerEmail"` // Pre-fills Stripe checkout
-	Metadata      map[string]string `json:"metadata"`      // Metadata for tracking
-	CouponCode    string            `json:"couponCode"`    // Coupon code for discount
-	SuccessURL    string            `json:"successUrl"`    // Redirect URL on success
-	CancelURL     string            `json:"cancelUrl"`     // Redirect URL on cancel
+	Resource       string            `json:"resource"`       // Plan/resource ID (maps to productId)
+	Interval       string            `json:"interval"`       // "weekly" | "monthly" | "yearly" | "custom"
+	IntervalDays   int               `json:"intervalDays"`   // Only used when interval is "custom"
+	TrialDays      int               `json:"trialDays"`      // Override product trial days
+	CustomerEmail  string            `json:"customerEmail"`  // Pre-fills Stripe checkout
+	Metadata       map[string]string `json:"metadata"`       // Metadata for tracking
+	CouponCode     string            `json:"couponCode"`     // Coupon code for discount
+	SuccessURL     string            `json:"successUrl"`     // Redirect URL on success
+	CancelURL      string            `json:"cancelUrl"`      // Redirect URL on cancel
+	PaymentMethods []string          `json:"paymentMethods"` // Subset of the product's payment methods
+	Quantity       int64             `json:"quantity"`       // Seats for per-seat prices (default 1)
 }
 
 // createStripeSubscriptionResponse is the response for subscription checkout creation.
@@ -53,6 +57,10 @@ func (h *handlers) createStripeSubscription(w http.ResponseWriter, r *http.Reque
 		apierrors.WriteSimpleError(w, apierrors.ErrCodeMissingField, "interval is required")
 		return
 	}
+	if req.Quantity < 0 {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, "quantity must be at least 1")
+		return
+	}
 
 	// Get product and verify it's a subscription product
 	product, err := h.paywall.GetProduct(r.Context(), req.Resource)
@@ -93,15 +101,23 @@ func (h *handlers) createStripeSubscription(w http.ResponseWriter, r *http.Reque
 		trialDays = req.TrialDays
 	}
 
+	paymentMethods, ok := h.resolvePaymentMethods(w, product.StripePaymentMethods, req.PaymentMethods)
+	if !ok {
+		return
+	}
+
 	// Create subscription checkout
 	session, err := h.stripe.CreateSubscriptionCheckout(r.Context(), stripesvc.CreateSubscriptionRequest{
-		ProductID:     req.Resource,
-		PriceID:       stripePriceID,
-		CustomerEmail: req.CustomerEmail,
-		Metadata:      metadata,
-		SuccessURL:    req.SuccessURL,
-		CancelURL:     req.CancelURL,
-		TrialDays:     trialDays,
+		ProductID:      req.Resource,
+		PriceID:        stripePriceID,
+		CustomerEmail:  req.CustomerEmail,
+		Metadata:       metadata,
+		SuccessURL:     req.SuccessURL,
+		CancelURL:      req.CancelURL,
+		TrialDays:      trialDays,
+		PaymentMethods: paymentMethods,
+		Destination:    h.stripeDestination(product.StripeConnectedAccount, product.StripeApplicationFeePercent),
+		Quantity:       req.Quantity,
 	})
 	if err != nil {
 		log.Error().Err(err).Str("resource", req.Resource).Msg("subscription.stripe.checkout_failed")
@@ -124,11 +140,12 @@ func (h *handlers) createStripeSubscription(w http.ResponseWriter, r *http.Reque
 // subscriptionStatusResponse matches BACKEND_SUBSCRIPTION_API.md spec.
 type subscriptionStatusResponse struct {
 	Active            bool    `json:"active"`                      // Required: Whether subscription is currently active
-	Status            string  `json:"status"`                      // Required: "active" | "trialing" | "past_due" | "canceled" | "unpaid" | "expired"
+	Status            string  `json:"status"`                      // Required: "active" | "trialing" | "past_due" | "paused" | "canceled" | "unpaid" | "expired"
 	ExpiresAt         *string `json:"expiresAt,omitempty"`         // When subscription expires (ISO 8601)
 	CurrentPeriodEnd  *string `json:"currentPeriodEnd,omitempty"`  // Current billing period end (ISO 8601)
 	Interval          string  `json:"interval,omitempty"`          // Billing interval
 	CancelAtPeriodEnd bool    `json:"cancelAtPeriodEnd,omitempty"` // Whether subscription will cancel at period end
+	Quantity          int     `json:"quantity,omitempty"`          // Seats billed for per-seat prices
 }
 
 // getSubscriptionStatus checks if a user has an active subscription.
@@ -193,6 +210,7 @@ func (h *handlers) getSubscriptionStatus(w http.ResponseWriter, r *http.Request)
 		CurrentPeriodEnd:  currentPeriodEnd,
 		Interval:          interval,
 		CancelAtPeriodEnd: sub.CancelAtPeriodEnd,
+		Quantity:          sub.Quantity,
 	})
 }
 
@@ -257,6 +275,10 @@ func (h *handlers) getSubscriptionQuote(w http.ResponseWriter, r *http.Request)
 
 	// Get the quote from paywall service
 	quote, err := h.paywall.GenerateQuote(r.Context(), req.Resource, req.CouponCode)
+	if errors.Is(err, paywall.ErrDraining) {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeServiceUnavailable, "server is shutting down, retry shortly")
+		return
+	}
 	if err != nil {
 		log.Error().Err(err).Str("resource", req.Resource).Msg("subscription.quote.failed")
 		apierrors.WriteSimpleError(w, apierrors.ErrCodeResourceNotFound, err.Error())
@@ -134,8 +134,8 @@ func TestOpenAPISpec(t *testing.T) {
 		t.Fatalf("failed to parse OpenAPI spec: %v", err)
 	}
 
-	if spec["openapi"] != "3.0.0" {
-		t.Errorf("expected OpenAPI version 3.0.0, got %v", spec["openapi"])
+	if spec["openapi"] != "3.1.0" {
+		t.Errorf("expected OpenAPI version 3.1.0, got %v", spec["openapi"])
 	}
 
 	// Verify info section
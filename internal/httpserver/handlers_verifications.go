@@ -0,0 +1,131 @@
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	apierrors "github.com/CedrosPay/server/internal/errors"
+	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/verification"
+	"github.com/CedrosPay/server/pkg/responders"
+	"github.com/CedrosPay/server/pkg/x402"
+)
+
+// wantsAsyncVerify reports whether the client opted into asynchronous verification,
+// via ?async=true or "Prefer: respond-async" (RFC 7240).
+func wantsAsyncVerify(r *http.Request) bool {
+	if async := r.URL.Query().Get("async"); async == "1" || strings.EqualFold(async, "true") {
+		return true
+	}
+	for _, pref := range strings.Split(r.Header.Get("Prefer"), ",") {
+		if strings.EqualFold(strings.TrimSpace(pref), "respond-async") {
+			return true
+		}
+	}
+	return false
+}
+
+func validVerifyResourceType(resourceType string) bool {
+	switch resourceType {
+	case "regular", "cart", "refund":
+		return true
+	default:
+		return false
+	}
+}
+
+// submitAsyncVerification queues a verification and replies 202 with a job the client can poll.
+func (h *handlers) submitAsyncVerification(w http.ResponseWriter, r *http.Request, resourceID, resourceType, paymentHeader string) {
+	log := logger.FromContext(r.Context())
+
+	couponCode := ""
+	if resourceType == "regular" {
+		couponCode = couponFromPaymentHeader(paymentHeader)
+	}
+
+	job, err := h.verifications.Submit(r.Context(), resourceID, resourceType, func(ctx context.Context) verification.Outcome {
+		return h.runVerification(ctx, resourceID, couponCode, paymentHeader)
+	})
+	if err != nil {
+		log.Warn().
+			Err(err).
+			Str("resource", resourceID).
+			Msg("paywall.verify_async.rejected")
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeServiceUnavailable, "Verification queue is full, please retry shortly")
+		return
+	}
+
+	log.Info().
+		Str("verification_id", job.ID).
+		Str("resource", resourceID).
+		Str("resource_type", resourceType).
+		Msg("paywall.verify_async.queued")
+
+	w.Header().Set("Location", h.cfg.Server.RoutePrefix+"/paywall/v1/verifications/"+job.ID)
+	responders.JSON(w, http.StatusAccepted, job)
+}
+
+// runVerification performs the blocking Authorize call on a pool worker and maps the result
+// to the same fields the synchronous endpoint returns.
+func (h *handlers) runVerification(ctx context.Context, resourceID, couponCode, paymentHeader string) verification.Outcome {
+	log := logger.FromContext(ctx)
+
+	result, err := h.paywall.Authorize(ctx, resourceID, "", paymentHeader, couponCode)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("resource", resourceID).
+			Msg("paywall.verify_async.authorization_failed")
+		var vErr x402.VerificationError
+		if errors.As(err, &vErr) {
+			return verification.Outcome{ErrorCode: string(vErr.Code), Error: vErr.Message}
+		}
+		return verification.Outcome{ErrorCode: string(apierrors.ErrCodeTransactionFailed), Error: err.Error()}
+	}
+	if !result.Granted && result.Partial == nil {
+		return verification.Outcome{ErrorCode: string(apierrors.ErrCodeTransactionFailed), Error: "Payment verification failed"}
+	}
+
+	payload := map[string]any{
+		"granted": result.Granted,
+		"method":  result.Method,
+	}
+	if result.Partial != nil {
+		payload["partial"] = result.Partial
+	}
+	if result.Wallet != "" {
+		payload["wallet"] = result.Wallet
+	}
+	if result.Settlement != nil {
+		payload["settlement"] = result.Settlement
+		if result.Settlement.TxHash != nil {
+			payload["signature"] = *result.Settlement.TxHash
+		}
+	}
+
+	log.Info().
+		Str("resource", resourceID).
+		Str("method", result.Method).
+		Str("wallet", logger.TruncateAddress(result.Wallet)).
+		Msg("paywall.verify_async.success")
+	return verification.Outcome{Result: payload}
+}
+
+// getVerification returns the current state of an asynchronous verification.
+// GET /paywall/v1/verifications/{id}
+func (h *handlers) getVerification(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	job, ok := h.verifications.Get(id)
+	if !ok {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeVerificationNotFound, "Verification not found or expired")
+		return
+	}
+	if !job.Status.Terminal() {
+		w.Header().Set("Retry-After", "1")
+	}
+	responders.JSON(w, http.StatusOK, job)
+}
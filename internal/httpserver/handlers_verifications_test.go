@@ -0,0 +1,119 @@
+package httpserver
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/products"
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/internal/verification"
+)
+
+func TestWantsAsyncVerify(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		prefer string
+		want   bool
+	}{
+		{name: "default sync", target: "/paywall/v1/verify", want: false},
+		{name: "query true", target: "/paywall/v1/verify?async=true", want: true},
+		{name: "query one", target: "/paywall/v1/verify?async=1", want: true},
+		{name: "query false", target: "/paywall/v1/verify?async=false", want: false},
+		{name: "prefer header", target: "/paywall/v1/verify", prefer: "respond-async", want: true},
+		{name: "prefer list", target: "/paywall/v1/verify", prefer: "return=minimal, Respond-Async", want: true},
+		{name: "other prefer", target: "/paywall/v1/verify", prefer: "return=minimal", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.target, nil)
+			if tt.prefer != "" {
+				req.Header.Set("Prefer", tt.prefer)
+			}
+			if got := wantsAsyncVerify(req); got != tt.want {
+				t.Fatalf("wantsAsyncVerify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func newVerificationsRouter(t *testing.T) *chi.Mux {
+	t.Helper()
+	cfg := &config.Config{}
+	repo := products.NewYAMLRepository(map[string]config.PaywallResource{})
+	svc := paywall.NewService(cfg, storage.NewMemoryStore(), nil, nil, repo, nil, nil)
+	pool := verification.NewPool(verification.Options{Workers: 1})
+	t.Cleanup(func() { _ = pool.Close() })
+
+	h := &handlers{cfg: cfg, paywall: svc, verifications: pool}
+	router := chi.NewRouter()
+	router.Post("/paywall/v1/verify", h.paywallVerify)
+	router.Get("/paywall/v1/verifications/{id}", h.getVerification)
+	return router
+}
+
+func TestAsyncVerify_QueuesAndReportsFailure(t *testing.T) {
+	router := newVerificationsRouter(t)
+
+	proof := `{"x402Version":0,"scheme":"solana-spl-transfer","network":"mainnet-beta","payload":{"signature":"sig","transaction":"tx","resource":"unknown-resource"}}`
+	req := httptest.NewRequest(http.MethodPost, "/paywall/v1/verify?async=true", nil)
+	req.Header.Set("X-PAYMENT", base64.StdEncoding.EncodeToString([]byte(proof)))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want 202: %s", rec.Code, rec.Body.String())
+	}
+	var queued verification.Job
+	if err := json.Unmarshal(rec.Body.Bytes(), &queued); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if queued.ID == "" || queued.Status != verification.StatusPending {
+		t.Fatalf("unexpected job %+v", queued)
+	}
+	if loc := rec.Header().Get("Location"); loc != "/paywall/v1/verifications/"+queued.ID {
+		t.Fatalf("Location = %q", loc)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		rec = httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/paywall/v1/verifications/"+queued.ID, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("poll status = %d: %s", rec.Code, rec.Body.String())
+		}
+		var job verification.Job
+		if err := json.Unmarshal(rec.Body.Bytes(), &job); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if job.Status.Terminal() {
+			if job.Status != verification.StatusFailed || job.ErrorCode == "" || job.ErrorCode == "internal_error" {
+				t.Fatalf("expected failed job with error code, got %+v", job)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job did not finish, last state %+v", job)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestGetVerification_NotFound(t *testing.T) {
+	router := newVerificationsRouter(t)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/paywall/v1/verifications/ver_missing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404", rec.Code)
+	}
+}
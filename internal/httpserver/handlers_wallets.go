@@ -0,0 +1,122 @@
+package httpserver
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/go-chi/chi/v5"
+
+	apierrors "github.com/CedrosPay/server/internal/errors"
+	"github.com/CedrosPay/server/internal/logger"
+	solanaHelpers "github.com/CedrosPay/server/internal/solana"
+	"github.com/CedrosPay/server/pkg/responders"
+	x402solana "github.com/CedrosPay/server/pkg/x402/solana"
+)
+
+// walletRotator is implemented by verifiers whose server wallets can change at runtime.
+type walletRotator interface {
+	ServerWallets() []x402solana.WalletStatus
+	AddServerWallet(wallet solanaHelpers.Signer) error
+	RetireServerWallet(pubkey solana.PublicKey) error
+}
+
+// addServerWalletRequest registers a server wallet.
+type addServerWalletRequest struct {
+	Key string `json:"key"` // Private key (base58 or JSON byte array) or awskms:/gcpkms: reference
+}
+
+// serverWalletsResponse lists server wallets.
+type serverWalletsResponse struct {
+	Wallets []x402solana.WalletStatus `json:"wallets"`
+}
+
+func (h *handlers) walletRotator(w http.ResponseWriter) (walletRotator, bool) {
+	rotator, ok := h.verifier.(walletRotator)
+	if !ok {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeConfigError, "verifier does not support server wallet rotation")
+	}
+	return rotator, ok
+}
+
+// listServerWallets handles GET /admin/wallets - active, draining, and retired server wallets.
+func (h *handlers) listServerWallets(w http.ResponseWriter, r *http.Request) {
+	rotator, ok := h.walletRotator(w)
+	if !ok {
+		return
+	}
+	responders.JSON(w, http.StatusOK, serverWalletsResponse{Wallets: rotator.ServerWallets()})
+}
+
+// addServerWallet handles POST /admin/wallets - registers a new server wallet without a restart.
+// The wallet is selected for gasless fees once the health checker sees a sufficient balance.
+func (h *handlers) addServerWallet(w http.ResponseWriter, r *http.Request) {
+	rotator, ok := h.walletRotator(w)
+	if !ok {
+		return
+	}
+
+	var req addServerWalletRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, err.Error())
+		return
+	}
+	if strings.TrimSpace(req.Key) == "" {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeMissingField, "key required")
+		return
+	}
+
+	// KMS-held keys are contacted here, so a bad key ID or missing permission fails the request
+	wallet, err := solanaHelpers.ParseSigner(r.Context(), req.Key)
+	if err != nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidWallet, err.Error())
+		return
+	}
+	if err := rotator.AddServerWallet(wallet); err != nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidWallet, err.Error())
+		return
+	}
+
+	log := logger.FromContext(r.Context())
+	log.Info().
+		Str("wallet", wallet.PublicKey().String()).
+		Msg("server_wallet.added")
+	responders.JSON(w, http.StatusCreated, x402solana.WalletStatus{
+		Address: wallet.PublicKey().String(),
+		State:   x402solana.WalletStateActive,
+	})
+}
+
+// retireServerWallet handles POST /admin/wallets/{address}/retire - stops selecting a wallet
+// and drains it. Poll GET /admin/wallets until its state is "retired" before sweeping its SOL.
+func (h *handlers) retireServerWallet(w http.ResponseWriter, r *http.Request) {
+	rotator, ok := h.walletRotator(w)
+	if !ok {
+		return
+	}
+
+	address := chi.URLParam(r, "address")
+	pubkey, err := solana.PublicKeyFromBase58(address)
+	if err != nil {
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidWallet, "invalid wallet address")
+		return
+	}
+	if err := rotator.RetireServerWallet(pubkey); err != nil {
+		if errors.Is(err, x402solana.ErrWalletNotFound) {
+			apierrors.WriteSimpleError(w, apierrors.ErrCodeWalletNotFound, err.Error())
+			return
+		}
+		apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidWallet, err.Error())
+		return
+	}
+
+	log := logger.FromContext(r.Context())
+	log.Info().
+		Str("wallet", address).
+		Msg("server_wallet.retiring")
+	responders.JSON(w, http.StatusAccepted, x402solana.WalletStatus{
+		Address: address,
+		State:   x402solana.WalletStateDraining,
+	})
+}
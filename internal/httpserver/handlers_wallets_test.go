@@ -0,0 +1,112 @@
+package httpserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/idempotency"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/products"
+	solanaHelpers "github.com/CedrosPay/server/internal/solana"
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/pkg/x402"
+	x402solana "github.com/CedrosPay/server/pkg/x402/solana"
+)
+
+// fakeRotator records wallet rotation calls.
+type fakeRotator struct {
+	active []string
+}
+
+func (f *fakeRotator) Verify(context.Context, x402.PaymentProof, x402.Requirement) (x402.VerificationResult, error) {
+	return x402.VerificationResult{}, nil
+}
+
+func (f *fakeRotator) ServerWallets() []x402solana.WalletStatus {
+	statuses := make([]x402solana.WalletStatus, 0, len(f.active))
+	for _, address := range f.active {
+		statuses = append(statuses, x402solana.WalletStatus{Address: address, State: x402solana.WalletStateActive})
+	}
+	return statuses
+}
+
+func (f *fakeRotator) AddServerWallet(wallet solanaHelpers.Signer) error {
+	f.active = append(f.active, wallet.PublicKey().String())
+	return nil
+}
+
+func (f *fakeRotator) RetireServerWallet(pubkey solana.PublicKey) error {
+	for i, address := range f.active {
+		if address == pubkey.String() {
+			f.active = append(f.active[:i], f.active[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", x402solana.ErrWalletNotFound, pubkey)
+}
+
+func TestServerWalletAdminEndpoints(t *testing.T) {
+	cfg := &config.Config{Server: config.ServerConfig{RoutePrefix: "/api", AdminMetricsAPIKey: "secret"}}
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	svc := paywall.NewService(cfg, store, nil, nil, products.NewYAMLRepository(nil), nil, nil)
+	idem := idempotency.NewMemoryStore()
+	t.Cleanup(idem.Stop)
+
+	existing := solana.NewWallet().PublicKey().String()
+	rotator := &fakeRotator{active: []string{existing}}
+	router := chi.NewRouter()
+	if err := ConfigureRouter(router, cfg, svc, nil, rotator, NewRPCProxyHandlers(cfg), nil, nil, idem, nil, nil, zerolog.Nop()); err != nil {
+		t.Fatalf("ConfigureRouter: %v", err)
+	}
+
+	added := solana.NewWallet()
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		auth       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "list without key", method: http.MethodGet, path: "/api/admin/wallets", wantStatus: http.StatusUnauthorized},
+		{name: "list", method: http.MethodGet, path: "/api/admin/wallets", auth: "Bearer secret", wantStatus: http.StatusOK, wantBody: existing},
+		{name: "add missing key", method: http.MethodPost, path: "/api/admin/wallets", body: `{}`, auth: "Bearer secret", wantStatus: http.StatusBadRequest, wantBody: "key required"},
+		{name: "add invalid key", method: http.MethodPost, path: "/api/admin/wallets", body: `{"key":"not-a-key"}`, auth: "Bearer secret", wantStatus: http.StatusBadRequest, wantBody: "invalid_wallet"},
+		{name: "add", method: http.MethodPost, path: "/api/admin/wallets", body: `{"key":"` + added.PrivateKey.String() + `"}`, auth: "Bearer secret", wantStatus: http.StatusCreated, wantBody: added.PublicKey().String()},
+		{name: "retire", method: http.MethodPost, path: "/api/admin/wallets/" + existing + "/retire", auth: "Bearer secret", wantStatus: http.StatusAccepted, wantBody: `"draining"`},
+		{name: "retire unknown", method: http.MethodPost, path: "/api/admin/wallets/" + existing + "/retire", auth: "Bearer secret", wantStatus: http.StatusNotFound, wantBody: "wallet_not_found"},
+		{name: "retire invalid address", method: http.MethodPost, path: "/api/admin/wallets/nope/retire", auth: "Bearer secret", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantBody != "" && !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body missing %q: %.300s", tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+
+	if len(rotator.active) != 1 || rotator.active[0] != added.PublicKey().String() {
+		t.Errorf("active wallets = %v, want only the added wallet", rotator.active)
+	}
+}
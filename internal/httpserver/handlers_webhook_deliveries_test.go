@@ -0,0 +1,89 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/idempotency"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/products"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func TestAdminWebhookDeliveries(t *testing.T) {
+	cfg := &config.Config{
+		Server: config.ServerConfig{RoutePrefix: "/api", AdminMetricsAPIKey: "secret"},
+		X402:   config.X402Config{PaymentAddress: "11111111111111111111111111111111", Network: "mainnet-beta", TokenMint: "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v", TokenDecimals: 6},
+	}
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	svc := paywall.NewService(cfg, store, nil, nil, products.NewYAMLRepository(nil), nil, nil)
+	idem := idempotency.NewMemoryStore()
+	t.Cleanup(idem.Stop)
+	router := chi.NewRouter()
+	if err := ConfigureRouter(router, cfg, svc, nil, nil, NewRPCProxyHandlers(cfg), nil, nil, idem, nil, nil, zerolog.Nop()); err != nil {
+		t.Fatalf("ConfigureRouter: %v", err)
+	}
+
+	now := time.Now().UTC()
+	for _, d := range []storage.WebhookDelivery{
+		{EventID: "evt_1", EventType: "payment", URL: "https://a.example.com/hook", Attempt: 1, StatusCode: 503, Error: "received status 503", AttemptedAt: now.Add(-time.Minute)},
+		{EventID: "evt_1", EventType: "payment", URL: "https://a.example.com/hook", Attempt: 2, StatusCode: 200, Success: true, AttemptedAt: now},
+		{EventID: "evt_2", EventType: "refund", URL: "https://b.example.com/hook", Attempt: 1, StatusCode: 204, Success: true, AttemptedAt: now},
+	} {
+		if err := store.RecordWebhookDelivery(context.Background(), d); err != nil {
+			t.Fatalf("RecordWebhookDelivery: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		auth       string
+		wantStatus int
+		wantCount  int
+	}{
+		{name: "all", wantStatus: http.StatusOK, wantCount: 3},
+		{name: "by event", query: "?eventId=evt_1", wantStatus: http.StatusOK, wantCount: 2},
+		{name: "failed at url", query: "?url=https://a.example.com/hook&status=failed", wantStatus: http.StatusOK, wantCount: 1},
+		{name: "by event type", query: "?eventType=refund", wantStatus: http.StatusOK, wantCount: 1},
+		{name: "limit", query: "?limit=1", wantStatus: http.StatusOK, wantCount: 1},
+		{name: "bad status", query: "?status=maybe", wantStatus: http.StatusBadRequest},
+		{name: "bad since", query: "?since=yesterday", wantStatus: http.StatusBadRequest},
+		{name: "unauthorized", auth: "Bearer wrong", wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/webhooks/deliveries"+tt.query, nil)
+			auth := tt.auth
+			if auth == "" {
+				auth = "Bearer secret"
+			}
+			req.Header.Set("Authorization", auth)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var resp webhookDeliveriesResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if resp.Count != tt.wantCount || len(resp.Deliveries) != tt.wantCount {
+				t.Errorf("count = %d (%d deliveries), want %d", resp.Count, len(resp.Deliveries), tt.wantCount)
+			}
+		})
+	}
+}
@@ -3,7 +3,8 @@ package httpserver
 import (
 	"encoding/json"
 	"net/http"
-	"strconv"
+
+	"github.com/CedrosPay/server/internal/money"
 )
 
 // WellKnownPaymentOptions represents the /.well-known/payment-options response
@@ -33,14 +34,16 @@ type WellKnownPriceInfo struct {
 
 // WellKnownFiatPrice contains fiat pricing
 type WellKnownFiatPrice struct {
-	Amount   float64 `json:"amount"`
-	Currency string  `json:"currency"`
+	Amount        money.LegacyFloat `json:"amount,omitzero"`
+	AmountDecimal string            `json:"amountDecimal"` // Amount as an exact decimal string
+	Currency      string            `json:"currency"`
 }
 
 // WellKnownCryptoPrice contains crypto pricing
 type WellKnownCryptoPrice struct {
-	Amount float64 `json:"amount"`
-	Token  string  `json:"token"`
+	Amount        money.LegacyFloat `json:"amount,omitzero"`
+	AmountDecimal string            `json:"amountDecimal"` // Amount as an exact decimal string
+	Token         string            `json:"token"`
 }
 
 // WellKnownPaymentInfo describes supported payment methods
@@ -72,19 +75,22 @@ func (h *handlers) wellKnownPaymentOptions(w http.ResponseWriter, r *http.Reques
 	resources := make([]WellKnownResourceEntry, 0, len(products))
 	for _, p := range products {
 		// Extract pricing from Money types
-		var fiatAmount, cryptoAmount float64
-		var fiatCurrency, cryptoToken string
-
+		fiat := &WellKnownFiatPrice{}
 		if p.FiatPrice != nil {
-			fiatAmountStr := p.FiatPrice.ToMajor()
-			fiatAmount, _ = strconv.ParseFloat(fiatAmountStr, 64)
-			fiatCurrency = p.FiatPrice.Asset.Code
+			fiat = &WellKnownFiatPrice{
+				Amount:        p.FiatPrice.LegacyFloat(),
+				AmountDecimal: p.FiatPrice.ToMajor(),
+				Currency:      p.FiatPrice.Asset.Code,
+			}
 		}
 
+		crypto := &WellKnownCryptoPrice{}
 		if p.CryptoPrice != nil {
-			cryptoAmountStr := p.CryptoPrice.ToMajor()
-			cryptoAmount, _ = strconv.ParseFloat(cryptoAmountStr, 64)
-			cryptoToken = p.CryptoPrice.Asset.Code
+			crypto = &WellKnownCryptoPrice{
+				Amount:        p.CryptoPrice.LegacyFloat(),
+				AmountDecimal: p.CryptoPrice.ToMajor(),
+				Token:         p.CryptoPrice.Asset.Code,
+			}
 		}
 
 		entry := WellKnownResourceEntry{
@@ -92,17 +98,8 @@ func (h *handlers) wellKnownPaymentOptions(w http.ResponseWriter, r *http.Reques
 			Name:        p.Description,
 			Description: p.Description,
 			Endpoint:    "/paywall/" + p.ID,
-			Price: WellKnownPriceInfo{
-				Fiat: &WellKnownFiatPrice{
-					Amount:   fiatAmount,
-					Currency: fiatCurrency,
-				},
-				Crypto: &WellKnownCryptoPrice{
-					Amount: cryptoAmount,
-					Token:  cryptoToken,
-				},
-			},
-			Metadata: p.Metadata,
+			Price:       WellKnownPriceInfo{Fiat: fiat, Crypto: crypto},
+			Metadata:    p.Metadata,
 		}
 		resources = append(resources, entry)
 	}
@@ -0,0 +1,104 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+// adminAuditAPIKeySigner is the signer recorded for calls authenticated with the admin API key.
+const adminAuditAPIKeySigner = "admin-api-key"
+
+// adminAuditUnsigned is the signer recorded for wallet-signed endpoints called without X-Signer.
+const adminAuditUnsigned = "unsigned"
+
+// walletSigner returns the wallet that signed a request. The audit entry's result shows
+// whether the signature was accepted.
+func walletSigner(r *http.Request) string {
+	if signer := r.Header.Get("X-Signer"); signer != "" {
+		return signer
+	}
+	return adminAuditUnsigned
+}
+
+// apiKeySigner attributes a request to the admin API key.
+func apiKeySigner(*http.Request) string {
+	return adminAuditAPIKeySigner
+}
+
+// adminOrScopedKeySigner attributes a request to the admin API key, or else to the scoped API key
+// in X-API-Key (see adminScopeAuth), recorded by hash so the key itself is never stored.
+func adminOrScopedKeySigner(adminKey string) func(*http.Request) string {
+	return func(r *http.Request) string {
+		key := strings.TrimSpace(r.Header.Get("X-API-Key"))
+		if hasAdminKey(adminKey, r) || key == "" {
+			return adminAuditAPIKeySigner
+		}
+		return paywall.MeterAccountForAPIKey(key)
+	}
+}
+
+// auditAdminActions is middleware that records every state-changing request it wraps in the
+// admin audit log as action, with the signer, a hash of the request body, and the response
+// status. Reads (GET, HEAD, OPTIONS) are not recorded.
+func (h *handlers) auditAdminActions(action string, signer func(*http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			log := logger.FromContext(r.Context())
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				log.Warn().Err(err).Str("action", action).Msg("admin_audit.read_body_failed")
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			hash := sha256.Sum256(body)
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			result := storage.AdminAuditSuccess
+			if status >= http.StatusBadRequest {
+				result = storage.AdminAuditFailure
+			}
+			entry := storage.AdminAuditEntry{
+				Action:      action,
+				Signer:      signer(r),
+				Method:      r.Method,
+				Path:        r.URL.Path,
+				RequestHash: hex.EncodeToString(hash[:]),
+				Status:      status,
+				Result:      result,
+				RecordedAt:  time.Now().UTC(),
+			}
+			// Record even if the client went away or the request timed out
+			if err := h.paywall.RecordAdminAction(context.WithoutCancel(r.Context()), entry); err != nil {
+				log.Error().
+					Err(err).
+					Str("action", action).
+					Str("signer", entry.Signer).
+					Int("status", status).
+					Msg("admin_audit.record_failed")
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package httpserver
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/CedrosPay/server/internal/paywall"
+)
+
+// clientIPMiddleware passes the client IP (as resolved by middleware.RealIP) to the paywall
+// service, whose quotes_per_ip fraud rule counts quotes per IP.
+func clientIPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := r.RemoteAddr
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+		next.ServeHTTP(w, r.WithContext(paywall.WithClientIP(r.Context(), ip)))
+	})
+}
@@ -1,13 +1,13 @@
 package httpserver
 
 import (
-	"encoding/json"
+	"crypto/subtle"
 	"net/http"
 
 	apierrors "github.com/CedrosPay/server/internal/errors"
 )
 
-// adminMetricsAuth is middleware that protects the /metrics endpoint with an API key.
+// adminMetricsAuth is middleware that protects the /metrics and /debug endpoints with an API key.
 // If no API key is configured, the endpoint is accessible without authentication.
 // If an API key is configured, requests must include an "Authorization: Bearer {key}" header.
 func adminMetricsAuth(apiKey string) func(http.Handler) http.Handler {
@@ -19,16 +19,8 @@ func adminMetricsAuth(apiKey string) func(http.Handler) http.Handler {
 				return
 			}
 
-			// Check Authorization header
-			authHeader := r.Header.Get("Authorization")
-			expectedHeader := "Bearer " + apiKey
-
-			if authHeader != expectedHeader {
-				// Return 401 Unauthorized with appropriate error code
-				resp := apierrors.NewErrorResponse(apierrors.ErrCodeInvalidField, "Invalid or missing admin API key", nil)
-				w.Header().Set("Content-Type", "application/json")
-				w.WriteHeader(http.StatusUnauthorized)
-				_ = json.NewEncoder(w).Encode(resp)
+			if !hasAdminKey(apiKey, r) {
+				apierrors.WriteSimpleError(w, apierrors.ErrCodeUnauthorized, "Invalid or missing admin API key")
 				return
 			}
 
@@ -37,3 +29,13 @@ func adminMetricsAuth(apiKey string) func(http.Handler) http.Handler {
 		})
 	}
 }
+
+// hasAdminKey reports whether the request carries "Authorization: Bearer {apiKey}". It is false
+// when no admin API key is configured.
+func hasAdminKey(apiKey string, r *http.Request) bool {
+	if apiKey == "" {
+		return false
+	}
+	expectedHeader := "Bearer " + apiKey
+	return subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(expectedHeader)) == 1
+}
@@ -0,0 +1,157 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// schemaStandIns document types with custom JSON encodings using the type they encode as.
+var schemaStandIns = map[reflect.Type]reflect.Type{
+	reflect.TypeOf(money.Money{}):         reflect.TypeOf(money.MoneyJSON{}),
+	reflect.TypeOf(money.MoneyRequest{}):  reflect.TypeOf(money.MoneyJSON{}),
+	reflect.TypeOf(money.MoneyResponse{}): reflect.TypeOf(money.MoneyJSON{}),
+}
+
+// schemaRegistry derives JSON Schema (draft 2020-12, as used by OpenAPI 3.1) from Go types
+// via their json tags. Named structs are emitted once under components/schemas and referenced.
+type schemaRegistry struct {
+	schemas map[string]any
+	names   map[reflect.Type]string
+}
+
+func newSchemaRegistry() *schemaRegistry {
+	return &schemaRegistry{
+		schemas: make(map[string]any),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+// schemaFor returns the schema for v's type, or nil if v is nil.
+func (reg *schemaRegistry) schemaFor(v any) map[string]any {
+	if v == nil {
+		return nil
+	}
+	return reg.schema(reflect.TypeOf(v))
+}
+
+func (reg *schemaRegistry) schema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if standIn, ok := schemaStandIns[t]; ok {
+		t = standIn
+	}
+
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t.Kind() != reflect.Interface && t.Implements(jsonMarshalerType):
+		// Custom encoding we have no stand-in for: accept anything rather than guess
+		return map[string]any{}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]any{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint, reflect.Uint64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Float32:
+		return map[string]any{"type": "number", "format": "float"}
+	case reflect.Float64:
+		return map[string]any{"type": "number", "format": "double"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]any{"type": "array", "items": reg.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": reg.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return reg.objectSchema(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + reg.register(t)}
+	default:
+		// interface{} and anything else without a static shape
+		return map[string]any{}
+	}
+}
+
+// register adds a named struct to the registry and returns its component name.
+func (reg *schemaRegistry) register(t reflect.Type) string {
+	if name, ok := reg.names[t]; ok {
+		return name
+	}
+	name := exportedName(t.Name())
+	if _, taken := reg.schemas[name]; taken {
+		// Same type name in two packages (e.g. paywall.CartItem, storage.CartItem)
+		name = exportedName(path.Base(t.PkgPath())) + name
+	}
+	reg.names[t] = name
+	reg.schemas[name] = map[string]any{} // Placeholder so recursive types terminate
+	reg.schemas[name] = reg.objectSchema(t)
+	return name
+}
+
+func (reg *schemaRegistry) objectSchema(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	reg.addFields(t, properties)
+	return map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+}
+
+func (reg *schemaRegistry) addFields(t reflect.Type, properties map[string]any) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		// Promote fields of untagged embedded structs, as encoding/json does
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				reg.addFields(ft, properties)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = reg.schema(field.Type)
+	}
+}
+
+func exportedName(name string) string {
+	if name == "" {
+		return name
+	}
+	runes := []rune(name)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
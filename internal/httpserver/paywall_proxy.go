@@ -0,0 +1,171 @@
+package httpserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/CedrosPay/server/internal/config"
+	apierrors "github.com/CedrosPay/server/internal/errors"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/pkg/paywallhttp"
+)
+
+// Entitlement headers the proxy sends upstream with paid requests. The proxy drops any a client
+// sends, so the upstream can trust them.
+var entitlementHeaders = []string{"X-Cedros-Resource", "X-Cedros-Wallet", "X-Cedros-Signature", "X-Cedros-Meter-Account"}
+
+// Client credentials Cedros consumes. The proxy never forwards them, so the upstream can't
+// replay a payment, API key, or wallet signature.
+var cedrosAuthHeaders = []string{"X-PAYMENT", "X-API-Key", "X-Signer", "X-Message", "X-Signature"}
+
+// meteredCall is a proxied request charged to a meter account, and the balance left after it.
+type meteredCall struct{ resource, account, balance string }
+
+type meteredCallKey struct{}
+
+// newPaywallProxy forwards requests to cfg.Proxy.Upstream, requiring payment first for those
+// matching a pattern in cfg.Proxy.Routes. Routes whose resource has a metering price are
+// charged per call to the caller's meter account instead.
+func newPaywallProxy(cfg *config.Config, paywallSvc *paywall.Service, appLogger zerolog.Logger) (http.Handler, error) {
+	proxy := cfg.Proxy
+	upstream, err := url.Parse(proxy.Upstream)
+	if err != nil {
+		return nil, fmt.Errorf("parse proxy upstream: %w", err)
+	}
+
+	forward := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(upstream)
+			pr.SetXForwarded()
+			for _, header := range cedrosAuthHeaders {
+				pr.Out.Header.Del(header)
+			}
+			for _, header := range entitlementHeaders {
+				pr.Out.Header.Del(header)
+			}
+			if payment, ok := paywallhttp.PaymentFromContext(pr.In.Context()); ok {
+				pr.Out.Header.Set("X-Cedros-Resource", payment.Resource)
+				pr.Out.Header.Set("X-Cedros-Wallet", payment.Wallet)
+				pr.Out.Header.Set("X-Cedros-Signature", payment.Signature)
+			}
+			if call, ok := pr.In.Context().Value(meteredCallKey{}).(meteredCall); ok {
+				pr.Out.Header.Set("X-Cedros-Resource", call.resource)
+				pr.Out.Header.Set("X-Cedros-Meter-Account", call.account)
+			}
+		},
+		// Metered calls are charged before forwarding and refunded if the upstream fails
+		ModifyResponse: func(resp *http.Response) error {
+			call, ok := resp.Request.Context().Value(meteredCallKey{}).(meteredCall)
+			if !ok {
+				return nil
+			}
+			if resp.StatusCode >= http.StatusInternalServerError {
+				refundMeteredCall(resp.Request, paywallSvc, call, appLogger)
+				return nil
+			}
+			resp.Header.Set("X-Cedros-Meter-Balance", call.balance)
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			if call, ok := r.Context().Value(meteredCallKey{}).(meteredCall); ok {
+				refundMeteredCall(r, paywallSvc, call, appLogger)
+			}
+			appLogger.Warn().Err(err).Str("path", r.URL.Path).Msg("proxy.upstream_failed")
+			apierrors.WriteError(w, apierrors.ErrCodeUpstreamUnavailable, "upstream unavailable", nil)
+		},
+	}
+
+	type routeKey struct{ method, route string }
+	backend := paywallhttp.NewEmbedded(paywallSvc)
+	routes := chi.NewRouter()
+	paid := make(map[routeKey]http.Handler, len(proxy.Routes))
+	noop := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+	for pattern, resource := range proxy.Routes {
+		method, route, err := config.ParseRateLimitEndpoint(pattern)
+		if err != nil {
+			continue // Rejected by config validation
+		}
+		if method == "" {
+			routes.Handle(route, noop)
+		} else {
+			routes.Method(method, route, noop)
+		}
+		if _, metered := paywallSvc.MeterPrice(resource); metered {
+			paid[routeKey{method, route}] = meteredProxy(cfg, paywallSvc, resource, forward)
+		} else {
+			paid[routeKey{method, route}] = paywallhttp.Middleware(backend, paywallhttp.Resource(resource))(forward)
+		}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Match and forward the same path, so /free/../reports/q3 is priced like /reports/q3
+		if canonical := canonicalPath(r.URL.Path); canonical != r.URL.Path {
+			r = r.Clone(r.Context())
+			r.URL.Path, r.URL.RawPath = canonical, ""
+		}
+		route := routes.Find(chi.NewRouteContext(), r.Method, r.URL.Path)
+		handler, ok := paid[routeKey{r.Method, route}]
+		if !ok {
+			handler, ok = paid[routeKey{"", route}]
+		}
+		if route == "" || !ok {
+			handler = forward
+		}
+		handler.ServeHTTP(w, r)
+	}), nil
+}
+
+// canonicalPath resolves dot segments and repeated slashes in a request path, keeping a trailing
+// slash, so each upstream path has one spelling for route matching.
+func canonicalPath(p string) string {
+	cleaned := path.Clean("/" + p)
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned
+}
+
+// meteredProxy charges one call to resource against the caller's meter account (see
+// requestMeterAccount) and forwards the request; the charge is refunded if the upstream answers
+// with a server error or cannot be reached. Callers without an account, or whose balance is too
+// low, get 402 meter_balance_insufficient.
+func meteredProxy(cfg *config.Config, paywallSvc *paywall.Service, resource string, forward http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		account, err := requestMeterAccount(cfg, paywallSvc, r)
+		if err != nil || account == "" {
+			message := "metered endpoint: send X-API-Key, or sign the message 'metering:<wallet>:<nonce>' with your wallet"
+			if err != nil {
+				message = err.Error()
+			}
+			apierrors.WriteErrorWithDetail(w, apierrors.ErrCodeMeterBalanceInsufficient, message, "resourceId", resource)
+			return
+		}
+		meter, err := paywallSvc.ChargeMetered(r.Context(), account, resource)
+		if err != nil {
+			writeMeteringError(w, r, err, resource)
+			return
+		}
+		call := meteredCall{resource: resource, account: account, balance: meter.Balance.ToMajor()}
+		forward.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), meteredCallKey{}, call)))
+	})
+}
+
+// refundMeteredCall returns the charge for a metered call the upstream did not serve. A failed
+// refund is logged; the caller has already been charged.
+func refundMeteredCall(r *http.Request, paywallSvc *paywall.Service, call meteredCall, appLogger zerolog.Logger) {
+	if _, err := paywallSvc.RefundMetered(context.WithoutCancel(r.Context()), call.account, call.resource); err != nil {
+		appLogger.Error().
+			Err(err).
+			Str("meter_account", call.account).
+			Str("resource_id", call.resource).
+			Msg("proxy.meter_refund_failed")
+	}
+}
@@ -0,0 +1,285 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/idempotency"
+	"github.com/CedrosPay/server/internal/money"
+	"github.com/CedrosPay/server/internal/paywall"
+	"github.com/CedrosPay/server/internal/products"
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/pkg/x402"
+)
+
+// payerVerifier accepts every payment as made by wallet.
+type payerVerifier struct{ wallet string }
+
+func (v payerVerifier) Verify(_ context.Context, proof x402.PaymentProof, requirement x402.Requirement) (x402.VerificationResult, error) {
+	return x402.VerificationResult{Wallet: v.wallet, Signature: proof.Signature, Amount: requirement.Amount, ExpiresAt: time.Now().Add(time.Hour)}, nil
+}
+
+func TestPaywallProxy(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var credentials []string
+		for _, header := range cedrosAuthHeaders {
+			credentials = append(credentials, r.Header.Get(header))
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"path":        r.URL.Path,
+			"resource":    r.Header.Get("X-Cedros-Resource"),
+			"wallet":      r.Header.Get("X-Cedros-Wallet"),
+			"signature":   r.Header.Get("X-Cedros-Signature"),
+			"credentials": strings.Join(credentials, ""),
+			"meter":       r.Header.Get("X-Cedros-Meter-Account"),
+		})
+	}))
+	t.Cleanup(upstream.Close)
+
+	cfg := &config.Config{
+		X402: config.X402Config{
+			PaymentAddress: "11111111111111111111111111111111",
+			TokenMint:      "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v",
+			Network:        "mainnet-beta",
+			AllowedTokens:  []string{"USDC"},
+			TokenDecimals:  6,
+		},
+		Paywall: config.PaywallConfig{
+			QuoteTTL: config.Duration{Duration: time.Minute},
+			Resources: map[string]config.PaywallResource{
+				"premium-report": {ResourceID: "premium-report", CryptoAtomicAmount: 1000000, CryptoToken: "USDC", CryptoAccount: "11111111111111111111111111111111"},
+			},
+		},
+		Proxy: config.ProxyConfig{
+			Enabled:  true,
+			Upstream: upstream.URL,
+			Routes:   map[string]string{"GET /reports/*": "premium-report", "GET /api/*": "api-call"},
+		},
+		Metering: config.MeteringConfig{
+			Enabled:          true,
+			Asset:            "USDC",
+			DepositResources: []string{"premium-report"},
+			Prices:           map[string]int64{"api-call": 250000},
+		},
+		APIKey: config.APIKeyConfig{Enabled: true, Keys: map[string]string{"partner_key": "pro"}},
+	}
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	idem := idempotency.NewMemoryStore()
+	t.Cleanup(idem.Stop)
+	svc := paywall.NewService(cfg, store, payerVerifier{wallet: "payer-wallet"}, nil, products.NewYAMLRepository(cfg.Paywall.Resources), nil, nil)
+	meterAccount := paywall.MeterAccountForAPIKey("partner_key")
+	if _, err := store.CreditMeterAccount(context.Background(), meterAccount, money.New(money.MustGetAsset("USDC"), 300000)); err != nil {
+		t.Fatalf("CreditMeterAccount: %v", err)
+	}
+	walletKey, err := solana.NewRandomPrivateKey()
+	if err != nil {
+		t.Fatalf("NewRandomPrivateKey: %v", err)
+	}
+	wallet := walletKey.PublicKey().String()
+	walletAccount := paywall.MeterAccountForWallet(wallet)
+	if _, err := store.CreditMeterAccount(context.Background(), walletAccount, money.New(money.MustGetAsset("USDC"), 300000)); err != nil {
+		t.Fatalf("CreditMeterAccount: %v", err)
+	}
+	if err := store.CreateNonce(context.Background(), storage.AdminNonce{ID: "nonce-1", Purpose: "metering", CreatedAt: time.Now(), ExpiresAt: time.Now().Add(storage.NonceTTL)}); err != nil {
+		t.Fatalf("CreateNonce: %v", err)
+	}
+	message := "metering:" + wallet + ":nonce-1"
+	walletSignature, err := walletKey.Sign([]byte(message))
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	signed := map[string]string{"X-Signer": wallet, "X-Message": message, "X-Signature": base64.StdEncoding.EncodeToString(walletSignature[:])}
+	unsignedNonce := map[string]string{"X-Signer": wallet, "X-Message": "metering:" + wallet, "X-Signature": base64.StdEncoding.EncodeToString(walletSignature[:])}
+	router := chi.NewRouter()
+	if err := ConfigureRouter(router, cfg, svc, nil, nil, NewRPCProxyHandlers(cfg), nil, nil, idem, nil, nil, zerolog.Nop()); err != nil {
+		t.Fatalf("ConfigureRouter: %v", err)
+	}
+
+	payload, _ := json.Marshal(x402.PaymentPayload{
+		Scheme:  x402.SchemeSolanaSPLTransfer,
+		Network: cfg.X402.Network,
+		Payload: x402.SolanaPayload{Signature: "proxy-signature", Transaction: base64.StdEncoding.EncodeToString([]byte("signed-tx"))},
+	})
+	paymentHeader := base64.StdEncoding.EncodeToString(payload)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		payment    string
+		apiKey     string
+		headers    map[string]string
+		wantStatus int
+		wantPath   string
+		wantWallet string
+		wantMeter  string
+	}{
+		{name: "unpriced path forwarded free", method: http.MethodGet, path: "/about", wantStatus: http.StatusOK},
+		{name: "other method forwarded free", method: http.MethodPost, path: "/reports/q3", wantStatus: http.StatusOK},
+		{name: "priced path without payment gets quote", method: http.MethodGet, path: "/reports/q3", wantStatus: http.StatusPaymentRequired},
+		{name: "priced path with payment", method: http.MethodGet, path: "/reports/q3", payment: paymentHeader, wantStatus: http.StatusOK, wantWallet: "payer-wallet"},
+		{name: "dot segments priced", method: http.MethodGet, path: "/free/../reports/q3", wantStatus: http.StatusPaymentRequired},
+		{name: "current directory segment priced", method: http.MethodGet, path: "/reports/./q3", wantStatus: http.StatusPaymentRequired},
+		{name: "repeated slashes priced", method: http.MethodGet, path: "//reports/q3", wantStatus: http.StatusPaymentRequired},
+		{name: "encoded dot segments priced", method: http.MethodGet, path: "/free/%2e%2e/reports/q3", wantStatus: http.StatusPaymentRequired},
+		{name: "canonical path forwarded", method: http.MethodGet, path: "/about/./team/", wantStatus: http.StatusOK, wantPath: "/about/team/"},
+		{name: "cedros routes still served", method: http.MethodGet, path: "/healthz", wantStatus: http.StatusOK},
+		{name: "metered path without account", method: http.MethodGet, path: "/api/search", wantStatus: http.StatusPaymentRequired},
+		{name: "metered path with unknown key", method: http.MethodGet, path: "/api/search", apiKey: "guessed", wantStatus: http.StatusPaymentRequired},
+		{name: "metered upstream failure not charged", method: http.MethodGet, path: "/api/broken", apiKey: "partner_key", wantStatus: http.StatusInternalServerError},
+		{name: "metered path charged to key", method: http.MethodGet, path: "/api/search", apiKey: "partner_key", wantStatus: http.StatusOK, wantMeter: meterAccount},
+		{name: "metered path with balance spent", method: http.MethodGet, path: "/api/search", apiKey: "partner_key", wantStatus: http.StatusPaymentRequired},
+		{name: "metered wallet without nonce", method: http.MethodGet, path: "/api/search", headers: unsignedNonce, wantStatus: http.StatusPaymentRequired},
+		{name: "metered path charged to wallet", method: http.MethodGet, path: "/api/search", headers: signed, wantStatus: http.StatusOK, wantMeter: walletAccount},
+		{name: "replayed wallet signature", method: http.MethodGet, path: "/api/search", headers: signed, wantStatus: http.StatusPaymentRequired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("X-Cedros-Wallet", "spoofed")
+			if tt.payment != "" {
+				req.Header.Set("X-PAYMENT", tt.payment)
+			}
+			if tt.apiKey != "" {
+				req.Header.Set("X-API-Key", tt.apiKey)
+			}
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusOK || tt.path == "/healthz" {
+				return
+			}
+			var forwarded map[string]string
+			if err := json.Unmarshal(rec.Body.Bytes(), &forwarded); err != nil {
+				t.Fatalf("decode upstream echo: %v: %s", err, rec.Body)
+			}
+			wantPath := tt.path
+			if tt.wantPath != "" {
+				wantPath = tt.wantPath
+			}
+			if forwarded["path"] != wantPath || forwarded["wallet"] != tt.wantWallet || forwarded["meter"] != tt.wantMeter || forwarded["credentials"] != "" {
+				t.Fatalf("upstream saw %v, want path %s, wallet %q, meter account %q, and no credentials", forwarded, wantPath, tt.wantWallet, tt.wantMeter)
+			}
+			if tt.wantMeter != "" && rec.Header().Get("X-Cedros-Meter-Balance") != "0.050000" {
+				t.Errorf("X-Cedros-Meter-Balance = %q, want 0.050000", rec.Header().Get("X-Cedros-Meter-Balance"))
+			}
+			if tt.wantWallet != "" && (forwarded["resource"] != "premium-report" || forwarded["signature"] != "proxy-signature") {
+				t.Errorf("entitlement headers = %v", forwarded)
+			}
+		})
+	}
+}
+
+func TestPaywallProxyMeteredCharges(t *testing.T) {
+	var served atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	cfg := &config.Config{
+		Proxy: config.ProxyConfig{Enabled: true, Upstream: upstream.URL, Routes: map[string]string{"GET /api/*": "api-call"}},
+		Metering: config.MeteringConfig{
+			Enabled: true,
+			Asset:   "USDC",
+			Prices:  map[string]int64{"api-call": 250000},
+		},
+		APIKey: config.APIKeyConfig{Enabled: true, Keys: map[string]string{"partner_key": "pro"}},
+	}
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	idem := idempotency.NewMemoryStore()
+	t.Cleanup(idem.Stop)
+	svc := paywall.NewService(cfg, store, payerVerifier{}, nil, products.NewYAMLRepository(nil), nil, nil)
+	account := paywall.MeterAccountForAPIKey("partner_key")
+	usdc := money.MustGetAsset("USDC")
+	if _, err := store.CreditMeterAccount(context.Background(), account, money.New(usdc, 300000)); err != nil {
+		t.Fatalf("CreditMeterAccount: %v", err)
+	}
+	router := chi.NewRouter()
+	if err := ConfigureRouter(router, cfg, svc, nil, nil, NewRPCProxyHandlers(cfg), nil, nil, idem, nil, nil, zerolog.Nop()); err != nil {
+		t.Fatalf("ConfigureRouter: %v", err)
+	}
+	call := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/api/search", nil)
+		req.Header.Set("X-API-Key", "partner_key")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Concurrent calls the balance covers once reach the upstream once
+	statuses := make(chan int, 5)
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			statuses <- call()
+		}()
+	}
+	wg.Wait()
+	close(statuses)
+	var ok int
+	for status := range statuses {
+		if status == http.StatusOK {
+			ok++
+		} else if status != http.StatusPaymentRequired {
+			t.Errorf("status = %d, want 200 or 402", status)
+		}
+	}
+	if ok != 1 || served.Load() != 1 {
+		t.Fatalf("%d calls succeeded and the upstream served %d, want 1 each", ok, served.Load())
+	}
+
+	// An unreachable upstream costs nothing
+	if _, err := store.CreditMeterAccount(context.Background(), account, money.New(usdc, 250000)); err != nil {
+		t.Fatalf("CreditMeterAccount: %v", err)
+	}
+	upstream.Close()
+	if status := call(); status != http.StatusBadGateway {
+		t.Fatalf("status with upstream down = %d, want 502", status)
+	}
+	meter, err := svc.MeterBalance(context.Background(), account)
+	if err != nil {
+		t.Fatalf("MeterBalance: %v", err)
+	}
+	if meter.Balance.Atomic != 300000 || meter.Unsettled.Atomic != 250000 || meter.Calls != 1 {
+		t.Errorf("meter account = %+v, want 0.30 balance and one 0.25 call", meter)
+	}
+}
+
+func TestConfigureRouterRejectsBadProxyUpstream(t *testing.T) {
+	cfg := &config.Config{Proxy: config.ProxyConfig{Enabled: true, Upstream: "http://[::1"}}
+	svc := paywall.NewService(cfg, storage.NewMemoryStore(), payerVerifier{}, nil, products.NewYAMLRepository(nil), nil, nil)
+	idem := idempotency.NewMemoryStore()
+	t.Cleanup(idem.Stop)
+	if err := ConfigureRouter(chi.NewRouter(), cfg, svc, nil, nil, NewRPCProxyHandlers(cfg), nil, nil, idem, nil, nil, zerolog.Nop()); err == nil {
+		t.Fatal("ConfigureRouter succeeded with an unparseable proxy upstream")
+	}
+}
@@ -3,10 +3,12 @@ package httpserver
 import (
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 
 	apierrors "github.com/CedrosPay/server/internal/errors"
+	"github.com/CedrosPay/server/internal/money"
 	"github.com/CedrosPay/server/internal/paywall"
 	"github.com/CedrosPay/server/pkg/responders"
 	"github.com/CedrosPay/server/pkg/x402"
@@ -40,6 +42,42 @@ func paymentVerificationFailedResponse(w http.ResponseWriter, err error, resourc
 		})
 		return
 	}
+	if errors.Is(err, paywall.ErrCouponWalletLimit) {
+		apierrors.WriteError(w, apierrors.ErrCodeCouponUsageLimitReached, err.Error(), map[string]interface{}{
+			resourceKey(resourceType): resourceID,
+		})
+		return
+	}
+	if errors.Is(err, paywall.ErrOutOfStock) {
+		apierrors.WriteError(w, apierrors.ErrCodeOutOfStock, err.Error(), map[string]interface{}{
+			resourceKey(resourceType): resourceID,
+		})
+		return
+	}
+	if errors.Is(err, paywall.ErrVelocityLimit) {
+		apierrors.WriteError(w, apierrors.ErrCodeVelocityLimitExceeded, err.Error(), map[string]interface{}{
+			resourceKey(resourceType): resourceID,
+		})
+		return
+	}
+	if errors.Is(err, paywall.ErrAccessDenied) {
+		apierrors.WriteError(w, apierrors.ErrCodeAccessDenied, err.Error(), map[string]interface{}{
+			resourceKey(resourceType): resourceID,
+		})
+		return
+	}
+	if errors.Is(err, paywall.ErrWalletFlagged) {
+		apierrors.WriteError(w, apierrors.ErrCodeComplianceBlocked, err.Error(), map[string]interface{}{
+			resourceKey(resourceType): resourceID,
+		})
+		return
+	}
+	if errors.Is(err, paywall.ErrScreeningUnavailable) {
+		apierrors.WriteError(w, apierrors.ErrCodeScreeningUnavailable, err.Error(), map[string]interface{}{
+			resourceKey(resourceType): resourceID,
+		})
+		return
+	}
 	apierrors.WriteError(w, apierrors.ErrCodeTransactionFailed, err.Error(), map[string]interface{}{
 		resourceKey(resourceType): resourceID,
 	})
@@ -80,6 +118,36 @@ func paymentSuccessResponse(w http.ResponseWriter, resourceID, resourceType stri
 	responders.JSON(w, http.StatusOK, response)
 }
 
+// partialPaymentResponse sends a 202 Accepted response when a split cart payment was recorded
+// but the cart total is not yet covered.
+func partialPaymentResponse(w http.ResponseWriter, cartID string, result paywall.AuthorizationResult) {
+	response := map[string]any{
+		"success":                true,
+		"granted":                false,
+		"message":                fmt.Sprintf("Partial payment recorded for cart %s", cartID),
+		"method":                 result.Method,
+		"cartId":                 cartID,
+		"paidAmountDecimal":      result.Partial.PaidAmountDecimal,
+		"remainingAmountDecimal": result.Partial.RemainingAmountDecimal,
+		"totalAmountDecimal":     result.Partial.TotalAmountDecimal,
+		"token":                  result.Partial.Token,
+	}
+	if money.LegacyFloats() {
+		response["paidAmount"] = result.Partial.PaidAmount
+		response["remainingAmount"] = result.Partial.RemainingAmount
+		response["totalAmount"] = result.Partial.TotalAmount
+	}
+	if result.Wallet != "" {
+		response["wallet"] = result.Wallet
+	}
+	if result.Settlement != nil && result.Settlement.TxHash != nil {
+		response["signature"] = *result.Settlement.TxHash
+	}
+
+	addSettlementHeader(w, result.Settlement)
+	responders.JSON(w, http.StatusAccepted, response)
+}
+
 // addSettlementHeader adds X-PAYMENT-RESPONSE header if settlement exists.
 // This header contains the base64-encoded JSON settlement proof per x402 spec.
 func addSettlementHeader(w http.ResponseWriter, settlement *paywall.SettlementResponse) {
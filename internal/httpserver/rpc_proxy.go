@@ -32,7 +32,7 @@ type cachedBlockhash struct {
 func NewRPCProxyHandlers(cfg *config.Config) *rpcProxyHandlers {
 	return &rpcProxyHandlers{
 		cfg:       cfg,
-		rpcClient: rpc.New(cfg.X402.RPCURL),
+		rpcClient: rpcutil.NewClient(cfg.X402.RPCURL),
 	}
 }
 
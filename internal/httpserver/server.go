@@ -2,25 +2,34 @@ package httpserver
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
+	"github.com/graphql-go/graphql"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog"
 
 	"github.com/CedrosPay/server/internal/apikey"
+	"github.com/CedrosPay/server/internal/callbacks"
 	"github.com/CedrosPay/server/internal/config"
 	"github.com/CedrosPay/server/internal/coupons"
+	"github.com/CedrosPay/server/internal/eventbus"
+	"github.com/CedrosPay/server/internal/geoip"
+	"github.com/CedrosPay/server/internal/graphqlapi"
 	"github.com/CedrosPay/server/internal/idempotency"
 	"github.com/CedrosPay/server/internal/logger"
 	"github.com/CedrosPay/server/internal/metrics"
 	"github.com/CedrosPay/server/internal/paywall"
 	"github.com/CedrosPay/server/internal/ratelimit"
+	"github.com/CedrosPay/server/internal/storage"
 	stripesvc "github.com/CedrosPay/server/internal/stripe"
 	"github.com/CedrosPay/server/internal/subscriptions"
+	"github.com/CedrosPay/server/internal/tracing"
+	"github.com/CedrosPay/server/internal/verification"
 	"github.com/CedrosPay/server/internal/versioning"
 	"github.com/CedrosPay/server/pkg/x402"
 )
@@ -39,18 +48,74 @@ type handlers struct {
 	cfg              *config.Config
 	paywall          *paywall.Service
 	stripe           *stripesvc.Client
-	cartService      *stripesvc.CartService   // Cart service for multi-item checkouts
+	cartService      *stripesvc.CartService // Cart service for multi-item checkouts
 	verifier         x402.Verifier
 	rpcProxy         *rpcProxyHandlers
-	couponRepo       coupons.Repository       // Coupon repository
-	idempotencyStore idempotency.Store        // Idempotency store for request deduplication
-	metrics          *metrics.Metrics         // Prometheus metrics collector
-	subscriptions    *subscriptions.Service   // Subscription management service
-	logger           zerolog.Logger           // Structured logger
+	couponRepo       coupons.Repository     // Coupon repository
+	idempotencyStore idempotency.Store      // Idempotency store for request deduplication
+	metrics          *metrics.Metrics       // Prometheus metrics collector
+	subscriptions    *subscriptions.Service // Subscription management service
+	logger           zerolog.Logger         // Structured logger
+	eventBus         *eventbus.Bus          // Merchant event bus (WebSocket channel)
+	verifications    *verification.Pool     // Async verification worker pool
+	graphqlSchema    *graphql.Schema        // Storefront GraphQL schema (nil when disabled)
+	store            storage.Store          // Optional: storage backend for runtime stats and health checks
+	dlq              callbacks.DLQStore     // Optional: failed webhook store, for the admin summary
+	dlqRedriver      *callbacks.DLQRedriver // Optional: re-drives rotated DLQ segments
+	geoDatabase      *geoip.Database        // Optional: IP-to-country database for geo_restriction
+	rateLimitCounter ratelimit.CounterFunc  // Optional: shared rate limit counters (rate_limit.backend)
+	rateLimits       *ratelimit.Inspector   // Rate limiter state, for the admin rate limit endpoint
+	healthProbe      *healthProbe           // Cached dependency checks for /healthz and /readyz
+}
+
+// RouterOption configures optional handler dependencies.
+type RouterOption func(*handlers)
+
+// WithEventBus enables the merchant events WebSocket backed by bus.
+func WithEventBus(bus *eventbus.Bus) RouterOption {
+	return func(h *handlers) {
+		h.eventBus = bus
+	}
+}
+
+// WithStore exposes the storage backend to diagnostic endpoints.
+func WithStore(store storage.Store) RouterOption {
+	return func(h *handlers) {
+		h.store = store
+	}
+}
+
+// WithDLQStore exposes the failed webhook store to the admin summary.
+func WithDLQStore(dlq callbacks.DLQStore) RouterOption {
+	return func(h *handlers) {
+		h.dlq = dlq
+	}
+}
+
+// WithGeoDatabase resolves client countries for geo_restriction from db.
+func WithGeoDatabase(db *geoip.Database) RouterOption {
+	return func(h *handlers) {
+		h.geoDatabase = db
+	}
+}
+
+// WithRateLimitCounters keeps rate limit counts in counters instead of in memory, so the limits
+// apply across instances.
+func WithRateLimitCounters(counters ratelimit.CounterFunc) RouterOption {
+	return func(h *handlers) {
+		h.rateLimitCounter = counters
+	}
+}
+
+// WithVerificationPool enables asynchronous verification backed by pool.
+func WithVerificationPool(pool *verification.Pool) RouterOption {
+	return func(h *handlers) {
+		h.verifications = pool
+	}
 }
 
 // New builds the HTTP server with configured router.
-func New(cfg *config.Config, paywallSvc *paywall.Service, stripeClient *stripesvc.Client, cartService *stripesvc.CartService, verifier x402.Verifier, couponRepo coupons.Repository, idempotencyStore idempotency.Store, metricsCollector *metrics.Metrics, subscriptionsSvc *subscriptions.Service, appLogger zerolog.Logger) *Server {
+func New(cfg *config.Config, paywallSvc *paywall.Service, stripeClient *stripesvc.Client, cartService *stripesvc.CartService, verifier x402.Verifier, couponRepo coupons.Repository, idempotencyStore idempotency.Store, metricsCollector *metrics.Metrics, subscriptionsSvc *subscriptions.Service, appLogger zerolog.Logger) (*Server, error) {
 	router := chi.NewRouter()
 	rpcProxy := NewRPCProxyHandlers(cfg)
 
@@ -77,15 +142,17 @@ func New(cfg *config.Config, paywallSvc *paywall.Service, stripeClient *stripesv
 		},
 	}
 
-	ConfigureRouter(router, cfg, paywallSvc, stripeClient, verifier, rpcProxy, cartService, couponRepo, idempotencyStore, metricsCollector, subscriptionsSvc, appLogger)
-
-	return s
+	if err := ConfigureRouter(router, cfg, paywallSvc, stripeClient, verifier, rpcProxy, cartService, couponRepo, idempotencyStore, metricsCollector, subscriptionsSvc, appLogger); err != nil {
+		return nil, err
+	}
+	return s, nil
 }
 
-// ConfigureRouter attaches Cedros routes to an existing router.
-func ConfigureRouter(router chi.Router, cfg *config.Config, paywallSvc *paywall.Service, stripeClient *stripesvc.Client, verifier x402.Verifier, rpcProxy *rpcProxyHandlers, cartService *stripesvc.CartService, couponRepo coupons.Repository, idempotencyStore idempotency.Store, metricsCollector *metrics.Metrics, subscriptionsSvc *subscriptions.Service, appLogger zerolog.Logger) {
+// ConfigureRouter attaches Cedros routes to an existing router. It fails if the configured
+// reverse proxy cannot be built.
+func ConfigureRouter(router chi.Router, cfg *config.Config, paywallSvc *paywall.Service, stripeClient *stripesvc.Client, verifier x402.Verifier, rpcProxy *rpcProxyHandlers, cartService *stripesvc.CartService, couponRepo coupons.Repository, idempotencyStore idempotency.Store, metricsCollector *metrics.Metrics, subscriptionsSvc *subscriptions.Service, appLogger zerolog.Logger, opts ...RouterOption) error {
 	if router == nil {
-		return
+		return nil
 	}
 
 	handler := handlers{
@@ -100,14 +167,33 @@ func ConfigureRouter(router chi.Router, cfg *config.Config, paywallSvc *paywall.
 		metrics:          metricsCollector,
 		subscriptions:    subscriptionsSvc,
 		logger:           appLogger,
+		rateLimits:       ratelimit.NewInspector(),
+		healthProbe:      &healthProbe{},
+	}
+	for _, opt := range opts {
+		opt(&handler)
+	}
+
+	if cfg.GraphQL.Enabled {
+		schema, err := graphqlapi.NewSchema(graphqlapi.Services{Paywall: paywallSvc, Subscriptions: subscriptionsSvc})
+		if err != nil {
+			appLogger.Error().Err(err).Msg("graphql.schema_build_failed")
+		} else {
+			handler.graphqlSchema = &schema
+		}
 	}
 
 	// RPC proxy handlers are already created and passed in
 
+	// Distributed tracing (outermost so spans cover every other middleware)
+	if cfg.Tracing.Enabled {
+		router.Use(tracing.Middleware)
+	}
+
 	if len(cfg.Server.CORSAllowedOrigins) > 0 {
 		router.Use(cors.New(cors.Options{
 			AllowedOrigins:   cfg.Server.CORSAllowedOrigins,
-			AllowedMethods:   []string{"GET", "POST", "DELETE", "OPTIONS"},
+			AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 			AllowedHeaders:   []string{"*"},
 			ExposedHeaders:   []string{"Location"},
 			AllowCredentials: false,
@@ -122,21 +208,16 @@ func ConfigureRouter(router chi.Router, cfg *config.Config, paywallSvc *paywall.
 	router.Use(logger.Middleware(appLogger))
 	router.Use(middleware.RequestID)
 	router.Use(middleware.RealIP)
+	router.Use(clientIPMiddleware)
 	router.Use(middleware.Recoverer)
 
 	// API version negotiation middleware (adds version to context from Accept header)
 	router.Use(versioning.Negotiation)
 
 	// API key authentication middleware (BEFORE rate limiting)
-	// Extracts X-API-Key header and stores tier in context for rate limit exemptions
-	apiKeyCfg := apikey.Config{
-		Enabled: cfg.APIKey.Enabled,
-		APIKeys: make(map[string]apikey.Tier),
-	}
-	for key, tierStr := range cfg.APIKey.Keys {
-		apiKeyCfg.APIKeys[key] = apikey.Tier(tierStr)
-	}
-	router.Use(apikey.Middleware(apiKeyCfg))
+	// Extracts X-API-Key header and stores tier in context for rate limit exemptions, and
+	// refuses scoped keys outside their scopes
+	router.Use(apikey.Middleware(apiKeyConfig(cfg)))
 
 	// Rate limiting middleware (applied globally)
 	// Convert config to ratelimit.Config
@@ -154,8 +235,23 @@ func ConfigureRouter(router chi.Router, cfg *config.Config, paywallSvc *paywall.
 		PerIPWindow:      cfg.RateLimit.PerIPWindow.Duration,
 		PerIPBurst:       cfg.RateLimit.PerIPLimit / 6, // Burst = ~17% of limit
 		Metrics:          metricsCollector,             // Pass metrics collector to rate limiter
+		Counters:         handler.rateLimitCounter,     // Shared counters when rate_limit.backend is set
+		Inspector:        handler.rateLimits,           // Limiter state for GET /admin/rate-limits
+	}
+	for pattern, endpoint := range cfg.RateLimit.Endpoints {
+		method, route, err := config.ParseRateLimitEndpoint(pattern)
+		if err != nil {
+			continue // Rejected by config validation
+		}
+		rateLimitCfg.Endpoints = append(rateLimitCfg.Endpoints, ratelimit.EndpointLimit{
+			Method: method,
+			Route:  cfg.Server.RoutePrefix + route,
+			Limit:  endpoint.Limit,
+			Window: endpoint.Window.Duration,
+		})
 	}
 	router.Use(ratelimit.GlobalLimiter(rateLimitCfg))
+	router.Use(ratelimit.EndpointLimiter(rateLimitCfg))
 	router.Use(ratelimit.WalletLimiter(rateLimitCfg))
 	router.Use(ratelimit.IPLimiter(rateLimitCfg))
 
@@ -169,18 +265,95 @@ func ConfigureRouter(router chi.Router, cfg *config.Config, paywallSvc *paywall.
 	router.Group(func(r chi.Router) {
 		r.Use(middleware.Timeout(5 * time.Second))
 		r.Get("/cedros-health", handler.health)
+		r.Get("/healthz", handler.healthz)
+		r.Get("/readyz", handler.readyz)
 		r.Get("/.well-known/payment-options", handler.wellKnownPaymentOptions)
 		r.Get("/.well-known/agent.json", handler.agentCard)
 		r.Get("/openapi.json", handler.openAPISpec)
+		r.Get(prefix+"/docs", handler.swaggerUI)
 		r.Post("/resources/list", handler.mcpResourcesList)
 		// Prometheus metrics endpoint (respects route prefix to avoid conflicts)
 		// Protected by optional admin API key (ADMIN_METRICS_API_KEY env var)
 		r.With(adminMetricsAuth(cfg.Server.AdminMetricsAPIKey)).Handle(prefix+"/metrics", promhttp.Handler())
 	})
 
+	// Admin debugging endpoints, only exposed when an admin key is configured
+	// (no timeout middleware - CPU profiles and traces run for ?seconds=N)
+	if cfg.Server.AdminMetricsAPIKey != "" {
+		router.Group(func(r chi.Router) {
+			r.Use(adminMetricsAuth(cfg.Server.AdminMetricsAPIKey))
+			r.Route(prefix+"/debug/pprof", pprofRoutes)
+			r.Get(prefix+"/debug/runtime", handler.runtimeStats)
+			r.Group(func(r chi.Router) {
+				// Writes through the admin API are recorded in the admin audit log
+				r.Use(handler.auditAdminActions(storage.AdminAuditConfigChange, apiKeySigner))
+				r.Get(prefix+"/admin/wallets", handler.listServerWallets)
+				r.Post(prefix+"/admin/wallets", handler.addServerWallet)
+				r.Post(prefix+"/admin/wallets/{address}/retire", handler.retireServerWallet)
+				r.Post(prefix+"/admin/settlements", handler.ingestSettlements)
+				r.Get(prefix+"/admin/inventory/{resource}", handler.getStock)
+				r.Put(prefix+"/admin/inventory/{resource}", handler.setStock)
+				r.Delete(prefix+"/admin/inventory/{resource}", handler.deleteStock)
+				r.Get(prefix+"/admin/products", handler.adminListProducts)
+				r.Post(prefix+"/admin/products", handler.adminCreateProduct)
+				r.Post(prefix+"/admin/products/cache/invalidate", handler.adminInvalidateProductCache)
+				r.Get(prefix+"/admin/products/{id}", handler.adminGetProduct)
+				r.Put(prefix+"/admin/products/{id}", handler.adminUpdateProduct)
+				r.Delete(prefix+"/admin/products/{id}", handler.adminArchiveProduct)
+				r.Get(prefix+"/admin/coupons", handler.adminListCoupons)
+				r.Post(prefix+"/admin/coupons", handler.adminCreateCoupon)
+				r.Get(prefix+"/admin/coupons/analytics", handler.adminCouponAnalytics)
+				r.Get(prefix+"/admin/coupons/{code}", handler.adminGetCoupon)
+				r.Put(prefix+"/admin/coupons/{code}", handler.adminUpdateCoupon)
+				r.Delete(prefix+"/admin/coupons/{code}", handler.adminDeactivateCoupon)
+				r.Get(prefix+"/admin/customers", handler.adminFindCustomer)
+				r.Post(prefix+"/admin/customers/link", handler.adminLinkCustomer)
+				r.Get(prefix+"/admin/customers/{id}", handler.adminGetCustomer)
+				r.Get(prefix+"/admin/access-lists", handler.adminListAccessRules)
+				r.Post(prefix+"/admin/access-lists", handler.adminSaveAccessRule)
+				r.Delete(prefix+"/admin/access-lists", handler.adminDeleteAccessRule)
+				r.Post(prefix+"/admin/circuit-breakers/{name}/reset", handler.adminResetCircuitBreaker)
+			})
+			r.With(handler.auditAdminActions(storage.AdminAuditWebhookRetry, apiKeySigner)).Post(prefix+"/admin/webhooks/dlq/segments/{segment}/redrive", handler.adminRedriveDLQSegment)
+			r.Get(prefix+"/admin/audit", handler.adminAuditLog)
+			r.Get(prefix+"/admin/webhooks/deliveries", handler.adminWebhookDeliveries)
+			r.Get(prefix+"/admin/webhooks/dlq/segments", handler.adminDLQSegments)
+			r.Get(prefix+"/admin/rate-limits", handler.adminRateLimits)
+			r.Get(prefix+"/admin/circuit-breakers", handler.adminListCircuitBreakers)
+			if cfg.Metering.Enabled {
+				r.Post(prefix+"/admin/metering/charge", handler.adminChargeMetering)
+			}
+			r.Get(prefix+"/paywall/v1/admin/summary", handler.adminSummary)
+		})
+	}
+
+	// Admin endpoints a scoped API key may call in place of the admin API key
+	router.Group(func(r chi.Router) {
+		r.With(adminScopeAuth(cfg, apikey.ScopeRefundsAdmin)).Get(prefix+"/admin/refunds/{id}/audit", handler.adminRefundAudit)
+		r.With(adminScopeAuth(cfg, apikey.ScopeWebhooksAdmin), handler.auditAdminActions(storage.AdminAuditWebhookRetry, adminOrScopedKeySigner(cfg.Server.AdminMetricsAPIKey))).Post(prefix+"/admin/webhooks/{id}/retry", handler.adminRetryWebhook)
+	})
+
+	// Long-lived streaming endpoints (no timeout middleware - handlers bound their own lifetime)
+	router.Group(func(r chi.Router) {
+		r.Get(prefix+"/paywall/v1/payments/{signature}/events", handler.paymentStatusEvents)
+		if cfg.MerchantEvents.Enabled && handler.eventBus != nil {
+			r.Get(prefix+"/paywall/v1/merchant/events", handler.merchantEvents)
+		}
+	})
+
 	// Idempotency middleware (24 hour cache for payment requests)
 	idempotencyMW := idempotency.Middleware(idempotencyStore, 24*time.Hour)
 
+	// Country blocking for quote and payment endpoints (no-op unless geo_restriction is enabled)
+	geoRestricted := geoip.Middleware(geoip.Config{
+		Enabled:          cfg.GeoRestriction.Enabled,
+		AllowedCountries: cfg.GeoRestriction.AllowedCountries,
+		BlockedCountries: cfg.GeoRestriction.BlockedCountries,
+		CountryHeader:    cfg.GeoRestriction.CountryHeader,
+		Database:         handler.geoDatabase,
+		BlockUnknown:     cfg.GeoRestriction.BlockUnknown,
+	})
+
 	// Payment processing endpoints with 60s timeout (blockchain confirmations, external API calls)
 	router.Group(func(r chi.Router) {
 		r.Use(middleware.Timeout(60 * time.Second))
@@ -193,20 +366,44 @@ func ConfigureRouter(router chi.Router, cfg *config.Config, paywallSvc *paywall.
 		r.Get(prefix+"/stripe/cancel", handler.stripeCancel)
 
 		// API v1 - Paywall endpoints
-		r.Post(prefix+"/paywall/v1/quote", handler.paywallQuote)
-		r.Post(prefix+"/paywall/v1/verify", handler.paywallVerify)
-		r.With(idempotencyMW).Post(prefix+"/paywall/v1/stripe-session", handler.createStripeSession)
+		r.With(geoRestricted).Post(prefix+"/paywall/v1/quote", handler.paywallQuote)
+		r.With(geoRestricted).Post(prefix+"/paywall/v1/verify", handler.paywallVerify)
+		if handler.graphqlSchema != nil {
+			r.With(geoRestricted).Get(prefix+"/paywall/v1/graphql", handler.graphQL)
+			r.With(geoRestricted).Post(prefix+"/paywall/v1/graphql", handler.graphQL)
+		}
+		if cfg.AsyncVerify.Enabled && handler.verifications != nil {
+			r.Get(prefix+"/paywall/v1/verifications/{id}", handler.getVerification)
+		}
+		r.With(geoRestricted, idempotencyMW).Post(prefix+"/paywall/v1/stripe-session", handler.createStripeSession)
 		r.Get(prefix+"/paywall/v1/stripe-session/verify", handler.verifyStripeSession)
-		r.Get(prefix+"/paywall/v1/x402-transaction/verify", handler.verifyX402Transaction)
-		r.With(idempotencyMW).Post(prefix+"/paywall/v1/cart/checkout", handler.createCartCheckout)
-		r.With(idempotencyMW).Post(prefix+"/paywall/v1/cart/quote", handler.requestCartQuote)
-		r.Post(prefix+"/paywall/v1/gasless-transaction", handler.buildGaslessTransaction)
+		r.With(geoRestricted, idempotencyMW).Post(prefix+"/paywall/v1/stripe-payment-intent", handler.createStripePaymentIntent)
+		r.With(geoRestricted, idempotencyMW).Post(prefix+"/paywall/v1/stripe-invoice", handler.createStripeInvoice)
+		r.With(geoRestricted).Get(prefix+"/paywall/v1/x402-transaction/verify", handler.verifyX402Transaction)
+		if cfg.X402.Facilitator {
+			r.Get(prefix+"/facilitator/supported", handler.facilitatorSupported)
+			r.With(facilitatorAuth(cfg)).Post(prefix+"/facilitator/verify", handler.facilitatorVerify)
+			r.With(facilitatorAuth(cfg)).Post(prefix+"/facilitator/settle", handler.facilitatorSettle)
+		}
+		r.With(geoRestricted, idempotencyMW).Post(prefix+"/paywall/v1/cart/checkout", handler.createCartCheckout)
+		r.With(geoRestricted, idempotencyMW).Post(prefix+"/paywall/v1/cart/quote", handler.requestCartQuote)
+		r.With(geoRestricted).Patch(prefix+"/paywall/v1/cart/{cartId}", handler.updateCartQuote)
+		r.Get(prefix+"/paywall/v1/cart/{cartId}/payments", handler.getCartPayments)
+		r.With(geoRestricted, idempotencyMW).Post(prefix+"/paywall/v1/cart/{cartId}/checkout", handler.createCartQuoteCheckout)
+		r.Get(prefix+"/paywall/v1/saved-carts", handler.listSavedCarts)
+		r.Put(prefix+"/paywall/v1/saved-carts/{name}", handler.saveSavedCart)
+		r.Get(prefix+"/paywall/v1/saved-carts/{name}", handler.getSavedCart)
+		r.Delete(prefix+"/paywall/v1/saved-carts/{name}", handler.deleteSavedCart)
+		r.With(geoRestricted, idempotencyMW).Post(prefix+"/paywall/v1/saved-carts/{name}/quote", handler.quoteSavedCart)
+		r.With(geoRestricted).Post(prefix+"/paywall/v1/gasless-transaction", handler.buildGaslessTransaction)
+		r.With(geoRestricted).Get(prefix+"/paywall/v1/preflight", handler.preflight)
 
 		// API v1 - Refund endpoints
 		r.With(idempotencyMW).Post(prefix+"/paywall/v1/refunds/request", handler.requestRefund)
-		r.Post(prefix+"/paywall/v1/refunds/approve", handler.getRefundQuote)
-		r.Post(prefix+"/paywall/v1/refunds/deny", handler.denyRefund)
-		r.Post(prefix+"/paywall/v1/refunds/pending", handler.listPendingRefunds)
+		r.With(handler.auditAdminActions(storage.AdminAuditRefundApprove, walletSigner)).Post(prefix+"/paywall/v1/refunds/approve", handler.getRefundQuote)
+		r.With(handler.auditAdminActions(storage.AdminAuditRefundDeny, walletSigner)).Post(prefix+"/paywall/v1/refunds/deny", handler.denyRefund)
+		r.With(handler.auditAdminActions(storage.AdminAuditNonceConsume, walletSigner)).Post(prefix+"/paywall/v1/refunds/pending", handler.listPendingRefunds)
+		r.Post(prefix+"/paywall/v1/refunds/notes", handler.addRefundNote)
 
 		// API v1 - Admin nonce generation (for replay protection)
 		r.Post(prefix+"/paywall/v1/nonce", handler.generateNonce)
@@ -216,19 +413,41 @@ func ConfigureRouter(router chi.Router, cfg *config.Config, paywallSvc *paywall.
 
 		// API v1 - Coupon validation endpoint
 		r.Post(prefix+"/paywall/v1/coupons/validate", handler.validateCoupon)
+		r.Get(prefix+"/paywall/v1/gift-cards/{code}", handler.getGiftCardBalance)
+		r.Get(prefix+"/paywall/v1/referrals", handler.getReferralDashboard)
+
+		// API v1 - Metering: prepaid balances charged per call
+		if cfg.Metering.Enabled {
+			r.With(geoRestricted).Post(prefix+"/paywall/v1/metering/deposit", handler.depositMetering)
+			r.Get(prefix+"/paywall/v1/metering/balance", handler.getMeterBalance)
+		}
 
 		// API v1 - Subscription endpoints (matches frontend BACKEND_SUBSCRIPTION_API.md spec)
 		r.Get(prefix+"/paywall/v1/subscription/status", handler.getSubscriptionStatus)
-		r.With(idempotencyMW).Post(prefix+"/paywall/v1/subscription/stripe-session", handler.createStripeSubscription)
-		r.With(idempotencyMW).Post(prefix+"/paywall/v1/subscription/quote", handler.getSubscriptionQuote)
+		r.With(geoRestricted, idempotencyMW).Post(prefix+"/paywall/v1/subscription/stripe-session", handler.createStripeSubscription)
+		r.With(geoRestricted, idempotencyMW).Post(prefix+"/paywall/v1/subscription/quote", handler.getSubscriptionQuote)
 		// Subscription management endpoints
 		r.Post(prefix+"/paywall/v1/subscription/cancel", handler.cancelSubscription)
 		r.Post(prefix+"/paywall/v1/subscription/portal", handler.getBillingPortal)
-		r.With(idempotencyMW).Post(prefix+"/paywall/v1/subscription/x402/activate", handler.createX402Subscription)
+		r.With(geoRestricted, idempotencyMW).Post(prefix+"/paywall/v1/subscription/x402/activate", handler.createX402Subscription)
 		// Upgrade/downgrade/reactivate endpoints
 		r.With(idempotencyMW).Post(prefix+"/paywall/v1/subscription/change", handler.changeSubscription)
+		r.Post(prefix+"/paywall/v1/subscription/change/preview", handler.previewSubscriptionChange)
+		r.With(idempotencyMW).Post(prefix+"/paywall/v1/subscription/seats", handler.updateSubscriptionSeats)
 		r.Post(prefix+"/paywall/v1/subscription/reactivate", handler.reactivateSubscription)
+		r.Post(prefix+"/paywall/v1/subscription/pause", handler.pauseSubscription)
+		r.Post(prefix+"/paywall/v1/subscription/resume", handler.resumeSubscription)
 	})
+
+	// Reverse-proxy mode: requests matching no Cedros route go to the upstream origin
+	if cfg.Proxy.Enabled {
+		proxy, err := newPaywallProxy(cfg, paywallSvc, appLogger)
+		if err != nil {
+			return fmt.Errorf("build paywall proxy: %w", err)
+		}
+		router.NotFound(proxy.ServeHTTP)
+	}
+	return nil
 }
 
 // ListenAndServe starts the HTTP server.
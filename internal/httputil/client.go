@@ -1,8 +1,11 @@
 package httputil
 
 import (
+	"crypto/tls"
 	"net/http"
 	"time"
+
+	"github.com/CedrosPay/server/internal/tracing"
 )
 
 // NewClient creates a new HTTP client with the given timeout and optimized transport settings.
@@ -15,13 +18,22 @@ import (
 //
 // These settings enable connection reuse and reduce latency for repeated requests
 // to the same hosts (e.g., webhook notifications, RPC calls).
+//
+// Requests are traced and carry a W3C traceparent header when tracing is enabled.
 func NewClient(timeout time.Duration) *http.Client {
+	return NewTLSClient(timeout, nil)
+}
+
+// NewTLSClient is NewClient with custom TLS settings, such as a client certificate for mutual
+// TLS or a private CA. A nil tlsConfig uses the system defaults.
+func NewTLSClient(timeout time.Duration, tlsConfig *tls.Config) *http.Client {
 	return &http.Client{
 		Timeout: timeout,
-		Transport: &http.Transport{
+		Transport: tracing.NewTransport(&http.Transport{
 			MaxIdleConns:        100,
 			MaxIdleConnsPerHost: 10,
 			IdleConnTimeout:     90 * time.Second,
-		},
+			TLSClientConfig:     tlsConfig,
+		}),
 	}
 }
@@ -2,8 +2,15 @@ package idempotency
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
 	"net/http"
+	"sync"
 	"time"
+
+	apierrors "github.com/CedrosPay/server/internal/errors"
 )
 
 const (
@@ -48,12 +55,19 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 	return rw.ResponseWriter.Write(b)
 }
 
-// Middleware creates idempotency middleware for payment endpoints
+// Middleware creates idempotency middleware for payment endpoints.
+//
+// A retry carrying the same Idempotency-Key replays the original 2xx response. A retry that
+// arrives while the original is still running gets 409, and reusing a key with a different
+// request body gets 422 so a client bug cannot silently receive another request's result.
 func Middleware(store Store, ttl time.Duration) func(http.Handler) http.Handler {
 	if ttl == 0 {
 		ttl = DefaultTTL
 	}
 
+	// Keys currently being processed by this instance
+	var inFlight sync.Map
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Extract idempotency key from header
@@ -69,9 +83,31 @@ func Middleware(store Store, ttl time.Duration) func(http.Handler) http.Handler
 			// This ensures the same idempotency key cannot be reused across different endpoints
 			key := r.Method + ":" + r.URL.Path + ":" + rawKey
 
+			// Fingerprint the body so a reused key can be told apart from a genuine retry
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				apierrors.WriteSimpleError(w, apierrors.ErrCodeInvalidField, "failed to read request body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			sum := sha256.Sum256(body)
+			fingerprint := hex.EncodeToString(sum[:])
+
+			if _, busy := inFlight.LoadOrStore(key, struct{}{}); busy {
+				w.Header().Set("Retry-After", "1")
+				apierrors.WriteSimpleError(w, apierrors.ErrCodeIdempotencyKeyInUse, "a request with this Idempotency-Key is still being processed")
+				return
+			}
+			defer inFlight.Delete(key)
+
 			// Check if we have a cached response
 			cached, found := store.Get(r.Context(), key)
 			if found {
+				if cached.Fingerprint != "" && cached.Fingerprint != fingerprint {
+					apierrors.WriteSimpleError(w, apierrors.ErrCodeIdempotencyKeyReused, "Idempotency-Key was already used with a different request body")
+					return
+				}
+
 				// Return cached response
 				for k, v := range cached.Headers {
 					w.Header().Set(k, v)
@@ -94,13 +130,15 @@ func Middleware(store Store, ttl time.Duration) func(http.Handler) http.Handler
 				rw.captureHeaders()
 
 				response := &Response{
-					StatusCode: rw.statusCode,
-					Headers:    rw.headers,
-					Body:       rw.body.Bytes(),
-					CachedAt:   time.Now(),
+					StatusCode:  rw.statusCode,
+					Headers:     rw.headers,
+					Body:        rw.body.Bytes(),
+					Fingerprint: fingerprint,
+					CachedAt:    time.Now(),
 				}
 
-				store.Set(r.Context(), key, response, ttl)
+				// Record even if the client already hung up: that client is the one most likely to retry
+				store.Set(context.WithoutCancel(r.Context()), key, response, ttl)
 			}
 		})
 	}
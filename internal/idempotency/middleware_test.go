@@ -5,6 +5,7 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
@@ -317,3 +318,91 @@ func TestStore_Expiration(t *testing.T) {
 		t.Error("expected key to be expired")
 	}
 }
+
+func TestMiddleware_DifferentBodyRejected(t *testing.T) {
+	store := NewMemoryStore()
+	defer store.Stop()
+	callCount := 0
+	handler := Middleware(store, 1*time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		callCount++
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("created"))
+	}))
+
+	send := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/test", strings.NewReader(body))
+		req.Header.Set("Idempotency-Key", "body-key")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	send(`{"amount":1}`)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantReplay bool
+	}{
+		{name: "same body replays", body: `{"amount":1}`, wantStatus: http.StatusOK, wantReplay: true},
+		{name: "different body rejected", body: `{"amount":2}`, wantStatus: http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := send(tt.body)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if got := rec.Header().Get("X-Idempotency-Replay") == "true"; got != tt.wantReplay {
+				t.Errorf("replay = %v, want %v", got, tt.wantReplay)
+			}
+		})
+	}
+	if callCount != 1 {
+		t.Errorf("expected handler to be called once, got %d times", callCount)
+	}
+}
+
+func TestMiddleware_ConcurrentDuplicate(t *testing.T) {
+	store := NewMemoryStore()
+	defer store.Stop()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := Middleware(store, 1*time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	newReq := func() *http.Request {
+		req := httptest.NewRequest("POST", "/test", nil)
+		req.Header.Set("Idempotency-Key", "slow-key")
+		return req
+	}
+
+	done := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(httptest.NewRecorder(), newReq())
+		close(done)
+	}()
+	<-started
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, newReq())
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected status 409 while original is in flight, got %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("expected Retry-After header")
+	}
+
+	close(release)
+	<-done
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, newReq())
+	if rec.Header().Get("X-Idempotency-Replay") != "true" {
+		t.Errorf("expected replay after original completed, got status %d", rec.Code)
+	}
+}
@@ -0,0 +1,59 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+// StorageStore persists idempotent responses in the paywall storage backend so they survive
+// restarts and are shared by every instance pointed at the same database.
+type StorageStore struct {
+	store storage.Store
+}
+
+// NewStorageStore creates an idempotency store backed by the given storage backend.
+func NewStorageStore(store storage.Store) *StorageStore {
+	return &StorageStore{store: store}
+}
+
+// Get retrieves a cached response for the given key
+func (s *StorageStore) Get(ctx context.Context, key string) (*Response, bool) {
+	record, err := s.store.GetIdempotencyKey(ctx, key)
+	if err != nil {
+		// Storage errors are treated as a miss: the request runs normally rather than failing
+		return nil, false
+	}
+
+	return &Response{
+		StatusCode:  record.StatusCode,
+		Headers:     record.Headers,
+		Body:        record.Body,
+		Fingerprint: record.Fingerprint,
+		CachedAt:    record.CreatedAt,
+	}, true
+}
+
+// Set stores a response for the given key with TTL
+func (s *StorageStore) Set(ctx context.Context, key string, response *Response, ttl time.Duration) error {
+	cachedAt := response.CachedAt
+	if cachedAt.IsZero() {
+		cachedAt = time.Now()
+	}
+
+	return s.store.SaveIdempotencyKey(ctx, storage.IdempotencyRecord{
+		Key:         key,
+		Fingerprint: response.Fingerprint,
+		StatusCode:  response.StatusCode,
+		Headers:     response.Headers,
+		Body:        response.Body,
+		CreatedAt:   cachedAt,
+		ExpiresAt:   cachedAt.Add(ttl),
+	})
+}
+
+// Delete removes a cached response
+func (s *StorageStore) Delete(ctx context.Context, key string) error {
+	return s.store.DeleteIdempotencyKey(ctx, key)
+}
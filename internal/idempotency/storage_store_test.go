@@ -0,0 +1,81 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func TestStorageStore(t *testing.T) {
+	backend := storage.NewMemoryStore()
+	defer backend.Close()
+	store := NewStorageStore(backend)
+	ctx := context.Background()
+
+	resp := &Response{
+		StatusCode:  201,
+		Headers:     map[string]string{"Content-Type": "application/json"},
+		Body:        []byte(`{"id":"refund_1"}`),
+		Fingerprint: "abc",
+		CachedAt:    time.Now(),
+	}
+	if err := store.Set(ctx, "k", resp, time.Hour); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	got, found := store.Get(ctx, "k")
+	if !found {
+		t.Fatal("expected to find stored response")
+	}
+	if got.StatusCode != 201 || string(got.Body) != string(resp.Body) || got.Fingerprint != "abc" || got.Headers["Content-Type"] != "application/json" {
+		t.Fatalf("unexpected response: %+v", got)
+	}
+
+	record, err := backend.GetIdempotencyKey(ctx, "k")
+	if err != nil {
+		t.Fatalf("GetIdempotencyKey: %v", err)
+	}
+	if ttl := record.ExpiresAt.Sub(record.CreatedAt); ttl != time.Hour {
+		t.Errorf("ttl = %v, want 1h", ttl)
+	}
+
+	if err := store.Delete(ctx, "k"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, found := store.Get(ctx, "k"); found {
+		t.Error("expected not found after delete")
+	}
+}
+
+// TestStorageStore_SharedAcrossInstances verifies that a retry reaching a different server
+// instance (separate middleware, same database) replays the original response.
+func TestStorageStore_SharedAcrossInstances(t *testing.T) {
+	backend := storage.NewMemoryStore()
+	defer backend.Close()
+
+	callCount := 0
+	newInstance := func() http.Handler {
+		return Middleware(NewStorageStore(backend), time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			callCount++
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("quote created"))
+		}))
+	}
+
+	for _, instance := range []http.Handler{newInstance(), newInstance()} {
+		req := httptest.NewRequest("POST", "/cart/quote", nil)
+		req.Header.Set("Idempotency-Key", "shared-key")
+		rec := httptest.NewRecorder()
+		instance.ServeHTTP(rec, req)
+		if rec.Body.String() != "quote created" {
+			t.Fatalf("unexpected body %q", rec.Body.String())
+		}
+	}
+	if callCount != 1 {
+		t.Errorf("expected handler to be called once, got %d times", callCount)
+	}
+}
@@ -9,10 +9,11 @@ import (
 
 // Response represents a cached idempotent response
 type Response struct {
-	StatusCode int
-	Headers    map[string]string
-	Body       []byte
-	CachedAt   time.Time
+	StatusCode  int
+	Headers     map[string]string
+	Body        []byte
+	Fingerprint string // SHA-256 of the request body that produced this response
+	CachedAt    time.Time
 }
 
 // Store manages idempotency keys and cached responses
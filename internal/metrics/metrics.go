@@ -22,6 +22,21 @@ type Metrics struct {
 	RPCCallDuration *prometheus.HistogramVec
 	RPCErrorsTotal  *prometheus.CounterVec
 
+	// RPC endpoint pool metrics
+	RPCEndpointRequestsTotal *prometheus.CounterVec
+	RPCEndpointDuration      *prometheus.HistogramVec
+	RPCEndpointHealthy       *prometheus.GaugeVec
+	RPCFailoversTotal        *prometheus.CounterVec
+
+	// Gasless fee budget metrics
+	GaslessFeesLamports         *prometheus.CounterVec
+	GaslessBudgetExhaustedTotal *prometheus.CounterVec
+
+	// Priority fee metrics
+	PriorityFeeMicroLamports *prometheus.HistogramVec
+	PriorityFeeEstimate      *prometheus.GaugeVec
+	NetworkCongestion        *prometheus.GaugeVec
+
 	// Cart metrics
 	CartCheckoutsTotal *prometheus.CounterVec
 	CartItemsTotal     prometheus.Counter
@@ -31,6 +46,8 @@ type Metrics struct {
 	RefundsTotal      *prometheus.CounterVec
 	RefundAmountTotal *prometheus.CounterVec
 	RefundDuration    *prometheus.HistogramVec
+	RefundsPending    prometheus.Gauge
+	RefundOldestAge   prometheus.Gauge
 
 	// Webhook metrics
 	WebhooksTotal       *prometheus.CounterVec
@@ -41,10 +58,19 @@ type Metrics struct {
 	// Rate limiting metrics
 	RateLimitHitsTotal *prometheus.CounterVec
 
+	// Fraud rule metrics
+	FraudRuleHitsTotal *prometheus.CounterVec
+	ScreeningsTotal    *prometheus.CounterVec
+
 	// Database metrics
 	DBQueryDuration     *prometheus.HistogramVec
 	DBConnectionsActive prometheus.Gauge
 
+	// Storage operation metrics (labeled by backend, table, operation)
+	StoreOperationDuration    *prometheus.HistogramVec
+	StoreOperationErrorsTotal *prometheus.CounterVec
+	StoreSlowOperationsTotal  *prometheus.CounterVec
+
 	// System metrics
 	ArchivalRunsTotal      prometheus.Counter
 	ArchivalRecordsDeleted prometheus.Counter
@@ -129,6 +155,77 @@ func New(registry prometheus.Registerer) *Metrics {
 			[]string{"method", "network", "error_type"},
 		),
 
+		// RPC endpoint pool metrics
+		RPCEndpointRequestsTotal: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "cedros_rpc_endpoint_requests_total",
+				Help: "Total number of requests sent to each RPC endpoint",
+			},
+			[]string{"endpoint", "status"},
+		),
+		RPCEndpointDuration: factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "cedros_rpc_endpoint_duration_seconds",
+				Help:    "Duration of requests to each RPC endpoint",
+				Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2, 5, 10},
+			},
+			[]string{"endpoint"},
+		),
+		RPCEndpointHealthy: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "cedros_rpc_endpoint_healthy",
+				Help: "Whether an RPC endpoint passed its last health check (1 = healthy, 0 = unhealthy)",
+			},
+			[]string{"endpoint"},
+		),
+		RPCFailoversTotal: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "cedros_rpc_failovers_total",
+				Help: "Total number of requests retried on another RPC endpoint, by the endpoint that failed",
+			},
+			[]string{"endpoint"},
+		),
+
+		// Gasless fee budget metrics
+		GaslessFeesLamports: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "cedros_gasless_fees_lamports_total",
+				Help: "Total network fees paid by server wallets for gasless transactions, in lamports",
+			},
+			[]string{"network", "wallet"},
+		),
+		GaslessBudgetExhaustedTotal: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "cedros_gasless_budget_exhausted_total",
+				Help: "Total number of times a server wallet exhausted its daily gasless budget",
+			},
+			[]string{"network", "wallet"},
+		),
+
+		// Priority fee metrics
+		PriorityFeeMicroLamports: factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "cedros_priority_fee_micro_lamports",
+				Help:    "Compute unit price of submitted transactions, in micro-lamports",
+				Buckets: []float64{0, 1, 10, 100, 1000, 10000, 50000, 100000, 500000, 1000000},
+			},
+			[]string{"network", "payer"},
+		),
+		PriorityFeeEstimate: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "cedros_priority_fee_estimate_micro_lamports",
+				Help: "Latest estimated compute unit price for gasless transactions, in micro-lamports",
+			},
+			[]string{"network"},
+		),
+		NetworkCongestion: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "cedros_network_congestion_ratio",
+				Help: "Share of recent slots in which transactions paid a prioritization fee",
+			},
+			[]string{"network"},
+		),
+
 		// Cart metrics
 		CartCheckoutsTotal: factory.NewCounterVec(
 			prometheus.CounterOpts{
@@ -173,6 +270,18 @@ func New(registry prometheus.Registerer) *Metrics {
 			},
 			[]string{"method"},
 		),
+		RefundsPending: factory.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "cedros_refund_requests_pending",
+				Help: "Number of refund requests awaiting an admin decision",
+			},
+		),
+		RefundOldestAge: factory.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "cedros_refund_request_oldest_pending_seconds",
+				Help: "Age of the oldest pending refund request in seconds",
+			},
+		),
 
 		// Webhook metrics
 		WebhooksTotal: factory.NewCounterVec(
@@ -214,6 +323,22 @@ func New(registry prometheus.Registerer) *Metrics {
 			[]string{"limit_type", "identifier"},
 		),
 
+		// Fraud rule metrics
+		FraudRuleHitsTotal: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "cedros_fraud_rule_hits_total",
+				Help: "Total number of requests that tripped a velocity rule, by rule and action taken",
+			},
+			[]string{"rule", "action"},
+		),
+		ScreeningsTotal: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "cedros_screenings_total",
+				Help: "Total number of wallet compliance screenings, by purpose and result",
+			},
+			[]string{"purpose", "result"},
+		),
+
 		// Database metrics
 		DBQueryDuration: factory.NewHistogramVec(
 			prometheus.HistogramOpts{
@@ -230,6 +355,30 @@ func New(registry prometheus.Registerer) *Metrics {
 			},
 		),
 
+		// Storage operation metrics
+		StoreOperationDuration: factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "cedros_storage_operation_duration_seconds",
+				Help:    "Storage operation latency by backend, table, and operation",
+				Buckets: []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.5, 1, 2},
+			},
+			[]string{"backend", "table", "operation"},
+		),
+		StoreOperationErrorsTotal: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "cedros_storage_operation_errors_total",
+				Help: "Total number of failed storage operations (not-found lookups excluded)",
+			},
+			[]string{"backend", "table", "operation"},
+		),
+		StoreSlowOperationsTotal: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "cedros_storage_slow_operations_total",
+				Help: "Total number of storage operations exceeding the slow-query threshold",
+			},
+			[]string{"backend", "table", "operation"},
+		),
+
 		// System metrics
 		ArchivalRunsTotal: factory.NewCounter(
 			prometheus.CounterOpts{
@@ -292,6 +441,52 @@ func (m *Metrics) ObserveRPCCall(method, network string, duration time.Duration,
 	}
 }
 
+// ObserveRPCEndpointRequest records a request sent to one endpoint of an RPC pool.
+func (m *Metrics) ObserveRPCEndpointRequest(endpoint string, duration time.Duration, err error) {
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+	m.RPCEndpointRequestsTotal.WithLabelValues(endpoint, status).Inc()
+	m.RPCEndpointDuration.WithLabelValues(endpoint).Observe(duration.Seconds())
+}
+
+// ObserveRPCEndpointHealth records the result of an RPC endpoint health check.
+func (m *Metrics) ObserveRPCEndpointHealth(endpoint string, healthy bool) {
+	value := 0.0
+	if healthy {
+		value = 1
+	}
+	m.RPCEndpointHealthy.WithLabelValues(endpoint).Set(value)
+}
+
+// ObserveRPCFailover records a request moving off a failed RPC endpoint.
+func (m *Metrics) ObserveRPCFailover(endpoint string) {
+	m.RPCFailoversTotal.WithLabelValues(endpoint).Inc()
+}
+
+// ObserveGaslessFee records network fees a server wallet paid for a gasless transaction.
+func (m *Metrics) ObserveGaslessFee(network, wallet string, lamports uint64) {
+	m.GaslessFeesLamports.WithLabelValues(network, wallet).Add(float64(lamports))
+}
+
+// ObserveGaslessBudgetExhausted records a server wallet reaching its daily gasless budget.
+func (m *Metrics) ObserveGaslessBudgetExhausted(network, wallet string) {
+	m.GaslessBudgetExhaustedTotal.WithLabelValues(network, wallet).Inc()
+}
+
+// ObservePriorityFee records the compute unit price a submitted transaction paid.
+// payer is "server" for gasless transactions and "user" otherwise.
+func (m *Metrics) ObservePriorityFee(network, payer string, microLamports uint64) {
+	m.PriorityFeeMicroLamports.WithLabelValues(network, payer).Observe(float64(microLamports))
+}
+
+// ObservePriorityFeeEstimate records a new priority fee estimate and the congestion it was based on.
+func (m *Metrics) ObservePriorityFeeEstimate(network string, microLamports uint64, congestion float64) {
+	m.PriorityFeeEstimate.WithLabelValues(network).Set(float64(microLamports))
+	m.NetworkCongestion.WithLabelValues(network).Set(congestion)
+}
+
 // ObserveCartCheckout records a cart checkout.
 func (m *Metrics) ObserveCartCheckout(status string, itemCount int) {
 	m.CartCheckoutsTotal.WithLabelValues(status).Inc()
@@ -307,6 +502,13 @@ func (m *Metrics) ObserveRefund(status string, amountCents int64, token string,
 	m.RefundDuration.WithLabelValues(method).Observe(duration.Seconds())
 }
 
+// ObservePendingRefunds records how many refund requests are pending and how long the oldest
+// has waited.
+func (m *Metrics) ObservePendingRefunds(count int, oldest time.Duration) {
+	m.RefundsPending.Set(float64(count))
+	m.RefundOldestAge.Set(oldest.Seconds())
+}
+
 // ObserveWebhook records webhook delivery.
 func (m *Metrics) ObserveWebhook(eventType, status string, duration time.Duration, attempt int, sentToDLQ bool) {
 	m.WebhooksTotal.WithLabelValues(eventType, status).Inc()
@@ -326,11 +528,33 @@ func (m *Metrics) ObserveRateLimit(limitType, identifier string) {
 	m.RateLimitHitsTotal.WithLabelValues(limitType, identifier).Inc()
 }
 
+// ObserveFraudRule records a request that tripped a velocity rule.
+func (m *Metrics) ObserveFraudRule(rule, action string) {
+	m.FraudRuleHitsTotal.WithLabelValues(rule, action).Inc()
+}
+
+// ObserveScreening records a wallet compliance screening; result is "clear", "flagged", or
+// "error".
+func (m *Metrics) ObserveScreening(purpose, result string) {
+	m.ScreeningsTotal.WithLabelValues(purpose, result).Inc()
+}
+
 // ObserveDBQuery records a database query.
 func (m *Metrics) ObserveDBQuery(operation, backend string, duration time.Duration) {
 	m.DBQueryDuration.WithLabelValues(operation, backend).Observe(duration.Seconds())
 }
 
+// ObserveStoreOperation records a storage operation's latency and outcome.
+func (m *Metrics) ObserveStoreOperation(backend, table, operation string, duration time.Duration, failed, slow bool) {
+	m.StoreOperationDuration.WithLabelValues(backend, table, operation).Observe(duration.Seconds())
+	if failed {
+		m.StoreOperationErrorsTotal.WithLabelValues(backend, table, operation).Inc()
+	}
+	if slow {
+		m.StoreSlowOperationsTotal.WithLabelValues(backend, table, operation).Inc()
+	}
+}
+
 // ObserveArchival records an archival run.
 func (m *Metrics) ObserveArchival(recordsDeleted int64) {
 	m.ArchivalRunsTotal.Inc()
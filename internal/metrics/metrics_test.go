@@ -187,6 +187,20 @@ func TestObserveRefund(t *testing.T) {
 	}
 }
 
+func TestObservePendingRefunds(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m := New(registry)
+
+	m.ObservePendingRefunds(3, 90*time.Minute)
+
+	if pending := promtest.ToFloat64(m.RefundsPending); pending != 3 {
+		t.Errorf("expected 3 pending refunds, got %.0f", pending)
+	}
+	if oldest := promtest.ToFloat64(m.RefundOldestAge); oldest != 5400 {
+		t.Errorf("expected oldest pending age 5400s, got %.0f", oldest)
+	}
+}
+
 func TestObserveWebhook(t *testing.T) {
 	registry := prometheus.NewRegistry()
 	m := New(registry)
@@ -227,6 +241,37 @@ func TestObserveRateLimit(t *testing.T) {
 	}
 }
 
+func TestObserveFraudRule(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m := New(registry)
+
+	m.ObserveFraudRule("quotes_per_ip", "block")
+	m.ObserveFraudRule("quotes_per_ip", "block")
+	m.ObserveFraudRule("refunds_per_wallet", "require_review")
+
+	if hits := promtest.ToFloat64(m.FraudRuleHitsTotal.WithLabelValues("quotes_per_ip", "block")); hits != 2 {
+		t.Errorf("expected 2 quotes_per_ip hits, got %.0f", hits)
+	}
+	if hits := promtest.ToFloat64(m.FraudRuleHitsTotal.WithLabelValues("refunds_per_wallet", "require_review")); hits != 1 {
+		t.Errorf("expected 1 refunds_per_wallet hit, got %.0f", hits)
+	}
+}
+
+func TestObserveScreening(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m := New(registry)
+
+	m.ObserveScreening("payment", "clear")
+	m.ObserveScreening("refund", "flagged")
+
+	if count := promtest.ToFloat64(m.ScreeningsTotal.WithLabelValues("payment", "clear")); count != 1 {
+		t.Errorf("expected 1 clear payment screening, got %.0f", count)
+	}
+	if count := promtest.ToFloat64(m.ScreeningsTotal.WithLabelValues("refund", "flagged")); count != 1 {
+		t.Errorf("expected 1 flagged refund screening, got %.0f", count)
+	}
+}
+
 func TestObserveDBQuery(t *testing.T) {
 	registry := prometheus.NewRegistry()
 	m := New(registry)
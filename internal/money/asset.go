@@ -2,6 +2,7 @@ package money
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 )
 
@@ -11,6 +12,7 @@ type Asset struct {
 	Decimals uint8  // Number of decimal places (2 for USD, 6 for USDC, 9 for SOL)
 	Type     AssetType
 	Metadata AssetMetadata
+	Symbol   string // Display symbol ("$", "€"); the code when empty
 }
 
 // AssetType categorizes the asset for different backends.
@@ -38,6 +40,7 @@ var (
 			Metadata: AssetMetadata{
 				StripeCurrency: "usd",
 			},
+			Symbol: "$",
 		},
 		"EUR": {
 			Code:     "EUR",
@@ -46,6 +49,7 @@ var (
 			Metadata: AssetMetadata{
 				StripeCurrency: "eur",
 			},
+			Symbol: "€",
 		},
 
 		// Solana SPL Tokens
@@ -114,20 +118,88 @@ func MustGetAsset(code string) Asset {
 	return asset
 }
 
-// RegisterAsset adds a new asset to the registry (for testing or dynamic tokens).
+// RegisterAsset adds an asset to the registry at runtime, e.g. a token from config, so new
+// tokens don't need a code change. A registered code can be registered again to change its
+// display symbol, but not its decimals, type, or mint: amounts already stored in atomic units
+// would be read at a different scale. A fiat asset's Stripe currency defaults to its code in
+// lowercase.
 func RegisterAsset(asset Asset) error {
+	if err := ValidateAsset(asset); err != nil {
+		return err
+	}
+	if asset.IsStripeCurrency() && asset.Metadata.StripeCurrency == "" {
+		asset.Metadata.StripeCurrency = strings.ToLower(asset.Code)
+	}
+
+	assetRegistryMu.Lock()
+	defer assetRegistryMu.Unlock()
+
+	if existing, ok := assetRegistry[asset.Code]; ok {
+		if existing.Decimals != asset.Decimals || existing.Type != asset.Type || existing.Metadata != asset.Metadata {
+			return fmt.Errorf("money: asset %s is already registered with different decimals, type, or mint", asset.Code)
+		}
+	}
+	if asset.IsSPLToken() {
+		if other, ok := assetByMintLocked(asset.Metadata.SolanaMint); ok && other.Code != asset.Code {
+			return fmt.Errorf("money: mint %s is already registered as %s", asset.Metadata.SolanaMint, other.Code)
+		}
+	}
+	assetRegistry[asset.Code] = asset
+
+	return nil
+}
+
+// ValidateAsset checks an asset definition before it is registered: an upper-case
+// alphanumeric code, at most 18 decimals, and a mint for an SPL token.
+func ValidateAsset(asset Asset) error {
 	if asset.Code == "" {
 		return fmt.Errorf("money: asset code required")
 	}
+	if len(asset.Code) > 10 || strings.Trim(asset.Code, "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789") != "" {
+		return fmt.Errorf("money: asset code %q must be up to 10 upper-case letters or digits", asset.Code)
+	}
 	if asset.Decimals > 18 {
 		return fmt.Errorf("money: decimals must be ≤ 18")
 	}
+	switch asset.Type {
+	case AssetTypeFiat:
+		if asset.Metadata.SolanaMint != "" {
+			return fmt.Errorf("money: fiat asset %s cannot have a Solana mint", asset.Code)
+		}
+	case AssetTypeSPL:
+		if !isBase58Address(asset.Metadata.SolanaMint) {
+			return fmt.Errorf("money: SPL token %s needs a base58 Solana mint address", asset.Code)
+		}
+	default:
+		return fmt.Errorf("money: asset %s has unknown type %d", asset.Code, asset.Type)
+	}
+	return nil
+}
 
-	assetRegistryMu.Lock()
-	assetRegistry[asset.Code] = asset
-	assetRegistryMu.Unlock()
+// isBase58Address reports whether s looks like a base58-encoded 32-byte Solana address.
+// Callers with the Solana SDK at hand should still decode it.
+func isBase58Address(s string) bool {
+	if len(s) < 32 || len(s) > 44 {
+		return false
+	}
+	return strings.Trim(s, "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz") == ""
+}
 
-	return nil
+// AssetByMint returns the SPL token registered with the given mint address.
+func AssetByMint(mint string) (Asset, bool) {
+	assetRegistryMu.RLock()
+	defer assetRegistryMu.RUnlock()
+	return assetByMintLocked(mint)
+}
+
+// assetByMintLocked is AssetByMint for callers already holding assetRegistryMu.
+func assetByMintLocked(mint string) (Asset, bool) {
+	for _, asset := range assetRegistry {
+		if asset.IsSPLToken() && asset.Metadata.SolanaMint == mint {
+			return asset, true
+		}
+	}
+	return Asset{}, false
 }
 
 // ListAssets returns all registered assets.
@@ -142,6 +214,14 @@ func ListAssets() []Asset {
 	return assets
 }
 
+// DisplaySymbol returns the symbol to show next to amounts, or the code if none is set.
+func (a Asset) DisplaySymbol() string {
+	if a.Symbol != "" {
+		return a.Symbol
+	}
+	return a.Code
+}
+
 // IsStripeCurrency returns true if the asset is a Stripe fiat currency.
 func (a Asset) IsStripeCurrency() bool {
 	return a.Type == AssetTypeFiat
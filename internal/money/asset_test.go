@@ -0,0 +1,49 @@
+package money
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegisterAsset(t *testing.T) {
+	const mint = "7kbnvuGBxxj8AG9qp8Scn56muWGaRaFqxg1FsRp3PaFT"
+	tests := []struct {
+		name    string
+		asset   Asset
+		wantErr string
+	}{
+		{name: "spl token", asset: Asset{Code: "UXD", Decimals: 6, Type: AssetTypeSPL, Metadata: AssetMetadata{SolanaMint: mint}}},
+		{name: "new display symbol", asset: Asset{Code: "UXD", Decimals: 6, Type: AssetTypeSPL, Metadata: AssetMetadata{SolanaMint: mint}, Symbol: "UXD$"}},
+		{name: "fiat defaults stripe currency", asset: Asset{Code: "CHF", Decimals: 2, Type: AssetTypeFiat}},
+		{name: "changed decimals", asset: Asset{Code: "UXD", Decimals: 9, Type: AssetTypeSPL, Metadata: AssetMetadata{SolanaMint: mint}}, wantErr: "already registered"},
+		{name: "lower-case code", asset: Asset{Code: "uxd", Decimals: 6, Type: AssetTypeSPL, Metadata: AssetMetadata{SolanaMint: mint}}, wantErr: "upper-case"},
+		{name: "too many decimals", asset: Asset{Code: "BIG", Decimals: 19, Type: AssetTypeFiat}, wantErr: "decimals"},
+		{name: "spl without mint", asset: Asset{Code: "NOMINT", Decimals: 6, Type: AssetTypeSPL}, wantErr: "base58"},
+		{name: "fiat with mint", asset: Asset{Code: "FIAT", Decimals: 2, Type: AssetTypeFiat, Metadata: AssetMetadata{SolanaMint: mint}}, wantErr: "cannot have a Solana mint"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := RegisterAsset(tt.asset)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RegisterAsset: %v", err)
+			}
+			got := MustGetAsset(tt.asset.Code)
+			if got.DisplaySymbol() != tt.asset.DisplaySymbol() {
+				t.Errorf("symbol = %q, want %q", got.DisplaySymbol(), tt.asset.DisplaySymbol())
+			}
+			if got.IsStripeCurrency() && got.Metadata.StripeCurrency != strings.ToLower(tt.asset.Code) {
+				t.Errorf("stripe currency = %q", got.Metadata.StripeCurrency)
+			}
+		})
+	}
+
+	if asset, ok := AssetByMint(mint); !ok || asset.Code != "UXD" {
+		t.Errorf("AssetByMint = %+v, %v; want UXD", asset, ok)
+	}
+}
@@ -0,0 +1,160 @@
+package money
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoRate indicates no exchange rate is known between two assets.
+var ErrNoRate = errors.New("money: no exchange rate")
+
+// RateSource supplies exchange rates by asset code. The rates package's providers satisfy it.
+type RateSource interface {
+	// Rate returns how many units of to one unit of from is worth.
+	Rate(ctx context.Context, from, to string) (float64, error)
+}
+
+// PeggedRates is a RateSource that only knows the $1 peg: USD and the known stablecoins
+// convert into one another at 1. It is the default source for Convert.
+type PeggedRates struct{}
+
+// Rate returns 1 between USD and stablecoins, or ErrNoRate.
+func (PeggedRates) Rate(_ context.Context, from, to string) (float64, error) {
+	if strings.EqualFold(from, to) || (isDollar(from) && isDollar(to)) {
+		return 1, nil
+	}
+	return 0, fmt.Errorf("%w from %s to %s", ErrNoRate, from, to)
+}
+
+// isDollar reports whether code is USD or a stablecoin pegged to it.
+func isDollar(code string) bool {
+	code = strings.ToUpper(code)
+	return code == "USD" || GetMintAddressForSymbol(code) != ""
+}
+
+// Converter converts amounts between assets at rates from a RateSource, reusing each rate
+// for a TTL so reports over many amounts don't each call the source.
+type Converter struct {
+	source RateSource
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cachedRate
+}
+
+type cachedRate struct {
+	rate      float64
+	fetchedAt time.Time
+}
+
+// NewConverter creates a converter over source, caching each rate for ttl. A nil source
+// converts only at the $1 peg.
+func NewConverter(source RateSource, ttl time.Duration) *Converter {
+	if source == nil {
+		source = PeggedRates{}
+	}
+	return &Converter{source: source, ttl: ttl, now: time.Now, entries: make(map[string]cachedRate)}
+}
+
+// Convert converts m into asset, rounding half away from zero to the nearest atomic unit.
+// Pairs the source can't price fall back to the $1 peg, so stablecoin amounts still add up
+// in USD while a price feed is down.
+func (c *Converter) Convert(ctx context.Context, m Money, to Asset) (Money, error) {
+	if m.Asset.Code == to.Code {
+		return m, nil
+	}
+	rate, err := c.rate(ctx, m.Asset.Code, to.Code)
+	if err != nil {
+		return Money{}, err
+	}
+	return ConvertAtRate(m, to, rate, RoundingStandard)
+}
+
+// rate returns the cached rate from from to to, fetching it when missing or older than the TTL.
+func (c *Converter) rate(ctx context.Context, from, to string) (float64, error) {
+	key := from + "/" + to
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && c.now().Sub(entry.fetchedAt) < c.ttl {
+		return entry.rate, nil
+	}
+
+	rate, err := c.source.Rate(ctx, from, to)
+	if err != nil {
+		pegged, pegErr := PeggedRates{}.Rate(ctx, from, to)
+		if pegErr != nil {
+			return 0, err
+		}
+		rate = pegged
+	}
+	c.mu.Lock()
+	c.entries[key] = cachedRate{rate: rate, fetchedAt: c.now()}
+	c.mu.Unlock()
+	return rate, nil
+}
+
+// defaultConverter backs Convert until SetRateSource replaces it.
+var (
+	defaultConverter   = NewConverter(nil, 0)
+	defaultConverterMu sync.RWMutex
+)
+
+// SetRateSource makes Convert use source, caching each rate for ttl.
+func SetRateSource(source RateSource, ttl time.Duration) {
+	converter := NewConverter(source, ttl)
+	defaultConverterMu.Lock()
+	defaultConverter = converter
+	defaultConverterMu.Unlock()
+}
+
+// Convert converts m into asset at the rate from the source set with SetRateSource (the $1
+// peg by default), e.g. to report totals across assets in USD.
+func Convert(ctx context.Context, m Money, to Asset) (Money, error) {
+	defaultConverterMu.RLock()
+	converter := defaultConverter
+	defaultConverterMu.RUnlock()
+	return converter.Convert(ctx, m, to)
+}
+
+// ConvertAtRate converts m into asset at rate units of asset per unit of m's asset, in exact
+// arithmetic. RoundingStandard rounds half away from zero; RoundingCeiling rounds up, so a
+// converted price never falls short.
+func ConvertAtRate(m Money, to Asset, rate float64, mode RoundingMode) (Money, error) {
+	if rate <= 0 || math.IsInf(rate, 0) || math.IsNaN(rate) {
+		return Money{}, fmt.Errorf("money: invalid exchange rate %v from %s to %s", rate, m.Asset.Code, to.Code)
+	}
+	r, _ := new(big.Rat).SetString(strconv.FormatFloat(rate, 'f', -1, 64))
+	scale := new(big.Rat).SetFrac(
+		new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(to.Decimals)), nil),
+		new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(m.Asset.Decimals)), nil),
+	)
+	converted := new(big.Rat).SetInt64(m.Atomic)
+	converted.Mul(converted, r).Mul(converted, scale)
+
+	// QuoRem truncates toward zero; the remainder carries the amount's sign
+	atomic, remainder := new(big.Int).QuoRem(converted.Num(), converted.Denom(), new(big.Int))
+	switch mode {
+	case RoundingCeiling:
+		if remainder.Sign() > 0 {
+			atomic.Add(atomic, big.NewInt(1))
+		}
+	default:
+		twice := new(big.Int).Lsh(new(big.Int).Abs(remainder), 1)
+		if twice.Cmp(converted.Denom()) >= 0 {
+			atomic.Add(atomic, big.NewInt(int64(remainder.Sign())))
+		}
+	}
+	if !atomic.IsInt64() {
+		return Money{}, fmt.Errorf("%w: converting %s %s to %s", ErrOverflow, m.ToMajor(), m.Asset.Code, to.Code)
+	}
+	return New(to, atomic.Int64()), nil
+}
@@ -0,0 +1,77 @@
+package money
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// countingSource is a RateSource with fixed rates that counts its lookups.
+type countingSource struct {
+	rates map[string]float64
+	calls int
+}
+
+func (s *countingSource) Rate(_ context.Context, from, to string) (float64, error) {
+	s.calls++
+	if rate, ok := s.rates[from+"/"+to]; ok {
+		return rate, nil
+	}
+	return 0, ErrNoRate
+}
+
+func TestConverter_Convert(t *testing.T) {
+	usd, usdc, sol, eur := MustGetAsset("USD"), MustGetAsset("USDC"), MustGetAsset("SOL"), MustGetAsset("EUR")
+	source := &countingSource{rates: map[string]float64{"SOL/USD": 142.37, "EUR/USD": 1.085}}
+	converter := NewConverter(source, time.Minute)
+
+	tests := []struct {
+		name    string
+		amount  Money
+		to      Asset
+		want    int64
+		wantErr error
+	}{
+		{name: "same asset", amount: New(usd, 1050), to: usd, want: 1050},
+		{name: "stablecoin at the peg", amount: New(usdc, 2_500000), to: usd, want: 250},
+		{name: "stablecoin rounds half up", amount: New(usdc, 5000), to: usd, want: 1},
+		{name: "token at the source rate", amount: New(sol, 500_000_000), to: usd, want: 7119},
+		{name: "fiat at the source rate", amount: New(eur, 1000), to: usd, want: 1085},
+		{name: "refund rounds away from zero", amount: New(usdc, -5000), to: usd, want: -1},
+		{name: "no rate", amount: New(usd, 100), to: sol, wantErr: ErrNoRate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := converter.Convert(context.Background(), tt.amount, tt.to)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Convert: %v", err)
+			}
+			if got.Asset.Code != tt.to.Code || got.Atomic != tt.want {
+				t.Errorf("Convert = %s (%d), want %d %s", got, got.Atomic, tt.want, tt.to.Code)
+			}
+		})
+	}
+
+	calls := source.calls
+	if _, err := converter.Convert(context.Background(), New(sol, 1), usd); err != nil || source.calls != calls {
+		t.Errorf("cached rate refetched: %d calls, want %d (%v)", source.calls, calls, err)
+	}
+}
+
+func TestConvertAtRate_Ceiling(t *testing.T) {
+	usd, usdc := MustGetAsset("USD"), MustGetAsset("USDC")
+	got, err := ConvertAtRate(New(usd, 999), usdc, 1.00033, RoundingCeiling)
+	if err != nil || got.Atomic != 9_993297 {
+		t.Fatalf("ConvertAtRate = %d, %v; want 9993297", got.Atomic, err)
+	}
+	if _, err := ConvertAtRate(New(usd, 1), usdc, 0, RoundingCeiling); err == nil {
+		t.Fatal("zero rate accepted")
+	}
+}
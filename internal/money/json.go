@@ -3,6 +3,8 @@ package money
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"sync/atomic"
 )
 
 // MoneyJSON represents the JSON format for Money.
@@ -120,3 +122,34 @@ func (mr MoneyResponse) ToMoney() Money {
 func FromMoney(m Money) MoneyResponse {
 	return MoneyResponse(m)
 }
+
+// LegacyFloat is an amount in major units as a JSON number. API payloads carry the exact
+// amount as a decimal string and keep a LegacyFloat beside it for clients that predate the
+// string fields. Tag LegacyFloat fields omitzero: after SetLegacyFloats(false) every
+// LegacyFloat reports itself zero and is left out of responses.
+type LegacyFloat float64
+
+// legacyFloatsOff is inverted so the zero value keeps the floats in responses.
+var legacyFloatsOff atomic.Bool
+
+// SetLegacyFloats sets whether responses include LegacyFloat amounts (on by default).
+func SetLegacyFloats(enabled bool) {
+	legacyFloatsOff.Store(!enabled)
+}
+
+// LegacyFloats reports whether responses include LegacyFloat amounts, for payloads built
+// as maps, where omitzero doesn't apply.
+func LegacyFloats() bool {
+	return !legacyFloatsOff.Load()
+}
+
+// IsZero reports whether encoding/json's omitzero should leave the field out.
+func (f LegacyFloat) IsZero() bool {
+	return legacyFloatsOff.Load()
+}
+
+// LegacyFloat returns m in major units as a LegacyFloat, which may lose precision.
+func (m Money) LegacyFloat() LegacyFloat {
+	f, _ := strconv.ParseFloat(m.ToMajor(), 64)
+	return LegacyFloat(f)
+}
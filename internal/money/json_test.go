@@ -236,3 +236,33 @@ func TestMoneyResponse_JSON(t *testing.T) {
 		t.Errorf("Unmarshal() = %v, want %v", parsed.Total, Money{USDC, 1500000})
 	}
 }
+
+func TestLegacyFloat_JSON(t *testing.T) {
+	t.Cleanup(func() { SetLegacyFloats(true) })
+	amount := Money{USDC, 1234567}
+	resp := struct {
+		Amount        LegacyFloat `json:"amount,omitzero"`
+		AmountDecimal string      `json:"amountDecimal"`
+	}{Amount: amount.LegacyFloat(), AmountDecimal: amount.ToMajor()}
+
+	tests := []struct {
+		name    string
+		enabled bool
+		want    string
+	}{
+		{name: "floats enabled", enabled: true, want: `{"amount":1.234567,"amountDecimal":"1.234567"}`},
+		{name: "floats disabled", enabled: false, want: `{"amountDecimal":"1.234567"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetLegacyFloats(tt.enabled)
+			data, err := json.Marshal(resp)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("Marshal() = %s, want %s", data, tt.want)
+			}
+		})
+	}
+}
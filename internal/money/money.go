@@ -11,6 +11,9 @@ import (
 
 // Money represents a monetary amount in atomic units for a specific asset.
 // All arithmetic is performed on int64 to avoid floating-point precision issues.
+// The int64 caps each asset's range by its decimals: about 92 billion USDC, but only about
+// 9.22 tokens at 18 decimals (see Asset.MaxMajor). Amounts beyond it fail with ErrOverflow
+// rather than wrap.
 //
 // Examples:
 //   - $10.50 USD  = Money{Asset: USD, Atomic: 1050}       // 1050 cents
@@ -66,12 +69,19 @@ func FromMajor(asset Asset, major string) (Money, error) {
 	if len(parts) == 2 {
 		fractionalPart = parts[1]
 	}
+	if strings.Trim(fractionalPart, "0123456789") != "" {
+		return Money{}, fmt.Errorf("%w: invalid fractional part %q", ErrInvalidFormat, fractionalPart)
+	}
 
 	// Parse integer part
 	integerVal, err := strconv.ParseInt(integerPart, 10, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		return Money{}, outOfRange(asset, major)
+	}
 	if err != nil {
 		return Money{}, fmt.Errorf("%w: %v", ErrInvalidFormat, err)
 	}
+	negative := strings.HasPrefix(integerPart, "-") // Also catches "-0.5"
 
 	// Handle fractional part with proper rounding
 	var atomicFromFraction int64
@@ -98,27 +108,32 @@ func FromMajor(asset Asset, major string) (Money, error) {
 		}
 	}
 
-	// Calculate total atomic units
-	multiplier := int64(math.Pow10(int(asset.Decimals)))
+	// Calculate total atomic units, in big.Int so the range check can't itself overflow
+	multiplier := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(asset.Decimals)), nil)
+	total := new(big.Int).Mul(big.NewInt(integerVal), multiplier)
 
-	// Check for overflow
-	if integerVal > 0 && multiplier > math.MaxInt64/integerVal {
-		return Money{}, ErrOverflow
-	}
-	if integerVal < 0 && multiplier > math.MaxInt64/(-integerVal) {
-		return Money{}, ErrOverflow
+	// Handle sign for fractional part
+	if negative {
+		total.Sub(total, big.NewInt(atomicFromFraction))
+	} else {
+		total.Add(total, big.NewInt(atomicFromFraction))
 	}
 
-	atomicFromInteger := integerVal * multiplier
-
-	// Handle sign for fractional part
-	if integerVal < 0 {
-		atomicFromFraction = -atomicFromFraction
+	if !total.IsInt64() {
+		return Money{}, outOfRange(asset, major)
 	}
+	return Money{Asset: asset, Atomic: total.Int64()}, nil
+}
 
-	total := atomicFromInteger + atomicFromFraction
+// outOfRange reports an amount the asset's int64 atomic units can't hold.
+func outOfRange(asset Asset, amount string) error {
+	return fmt.Errorf("%w: %s %s is outside the asset's range of ±%s", ErrOverflow, amount, asset.Code, asset.MaxMajor())
+}
 
-	return Money{Asset: asset, Atomic: total}, nil
+// MaxMajor returns the largest amount of the asset Money can hold, in major units, e.g.
+// "92233720368547.758070" for USDC and "9.223372036854775807" for an 18-decimal token.
+func (a Asset) MaxMajor() string {
+	return Money{Asset: a, Atomic: math.MaxInt64}.ToMajor()
 }
 
 // FromAtomic creates Money from an atomic units string.
@@ -128,6 +143,9 @@ func FromMajor(asset Asset, major string) (Money, error) {
 //   - FromAtomic(USDC, "1500000")  → 1.5 USDC
 func FromAtomic(asset Asset, atomic string) (Money, error) {
 	value, err := strconv.ParseInt(atomic, 10, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		return Money{}, fmt.Errorf("%w: %s atomic %s is outside the asset's range of ±%s", ErrOverflow, atomic, asset.Code, asset.MaxMajor())
+	}
 	if err != nil {
 		return Money{}, fmt.Errorf("%w: %v", ErrInvalidFormat, err)
 	}
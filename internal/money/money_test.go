@@ -1,6 +1,7 @@
 package money
 
 import (
+	"errors"
 	"testing"
 )
 
@@ -27,6 +28,7 @@ func TestFromMajor(t *testing.T) {
 		{"USD -5.25", USD, "-5.25", -525, false},
 		{"USD rounding up", USD, "10.555", 1056, false},
 		{"USD rounding down", USD, "10.554", 1055, false},
+		{"USD -0.50", USD, "-0.50", -50, false},
 
 		// USDC (6 decimals)
 		{"USDC 1.5", USDC, "1.5", 1500000, false},
@@ -40,6 +42,7 @@ func TestFromMajor(t *testing.T) {
 		// Errors
 		{"invalid format", USD, "10.50.30", 0, true},
 		{"invalid number", USD, "abc", 0, true},
+		{"invalid fraction", USD, "1.5x", 0, true},
 	}
 
 	for _, tt := range tests {
@@ -554,3 +557,45 @@ func TestRoundUpToCents(t *testing.T) {
 		})
 	}
 }
+
+func TestHighDecimalRange(t *testing.T) {
+	weth := Asset{Code: "WETH", Decimals: 18, Type: AssetTypeSPL}
+	if got := weth.MaxMajor(); got != "9.223372036854775807" {
+		t.Fatalf("MaxMajor() = %s, want 9.223372036854775807", got)
+	}
+
+	tests := []struct {
+		name       string
+		major      string
+		wantAtomic int64
+		wantErr    error
+	}{
+		{name: "within range", major: "9.2", wantAtomic: 9_200_000_000_000_000_000},
+		{name: "largest amount", major: "9.223372036854775807", wantAtomic: 9_223372036854775807},
+		{name: "negative within range", major: "-1.5", wantAtomic: -1_500_000_000_000_000_000},
+		{name: "fraction pushes past the limit", major: "9.3", wantErr: ErrOverflow},
+		{name: "whole tokens past the limit", major: "10", wantErr: ErrOverflow},
+		{name: "integer part past int64", major: "99999999999999999999", wantErr: ErrOverflow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromMajor(weth, tt.major)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("FromMajor(%s) error = %v, want %v", tt.major, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got.Atomic != tt.wantAtomic {
+				t.Fatalf("FromMajor(%s) = %d, %v; want %d", tt.major, got.Atomic, err, tt.wantAtomic)
+			}
+		})
+	}
+
+	if _, err := FromAtomic(weth, "10000000000000000000"); !errors.Is(err, ErrOverflow) {
+		t.Errorf("FromAtomic past int64 error = %v, want ErrOverflow", err)
+	}
+	if _, err := New(weth, 9_000_000_000_000_000_000).Add(New(weth, 1_000_000_000_000_000_000)); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Add past the limit error = %v, want ErrOverflow", err)
+	}
+}
@@ -58,17 +58,8 @@ func (a *SPLAdapter) ToSPLAmount(m Money) (mint string, amount uint64, err error
 //   - Amount exceeds int64 max value (overflow)
 func (a *SPLAdapter) FromSPLAmount(mint string, amount uint64) (Money, error) {
 	// Find asset by mint address
-	var foundAsset *Asset
-	for _, asset := range assetRegistry {
-		if asset.IsSPLToken() {
-			if assetMint, _ := asset.GetSolanaMint(); assetMint == mint {
-				foundAsset = &asset
-				break
-			}
-		}
-	}
-
-	if foundAsset == nil {
+	asset, ok := AssetByMint(mint)
+	if !ok {
 		return Money{}, fmt.Errorf("money: unknown SPL token mint: %s", mint)
 	}
 
@@ -77,7 +68,7 @@ func (a *SPLAdapter) FromSPLAmount(mint string, amount uint64) (Money, error) {
 		return Money{}, fmt.Errorf("money: SPL amount exceeds int64 max: %d", amount)
 	}
 
-	return Money{Asset: *foundAsset, Atomic: int64(amount)}, nil
+	return Money{Asset: asset, Atomic: int64(amount)}, nil
 }
 
 // ValidateSPLAmount checks if a Money value is valid for SPL tokens.
@@ -101,12 +92,8 @@ func (a *SPLAdapter) ValidateSPLAmount(m Money) error {
 // This is useful for external callers who have a mint address and need
 // to know the token's decimal places.
 func (a *SPLAdapter) GetMintDecimals(mint string) (uint8, error) {
-	for _, asset := range assetRegistry {
-		if asset.IsSPLToken() {
-			if assetMint, _ := asset.GetSolanaMint(); assetMint == mint {
-				return asset.Decimals, nil
-			}
-		}
+	if asset, ok := AssetByMint(mint); ok {
+		return asset.Decimals, nil
 	}
 	return 0, fmt.Errorf("money: unknown SPL token mint: %s", mint)
 }
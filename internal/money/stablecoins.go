@@ -1,10 +1,16 @@
 package money
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
 
 // KnownStablecoins maps Solana token mint addresses to their stablecoin symbols.
 // These are the ONLY tokens that should be used for payments to ensure proper
 // decimal handling (all stablecoins use 2-6 decimals and are pegged to $1).
+// RegisterStablecoin adds to it; read it through the functions below.
 //
 // WARNING: Using non-stablecoin tokens (SOL, BONK, etc.) will cause precision
 // issues because the system rounds to 2 decimal places (cents).
@@ -15,6 +21,25 @@ var KnownStablecoins = map[string]string{
 	"2b1kV6DkPAnxd5ixfnxCpjxmKwqjjaYmCZfHsFu24GXo": "PYUSD", // PayPal USD
 }
 
+// stablecoinsMu guards KnownStablecoins against RegisterStablecoin.
+var stablecoinsMu sync.RWMutex
+
+// RegisterStablecoin registers an SPL token pegged to $1 and accepts its mint for payments,
+// like the built-in stablecoins.
+func RegisterStablecoin(asset Asset) error {
+	if !asset.IsSPLToken() {
+		return fmt.Errorf("money: stablecoin %s must be an SPL token", asset.Code)
+	}
+	if err := RegisterAsset(asset); err != nil {
+		return err
+	}
+
+	stablecoinsMu.Lock()
+	KnownStablecoins[asset.Metadata.SolanaMint] = asset.Code
+	stablecoinsMu.Unlock()
+	return nil
+}
+
 // ValidateStablecoinMint checks if a token mint address is a known stablecoin.
 // Returns the stablecoin symbol if valid, or an error if not.
 //
@@ -23,11 +48,19 @@ var KnownStablecoins = map[string]string{
 //   - Non-stablecoins have unpredictable values (1 SOL ≠ $1, 1 BONK ≠ $1)
 //   - System rounds to 2 decimal places assuming $1 peg
 func ValidateStablecoinMint(mintAddress string) (string, error) {
+	stablecoinsMu.RLock()
+	defer stablecoinsMu.RUnlock()
+
 	symbol, ok := KnownStablecoins[mintAddress]
 	if !ok {
+		symbols := make([]string, 0, len(KnownStablecoins))
+		for _, sym := range KnownStablecoins {
+			symbols = append(symbols, sym)
+		}
+		sort.Strings(symbols)
 		return "", fmt.Errorf(
-			"token mint %s is not a recognized stablecoin - only stablecoins are supported (USDC, USDT, PYUSD, CASH)",
-			mintAddress,
+			"token mint %s is not a recognized stablecoin - only stablecoins are supported (%s)",
+			mintAddress, strings.Join(symbols, ", "),
 		)
 	}
 	return symbol, nil
@@ -35,6 +68,8 @@ func ValidateStablecoinMint(mintAddress string) (string, error) {
 
 // IsStablecoin returns true if the mint address is a known stablecoin.
 func IsStablecoin(mintAddress string) bool {
+	stablecoinsMu.RLock()
+	defer stablecoinsMu.RUnlock()
 	_, ok := KnownStablecoins[mintAddress]
 	return ok
 }
@@ -42,12 +77,16 @@ func IsStablecoin(mintAddress string) bool {
 // GetStablecoinSymbol returns the symbol for a stablecoin mint address.
 // Returns empty string if not a known stablecoin.
 func GetStablecoinSymbol(mintAddress string) string {
+	stablecoinsMu.RLock()
+	defer stablecoinsMu.RUnlock()
 	return KnownStablecoins[mintAddress]
 }
 
 // GetMintAddressForSymbol returns the mint address for a stablecoin symbol.
 // Returns empty string if symbol not found.
 func GetMintAddressForSymbol(symbol string) string {
+	stablecoinsMu.RLock()
+	defer stablecoinsMu.RUnlock()
 	for mint, sym := range KnownStablecoins {
 		if sym == symbol {
 			return mint
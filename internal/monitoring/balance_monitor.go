@@ -15,6 +15,7 @@ import (
 	"github.com/CedrosPay/server/internal/config"
 	"github.com/CedrosPay/server/internal/httputil"
 	"github.com/CedrosPay/server/internal/logger"
+	solanaHelpers "github.com/CedrosPay/server/internal/solana"
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
 )
@@ -42,8 +43,8 @@ type BalanceAlert struct {
 }
 
 // NewBalanceMonitor creates a new balance monitor for the configured server wallets.
-func NewBalanceMonitor(cfg *config.Config, rpcClient *rpc.Client, wallets []solana.PrivateKey) *BalanceMonitor {
-	// Extract public keys from private keys
+func NewBalanceMonitor(cfg *config.Config, rpcClient *rpc.Client, wallets []solanaHelpers.Signer) *BalanceMonitor {
+	// Only addresses are needed to check balances
 	publicKeys := make([]solana.PublicKey, len(wallets))
 	for i, wallet := range wallets {
 		publicKeys[i] = wallet.PublicKey()
@@ -219,13 +220,28 @@ func (m *BalanceMonitor) sendAlert(ctx context.Context, wallet string, balance f
 		}
 	}
 
+	if !m.post(ctx, wallet, body) {
+		return
+	}
+	log.Info().
+		Str("wallet", logger.TruncateAddress(wallet)).
+		Float64("balance_sol", balance).
+		Msg("balance_monitor.alert_sent")
+	// Mark as alerted
+	m.mu.Lock()
+	m.alertedKeys[wallet] = time.Now()
+	m.mu.Unlock()
+}
+
+// post delivers an alert body to the configured webhook, reporting whether it was accepted.
+func (m *BalanceMonitor) post(ctx context.Context, wallet string, body []byte) bool {
 	req, err := http.NewRequestWithContext(ctx, "POST", m.cfg.Monitoring.LowBalanceAlertURL, bytes.NewReader(body))
 	if err != nil {
 		log.Error().
 			Err(err).
 			Str("wallet", logger.TruncateAddress(wallet)).
 			Msg("balance_monitor.request_error")
-		return
+		return false
 	}
 
 	// Set default Content-Type for Discord/Slack
@@ -242,30 +258,22 @@ func (m *BalanceMonitor) sendAlert(ctx context.Context, wallet string, balance f
 			Err(err).
 			Str("wallet", logger.TruncateAddress(wallet)).
 			Msg("balance_monitor.send_error")
-		return
+		return false
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		log.Info().
-			Str("wallet", logger.TruncateAddress(wallet)).
-			Float64("balance_sol", balance).
-			Int("status_code", resp.StatusCode).
-			Msg("balance_monitor.alert_sent")
-		// Mark as alerted
-		m.mu.Lock()
-		m.alertedKeys[wallet] = time.Now()
-		m.mu.Unlock()
-	} else {
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		log.Warn().
 			Str("wallet", logger.TruncateAddress(wallet)).
 			Int("status_code", resp.StatusCode).
 			Msg("balance_monitor.alert_failed")
+		return false
 	}
+	return true
 }
 
 // renderTemplate renders the custom body template with alert data.
-func (m *BalanceMonitor) renderTemplate(alert BalanceAlert) ([]byte, error) {
+func (m *BalanceMonitor) renderTemplate(alert any) ([]byte, error) {
 	tmpl, err := template.New("alert").Parse(m.cfg.Monitoring.BodyTemplate)
 	if err != nil {
 		return nil, fmt.Errorf("parse template: %w", err)
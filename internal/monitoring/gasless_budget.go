@@ -0,0 +1,79 @@
+package monitoring
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/CedrosPay/server/internal/logger"
+)
+
+// GaslessBudgetAlert is sent when a server wallet spends its daily gasless fee budget.
+type GaslessBudgetAlert struct {
+	Type      string    `json:"type"` // Always "gasless_budget_exhausted"
+	Wallet    string    `json:"wallet"`
+	Spent     float64   `json:"spent"`  // SOL spent on gasless fees today
+	Budget    float64   `json:"budget"` // Daily budget in SOL
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// SendGaslessBudgetAlert notifies the low balance webhook that wallet has stopped paying
+// gasless fees for the rest of the UTC day. Custom body templates are rendered with the
+// GaslessBudgetAlert; if that fails, the default Discord format is sent instead.
+func (m *BalanceMonitor) SendGaslessBudgetAlert(ctx context.Context, wallet string, spentSOL, budgetSOL float64) {
+	if m.cfg.Monitoring.LowBalanceAlertURL == "" {
+		return
+	}
+
+	alert := GaslessBudgetAlert{
+		Type:      "gasless_budget_exhausted",
+		Wallet:    wallet,
+		Spent:     spentSOL,
+		Budget:    budgetSOL,
+		Timestamp: time.Now(),
+	}
+
+	var body []byte
+	if m.cfg.Monitoring.BodyTemplate != "" {
+		rendered, err := m.renderTemplate(alert)
+		if err != nil {
+			log.Warn().
+				Err(err).
+				Str("wallet", logger.TruncateAddress(wallet)).
+				Msg("balance_monitor.budget_template_error")
+		} else {
+			body = rendered
+		}
+	}
+	if body == nil {
+		var err error
+		body, err = json.Marshal(map[string]any{
+			"content": fmt.Sprintf(
+				"⚠️ **Gasless Budget Exhausted**\n\n"+
+					"Wallet: `%s`\n"+
+					"Spent today: **%.6f SOL**\n"+
+					"Daily budget: %.6f SOL\n\n"+
+					"This wallet stops paying network fees until midnight UTC. "+
+					"Once every server wallet is exhausted, quotes fall back to non-gasless payments.",
+				wallet, spentSOL, budgetSOL,
+			),
+		})
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str("wallet", logger.TruncateAddress(wallet)).
+				Msg("balance_monitor.marshal_error")
+			return
+		}
+	}
+
+	if m.post(ctx, wallet, body) {
+		log.Info().
+			Str("wallet", logger.TruncateAddress(wallet)).
+			Float64("spent_sol", spentSOL).
+			Msg("balance_monitor.budget_alert_sent")
+	}
+}
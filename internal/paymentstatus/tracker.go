@@ -0,0 +1,142 @@
+package paymentstatus
+
+import (
+	"sync"
+	"time"
+)
+
+// Stage is a step in the x402 verification lifecycle.
+type Stage string
+
+const (
+	StageReceived  Stage = "received"  // Payment proof parsed and accepted for verification
+	StageSubmitted Stage = "submitted" // Transaction on-chain (or co-signed and sent), awaiting confirmation
+	StageConfirmed Stage = "confirmed" // Transaction confirmed and amount/recipient verified
+	StageGranted   Stage = "granted"   // Payment recorded and access granted
+	StagePartial   Stage = "partial"   // Split cart payment recorded; the cart total is not yet covered
+	StageFailed    Stage = "failed"    // Verification failed; see Update.Error
+)
+
+// Terminal reports whether no further updates follow this stage.
+func (s Stage) Terminal() bool {
+	return s == StageGranted || s == StagePartial || s == StageFailed
+}
+
+// Update is a single status change for a payment signature.
+type Update struct {
+	Signature  string    `json:"signature"`
+	Stage      Stage     `json:"stage"`
+	ResourceID string    `json:"resourceId,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// subscriberBuffer holds every stage of one payment, so slow readers never miss updates.
+const subscriberBuffer = 8
+
+type entry struct {
+	updates   []Update
+	updatedAt time.Time
+}
+
+// Tracker keeps recent payment status history in memory and fans updates out to subscribers.
+// History is retained for the configured TTL so clients that connect after verification
+// started (or finished) still receive the full sequence.
+// A nil *Tracker is valid and discards all updates.
+type Tracker struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	history   map[string]*entry
+	subs      map[string]map[chan Update]struct{}
+	lastSweep time.Time
+}
+
+// NewTracker creates a tracker that retains history for ttl (default: 10m).
+func NewTracker(ttl time.Duration) *Tracker {
+	if ttl <= 0 {
+		ttl = 10 * time.Minute
+	}
+	return &Tracker{
+		ttl:       ttl,
+		history:   make(map[string]*entry),
+		subs:      make(map[string]map[chan Update]struct{}),
+		lastSweep: time.Now(),
+	}
+}
+
+// Publish records an update and delivers it to current subscribers of the signature.
+func (t *Tracker) Publish(update Update) {
+	if t == nil || update.Signature == "" {
+		return
+	}
+	if update.Timestamp.IsZero() {
+		update.Timestamp = time.Now().UTC()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.sweepLocked(update.Timestamp)
+
+	e, ok := t.history[update.Signature]
+	if !ok {
+		e = &entry{}
+		t.history[update.Signature] = e
+	}
+	e.updates = append(e.updates, update)
+	e.updatedAt = time.Now()
+
+	for ch := range t.subs[update.Signature] {
+		select {
+		case ch <- update:
+		default:
+			// Buffer full - subscriber is not reading; drop rather than block verification
+		}
+	}
+}
+
+// Subscribe returns the history recorded so far for a signature and a channel of future updates.
+// The returned cancel function must be called to release the subscription.
+func (t *Tracker) Subscribe(signature string) ([]Update, <-chan Update, func()) {
+	ch := make(chan Update, subscriberBuffer)
+	if t == nil {
+		return nil, ch, func() {}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var history []Update
+	if e, ok := t.history[signature]; ok {
+		history = append(history, e.updates...)
+	}
+
+	if t.subs[signature] == nil {
+		t.subs[signature] = make(map[chan Update]struct{})
+	}
+	t.subs[signature][ch] = struct{}{}
+
+	cancel := func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		delete(t.subs[signature], ch)
+		if len(t.subs[signature]) == 0 {
+			delete(t.subs, signature)
+		}
+	}
+	return history, ch, cancel
+}
+
+// sweepLocked drops expired history at most once per TTL. Caller must hold t.mu.
+func (t *Tracker) sweepLocked(now time.Time) {
+	if now.Sub(t.lastSweep) < t.ttl {
+		return
+	}
+	t.lastSweep = now
+	cutoff := time.Now().Add(-t.ttl)
+	for sig, e := range t.history {
+		if e.updatedAt.Before(cutoff) {
+			delete(t.history, sig)
+		}
+	}
+}
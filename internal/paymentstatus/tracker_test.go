@@ -0,0 +1,78 @@
+package paymentstatus
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTracker_SubscribeReceivesHistoryAndUpdates(t *testing.T) {
+	tracker := NewTracker(time.Minute)
+	tracker.Publish(Update{Signature: "sig1", Stage: StageReceived})
+
+	history, updates, cancel := tracker.Subscribe("sig1")
+	defer cancel()
+
+	if len(history) != 1 || history[0].Stage != StageReceived {
+		t.Fatalf("expected received in history, got %+v", history)
+	}
+
+	tracker.Publish(Update{Signature: "sig1", Stage: StageSubmitted})
+	tracker.Publish(Update{Signature: "other", Stage: StageGranted})
+
+	select {
+	case u := <-updates:
+		if u.Stage != StageSubmitted {
+			t.Fatalf("expected submitted, got %s", u.Stage)
+		}
+		if u.Timestamp.IsZero() {
+			t.Fatal("expected timestamp to be set")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for update")
+	}
+
+	select {
+	case u := <-updates:
+		t.Fatalf("received update for another signature: %+v", u)
+	default:
+	}
+}
+
+func TestTracker_CancelStopsDelivery(t *testing.T) {
+	tracker := NewTracker(time.Minute)
+	_, updates, cancel := tracker.Subscribe("sig1")
+	cancel()
+
+	tracker.Publish(Update{Signature: "sig1", Stage: StageGranted})
+
+	select {
+	case u := <-updates:
+		t.Fatalf("unexpected update after cancel: %+v", u)
+	default:
+	}
+}
+
+func TestTracker_NilIsNoop(t *testing.T) {
+	var tracker *Tracker
+	tracker.Publish(Update{Signature: "sig1", Stage: StageReceived})
+	history, _, cancel := tracker.Subscribe("sig1")
+	defer cancel()
+	if len(history) != 0 {
+		t.Fatalf("expected no history, got %+v", history)
+	}
+}
+
+func TestStage_Terminal(t *testing.T) {
+	tests := map[Stage]bool{
+		StageReceived:  false,
+		StageSubmitted: false,
+		StageConfirmed: false,
+		StageGranted:   true,
+		StageFailed:    true,
+	}
+	for stage, want := range tests {
+		if got := stage.Terminal(); got != want {
+			t.Errorf("%s.Terminal() = %v, want %v", stage, got, want)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package paywall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+
+	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+// ErrAccessDenied is returned when the paying wallet or the client IP is on the deny list.
+var ErrAccessDenied = errors.New("paywall: access denied")
+
+// ErrInvalidAccessRule indicates an access rule failed validation.
+var ErrInvalidAccessRule = errors.New("paywall: invalid access rule")
+
+// accessListsTTL is how long the access lists are cached. Writes through this service take
+// effect immediately; other instances pick them up within the TTL.
+const accessListsTTL = 30 * time.Second
+
+// accessLists caches the stored access rules in the form they are matched in.
+type accessLists struct {
+	mu      sync.Mutex
+	loaded  time.Time
+	wallets map[string]string // wallet -> allow or deny
+	ranges  []accessRange
+}
+
+type accessRange struct {
+	prefix netip.Prefix
+	list   string
+}
+
+// AccessRules returns every access rule, ordered by kind and value.
+func (s *Service) AccessRules(ctx context.Context) ([]storage.AccessRule, error) {
+	return s.store.ListAccessRules(ctx)
+}
+
+// SaveAccessRule puts a wallet or IP range on the allow or deny list, replacing its rule if it
+// is already on one. A single IP is stored as a /32 (or /128) range.
+func (s *Service) SaveAccessRule(ctx context.Context, rule storage.AccessRule) (storage.AccessRule, error) {
+	if rule.List != storage.AccessListAllow && rule.List != storage.AccessListDeny {
+		return storage.AccessRule{}, fmt.Errorf("%w: list must be allow or deny", ErrInvalidAccessRule)
+	}
+	value, err := normalizeAccessValue(rule.Kind, rule.Value)
+	if err != nil {
+		return storage.AccessRule{}, err
+	}
+	rule.Value = value
+
+	saved, err := s.store.SaveAccessRule(ctx, rule)
+	if err != nil {
+		return storage.AccessRule{}, fmt.Errorf("paywall: save access rule: %w", err)
+	}
+	s.access.invalidate()
+	return saved, nil
+}
+
+// DeleteAccessRule takes a wallet or IP range off its list. Returns storage.ErrNotFound if it
+// is on neither.
+func (s *Service) DeleteAccessRule(ctx context.Context, kind, value string) error {
+	value, err := normalizeAccessValue(kind, value)
+	if err != nil {
+		return err
+	}
+	if err := s.store.DeleteAccessRule(ctx, kind, value); err != nil {
+		return err
+	}
+	s.access.invalidate()
+	return nil
+}
+
+// normalizeAccessValue validates a wallet address or IP range and returns it in the form it is
+// stored in, so each wallet and range has one rule.
+func normalizeAccessValue(kind, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	switch kind {
+	case storage.AccessRuleWallet:
+		key, err := solana.PublicKeyFromBase58(value)
+		if err != nil {
+			return "", fmt.Errorf("%w: invalid wallet address: %v", ErrInvalidAccessRule, err)
+		}
+		return key.String(), nil
+	case storage.AccessRuleIP:
+		if !strings.Contains(value, "/") {
+			addr, err := netip.ParseAddr(value)
+			if err != nil {
+				return "", fmt.Errorf("%w: invalid IP address %q", ErrInvalidAccessRule, value)
+			}
+			addr = addr.Unmap()
+			return netip.PrefixFrom(addr, addr.BitLen()).String(), nil
+		}
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return "", fmt.Errorf("%w: invalid IP range %q", ErrInvalidAccessRule, value)
+		}
+		return prefix.Masked().String(), nil
+	default:
+		return "", fmt.Errorf("%w: kind must be wallet or ip", ErrInvalidAccessRule)
+	}
+}
+
+// accessList returns the list wallet or ip is on: allow if either is allowed, otherwise deny if
+// either is denied, otherwise "". Empty values are not matched.
+func (s *Service) accessList(ctx context.Context, wallet, ip string) (string, error) {
+	s.access.mu.Lock()
+	defer s.access.mu.Unlock()
+
+	if time.Since(s.access.loaded) >= accessListsTTL {
+		rules, err := s.store.ListAccessRules(ctx)
+		if err != nil {
+			return "", fmt.Errorf("paywall: load access lists: %w", err)
+		}
+		s.access.load(rules)
+	}
+
+	var lists []string
+	if wallet != "" {
+		if list, ok := s.access.wallets[wallet]; ok {
+			lists = append(lists, list)
+		}
+	}
+	if addr, err := netip.ParseAddr(ip); err == nil {
+		addr = addr.Unmap()
+		for _, r := range s.access.ranges {
+			if r.prefix.Contains(addr) {
+				lists = append(lists, r.list)
+			}
+		}
+	}
+
+	result := ""
+	for _, list := range lists {
+		if list == storage.AccessListAllow {
+			return list, nil
+		}
+		result = list
+	}
+	return result, nil
+}
+
+// screenPayer applies the access lists to a paying wallet and the client IP. It returns
+// ErrAccessDenied if either is denied, and whether either is allowed, which exempts the
+// payer from the velocity rules.
+func (s *Service) screenPayer(ctx context.Context, wallet string) (bool, error) {
+	ip := clientIPFromContext(ctx)
+	list, err := s.accessList(ctx, wallet, ip)
+	if err != nil {
+		return false, err
+	}
+	if list == storage.AccessListDeny {
+		log := logger.FromContext(ctx)
+		log.Warn().
+			Str("wallet", logger.TruncateAddress(wallet)).
+			Str("ip", ip).
+			Msg("fraud.access_denied")
+		return false, ErrAccessDenied
+	}
+	return list == storage.AccessListAllow, nil
+}
+
+// load replaces the cached lists with rules. Callers hold mu.
+func (a *accessLists) load(rules []storage.AccessRule) {
+	a.wallets = make(map[string]string)
+	a.ranges = nil
+	for _, rule := range rules {
+		switch rule.Kind {
+		case storage.AccessRuleWallet:
+			a.wallets[rule.Value] = rule.List
+		case storage.AccessRuleIP:
+			if prefix, err := netip.ParsePrefix(rule.Value); err == nil {
+				a.ranges = append(a.ranges, accessRange{prefix: prefix, list: rule.List})
+			}
+		}
+	}
+	a.loaded = time.Now()
+}
+
+// invalidate makes the next lookup reload the lists from storage.
+func (a *accessLists) invalidate() {
+	a.mu.Lock()
+	a.loaded = time.Time{}
+	a.mu.Unlock()
+}
@@ -0,0 +1,145 @@
+package paywall
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/CedrosPay/server/internal/callbacks"
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/pkg/x402"
+)
+
+const (
+	deniedWallet  = "So11111111111111111111111111111111111111112"
+	allowedWallet = "11111111111111111111111111111111"
+)
+
+func TestSaveAccessRuleNormalizes(t *testing.T) {
+	cfg := testConfig()
+	svc := NewService(cfg, storage.NewMemoryStore(), stubVerifier{}, callbacks.NoopNotifier{}, testRepository(cfg), nil, nil)
+
+	tests := []struct {
+		name      string
+		rule      storage.AccessRule
+		wantValue string
+		wantErr   bool
+	}{
+		{name: "single IPv4", rule: storage.AccessRule{Kind: storage.AccessRuleIP, Value: "203.0.113.7", List: storage.AccessListDeny}, wantValue: "203.0.113.7/32"},
+		{name: "single IPv6", rule: storage.AccessRule{Kind: storage.AccessRuleIP, Value: "2001:db8::1", List: storage.AccessListDeny}, wantValue: "2001:db8::1/128"},
+		{name: "unmasked range", rule: storage.AccessRule{Kind: storage.AccessRuleIP, Value: "198.51.100.9/24", List: storage.AccessListAllow}, wantValue: "198.51.100.0/24"},
+		{name: "wallet", rule: storage.AccessRule{Kind: storage.AccessRuleWallet, Value: " " + deniedWallet, List: storage.AccessListDeny}, wantValue: deniedWallet},
+		{name: "invalid wallet", rule: storage.AccessRule{Kind: storage.AccessRuleWallet, Value: "not-a-wallet", List: storage.AccessListDeny}, wantErr: true},
+		{name: "invalid IP", rule: storage.AccessRule{Kind: storage.AccessRuleIP, Value: "300.0.0.1", List: storage.AccessListDeny}, wantErr: true},
+		{name: "unknown list", rule: storage.AccessRule{Kind: storage.AccessRuleIP, Value: "203.0.113.7", List: "block"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		rule, err := svc.SaveAccessRule(context.Background(), tt.rule)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidAccessRule) {
+				t.Errorf("%s: err = %v, want ErrInvalidAccessRule", tt.name, err)
+			}
+			continue
+		}
+		if err != nil || rule.Value != tt.wantValue {
+			t.Errorf("%s: SaveAccessRule = %q, %v, want %q", tt.name, rule.Value, err, tt.wantValue)
+		}
+	}
+}
+
+func TestQuoteAccessLists(t *testing.T) {
+	cfg := testConfig()
+	cfg.Paywall.Fraud.QuotesPerIP = config.VelocityRuleConfig{Max: 1}
+	svc := NewService(cfg, storage.NewMemoryStore(), stubVerifier{}, callbacks.NoopNotifier{}, testRepository(cfg), nil, nil)
+	ctx := context.Background()
+	for _, rule := range []storage.AccessRule{
+		{Kind: storage.AccessRuleWallet, Value: deniedWallet, List: storage.AccessListDeny},
+		{Kind: storage.AccessRuleWallet, Value: allowedWallet, List: storage.AccessListAllow},
+		{Kind: storage.AccessRuleIP, Value: "203.0.113.0/24", List: storage.AccessListDeny},
+		{Kind: storage.AccessRuleIP, Value: "198.51.100.7", List: storage.AccessListAllow},
+	} {
+		if _, err := svc.SaveAccessRule(ctx, rule); err != nil {
+			t.Fatalf("SaveAccessRule: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		wallet  string
+		ip      string
+		wantErr error
+	}{
+		{name: "denied wallet", wallet: deniedWallet, ip: "192.0.2.1", wantErr: ErrAccessDenied},
+		{name: "denied range", ip: "203.0.113.99", wantErr: ErrAccessDenied},
+		{name: "allowed wallet in denied range", wallet: allowedWallet, ip: "203.0.113.99"},
+		{name: "first quote from IP", ip: "192.0.2.1"},
+		{name: "over quotes_per_ip", ip: "192.0.2.1", wantErr: ErrVelocityLimit},
+		{name: "allowed IP", ip: "198.51.100.7"},
+		{name: "allowed IP exempt from quotes_per_ip", ip: "198.51.100.7"},
+	}
+	for _, tt := range tests {
+		_, err := svc.GenerateQuoteForWallet(WithClientIP(ctx, tt.ip), "demo-content", "", tt.wallet)
+		if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+			t.Fatalf("%s: GenerateQuoteForWallet error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+
+	// Removing the rule takes effect immediately
+	if err := svc.DeleteAccessRule(ctx, storage.AccessRuleWallet, deniedWallet); err != nil {
+		t.Fatalf("DeleteAccessRule: %v", err)
+	}
+	if _, err := svc.GenerateQuoteForWallet(ctx, "demo-content", "", deniedWallet); err != nil {
+		t.Fatalf("quote after removing deny rule: %v", err)
+	}
+	if err := svc.DeleteAccessRule(ctx, storage.AccessRuleWallet, deniedWallet); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("DeleteAccessRule twice: err = %v, want storage.ErrNotFound", err)
+	}
+}
+
+func TestPaymentAccessLists(t *testing.T) {
+	tests := []struct {
+		name    string
+		list    string
+		wantErr bool
+	}{
+		{name: "denied", list: storage.AccessListDeny, wantErr: true},
+		{name: "allowed", list: storage.AccessListAllow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cfg := testConfig()
+			store := storage.NewMemoryStore()
+			t.Cleanup(func() { _ = store.Close() })
+			verifier := payerCheckingVerifier{stubVerifier{result: x402.VerificationResult{Wallet: deniedWallet}}}
+			svc := NewService(cfg, store, verifier, callbacks.NoopNotifier{}, testRepository(cfg), nil, nil)
+			if _, err := svc.SaveAccessRule(ctx, storage.AccessRule{Kind: storage.AccessRuleWallet, Value: deniedWallet, List: tt.list}); err != nil {
+				t.Fatalf("SaveAccessRule: %v", err)
+			}
+
+			signature := computeSignature("demo-content", cfg.X402.PaymentAddress, "access-list")
+			payload, _ := json.Marshal(x402.PaymentPayload{
+				Scheme:  "solana-spl-transfer",
+				Network: cfg.X402.Network,
+				Payload: x402.SolanaPayload{Signature: signature, Transaction: base64.StdEncoding.EncodeToString([]byte("signed-tx"))},
+			})
+			_, err := svc.Authorize(ctx, "demo-content", "", base64.StdEncoding.EncodeToString(payload), "")
+			if tt.wantErr {
+				if !errors.Is(err, ErrAccessDenied) {
+					t.Fatalf("Authorize error = %v, want ErrAccessDenied", err)
+				}
+				// Only the replay-protection placeholder is kept
+				if payment, _ := store.GetPayment(ctx, signature); payment.Wallet != "" {
+					t.Fatalf("denied payment recorded: %+v", payment)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authorize: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package paywall
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+// RecordAdminAction appends an entry to the append-only admin audit log.
+func (s *Service) RecordAdminAction(ctx context.Context, entry storage.AdminAuditEntry) error {
+	if err := s.store.RecordAdminAudit(ctx, entry); err != nil {
+		return fmt.Errorf("paywall: record admin audit: %w", err)
+	}
+	return nil
+}
+
+// ListAdminAudit returns the admin audit entries matching filter, newest first.
+func (s *Service) ListAdminAudit(ctx context.Context, filter storage.AdminAuditFilter) ([]storage.AdminAuditEntry, error) {
+	return s.store.ListAdminAudit(ctx, filter)
+}
+
+// RetryWebhook resets a queued webhook to pending so the worker delivers it again.
+// Returns storage.ErrNotFound if the webhook doesn't exist.
+func (s *Service) RetryWebhook(ctx context.Context, webhookID string) error {
+	return s.store.RetryWebhook(ctx, webhookID)
+}
+
+// ListWebhookDeliveries returns the webhook delivery attempts matching filter, newest first.
+func (s *Service) ListWebhookDeliveries(ctx context.Context, filter storage.WebhookDeliveryFilter) ([]storage.WebhookDelivery, error) {
+	return s.store.ListWebhookDeliveries(ctx, filter)
+}
@@ -0,0 +1,18 @@
+package paywall
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+// PaymentTotals sums the payments recorded in [since, until) per asset.
+func (s *Service) PaymentTotals(ctx context.Context, since, until time.Time) ([]storage.PaymentTotal, error) {
+	totals, err := s.store.SumPayments(ctx, since, until)
+	if err != nil {
+		return nil, fmt.Errorf("paywall: sum payments: %w", err)
+	}
+	return totals, nil
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/CedrosPay/server/internal/coupons"
 	"github.com/CedrosPay/server/internal/logger"
 	"github.com/CedrosPay/server/internal/money"
+	"github.com/CedrosPay/server/internal/paymentstatus"
 	"github.com/CedrosPay/server/internal/storage"
 	"github.com/CedrosPay/server/pkg/x402"
 )
@@ -60,7 +61,7 @@ func (s *Service) AuthorizeWithWallet(ctx context.Context, resourceID, stripeSes
 		sessionSignature := fmt.Sprintf("stripe:%s", stripeSessionID)
 		payment, err := s.store.GetPayment(ctx, sessionSignature)
 		if err == nil {
-			if payment.ResourceID != resourceID {
+			if !PaymentGrantsAccess(payment, resourceID) {
 				return AuthorizationResult{}, fmt.Errorf("stripe session belongs to %s, not %s", payment.ResourceID, resourceID)
 			}
 			return AuthorizationResult{Granted: true, Method: "stripe", Wallet: payment.Wallet}, nil
@@ -77,13 +78,20 @@ func (s *Service) AuthorizeWithWallet(ctx context.Context, resourceID, stripeSes
 			return AuthorizationResult{}, fmt.Errorf("parse payment header: %w", err)
 		}
 		// Validate scheme and network match configuration
-		if proof.Scheme != "solana-spl-transfer" && proof.Scheme != "solana" {
+		if !x402.SupportedScheme(proof.Scheme) {
 			return AuthorizationResult{}, fmt.Errorf("unsupported scheme: %s", proof.Scheme)
 		}
 		if proof.Network != s.cfg.X402.Network {
 			return AuthorizationResult{}, fmt.Errorf("network mismatch: expected %s, got %s", s.cfg.X402.Network, proof.Network)
 		}
 
+		// A fiat-priced resource may be paid at the rate of any quote that is still unexpired
+		pricings, err := s.acceptedFiatPricings(ctx, resource)
+		if err != nil {
+			return AuthorizationResult{}, err
+		}
+		resource = pricings[0].resource
+
 		// Verify crypto pricing is configured
 		if resource.CryptoAtomicAmount <= 0 {
 			return AuthorizationResult{}, fmt.Errorf("resource has no crypto pricing configured")
@@ -105,28 +113,48 @@ func (s *Service) AuthorizeWithWallet(ctx context.Context, resourceID, stripeSes
 		if s.coupons != nil {
 			catalogCoupons := SelectCouponsForPayment(ctx, s.coupons, resourceID, coupons.PaymentMethodX402, manualCoupon, ScopeCatalog)
 			checkoutCoupons := SelectCouponsForPayment(ctx, s.coupons, "", coupons.PaymentMethodX402, nil, ScopeCheckout)
-			applicableCoupons = append(catalogCoupons, checkoutCoupons...)
+			applicableCoupons = unitCoupons(append(catalogCoupons, checkoutCoupons...))
 		}
 
-		// Use atomic amount directly (Money type)
-		expectedMoney := money.Money{Asset: cryptoAsset, Atomic: resource.CryptoAtomicAmount}
+		expectedAmounts := make([]money.Money, len(pricings))
+		giftCards := make([]giftCardRedemption, len(pricings))
+		for i, pricing := range pricings {
+			// Use atomic amount directly (Money type)
+			expectedMoney := money.Money{Asset: cryptoAsset, Atomic: pricing.resource.CryptoAtomicAmount}
+
+			// Apply stacked coupons using precise Money arithmetic (catalog first, then checkout)
+			if len(applicableCoupons) > 0 {
+				roundingMode := money.ParseRoundingMode(s.cfg.X402.RoundingMode)
+				expectedMoney, err = StackCouponsOnMoney(expectedMoney, applicableCoupons, roundingMode)
+				if err != nil {
+					return AuthorizationResult{}, fmt.Errorf("apply coupons to expected amount: %w", err)
+				}
+			}
 
-		// Apply stacked coupons using precise Money arithmetic (catalog first, then checkout)
-		if len(applicableCoupons) > 0 {
-			roundingMode := money.ParseRoundingMode(s.cfg.X402.RoundingMode)
-			expectedMoney, err = StackCouponsOnMoney(expectedMoney, applicableCoupons, roundingMode)
-			if err != nil {
-				return AuthorizationResult{}, fmt.Errorf("apply coupons to expected amount: %w", err)
+			// IMPORTANT: Round to cents precision (2 decimals) using precise integer arithmetic
+			// This ensures authorization compares against the same rounded amount as the quote
+			// Example: $0.184 (after coupons) → $0.19 (ceiling)
+			expectedMoney = expectedMoney.RoundUpToCents()
+
+			// A gift card pays part of the price from its balance, as in the quote
+			if giftCards[i], err = s.redeemGiftCard(ctx, couponCode, expectedMoney); err != nil {
+				return AuthorizationResult{}, err
+			}
+			if expectedAmounts[i], err = expectedMoney.Sub(giftCards[i].amount); err != nil {
+				return AuthorizationResult{}, fmt.Errorf("apply gift card to expected amount: %w", err)
+			}
+		}
+		expectedMoney := expectedAmounts[0]
+		minimumMoney := expectedMoney
+		for _, amount := range expectedAmounts[1:] {
+			if amount.Atomic < minimumMoney.Atomic {
+				minimumMoney = amount
 			}
 		}
-
-		// IMPORTANT: Round to cents precision (2 decimals) using precise integer arithmetic
-		// This ensures authorization compares against the same rounded amount as the quote
-		// Example: $0.184 (after coupons) → $0.19 (ceiling)
-		expectedMoney = expectedMoney.RoundUpToCents()
 
 		// Convert back to float64 for x402 verification (external API boundary)
 		expectedAmount, _ := strconv.ParseFloat(expectedMoney.ToMajor(), 64)
+		minimumAmount, _ := strconv.ParseFloat(minimumMoney.ToMajor(), 64)
 
 		// For verification, we need the actual token account to check the transaction
 		recipientTokenAccount := resource.CryptoAccount
@@ -134,18 +162,22 @@ func (s *Service) AuthorizeWithWallet(ctx context.Context, resourceID, stripeSes
 			recipientTokenAccount = deriveTokenAccountSafe(s.cfg.X402.PaymentAddress, s.cfg.X402.TokenMint)
 		}
 
+		var velocity velocityHit
 		requirement := x402.Requirement{
 			ResourceID:            resourceID,
 			RecipientOwner:        s.cfg.X402.PaymentAddress,
 			RecipientTokenAccount: recipientTokenAccount,
 			TokenMint:             s.cfg.X402.TokenMint,
-			Amount:                expectedAmount, // Use discounted amount
+			Amount:                minimumAmount, // Use discounted amount (the lowest accepted rate's)
 			Network:               s.cfg.X402.Network,
 			TokenDecimals:         s.cfg.X402.TokenDecimals,
 			AllowedTokens:         s.cfg.X402.AllowedTokens,
 			QuoteTTL:              s.cfg.Paywall.QuoteTTL.Duration,
 			SkipPreflight:         s.cfg.X402.SkipPreflight,
+			SimulateTransaction:   s.cfg.X402.SimulateTransactions,
 			Commitment:            s.cfg.X402.Commitment,
+			Memo:                  s.requiredMemo(resourceID),
+			CheckPayer:            s.paymentVelocity(&velocity),
 		}
 
 		// CRITICAL: Atomically claim this signature BEFORE verification to prevent TOCTOU race
@@ -155,6 +187,26 @@ func (s *Service) AuthorizeWithWallet(ctx context.Context, resourceID, stripeSes
 		log := logger.FromContext(ctx)
 		now := time.Now()
 
+		// Hold a unit of a stock-tracked resource while the payment verifies, so concurrent
+		// payments cannot both buy the last one. The hold is returned unless the payment lands.
+		stockHold := storage.StockReservation{
+			ID:        "x402_" + generateNonce(),
+			Items:     map[string]int64{resourceID: 1},
+			ExpiresAt: now.Add(s.cfg.Paywall.QuoteTTL.Duration),
+		}
+		if err := s.store.ReserveStock(ctx, stockHold); err != nil {
+			if s.metrics != nil {
+				s.metrics.ObservePaymentFailure("x402", resourceID, "out_of_stock")
+			}
+			return AuthorizationResult{}, fmt.Errorf("paywall: reserve stock: %w", err)
+		}
+		sold := false
+		defer func() {
+			if !sold {
+				_ = s.store.ReleaseStockReservation(ctx, stockHold.ID)
+			}
+		}()
+
 		// For gasless transactions, skip optimistic recording since signature doesn't exist yet
 		// The actual signature is only known after the backend co-signs and Solana accepts the transaction
 		isGasless := proof.FeePayer != ""
@@ -186,6 +238,22 @@ func (s *Service) AuthorizeWithWallet(ctx context.Context, resourceID, stripeSes
 
 				return AuthorizationResult{}, fmt.Errorf("payment proof has already been used (originally for resource: %s)", originalTx.ResourceID)
 			}
+			s.publishStatus(proof.Signature, resourceID, paymentstatus.StageReceived, nil)
+		}
+		requirement.OnSubmitted = s.submittedStatus(resourceID, isGasless)
+
+		// Hold the gift card's part of the price while the payment verifies, so concurrent
+		// payments cannot spend the same balance. With several accepted rates the largest part
+		// is held and the rest returned once the paid amount is matched.
+		heldGiftCard := giftCards[0]
+		for _, giftCard := range giftCards[1:] {
+			if giftCard.amount.Atomic > heldGiftCard.amount.Atomic {
+				heldGiftCard = giftCard
+			}
+		}
+		if err := s.spendGiftCard(ctx, heldGiftCard, heldGiftCard.amount); err != nil {
+			s.publishStatus(proof.Signature, resourceID, paymentstatus.StageFailed, err)
+			return AuthorizationResult{}, err
 		}
 
 		// Track payment attempt timing
@@ -195,14 +263,14 @@ func (s *Service) AuthorizeWithWallet(ctx context.Context, resourceID, stripeSes
 		paymentDuration := time.Since(paymentStart)
 
 		if err != nil {
+			s.creditGiftCard(ctx, heldGiftCard, heldGiftCard.amount)
+			s.publishStatus(proof.Signature, resourceID, paymentstatus.StageFailed, err)
 			// Record failed payment metric
+			reason := paymentFailureReason(err)
 			if s.metrics != nil {
-				reason := "verification_failed"
-				if vErr, ok := err.(x402.VerificationError); ok {
-					reason = string(vErr.Code)
-				}
 				s.metrics.ObservePaymentFailure("x402", resourceID, reason)
 			}
+			s.notifyPaymentFailed(ctx, resourceID, reason, err, proof, "", proof.Signature)
 
 			if vErr, ok := err.(x402.VerificationError); ok {
 				// Log technical details for debugging (resource ID hashed for security)
@@ -220,7 +288,16 @@ func (s *Service) AuthorizeWithWallet(ctx context.Context, resourceID, stripeSes
 		// SECURITY: Enforce exact amount matching to prevent frontend bugs and user error
 		// The Solana verifier allows overpayment (for tips), but we require exact match
 		// Use a separate product/resource for tips/donations if overpayment is desired
+		pricing, giftCard := pricings[0], giftCards[0]
+		for i, amount := range expectedAmounts {
+			if paid, _ := strconv.ParseFloat(amount.ToMajor(), 64); paid == result.Amount {
+				pricing, giftCard, expectedMoney, expectedAmount = pricings[i], giftCards[i], amount, paid
+				break
+			}
+		}
+		resource = pricing.resource
 		if result.Amount != expectedAmount {
+			s.creditGiftCard(ctx, heldGiftCard, heldGiftCard.amount)
 			// Record amount mismatch failure
 			if s.metrics != nil {
 				s.metrics.ObservePaymentFailure("x402", resourceID, "amount_mismatch")
@@ -232,8 +309,14 @@ func (s *Service) AuthorizeWithWallet(ctx context.Context, resourceID, stripeSes
 				Float64("paid_amount", result.Amount).
 				Str("wallet", logger.TruncateAddress(result.Wallet)).
 				Msg("authorize.payment_amount_mismatch")
-			return AuthorizationResult{}, fmt.Errorf("payment amount (%.6f %s) does not match required amount (%.6f %s). Please ensure you're paying the exact quoted amount.",
+			mismatchErr := fmt.Errorf("payment amount (%.6f %s) does not match required amount (%.6f %s). Please ensure you're paying the exact quoted amount.",
 				result.Amount, cryptoAsset.Code, expectedAmount, cryptoAsset.Code)
+			s.publishStatus(result.Signature, resourceID, paymentstatus.StageFailed, mismatchErr)
+			s.notifyPaymentFailed(ctx, resourceID, "amount_mismatch", mismatchErr, proof, result.Wallet, result.Signature)
+			return AuthorizationResult{}, mismatchErr
+		}
+		if excess, err := heldGiftCard.amount.Sub(giftCard.amount); err == nil {
+			s.creditGiftCard(ctx, heldGiftCard, excess)
 		}
 
 		// For gasless transactions, the actual signature comes from the verifier (after co-signing + submission)
@@ -243,6 +326,23 @@ func (s *Service) AuthorizeWithWallet(ctx context.Context, resourceID, stripeSes
 			actualSignature = proof.Signature
 		}
 
+		// The paying wallet is only known once verified: count its use of coupons limited per wallet
+		if err := s.redeemCoupons(ctx, applicableCoupons, result.Wallet, actualSignature, now); err != nil {
+			s.creditGiftCard(ctx, giftCard, giftCard.amount)
+			if s.metrics != nil {
+				s.metrics.ObservePaymentFailure("x402", resourceID, "coupon_wallet_limit")
+			}
+			log.Warn().
+				Err(err).
+				Str("resource_hash", hashResourceID(resourceID)).
+				Str("wallet", logger.TruncateAddress(result.Wallet)).
+				Msg("authorize.coupon_wallet_limit")
+			s.publishStatus(actualSignature, resourceID, paymentstatus.StageFailed, err)
+			s.notifyPaymentFailed(ctx, resourceID, "coupon_wallet_limit", err, proof, result.Wallet, actualSignature)
+			return AuthorizationResult{}, err
+		}
+		s.publishStatus(actualSignature, resourceID, paymentstatus.StageConfirmed, nil)
+
 		// Build metadata with coupon information BEFORE recording payment
 		// This ensures coupon codes are persisted in the database
 		// Merge: resource metadata → proof metadata → payment-specific metadata
@@ -252,6 +352,10 @@ func (s *Service) AuthorizeWithWallet(ctx context.Context, resourceID, stripeSes
 		}
 		paymentMetadata["status"] = "verified"
 		paymentMetadata["network"] = s.cfg.X402.Network
+		addBundleMetadata(paymentMetadata, resource)
+		pricing.addMetadata(paymentMetadata)
+		giftCard.addMetadata(paymentMetadata)
+		velocity.addMetadata(paymentMetadata)
 
 		if len(applicableCoupons) > 0 {
 			// Store all applied coupon codes (comma-separated)
@@ -295,6 +399,16 @@ func (s *Service) AuthorizeWithWallet(ctx context.Context, resourceID, stripeSes
 				Str("signature", logger.TruncateAddress(actualSignature)).
 				Msg("authorize.failed_to_finalize_payment_record")
 		}
+		s.recordVelocity(velocityPaymentsPerWallet, result.Wallet)
+
+		// Take the sold unit out of stock, even if its hold lapsed while the payment confirmed
+		sold = true
+		if err := s.store.CommitStockReservation(ctx, stockHold.ID, stockHold.Items); err != nil {
+			log.Error().
+				Err(err).
+				Str("resource_hash", hashResourceID(resourceID)).
+				Msg("authorize.stock_commit_failed")
+		}
 
 		// Convert amount to cents for metrics (stored as float64 in USD)
 		amountCents := int64(result.Amount * 100)
@@ -317,8 +431,10 @@ func (s *Service) AuthorizeWithWallet(ctx context.Context, resourceID, stripeSes
 						Str("coupon_code", coupon.Code).
 						Msg("authorize.coupon_increment_failed")
 				}
+				s.recordReferral(ctx, coupon, actualSignature, resourceID, expectedMoney, now)
 			}
 		}
+		s.linkPayer(ctx, storage.PayerIdentities("", result.Wallet, ""))
 
 		// Use paymentMetadata for callback (already includes resource, proof, and coupon metadata)
 		s.notifier.PaymentSucceeded(ctx, callbacks.PaymentEvent{
@@ -334,19 +450,15 @@ func (s *Service) AuthorizeWithWallet(ctx context.Context, resourceID, stripeSes
 
 		// Build settlement response following x402 spec
 		// Reference: https://github.com/coinbase/x402
-		networkID := s.cfg.X402.Network
-		settlement := &SettlementResponse{
-			Success:   true,
-			Error:     nil,
-			TxHash:    &result.Signature, // Use actual signature from verification result
-			NetworkID: &networkID,
-		}
+		settlement := s.settlement(result.Signature, result.Wallet)
 
+		s.publishStatus(actualSignature, resourceID, paymentstatus.StageGranted, nil)
 		return AuthorizationResult{
 			Granted:    true,
 			Method:     "x402",
 			Wallet:     result.Wallet,
 			Settlement: settlement,
+			Amount:     expectedMoney,
 		}, nil
 	}
 
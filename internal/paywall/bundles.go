@@ -0,0 +1,34 @@
+package paywall
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+// BundleItemsKey is the payment metadata key listing, comma-separated, the resources a bundle
+// purchase grants access to. Recording the composition with the payment keeps access stable
+// when the bundle's definition changes later.
+const BundleItemsKey = "bundle_items"
+
+// addBundleMetadata records resource's bundle composition in metadata, if it is a bundle.
+func addBundleMetadata(metadata map[string]string, resource config.PaywallResource) {
+	if len(resource.Bundle) > 0 {
+		metadata[BundleItemsKey] = strings.Join(resource.Bundle, ",")
+	}
+}
+
+// PaymentGrantsAccess reports whether payment paid for resourceID, either directly or as a
+// member of the bundle it bought.
+func PaymentGrantsAccess(payment storage.PaymentTransaction, resourceID string) bool {
+	if IsFacilitatedPayment(payment) {
+		return false
+	}
+	if payment.ResourceID == resourceID {
+		return true
+	}
+	items := payment.Metadata[BundleItemsKey]
+	return items != "" && slices.Contains(strings.Split(items, ","), resourceID)
+}
@@ -0,0 +1,106 @@
+package paywall
+
+import (
+	"context"
+	"testing"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/money"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func TestPaymentGrantsAccess(t *testing.T) {
+	bundle := storage.PaymentTransaction{ResourceID: "starter-pack", Metadata: map[string]string{BundleItemsKey: "ebook,course"}}
+
+	tests := []struct {
+		name     string
+		payment  storage.PaymentTransaction
+		resource string
+		want     bool
+	}{
+		{name: "paid resource", payment: storage.PaymentTransaction{ResourceID: "ebook"}, resource: "ebook", want: true},
+		{name: "other resource", payment: storage.PaymentTransaction{ResourceID: "ebook"}, resource: "course"},
+		{name: "bundle itself", payment: bundle, resource: "starter-pack", want: true},
+		{name: "bundle member", payment: bundle, resource: "course", want: true},
+		{name: "not a member", payment: bundle, resource: "workbook"},
+		{name: "member prefix", payment: bundle, resource: "eb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PaymentGrantsAccess(tt.payment, tt.resource); got != tt.want {
+				t.Errorf("PaymentGrantsAccess(%s) = %v, want %v", tt.resource, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBundlePurchase(t *testing.T) {
+	ctx := context.Background()
+	cfg := testConfig()
+	cfg.Paywall.Resources["course"] = config.PaywallResource{ResourceID: "course", CryptoAtomicAmount: 3000000, CryptoToken: "USDC"}
+	cfg.Paywall.Resources["starter-pack"] = config.PaywallResource{
+		ResourceID:         "starter-pack",
+		Description:        "Demo and course",
+		CryptoAtomicAmount: 3500000, // 0.50 off buying both
+		CryptoToken:        "USDC",
+		Bundle:             []string{"demo-content", "course"},
+	}
+	cfg.Paywall.Resources["workbook"] = config.PaywallResource{ResourceID: "workbook", CryptoAtomicAmount: 1000000, CryptoToken: "USDC"}
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	notifier := &recordingNotifier{}
+	svc := NewService(cfg, store, stubVerifier{}, notifier, testRepository(cfg), nil, nil)
+
+	if _, err := svc.Authorize(ctx, "starter-pack", "", cartPaymentHeader(t, cfg, "sig-bundle"), ""); err != nil {
+		t.Fatalf("Authorize error: %v", err)
+	}
+	payment, err := store.GetPayment(ctx, "sig-bundle")
+	if err != nil {
+		t.Fatalf("GetPayment error: %v", err)
+	}
+	if payment.Metadata[BundleItemsKey] != "demo-content,course" {
+		t.Errorf("payment metadata %s = %q, want demo-content,course", BundleItemsKey, payment.Metadata[BundleItemsKey])
+	}
+	if len(notifier.payments) != 1 || notifier.payments[0].Metadata[BundleItemsKey] != "demo-content,course" {
+		t.Errorf("callback metadata = %+v, want the bundle composition", notifier.payments)
+	}
+	for _, member := range []string{"demo-content", "course"} {
+		if !PaymentGrantsAccess(payment, member) {
+			t.Errorf("bundle payment does not grant %s", member)
+		}
+	}
+
+	// A Stripe session for the bundle unlocks each member
+	usd, _ := money.GetAsset("USD")
+	if err := store.RecordPayment(ctx, storage.PaymentTransaction{
+		Signature:  "stripe:cs_bundle",
+		ResourceID: "starter-pack",
+		Amount:     money.New(usd, 350),
+		Metadata:   map[string]string{BundleItemsKey: "demo-content,course"},
+	}); err != nil {
+		t.Fatalf("RecordPayment error: %v", err)
+	}
+	result, err := svc.Authorize(ctx, "course", "cs_bundle", "", "")
+	if err != nil || !result.Granted {
+		t.Fatalf("Authorize member with bundle session = %+v, %v; want granted", result, err)
+	}
+	if _, err := svc.Authorize(ctx, "workbook", "cs_bundle", "", ""); err == nil {
+		t.Error("bundle session granted a resource outside the bundle")
+	}
+
+	// Cart items record the composition of bundles they contain
+	quote, err := svc.GenerateCartQuote(ctx, CartQuoteRequest{Items: []CartQuoteItem{{ResourceID: "starter-pack"}, {ResourceID: "course"}}})
+	if err != nil {
+		t.Fatalf("GenerateCartQuote error: %v", err)
+	}
+	if _, err := svc.Authorize(ctx, quote.CartID, "", cartPaymentHeader(t, cfg, "sig-bundle-cart"), ""); err != nil {
+		t.Fatalf("Authorize cart error: %v", err)
+	}
+	metadata := notifier.payments[len(notifier.payments)-1].Metadata
+	if metadata["item_0_bundle_items"] != "demo-content,course" {
+		t.Errorf("cart callback item_0_bundle_items = %q, want demo-content,course", metadata["item_0_bundle_items"])
+	}
+	if _, ok := metadata["item_1_bundle_items"]; ok {
+		t.Error("cart callback lists a composition for a plain item")
+	}
+}
@@ -0,0 +1,41 @@
+package paywall
+
+import (
+	"context"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/coupons"
+	"github.com/CedrosPay/server/internal/money"
+)
+
+// CardPrice is what a card payment charged outside hosted Checkout costs after coupons.
+type CardPrice struct {
+	AmountCents   int64
+	OriginalCents int64
+	CouponCode    string // Manual coupon applied, if any; its usage is counted once paid
+}
+
+// PriceCardPayment prices resource (in the fiat currency selected for it) for a Stripe
+// PaymentIntent. Hosted Checkout lets Stripe apply promotion codes, but a PaymentIntent is
+// created for the final amount, so auto-apply card coupons and couponCode are applied here, as
+// in the quote's Stripe option. Invalid coupons are ignored.
+func (s *Service) PriceCardPayment(ctx context.Context, resourceID string, resource config.PaywallResource, couponCode string) (CardPrice, error) {
+	if s.Draining() {
+		return CardPrice{}, ErrDraining
+	}
+	if resource.FiatAmountCents <= 0 {
+		return CardPrice{}, ErrNoFiatAmount
+	}
+
+	manualCoupon := s.validateManualCoupon(ctx, couponCode, resourceID, coupons.PaymentMethodStripe)
+	price := CardPrice{AmountCents: resource.FiatAmountCents, OriginalCents: resource.FiatAmountCents}
+	if manualCoupon != nil {
+		price.CouponCode = manualCoupon.Code
+	}
+	if s.coupons != nil {
+		stripeCoupons := SelectCouponsForPayment(ctx, s.coupons, resourceID, coupons.PaymentMethodStripe, manualCoupon, ScopeAll)
+		roundingMode := money.ParseRoundingMode(s.cfg.X402.RoundingMode)
+		price.AmountCents = stackFiatCoupons(resource.FiatAmountCents, stripeCoupons, roundingMode)
+	}
+	return price, nil
+}
@@ -0,0 +1,49 @@
+package paywall
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/coupons"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func TestPriceCardPayment(t *testing.T) {
+	ctx := context.Background()
+	cfg := testConfig()
+	repo := coupons.NewYAMLRepository(map[string]config.Coupon{
+		"SITE10":    {Code: "SITE10", DiscountType: "percentage", DiscountValue: 10, Scope: "all", AutoApply: true, Active: true},
+		"HALF":      {Code: "HALF", DiscountType: "percentage", DiscountValue: 50, Scope: "all", Active: true},
+		"CRYPTO":    {Code: "CRYPTO", DiscountType: "percentage", DiscountValue: 50, Scope: "all", PaymentMethod: "x402", Active: true},
+		"X402ONLY5": {Code: "X402ONLY5", DiscountType: "percentage", DiscountValue: 5, Scope: "all", PaymentMethod: "x402", AutoApply: true, Active: true},
+	})
+	svc := NewService(cfg, storage.NewMemoryStore(), stubVerifier{}, nil, testRepository(cfg), repo, nil)
+	resource := cfg.Paywall.Resources["demo-content"]
+
+	tests := []struct {
+		name       string
+		resource   config.PaywallResource
+		couponCode string
+		want       CardPrice
+		wantErr    error
+	}{
+		{name: "auto-apply card coupons", resource: resource, want: CardPrice{AmountCents: 90, OriginalCents: 100}},
+		{name: "manual coupon stacks", resource: resource, couponCode: "HALF", want: CardPrice{AmountCents: 45, OriginalCents: 100, CouponCode: "HALF"}},
+		{name: "crypto-only coupon ignored", resource: resource, couponCode: "CRYPTO", want: CardPrice{AmountCents: 90, OriginalCents: 100}},
+		{name: "unknown coupon ignored", resource: resource, couponCode: "NOPE", want: CardPrice{AmountCents: 90, OriginalCents: 100}},
+		{name: "stripe price only", resource: config.PaywallResource{ResourceID: "demo-content", StripePriceID: "price_123"}, wantErr: ErrNoFiatAmount},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.PriceCardPayment(ctx, "demo-content", tt.resource, tt.couponCode)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("price = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/CedrosPay/server/internal/coupons"
 	"github.com/CedrosPay/server/internal/logger"
 	"github.com/CedrosPay/server/internal/money"
+	"github.com/CedrosPay/server/internal/paymentstatus"
 	"github.com/CedrosPay/server/internal/storage"
 	"github.com/CedrosPay/server/pkg/x402"
 )
@@ -21,34 +22,60 @@ type CartQuoteRequest struct {
 	Items      []CartQuoteItem   `json:"items"`
 	Metadata   map[string]string `json:"metadata,omitempty"`   // Cart-level metadata (user_id, campaign, etc.)
 	CouponCode string            `json:"couponCode,omitempty"` // Optional coupon code to apply discount
+	Wallet     string            `json:"wallet,omitempty"`     // Paying wallet, checked against per-wallet coupon limits
+	// SplitPayment lets several wallets pay toward the total; the cart is granted once fully paid
+	SplitPayment bool `json:"splitPayment,omitempty"`
 }
 
 // CartQuoteItem represents a single item in a cart quote request.
 type CartQuoteItem struct {
 	ResourceID string            `json:"resource"`           // Resource ID from paywall config
+	VariantID  string            `json:"variant,omitempty"`  // Variant of the resource (size, tier, duration)
 	Quantity   int64             `json:"quantity"`           // Number of this item
 	Metadata   map[string]string `json:"metadata,omitempty"` // Per-item custom metadata
 }
 
 // CartQuoteResponse contains the generated quote for a cart.
 type CartQuoteResponse struct {
-	CartID      string            `json:"cartId"`             // Unique cart identifier
-	Quote       *CryptoQuote      `json:"quote"`              // x402 requirement for the cart total (unwrapped)
-	Items       []CartItem        `json:"items"`              // Itemized breakdown
-	TotalAmount float64           `json:"totalAmount"`        // Final total after all discounts
-	Metadata    map[string]string `json:"metadata,omitempty"` // Cart metadata including coupon info
-	ExpiresAt   time.Time         `json:"expiresAt"`          // When this cart quote expires
+	CartID      string            `json:"cartId"`               // Unique cart identifier
+	Quote       *CryptoQuote      `json:"quote"`                // x402 requirement for the cart total (unwrapped)
+	Items       []CartItem        `json:"items"`                // Itemized breakdown
+	Lines       []CartLine        `json:"lines,omitempty"`      // Shipping and tax lines included in the total
+	TotalAmount money.LegacyFloat `json:"totalAmount,omitzero"` // Final total after all discounts
+	Metadata    map[string]string `json:"metadata,omitempty"`   // Cart metadata including coupon info
+	ExpiresAt   time.Time         `json:"expiresAt"`            // When this cart quote expires
+	// TotalAmountDecimal is TotalAmount as an exact decimal string
+	TotalAmountDecimal string `json:"totalAmountDecimal"`
 }
 
 // CartItem represents an item in the quote response.
 type CartItem struct {
-	ResourceID     string   `json:"resource"`
-	Quantity       int64    `json:"quantity"`
-	PriceAmount    float64  `json:"priceAmount"`   // Price per unit (after catalog coupons)
-	OriginalPrice  float64  `json:"originalPrice"` // Original price before any discounts
-	Token          string   `json:"token"`         // Token symbol
-	Description    string   `json:"description,omitempty"`
-	AppliedCoupons []string `json:"appliedCoupons,omitempty"` // Catalog coupons applied to this item
+	ResourceID     string            `json:"resource"`
+	VariantID      string            `json:"variant,omitempty"`
+	Quantity       int64             `json:"quantity"`
+	PriceAmount    money.LegacyFloat `json:"priceAmount,omitzero"`   // Price per unit (after catalog coupons)
+	OriginalPrice  money.LegacyFloat `json:"originalPrice,omitzero"` // Original price before any discounts
+	Token          string            `json:"token"`                  // Token symbol
+	Description    string            `json:"description,omitempty"`
+	AppliedCoupons []string          `json:"appliedCoupons,omitempty"`     // Catalog coupons applied to this item
+	ConvertedFrom  string            `json:"convertedFrom,omitempty"`      // Token the item is listed in, when converted to the cart's token
+	ExchangeRate   float64           `json:"exchangeRate,omitempty"`       // Units of the cart's token per unit of ConvertedFrom
+	TierPrice      money.LegacyFloat `json:"tierPrice,omitempty,omitzero"` // Unit price at the volume tier reached (before coupons)
+	TierQuantity   int64             `json:"tierQuantity,omitempty"`       // Minimum quantity of the volume tier reached
+	// Discounts are the quantity rule coupons taken off the item's line total
+	Discounts []CartItemDiscount `json:"discounts,omitempty"`
+	// Exact decimal strings of PriceAmount, OriginalPrice, and TierPrice
+	PriceAmountDecimal   string `json:"priceAmountDecimal"`
+	OriginalPriceDecimal string `json:"originalPriceDecimal"`
+	TierPriceDecimal     string `json:"tierPriceDecimal,omitempty"`
+}
+
+// CartItemDiscount is a quantity rule coupon's discount on a cart item in the quote response.
+type CartItemDiscount struct {
+	Coupon        string            `json:"coupon"`
+	Units         int64             `json:"units"`           // Units of the item discounted
+	Amount        money.LegacyFloat `json:"amount,omitzero"` // Taken off the line total, in the cart's token
+	AmountDecimal string            `json:"amountDecimal"`   // Amount as an exact decimal string
 }
 
 // GetCartQuote retrieves an existing cart quote by ID.
@@ -58,25 +85,51 @@ func (s *Service) GetCartQuote(ctx context.Context, cartID string) (storage.Cart
 
 // GenerateCartQuote creates a quote for multiple items with locked prices.
 func (s *Service) GenerateCartQuote(ctx context.Context, req CartQuoteRequest) (CartQuoteResponse, error) {
+	if s.Draining() {
+		return CartQuoteResponse{}, ErrDraining
+	}
 	if len(req.Items) == 0 {
 		return CartQuoteResponse{}, errors.New("paywall: at least one item required")
 	}
+	if err := s.countQuote(ctx, req.Wallet); err != nil {
+		return CartQuoteResponse{}, err
+	}
 
 	// Generate unique cart ID
 	cartID, err := storage.GenerateCartID()
 	if err != nil {
 		return CartQuoteResponse{}, fmt.Errorf("paywall: generate cart id: %w", err)
 	}
+	return s.quoteCart(ctx, cartID, req)
+}
 
+// quoteCart prices req's items at the current catalog prices and coupons, and saves them as
+// cartID with the total locked until the cart TTL elapses.
+func (s *Service) quoteCart(ctx context.Context, cartID string, req CartQuoteRequest) (CartQuoteResponse, error) {
 	// Lookup all resources and validate they exist
 	// Apply catalog-level coupons to each item's price
 	var storageItems []storage.CartItem
 	var responseItems []CartItem
 	var totalMoney money.Money               // Use Money for precise arithmetic
+	var originalMoney money.Money            // Items total before coupons, for coupon analytics
 	var cryptoAsset money.Asset              // Asset for all items (must be consistent)
 	var token string                         // All items must use same token
 	var allAppliedCatalogCoupons []string    // Track all catalog coupons applied across items
 	seenCouponCodes := make(map[string]bool) // O(1) deduplication instead of O(n) linear search
+	var couponItems []CouponItem             // Items quantity rule coupons are evaluated against
+
+	// With a settlement token, items listed in other tokens are converted into it
+	settlementToken := s.cfg.X402.CartSettlementToken
+	if settlementToken != "" {
+		var err error
+		cryptoAsset, err = money.GetAsset(settlementToken)
+		if err != nil {
+			return CartQuoteResponse{}, fmt.Errorf("get asset for settlement token %s: %w", settlementToken, err)
+		}
+		token = settlementToken
+		totalMoney = money.Zero(cryptoAsset)
+		originalMoney = totalMoney
+	}
 
 	for i, item := range req.Items {
 		if item.ResourceID == "" {
@@ -91,31 +144,61 @@ func (s *Service) GenerateCartQuote(ctx context.Context, req CartQuoteRequest) (
 			return CartQuoteResponse{}, fmt.Errorf("paywall: item %d (%s): %w", i, item.ResourceID, err)
 		}
 
+		// A variant replaces the resource's prices; its metadata defaults the item's metadata
+		itemMetadata := item.Metadata
+		if variantMetadata := resource.Variants[item.VariantID].Metadata; item.VariantID != "" && len(variantMetadata) > 0 {
+			itemMetadata = mergeMetadata(variantMetadata, item.Metadata)
+		}
+		if resource, err = applyVariant(resource, item.VariantID); err != nil {
+			return CartQuoteResponse{}, fmt.Errorf("paywall: item %d (%s): %w", i, item.ResourceID, err)
+		}
+		if len(resource.Bundle) > 0 {
+			itemMetadata = mergeMetadata(itemMetadata) // Copy before adding to the client's map
+			addBundleMetadata(itemMetadata, resource)
+		}
+
+		// Fiat-priced resources are converted into their token at the current locked rate
+		if resource.PriceFromFiat {
+			pricing, err := s.quoteFiatPricing(ctx, resource)
+			if err != nil {
+				return CartQuoteResponse{}, fmt.Errorf("paywall: item %d (%s): %w", i, item.ResourceID, err)
+			}
+			resource = pricing.resource
+			itemMetadata = mergeMetadata(itemMetadata) // Copy before adding to the client's map
+			pricing.addMetadata(itemMetadata)
+		}
+
 		// Verify crypto amount is configured
 		if resource.CryptoAtomicAmount <= 0 {
 			return CartQuoteResponse{}, fmt.Errorf("paywall: resource %s has no crypto price configured", item.ResourceID)
 		}
 
-		// Get asset for Money conversion (only once for first item)
+		// Get asset for Money conversion
+		itemAsset, err := money.GetAsset(resource.CryptoToken)
+		if err != nil {
+			return CartQuoteResponse{}, fmt.Errorf("get asset for token %s: %w", resource.CryptoToken, err)
+		}
 		if token == "" {
-			var err error
-			cryptoAsset, err = money.GetAsset(resource.CryptoToken)
-			if err != nil {
-				return CartQuoteResponse{}, fmt.Errorf("get asset for token %s: %w", resource.CryptoToken, err)
-			}
+			cryptoAsset = itemAsset
 			token = resource.CryptoToken
 			totalMoney = money.Zero(cryptoAsset) // Initialize total
-		} else if token != resource.CryptoToken {
+			originalMoney = totalMoney
+		} else if token != resource.CryptoToken && settlementToken == "" {
 			return CartQuoteResponse{}, fmt.Errorf("paywall: mixed tokens in cart (got %s and %s)", token, resource.CryptoToken)
 		}
 
 		// Use atomic amount directly (Money type)
-		originalPriceMoney := money.Money{Asset: cryptoAsset, Atomic: resource.CryptoAtomicAmount}
+		originalPriceMoney := money.Money{Asset: itemAsset, Atomic: resource.CryptoAtomicAmount}
+
+		// A volume tier reached by the line's quantity replaces the unit price before coupons
+		tierAtomic, tierQuantity := tierPrice(resource, item.Quantity)
+		tierPriceMoney := money.Money{Asset: itemAsset, Atomic: tierAtomic}
 
 		// IMPORTANT: Apply catalog-level coupons to each item's unit price using Money arithmetic
 		// This ensures product-specific discounts are shown at item level
-		itemPriceMoney := originalPriceMoney
+		itemPriceMoney := tierPriceMoney
 		var itemCouponCodes []string
+		var itemRuleCoupons []coupons.Coupon // Evaluated against the item's quantity once the cart is priced
 
 		if s.coupons != nil {
 			// Get catalog-level auto-apply coupons for this product (no manual coupons at item level)
@@ -130,6 +213,10 @@ func (s *Service) GenerateCartQuote(ctx context.Context, req CartQuoteRequest) (
 
 				// Track coupon codes for this specific item
 				for _, c := range catalogCoupons {
+					if c.HasQuantityRule() {
+						itemRuleCoupons = append(itemRuleCoupons, c)
+						continue
+					}
 					itemCouponCodes = append(itemCouponCodes, c.Code)
 					// Also track globally for cart metadata using O(1) map lookup
 					if !seenCouponCodes[c.Code] {
@@ -140,6 +227,24 @@ func (s *Service) GenerateCartQuote(ctx context.Context, req CartQuoteRequest) (
 			}
 		}
 
+		// Convert items listed in another token at the current rate, locking the converted price
+		var exchangeRate float64
+		if itemAsset.Code != token {
+			exchangeRate, err = s.rates.Rate(ctx, itemAsset.Code, token)
+			if err != nil {
+				return CartQuoteResponse{}, fmt.Errorf("paywall: item %d (%s): %w", i, item.ResourceID, err)
+			}
+			if originalPriceMoney, err = convertMoney(originalPriceMoney, cryptoAsset, exchangeRate); err != nil {
+				return CartQuoteResponse{}, err
+			}
+			if tierPriceMoney, err = convertMoney(tierPriceMoney, cryptoAsset, exchangeRate); err != nil {
+				return CartQuoteResponse{}, err
+			}
+			if itemPriceMoney, err = convertMoney(itemPriceMoney, cryptoAsset, exchangeRate); err != nil {
+				return CartQuoteResponse{}, err
+			}
+		}
+
 		// Calculate item total with discounted price using integer arithmetic
 		itemTotalMoney, err := itemPriceMoney.Mul(int64(item.Quantity))
 		if err != nil {
@@ -151,29 +256,52 @@ func (s *Service) GenerateCartQuote(ctx context.Context, req CartQuoteRequest) (
 		if err != nil {
 			return CartQuoteResponse{}, fmt.Errorf("add item to cart total: %w", err)
 		}
+		itemOriginalMoney, err := tierPriceMoney.Mul(int64(item.Quantity))
+		if err != nil {
+			return CartQuoteResponse{}, fmt.Errorf("multiply item price by quantity: %w", err)
+		}
+		if originalMoney, err = originalMoney.Add(itemOriginalMoney); err != nil {
+			return CartQuoteResponse{}, fmt.Errorf("add item to cart original total: %w", err)
+		}
+		couponItems = append(couponItems, CouponItem{
+			ResourceID: item.ResourceID,
+			Quantity:   item.Quantity,
+			UnitPrice:  itemPriceMoney,
+			Coupons:    itemRuleCoupons,
+		})
 
 		// Store item with locked discounted price (already Money)
 		storageItems = append(storageItems, storage.CartItem{
 			ResourceID: item.ResourceID,
+			VariantID:  item.VariantID,
 			Quantity:   item.Quantity,
 			Price:      itemPriceMoney, // Already Money with coupons applied
-			Metadata:   item.Metadata,
+			Metadata:   itemMetadata,
 		})
 
 		// Build response item with original price, discounted price, and applied coupons
-		// Convert Money to float64 for legacy response format
-		itemPriceFloat, _ := strconv.ParseFloat(itemPriceMoney.ToMajor(), 64)
-		originalPriceFloat, _ := strconv.ParseFloat(originalPriceMoney.ToMajor(), 64)
-
-		responseItems = append(responseItems, CartItem{
-			ResourceID:     item.ResourceID,
-			Quantity:       item.Quantity,
-			PriceAmount:    itemPriceFloat,     // Discounted price (float64 for response)
-			OriginalPrice:  originalPriceFloat, // Original price before discounts
-			Token:          resource.CryptoToken,
-			Description:    resource.Description,
-			AppliedCoupons: itemCouponCodes, // Coupons applied to this specific item
-		})
+		responseItem := CartItem{
+			ResourceID:           item.ResourceID,
+			VariantID:            item.VariantID,
+			Quantity:             item.Quantity,
+			PriceAmount:          itemPriceMoney.LegacyFloat(),     // Discounted price
+			OriginalPrice:        originalPriceMoney.LegacyFloat(), // Original price before discounts
+			PriceAmountDecimal:   itemPriceMoney.ToMajor(),
+			OriginalPriceDecimal: originalPriceMoney.ToMajor(),
+			Token:                token,
+			Description:          resource.Description,
+			AppliedCoupons:       itemCouponCodes, // Coupons applied to this specific item
+		}
+		if exchangeRate != 0 {
+			responseItem.ConvertedFrom = itemAsset.Code
+			responseItem.ExchangeRate = exchangeRate
+		}
+		if tierQuantity > 0 {
+			responseItem.TierPrice = tierPriceMoney.LegacyFloat()
+			responseItem.TierPriceDecimal = tierPriceMoney.ToMajor()
+			responseItem.TierQuantity = tierQuantity
+		}
+		responseItems = append(responseItems, responseItem)
 	}
 
 	// PHASE 2: Apply checkout-level (site-wide) coupons to cart total using Money arithmetic
@@ -184,6 +312,9 @@ func (s *Service) GenerateCartQuote(ctx context.Context, req CartQuoteRequest) (
 	// For cart checkout: Only allow site-wide coupons (scope="all")
 	// Product-specific coupons are already applied at item level
 	manualCoupon := s.validateManualCoupon(ctx, req.CouponCode, "", coupons.PaymentMethodX402)
+	if err := s.checkManualCouponLimit(ctx, manualCoupon, req.Wallet); err != nil {
+		return CartQuoteResponse{}, err
+	}
 
 	// Get checkout-level coupons (site-wide auto-apply + optional manual)
 	var checkoutCoupons []coupons.Coupon
@@ -191,15 +322,42 @@ func (s *Service) GenerateCartQuote(ctx context.Context, req CartQuoteRequest) (
 		checkoutCoupons = SelectCouponsForPayment(ctx, s.coupons, "", coupons.PaymentMethodX402, manualCoupon, ScopeCheckout)
 	}
 
-	// Apply stacked checkout coupons to cart total using Money arithmetic
-	if len(checkoutCoupons) > 0 {
-		roundingMode := money.ParseRoundingMode(s.cfg.X402.RoundingMode)
-		discounted, err := StackCouponsOnMoney(totalMoney, checkoutCoupons, roundingMode)
-		if err != nil {
-			return CartQuoteResponse{}, fmt.Errorf("apply checkout coupons: %w", err)
+	// Apply quantity rules to each item, then stacked checkout coupons to the cart total
+	roundingMode := money.ParseRoundingMode(s.cfg.X402.RoundingMode)
+	discounted, itemDiscounts, err := StackCouponsOnCart(totalMoney, couponItems, checkoutCoupons, roundingMode)
+	if err != nil {
+		return CartQuoteResponse{}, fmt.Errorf("apply checkout coupons: %w", err)
+	}
+	totalMoney = discounted
+	appliedRules := make(map[string]bool)
+	for i, discounts := range itemDiscounts {
+		for _, d := range discounts {
+			responseItems[i].Discounts = append(responseItems[i].Discounts, CartItemDiscount{
+				Coupon:        d.Coupon,
+				Units:         d.Units,
+				Amount:        d.Amount.LegacyFloat(),
+				AmountDecimal: d.Amount.ToMajor(),
+			})
+			appliedRules[d.Coupon] = true
+		}
+	}
+	for _, item := range couponItems {
+		for _, c := range item.Coupons {
+			if appliedRules[c.Code] && !seenCouponCodes[c.Code] {
+				allAppliedCatalogCoupons = append(allAppliedCatalogCoupons, c.Code)
+				seenCouponCodes[c.Code] = true
+			}
+		}
+	}
+
+	// Quantity rules that matched no item were not applied
+	var appliedCheckoutCoupons []coupons.Coupon
+	for _, c := range checkoutCoupons {
+		if !c.HasQuantityRule() || appliedRules[c.Code] {
+			appliedCheckoutCoupons = append(appliedCheckoutCoupons, c)
 		}
-		totalMoney = discounted
 	}
+	checkoutCoupons = appliedCheckoutCoupons
 
 	// IMPORTANT: Round to cents precision (2 decimals) using integer arithmetic
 	// This ensures $2.7661 becomes $2.77, not $2.7661
@@ -211,6 +369,12 @@ func (s *Service) GenerateCartQuote(ctx context.Context, req CartQuoteRequest) (
 	if cartMetadata == nil {
 		cartMetadata = make(map[string]string)
 	}
+	if manualCoupon != nil {
+		cartMetadata["manual_coupon"] = manualCoupon.Code // Re-applied when the cart is updated
+	}
+	if req.SplitPayment {
+		cartMetadata[splitPaymentKey] = "true" // Kept when the cart is updated
+	}
 
 	// Track all coupons applied (catalog + checkout)
 	var allAppliedCouponCodes []string
@@ -221,6 +385,7 @@ func (s *Service) GenerateCartQuote(ctx context.Context, req CartQuoteRequest) (
 
 	if len(allAppliedCouponCodes) > 0 {
 		cartMetadata["coupon_codes"] = formatCouponCodes(allAppliedCouponCodes)
+		cartMetadata["original_amount"] = originalMoney.ToMajor()
 		cartMetadata["subtotal_after_catalog"] = subtotalAfterCatalogCoupons.ToMajor()
 		cartMetadata["discounted_amount"] = totalMoney.ToMajor()
 	}
@@ -239,6 +404,34 @@ func (s *Service) GenerateCartQuote(ctx context.Context, req CartQuoteRequest) (
 		}
 	}
 
+	// PHASE 3: Add shipping and tax lines to the discounted item total
+	lines, lineAmounts, err := s.cartLines(ctx, storageItems, totalMoney, cartMetadata)
+	if err != nil {
+		return CartQuoteResponse{}, err
+	}
+	if len(lines) > 0 {
+		cartMetadata["subtotal_amount"] = totalMoney.ToMajor()
+	}
+	for i, amount := range lineAmounts {
+		if totalMoney, err = totalMoney.Add(amount); err != nil {
+			return CartQuoteResponse{}, fmt.Errorf("add %s to cart total: %w", lines[i].Type, err)
+		}
+		cartMetadata[lines[i].Type+"_amount"] = amount.ToMajor() // Itemized in payment callbacks
+	}
+
+	// PHASE 4: A gift card pays part of the total from its balance. Split carts are paid in
+	// full by their contributors.
+	if cartMetadata[splitPaymentKey] != "true" {
+		giftCard, err := s.redeemGiftCard(ctx, req.CouponCode, totalMoney)
+		if err != nil {
+			return CartQuoteResponse{}, err
+		}
+		if totalMoney, err = totalMoney.Sub(giftCard.amount); err != nil {
+			return CartQuoteResponse{}, fmt.Errorf("apply gift card to cart total: %w", err)
+		}
+		giftCard.addMetadata(cartMetadata) // Spent when the cart is paid
+	}
+
 	// Save cart quote to storage
 	now := time.Now()
 	cartTTL := s.cfg.Storage.CartQuoteTTL.Duration
@@ -257,6 +450,11 @@ func (s *Service) GenerateCartQuote(ctx context.Context, req CartQuoteRequest) (
 		ExpiresAt: expiresAt,
 	}
 
+	// Hold stock for the cart until the quote expires (untracked resources are unlimited)
+	if err := s.reserveCartStock(ctx, cartID, storageItems, expiresAt); err != nil {
+		return CartQuoteResponse{}, err
+	}
+
 	if err := s.store.SaveCartQuote(ctx, cartQuote); err != nil {
 		return CartQuoteResponse{}, fmt.Errorf("paywall: save cart quote: %w", err)
 	}
@@ -268,16 +466,15 @@ func (s *Service) GenerateCartQuote(ctx context.Context, req CartQuoteRequest) (
 		return CartQuoteResponse{}, fmt.Errorf("paywall: build x402 quote: %w", err)
 	}
 
-	// Convert Money to float64 for JSON response (external API boundary)
-	totalAmountFloat, _ := strconv.ParseFloat(totalMoney.ToMajor(), 64)
-
 	return CartQuoteResponse{
-		CartID:      cartID,
-		Quote:       quote,
-		Items:       responseItems,
-		TotalAmount: totalAmountFloat, // Convert to float64 for JSON response
-		Metadata:    cartMetadata,
-		ExpiresAt:   expiresAt,
+		CartID:             cartID,
+		Quote:              quote,
+		Items:              responseItems,
+		Lines:              lines,
+		TotalAmount:        totalMoney.LegacyFloat(),
+		TotalAmountDecimal: totalMoney.ToMajor(),
+		Metadata:           cartMetadata,
+		ExpiresAt:          expiresAt,
 	}, nil
 }
 
@@ -321,7 +518,7 @@ func (s *Service) authorizeCart(ctx context.Context, cartID, paymentHeader, coup
 	}
 
 	// Validate scheme and network
-	if proof.Scheme != "solana-spl-transfer" && proof.Scheme != "solana" {
+	if !x402.SupportedScheme(proof.Scheme) {
 		return AuthorizationResult{}, fmt.Errorf("unsupported scheme: %s", proof.Scheme)
 	}
 	if proof.Network != s.cfg.X402.Network {
@@ -357,6 +554,7 @@ func (s *Service) authorizeCart(ctx context.Context, cartID, paymentHeader, coup
 		return AuthorizationResult{}, fmt.Errorf("parse cart total: %w", err)
 	}
 
+	var velocity velocityHit
 	requirement := x402.Requirement{
 		ResourceID:            cartID,
 		RecipientOwner:        s.cfg.X402.PaymentAddress,
@@ -368,7 +566,21 @@ func (s *Service) authorizeCart(ctx context.Context, cartID, paymentHeader, coup
 		AllowedTokens:         []string{cart.Total.Asset.Code}, // Only cart's token allowed
 		QuoteTTL:              cartTTL,
 		SkipPreflight:         s.cfg.X402.SkipPreflight,
+		SimulateTransaction:   s.cfg.X402.SimulateTransactions,
 		Commitment:            s.cfg.X402.Commitment,
+		Memo:                  s.requiredMemo(cartID),
+		CheckPayer:            s.paymentVelocity(&velocity),
+	}
+	giftCard, err := s.cartGiftCard(ctx, cart)
+	if err != nil {
+		return AuthorizationResult{}, err
+	}
+	split := isSplitCart(cart)
+	if split {
+		// Each wallet pays part of the total; a payment larger than what is still owed is
+		// rejected before it is sent
+		requirement.Amount = minimumContribution(cart.Total.Asset)
+		requirement.CheckAmount = s.checkContribution(cart)
 	}
 
 	// CRITICAL: Atomically claim this signature BEFORE verification to prevent TOCTOU race
@@ -409,6 +621,15 @@ func (s *Service) authorizeCart(ctx context.Context, cartID, paymentHeader, coup
 
 			return AuthorizationResult{}, fmt.Errorf("payment proof has already been used (originally for: %s)", originalTx.ResourceID)
 		}
+		s.publishStatus(proof.Signature, cartID, paymentstatus.StageReceived, nil)
+	}
+	requirement.OnSubmitted = s.submittedStatus(cartID, isGasless)
+
+	// Hold the gift card's part of the total while the payment verifies; the balance may have
+	// been spent since the cart was quoted
+	if err := s.spendGiftCard(ctx, giftCard, giftCard.amount); err != nil {
+		s.publishStatus(proof.Signature, cartID, paymentstatus.StageFailed, err)
+		return AuthorizationResult{}, err
 	}
 
 	// Track cart payment timing
@@ -418,14 +639,14 @@ func (s *Service) authorizeCart(ctx context.Context, cartID, paymentHeader, coup
 	paymentDuration := time.Since(paymentStart)
 
 	if err != nil {
+		s.creditGiftCard(ctx, giftCard, giftCard.amount)
+		s.publishStatus(proof.Signature, cartID, paymentstatus.StageFailed, err)
 		// Record failed cart payment metric
+		reason := paymentFailureReason(err)
 		if s.metrics != nil {
-			reason := "verification_failed"
-			if vErr, ok := err.(x402.VerificationError); ok {
-				reason = string(vErr.Code)
-			}
 			s.metrics.ObservePaymentFailure("x402", cartID, reason)
 		}
+		s.notifyPaymentFailed(ctx, cartID, reason, err, proof, "", proof.Signature)
 
 		if vErr, ok := err.(x402.VerificationError); ok {
 			log.Error().
@@ -443,7 +664,8 @@ func (s *Service) authorizeCart(ctx context.Context, cartID, paymentHeader, coup
 	// Use tolerance of 1 smallest unit (0.000001 for 6 decimals) to handle floating-point precision
 	const tolerance = 0.000001
 	amountDiff := result.Amount - cartTotalFloat
-	if amountDiff < -tolerance || amountDiff > tolerance {
+	if !split && (amountDiff < -tolerance || amountDiff > tolerance) {
+		s.creditGiftCard(ctx, giftCard, giftCard.amount)
 		// Record amount mismatch failure
 		if s.metrics != nil {
 			s.metrics.ObservePaymentFailure("x402", cartID, "amount_mismatch")
@@ -456,8 +678,11 @@ func (s *Service) authorizeCart(ctx context.Context, cartID, paymentHeader, coup
 			Float64("difference", amountDiff).
 			Str("wallet", logger.TruncateAddress(result.Wallet)).
 			Msg("cart.payment_amount_mismatch")
-		return AuthorizationResult{}, fmt.Errorf("payment amount (%.6f %s) does not match required cart total (%.6f %s). Please ensure you're paying the exact quoted amount.",
+		mismatchErr := fmt.Errorf("payment amount (%.6f %s) does not match required cart total (%.6f %s). Please ensure you're paying the exact quoted amount.",
 			result.Amount, cart.Total.Asset.Code, cartTotalFloat, cart.Total.Asset.Code)
+		s.publishStatus(result.Signature, cartID, paymentstatus.StageFailed, mismatchErr)
+		s.notifyPaymentFailed(ctx, cartID, "amount_mismatch", mismatchErr, proof, result.Wallet, result.Signature)
+		return AuthorizationResult{}, mismatchErr
 	}
 
 	// For gasless transactions, the actual signature comes from the verifier (after co-signing + submission)
@@ -467,6 +692,26 @@ func (s *Service) authorizeCart(ctx context.Context, cartID, paymentHeader, coup
 		actualSignature = proof.Signature
 	}
 
+	// Count the paying wallet's use of coupons limited per wallet. Split carts are paid by several
+	// wallets, so their coupons are only limited at quote time.
+	if !split {
+		if err := s.redeemCoupons(ctx, s.cartCoupons(ctx, cart.Metadata["coupon_codes"]), result.Wallet, actualSignature, now); err != nil {
+			s.creditGiftCard(ctx, giftCard, giftCard.amount)
+			if s.metrics != nil {
+				s.metrics.ObservePaymentFailure("x402", cartID, "coupon_wallet_limit")
+			}
+			log.Warn().
+				Err(err).
+				Str("cart_hash", hashResourceID(cartID)).
+				Str("wallet", logger.TruncateAddress(result.Wallet)).
+				Msg("cart.coupon_wallet_limit")
+			s.publishStatus(actualSignature, cartID, paymentstatus.StageFailed, err)
+			s.notifyPaymentFailed(ctx, cartID, "coupon_wallet_limit", err, proof, result.Wallet, actualSignature)
+			return AuthorizationResult{}, err
+		}
+	}
+	s.publishStatus(actualSignature, cartID, paymentstatus.StageConfirmed, nil)
+
 	// Payment signature was already recorded before verification (atomic claim) for non-gasless
 	// For gasless, this is the first time we're recording since we didn't know the signature before
 	// Now update/create the record with verified payment details
@@ -482,6 +727,14 @@ func (s *Service) authorizeCart(ctx context.Context, cartID, paymentHeader, coup
 			"type":    "cart",
 		},
 	}
+	giftCard.addMetadata(finalPaymentTx.Metadata)
+	velocity.addMetadata(finalPaymentTx.Metadata)
+	if split {
+		finalPaymentTx.Amount = contributionAmount(cart.Total.Asset, result.Amount)
+		finalPaymentTx.Metadata[splitPaymentKey] = "true"
+	} else {
+		addCouponMetadata(finalPaymentTx.Metadata, cart.Metadata)
+	}
 	if err := s.store.RecordPayment(ctx, finalPaymentTx); err != nil {
 		// For gasless: might be a race where same tx was submitted twice
 		// For non-gasless: should not happen since we claimed the signature earlier
@@ -503,10 +756,55 @@ func (s *Service) authorizeCart(ctx context.Context, cartID, paymentHeader, coup
 			Str("cart_hash", hashResourceID(cartID)).
 			Msg("cart.failed_to_finalize_payment_record")
 	}
+	s.recordVelocity(velocityPaymentsPerWallet, result.Wallet)
 
 	// Convert amount to cents for metrics (stored as float64 in USD)
 	amountCents := int64(result.Amount * 100)
 
+	// Build settlement response
+	settlement := s.settlement(result.Signature, result.Wallet)
+
+	// Add a split payment to the cart's contributions; the cart is only paid once they cover its total
+	if split {
+		paid, err := s.store.AddCartContribution(ctx, storage.CartContribution{
+			CartID:    cartID,
+			Signature: actualSignature,
+			Wallet:    result.Wallet,
+			Amount:    finalPaymentTx.Amount,
+			PaidAt:    now,
+		}, cart.Total)
+		if err != nil {
+			// Overpayments are rejected before sending; this catches concurrent contributions
+			// that together took the cart past its total
+			if errors.Is(err, storage.ErrContributionExceedsTotal) {
+				if s.metrics != nil {
+					s.metrics.ObservePaymentFailure("x402", cartID, "amount_mismatch")
+				}
+				s.notifyPaymentFailed(ctx, cartID, "amount_mismatch", err, proof, result.Wallet, actualSignature)
+			}
+			log.Error().
+				Err(err).
+				Str("cart_hash", hashResourceID(cartID)).
+				Str("wallet", logger.TruncateAddress(result.Wallet)).
+				Msg("cart.split_payment_rejected")
+			s.publishStatus(actualSignature, cartID, paymentstatus.StageFailed, err)
+			return AuthorizationResult{}, fmt.Errorf("record split payment: %w", err)
+		}
+		if paid.LessThan(cart.Total) {
+			if s.metrics != nil {
+				s.metrics.ObservePayment("x402", cartID, true, paymentDuration, amountCents, cart.Total.Asset.Code)
+				s.metrics.ObserveSettlement(s.cfg.X402.Network, paymentDuration)
+			}
+			s.publishStatus(actualSignature, cartID, paymentstatus.StagePartial, nil)
+			return AuthorizationResult{
+				Method:     "x402-cart",
+				Wallet:     result.Wallet,
+				Settlement: settlement,
+				Partial:    partialPayment(paid, cart.Total),
+			}, nil
+		}
+	}
+
 	// Record successful cart payment metrics
 	if s.metrics != nil {
 		s.metrics.ObservePayment("x402", cartID, true, paymentDuration, amountCents, cart.Total.Asset.Code)
@@ -517,48 +815,44 @@ func (s *Service) authorizeCart(ctx context.Context, cartID, paymentHeader, coup
 
 	// Mark cart as paid
 	if err := s.store.MarkCartPaid(ctx, cartID, result.Wallet); err != nil {
+		s.publishStatus(actualSignature, cartID, paymentstatus.StageFailed, err)
 		return AuthorizationResult{}, fmt.Errorf("mark cart paid: %w", err)
 	}
 
-	// Increment usage for all coupons applied to the cart
-	storedCouponCodes := cart.Metadata["coupon_codes"]
-	if storedCouponCodes != "" && s.coupons != nil {
-		codes := strings.Split(storedCouponCodes, ",")
-		for _, code := range codes {
-			if err := s.coupons.IncrementUsage(ctx, code); err != nil {
-				// Log error but don't fail - payment was successful
-				log.Warn().
-					Err(err).
-					Str("coupon_code", code).
-					Msg("cart.coupon_increment_failed")
-			}
+	// If the cart was updated (and re-locked) while this payment was verifying, restore the
+	// items that were actually paid for
+	if current, err := s.store.GetCartQuote(ctx, cartID); err == nil && !current.ExpiresAt.Equal(cart.ExpiresAt) {
+		cart.WalletPaidBy = result.Wallet
+		if err := s.store.SaveCartQuote(ctx, cart); err != nil {
+			log.Error().
+				Err(err).
+				Str("cart_hash", hashResourceID(cartID)).
+				Msg("cart.restore_paid_items_failed")
+		}
+		if err := s.reserveCartStock(ctx, cartID, cart.Items, current.ExpiresAt); err != nil {
+			log.Error().
+				Err(err).
+				Str("cart_hash", hashResourceID(cartID)).
+				Msg("cart.restore_paid_stock_failed")
 		}
 	}
 
-	// Build callback event with cart item details
-	metadata := mergeMetadata(cart.Metadata, proof.Metadata)
-
-	// Enhance metadata with coupon info (if coupons were applied)
-	// Note: With stacking, metadata now contains aggregated discount info
-	// original_amount and discounted_amount already in cart.Metadata from quote generation
+	// Take the units held for the cart out of stock
+	if err := s.store.CommitStockReservation(ctx, cartID, cartStockUnits(cart.Items)); err != nil {
+		log.Error().
+			Err(err).
+			Str("cart_hash", hashResourceID(cartID)).
+			Msg("cart.stock_commit_failed")
+	}
 
-	// Add cart item details to metadata for callback processing
-	metadata["cart_items"] = fmt.Sprintf("%d", len(cart.Items))
-	var totalQuantity int64
-	for i, item := range cart.Items {
-		prefix := fmt.Sprintf("item_%d_", i)
-		metadata[prefix+"resource"] = item.ResourceID
-		metadata[prefix+"quantity"] = fmt.Sprintf("%d", item.Quantity)
-		metadata[prefix+"price_amount"] = item.Price.ToMajor() // Convert Money to string
-		metadata[prefix+"token"] = item.Price.Asset.Code
-		totalQuantity += item.Quantity
+	// Increment usage for all coupons applied to the cart
+	s.incrementCartCoupons(ctx, cart.Metadata["coupon_codes"])
+	s.recordCartReferrals(ctx, cart.Metadata["coupon_codes"], actualSignature, cartID, cart.Total, now)
+	s.linkPayer(ctx, storage.PayerIdentities("", result.Wallet, ""))
+	s.applySubscriptionChange(ctx, cart)
 
-		// Merge per-item metadata
-		for k, v := range item.Metadata {
-			metadata[prefix+k] = v
-		}
-	}
-	metadata["total_quantity"] = fmt.Sprintf("%d", totalQuantity)
+	// Build callback event with cart item details
+	metadata := cartCallbackMetadata(cart, proof.Metadata)
 
 	// Fire payment succeeded callback
 	s.notifier.PaymentSucceeded(ctx, callbacks.PaymentEvent{
@@ -572,15 +866,7 @@ func (s *Service) authorizeCart(ctx context.Context, cartID, paymentHeader, coup
 		PaidAt:             now.UTC(),
 	})
 
-	// Build settlement response
-	networkID := s.cfg.X402.Network
-	settlement := &SettlementResponse{
-		Success:   true,
-		Error:     nil,
-		TxHash:    &result.Signature, // Use actual signature from verification result
-		NetworkID: &networkID,
-	}
-
+	s.publishStatus(actualSignature, cartID, paymentstatus.StageGranted, nil)
 	return AuthorizationResult{
 		Granted:    true,
 		Method:     "x402-cart",
@@ -588,3 +874,49 @@ func (s *Service) authorizeCart(ctx context.Context, cartID, paymentHeader, coup
 		Settlement: settlement,
 	}, nil
 }
+
+// incrementCartCoupons records a use of each coupon in codes, the comma-separated
+// coupon_codes a paid cart was priced with.
+func (s *Service) incrementCartCoupons(ctx context.Context, codes string) {
+	if codes == "" || s.coupons == nil {
+		return
+	}
+	log := logger.FromContext(ctx)
+	for _, code := range strings.Split(codes, ",") {
+		if err := s.coupons.IncrementUsage(ctx, code); err != nil {
+			// Log error but don't fail - payment was successful
+			log.Warn().
+				Err(err).
+				Str("coupon_code", code).
+				Msg("cart.coupon_increment_failed")
+		}
+	}
+}
+
+// cartCallbackMetadata builds the payment callback metadata for a paid cart: the cart's
+// metadata (coupon breakdown, shipping and tax lines), then extra, then each item's details.
+func cartCallbackMetadata(cart storage.CartQuote, extra map[string]string) map[string]string {
+	metadata := mergeMetadata(cart.Metadata, extra)
+
+	// Add cart item details to metadata for callback processing
+	metadata["cart_items"] = fmt.Sprintf("%d", len(cart.Items))
+	var totalQuantity int64
+	for i, item := range cart.Items {
+		prefix := fmt.Sprintf("item_%d_", i)
+		metadata[prefix+"resource"] = item.ResourceID
+		if item.VariantID != "" {
+			metadata[prefix+"variant"] = item.VariantID
+		}
+		metadata[prefix+"quantity"] = fmt.Sprintf("%d", item.Quantity)
+		metadata[prefix+"price_amount"] = item.Price.ToMajor() // Convert Money to string
+		metadata[prefix+"token"] = item.Price.Asset.Code
+		totalQuantity += item.Quantity
+
+		// Merge per-item metadata
+		for k, v := range item.Metadata {
+			metadata[prefix+k] = v
+		}
+	}
+	metadata["total_quantity"] = fmt.Sprintf("%d", totalQuantity)
+	return metadata
+}
@@ -0,0 +1,221 @@
+package paywall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/CedrosPay/server/internal/callbacks"
+	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/money"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+// ErrCartNotCardPayable indicates a cart is priced in a token without a fiat equivalent.
+var ErrCartNotCardPayable = errors.New("paywall: cart cannot be paid by card")
+
+// CartCheckoutLine is one line item of a cart's card checkout.
+type CartCheckoutLine struct {
+	Name       string
+	UnitAmount int64 // Price per unit in cents
+	Quantity   int64
+}
+
+// CartCheckout is an unpaid cart priced for card checkout. Stablecoin prices convert to USD at
+// their $1 peg, rounded up to the cent.
+type CartCheckout struct {
+	CartID   string
+	Currency string             // Stripe currency code
+	Lines    []CartCheckoutLine // Items at their locked unit prices, then shipping and tax
+	Discount int64              // Cents the lines exceed Total by (checkout coupons, rounding)
+	Total    int64              // Locked cart total in cents
+	Metadata map[string]string  // Payment callback metadata, as for an x402 cart payment
+}
+
+// CartCheckoutPayment is a completed card checkout for a cart.
+type CartCheckoutPayment struct {
+	CartID           string
+	SessionID        string // Stripe Checkout session ID
+	InvoiceID        string // Stripe invoice ID, when the cart was invoiced instead
+	Customer         string // Customer email, if collected
+	StripeCustomerID string // Stripe customer ID, if the payment created or used one
+	AmountCents      int64
+	Currency         string
+	Metadata         map[string]string // Checkout session metadata (from CartCheckout.Metadata)
+}
+
+// PrepareCartCheckout prices an unpaid, unexpired cart for card checkout and holds its stock
+// until holdUntil, when the checkout session expires.
+func (s *Service) PrepareCartCheckout(ctx context.Context, cartID string, holdUntil time.Time) (CartCheckout, error) {
+	if s.Draining() {
+		return CartCheckout{}, ErrDraining
+	}
+	cart, err := s.store.GetCartQuote(ctx, cartID)
+	if err != nil {
+		return CartCheckout{}, err
+	}
+	if cart.WalletPaidBy != "" {
+		return CartCheckout{}, ErrCartPaid
+	}
+	if err := s.ensureNoContributions(ctx, cart); err != nil {
+		return CartCheckout{}, err
+	}
+	if cart.Metadata[giftCardKey] != "" {
+		return CartCheckout{}, fmt.Errorf("%w: gift cards are only redeemed in crypto payments", ErrCartNotCardPayable)
+	}
+	if money.GetMintAddressForSymbol(cart.Total.Asset.Code) == "" {
+		return CartCheckout{}, fmt.Errorf("%w: %s has no fiat price", ErrCartNotCardPayable, cart.Total.Asset.Code)
+	}
+	usd := money.MustGetAsset("USD")
+
+	checkout := CartCheckout{
+		CartID:   cartID,
+		Currency: "usd",
+		Metadata: cartCallbackMetadata(cart, nil),
+	}
+	var linesTotal int64
+	addLine := func(name string, price money.Money, quantity int64) error {
+		cents, err := convertMoney(price, usd, 1)
+		if err != nil {
+			return err
+		}
+		checkout.Lines = append(checkout.Lines, CartCheckoutLine{Name: name, UnitAmount: cents.Atomic, Quantity: quantity})
+		linesTotal += cents.Atomic * quantity
+		return nil
+	}
+
+	for _, item := range cart.Items {
+		name := item.ResourceID
+		if resource, err := s.VariantDefinition(ctx, item.ResourceID, item.VariantID); err == nil && resource.Description != "" {
+			name = resource.Description
+		}
+		if err := addLine(name, item.Price, item.Quantity); err != nil {
+			return CartCheckout{}, err
+		}
+	}
+	extraLines := []struct{ lineType, label string }{
+		{CartLineShipping, lineLabel(s.cfg.Paywall.Shipping.Label, "Shipping")},
+		{CartLineTax, lineLabel(s.cfg.Paywall.Tax.Label, "Tax")},
+	}
+	for _, line := range extraLines {
+		amount := cart.Metadata[line.lineType+"_amount"]
+		if amount == "" {
+			continue
+		}
+		price, err := money.FromMajor(cart.Total.Asset, amount)
+		if err != nil {
+			return CartCheckout{}, fmt.Errorf("paywall: cart %s %s: %w", cartID, line.lineType, err)
+		}
+		if err := addLine(line.label, price, 1); err != nil {
+			return CartCheckout{}, err
+		}
+	}
+
+	total, err := convertMoney(cart.Total, usd, 1)
+	if err != nil {
+		return CartCheckout{}, err
+	}
+	checkout.Total = total.Atomic
+	checkout.Discount = linesTotal - total.Atomic
+	if err := addCheckoutCouponAmounts(checkout.Metadata, cart); err != nil {
+		return CartCheckout{}, err
+	}
+
+	// Keep the cart's units held while the customer is on the checkout page
+	if err := s.reserveCartStock(ctx, cartID, cart.Items, holdUntil); err != nil {
+		return CartCheckout{}, err
+	}
+	return checkout, nil
+}
+
+// CompleteCartCheckout records a card payment for a cart, marks the cart paid, takes its held
+// units out of stock, and fires the payment callback with the cart metadata the checkout was
+// created with. Repeated deliveries of the same session or invoice are ignored.
+func (s *Service) CompleteCartCheckout(ctx context.Context, payment CartCheckoutPayment) error {
+	paymentID, idKey := payment.SessionID, "session_id"
+	if paymentID == "" {
+		paymentID, idKey = payment.InvoiceID, "invoice_id"
+	}
+	if payment.CartID == "" || paymentID == "" {
+		return errors.New("paywall: cart checkout missing cart or session id")
+	}
+	asset, err := money.GetAsset(strings.ToUpper(payment.Currency))
+	if err != nil {
+		return fmt.Errorf("paywall: unsupported currency %s: %w", payment.Currency, err)
+	}
+	log := logger.FromContext(ctx)
+	now := time.Now()
+
+	// The customer pays without a wallet; fall back to the session so the cart still reads as paid
+	signature := "stripe:" + paymentID
+	payer := payment.Customer
+	if payer == "" {
+		payer = signature
+	}
+	tx := storage.PaymentTransaction{
+		Signature:  signature,
+		ResourceID: payment.CartID,
+		Wallet:     payer,
+		Amount:     money.New(asset, payment.AmountCents),
+		CreatedAt:  now,
+		Metadata: map[string]string{
+			"status": "stripe",
+			idKey:    paymentID,
+			"type":   "cart",
+		},
+	}
+	if payment.StripeCustomerID != "" {
+		tx.Metadata["stripe_customer_id"] = payment.StripeCustomerID
+	}
+	addCardCouponMetadata(tx.Metadata, payment.Metadata["coupon_codes"], payment.Metadata, asset)
+	if err := s.store.RecordPayment(ctx, tx); err != nil {
+		if !strings.Contains(err.Error(), "signature already used") {
+			return fmt.Errorf("paywall: record cart payment: %w", err)
+		}
+		return nil // duplicate webhook – already processed
+	}
+
+	// A cart that expired while the customer was paying is still marked paid if it hasn't been purged
+	if err := s.store.MarkCartPaid(ctx, payment.CartID, payer); err != nil {
+		log.Warn().
+			Err(err).
+			Str("cart_hash", hashResourceID(payment.CartID)).
+			Msg("cart.checkout.mark_paid_failed")
+	}
+	// The hold may have lapsed while the customer paid; the cart's items are then committed directly
+	var sold map[string]int64
+	if cart, err := s.store.GetCartQuote(ctx, payment.CartID); err == nil {
+		sold = cartStockUnits(cart.Items)
+	}
+	if err := s.store.CommitStockReservation(ctx, payment.CartID, sold); err != nil {
+		log.Error().
+			Err(err).
+			Str("cart_hash", hashResourceID(payment.CartID)).
+			Msg("cart.stock_commit_failed")
+	}
+	s.incrementCartCoupons(ctx, payment.Metadata["coupon_codes"])
+	s.recordCartReferrals(ctx, payment.Metadata["coupon_codes"], signature, payment.CartID, tx.Amount, now)
+	s.recordCardRedemptions(ctx, payment.Metadata["coupon_codes"], payment.Customer, signature, now)
+	s.linkPayer(ctx, storage.PayerIdentities(payment.Customer, "", payment.StripeCustomerID))
+
+	if s.metrics != nil {
+		itemCount, _ := strconv.Atoi(payment.Metadata["cart_items"])
+		s.metrics.ObserveCartCheckout("success", itemCount)
+	}
+
+	s.notifier.PaymentSucceeded(ctx, callbacks.PaymentEvent{
+		ResourceID:      payment.CartID,
+		Method:          "stripe-cart",
+		StripeSessionID: payment.SessionID,
+		StripeInvoiceID: payment.InvoiceID,
+		StripeCustomer:  payment.Customer,
+		FiatAmountCents: payment.AmountCents,
+		FiatCurrency:    payment.Currency,
+		Metadata:        payment.Metadata,
+		PaidAt:          now.UTC(),
+	})
+	return nil
+}
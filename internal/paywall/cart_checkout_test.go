@@ -0,0 +1,161 @@
+package paywall
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/coupons"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func TestPrepareCartCheckout(t *testing.T) {
+	tests := []struct {
+		name         string
+		items        []CartQuoteItem
+		coupon       string
+		shipping     config.CartShippingConfig
+		paid         bool
+		wantLines    []CartCheckoutLine
+		wantTotal    int64
+		wantDiscount int64
+		wantErr      error
+	}{
+		{
+			name:      "items at locked prices",
+			items:     []CartQuoteItem{{ResourceID: "demo-content", Quantity: 2}, {ResourceID: "fraction-content", Quantity: 3}},
+			wantLines: []CartCheckoutLine{{Name: "Demo content", UnitAmount: 100, Quantity: 2}, {Name: "fraction-content", UnitAmount: 34, Quantity: 3}},
+			wantTotal: 300, wantDiscount: 2,
+		},
+		{
+			name:      "shipping line",
+			items:     []CartQuoteItem{{ResourceID: "demo-content", Quantity: 1}},
+			shipping:  config.CartShippingConfig{Mode: "flat", FlatAmount: "4.99", Label: "Courier"},
+			wantLines: []CartCheckoutLine{{Name: "Demo content", UnitAmount: 100, Quantity: 1}, {Name: "Courier", UnitAmount: 499, Quantity: 1}},
+			wantTotal: 599,
+		},
+		{
+			name:      "checkout coupon",
+			items:     []CartQuoteItem{{ResourceID: "demo-content", Quantity: 2}},
+			coupon:    "HALF",
+			wantLines: []CartCheckoutLine{{Name: "Demo content", UnitAmount: 100, Quantity: 2}},
+			wantTotal: 100, wantDiscount: 100,
+		},
+		{
+			name:    "paid cart",
+			items:   []CartQuoteItem{{ResourceID: "demo-content", Quantity: 1}},
+			paid:    true,
+			wantErr: ErrCartPaid,
+		},
+		{
+			name:    "token without a fiat price",
+			items:   []CartQuoteItem{{ResourceID: "sol-content", Quantity: 1}},
+			wantErr: ErrCartNotCardPayable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cfg := testConfig()
+			cfg.Paywall.Shipping = tt.shipping
+			demo := cfg.Paywall.Resources["demo-content"]
+			demo.Description = "Demo content"
+			cfg.Paywall.Resources["demo-content"] = demo
+			cfg.Paywall.Resources["fraction-content"] = config.PaywallResource{ResourceID: "fraction-content", CryptoAtomicAmount: 333333, CryptoToken: "USDC"}
+			cfg.Paywall.Resources["sol-content"] = config.PaywallResource{ResourceID: "sol-content", CryptoAtomicAmount: 10000000, CryptoToken: "SOL"}
+			store := storage.NewMemoryStore()
+			t.Cleanup(func() { _ = store.Close() })
+			svc := NewService(cfg, store, stubVerifier{}, &recordingNotifier{}, testRepository(cfg), coupons.NewYAMLRepository(map[string]config.Coupon{
+				"HALF": {Code: "HALF", DiscountType: "percentage", DiscountValue: 50, Scope: "all", AppliesAt: "checkout", Active: true},
+			}), nil)
+
+			quote, err := svc.GenerateCartQuote(ctx, CartQuoteRequest{Items: tt.items, CouponCode: tt.coupon})
+			if err != nil {
+				t.Fatalf("GenerateCartQuote error: %v", err)
+			}
+			if tt.paid {
+				_ = store.MarkCartPaid(ctx, quote.CartID, "payer")
+			}
+
+			checkout, err := svc.PrepareCartCheckout(ctx, quote.CartID, time.Now().Add(time.Hour))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("PrepareCartCheckout error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PrepareCartCheckout error: %v", err)
+			}
+			if checkout.Currency != "usd" || checkout.Total != tt.wantTotal || checkout.Discount != tt.wantDiscount {
+				t.Errorf("checkout %s total %d discount %d, want usd total %d discount %d", checkout.Currency, checkout.Total, checkout.Discount, tt.wantTotal, tt.wantDiscount)
+			}
+			if len(checkout.Lines) != len(tt.wantLines) {
+				t.Fatalf("lines = %+v, want %+v", checkout.Lines, tt.wantLines)
+			}
+			for i, want := range tt.wantLines {
+				if checkout.Lines[i] != want {
+					t.Errorf("line %d = %+v, want %+v", i, checkout.Lines[i], want)
+				}
+			}
+			if checkout.Metadata["item_0_resource"] != tt.items[0].ResourceID || checkout.Metadata["cart_items"] == "" {
+				t.Errorf("checkout metadata = %v", checkout.Metadata)
+			}
+		})
+	}
+}
+
+func TestCompleteCartCheckout(t *testing.T) {
+	ctx := context.Background()
+	cfg := testConfig()
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	notifier := &recordingNotifier{}
+	svc := NewService(cfg, store, stubVerifier{}, notifier, testRepository(cfg), nil, nil)
+	if _, err := svc.SetStock(ctx, "demo-content", 5); err != nil {
+		t.Fatalf("SetStock error: %v", err)
+	}
+
+	quote, err := svc.GenerateCartQuote(ctx, CartQuoteRequest{Items: []CartQuoteItem{{ResourceID: "demo-content", Quantity: 2}}, Metadata: map[string]string{"user_id": "42"}})
+	if err != nil {
+		t.Fatalf("GenerateCartQuote error: %v", err)
+	}
+	checkout, err := svc.PrepareCartCheckout(ctx, quote.CartID, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("PrepareCartCheckout error: %v", err)
+	}
+
+	payment := CartCheckoutPayment{
+		CartID:      quote.CartID,
+		SessionID:   "cs_test_cart",
+		Customer:    "buyer@example.com",
+		AmountCents: checkout.Total,
+		Currency:    checkout.Currency,
+		Metadata:    checkout.Metadata,
+	}
+	for i := 0; i < 2; i++ { // The second delivery is a webhook retry
+		if err := svc.CompleteCartCheckout(ctx, payment); err != nil {
+			t.Fatalf("CompleteCartCheckout error: %v", err)
+		}
+	}
+
+	if len(notifier.payments) != 1 {
+		t.Fatalf("callbacks = %d, want 1", len(notifier.payments))
+	}
+	event := notifier.payments[0]
+	if event.Method != "stripe-cart" || event.ResourceID != quote.CartID || event.FiatAmountCents != 200 ||
+		event.Metadata["item_0_quantity"] != "2" || event.Metadata["user_id"] != "42" {
+		t.Errorf("callback = %+v", event)
+	}
+	if !store.HasCartAccess(ctx, quote.CartID, "buyer@example.com") {
+		t.Error("cart not marked paid by the customer")
+	}
+	if level, err := svc.GetStock(ctx, "demo-content"); err != nil || level.Quantity != 3 || level.Reserved != 0 {
+		t.Errorf("stock after payment = %+v, %v; want 3 on hand, 0 reserved", level, err)
+	}
+	if _, err := svc.PrepareCartCheckout(ctx, quote.CartID, time.Now().Add(time.Hour)); !errors.Is(err, ErrCartPaid) {
+		t.Errorf("PrepareCartCheckout on a paid cart: error = %v, want ErrCartPaid", err)
+	}
+}
@@ -0,0 +1,190 @@
+package paywall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/money"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+// Cart line types.
+const (
+	CartLineShipping = "shipping"
+	CartLineTax      = "tax"
+)
+
+// ErrNoShippingRate indicates a cart's destination has no shipping rate.
+var ErrNoShippingRate = errors.New("paywall: no shipping rate")
+
+// CartLine is a computed shipping or tax line included in a cart's locked total.
+type CartLine struct {
+	Type          string            `json:"type"`            // "shipping" or "tax"
+	Label         string            `json:"label"`           // Display label
+	Amount        money.LegacyFloat `json:"amount,omitzero"` // Amount in the cart's token
+	AmountDecimal string            `json:"amountDecimal"`   // Amount as an exact decimal string
+	Token         string            `json:"token"`
+}
+
+// CartPricing is the cart a LineCalculator prices.
+type CartPricing struct {
+	Items    []storage.CartItem // Items at their locked prices
+	Subtotal money.Money        // Item total after coupons
+	Shipping money.Money        // Shipping line (zero while shipping itself is calculated)
+	Metadata map[string]string  // Cart metadata, e.g. "country"
+}
+
+// LineCalculator computes the amount of a shipping or tax line in the subtotal's token.
+// A zero amount adds no line.
+type LineCalculator interface {
+	Calculate(ctx context.Context, cart CartPricing) (money.Money, error)
+}
+
+// SetShippingCalculator replaces paywall.shipping, e.g. with a carrier rate lookup.
+func (s *Service) SetShippingCalculator(calc LineCalculator) {
+	s.shipping = calc
+}
+
+// SetTaxCalculator replaces paywall.tax, e.g. with an external tax service.
+func (s *Service) SetTaxCalculator(calc LineCalculator) {
+	s.tax = calc
+}
+
+// ShippingRates prices shipping from paywall.shipping.
+type ShippingRates struct {
+	Flat  string            // Amount for every cart when Rates is empty
+	Rates map[string]string // Country code -> amount; "*" matches any other country
+}
+
+// Calculate returns the flat amount or the rate for the cart's country.
+func (r ShippingRates) Calculate(_ context.Context, cart CartPricing) (money.Money, error) {
+	amount := r.Flat
+	if len(r.Rates) > 0 {
+		var ok bool
+		if amount, ok = lookupCountry(r.Rates, cart.Metadata); !ok {
+			return money.Money{}, fmt.Errorf("%w for country %q", ErrNoShippingRate, cart.Metadata["country"])
+		}
+	}
+	return money.FromMajor(cart.Subtotal.Asset, amount)
+}
+
+// TaxRates prices tax from paywall.tax as a percentage, rounded up to the cent.
+type TaxRates struct {
+	Percent         float64            // Rate for every cart when Rates is empty
+	Rates           map[string]float64 // Country code -> rate percent; "*" matches any other country
+	IncludeShipping bool               // Tax the shipping line too
+}
+
+// Calculate returns the tax on the cart's subtotal (and shipping, if included).
+func (r TaxRates) Calculate(_ context.Context, cart CartPricing) (money.Money, error) {
+	percent := r.Percent
+	if len(r.Rates) > 0 {
+		percent, _ = lookupCountry(r.Rates, cart.Metadata) // Countries without a rate are not taxed
+	}
+	base := cart.Subtotal
+	if r.IncludeShipping && cart.Shipping.Atomic > 0 {
+		var err error
+		if base, err = base.Add(cart.Shipping); err != nil {
+			return money.Money{}, err
+		}
+	}
+	tax, err := base.MulBasisPointsWithRounding(int64(math.Round(percent*100)), money.RoundingStandard)
+	if err != nil {
+		return money.Money{}, err
+	}
+	return tax.RoundUpToCents(), nil
+}
+
+// lookupCountry returns the rate for the cart's "country" metadata, falling back to "*".
+func lookupCountry[T any](rates map[string]T, metadata map[string]string) (T, bool) {
+	if rate, ok := rates[strings.ToUpper(metadata["country"])]; ok {
+		return rate, true
+	}
+	rate, ok := rates["*"]
+	return rate, ok
+}
+
+// shippingFromConfig returns the calculator for paywall.shipping (nil when disabled).
+func shippingFromConfig(cfg config.CartShippingConfig) LineCalculator {
+	switch cfg.Mode {
+	case "flat":
+		return ShippingRates{Flat: cfg.FlatAmount}
+	case "table":
+		return ShippingRates{Rates: upperKeys(cfg.Rates)}
+	}
+	return nil
+}
+
+// taxFromConfig returns the calculator for paywall.tax (nil when disabled).
+func taxFromConfig(cfg config.CartTaxConfig) LineCalculator {
+	switch cfg.Mode {
+	case "flat":
+		return TaxRates{Percent: cfg.RatePercent, IncludeShipping: cfg.IncludeShipping}
+	case "table":
+		return TaxRates{Rates: upperKeys(cfg.Rates), IncludeShipping: cfg.IncludeShipping}
+	}
+	return nil
+}
+
+func upperKeys[T any](m map[string]T) map[string]T {
+	out := make(map[string]T, len(m))
+	for k, v := range m {
+		out[strings.ToUpper(k)] = v
+	}
+	return out
+}
+
+// cartLines computes the shipping and tax lines for a cart whose discounted item total is
+// subtotal. Amounts are returned in the subtotal's token.
+func (s *Service) cartLines(ctx context.Context, items []storage.CartItem, subtotal money.Money, metadata map[string]string) ([]CartLine, []money.Money, error) {
+	pricing := CartPricing{Items: items, Subtotal: subtotal, Shipping: money.Zero(subtotal.Asset), Metadata: metadata}
+	var lines []CartLine
+	var amounts []money.Money
+
+	add := func(lineType, label string, calc LineCalculator) error {
+		if calc == nil {
+			return nil
+		}
+		amount, err := calc.Calculate(ctx, pricing)
+		if err != nil {
+			return fmt.Errorf("paywall: calculate %s: %w", lineType, err)
+		}
+		if amount.Atomic == 0 {
+			return nil
+		}
+		if amount.Asset.Code != subtotal.Asset.Code || amount.IsNegative() {
+			return fmt.Errorf("paywall: %s must be a non-negative %s amount, got %s %s", lineType, subtotal.Asset.Code, amount.ToMajor(), amount.Asset.Code)
+		}
+		if lineType == CartLineShipping {
+			pricing.Shipping = amount
+		}
+		lines = append(lines, CartLine{
+			Type:          lineType,
+			Label:         label,
+			Amount:        amount.LegacyFloat(),
+			AmountDecimal: amount.ToMajor(),
+			Token:         amount.Asset.Code,
+		})
+		amounts = append(amounts, amount)
+		return nil
+	}
+
+	if err := add(CartLineShipping, lineLabel(s.cfg.Paywall.Shipping.Label, "Shipping"), s.shipping); err != nil {
+		return nil, nil, err
+	}
+	if err := add(CartLineTax, lineLabel(s.cfg.Paywall.Tax.Label, "Tax"), s.tax); err != nil {
+		return nil, nil, err
+	}
+	return lines, amounts, nil
+}
+
+func lineLabel(label, fallback string) string {
+	if label == "" {
+		return fallback
+	}
+	return label
+}
@@ -0,0 +1,167 @@
+package paywall
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/CedrosPay/server/internal/callbacks"
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/money"
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/pkg/x402"
+)
+
+type recordingNotifier struct {
+	callbacks.NoopNotifier
+	payments       []callbacks.PaymentEvent
+	refundRequests []callbacks.RefundRequestEvent
+	failures       []callbacks.PaymentFailedEvent
+}
+
+func (n *recordingNotifier) PaymentSucceeded(_ context.Context, event callbacks.PaymentEvent) {
+	n.payments = append(n.payments, event)
+}
+
+func (n *recordingNotifier) RefundRequestChanged(_ context.Context, event callbacks.RefundRequestEvent) {
+	n.refundRequests = append(n.refundRequests, event)
+}
+
+func (n *recordingNotifier) PaymentFailed(_ context.Context, event callbacks.PaymentFailedEvent) {
+	n.failures = append(n.failures, event)
+}
+
+type fixedLine money.Money
+
+func (l fixedLine) Calculate(context.Context, CartPricing) (money.Money, error) {
+	return money.Money(l), nil
+}
+
+// cartPaymentHeader builds an X-PAYMENT header that stubVerifier accepts for any cart.
+func cartPaymentHeader(t *testing.T, cfg *config.Config, signature string) string {
+	t.Helper()
+	payload, err := json.Marshal(x402.PaymentPayload{
+		Scheme:  "solana-spl-transfer",
+		Network: cfg.X402.Network,
+		Payload: x402.SolanaPayload{
+			Signature:   signature,
+			Transaction: base64.StdEncoding.EncodeToString([]byte("signed-tx")),
+		},
+	})
+	if err != nil {
+		t.Fatalf("marshal payment payload: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(payload)
+}
+
+func TestCartShippingAndTax(t *testing.T) {
+	// Two demo-content items: a 2.00 USDC subtotal
+	tests := []struct {
+		name      string
+		shipping  config.CartShippingConfig
+		tax       config.CartTaxConfig
+		calc      LineCalculator
+		country   string
+		wantLines []CartLine
+		wantTotal money.LegacyFloat
+		wantErr   error
+	}{
+		{name: "no lines", wantTotal: 2},
+		{
+			name:      "flat shipping and tax",
+			shipping:  config.CartShippingConfig{Mode: "flat", FlatAmount: "4.99"},
+			tax:       config.CartTaxConfig{Mode: "flat", RatePercent: 8.25},
+			wantLines: []CartLine{{Type: "shipping", Label: "Shipping", Amount: 4.99, AmountDecimal: "4.990000", Token: "USDC"}, {Type: "tax", Label: "Tax", Amount: 0.17, AmountDecimal: "0.170000", Token: "USDC"}},
+			wantTotal: 7.16,
+		},
+		{
+			name:      "tax on shipping",
+			shipping:  config.CartShippingConfig{Mode: "flat", FlatAmount: "3"},
+			tax:       config.CartTaxConfig{Mode: "flat", RatePercent: 10, IncludeShipping: true, Label: "VAT"},
+			wantLines: []CartLine{{Type: "shipping", Label: "Shipping", Amount: 3, AmountDecimal: "3.000000", Token: "USDC"}, {Type: "tax", Label: "VAT", Amount: 0.5, AmountDecimal: "0.500000", Token: "USDC"}},
+			wantTotal: 5.5,
+		},
+		{
+			name:      "table rates by country",
+			shipping:  config.CartShippingConfig{Mode: "table", Rates: map[string]string{"us": "5", "*": "15"}},
+			tax:       config.CartTaxConfig{Mode: "table", Rates: map[string]float64{"DE": 19}},
+			country:   "de",
+			wantLines: []CartLine{{Type: "shipping", Label: "Shipping", Amount: 15, AmountDecimal: "15.000000", Token: "USDC"}, {Type: "tax", Label: "Tax", Amount: 0.38, AmountDecimal: "0.380000", Token: "USDC"}},
+			wantTotal: 17.38,
+		},
+		{
+			name:      "untaxed country",
+			tax:       config.CartTaxConfig{Mode: "table", Rates: map[string]float64{"DE": 19}},
+			country:   "US",
+			wantTotal: 2,
+		},
+		{
+			name:     "no shipping rate",
+			shipping: config.CartShippingConfig{Mode: "table", Rates: map[string]string{"US": "5"}},
+			country:  "FR",
+			wantErr:  ErrNoShippingRate,
+		},
+		{
+			name:      "calculator hook",
+			shipping:  config.CartShippingConfig{Mode: "flat", FlatAmount: "4.99"},
+			calc:      fixedLine(money.New(money.MustGetAsset("USDC"), 1250000)),
+			wantLines: []CartLine{{Type: "shipping", Label: "Shipping", Amount: 1.25, AmountDecimal: "1.250000", Token: "USDC"}},
+			wantTotal: 3.25,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cfg := testConfig()
+			cfg.Paywall.Shipping = tt.shipping
+			cfg.Paywall.Tax = tt.tax
+			store := storage.NewMemoryStore()
+			t.Cleanup(func() { _ = store.Close() })
+			notifier := &recordingNotifier{}
+			svc := NewService(cfg, store, stubVerifier{}, notifier, testRepository(cfg), nil, nil)
+			if tt.calc != nil {
+				svc.SetShippingCalculator(tt.calc)
+			}
+
+			resp, err := svc.GenerateCartQuote(ctx, CartQuoteRequest{
+				Items:    []CartQuoteItem{{ResourceID: "demo-content", Quantity: 2}},
+				Metadata: map[string]string{"country": tt.country},
+			})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("GenerateCartQuote error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GenerateCartQuote error: %v", err)
+			}
+			if resp.TotalAmount != tt.wantTotal || len(resp.Lines) != len(tt.wantLines) {
+				t.Fatalf("total %v lines %+v, want %v %+v", resp.TotalAmount, resp.Lines, tt.wantTotal, tt.wantLines)
+			}
+			for i, want := range tt.wantLines {
+				if resp.Lines[i] != want {
+					t.Errorf("line %d = %+v, want %+v", i, resp.Lines[i], want)
+				}
+			}
+
+			if _, err := svc.Authorize(ctx, resp.CartID, "", cartPaymentHeader(t, cfg, "sig-"+tt.name), ""); err != nil {
+				t.Fatalf("Authorize cart: %v", err)
+			}
+			if len(notifier.payments) != 1 {
+				t.Fatalf("callbacks = %d, want 1", len(notifier.payments))
+			}
+			event := notifier.payments[0]
+			if event.CryptoAtomicAmount != int64(tt.wantTotal*1e6+0.5) {
+				t.Errorf("callback amount = %d, want total %v", event.CryptoAtomicAmount, tt.wantTotal)
+			}
+			for _, line := range resp.Lines {
+				if event.Metadata[line.Type+"_amount"] == "" || event.Metadata["subtotal_amount"] != "2.000000" {
+					t.Errorf("callback metadata missing %s line: %v", line.Type, event.Metadata)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package paywall
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/CedrosPay/server/internal/money"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+// splitPaymentKey is the cart metadata flag marking a cart that several wallets pay together.
+const splitPaymentKey = "split_payment"
+
+// CartPaymentsResponse lists the payments made toward a cart.
+type CartPaymentsResponse struct {
+	CartID         string             `json:"cartId"`
+	SplitPayment   bool               `json:"splitPayment"`
+	Paid           bool               `json:"paid"` // The total is covered and the cart's items are granted
+	PartialPayment                    // Amounts paid and remaining, in the cart's token
+	Contributions  []CartContribution `json:"contributions"`
+}
+
+// CartContribution is one wallet's payment toward a split cart.
+type CartContribution struct {
+	Wallet        string            `json:"wallet"`
+	Signature     string            `json:"signature"`
+	Amount        money.LegacyFloat `json:"amount,omitzero"`
+	AmountDecimal string            `json:"amountDecimal"`
+	PaidAt        time.Time         `json:"paidAt"`
+}
+
+// isSplitCart reports whether cart accepts partial payments from several wallets.
+func isSplitCart(cart storage.CartQuote) bool {
+	return cart.Metadata[splitPaymentKey] == "true"
+}
+
+// CartPayments returns the payments made toward a cart and the amount still owed.
+func (s *Service) CartPayments(ctx context.Context, cartID string) (CartPaymentsResponse, error) {
+	cart, err := s.store.GetCartQuote(ctx, cartID)
+	if err != nil {
+		return CartPaymentsResponse{}, err
+	}
+	resp := CartPaymentsResponse{
+		CartID:        cartID,
+		SplitPayment:  isSplitCart(cart),
+		Paid:          cart.WalletPaidBy != "",
+		Contributions: []CartContribution{},
+	}
+
+	paid := money.Zero(cart.Total.Asset)
+	if resp.SplitPayment {
+		contributions, err := s.store.ListCartContributions(ctx, cartID)
+		if err != nil {
+			return CartPaymentsResponse{}, fmt.Errorf("paywall: list cart contributions: %w", err)
+		}
+		for _, c := range contributions {
+			if paid, err = paid.Add(c.Amount); err != nil {
+				return CartPaymentsResponse{}, err
+			}
+			resp.Contributions = append(resp.Contributions, CartContribution{
+				Wallet:        c.Wallet,
+				Signature:     c.Signature,
+				Amount:        c.Amount.LegacyFloat(),
+				AmountDecimal: c.Amount.ToMajor(),
+				PaidAt:        c.PaidAt,
+			})
+		}
+	} else if resp.Paid {
+		paid = cart.Total
+	}
+	resp.PartialPayment = *partialPayment(paid, cart.Total)
+	return resp, nil
+}
+
+// ensureNoContributions rejects changes to a split cart once any wallet has paid toward it,
+// since the contributions were made against its locked total.
+func (s *Service) ensureNoContributions(ctx context.Context, cart storage.CartQuote) error {
+	if !isSplitCart(cart) {
+		return nil
+	}
+	contributions, err := s.store.ListCartContributions(ctx, cart.ID)
+	if err != nil {
+		return fmt.Errorf("paywall: list cart contributions: %w", err)
+	}
+	if len(contributions) > 0 {
+		return fmt.Errorf("%w: %d split payments already made", ErrCartPaid, len(contributions))
+	}
+	return nil
+}
+
+// checkContribution returns an x402.Requirement.CheckAmount that rejects a payment toward a split
+// cart with storage.ErrContributionExceedsTotal if it would take the cart past its total.
+func (s *Service) checkContribution(cart storage.CartQuote) func(context.Context, float64) error {
+	return func(ctx context.Context, amount float64) error {
+		contributions, err := s.store.ListCartContributions(ctx, cart.ID)
+		if err != nil {
+			return fmt.Errorf("paywall: list cart contributions: %w", err)
+		}
+		paid := money.Zero(cart.Total.Asset)
+		for _, c := range contributions {
+			if paid, err = paid.Add(c.Amount); err != nil {
+				return err
+			}
+		}
+		contribution := contributionAmount(cart.Total.Asset, amount)
+		if paid.Atomic+contribution.Atomic > cart.Total.Atomic {
+			return fmt.Errorf("%w: %s of %s %s paid, contribution %s", storage.ErrContributionExceedsTotal,
+				paid.ToMajor(), cart.Total.ToMajor(), cart.Total.Asset.Code, contribution.ToMajor())
+		}
+		return nil
+	}
+}
+
+// minimumContribution is the smallest payment toward a split cart: one atomic unit of asset.
+func minimumContribution(asset money.Asset) float64 {
+	return math.Pow10(-int(asset.Decimals))
+}
+
+// contributionAmount converts a verified payment amount into asset's atomic units.
+func contributionAmount(asset money.Asset, amount float64) money.Money {
+	return money.New(asset, int64(math.Round(amount*math.Pow10(int(asset.Decimals)))))
+}
+
+// partialPayment reports paid against total.
+func partialPayment(paid, total money.Money) *PartialPayment {
+	remaining := money.Zero(total.Asset)
+	if paid.LessThan(total) {
+		remaining, _ = total.Sub(paid)
+	}
+	return &PartialPayment{
+		PaidAmount:             paid.LegacyFloat(),
+		RemainingAmount:        remaining.LegacyFloat(),
+		TotalAmount:            total.LegacyFloat(),
+		PaidAmountDecimal:      paid.ToMajor(),
+		RemainingAmountDecimal: remaining.ToMajor(),
+		TotalAmountDecimal:     total.ToMajor(),
+		Token:                  total.Asset.Code,
+	}
+}
@@ -0,0 +1,105 @@
+package paywall
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/pkg/x402"
+)
+
+// amountVerifier verifies each payment for the amount set for its signature.
+// Payments rejected by the requirement's CheckAmount are not sent.
+type amountVerifier struct {
+	amounts map[string]float64
+	sent    map[string]bool
+}
+
+func (v amountVerifier) Verify(ctx context.Context, proof x402.PaymentProof, requirement x402.Requirement) (x402.VerificationResult, error) {
+	amount := v.amounts[proof.Signature]
+	if requirement.CheckAmount != nil {
+		if err := requirement.CheckAmount(ctx, amount); err != nil {
+			return x402.VerificationResult{}, err
+		}
+	}
+	v.sent[proof.Signature] = true
+	return x402.VerificationResult{Wallet: "wallet-" + proof.Signature, Signature: proof.Signature, Amount: amount}, nil
+}
+
+func TestSplitCartPayment(t *testing.T) {
+	ctx := context.Background()
+	cfg := testConfig()
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	notifier := &recordingNotifier{}
+	verifier := amountVerifier{
+		amounts: map[string]float64{"sig-a": 1, "sig-b": 2.5, "sig-c": 2, "sig-d": 1},
+		sent:    make(map[string]bool),
+	}
+	svc := NewService(cfg, store, verifier, notifier, testRepository(cfg), nil, nil)
+
+	// Three demo-content items: a 3.00 USDC total
+	items := []CartQuoteItem{{ResourceID: "demo-content", Quantity: 3}}
+	quote, err := svc.GenerateCartQuote(ctx, CartQuoteRequest{Items: items, SplitPayment: true})
+	if err != nil {
+		t.Fatalf("GenerateCartQuote error: %v", err)
+	}
+
+	steps := []struct {
+		name          string
+		signature     string
+		wantErr       error
+		wantGranted   bool
+		wantRemaining float64
+	}{
+		{name: "first wallet", signature: "sig-a", wantRemaining: 2},
+		{name: "more than the rest", signature: "sig-b", wantErr: storage.ErrContributionExceedsTotal},
+		{name: "covers the rest", signature: "sig-c", wantGranted: true},
+	}
+	for _, step := range steps {
+		result, err := svc.Authorize(ctx, quote.CartID, "", cartPaymentHeader(t, cfg, step.signature), "")
+		if !errors.Is(err, step.wantErr) {
+			t.Fatalf("%s: Authorize error = %v, want %v", step.name, err, step.wantErr)
+		}
+		if verifier.sent[step.signature] != (err == nil) {
+			t.Errorf("%s: payment sent = %v, want %v", step.name, verifier.sent[step.signature], err == nil)
+		}
+		if err != nil {
+			continue
+		}
+		if result.Granted != step.wantGranted {
+			t.Errorf("%s: granted = %v, want %v", step.name, result.Granted, step.wantGranted)
+		}
+		if !step.wantGranted && (result.Partial == nil || float64(result.Partial.RemainingAmount) != step.wantRemaining) {
+			t.Errorf("%s: partial = %+v, want %v remaining", step.name, result.Partial, step.wantRemaining)
+		}
+
+		// A partly paid cart keeps its locked total
+		if step.signature == "sig-a" {
+			if _, err := svc.UpdateCartQuote(ctx, quote.CartID, CartUpdateRequest{Items: []CartItemChange{{ResourceID: "demo-content", Quantity: 1}}}); !errors.Is(err, ErrCartPaid) {
+				t.Errorf("UpdateCartQuote on a partly paid cart: error = %v, want ErrCartPaid", err)
+			}
+		}
+	}
+
+	payments, err := svc.CartPayments(ctx, quote.CartID)
+	if err != nil {
+		t.Fatalf("CartPayments error: %v", err)
+	}
+	if !payments.Paid || payments.PaidAmount != 3 || payments.RemainingAmount != 0 || len(payments.Contributions) != 2 {
+		t.Errorf("cart payments = %+v, want 3.00 paid by two wallets", payments)
+	}
+	if len(notifier.payments) != 1 || notifier.payments[0].Wallet != "wallet-sig-c" || notifier.payments[0].CryptoAtomicAmount != 3000000 {
+		t.Errorf("payment callbacks = %+v, want one for the full total", notifier.payments)
+	}
+
+	// Carts without split payment still require the exact total
+	whole, err := svc.GenerateCartQuote(ctx, CartQuoteRequest{Items: items})
+	if err != nil {
+		t.Fatalf("GenerateCartQuote error: %v", err)
+	}
+	if _, err := svc.Authorize(ctx, whole.CartID, "", cartPaymentHeader(t, cfg, "sig-d"), ""); err == nil {
+		t.Error("partial payment accepted for a cart without split payment")
+	}
+}
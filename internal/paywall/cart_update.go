@@ -0,0 +1,121 @@
+package paywall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrCartPaid indicates a cart can no longer change because it has been paid.
+var ErrCartPaid = errors.New("paywall: cart already paid")
+
+// ErrInvalidCartChange indicates a cart update is malformed or would leave the cart empty.
+var ErrInvalidCartChange = errors.New("paywall: invalid cart change")
+
+// pricingMetadataKeys are the cart metadata entries quoteCart derives from coupons and shipping
+// and tax lines; they are dropped and recomputed when a cart is re-priced.
+var pricingMetadataKeys = []string{
+	"coupon_codes", "original_amount", "subtotal_after_catalog", "discounted_amount", "catalog_coupons", "checkout_coupons", "manual_coupon",
+	"subtotal_amount", "shipping_amount", "tax_amount", "gift_card", "gift_card_amount",
+}
+
+// CartItemChange sets the quantity of one resource (or one variant of it) in a cart.
+type CartItemChange struct {
+	ResourceID string            `json:"resource"`           // Resource ID from paywall config
+	VariantID  string            `json:"variant,omitempty"`  // Variant of the resource; variants are separate lines
+	Quantity   int64             `json:"quantity"`           // New quantity; 0 removes the resource from the cart
+	Metadata   map[string]string `json:"metadata,omitempty"` // Replaces the item's metadata when set
+}
+
+// CartUpdateRequest adds, removes, or changes the quantities of items in an unpaid cart.
+type CartUpdateRequest struct {
+	Items      []CartItemChange `json:"items"`
+	CouponCode string           `json:"couponCode,omitempty"` // Replaces the cart's coupon code when set
+}
+
+// UpdateCartQuote applies req to an unpaid, unexpired cart and re-prices every item at the
+// current catalog prices and coupons, keeping the cart ID. The new total is locked for a fresh
+// cart TTL. A payment already verifying for the old total is kept with the items it paid for.
+func (s *Service) UpdateCartQuote(ctx context.Context, cartID string, req CartUpdateRequest) (CartQuoteResponse, error) {
+	if s.Draining() {
+		return CartQuoteResponse{}, ErrDraining
+	}
+	if len(req.Items) == 0 {
+		return CartQuoteResponse{}, fmt.Errorf("%w: at least one item change required", ErrInvalidCartChange)
+	}
+
+	cart, err := s.store.GetCartQuote(ctx, cartID)
+	if err != nil {
+		return CartQuoteResponse{}, err
+	}
+	if cart.WalletPaidBy != "" {
+		return CartQuoteResponse{}, ErrCartPaid
+	}
+	if err := s.ensureNoContributions(ctx, cart); err != nil {
+		return CartQuoteResponse{}, err
+	}
+
+	items := make([]CartQuoteItem, 0, len(cart.Items)+len(req.Items))
+	for _, item := range cart.Items {
+		items = append(items, CartQuoteItem{ResourceID: item.ResourceID, VariantID: item.VariantID, Quantity: item.Quantity, Metadata: item.Metadata})
+	}
+	for i, change := range req.Items {
+		if change.ResourceID == "" {
+			return CartQuoteResponse{}, fmt.Errorf("%w: change %d missing resource id", ErrInvalidCartChange, i)
+		}
+		if change.Quantity < 0 {
+			return CartQuoteResponse{}, fmt.Errorf("%w: change %d (%s) has negative quantity", ErrInvalidCartChange, i, change.ResourceID)
+		}
+		items = applyCartChange(items, change)
+	}
+	if len(items) == 0 {
+		return CartQuoteResponse{}, fmt.Errorf("%w: at least one item required", ErrInvalidCartChange)
+	}
+
+	metadata := make(map[string]string, len(cart.Metadata))
+	for k, v := range cart.Metadata {
+		metadata[k] = v
+	}
+	for _, key := range pricingMetadataKeys {
+		delete(metadata, key)
+	}
+	couponCode := req.CouponCode
+	if couponCode == "" {
+		couponCode = cart.Metadata["manual_coupon"]
+	}
+	if couponCode == "" {
+		couponCode = cart.Metadata[giftCardKey]
+	}
+
+	return s.quoteCart(ctx, cartID, CartQuoteRequest{Items: items, Metadata: metadata, CouponCode: couponCode})
+}
+
+// applyCartChange replaces the lines for change's resource and variant with one line at the new
+// quantity, keeping the first line's position and metadata unless change replaces the metadata.
+// Resources not yet in the cart are appended; quantity 0 removes the resource.
+func applyCartChange(items []CartQuoteItem, change CartItemChange) []CartQuoteItem {
+	updated := make([]CartQuoteItem, 0, len(items)+1)
+	line := -1
+	for _, item := range items {
+		if item.ResourceID != change.ResourceID || item.VariantID != change.VariantID {
+			updated = append(updated, item)
+			continue
+		}
+		if line < 0 && change.Quantity > 0 {
+			line = len(updated)
+			updated = append(updated, item)
+		}
+	}
+	if change.Quantity == 0 {
+		return updated
+	}
+	if line < 0 {
+		line = len(updated)
+		updated = append(updated, CartQuoteItem{ResourceID: change.ResourceID, VariantID: change.VariantID})
+	}
+	updated[line].Quantity = change.Quantity
+	if change.Metadata != nil {
+		updated[line].Metadata = change.Metadata
+	}
+	return updated
+}
@@ -0,0 +1,109 @@
+package paywall
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/CedrosPay/server/internal/callbacks"
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func TestUpdateCartQuote(t *testing.T) {
+	initial := []CartQuoteItem{
+		{ResourceID: "demo-content", Quantity: 1, Metadata: map[string]string{"note": "gift"}},
+		{ResourceID: "extra-content", Quantity: 2},
+	}
+	tests := []struct {
+		name      string
+		changes   []CartItemChange
+		paid      bool
+		wantItems []CartQuoteItem
+		wantTotal float64
+		wantErr   error
+	}{
+		{
+			name:      "change quantity",
+			changes:   []CartItemChange{{ResourceID: "demo-content", Quantity: 3}},
+			wantItems: []CartQuoteItem{{ResourceID: "demo-content", Quantity: 3}, {ResourceID: "extra-content", Quantity: 2}},
+			wantTotal: 4,
+		},
+		{
+			name:      "add and remove",
+			changes:   []CartItemChange{{ResourceID: "extra-content", Quantity: 0}, {ResourceID: "bonus-content", Quantity: 1}},
+			wantItems: []CartQuoteItem{{ResourceID: "demo-content", Quantity: 1}, {ResourceID: "bonus-content", Quantity: 1}},
+			wantTotal: 3,
+		},
+		{
+			name:    "remove everything",
+			changes: []CartItemChange{{ResourceID: "demo-content"}, {ResourceID: "extra-content"}},
+			wantErr: ErrInvalidCartChange,
+		},
+		{
+			name:    "negative quantity",
+			changes: []CartItemChange{{ResourceID: "demo-content", Quantity: -1}},
+			wantErr: ErrInvalidCartChange,
+		},
+		{
+			name:    "unknown resource",
+			changes: []CartItemChange{{ResourceID: "missing", Quantity: 1}},
+			wantErr: ErrResourceNotConfigured,
+		},
+		{
+			name:    "paid cart",
+			changes: []CartItemChange{{ResourceID: "demo-content", Quantity: 3}},
+			paid:    true,
+			wantErr: ErrCartPaid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cfg := testConfig()
+			cfg.Paywall.Resources["extra-content"] = config.PaywallResource{ResourceID: "extra-content", CryptoAtomicAmount: 500000, CryptoToken: "USDC"}
+			cfg.Paywall.Resources["bonus-content"] = config.PaywallResource{ResourceID: "bonus-content", CryptoAtomicAmount: 2000000, CryptoToken: "USDC"}
+			store := storage.NewMemoryStore()
+			t.Cleanup(func() { _ = store.Close() })
+			svc := NewService(cfg, store, stubVerifier{}, callbacks.NoopNotifier{}, testRepository(cfg), nil, nil)
+
+			created, err := svc.GenerateCartQuote(ctx, CartQuoteRequest{Items: initial, Metadata: map[string]string{"user_id": "42"}})
+			if err != nil {
+				t.Fatalf("GenerateCartQuote error: %v", err)
+			}
+			if tt.paid {
+				_ = store.MarkCartPaid(ctx, created.CartID, "payer")
+			}
+
+			updated, err := svc.UpdateCartQuote(ctx, created.CartID, CartUpdateRequest{Items: tt.changes})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("UpdateCartQuote error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UpdateCartQuote error: %v", err)
+			}
+			if updated.CartID != created.CartID || float64(updated.TotalAmount) != tt.wantTotal || updated.Metadata["user_id"] != "42" {
+				t.Errorf("updated cart %s total %v metadata %v, want %s total %v", updated.CartID, updated.TotalAmount, updated.Metadata, created.CartID, tt.wantTotal)
+			}
+
+			cart, err := svc.GetCartQuote(ctx, created.CartID)
+			if err != nil {
+				t.Fatalf("GetCartQuote error: %v", err)
+			}
+			if len(cart.Items) != len(tt.wantItems) {
+				t.Fatalf("stored items = %+v, want %+v", cart.Items, tt.wantItems)
+			}
+			for i, want := range tt.wantItems {
+				if cart.Items[i].ResourceID != want.ResourceID || cart.Items[i].Quantity != want.Quantity {
+					t.Errorf("item %d = %s x%d, want %s x%d", i, cart.Items[i].ResourceID, cart.Items[i].Quantity, want.ResourceID, want.Quantity)
+				}
+			}
+			if cart.Items[0].Metadata["note"] != "gift" {
+				t.Errorf("item metadata lost: %v", cart.Items[0].Metadata)
+			}
+		})
+	}
+}
@@ -0,0 +1,282 @@
+package paywall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/CedrosPay/server/internal/money"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+// ErrInvalidAnalyticsWindow indicates a coupon analytics window or interval is not supported.
+var ErrInvalidAnalyticsWindow = errors.New("paywall: invalid analytics window")
+
+// maxAnalyticsPeriods bounds the periods in one coupon analytics report.
+const maxAnalyticsPeriods = 366
+
+// Coupon analytics intervals.
+const (
+	AnalyticsIntervalDay  = "day"
+	AnalyticsIntervalWeek = "week"
+)
+
+// CouponAnalyticsRequest selects the window a coupon analytics report covers.
+type CouponAnalyticsRequest struct {
+	Since    time.Time
+	Until    time.Time
+	Interval string // AnalyticsIntervalDay (default) or AnalyticsIntervalWeek
+	Code     string // Report on a single coupon; empty for every coupon used in the window
+}
+
+// CouponAnalytics reports coupon redemptions, revenue impact, and cart conversion over a window.
+type CouponAnalytics struct {
+	Since    time.Time     `json:"since"`
+	Until    time.Time     `json:"until"`
+	Interval string        `json:"interval"`
+	Coupons  []CouponStats `json:"coupons"`
+}
+
+// CouponStats is one coupon's activity over the window, in total and per period.
+type CouponStats struct {
+	Code string `json:"code"`
+	CouponActivity
+	Revenue []CouponRevenue  `json:"revenue"`
+	Periods []CouponActivity `json:"periods"`
+}
+
+// CouponActivity counts a coupon's payments and cart quotes. Periods set Start.
+type CouponActivity struct {
+	Start          *time.Time `json:"start,omitempty"`
+	Redemptions    int        `json:"redemptions"`    // Payments made with the coupon
+	CartQuotes     int        `json:"cartQuotes"`     // Carts priced with the coupon
+	CartsPaid      int        `json:"cartsPaid"`      // Of those carts, the ones paid
+	ConversionRate float64    `json:"conversionRate"` // CartsPaid / CartQuotes, 0 without carts
+}
+
+// CouponRevenue sums, in one currency, what the coupon's payments were priced at before and
+// after their coupons. A payment stacking several coupons counts toward each.
+type CouponRevenue struct {
+	Currency         string `json:"currency"`
+	OriginalAmount   string `json:"originalAmount"`
+	DiscountedAmount string `json:"discountedAmount"`
+	DiscountAmount   string `json:"discountAmount"`
+}
+
+// couponTally accumulates one coupon's stats while a report is built.
+type couponTally struct {
+	stats      CouponStats
+	original   map[string]money.Money
+	discounted map[string]money.Money
+}
+
+// CouponAnalytics aggregates coupon usage over req's window from the payments and cart quotes
+// whose metadata lists applied coupons. Cart quotes are purged once expired, so conversion only
+// covers the carts still retained.
+func (s *Service) CouponAnalytics(ctx context.Context, req CouponAnalyticsRequest) (CouponAnalytics, error) {
+	step := 24 * time.Hour
+	switch req.Interval {
+	case "", AnalyticsIntervalDay:
+		req.Interval = AnalyticsIntervalDay
+	case AnalyticsIntervalWeek:
+		step = 7 * 24 * time.Hour
+	default:
+		return CouponAnalytics{}, fmt.Errorf("%w: interval must be day or week", ErrInvalidAnalyticsWindow)
+	}
+	since, until := req.Since.UTC().Truncate(24*time.Hour), req.Until.UTC()
+	if !since.Before(until) {
+		return CouponAnalytics{}, fmt.Errorf("%w: since must be before until", ErrInvalidAnalyticsWindow)
+	}
+	periods := int((until.Sub(since) + step - 1) / step)
+	if periods > maxAnalyticsPeriods {
+		return CouponAnalytics{}, fmt.Errorf("%w: window spans more than %d periods", ErrInvalidAnalyticsWindow, maxAnalyticsPeriods)
+	}
+
+	payments, err := s.store.ListCouponPayments(ctx, since, until)
+	if err != nil {
+		return CouponAnalytics{}, fmt.Errorf("paywall: list coupon payments: %w", err)
+	}
+	quotes, err := s.store.ListCouponCartQuotes(ctx, since, until)
+	if err != nil {
+		return CouponAnalytics{}, fmt.Errorf("paywall: list coupon cart quotes: %w", err)
+	}
+
+	tallies := make(map[string]*couponTally)
+	tally := func(code string) *couponTally {
+		t, ok := tallies[code]
+		if !ok {
+			t = &couponTally{
+				stats:      CouponStats{Code: code, Periods: make([]CouponActivity, periods)},
+				original:   make(map[string]money.Money),
+				discounted: make(map[string]money.Money),
+			}
+			for i := range t.stats.Periods {
+				start := since.Add(time.Duration(i) * step)
+				t.stats.Periods[i].Start = &start
+			}
+			tallies[code] = t
+		}
+		return t
+	}
+	period := func(at time.Time) int {
+		return int(at.Sub(since) / step)
+	}
+
+	for _, payment := range payments {
+		original, discounted, priced := paymentCouponAmounts(payment.Metadata, payment.Amount.Asset)
+		for _, code := range analyticsCouponCodes(payment.Metadata["coupon_codes"], req.Code) {
+			t := tally(code)
+			t.stats.Redemptions++
+			t.stats.Periods[period(payment.CreatedAt)].Redemptions++
+			if priced {
+				currency := original.Asset.Code
+				t.original[currency] = addMoney(t.original[currency], original)
+				t.discounted[currency] = addMoney(t.discounted[currency], discounted)
+			}
+		}
+	}
+	for _, quote := range quotes {
+		for _, code := range analyticsCouponCodes(quote.Metadata["coupon_codes"], req.Code) {
+			t := tally(code)
+			p := &t.stats.Periods[period(quote.CreatedAt)]
+			t.stats.CartQuotes++
+			p.CartQuotes++
+			if quote.WalletPaidBy != "" {
+				t.stats.CartsPaid++
+				p.CartsPaid++
+			}
+		}
+	}
+
+	report := CouponAnalytics{Since: since, Until: until, Interval: req.Interval, Coupons: []CouponStats{}}
+	for _, t := range tallies {
+		t.stats.ConversionRate = conversionRate(t.stats.CartsPaid, t.stats.CartQuotes)
+		for i := range t.stats.Periods {
+			p := &t.stats.Periods[i]
+			p.ConversionRate = conversionRate(p.CartsPaid, p.CartQuotes)
+		}
+		t.stats.Revenue = []CouponRevenue{}
+		for currency, original := range t.original {
+			discounted := t.discounted[currency]
+			discount, _ := original.Sub(discounted)
+			t.stats.Revenue = append(t.stats.Revenue, CouponRevenue{
+				Currency:         currency,
+				OriginalAmount:   original.ToMajor(),
+				DiscountedAmount: discounted.ToMajor(),
+				DiscountAmount:   discount.ToMajor(),
+			})
+		}
+		sort.Slice(t.stats.Revenue, func(i, j int) bool {
+			return t.stats.Revenue[i].Currency < t.stats.Revenue[j].Currency
+		})
+		report.Coupons = append(report.Coupons, t.stats)
+	}
+	sort.Slice(report.Coupons, func(i, j int) bool {
+		return report.Coupons[i].Code < report.Coupons[j].Code
+	})
+	return report, nil
+}
+
+// analyticsCouponCodes splits codes, the comma-separated coupon_codes of a payment or cart. A
+// non-empty only keeps just that coupon.
+func analyticsCouponCodes(codes, only string) []string {
+	var matched []string
+	for _, code := range strings.Split(codes, ",") {
+		code = strings.TrimSpace(code)
+		if code != "" && (only == "" || code == only) {
+			matched = append(matched, code)
+		}
+	}
+	return matched
+}
+
+// paymentCouponAmounts parses the original_amount and discounted_amount a payment recorded in
+// asset. Payments recorded without them are counted as redemptions only.
+func paymentCouponAmounts(metadata map[string]string, asset money.Asset) (original, discounted money.Money, ok bool) {
+	if metadata["original_amount"] == "" || metadata["discounted_amount"] == "" {
+		return money.Money{}, money.Money{}, false
+	}
+	original, err := money.FromMajor(asset, metadata["original_amount"])
+	if err != nil {
+		return money.Money{}, money.Money{}, false
+	}
+	discounted, err = money.FromMajor(asset, metadata["discounted_amount"])
+	if err != nil {
+		return money.Money{}, money.Money{}, false
+	}
+	return original, discounted, true
+}
+
+// addCardCouponMetadata records on a card payment's metadata the coupons in codes and the
+// amounts from the session's original_amount_cents and discount_amount_cents, in asset.
+func addCardCouponMetadata(dst map[string]string, codes string, session map[string]string, asset money.Asset) {
+	if codes == "" {
+		return
+	}
+	dst["coupon_codes"] = codes
+	original, err := strconv.ParseInt(session["original_amount_cents"], 10, 64)
+	if err != nil {
+		return
+	}
+	discount, _ := strconv.ParseInt(session["discount_amount_cents"], 10, 64)
+	dst["original_amount"] = money.New(asset, original).ToMajor()
+	dst["discounted_amount"] = money.New(asset, original-discount).ToMajor()
+}
+
+// addCouponMetadata copies the coupons a cart was priced with, and its items total before and
+// after them, onto the cart's payment metadata.
+func addCouponMetadata(dst, cart map[string]string) {
+	for _, key := range []string{"coupon_codes", "original_amount", "discounted_amount"} {
+		if v := cart[key]; v != "" {
+			dst[key] = v
+		}
+	}
+}
+
+// addCheckoutCouponAmounts adds to a card checkout's metadata the cart's items total before and
+// after coupons in USD cents, as single-resource sessions record them.
+func addCheckoutCouponAmounts(metadata map[string]string, cart storage.CartQuote) error {
+	if cart.Metadata["original_amount"] == "" || cart.Metadata["discounted_amount"] == "" {
+		return nil
+	}
+	usd := money.MustGetAsset("USD")
+	var cents [2]int64
+	for i, key := range []string{"original_amount", "discounted_amount"} {
+		amount, err := money.FromMajor(cart.Total.Asset, cart.Metadata[key])
+		if err != nil {
+			return fmt.Errorf("paywall: cart %s %s: %w", cart.ID, key, err)
+		}
+		converted, err := convertMoney(amount, usd, 1)
+		if err != nil {
+			return err
+		}
+		cents[i] = converted.Atomic
+	}
+	metadata["original_amount_cents"] = strconv.FormatInt(cents[0], 10)
+	metadata["discount_amount_cents"] = strconv.FormatInt(cents[0]-cents[1], 10)
+	return nil
+}
+
+// addMoney adds amount to sum, where a zero-value sum starts in amount's asset.
+func addMoney(sum, amount money.Money) money.Money {
+	if sum.Asset.Code == "" {
+		return amount
+	}
+	total, err := sum.Add(amount)
+	if err != nil {
+		return sum
+	}
+	return total
+}
+
+// conversionRate is paid / quoted, or 0 without quotes.
+func conversionRate(paid, quoted int) float64 {
+	if quoted == 0 {
+		return 0
+	}
+	return float64(paid) / float64(quoted)
+}
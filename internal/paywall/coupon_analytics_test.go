@@ -0,0 +1,132 @@
+package paywall
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/CedrosPay/server/internal/money"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func TestCouponAnalytics(t *testing.T) {
+	ctx := context.Background()
+	cfg := testConfig()
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	svc := NewService(cfg, store, stubVerifier{}, nil, testRepository(cfg), nil, nil)
+
+	usdc, usd := money.MustGetAsset("USDC"), money.MustGetAsset("USD")
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	yesterday := today.Add(-24 * time.Hour)
+	payments := []storage.PaymentTransaction{
+		{Signature: "sig-1", ResourceID: "demo-content", Wallet: "w1", Amount: money.New(usdc, 500000), CreatedAt: yesterday.Add(time.Hour),
+			Metadata: map[string]string{"coupon_codes": "HALF", "original_amount": "1.000000", "discounted_amount": "0.500000"}},
+		{Signature: "sig-2", ResourceID: "cart_1", Wallet: "w2", Amount: money.New(usdc, 1350000), CreatedAt: today.Add(time.Hour),
+			Metadata: map[string]string{"coupon_codes": "HALF,TENOFF", "original_amount": "3", "discounted_amount": "1.35"}},
+		{Signature: "stripe:cs_1", ResourceID: "demo-content", Wallet: "a@example.com", Amount: money.New(usd, 50), CreatedAt: today.Add(time.Hour),
+			Metadata: map[string]string{"coupon_codes": "HALF", "original_amount": "1.00", "discounted_amount": "0.50"}},
+		{Signature: "sig-3", ResourceID: "demo-content", Wallet: "w3", Amount: money.New(usdc, 1000000), CreatedAt: today.Add(time.Hour),
+			Metadata: map[string]string{"status": "verified"}},
+	}
+	for _, p := range payments {
+		if err := store.RecordPayment(ctx, p); err != nil {
+			t.Fatalf("RecordPayment: %v", err)
+		}
+	}
+	for _, quote := range []storage.CartQuote{
+		{ID: "cart_1", Total: money.New(usdc, 1350000), Metadata: map[string]string{"coupon_codes": "HALF,TENOFF"}, CreatedAt: today.Add(time.Minute), ExpiresAt: time.Now().Add(time.Hour)},
+		{ID: "cart_2", Total: money.New(usdc, 1350000), Metadata: map[string]string{"coupon_codes": "HALF"}, CreatedAt: today.Add(time.Minute), ExpiresAt: time.Now().Add(time.Hour)},
+	} {
+		if err := store.SaveCartQuote(ctx, quote); err != nil {
+			t.Fatalf("SaveCartQuote: %v", err)
+		}
+	}
+	if err := store.MarkCartPaid(ctx, "cart_1", "w2"); err != nil {
+		t.Fatalf("MarkCartPaid: %v", err)
+	}
+
+	report, err := svc.CouponAnalytics(ctx, CouponAnalyticsRequest{Since: yesterday, Until: today.Add(24 * time.Hour)})
+	if err != nil {
+		t.Fatalf("CouponAnalytics error: %v", err)
+	}
+	if len(report.Coupons) != 2 || report.Coupons[0].Code != "HALF" || report.Coupons[1].Code != "TENOFF" {
+		t.Fatalf("coupons = %+v, want HALF and TENOFF", report.Coupons)
+	}
+
+	half := report.Coupons[0]
+	if half.Redemptions != 3 || half.CartQuotes != 2 || half.CartsPaid != 1 || half.ConversionRate != 0.5 {
+		t.Errorf("HALF totals = %+v, want 3 redemptions and 1 of 2 carts paid", half.CouponActivity)
+	}
+	wantRevenue := []CouponRevenue{
+		{Currency: "USD", OriginalAmount: "1.00", DiscountedAmount: "0.50", DiscountAmount: "0.50"},
+		{Currency: "USDC", OriginalAmount: "4.000000", DiscountedAmount: "1.850000", DiscountAmount: "2.150000"},
+	}
+	if len(half.Revenue) != len(wantRevenue) {
+		t.Fatalf("HALF revenue = %+v, want %+v", half.Revenue, wantRevenue)
+	}
+	for i, want := range wantRevenue {
+		if half.Revenue[i] != want {
+			t.Errorf("HALF revenue[%d] = %+v, want %+v", i, half.Revenue[i], want)
+		}
+	}
+	if len(half.Periods) != 2 || !half.Periods[0].Start.Equal(yesterday) {
+		t.Fatalf("HALF periods = %+v, want 2 starting %s", half.Periods, yesterday)
+	}
+	if half.Periods[0].Redemptions != 1 || half.Periods[1].Redemptions != 2 || half.Periods[1].ConversionRate != 0.5 {
+		t.Errorf("HALF periods = %+v, want 1 then 2 redemptions", half.Periods)
+	}
+
+	single, err := svc.CouponAnalytics(ctx, CouponAnalyticsRequest{Since: today, Until: today.Add(24 * time.Hour), Interval: AnalyticsIntervalWeek, Code: "TENOFF"})
+	if err != nil {
+		t.Fatalf("CouponAnalytics for TENOFF error: %v", err)
+	}
+	if len(single.Coupons) != 1 || single.Coupons[0].Redemptions != 1 || single.Coupons[0].ConversionRate != 1 {
+		t.Errorf("TENOFF report = %+v, want 1 redemption and its cart paid", single.Coupons)
+	}
+
+	invalid := []CouponAnalyticsRequest{
+		{Since: today, Until: yesterday},
+		{Since: yesterday, Until: today, Interval: "hour"},
+		{Since: today.Add(-400 * 24 * time.Hour), Until: today},
+	}
+	for _, req := range invalid {
+		if _, err := svc.CouponAnalytics(ctx, req); !errors.Is(err, ErrInvalidAnalyticsWindow) {
+			t.Errorf("CouponAnalytics(%s to %s, %q) error = %v, want ErrInvalidAnalyticsWindow", req.Since, req.Until, req.Interval, err)
+		}
+	}
+}
+
+func TestCardCouponMetadata(t *testing.T) {
+	usd := money.MustGetAsset("USD")
+	tests := []struct {
+		name    string
+		codes   string
+		session map[string]string
+		want    map[string]string
+	}{
+		{name: "no coupons", session: map[string]string{"original_amount_cents": "1000"}, want: map[string]string{}},
+		{name: "codes without amounts", codes: "HALF", session: map[string]string{}, want: map[string]string{"coupon_codes": "HALF"}},
+		{
+			name:    "amounts",
+			codes:   "HALF",
+			session: map[string]string{"original_amount_cents": "1000", "discount_amount_cents": "500"},
+			want:    map[string]string{"coupon_codes": "HALF", "original_amount": "10.00", "discounted_amount": "5.00"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := map[string]string{}
+			addCardCouponMetadata(got, tt.codes, tt.session, usd)
+			if len(got) != len(tt.want) {
+				t.Fatalf("metadata = %v, want %v", got, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("%s = %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package paywall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/CedrosPay/server/internal/coupons"
+	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+// ErrCouponWalletLimit indicates a wallet (or a Stripe customer's email) has used a coupon as
+// many times as its per-wallet limit allows.
+var ErrCouponWalletLimit = errors.New("paywall: coupon usage limit reached for this wallet")
+
+// ErrCouponEmailRequired indicates a card checkout applies a coupon with a per-wallet limit
+// without the customer email its uses are counted against.
+var ErrCouponEmailRequired = errors.New("paywall: customer email required for this coupon")
+
+// checkCouponLimit returns ErrCouponWalletLimit if redeemer, a wallet or a Stripe customer's
+// email, has used coupon as many times as its per-wallet limit allows.
+func (s *Service) checkCouponLimit(ctx context.Context, coupon coupons.Coupon, redeemer string) error {
+	limit := coupon.WalletLimit()
+	if limit == 0 || redeemer == "" {
+		return nil
+	}
+	used, err := s.store.CountCouponRedemptions(ctx, coupon.Code, redeemer)
+	if err != nil {
+		return fmt.Errorf("paywall: count coupon redemptions: %w", err)
+	}
+	if used >= limit {
+		return fmt.Errorf("%w: %s", ErrCouponWalletLimit, coupon.Code)
+	}
+	return nil
+}
+
+// checkManualCouponLimit checks a manually entered coupon's per-wallet limit at quote time.
+// Quotes requested without a wallet are not checked; the limit is enforced again once the
+// payment is verified and the paying wallet known.
+func (s *Service) checkManualCouponLimit(ctx context.Context, manualCoupon *coupons.Coupon, wallet string) error {
+	if manualCoupon == nil {
+		return nil
+	}
+	return s.checkCouponLimit(ctx, *manualCoupon, wallet)
+}
+
+// CheckCardCouponLimits checks the per-wallet limits of codes, the comma-separated coupons a
+// Stripe checkout applies, against the customer's email. A coupon with a limit requires an
+// email, failing with ErrCouponEmailRequired without one.
+func (s *Service) CheckCardCouponLimits(ctx context.Context, codes, email string) error {
+	for _, coupon := range s.cartCoupons(ctx, codes) {
+		if coupon.WalletLimit() == 0 {
+			continue
+		}
+		if coupons.EmailRedeemer(email) == "" {
+			return fmt.Errorf("%w: %s", ErrCouponEmailRequired, coupon.Code)
+		}
+		if err := s.checkCouponLimit(ctx, coupon, coupons.EmailRedeemer(email)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cartCoupons returns the coupons among codes, the comma-separated coupon_codes a cart was
+// priced with.
+func (s *Service) cartCoupons(ctx context.Context, codes string) []coupons.Coupon {
+	if codes == "" || s.coupons == nil {
+		return nil
+	}
+	var applied []coupons.Coupon
+	for _, code := range strings.Split(codes, ",") {
+		if coupon, err := s.coupons.GetCoupon(ctx, code); err == nil {
+			applied = append(applied, coupon)
+		}
+	}
+	return applied
+}
+
+// redeemCoupons records a verified payment's use of each applied coupon with a per-wallet
+// limit, failing with ErrCouponWalletLimit if wallet has already used one up to its limit.
+// Only manually entered coupons carry per-wallet limits, so at most one is recorded.
+func (s *Service) redeemCoupons(ctx context.Context, applied []coupons.Coupon, wallet, signature string, now time.Time) error {
+	for _, coupon := range applied {
+		limit := coupon.WalletLimit()
+		if limit == 0 {
+			continue
+		}
+		err := s.store.RecordCouponRedemption(ctx, storage.CouponRedemption{
+			Code:       coupon.Code,
+			Redeemer:   wallet,
+			Signature:  signature,
+			RedeemedAt: now,
+		}, limit)
+		if errors.Is(err, storage.ErrCouponRedemptionLimit) {
+			return fmt.Errorf("%w: %s", ErrCouponWalletLimit, coupon.Code)
+		}
+		if err != nil {
+			return fmt.Errorf("paywall: record coupon redemption: %w", err)
+		}
+	}
+	return nil
+}
+
+// recordCardRedemptions records a completed Stripe payment's use of each coupon among codes
+// with a per-wallet limit against the customer's email. The limit was checked when the session
+// was created and the payment has already been taken, so it is not enforced again.
+func (s *Service) recordCardRedemptions(ctx context.Context, codes, email, signature string, now time.Time) {
+	redeemer := coupons.EmailRedeemer(email)
+	if redeemer == "" {
+		return
+	}
+	for _, coupon := range s.cartCoupons(ctx, codes) {
+		if coupon.WalletLimit() == 0 {
+			continue
+		}
+		err := s.store.RecordCouponRedemption(ctx, storage.CouponRedemption{
+			Code:       coupon.Code,
+			Redeemer:   redeemer,
+			Signature:  signature,
+			RedeemedAt: now,
+		}, 0)
+		if err != nil {
+			log := logger.FromContext(ctx)
+			log.Warn().
+				Err(err).
+				Str("coupon_code", coupon.Code).
+				Msg("paywall.coupon_redemption_record_failed")
+		}
+	}
+}
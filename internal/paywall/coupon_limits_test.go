@@ -0,0 +1,122 @@
+package paywall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/coupons"
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/pkg/x402"
+)
+
+func walletLimitCoupons() coupons.Repository {
+	once := 1
+	return coupons.NewYAMLRepository(map[string]config.Coupon{
+		"ONCE": {Code: "ONCE", DiscountType: "percentage", DiscountValue: 50, Scope: "all", Active: true, UsageLimitPerWallet: &once},
+	})
+}
+
+func TestCouponWalletLimit(t *testing.T) {
+	ctx := context.Background()
+	cfg := testConfig()
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	svc := NewService(cfg, store, stubVerifier{}, &recordingNotifier{}, testRepository(cfg), walletLimitCoupons(), nil)
+
+	purchases := []struct {
+		name         string
+		wallet       string
+		wantQuoteErr error
+		wantErr      error
+	}{
+		{name: "first use", wallet: "wallet-a"},
+		{name: "second use by the same wallet", wallet: "wallet-a", wantQuoteErr: ErrCouponWalletLimit, wantErr: ErrCouponWalletLimit},
+		{name: "first use by another wallet", wallet: "wallet-b"},
+	}
+	for i, purchase := range purchases {
+		if _, err := svc.GenerateQuoteForWallet(ctx, "demo-content", "ONCE", purchase.wallet); !errors.Is(err, purchase.wantQuoteErr) {
+			t.Errorf("%s: GenerateQuoteForWallet error = %v, want %v", purchase.name, err, purchase.wantQuoteErr)
+		}
+		// Without a wallet the quote is issued and the limit left to verification
+		if _, err := svc.GenerateQuote(ctx, "demo-content", "ONCE"); err != nil {
+			t.Fatalf("%s: GenerateQuote error: %v", purchase.name, err)
+		}
+
+		svc.verifier = stubVerifier{result: x402.VerificationResult{Wallet: purchase.wallet}}
+		signature := fmt.Sprintf("sig-limit-%d", i)
+		_, err := svc.Authorize(ctx, "demo-content", "", cartPaymentHeader(t, cfg, signature), "ONCE")
+		if !errors.Is(err, purchase.wantErr) {
+			t.Fatalf("%s: Authorize error = %v, want %v", purchase.name, err, purchase.wantErr)
+		}
+		if payment, _ := store.GetPayment(ctx, signature); (payment.Metadata["status"] == "verified") != (purchase.wantErr == nil) {
+			t.Errorf("%s: payment status = %q, want verified %v", purchase.name, payment.Metadata["status"], purchase.wantErr == nil)
+		}
+	}
+
+	if used, _ := store.CountCouponRedemptions(ctx, "ONCE", "wallet-a"); used != 1 {
+		t.Errorf("wallet-a redemptions = %d, want 1", used)
+	}
+}
+
+func TestCouponWalletLimitCart(t *testing.T) {
+	ctx := context.Background()
+	cfg := testConfig()
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	svc := NewService(cfg, store, stubVerifier{result: x402.VerificationResult{Wallet: "wallet-a"}}, &recordingNotifier{}, testRepository(cfg), walletLimitCoupons(), nil)
+	items := []CartQuoteItem{{ResourceID: "demo-content", Quantity: 2}}
+
+	first, err := svc.GenerateCartQuote(ctx, CartQuoteRequest{Items: items, CouponCode: "ONCE", Wallet: "wallet-a"})
+	if err != nil {
+		t.Fatalf("GenerateCartQuote error: %v", err)
+	}
+	second, err := svc.GenerateCartQuote(ctx, CartQuoteRequest{Items: items, CouponCode: "ONCE"})
+	if err != nil {
+		t.Fatalf("GenerateCartQuote error: %v", err)
+	}
+	if _, err := svc.Authorize(ctx, first.CartID, "", cartPaymentHeader(t, cfg, "sig-cart-1"), ""); err != nil {
+		t.Fatalf("Authorize error: %v", err)
+	}
+
+	if _, err := svc.GenerateCartQuote(ctx, CartQuoteRequest{Items: items, CouponCode: "ONCE", Wallet: "wallet-a"}); !errors.Is(err, ErrCouponWalletLimit) {
+		t.Errorf("GenerateCartQuote error = %v, want ErrCouponWalletLimit", err)
+	}
+	if _, err := svc.Authorize(ctx, second.CartID, "", cartPaymentHeader(t, cfg, "sig-cart-2"), ""); !errors.Is(err, ErrCouponWalletLimit) {
+		t.Errorf("Authorize error = %v, want ErrCouponWalletLimit", err)
+	}
+	if cart, _ := svc.GetCartQuote(ctx, second.CartID); cart.WalletPaidBy != "" {
+		t.Errorf("cart paid by %q, want unpaid", cart.WalletPaidBy)
+	}
+}
+
+func TestCheckCardCouponLimits(t *testing.T) {
+	ctx := context.Background()
+	cfg := testConfig()
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	svc := NewService(cfg, store, stubVerifier{}, &recordingNotifier{}, testRepository(cfg), walletLimitCoupons(), nil)
+	svc.recordCardRedemptions(ctx, "ONCE", "Buyer@Example.com", "stripe:cs_1", time.Now())
+
+	tests := []struct {
+		name    string
+		codes   string
+		email   string
+		wantErr error
+	}{
+		{name: "no coupons", email: ""},
+		{name: "missing email", codes: "ONCE", wantErr: ErrCouponEmailRequired},
+		{name: "email already used", codes: "ONCE", email: " buyer@example.com", wantErr: ErrCouponWalletLimit},
+		{name: "new email", codes: "ONCE", email: "other@example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := svc.CheckCardCouponLimits(ctx, tt.codes, tt.email); !errors.Is(err, tt.wantErr) {
+				t.Errorf("CheckCardCouponLimits error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package paywall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/CedrosPay/server/internal/coupons"
+	"github.com/CedrosPay/server/internal/money"
+	"github.com/CedrosPay/server/internal/products"
+)
+
+// ErrInvalidCoupon indicates a coupon definition failed validation.
+var ErrInvalidCoupon = errors.New("paywall: invalid coupon")
+
+const maxCouponCodeLength = 64
+
+// CouponRequest defines a coupon managed through the admin API.
+type CouponRequest struct {
+	Code                string            `json:"code,omitempty"` // Required on create; taken from the path on update
+	DiscountType        string            `json:"discountType"`   // "percentage", "fixed", or "gift_card"
+	DiscountValue       float64           `json:"discountValue"`
+	Currency            string            `json:"currency,omitempty"`
+	Scope               string            `json:"scope,omitempty"` // Defaults to "all"
+	ProductIDs          []string          `json:"productIds,omitempty"`
+	PaymentMethod       string            `json:"paymentMethod,omitempty"` // "stripe", "x402", or empty for any
+	AutoApply           bool              `json:"autoApply,omitempty"`
+	AppliesAt           string            `json:"appliesAt,omitempty"` // "catalog" or "checkout"
+	UsageLimit          *int              `json:"usageLimit,omitempty"`
+	UsageLimitPerWallet *int              `json:"usageLimitPerWallet,omitempty"`
+	StartsAt            *time.Time        `json:"startsAt,omitempty"`
+	ExpiresAt           *time.Time        `json:"expiresAt,omitempty"`
+	Metadata            map[string]string `json:"metadata,omitempty"`
+}
+
+// CreateCoupon validates req and adds it as an active coupon. Returns coupons.ErrCouponExists
+// if the code is taken and coupons.ErrReadOnly for a YAML or disabled coupon source.
+func (s *Service) CreateCoupon(ctx context.Context, req CouponRequest) (coupons.Coupon, error) {
+	coupon, err := s.couponFromRequest(ctx, req.Code, req)
+	if err != nil {
+		return coupons.Coupon{}, err
+	}
+	if s.coupons == nil {
+		return coupons.Coupon{}, coupons.ErrReadOnly
+	}
+	if err := s.coupons.CreateCoupon(ctx, coupon); err != nil {
+		return coupons.Coupon{}, err
+	}
+	return s.coupons.GetCoupon(ctx, coupon.Code)
+}
+
+// UpdateCoupon replaces the definition of coupon code, keeping its usage count. Updating a
+// deactivated coupon reactivates it.
+func (s *Service) UpdateCoupon(ctx context.Context, code string, req CouponRequest) (coupons.Coupon, error) {
+	coupon, err := s.couponFromRequest(ctx, code, req)
+	if err != nil {
+		return coupons.Coupon{}, err
+	}
+	if s.coupons == nil {
+		return coupons.Coupon{}, coupons.ErrReadOnly
+	}
+	if err := s.coupons.UpdateCoupon(ctx, coupon); err != nil {
+		return coupons.Coupon{}, err
+	}
+	return s.coupons.GetCoupon(ctx, coupon.Code)
+}
+
+// DeactivateCoupon stops coupon code from being redeemed or auto-applied. The coupon and its
+// usage count are kept, so UpdateCoupon can reactivate it.
+func (s *Service) DeactivateCoupon(ctx context.Context, code string) error {
+	if s.coupons == nil {
+		return coupons.ErrReadOnly
+	}
+	return s.coupons.DeleteCoupon(ctx, code)
+}
+
+// couponFromRequest validates req and converts it into an active coupon.
+func (s *Service) couponFromRequest(ctx context.Context, code string, req CouponRequest) (coupons.Coupon, error) {
+	if code == "" || len(code) > maxCouponCodeLength || strings.ContainsAny(code, "/, \t\n") {
+		// Applied codes are joined with commas in cart and session metadata
+		return coupons.Coupon{}, fmt.Errorf("%w: code must be 1-%d characters without '/', ',' or spaces", ErrInvalidCoupon, maxCouponCodeLength)
+	}
+
+	coupon := coupons.Coupon{
+		Code:                code,
+		DiscountType:        coupons.DiscountType(req.DiscountType),
+		DiscountValue:       req.DiscountValue,
+		Currency:            strings.ToLower(req.Currency),
+		Scope:               coupons.Scope(req.Scope),
+		ProductIDs:          req.ProductIDs,
+		PaymentMethod:       coupons.PaymentMethod(req.PaymentMethod),
+		AutoApply:           req.AutoApply,
+		AppliesAt:           coupons.AppliesAt(req.AppliesAt),
+		UsageLimit:          req.UsageLimit,
+		UsageLimitPerWallet: req.UsageLimitPerWallet,
+		StartsAt:            req.StartsAt,
+		ExpiresAt:           req.ExpiresAt,
+		Metadata:            req.Metadata,
+		Active:              true,
+	}
+	if coupon.Scope == "" {
+		coupon.Scope = coupons.ScopeAll
+	}
+
+	switch coupon.DiscountType {
+	case coupons.DiscountTypePercentage:
+		if req.DiscountValue <= 0 || req.DiscountValue > 100 {
+			return coupons.Coupon{}, fmt.Errorf("%w: percentage discountValue must be above 0 and at most 100", ErrInvalidCoupon)
+		}
+	case coupons.DiscountTypeFixed, coupons.DiscountTypeGiftCard:
+		if req.DiscountValue <= 0 {
+			return coupons.Coupon{}, fmt.Errorf("%w: discountValue must be positive", ErrInvalidCoupon)
+		}
+	default:
+		return coupons.Coupon{}, fmt.Errorf("%w: discountType must be percentage, fixed, or gift_card", ErrInvalidCoupon)
+	}
+	if coupon.Currency != "" {
+		if _, err := money.GetAsset(strings.ToUpper(coupon.Currency)); err != nil {
+			return coupons.Coupon{}, fmt.Errorf("%w: unsupported currency %q", ErrInvalidCoupon, req.Currency)
+		}
+	}
+
+	if err := s.validateCouponScope(ctx, coupon); err != nil {
+		return coupons.Coupon{}, err
+	}
+	switch coupon.PaymentMethod {
+	case coupons.PaymentMethodAny, coupons.PaymentMethodStripe, coupons.PaymentMethodX402:
+	default:
+		return coupons.Coupon{}, fmt.Errorf("%w: paymentMethod must be stripe, x402, or empty", ErrInvalidCoupon)
+	}
+	switch coupon.AppliesAt {
+	case "", coupons.AppliesAtCatalog, coupons.AppliesAtCheckout:
+	default:
+		return coupons.Coupon{}, fmt.Errorf("%w: appliesAt must be catalog or checkout", ErrInvalidCoupon)
+	}
+	if coupon.UsageLimit != nil && *coupon.UsageLimit <= 0 {
+		return coupons.Coupon{}, fmt.Errorf("%w: usageLimit must be positive", ErrInvalidCoupon)
+	}
+
+	// The redemption window must be open at some point from now on
+	if coupon.StartsAt != nil && coupon.ExpiresAt != nil && !coupon.StartsAt.Before(*coupon.ExpiresAt) {
+		return coupons.Coupon{}, fmt.Errorf("%w: startsAt must be before expiresAt", ErrInvalidCoupon)
+	}
+	if coupon.ExpiresAt != nil && !coupon.ExpiresAt.After(time.Now()) {
+		return coupons.Coupon{}, fmt.Errorf("%w: expiresAt is in the past", ErrInvalidCoupon)
+	}
+
+	if err := coupon.ValidateConfiguration(); err != nil {
+		return coupons.Coupon{}, fmt.Errorf("%w: %v", ErrInvalidCoupon, err)
+	}
+	return coupon, nil
+}
+
+// validateCouponScope checks coupon lists products exactly when scoped to specific ones, and
+// that each is in the product catalog.
+func (s *Service) validateCouponScope(ctx context.Context, coupon coupons.Coupon) error {
+	switch coupon.Scope {
+	case coupons.ScopeAll:
+		if len(coupon.ProductIDs) > 0 {
+			return fmt.Errorf("%w: productIds require scope specific", ErrInvalidCoupon)
+		}
+		return nil
+	case coupons.ScopeSpecific:
+		if len(coupon.ProductIDs) == 0 {
+			return fmt.Errorf("%w: scope specific requires productIds", ErrInvalidCoupon)
+		}
+	default:
+		return fmt.Errorf("%w: scope must be all or specific", ErrInvalidCoupon)
+	}
+
+	for _, id := range coupon.ProductIDs {
+		_, err := s.repository.GetProduct(ctx, id)
+		if errors.Is(err, products.ErrProductNotFound) {
+			return fmt.Errorf("%w: product %q not found", ErrInvalidCoupon, id)
+		}
+		if err != nil {
+			return fmt.Errorf("paywall: look up coupon product %s: %w", id, err)
+		}
+	}
+	return nil
+}
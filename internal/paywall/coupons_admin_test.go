@@ -0,0 +1,207 @@
+package paywall
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/CedrosPay/server/internal/coupons"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+// couponStore is a writable in-memory coupon repository.
+type couponStore struct {
+	mu      sync.Mutex
+	coupons map[string]coupons.Coupon
+}
+
+func (r *couponStore) GetCoupon(_ context.Context, code string) (coupons.Coupon, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.coupons[code]
+	if !ok || !c.Active {
+		return coupons.Coupon{}, coupons.ErrCouponNotFound
+	}
+	return c, nil
+}
+
+func (r *couponStore) ListCoupons(_ context.Context) ([]coupons.Coupon, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var list []coupons.Coupon
+	for _, c := range r.coupons {
+		if c.Active {
+			list = append(list, c)
+		}
+	}
+	return list, nil
+}
+
+func (r *couponStore) GetAutoApplyCouponsForPayment(ctx context.Context, productID string, method coupons.PaymentMethod) ([]coupons.Coupon, error) {
+	list, _ := r.ListCoupons(ctx)
+	var matched []coupons.Coupon
+	for _, c := range list {
+		if c.AutoApply && c.AppliesToProduct(productID) && c.AppliesToPaymentMethod(method) {
+			matched = append(matched, c)
+		}
+	}
+	return matched, nil
+}
+
+func (r *couponStore) GetAllAutoApplyCouponsForPayment(_ context.Context, _ coupons.PaymentMethod) (map[string][]coupons.Coupon, error) {
+	return map[string][]coupons.Coupon{}, nil
+}
+
+func (r *couponStore) CreateCoupon(_ context.Context, c coupons.Coupon) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.coupons[c.Code]; ok {
+		return coupons.ErrCouponExists
+	}
+	c.CreatedAt, c.UpdatedAt = time.Now(), time.Now()
+	r.coupons[c.Code] = c
+	return nil
+}
+
+func (r *couponStore) UpdateCoupon(_ context.Context, c coupons.Coupon) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	existing, ok := r.coupons[c.Code]
+	if !ok {
+		return coupons.ErrCouponNotFound
+	}
+	c.UsageCount, c.CreatedAt, c.UpdatedAt = existing.UsageCount, existing.CreatedAt, time.Now()
+	r.coupons[c.Code] = c
+	return nil
+}
+
+func (r *couponStore) IncrementUsage(_ context.Context, code string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.coupons[code]
+	if !ok {
+		return coupons.ErrCouponNotFound
+	}
+	c.UsageCount++
+	r.coupons[code] = c
+	return nil
+}
+
+func (r *couponStore) DeleteCoupon(_ context.Context, code string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.coupons[code]
+	if !ok {
+		return coupons.ErrCouponNotFound
+	}
+	c.Active = false
+	r.coupons[code] = c
+	return nil
+}
+
+func (r *couponStore) Close() error { return nil }
+
+func TestCouponRequestValidation(t *testing.T) {
+	cfg := testConfig()
+	svc := NewService(cfg, storage.NewMemoryStore(), stubVerifier{}, nil, testRepository(cfg), nil, nil)
+	past, now, future := time.Now().Add(-time.Hour), time.Now(), time.Now().Add(time.Hour)
+	zero := 0
+
+	tests := []struct {
+		name    string
+		req     CouponRequest
+		wantErr bool
+	}{
+		{name: "percentage", req: CouponRequest{Code: "SAVE10", DiscountType: "percentage", DiscountValue: 10}},
+		{name: "specific products", req: CouponRequest{Code: "DEMO", DiscountType: "fixed", DiscountValue: 0.5, Currency: "USD", Scope: "specific", ProductIDs: []string{"demo-content"}}},
+		{name: "date window", req: CouponRequest{Code: "WEEK", DiscountType: "percentage", DiscountValue: 5, StartsAt: &past, ExpiresAt: &future}},
+		{name: "auto-apply at checkout", req: CouponRequest{Code: "SITE", DiscountType: "percentage", DiscountValue: 5, AutoApply: true, AppliesAt: "checkout"}},
+		{name: "missing code", req: CouponRequest{DiscountType: "percentage", DiscountValue: 10}, wantErr: true},
+		{name: "comma in code", req: CouponRequest{Code: "A,B", DiscountType: "percentage", DiscountValue: 10}, wantErr: true},
+		{name: "unknown discount type", req: CouponRequest{Code: "X", DiscountType: "bogus", DiscountValue: 10}, wantErr: true},
+		{name: "percentage over 100", req: CouponRequest{Code: "X", DiscountType: "percentage", DiscountValue: 150}, wantErr: true},
+		{name: "zero fixed amount", req: CouponRequest{Code: "X", DiscountType: "fixed"}, wantErr: true},
+		{name: "unknown currency", req: CouponRequest{Code: "X", DiscountType: "fixed", DiscountValue: 1, Currency: "doubloons"}, wantErr: true},
+		{name: "unknown scope", req: CouponRequest{Code: "X", DiscountType: "percentage", DiscountValue: 10, Scope: "some"}, wantErr: true},
+		{name: "specific without products", req: CouponRequest{Code: "X", DiscountType: "percentage", DiscountValue: 10, Scope: "specific"}, wantErr: true},
+		{name: "products with scope all", req: CouponRequest{Code: "X", DiscountType: "percentage", DiscountValue: 10, ProductIDs: []string{"demo-content"}}, wantErr: true},
+		{name: "unknown product", req: CouponRequest{Code: "X", DiscountType: "percentage", DiscountValue: 10, Scope: "specific", ProductIDs: []string{"missing"}}, wantErr: true},
+		{name: "unknown payment method", req: CouponRequest{Code: "X", DiscountType: "percentage", DiscountValue: 10, PaymentMethod: "cash"}, wantErr: true},
+		{name: "zero usage limit", req: CouponRequest{Code: "X", DiscountType: "percentage", DiscountValue: 10, UsageLimit: &zero}, wantErr: true},
+		{name: "window ends before it starts", req: CouponRequest{Code: "X", DiscountType: "percentage", DiscountValue: 10, StartsAt: &future, ExpiresAt: &now}, wantErr: true},
+		{name: "already expired", req: CouponRequest{Code: "X", DiscountType: "percentage", DiscountValue: 10, ExpiresAt: &past}, wantErr: true},
+		{name: "catalog coupon for all products", req: CouponRequest{Code: "X", DiscountType: "percentage", DiscountValue: 10, AutoApply: true, AppliesAt: "catalog"}, wantErr: true},
+		{name: "auto-apply gift card", req: CouponRequest{Code: "X", DiscountType: "gift_card", DiscountValue: 25, AutoApply: true, AppliesAt: "checkout"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			coupon, err := svc.couponFromRequest(context.Background(), tt.req.Code, tt.req)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCoupon) {
+					t.Fatalf("error = %v, want ErrInvalidCoupon", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !coupon.Active || coupon.Scope == "" {
+				t.Errorf("coupon = %+v, want active with a scope", coupon)
+			}
+		})
+	}
+}
+
+func TestCouponAdmin(t *testing.T) {
+	ctx := context.Background()
+	cfg := testConfig()
+	repo := coupons.NewCachedRepository(&couponStore{coupons: map[string]coupons.Coupon{}}, time.Hour)
+	svc := NewService(cfg, storage.NewMemoryStore(), stubVerifier{}, nil, testRepository(cfg), repo, nil)
+
+	// Prime the cache so the writes below must invalidate it
+	if _, err := repo.GetCoupon(ctx, "HALF"); !errors.Is(err, coupons.ErrCouponNotFound) {
+		t.Fatalf("GetCoupon error = %v, want ErrCouponNotFound", err)
+	}
+
+	if _, err := svc.CreateCoupon(ctx, CouponRequest{Code: "HALF", DiscountType: "percentage", DiscountValue: 50}); err != nil {
+		t.Fatalf("CreateCoupon error: %v", err)
+	}
+	if _, err := svc.CreateCoupon(ctx, CouponRequest{Code: "HALF", DiscountType: "percentage", DiscountValue: 10}); !errors.Is(err, coupons.ErrCouponExists) {
+		t.Errorf("duplicate CreateCoupon error = %v, want ErrCouponExists", err)
+	}
+	quote, err := svc.GenerateQuote(ctx, "demo-content", "HALF")
+	if err != nil || quote.Crypto.MaxAmountRequired != "500000" {
+		t.Fatalf("GenerateQuote = %+v, %v; want 500000", quote.Crypto, err)
+	}
+
+	if err := repo.IncrementUsage(ctx, "HALF"); err != nil {
+		t.Fatalf("IncrementUsage error: %v", err)
+	}
+	updated, err := svc.UpdateCoupon(ctx, "HALF", CouponRequest{DiscountType: "percentage", DiscountValue: 25})
+	if err != nil || updated.DiscountValue != 25 || updated.UsageCount != 1 {
+		t.Fatalf("UpdateCoupon = %+v, %v; want 25%% with 1 use", updated, err)
+	}
+	if _, err := svc.UpdateCoupon(ctx, "MISSING", CouponRequest{DiscountType: "percentage", DiscountValue: 5}); !errors.Is(err, coupons.ErrCouponNotFound) {
+		t.Errorf("UpdateCoupon on unknown coupon error = %v, want ErrCouponNotFound", err)
+	}
+
+	if err := svc.DeactivateCoupon(ctx, "HALF"); err != nil {
+		t.Fatalf("DeactivateCoupon error: %v", err)
+	}
+	if quote, _ := svc.GenerateQuote(ctx, "demo-content", "HALF"); quote.Crypto.MaxAmountRequired != "1000000" {
+		t.Errorf("deactivated coupon: quote = %s, want full price 1000000", quote.Crypto.MaxAmountRequired)
+	}
+	if _, err := svc.UpdateCoupon(ctx, "HALF", CouponRequest{DiscountType: "percentage", DiscountValue: 25}); err != nil {
+		t.Fatalf("reactivating coupon: %v", err)
+	}
+	if quote, err := svc.GenerateQuote(ctx, "demo-content", "HALF"); err != nil || quote.Crypto.MaxAmountRequired != "750000" {
+		t.Errorf("reactivated coupon: GenerateQuote = %+v, %v; want 750000", quote.Crypto, err)
+	}
+
+	readOnly := NewService(cfg, storage.NewMemoryStore(), stubVerifier{}, nil, testRepository(cfg), coupons.NewYAMLRepository(nil), nil)
+	if _, err := readOnly.CreateCoupon(ctx, CouponRequest{Code: "HALF", DiscountType: "percentage", DiscountValue: 50}); !errors.Is(err, coupons.ErrReadOnly) {
+		t.Errorf("YAML CreateCoupon error = %v, want ErrReadOnly", err)
+	}
+}
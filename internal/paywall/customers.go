@@ -0,0 +1,171 @@
+package paywall
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/money"
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/internal/subscriptions"
+)
+
+// recentCustomerPayments is how many payments a customer profile lists.
+const recentCustomerPayments = 50
+
+// CustomerProfile is one customer's identities across payment rails, what they have paid for,
+// and their recent payments.
+type CustomerProfile struct {
+	ID                string                       `json:"id"`
+	Emails            []string                     `json:"emails"`
+	Wallets           []string                     `json:"wallets"`
+	StripeCustomerIDs []string                     `json:"stripeCustomerIds"`
+	Entitlements      []string                     `json:"entitlements"`  // Resources bought (bundles expanded) or subscribed to, sorted
+	Payments          []CustomerPayment            `json:"payments"`      // Most recent first
+	Subscriptions     []subscriptions.Subscription `json:"subscriptions"` // Set when subscriptions are enabled
+	CreatedAt         time.Time                    `json:"createdAt"`
+	UpdatedAt         time.Time                    `json:"updatedAt"`
+}
+
+// CustomerPayment is a payment in a customer profile.
+type CustomerPayment struct {
+	Signature  string      `json:"signature"` // Transaction signature, or stripe:<id> for card payments
+	ResourceID string      `json:"resourceId"`
+	Method     string      `json:"method"` // stripe or x402
+	Payer      string      `json:"payer"`  // Wallet, or email for card payments
+	Amount     money.Money `json:"amount"`
+	PaidAt     time.Time   `json:"paidAt"`
+}
+
+// LinkCustomer links identities to one customer, merging the customers they already belong
+// to, and returns the merged customer's profile. Merchants use it to connect a wallet to the
+// email a customer pays by card with.
+func (s *Service) LinkCustomer(ctx context.Context, identities []storage.CustomerIdentity) (CustomerProfile, error) {
+	customer, err := s.store.LinkCustomerIdentities(ctx, identities)
+	if err != nil {
+		return CustomerProfile{}, err
+	}
+	return s.customerProfile(ctx, customer)
+}
+
+// CustomerProfile returns a customer's profile by ID (storage.ErrNotFound if unknown).
+func (s *Service) CustomerProfile(ctx context.Context, id string) (CustomerProfile, error) {
+	customer, err := s.store.GetCustomer(ctx, id)
+	if err != nil {
+		return CustomerProfile{}, err
+	}
+	return s.customerProfile(ctx, customer)
+}
+
+// FindCustomerProfile returns the profile of the customer an email, wallet, or Stripe customer
+// ID is linked to (storage.ErrNotFound if it is unlinked).
+func (s *Service) FindCustomerProfile(ctx context.Context, kind, value string) (CustomerProfile, error) {
+	customer, err := s.store.FindCustomer(ctx, kind, value)
+	if err != nil {
+		return CustomerProfile{}, err
+	}
+	return s.customerProfile(ctx, customer)
+}
+
+// customerProfile gathers the payments made with any of customer's emails or wallets.
+func (s *Service) customerProfile(ctx context.Context, customer storage.Customer) (CustomerProfile, error) {
+	profile := CustomerProfile{
+		ID:                customer.ID,
+		Emails:            nonNilStrings(customer.Values(storage.IdentityEmail)),
+		Wallets:           nonNilStrings(customer.Values(storage.IdentityWallet)),
+		StripeCustomerIDs: nonNilStrings(customer.Values(storage.IdentityStripeCustomer)),
+		Entitlements:      []string{},
+		Payments:          []CustomerPayment{},
+		Subscriptions:     []subscriptions.Subscription{},
+		CreatedAt:         customer.CreatedAt,
+		UpdatedAt:         customer.UpdatedAt,
+	}
+
+	payers := append(append([]string(nil), profile.Emails...), profile.Wallets...)
+	payments, err := s.store.ListPaymentsByPayer(ctx, payers, 0)
+	if err != nil {
+		return CustomerProfile{}, fmt.Errorf("paywall: list customer payments: %w", err)
+	}
+	for _, tx := range payments {
+		if IsFacilitatedPayment(tx) {
+			continue
+		}
+		switch tx.Metadata["type"] {
+		case "refund":
+			continue
+		case "cart":
+			// Carts grant no per-resource access; they are listed with the payments
+		default:
+			profile.addEntitlements(tx.ResourceID)
+			if items := tx.Metadata[BundleItemsKey]; items != "" {
+				profile.addEntitlements(strings.Split(items, ",")...)
+			}
+		}
+		if len(profile.Payments) < recentCustomerPayments {
+			method := "x402"
+			if strings.HasPrefix(tx.Signature, "stripe:") {
+				method = "stripe"
+			}
+			profile.Payments = append(profile.Payments, CustomerPayment{
+				Signature:  tx.Signature,
+				ResourceID: tx.ResourceID,
+				Method:     method,
+				Payer:      tx.Wallet,
+				Amount:     tx.Amount,
+				PaidAt:     tx.CreatedAt,
+			})
+		}
+	}
+	return profile, nil
+}
+
+// AddSubscriptions adds a customer's subscriptions to the profile; the products of those still
+// active count as entitlements.
+func (p *CustomerProfile) AddSubscriptions(subs []subscriptions.Subscription) {
+	for _, sub := range subs {
+		if slices.ContainsFunc(p.Subscriptions, func(existing subscriptions.Subscription) bool { return existing.ID == sub.ID }) {
+			continue
+		}
+		p.Subscriptions = append(p.Subscriptions, sub)
+		if sub.IsActive() {
+			p.addEntitlements(sub.ProductID)
+		}
+	}
+}
+
+// addEntitlements adds resource IDs to the profile's sorted entitlements.
+func (p *CustomerProfile) addEntitlements(resourceIDs ...string) {
+	for _, id := range resourceIDs {
+		if id == "" {
+			continue
+		}
+		if i, found := slices.BinarySearch(p.Entitlements, id); !found {
+			p.Entitlements = slices.Insert(p.Entitlements, i, id)
+		}
+	}
+}
+
+// linkPayer links the identities a payment was made with to one customer. Failures are
+// logged: the payment already succeeded.
+func (s *Service) linkPayer(ctx context.Context, identities []storage.CustomerIdentity) {
+	if len(identities) == 0 {
+		return
+	}
+	if _, err := s.store.LinkCustomerIdentities(ctx, identities); err != nil {
+		log := logger.FromContext(ctx)
+		log.Warn().
+			Err(err).
+			Msg("paywall.customer_link_failed")
+	}
+}
+
+// nonNilStrings returns values, or an empty slice if it is nil, so it encodes as [].
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
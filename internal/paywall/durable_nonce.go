@@ -0,0 +1,64 @@
+package paywall
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+
+	x402solana "github.com/CedrosPay/server/pkg/x402/solana"
+)
+
+// durableTransferVerifier is implemented by verifiers that can build durable nonce transfers.
+type durableTransferVerifier interface {
+	BuildDurableTransfer(ctx context.Context, req x402solana.DurableTransferRequest) (x402solana.DurableTransferResponse, error)
+}
+
+// BuildRefundNonceTransaction builds the refund transfer against the configured durable nonce
+// account. Unlike a regular quote it does not go stale with the blockhash, so the admin can
+// sign it offline and submit it as the refund's x402 payment any time before the quote expires.
+func (s *Service) BuildRefundNonceTransaction(ctx context.Context, refundID string) (*x402solana.DurableTransferResponse, error) {
+	nonceAccount, err := solana.PublicKeyFromBase58(s.cfg.X402.RefundNonceAccount)
+	if err != nil {
+		return nil, fmt.Errorf("paywall: invalid refund nonce account: %w", err)
+	}
+	verifier, ok := s.verifier.(durableTransferVerifier)
+	if !ok {
+		return nil, fmt.Errorf("paywall: verifier does not support durable nonce transactions")
+	}
+	authority, err := solana.PublicKeyFromBase58(s.cfg.X402.PaymentAddress)
+	if err != nil {
+		return nil, fmt.Errorf("paywall: invalid payment address: %w", err)
+	}
+
+	refund, err := s.store.GetRefundQuote(ctx, refundID)
+	if err != nil {
+		return nil, fmt.Errorf("paywall: get refund: %w", err)
+	}
+	mint, err := solana.PublicKeyFromBase58(refund.Amount.Asset.Metadata.SolanaMint)
+	if err != nil {
+		return nil, fmt.Errorf("paywall: invalid token mint for %s: %w", refund.Amount.Asset.Code, err)
+	}
+	recipient, err := solana.PublicKeyFromBase58(refund.RecipientWallet)
+	if err != nil {
+		return nil, fmt.Errorf("paywall: invalid recipient wallet: %w", err)
+	}
+	recipientTokenAccount, _, err := solana.FindAssociatedTokenAddress(recipient, mint)
+	if err != nil {
+		return nil, fmt.Errorf("paywall: derive recipient token account: %w", err)
+	}
+
+	tx, err := verifier.BuildDurableTransfer(ctx, x402solana.DurableTransferRequest{
+		NonceAccount:          nonceAccount,
+		Authority:             authority,
+		RecipientTokenAccount: recipientTokenAccount,
+		TokenMint:             mint,
+		Amount:                uint64(refund.Amount.Atomic),
+		Decimals:              refund.Amount.Asset.Decimals,
+		Memo:                  fmt.Sprintf("%s:refund:%s", s.cfg.X402.MemoPrefix, refundID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("paywall: build durable refund transaction: %w", err)
+	}
+	return &tx, nil
+}
@@ -0,0 +1,230 @@
+package paywall
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	apierrors "github.com/CedrosPay/server/internal/errors"
+	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/money"
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/pkg/x402"
+)
+
+// FacilitatedResourcePrefix namespaces the resource ID of payments settled for other resource
+// servers. Those records only stop the same payment being settled twice: they grant no access,
+// cannot be refunded, and are not payments to this server.
+const FacilitatedResourcePrefix = "facilitator:"
+
+// IsFacilitatedPayment reports whether a payment was settled for another resource server.
+func IsFacilitatedPayment(payment storage.PaymentTransaction) bool {
+	return strings.HasPrefix(payment.ResourceID, FacilitatedResourcePrefix)
+}
+
+// FacilitatorRequest is the body of the facilitator verify and settle calls: a payment and the
+// requirements the resource server quoted for it. The payment is either the X-PAYMENT header
+// value or its decoded payload.
+type FacilitatorRequest struct {
+	X402Version         int             `json:"x402Version"`
+	PaymentHeader       string          `json:"paymentHeader,omitempty"`
+	PaymentPayload      json.RawMessage `json:"paymentPayload,omitempty"`
+	PaymentRequirements CryptoQuote     `json:"paymentRequirements"`
+}
+
+// FacilitatorVerifyResponse reports whether a payment meets its requirements.
+type FacilitatorVerifyResponse struct {
+	IsValid       bool   `json:"isValid"`
+	InvalidReason string `json:"invalidReason,omitempty"`
+	Payer         string `json:"payer,omitempty"`
+}
+
+// FacilitatorSettleResponse reports the outcome of submitting a payment.
+type FacilitatorSettleResponse struct {
+	Success     bool   `json:"success"`
+	ErrorReason string `json:"errorReason,omitempty"`
+	Transaction string `json:"transaction"`
+	Network     string `json:"network"`
+	Payer       string `json:"payer,omitempty"`
+}
+
+// FacilitatorKind is a scheme and network pair the facilitator settles.
+type FacilitatorKind struct {
+	X402Version int    `json:"x402Version"`
+	Scheme      string `json:"scheme"`
+	Network     string `json:"network"`
+}
+
+// facilitatorError is a payment that was rejected before reaching the verifier.
+type facilitatorError struct {
+	code apierrors.ErrorCode
+	err  error
+}
+
+func (e facilitatorError) Error() string { return e.err.Error() }
+
+// FacilitatorSupported lists the kinds of payment the facilitator verifies and settles.
+func (s *Service) FacilitatorSupported() []FacilitatorKind {
+	network := x402.V1Network(s.cfg.X402.Network)
+	return []FacilitatorKind{
+		{X402Version: x402.Version1, Scheme: x402.SchemeExact, Network: network},
+		{X402Version: 0, Scheme: x402.SchemeSolanaSPLTransfer, Network: s.cfg.X402.Network},
+	}
+}
+
+// FacilitatorVerify checks a payment against the requirements another resource server quoted,
+// simulating the transaction without submitting it.
+func (s *Service) FacilitatorVerify(ctx context.Context, req FacilitatorRequest) FacilitatorVerifyResponse {
+	proof, requirement, err := s.facilitatorRequirement(req)
+	if err != nil {
+		return FacilitatorVerifyResponse{InvalidReason: facilitatorReason(err)}
+	}
+	if proof.Signature != "" {
+		if _, err := s.store.GetPayment(ctx, proof.Signature); err == nil {
+			return FacilitatorVerifyResponse{InvalidReason: string(apierrors.ErrCodePaymentAlreadyUsed)}
+		}
+	}
+	requirement.VerifyOnly = true
+	result, err := s.verifier.Verify(ctx, proof, requirement)
+	if err != nil {
+		return FacilitatorVerifyResponse{InvalidReason: facilitatorReason(err), Payer: result.Wallet}
+	}
+	return FacilitatorVerifyResponse{IsValid: true, Payer: result.Wallet}
+}
+
+// FacilitatorSettle submits a payment for another resource server and waits for confirmation.
+// The signature is recorded under a facilitator: resource ID with a zero amount (the settled amount
+// is kept in metadata) so the same payment cannot be settled twice, without the record counting as
+// a payment to this server (see IsFacilitatedPayment). A payment whose asset or
+// amount cannot be recorded returns an error (see FacilitatorErrorCode) alongside the response.
+func (s *Service) FacilitatorSettle(ctx context.Context, req FacilitatorRequest) (FacilitatorSettleResponse, error) {
+	response := FacilitatorSettleResponse{Network: req.PaymentRequirements.Network}
+	proof, requirement, err := s.facilitatorRequirement(req)
+	if err != nil {
+		response.ErrorReason = facilitatorReason(err)
+		return response, nil
+	}
+	asset, ok := money.AssetByMint(requirement.TokenMint)
+	if !ok {
+		return response, facilitatorError{code: apierrors.ErrCodeInvalidTokenMint, err: fmt.Errorf("asset %q is not a registered token", requirement.TokenMint)}
+	}
+
+	result, err := s.verifier.Verify(ctx, proof, requirement)
+	if err != nil {
+		response.ErrorReason = facilitatorReason(err)
+		return response, nil
+	}
+	response.Success = true
+	response.Transaction = result.Signature
+	response.Payer = result.Wallet
+
+	amount, err := money.FromMajor(asset, strconv.FormatFloat(result.Amount, 'f', int(asset.Decimals), 64))
+	if err != nil {
+		return response, facilitatorError{code: apierrors.ErrCodeInvalidAmount, err: fmt.Errorf("settled amount %v: %w", result.Amount, err)}
+	}
+	payment := storage.PaymentTransaction{
+		Signature:  result.Signature,
+		ResourceID: FacilitatedResourcePrefix + requirement.ResourceID,
+		Wallet:     result.Wallet,
+		Amount:     money.Zero(asset),
+		CreatedAt:  time.Now(),
+		Metadata: map[string]string{
+			"status":         "facilitated",
+			"network":        s.cfg.X402.Network,
+			"pay_to":         requirement.RecipientOwner,
+			"settled_amount": amount.ToMajor(),
+		},
+	}
+	if err := s.store.RecordPayment(ctx, payment); err != nil {
+		log := logger.FromContext(ctx)
+		log.Warn().
+			Err(err).
+			Str("signature", logger.TruncateAddress(result.Signature)).
+			Msg("facilitator.settle_replay_detected")
+		return FacilitatorSettleResponse{
+			ErrorReason: string(apierrors.ErrCodePaymentAlreadyUsed),
+			Transaction: result.Signature,
+			Network:     response.Network,
+			Payer:       result.Wallet,
+		}, nil
+	}
+	return response, nil
+}
+
+// FacilitatorErrorCode is the error code of an error returned by FacilitatorSettle.
+func FacilitatorErrorCode(err error) apierrors.ErrorCode {
+	return apierrors.ErrorCode(facilitatorReason(err))
+}
+
+// facilitatorRequirement parses the payment and turns the quoted requirements into a verifier
+// requirement on this server's network.
+func (s *Service) facilitatorRequirement(req FacilitatorRequest) (x402.PaymentProof, x402.Requirement, error) {
+	header := req.PaymentHeader
+	if header == "" {
+		header = string(req.PaymentPayload)
+	}
+	proof, err := x402.ParsePaymentProof(header)
+	if err != nil {
+		return proof, x402.Requirement{}, facilitatorError{code: apierrors.ErrCodeInvalidPaymentProof, err: err}
+	}
+
+	quote := req.PaymentRequirements
+	if !x402.SupportedScheme(quote.Scheme) || !x402.SupportedScheme(proof.Scheme) {
+		return proof, x402.Requirement{}, facilitatorError{code: apierrors.ErrCodeInvalidPaymentProof, err: fmt.Errorf("unsupported scheme %q", quote.Scheme)}
+	}
+	if cluster := x402.ClusterForNetwork(quote.Network); cluster != s.cfg.X402.Network || (proof.Network != "" && proof.Network != cluster) {
+		return proof, x402.Requirement{}, facilitatorError{code: apierrors.ErrCodeInvalidField, err: fmt.Errorf("network %q is not served here", quote.Network)}
+	}
+	if quote.PayTo == "" {
+		return proof, x402.Requirement{}, facilitatorError{code: apierrors.ErrCodeInvalidRecipient, err: errors.New("payTo is required")}
+	}
+	asset, ok := money.AssetByMint(quote.Asset)
+	if !ok {
+		return proof, x402.Requirement{}, facilitatorError{code: apierrors.ErrCodeInvalidTokenMint, err: fmt.Errorf("asset %q is not a registered token", quote.Asset)}
+	}
+	atomic, err := strconv.ParseInt(quote.MaxAmountRequired, 10, 64)
+	if err != nil || atomic <= 0 {
+		return proof, x402.Requirement{}, facilitatorError{code: apierrors.ErrCodeInvalidAmount, err: fmt.Errorf("invalid maxAmountRequired %q", quote.MaxAmountRequired)}
+	}
+	amount, _ := strconv.ParseFloat(money.New(asset, atomic).ToMajor(), 64)
+
+	var recipientTokenAccount string
+	if extra, ok := quote.Extra.(map[string]any); ok {
+		recipientTokenAccount, _ = extra["recipientTokenAccount"].(string)
+	}
+	if recipientTokenAccount == "" {
+		recipientTokenAccount = deriveTokenAccountSafe(quote.PayTo, quote.Asset)
+	}
+
+	return proof, x402.Requirement{
+		ResourceID:            quote.Resource,
+		RecipientOwner:        quote.PayTo,
+		RecipientTokenAccount: recipientTokenAccount,
+		TokenMint:             quote.Asset,
+		Amount:                amount,
+		Network:               s.cfg.X402.Network,
+		TokenDecimals:         asset.Decimals,
+		QuoteTTL:              time.Duration(quote.MaxTimeoutSeconds) * time.Second,
+		SkipPreflight:         s.cfg.X402.SkipPreflight,
+		SimulateTransaction:   s.cfg.X402.SimulateTransactions,
+		Commitment:            s.cfg.X402.Commitment,
+		Unsponsored:           quote.PayTo != s.cfg.X402.PaymentAddress, // Server wallets only fund payments to this server
+	}, nil
+}
+
+// facilitatorReason is the machine-readable reason a payment was rejected.
+func facilitatorReason(err error) string {
+	var fErr facilitatorError
+	if errors.As(err, &fErr) {
+		return string(fErr.code)
+	}
+	var vErr x402.VerificationError
+	if errors.As(err, &vErr) {
+		return string(vErr.Code)
+	}
+	return string(apierrors.ErrCodeTransactionFailed)
+}
@@ -0,0 +1,177 @@
+package paywall
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/CedrosPay/server/internal/callbacks"
+	apierrors "github.com/CedrosPay/server/internal/errors"
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/pkg/x402"
+)
+
+func TestFacilitatorSettle(t *testing.T) {
+	const usdcMint = "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"
+	payment := func(signature string) string {
+		payload, err := json.Marshal(map[string]any{
+			"x402Version": 1,
+			"scheme":      "exact",
+			"network":     "solana",
+			"payload":     map[string]string{"signature": signature, "transaction": base64.StdEncoding.EncodeToString([]byte("signed-tx"))},
+		})
+		if err != nil {
+			t.Fatalf("marshal payment payload: %v", err)
+		}
+		return base64.StdEncoding.EncodeToString(payload)
+	}
+	requirements := func(network, asset string) CryptoQuote {
+		return CryptoQuote{
+			Scheme:            x402.SchemeExact,
+			Network:           network,
+			MaxAmountRequired: "1500000",
+			Resource:          "https://api.example.com/report",
+			PayTo:             "11111111111111111111111111111111",
+			MaxTimeoutSeconds: 60,
+			Asset:             asset,
+		}
+	}
+
+	cfg := testConfig()
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	svc := NewService(cfg, store, stubVerifier{result: x402.VerificationResult{Wallet: "payer-wallet", Amount: 1.5}}, callbacks.NoopNotifier{}, testRepository(cfg), nil, nil)
+	failing := NewService(cfg, store, stubVerifier{err: x402.NewVerificationError(apierrors.ErrCodeAmountBelowMinimum, errors.New("short"))}, callbacks.NoopNotifier{}, testRepository(cfg), nil, nil)
+
+	tests := []struct {
+		name       string
+		svc        *Service
+		req        FacilitatorRequest
+		wantReason string
+	}{
+		{
+			name: "settles",
+			svc:  svc,
+			req:  FacilitatorRequest{X402Version: 1, PaymentHeader: payment("sig-1"), PaymentRequirements: requirements("solana", usdcMint)},
+		},
+		{
+			name:       "same payment again",
+			svc:        svc,
+			req:        FacilitatorRequest{X402Version: 1, PaymentHeader: payment("sig-1"), PaymentRequirements: requirements("solana", usdcMint)},
+			wantReason: string(apierrors.ErrCodePaymentAlreadyUsed),
+		},
+		{
+			name:       "other network",
+			svc:        svc,
+			req:        FacilitatorRequest{X402Version: 1, PaymentHeader: payment("sig-2"), PaymentRequirements: requirements("solana-devnet", usdcMint)},
+			wantReason: string(apierrors.ErrCodeInvalidField),
+		},
+		{
+			name:       "unregistered asset",
+			svc:        svc,
+			req:        FacilitatorRequest{X402Version: 1, PaymentHeader: payment("sig-3"), PaymentRequirements: requirements("solana", "11111111111111111111111111111112")},
+			wantReason: string(apierrors.ErrCodeInvalidTokenMint),
+		},
+		{
+			name:       "verification fails",
+			svc:        failing,
+			req:        FacilitatorRequest{X402Version: 1, PaymentHeader: payment("sig-4"), PaymentRequirements: requirements("solana", usdcMint)},
+			wantReason: string(apierrors.ErrCodeAmountBelowMinimum),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := tt.svc.FacilitatorSettle(context.Background(), tt.req)
+			if err != nil {
+				t.Fatalf("FacilitatorSettle: %v", err)
+			}
+			if resp.ErrorReason != tt.wantReason {
+				t.Fatalf("errorReason = %q, want %q", resp.ErrorReason, tt.wantReason)
+			}
+			if tt.wantReason != "" {
+				if resp.Success {
+					t.Fatal("success = true for a rejected payment")
+				}
+				return
+			}
+			if !resp.Success || resp.Transaction != "sig-1" || resp.Payer != "payer-wallet" || resp.Network != "solana" {
+				t.Fatalf("response = %+v, want settled sig-1 on solana", resp)
+			}
+			recorded, err := store.GetPayment(context.Background(), "sig-1")
+			if err != nil {
+				t.Fatalf("GetPayment: %v", err)
+			}
+			if recorded.ResourceID != FacilitatedResourcePrefix+"https://api.example.com/report" || !recorded.Amount.IsZero() ||
+				recorded.Metadata["settled_amount"] != "1.500000" || recorded.Metadata["status"] != "facilitated" {
+				t.Errorf("recorded payment = %+v, want a zero-amount facilitator record of 1.5 settled", recorded)
+			}
+		})
+	}
+
+	// Verifying a settled payment reports it as used instead of simulating it again
+	if resp := svc.FacilitatorVerify(context.Background(), tests[0].req); resp.IsValid || resp.InvalidReason != string(apierrors.ErrCodePaymentAlreadyUsed) {
+		t.Errorf("verify settled payment = %+v, want payment_already_used", resp)
+	}
+
+	// Server wallets only pay fees and create token accounts for payments to this server
+	for _, payTo := range []string{requirements("solana", usdcMint).PayTo, cfg.X402.PaymentAddress} {
+		var got x402.Requirement
+		recorder := NewService(cfg, store, requirementRecorder{got: &got}, callbacks.NoopNotifier{}, testRepository(cfg), nil, nil)
+		req := FacilitatorRequest{X402Version: 1, PaymentHeader: payment("sig-" + payTo), PaymentRequirements: requirements("solana", usdcMint)}
+		req.PaymentRequirements.PayTo = payTo
+		recorder.FacilitatorVerify(context.Background(), req)
+		if want := payTo != cfg.X402.PaymentAddress; got.Unsponsored != want {
+			t.Errorf("payTo %s: unsponsored = %v, want %v", payTo, got.Unsponsored, want)
+		}
+	}
+}
+
+func TestFacilitatorSettle_GrantsNoAccess(t *testing.T) {
+	cfg := testConfig()
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	svc := NewService(cfg, store, stubVerifier{result: x402.VerificationResult{Signature: "sig-foreign", Wallet: "payer-wallet", Amount: 1.5}}, callbacks.NoopNotifier{}, testRepository(cfg), nil, nil)
+
+	// A payment to someone else's wallet, quoted for a resource this server sells
+	payload, err := json.Marshal(map[string]any{
+		"x402Version": 1,
+		"scheme":      "exact",
+		"network":     "solana",
+		"payload":     map[string]string{"signature": "sig-foreign", "transaction": base64.StdEncoding.EncodeToString([]byte("signed-tx"))},
+	})
+	if err != nil {
+		t.Fatalf("marshal payment payload: %v", err)
+	}
+	resp, err := svc.FacilitatorSettle(context.Background(), FacilitatorRequest{
+		X402Version:   1,
+		PaymentHeader: base64.StdEncoding.EncodeToString(payload),
+		PaymentRequirements: CryptoQuote{
+			Scheme:            x402.SchemeExact,
+			Network:           "solana",
+			MaxAmountRequired: "1500000",
+			Resource:          "demo-content",
+			PayTo:             "11111111111111111111111111111111",
+			MaxTimeoutSeconds: 60,
+			Asset:             "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v",
+		},
+	})
+	if err != nil || !resp.Success {
+		t.Fatalf("FacilitatorSettle = %+v, %v; want settled", resp, err)
+	}
+
+	recorded, err := store.GetPayment(context.Background(), "sig-foreign")
+	if err != nil {
+		t.Fatalf("store.GetPayment: %v", err)
+	}
+	if PaymentGrantsAccess(recorded, "demo-content") {
+		t.Error("facilitated payment grants access to demo-content")
+	}
+	if _, err := svc.GetPayment(context.Background(), "sig-foreign"); err == nil {
+		t.Error("GetPayment found a facilitated payment, so it could be verified or refunded")
+	}
+	if processed, err := svc.HasPaymentBeenProcessed(context.Background(), "sig-foreign"); err != nil || processed {
+		t.Errorf("HasPaymentBeenProcessed = %v, %v; want false", processed, err)
+	}
+}
@@ -0,0 +1,118 @@
+package paywall
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/CedrosPay/server/internal/config"
+)
+
+// ErrUnsupportedCurrency indicates a checkout asked for a fiat currency the resource has no price in.
+var ErrUnsupportedCurrency = errors.New("paywall: currency not offered")
+
+// regionCurrencies maps ISO 3166 region subtags of Accept-Language locales to currencies.
+var regionCurrencies = map[string]string{
+	"US": "usd", "GB": "gbp", "CA": "cad", "AU": "aud", "NZ": "nzd", "JP": "jpy", "CH": "chf",
+	"IN": "inr", "BR": "brl", "MX": "mxn", "SG": "sgd", "HK": "hkd", "SE": "sek", "NO": "nok",
+	"DK": "dkk", "PL": "pln",
+	// Euro area
+	"AT": "eur", "BE": "eur", "CY": "eur", "DE": "eur", "EE": "eur", "ES": "eur", "FI": "eur",
+	"FR": "eur", "GR": "eur", "HR": "eur", "IE": "eur", "IT": "eur", "LT": "eur", "LU": "eur",
+	"LV": "eur", "MT": "eur", "NL": "eur", "PT": "eur", "SI": "eur", "SK": "eur",
+}
+
+// languageCurrencies maps languages spoken mainly inside one currency area, for locales
+// without a region subtag.
+var languageCurrencies = map[string]string{
+	"ja": "jpy", "de": "eur", "fr": "eur", "it": "eur", "nl": "eur", "fi": "eur", "el": "eur",
+	"et": "eur", "lv": "eur", "lt": "eur", "sk": "eur", "sl": "eur",
+}
+
+// PreferredCurrencies lists the fiat currencies an Accept-Language header implies, most
+// preferred first.
+func PreferredCurrencies(acceptLanguage string) []string {
+	type locale struct {
+		tag string
+		q   float64
+	}
+	var locales []locale
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		if tag != "" && tag != "*" && q > 0 {
+			locales = append(locales, locale{tag: tag, q: q})
+		}
+	}
+	sort.SliceStable(locales, func(i, j int) bool { return locales[i].q > locales[j].q })
+
+	var currencies []string
+	seen := make(map[string]bool)
+	for _, l := range locales {
+		subtags := strings.FieldsFunc(l.tag, func(r rune) bool { return r == '-' || r == '_' })
+		if len(subtags) == 0 {
+			continue
+		}
+		currency := languageCurrencies[strings.ToLower(subtags[0])]
+		for _, subtag := range subtags[1:] {
+			if c, ok := regionCurrencies[strings.ToUpper(subtag)]; ok {
+				currency = c
+				break
+			}
+		}
+		if currency != "" && !seen[currency] {
+			currencies = append(currencies, currency)
+			seen[currency] = true
+		}
+	}
+	return currencies
+}
+
+// OffersCurrency reports whether resource can be paid by card in currency.
+func OffersCurrency(resource config.PaywallResource, currency string) bool {
+	currency = strings.ToLower(currency)
+	if strings.EqualFold(resource.FiatCurrency, currency) {
+		return resource.FiatAmountCents > 0 || resource.StripePriceID != ""
+	}
+	_, ok := resource.FiatPrices[currency]
+	return ok
+}
+
+// PriceInCurrency returns resource with its fiat price, currency, and Stripe price set to its
+// price in currency.
+func PriceInCurrency(resource config.PaywallResource, currency string) (config.PaywallResource, error) {
+	currency = strings.ToLower(currency)
+	if strings.EqualFold(resource.FiatCurrency, currency) {
+		return resource, nil
+	}
+	price, ok := resource.FiatPrices[currency]
+	if !ok {
+		return config.PaywallResource{}, fmt.Errorf("%w: %s has no %s price", ErrUnsupportedCurrency, resource.ResourceID, currency)
+	}
+	resource.FiatCurrency = currency
+	resource.FiatAmountCents = price.AmountCents
+	resource.StripePriceID = price.StripePriceID // The default price is in another currency
+	return resource, nil
+}
+
+// SelectFiatCurrency prices resource for a Stripe checkout: in currency when given, otherwise
+// in the first currency implied by acceptLanguage that the resource offers, otherwise in its
+// default currency.
+func SelectFiatCurrency(resource config.PaywallResource, currency, acceptLanguage string) (config.PaywallResource, error) {
+	if currency != "" {
+		return PriceInCurrency(resource, currency)
+	}
+	for _, preferred := range PreferredCurrencies(acceptLanguage) {
+		if OffersCurrency(resource, preferred) {
+			return PriceInCurrency(resource, preferred)
+		}
+	}
+	return resource, nil
+}
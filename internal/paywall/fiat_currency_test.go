@@ -0,0 +1,77 @@
+package paywall
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/CedrosPay/server/internal/config"
+)
+
+func TestPreferredCurrencies(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []string
+	}{
+		{header: "", want: nil},
+		{header: "en-US,en;q=0.9", want: []string{"usd"}},
+		{header: "de-CH, de;q=0.8, en-GB;q=0.5", want: []string{"chf", "eur", "gbp"}},
+		{header: "en-GB;q=0.4, fr-FR", want: []string{"eur", "gbp"}},
+		{header: "zh-Hant-HK", want: []string{"hkd"}},
+		{header: "pt_BR", want: []string{"brl"}},
+		{header: "en, *;q=0.1", want: nil},
+		{header: "fr-FR;q=0, ja", want: []string{"jpy"}},
+	}
+	for _, tt := range tests {
+		if got := PreferredCurrencies(tt.header); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("PreferredCurrencies(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestSelectFiatCurrency(t *testing.T) {
+	resource := config.PaywallResource{
+		ResourceID:      "ebook",
+		FiatAmountCents: 1000,
+		FiatCurrency:    "usd",
+		StripePriceID:   "price_usd",
+		FiatPrices: map[string]config.FiatPrice{
+			"eur": {AmountCents: 900},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		currency       string
+		acceptLanguage string
+		wantCurrency   string
+		wantCents      int64
+		wantPriceID    string
+		wantErr        bool
+	}{
+		{name: "default", wantCurrency: "usd", wantCents: 1000, wantPriceID: "price_usd"},
+		{name: "explicit", currency: "EUR", wantCurrency: "eur", wantCents: 900},
+		{name: "explicit default", currency: "usd", acceptLanguage: "de-DE", wantCurrency: "usd", wantCents: 1000, wantPriceID: "price_usd"},
+		{name: "explicit not offered", currency: "gbp", wantErr: true},
+		{name: "by locale", acceptLanguage: "de-DE,en;q=0.5", wantCurrency: "eur", wantCents: 900},
+		{name: "first offered locale", acceptLanguage: "en-GB,fr;q=0.8", wantCurrency: "eur", wantCents: 900},
+		{name: "no offered locale", acceptLanguage: "ja-JP", wantCurrency: "usd", wantCents: 1000, wantPriceID: "price_usd"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SelectFiatCurrency(resource, tt.currency, tt.acceptLanguage)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnsupportedCurrency) {
+					t.Fatalf("error = %v, want ErrUnsupportedCurrency", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.FiatCurrency != tt.wantCurrency || got.FiatAmountCents != tt.wantCents || got.StripePriceID != tt.wantPriceID {
+				t.Errorf("priced as %s %d (%q), want %s %d (%q)", got.FiatCurrency, got.FiatAmountCents, got.StripePriceID, tt.wantCurrency, tt.wantCents, tt.wantPriceID)
+			}
+		})
+	}
+}
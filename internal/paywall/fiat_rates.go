@@ -0,0 +1,183 @@
+package paywall
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/money"
+)
+
+// Payment metadata recording the conversion of a fiat-priced resource.
+const (
+	exchangeRateKey = "exchange_rate"
+	fiatAmountKey   = "fiat_amount"
+	fiatCurrencyKey = "fiat_currency"
+)
+
+// fiatPricing is a fiat-priced resource with its crypto price set at one locked exchange rate.
+type fiatPricing struct {
+	resource config.PaywallResource
+	rate     float64     // Units of the crypto token per unit of fiat; 0 for a fixed crypto price
+	fiat     money.Money // The fiat price converted
+}
+
+// addMetadata records the conversion in payment metadata.
+func (p fiatPricing) addMetadata(metadata map[string]string) {
+	if p.rate == 0 {
+		return
+	}
+	metadata[exchangeRateKey] = strconv.FormatFloat(p.rate, 'f', -1, 64)
+	metadata[fiatAmountKey] = p.fiat.ToMajor()
+	metadata[fiatCurrencyKey] = p.fiat.Asset.Code
+}
+
+// lockedRate is an exchange rate fixed for the quotes issued while it is current.
+type lockedRate struct {
+	rate     float64
+	lockedAt time.Time
+}
+
+// rateLocks holds the locked rate per fiat/token pair. A rate stays current for one quote TTL;
+// quotes issued while it is current are payable until they expire, so authorization also
+// accepts the previous rate for a second TTL.
+type rateLocks struct {
+	mu    sync.Mutex
+	pairs map[string]*lockedPair
+}
+
+type lockedPair struct {
+	current, previous lockedRate
+}
+
+// quoteRate returns the rate new quotes from fiat to token use, locking a fresh rate from the
+// provider once the current one is a quote TTL old.
+func (s *Service) quoteRate(ctx context.Context, fiat, token string) (float64, error) {
+	key := fiat + "/" + token
+	ttl := s.cfg.Paywall.QuoteTTL.Duration
+
+	s.rateLocks.mu.Lock()
+	pair := s.rateLocks.pairs[key]
+	if pair != nil && time.Since(pair.current.lockedAt) < ttl {
+		rate := pair.current.rate
+		s.rateLocks.mu.Unlock()
+		return rate, nil
+	}
+	s.rateLocks.mu.Unlock()
+
+	rate, err := s.rates.Rate(ctx, fiat, token)
+	if err != nil {
+		return 0, fmt.Errorf("paywall: exchange rate from %s to %s: %w", fiat, token, err)
+	}
+
+	s.rateLocks.mu.Lock()
+	defer s.rateLocks.mu.Unlock()
+	if s.rateLocks.pairs == nil {
+		s.rateLocks.pairs = make(map[string]*lockedPair)
+	}
+	pair = s.rateLocks.pairs[key]
+	if pair == nil {
+		pair = &lockedPair{}
+		s.rateLocks.pairs[key] = pair
+	}
+	if time.Since(pair.current.lockedAt) < ttl {
+		return pair.current.rate, nil // Another quote locked a rate meanwhile
+	}
+	if !pair.current.lockedAt.IsZero() {
+		pair.previous = pair.current
+	}
+	pair.current = lockedRate{rate: rate, lockedAt: time.Now()}
+	return rate, nil
+}
+
+// acceptedRates returns the rates from fiat to token of quotes that may still be unexpired,
+// current first. Without any, it locks a new rate as a quote would.
+func (s *Service) acceptedRates(ctx context.Context, fiat, token string) ([]float64, error) {
+	window := 2 * s.cfg.Paywall.QuoteTTL.Duration
+
+	s.rateLocks.mu.Lock()
+	var rates []float64
+	if pair := s.rateLocks.pairs[fiat+"/"+token]; pair != nil {
+		for _, locked := range []lockedRate{pair.current, pair.previous} {
+			if !locked.lockedAt.IsZero() && time.Since(locked.lockedAt) < window {
+				rates = append(rates, locked.rate)
+			}
+		}
+	}
+	s.rateLocks.mu.Unlock()
+	if len(rates) > 0 {
+		return rates, nil
+	}
+
+	rate, err := s.quoteRate(ctx, fiat, token)
+	if err != nil {
+		return nil, err
+	}
+	return []float64{rate}, nil
+}
+
+// priceAtRate sets a fiat-priced resource's crypto price to its fiat price converted at rate.
+func priceAtRate(resource config.PaywallResource, rate float64) (fiatPricing, error) {
+	fiatAsset, err := money.GetAsset(strings.ToUpper(resource.FiatCurrency))
+	if err != nil {
+		return fiatPricing{}, fmt.Errorf("paywall: get asset for currency %s: %w", resource.FiatCurrency, err)
+	}
+	tokenAsset, err := money.GetAsset(resource.CryptoToken)
+	if err != nil {
+		return fiatPricing{}, fmt.Errorf("paywall: get asset for token %s: %w", resource.CryptoToken, err)
+	}
+	fiat := money.New(fiatAsset, resource.FiatAmountCents)
+	converted, err := convertMoney(fiat, tokenAsset, rate)
+	if err != nil {
+		return fiatPricing{}, err
+	}
+	resource.CryptoAtomicAmount = converted.Atomic
+	return fiatPricing{resource: resource, rate: rate, fiat: fiat}, nil
+}
+
+// quoteFiatPricing prices a resource for a new quote: fiat-priced resources are converted at
+// the currently locked rate, others keep their crypto price.
+func (s *Service) quoteFiatPricing(ctx context.Context, resource config.PaywallResource) (fiatPricing, error) {
+	if !resource.PriceFromFiat {
+		return fiatPricing{resource: resource}, nil
+	}
+	rate, err := s.quoteRate(ctx, strings.ToUpper(resource.FiatCurrency), resource.CryptoToken)
+	if err != nil {
+		return fiatPricing{}, err
+	}
+	return priceAtRate(resource, rate)
+}
+
+// acceptedFiatPricings prices a resource at each rate an unexpired quote may have used.
+func (s *Service) acceptedFiatPricings(ctx context.Context, resource config.PaywallResource) ([]fiatPricing, error) {
+	if !resource.PriceFromFiat {
+		return []fiatPricing{{resource: resource}}, nil
+	}
+	rates, err := s.acceptedRates(ctx, strings.ToUpper(resource.FiatCurrency), resource.CryptoToken)
+	if err != nil {
+		return nil, err
+	}
+	pricings := make([]fiatPricing, 0, len(rates))
+	for _, rate := range rates {
+		pricing, err := priceAtRate(resource, rate)
+		if err != nil {
+			return nil, err
+		}
+		pricings = append(pricings, pricing)
+	}
+	return pricings, nil
+}
+
+// QuotedResource returns resource with the crypto price a new quote would carry, converting
+// fiat-priced resources at the currently locked exchange rate.
+func (s *Service) QuotedResource(ctx context.Context, resource config.PaywallResource) (config.PaywallResource, error) {
+	pricing, err := s.quoteFiatPricing(ctx, resource)
+	if err != nil {
+		return config.PaywallResource{}, err
+	}
+	return pricing.resource, nil
+}
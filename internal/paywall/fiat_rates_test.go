@@ -0,0 +1,136 @@
+package paywall
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/pkg/x402"
+)
+
+// fiatRate is a RateProvider whose USD to USDC rate tests can move.
+type fiatRate struct {
+	rate float64
+}
+
+func (r *fiatRate) Rate(_ context.Context, from, to string) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+	return r.rate, nil
+}
+
+func fiatPricedConfig() *config.Config {
+	cfg := testConfig()
+	cfg.Paywall.Resources["fiat-content"] = config.PaywallResource{
+		ResourceID:      "fiat-content",
+		FiatAmountCents: 250,
+		FiatCurrency:    "USD",
+		CryptoToken:     "USDC",
+		PriceFromFiat:   true,
+	}
+	return cfg
+}
+
+func TestFiatPricedQuote(t *testing.T) {
+	ctx := context.Background()
+	cfg := fiatPricedConfig()
+	svc := NewService(cfg, storage.NewMemoryStore(), stubVerifier{}, nil, testRepository(cfg), nil, nil)
+	rates := &fiatRate{rate: 1.02}
+	svc.SetRateProvider(rates)
+
+	quote, err := svc.GenerateQuote(ctx, "fiat-content", "")
+	if err != nil {
+		t.Fatalf("GenerateQuote error: %v", err)
+	}
+	if quote.Crypto == nil || quote.Crypto.MaxAmountRequired != "2550000" {
+		t.Fatalf("quote crypto = %+v, want 2550000 atomic USDC", quote.Crypto)
+	}
+	extra := quote.Crypto.Extra.(map[string]any)
+	if extra["exchangeRate"] != "1.02" || extra["fiatAmount"] != "2.50" || extra["fiatCurrency"] != "USD" {
+		t.Errorf("quote extra = %v, want the fiat price and rate", extra)
+	}
+
+	// The rate stays locked for the quote TTL
+	rates.rate = 1.1
+	if quote, _ = svc.GenerateQuote(ctx, "fiat-content", ""); quote.Crypto.MaxAmountRequired != "2550000" {
+		t.Errorf("quote within the TTL = %s, want the locked 2550000", quote.Crypto.MaxAmountRequired)
+	}
+
+	// Fixed-price resources are unaffected
+	if quote, _ = svc.GenerateQuote(ctx, "demo-content", ""); quote.Crypto.MaxAmountRequired != "1000000" {
+		t.Errorf("fixed-price quote = %s, want 1000000", quote.Crypto.MaxAmountRequired)
+	}
+
+	// A cart quote converts at the locked rate and records it on the item
+	cart, err := svc.GenerateCartQuote(ctx, CartQuoteRequest{Items: []CartQuoteItem{{ResourceID: "fiat-content", Quantity: 2}}})
+	if err != nil {
+		t.Fatalf("GenerateCartQuote error: %v", err)
+	}
+	if cart.Quote.MaxAmountRequired != "5100000" {
+		t.Errorf("cart total = %s, want 5100000", cart.Quote.MaxAmountRequired)
+	}
+	stored, _ := svc.GetCartQuote(ctx, cart.CartID)
+	if stored.Items[0].Metadata[exchangeRateKey] != "1.02" {
+		t.Errorf("cart item metadata = %v, want exchange_rate 1.02", stored.Items[0].Metadata)
+	}
+}
+
+func TestFiatPricedAuthorize(t *testing.T) {
+	ctx := context.Background()
+	cfg := fiatPricedConfig()
+	tests := []struct {
+		name     string
+		paid     float64
+		age      time.Duration // Age of the rate locked at 1.02 when the next quote is issued
+		wantRate string
+		wantErr  bool
+	}{
+		{name: "current rate", paid: 2.55, wantRate: "1.02"},
+		{name: "rate of a quote still valid", paid: 2.55, age: 90 * time.Second, wantRate: "1.02"},
+		{name: "new rate", paid: 2.75, age: 90 * time.Second, wantRate: "1.1"},
+		{name: "rate of expired quotes", paid: 2.55, age: 3 * time.Minute, wantErr: true},
+		{name: "other amount", paid: 2.6, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewMemoryStore()
+			t.Cleanup(func() { _ = store.Close() })
+			svc := NewService(cfg, store, stubVerifier{result: x402.VerificationResult{Amount: tt.paid}}, nil, testRepository(cfg), nil, nil)
+			rates := &fiatRate{rate: 1.02}
+			svc.SetRateProvider(rates)
+			if _, err := svc.GenerateQuote(ctx, "fiat-content", ""); err != nil {
+				t.Fatalf("GenerateQuote error: %v", err)
+			}
+
+			rates.rate = 1.1
+			if tt.age > 0 {
+				svc.rateLocks.pairs["USD/USDC"].current.lockedAt = time.Now().Add(-tt.age)
+				if _, err := svc.GenerateQuote(ctx, "fiat-content", ""); err != nil {
+					t.Fatalf("GenerateQuote error: %v", err)
+				}
+			}
+			if tt.age > 2*cfg.Paywall.QuoteTTL.Duration {
+				svc.rateLocks.pairs["USD/USDC"].previous.lockedAt = time.Now().Add(-tt.age)
+				svc.rateLocks.pairs["USD/USDC"].current.lockedAt = time.Now().Add(-tt.age)
+			}
+
+			_, err := svc.Authorize(ctx, "fiat-content", "", cartPaymentHeader(t, cfg, "sig-fiat"), "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Authorize error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			payment, err := store.GetPayment(ctx, "sig-fiat")
+			if err != nil {
+				t.Fatalf("GetPayment error: %v", err)
+			}
+			if payment.Metadata[exchangeRateKey] != tt.wantRate || payment.Metadata[fiatAmountKey] != "2.50" || payment.Metadata[fiatCurrencyKey] != "USD" {
+				t.Errorf("payment metadata = %v, want exchange_rate %s for 2.50 USD", payment.Metadata, tt.wantRate)
+			}
+		})
+	}
+}
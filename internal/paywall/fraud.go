@@ -0,0 +1,192 @@
+package paywall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/logger"
+)
+
+// Velocity rule names, as reported in errors, logs, and cedros_fraud_rule_hits_total.
+const (
+	velocityPaymentsPerWallet = "payments_per_wallet"
+	velocityRefundsPerWallet  = "refunds_per_wallet"
+	velocityQuotesPerIP       = "quotes_per_ip"
+)
+
+// Metadata keys set on payments and refund requests that tripped a rule without being blocked.
+const (
+	fraudFlagKey   = "fraud_flag"   // Rule that flagged the payment or refund request
+	fraudReviewKey = "fraud_review" // Rule that marked the refund request for review
+)
+
+// velocitySweepInterval is how often counters for keys with no recent events are dropped.
+const velocitySweepInterval = 10 * time.Minute
+
+// ErrVelocityLimit is returned when a velocity rule with the block action trips.
+var ErrVelocityLimit = errors.New("paywall: velocity limit exceeded")
+
+// velocityCounters holds recent event times per rule and key (wallet or client IP).
+type velocityCounters struct {
+	mu     sync.Mutex
+	events map[velocityKey][]time.Time
+	swept  time.Time
+}
+
+type velocityKey struct {
+	rule string
+	key  string
+}
+
+// velocityHit is a rule that tripped with the flag or require_review action; the zero value
+// means no rule tripped.
+type velocityHit struct {
+	rule   string
+	action string
+}
+
+// addMetadata tags a payment or refund request with the rule that tripped.
+func (h velocityHit) addMetadata(metadata map[string]string) {
+	switch h.action {
+	case config.FraudActionFlag:
+		metadata[fraudFlagKey] = h.rule
+	case config.FraudActionRequireReview:
+		metadata[fraudReviewKey] = h.rule
+	}
+}
+
+// velocityRule returns a rule's config with its default window and action applied.
+func (s *Service) velocityRule(rule string) config.VelocityRuleConfig {
+	var (
+		cfg    config.VelocityRuleConfig
+		window time.Duration
+	)
+	switch rule {
+	case velocityPaymentsPerWallet:
+		cfg, window = s.cfg.Paywall.Fraud.PaymentsPerWallet, time.Hour
+	case velocityRefundsPerWallet:
+		cfg, window = s.cfg.Paywall.Fraud.RefundsPerWallet, 24*time.Hour
+	case velocityQuotesPerIP:
+		cfg, window = s.cfg.Paywall.Fraud.QuotesPerIP, time.Hour
+	}
+	if cfg.Window.Duration <= 0 {
+		cfg.Window.Duration = window
+	}
+	if cfg.Action == "" {
+		cfg.Action = config.FraudActionBlock
+	}
+	return cfg
+}
+
+// checkVelocity evaluates a rule for key before an event. Once the rule's max events happened
+// within its window, the event is blocked with ErrVelocityLimit or returned as a hit to flag.
+// Disabled rules and empty keys never trip.
+func (s *Service) checkVelocity(ctx context.Context, rule, key string) (velocityHit, error) {
+	cfg := s.velocityRule(rule)
+	if cfg.Max <= 0 || key == "" {
+		return velocityHit{}, nil
+	}
+
+	now := time.Now()
+	s.velocity.mu.Lock()
+	count := len(s.velocity.recent(velocityKey{rule, key}, now.Add(-cfg.Window.Duration)))
+	s.velocity.mu.Unlock()
+	if count < cfg.Max {
+		return velocityHit{}, nil
+	}
+
+	if s.metrics != nil {
+		s.metrics.ObserveFraudRule(rule, cfg.Action)
+	}
+	log := logger.FromContext(ctx)
+	log.Warn().
+		Str("rule", rule).
+		Str("key", logger.TruncateAddress(key)).
+		Int("count", count).
+		Dur("window", cfg.Window.Duration).
+		Str("action", cfg.Action).
+		Msg("fraud.velocity_rule_tripped")
+
+	if cfg.Action == config.FraudActionBlock {
+		return velocityHit{}, fmt.Errorf("%w: %s allows %d per %s", ErrVelocityLimit, rule, cfg.Max, cfg.Window.Duration)
+	}
+	return velocityHit{rule: rule, action: cfg.Action}, nil
+}
+
+// recordVelocity counts an event for key against a rule. Events are only counted while the
+// rule is enabled.
+func (s *Service) recordVelocity(rule, key string) {
+	cfg := s.velocityRule(rule)
+	if cfg.Max <= 0 || key == "" {
+		return
+	}
+
+	now := time.Now()
+	s.velocity.mu.Lock()
+	defer s.velocity.mu.Unlock()
+	if s.velocity.events == nil {
+		s.velocity.events = make(map[velocityKey][]time.Time)
+	}
+	k := velocityKey{rule, key}
+	s.velocity.events[k] = append(s.velocity.recent(k, now.Add(-cfg.Window.Duration)), now)
+
+	if now.Sub(s.velocity.swept) >= velocitySweepInterval {
+		for k := range s.velocity.events {
+			s.velocity.recent(k, now.Add(-s.velocityRule(k.rule).Window.Duration))
+		}
+		s.velocity.swept = now
+	}
+}
+
+// countQuote applies the access lists and the quotes_per_ip rule to a quote about to be
+// generated for wallet (empty if unknown), then counts it.
+func (s *Service) countQuote(ctx context.Context, wallet string) error {
+	allowed, err := s.screenPayer(ctx, wallet)
+	if err != nil || allowed {
+		return err
+	}
+	ip := clientIPFromContext(ctx)
+	if _, err := s.checkVelocity(ctx, velocityQuotesPerIP, ip); err != nil {
+		return err
+	}
+	s.recordVelocity(velocityQuotesPerIP, ip)
+	return nil
+}
+
+// paymentVelocity returns an x402.Requirement.CheckPayer that applies the access lists,
+// compliance screening, and the payments_per_wallet rule to the paying wallet, storing a flag
+// in hit. Allowed wallets are still screened.
+func (s *Service) paymentVelocity(hit *velocityHit) func(context.Context, string) error {
+	return func(ctx context.Context, wallet string) error {
+		allowed, err := s.screenPayer(ctx, wallet)
+		if err != nil {
+			return err
+		}
+		if err := s.screenWallet(ctx, wallet, screeningPayment); err != nil || allowed {
+			return err
+		}
+		*hit, err = s.checkVelocity(ctx, velocityPaymentsPerWallet, wallet)
+		return err
+	}
+}
+
+// recent drops a key's events from before since and returns the rest. Keys left without
+// events are removed. Callers hold mu.
+func (v *velocityCounters) recent(k velocityKey, since time.Time) []time.Time {
+	events := v.events[k]
+	i := 0
+	for i < len(events) && events[i].Before(since) {
+		i++
+	}
+	events = events[i:]
+	if len(events) == 0 {
+		delete(v.events, k)
+		return nil
+	}
+	v.events[k] = events
+	return events
+}
@@ -0,0 +1,169 @@
+package paywall
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/CedrosPay/server/internal/callbacks"
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/pkg/x402"
+)
+
+// payerCheckingVerifier calls Requirement.CheckPayer with its wallet before verifying, as the
+// Solana verifier does once the transaction is decoded.
+type payerCheckingVerifier struct {
+	stubVerifier
+}
+
+func (v payerCheckingVerifier) Verify(ctx context.Context, proof x402.PaymentProof, requirement x402.Requirement) (x402.VerificationResult, error) {
+	if requirement.CheckPayer != nil {
+		if err := requirement.CheckPayer(ctx, v.result.Wallet); err != nil {
+			return x402.VerificationResult{}, err
+		}
+	}
+	return v.stubVerifier.Verify(ctx, proof, requirement)
+}
+
+func TestQuoteVelocity(t *testing.T) {
+	cfg := testConfig()
+	cfg.Paywall.Fraud.QuotesPerIP = config.VelocityRuleConfig{Max: 2}
+	svc := NewService(cfg, storage.NewMemoryStore(), stubVerifier{}, callbacks.NoopNotifier{}, testRepository(cfg), nil, nil)
+
+	tests := []struct {
+		name    string
+		ip      string
+		wantErr bool
+	}{
+		{name: "first", ip: "203.0.113.7"},
+		{name: "second", ip: "203.0.113.7"},
+		{name: "over the limit", ip: "203.0.113.7", wantErr: true},
+		{name: "still over the limit", ip: "203.0.113.7", wantErr: true},
+		{name: "other IP", ip: "198.51.100.1"},
+		{name: "no IP", ip: ""},
+	}
+	for _, tt := range tests {
+		ctx := context.Background()
+		if tt.ip != "" {
+			ctx = WithClientIP(ctx, tt.ip)
+		}
+		_, err := svc.GenerateQuote(ctx, "demo-content", "")
+		if tt.wantErr != errors.Is(err, ErrVelocityLimit) || (!tt.wantErr && err != nil) {
+			t.Fatalf("%s: GenerateQuote error = %v, want velocity limit %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestPaymentVelocity(t *testing.T) {
+	tests := []struct {
+		name     string
+		action   string
+		wantErr  bool
+		wantFlag string
+	}{
+		{name: "block", action: config.FraudActionBlock, wantErr: true},
+		{name: "flag", action: config.FraudActionFlag, wantFlag: velocityPaymentsPerWallet},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cfg := testConfig()
+			cfg.Paywall.Fraud.PaymentsPerWallet = config.VelocityRuleConfig{Max: 1, Action: tt.action}
+			store := storage.NewMemoryStore()
+			t.Cleanup(func() { _ = store.Close() })
+			verifier := payerCheckingVerifier{stubVerifier{result: x402.VerificationResult{Wallet: "wallet-1"}}}
+			svc := NewService(cfg, store, verifier, callbacks.NoopNotifier{}, testRepository(cfg), nil, nil)
+
+			var signatures []string
+			for i := 0; i < 2; i++ {
+				signature := computeSignature("demo-content", cfg.X402.PaymentAddress, fmt.Sprintf("velocity-%d", i))
+				payload, _ := json.Marshal(x402.PaymentPayload{
+					Scheme:  "solana-spl-transfer",
+					Network: cfg.X402.Network,
+					Payload: x402.SolanaPayload{Signature: signature, Transaction: base64.StdEncoding.EncodeToString([]byte("signed-tx"))},
+				})
+				_, err := svc.Authorize(ctx, "demo-content", "", base64.StdEncoding.EncodeToString(payload), "")
+				if i == 1 && tt.wantErr {
+					if !errors.Is(err, ErrVelocityLimit) {
+						t.Fatalf("second payment error = %v, want ErrVelocityLimit", err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("payment %d: %v", i, err)
+				}
+				signatures = append(signatures, signature)
+			}
+
+			for i, signature := range signatures {
+				payment, err := store.GetPayment(ctx, signature)
+				if err != nil {
+					t.Fatalf("GetPayment: %v", err)
+				}
+				want := ""
+				if i == 1 {
+					want = tt.wantFlag
+				}
+				if got := payment.Metadata[fraudFlagKey]; got != want {
+					t.Errorf("payment %d %s = %q, want %q", i, fraudFlagKey, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRefundVelocity(t *testing.T) {
+	tests := []struct {
+		name        string
+		action      string
+		wantErr     bool
+		wantKey     string
+		wantHistory string
+	}{
+		{name: "block", action: config.FraudActionBlock, wantErr: true},
+		{name: "flag", action: config.FraudActionFlag, wantKey: fraudFlagKey, wantHistory: storage.RefundStatusRequested},
+		{name: "require review", action: config.FraudActionRequireReview, wantKey: fraudReviewKey, wantHistory: storage.RefundStatusEscalated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cfg := testConfig()
+			cfg.Paywall.Fraud.RefundsPerWallet = config.VelocityRuleConfig{Max: 1, Action: tt.action}
+			store := storage.NewMemoryStore()
+			t.Cleanup(func() { _ = store.Close() })
+			svc := NewService(cfg, store, stubVerifier{}, callbacks.NoopNotifier{}, testRepository(cfg), nil, nil)
+
+			request := func(purchaseID string) (storage.RefundQuote, error) {
+				return svc.CreateRefundRequest(ctx, RefundQuoteRequest{
+					OriginalPurchaseID: purchaseID,
+					RecipientWallet:    "11111111111111111111111111111111",
+					Amount:             1,
+					Token:              "USDC",
+				})
+			}
+			if _, err := request("purchase_1"); err != nil {
+				t.Fatalf("first refund request: %v", err)
+			}
+			refund, err := request("purchase_2")
+			if tt.wantErr {
+				if !errors.Is(err, ErrVelocityLimit) {
+					t.Fatalf("second refund request error = %v, want ErrVelocityLimit", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("second refund request: %v", err)
+			}
+			if got := refund.Metadata[tt.wantKey]; got != velocityRefundsPerWallet {
+				t.Errorf("metadata %s = %q, want %q", tt.wantKey, got, velocityRefundsPerWallet)
+			}
+			if got := refund.History[len(refund.History)-1].Status; got != tt.wantHistory {
+				t.Errorf("latest history status = %q, want %q", got, tt.wantHistory)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package paywall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/CedrosPay/server/internal/coupons"
+	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/money"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+// ErrNotGiftCard indicates a code is not an active gift card coupon.
+var ErrNotGiftCard = errors.New("paywall: not a gift card")
+
+// Payment and cart metadata recording a gift card redemption.
+const (
+	giftCardKey       = "gift_card"
+	giftCardAmountKey = "gift_card_amount"
+)
+
+// giftCardRedemption is the part of a price paid from a gift card's balance.
+type giftCardRedemption struct {
+	code      string
+	faceValue money.Money // The card's starting balance in USD
+	amount    money.Money // Paid from the balance, in the price's token; zero without a gift card
+}
+
+// addMetadata records the redemption in payment or cart metadata.
+func (r giftCardRedemption) addMetadata(metadata map[string]string) {
+	if r.amount.IsZero() {
+		return
+	}
+	metadata[giftCardKey] = r.code
+	metadata[giftCardAmountKey] = r.amount.ToMajor()
+}
+
+// giftCard returns the active gift card coupon with code, or nil if code is not one.
+func (s *Service) giftCard(ctx context.Context, code string) *coupons.Coupon {
+	if code == "" || s.coupons == nil {
+		return nil
+	}
+	coupon, err := s.coupons.GetCoupon(ctx, code)
+	if err != nil || coupon.DiscountType != coupons.DiscountTypeGiftCard || coupon.IsValid() != nil {
+		return nil
+	}
+	return &coupon
+}
+
+// giftCardFaceValue returns a gift card's starting balance in USD.
+func giftCardFaceValue(coupon coupons.Coupon) (money.Money, error) {
+	faceValue, err := money.FromMajor(money.MustGetAsset("USD"), formatFloat(coupon.DiscountValue))
+	if err != nil {
+		return money.Money{}, fmt.Errorf("paywall: gift card %s face value: %w", coupon.Code, err)
+	}
+	return faceValue, nil
+}
+
+// giftCardBalance returns a gift card's face value and remaining balance in USD. A card that
+// was never spent from has its full face value.
+func (s *Service) giftCardBalance(ctx context.Context, coupon coupons.Coupon) (faceValue, balance money.Money, err error) {
+	if faceValue, err = giftCardFaceValue(coupon); err != nil {
+		return money.Money{}, money.Money{}, err
+	}
+	card, err := s.store.GetGiftCard(ctx, coupon.Code)
+	if errors.Is(err, storage.ErrNotFound) {
+		return faceValue, faceValue, nil
+	}
+	if err != nil {
+		return money.Money{}, money.Money{}, fmt.Errorf("paywall: get gift card %s: %w", coupon.Code, err)
+	}
+	return faceValue, card.Balance, nil
+}
+
+// redeemGiftCard returns how much of due the gift card with code pays. Only prices in
+// USD-pegged tokens are paid from a balance, and at least one cent is left to pay on-chain
+// since an x402 payment is only verified through its transfer. Codes that are not gift cards
+// redeem nothing.
+func (s *Service) redeemGiftCard(ctx context.Context, code string, due money.Money) (giftCardRedemption, error) {
+	none := giftCardRedemption{amount: money.Zero(due.Asset)}
+	coupon := s.giftCard(ctx, code)
+	if coupon == nil || !isUSDPegged(due.Asset.Code) {
+		return none, nil
+	}
+	faceValue, balance, err := s.giftCardBalance(ctx, *coupon)
+	if err != nil {
+		return giftCardRedemption{}, err
+	}
+	available, err := convertMoney(balance, due.Asset, 1)
+	if err != nil {
+		return giftCardRedemption{}, err
+	}
+
+	cent := money.New(due.Asset, 1).RoundUpToCents().Atomic
+	payable := (due.Atomic - cent) / cent * cent // Whole cents, leaving at least one to pay
+	amount := min(available.Atomic, payable)
+	if amount <= 0 {
+		return none, nil
+	}
+	return giftCardRedemption{code: coupon.Code, faceValue: faceValue, amount: money.New(due.Asset, amount)}, nil
+}
+
+// cartGiftCard returns the gift card redemption a cart was quoted with.
+func (s *Service) cartGiftCard(ctx context.Context, cart storage.CartQuote) (giftCardRedemption, error) {
+	code := cart.Metadata[giftCardKey]
+	if code == "" {
+		return giftCardRedemption{amount: money.Zero(cart.Total.Asset)}, nil
+	}
+	coupon := s.giftCard(ctx, code)
+	if coupon == nil {
+		return giftCardRedemption{}, fmt.Errorf("%w: %s", ErrNotGiftCard, code)
+	}
+	faceValue, err := giftCardFaceValue(*coupon)
+	if err != nil {
+		return giftCardRedemption{}, err
+	}
+	amount, err := money.FromMajor(cart.Total.Asset, cart.Metadata[giftCardAmountKey])
+	if err != nil {
+		return giftCardRedemption{}, fmt.Errorf("paywall: cart gift card amount: %w", err)
+	}
+	return giftCardRedemption{code: coupon.Code, faceValue: faceValue, amount: amount}, nil
+}
+
+// spendGiftCard takes amount of a redemption out of its gift card's balance, failing with
+// storage.ErrGiftCardBalance if the balance no longer covers it.
+func (s *Service) spendGiftCard(ctx context.Context, r giftCardRedemption, amount money.Money) error {
+	if amount.IsZero() {
+		return nil
+	}
+	spent, err := convertMoney(amount, r.faceValue.Asset, 1)
+	if err != nil {
+		return err
+	}
+	if _, err := s.store.AdjustGiftCard(ctx, r.code, r.faceValue, spent.Negate()); err != nil {
+		return fmt.Errorf("paywall: spend gift card %s: %w", r.code, err)
+	}
+	return nil
+}
+
+// creditGiftCard returns amount of a redemption to its gift card's balance after the payment
+// it was spent on failed.
+func (s *Service) creditGiftCard(ctx context.Context, r giftCardRedemption, amount money.Money) {
+	if amount.IsZero() {
+		return
+	}
+	credit, err := convertMoney(amount, r.faceValue.Asset, 1)
+	if err == nil {
+		_, err = s.store.AdjustGiftCard(ctx, r.code, r.faceValue, credit)
+	}
+	if err != nil {
+		log := logger.FromContext(ctx)
+		log.Error().
+			Err(err).
+			Str("gift_card", r.code).
+			Str("amount", amount.ToMajor()).
+			Msg("paywall.gift_card_credit_failed")
+	}
+}
+
+// GiftCardBalance returns the remaining balance of the gift card with code in USD, or
+// ErrNotGiftCard if code is not an active gift card.
+func (s *Service) GiftCardBalance(ctx context.Context, code string) (money.Money, error) {
+	coupon := s.giftCard(ctx, code)
+	if coupon == nil {
+		return money.Money{}, ErrNotGiftCard
+	}
+	_, balance, err := s.giftCardBalance(ctx, *coupon)
+	return balance, err
+}
+
+// ApplyGiftCard returns due less the part the gift card with code pays, as quotes and
+// authorization price it. Codes that are not gift cards leave due unchanged.
+func (s *Service) ApplyGiftCard(ctx context.Context, code string, due money.Money) (money.Money, error) {
+	redemption, err := s.redeemGiftCard(ctx, code, due)
+	if err != nil {
+		return money.Money{}, err
+	}
+	return due.Sub(redemption.amount)
+}
@@ -0,0 +1,165 @@
+package paywall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/coupons"
+	"github.com/CedrosPay/server/internal/money"
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/pkg/x402"
+)
+
+func giftCardCoupons() coupons.Repository {
+	return coupons.NewYAMLRepository(map[string]config.Coupon{
+		"GIFT250": {Code: "GIFT250", DiscountType: "gift_card", DiscountValue: 2.5, Scope: "all", Active: true},
+		"HALF":    {Code: "HALF", DiscountType: "percentage", DiscountValue: 50, Scope: "all", Active: true},
+	})
+}
+
+func TestGiftCardPurchases(t *testing.T) {
+	ctx := context.Background()
+	cfg := testConfig()
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	svc := NewService(cfg, store, stubVerifier{}, &recordingNotifier{}, testRepository(cfg), giftCardCoupons(), nil)
+
+	// demo-content costs 1.00 USDC; the card starts at 2.50 USD and always leaves a cent to pay
+	purchases := []struct {
+		name        string
+		code        string
+		verifyErr   error
+		wantAmount  string // Atomic USDC quoted and paid
+		wantRedeem  string
+		wantBalance string
+	}{
+		{name: "first purchase", code: "GIFT250", wantAmount: "10000", wantRedeem: "0.990000", wantBalance: "1.51"},
+		{name: "failed payment returns the balance", code: "GIFT250", verifyErr: errors.New("transaction not found"), wantAmount: "10000", wantRedeem: "0.990000", wantBalance: "1.51"},
+		{name: "second purchase", code: "GIFT250", wantAmount: "10000", wantRedeem: "0.990000", wantBalance: "0.52"},
+		{name: "balance runs out", code: "GIFT250", wantAmount: "480000", wantRedeem: "0.520000", wantBalance: "0.00"},
+		{name: "empty card", code: "GIFT250", wantAmount: "1000000", wantBalance: "0.00"},
+		{name: "discount coupon is not a gift card", code: "HALF", wantAmount: "500000", wantBalance: "0.00"},
+	}
+	for i, purchase := range purchases {
+		quote, err := svc.GenerateQuote(ctx, "demo-content", purchase.code)
+		if err != nil {
+			t.Fatalf("%s: GenerateQuote error: %v", purchase.name, err)
+		}
+		if quote.Crypto.MaxAmountRequired != purchase.wantAmount {
+			t.Errorf("%s: quoted %s, want %s", purchase.name, quote.Crypto.MaxAmountRequired, purchase.wantAmount)
+		}
+		if extra := quote.Crypto.Extra.(map[string]any); purchase.wantRedeem != "" && extra["giftCardAmount"] != purchase.wantRedeem {
+			t.Errorf("%s: quote extra = %v, want giftCardAmount %s", purchase.name, extra, purchase.wantRedeem)
+		}
+
+		svc.verifier = stubVerifier{err: purchase.verifyErr}
+		signature := fmt.Sprintf("sig-gift-%d", i)
+		_, err = svc.Authorize(ctx, "demo-content", "", cartPaymentHeader(t, cfg, signature), purchase.code)
+		if (err != nil) != (purchase.verifyErr != nil) {
+			t.Fatalf("%s: Authorize error = %v", purchase.name, err)
+		}
+		if err == nil {
+			payment, _ := store.GetPayment(ctx, signature)
+			if fmt.Sprint(payment.Amount.Atomic) != purchase.wantAmount || payment.Metadata[giftCardAmountKey] != purchase.wantRedeem {
+				t.Errorf("%s: payment = %d with metadata %v, want %s paid and %q redeemed", purchase.name, payment.Amount.Atomic, payment.Metadata, purchase.wantAmount, purchase.wantRedeem)
+			}
+		}
+
+		balance, err := svc.GiftCardBalance(ctx, "GIFT250")
+		if err != nil {
+			t.Fatalf("%s: GiftCardBalance error: %v", purchase.name, err)
+		}
+		if balance.ToMajor() != purchase.wantBalance {
+			t.Errorf("%s: balance = %s, want %s", purchase.name, balance.ToMajor(), purchase.wantBalance)
+		}
+	}
+
+	if _, err := svc.GiftCardBalance(ctx, "HALF"); !errors.Is(err, ErrNotGiftCard) {
+		t.Errorf("GiftCardBalance(HALF) error = %v, want ErrNotGiftCard", err)
+	}
+}
+
+func TestGiftCardCart(t *testing.T) {
+	ctx := context.Background()
+	cfg := testConfig()
+	usd := money.MustGetAsset("USD")
+	tests := []struct {
+		name        string
+		spentFirst  int64 // Cents spent from the card elsewhere after the cart is quoted
+		wantErr     error
+		wantBalance string
+	}{
+		{name: "paid with the card", wantBalance: "0.51"},
+		{name: "balance spent since the quote", spentFirst: 100, wantErr: storage.ErrGiftCardBalance, wantBalance: "1.50"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewMemoryStore()
+			t.Cleanup(func() { _ = store.Close() })
+			svc := NewService(cfg, store, stubVerifier{}, &recordingNotifier{}, testRepository(cfg), giftCardCoupons(), nil)
+
+			cart, err := svc.GenerateCartQuote(ctx, CartQuoteRequest{Items: []CartQuoteItem{{ResourceID: "demo-content", Quantity: 2}}, CouponCode: "GIFT250"})
+			if err != nil {
+				t.Fatalf("GenerateCartQuote error: %v", err)
+			}
+			if cart.Quote.MaxAmountRequired != "10000" || cart.Metadata[giftCardAmountKey] != "1.990000" {
+				t.Fatalf("cart quote = %s with metadata %v, want 10000 after 1.99 from the card", cart.Quote.MaxAmountRequired, cart.Metadata)
+			}
+			if _, err := svc.PrepareCartCheckout(ctx, cart.CartID, cart.ExpiresAt); !errors.Is(err, ErrCartNotCardPayable) {
+				t.Errorf("PrepareCartCheckout error = %v, want ErrCartNotCardPayable", err)
+			}
+
+			if tt.spentFirst > 0 {
+				if _, err := store.AdjustGiftCard(ctx, "GIFT250", money.New(usd, 250), money.New(usd, -tt.spentFirst)); err != nil {
+					t.Fatalf("AdjustGiftCard error: %v", err)
+				}
+			}
+
+			_, err = svc.Authorize(ctx, cart.CartID, "", cartPaymentHeader(t, cfg, "sig-gift-cart"), "")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Authorize error = %v, want %v", err, tt.wantErr)
+			}
+			balance, _ := svc.GiftCardBalance(ctx, "GIFT250")
+			if balance.ToMajor() != tt.wantBalance {
+				t.Errorf("balance = %s, want %s", balance.ToMajor(), tt.wantBalance)
+			}
+		})
+	}
+}
+
+func TestGiftCardFiatPricedAuthorize(t *testing.T) {
+	// With two accepted rates the larger redemption is held, and the excess returned once the
+	// payment matches the other rate's price
+	ctx := context.Background()
+	cfg := fiatPricedConfig()
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	svc := NewService(cfg, store, stubVerifier{result: x402.VerificationResult{Amount: 0.01}}, &recordingNotifier{}, testRepository(cfg), giftCardCoupons(), nil)
+	rates := &fiatRate{rate: 1.02}
+	svc.SetRateProvider(rates)
+
+	// 2.50 USD at 1.02 is 2.55 USDC: the card pays 2.50, leaving 0.05
+	quote, err := svc.GenerateQuote(ctx, "fiat-content", "GIFT250")
+	if err != nil {
+		t.Fatalf("GenerateQuote error: %v", err)
+	}
+	if quote.Crypto.MaxAmountRequired != "50000" {
+		t.Fatalf("quoted %s, want 50000", quote.Crypto.MaxAmountRequired)
+	}
+
+	// At 0.99 the price is 2.48 USDC (rounded up): the card pays 2.47, leaving the 0.01 paid
+	rates.rate = 0.99
+	svc.rateLocks.pairs["USD/USDC"].current.lockedAt = svc.rateLocks.pairs["USD/USDC"].current.lockedAt.Add(-cfg.Paywall.QuoteTTL.Duration)
+	if _, err := svc.GenerateQuote(ctx, "fiat-content", "GIFT250"); err != nil {
+		t.Fatalf("GenerateQuote error: %v", err)
+	}
+	if _, err := svc.Authorize(ctx, "fiat-content", "", cartPaymentHeader(t, cfg, "sig-gift-fiat"), "GIFT250"); err != nil {
+		t.Fatalf("Authorize error: %v", err)
+	}
+	if balance, _ := svc.GiftCardBalance(ctx, "GIFT250"); balance.ToMajor() != "0.03" {
+		t.Errorf("balance = %s, want 0.03", balance.ToMajor())
+	}
+}
@@ -0,0 +1,70 @@
+package paywall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+// ErrOutOfStock indicates a cart asks for more units of a resource than remain unreserved.
+var ErrOutOfStock = storage.ErrOutOfStock
+
+// reserveCartStock holds the cart's units of stock-tracked resources until the quote expires,
+// replacing the units held for an earlier version of the cart.
+func (s *Service) reserveCartStock(ctx context.Context, cartID string, items []storage.CartItem, expiresAt time.Time) error {
+	err := s.store.ReserveStock(ctx, storage.StockReservation{ID: cartID, Items: cartStockUnits(items), ExpiresAt: expiresAt})
+	if err != nil {
+		return fmt.Errorf("paywall: reserve stock: %w", err)
+	}
+	return nil
+}
+
+// cartStockUnits sums the units of each resource in a cart.
+func cartStockUnits(items []storage.CartItem) map[string]int64 {
+	units := make(map[string]int64, len(items))
+	for _, item := range items {
+		units[item.ResourceID] += item.Quantity
+	}
+	return units
+}
+
+// CheckStock returns ErrOutOfStock if a stock-tracked resource has fewer than units unreserved.
+// Untracked resources always pass.
+func (s *Service) CheckStock(ctx context.Context, resourceID string, units int64) error {
+	level, err := s.store.GetStock(ctx, resourceID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("paywall: get stock: %w", err)
+	}
+	if available := level.Available(); available < units {
+		return fmt.Errorf("paywall: %w: %s (requested %d, available %d)", ErrOutOfStock, resourceID, units, available)
+	}
+	return nil
+}
+
+// SetStock sets the units on hand for a configured resource. Carts can then hold at most the
+// units not already reserved by other unexpired carts.
+func (s *Service) SetStock(ctx context.Context, resourceID string, quantity int64) (storage.StockLevel, error) {
+	if _, err := s.ResourceDefinition(ctx, resourceID); err != nil {
+		return storage.StockLevel{}, err
+	}
+	if err := s.store.SetStock(ctx, resourceID, quantity); err != nil {
+		return storage.StockLevel{}, err
+	}
+	return s.store.GetStock(ctx, resourceID)
+}
+
+// GetStock returns the stock level for a resource (storage.ErrNotFound if it is untracked).
+func (s *Service) GetStock(ctx context.Context, resourceID string) (storage.StockLevel, error) {
+	return s.store.GetStock(ctx, resourceID)
+}
+
+// DeleteStock stops tracking stock for a resource so it can be sold without limit.
+func (s *Service) DeleteStock(ctx context.Context, resourceID string) error {
+	return s.store.DeleteStock(ctx, resourceID)
+}
@@ -0,0 +1,107 @@
+package paywall
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/CedrosPay/server/internal/callbacks"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func TestCartStockReservations(t *testing.T) {
+	ctx := context.Background()
+	cfg := testConfig()
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	svc := NewService(cfg, store, stubVerifier{}, callbacks.NoopNotifier{}, testRepository(cfg), nil, nil)
+
+	if _, err := svc.SetStock(ctx, "missing", 1); !errors.Is(err, ErrResourceNotConfigured) {
+		t.Fatalf("SetStock unknown resource: err = %v", err)
+	}
+	if _, err := svc.SetStock(ctx, "demo-content", 3); err != nil {
+		t.Fatalf("SetStock: %v", err)
+	}
+
+	quote := func(quantity int64) (CartQuoteResponse, error) {
+		return svc.GenerateCartQuote(ctx, CartQuoteRequest{Items: []CartQuoteItem{{ResourceID: "demo-content", Quantity: quantity}}})
+	}
+	cartA, err := quote(2)
+	if err != nil {
+		t.Fatalf("GenerateCartQuote: %v", err)
+	}
+	if _, err := quote(2); !errors.Is(err, ErrOutOfStock) {
+		t.Fatalf("GenerateCartQuote beyond stock: err = %v, want ErrOutOfStock", err)
+	}
+
+	// Shrinking cart A frees units for another cart; growing it past stock keeps the old hold
+	if _, err := svc.UpdateCartQuote(ctx, cartA.CartID, CartUpdateRequest{Items: []CartItemChange{{ResourceID: "demo-content", Quantity: 1}}}); err != nil {
+		t.Fatalf("UpdateCartQuote: %v", err)
+	}
+	if _, err := quote(2); err != nil {
+		t.Fatalf("GenerateCartQuote after shrink: %v", err)
+	}
+	if _, err := svc.UpdateCartQuote(ctx, cartA.CartID, CartUpdateRequest{Items: []CartItemChange{{ResourceID: "demo-content", Quantity: 2}}}); !errors.Is(err, ErrOutOfStock) {
+		t.Fatalf("UpdateCartQuote beyond stock: err = %v, want ErrOutOfStock", err)
+	}
+
+	// Paying cart A takes its unit out of stock
+	if _, err := svc.Authorize(ctx, cartA.CartID, "", cartPaymentHeader(t, cfg, "cart-signature"), ""); err != nil {
+		t.Fatalf("Authorize cart: %v", err)
+	}
+	level, err := svc.GetStock(ctx, "demo-content")
+	if err != nil || level.Quantity != 2 || level.Reserved != 2 || level.Available() != 0 {
+		t.Errorf("stock after payment = %+v, %v, want quantity 2 reserved 2", level, err)
+	}
+
+	if err := svc.DeleteStock(ctx, "demo-content"); err != nil {
+		t.Fatalf("DeleteStock: %v", err)
+	}
+	if _, err := quote(50); err != nil {
+		t.Errorf("GenerateCartQuote untracked: %v", err)
+	}
+}
+
+func TestSingleResourceStock(t *testing.T) {
+	ctx := context.Background()
+	cfg := testConfig()
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	svc := NewService(cfg, store, stubVerifier{}, callbacks.NoopNotifier{}, testRepository(cfg), nil, nil)
+
+	if _, err := svc.SetStock(ctx, "demo-content", 1); err != nil {
+		t.Fatalf("SetStock: %v", err)
+	}
+	if _, err := svc.GenerateQuote(ctx, "demo-content", ""); err != nil {
+		t.Fatalf("GenerateQuote in stock: %v", err)
+	}
+
+	// Buying the last unit takes it out of stock; later quotes and payments are refused
+	if _, err := svc.Authorize(ctx, "demo-content", "", cartPaymentHeader(t, cfg, "sig-1"), ""); err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+	if level, err := svc.GetStock(ctx, "demo-content"); err != nil || level.Quantity != 0 || level.Reserved != 0 {
+		t.Errorf("stock after payment = %+v, %v, want quantity 0", level, err)
+	}
+	if _, err := svc.GenerateQuote(ctx, "demo-content", ""); !errors.Is(err, ErrOutOfStock) {
+		t.Errorf("GenerateQuote sold out: err = %v, want ErrOutOfStock", err)
+	}
+	if _, err := svc.Authorize(ctx, "demo-content", "", cartPaymentHeader(t, cfg, "sig-2"), ""); !errors.Is(err, ErrOutOfStock) {
+		t.Errorf("Authorize sold out: err = %v, want ErrOutOfStock", err)
+	}
+	if err := svc.CheckStock(ctx, "demo-content", 1); !errors.Is(err, ErrOutOfStock) {
+		t.Errorf("CheckStock sold out: err = %v, want ErrOutOfStock", err)
+	}
+
+	// A failed payment returns its hold
+	if _, err := svc.SetStock(ctx, "demo-content", 1); err != nil {
+		t.Fatalf("SetStock: %v", err)
+	}
+	failing := NewService(cfg, store, stubVerifier{err: errors.New("rpc down")}, callbacks.NoopNotifier{}, testRepository(cfg), nil, nil)
+	if _, err := failing.Authorize(ctx, "demo-content", "", cartPaymentHeader(t, cfg, "sig-3"), ""); err == nil {
+		t.Fatal("Authorize with failing verifier succeeded")
+	}
+	if level, _ := svc.GetStock(ctx, "demo-content"); level.Quantity != 1 || level.Available() != 1 {
+		t.Errorf("stock after failed payment = %+v, want 1 available", level)
+	}
+}
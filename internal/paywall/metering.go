@@ -0,0 +1,313 @@
+package paywall
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/money"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+// ErrMeterBalance indicates a meter account's balance cannot cover a metered call.
+var ErrMeterBalance = storage.ErrMeterBalance
+
+// ErrNotMetered indicates a resource has no metering price.
+var ErrNotMetered = errors.New("paywall: resource is not metered")
+
+// ErrNotDepositResource indicates a resource cannot be paid to top up a meter account.
+var ErrNotDepositResource = errors.New("paywall: resource is not a metering deposit")
+
+// meteringResourceID is the resource metering settlements are recorded against.
+const meteringResourceID = "metering"
+
+// meteringSettlementBatch is how many meter accounts a settlement pass lists at a time.
+const meteringSettlementBatch = 500
+
+// Meter account ID prefixes.
+const (
+	meterWalletPrefix = "wallet:"
+	meterAPIKeyPrefix = "key:"
+)
+
+// MeterAccountForWallet returns the meter account of a wallet.
+func MeterAccountForWallet(wallet string) string {
+	return meterWalletPrefix + wallet
+}
+
+// MeterAccountForAPIKey returns the meter account of an API key. The key is hashed so it is
+// never stored or logged.
+func MeterAccountForAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return meterAPIKeyPrefix + hex.EncodeToString(hash[:])[:32]
+}
+
+// meteringAsset returns the asset meter balances are held in.
+func (s *Service) meteringAsset() (money.Asset, error) {
+	asset, err := money.GetAsset(s.cfg.Metering.Asset)
+	if err != nil {
+		return money.Asset{}, fmt.Errorf("paywall: metering asset: %w", err)
+	}
+	return asset, nil
+}
+
+// MeterPrice returns what one call to a metered resource costs, and false if the resource is
+// not metered.
+func (s *Service) MeterPrice(resourceID string) (money.Money, bool) {
+	price, ok := s.cfg.Metering.Prices[resourceID]
+	if !s.cfg.Metering.Enabled || !ok {
+		return money.Money{}, false
+	}
+	asset, err := s.meteringAsset()
+	if err != nil {
+		return money.Money{}, false
+	}
+	return money.New(asset, price), true
+}
+
+// DepositMetered tops up a meter account by paying a deposit resource. The account is credited
+// with the amount paid after coupons; when account is empty, the paying wallet's account is
+// credited. Without a payment (or with one that does not grant access) nothing is credited and
+// the result carries the deposit's quote.
+func (s *Service) DepositMetered(ctx context.Context, account, resourceID, paymentHeader, couponCode string) (storage.MeterAccount, AuthorizationResult, error) {
+	if !s.cfg.Metering.Enabled || !slices.Contains(s.cfg.Metering.DepositResources, resourceID) {
+		return storage.MeterAccount{}, AuthorizationResult{}, fmt.Errorf("%w: %s", ErrNotDepositResource, resourceID)
+	}
+	asset, err := s.meteringAsset()
+	if err != nil {
+		return storage.MeterAccount{}, AuthorizationResult{}, err
+	}
+	resource, err := s.ResourceDefinition(ctx, resourceID)
+	if err != nil {
+		return storage.MeterAccount{}, AuthorizationResult{}, err
+	}
+	// Checked before taking payment, since a deposit in another asset could not be credited
+	if token, err := money.GetAsset(resource.CryptoToken); err != nil || token.Code != asset.Code {
+		return storage.MeterAccount{}, AuthorizationResult{}, fmt.Errorf("%w: %s is not priced in %s", ErrNotDepositResource, resourceID, asset.Code)
+	}
+
+	result, err := s.Authorize(ctx, resourceID, "", paymentHeader, couponCode)
+	if err != nil || !result.Granted {
+		return storage.MeterAccount{}, result, err
+	}
+	if account == "" {
+		account = MeterAccountForWallet(result.Wallet)
+	}
+	meter, err := s.store.CreditMeterAccount(ctx, account, result.Amount)
+	if err != nil {
+		// The payment is recorded, so the deposit can be credited by hand from its signature
+		log := logger.FromContext(ctx)
+		log.Error().
+			Err(err).
+			Str("meter_account", account).
+			Str("amount", result.Amount.ToMajor()).
+			Msg("metering.deposit_credit_failed")
+		return storage.MeterAccount{}, result, fmt.Errorf("paywall: credit meter account: %w", err)
+	}
+	return meter, result, nil
+}
+
+// ChargeMetered charges one call to a metered resource against a meter account. It fails with
+// ErrMeterBalance, leaving the account unchanged, when the balance does not cover the price.
+func (s *Service) ChargeMetered(ctx context.Context, account, resourceID string) (storage.MeterAccount, error) {
+	price, ok := s.MeterPrice(resourceID)
+	if !ok {
+		return storage.MeterAccount{}, fmt.Errorf("%w: %s", ErrNotMetered, resourceID)
+	}
+	meter, err := s.store.ChargeMeterAccount(ctx, account, price)
+	if err != nil {
+		return meter, fmt.Errorf("paywall: charge meter account: %w", err)
+	}
+	return meter, nil
+}
+
+// RefundMetered reverses one ChargeMetered for a call that could not be served: the call is taken
+// out of the account's unsettled usage and its price returned to the balance. Usage is restored if
+// the balance cannot be credited.
+func (s *Service) RefundMetered(ctx context.Context, account, resourceID string) (storage.MeterAccount, error) {
+	price, ok := s.MeterPrice(resourceID)
+	if !ok {
+		return storage.MeterAccount{}, fmt.Errorf("%w: %s", ErrNotMetered, resourceID)
+	}
+	if err := s.store.SettleMeterAccount(ctx, account, price, 1); err != nil {
+		return storage.MeterAccount{}, fmt.Errorf("paywall: release meter usage: %w", err)
+	}
+	meter, err := s.store.CreditMeterAccount(ctx, account, price)
+	if err != nil {
+		if restoreErr := s.store.SettleMeterAccount(ctx, account, price.Negate(), -1); restoreErr != nil {
+			log := logger.FromContext(ctx)
+			log.Error().
+				Err(restoreErr).
+				Str("meter_account", account).
+				Str("amount", price.ToMajor()).
+				Msg("metering.restore_usage_failed")
+		}
+		return storage.MeterAccount{}, fmt.Errorf("paywall: refund meter charge: %w", err)
+	}
+	return meter, nil
+}
+
+// MeterBalance returns a meter account. Accounts never topped up have a zero balance.
+func (s *Service) MeterBalance(ctx context.Context, account string) (storage.MeterAccount, error) {
+	meter, err := s.store.GetMeterAccount(ctx, account)
+	if errors.Is(err, storage.ErrNotFound) {
+		asset, err := s.meteringAsset()
+		if err != nil {
+			return storage.MeterAccount{}, err
+		}
+		return storage.MeterAccount{Account: account, Balance: money.Zero(asset), Unsettled: money.Zero(asset)}, nil
+	}
+	if err != nil {
+		return storage.MeterAccount{}, fmt.Errorf("paywall: get meter account: %w", err)
+	}
+	return meter, nil
+}
+
+// SettleMetering records each meter account's unsettled usage as one payment against the
+// "metering" resource, and returns how many accounts it settled. Calls charged while a pass
+// runs are left for the next pass.
+func (s *Service) SettleMetering(ctx context.Context, now time.Time) (int, error) {
+	settled := make(map[string]bool)
+	for {
+		accounts, err := s.store.ListUnsettledMeterAccounts(ctx, meteringSettlementBatch)
+		if err != nil {
+			return len(settled), fmt.Errorf("paywall: list unsettled meter accounts: %w", err)
+		}
+		progressed := false
+		for _, account := range accounts {
+			if settled[account.Account] {
+				continue
+			}
+			if err := s.settleMeterAccount(ctx, account, now); err != nil {
+				return len(settled), err
+			}
+			settled[account.Account] = true
+			progressed = true
+		}
+		if !progressed || len(accounts) < meteringSettlementBatch {
+			return len(settled), nil
+		}
+	}
+}
+
+// settleMeterAccount clears a meter account's usage and records it as a payment. Usage is
+// cleared first so concurrent passes cannot record it twice; it is restored if the payment
+// cannot be recorded.
+func (s *Service) settleMeterAccount(ctx context.Context, account storage.MeterAccount, now time.Time) error {
+	if err := s.store.SettleMeterAccount(ctx, account.Account, account.Unsettled, account.Calls); err != nil {
+		return fmt.Errorf("paywall: settle meter account %s: %w", account.Account, err)
+	}
+
+	wallet, isWallet := strings.CutPrefix(account.Account, meterWalletPrefix)
+	if !isWallet {
+		wallet = "" // API key accounts have no wallet
+	}
+	tx := storage.PaymentTransaction{
+		Signature:  fmt.Sprintf("meter:%s:%d", account.Account, now.UnixNano()),
+		ResourceID: meteringResourceID,
+		Wallet:     wallet,
+		Amount:     account.Unsettled,
+		CreatedAt:  now,
+		Metadata: map[string]string{
+			"status":        "metered",
+			"meter_account": account.Account,
+			"meter_calls":   strconv.FormatInt(account.Calls, 10),
+		},
+	}
+	if err := s.store.RecordPayment(ctx, tx); err != nil {
+		if restoreErr := s.store.SettleMeterAccount(ctx, account.Account, account.Unsettled.Negate(), -account.Calls); restoreErr != nil {
+			log := logger.FromContext(ctx)
+			log.Error().
+				Err(restoreErr).
+				Str("meter_account", account.Account).
+				Str("unsettled", account.Unsettled.ToMajor()).
+				Int64("calls", account.Calls).
+				Msg("metering.restore_usage_failed")
+		}
+		return fmt.Errorf("paywall: record metering settlement for %s: %w", account.Account, err)
+	}
+	return nil
+}
+
+// MeteringSettlementMonitor periodically records metered usage as payments.
+type MeteringSettlementMonitor struct {
+	service  *Service
+	interval time.Duration
+	logger   zerolog.Logger
+	stopChan chan struct{}
+	doneChan chan struct{}
+}
+
+// NewMeteringSettlementMonitor creates a monitor that settles metered usage every interval
+// (default: 1 hour).
+func NewMeteringSettlementMonitor(service *Service, interval time.Duration, logger zerolog.Logger) *MeteringSettlementMonitor {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	return &MeteringSettlementMonitor{
+		service:  service,
+		interval: interval,
+		logger:   logger,
+		stopChan: make(chan struct{}),
+		doneChan: make(chan struct{}),
+	}
+}
+
+// Start begins the monitor's background loop.
+func (m *MeteringSettlementMonitor) Start() {
+	m.logger.Info().
+		Str("asset", m.service.cfg.Metering.Asset).
+		Dur("runInterval", m.interval).
+		Msg("metering.settlement_monitor_started")
+
+	go m.run()
+}
+
+// Stop stops the monitor and waits for a pass in progress to finish.
+func (m *MeteringSettlementMonitor) Stop() {
+	close(m.stopChan)
+	<-m.doneChan
+}
+
+// run is the main monitor loop.
+func (m *MeteringSettlementMonitor) run() {
+	defer close(m.doneChan)
+
+	m.check()
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.check()
+		case <-m.stopChan:
+			return
+		}
+	}
+}
+
+// check performs a single settlement pass.
+func (m *MeteringSettlementMonitor) check() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	count, err := m.service.SettleMetering(ctx, time.Now())
+	if err != nil {
+		m.logger.Error().Err(err).Msg("metering.settlement_failed")
+		return
+	}
+	if count > 0 {
+		m.logger.Info().Int("count", count).Msg("metering.settled")
+	}
+}
@@ -0,0 +1,115 @@
+package paywall
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/CedrosPay/server/internal/callbacks"
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/pkg/x402"
+)
+
+func TestMetering(t *testing.T) {
+	ctx := context.Background()
+	cfg := testConfig()
+	cfg.Metering = config.MeteringConfig{
+		Enabled:          true,
+		Asset:            "USDC",
+		DepositResources: []string{"demo-content"},
+		Prices:           map[string]int64{"api-call": 400000},
+	}
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	svc := NewService(cfg, store, stubVerifier{result: x402.VerificationResult{Wallet: "payer-wallet"}}, callbacks.NoopNotifier{}, testRepository(cfg), nil, nil)
+	account := MeterAccountForWallet("payer-wallet")
+
+	if _, result, err := svc.DepositMetered(ctx, "", "demo-content", "", ""); err != nil || result.Granted || result.Quote == nil {
+		t.Fatalf("DepositMetered without payment = %+v, %v; want a quote", result, err)
+	}
+	if _, _, err := svc.DepositMetered(ctx, "", "other-content", cartPaymentHeader(t, cfg, "sig-other"), ""); !errors.Is(err, ErrNotDepositResource) {
+		t.Fatalf("DepositMetered other resource err = %v, want ErrNotDepositResource", err)
+	}
+	meter, _, err := svc.DepositMetered(ctx, "", "demo-content", cartPaymentHeader(t, cfg, "sig-deposit"), "")
+	if err != nil {
+		t.Fatalf("DepositMetered: %v", err)
+	}
+	if meter.Account != account || meter.Balance.ToMajor() != "1.000000" {
+		t.Fatalf("deposit credited %+v, want 1.000000 to %s", meter, account)
+	}
+
+	charges := []struct {
+		name        string
+		resource    string
+		refund      bool
+		wantErr     error
+		wantBalance string
+	}{
+		{name: "first call", resource: "api-call", wantBalance: "0.600000"},
+		{name: "refunded call", resource: "api-call", refund: true, wantBalance: "0.600000"},
+		{name: "second call", resource: "api-call", wantBalance: "0.200000"},
+		{name: "balance too low", resource: "api-call", wantErr: ErrMeterBalance, wantBalance: "0.200000"},
+		{name: "unmetered resource", resource: "demo-content", wantErr: ErrNotMetered, wantBalance: "0.200000"},
+	}
+	for _, charge := range charges {
+		if _, err := svc.ChargeMetered(ctx, account, charge.resource); !errors.Is(err, charge.wantErr) {
+			t.Fatalf("%s: ChargeMetered err = %v, want %v", charge.name, err, charge.wantErr)
+		}
+		if charge.refund {
+			if _, err := svc.RefundMetered(ctx, account, charge.resource); err != nil {
+				t.Fatalf("%s: RefundMetered: %v", charge.name, err)
+			}
+		}
+		meter, err := svc.MeterBalance(ctx, account)
+		if err != nil {
+			t.Fatalf("%s: MeterBalance: %v", charge.name, err)
+		}
+		if meter.Balance.ToMajor() != charge.wantBalance {
+			t.Errorf("%s: balance = %s, want %s", charge.name, meter.Balance.ToMajor(), charge.wantBalance)
+		}
+	}
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	if settled, err := svc.SettleMetering(ctx, now); err != nil || settled != 1 {
+		t.Fatalf("SettleMetering = %d, %v; want 1 account", settled, err)
+	}
+	payments, err := store.ListPaymentsByPayer(ctx, []string{"payer-wallet"}, 0)
+	if err != nil {
+		t.Fatalf("ListPaymentsByPayer: %v", err)
+	}
+	var settlement *storage.PaymentTransaction
+	for i := range payments {
+		if payments[i].ResourceID == meteringResourceID {
+			settlement = &payments[i]
+		}
+	}
+	if settlement == nil || settlement.Amount.ToMajor() != "0.800000" || settlement.Metadata["meter_calls"] != "2" {
+		t.Fatalf("metering settlement = %+v, want 0.800000 over 2 calls", settlement)
+	}
+	if settled, err := svc.SettleMetering(ctx, now); err != nil || settled != 0 {
+		t.Fatalf("second SettleMetering = %d, %v; want nothing to settle", settled, err)
+	}
+
+	// A settlement that cannot be recorded leaves the usage for the next pass
+	if _, err := svc.ChargeMetered(ctx, account, "api-call"); err == nil {
+		t.Fatal("ChargeMetered succeeded with 0.20 left, want ErrMeterBalance")
+	}
+	if _, _, err := svc.DepositMetered(ctx, account, "demo-content", cartPaymentHeader(t, cfg, "sig-top-up"), ""); err != nil {
+		t.Fatalf("DepositMetered: %v", err)
+	}
+	if _, err := svc.ChargeMetered(ctx, account, "api-call"); err != nil {
+		t.Fatalf("ChargeMetered: %v", err)
+	}
+	if _, err := svc.SettleMetering(ctx, now); err == nil {
+		t.Fatal("SettleMetering reusing a settlement signature succeeded, want error")
+	}
+	meter, err = svc.MeterBalance(ctx, account)
+	if err != nil {
+		t.Fatalf("MeterBalance: %v", err)
+	}
+	if meter.Unsettled.ToMajor() != "0.400000" || meter.Calls != 1 {
+		t.Errorf("usage after failed settlement = %s over %d calls, want 0.400000 over 1", meter.Unsettled.ToMajor(), meter.Calls)
+	}
+}
@@ -14,6 +14,7 @@ type contextKey string
 const (
 	contextKeyAuthorization contextKey = "paywall.authorization"
 	contextKeyResourceID    contextKey = "paywall.resourceID"
+	contextKeyClientIP      contextKey = "paywall.clientIP"
 )
 
 // ResourceResolver extracts the paywall resource identifier from the request.
@@ -49,6 +50,22 @@ func (s *Service) Middleware(resolver ResourceResolver) func(http.Handler) http.
 					responders.JSON(w, http.StatusPaymentRequired, map[string]any{"error": err.Error()})
 					return
 				}
+				if errors.Is(err, ErrOutOfStock) {
+					responders.JSON(w, http.StatusConflict, map[string]any{"error": err.Error()})
+					return
+				}
+				if errors.Is(err, ErrVelocityLimit) {
+					responders.JSON(w, http.StatusTooManyRequests, map[string]any{"error": err.Error()})
+					return
+				}
+				if errors.Is(err, ErrWalletFlagged) {
+					responders.JSON(w, http.StatusUnavailableForLegalReasons, map[string]any{"error": err.Error()})
+					return
+				}
+				if errors.Is(err, ErrScreeningUnavailable) {
+					responders.JSON(w, http.StatusServiceUnavailable, map[string]any{"error": err.Error()})
+					return
+				}
 				responders.JSON(w, http.StatusForbidden, map[string]any{
 					"error": err.Error(),
 				})
@@ -58,19 +75,12 @@ func (s *Service) Middleware(resolver ResourceResolver) func(http.Handler) http.
 			if !result.Granted {
 				// Build x402 compliant Payment Required Response
 				// Reference: https://github.com/coinbase/x402
-				response := map[string]any{
-					"x402Version": 0,
-					"error":       "payment required",
-				}
-
-				// Build accepts array with payment requirements
-				var accepts []any
-				if result.Quote != nil && result.Quote.Crypto != nil {
-					accepts = append(accepts, result.Quote.Crypto)
-				}
-				if len(accepts) > 0 {
-					response["accepts"] = accepts
+				var crypto *CryptoQuote
+				if result.Quote != nil {
+					crypto = result.Quote.Crypto
 				}
+				response := s.PaymentRequired(crypto)
+				response["error"] = "payment required"
 
 				responders.JSON(w, http.StatusPaymentRequired, response)
 				return
@@ -93,6 +103,17 @@ func AuthorizationFromContext(ctx context.Context) (AuthorizationResult, bool) {
 	return result, ok
 }
 
+// WithClientIP records the requesting client's IP, which the quotes_per_ip fraud rule counts.
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, contextKeyClientIP, ip)
+}
+
+// clientIPFromContext returns the IP recorded by WithClientIP, or "" when there is none.
+func clientIPFromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(contextKeyClientIP).(string)
+	return ip
+}
+
 // ResourceIDFromContext retrieves the resolved resource identifier.
 func ResourceIDFromContext(ctx context.Context) (string, bool) {
 	val := ctx.Value(contextKeyResourceID)
@@ -1,6 +1,7 @@
 package paywall
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/CedrosPay/server/internal/coupons"
@@ -39,6 +40,7 @@ func isUSDPegged(assetCode string) bool {
 //
 // All arithmetic is done using int64 atomic units to avoid floating-point errors.
 // The roundingMode parameter controls how fractional cents are rounded.
+// Quantity rule coupons are skipped: they apply to cart items (see StackCouponsOnCart).
 func StackCouponsOnMoney(originalPrice money.Money, applicableCoupons []coupons.Coupon, roundingMode money.RoundingMode) (money.Money, error) {
 	if len(applicableCoupons) == 0 {
 		return originalPrice, nil
@@ -49,6 +51,9 @@ func StackCouponsOnMoney(originalPrice money.Money, applicableCoupons []coupons.
 
 	// Single pass: apply percentage coupons and accumulate fixed discounts
 	for _, coupon := range applicableCoupons {
+		if coupon.HasQuantityRule() {
+			continue
+		}
 		if coupon.DiscountType == coupons.DiscountTypePercentage {
 			// Apply percentage discount using precise integer arithmetic with configured rounding
 			discounted, err := price.ApplyPercentageDiscountWithRounding(coupon.DiscountValue, roundingMode)
@@ -91,3 +96,118 @@ func StackCouponsOnMoney(originalPrice money.Money, applicableCoupons []coupons.
 
 	return price, nil
 }
+
+// unitCoupons drops quantity rule coupons from applicable: a single resource is bought one unit
+// at a time, so they never apply to it.
+func unitCoupons(applicable []coupons.Coupon) []coupons.Coupon {
+	var result []coupons.Coupon
+	for _, coupon := range applicable {
+		if !coupon.HasQuantityRule() {
+			result = append(result, coupon)
+		}
+	}
+	return result
+}
+
+// CouponItem is a cart item quantity rule coupons are evaluated against.
+type CouponItem struct {
+	ResourceID string
+	Quantity   int64
+	UnitPrice  money.Money      // After catalog coupons, in the cart's token
+	Coupons    []coupons.Coupon // The item's own (catalog) coupons
+}
+
+// ItemDiscount is the part of a cart item's line total a quantity rule coupon takes off.
+type ItemDiscount struct {
+	Coupon string
+	Units  int64 // Units of the item the rule discounted
+	Amount money.Money
+}
+
+// StackCouponsOnCart applies checkout coupons to a cart's subtotal. Quantity rule coupons among
+// each item's own coupons and applicableCoupons are evaluated against the item first, their
+// discounts taken off the subtotal, and the remaining coupons then stacked on it with
+// StackCouponsOnMoney. It returns the discounted total and each item's rule discounts, in the
+// order of items.
+//
+// Example:
+//
+//	Items: 3 x $2.00 with "10% off 3 or more", 2 x $5.00 with "buy one get one free"
+//	Item 1: 3 units at $0.20 off = $0.60 off
+//	Item 2: 1 unit at $5.00 off = $5.00 off
+//	Subtotal: $16.00 - $5.60 = $10.40, then checkout coupons
+func StackCouponsOnCart(subtotal money.Money, items []CouponItem, applicableCoupons []coupons.Coupon, roundingMode money.RoundingMode) (money.Money, [][]ItemDiscount, error) {
+	discounts := make([][]ItemDiscount, len(items))
+	total := subtotal
+	for i, item := range items {
+		lineTotal, err := item.UnitPrice.Mul(item.Quantity)
+		if err != nil {
+			return money.Money{}, nil, fmt.Errorf("multiply item price by quantity: %w", err)
+		}
+		for _, coupon := range append(append([]coupons.Coupon{}, item.Coupons...), applicableCoupons...) {
+			rule, ok := coupon.QuantityRule()
+			if !ok || !coupon.AppliesToProduct(item.ResourceID) {
+				continue
+			}
+			units := rule.DiscountedUnits(item.Quantity)
+			if units == 0 {
+				continue
+			}
+			unitDiscount, err := quantityRuleUnitDiscount(coupon, item.UnitPrice, roundingMode)
+			if err != nil {
+				return money.Money{}, nil, err
+			}
+			amount, err := unitDiscount.Mul(units)
+			if err != nil {
+				return money.Money{}, nil, fmt.Errorf("multiply unit discount by units: %w", err)
+			}
+			// Rules stack on an item until its line total is used up
+			if amount.GreaterThan(lineTotal) {
+				amount = lineTotal
+			}
+			if amount.IsZero() {
+				continue
+			}
+			if lineTotal, err = lineTotal.Sub(amount); err != nil {
+				return money.Money{}, nil, err
+			}
+			if total, err = total.ApplyFixedDiscount(amount); err != nil {
+				return money.Money{}, nil, err
+			}
+			discounts[i] = append(discounts[i], ItemDiscount{Coupon: coupon.Code, Units: units, Amount: amount})
+		}
+	}
+
+	total, err := StackCouponsOnMoney(total, applicableCoupons, roundingMode)
+	if err != nil {
+		return money.Money{}, nil, err
+	}
+	return total, discounts, nil
+}
+
+// quantityRuleUnitDiscount returns how much a quantity rule coupon takes off each unit it
+// discounts. Fixed amounts only apply to USD-pegged tokens, as in StackCouponsOnMoney.
+func quantityRuleUnitDiscount(coupon coupons.Coupon, unitPrice money.Money, roundingMode money.RoundingMode) (money.Money, error) {
+	switch coupon.DiscountType {
+	case coupons.DiscountTypePercentage:
+		discounted, err := unitPrice.ApplyPercentageDiscountWithRounding(coupon.DiscountValue, roundingMode)
+		if err != nil {
+			return money.Money{}, err
+		}
+		return unitPrice.Sub(discounted)
+	case coupons.DiscountTypeFixed:
+		if !isUSDPegged(unitPrice.Asset.Code) {
+			return money.Zero(unitPrice.Asset), nil
+		}
+		discount, err := money.FromMajor(unitPrice.Asset, formatFloat(coupon.DiscountValue))
+		if err != nil {
+			return money.Zero(unitPrice.Asset), nil // Skip invalid discount
+		}
+		if discount.GreaterThan(unitPrice) {
+			return unitPrice, nil
+		}
+		return discount, nil
+	default:
+		return money.Zero(unitPrice.Asset), nil
+	}
+}
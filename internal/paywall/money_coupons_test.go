@@ -0,0 +1,146 @@
+package paywall
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/coupons"
+	"github.com/CedrosPay/server/internal/money"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func TestStackCouponsOnCart(t *testing.T) {
+	usdc := money.MustGetAsset("USDC")
+	threeOrMore := coupons.Coupon{Code: "BULK10", DiscountType: coupons.DiscountTypePercentage, DiscountValue: 10, Scope: coupons.ScopeAll, Metadata: map[string]string{coupons.MetadataMinQuantity: "3"}}
+	bogo := coupons.Coupon{Code: "BOGO", DiscountType: coupons.DiscountTypePercentage, DiscountValue: 100, Scope: coupons.ScopeSpecific, ProductIDs: []string{"poster"}, Metadata: map[string]string{coupons.MetadataBuyQuantity: "1", coupons.MetadataGetQuantity: "1"}}
+	dollarOff := coupons.Coupon{Code: "DOLLAR", DiscountType: coupons.DiscountTypeFixed, DiscountValue: 1, Scope: coupons.ScopeAll, Metadata: map[string]string{coupons.MetadataMinQuantity: "2"}}
+	half := coupons.Coupon{Code: "HALF", DiscountType: coupons.DiscountTypePercentage, DiscountValue: 50, Scope: coupons.ScopeAll}
+
+	tests := []struct {
+		name          string
+		items         []CouponItem
+		coupons       []coupons.Coupon
+		wantTotal     int64
+		wantDiscounts [][]ItemDiscount
+	}{
+		{
+			name: "percentage off from a quantity",
+			items: []CouponItem{
+				{ResourceID: "sticker", Quantity: 3, UnitPrice: money.New(usdc, 2000000)},
+				{ResourceID: "pin", Quantity: 2, UnitPrice: money.New(usdc, 2000000)},
+			},
+			coupons:       []coupons.Coupon{threeOrMore},
+			wantTotal:     9400000,
+			wantDiscounts: [][]ItemDiscount{{{Coupon: "BULK10", Units: 3, Amount: money.New(usdc, 600000)}}, nil},
+		},
+		{
+			name:          "buy one get one free on the item's own coupon",
+			items:         []CouponItem{{ResourceID: "poster", Quantity: 5, UnitPrice: money.New(usdc, 5000000), Coupons: []coupons.Coupon{bogo}}},
+			wantTotal:     15000000,
+			wantDiscounts: [][]ItemDiscount{{{Coupon: "BOGO", Units: 2, Amount: money.New(usdc, 10000000)}}},
+		},
+		{
+			name:          "fixed amount capped at the unit price, then checkout coupons",
+			items:         []CouponItem{{ResourceID: "pin", Quantity: 2, UnitPrice: money.New(usdc, 500000)}},
+			coupons:       []coupons.Coupon{dollarOff, half},
+			wantTotal:     0,
+			wantDiscounts: [][]ItemDiscount{{{Coupon: "DOLLAR", Units: 2, Amount: money.New(usdc, 1000000)}}},
+		},
+		{
+			name:          "rules stack on an item before checkout coupons",
+			items:         []CouponItem{{ResourceID: "poster", Quantity: 4, UnitPrice: money.New(usdc, 1000000), Coupons: []coupons.Coupon{bogo}}},
+			coupons:       []coupons.Coupon{threeOrMore, half},
+			wantTotal:     800000,
+			wantDiscounts: [][]ItemDiscount{{{Coupon: "BOGO", Units: 2, Amount: money.New(usdc, 2000000)}, {Coupon: "BULK10", Units: 4, Amount: money.New(usdc, 400000)}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subtotal := money.Zero(usdc)
+			for _, item := range tt.items {
+				line, _ := item.UnitPrice.Mul(item.Quantity)
+				subtotal, _ = subtotal.Add(line)
+			}
+			total, discounts, err := StackCouponsOnCart(subtotal, tt.items, tt.coupons, money.RoundingStandard)
+			if err != nil {
+				t.Fatalf("StackCouponsOnCart error: %v", err)
+			}
+			if total.Atomic != tt.wantTotal {
+				t.Errorf("total = %d, want %d", total.Atomic, tt.wantTotal)
+			}
+			if !reflect.DeepEqual(discounts, tt.wantDiscounts) {
+				t.Errorf("discounts = %+v, want %+v", discounts, tt.wantDiscounts)
+			}
+		})
+	}
+}
+
+func TestCartQuantityRules(t *testing.T) {
+	ctx := context.Background()
+	cfg := testConfig()
+	poster := cfg.Paywall.Resources["demo-content"]
+	poster.ResourceID, poster.CryptoAtomicAmount = "poster", 5000000
+	cfg.Paywall.Resources["poster"] = poster
+	repo := coupons.NewYAMLRepository(map[string]config.Coupon{
+		"BULK10": {Code: "BULK10", DiscountType: "percentage", DiscountValue: 10, Scope: "all", AutoApply: true, AppliesAt: "checkout", Active: true, Metadata: map[string]string{"min_quantity": "3"}},
+		"BOGO":   {Code: "BOGO", DiscountType: "percentage", DiscountValue: 100, Scope: "specific", ProductIDs: []string{"poster"}, AutoApply: true, AppliesAt: "catalog", Active: true, Metadata: map[string]string{"buy_quantity": "1", "get_quantity": "1"}},
+	})
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	svc := NewService(cfg, store, stubVerifier{}, &recordingNotifier{}, testRepository(cfg), repo, nil)
+
+	tests := []struct {
+		name          string
+		items         []CartQuoteItem
+		wantTotal     string
+		wantCodes     string
+		wantDiscounts [][]CartItemDiscount
+	}{
+		{
+			name:          "below every rule",
+			items:         []CartQuoteItem{{ResourceID: "demo-content", Quantity: 2}, {ResourceID: "poster", Quantity: 1}},
+			wantTotal:     "7000000",
+			wantDiscounts: [][]CartItemDiscount{nil, nil},
+		},
+		{
+			name:      "quantity and buy-one-get-one rules",
+			items:     []CartQuoteItem{{ResourceID: "demo-content", Quantity: 3}, {ResourceID: "poster", Quantity: 2}},
+			wantTotal: "7700000",
+			wantCodes: "BOGO,BULK10",
+			wantDiscounts: [][]CartItemDiscount{
+				{{Coupon: "BULK10", Units: 3, Amount: 0.3, AmountDecimal: "0.300000"}},
+				{{Coupon: "BOGO", Units: 1, Amount: 5, AmountDecimal: "5.000000"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cart, err := svc.GenerateCartQuote(ctx, CartQuoteRequest{Items: tt.items})
+			if err != nil {
+				t.Fatalf("GenerateCartQuote error: %v", err)
+			}
+			if cart.Quote.MaxAmountRequired != tt.wantTotal {
+				t.Errorf("total = %s, want %s", cart.Quote.MaxAmountRequired, tt.wantTotal)
+			}
+			if cart.Metadata["coupon_codes"] != tt.wantCodes {
+				t.Errorf("coupon_codes = %q, want %q", cart.Metadata["coupon_codes"], tt.wantCodes)
+			}
+			for i, item := range cart.Items {
+				if !reflect.DeepEqual(item.Discounts, tt.wantDiscounts[i]) {
+					t.Errorf("item %s discounts = %+v, want %+v", item.ResourceID, item.Discounts, tt.wantDiscounts[i])
+				}
+			}
+		})
+	}
+
+	// A single resource is one unit, so quantity rules never apply to it
+	quote, err := svc.GenerateQuote(ctx, "poster", "")
+	if err != nil {
+		t.Fatalf("GenerateQuote error: %v", err)
+	}
+	if quote.Crypto.MaxAmountRequired != "5000000" {
+		t.Errorf("single quote = %s, want 5000000", quote.Crypto.MaxAmountRequired)
+	}
+}
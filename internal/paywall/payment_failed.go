@@ -0,0 +1,49 @@
+package paywall
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/CedrosPay/server/internal/callbacks"
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/pkg/x402"
+)
+
+// paymentFailureReason is the error code reported for a failed x402 verification, in metrics and
+// payment.failed events.
+func paymentFailureReason(err error) string {
+	if vErr, ok := err.(x402.VerificationError); ok {
+		return string(vErr.Code)
+	} else if errors.Is(err, ErrVelocityLimit) {
+		return "velocity_limit"
+	} else if errors.Is(err, ErrAccessDenied) {
+		return "access_denied"
+	} else if errors.Is(err, ErrWalletFlagged) {
+		return "compliance_blocked"
+	} else if errors.Is(err, ErrScreeningUnavailable) {
+		return "screening_unavailable"
+	} else if errors.Is(err, storage.ErrContributionExceedsTotal) {
+		return "amount_mismatch"
+	}
+	return "verification_failed"
+}
+
+// notifyPaymentFailed sends a payment.failed event for a rejected x402 payment of resourceID (a
+// resource or cart), so merchants can follow up on it. Before verification the paying wallet
+// is unknown and the payer named in the proof, if any, is reported.
+func (s *Service) notifyPaymentFailed(ctx context.Context, resourceID, code string, err error, proof x402.PaymentProof, wallet, signature string) {
+	if wallet == "" {
+		wallet = proof.Payer
+	}
+	s.notifier.PaymentFailed(ctx, callbacks.PaymentFailedEvent{
+		ResourceID:     resourceID,
+		Method:         "x402",
+		ErrorCode:      code,
+		ErrorMessage:   err.Error(), // User-facing; VerificationError keeps technical details in Err
+		Wallet:         wallet,
+		ProofSignature: signature,
+		Metadata:       proof.Metadata,
+		FailedAt:       time.Now().UTC(),
+	})
+}
@@ -0,0 +1,66 @@
+package paywall
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	apierrors "github.com/CedrosPay/server/internal/errors"
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/pkg/x402"
+)
+
+func TestAuthorizeNotifiesPaymentFailures(t *testing.T) {
+	tests := []struct {
+		name       string
+		verifier   stubVerifier
+		wantCode   string
+		wantWallet string
+	}{
+		{
+			name:     "verification failed",
+			verifier: stubVerifier{err: x402.VerificationError{Code: apierrors.ErrCodeTransactionFailed, Message: "transaction failed", Err: errors.New("rpc: simulation failed")}},
+			wantCode: string(apierrors.ErrCodeTransactionFailed),
+		},
+		{
+			name:       "amount mismatch",
+			verifier:   stubVerifier{result: x402.VerificationResult{Wallet: "payer-wallet", Amount: 0.5}},
+			wantCode:   "amount_mismatch",
+			wantWallet: "payer-wallet",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			store := storage.NewMemoryStore()
+			t.Cleanup(func() { _ = store.Close() })
+			notifier := &recordingNotifier{}
+			svc := NewService(cfg, store, tt.verifier, notifier, testRepository(cfg), nil, nil)
+
+			payload, _ := json.Marshal(x402.PaymentPayload{
+				Scheme:  "solana-spl-transfer",
+				Network: cfg.X402.Network,
+				Payload: x402.SolanaPayload{
+					Signature:   computeSignature("demo-content", cfg.X402.PaymentAddress, tt.name),
+					Transaction: base64.StdEncoding.EncodeToString([]byte("signed-tx")),
+				},
+			})
+			if _, err := svc.Authorize(context.Background(), "demo-content", "", base64.StdEncoding.EncodeToString(payload), ""); err == nil {
+				t.Fatal("Authorize succeeded, want a failed payment")
+			}
+
+			if len(notifier.failures) != 1 || len(notifier.payments) != 0 {
+				t.Fatalf("got %d failure and %d payment callbacks, want one failure", len(notifier.failures), len(notifier.payments))
+			}
+			got := notifier.failures[0]
+			if got.ResourceID != "demo-content" || got.Method != "x402" || got.ErrorCode != tt.wantCode || got.Wallet != tt.wantWallet {
+				t.Errorf("failure = %+v, want demo-content %s from %q", got, tt.wantCode, tt.wantWallet)
+			}
+			if got.ErrorMessage == "" || got.ErrorMessage == "rpc: simulation failed" {
+				t.Errorf("error message = %q, want the user-facing message", got.ErrorMessage)
+			}
+		})
+	}
+}
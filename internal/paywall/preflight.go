@@ -0,0 +1,52 @@
+package paywall
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/CedrosPay/server/internal/money"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+// PaymentTarget is what a payer would be asked to pay for a resource or cart right now.
+type PaymentTarget struct {
+	Amount  money.Money // Amount after coupons, as quoted
+	Gasless bool        // Whether a server wallet would pay the network fees
+}
+
+// PaymentTarget returns the amount a payer would be quoted for resourceID (a resource or a
+// cart_ ID) and whether gasless applies, without creating a quote.
+func (s *Service) PaymentTarget(ctx context.Context, resourceID, couponCode string) (PaymentTarget, error) {
+	if strings.HasPrefix(resourceID, "cart_") {
+		cart, err := s.GetCartQuote(ctx, resourceID)
+		if err != nil {
+			return PaymentTarget{}, err
+		}
+		if cart.IsExpiredAt(time.Now()) {
+			return PaymentTarget{}, storage.ErrCartExpired
+		}
+		return PaymentTarget{Amount: cart.Total, Gasless: s.getFeePayerPublicKey() != ""}, nil
+	}
+
+	quote, err := s.GenerateQuote(ctx, resourceID, couponCode)
+	if err != nil {
+		return PaymentTarget{}, err
+	}
+	if quote.Crypto == nil {
+		return PaymentTarget{}, ErrNoCryptoPrice
+	}
+	extra, _ := quote.Crypto.Extra.(map[string]any)
+	token, _ := extra["tokenSymbol"].(string)
+	asset, err := money.GetAsset(token)
+	if err != nil {
+		return PaymentTarget{}, fmt.Errorf("paywall: get asset for token %s: %w", token, err)
+	}
+	amount, err := money.FromAtomic(asset, quote.Crypto.MaxAmountRequired)
+	if err != nil {
+		return PaymentTarget{}, fmt.Errorf("paywall: parse quoted amount: %w", err)
+	}
+	_, gasless := extra["feePayer"]
+	return PaymentTarget{Amount: amount, Gasless: gasless}, nil
+}
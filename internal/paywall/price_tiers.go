@@ -0,0 +1,16 @@
+package paywall
+
+import "github.com/CedrosPay/server/internal/config"
+
+// tierPrice returns the crypto unit price of a cart line of quantity units of resource: the
+// price of the highest tier the quantity reaches, with that tier's min quantity, or the
+// resource's own price and 0 below every tier.
+func tierPrice(resource config.PaywallResource, quantity int64) (atomic, minQuantity int64) {
+	atomic = resource.CryptoAtomicAmount
+	for _, tier := range resource.PriceTiers {
+		if quantity >= tier.MinQuantity && tier.MinQuantity > minQuantity {
+			atomic, minQuantity = tier.CryptoAtomicAmount, tier.MinQuantity
+		}
+	}
+	return atomic, minQuantity
+}
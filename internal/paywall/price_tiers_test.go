@@ -0,0 +1,88 @@
+package paywall
+
+import (
+	"context"
+	"testing"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func TestTierPrice(t *testing.T) {
+	resource := config.PaywallResource{
+		CryptoAtomicAmount: 5000000,
+		PriceTiers: []config.PriceTier{
+			{MinQuantity: 50, CryptoAtomicAmount: 3000000}, // Out of order on purpose
+			{MinQuantity: 10, CryptoAtomicAmount: 4000000},
+		},
+	}
+
+	tests := []struct {
+		quantity     int64
+		wantAtomic   int64
+		wantQuantity int64
+	}{
+		{quantity: 1, wantAtomic: 5000000},
+		{quantity: 9, wantAtomic: 5000000},
+		{quantity: 10, wantAtomic: 4000000, wantQuantity: 10},
+		{quantity: 49, wantAtomic: 4000000, wantQuantity: 10},
+		{quantity: 50, wantAtomic: 3000000, wantQuantity: 50},
+		{quantity: 1000, wantAtomic: 3000000, wantQuantity: 50},
+	}
+	for _, tt := range tests {
+		atomic, minQuantity := tierPrice(resource, tt.quantity)
+		if atomic != tt.wantAtomic || minQuantity != tt.wantQuantity {
+			t.Errorf("tierPrice(%d) = %d, %d; want %d, %d", tt.quantity, atomic, minQuantity, tt.wantAtomic, tt.wantQuantity)
+		}
+	}
+}
+
+func TestCartVolumePricing(t *testing.T) {
+	ctx := context.Background()
+	cfg := testConfig()
+	resource := cfg.Paywall.Resources["demo-content"]
+	resource.PriceTiers = []config.PriceTier{{MinQuantity: 10, CryptoAtomicAmount: 800000}}
+	resource.Variants = map[string]config.ResourceVariant{
+		"gift":    {Description: "demo, gift wrapped"},
+		"premium": {CryptoAtomicAmount: 2000000},
+	}
+	cfg.Paywall.Resources["demo-content"] = resource
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	svc := NewService(cfg, store, stubVerifier{}, nil, testRepository(cfg), nil, nil)
+
+	quote, err := svc.GenerateCartQuote(ctx, CartQuoteRequest{Items: []CartQuoteItem{
+		{ResourceID: "demo-content", Quantity: 10},
+		{ResourceID: "demo-content", VariantID: "gift", Quantity: 9},
+		{ResourceID: "demo-content", VariantID: "premium", Quantity: 10}, // Own price: no tiers
+	}})
+	if err != nil {
+		t.Fatalf("GenerateCartQuote error: %v", err)
+	}
+	wantItems := []struct {
+		price, original, tierPrice float64
+		tierQuantity               int64
+	}{
+		{price: 0.8, original: 1, tierPrice: 0.8, tierQuantity: 10},
+		{price: 1, original: 1},
+		{price: 2, original: 2},
+	}
+	for i, want := range wantItems {
+		item := quote.Items[i]
+		if float64(item.PriceAmount) != want.price || float64(item.OriginalPrice) != want.original || float64(item.TierPrice) != want.tierPrice || item.TierQuantity != want.tierQuantity {
+			t.Errorf("item %d = %+v, want price %v (original %v, tier %v from %d)", i, item, want.price, want.original, want.tierPrice, want.tierQuantity)
+		}
+	}
+	if quote.TotalAmount != 37 {
+		t.Errorf("total = %v, want 37 (10 at 0.80, 9 at 1.00, 10 at 2.00)", quote.TotalAmount)
+	}
+
+	// Changing the quantity re-evaluates the tier
+	updated, err := svc.UpdateCartQuote(ctx, quote.CartID, CartUpdateRequest{Items: []CartItemChange{{ResourceID: "demo-content", VariantID: "gift", Quantity: 12}}})
+	if err != nil {
+		t.Fatalf("UpdateCartQuote error: %v", err)
+	}
+	if item := updated.Items[1]; item.PriceAmount != 0.8 || item.TierQuantity != 10 {
+		t.Errorf("updated gift line = %+v, want the 10+ tier", item)
+	}
+}
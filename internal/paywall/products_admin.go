@@ -0,0 +1,164 @@
+package paywall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/CedrosPay/server/internal/money"
+	"github.com/CedrosPay/server/internal/products"
+)
+
+// ErrInvalidProduct indicates a product definition failed validation.
+var ErrInvalidProduct = errors.New("paywall: invalid product")
+
+const maxProductIDLength = 255
+
+// ProductRequest defines a product in the catalog. Prices are decimal strings in major units
+// ("1.50") so they are never rounded through floating point.
+type ProductRequest struct {
+	ID             string            `json:"id,omitempty"` // Required on create; taken from the path on update
+	Description    string            `json:"description"`
+	FiatAmount     string            `json:"fiatAmount,omitempty"`     // Stripe price, e.g. "10.00"
+	FiatCurrency   string            `json:"fiatCurrency,omitempty"`   // Defaults to "usd"
+	StripePriceID  string            `json:"stripePriceId,omitempty"`  // Existing Stripe price to charge
+	CryptoAmount   string            `json:"cryptoAmount,omitempty"`   // x402 price, e.g. "1.5"
+	CryptoToken    string            `json:"cryptoToken,omitempty"`    // Stablecoin symbol or mint; defaults to x402.token_mint
+	CryptoMint     string            `json:"cryptoMint,omitempty"`     // Optional: must match the token's mint
+	CryptoDecimals *uint8            `json:"cryptoDecimals,omitempty"` // Optional: must match the token's decimals
+	CryptoAccount  string            `json:"cryptoAccount,omitempty"`
+	MemoTemplate   string            `json:"memoTemplate,omitempty"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+}
+
+// CreateProduct validates req and adds it to the product catalog. Returns
+// products.ErrProductExists if the ID is taken and products.ErrReadOnly for a YAML catalog.
+func (s *Service) CreateProduct(ctx context.Context, req ProductRequest) (products.Product, error) {
+	product, err := s.productFromRequest(req.ID, req)
+	if err != nil {
+		return products.Product{}, err
+	}
+	if err := s.repository.CreateProduct(ctx, product); err != nil {
+		return products.Product{}, err
+	}
+	return s.repository.GetProduct(ctx, product.ID)
+}
+
+// UpdateProduct replaces the definition of product id. Updating an archived product restores it.
+func (s *Service) UpdateProduct(ctx context.Context, id string, req ProductRequest) (products.Product, error) {
+	product, err := s.productFromRequest(id, req)
+	if err != nil {
+		return products.Product{}, err
+	}
+	if err := s.repository.UpdateProduct(ctx, product); err != nil {
+		return products.Product{}, err
+	}
+	return s.repository.GetProduct(ctx, product.ID)
+}
+
+// ArchiveProduct takes product id off sale. The product is kept, so UpdateProduct can restore it.
+func (s *Service) ArchiveProduct(ctx context.Context, id string) error {
+	return s.repository.DeleteProduct(ctx, id)
+}
+
+// InvalidateProductCache drops cached product data so the next lookups read the catalog
+// source, e.g. after the database was edited directly. Reports whether a cache was cleared.
+func (s *Service) InvalidateProductCache() bool {
+	cached, ok := s.repository.(interface{ InvalidateCache() })
+	if ok {
+		cached.InvalidateCache()
+	}
+	return ok
+}
+
+// productFromRequest validates req and converts it into an active product.
+func (s *Service) productFromRequest(id string, req ProductRequest) (products.Product, error) {
+	if id == "" || len(id) > maxProductIDLength || strings.Contains(id, "/") {
+		return products.Product{}, fmt.Errorf("%w: id must be 1-%d characters without '/'", ErrInvalidProduct, maxProductIDLength)
+	}
+
+	product := products.Product{
+		ID:            id,
+		Description:   req.Description,
+		StripePriceID: req.StripePriceID,
+		CryptoAccount: req.CryptoAccount,
+		MemoTemplate:  req.MemoTemplate,
+		Metadata:      req.Metadata,
+		Active:        true,
+	}
+
+	if req.FiatAmount != "" {
+		currency := req.FiatCurrency
+		if currency == "" {
+			currency = "usd"
+		}
+		asset, err := money.GetAsset(strings.ToUpper(currency))
+		if err != nil || !asset.IsStripeCurrency() {
+			return products.Product{}, fmt.Errorf("%w: unsupported fiatCurrency %q", ErrInvalidProduct, currency)
+		}
+		price, err := parsePrice(asset, req.FiatAmount)
+		if err != nil {
+			return products.Product{}, fmt.Errorf("%w: fiatAmount %v", ErrInvalidProduct, err)
+		}
+		product.FiatPrice = &price
+	}
+
+	if req.CryptoAmount != "" {
+		asset, err := s.productToken(req)
+		if err != nil {
+			return products.Product{}, err
+		}
+		price, err := parsePrice(asset, req.CryptoAmount)
+		if err != nil {
+			return products.Product{}, fmt.Errorf("%w: cryptoAmount %v", ErrInvalidProduct, err)
+		}
+		product.CryptoPrice = &price
+	} else if req.CryptoToken != "" || req.CryptoMint != "" || req.CryptoDecimals != nil {
+		return products.Product{}, fmt.Errorf("%w: cryptoAmount required with a crypto token", ErrInvalidProduct)
+	}
+
+	if product.FiatPrice == nil && product.CryptoPrice == nil && product.StripePriceID == "" {
+		return products.Product{}, fmt.Errorf("%w: fiatAmount, cryptoAmount, or stripePriceId required", ErrInvalidProduct)
+	}
+	return product, nil
+}
+
+// productToken resolves the stablecoin a product is priced in and checks the mint and decimals
+// the client expects against it, so a price is never stored in a token with other decimals.
+func (s *Service) productToken(req ProductRequest) (money.Asset, error) {
+	token := req.CryptoToken
+	if token == "" {
+		token = s.cfg.X402.TokenMint
+	}
+	symbol := money.GetStablecoinSymbol(token) // Accept a mint address as well as a symbol
+	if symbol == "" {
+		symbol = strings.ToUpper(token)
+	}
+	asset, err := money.GetAsset(symbol)
+	if err != nil || !asset.IsSPLToken() || !money.IsStablecoin(asset.Metadata.SolanaMint) {
+		return money.Asset{}, fmt.Errorf("%w: cryptoToken %q is not a supported stablecoin", ErrInvalidProduct, token)
+	}
+	if req.CryptoMint != "" && req.CryptoMint != asset.Metadata.SolanaMint {
+		return money.Asset{}, fmt.Errorf("%w: cryptoMint %s is not the %s mint", ErrInvalidProduct, req.CryptoMint, asset.Code)
+	}
+	if req.CryptoDecimals != nil && *req.CryptoDecimals != asset.Decimals {
+		return money.Asset{}, fmt.Errorf("%w: %s has %d decimals, not %d", ErrInvalidProduct, asset.Code, asset.Decimals, *req.CryptoDecimals)
+	}
+	return asset, nil
+}
+
+// parsePrice parses a positive major-unit amount with at most the asset's decimal places.
+func parsePrice(asset money.Asset, amount string) (money.Money, error) {
+	if _, fraction, ok := strings.Cut(amount, "."); ok && len(fraction) > int(asset.Decimals) {
+		return money.Money{}, fmt.Errorf("%q has more than %d decimal places", amount, asset.Decimals)
+	}
+	price, err := money.FromMajor(asset, amount)
+	if err != nil {
+		return money.Money{}, fmt.Errorf("%q: %v", amount, err)
+	}
+	if !price.IsPositive() {
+		return money.Money{}, fmt.Errorf("%q must be positive", amount)
+	}
+	return price, nil
+}
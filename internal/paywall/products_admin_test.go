@@ -0,0 +1,196 @@
+package paywall
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/CedrosPay/server/internal/products"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+// catalogRepo is a writable in-memory product repository.
+type catalogRepo struct {
+	mu       sync.Mutex
+	products map[string]products.Product
+}
+
+func (r *catalogRepo) GetProduct(_ context.Context, id string) (products.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.products[id]
+	if !ok || !p.Active {
+		return products.Product{}, products.ErrProductNotFound
+	}
+	return p, nil
+}
+
+func (r *catalogRepo) GetProductByStripePriceID(_ context.Context, _ string) (products.Product, error) {
+	return products.Product{}, products.ErrProductNotFound
+}
+
+func (r *catalogRepo) ListProducts(_ context.Context) ([]products.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var list []products.Product
+	for _, p := range r.products {
+		if p.Active {
+			list = append(list, p)
+		}
+	}
+	return list, nil
+}
+
+func (r *catalogRepo) CreateProduct(_ context.Context, p products.Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.products[p.ID]; ok {
+		return products.ErrProductExists
+	}
+	p.CreatedAt, p.UpdatedAt = time.Now(), time.Now()
+	r.products[p.ID] = p
+	return nil
+}
+
+func (r *catalogRepo) UpdateProduct(_ context.Context, p products.Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.products[p.ID]; !ok {
+		return products.ErrProductNotFound
+	}
+	p.UpdatedAt = time.Now()
+	r.products[p.ID] = p
+	return nil
+}
+
+func (r *catalogRepo) DeleteProduct(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.products[id]
+	if !ok {
+		return products.ErrProductNotFound
+	}
+	p.Active = false
+	r.products[id] = p
+	return nil
+}
+
+func (r *catalogRepo) Close() error { return nil }
+
+func TestProductRequestValidation(t *testing.T) {
+	cfg := testConfig()
+	cfg.X402.TokenMint = "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v" // USDC
+	svc := NewService(cfg, storage.NewMemoryStore(), stubVerifier{}, nil, testRepository(cfg), nil, nil)
+	six, nine := uint8(6), uint8(9)
+
+	tests := []struct {
+		name       string
+		req        ProductRequest
+		wantErr    bool
+		wantCrypto int64
+		wantFiat   int64
+	}{
+		{name: "crypto price", req: ProductRequest{ID: "ebook", CryptoAmount: "1.5", CryptoToken: "USDC"}, wantCrypto: 1500000},
+		{name: "token by mint", req: ProductRequest{ID: "ebook", CryptoAmount: "2", CryptoToken: "Es9vMFrzaCERmJfrF4H2FYD4KCoNkY11McCe8BenwNYB"}, wantCrypto: 2000000},
+		{name: "default token", req: ProductRequest{ID: "ebook", CryptoAmount: "0.25"}, wantCrypto: 250000},
+		{name: "matching mint and decimals", req: ProductRequest{ID: "ebook", CryptoAmount: "1", CryptoToken: "usdc", CryptoMint: "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v", CryptoDecimals: &six}, wantCrypto: 1000000},
+		{name: "fiat price", req: ProductRequest{ID: "ebook", FiatAmount: "10.50"}, wantFiat: 1050},
+		{name: "stripe price only", req: ProductRequest{ID: "ebook", StripePriceID: "price_123"}},
+		{name: "missing id", req: ProductRequest{CryptoAmount: "1"}, wantErr: true},
+		{name: "slash in id", req: ProductRequest{ID: "a/b", CryptoAmount: "1"}, wantErr: true},
+		{name: "no price", req: ProductRequest{ID: "ebook"}, wantErr: true},
+		{name: "zero price", req: ProductRequest{ID: "ebook", CryptoAmount: "0"}, wantErr: true},
+		{name: "negative price", req: ProductRequest{ID: "ebook", FiatAmount: "-1"}, wantErr: true},
+		{name: "malformed price", req: ProductRequest{ID: "ebook", CryptoAmount: "one"}, wantErr: true},
+		{name: "too precise", req: ProductRequest{ID: "ebook", CryptoAmount: "0.0000001"}, wantErr: true},
+		{name: "non-stablecoin token", req: ProductRequest{ID: "ebook", CryptoAmount: "1", CryptoToken: "SOL"}, wantErr: true},
+		{name: "unknown token", req: ProductRequest{ID: "ebook", CryptoAmount: "1", CryptoToken: "BONK"}, wantErr: true},
+		{name: "wrong mint", req: ProductRequest{ID: "ebook", CryptoAmount: "1", CryptoToken: "USDC", CryptoMint: "Es9vMFrzaCERmJfrF4H2FYD4KCoNkY11McCe8BenwNYB"}, wantErr: true},
+		{name: "wrong decimals", req: ProductRequest{ID: "ebook", CryptoAmount: "1", CryptoToken: "USDC", CryptoDecimals: &nine}, wantErr: true},
+		{name: "token without amount", req: ProductRequest{ID: "ebook", FiatAmount: "1", CryptoToken: "USDC"}, wantErr: true},
+		{name: "crypto fiat currency", req: ProductRequest{ID: "ebook", FiatAmount: "1", FiatCurrency: "usdc"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product, err := svc.productFromRequest(tt.req.ID, tt.req)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidProduct) {
+					t.Fatalf("error = %v, want ErrInvalidProduct", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !product.Active {
+				t.Error("product not active")
+			}
+			if (product.CryptoPrice == nil) != (tt.wantCrypto == 0) || (product.CryptoPrice != nil && product.CryptoPrice.Atomic != tt.wantCrypto) {
+				t.Errorf("crypto price = %v, want %d", product.CryptoPrice, tt.wantCrypto)
+			}
+			if (product.FiatPrice == nil) != (tt.wantFiat == 0) || (product.FiatPrice != nil && product.FiatPrice.Atomic != tt.wantFiat) {
+				t.Errorf("fiat price = %v, want %d", product.FiatPrice, tt.wantFiat)
+			}
+		})
+	}
+}
+
+func TestProductCatalogAdmin(t *testing.T) {
+	ctx := context.Background()
+	cfg := testConfig()
+	cfg.X402.TokenMint = "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v" // USDC
+	repo := products.NewCachedRepository(&catalogRepo{products: map[string]products.Product{}}, time.Hour)
+	svc := NewService(cfg, storage.NewMemoryStore(), stubVerifier{}, nil, repo, nil, nil)
+
+	// Prime the cache so the writes below must invalidate it
+	if list, err := svc.ListProducts(ctx); err != nil || len(list) != 0 {
+		t.Fatalf("ListProducts = %v, %v; want empty", list, err)
+	}
+
+	if _, err := svc.CreateProduct(ctx, ProductRequest{ID: "ebook", Description: "E-book", CryptoAmount: "2"}); err != nil {
+		t.Fatalf("CreateProduct error: %v", err)
+	}
+	if _, err := svc.CreateProduct(ctx, ProductRequest{ID: "ebook", CryptoAmount: "2"}); !errors.Is(err, products.ErrProductExists) {
+		t.Errorf("duplicate CreateProduct error = %v, want ErrProductExists", err)
+	}
+	resource, err := svc.ResourceDefinition(ctx, "ebook")
+	if err != nil || resource.CryptoAtomicAmount != 2000000 {
+		t.Fatalf("ResourceDefinition = %+v, %v; want 2 USDC", resource, err)
+	}
+	if list, _ := svc.ListProducts(ctx); len(list) != 1 {
+		t.Errorf("ListProducts after create = %d products, want 1", len(list))
+	}
+
+	updated, err := svc.UpdateProduct(ctx, "ebook", ProductRequest{Description: "E-book", CryptoAmount: "3"})
+	if err != nil || updated.CryptoPrice.Atomic != 3000000 {
+		t.Fatalf("UpdateProduct = %+v, %v; want 3 USDC", updated, err)
+	}
+	if resource, _ := svc.ResourceDefinition(ctx, "ebook"); resource.CryptoAtomicAmount != 3000000 {
+		t.Errorf("ResourceDefinition after update = %d, want 3000000", resource.CryptoAtomicAmount)
+	}
+	if _, err := svc.UpdateProduct(ctx, "missing", ProductRequest{CryptoAmount: "1"}); !errors.Is(err, products.ErrProductNotFound) {
+		t.Errorf("UpdateProduct on unknown product error = %v, want ErrProductNotFound", err)
+	}
+
+	if err := svc.ArchiveProduct(ctx, "ebook"); err != nil {
+		t.Fatalf("ArchiveProduct error: %v", err)
+	}
+	if _, err := svc.ResourceDefinition(ctx, "ebook"); !errors.Is(err, ErrResourceNotConfigured) {
+		t.Errorf("archived product: ResourceDefinition error = %v, want ErrResourceNotConfigured", err)
+	}
+	if _, err := svc.UpdateProduct(ctx, "ebook", ProductRequest{CryptoAmount: "1"}); err != nil {
+		t.Fatalf("restoring archived product: %v", err)
+	}
+	if _, err := svc.ResourceDefinition(ctx, "ebook"); err != nil {
+		t.Errorf("restored product: ResourceDefinition error = %v", err)
+	}
+
+	if !svc.InvalidateProductCache() {
+		t.Error("InvalidateProductCache = false for a cached repository")
+	}
+	if NewService(cfg, storage.NewMemoryStore(), stubVerifier{}, nil, testRepository(cfg), nil, nil).InvalidateProductCache() {
+		t.Error("InvalidateProductCache = true for an uncached repository")
+	}
+}
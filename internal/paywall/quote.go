@@ -13,19 +13,44 @@ import (
 
 // GenerateQuote builds a paywall quote for the resource with optional coupon.
 func (s *Service) GenerateQuote(ctx context.Context, resourceID, couponCode string) (Quote, error) {
+	return s.GenerateQuoteForWallet(ctx, resourceID, couponCode, "")
+}
+
+// GenerateQuoteForWallet builds a quote for the wallet that will pay, failing with
+// ErrCouponWalletLimit if the wallet has used the coupon as many times as it allows.
+func (s *Service) GenerateQuoteForWallet(ctx context.Context, resourceID, couponCode, wallet string) (Quote, error) {
+	if s.Draining() {
+		return Quote{}, ErrDraining
+	}
+	if err := s.countQuote(ctx, wallet); err != nil {
+		return Quote{}, err
+	}
 	resource, err := s.ResourceDefinition(ctx, resourceID)
 	if err != nil {
 		return Quote{}, err
 	}
+	if err := s.CheckStock(ctx, resourceID, 1); err != nil {
+		return Quote{}, err
+	}
+
+	// Fiat-priced resources are converted into their token at the rate locked for this quote
+	pricing, err := s.quoteFiatPricing(ctx, resource)
+	if err != nil {
+		return Quote{}, err
+	}
+	resource = pricing.resource
 
 	generatedAt := time.Now()
 	expiry := generatedAt.Add(s.cfg.Paywall.QuoteTTL.Duration)
-	memo := InterpolateMemo(resource.MemoTemplate, resourceID)
+	memo := s.InterpolateMemo(resource.MemoTemplate, resourceID)
 
 	// Validate and apply manually provided coupon if specified
 	// Note: We silently ignore invalid coupons (don't error out)
 	// This matches the Stripe behavior - invalid coupons are just not applied
 	manualCoupon := s.validateManualCoupon(ctx, couponCode, resourceID, "")
+	if err := s.checkManualCouponLimit(ctx, manualCoupon, wallet); err != nil {
+		return Quote{}, err
+	}
 
 	quote := Quote{
 		ResourceID: resourceID,
@@ -61,7 +86,7 @@ func (s *Service) GenerateQuote(ctx context.Context, resourceID, couponCode stri
 
 		// Combine catalog + checkout coupons for single product quotes
 		allApplicableCoupons := append([]coupons.Coupon{}, catalogCoupons...)
-		allApplicableCoupons = append(allApplicableCoupons, checkoutCoupons...)
+		allApplicableCoupons = unitCoupons(append(allApplicableCoupons, checkoutCoupons...))
 
 		// Use atomic amount directly (Money type)
 		cryptoAsset, err := money.GetAsset(resource.CryptoToken)
@@ -83,6 +108,15 @@ func (s *Service) GenerateQuote(ctx context.Context, resourceID, couponCode stri
 		// This ensures $2.7661 becomes $2.77, not $2.7661
 		cryptoMoney = cryptoMoney.RoundUpToCents()
 
+		// A gift card pays part of the price from its balance
+		giftCard, err := s.redeemGiftCard(ctx, couponCode, cryptoMoney)
+		if err != nil {
+			return Quote{}, err
+		}
+		if cryptoMoney, err = cryptoMoney.Sub(giftCard.amount); err != nil {
+			return Quote{}, fmt.Errorf("apply gift card to crypto price: %w", err)
+		}
+
 		// Use atomic units directly from Money type (no float64 conversion needed)
 		atomicAmount := uint64(cryptoMoney.Atomic)
 
@@ -118,6 +152,19 @@ func (s *Service) GenerateQuote(ctx context.Context, resourceID, couponCode stri
 			extra["feePayer"] = feePayerPubKey
 		}
 
+		// Show the fiat price and the rate it was converted at, locked until the quote expires
+		if pricing.rate != 0 {
+			extra["exchangeRate"] = strconv.FormatFloat(pricing.rate, 'f', -1, 64)
+			extra["fiatAmount"] = pricing.fiat.ToMajor()
+			extra["fiatCurrency"] = pricing.fiat.Asset.Code
+		}
+
+		// Show what the gift card pays so the frontend can display the remaining price
+		if !giftCard.amount.IsZero() {
+			extra["giftCard"] = giftCard.code
+			extra["giftCardAmount"] = giftCard.amount.ToMajor()
+		}
+
 		// IMPORTANT: Add coupon metadata to extra so frontend knows original price
 		// Without this, frontend has no way to display discount information
 		if len(allApplicableCoupons) > 0 {
@@ -161,8 +208,8 @@ func (s *Service) GenerateQuote(ctx context.Context, resourceID, couponCode stri
 		}
 
 		quote.Crypto = &CryptoQuote{
-			Scheme:            "solana-spl-transfer",
-			Network:           s.cfg.X402.Network,
+			Scheme:            s.quoteScheme(),
+			Network:           s.quoteNetwork(),
 			MaxAmountRequired: strconv.FormatUint(atomicAmount, 10),
 			Resource:          resourceID,
 			Description:       resource.Description,
@@ -303,8 +350,26 @@ func stackFiatCoupons(originalPriceCents int64, applicableCoupons []coupons.Coup
 	return discounted.Atomic
 }
 
-// InterpolateMemo wraps the standalone InterpolateMemo function.
+// InterpolateMemo wraps the standalone InterpolateMemo function. With strict_memo enabled it
+// ignores the template and returns the memo verification requires.
 // Exposed as a method on Service for use by HTTP handlers.
 func (s *Service) InterpolateMemo(template, resourceID string) string {
+	if s.cfg.X402.StrictMemo {
+		return s.boundMemo(resourceID)
+	}
 	return InterpolateMemo(template, resourceID)
 }
+
+// boundMemo binds a payment to a resource or cart ID: memo_prefix:<id>.
+func (s *Service) boundMemo(resourceID string) string {
+	return fmt.Sprintf("%s:%s", s.cfg.X402.MemoPrefix, resourceID)
+}
+
+// requiredMemo returns the memo a payment for resourceID must carry, or "" when strict_memo
+// is disabled and any memo is accepted.
+func (s *Service) requiredMemo(resourceID string) string {
+	if !s.cfg.X402.StrictMemo {
+		return ""
+	}
+	return s.boundMemo(resourceID)
+}
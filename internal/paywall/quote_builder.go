@@ -1,7 +1,6 @@
 package paywall
 
 import (
-	"fmt"
 	"strconv"
 	"time"
 )
@@ -29,7 +28,7 @@ func (s *Service) buildX402Quote(opts x402QuoteOptions) (*CryptoQuote, error) {
 		"recipientTokenAccount": opts.RecipientTokenAccount,
 		"decimals":              s.cfg.X402.TokenDecimals,
 		"tokenSymbol":           opts.Token,
-		"memo":                  fmt.Sprintf("%s:%s", s.cfg.X402.MemoPrefix, opts.ResourceID),
+		"memo":                  s.boundMemo(opts.ResourceID),
 	}
 
 	// Add feePayer for gasless transactions (if requested)
@@ -41,8 +40,8 @@ func (s *Service) buildX402Quote(opts x402QuoteOptions) (*CryptoQuote, error) {
 
 	// Build crypto quote
 	return &CryptoQuote{
-		Scheme:            "solana-spl-transfer",
-		Network:           s.cfg.X402.Network,
+		Scheme:            s.quoteScheme(),
+		Network:           s.quoteNetwork(),
 		MaxAmountRequired: strconv.FormatUint(atomicAmount, 10),
 		Resource:          opts.ResourceID,
 		Description:       opts.Description,
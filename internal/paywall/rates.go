@@ -0,0 +1,48 @@
+package paywall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+// ErrNoExchangeRate indicates no rate is known between two tokens.
+var ErrNoExchangeRate = errors.New("paywall: no exchange rate")
+
+// RateProvider supplies exchange rates for converting cart items into the settlement token.
+type RateProvider interface {
+	// Rate returns how many units of token to one unit of token from is worth.
+	Rate(ctx context.Context, from, to string) (float64, error)
+}
+
+// StaticRates is a RateProvider with fixed rates into a single token, from x402.token_rates.
+type StaticRates struct {
+	To    string             // Token the rates convert into
+	Rates map[string]float64 // Units of To per unit of each token
+}
+
+// Rate returns the configured rate from from into r.To.
+func (r StaticRates) Rate(_ context.Context, from, to string) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+	rate, ok := r.Rates[from]
+	if !ok || to != r.To {
+		return 0, fmt.Errorf("%w from %s to %s", ErrNoExchangeRate, from, to)
+	}
+	return rate, nil
+}
+
+// SetRateProvider replaces the static x402.token_rates used to convert mixed-token carts,
+// e.g. with live rates from a price feed.
+func (s *Service) SetRateProvider(rates RateProvider) {
+	s.rates = rates
+}
+
+// convertMoney converts m into asset at rate units of asset per unit of m's asset, rounding
+// up to the next atomic unit so the merchant never receives less than the listed price.
+func convertMoney(m money.Money, asset money.Asset, rate float64) (money.Money, error) {
+	return money.ConvertAtRate(m, asset, rate, money.RoundingCeiling)
+}
@@ -0,0 +1,101 @@
+package paywall
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/CedrosPay/server/internal/callbacks"
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/money"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func TestConvertMoney(t *testing.T) {
+	tests := []struct {
+		name       string
+		from       money.Money
+		to         string
+		rate       float64
+		wantAtomic int64
+		wantErr    bool
+	}{
+		{name: "same decimals", from: money.New(money.MustGetAsset("USDT"), 2000000), to: "USDC", rate: 0.999, wantAtomic: 1998000},
+		{name: "more decimals to fewer", from: money.New(money.MustGetAsset("SOL"), 10000000), to: "USDC", rate: 150.25, wantAtomic: 1502500},
+		{name: "fewer decimals to more", from: money.New(money.MustGetAsset("USDC"), 1000000), to: "SOL", rate: 0.0066, wantAtomic: 6600000},
+		{name: "rounds up", from: money.New(money.MustGetAsset("USDT"), 1), to: "USDC", rate: 0.5, wantAtomic: 1},
+		{name: "zero rate", from: money.New(money.MustGetAsset("USDT"), 1), to: "USDC", rate: 0, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := convertMoney(tt.from, money.MustGetAsset(tt.to), tt.rate)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("convertMoney error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (got.Atomic != tt.wantAtomic || got.Asset.Code != tt.to) {
+				t.Errorf("convertMoney = %d %s, want %d %s", got.Atomic, got.Asset.Code, tt.wantAtomic, tt.to)
+			}
+		})
+	}
+}
+
+func TestMixedTokenCarts(t *testing.T) {
+	items := []CartQuoteItem{
+		{ResourceID: "demo-content", Quantity: 1},
+		{ResourceID: "tether-content", Quantity: 2},
+		{ResourceID: "sol-content", Quantity: 1},
+	}
+	tests := []struct {
+		name            string
+		settlementToken string
+		rates           map[string]float64
+		wantTotal       money.LegacyFloat
+		wantErr         string
+	}{
+		{name: "rejected without a settlement token", wantErr: "mixed tokens in cart"},
+		{name: "converted to the settlement token", settlementToken: "USDC", rates: map[string]float64{"USDT": 0.999, "SOL": 150}, wantTotal: 4.5},
+		{name: "missing rate", settlementToken: "USDC", rates: map[string]float64{"USDT": 0.999}, wantErr: ErrNoExchangeRate.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.X402.CartSettlementToken = tt.settlementToken
+			cfg.X402.TokenRates = tt.rates
+			cfg.Paywall.Resources["tether-content"] = config.PaywallResource{ResourceID: "tether-content", CryptoAtomicAmount: 1000000, CryptoToken: "USDT"}
+			cfg.Paywall.Resources["sol-content"] = config.PaywallResource{ResourceID: "sol-content", CryptoAtomicAmount: 10000000, CryptoToken: "SOL"}
+			store := storage.NewMemoryStore()
+			t.Cleanup(func() { _ = store.Close() })
+			svc := NewService(cfg, store, stubVerifier{}, callbacks.NoopNotifier{}, testRepository(cfg), nil, nil)
+
+			resp, err := svc.GenerateCartQuote(context.Background(), CartQuoteRequest{Items: items})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("GenerateCartQuote error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GenerateCartQuote error: %v", err)
+			}
+			if resp.TotalAmount != tt.wantTotal {
+				t.Errorf("TotalAmount = %v, want %v", resp.TotalAmount, tt.wantTotal)
+			}
+			tether := resp.Items[1]
+			if tether.Token != "USDC" || tether.PriceAmount != 0.999 || tether.ConvertedFrom != "USDT" || tether.ExchangeRate != 0.999 {
+				t.Errorf("converted item = %+v", tether)
+			}
+			if resp.Items[0].ConvertedFrom != "" {
+				t.Errorf("settlement token item marked converted: %+v", resp.Items[0])
+			}
+			cart, err := svc.GetCartQuote(context.Background(), resp.CartID)
+			if err != nil || cart.Total.Asset.Code != "USDC" || cart.Items[2].Price.Atomic != 1500000 {
+				t.Errorf("stored cart = %+v, %v", cart, err)
+			}
+		})
+	}
+
+	if _, err := (StaticRates{To: "USDC", Rates: map[string]float64{"USDT": 1}}).Rate(context.Background(), "USDT", "PYUSD"); !errors.Is(err, ErrNoExchangeRate) {
+		t.Errorf("rate into an unconfigured token: error = %v, want ErrNoExchangeRate", err)
+	}
+}
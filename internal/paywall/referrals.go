@@ -0,0 +1,116 @@
+package paywall
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/CedrosPay/server/internal/coupons"
+	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/money"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+// recentReferralConversions is how many conversions a referrer dashboard lists.
+const recentReferralConversions = 20
+
+// ReferralDashboard summarizes the purchases attributed to a referrer wallet.
+type ReferralDashboard struct {
+	Wallet            string                       `json:"wallet"`
+	Conversions       int                          `json:"conversions"`
+	Codes             []ReferralCodeStats          `json:"codes"`             // Conversions per referral code, most first
+	OwedRewards       []money.Money                `json:"owedRewards"`       // Rewards owed, one entry per token
+	RecentConversions []storage.ReferralConversion `json:"recentConversions"` // Most recent first
+}
+
+// ReferralCodeStats counts the conversions made with one referral code.
+type ReferralCodeStats struct {
+	Code        string `json:"code"`
+	Conversions int    `json:"conversions"`
+}
+
+// recordReferral attributes a paid purchase made with coupon to its referrer, if coupon is a
+// referral code. Failures are logged: the payment already succeeded.
+func (s *Service) recordReferral(ctx context.Context, coupon coupons.Coupon, signature, resourceID string, paid money.Money, now time.Time) {
+	wallet, _, ok := coupon.Referrer()
+	if !ok {
+		return
+	}
+	reward, err := coupon.ReferralReward(paid)
+	if err == nil {
+		err = s.store.RecordReferralConversion(ctx, storage.ReferralConversion{
+			Signature:      signature,
+			ReferrerWallet: wallet,
+			Code:           coupon.Code,
+			ResourceID:     resourceID,
+			Amount:         paid,
+			Reward:         reward,
+			ConvertedAt:    now,
+		})
+	}
+	if err != nil {
+		log := logger.FromContext(ctx)
+		log.Warn().
+			Err(err).
+			Str("coupon_code", coupon.Code).
+			Str("signature", logger.TruncateAddress(signature)).
+			Msg("paywall.referral_record_failed")
+	}
+}
+
+// recordCartReferrals attributes a paid cart to the referrers of the referral codes among
+// codes, the comma-separated coupon_codes the cart was priced with.
+func (s *Service) recordCartReferrals(ctx context.Context, codes, signature, cartID string, paid money.Money, now time.Time) {
+	for _, coupon := range s.cartCoupons(ctx, codes) {
+		s.recordReferral(ctx, coupon, signature, cartID, paid, now)
+	}
+}
+
+// ReferralDashboard summarizes the conversions and owed rewards of the referral codes that
+// credit wallet.
+func (s *Service) ReferralDashboard(ctx context.Context, wallet string) (ReferralDashboard, error) {
+	conversions, err := s.store.ListReferralConversions(ctx, wallet)
+	if err != nil {
+		return ReferralDashboard{}, fmt.Errorf("paywall: list referral conversions: %w", err)
+	}
+
+	dashboard := ReferralDashboard{
+		Wallet:            wallet,
+		Conversions:       len(conversions),
+		Codes:             []ReferralCodeStats{},
+		OwedRewards:       []money.Money{},
+		RecentConversions: conversions[:min(len(conversions), recentReferralConversions)],
+	}
+	perCode := make(map[string]int)
+	owed := make(map[string]money.Money)
+	for _, c := range conversions {
+		perCode[c.Code]++
+		total, ok := owed[c.Reward.Asset.Code]
+		if !ok {
+			total = money.Zero(c.Reward.Asset)
+		}
+		if owed[c.Reward.Asset.Code], err = total.Add(c.Reward); err != nil {
+			return ReferralDashboard{}, fmt.Errorf("paywall: sum referral rewards: %w", err)
+		}
+	}
+	for code, count := range perCode {
+		dashboard.Codes = append(dashboard.Codes, ReferralCodeStats{Code: code, Conversions: count})
+	}
+	sort.Slice(dashboard.Codes, func(i, j int) bool {
+		if dashboard.Codes[i].Conversions != dashboard.Codes[j].Conversions {
+			return dashboard.Codes[i].Conversions > dashboard.Codes[j].Conversions
+		}
+		return dashboard.Codes[i].Code < dashboard.Codes[j].Code
+	})
+	for _, total := range owed {
+		dashboard.OwedRewards = append(dashboard.OwedRewards, total)
+	}
+	sort.Slice(dashboard.OwedRewards, func(i, j int) bool {
+		return dashboard.OwedRewards[i].Asset.Code < dashboard.OwedRewards[j].Asset.Code
+	})
+	if dashboard.RecentConversions == nil {
+		dashboard.RecentConversions = []storage.ReferralConversion{}
+	}
+	return dashboard, nil
+}
@@ -0,0 +1,74 @@
+package paywall
+
+import (
+	"context"
+	"testing"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/coupons"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func referralCoupons() coupons.Repository {
+	return coupons.NewYAMLRepository(map[string]config.Coupon{
+		"FRIEND10": {Code: "FRIEND10", DiscountType: "percentage", DiscountValue: 10, Scope: "all", Active: true,
+			Metadata: map[string]string{coupons.MetadataReferrerWallet: "referrer", coupons.MetadataReferralRewardPercent: "5"}},
+		"PAL20": {Code: "PAL20", DiscountType: "percentage", DiscountValue: 20, Scope: "all", Active: true,
+			Metadata: map[string]string{coupons.MetadataReferrerWallet: "referrer"}},
+		"HALF": {Code: "HALF", DiscountType: "percentage", DiscountValue: 50, Scope: "all", Active: true},
+	})
+}
+
+func TestReferralConversions(t *testing.T) {
+	ctx := context.Background()
+	cfg := testConfig()
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	svc := NewService(cfg, store, stubVerifier{}, &recordingNotifier{}, testRepository(cfg), referralCoupons(), nil)
+
+	// demo-content costs 1.00 USDC
+	purchases := []struct {
+		signature string
+		code      string
+	}{
+		{signature: "sig-ref-1", code: "FRIEND10"}, // Pays 0.90, owes 0.045
+		{signature: "sig-ref-2", code: "FRIEND10"},
+		{signature: "sig-ref-3", code: "PAL20"}, // Attributed without a reward
+		{signature: "sig-ref-4", code: "HALF"},  // Not a referral code
+	}
+	for _, purchase := range purchases {
+		if _, err := svc.Authorize(ctx, "demo-content", "", cartPaymentHeader(t, cfg, purchase.signature), purchase.code); err != nil {
+			t.Fatalf("Authorize with %s error: %v", purchase.code, err)
+		}
+	}
+
+	// A cart of two pays 1.80 after FRIEND10, owing 0.09
+	cart, err := svc.GenerateCartQuote(ctx, CartQuoteRequest{Items: []CartQuoteItem{{ResourceID: "demo-content", Quantity: 2}}, CouponCode: "FRIEND10"})
+	if err != nil {
+		t.Fatalf("GenerateCartQuote error: %v", err)
+	}
+	if _, err := svc.Authorize(ctx, cart.CartID, "", cartPaymentHeader(t, cfg, "sig-ref-cart"), ""); err != nil {
+		t.Fatalf("Authorize cart error: %v", err)
+	}
+
+	dashboard, err := svc.ReferralDashboard(ctx, "referrer")
+	if err != nil {
+		t.Fatalf("ReferralDashboard error: %v", err)
+	}
+	if dashboard.Conversions != 4 || len(dashboard.RecentConversions) != 4 {
+		t.Errorf("conversions = %d with %d listed, want 4", dashboard.Conversions, len(dashboard.RecentConversions))
+	}
+	if len(dashboard.Codes) != 2 || dashboard.Codes[0] != (ReferralCodeStats{Code: "FRIEND10", Conversions: 3}) || dashboard.Codes[1] != (ReferralCodeStats{Code: "PAL20", Conversions: 1}) {
+		t.Errorf("codes = %+v, want FRIEND10 x3 then PAL20 x1", dashboard.Codes)
+	}
+	if len(dashboard.OwedRewards) != 1 || dashboard.OwedRewards[0].Asset.Code != "USDC" || dashboard.OwedRewards[0].ToMajor() != "0.180000" {
+		t.Errorf("owed rewards = %+v, want 0.18 USDC", dashboard.OwedRewards)
+	}
+	if recent := dashboard.RecentConversions[0]; recent.Signature != "sig-ref-cart" || recent.ResourceID != cart.CartID {
+		t.Errorf("most recent conversion = %+v, want the cart payment", recent)
+	}
+
+	if empty, _ := svc.ReferralDashboard(ctx, "nobody"); empty.Conversions != 0 || empty.Codes == nil || empty.OwedRewards == nil {
+		t.Errorf("empty dashboard = %+v, want zero conversions with empty lists", empty)
+	}
+}
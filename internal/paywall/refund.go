@@ -2,18 +2,24 @@ package paywall
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
 	"time"
 
 	"github.com/CedrosPay/server/internal/callbacks"
+	"github.com/CedrosPay/server/internal/config"
 	"github.com/CedrosPay/server/internal/logger"
 	"github.com/CedrosPay/server/internal/money"
 	"github.com/CedrosPay/server/internal/storage"
 	"github.com/CedrosPay/server/pkg/x402"
+	x402solana "github.com/CedrosPay/server/pkg/x402/solana"
 	"github.com/gagliardetto/solana-go"
 )
 
+// ErrRefundAmountTooHigh is returned when an admin approves more than the customer requested.
+var ErrRefundAmountTooHigh = errors.New("paywall: approved refund amount exceeds the requested amount")
+
 // RefundQuoteRequest represents a request to generate a refund quote.
 type RefundQuoteRequest struct {
 	OriginalPurchaseID string            `json:"originalPurchaseId"` // Reference to original purchase
@@ -22,6 +28,8 @@ type RefundQuoteRequest struct {
 	Token              string            `json:"token"`              // Token symbol
 	Reason             string            `json:"reason,omitempty"`   // Optional reason
 	Metadata           map[string]string `json:"metadata,omitempty"` // Optional metadata
+	// AmountDecimal is the amount to refund as an exact decimal string; it takes precedence over Amount
+	AmountDecimal string `json:"amountDecimal,omitempty"`
 }
 
 // RefundQuoteResponse contains the generated refund quote.
@@ -29,6 +37,23 @@ type RefundQuoteResponse struct {
 	RefundID  string       `json:"refundId"`  // Unique refund identifier
 	Quote     *CryptoQuote `json:"quote"`     // x402 requirement for the refund
 	ExpiresAt time.Time    `json:"expiresAt"` // When this refund quote expires
+
+	// Proposal is set when the payment address is a Squads multisig vault. The approving
+	// member signs and submits it in place of a direct transfer.
+	Proposal *x402solana.SquadsProposalResponse `json:"proposal,omitempty"`
+
+	// NonceTransaction is set when a refund nonce account is configured. It is built on the
+	// durable nonce, so the admin can sign it offline and submit it after the blockhash of a
+	// regular quote would have gone stale.
+	NonceTransaction *x402solana.DurableTransferResponse `json:"nonceTransaction,omitempty"`
+}
+
+// major returns the requested amount in major units, preferring the exact AmountDecimal.
+func (req RefundQuoteRequest) major() string {
+	if req.AmountDecimal != "" {
+		return req.AmountDecimal
+	}
+	return strconv.FormatFloat(req.Amount, 'f', -1, 64)
 }
 
 // CreateRefundRequest creates a refund request without generating an x402 quote.
@@ -42,7 +67,7 @@ func (s *Service) CreateRefundRequest(ctx context.Context, req RefundQuoteReques
 	if req.RecipientWallet == "" {
 		return storage.RefundQuote{}, fmt.Errorf("paywall: recipientWallet required")
 	}
-	if req.Amount <= 0 {
+	if req.AmountDecimal == "" && req.Amount <= 0 {
 		return storage.RefundQuote{}, fmt.Errorf("paywall: amount must be positive")
 	}
 	if req.Token == "" {
@@ -55,6 +80,18 @@ func (s *Service) CreateRefundRequest(ctx context.Context, req RefundQuoteReques
 		return storage.RefundQuote{}, fmt.Errorf("paywall: invalid recipient wallet address: %w", err)
 	}
 
+	// Allowed wallets are exempt from the refunds_per_wallet rule
+	var velocity velocityHit
+	list, err := s.accessList(ctx, req.RecipientWallet, "")
+	if err != nil {
+		return storage.RefundQuote{}, err
+	}
+	if list != storage.AccessListAllow {
+		if velocity, err = s.checkVelocity(ctx, velocityRefundsPerWallet, req.RecipientWallet); err != nil {
+			return storage.RefundQuote{}, err
+		}
+	}
+
 	// SECURITY: Enforce one-refund-per-signature limit
 	// Check if a refund already exists for this transaction signature
 	existingRefund, err := s.store.GetRefundQuoteByOriginalPurchaseID(ctx, req.OriginalPurchaseID)
@@ -81,30 +118,48 @@ func (s *Service) CreateRefundRequest(ctx context.Context, req RefundQuoteReques
 	}
 	expiresAt := now.Add(refundTTL) // Will be updated when admin approves
 
-	// Convert float64 amount to Money for storage
+	// Convert the amount to Money for storage
 	asset, err := money.GetAsset(req.Token)
 	if err != nil {
 		return storage.RefundQuote{}, fmt.Errorf("paywall: get asset for token %s: %w", req.Token, err)
 	}
-	refundAmount, err := money.FromMajor(asset, strconv.FormatFloat(req.Amount, 'f', -1, 64))
+	refundAmount, err := money.FromMajor(asset, req.major())
 	if err != nil {
 		return storage.RefundQuote{}, fmt.Errorf("paywall: convert refund amount to Money: %w", err)
 	}
+	if !refundAmount.IsPositive() {
+		return storage.RefundQuote{}, fmt.Errorf("paywall: amount must be positive")
+	}
 
+	requestedAmount := refundAmount
 	refundQuote := storage.RefundQuote{
 		ID:                 refundID,
 		OriginalPurchaseID: req.OriginalPurchaseID,
 		RecipientWallet:    req.RecipientWallet,
 		Amount:             refundAmount, // Store as Money
+		RequestedAmount:    &requestedAmount,
 		Reason:             req.Reason,
 		Metadata:           req.Metadata,
 		CreatedAt:          now,
 		ExpiresAt:          expiresAt,
+		History:            []storage.RefundHistoryEntry{refundStatusEntry(storage.RefundStatusRequested, refundActorCustomer, req.Reason, now)},
+	}
+	if velocity.rule != "" {
+		refundQuote.Metadata = cloneMap(req.Metadata)
+		if refundQuote.Metadata == nil {
+			refundQuote.Metadata = make(map[string]string)
+		}
+		velocity.addMetadata(refundQuote.Metadata)
+		if velocity.action == config.FraudActionRequireReview {
+			appendRefundHistory(&refundQuote, refundStatusEntry(storage.RefundStatusEscalated, refundActorPolicy,
+				fmt.Sprintf("review required by the %s velocity rule", velocity.rule), now))
+		}
 	}
 
 	if err := s.store.SaveRefundQuote(ctx, refundQuote); err != nil {
 		return storage.RefundQuote{}, fmt.Errorf("paywall: save refund quote: %w", err)
 	}
+	s.recordVelocity(velocityRefundsPerWallet, req.RecipientWallet)
 
 	return refundQuote, nil
 }
@@ -144,7 +199,7 @@ func (s *Service) authorizeRefund(ctx context.Context, refundID, paymentHeader s
 	}
 
 	// Validate scheme and network
-	if proof.Scheme != "solana-spl-transfer" && proof.Scheme != "solana" {
+	if !x402.SupportedScheme(proof.Scheme) {
 		return AuthorizationResult{}, fmt.Errorf("unsupported scheme: %s", proof.Scheme)
 	}
 	if proof.Network != s.cfg.X402.Network {
@@ -202,7 +257,9 @@ func (s *Service) authorizeRefund(ctx context.Context, refundID, paymentHeader s
 		AllowedTokens:         []string{refund.Amount.Asset.Code}, // Token symbol from asset
 		QuoteTTL:              refundTTL,
 		SkipPreflight:         s.cfg.X402.SkipPreflight,
+		SimulateTransaction:   s.cfg.X402.SimulateTransactions,
 		Commitment:            s.cfg.X402.Commitment,
+		SquadsMultisig:        s.cfg.X402.SquadsMultisig, // Multisig vaults refund via a proposal, not a transfer
 	}
 
 	// CRITICAL: Atomically claim this signature BEFORE verification to prevent TOCTOU race
@@ -244,13 +301,7 @@ func (s *Service) authorizeRefund(ctx context.Context, refundID, paymentHeader s
 					Msg("refund.idempotent_retry_already_verified")
 
 				// Return success with the already-verified transaction details
-				networkID := s.cfg.X402.Network
-				settlement := &SettlementResponse{
-					Success:   true,
-					Error:     nil,
-					TxHash:    &proof.Signature,
-					NetworkID: &networkID,
-				}
+				settlement := s.settlement(proof.Signature, originalTx.Wallet)
 				return AuthorizationResult{
 					Granted:    true,
 					Method:     "x402-refund",
@@ -306,6 +357,7 @@ func (s *Service) authorizeRefund(ctx context.Context, refundID, paymentHeader s
 
 	// IMPORTANT: Verify that the payer is the configured payTo wallet (only server can issue refunds)
 	// We check the wallet from the verification result, not from the proof, as it's extracted from the actual transaction
+	// For a Squads multisig this is the vault named in the proposal
 	if result.Wallet != s.cfg.X402.PaymentAddress {
 		return AuthorizationResult{}, fmt.Errorf("unauthorized: only payment address %s can issue refunds (got %s)", s.cfg.X402.PaymentAddress, result.Wallet)
 	}
@@ -336,6 +388,7 @@ func (s *Service) authorizeRefund(ctx context.Context, refundID, paymentHeader s
 	if err := s.store.MarkRefundProcessed(ctx, refundID, result.Wallet, proof.Signature); err != nil {
 		return AuthorizationResult{}, fmt.Errorf("mark refund processed: %w", err)
 	}
+	s.recordRefundProcessed(ctx, refundID, proof.Signature, now)
 
 	// Use atomic units directly for metrics (no float64 conversion)
 	amountCents := refund.Amount.Atomic
@@ -350,6 +403,14 @@ func (s *Service) authorizeRefund(ctx context.Context, refundID, paymentHeader s
 	metadata["original_purchase_id"] = refund.OriginalPurchaseID
 	metadata["recipient_wallet"] = refund.RecipientWallet
 	metadata["reason"] = refund.Reason
+	if refund.IsAdjusted() {
+		requested := refund.Requested()
+		metadata["requested_amount"] = requested.ToMajor()
+	}
+	if s.cfg.X402.SquadsMultisig != "" {
+		// The signature is the proposal's; funds move once the multisig executes it
+		metadata["squads_multisig"] = s.cfg.X402.SquadsMultisig
+	}
 
 	// Fire refund succeeded callback
 	s.notifier.RefundSucceeded(ctx, callbacks.RefundEvent{
@@ -366,13 +427,7 @@ func (s *Service) authorizeRefund(ctx context.Context, refundID, paymentHeader s
 	})
 
 	// Build settlement response
-	networkID := s.cfg.X402.Network
-	settlement := &SettlementResponse{
-		Success:   true,
-		Error:     nil,
-		TxHash:    &proof.Signature,
-		NetworkID: &networkID,
-	}
+	settlement := s.settlement(proof.Signature, result.Wallet)
 
 	return AuthorizationResult{
 		Granted:    true,
@@ -389,6 +444,8 @@ func (s *Service) GetRefundQuote(ctx context.Context, refundID string) (storage.
 
 // RegenerateRefundQuote generates a fresh x402 quote for an existing refund request.
 // This is used when the original quote expires (blockhash becomes stale after 15 min).
+// With a refund nonce account the quote is valid for refund_nonce_quote_ttl instead, since
+// the transaction does not depend on a recent blockhash.
 func (s *Service) RegenerateRefundQuote(ctx context.Context, refundID string) (RefundQuoteResponse, error) {
 	// Get existing refund
 	refund, err := s.store.GetRefundQuote(ctx, refundID)
@@ -401,16 +458,27 @@ func (s *Service) RegenerateRefundQuote(ctx context.Context, refundID string) (R
 		return RefundQuoteResponse{}, fmt.Errorf("paywall: refund already processed")
 	}
 
+	// Screen the recipient before approving a transfer to them
+	if err := s.screenWallet(ctx, refund.RecipientWallet, screeningRefund); err != nil {
+		return RefundQuoteResponse{}, err
+	}
+
 	// Generate fresh quote with new expiry
 	now := time.Now()
 	refundTTL := s.cfg.Storage.RefundQuoteTTL.Duration
 	if refundTTL == 0 {
 		refundTTL = 15 * time.Minute // Fallback default
 	}
+	if s.cfg.X402.RefundNonceAccount != "" {
+		refundTTL = s.cfg.X402.RefundNonceQuoteTTL.Duration
+	}
 	expiresAt := now.Add(refundTTL)
 
-	// Update expiry in storage
+	// Update expiry in storage; re-quoting an approved refund is not a new approval
 	refund.ExpiresAt = expiresAt
+	if refund.LatestStatus() != storage.RefundStatusApproved {
+		appendRefundHistory(&refund, refundStatusEntry(storage.RefundStatusApproved, refundActorAdmin, "", now))
+	}
 	if err := s.store.SaveRefundQuote(ctx, refund); err != nil {
 		return RefundQuoteResponse{}, fmt.Errorf("paywall: update refund expiry: %w", err)
 	}
@@ -429,6 +497,54 @@ func (s *Service) RegenerateRefundQuote(ctx context.Context, refundID string) (R
 	}, nil
 }
 
+// AdjustRefundAmount lowers the amount of a pending refund request to what the admin approved,
+// keeping the requested amount on the request. approvedAmount is in major units of the refund's
+// token, e.g. "7.50". The next quote (see RegenerateRefundQuote) is for the approved amount.
+func (s *Service) AdjustRefundAmount(ctx context.Context, refundID, approvedAmount string) (storage.RefundQuote, error) {
+	refund, err := s.store.GetRefundQuote(ctx, refundID)
+	if err != nil {
+		return storage.RefundQuote{}, fmt.Errorf("paywall: get refund: %w", err)
+	}
+	if refund.IsProcessed() {
+		return storage.RefundQuote{}, fmt.Errorf("paywall: refund already processed")
+	}
+
+	approved, err := money.FromMajor(refund.Amount.Asset, approvedAmount)
+	if err != nil {
+		return storage.RefundQuote{}, fmt.Errorf("paywall: invalid approved amount: %w", err)
+	}
+	if !approved.IsPositive() {
+		return storage.RefundQuote{}, fmt.Errorf("paywall: approved amount must be positive")
+	}
+	requested := refund.Requested()
+	if approved.GreaterThan(requested) {
+		return storage.RefundQuote{}, ErrRefundAmountTooHigh
+	}
+	if approved.Equal(refund.Amount) {
+		return refund, nil
+	}
+
+	refund.RequestedAmount = &requested
+	refund.Amount = approved
+	reason := fmt.Sprintf("approved %s of %s requested", approved.ToMajor(), requested.ToMajor())
+	appendRefundHistory(&refund, refundStatusEntry(storage.RefundStatusAdjusted, refundActorAdmin, reason, time.Now()))
+	if err := s.store.SaveRefundQuote(ctx, refund); err != nil {
+		return storage.RefundQuote{}, fmt.Errorf("paywall: save approved amount: %w", err)
+	}
+
+	if err := s.recordRefundAudit(ctx, refund, storage.RefundAuditAdjusted, refundActorAdmin, reason, time.Now()); err != nil {
+		return storage.RefundQuote{}, err
+	}
+
+	log := logger.FromContext(ctx)
+	log.Info().
+		Str("refund_id", refundID).
+		Str("requested", requested.ToMajor()).
+		Str("approved", approved.ToMajor()).
+		Msg("refund.amount_adjusted")
+	return refund, nil
+}
+
 // ListPendingRefunds returns all unprocessed refund quotes.
 // This is used by admin to review pending refund requests.
 func (s *Service) ListPendingRefunds(ctx context.Context) ([]storage.RefundQuote, error) {
@@ -453,6 +569,11 @@ func (s *Service) DenyRefund(ctx context.Context, refundID string) error {
 		return fmt.Errorf("paywall: cannot deny already processed refund")
 	}
 
+	// Record the decision before the request is gone
+	if err := s.recordRefundAudit(ctx, quote, storage.RefundAuditDenied, refundActorAdmin, "", time.Now()); err != nil {
+		return err
+	}
+
 	// Delete the refund quote
 	if err := s.store.DeleteRefundQuote(ctx, refundID); err != nil {
 		return fmt.Errorf("paywall: failed to delete refund: %w", err)
@@ -0,0 +1,101 @@
+package paywall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+// Limits on a refund request's conversation thread.
+const (
+	maxRefundNoteLength = 2000 // Characters per note
+	maxRefundNotes      = 100  // Notes per refund request
+)
+
+// ErrInvalidRefundNote is returned for an empty, oversized, or excess note on a refund request.
+var ErrInvalidRefundNote = errors.New("paywall: invalid refund note")
+
+// AddRefundNote adds a note from the customer or an admin (author "customer" or "admin") to a
+// refund request's thread and returns the updated request.
+func (s *Service) AddRefundNote(ctx context.Context, refundID, author, message string) (storage.RefundQuote, error) {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return storage.RefundQuote{}, fmt.Errorf("%w: message required", ErrInvalidRefundNote)
+	}
+	if len([]rune(message)) > maxRefundNoteLength {
+		return storage.RefundQuote{}, fmt.Errorf("%w: message longer than %d characters", ErrInvalidRefundNote, maxRefundNoteLength)
+	}
+	if author != refundActorCustomer && author != refundActorAdmin {
+		return storage.RefundQuote{}, fmt.Errorf("%w: unknown author %q", ErrInvalidRefundNote, author)
+	}
+
+	refund, err := s.store.GetRefundQuote(ctx, refundID)
+	if err != nil {
+		return storage.RefundQuote{}, fmt.Errorf("paywall: %w", err)
+	}
+	notes := 0
+	for _, entry := range refund.History {
+		if entry.Kind == storage.RefundHistoryMessage {
+			notes++
+		}
+	}
+	if notes >= maxRefundNotes {
+		return storage.RefundQuote{}, fmt.Errorf("%w: thread is limited to %d notes", ErrInvalidRefundNote, maxRefundNotes)
+	}
+
+	appendRefundHistory(&refund, storage.RefundHistoryEntry{
+		Kind:    storage.RefundHistoryMessage,
+		Author:  author,
+		Message: message,
+		At:      time.Now().UTC(),
+	})
+	if err := s.store.SaveRefundQuote(ctx, refund); err != nil {
+		return storage.RefundQuote{}, fmt.Errorf("paywall: save refund note: %w", err)
+	}
+
+	log := logger.FromContext(ctx)
+	log.Info().
+		Str("refund_id", refundID).
+		Str("author", author).
+		Msg("refund.note_added")
+	return refund, nil
+}
+
+// appendRefundHistory adds an entry to the refund's history without sharing the stored slice.
+func appendRefundHistory(refund *storage.RefundQuote, entry storage.RefundHistoryEntry) {
+	refund.History = append(slices.Clip(refund.History), entry)
+}
+
+// refundStatusEntry builds a status change entry for a refund's history.
+func refundStatusEntry(status, author, message string, at time.Time) storage.RefundHistoryEntry {
+	return storage.RefundHistoryEntry{
+		Kind:    storage.RefundHistoryStatus,
+		Author:  author,
+		Status:  status,
+		Message: message,
+		At:      at.UTC(),
+	}
+}
+
+// recordRefundProcessed adds the processed status to a refund's history once it has been
+// marked processed. Failures are logged: the refund itself already went through.
+func (s *Service) recordRefundProcessed(ctx context.Context, refundID, signature string, at time.Time) {
+	log := logger.FromContext(ctx)
+	refund, err := s.store.GetRefundQuote(ctx, refundID)
+	if err == nil {
+		appendRefundHistory(&refund, refundStatusEntry(storage.RefundStatusProcessed, refundActorAdmin, "signature "+signature, at))
+		err = s.store.SaveRefundQuote(ctx, refund)
+	}
+	if err != nil {
+		log.Warn().
+			Err(err).
+			Str("refund_id", refundID).
+			Msg("refund.history_update_failed")
+	}
+}
@@ -0,0 +1,116 @@
+package paywall
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/CedrosPay/server/internal/callbacks"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func TestRefundHistory(t *testing.T) {
+	ctx := context.Background()
+	cfg := testConfig()
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	svc := NewService(cfg, store, stubVerifier{}, callbacks.NoopNotifier{}, testRepository(cfg), nil, nil)
+
+	refund, err := svc.CreateRefundRequest(ctx, RefundQuoteRequest{
+		OriginalPurchaseID: "purchase_1",
+		RecipientWallet:    "11111111111111111111111111111111",
+		Amount:             10,
+		Token:              "USDC",
+		Reason:             "never arrived",
+	})
+	if err != nil {
+		t.Fatalf("CreateRefundRequest: %v", err)
+	}
+	if _, err := svc.AddRefundNote(ctx, refund.ID, refundActorCustomer, "  any update?  "); err != nil {
+		t.Fatalf("AddRefundNote: %v", err)
+	}
+	if _, err := svc.AdjustRefundAmount(ctx, refund.ID, "5"); err != nil {
+		t.Fatalf("AdjustRefundAmount: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := svc.RegenerateRefundQuote(ctx, refund.ID); err != nil {
+			t.Fatalf("RegenerateRefundQuote: %v", err)
+		}
+	}
+
+	pending, err := svc.ListPendingRefunds(ctx)
+	if err != nil || len(pending) != 1 {
+		t.Fatalf("ListPendingRefunds = %d, %v", len(pending), err)
+	}
+	want := []storage.RefundHistoryEntry{
+		{Kind: storage.RefundHistoryStatus, Author: "customer", Status: storage.RefundStatusRequested, Message: "never arrived"},
+		{Kind: storage.RefundHistoryMessage, Author: "customer", Message: "any update?"},
+		{Kind: storage.RefundHistoryStatus, Author: "admin", Status: storage.RefundStatusAdjusted, Message: "approved 5.000000 of 10.000000 requested"},
+		{Kind: storage.RefundHistoryStatus, Author: "admin", Status: storage.RefundStatusApproved},
+	}
+	history := pending[0].History
+	if len(history) != len(want) {
+		t.Fatalf("history = %+v, want %d entries", history, len(want))
+	}
+	for i, entry := range history {
+		if entry.At.IsZero() {
+			t.Errorf("entry %d has no timestamp", i)
+		}
+		entry.At = want[i].At
+		if entry != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, entry, want[i])
+		}
+	}
+	if got := pending[0].LatestStatus(); got != storage.RefundStatusApproved {
+		t.Errorf("LatestStatus() = %q, want %q", got, storage.RefundStatusApproved)
+	}
+}
+
+func TestAddRefundNote_Validation(t *testing.T) {
+	tests := []struct {
+		name    string
+		author  string
+		message string
+		wantErr error
+	}{
+		{name: "admin reply", author: refundActorAdmin, message: "looking into it"},
+		{name: "empty message", author: refundActorCustomer, message: "   ", wantErr: ErrInvalidRefundNote},
+		{name: "too long", author: refundActorCustomer, message: strings.Repeat("a", maxRefundNoteLength+1), wantErr: ErrInvalidRefundNote},
+		{name: "unknown author", author: refundActorPolicy, message: "hello", wantErr: ErrInvalidRefundNote},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cfg := testConfig()
+			store := storage.NewMemoryStore()
+			t.Cleanup(func() { _ = store.Close() })
+			svc := NewService(cfg, store, stubVerifier{}, callbacks.NoopNotifier{}, testRepository(cfg), nil, nil)
+
+			refund, err := svc.CreateRefundRequest(ctx, RefundQuoteRequest{
+				OriginalPurchaseID: "purchase_1",
+				RecipientWallet:    "11111111111111111111111111111111",
+				Amount:             10,
+				Token:              "USDC",
+			})
+			if err != nil {
+				t.Fatalf("CreateRefundRequest: %v", err)
+			}
+
+			_, err = svc.AddRefundNote(ctx, refund.ID, tt.author, tt.message)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("unknown refund", func(t *testing.T) {
+		cfg := testConfig()
+		store := storage.NewMemoryStore()
+		t.Cleanup(func() { _ = store.Close() })
+		svc := NewService(cfg, store, stubVerifier{}, callbacks.NoopNotifier{}, testRepository(cfg), nil, nil)
+		if _, err := svc.AddRefundNote(context.Background(), "refund_missing", refundActorCustomer, "hi"); !errors.Is(err, storage.ErrNotFound) {
+			t.Fatalf("err = %v, want ErrNotFound", err)
+		}
+	})
+}
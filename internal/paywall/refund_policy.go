@@ -0,0 +1,236 @@
+package paywall
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/CedrosPay/server/internal/callbacks"
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/rs/zerolog"
+)
+
+// refundEscalatedAtKey marks a refund request the pending policy has already escalated, so it
+// is escalated once rather than on every pass.
+const refundEscalatedAtKey = "escalated_at"
+
+// Refund audit and history actors.
+const (
+	refundActorCustomer = "customer"
+	refundActorAdmin    = "admin"
+	refundActorPolicy   = "policy"
+)
+
+// ExpirePendingRefunds applies the pending refund policy: requests waiting longer than
+// paywall.refunds.pending_expiry_days are denied or escalated, each with an audit entry and a
+// callback. It also refreshes the pending refund metrics, and returns how many requests it acted on.
+func (s *Service) ExpirePendingRefunds(ctx context.Context, now time.Time) (int, error) {
+	pending, err := s.store.ListPendingRefunds(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("paywall: list pending refunds: %w", err)
+	}
+
+	policy := s.cfg.Paywall.Refunds
+	maxAge := time.Duration(policy.PendingExpiryDays) * 24 * time.Hour
+	acted, remaining := 0, 0
+	var oldest time.Duration
+	for _, refund := range pending {
+		age := now.Sub(refund.CreatedAt)
+		if maxAge > 0 && age >= maxAge {
+			switch {
+			case policy.PendingExpiryAction != config.RefundPendingActionEscalate:
+				if err := s.autoDenyRefund(ctx, refund, age, now); err != nil {
+					return acted, err
+				}
+				acted++
+				continue
+			case refund.Metadata[refundEscalatedAtKey] == "":
+				// Escalated requests stay pending for an admin
+				if err := s.escalateRefund(ctx, refund, age, now); err != nil {
+					return acted, err
+				}
+				acted++
+			}
+		}
+		remaining++
+		if age > oldest {
+			oldest = age
+		}
+	}
+
+	if s.metrics != nil {
+		s.metrics.ObservePendingRefunds(remaining, oldest)
+	}
+	return acted, nil
+}
+
+// autoDenyRefund deletes a refund request the policy expired.
+func (s *Service) autoDenyRefund(ctx context.Context, refund storage.RefundQuote, age time.Duration, now time.Time) error {
+	reason := fmt.Sprintf("pending for more than %d days", s.cfg.Paywall.Refunds.PendingExpiryDays)
+	if err := s.recordRefundAudit(ctx, refund, storage.RefundAuditAutoDenied, refundActorPolicy, reason, now); err != nil {
+		return err
+	}
+	if err := s.store.DeleteRefundQuote(ctx, refund.ID); err != nil {
+		return fmt.Errorf("paywall: delete refund %s: %w", refund.ID, err)
+	}
+	if s.metrics != nil {
+		s.metrics.RefundsTotal.WithLabelValues(storage.RefundAuditAutoDenied).Inc()
+	}
+
+	log := logger.FromContext(ctx)
+	log.Info().
+		Str("refund_id", refund.ID).
+		Dur("pending", age).
+		Msg("refund.auto_denied")
+	s.notifier.RefundRequestChanged(ctx, refundRequestEvent(callbacks.RefundRequestAutoDenied, refund, age, now))
+	return nil
+}
+
+// escalateRefund flags a refund request the policy expired, leaving it pending for an admin.
+func (s *Service) escalateRefund(ctx context.Context, refund storage.RefundQuote, age time.Duration, now time.Time) error {
+	metadata := make(map[string]string, len(refund.Metadata)+1)
+	for k, v := range refund.Metadata {
+		metadata[k] = v
+	}
+	metadata[refundEscalatedAtKey] = now.UTC().Format(time.RFC3339)
+	refund.Metadata = metadata
+	appendRefundHistory(&refund, refundStatusEntry(storage.RefundStatusEscalated, refundActorPolicy,
+		fmt.Sprintf("pending for more than %d days", s.cfg.Paywall.Refunds.PendingExpiryDays), now))
+	if err := s.store.SaveRefundQuote(ctx, refund); err != nil {
+		return fmt.Errorf("paywall: escalate refund %s: %w", refund.ID, err)
+	}
+
+	reason := fmt.Sprintf("pending for more than %d days", s.cfg.Paywall.Refunds.PendingExpiryDays)
+	if err := s.recordRefundAudit(ctx, refund, storage.RefundAuditEscalated, refundActorPolicy, reason, now); err != nil {
+		return err
+	}
+	if s.metrics != nil {
+		s.metrics.RefundsTotal.WithLabelValues(storage.RefundAuditEscalated).Inc()
+	}
+
+	log := logger.FromContext(ctx)
+	log.Warn().
+		Str("refund_id", refund.ID).
+		Dur("pending", age).
+		Msg("refund.escalated")
+	s.notifier.RefundRequestChanged(ctx, refundRequestEvent(callbacks.RefundRequestEscalated, refund, age, now))
+	return nil
+}
+
+// recordRefundAudit adds an audit entry for a decision on a refund request.
+func (s *Service) recordRefundAudit(ctx context.Context, refund storage.RefundQuote, action, actor, reason string, now time.Time) error {
+	entry := storage.RefundAuditEntry{
+		RefundID:           refund.ID,
+		Action:             action,
+		Actor:              actor,
+		OriginalPurchaseID: refund.OriginalPurchaseID,
+		RecipientWallet:    refund.RecipientWallet,
+		Amount:             refund.Amount,
+		Reason:             reason,
+		Metadata:           refund.Metadata,
+		RecordedAt:         now.UTC(),
+	}
+	if err := s.store.RecordRefundAudit(ctx, entry); err != nil {
+		return fmt.Errorf("paywall: record refund audit: %w", err)
+	}
+	return nil
+}
+
+// ListRefundAudit returns the audit trail of a refund request, oldest first.
+func (s *Service) ListRefundAudit(ctx context.Context, refundID string) ([]storage.RefundAuditEntry, error) {
+	return s.store.ListRefundAudit(ctx, refundID)
+}
+
+// refundRequestEvent builds the callback for a policy decision on a refund request.
+func refundRequestEvent(eventType string, refund storage.RefundQuote, age time.Duration, now time.Time) callbacks.RefundRequestEvent {
+	return callbacks.RefundRequestEvent{
+		EventType:          eventType,
+		RefundID:           refund.ID,
+		OriginalPurchaseID: refund.OriginalPurchaseID,
+		RecipientWallet:    refund.RecipientWallet,
+		AtomicAmount:       refund.Amount.Atomic,
+		Token:              refund.Amount.Asset.Code,
+		Reason:             refund.Reason,
+		Metadata:           refund.Metadata,
+		RequestedAt:        refund.CreatedAt,
+		PendingSeconds:     int64(age / time.Second),
+		ChangedAt:          now.UTC(),
+	}
+}
+
+// RefundExpiryMonitor periodically applies the pending refund policy.
+type RefundExpiryMonitor struct {
+	service  *Service
+	interval time.Duration
+	logger   zerolog.Logger
+	stopChan chan struct{}
+	doneChan chan struct{}
+}
+
+// NewRefundExpiryMonitor creates a monitor that checks pending refunds every interval
+// (default: 1 hour).
+func NewRefundExpiryMonitor(service *Service, interval time.Duration, logger zerolog.Logger) *RefundExpiryMonitor {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	return &RefundExpiryMonitor{
+		service:  service,
+		interval: interval,
+		logger:   logger,
+		stopChan: make(chan struct{}),
+		doneChan: make(chan struct{}),
+	}
+}
+
+// Start begins the monitor's background loop.
+func (m *RefundExpiryMonitor) Start() {
+	m.logger.Info().
+		Int("pendingExpiryDays", m.service.cfg.Paywall.Refunds.PendingExpiryDays).
+		Str("action", m.service.cfg.Paywall.Refunds.PendingExpiryAction).
+		Dur("runInterval", m.interval).
+		Msg("refund.expiry_monitor_started")
+
+	go m.run()
+}
+
+// Stop stops the monitor and waits for a pass in progress to finish.
+func (m *RefundExpiryMonitor) Stop() {
+	close(m.stopChan)
+	<-m.doneChan
+}
+
+// run is the main monitor loop.
+func (m *RefundExpiryMonitor) run() {
+	defer close(m.doneChan)
+
+	m.check()
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.check()
+		case <-m.stopChan:
+			return
+		}
+	}
+}
+
+// check performs a single pass over pending refunds.
+func (m *RefundExpiryMonitor) check() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	count, err := m.service.ExpirePendingRefunds(ctx, time.Now())
+	if err != nil {
+		m.logger.Error().Err(err).Msg("refund.expiry_check_failed")
+		return
+	}
+	if count > 0 {
+		m.logger.Info().Int("count", count).Msg("refund.pending_expired")
+	}
+}
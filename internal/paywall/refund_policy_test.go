@@ -0,0 +1,121 @@
+package paywall
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/CedrosPay/server/internal/callbacks"
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/money"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func TestExpirePendingRefunds(t *testing.T) {
+	tests := []struct {
+		name       string
+		days       int
+		action     string
+		wantActed  int
+		wantEvent  string
+		wantAudit  string
+		wantExists bool
+	}{
+		{name: "policy disabled", wantExists: true},
+		{name: "stale request is denied", days: 7, wantActed: 1, wantEvent: callbacks.RefundRequestAutoDenied, wantAudit: storage.RefundAuditAutoDenied},
+		{name: "stale request is escalated", days: 7, action: config.RefundPendingActionEscalate, wantActed: 1, wantEvent: callbacks.RefundRequestEscalated, wantAudit: storage.RefundAuditEscalated, wantExists: true},
+		{name: "request within the window", days: 30, wantExists: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cfg := testConfig()
+			cfg.Paywall.Refunds = config.RefundPolicyConfig{PendingExpiryDays: tt.days, PendingExpiryAction: tt.action}
+			store := storage.NewMemoryStore()
+			t.Cleanup(func() { _ = store.Close() })
+			notifier := &recordingNotifier{}
+			svc := NewService(cfg, store, stubVerifier{}, notifier, testRepository(cfg), nil, nil)
+
+			now := time.Now()
+			refund := storage.RefundQuote{
+				ID:                 "refund_stale",
+				OriginalPurchaseID: "purchase_1",
+				RecipientWallet:    "11111111111111111111111111111111",
+				Amount:             money.New(money.MustGetAsset("USDC"), 5_000000),
+				CreatedAt:          now.Add(-10 * 24 * time.Hour),
+				ExpiresAt:          now.Add(-10*24*time.Hour + 15*time.Minute),
+			}
+			if err := store.SaveRefundQuote(ctx, refund); err != nil {
+				t.Fatalf("SaveRefundQuote: %v", err)
+			}
+
+			acted, err := svc.ExpirePendingRefunds(ctx, now)
+			if err != nil {
+				t.Fatalf("ExpirePendingRefunds: %v", err)
+			}
+			if acted != tt.wantActed {
+				t.Fatalf("acted = %d, want %d", acted, tt.wantActed)
+			}
+
+			_, err = store.GetRefundQuote(ctx, refund.ID)
+			if exists := err == nil; exists != tt.wantExists {
+				t.Fatalf("refund exists = %v (err %v), want %v", exists, err, tt.wantExists)
+			}
+			if !tt.wantExists && !errors.Is(err, storage.ErrNotFound) {
+				t.Fatalf("GetRefundQuote err = %v, want ErrNotFound", err)
+			}
+
+			audit, err := svc.ListRefundAudit(ctx, refund.ID)
+			if err != nil {
+				t.Fatalf("ListRefundAudit: %v", err)
+			}
+			if tt.wantEvent == "" {
+				if len(notifier.refundRequests) != 0 || len(audit) != 0 {
+					t.Fatalf("unexpected events %+v / audit %+v", notifier.refundRequests, audit)
+				}
+				return
+			}
+			if len(notifier.refundRequests) != 1 || notifier.refundRequests[0].EventType != tt.wantEvent {
+				t.Fatalf("events = %+v, want one %s", notifier.refundRequests, tt.wantEvent)
+			}
+			if len(audit) != 1 || audit[0].Action != tt.wantAudit || audit[0].Actor != "policy" {
+				t.Fatalf("audit = %+v, want one %s by policy", audit, tt.wantAudit)
+			}
+
+			// A second pass does not act on the same request again
+			if acted, err := svc.ExpirePendingRefunds(ctx, now.Add(time.Hour)); err != nil || acted != 0 {
+				t.Fatalf("second pass acted = %d, err = %v", acted, err)
+			}
+		})
+	}
+}
+
+func TestDenyRefundRecordsAudit(t *testing.T) {
+	ctx := context.Background()
+	cfg := testConfig()
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	svc := NewService(cfg, store, stubVerifier{}, callbacks.NoopNotifier{}, testRepository(cfg), nil, nil)
+
+	refund, err := svc.CreateRefundRequest(ctx, RefundQuoteRequest{
+		OriginalPurchaseID: "purchase_1",
+		RecipientWallet:    "11111111111111111111111111111111",
+		Amount:             1,
+		Token:              "USDC",
+	})
+	if err != nil {
+		t.Fatalf("CreateRefundRequest: %v", err)
+	}
+	if err := svc.DenyRefund(ctx, refund.ID); err != nil {
+		t.Fatalf("DenyRefund: %v", err)
+	}
+
+	audit, err := svc.ListRefundAudit(ctx, refund.ID)
+	if err != nil {
+		t.Fatalf("ListRefundAudit: %v", err)
+	}
+	if len(audit) != 1 || audit[0].Action != storage.RefundAuditDenied || audit[0].Actor != "admin" {
+		t.Fatalf("audit = %+v, want one denied by admin", audit)
+	}
+}
@@ -4,14 +4,17 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"strconv"
 	"testing"
 	"time"
 
 	"github.com/CedrosPay/server/internal/callbacks"
+	"github.com/CedrosPay/server/internal/config"
 	"github.com/CedrosPay/server/internal/money"
 	"github.com/CedrosPay/server/internal/storage"
 	"github.com/CedrosPay/server/pkg/x402"
+	x402solana "github.com/CedrosPay/server/pkg/x402/solana"
 )
 
 func TestGenerateRefundQuote_ValidRequest(t *testing.T) {
@@ -508,3 +511,143 @@ func TestAuthorizeRefund_RefundNotFound(t *testing.T) {
 		t.Error("Authorize() should error for non-existent refund")
 	}
 }
+
+// durableStubVerifier records the durable transfer it is asked to build.
+type durableStubVerifier struct {
+	stubVerifier
+	req *x402solana.DurableTransferRequest
+}
+
+func (s durableStubVerifier) BuildDurableTransfer(_ context.Context, req x402solana.DurableTransferRequest) (x402solana.DurableTransferResponse, error) {
+	*s.req = req
+	return x402solana.DurableTransferResponse{Transaction: "tx", NonceAccount: req.NonceAccount.String(), Nonce: "nonce"}, nil
+}
+
+func TestRegenerateRefundQuote_DurableNonce(t *testing.T) {
+	cfg := testConfig()
+	cfg.X402.MemoPrefix = "cedros"
+	cfg.X402.RefundNonceAccount = "9xQeWvG816bUx9EPjHmaT23yvVM2ZWbrrpZb9PusVFin"
+	cfg.X402.RefundNonceQuoteTTL = config.Duration{Duration: 48 * time.Hour}
+	store := storage.NewMemoryStore()
+	defer store.Stop()
+	var built x402solana.DurableTransferRequest
+	svc := NewService(cfg, store, durableStubVerifier{req: &built}, callbacks.NoopNotifier{}, testRepository(cfg), nil, nil)
+
+	refund, err := svc.CreateRefundRequest(context.Background(), RefundQuoteRequest{
+		OriginalPurchaseID: "purchase_123",
+		RecipientWallet:    "11111111111111111111111111111111",
+		Amount:             2.5,
+		Token:              "USDC",
+	})
+	if err != nil {
+		t.Fatalf("CreateRefundRequest() error = %v", err)
+	}
+
+	// The quote outlives the blockhash: it is valid for the nonce TTL, not refund_quote_ttl
+	resp, err := svc.RegenerateRefundQuote(context.Background(), refund.ID)
+	if err != nil {
+		t.Fatalf("RegenerateRefundQuote() error = %v", err)
+	}
+	if until := time.Until(resp.ExpiresAt); until < 47*time.Hour {
+		t.Errorf("ExpiresAt in %v, want the 48h nonce TTL", until)
+	}
+
+	tx, err := svc.BuildRefundNonceTransaction(context.Background(), refund.ID)
+	if err != nil {
+		t.Fatalf("BuildRefundNonceTransaction() error = %v", err)
+	}
+	if tx.NonceAccount != cfg.X402.RefundNonceAccount {
+		t.Errorf("NonceAccount = %q, want %q", tx.NonceAccount, cfg.X402.RefundNonceAccount)
+	}
+	if built.Authority.String() != cfg.X402.PaymentAddress || built.Amount != 2500000 || built.Decimals != 6 {
+		t.Errorf("transfer = %+v, want 2.5 USDC from the payment address", built)
+	}
+	if want := "cedros:refund:" + refund.ID; built.Memo != want {
+		t.Errorf("Memo = %q, want %q (the refund quote's memo)", built.Memo, want)
+	}
+}
+
+func TestBuildRefundNonceTransaction_UnsupportedVerifier(t *testing.T) {
+	cfg := testConfig()
+	cfg.X402.RefundNonceAccount = "9xQeWvG816bUx9EPjHmaT23yvVM2ZWbrrpZb9PusVFin"
+	store := storage.NewMemoryStore()
+	defer store.Stop()
+	svc := NewService(cfg, store, stubVerifier{}, callbacks.NoopNotifier{}, testRepository(cfg), nil, nil)
+
+	if _, err := svc.BuildRefundNonceTransaction(context.Background(), "refund_123"); err == nil {
+		t.Error("BuildRefundNonceTransaction() should error when the verifier cannot build durable transfers")
+	}
+}
+
+func TestAdjustRefundAmount(t *testing.T) {
+	tests := []struct {
+		name         string
+		approved     string
+		wantErr      error
+		wantAtomic   int64
+		wantAudit    bool
+		wantAdjusted bool
+	}{
+		{name: "approve less", approved: "7.5", wantAtomic: 7_500000, wantAudit: true, wantAdjusted: true},
+		{name: "approve as requested", approved: "10", wantAtomic: 10_000000},
+		{name: "approve more", approved: "12", wantErr: ErrRefundAmountTooHigh},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cfg := testConfig()
+			store := storage.NewMemoryStore()
+			t.Cleanup(func() { _ = store.Close() })
+			svc := NewService(cfg, store, stubVerifier{}, callbacks.NoopNotifier{}, testRepository(cfg), nil, nil)
+
+			refund, err := svc.CreateRefundRequest(ctx, RefundQuoteRequest{
+				OriginalPurchaseID: "purchase_1",
+				RecipientWallet:    "11111111111111111111111111111111",
+				Amount:             10,
+				Token:              "USDC",
+			})
+			if err != nil {
+				t.Fatalf("CreateRefundRequest: %v", err)
+			}
+
+			_, err = svc.AdjustRefundAmount(ctx, refund.ID, tt.approved)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AdjustRefundAmount: %v", err)
+			}
+
+			stored, err := store.GetRefundQuote(ctx, refund.ID)
+			if err != nil {
+				t.Fatalf("GetRefundQuote: %v", err)
+			}
+			if stored.Amount.Atomic != tt.wantAtomic || stored.Requested().Atomic != 10_000000 {
+				t.Fatalf("amount = %d, requested = %d", stored.Amount.Atomic, stored.Requested().Atomic)
+			}
+			if stored.IsAdjusted() != tt.wantAdjusted {
+				t.Errorf("IsAdjusted() = %v, want %v", stored.IsAdjusted(), tt.wantAdjusted)
+			}
+
+			// The regenerated quote is for the approved amount
+			resp, err := svc.RegenerateRefundQuote(ctx, refund.ID)
+			if err != nil {
+				t.Fatalf("RegenerateRefundQuote: %v", err)
+			}
+			if resp.Quote.MaxAmountRequired != strconv.FormatInt(tt.wantAtomic, 10) {
+				t.Errorf("quote amount = %s, want %d", resp.Quote.MaxAmountRequired, tt.wantAtomic)
+			}
+
+			audit, err := svc.ListRefundAudit(ctx, refund.ID)
+			if err != nil {
+				t.Fatalf("ListRefundAudit: %v", err)
+			}
+			if got := len(audit) == 1 && audit[0].Action == storage.RefundAuditAdjusted; got != tt.wantAudit {
+				t.Errorf("audit = %+v, want adjusted entry %v", audit, tt.wantAudit)
+			}
+		})
+	}
+}
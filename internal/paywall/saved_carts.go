@@ -0,0 +1,117 @@
+package paywall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+// ErrInvalidSavedCart indicates a saved cart is malformed or the wallet has too many.
+var ErrInvalidSavedCart = errors.New("paywall: invalid saved cart")
+
+const (
+	maxSavedCartsPerWallet = 50
+	maxSavedCartNameLength = 64
+)
+
+// SavedCartRequest is the contents of a saved cart. Items are kept without prices; they are
+// priced when the saved cart is quoted.
+type SavedCartRequest struct {
+	Items      []CartQuoteItem   `json:"items"`
+	CouponCode string            `json:"couponCode,omitempty"` // Applied when the cart is quoted
+	Metadata   map[string]string `json:"metadata,omitempty"`   // Copied into quotes of the cart
+}
+
+// SaveCart creates or replaces the wallet's saved cart called name. Every item must be a
+// configured resource.
+func (s *Service) SaveCart(ctx context.Context, wallet, name string, req SavedCartRequest) (storage.SavedCart, error) {
+	if name == "" || len(name) > maxSavedCartNameLength || strings.Contains(name, "/") {
+		return storage.SavedCart{}, fmt.Errorf("%w: name must be 1-%d characters without '/'", ErrInvalidSavedCart, maxSavedCartNameLength)
+	}
+	if len(req.Items) == 0 {
+		return storage.SavedCart{}, fmt.Errorf("%w: at least one item required", ErrInvalidSavedCart)
+	}
+
+	items := make([]storage.SavedCartItem, 0, len(req.Items))
+	for i, item := range req.Items {
+		if item.ResourceID == "" {
+			return storage.SavedCart{}, fmt.Errorf("%w: item %d missing resource id", ErrInvalidSavedCart, i)
+		}
+		if item.Quantity <= 0 {
+			item.Quantity = 1 // Default to 1, as for cart quotes
+		}
+		if _, err := s.VariantDefinition(ctx, item.ResourceID, item.VariantID); err != nil {
+			return storage.SavedCart{}, fmt.Errorf("paywall: item %d (%s): %w", i, item.ResourceID, err)
+		}
+		items = append(items, storage.SavedCartItem{ResourceID: item.ResourceID, VariantID: item.VariantID, Quantity: item.Quantity, Metadata: item.Metadata})
+	}
+
+	existing, err := s.store.ListSavedCarts(ctx, wallet)
+	if err != nil {
+		return storage.SavedCart{}, fmt.Errorf("paywall: list saved carts: %w", err)
+	}
+	if len(existing) >= maxSavedCartsPerWallet && !containsSavedCart(existing, name) {
+		return storage.SavedCart{}, fmt.Errorf("%w: a wallet can keep at most %d saved carts", ErrInvalidSavedCart, maxSavedCartsPerWallet)
+	}
+
+	cart := storage.SavedCart{
+		Wallet:     wallet,
+		Name:       name,
+		Items:      items,
+		CouponCode: req.CouponCode,
+		Metadata:   req.Metadata,
+	}
+	if err := s.store.SaveSavedCart(ctx, cart); err != nil {
+		return storage.SavedCart{}, fmt.Errorf("paywall: save cart: %w", err)
+	}
+	return s.store.GetSavedCart(ctx, wallet, name)
+}
+
+// GetSavedCart returns the wallet's saved cart called name.
+func (s *Service) GetSavedCart(ctx context.Context, wallet, name string) (storage.SavedCart, error) {
+	return s.store.GetSavedCart(ctx, wallet, name)
+}
+
+// ListSavedCarts returns the wallet's saved carts, most recently updated first.
+func (s *Service) ListSavedCarts(ctx context.Context, wallet string) ([]storage.SavedCart, error) {
+	return s.store.ListSavedCarts(ctx, wallet)
+}
+
+// DeleteSavedCart removes the wallet's saved cart called name.
+func (s *Service) DeleteSavedCart(ctx context.Context, wallet, name string) error {
+	return s.store.DeleteSavedCart(ctx, wallet, name)
+}
+
+// QuoteSavedCart converts the wallet's saved cart into a live cart quote at the current catalog
+// prices and coupons. The saved cart is kept, so it can be quoted again later.
+func (s *Service) QuoteSavedCart(ctx context.Context, wallet, name string) (CartQuoteResponse, error) {
+	saved, err := s.store.GetSavedCart(ctx, wallet, name)
+	if err != nil {
+		return CartQuoteResponse{}, err
+	}
+
+	items := make([]CartQuoteItem, 0, len(saved.Items))
+	for _, item := range saved.Items {
+		items = append(items, CartQuoteItem{ResourceID: item.ResourceID, VariantID: item.VariantID, Quantity: item.Quantity, Metadata: item.Metadata})
+	}
+	metadata := make(map[string]string, len(saved.Metadata)+1)
+	for k, v := range saved.Metadata {
+		metadata[k] = v
+	}
+	metadata["saved_cart"] = saved.Name
+
+	return s.GenerateCartQuote(ctx, CartQuoteRequest{Items: items, Metadata: metadata, CouponCode: saved.CouponCode, Wallet: wallet})
+}
+
+// containsSavedCart reports whether carts includes one called name.
+func containsSavedCart(carts []storage.SavedCart, name string) bool {
+	for _, cart := range carts {
+		if cart.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package paywall
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/CedrosPay/server/internal/callbacks"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func TestSaveCart(t *testing.T) {
+	tests := []struct {
+		name      string
+		cartName  string
+		req       SavedCartRequest
+		wantErr   error
+		wantItems int
+	}{
+		{name: "wishlist", cartName: "wishlist", req: SavedCartRequest{Items: []CartQuoteItem{{ResourceID: "demo-content"}}}, wantItems: 1},
+		{name: "empty", cartName: "wishlist", wantErr: ErrInvalidSavedCart},
+		{name: "slash in name", cartName: "a/b", req: SavedCartRequest{Items: []CartQuoteItem{{ResourceID: "demo-content"}}}, wantErr: ErrInvalidSavedCart},
+		{name: "unknown resource", cartName: "wishlist", req: SavedCartRequest{Items: []CartQuoteItem{{ResourceID: "missing"}}}, wantErr: ErrResourceNotConfigured},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			store := storage.NewMemoryStore()
+			t.Cleanup(func() { _ = store.Close() })
+			svc := NewService(cfg, store, stubVerifier{}, callbacks.NoopNotifier{}, testRepository(cfg), nil, nil)
+
+			cart, err := svc.SaveCart(context.Background(), "wallet-a", tt.cartName, tt.req)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("SaveCart error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SaveCart error: %v", err)
+			}
+			if len(cart.Items) != tt.wantItems || cart.Items[0].Quantity != 1 || cart.CreatedAt.IsZero() {
+				t.Errorf("saved cart = %+v", cart)
+			}
+		})
+	}
+}
+
+func TestQuoteSavedCart(t *testing.T) {
+	ctx := context.Background()
+	cfg := testConfig()
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	svc := NewService(cfg, store, stubVerifier{}, callbacks.NoopNotifier{}, testRepository(cfg), nil, nil)
+
+	req := SavedCartRequest{Items: []CartQuoteItem{{ResourceID: "demo-content", Quantity: 2}}, Metadata: map[string]string{"user_id": "42"}}
+	if _, err := svc.SaveCart(ctx, "wallet-a", "wishlist", req); err != nil {
+		t.Fatalf("SaveCart error: %v", err)
+	}
+
+	quote, err := svc.QuoteSavedCart(ctx, "wallet-a", "wishlist")
+	if err != nil {
+		t.Fatalf("QuoteSavedCart error: %v", err)
+	}
+	if quote.TotalAmount != 2 || quote.Metadata["saved_cart"] != "wishlist" || quote.Metadata["user_id"] != "42" {
+		t.Errorf("quote total %v metadata %v, want 2 from the saved cart", quote.TotalAmount, quote.Metadata)
+	}
+	if _, err := svc.GetSavedCart(ctx, "wallet-a", "wishlist"); err != nil {
+		t.Errorf("saved cart removed by quoting: %v", err)
+	}
+	if _, err := svc.QuoteSavedCart(ctx, "wallet-b", "wishlist"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("another wallet's saved cart: error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,73 @@
+package paywall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/screening"
+)
+
+// What a wallet is screened for, as reported in logs and cedros_screenings_total.
+const (
+	screeningPayment = "payment" // Payer, before the payment grants access
+	screeningRefund  = "refund"  // Recipient, before the refund is approved
+)
+
+// ErrWalletFlagged is returned when compliance screening flags a payer or refund recipient.
+var ErrWalletFlagged = errors.New("paywall: wallet flagged by compliance screening")
+
+// ErrScreeningUnavailable is returned when a wallet could not be screened and
+// paywall.screening.fail_mode is closed.
+var ErrScreeningUnavailable = errors.New("paywall: compliance screening unavailable")
+
+// SetScreener sets the compliance screener run on payers and refund recipients, e.g. one from
+// screening.NewScreener. This is optional - if not set, wallets are not screened.
+func (s *Service) SetScreener(screener screening.Screener) {
+	s.screener = screener
+}
+
+// screenWallet runs the screener on wallet before it is served for purpose. A flagged wallet
+// gets ErrWalletFlagged; a screener failure gets ErrScreeningUnavailable unless fail_mode is
+// open, in which case the wallet passes.
+func (s *Service) screenWallet(ctx context.Context, wallet, purpose string) error {
+	if s.screener == nil || wallet == "" {
+		return nil
+	}
+
+	log := logger.FromContext(ctx)
+	result, err := s.screener.Screen(ctx, wallet)
+	if err != nil {
+		failOpen := s.cfg.Paywall.Screening.FailMode == config.ScreeningFailOpen
+		s.observeScreening(purpose, "error")
+		log.Error().
+			Err(err).
+			Str("wallet", logger.TruncateAddress(wallet)).
+			Str("purpose", purpose).
+			Bool("fail_open", failOpen).
+			Msg("screening.failed")
+		if failOpen {
+			return nil
+		}
+		return fmt.Errorf("%w: %v", ErrScreeningUnavailable, err)
+	}
+	if result.Flagged {
+		s.observeScreening(purpose, "flagged")
+		log.Warn().
+			Str("wallet", logger.TruncateAddress(wallet)).
+			Str("purpose", purpose).
+			Str("reason", result.Reason).
+			Msg("screening.wallet_flagged")
+		return ErrWalletFlagged
+	}
+	s.observeScreening(purpose, "clear")
+	return nil
+}
+
+func (s *Service) observeScreening(purpose, result string) {
+	if s.metrics != nil {
+		s.metrics.ObserveScreening(purpose, result)
+	}
+}
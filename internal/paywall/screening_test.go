@@ -0,0 +1,115 @@
+package paywall
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/CedrosPay/server/internal/callbacks"
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/screening"
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/pkg/x402"
+)
+
+type stubScreener struct {
+	flagged map[string]bool
+	err     error
+}
+
+func (s stubScreener) Screen(_ context.Context, wallet string) (screening.Result, error) {
+	if s.err != nil {
+		return screening.Result{}, s.err
+	}
+	if s.flagged[wallet] {
+		return screening.Result{Flagged: true, Reason: "sanctions"}, nil
+	}
+	return screening.Result{}, nil
+}
+
+func TestPaymentScreening(t *testing.T) {
+	tests := []struct {
+		name     string
+		screener stubScreener
+		failMode string
+		wantErr  error
+	}{
+		{name: "clear", screener: stubScreener{flagged: map[string]bool{"wallet-2": true}}},
+		{name: "flagged", screener: stubScreener{flagged: map[string]bool{"wallet-1": true}}, wantErr: ErrWalletFlagged},
+		{name: "fail closed", screener: stubScreener{err: errors.New("provider down")}, failMode: config.ScreeningFailClosed, wantErr: ErrScreeningUnavailable},
+		{name: "fail open", screener: stubScreener{err: errors.New("provider down")}, failMode: config.ScreeningFailOpen},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.Paywall.Screening.FailMode = tt.failMode
+			store := storage.NewMemoryStore()
+			t.Cleanup(func() { _ = store.Close() })
+			verifier := payerCheckingVerifier{stubVerifier{result: x402.VerificationResult{Wallet: "wallet-1"}}}
+			svc := NewService(cfg, store, verifier, callbacks.NoopNotifier{}, testRepository(cfg), nil, nil)
+			svc.SetScreener(tt.screener)
+
+			signature := computeSignature("demo-content", cfg.X402.PaymentAddress, "screening")
+			payload, _ := json.Marshal(x402.PaymentPayload{
+				Scheme:  "solana-spl-transfer",
+				Network: cfg.X402.Network,
+				Payload: x402.SolanaPayload{Signature: signature, Transaction: base64.StdEncoding.EncodeToString([]byte("signed-tx"))},
+			})
+			result, err := svc.Authorize(context.Background(), "demo-content", "", base64.StdEncoding.EncodeToString(payload), "")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Authorize error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || !result.Granted {
+				t.Fatalf("Authorize = %+v, %v, want granted", result, err)
+			}
+		})
+	}
+}
+
+func TestRefundScreening(t *testing.T) {
+	const recipient = "11111111111111111111111111111111"
+	tests := []struct {
+		name       string
+		screener   stubScreener
+		wantErr    error
+		wantStatus string
+	}{
+		{name: "clear", screener: stubScreener{}, wantStatus: storage.RefundStatusApproved},
+		{name: "flagged", screener: stubScreener{flagged: map[string]bool{recipient: true}}, wantErr: ErrWalletFlagged, wantStatus: storage.RefundStatusRequested},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cfg := testConfig()
+			store := storage.NewMemoryStore()
+			t.Cleanup(func() { _ = store.Close() })
+			svc := NewService(cfg, store, stubVerifier{}, callbacks.NoopNotifier{}, testRepository(cfg), nil, nil)
+			svc.SetScreener(tt.screener)
+
+			refund, err := svc.CreateRefundRequest(ctx, RefundQuoteRequest{
+				OriginalPurchaseID: "purchase_1",
+				RecipientWallet:    recipient,
+				Amount:             1,
+				Token:              "USDC",
+			})
+			if err != nil {
+				t.Fatalf("CreateRefundRequest: %v", err)
+			}
+			if _, err := svc.RegenerateRefundQuote(ctx, refund.ID); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RegenerateRefundQuote error = %v, want %v", err, tt.wantErr)
+			}
+			stored, err := store.GetRefundQuote(ctx, refund.ID)
+			if err != nil {
+				t.Fatalf("GetRefundQuote: %v", err)
+			}
+			if got := stored.LatestStatus(); got != tt.wantStatus {
+				t.Errorf("latest status = %q, want %q", got, tt.wantStatus)
+			}
+		})
+	}
+}
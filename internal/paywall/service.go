@@ -2,13 +2,17 @@ package paywall
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync/atomic"
 
 	"github.com/CedrosPay/server/internal/callbacks"
 	"github.com/CedrosPay/server/internal/config"
 	"github.com/CedrosPay/server/internal/coupons"
 	"github.com/CedrosPay/server/internal/metrics"
+	"github.com/CedrosPay/server/internal/paymentstatus"
 	"github.com/CedrosPay/server/internal/products"
+	"github.com/CedrosPay/server/internal/screening"
 	solanaKeypair "github.com/CedrosPay/server/internal/solana"
 	"github.com/CedrosPay/server/internal/storage"
 	"github.com/CedrosPay/server/internal/subscriptions"
@@ -21,6 +25,11 @@ type SubscriptionChecker interface {
 	HasAccess(ctx context.Context, wallet, productID string) (bool, *subscriptions.Subscription, error)
 }
 
+// SubscriptionChanger applies crypto subscription plan changes once their prorated charge is paid.
+type SubscriptionChanger interface {
+	ChangeSubscription(ctx context.Context, req subscriptions.ChangeSubscriptionRequest) (*subscriptions.ChangeSubscriptionResult, error)
+}
+
 // Service orchestrates paywall pricing, quotes, and authorization.
 type Service struct {
 	cfg           *config.Config
@@ -29,8 +38,18 @@ type Service struct {
 	notifier      callbacks.Notifier
 	repository    products.Repository
 	coupons       coupons.Repository
-	subscriptions SubscriptionChecker // Optional subscription access checker
-	metrics       *metrics.Metrics    // Prometheus metrics collector
+	subscriptions SubscriptionChecker    // Optional subscription access checker
+	planChanges   SubscriptionChanger    // Optional; applies paid subscription plan changes
+	rates         RateProvider           // Converts cart items into x402.cart_settlement_token
+	rateLocks     rateLocks              // Exchange rates locked for quotes of fiat-priced resources
+	shipping      LineCalculator         // Optional cart shipping line (paywall.shipping)
+	tax           LineCalculator         // Optional cart tax line (paywall.tax)
+	metrics       *metrics.Metrics       // Prometheus metrics collector
+	status        *paymentstatus.Tracker // Optional verification progress tracker (SSE)
+	velocity      velocityCounters       // Recent events counted by paywall.fraud rules
+	access        accessLists            // Cached wallet and IP allow and deny lists
+	screener      screening.Screener     // Optional compliance screening of payers and refund recipients
+	draining      atomic.Bool            // Set on shutdown; new quotes are refused
 }
 
 // NewService constructs a paywall service.
@@ -47,6 +66,9 @@ func NewService(cfg *config.Config, store storage.Store, verifier x402.Verifier,
 		repository: repository,
 		coupons:    couponRepo,
 		metrics:    metricsCollector,
+		rates:      StaticRates{To: cfg.X402.CartSettlementToken, Rates: cfg.X402.TokenRates},
+		shipping:   shippingFromConfig(cfg.Paywall.Shipping),
+		tax:        taxFromConfig(cfg.Paywall.Tax),
 	}
 }
 
@@ -56,12 +78,79 @@ func (s *Service) SetSubscriptionChecker(checker SubscriptionChecker) {
 	s.subscriptions = checker
 }
 
+// SetSubscriptionChanger sets where paid crypto subscription plan changes are applied.
+// This is optional - if not set, paying a plan change quote does not change the subscription.
+func (s *Service) SetSubscriptionChanger(changer SubscriptionChanger) {
+	s.planChanges = changer
+}
+
+// SetStatusTracker enables publishing of x402 verification progress.
+// This is optional - if not set, status updates are discarded.
+func (s *Service) SetStatusTracker(tracker *paymentstatus.Tracker) {
+	s.status = tracker
+}
+
+// StatusTracker returns the verification progress tracker (nil if disabled).
+func (s *Service) StatusTracker() *paymentstatus.Tracker {
+	return s.status
+}
+
+// BeginDrain stops issuing new quotes so in-flight payments can finish before shutdown.
+// Payments against quotes already issued are still verified.
+func (s *Service) BeginDrain() {
+	s.draining.Store(true)
+}
+
+// Draining reports whether BeginDrain has been called.
+func (s *Service) Draining() bool {
+	return s.draining.Load()
+}
+
+// publishStatus records a verification stage for a payment signature.
+func (s *Service) publishStatus(signature, resourceID string, stage paymentstatus.Stage, err error) {
+	update := paymentstatus.Update{
+		Signature:  signature,
+		Stage:      stage,
+		ResourceID: resourceID,
+	}
+	if vErr, ok := err.(x402.VerificationError); ok {
+		update.Error = vErr.Message // User-facing message, not the underlying RPC error
+	} else if err != nil {
+		update.Error = err.Error()
+	}
+	s.status.Publish(update)
+}
+
+// submittedStatus returns an x402.Requirement.OnSubmitted that publishes the submitted stage.
+// Gasless payments have no signature until the server co-signs and sends them, so their
+// received stage is published then too.
+func (s *Service) submittedStatus(resourceID string, gasless bool) func(signature string) {
+	return func(signature string) {
+		if gasless {
+			s.publishStatus(signature, resourceID, paymentstatus.StageReceived, nil)
+		}
+		s.publishStatus(signature, resourceID, paymentstatus.StageSubmitted, nil)
+	}
+}
+
 // getFeePayerPublicKey returns the server wallet public key for gasless transactions.
 // This is a lightweight operation (microseconds) and does not require caching.
+// Returns "" once every server wallet has spent its daily gasless budget, so quotes fall
+// back to the payer covering network fees.
 func (s *Service) getFeePayerPublicKey() string {
 	if !s.cfg.X402.GaslessEnabled || len(s.cfg.X402.ServerWalletKeys) == 0 {
 		return ""
 	}
+	if budget, ok := s.verifier.(interface{ GaslessAvailable() bool }); ok && !budget.GaslessAvailable() {
+		return ""
+	}
+
+	// The verifier resolved KMS-held keys' addresses at startup; they can't be parsed here
+	if wallets, ok := s.verifier.(interface{ FeePayerPublicKey() string }); ok {
+		if feePayer := wallets.FeePayerPublicKey(); feePayer != "" {
+			return feePayer
+		}
+	}
 
 	serverWalletKey, err := solanaKeypair.ParsePrivateKey(s.cfg.X402.ServerWalletKeys[0])
 	if err != nil {
@@ -123,15 +212,24 @@ func (s *Service) GetProduct(ctx context.Context, productID string) (products.Pr
 
 // HasPaymentBeenProcessed checks if a payment signature has been processed by this server.
 // This is used for refund request validation to ensure refunds can only be requested for actual payments.
+// Payments settled for other resource servers do not count.
 func (s *Service) HasPaymentBeenProcessed(ctx context.Context, signature string) (bool, error) {
-	return s.store.HasPaymentBeenProcessed(ctx, signature)
+	payment, err := s.store.GetPayment(ctx, signature)
+	if errors.Is(err, storage.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("paywall: get payment: %w", err)
+	}
+	return !IsFacilitatedPayment(payment), nil
 }
 
 // GetPayment retrieves payment transaction details by signature.
 // This is used for refund wallet validation to ensure refunds go to the original payer.
+// Payments settled for other resource servers are reported as not found.
 func (s *Service) GetPayment(ctx context.Context, signature string) (storage.PaymentTransaction, error) {
 	payment, err := s.store.GetPayment(ctx, signature)
-	if err == storage.ErrNotFound {
+	if err == storage.ErrNotFound || (err == nil && IsFacilitatedPayment(payment)) {
 		return storage.PaymentTransaction{}, fmt.Errorf("paywall: payment not found")
 	}
 	if err != nil {
@@ -152,14 +250,15 @@ func (s *Service) ConsumeNonce(ctx context.Context, nonceID string) error {
 
 // validateManualCoupon validates a manual coupon code with optional product and payment method filters.
 // Returns the coupon if valid and passes all applicable filters, nil otherwise.
-// Silently ignores invalid coupons (matches Stripe behavior).
+// Silently ignores invalid coupons (matches Stripe behavior). Gift cards are not discounts and are
+// redeemed separately (see redeemGiftCard).
 // Pass empty string for productID or empty PaymentMethod to skip that filter.
 func (s *Service) validateManualCoupon(ctx context.Context, couponCode string, productID string, paymentMethod coupons.PaymentMethod) *coupons.Coupon {
 	if couponCode == "" || s.coupons == nil {
 		return nil
 	}
 	coupon, err := s.coupons.GetCoupon(ctx, couponCode)
-	if err != nil || coupon.IsValid() != nil {
+	if err != nil || coupon.IsValid() != nil || coupon.DiscountType == coupons.DiscountTypeGiftCard {
 		return nil
 	}
 
@@ -6,11 +6,14 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"slices"
 	"testing"
 	"time"
 
 	"github.com/CedrosPay/server/internal/callbacks"
 	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/paymentstatus"
 	"github.com/CedrosPay/server/internal/products"
 	"github.com/CedrosPay/server/internal/storage"
 	"github.com/CedrosPay/server/pkg/x402"
@@ -39,6 +42,9 @@ func (s stubVerifier) Verify(_ context.Context, proof x402.PaymentProof, require
 	if res.ExpiresAt.IsZero() {
 		res.ExpiresAt = time.Now().Add(time.Hour)
 	}
+	if requirement.OnSubmitted != nil {
+		requirement.OnSubmitted(res.Signature)
+	}
 	return res, nil
 }
 
@@ -62,6 +68,20 @@ func TestGenerateQuoteIncludesStripeAndCrypto(t *testing.T) {
 	}
 }
 
+func TestDrainingRefusesNewQuotes(t *testing.T) {
+	cfg := testConfig()
+	svc := NewService(cfg, storage.NewMemoryStore(), stubVerifier{}, callbacks.NoopNotifier{}, testRepository(cfg), nil, nil)
+	svc.BeginDrain()
+
+	if _, err := svc.GenerateQuote(context.Background(), "demo-content", ""); !errors.Is(err, ErrDraining) {
+		t.Fatalf("GenerateQuote error = %v, want ErrDraining", err)
+	}
+	cart := CartQuoteRequest{Items: []CartQuoteItem{{ResourceID: "demo-content", Quantity: 1}}}
+	if _, err := svc.GenerateCartQuote(context.Background(), cart); !errors.Is(err, ErrDraining) {
+		t.Fatalf("GenerateCartQuote error = %v, want ErrDraining", err)
+	}
+}
+
 func TestAuthorizeRequiresPayment(t *testing.T) {
 	cfg := testConfig()
 	svc := NewService(cfg, storage.NewMemoryStore(), stubVerifier{}, callbacks.NoopNotifier{}, testRepository(cfg), nil, nil)
@@ -133,6 +153,102 @@ func TestAuthorizeWithMockPayment(t *testing.T) {
 	}
 }
 
+// requirementRecorder records the requirement each payment is verified against.
+type requirementRecorder struct {
+	stubVerifier
+	got *x402.Requirement
+}
+
+func (r requirementRecorder) Verify(ctx context.Context, proof x402.PaymentProof, requirement x402.Requirement) (x402.VerificationResult, error) {
+	*r.got = requirement
+	return r.stubVerifier.Verify(ctx, proof, requirement)
+}
+
+func TestAuthorizePublishesStatus(t *testing.T) {
+	tests := []struct {
+		name      string
+		signature string // Signature in the payment payload; empty for gasless
+		feePayer  string
+		result    string // Signature the verifier returns
+	}{
+		{name: "payer submitted", signature: "payer-sig", result: "payer-sig"},
+		{name: "gasless", feePayer: "server-wallet", result: "cosigned-sig"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			svc := NewService(cfg, storage.NewMemoryStore(), stubVerifier{
+				result: x402.VerificationResult{Wallet: "payer-wallet", Signature: tt.result},
+			}, callbacks.NoopNotifier{}, testRepository(cfg), nil, nil)
+			tracker := paymentstatus.NewTracker(time.Minute)
+			svc.SetStatusTracker(tracker)
+
+			payload, err := json.Marshal(x402.PaymentPayload{
+				Scheme:  "solana-spl-transfer",
+				Network: cfg.X402.Network,
+				Payload: x402.SolanaPayload{
+					Signature:   tt.signature,
+					Transaction: base64.StdEncoding.EncodeToString([]byte("signed-tx")),
+					FeePayer:    tt.feePayer,
+				},
+			})
+			if err != nil {
+				t.Fatalf("marshal payment payload: %v", err)
+			}
+			if _, err := svc.Authorize(context.Background(), "demo-content", "", base64.StdEncoding.EncodeToString(payload), ""); err != nil {
+				t.Fatalf("Authorize error: %v", err)
+			}
+
+			history, _, cancel := tracker.Subscribe(tt.result)
+			cancel()
+			var stages []paymentstatus.Stage
+			for _, update := range history {
+				stages = append(stages, update.Stage)
+			}
+			want := []paymentstatus.Stage{paymentstatus.StageReceived, paymentstatus.StageSubmitted, paymentstatus.StageConfirmed, paymentstatus.StageGranted}
+			if !slices.Equal(stages, want) {
+				t.Errorf("stages = %v, want %v", stages, want)
+			}
+		})
+	}
+}
+
+func TestStrictMemo(t *testing.T) {
+	for _, strict := range []bool{false, true} {
+		cfg := testConfig()
+		cfg.X402.MemoPrefix = "cedros"
+		cfg.X402.StrictMemo = strict
+		var got x402.Requirement
+		svc := NewService(cfg, storage.NewMemoryStore(), requirementRecorder{got: &got}, callbacks.NoopNotifier{}, testRepository(cfg), nil, nil)
+
+		quote, err := svc.GenerateQuote(context.Background(), "demo-content", "")
+		if err != nil {
+			t.Fatalf("GenerateQuote error: %v", err)
+		}
+		memo := quote.Crypto.Extra.(map[string]any)["memo"]
+
+		payload, _ := json.Marshal(x402.PaymentPayload{
+			Scheme:  "solana-spl-transfer",
+			Network: cfg.X402.Network,
+			Payload: x402.SolanaPayload{
+				Signature:   computeSignature("demo-content", cfg.X402.PaymentAddress, "strict"),
+				Transaction: base64.StdEncoding.EncodeToString([]byte("signed-tx")),
+			},
+		})
+		if _, err := svc.Authorize(context.Background(), "demo-content", "", base64.StdEncoding.EncodeToString(payload), ""); err != nil {
+			t.Fatalf("Authorize error: %v", err)
+		}
+
+		if strict && (memo != "cedros:demo-content" || got.Memo != "cedros:demo-content") {
+			t.Errorf("strict: quoted memo %v, required memo %q; want both cedros:demo-content", memo, got.Memo)
+		}
+		if !strict && got.Memo != "" {
+			t.Errorf("required memo %q without strict_memo, want none", got.Memo)
+		}
+	}
+}
+
 // TestAuthorizeWithStripeSession removed - Stripe session tracking has been removed
 // System now relies on webhook callbacks for access control
 
@@ -0,0 +1,122 @@
+package paywall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/CedrosPay/server/internal/callbacks"
+	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/money"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+// MaxSettlementBatch is the most settlements IngestSettlements accepts in one call.
+const MaxSettlementBatch = 1000
+
+// ErrInvalidSettlement indicates a settlement in an ingestion batch failed validation.
+var ErrInvalidSettlement = errors.New("paywall: invalid settlement")
+
+// ErrSettlementRecorded indicates a settlement's signature is already recorded.
+var ErrSettlementRecorded = errors.New("paywall: settlement already recorded")
+
+// Settlement is a payment verified outside CedrosPay, such as a historical sale found by an indexer.
+type Settlement struct {
+	Signature  string
+	ResourceID string
+	Wallet     string
+	Amount     money.Money
+	PaidAt     time.Time // Defaults to now
+	Metadata   map[string]string
+}
+
+// IngestSettlements records externally verified payments so their wallets get access to the
+// resources they paid for. The batch is stored in full or not at all: it fails without
+// recording anything if any settlement is invalid, for an unknown resource, or already recorded.
+// With notify set, a payment.succeeded callback is sent for each settlement once stored.
+func (s *Service) IngestSettlements(ctx context.Context, settlements []Settlement, notify bool) ([]storage.PaymentTransaction, error) {
+	if len(settlements) == 0 {
+		return nil, fmt.Errorf("%w: no settlements", ErrInvalidSettlement)
+	}
+	if len(settlements) > MaxSettlementBatch {
+		return nil, fmt.Errorf("%w: %d settlements exceeds the batch limit of %d", ErrInvalidSettlement, len(settlements), MaxSettlementBatch)
+	}
+
+	now := time.Now()
+	seen := make(map[string]bool, len(settlements))
+	txs := make([]storage.PaymentTransaction, 0, len(settlements))
+	for i, settlement := range settlements {
+		switch {
+		case settlement.Signature == "":
+			return nil, fmt.Errorf("%w: settlement %d: signature is required", ErrInvalidSettlement, i)
+		case seen[settlement.Signature]:
+			return nil, fmt.Errorf("%w: settlement %d: duplicate signature %s", ErrInvalidSettlement, i, settlement.Signature)
+		case settlement.Wallet == "":
+			return nil, fmt.Errorf("%w: settlement %d: wallet is required", ErrInvalidSettlement, i)
+		case !settlement.Amount.IsPositive():
+			return nil, fmt.Errorf("%w: settlement %d: amount must be positive", ErrInvalidSettlement, i)
+		}
+		seen[settlement.Signature] = true
+
+		if _, err := s.ResourceDefinition(ctx, settlement.ResourceID); err != nil {
+			if errors.Is(err, ErrResourceNotConfigured) {
+				return nil, fmt.Errorf("%w: settlement %d: unknown resource %q", ErrInvalidSettlement, i, settlement.ResourceID)
+			}
+			return nil, err
+		}
+		processed, err := s.store.HasPaymentBeenProcessed(ctx, settlement.Signature)
+		if err != nil {
+			return nil, fmt.Errorf("check settlement %d: %w", i, err)
+		}
+		if processed {
+			return nil, fmt.Errorf("%w: settlement %d: signature %s", ErrSettlementRecorded, i, settlement.Signature)
+		}
+
+		metadata := make(map[string]string, len(settlement.Metadata)+2)
+		for k, v := range settlement.Metadata {
+			metadata[k] = v
+		}
+		metadata["status"] = "verified"
+		metadata["source"] = "ingested"
+
+		paidAt := settlement.PaidAt
+		if paidAt.IsZero() {
+			paidAt = now
+		}
+		txs = append(txs, storage.PaymentTransaction{
+			Signature:  settlement.Signature,
+			ResourceID: settlement.ResourceID,
+			Wallet:     settlement.Wallet,
+			Amount:     settlement.Amount,
+			CreatedAt:  paidAt,
+			Metadata:   metadata,
+		})
+	}
+
+	if err := s.store.RecordPayments(ctx, txs); err != nil {
+		return nil, fmt.Errorf("record settlements: %w", err)
+	}
+
+	log := logger.FromContext(ctx)
+	log.Info().
+		Int("count", len(txs)).
+		Bool("notify", notify).
+		Msg("settlements.ingested")
+
+	if notify {
+		for _, tx := range txs {
+			s.notifier.PaymentSucceeded(ctx, callbacks.PaymentEvent{
+				ResourceID:         tx.ResourceID,
+				Method:             "x402",
+				CryptoAtomicAmount: tx.Amount.Atomic,
+				CryptoToken:        tx.Amount.Asset.Code,
+				Wallet:             tx.Wallet,
+				ProofSignature:     tx.Signature,
+				Metadata:           tx.Metadata,
+				PaidAt:             tx.CreatedAt.UTC(),
+			})
+		}
+	}
+	return txs, nil
+}
@@ -0,0 +1,98 @@
+package paywall
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+
+	solanaHelpers "github.com/CedrosPay/server/internal/solana"
+	x402solana "github.com/CedrosPay/server/pkg/x402/solana"
+)
+
+// squadsVerifier is implemented by verifiers that can read and propose to Squads multisigs.
+type squadsVerifier interface {
+	FetchSquadsMultisig(ctx context.Context, multisig solana.PublicKey) (solanaHelpers.SquadsMultisig, error)
+	BuildSquadsProposal(ctx context.Context, req x402solana.SquadsProposalRequest) (x402solana.SquadsProposalResponse, error)
+}
+
+// squads returns the configured multisig and a verifier able to talk to it.
+func (s *Service) squads() (solana.PublicKey, squadsVerifier, error) {
+	multisig, err := solana.PublicKeyFromBase58(s.cfg.X402.SquadsMultisig)
+	if err != nil {
+		return solana.PublicKey{}, nil, fmt.Errorf("paywall: invalid squads multisig: %w", err)
+	}
+	verifier, ok := s.verifier.(squadsVerifier)
+	if !ok {
+		return solana.PublicKey{}, nil, fmt.Errorf("paywall: verifier does not support squads multisigs")
+	}
+	return multisig, verifier, nil
+}
+
+// RefundAdmins returns the wallets allowed to approve, deny, and list refunds: the payment
+// address itself, or every member of its Squads multisig. Members are read from chain on
+// each call so membership changes apply immediately.
+func (s *Service) RefundAdmins(ctx context.Context) ([]string, error) {
+	if s.cfg.X402.SquadsMultisig == "" {
+		return []string{s.cfg.X402.PaymentAddress}, nil
+	}
+	multisigKey, verifier, err := s.squads()
+	if err != nil {
+		return nil, err
+	}
+	multisig, err := verifier.FetchSquadsMultisig(ctx, multisigKey)
+	if err != nil {
+		return nil, fmt.Errorf("paywall: %w", err)
+	}
+	admins := make([]string, 0, len(multisig.Members))
+	for _, member := range multisig.Members {
+		admins = append(admins, member.Key.String())
+	}
+	return admins, nil
+}
+
+// BuildRefundProposal builds the Squads proposal that pays out a refund from the multisig
+// vault. member signs and submits it as the refund's x402 payment; the transfer itself runs
+// once the multisig threshold approves and a member executes it.
+func (s *Service) BuildRefundProposal(ctx context.Context, refundID, member string) (*x402solana.SquadsProposalResponse, error) {
+	multisig, verifier, err := s.squads()
+	if err != nil {
+		return nil, err
+	}
+	memberKey, err := solana.PublicKeyFromBase58(member)
+	if err != nil {
+		return nil, fmt.Errorf("paywall: invalid member address: %w", err)
+	}
+
+	refund, err := s.store.GetRefundQuote(ctx, refundID)
+	if err != nil {
+		return nil, fmt.Errorf("paywall: get refund: %w", err)
+	}
+	mint, err := solana.PublicKeyFromBase58(refund.Amount.Asset.Metadata.SolanaMint)
+	if err != nil {
+		return nil, fmt.Errorf("paywall: invalid token mint for %s: %w", refund.Amount.Asset.Code, err)
+	}
+	recipient, err := solana.PublicKeyFromBase58(refund.RecipientWallet)
+	if err != nil {
+		return nil, fmt.Errorf("paywall: invalid recipient wallet: %w", err)
+	}
+	recipientTokenAccount, _, err := solana.FindAssociatedTokenAddress(recipient, mint)
+	if err != nil {
+		return nil, fmt.Errorf("paywall: derive recipient token account: %w", err)
+	}
+
+	proposal, err := verifier.BuildSquadsProposal(ctx, x402solana.SquadsProposalRequest{
+		Multisig:              multisig,
+		VaultIndex:            uint8(s.cfg.X402.SquadsVaultIndex),
+		Member:                memberKey,
+		RecipientTokenAccount: recipientTokenAccount,
+		TokenMint:             mint,
+		Amount:                uint64(refund.Amount.Atomic),
+		Decimals:              refund.Amount.Asset.Decimals,
+		Memo:                  fmt.Sprintf("refund:%s", refundID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("paywall: build squads proposal: %w", err)
+	}
+	return &proposal, nil
+}
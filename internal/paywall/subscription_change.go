@@ -0,0 +1,173 @@
+package paywall
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/money"
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/internal/subscriptions"
+)
+
+// subscriptionChangeCartType marks carts that pay for a crypto subscription plan change.
+const subscriptionChangeCartType = "subscription_change"
+
+// SubscriptionChangeQuote is the prorated cost of moving a crypto subscription to another plan.
+// CartID and Quote are only set when an amount is due; a cheaper plan can be switched to directly.
+type SubscriptionChangeQuote struct {
+	SubscriptionID   string
+	PreviousResource string
+	NewResource      string
+	Quantity         int
+	Proration        subscriptions.PlanChangeProration
+	CartID           string
+	Quote            *CryptoQuote
+	ExpiresAt        time.Time
+}
+
+// AmountDue reports whether the plan change must be paid for before it is applied.
+func (q SubscriptionChangeQuote) AmountDue() bool {
+	return q.CartID != ""
+}
+
+// QuoteSubscriptionChange prices a mid-period move of a crypto subscription to newResourceID
+// (and optionally a new seat count), crediting the unused time on its current plan. When an
+// amount is due it is saved as a cart quote; paying that cart applies the change.
+func (s *Service) QuoteSubscriptionChange(ctx context.Context, sub subscriptions.Subscription, newResourceID string, quantity int) (SubscriptionChangeQuote, error) {
+	if s.Draining() {
+		return SubscriptionChangeQuote{}, ErrDraining
+	}
+
+	current, err := s.subscriptionPlanPrice(ctx, sub.ProductID)
+	if err != nil {
+		return SubscriptionChangeQuote{}, fmt.Errorf("price current plan: %w", err)
+	}
+	next, err := s.subscriptionPlanPrice(ctx, newResourceID)
+	if err != nil {
+		return SubscriptionChangeQuote{}, fmt.Errorf("price new plan: %w", err)
+	}
+
+	now := time.Now()
+	proration, err := subscriptions.ProratePlanChange(sub, current, next, quantity, now)
+	if err != nil {
+		return SubscriptionChangeQuote{}, err
+	}
+
+	result := SubscriptionChangeQuote{
+		SubscriptionID:   sub.ID,
+		PreviousResource: sub.ProductID,
+		NewResource:      newResourceID,
+		Quantity:         quantity,
+		Proration:        proration,
+	}
+	if !proration.AmountDue.IsPositive() {
+		return result, nil
+	}
+
+	cartID, err := storage.GenerateCartID()
+	if err != nil {
+		return SubscriptionChangeQuote{}, err
+	}
+	cartTTL := s.cfg.Storage.CartQuoteTTL.Duration
+	if cartTTL == 0 {
+		cartTTL = 15 * time.Minute // Fallback default
+	}
+	result.CartID = cartID
+	result.ExpiresAt = now.Add(cartTTL)
+
+	cart := storage.CartQuote{
+		ID: cartID,
+		Items: []storage.CartItem{{
+			ResourceID: newResourceID,
+			Quantity:   1,
+			Price:      proration.AmountDue,
+		}},
+		Total:     proration.AmountDue,
+		Metadata:  subscriptionChangeMetadata(result),
+		CreatedAt: now,
+		ExpiresAt: result.ExpiresAt,
+	}
+	if err := s.store.SaveCartQuote(ctx, cart); err != nil {
+		return SubscriptionChangeQuote{}, fmt.Errorf("paywall: save subscription change quote: %w", err)
+	}
+
+	if result.Quote, err = s.buildCartX402Quote(cartID, uint64(proration.AmountDue.Atomic), proration.AmountDue.Asset.Code, result.ExpiresAt); err != nil {
+		return SubscriptionChangeQuote{}, fmt.Errorf("paywall: build x402 quote: %w", err)
+	}
+	return result, nil
+}
+
+// subscriptionPlanPrice returns a subscription product's per-seat crypto price, converting
+// fiat-priced products into their token.
+func (s *Service) subscriptionPlanPrice(ctx context.Context, resourceID string) (money.Money, error) {
+	resource, err := s.ResourceDefinition(ctx, resourceID)
+	if err != nil {
+		return money.Money{}, err
+	}
+	if resource, err = s.QuotedResource(ctx, resource); err != nil {
+		return money.Money{}, err
+	}
+	if resource.CryptoAtomicAmount <= 0 {
+		return money.Money{}, ErrNoCryptoPrice
+	}
+	asset, err := money.GetAsset(resource.CryptoToken)
+	if err != nil {
+		return money.Money{}, fmt.Errorf("get crypto asset: %w", err)
+	}
+	return money.New(asset, resource.CryptoAtomicAmount), nil
+}
+
+// subscriptionChangeMetadata records a plan change on its cart, for applying it once paid and
+// for the payment callback.
+func subscriptionChangeMetadata(q SubscriptionChangeQuote) map[string]string {
+	metadata := map[string]string{
+		"type":              subscriptionChangeCartType,
+		"subscription_id":   q.SubscriptionID,
+		"previous_resource": q.PreviousResource,
+		"new_resource":      q.NewResource,
+		"proration_credit":  q.Proration.Credit.ToMajor(),
+		"proration_charge":  q.Proration.Charge.ToMajor(),
+	}
+	if q.Quantity > 0 {
+		metadata["quantity"] = strconv.Itoa(q.Quantity)
+	}
+	return metadata
+}
+
+// applySubscriptionChange moves the subscription a paid plan change cart was for to its new
+// plan. Failures are logged: the payment already succeeded.
+func (s *Service) applySubscriptionChange(ctx context.Context, cart storage.CartQuote) {
+	if cart.Metadata["type"] != subscriptionChangeCartType || s.planChanges == nil {
+		return
+	}
+	log := logger.FromContext(ctx)
+
+	req := subscriptions.ChangeSubscriptionRequest{
+		SubscriptionID: cart.Metadata["subscription_id"],
+		NewProductID:   cart.Metadata["new_resource"],
+		Metadata: map[string]string{
+			"proration_cart_id": cart.ID,
+			"proration_credit":  cart.Metadata["proration_credit"],
+			"proration_charge":  cart.Metadata["proration_charge"],
+			"proration_paid":    cart.Total.ToMajor(),
+		},
+	}
+	if quantity, err := strconv.Atoi(cart.Metadata["quantity"]); err == nil {
+		req.NewQuantity = quantity
+	}
+	if product, err := s.repository.GetProduct(ctx, req.NewProductID); err == nil && product.IsSubscription() {
+		req.NewBillingPeriod = subscriptions.BillingPeriod(product.Subscription.BillingPeriod)
+		req.NewBillingInterval = product.Subscription.BillingInterval
+	}
+
+	if _, err := s.planChanges.ChangeSubscription(ctx, req); err != nil {
+		log.Error().
+			Err(err).
+			Str("cart_hash", hashResourceID(cart.ID)).
+			Str("subscription_id", req.SubscriptionID).
+			Msg("cart.subscription_change_failed")
+	}
+}
@@ -0,0 +1,92 @@
+package paywall
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/CedrosPay/server/internal/callbacks"
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/internal/subscriptions"
+)
+
+func TestQuoteSubscriptionChange(t *testing.T) {
+	tests := []struct {
+		name        string
+		newResource string
+		wantDue     int64
+	}{
+		{name: "upgrade is paid through a cart", newResource: "pro-plan", wantDue: 5_000000},
+		{name: "downgrade applies without payment", newResource: "lite-plan"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cfg := testConfig()
+			monthly := &config.SubscriptionResourceConfig{BillingPeriod: "month", BillingInterval: 1, AllowX402: true}
+			cfg.Paywall.Resources["lite-plan"] = config.PaywallResource{ResourceID: "lite-plan", CryptoAtomicAmount: 5_000000, CryptoToken: "USDC", Subscription: monthly}
+			cfg.Paywall.Resources["basic-plan"] = config.PaywallResource{ResourceID: "basic-plan", CryptoAtomicAmount: 10_000000, CryptoToken: "USDC", Subscription: monthly}
+			cfg.Paywall.Resources["pro-plan"] = config.PaywallResource{ResourceID: "pro-plan", CryptoAtomicAmount: 20_000000, CryptoToken: "USDC", Subscription: monthly}
+			store := storage.NewMemoryStore()
+			t.Cleanup(func() { _ = store.Close() })
+			svc := NewService(cfg, store, stubVerifier{}, callbacks.NoopNotifier{}, testRepository(cfg), nil, nil)
+
+			repo := subscriptions.NewMemoryRepository()
+			subs := subscriptions.NewService(repo, 0)
+			svc.SetSubscriptionChanger(subs)
+
+			now := time.Now()
+			sub := subscriptions.Subscription{
+				ID:                 "sub_1",
+				ProductID:          "basic-plan",
+				Wallet:             "wallet-1",
+				PaymentMethod:      subscriptions.PaymentMethodX402,
+				Status:             subscriptions.StatusActive,
+				CurrentPeriodStart: now.Add(-15 * 24 * time.Hour),
+				CurrentPeriodEnd:   now.Add(15 * 24 * time.Hour),
+			}
+			if err := repo.Create(ctx, sub); err != nil {
+				t.Fatalf("create subscription: %v", err)
+			}
+
+			quote, err := svc.QuoteSubscriptionChange(ctx, sub, tt.newResource, 0)
+			if err != nil {
+				t.Fatalf("QuoteSubscriptionChange: %v", err)
+			}
+			if quote.Proration.AmountDue.Atomic != tt.wantDue {
+				t.Fatalf("amount due = %d, want %d", quote.Proration.AmountDue.Atomic, tt.wantDue)
+			}
+			if quote.AmountDue() != (tt.wantDue > 0) {
+				t.Fatalf("AmountDue() = %v with cart %q", quote.AmountDue(), quote.CartID)
+			}
+			if !quote.AmountDue() {
+				return
+			}
+			if quote.Quote == nil {
+				t.Fatal("expected an x402 quote for the amount due")
+			}
+			cart, err := store.GetCartQuote(ctx, quote.CartID)
+			if err != nil {
+				t.Fatalf("GetCartQuote: %v", err)
+			}
+			if cart.Total.Atomic != tt.wantDue || cart.Metadata["subscription_id"] != sub.ID {
+				t.Fatalf("cart = %+v", cart)
+			}
+
+			if _, err := svc.Authorize(ctx, quote.CartID, "", cartPaymentHeader(t, cfg, "sig-"+tt.newResource), ""); err != nil {
+				t.Fatalf("Authorize: %v", err)
+			}
+			changed, err := repo.Get(ctx, sub.ID)
+			if err != nil {
+				t.Fatalf("get subscription: %v", err)
+			}
+			if changed.ProductID != tt.newResource {
+				t.Errorf("product = %s, want %s", changed.ProductID, tt.newResource)
+			}
+			if changed.Metadata["proration_cart_id"] != quote.CartID {
+				t.Errorf("proration_cart_id = %q, want %q", changed.Metadata["proration_cart_id"], quote.CartID)
+			}
+		})
+	}
+}
@@ -3,14 +3,26 @@ package paywall
 import (
 	"errors"
 	"time"
+
+	"github.com/CedrosPay/server/internal/money"
 )
 
 // ErrResourceNotConfigured indicates the requested resource lacks pricing metadata.
 var ErrResourceNotConfigured = errors.New("paywall: resource not configured")
 
+// ErrDraining indicates the server is shutting down and no longer issues new quotes.
+var ErrDraining = errors.New("paywall: server is shutting down")
+
 // ErrStripeSessionPending indicates a Stripe session is still awaiting webhook confirmation.
 var ErrStripeSessionPending = errors.New("paywall: stripe session pending")
 
+// ErrNoCryptoPrice indicates the resource can only be paid with Stripe.
+var ErrNoCryptoPrice = errors.New("paywall: resource has no crypto price")
+
+// ErrNoFiatAmount indicates the resource has no fiat amount to charge outside hosted Checkout,
+// such as one priced only by a Stripe price ID.
+var ErrNoFiatAmount = errors.New("paywall: resource has no fiat amount")
+
 // AuthorizationResult captures the outcome of an access attempt.
 type AuthorizationResult struct {
 	Granted      bool
@@ -18,7 +30,21 @@ type AuthorizationResult struct {
 	Wallet       string
 	Quote        *Quote
 	Settlement   *SettlementResponse
+	Amount       money.Money       // Paid for x402 access, after coupons
 	Subscription *SubscriptionInfo // Present when access granted via subscription
+	Partial      *PartialPayment   // Present when a split cart payment left part of the total unpaid
+}
+
+// PartialPayment reports how much of a split cart's total has been paid.
+type PartialPayment struct {
+	PaidAmount      money.LegacyFloat `json:"paidAmount,omitzero"`
+	RemainingAmount money.LegacyFloat `json:"remainingAmount,omitzero"`
+	TotalAmount     money.LegacyFloat `json:"totalAmount,omitzero"`
+	Token           string            `json:"token"`
+	// Exact decimal strings of the amounts above
+	PaidAmountDecimal      string `json:"paidAmountDecimal"`
+	RemainingAmountDecimal string `json:"remainingAmountDecimal"`
+	TotalAmountDecimal     string `json:"totalAmountDecimal"`
 }
 
 // SubscriptionInfo contains subscription details when access is granted via subscription.
@@ -70,4 +96,9 @@ type SettlementResponse struct {
 	Error     *string `json:"error"`
 	TxHash    *string `json:"txHash"`
 	NetworkID *string `json:"networkId"`
+
+	// The same settlement in the published x402 v1 fields
+	Transaction string `json:"transaction,omitempty"`
+	Network     string `json:"network,omitempty"`
+	Payer       string `json:"payer,omitempty"`
 }
@@ -0,0 +1,51 @@
+package paywall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/CedrosPay/server/internal/config"
+)
+
+// ErrUnknownVariant indicates a cart item names a variant its resource does not define.
+var ErrUnknownVariant = errors.New("paywall: unknown variant")
+
+// VariantDefinition resolves the pricing config for one variant of a resource. An empty
+// variantID returns the resource itself.
+func (s *Service) VariantDefinition(ctx context.Context, resourceID, variantID string) (config.PaywallResource, error) {
+	resource, err := s.ResourceDefinition(ctx, resourceID)
+	if err != nil {
+		return config.PaywallResource{}, err
+	}
+	return applyVariant(resource, variantID)
+}
+
+// applyVariant returns resource with the variant's description, prices, and Stripe price in
+// place of its own and the variant's metadata merged over its metadata.
+func applyVariant(resource config.PaywallResource, variantID string) (config.PaywallResource, error) {
+	if variantID == "" {
+		return resource, nil
+	}
+	variant, ok := resource.Variants[variantID]
+	if !ok {
+		return config.PaywallResource{}, fmt.Errorf("%w: %s has no variant %q", ErrUnknownVariant, resource.ResourceID, variantID)
+	}
+
+	if variant.Description != "" {
+		resource.Description = variant.Description
+	}
+	if variant.StripePriceID != "" {
+		resource.StripePriceID = variant.StripePriceID
+	}
+	if variant.FiatAmountCents > 0 {
+		resource.FiatAmountCents = variant.FiatAmountCents
+	}
+	if variant.CryptoAtomicAmount > 0 {
+		resource.CryptoAtomicAmount = variant.CryptoAtomicAmount
+		resource.PriceTiers = nil // Tiers break the resource's own price, not the variant's
+	}
+	resource.Metadata = mergeMetadata(resource.Metadata, variant.Metadata)
+	resource.Variants = nil
+	return resource, nil
+}
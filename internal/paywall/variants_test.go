@@ -0,0 +1,89 @@
+package paywall
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func TestCartVariants(t *testing.T) {
+	ctx := context.Background()
+	cfg := testConfig()
+	resource := cfg.Paywall.Resources["demo-content"]
+	resource.Metadata = map[string]string{"kind": "print"}
+	resource.Variants = map[string]config.ResourceVariant{
+		"large":  {Description: "demo, large", CryptoAtomicAmount: 2000000, Metadata: map[string]string{"size": "L"}},
+		"signed": {Metadata: map[string]string{"signed": "true"}}, // Resource's price
+	}
+	cfg.Paywall.Resources["demo-content"] = resource
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	notifier := &recordingNotifier{}
+	svc := NewService(cfg, store, stubVerifier{}, notifier, testRepository(cfg), nil, nil)
+
+	if _, err := svc.GenerateCartQuote(ctx, CartQuoteRequest{Items: []CartQuoteItem{{ResourceID: "demo-content", VariantID: "huge"}}}); !errors.Is(err, ErrUnknownVariant) {
+		t.Fatalf("unknown variant: error = %v, want ErrUnknownVariant", err)
+	}
+
+	quote, err := svc.GenerateCartQuote(ctx, CartQuoteRequest{Items: []CartQuoteItem{
+		{ResourceID: "demo-content", VariantID: "large", Quantity: 2, Metadata: map[string]string{"gift": "yes"}},
+		{ResourceID: "demo-content", VariantID: "signed"},
+		{ResourceID: "demo-content"},
+	}})
+	if err != nil {
+		t.Fatalf("GenerateCartQuote error: %v", err)
+	}
+	if quote.TotalAmount != 6 {
+		t.Errorf("total = %v, want 6 (2 large at 2.00, signed and plain at 1.00)", quote.TotalAmount)
+	}
+	wantItems := []struct {
+		variant     string
+		price       float64
+		description string
+	}{
+		{"large", 2, "demo, large"},
+		{"signed", 1, "demo"},
+		{"", 1, "demo"},
+	}
+	for i, want := range wantItems {
+		item := quote.Items[i]
+		if item.VariantID != want.variant || float64(item.PriceAmount) != want.price || item.Description != want.description {
+			t.Errorf("item %d = %+v, want variant %q at %v (%s)", i, item, want.variant, want.price, want.description)
+		}
+	}
+
+	// Variants are separate lines when the cart changes
+	updated, err := svc.UpdateCartQuote(ctx, quote.CartID, CartUpdateRequest{Items: []CartItemChange{{ResourceID: "demo-content", VariantID: "large", Quantity: 1}}})
+	if err != nil {
+		t.Fatalf("UpdateCartQuote error: %v", err)
+	}
+	if len(updated.Items) != 3 || updated.Items[0].Quantity != 1 || updated.TotalAmount != 4 {
+		t.Errorf("updated cart = %+v, want 3 lines totalling 4", updated)
+	}
+
+	if _, err := svc.Authorize(ctx, quote.CartID, "", cartPaymentHeader(t, cfg, "sig-variants"), ""); err != nil {
+		t.Fatalf("Authorize error: %v", err)
+	}
+	if len(notifier.payments) != 1 {
+		t.Fatalf("payment callbacks = %d, want 1", len(notifier.payments))
+	}
+	metadata := notifier.payments[0].Metadata
+	want := map[string]string{
+		"item_0_variant": "large",
+		"item_0_size":    "L",
+		"item_0_gift":    "yes",
+		"item_1_variant": "signed",
+		"item_1_signed":  "true",
+	}
+	for k, v := range want {
+		if metadata[k] != v {
+			t.Errorf("callback metadata[%s] = %q, want %q", k, metadata[k], v)
+		}
+	}
+	if _, ok := metadata["item_2_variant"]; ok {
+		t.Error("callback metadata has a variant for the plain item")
+	}
+}
@@ -0,0 +1,53 @@
+package paywall
+
+import "github.com/CedrosPay/server/pkg/x402"
+
+// X402Version returns the x402 version payment-required responses declare: 1 when
+// x402.spec_version selects the published v1 format, else 0 for Cedros payloads.
+func (s *Service) X402Version() int {
+	if s.cfg.X402.SpecVersion == x402.Version1 {
+		return x402.Version1
+	}
+	return 0
+}
+
+// quoteScheme and quoteNetwork are the scheme and network quotes advertise. In v1 mode they
+// are the published names ("exact" on "solana" or "solana-devnet"), so third-party x402 clients
+// and facilitators recognize the requirements; the Solana extras (recipientTokenAccount, memo,
+// feePayer) are the same in both modes.
+func (s *Service) quoteScheme() string {
+	if s.X402Version() == x402.Version1 {
+		return x402.SchemeExact
+	}
+	return x402.SchemeSolanaSPLTransfer
+}
+
+func (s *Service) quoteNetwork() string {
+	if s.X402Version() == x402.Version1 {
+		return x402.V1Network(s.cfg.X402.Network)
+	}
+	return s.cfg.X402.Network
+}
+
+// PaymentRequired builds an x402 Payment Required body listing quote in accepts.
+func (s *Service) PaymentRequired(quote *CryptoQuote) map[string]any {
+	response := map[string]any{"x402Version": s.X402Version()}
+	if quote != nil {
+		response["accepts"] = []*CryptoQuote{quote}
+	}
+	return response
+}
+
+// settlement builds the settlement of a verified payment for the X-PAYMENT-RESPONSE header,
+// in both the Cedros and the published v1 fields.
+func (s *Service) settlement(signature, payer string) *SettlementResponse {
+	networkID := s.cfg.X402.Network
+	return &SettlementResponse{
+		Success:     true,
+		TxHash:      &signature,
+		NetworkID:   &networkID,
+		Transaction: signature,
+		Network:     x402.V1Network(networkID),
+		Payer:       payer,
+	}
+}
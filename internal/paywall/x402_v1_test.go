@@ -0,0 +1,85 @@
+package paywall
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/CedrosPay/server/internal/callbacks"
+	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/pkg/x402"
+)
+
+func TestX402SpecVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		specVersion int
+		wantVersion int
+		wantScheme  string
+		wantNetwork string
+	}{
+		{name: "cedros payloads", specVersion: 0, wantVersion: 0, wantScheme: x402.SchemeSolanaSPLTransfer, wantNetwork: "mainnet-beta"},
+		{name: "published v1", specVersion: 1, wantVersion: 1, wantScheme: x402.SchemeExact, wantNetwork: "solana"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.X402.SpecVersion = tt.specVersion
+			svc := NewService(cfg, storage.NewMemoryStore(), stubVerifier{}, callbacks.NoopNotifier{}, testRepository(cfg), nil, nil)
+
+			result, err := svc.Authorize(context.Background(), "demo-content", "", "", "")
+			if err != nil {
+				t.Fatalf("Authorize error: %v", err)
+			}
+			body := svc.PaymentRequired(result.Quote.Crypto)
+			if body["x402Version"] != tt.wantVersion {
+				t.Errorf("x402Version = %v, want %d", body["x402Version"], tt.wantVersion)
+			}
+			accepts, ok := body["accepts"].([]*CryptoQuote)
+			if !ok || len(accepts) != 1 {
+				t.Fatalf("accepts = %#v, want one requirement", body["accepts"])
+			}
+			if accepts[0].Scheme != tt.wantScheme || accepts[0].Network != tt.wantNetwork {
+				t.Errorf("requirement = %s on %s, want %s on %s", accepts[0].Scheme, accepts[0].Network, tt.wantScheme, tt.wantNetwork)
+			}
+		})
+	}
+}
+
+func TestAuthorizeV1PaymentHeader(t *testing.T) {
+	cfg := testConfig()
+	cfg.X402.SpecVersion = 1
+	svc := NewService(cfg, storage.NewMemoryStore(), stubVerifier{
+		result: x402.VerificationResult{
+			Wallet:    "payer-wallet",
+			Signature: "v1-signature",
+			Amount:    1.0,
+			ExpiresAt: time.Now().Add(time.Hour),
+		},
+	}, callbacks.NoopNotifier{}, testRepository(cfg), nil, nil)
+
+	// A published v1 payload: scheme "exact", v1 network name, only the transaction
+	payload, err := json.Marshal(map[string]any{
+		"x402Version": 1,
+		"scheme":      "exact",
+		"network":     "solana",
+		"payload":     map[string]string{"transaction": base64.StdEncoding.EncodeToString([]byte("signed-tx"))},
+	})
+	if err != nil {
+		t.Fatalf("marshal payment payload: %v", err)
+	}
+
+	result, err := svc.Authorize(context.Background(), "demo-content", "", base64.StdEncoding.EncodeToString(payload), "")
+	if err != nil {
+		t.Fatalf("Authorize error: %v", err)
+	}
+	if !result.Granted || result.Settlement == nil {
+		t.Fatalf("result = %+v, want access granted with a settlement", result)
+	}
+	got := result.Settlement
+	if !got.Success || got.Transaction != "v1-signature" || got.Network != "solana" || got.Payer != "payer-wallet" {
+		t.Errorf("settlement = %+v, want v1 transaction, network, and payer", got)
+	}
+}
@@ -154,7 +154,7 @@ func (r *MongoDBRepository) CreateProduct(ctx context.Context, p Product) error
 	if err != nil {
 		// Check if this is a duplicate key error
 		if mongo.IsDuplicateKeyError(err) {
-			return fmt.Errorf("product already exists: %s", p.ID)
+			return fmt.Errorf("%w: %s", ErrProductExists, p.ID)
 		}
 		return fmt.Errorf("insert product: %w", err)
 	}
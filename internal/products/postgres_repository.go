@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"regexp"
 	"time"
@@ -32,6 +33,9 @@ const (
 // Input validation constraints
 const maxIDLength = 255
 
+// uniqueViolation is the PostgreSQL error code for a duplicate primary key.
+const uniqueViolation = "23505"
+
 var validTableNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
 
 // validateProductID validates product ID input
@@ -426,6 +430,10 @@ func (r *PostgresRepository) CreateProduct(ctx context.Context, p Product) error
 	)
 
 	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return fmt.Errorf("%w: %s", ErrProductExists, p.ID)
+		}
 		return fmt.Errorf("insert product: %w", err)
 	}
 
@@ -13,6 +13,13 @@ import (
 // ErrProductNotFound is returned when a product doesn't exist.
 var ErrProductNotFound = errors.New("product not found")
 
+// ErrProductExists is returned when creating a product whose ID is already taken
+// (including by an archived product).
+var ErrProductExists = errors.New("product already exists")
+
+// ErrReadOnly is returned by repositories that cannot be written to (YAML config).
+var ErrReadOnly = errors.New("product repository is read-only")
+
 // Product represents a product/resource with pricing information.
 type Product struct {
 	ID            string            // Resource ID (e.g., "demo-content")
@@ -28,6 +35,30 @@ type Product struct {
 	// Subscription configuration (nil = one-time purchase only)
 	Subscription *SubscriptionConfig
 
+	// Variants selectable in cart items, keyed by variant ID (YAML catalogs only)
+	Variants map[string]Variant
+
+	// Bundle lists the products a purchase also grants access to (YAML catalogs only)
+	Bundle []string
+
+	// PriceTiers are quantity price breaks in the crypto price's token (YAML catalogs only)
+	PriceTiers []PriceTier
+
+	// FiatPrices are prices in other fiat currencies, keyed by lowercase currency code (YAML catalogs only)
+	FiatPrices map[string]FiatPrice
+
+	// FiatQuoteToken is the token x402 quotes convert the fiat price into at the live exchange
+	// rate; empty when the crypto price is fixed (YAML catalogs only)
+	FiatQuoteToken string
+
+	// StripePaymentMethods overrides stripe.payment_methods for this product's checkouts (YAML catalogs only)
+	StripePaymentMethods []string
+
+	// StripeConnectedAccount receives this product's Stripe payments, less the application fee (YAML catalogs only)
+	StripeConnectedAccount string
+	// StripeApplicationFeePercent overrides the platform's application fee (YAML catalogs only)
+	StripeApplicationFeePercent *float64
+
 	CreatedAt time.Time // Creation timestamp
 	UpdatedAt time.Time // Last update timestamp
 }
@@ -42,6 +73,28 @@ type SubscriptionConfig struct {
 	GracePeriodHours int    `json:"gracePeriodHours" yaml:"grace_period_hours"` // Hours after expiry before blocking
 }
 
+// Variant is a variant of a product (size, tier, duration) with its own prices and metadata.
+// Nil prices fall back to the product's.
+type Variant struct {
+	Description   string            // Replaces the product's description
+	StripePriceID string            // Stripe price for cart checkouts
+	FiatPrice     *money.Money      // Stripe price (nil = product's)
+	CryptoPrice   *money.Money      // Crypto price (nil = product's)
+	Metadata      map[string]string // Merged over the product's metadata
+}
+
+// PriceTier prices every unit of a cart line at CryptoPrice once its quantity reaches MinQuantity.
+type PriceTier struct {
+	MinQuantity int64
+	CryptoPrice money.Money
+}
+
+// FiatPrice is a product's price in one fiat currency.
+type FiatPrice struct {
+	Price         *money.Money // Nil when only StripePriceID is set
+	StripePriceID string
+}
+
 // IsSubscription returns true if this product requires a subscription.
 func (p Product) IsSubscription() bool {
 	return p.Subscription != nil && p.Subscription.BillingPeriod != ""
@@ -164,5 +217,47 @@ func (p Product) ToPaywallResource() config.PaywallResource {
 		resource.CryptoToken = p.CryptoPrice.Asset.Code
 	}
 
+	if len(p.Variants) > 0 {
+		resource.Variants = make(map[string]config.ResourceVariant, len(p.Variants))
+		for id, v := range p.Variants {
+			variant := config.ResourceVariant{
+				Description:   v.Description,
+				StripePriceID: v.StripePriceID,
+				Metadata:      v.Metadata,
+			}
+			if v.FiatPrice != nil {
+				variant.FiatAmountCents = v.FiatPrice.Atomic
+			}
+			if v.CryptoPrice != nil {
+				variant.CryptoAtomicAmount = v.CryptoPrice.Atomic
+			}
+			resource.Variants[id] = variant
+		}
+	}
+	resource.Bundle = p.Bundle
+	for _, tier := range p.PriceTiers {
+		resource.PriceTiers = append(resource.PriceTiers, config.PriceTier{
+			MinQuantity:        tier.MinQuantity,
+			CryptoAtomicAmount: tier.CryptoPrice.Atomic,
+		})
+	}
+	if len(p.FiatPrices) > 0 {
+		resource.FiatPrices = make(map[string]config.FiatPrice, len(p.FiatPrices))
+		for currency, price := range p.FiatPrices {
+			fiatPrice := config.FiatPrice{StripePriceID: price.StripePriceID}
+			if price.Price != nil {
+				fiatPrice.AmountCents = price.Price.Atomic
+			}
+			resource.FiatPrices[currency] = fiatPrice
+		}
+	}
+	if p.FiatQuoteToken != "" {
+		resource.PriceFromFiat = true
+		resource.CryptoToken = p.FiatQuoteToken
+	}
+	resource.StripePaymentMethods = p.StripePaymentMethods
+	resource.StripeConnectedAccount = p.StripeConnectedAccount
+	resource.StripeApplicationFeePercent = p.StripeApplicationFeePercent
+
 	return resource
 }
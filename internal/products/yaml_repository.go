@@ -2,7 +2,7 @@ package products
 
 import (
 	"context"
-	"errors"
+	"sync"
 	"time"
 
 	"github.com/CedrosPay/server/internal/config"
@@ -11,6 +11,7 @@ import (
 
 // YAMLRepository implements Repository using in-memory YAML config.
 type YAMLRepository struct {
+	mu        sync.RWMutex
 	resources map[string]config.PaywallResource
 }
 
@@ -26,13 +27,51 @@ func NewYAMLRepository(resources map[string]config.PaywallResource) *YAMLReposit
 	}
 }
 
+// Reload replaces the configured resources, e.g. after the config file changed.
+func (r *YAMLRepository) Reload(resources map[string]config.PaywallResource) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.resources = resources
+}
+
+// ReloadYAML replaces the resources of a YAML-backed repo, including one wrapped by
+// CachedRepository, and clears the cache. It reports false for database sources, which
+// serve changes without a reload.
+func ReloadYAML(repo Repository, resources map[string]config.PaywallResource) bool {
+	cached, isCached := repo.(*CachedRepository)
+	if isCached {
+		repo = cached.underlying
+	}
+	yaml, ok := repo.(*YAMLRepository)
+	if !ok {
+		return false
+	}
+	yaml.Reload(resources)
+	if isCached {
+		cached.InvalidateCache()
+	}
+	return true
+}
+
+// current returns the resource map in effect. Reload swaps the map rather than
+// mutating it, so callers may range over the result without holding the lock.
+func (r *YAMLRepository) current() map[string]config.PaywallResource {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.resources
+}
+
 // GetProduct retrieves a product by ID.
 func (r *YAMLRepository) GetProduct(_ context.Context, id string) (Product, error) {
-	resource, ok := r.resources[id]
+	resource, ok := r.current()[id]
 	if !ok {
 		return Product{}, ErrProductNotFound
 	}
+	return resourceToProduct(id, resource), nil
+}
 
+// resourceToProduct converts a YAML resource into a Product.
+func resourceToProduct(id string, resource config.PaywallResource) Product {
 	p := Product{
 		ID:            id,
 		Description:   resource.Description,
@@ -40,6 +79,7 @@ func (r *YAMLRepository) GetProduct(_ context.Context, id string) (Product, erro
 		CryptoAccount: resource.CryptoAccount,
 		MemoTemplate:  resource.MemoTemplate,
 		Metadata:      cloneMetadata(resource.Metadata),
+		Bundle:        resource.Bundle,
 		Active:        true, // YAML resources are always active
 		CreatedAt:     zeroTime,
 		UpdatedAt:     zeroTime,
@@ -65,6 +105,66 @@ func (r *YAMLRepository) GetProduct(_ context.Context, id string) (Product, erro
 		}
 	}
 
+	// Convert variants; their prices are in the resource's currency and token
+	if len(resource.Variants) > 0 {
+		p.Variants = make(map[string]Variant, len(resource.Variants))
+		for variantID, v := range resource.Variants {
+			variant := Variant{
+				Description:   v.Description,
+				StripePriceID: v.StripePriceID,
+				Metadata:      cloneMetadata(v.Metadata),
+			}
+			if v.FiatAmountCents > 0 {
+				if asset, err := money.GetAsset(toUpperCase(resource.FiatCurrency)); err == nil {
+					price := money.New(asset, v.FiatAmountCents)
+					variant.FiatPrice = &price
+				}
+			}
+			if v.CryptoAtomicAmount > 0 {
+				if asset, err := money.GetAsset(toUpperCase(resource.CryptoToken)); err == nil {
+					price := money.New(asset, v.CryptoAtomicAmount)
+					variant.CryptoPrice = &price
+				}
+			}
+			p.Variants[variantID] = variant
+		}
+	}
+
+	// Convert prices in other fiat currencies
+	if len(resource.FiatPrices) > 0 {
+		p.FiatPrices = make(map[string]FiatPrice, len(resource.FiatPrices))
+		for currency, fp := range resource.FiatPrices {
+			fiatPrice := FiatPrice{StripePriceID: fp.StripePriceID}
+			if fp.AmountCents > 0 {
+				if asset, err := money.GetAsset(toUpperCase(currency)); err == nil {
+					price := money.New(asset, fp.AmountCents)
+					fiatPrice.Price = &price
+				}
+			}
+			p.FiatPrices[currency] = fiatPrice
+		}
+	}
+
+	// A fiat-priced resource has no fixed crypto price, only the token it is quoted in
+	if resource.PriceFromFiat {
+		p.FiatQuoteToken = toUpperCase(resource.CryptoToken)
+	}
+	p.StripePaymentMethods = resource.StripePaymentMethods
+	p.StripeConnectedAccount = resource.StripeConnectedAccount
+	p.StripeApplicationFeePercent = resource.StripeApplicationFeePercent
+
+	// Convert price tiers; they are in the resource's crypto token
+	if len(resource.PriceTiers) > 0 {
+		if asset, err := money.GetAsset(toUpperCase(resource.CryptoToken)); err == nil {
+			for _, tier := range resource.PriceTiers {
+				p.PriceTiers = append(p.PriceTiers, PriceTier{
+					MinQuantity: tier.MinQuantity,
+					CryptoPrice: money.New(asset, tier.CryptoAtomicAmount),
+				})
+			}
+		}
+	}
+
 	// Convert subscription config if present
 	if resource.Subscription != nil && resource.Subscription.BillingPeriod != "" {
 		p.Subscription = &SubscriptionConfig{
@@ -81,32 +181,27 @@ func (r *YAMLRepository) GetProduct(_ context.Context, id string) (Product, erro
 		}
 	}
 
-	return p, nil
+	return p
 }
 
 // GetProductByStripePriceID retrieves a product by its Stripe Price ID.
-func (r *YAMLRepository) GetProductByStripePriceID(ctx context.Context, stripePriceID string) (Product, error) {
+func (r *YAMLRepository) GetProductByStripePriceID(_ context.Context, stripePriceID string) (Product, error) {
 	// Linear search through YAML resources to find matching Stripe Price ID
-	for id, resource := range r.resources {
+	for id, resource := range r.current() {
 		if resource.StripePriceID == stripePriceID {
-			// Reuse GetProduct logic to ensure consistent conversion
-			return r.GetProduct(ctx, id)
+			return resourceToProduct(id, resource), nil
 		}
 	}
 	return Product{}, ErrProductNotFound
 }
 
 // ListProducts returns all active products.
-func (r *YAMLRepository) ListProducts(ctx context.Context) ([]Product, error) {
-	products := make([]Product, 0, len(r.resources))
-
-	for id := range r.resources {
-		// Reuse GetProduct logic to ensure consistent conversion
-		p, err := r.GetProduct(ctx, id)
-		if err != nil {
-			return nil, err
-		}
-		products = append(products, p)
+func (r *YAMLRepository) ListProducts(_ context.Context) ([]Product, error) {
+	resources := r.current()
+	products := make([]Product, 0, len(resources))
+
+	for id, resource := range resources {
+		products = append(products, resourceToProduct(id, resource))
 	}
 
 	return products, nil
@@ -114,17 +209,17 @@ func (r *YAMLRepository) ListProducts(ctx context.Context) ([]Product, error) {
 
 // CreateProduct is not supported for YAML repository (read-only).
 func (r *YAMLRepository) CreateProduct(_ context.Context, _ Product) error {
-	return errors.New("yaml repository is read-only")
+	return ErrReadOnly
 }
 
 // UpdateProduct is not supported for YAML repository (read-only).
 func (r *YAMLRepository) UpdateProduct(_ context.Context, _ Product) error {
-	return errors.New("yaml repository is read-only")
+	return ErrReadOnly
 }
 
 // DeleteProduct is not supported for YAML repository (read-only).
 func (r *YAMLRepository) DeleteProduct(_ context.Context, _ string) error {
-	return errors.New("yaml repository is read-only")
+	return ErrReadOnly
 }
 
 // Close is a no-op for YAML repository.
@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/httprate"
+
+	"github.com/CedrosPay/server/internal/apikey"
+)
+
+// EndpointLimit overrides the per-wallet and per-IP limits for requests matching Route.
+type EndpointLimit struct {
+	Method string        // Empty for any method
+	Route  string        // chi route pattern, e.g. "/paywall/v1/payment-status/{signature}"
+	Limit  int           // Requests allowed per wallet (or IP) per window
+	Window time.Duration // Time window
+}
+
+type endpointLimitedKey struct{}
+
+// EndpointLimiter creates a middleware applying cfg.Endpoints. A request matching an endpoint
+// is limited per wallet (or IP) by that endpoint's limit, and the per-wallet and per-IP
+// limiters after it let it through.
+func EndpointLimiter(cfg Config) func(http.Handler) http.Handler {
+	if len(cfg.Endpoints) == 0 {
+		return func(next http.Handler) http.Handler {
+			return next
+		}
+	}
+
+	type endpointKey struct{ method, route string }
+	routes := chi.NewRouter()
+	limiters := make(map[endpointKey]*httprate.RateLimiter, len(cfg.Endpoints))
+	noop := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+	for _, endpoint := range cfg.Endpoints {
+		name := "endpoint:" + endpoint.Route // Counter name, for shared counters
+		if endpoint.Method == "" {
+			routes.Handle(endpoint.Route, noop)
+		} else {
+			routes.Method(endpoint.Method, endpoint.Route, noop)
+			name = "endpoint:" + endpoint.Method + " " + endpoint.Route
+		}
+		limiter := httprate.NewRateLimiter(
+			endpoint.Limit,
+			endpoint.Window,
+			httprate.WithKeyFuncs(walletKeyExtractor),
+			cfg.counterOption(name),
+			httprate.WithLimitHandler(
+				createRateLimitHandler(
+					"endpoint",
+					endpoint.Window,
+					extractWalletFromRequest,
+					cfg.Metrics,
+				),
+			),
+		)
+		limiters[endpointKey{endpoint.Method, endpoint.Route}] = limiter
+		cfg.Inspector.add(name, limiter, endpoint.Limit, endpoint.Window, walletKeyExtractor)
+	}
+
+	return func(next http.Handler) http.Handler {
+		limited := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), endpointLimitedKey{}, true)))
+		})
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := routes.Find(chi.NewRouteContext(), r.Method, r.URL.Path)
+			limiter, ok := limiters[endpointKey{r.Method, route}]
+			if !ok {
+				limiter, ok = limiters[endpointKey{"", route}]
+			}
+			if route == "" || !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			// Enterprise and Partner tiers bypass per-endpoint limits like the per-client ones
+			if apikey.IsExemptFromRateLimits(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			serveLimited(limiter, limited, w, r)
+		})
+	}
+}
+
+// endpointLimited reports whether EndpointLimiter already limited the request.
+func endpointLimited(r *http.Request) bool {
+	limited, _ := r.Context().Value(endpointLimitedKey{}).(bool)
+	return limited
+}
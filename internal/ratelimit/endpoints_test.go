@@ -0,0 +1,49 @@
+package ratelimit
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestEndpointLimiter(t *testing.T) {
+	cfg := Config{
+		PerIPEnabled: true,
+		PerIPLimit:   1,
+		PerIPWindow:  time.Minute,
+		Endpoints: []EndpointLimit{
+			{Method: http.MethodPost, Route: "/api/quote", Limit: 2, Window: time.Minute},
+			{Route: "/api/status/{signature}", Limit: 3, Window: time.Minute},
+		},
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := EndpointLimiter(cfg)(IPLimiter(cfg)(ok))
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   []int
+	}{
+		{name: "quote uses its endpoint limit", method: http.MethodPost, path: "/api/quote", want: []int{200, 200, 429}},
+		{name: "status pattern matches any signature", method: http.MethodGet, path: "/api/status/sig-1", want: []int{200, 200, 200, 429}},
+		{name: "other methods use the per-IP limit", method: http.MethodGet, path: "/api/quote", want: []int{200, 429}},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for j, code := range tt.want {
+				req := httptest.NewRequest(tt.method, tt.path, nil)
+				req.RemoteAddr = fmt.Sprintf("203.0.113.%d:1234", i+1) // A fresh client per case
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, req)
+				if w.Code != code {
+					t.Fatalf("Request %d: expected %d, got %d", j, code, w.Code)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package ratelimit
+
+import (
+	"math"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/go-chi/httprate"
+)
+
+// LimiterState is one limiter's current count for a client.
+type LimiterState struct {
+	Limiter   string    `json:"limiter"` // global, per_wallet, per_ip, or endpoint:<route>
+	Key       string    `json:"key"`     // Counter key: "*", "wallet:<address>", or an IP (IPv6 as its /64)
+	Limit     int       `json:"limit"`
+	Window    string    `json:"window"`
+	Used      int       `json:"used"` // Requests counted in the sliding window
+	Remaining int       `json:"remaining"`
+	Limited   bool      `json:"limited"` // The next request would be rejected
+	ResetAt   time.Time `json:"resetAt"` // End of the current window
+}
+
+// Inspector reports the state of the limiters created with it in Config.Inspector, for
+// support debugging. Its methods are safe to call on a nil Inspector.
+type Inspector struct {
+	mu       sync.Mutex
+	limiters []inspectedLimiter
+}
+
+type inspectedLimiter struct {
+	name    string
+	limiter *httprate.RateLimiter
+	limit   int
+	window  time.Duration
+	keyFn   httprate.KeyFunc // nil for the global limiter, which counts every request under "*"
+}
+
+// NewInspector creates an Inspector with no limiters.
+func NewInspector() *Inspector {
+	return &Inspector{}
+}
+
+// add registers a limiter.
+func (i *Inspector) add(name string, limiter *httprate.RateLimiter, limit int, window time.Duration, keyFn httprate.KeyFunc) {
+	if i == nil {
+		return
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.limiters = append(i.limiters, inspectedLimiter{name: name, limiter: limiter, limit: limit, window: window, keyFn: keyFn})
+}
+
+// Inspect returns the state of each limiter for a client identified by wallet, ip, or both,
+// keyed as the limiters would key that client's requests. Per-IP limiters are skipped without
+// an IP, and per-wallet ones without either.
+func (i *Inspector) Inspect(wallet, ip string) []LimiterState {
+	states := []LimiterState{}
+	if i == nil {
+		return states
+	}
+
+	// A request carrying the client's identity, for the limiters' key functions
+	r := &http.Request{Header: http.Header{}, URL: &url.URL{}, RemoteAddr: ip}
+	if wallet != "" {
+		r.Header.Set("X-Wallet", wallet)
+	}
+
+	i.mu.Lock()
+	limiters := i.limiters
+	i.mu.Unlock()
+
+	now := time.Now().UTC()
+	for _, l := range limiters {
+		key, counterKey := "*", "*"
+		if l.keyFn != nil {
+			if ip == "" && (wallet == "" || l.name == "per_ip") {
+				continue
+			}
+			var err error
+			if key, err = l.keyFn(r); err != nil {
+				continue
+			}
+			counterKey = key + ":" // httprate.WithKeyFuncs ends each key with ':'
+		}
+		_, rate, err := l.limiter.Status(counterKey)
+		if err != nil {
+			continue
+		}
+		used := int(math.Round(rate))
+		states = append(states, LimiterState{
+			Limiter:   l.name,
+			Key:       key,
+			Limit:     l.limit,
+			Window:    l.window.String(),
+			Used:      used,
+			Remaining: max(l.limit-used, 0),
+			Limited:   used+1 > l.limit,
+			ResetAt:   now.Truncate(l.window).Add(l.window),
+		})
+	}
+	return states
+}
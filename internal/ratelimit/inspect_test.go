@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestInspector(t *testing.T) {
+	inspector := NewInspector()
+	cfg := Config{
+		GlobalEnabled:    true,
+		GlobalLimit:      100,
+		GlobalWindow:     time.Hour,
+		PerWalletEnabled: true,
+		PerWalletLimit:   2,
+		PerWalletWindow:  time.Hour,
+		PerIPEnabled:     true,
+		PerIPLimit:       50,
+		PerIPWindow:      time.Hour,
+		Inspector:        inspector,
+	}
+	handler := GlobalLimiter(cfg)(WalletLimiter(cfg)(IPLimiter(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))))
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.RemoteAddr = "192.168.1.100:54321"
+		req.Header.Set("X-Wallet", "wallet-1")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	type state struct {
+		key       string
+		used      int
+		remaining int
+		limited   bool
+	}
+	tests := []struct {
+		name   string
+		wallet string
+		ip     string
+		want   map[string]state
+	}{
+		{
+			name:   "wallet and IP",
+			wallet: "wallet-1",
+			ip:     "192.168.1.100",
+			want: map[string]state{
+				"global":     {key: "*", used: 3, remaining: 97},
+				"per_wallet": {key: "wallet:wallet-1", used: 2, limited: true},
+				"per_ip":     {key: "192.168.1.100", used: 2, remaining: 48},
+			},
+		},
+		{
+			name:   "wallet only",
+			wallet: "wallet-2",
+			want: map[string]state{
+				"global":     {key: "*", used: 3, remaining: 97},
+				"per_wallet": {key: "wallet:wallet-2", remaining: 2},
+			},
+		},
+		{
+			name: "IP only",
+			ip:   "192.168.1.100",
+			want: map[string]state{
+				"global":     {key: "*", used: 3, remaining: 97},
+				"per_wallet": {key: "192.168.1.100", remaining: 2},
+				"per_ip":     {key: "192.168.1.100", used: 2, remaining: 48},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			states := inspector.Inspect(tt.wallet, tt.ip)
+			if len(states) != len(tt.want) {
+				t.Fatalf("got %d limiters, want %d: %+v", len(states), len(tt.want), states)
+			}
+			for _, s := range states {
+				got := state{key: s.Key, used: s.Used, remaining: s.Remaining, limited: s.Limited}
+				if got != tt.want[s.Limiter] {
+					t.Errorf("%s = %+v, want %+v", s.Limiter, got, tt.want[s.Limiter])
+				}
+				if s.ResetAt.Before(time.Now()) {
+					t.Errorf("%s resetAt %s is in the past", s.Limiter, s.ResetAt)
+				}
+			}
+		})
+	}
+
+	if states := (*Inspector)(nil).Inspect("wallet-1", ""); len(states) != 0 {
+		t.Errorf("nil Inspector returned %+v", states)
+	}
+}
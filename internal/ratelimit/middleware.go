@@ -3,7 +3,9 @@ package ratelimit
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/CedrosPay/server/internal/apikey"
@@ -33,6 +35,27 @@ type Config struct {
 
 	// Metrics collector (optional)
 	Metrics *metrics.Metrics
+
+	// Per-endpoint limits, replacing the per-wallet and per-IP limits for matching requests
+	Endpoints []EndpointLimit
+
+	// Shared counters (optional), e.g. PostgresCounters.Counter; nil counts in memory per instance
+	Counters CounterFunc
+
+	// Inspector (optional) the limiters register with, for the admin rate limit endpoint
+	Inspector *Inspector
+}
+
+// CounterFunc returns the counter for a limiter: "global", "per_wallet", "per_ip", or
+// "endpoint:" followed by an endpoint's method (if any) and route.
+type CounterFunc func(limitType string) httprate.LimitCounter
+
+// counterOption stores a limiter's counts in cfg.Counters, if set.
+func (cfg Config) counterOption(limitType string) httprate.Option {
+	if cfg.Counters == nil {
+		return httprate.WithNoop()
+	}
+	return httprate.WithLimitCounter(cfg.Counters(limitType))
 }
 
 // rateLimitResponse represents the JSON error response for rate limit exceeded.
@@ -70,7 +93,7 @@ func DefaultConfig() Config {
 // This eliminates duplication across global, per-wallet, and per-IP limiters.
 func createRateLimitHandler(
 	limitType string,
-	windowSeconds int,
+	window time.Duration,
 	extractIdentifier func(*http.Request) string,
 	metricsCollector *metrics.Metrics,
 ) func(http.ResponseWriter, *http.Request) {
@@ -101,24 +124,39 @@ func createRateLimitHandler(
 			}
 		case "per_ip":
 			message = "IP rate limit exceeded. Please try again later."
+		case "endpoint":
+			message = "Rate limit exceeded for this endpoint. Please try again later."
 		default:
 			message = "Rate limit exceeded. Please try again later."
 		}
 
+		retryAfter := retryAfterSeconds(w.Header(), window)
 		response := rateLimitResponse{
 			Error:             "rate_limit_exceeded",
 			Message:           message,
-			RetryAfterSeconds: windowSeconds,
+			RetryAfterSeconds: retryAfter,
 		}
 
-		// Set headers and write response
+		// Set headers and write response. httprate already set X-RateLimit-Limit and
+		// X-RateLimit-Reset; its X-RateLimit-Remaining can go negative once over the limit.
 		w.Header().Set("Content-Type", "application/json")
-		w.Header().Set("Retry-After", fmt.Sprintf("%d", windowSeconds))
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
 		w.WriteHeader(http.StatusTooManyRequests)
 		json.NewEncoder(w).Encode(response)
 	}
 }
 
+// retryAfterSeconds returns the seconds until the limiter's window resets, from the
+// X-RateLimit-Reset header httprate sets, or the whole window without one.
+func retryAfterSeconds(h http.Header, window time.Duration) int {
+	reset, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return int(window.Seconds())
+	}
+	return max(1, int(math.Ceil(time.Until(time.Unix(reset, 0)).Seconds())))
+}
+
 // GlobalLimiter creates a global rate limiter middleware.
 func GlobalLimiter(cfg Config) func(http.Handler) http.Handler {
 	if !cfg.GlobalEnabled {
@@ -127,18 +165,20 @@ func GlobalLimiter(cfg Config) func(http.Handler) http.Handler {
 		}
 	}
 
-	limiter := httprate.Limit(
+	limiter := httprate.NewRateLimiter(
 		cfg.GlobalLimit,
 		cfg.GlobalWindow,
+		cfg.counterOption("global"),
 		httprate.WithLimitHandler(
 			createRateLimitHandler(
 				"global",
-				int(cfg.GlobalWindow.Seconds()),
+				cfg.GlobalWindow,
 				nil, // No identifier extraction for global limiter
 				cfg.Metrics,
 			),
 		),
 	)
+	cfg.Inspector.add("global", limiter, cfg.GlobalLimit, cfg.GlobalWindow, nil)
 
 	// Wrap limiter to check for API key exemptions
 	return func(next http.Handler) http.Handler {
@@ -148,7 +188,7 @@ func GlobalLimiter(cfg Config) func(http.Handler) http.Handler {
 				next.ServeHTTP(w, r)
 				return
 			}
-			limiter(next).ServeHTTP(w, r)
+			serveLimited(limiter, next, w, r)
 		})
 	}
 }
@@ -162,29 +202,32 @@ func WalletLimiter(cfg Config) func(http.Handler) http.Handler {
 		}
 	}
 
-	limiter := httprate.Limit(
+	limiter := httprate.NewRateLimiter(
 		cfg.PerWalletLimit,
 		cfg.PerWalletWindow,
 		httprate.WithKeyFuncs(walletKeyExtractor),
+		cfg.counterOption("per_wallet"),
 		httprate.WithLimitHandler(
 			createRateLimitHandler(
 				"per_wallet",
-				int(cfg.PerWalletWindow.Seconds()),
+				cfg.PerWalletWindow,
 				extractWalletFromRequest,
 				cfg.Metrics,
 			),
 		),
 	)
+	cfg.Inspector.add("per_wallet", limiter, cfg.PerWalletLimit, cfg.PerWalletWindow, walletKeyExtractor)
 
 	// Wrap limiter to check for API key exemptions
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Enterprise and Partner tiers bypass per-wallet limits
-			if apikey.IsExemptFromRateLimits(r) {
+			// Enterprise and Partner tiers bypass per-wallet limits, and so do requests already
+			// limited by an endpoint limit
+			if apikey.IsExemptFromRateLimits(r) || endpointLimited(r) {
 				next.ServeHTTP(w, r)
 				return
 			}
-			limiter(next).ServeHTTP(w, r)
+			serveLimited(limiter, next, w, r)
 		})
 	}
 }
@@ -197,33 +240,79 @@ func IPLimiter(cfg Config) func(http.Handler) http.Handler {
 		}
 	}
 
-	limiter := httprate.Limit(
+	limiter := httprate.NewRateLimiter(
 		cfg.PerIPLimit,
 		cfg.PerIPWindow,
 		httprate.WithKeyByIP(),
+		cfg.counterOption("per_ip"),
 		httprate.WithLimitHandler(
 			createRateLimitHandler(
 				"per_ip",
-				int(cfg.PerIPWindow.Seconds()),
+				cfg.PerIPWindow,
 				func(r *http.Request) string { return r.RemoteAddr },
 				cfg.Metrics,
 			),
 		),
 	)
+	cfg.Inspector.add("per_ip", limiter, cfg.PerIPLimit, cfg.PerIPWindow, httprate.KeyByIP)
 
 	// Wrap limiter to check for API key exemptions
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Enterprise and Partner tiers bypass per-IP limits
-			if apikey.IsExemptFromRateLimits(r) {
+			// Enterprise and Partner tiers bypass per-IP limits, and so do requests already
+			// limited by an endpoint limit
+			if apikey.IsExemptFromRateLimits(r) || endpointLimited(r) {
 				next.ServeHTTP(w, r)
 				return
 			}
-			limiter(next).ServeHTTP(w, r)
+			serveLimited(limiter, next, w, r)
 		})
 	}
 }
 
+// serveLimited serves the request through limiter. Each limiter sets the X-RateLimit-*
+// headers, so when an earlier limiter in the chain has fewer requests remaining its headers
+// are put back: clients see the limit they will hit first.
+func serveLimited(limiter *httprate.RateLimiter, next http.Handler, w http.ResponseWriter, r *http.Request) {
+	earlier := copyLimitHeaders(w.Header())
+	limiter.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if earlier.tighterThan(copyLimitHeaders(w.Header())) {
+			earlier.restore(w.Header())
+		}
+		next.ServeHTTP(w, r)
+	})).ServeHTTP(w, r)
+}
+
+// limitHeaders is a copy of a limiter's X-RateLimit-* headers.
+type limitHeaders struct {
+	limit, remaining, reset string
+}
+
+func copyLimitHeaders(h http.Header) limitHeaders {
+	return limitHeaders{
+		limit:     h.Get("X-RateLimit-Limit"),
+		remaining: h.Get("X-RateLimit-Remaining"),
+		reset:     h.Get("X-RateLimit-Reset"),
+	}
+}
+
+// tighterThan reports whether l has fewer requests remaining than other. It is false when no
+// limiter set l.
+func (l limitHeaders) tighterThan(other limitHeaders) bool {
+	remaining, err := strconv.Atoi(l.remaining)
+	if err != nil {
+		return false
+	}
+	otherRemaining, err := strconv.Atoi(other.remaining)
+	return err != nil || remaining < otherRemaining
+}
+
+func (l limitHeaders) restore(h http.Header) {
+	h.Set("X-RateLimit-Limit", l.limit)
+	h.Set("X-RateLimit-Remaining", l.remaining)
+	h.Set("X-RateLimit-Reset", l.reset)
+}
+
 // walletKeyExtractor is a httprate.KeyFunc that extracts wallet address from request.
 func walletKeyExtractor(r *http.Request) (string, error) {
 	wallet := extractWalletFromRequest(r)
@@ -1,8 +1,10 @@
 package ratelimit
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 	"time"
 )
@@ -294,3 +296,76 @@ func TestIPLimiter_EnforcesLimit(t *testing.T) {
 		t.Errorf("Different IP: Expected 200, got %d", w.Code)
 	}
 }
+
+func TestRateLimitHeaders(t *testing.T) {
+	tests := []struct {
+		name        string
+		globalLimit int
+		ipLimit     int
+		wantLimit   string // X-RateLimit-Limit on every response: the tighter limiter's
+	}{
+		{name: "later limiter tighter", globalLimit: 10, ipLimit: 2, wantLimit: "2"},
+		{name: "earlier limiter tighter", globalLimit: 2, ipLimit: 10, wantLimit: "2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{
+				GlobalEnabled: true,
+				GlobalLimit:   tt.globalLimit,
+				GlobalWindow:  time.Hour,
+				PerIPEnabled:  true,
+				PerIPLimit:    tt.ipLimit,
+				PerIPWindow:   time.Hour,
+			}
+			handler := GlobalLimiter(cfg)(IPLimiter(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})))
+			serve := func() *httptest.ResponseRecorder {
+				req := httptest.NewRequest("GET", "/test", nil)
+				req.RemoteAddr = "192.168.1.100:54321"
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, req)
+				return w
+			}
+
+			for i, wantRemaining := range []string{"1", "0"} {
+				w := serve()
+				if w.Code != http.StatusOK {
+					t.Fatalf("request %d: expected 200, got %d", i, w.Code)
+				}
+				if got := w.Header().Get("X-RateLimit-Limit"); got != tt.wantLimit {
+					t.Errorf("request %d: X-RateLimit-Limit = %q, want %q", i, got, tt.wantLimit)
+				}
+				if got := w.Header().Get("X-RateLimit-Remaining"); got != wantRemaining {
+					t.Errorf("request %d: X-RateLimit-Remaining = %q, want %q", i, got, wantRemaining)
+				}
+			}
+
+			w := serve()
+			if w.Code != http.StatusTooManyRequests {
+				t.Fatalf("expected 429 after limit exceeded, got %d", w.Code)
+			}
+			if got := w.Header().Get("X-RateLimit-Remaining"); got != "0" {
+				t.Errorf("limited X-RateLimit-Remaining = %q, want 0", got)
+			}
+			reset, err := strconv.ParseInt(w.Header().Get("X-RateLimit-Reset"), 10, 64)
+			if err != nil {
+				t.Fatalf("X-RateLimit-Reset = %q: %v", w.Header().Get("X-RateLimit-Reset"), err)
+			}
+			retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+			if err != nil || retryAfter < 1 || retryAfter > 3600 {
+				t.Fatalf("Retry-After = %q, want 1-3600 seconds", w.Header().Get("Retry-After"))
+			}
+			if untilReset := time.Until(time.Unix(reset, 0)); untilReset > time.Duration(retryAfter)*time.Second {
+				t.Errorf("Retry-After = %ds, but the window resets in %s", retryAfter, untilReset)
+			}
+			var body rateLimitResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if body.RetryAfterSeconds != retryAfter {
+				t.Errorf("retry_after_seconds = %d, want Retry-After %d", body.RetryAfterSeconds, retryAfter)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/httprate"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// postgresCounterTable holds one row per key and window; rows expire after two windows.
+	postgresCounterTable = "rate_limit_counters"
+
+	// postgresCounterTimeout bounds each counter query, so a slow database delays requests by
+	// at most this long before the instance falls back to counting locally.
+	postgresCounterTimeout = 500 * time.Millisecond
+
+	// postgresSweepInterval is how often expired counter rows are deleted.
+	postgresSweepInterval = time.Minute
+)
+
+// PostgresCounters keeps rate limit counts in a PostgreSQL table, so every instance sharing
+// the database enforces one cluster-wide limit. While the database can't be reached, each
+// limiter falls back to counting in memory, per instance.
+type PostgresCounters struct {
+	db *sql.DB
+
+	mu    sync.Mutex
+	swept time.Time
+}
+
+// NewPostgresCounters creates the counter table if needed.
+func NewPostgresCounters(ctx context.Context, db *sql.DB) (*PostgresCounters, error) {
+	// Unlogged: counts are short-lived and not worth write-ahead logging
+	_, err := db.ExecContext(ctx, `
+		CREATE UNLOGGED TABLE IF NOT EXISTS `+postgresCounterTable+` (
+			key TEXT NOT NULL,
+			window_start TIMESTAMPTZ NOT NULL,
+			count INTEGER NOT NULL DEFAULT 0,
+			expires_at TIMESTAMPTZ NOT NULL,
+			PRIMARY KEY (key, window_start)
+		);
+		CREATE INDEX IF NOT EXISTS idx_rate_limit_counters_expires_at ON `+postgresCounterTable+` (expires_at);
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("ratelimit: create %s table: %w", postgresCounterTable, err)
+	}
+	return &PostgresCounters{db: db}, nil
+}
+
+// Counter returns the counter for one limiter. It satisfies CounterFunc.
+func (p *PostgresCounters) Counter(limitType string) httprate.LimitCounter {
+	return &postgresCounter{counters: p, limitType: limitType}
+}
+
+// sweep deletes expired rows, at most once per postgresSweepInterval.
+func (p *PostgresCounters) sweep(now time.Time) {
+	p.mu.Lock()
+	if now.Sub(p.swept) < postgresSweepInterval {
+		p.mu.Unlock()
+		return
+	}
+	p.swept = now
+	p.mu.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, err := p.db.ExecContext(ctx, `DELETE FROM `+postgresCounterTable+` WHERE expires_at < $1`, now); err != nil {
+			log.Warn().Err(err).Msg("ratelimit.sweep_failed")
+		}
+	}()
+}
+
+// postgresCounter is an httprate.LimitCounter for one limiter, keyed by its limit type.
+type postgresCounter struct {
+	counters  *PostgresCounters
+	limitType string
+	window    time.Duration
+	local     httprate.LimitCounter // Used while the database is unreachable
+	failing   atomic.Bool
+}
+
+// Config is called by httprate with the limiter's settings.
+func (c *postgresCounter) Config(requestLimit int, windowLength time.Duration) {
+	c.window = windowLength
+	c.local = httprate.NewLocalLimitCounter(windowLength)
+	c.local.Config(requestLimit, windowLength)
+}
+
+// Increment counts one request for key in currentWindow.
+func (c *postgresCounter) Increment(key string, currentWindow time.Time) error {
+	return c.IncrementBy(key, currentWindow, 1)
+}
+
+// IncrementBy counts amount requests for key in currentWindow.
+func (c *postgresCounter) IncrementBy(key string, currentWindow time.Time, amount int) error {
+	// Keep the local counts current too, so a fallback starts from this instance's traffic
+	_ = c.local.IncrementBy(key, currentWindow, amount)
+
+	ctx, cancel := context.WithTimeout(context.Background(), postgresCounterTimeout)
+	defer cancel()
+	_, err := c.counters.db.ExecContext(ctx, `
+		INSERT INTO `+postgresCounterTable+` (key, window_start, count, expires_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (key, window_start) DO UPDATE SET count = `+postgresCounterTable+`.count + EXCLUDED.count
+	`, c.limitType+":"+key, currentWindow, amount, currentWindow.Add(2*c.window))
+	c.observe(err)
+	if err == nil {
+		c.counters.sweep(time.Now())
+	}
+	return nil
+}
+
+// Get returns the counts for key in the current and previous windows.
+func (c *postgresCounter) Get(key string, currentWindow, previousWindow time.Time) (int, int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), postgresCounterTimeout)
+	defer cancel()
+	rows, err := c.counters.db.QueryContext(ctx, `
+		SELECT window_start, count FROM `+postgresCounterTable+`
+		WHERE key = $1 AND window_start IN ($2, $3)
+	`, c.limitType+":"+key, currentWindow, previousWindow)
+	if err != nil {
+		c.observe(err)
+		return c.local.Get(key, currentWindow, previousWindow)
+	}
+	defer rows.Close()
+
+	var curr, prev int
+	for rows.Next() {
+		var (
+			windowStart time.Time
+			count       int
+		)
+		if err := rows.Scan(&windowStart, &count); err != nil {
+			c.observe(err)
+			return c.local.Get(key, currentWindow, previousWindow)
+		}
+		if windowStart.Equal(currentWindow) {
+			curr = count
+		} else {
+			prev = count
+		}
+	}
+	if err := rows.Err(); err != nil {
+		c.observe(err)
+		return c.local.Get(key, currentWindow, previousWindow)
+	}
+	c.observe(nil)
+	return curr, prev, nil
+}
+
+// observe logs when the database stops and starts answering, rather than on every request.
+func (c *postgresCounter) observe(err error) {
+	if err != nil {
+		if !c.failing.Swap(true) {
+			log.Warn().Err(err).Str("limit_type", c.limitType).Msg("ratelimit.shared_counter_unavailable")
+		}
+		return
+	}
+	if c.failing.Swap(false) {
+		log.Info().Str("limit_type", c.limitType).Msg("ratelimit.shared_counter_recovered")
+	}
+}
@@ -0,0 +1,107 @@
+package ratelimit
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/httprate"
+	_ "github.com/lib/pq" // PostgreSQL driver
+)
+
+// sharedCounter is an httprate.LimitCounter whose counts are shared by every limiter using it,
+// standing in for a database reached by several instances.
+type sharedCounter struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (c *sharedCounter) Config(int, time.Duration) {}
+
+func (c *sharedCounter) Increment(key string, currentWindow time.Time) error {
+	return c.IncrementBy(key, currentWindow, 1)
+}
+
+func (c *sharedCounter) IncrementBy(key string, currentWindow time.Time, amount int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[key+currentWindow.String()] += amount
+	return nil
+}
+
+func (c *sharedCounter) Get(key string, currentWindow, previousWindow time.Time) (int, int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[key+currentWindow.String()], c.counts[key+previousWindow.String()], nil
+}
+
+func TestSharedCountersAcrossInstances(t *testing.T) {
+	shared := &sharedCounter{counts: make(map[string]int)}
+	var limitTypes []string
+	cfg := Config{
+		GlobalEnabled: true,
+		GlobalLimit:   4,
+		GlobalWindow:  time.Minute,
+		Counters: func(limitType string) httprate.LimitCounter {
+			limitTypes = append(limitTypes, limitType)
+			return shared
+		},
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	// Two instances, each with its own limiter
+	instances := []http.Handler{GlobalLimiter(cfg)(ok), GlobalLimiter(cfg)(ok)}
+
+	for i := 0; i < 4; i++ {
+		w := httptest.NewRecorder()
+		instances[i%2].ServeHTTP(w, httptest.NewRequest("GET", "/test", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Request %d: expected 200, got %d", i, w.Code)
+		}
+	}
+	for i, instance := range instances {
+		w := httptest.NewRecorder()
+		instance.ServeHTTP(w, httptest.NewRequest("GET", "/test", nil))
+		if w.Code != http.StatusTooManyRequests {
+			t.Errorf("Instance %d: expected 429 once the shared limit is reached, got %d", i, w.Code)
+		}
+	}
+	if len(limitTypes) != 2 || limitTypes[0] != "global" {
+		t.Errorf("Counters called with %v, want global for each limiter", limitTypes)
+	}
+}
+
+func TestPostgresCounterFallback(t *testing.T) {
+	// Nothing listens on port 1, so every query fails and the counter counts locally
+	db, err := sql.Open("postgres", "postgres://cedros@127.0.0.1:1/cedros?sslmode=disable&connect_timeout=1")
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	counters := &PostgresCounters{db: db}
+
+	cfg := Config{
+		PerIPEnabled: true,
+		PerIPLimit:   2,
+		PerIPWindow:  time.Minute,
+		Counters:     counters.Counter,
+	}
+	handler := IPLimiter(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	want := []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}
+	for i, code := range want {
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.RemoteAddr = "203.0.113.7:1234"
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != code {
+			t.Errorf("Request %d: expected %d, got %d", i, code, w.Code)
+		}
+	}
+}
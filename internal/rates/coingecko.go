@@ -0,0 +1,97 @@
+package rates
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	coinGeckoURL    = "https://api.coingecko.com/api/v3"
+	coinGeckoProURL = "https://pro-api.coingecko.com/api/v3"
+)
+
+// coinGeckoIDs maps token symbols to CoinGecko coin IDs.
+var coinGeckoIDs = map[string]string{
+	"USDC":  "usd-coin",
+	"USDT":  "tether",
+	"PYUSD": "paypal-usd",
+	"SOL":   "solana",
+}
+
+// CoinGecko is a Provider backed by CoinGecko's simple price API.
+type CoinGecko struct {
+	BaseURL string // Defaults to the public API, or the Pro API when APIKey is set
+	APIKey  string // Optional Pro API key
+	Client  *http.Client
+}
+
+// Rate returns how many units of to one unit of from is worth. Rates between a fiat currency
+// and a token use CoinGecko's price of the token in that currency; other pairs cross via USD.
+func (c *CoinGecko) Rate(ctx context.Context, from, to string) (float64, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return 1, nil
+	}
+	if _, ok := coinGeckoIDs[to]; ok && coinGeckoIDs[from] == "" {
+		price, err := c.price(ctx, to, from) // from per to
+		if err != nil {
+			return 0, err
+		}
+		return 1 / price, nil
+	}
+	if _, ok := coinGeckoIDs[from]; ok && coinGeckoIDs[to] == "" {
+		return c.price(ctx, from, to)
+	}
+	return crossRate(ctx, func(ctx context.Context, token string) (float64, error) {
+		return c.price(ctx, token, "USD")
+	}, from, to)
+}
+
+// price returns the price of token in currency.
+func (c *CoinGecko) price(ctx context.Context, token, currency string) (float64, error) {
+	id, ok := coinGeckoIDs[token]
+	if !ok {
+		return 0, fmt.Errorf("%w: coingecko does not list %s", ErrNoRate, token)
+	}
+	vs := strings.ToLower(currency)
+
+	base := c.BaseURL
+	if base == "" {
+		base = coinGeckoURL
+		if c.APIKey != "" {
+			base = coinGeckoProURL
+		}
+	}
+	endpoint := strings.TrimSuffix(base, "/") + "/simple/price?" + url.Values{"ids": {id}, "vs_currencies": {vs}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return 0, fmt.Errorf("rates: coingecko request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.APIKey != "" {
+		req.Header.Set("x-cg-pro-api-key", c.APIKey)
+	}
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("rates: coingecko: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("rates: coingecko: status %d", resp.StatusCode)
+	}
+
+	var prices map[string]map[string]float64
+	if err := json.NewDecoder(resp.Body).Decode(&prices); err != nil {
+		return 0, fmt.Errorf("rates: coingecko: decode: %w", err)
+	}
+	price, ok := prices[id][vs]
+	if !ok || price <= 0 {
+		return 0, fmt.Errorf("%w: coingecko has no %s price for %s", ErrNoRate, currency, token)
+	}
+	return price, nil
+}
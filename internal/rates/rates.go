@@ -0,0 +1,131 @@
+// Package rates provides exchange-rate sources (fixed, CoinGecko, Pyth) for quoting prices set
+// in one asset, e.g. USD, in another, e.g. USDC.
+package rates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CedrosPay/server/internal/config"
+)
+
+// ErrNoRate indicates a provider has no rate between two assets.
+var ErrNoRate = errors.New("rates: no rate")
+
+// Provider supplies exchange rates. It satisfies paywall.RateProvider.
+type Provider interface {
+	// Rate returns how many units of to one unit of from is worth.
+	Rate(ctx context.Context, from, to string) (float64, error)
+}
+
+// NewProvider builds the provider cfg selects, caching fetched rates for cfg.CacheTTL.
+// Returns nil when no provider is configured.
+func NewProvider(cfg config.RateOracleConfig) (Provider, error) {
+	client := &http.Client{Timeout: cfg.Timeout.Duration}
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case "fixed":
+		return Fixed(cfg.FixedRates), nil
+	case "coingecko":
+		return NewCached(&CoinGecko{BaseURL: cfg.APIURL, APIKey: cfg.APIKey, Client: client}, cfg.CacheTTL.Duration), nil
+	case "pyth":
+		return NewCached(&Pyth{BaseURL: cfg.APIURL, Feeds: cfg.PythFeeds, Client: client}, cfg.CacheTTL.Duration), nil
+	default:
+		return nil, fmt.Errorf("rates: unknown provider %q", cfg.Provider)
+	}
+}
+
+// Fixed is a Provider with configured rates keyed "FROM/TO" (units of TO per unit of FROM).
+// The inverse of a configured pair is derived.
+type Fixed map[string]float64
+
+// Rate returns the configured rate from from to to.
+func (f Fixed) Rate(_ context.Context, from, to string) (float64, error) {
+	if strings.EqualFold(from, to) {
+		return 1, nil
+	}
+	for pair, rate := range f {
+		pairFrom, pairTo, _ := strings.Cut(pair, "/")
+		switch {
+		case rate <= 0:
+		case strings.EqualFold(pairFrom, from) && strings.EqualFold(pairTo, to):
+			return rate, nil
+		case strings.EqualFold(pairFrom, to) && strings.EqualFold(pairTo, from):
+			return 1 / rate, nil
+		}
+	}
+	return 0, fmt.Errorf("%w from %s to %s", ErrNoRate, from, to)
+}
+
+// Cached reuses each rate from a Provider for a TTL, so quotes don't each call the source.
+type Cached struct {
+	source Provider
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cachedRate
+}
+
+type cachedRate struct {
+	rate      float64
+	fetchedAt time.Time
+}
+
+// NewCached wraps source with a cache holding each rate for ttl.
+func NewCached(source Provider, ttl time.Duration) *Cached {
+	return &Cached{source: source, ttl: ttl, now: time.Now, entries: make(map[string]cachedRate)}
+}
+
+// Rate returns the cached rate from from to to, fetching it when missing or older than the TTL.
+func (c *Cached) Rate(ctx context.Context, from, to string) (float64, error) {
+	key := strings.ToUpper(from) + "/" + strings.ToUpper(to)
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && c.now().Sub(entry.fetchedAt) < c.ttl {
+		return entry.rate, nil
+	}
+
+	rate, err := c.source.Rate(ctx, from, to)
+	if err != nil {
+		return 0, err
+	}
+	c.mu.Lock()
+	c.entries[key] = cachedRate{rate: rate, fetchedAt: c.now()}
+	c.mu.Unlock()
+	return rate, nil
+}
+
+// crossRate converts through USD prices: the rate from from to to is from's USD price over
+// to's. USD itself is priced at 1.
+func crossRate(ctx context.Context, usdPrice func(context.Context, string) (float64, error), from, to string) (float64, error) {
+	if strings.EqualFold(from, to) {
+		return 1, nil
+	}
+	fromUSD, err := usdPriceOf(ctx, usdPrice, from)
+	if err != nil {
+		return 0, err
+	}
+	toUSD, err := usdPriceOf(ctx, usdPrice, to)
+	if err != nil {
+		return 0, err
+	}
+	if fromUSD <= 0 || toUSD <= 0 {
+		return 0, fmt.Errorf("%w from %s to %s: non-positive price", ErrNoRate, from, to)
+	}
+	return fromUSD / toUSD, nil
+}
+
+func usdPriceOf(ctx context.Context, usdPrice func(context.Context, string) (float64, error), asset string) (float64, error) {
+	if strings.EqualFold(asset, "USD") {
+		return 1, nil
+	}
+	return usdPrice(ctx, strings.ToUpper(asset))
+}
@@ -0,0 +1,187 @@
+package rates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/CedrosPay/server/internal/config"
+)
+
+func TestFixed(t *testing.T) {
+	fixed := Fixed{"USD/USDC": 1.25, "SOL/USDC": 150}
+	tests := []struct {
+		from, to string
+		want     float64
+		wantErr  bool
+	}{
+		{from: "USD", to: "USDC", want: 1.25},
+		{from: "usdc", to: "usd", want: 0.8},
+		{from: "SOL", to: "USDC", want: 150},
+		{from: "USDC", to: "USDC", want: 1},
+		{from: "EUR", to: "USDC", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from+"/"+tt.to, func(t *testing.T) {
+			got, err := fixed.Rate(context.Background(), tt.from, tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Rate error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrNoRate) {
+				t.Errorf("Rate error = %v, want ErrNoRate", err)
+			}
+			if got != tt.want {
+				t.Errorf("Rate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type countingProvider struct {
+	calls int
+	rate  float64
+}
+
+func (p *countingProvider) Rate(context.Context, string, string) (float64, error) {
+	p.calls++
+	return p.rate, nil
+}
+
+func TestCached(t *testing.T) {
+	source := &countingProvider{rate: 1.1}
+	cached := NewCached(source, time.Minute)
+	now := time.Now()
+	cached.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for range 3 {
+		if rate, _ := cached.Rate(ctx, "USD", "USDC"); rate != 1.1 {
+			t.Fatalf("Rate = %v, want 1.1", rate)
+		}
+	}
+	if source.calls != 1 {
+		t.Errorf("source called %d times within the TTL, want 1", source.calls)
+	}
+
+	source.rate = 1.2
+	now = now.Add(time.Minute)
+	if rate, _ := cached.Rate(ctx, "usd", "usdc"); rate != 1.2 || source.calls != 2 {
+		t.Errorf("Rate after TTL = %v with %d calls, want 1.2 with 2", rate, source.calls)
+	}
+}
+
+func TestCoinGecko(t *testing.T) {
+	var apiKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiKey = r.Header.Get("x-cg-pro-api-key")
+		if r.URL.Path != "/simple/price" {
+			http.NotFound(w, r)
+			return
+		}
+		prices := map[string]map[string]float64{
+			"usd-coin": {"usd": 0.8, "eur": 0.9},
+			"solana":   {"usd": 150},
+		}
+		id, vs := r.URL.Query().Get("ids"), r.URL.Query().Get("vs_currencies")
+		fmt.Fprintf(w, `{%q: {%q: %v}}`, id, vs, prices[id][vs])
+	}))
+	defer server.Close()
+
+	coingecko := &CoinGecko{BaseURL: server.URL, APIKey: "cg-key", Client: server.Client()}
+	tests := []struct {
+		from, to string
+		want     float64
+		wantErr  bool
+	}{
+		{from: "USD", to: "USDC", want: 1.25},
+		{from: "EUR", to: "USDC", want: 1 / 0.9},
+		{from: "USDC", to: "USD", want: 0.8},
+		{from: "SOL", to: "USDC", want: 187.5},
+		{from: "USD", to: "BONK", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from+"/"+tt.to, func(t *testing.T) {
+			got, err := coingecko.Rate(context.Background(), tt.from, tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Rate error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Rate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if apiKey != "cg-key" {
+		t.Errorf("x-cg-pro-api-key = %q, want cg-key", apiKey)
+	}
+}
+
+func TestPyth(t *testing.T) {
+	now := time.Now()
+	publishTime := now.Unix()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prices := map[string]string{pythFeeds["SOL"]: "15025000000", pythFeeds["USDC"]: "100000000", "abc123": "50000000"}
+		id := r.URL.Query().Get("ids[]")
+		fmt.Fprintf(w, `{"parsed": [{"id": %q, "price": {"price": %q, "expo": -8, "publish_time": %d}}]}`, id, prices[id], publishTime)
+	}))
+	defer server.Close()
+
+	pyth := &Pyth{BaseURL: server.URL, Feeds: map[string]string{"BONK": "0xabc123"}, Client: server.Client(), now: func() time.Time { return now }}
+	ctx := context.Background()
+	tests := []struct {
+		from, to string
+		want     float64
+		wantErr  bool
+	}{
+		{from: "USD", to: "SOL", want: 1 / 150.25},
+		{from: "SOL", to: "USDC", want: 150.25},
+		{from: "BONK", to: "USD", want: 0.5},
+		{from: "USD", to: "USDT", wantErr: true}, // Feed not served
+		{from: "USD", to: "PYUSD", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from+"/"+tt.to, func(t *testing.T) {
+			got, err := pyth.Rate(ctx, tt.from, tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Rate error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Rate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	publishTime = now.Add(-pythMaxAge - time.Second).Unix()
+	if _, err := pyth.Rate(ctx, "SOL", "USD"); !errors.Is(err, ErrNoRate) {
+		t.Errorf("Rate with a stale price error = %v, want ErrNoRate", err)
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	tests := []struct {
+		provider string
+		wantNil  bool
+		wantErr  bool
+	}{
+		{provider: "", wantNil: true},
+		{provider: "fixed"},
+		{provider: "coingecko"},
+		{provider: "pyth"},
+		{provider: "chainlink", wantNil: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			provider, err := NewProvider(config.RateOracleConfig{Provider: tt.provider})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewProvider error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (provider == nil) != tt.wantNil {
+				t.Errorf("NewProvider = %v, want nil %v", provider, tt.wantNil)
+			}
+		})
+	}
+}
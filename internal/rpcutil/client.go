@@ -0,0 +1,90 @@
+package rpcutil
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+	"github.com/klauspost/compress/gzhttp"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/CedrosPay/server/internal/tracing"
+)
+
+// NewClient creates a Solana RPC client whose calls are traced as "solana.rpc <method>" spans.
+// HTTP settings mirror rpc.New.
+func NewClient(rpcURL string) *rpc.Client {
+	return rpc.NewWithCustomRPCClient(newTracedRPCClient(rpcURL))
+}
+
+// newTracedRPCClient creates the traced JSON-RPC client behind NewClient.
+func newTracedRPCClient(rpcURL string) *tracedRPCClient {
+	transport := &http.Transport{
+		IdleConnTimeout:     5 * time.Minute,
+		MaxConnsPerHost:     9,
+		MaxIdleConnsPerHost: 9,
+		Proxy:               http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   5 * time.Minute,
+			KeepAlive: 180 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:   true,
+		TLSHandshakeTimeout: 10 * time.Second,
+	}
+	inner := jsonrpc.NewClientWithOpts(rpcURL, &jsonrpc.RPCClientOpts{
+		HTTPClient: &http.Client{Timeout: 5 * time.Minute, Transport: gzhttp.Transport(transport)},
+	})
+	return &tracedRPCClient{inner: inner}
+}
+
+// tracedRPCClient wraps a JSON-RPC client with a span per call.
+type tracedRPCClient struct {
+	inner rpc.JSONRPCClient
+}
+
+func (c *tracedRPCClient) start(ctx context.Context, method string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, "solana.rpc "+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.RPCSystemKey.String("jsonrpc"),
+			semconv.RPCService("solana"),
+			semconv.RPCMethod(method),
+		),
+	)
+}
+
+func (c *tracedRPCClient) CallForInto(ctx context.Context, out interface{}, method string, params []interface{}) error {
+	ctx, span := c.start(ctx, method)
+	err := c.inner.CallForInto(ctx, out, method, params)
+	tracing.End(span, err)
+	return err
+}
+
+func (c *tracedRPCClient) CallWithCallback(ctx context.Context, method string, params []interface{}, callback func(*http.Request, *http.Response) error) error {
+	ctx, span := c.start(ctx, method)
+	err := c.inner.CallWithCallback(ctx, method, params, callback)
+	tracing.End(span, err)
+	return err
+}
+
+func (c *tracedRPCClient) CallBatch(ctx context.Context, requests jsonrpc.RPCRequests) (jsonrpc.RPCResponses, error) {
+	ctx, span := c.start(ctx, "batch")
+	span.SetAttributes(attribute.Int("rpc.jsonrpc.batch_size", len(requests)))
+	resp, err := c.inner.CallBatch(ctx, requests)
+	tracing.End(span, err)
+	return resp, err
+}
+
+// Close releases the underlying client's idle connections.
+func (c *tracedRPCClient) Close() error {
+	if closer, ok := c.inner.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
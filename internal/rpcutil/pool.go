@@ -0,0 +1,360 @@
+package rpcutil
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+	"github.com/rs/zerolog"
+	"github.com/sony/gobreaker"
+
+	"github.com/CedrosPay/server/internal/circuitbreaker"
+	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/metrics"
+)
+
+const (
+	// healthCheckTimeout bounds a single getHealth probe.
+	healthCheckTimeout = 5 * time.Second
+
+	// latencyWeight is the weight of the newest sample in an endpoint's latency average.
+	latencyWeight = 0.3
+
+	// rpcErrNodeUnhealthy is the JSON-RPC error a node returns while it is behind the cluster.
+	rpcErrNodeUnhealthy = -32005
+)
+
+// PoolOptions configures a Pool.
+type PoolOptions struct {
+	HealthCheckInterval time.Duration                 // How often endpoints are probed with getHealth (0 = no background checks)
+	Breaker             *circuitbreaker.BreakerConfig // Optional: per-endpoint circuit breaker
+	Metrics             *metrics.Metrics              // Optional: per-endpoint request, health, and failover metrics
+}
+
+// Pool spreads Solana RPC calls over several endpoints. Each call goes to the healthy endpoint
+// with the lowest observed latency and moves on to the next one when an endpoint errors, times
+// out, or has its circuit breaker open. JSON-RPC errors a node returns (e.g. a failed
+// preflight) are answers rather than endpoint failures and are returned as-is.
+type Pool struct {
+	endpoints []*endpoint
+	opts      PoolOptions
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+	log       zerolog.Logger
+}
+
+type endpoint struct {
+	name    string // Host only, so API keys embedded in the URL stay out of logs and metrics; "#2", "#3", ... for repeated hosts
+	client  *tracedRPCClient
+	breaker *circuitbreaker.Breaker
+
+	mu      sync.Mutex
+	healthy bool
+	latency time.Duration // Moving average of successful requests
+}
+
+// NewPool creates a pool over rpcURLs, in order of preference until latencies are known, and
+// starts health checks when opts.HealthCheckInterval is set.
+func NewPool(rpcURLs []string, opts PoolOptions) (*Pool, error) {
+	if len(rpcURLs) == 0 {
+		return nil, errors.New("rpc pool: at least one endpoint required")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Pool{
+		opts:   opts,
+		ctx:    ctx,
+		cancel: cancel,
+		log: logger.FromContext(ctx).With().
+			Str("component", "rpc_pool").
+			Logger(),
+	}
+	hosts := make(map[string]int, len(rpcURLs))
+	for _, rpcURL := range rpcURLs {
+		name, err := endpointName(rpcURL)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		// Several keys at one provider share a host; each still gets its own breaker and metrics
+		hosts[name]++
+		if n := hosts[name]; n > 1 {
+			name = fmt.Sprintf("%s#%d", name, n)
+		}
+		ep := &endpoint{name: name, client: newTracedRPCClient(rpcURL), healthy: true}
+		if opts.Breaker != nil {
+			ep.breaker = circuitbreaker.NewBreaker("solana_rpc:"+name, *opts.Breaker, func(err error) bool {
+				return !isEndpointFailure(err)
+			})
+		}
+		p.endpoints = append(p.endpoints, ep)
+	}
+
+	if opts.HealthCheckInterval > 0 {
+		p.wg.Add(1)
+		go p.healthCheckLoop()
+	}
+	return p, nil
+}
+
+// Client returns an RPC client whose calls go through the pool.
+func (p *Pool) Client() *rpc.Client {
+	return rpc.NewWithCustomRPCClient(p)
+}
+
+// EndpointStatus is the observed state of one pool endpoint.
+type EndpointStatus struct {
+	Name      string  `json:"name"`      // Host only
+	Healthy   bool    `json:"healthy"`   // Last health check or request succeeded
+	LatencyMs float64 `json:"latencyMs"` // Moving average of successful requests
+	Breaker   string  `json:"breaker"`   // Circuit breaker state: closed, half-open, open, or disabled
+}
+
+// Endpoints reports the state of each endpoint, in configured order.
+func (p *Pool) Endpoints() []EndpointStatus {
+	statuses := make([]EndpointStatus, len(p.endpoints))
+	for i, ep := range p.endpoints {
+		ep.mu.Lock()
+		statuses[i] = EndpointStatus{
+			Name:      ep.name,
+			Healthy:   ep.healthy,
+			LatencyMs: float64(ep.latency) / float64(time.Millisecond),
+			Breaker:   "disabled",
+		}
+		ep.mu.Unlock()
+		if ep.breaker != nil {
+			statuses[i].Breaker = ep.breaker.State().String()
+		}
+	}
+	return statuses
+}
+
+// Breakers returns the endpoints' circuit breakers, in configured order; nil when they are
+// disabled.
+func (p *Pool) Breakers() []*circuitbreaker.Breaker {
+	var breakers []*circuitbreaker.Breaker
+	for _, ep := range p.endpoints {
+		if ep.breaker != nil {
+			breakers = append(breakers, ep.breaker)
+		}
+	}
+	return breakers
+}
+
+// Close stops health checks and releases idle connections.
+func (p *Pool) Close() error {
+	p.cancel()
+	p.wg.Wait()
+	for _, ep := range p.endpoints {
+		_ = ep.client.Close()
+	}
+	return nil
+}
+
+// CallForInto implements rpc.JSONRPCClient.
+func (p *Pool) CallForInto(ctx context.Context, out interface{}, method string, params []interface{}) error {
+	return p.do(ctx, method, func(client rpc.JSONRPCClient) error {
+		return client.CallForInto(ctx, out, method, params)
+	})
+}
+
+// CallWithCallback implements rpc.JSONRPCClient.
+func (p *Pool) CallWithCallback(ctx context.Context, method string, params []interface{}, callback func(*http.Request, *http.Response) error) error {
+	return p.do(ctx, method, func(client rpc.JSONRPCClient) error {
+		return client.CallWithCallback(ctx, method, params, callback)
+	})
+}
+
+// CallBatch implements rpc.JSONRPCClient.
+func (p *Pool) CallBatch(ctx context.Context, requests jsonrpc.RPCRequests) (jsonrpc.RPCResponses, error) {
+	var responses jsonrpc.RPCResponses
+	err := p.do(ctx, "batch", func(client rpc.JSONRPCClient) error {
+		var err error
+		responses, err = client.CallBatch(ctx, requests)
+		return err
+	})
+	return responses, err
+}
+
+// do runs call against each endpoint in order of preference until one answers.
+func (p *Pool) do(ctx context.Context, method string, call func(client rpc.JSONRPCClient) error) error {
+	var lastErr error
+	candidates := p.ordered()
+	for i, ep := range candidates {
+		start := time.Now()
+		err := ep.execute(call)
+		if errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) {
+			lastErr = fmt.Errorf("rpc endpoint %s: %w", ep.name, err)
+			continue
+		}
+		elapsed := time.Since(start)
+
+		failed := isEndpointFailure(err)
+		if p.opts.Metrics != nil {
+			var observed error
+			if failed {
+				observed = err
+			}
+			p.opts.Metrics.ObserveRPCEndpointRequest(ep.name, elapsed, observed)
+		}
+		if !failed {
+			ep.succeeded(elapsed)
+			return err
+		}
+
+		ep.setHealthy(false)
+		lastErr = err
+		if ctx.Err() != nil {
+			return err
+		}
+		if i < len(candidates)-1 {
+			if p.opts.Metrics != nil {
+				p.opts.Metrics.ObserveRPCFailover(ep.name)
+			}
+			log := logger.FromContext(ctx)
+			log.Warn().
+				Err(err).
+				Str("endpoint", ep.name).
+				Str("method", method).
+				Msg("rpc.endpoint_failover")
+		}
+	}
+	return lastErr
+}
+
+// ordered returns the endpoints to try: healthy ones fastest first, then unhealthy ones as a
+// last resort. Ties keep the configured order.
+func (p *Pool) ordered() []*endpoint {
+	type candidate struct {
+		ep      *endpoint
+		healthy bool
+		latency time.Duration
+	}
+	candidates := make([]candidate, len(p.endpoints))
+	for i, ep := range p.endpoints {
+		ep.mu.Lock()
+		candidates[i] = candidate{ep: ep, healthy: ep.healthy, latency: ep.latency}
+		ep.mu.Unlock()
+	}
+	slices.SortStableFunc(candidates, func(a, b candidate) int {
+		if a.healthy != b.healthy {
+			if a.healthy {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Compare(a.latency, b.latency)
+	})
+
+	ordered := make([]*endpoint, len(candidates))
+	for i, c := range candidates {
+		ordered[i] = c.ep
+	}
+	return ordered
+}
+
+// healthCheckLoop probes every endpoint until the pool is closed.
+func (p *Pool) healthCheckLoop() {
+	defer p.wg.Done()
+
+	p.checkAll()
+	ticker := time.NewTicker(p.opts.HealthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+			p.checkAll()
+		}
+	}
+}
+
+// checkAll calls getHealth on each endpoint, bypassing circuit breakers so an open endpoint's
+// recovery is still noticed.
+func (p *Pool) checkAll() {
+	for _, ep := range p.endpoints {
+		ctx, cancel := context.WithTimeout(p.ctx, healthCheckTimeout)
+		var out string
+		start := time.Now()
+		err := ep.client.CallForInto(ctx, &out, "getHealth", nil)
+		elapsed := time.Since(start)
+		cancel()
+		if p.ctx.Err() != nil {
+			return
+		}
+
+		healthy := err == nil
+		if healthy {
+			ep.succeeded(elapsed)
+		} else {
+			ep.setHealthy(false)
+		}
+		if p.opts.Metrics != nil {
+			p.opts.Metrics.ObserveRPCEndpointHealth(ep.name, healthy)
+		}
+		if !healthy {
+			p.log.Warn().Err(err).Str("endpoint", ep.name).Msg("rpc.endpoint_unhealthy")
+		}
+	}
+}
+
+// execute runs call against the endpoint through its circuit breaker, if any.
+func (ep *endpoint) execute(call func(client rpc.JSONRPCClient) error) error {
+	if ep.breaker == nil {
+		return call(ep.client)
+	}
+	_, err := ep.breaker.Execute(func() (interface{}, error) {
+		return nil, call(ep.client)
+	})
+	return err
+}
+
+// succeeded marks the endpoint healthy and folds elapsed into its latency average.
+func (ep *endpoint) succeeded(elapsed time.Duration) {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	ep.healthy = true
+	if ep.latency == 0 {
+		ep.latency = elapsed
+		return
+	}
+	ep.latency = time.Duration(latencyWeight*float64(elapsed) + (1-latencyWeight)*float64(ep.latency))
+}
+
+func (ep *endpoint) setHealthy(healthy bool) {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	ep.healthy = healthy
+}
+
+// isEndpointFailure reports whether err means the endpoint could not serve the request, as
+// opposed to the node answering with a JSON-RPC error or the caller giving up.
+func isEndpointFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var rpcErr *jsonrpc.RPCError
+	if errors.As(err, &rpcErr) {
+		return rpcErr.Code == rpcErrNodeUnhealthy
+	}
+	return true
+}
+
+// endpointName returns the host of rpcURL.
+func endpointName(rpcURL string) (string, error) {
+	u, err := url.Parse(rpcURL)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("rpc pool: invalid endpoint url %q", rpcURL)
+	}
+	return u.Host, nil
+}
@@ -0,0 +1,193 @@
+package rpcutil
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/CedrosPay/server/internal/circuitbreaker"
+)
+
+// fakeEndpoint answers with body while status is 200 and with a plain error page otherwise,
+// counting calls.
+func fakeEndpoint(t *testing.T, status *atomic.Int32, body string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		if code := int(status.Load()); code != http.StatusOK {
+			http.Error(w, http.StatusText(code), code)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestPoolFailover(t *testing.T) {
+	var primaryStatus, backupStatus atomic.Int32
+	primaryStatus.Store(http.StatusServiceUnavailable)
+	backupStatus.Store(http.StatusOK)
+	primary, primaryCalls := fakeEndpoint(t, &primaryStatus, `{"jsonrpc":"2.0","id":1,"result":1}`)
+	backup, backupCalls := fakeEndpoint(t, &backupStatus, `{"jsonrpc":"2.0","id":1,"result":42}`)
+
+	pool, err := NewPool([]string{primary.URL, backup.URL}, PoolOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	client := pool.Client()
+	ctx := context.Background()
+
+	slot, err := client.GetSlot(ctx, rpc.CommitmentConfirmed)
+	if err != nil || slot != 42 {
+		t.Fatalf("GetSlot = (%d, %v), want 42 from the backup", slot, err)
+	}
+	if primaryCalls.Load() != 1 || backupCalls.Load() != 1 {
+		t.Fatalf("calls = (%d, %d), want one each", primaryCalls.Load(), backupCalls.Load())
+	}
+
+	// The failed endpoint moves to the back until it answers again
+	if _, err := client.GetSlot(ctx, rpc.CommitmentConfirmed); err != nil {
+		t.Fatal(err)
+	}
+	if primaryCalls.Load() != 1 || backupCalls.Load() != 2 {
+		t.Errorf("calls = (%d, %d), want the backup to be tried first", primaryCalls.Load(), backupCalls.Load())
+	}
+
+	// Every endpoint down: the last error is returned
+	backupStatus.Store(http.StatusBadGateway)
+	if _, err := client.GetSlot(ctx, rpc.CommitmentConfirmed); err == nil {
+		t.Error("expected an error when every endpoint fails")
+	}
+}
+
+func TestPoolReturnsNodeErrors(t *testing.T) {
+	var ok atomic.Int32
+	ok.Store(http.StatusOK)
+	primary, _ := fakeEndpoint(t, &ok, `{"jsonrpc":"2.0","id":1,"error":{"code":-32002,"message":"Transaction simulation failed"}}`)
+	backup, backupCalls := fakeEndpoint(t, &ok, `{"jsonrpc":"2.0","id":1,"result":42}`)
+
+	pool, err := NewPool([]string{primary.URL, backup.URL}, PoolOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	if _, err := pool.Client().GetSlot(context.Background(), rpc.CommitmentConfirmed); err == nil {
+		t.Fatal("expected the node's JSON-RPC error")
+	}
+	if backupCalls.Load() != 0 {
+		t.Error("a JSON-RPC error is an answer and must not fail over")
+	}
+}
+
+func TestPoolCircuitBreaker(t *testing.T) {
+	var down, ok atomic.Int32
+	down.Store(http.StatusServiceUnavailable)
+	ok.Store(http.StatusOK)
+	primary, primaryCalls := fakeEndpoint(t, &down, `{"jsonrpc":"2.0","id":1,"result":1}`)
+	backup, _ := fakeEndpoint(t, &ok, `{"jsonrpc":"2.0","id":1,"result":42}`)
+
+	pool, err := NewPool([]string{primary.URL, backup.URL}, PoolOptions{
+		Breaker: &circuitbreaker.BreakerConfig{MaxRequests: 1, Timeout: time.Minute, ConsecutiveFailures: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	client := pool.Client()
+	ctx := context.Background()
+
+	if _, err := client.GetSlot(ctx, rpc.CommitmentConfirmed); err != nil {
+		t.Fatal(err)
+	}
+	// Even when the primary is preferred again, its open breaker skips it without a request
+	backupEndpoint := pool.endpoints[1]
+	backupEndpoint.setHealthy(false)
+	if _, err := client.GetSlot(ctx, rpc.CommitmentConfirmed); err != nil {
+		t.Fatal(err)
+	}
+	if primaryCalls.Load() != 1 {
+		t.Errorf("primary calls = %d, want 1 while its breaker is open", primaryCalls.Load())
+	}
+
+	statuses := pool.Endpoints()
+	if len(statuses) != 2 || statuses[0].Breaker != "open" || statuses[0].Healthy || statuses[1].Breaker != "closed" {
+		t.Errorf("Endpoints() = %+v, want the primary open and unhealthy, the backup closed", statuses)
+	}
+}
+
+func TestPoolHealthChecks(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusOK)
+	srv, calls := fakeEndpoint(t, &status, `{"jsonrpc":"2.0","id":1,"result":"ok"}`)
+
+	pool, err := NewPool([]string{srv.URL}, PoolOptions{HealthCheckInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for calls.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	_ = pool.Close()
+	if calls.Load() < 2 {
+		t.Fatalf("health checks = %d, want periodic probes", calls.Load())
+	}
+	if ep := pool.endpoints[0]; !ep.healthy || ep.latency == 0 {
+		t.Errorf("endpoint = healthy %v latency %v, want healthy with a measured latency", ep.healthy, ep.latency)
+	}
+}
+
+func TestPoolBreakersPerEndpointOnSharedHost(t *testing.T) {
+	// One provider host, two API keys: the revoked key's failures must not trip the other's breaker
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("api-key") == "revoked" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":42}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	pool, err := NewPool([]string{srv.URL + "/?api-key=revoked", srv.URL + "/?api-key=valid"}, PoolOptions{
+		Breaker: &circuitbreaker.BreakerConfig{MaxRequests: 1, Timeout: time.Minute, ConsecutiveFailures: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	if _, err := pool.Client().GetSlot(context.Background(), rpc.CommitmentConfirmed); err != nil {
+		t.Fatal(err)
+	}
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+	breakers := pool.Breakers()
+	if len(breakers) != 2 {
+		t.Fatalf("breakers = %d, want one per endpoint", len(breakers))
+	}
+	tests := []struct {
+		breaker   *circuitbreaker.Breaker
+		wantName  string
+		wantState string
+	}{
+		{breaker: breakers[0], wantName: "solana_rpc:" + host, wantState: "open"},
+		{breaker: breakers[1], wantName: "solana_rpc:" + host + "#2", wantState: "closed"},
+	}
+	for _, tt := range tests {
+		if got := tt.breaker.Status(); got.Name != tt.wantName || got.State != tt.wantState {
+			t.Errorf("breaker = %s %s, want %s %s", got.Name, got.State, tt.wantName, tt.wantState)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package screening
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const chainalysisURL = "https://public.chainalysis.com/api/v1"
+
+// Chainalysis is a Screener backed by the Chainalysis sanctions screening API, which lists the
+// sanctions designations of an address.
+type Chainalysis struct {
+	BaseURL string // Defaults to the public API
+	APIKey  string
+	Client  *http.Client
+}
+
+// Screen flags wallet when Chainalysis reports any sanctions identification for it.
+func (c *Chainalysis) Screen(ctx context.Context, wallet string) (Result, error) {
+	base := c.BaseURL
+	if base == "" {
+		base = chainalysisURL
+	}
+	endpoint := strings.TrimSuffix(base, "/") + "/address/" + url.PathEscape(wallet)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return Result{}, fmt.Errorf("screening: chainalysis request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-API-Key", c.APIKey)
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return Result{}, fmt.Errorf("screening: chainalysis: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Result{}, fmt.Errorf("screening: chainalysis: status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Identifications []struct {
+			Category string `json:"category"`
+			Name     string `json:"name"`
+		} `json:"identifications"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return Result{}, fmt.Errorf("screening: chainalysis: decode: %w", err)
+	}
+	if len(body.Identifications) == 0 {
+		return Result{}, nil
+	}
+	reasons := make([]string, 0, len(body.Identifications))
+	for _, id := range body.Identifications {
+		reasons = append(reasons, id.Name)
+	}
+	return Result{Flagged: true, Reason: strings.Join(reasons, "; ")}, nil
+}
@@ -0,0 +1,85 @@
+// Package screening checks wallet addresses against compliance lists, e.g. sanctions, before
+// the paywall grants a payer access or refunds a recipient.
+package screening
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/CedrosPay/server/internal/config"
+)
+
+// Result is the outcome of screening a wallet.
+type Result struct {
+	Flagged bool   // The wallet must not be paid or served
+	Reason  string // Why it was flagged, e.g. the sanctions list entry
+}
+
+// Screener checks wallets against a compliance list. Errors mean the wallet could not be
+// screened; what happens then is up to paywall.screening.fail_mode.
+type Screener interface {
+	Screen(ctx context.Context, wallet string) (Result, error)
+}
+
+// NewScreener builds the screener cfg selects, caching results for cfg.CacheTTL. Returns nil
+// when no provider is configured.
+func NewScreener(cfg config.ScreeningConfig) (Screener, error) {
+	client := &http.Client{Timeout: cfg.Timeout.Duration}
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case "chainalysis":
+		return NewCached(&Chainalysis{BaseURL: cfg.APIURL, APIKey: cfg.APIKey, Client: client}, cfg.CacheTTL.Duration), nil
+	default:
+		return nil, fmt.Errorf("screening: unknown provider %q", cfg.Provider)
+	}
+}
+
+// Cached reuses each wallet's result from a Screener for a TTL, so repeat payers don't each
+// call the provider. Failures are not cached.
+type Cached struct {
+	source Screener
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cachedResult
+}
+
+type cachedResult struct {
+	result     Result
+	screenedAt time.Time
+}
+
+// NewCached wraps source with a cache holding each result for ttl.
+func NewCached(source Screener, ttl time.Duration) *Cached {
+	return &Cached{source: source, ttl: ttl, now: time.Now, entries: make(map[string]cachedResult)}
+}
+
+// Screen returns the cached result for wallet, screening it when missing or older than the TTL.
+func (c *Cached) Screen(ctx context.Context, wallet string) (Result, error) {
+	now := c.now()
+	c.mu.Lock()
+	entry, ok := c.entries[wallet]
+	c.mu.Unlock()
+	if ok && now.Sub(entry.screenedAt) < c.ttl {
+		return entry.result, nil
+	}
+
+	result, err := c.source.Screen(ctx, wallet)
+	if err != nil {
+		return Result{}, err
+	}
+	c.mu.Lock()
+	for k, e := range c.entries {
+		if now.Sub(e.screenedAt) >= c.ttl {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[wallet] = cachedResult{result: result, screenedAt: now}
+	c.mu.Unlock()
+	return result, nil
+}
@@ -0,0 +1,110 @@
+package screening
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/CedrosPay/server/internal/config"
+)
+
+type countingScreener struct {
+	calls  int
+	result Result
+	err    error
+}
+
+func (s *countingScreener) Screen(context.Context, string) (Result, error) {
+	s.calls++
+	return s.result, s.err
+}
+
+func TestCached(t *testing.T) {
+	source := &countingScreener{}
+	cached := NewCached(source, time.Hour)
+	now := time.Now()
+	cached.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for range 3 {
+		if result, _ := cached.Screen(ctx, "wallet-1"); result.Flagged {
+			t.Fatalf("Screen = %+v, want clear", result)
+		}
+	}
+	if source.calls != 1 {
+		t.Errorf("source called %d times within the TTL, want 1", source.calls)
+	}
+
+	source.result = Result{Flagged: true, Reason: "sanctioned"}
+	now = now.Add(time.Hour)
+	if result, _ := cached.Screen(ctx, "wallet-1"); !result.Flagged || source.calls != 2 {
+		t.Errorf("Screen after TTL = %+v with %d calls, want flagged with 2", result, source.calls)
+	}
+
+	source.err = errors.New("provider down")
+	for range 2 {
+		if _, err := cached.Screen(ctx, "wallet-2"); err == nil {
+			t.Fatal("Screen error = nil, want the provider error")
+		}
+	}
+	if source.calls != 4 {
+		t.Errorf("source called %d times, want failures not to be cached", source.calls)
+	}
+}
+
+func TestChainalysis(t *testing.T) {
+	var apiKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiKey = r.Header.Get("X-API-Key")
+		switch r.URL.Path {
+		case "/address/sanctioned":
+			fmt.Fprint(w, `{"identifications": [{"category": "sanctions", "name": "SANCTIONS: OFAC SDN Example", "description": "", "url": ""}]}`)
+		case "/address/clear":
+			fmt.Fprint(w, `{"identifications": []}`)
+		default:
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	chainalysis := &Chainalysis{BaseURL: server.URL, APIKey: "ca-key", Client: server.Client()}
+	tests := []struct {
+		wallet  string
+		want    Result
+		wantErr bool
+	}{
+		{wallet: "sanctioned", want: Result{Flagged: true, Reason: "SANCTIONS: OFAC SDN Example"}},
+		{wallet: "clear"},
+		{wallet: "unknown", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.wallet, func(t *testing.T) {
+			got, err := chainalysis.Screen(context.Background(), tt.wallet)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Screen error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Screen = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+	if apiKey != "ca-key" {
+		t.Errorf("X-API-Key = %q, want ca-key", apiKey)
+	}
+}
+
+func TestNewScreener(t *testing.T) {
+	if s, err := NewScreener(config.ScreeningConfig{}); s != nil || err != nil {
+		t.Errorf("NewScreener(disabled) = %v, %v, want nil, nil", s, err)
+	}
+	if s, err := NewScreener(config.ScreeningConfig{Provider: "chainalysis", APIKey: "key"}); s == nil || err != nil {
+		t.Errorf("NewScreener(chainalysis) = %v, %v, want a screener", s, err)
+	}
+	if _, err := NewScreener(config.ScreeningConfig{Provider: "ofac"}); err == nil {
+		t.Error("NewScreener(unknown) error = nil, want an error")
+	}
+}
@@ -0,0 +1,262 @@
+// Package secrets resolves configuration values held in external secret stores.
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	vault "github.com/hashicorp/vault/api"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultVaultTimeout     = 10 * time.Second
+	defaultKubernetesJWT    = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	vaultAuthToken          = "token"
+	vaultAuthKubernetes     = "kubernetes"
+	vaultAuthAppRole        = "approle"
+	defaultKubernetesMount  = "kubernetes"
+	defaultAppRoleAuthMount = "approle"
+)
+
+// VaultOptions configures the Vault client. Empty Address and Token fall back to the
+// standard VAULT_ADDR and VAULT_TOKEN environment variables.
+type VaultOptions struct {
+	Address    string
+	Token      string
+	Namespace  string
+	AuthMethod string // "token" (default), "kubernetes", or "approle"
+	AuthMount  string // Auth backend mount path (default: the method name)
+	Role       string // Kubernetes auth role
+	RoleID     string // AppRole role_id
+	SecretID   string // AppRole secret_id
+	JWTPath    string // Kubernetes service account token (default: the in-cluster path)
+	Timeout    time.Duration
+}
+
+// Vault reads secrets from HashiCorp Vault and keeps its token and any leased secrets
+// renewed until Close. Each path is read once; later references reuse the response.
+type Vault struct {
+	client *vault.Client
+
+	mu       sync.Mutex
+	cache    map[string]*vault.Secret
+	watchers []*vault.LifetimeWatcher
+	closed   bool
+	done     chan struct{}
+}
+
+// NewVault connects to Vault, logs in with the configured auth method, and starts
+// renewing the resulting token when it is renewable.
+func NewVault(ctx context.Context, opts VaultOptions) (*Vault, error) {
+	cfg := vault.DefaultConfig()
+	if cfg.Error != nil {
+		return nil, fmt.Errorf("vault config: %w", cfg.Error)
+	}
+	if opts.Address != "" {
+		cfg.Address = opts.Address
+	}
+	cfg.Timeout = opts.Timeout
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultVaultTimeout
+	}
+
+	client, err := vault.NewClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("vault client: %w", err)
+	}
+	if opts.Namespace != "" {
+		client.SetNamespace(opts.Namespace)
+	}
+
+	v := &Vault{client: client, cache: make(map[string]*vault.Secret), done: make(chan struct{})}
+	auth, err := v.login(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if auth != nil {
+		v.watch("token", auth)
+	}
+	return v, nil
+}
+
+// login authenticates the client and returns the secret to renew, if any.
+func (v *Vault) login(ctx context.Context, opts VaultOptions) (*vault.Secret, error) {
+	var path string
+	var data map[string]interface{}
+
+	switch method := strings.ToLower(opts.AuthMethod); method {
+	case "", vaultAuthToken:
+		if opts.Token != "" {
+			v.client.SetToken(opts.Token)
+		}
+		if v.client.Token() == "" {
+			return nil, errors.New("vault: no token configured (set secrets.vault.token or VAULT_TOKEN)")
+		}
+		self, err := v.client.Auth().Token().LookupSelfWithContext(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("vault: look up token: %w", err)
+		}
+		renewable, _ := self.TokenIsRenewable()
+		if !renewable {
+			return nil, nil
+		}
+		ttl, _ := self.TokenTTL()
+		return &vault.Secret{Auth: &vault.SecretAuth{
+			ClientToken:   v.client.Token(),
+			Renewable:     true,
+			LeaseDuration: int(ttl.Seconds()),
+		}}, nil
+	case vaultAuthKubernetes:
+		jwtPath := opts.JWTPath
+		if jwtPath == "" {
+			jwtPath = defaultKubernetesJWT
+		}
+		jwt, err := os.ReadFile(jwtPath)
+		if err != nil {
+			return nil, fmt.Errorf("vault: read service account token: %w", err)
+		}
+		path = loginPath(opts.AuthMount, defaultKubernetesMount)
+		data = map[string]interface{}{"role": opts.Role, "jwt": strings.TrimSpace(string(jwt))}
+	case vaultAuthAppRole:
+		path = loginPath(opts.AuthMount, defaultAppRoleAuthMount)
+		data = map[string]interface{}{"role_id": opts.RoleID, "secret_id": opts.SecretID}
+	default:
+		return nil, fmt.Errorf("vault: unsupported auth method %q", method)
+	}
+
+	secret, err := v.client.Logical().WriteWithContext(ctx, path, data)
+	if err != nil {
+		return nil, fmt.Errorf("vault: login via %s: %w", path, err)
+	}
+	if secret == nil || secret.Auth == nil || secret.Auth.ClientToken == "" {
+		return nil, fmt.Errorf("vault: login via %s returned no token", path)
+	}
+	v.client.SetToken(secret.Auth.ClientToken)
+	if !secret.Auth.Renewable {
+		return nil, nil
+	}
+	return secret, nil
+}
+
+func loginPath(mount, fallback string) string {
+	mount = strings.Trim(mount, "/")
+	if mount == "" {
+		mount = fallback
+	}
+	return "auth/" + mount + "/login"
+}
+
+// Resolve returns one field of a secret, addressed as "path#field". For KV v2 mounts the
+// path includes "data/" (secret/data/cedros) and fields are looked up in the nested data.
+func (v *Vault) Resolve(ctx context.Context, ref string) (string, error) {
+	path, field, ok := strings.Cut(ref, "#")
+	path = strings.Trim(path, "/")
+	if !ok || path == "" || field == "" {
+		return "", fmt.Errorf("vault reference %q must look like path#field", ref)
+	}
+
+	secret, err := v.read(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	value, ok := secret.Data[field]
+	if !ok {
+		if nested, isKV2 := secret.Data["data"].(map[string]interface{}); isKV2 {
+			value, ok = nested[field]
+		}
+	}
+	if !ok || value == nil {
+		return "", fmt.Errorf("vault secret %s has no field %q", path, field)
+	}
+	if s, isString := value.(string); isString {
+		return s, nil
+	}
+	return fmt.Sprint(value), nil
+}
+
+func (v *Vault) read(ctx context.Context, path string) (*vault.Secret, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if secret, ok := v.cache[path]; ok {
+		return secret, nil
+	}
+
+	secret, err := v.client.Logical().ReadWithContext(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("vault: read %s: %w", path, err)
+	}
+	if secret == nil {
+		return nil, fmt.Errorf("vault: no secret at %s", path)
+	}
+	v.cache[path] = secret
+	if secret.LeaseID != "" && secret.Renewable {
+		v.watchLocked(path, secret)
+	}
+	return secret, nil
+}
+
+func (v *Vault) watch(name string, secret *vault.Secret) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.watchLocked(name, secret)
+}
+
+// watchLocked renews secret in the background until its max TTL or Close. Values already
+// handed out are not re-read: once a lease can no longer be renewed the process must be
+// restarted (or reloaded) to pick up fresh credentials, so that is logged loudly.
+func (v *Vault) watchLocked(name string, secret *vault.Secret) {
+	if v.closed {
+		return
+	}
+	watcher, err := v.client.NewLifetimeWatcher(&vault.LifetimeWatcherInput{Secret: secret})
+	if err != nil {
+		log.Warn().Err(err).Str("secret", name).Msg("vault.renew_setup_failed")
+		return
+	}
+	v.watchers = append(v.watchers, watcher)
+
+	go watcher.Start()
+	go func() {
+		for {
+			select {
+			case err := <-watcher.DoneCh():
+				select {
+				case <-v.done:
+					return // Stopped by Close
+				default:
+				}
+				if err != nil {
+					log.Warn().Err(err).Str("secret", name).Msg("vault.renew_failed")
+				} else {
+					log.Warn().Str("secret", name).Msg("vault.lease_expiring")
+				}
+				return
+			case renewal := <-watcher.RenewCh():
+				log.Debug().Str("secret", name).Time("renewed_at", renewal.RenewedAt).Msg("vault.renewed")
+			}
+		}
+	}()
+}
+
+// Close stops lease renewal. Leases are left to expire rather than revoked, since the
+// values read from them may still be in use by connections that outlive the process.
+func (v *Vault) Close() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.closed {
+		return nil
+	}
+	v.closed = true
+	close(v.done)
+	for _, w := range v.watchers {
+		w.Stop()
+	}
+	v.watchers = nil
+	return nil
+}
@@ -0,0 +1,140 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// fakeVault serves just enough of the Vault HTTP API for token and AppRole auth, a KV v2
+// secret, and a leased dynamic secret.
+func fakeVault(t *testing.T, reads *atomic.Int32) *httptest.Server {
+	t.Helper()
+	respond := func(w http.ResponseWriter, body string) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/auth/approle/login" {
+			var body map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if body["role_id"] != "role" || body["secret_id"] != "secret" {
+				http.Error(w, `{"errors":["invalid role or secret ID"]}`, http.StatusBadRequest)
+				return
+			}
+			respond(w, `{"auth":{"client_token":"approle-token","renewable":false}}`)
+			return
+		}
+
+		token := r.Header.Get("X-Vault-Token")
+		if token != "root" && token != "approle-token" {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/auth/token/lookup-self":
+			respond(w, `{"data":{"renewable":false,"ttl":0}}`)
+		case "/v1/secret/data/cedros":
+			reads.Add(1)
+			respond(w, `{"data":{"data":{"stripe_secret_key":"sk_test_vault","port":8443},"metadata":{"version":3}}}`)
+		case "/v1/database/creds/app":
+			reads.Add(1)
+			respond(w, `{"lease_id":"database/creds/app/abc","renewable":true,"lease_duration":3600,"data":{"password":"leased"}}`)
+		default:
+			http.Error(w, `{"errors":[]}`, http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestVaultResolve(t *testing.T) {
+	var reads atomic.Int32
+	srv := fakeVault(t, &reads)
+
+	v, err := NewVault(context.Background(), VaultOptions{Address: srv.URL, Token: "root"})
+	if err != nil {
+		t.Fatalf("NewVault: %v", err)
+	}
+	defer v.Close()
+
+	tests := []struct {
+		ref     string
+		want    string
+		wantErr string
+	}{
+		{ref: "secret/data/cedros#stripe_secret_key", want: "sk_test_vault"},
+		{ref: "/secret/data/cedros#port", want: "8443"},
+		{ref: "database/creds/app#password", want: "leased"},
+		{ref: "secret/data/cedros#missing", wantErr: `no field "missing"`},
+		{ref: "secret/data/other#key", wantErr: "no secret at secret/data/other"},
+		{ref: "secret/data/cedros", wantErr: "path#field"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := v.Resolve(context.Background(), tt.ref)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("value = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if got := reads.Load(); got != 2 {
+		t.Errorf("vault reads = %d, want 2 (one per path)", got)
+	}
+	if len(v.watchers) != 1 {
+		t.Errorf("watchers = %d, want 1 for the leased secret", len(v.watchers))
+	}
+}
+
+func TestVaultAuth(t *testing.T) {
+	var reads atomic.Int32
+	srv := fakeVault(t, &reads)
+	t.Setenv("VAULT_TOKEN", "")
+
+	tests := []struct {
+		name    string
+		opts    VaultOptions
+		wantErr string
+	}{
+		{name: "token", opts: VaultOptions{Token: "root"}},
+		{name: "bad token", opts: VaultOptions{Token: "nope"}, wantErr: "permission denied"},
+		{name: "no token", opts: VaultOptions{}, wantErr: "no token configured"},
+		{name: "approle", opts: VaultOptions{AuthMethod: "approle", RoleID: "role", SecretID: "secret"}},
+		{name: "approle rejected", opts: VaultOptions{AuthMethod: "approle", RoleID: "role", SecretID: "wrong"}, wantErr: "invalid role or secret ID"},
+		{name: "kubernetes without token file", opts: VaultOptions{AuthMethod: "kubernetes", JWTPath: "/nonexistent"}, wantErr: "service account token"},
+		{name: "unknown method", opts: VaultOptions{AuthMethod: "ldap"}, wantErr: "unsupported auth method"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Address = srv.URL
+			v, err := NewVault(context.Background(), tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewVault: %v", err)
+			}
+			defer v.Close()
+			if _, err := v.Resolve(context.Background(), "secret/data/cedros#stripe_secret_key"); err != nil {
+				t.Fatalf("Resolve after login: %v", err)
+			}
+		})
+	}
+}
@@ -12,7 +12,6 @@ import (
 	"github.com/gagliardetto/solana-go"
 	associatedtokenaccount "github.com/gagliardetto/solana-go/programs/associated-token-account"
 	"github.com/gagliardetto/solana-go/rpc"
-	"github.com/gagliardetto/solana-go/rpc/ws"
 )
 
 // ParsePrivateKey parses a Solana private key from either base58 or JSON array format.
@@ -75,8 +74,8 @@ func parsePrivateKeyArray(keyStr string) (solana.PrivateKey, error) {
 
 // CreateAssociatedTokenAccount creates an associated token account for the given owner and mint.
 // This is useful when a merchant's wallet doesn't have a token account initialized yet.
-// It waits for the transaction to be confirmed before returning.
-func CreateAssociatedTokenAccount(ctx context.Context, rpcClient *rpc.Client, wsClient *ws.Client, payer solana.PrivateKey, owner solana.PublicKey, mint solana.PublicKey) (solana.PublicKey, error) {
+// It waits for confirm to report the transaction confirmed before returning.
+func CreateAssociatedTokenAccount(ctx context.Context, rpcClient *rpc.Client, confirm func(ctx context.Context, signature solana.Signature) error, payer Signer, owner solana.PublicKey, mint solana.PublicKey) (solana.PublicKey, error) {
 	// Derive the associated token account address
 	ata, _, err := solana.FindAssociatedTokenAddress(owner, mint)
 	if err != nil {
@@ -116,13 +115,7 @@ func CreateAssociatedTokenAccount(ctx context.Context, rpcClient *rpc.Client, ws
 	}
 
 	// Sign transaction
-	_, err = tx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
-		if key.Equals(payer.PublicKey()) {
-			return &payer
-		}
-		return nil
-	})
-	if err != nil {
+	if err := SignTransaction(ctx, tx, payer); err != nil {
 		return solana.PublicKey{}, fmt.Errorf("sign transaction: %w", err)
 	}
 
@@ -138,34 +131,13 @@ func CreateAssociatedTokenAccount(ctx context.Context, rpcClient *rpc.Client, ws
 		Str("signature", logger.TruncateAddress(sig.String())).
 		Msg("token_account.waiting_for_confirmation")
 
-	// Subscribe to transaction confirmation via WebSocket
-	sub, err := wsClient.SignatureSubscribe(sig, rpc.CommitmentConfirmed)
-	if err != nil {
-		return solana.PublicKey{}, fmt.Errorf("subscribe to confirmation: %w", err)
-	}
-	defer sub.Unsubscribe()
-
 	// Wait for confirmation with timeout
 	confirmCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
-
-	for {
-		select {
-		case <-confirmCtx.Done():
-			return solana.PublicKey{}, fmt.Errorf("confirmation timeout: %w", confirmCtx.Err())
-		case result := <-sub.Response():
-			if result.Value.Err != nil {
-				return solana.PublicKey{}, fmt.Errorf("transaction failed: %v", result.Value.Err)
-			}
-			// Transaction confirmed successfully
-			goto confirmed
-		case err := <-sub.Err():
-			return solana.PublicKey{}, fmt.Errorf("subscription error: %w", err)
-		}
+	if err := confirm(confirmCtx, sig); err != nil {
+		return solana.PublicKey{}, fmt.Errorf("confirm transaction: %w", err)
 	}
 
-confirmed:
-
 	log.Info().
 		Str("ata", logger.TruncateAddress(ata.String())).
 		Str("signature", logger.TruncateAddress(sig.String())).
@@ -0,0 +1,159 @@
+package solana
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/gagliardetto/solana-go"
+
+	"github.com/CedrosPay/server/internal/httputil"
+)
+
+const (
+	kmsRequestTimeout   = 10 * time.Second
+	awsKMSSigningScheme = "ED25519_SHA_512"
+)
+
+// AWSKMSSigner signs with an AWS KMS ECC_NIST_EDWARDS25519 key. KMS's JSON protocol is
+// small enough that requests are signed with SigV4 directly rather than adding the KMS
+// SDK module; credentials come from the default chain (env, shared config, IAM role).
+type AWSKMSSigner struct {
+	keyID      string
+	region     string
+	endpoint   string
+	creds      aws.CredentialsProvider
+	signer     *v4.Signer
+	httpClient *http.Client
+	publicKey  solana.PublicKey
+}
+
+// NewAWSKMSSigner loads AWS credentials and fetches the key's public key. The region is
+// taken from keyID when it is an ARN, otherwise from the default AWS config.
+func NewAWSKMSSigner(ctx context.Context, keyID string) (*AWSKMSSigner, error) {
+	if keyID == "" {
+		return nil, errors.New("aws kms: key id required")
+	}
+	var loadOpts []func(*awsconfig.LoadOptions) error
+	if region := arnRegion(keyID); region != "" {
+		loadOpts = append(loadOpts, awsconfig.WithRegion(region))
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, loadOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("aws kms: load aws config: %w", err)
+	}
+	if cfg.Region == "" {
+		return nil, fmt.Errorf("aws kms: no region for key %s (use a key ARN or set AWS_REGION)", keyID)
+	}
+
+	s := &AWSKMSSigner{
+		keyID:      keyID,
+		region:     cfg.Region,
+		endpoint:   fmt.Sprintf("https://kms.%s.amazonaws.com/", cfg.Region),
+		creds:      cfg.Credentials,
+		signer:     v4.NewSigner(),
+		httpClient: httputil.NewClient(kmsRequestTimeout),
+	}
+	if cfg.BaseEndpoint != nil {
+		s.endpoint = *cfg.BaseEndpoint // AWS_ENDPOINT_URL, e.g. LocalStack
+	}
+
+	var resp struct {
+		PublicKey []byte `json:"PublicKey"`
+		KeySpec   string `json:"KeySpec"`
+	}
+	if err := s.call(ctx, "GetPublicKey", map[string]any{"KeyId": keyID}, &resp); err != nil {
+		return nil, err
+	}
+	if s.publicKey, err = ed25519PublicKey(resp.PublicKey); err != nil {
+		return nil, fmt.Errorf("aws kms: key %s (%s): %w", keyID, resp.KeySpec, err)
+	}
+	return s, nil
+}
+
+// arnRegion extracts the region from "arn:aws:kms:<region>:<account>:key/<id>".
+func arnRegion(keyID string) string {
+	parts := strings.SplitN(keyID, ":", 5)
+	if len(parts) == 5 && parts[0] == "arn" && parts[2] == "kms" {
+		return parts[3]
+	}
+	return ""
+}
+
+// PublicKey returns the wallet address.
+func (s *AWSKMSSigner) PublicKey() solana.PublicKey {
+	return s.publicKey
+}
+
+// Sign has KMS sign message (pure Ed25519 over the raw message, as Solana requires).
+func (s *AWSKMSSigner) Sign(ctx context.Context, message []byte) (solana.Signature, error) {
+	var resp struct {
+		Signature []byte `json:"Signature"`
+	}
+	if err := s.call(ctx, "Sign", map[string]any{
+		"KeyId":            s.keyID,
+		"Message":          message, // []byte marshals as base64, as KMS expects
+		"MessageType":      "RAW",
+		"SigningAlgorithm": awsKMSSigningScheme,
+	}, &resp); err != nil {
+		return solana.Signature{}, err
+	}
+	return checkSignature(s.publicKey, message, resp.Signature)
+}
+
+func (s *AWSKMSSigner) call(ctx context.Context, action string, input, output any) error {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Errorf("aws kms: encode %s: %w", action, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("aws kms: build %s request: %w", action, err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "TrentService."+action)
+
+	creds, err := s.creds.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("aws kms: retrieve credentials: %w", err)
+	}
+	sum := sha256.Sum256(body)
+	if err := s.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(sum[:]), "kms", s.region, time.Now()); err != nil {
+		return fmt.Errorf("aws kms: sign %s request: %w", action, err)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("aws kms: %s: %w", action, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("aws kms: read %s response: %w", action, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &apiErr) != nil || apiErr.Type == "" {
+			apiErr.Type = resp.Status
+		}
+		return fmt.Errorf("aws kms: %s %s: %s %s", action, s.keyID, apiErr.Type, apiErr.Message)
+	}
+	if err := json.Unmarshal(data, output); err != nil {
+		return fmt.Errorf("aws kms: decode %s response: %w", action, err)
+	}
+	return nil
+}
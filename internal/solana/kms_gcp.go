@@ -0,0 +1,126 @@
+package solana
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gagliardetto/solana-go"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+
+	"github.com/CedrosPay/server/internal/httputil"
+)
+
+const (
+	gcpKMSScope    = "https://www.googleapis.com/auth/cloudkms"
+	gcpKMSEndpoint = "https://cloudkms.googleapis.com"
+)
+
+// GCPKMSSigner signs with a Cloud KMS EC_SIGN_ED25519 key version over the REST API,
+// authenticating with Application Default Credentials.
+type GCPKMSSigner struct {
+	name       string // projects/<p>/locations/<l>/keyRings/<r>/cryptoKeys/<k>/cryptoKeyVersions/<v>
+	endpoint   string
+	httpClient *http.Client
+	publicKey  solana.PublicKey
+}
+
+// NewGCPKMSSigner loads Application Default Credentials and fetches the key version's
+// public key.
+func NewGCPKMSSigner(ctx context.Context, name string) (*GCPKMSSigner, error) {
+	if name == "" {
+		return nil, errors.New("gcp kms: key version name required")
+	}
+	creds, err := google.FindDefaultCredentials(ctx, gcpKMSScope)
+	if err != nil {
+		return nil, fmt.Errorf("gcp kms: load credentials: %w", err)
+	}
+	// Token refreshes reuse the pooled base client
+	tokenCtx := context.WithValue(context.Background(), oauth2.HTTPClient, httputil.NewClient(kmsRequestTimeout))
+	return newGCPKMSSigner(ctx, name, gcpKMSEndpoint, oauth2.NewClient(tokenCtx, creds.TokenSource))
+}
+
+func newGCPKMSSigner(ctx context.Context, name, endpoint string, httpClient *http.Client) (*GCPKMSSigner, error) {
+	s := &GCPKMSSigner{name: name, endpoint: endpoint, httpClient: httpClient}
+
+	var resp struct {
+		PEM       string `json:"pem"`
+		Algorithm string `json:"algorithm"`
+	}
+	if err := s.call(ctx, http.MethodGet, "/publicKey", nil, &resp); err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode([]byte(resp.PEM))
+	if block == nil {
+		return nil, fmt.Errorf("gcp kms: key %s returned no PEM public key", name)
+	}
+	publicKey, err := ed25519PublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("gcp kms: key %s (%s): %w", name, resp.Algorithm, err)
+	}
+	s.publicKey = publicKey
+	return s, nil
+}
+
+// PublicKey returns the wallet address.
+func (s *GCPKMSSigner) PublicKey() solana.PublicKey {
+	return s.publicKey
+}
+
+// Sign has Cloud KMS sign message. Ed25519 keys sign the raw data rather than a digest.
+func (s *GCPKMSSigner) Sign(ctx context.Context, message []byte) (solana.Signature, error) {
+	var resp struct {
+		Signature []byte `json:"signature"`
+	}
+	if err := s.call(ctx, http.MethodPost, ":asymmetricSign", map[string]any{"data": message}, &resp); err != nil {
+		return solana.Signature{}, err
+	}
+	return checkSignature(s.publicKey, message, resp.Signature)
+}
+
+func (s *GCPKMSSigner) call(ctx context.Context, method, suffix string, input, output any) error {
+	var body io.Reader
+	if input != nil {
+		data, err := json.Marshal(input)
+		if err != nil {
+			return fmt.Errorf("gcp kms: encode request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.endpoint+"/v1/"+s.name+suffix, body)
+	if err != nil {
+		return fmt.Errorf("gcp kms: build request: %w", err)
+	}
+	if input != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("gcp kms: %s%s: %w", s.name, suffix, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("gcp kms: read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		_ = json.Unmarshal(data, &apiErr)
+		return fmt.Errorf("gcp kms: %s%s: %s %s", s.name, suffix, resp.Status, apiErr.Error.Message)
+	}
+	if err := json.Unmarshal(data, output); err != nil {
+		return fmt.Errorf("gcp kms: decode response: %w", err)
+	}
+	return nil
+}
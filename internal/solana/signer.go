@@ -0,0 +1,135 @@
+package solana
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"fmt"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// Signer signs transaction messages on behalf of a server wallet. The private key may live
+// in process (LocalSigner) or stay inside a cloud KMS that only returns signatures.
+type Signer interface {
+	PublicKey() solana.PublicKey
+	Sign(ctx context.Context, message []byte) (solana.Signature, error)
+}
+
+// Server wallet key prefixes selecting a KMS-held key instead of a raw private key.
+const (
+	awsKMSPrefix = "awskms:"
+	gcpKMSPrefix = "gcpkms:"
+)
+
+// ParseSigner builds a signer from a server wallet key. Supported formats:
+//   - "awskms:<key ID, alias, or ARN>" - AWS KMS ECC_NIST_EDWARDS25519 key
+//   - "gcpkms:projects/.../cryptoKeyVersions/N" - GCP Cloud KMS EC_SIGN_ED25519 key version
+//   - anything else is parsed as a private key (see ParsePrivateKey)
+//
+// KMS signers fetch their public key up front, so a missing key or permission fails here.
+func ParseSigner(ctx context.Context, key string) (Signer, error) {
+	key = strings.TrimSpace(key)
+	switch {
+	case strings.HasPrefix(key, awsKMSPrefix):
+		return NewAWSKMSSigner(ctx, strings.TrimPrefix(key, awsKMSPrefix))
+	case strings.HasPrefix(key, gcpKMSPrefix):
+		return NewGCPKMSSigner(ctx, strings.TrimPrefix(key, gcpKMSPrefix))
+	default:
+		privateKey, err := ParsePrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		return NewLocalSigner(privateKey), nil
+	}
+}
+
+// ParseSigners parses each server wallet key with ParseSigner.
+func ParseSigners(ctx context.Context, keys []string) ([]Signer, error) {
+	signers := make([]Signer, 0, len(keys))
+	for i, key := range keys {
+		signer, err := ParseSigner(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("server wallet %d: %w", i+1, err)
+		}
+		signers = append(signers, signer)
+	}
+	return signers, nil
+}
+
+// LocalSigner signs with a private key held in process memory.
+type LocalSigner struct {
+	key solana.PrivateKey
+}
+
+// NewLocalSigner wraps a private key.
+func NewLocalSigner(key solana.PrivateKey) *LocalSigner {
+	return &LocalSigner{key: key}
+}
+
+// PublicKey returns the wallet address.
+func (s *LocalSigner) PublicKey() solana.PublicKey {
+	return s.key.PublicKey()
+}
+
+// Sign signs message with the private key.
+func (s *LocalSigner) Sign(_ context.Context, message []byte) (solana.Signature, error) {
+	return s.key.Sign(message)
+}
+
+// SignTransaction adds a signature from each signer that is a required signer of tx,
+// leaving signatures already present (e.g. the user's, for gasless payments) in place.
+func SignTransaction(ctx context.Context, tx *solana.Transaction, signers ...Signer) error {
+	message, err := tx.Message.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("encode message for signing: %w", err)
+	}
+
+	required := int(tx.Message.Header.NumRequiredSignatures)
+	if required > len(tx.Message.AccountKeys) {
+		return fmt.Errorf("transaction requires %d signatures but has %d account keys", required, len(tx.Message.AccountKeys))
+	}
+	if len(tx.Signatures) == 0 {
+		tx.Signatures = make([]solana.Signature, required)
+	} else if len(tx.Signatures) != required {
+		return fmt.Errorf("invalid signatures length, expected %d, actual %d", required, len(tx.Signatures))
+	}
+
+	for _, signer := range signers {
+		pubkey := signer.PublicKey()
+		for i, key := range tx.Message.AccountKeys[:required] {
+			if !key.Equals(pubkey) {
+				continue
+			}
+			signature, err := signer.Sign(ctx, message)
+			if err != nil {
+				return fmt.Errorf("sign with %s: %w", pubkey, err)
+			}
+			tx.Signatures[i] = signature
+		}
+	}
+	return nil
+}
+
+// ed25519PublicKey decodes a DER SubjectPublicKeyInfo, as returned by both KMS providers.
+func ed25519PublicKey(der []byte) (solana.PublicKey, error) {
+	parsed, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("parse public key: %w", err)
+	}
+	key, ok := parsed.(ed25519.PublicKey)
+	if !ok {
+		return solana.PublicKey{}, fmt.Errorf("key is %T, not ed25519", parsed)
+	}
+	return solana.PublicKeyFromBytes(key), nil
+}
+
+// checkSignature guards against a misconfigured KMS key (wrong algorithm or message type)
+// producing signatures the network would reject with an unhelpful error.
+func checkSignature(pubkey solana.PublicKey, message, signature []byte) (solana.Signature, error) {
+	if len(signature) != ed25519.SignatureSize || !ed25519.Verify(pubkey[:], message, signature) {
+		return solana.Signature{}, fmt.Errorf("kms returned an invalid ed25519 signature for %s", pubkey)
+	}
+	return solana.SignatureFromBytes(signature), nil
+}
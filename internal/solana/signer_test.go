@@ -0,0 +1,208 @@
+package solana
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/memo"
+)
+
+func TestSignTransaction(t *testing.T) {
+	server := solana.NewWallet().PrivateKey
+	user := solana.NewWallet().PrivateKey
+
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{memo.NewMemoInstruction([]byte("cedros"), user.PublicKey()).Build()},
+		solana.Hash{1},
+		solana.TransactionPayer(server.PublicKey()),
+	)
+	if err != nil {
+		t.Fatalf("NewTransaction: %v", err)
+	}
+
+	// User signs first, as in the gasless flow; the server's signature must not disturb it
+	if _, err := tx.PartialSign(func(key solana.PublicKey) *solana.PrivateKey {
+		if key.Equals(user.PublicKey()) {
+			return &user
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("PartialSign: %v", err)
+	}
+	if err := SignTransaction(context.Background(), tx, NewLocalSigner(server)); err != nil {
+		t.Fatalf("SignTransaction: %v", err)
+	}
+	if err := tx.VerifySignatures(); err != nil {
+		t.Fatalf("VerifySignatures: %v", err)
+	}
+}
+
+// fakeKMS signs with key the way both providers do, optionally corrupting signatures.
+type fakeKMS struct {
+	key     ed25519.PrivateKey
+	corrupt bool
+}
+
+func newFakeKMS(t *testing.T) *fakeKMS {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &fakeKMS{key: key}
+}
+
+func (f *fakeKMS) publicKeyDER(t *testing.T) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(f.key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+func (f *fakeKMS) sign(message []byte) []byte {
+	sig := ed25519.Sign(f.key, message)
+	if f.corrupt {
+		sig[0] ^= 0xff
+	}
+	return sig
+}
+
+func (f *fakeKMS) address() solana.PublicKey {
+	return solana.PublicKeyFromBytes(f.key.Public().(ed25519.PublicKey))
+}
+
+func TestAWSKMSSigner(t *testing.T) {
+	kms := newFakeKMS(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDTEST/") ||
+			!strings.Contains(r.Header.Get("Authorization"), "/us-east-2/kms/aws4_request") {
+			http.Error(w, `{"__type":"UnrecognizedClientException","message":"bad signature"}`, http.StatusBadRequest)
+			return
+		}
+		var req struct {
+			KeyID            string `json:"KeyId"`
+			Message          []byte `json:"Message"`
+			MessageType      string `json:"MessageType"`
+			SigningAlgorithm string `json:"SigningAlgorithm"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if !strings.HasSuffix(req.KeyID, "key/1234") {
+			http.Error(w, `{"__type":"NotFoundException","message":"key not found"}`, http.StatusBadRequest)
+			return
+		}
+		switch r.Header.Get("X-Amz-Target") {
+		case "TrentService.GetPublicKey":
+			_ = json.NewEncoder(w).Encode(map[string]any{"PublicKey": kms.publicKeyDER(t), "KeySpec": "ECC_NIST_EDWARDS25519"})
+		case "TrentService.Sign":
+			if req.MessageType != "RAW" || req.SigningAlgorithm != "ED25519_SHA_512" {
+				http.Error(w, `{"__type":"ValidationException"}`, http.StatusBadRequest)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"Signature": kms.sign(req.Message)})
+		default:
+			http.Error(w, `{"__type":"UnknownOperationException"}`, http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDTEST")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_ENDPOINT_URL", srv.URL)
+	t.Setenv("AWS_CONFIG_FILE", "/nonexistent")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/nonexistent")
+
+	ctx := context.Background()
+	signer, err := ParseSigner(ctx, "awskms:arn:aws:kms:us-east-2:111122223333:key/1234")
+	if err != nil {
+		t.Fatalf("ParseSigner: %v", err)
+	}
+	testKMSSigner(t, signer, kms)
+
+	if _, err := ParseSigner(ctx, "awskms:arn:aws:kms:us-east-2:111122223333:key/missing"); err == nil || !strings.Contains(err.Error(), "NotFoundException") {
+		t.Errorf("missing key error = %v", err)
+	}
+}
+
+func TestGCPKMSSigner(t *testing.T) {
+	const name = "projects/p/locations/global/keyRings/cedros/cryptoKeys/wallet/cryptoKeyVersions/1"
+	kms := newFakeKMS(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/" + name + "/publicKey":
+			block := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: kms.publicKeyDER(t)})
+			_ = json.NewEncoder(w).Encode(map[string]string{"pem": string(block), "algorithm": "EC_SIGN_ED25519"})
+		case "/v1/" + name + ":asymmetricSign":
+			var req struct {
+				Data []byte `json:"data"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			_ = json.NewEncoder(w).Encode(map[string]any{"signature": kms.sign(req.Data)})
+		default:
+			http.Error(w, `{"error":{"message":"not found"}}`, http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	signer, err := newGCPKMSSigner(context.Background(), name, srv.URL, srv.Client())
+	if err != nil {
+		t.Fatalf("newGCPKMSSigner: %v", err)
+	}
+	testKMSSigner(t, signer, kms)
+
+	if _, err := newGCPKMSSigner(context.Background(), name+"0", srv.URL, srv.Client()); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("missing key error = %v", err)
+	}
+}
+
+func testKMSSigner(t *testing.T, signer Signer, kms *fakeKMS) {
+	t.Helper()
+	if !signer.PublicKey().Equals(kms.address()) {
+		t.Fatalf("public key = %s, want %s", signer.PublicKey(), kms.address())
+	}
+
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{memo.NewMemoInstruction([]byte("cedros"), signer.PublicKey()).Build()},
+		solana.Hash{1},
+		solana.TransactionPayer(signer.PublicKey()),
+	)
+	if err != nil {
+		t.Fatalf("NewTransaction: %v", err)
+	}
+	if err := SignTransaction(context.Background(), tx, signer); err != nil {
+		t.Fatalf("SignTransaction: %v", err)
+	}
+	if err := tx.VerifySignatures(); err != nil {
+		t.Fatalf("VerifySignatures: %v", err)
+	}
+
+	kms.corrupt = true
+	defer func() { kms.corrupt = false }()
+	if _, err := signer.Sign(context.Background(), []byte("message")); err == nil || !strings.Contains(err.Error(), "invalid ed25519 signature") {
+		t.Errorf("corrupted signature error = %v", err)
+	}
+}
+
+func TestParseSignerLocalKey(t *testing.T) {
+	wallet := solana.NewWallet()
+	signer, err := ParseSigner(context.Background(), wallet.PrivateKey.String())
+	if err != nil {
+		t.Fatalf("ParseSigner: %v", err)
+	}
+	if _, ok := signer.(*LocalSigner); !ok || !signer.PublicKey().Equals(wallet.PublicKey()) {
+		t.Errorf("signer = %T %s", signer, signer.PublicKey())
+	}
+	if _, err := ParseSigners(context.Background(), []string{wallet.PrivateKey.String(), "not-a-key"}); err == nil || !strings.Contains(err.Error(), "server wallet 2") {
+		t.Errorf("ParseSigners error = %v", err)
+	}
+}
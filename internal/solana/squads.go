@@ -0,0 +1,418 @@
+package solana
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// SquadsProgramID is the Squads v4 multisig program.
+var SquadsProgramID = solana.MustPublicKeyFromBase58("SQDS4ep65T869zMMBKyuUq6aD6EgTu8psMjkvj52pCf")
+
+// Squads member permission bits.
+const (
+	SquadsPermissionInitiate uint8 = 1 << iota
+	SquadsPermissionVote
+	SquadsPermissionExecute
+)
+
+var (
+	squadsMultisigDiscriminator = anchorDiscriminator("account:Multisig")
+	squadsVaultTxCreate         = anchorDiscriminator("global:vault_transaction_create")
+	squadsProposalCreate        = anchorDiscriminator("global:proposal_create")
+	squadsProposalApprove       = anchorDiscriminator("global:proposal_approve")
+)
+
+// anchorDiscriminator returns the 8-byte Anchor discriminator for an account or instruction.
+func anchorDiscriminator(name string) [8]byte {
+	sum := sha256.Sum256([]byte(name))
+	var d [8]byte
+	copy(d[:], sum[:8])
+	return d
+}
+
+// SquadsMember is a multisig member and its permission mask.
+type SquadsMember struct {
+	Key         solana.PublicKey
+	Permissions uint8
+}
+
+// Has reports whether the member holds permission.
+func (m SquadsMember) Has(permission uint8) bool {
+	return m.Permissions&permission != 0
+}
+
+// SquadsMultisig is the subset of a Squads v4 multisig account the server needs.
+type SquadsMultisig struct {
+	Threshold        uint16
+	TransactionIndex uint64 // Index of the last vault or config transaction created
+	Members          []SquadsMember
+}
+
+// Member returns the member with key, if any.
+func (m SquadsMultisig) Member(key solana.PublicKey) (SquadsMember, bool) {
+	for _, member := range m.Members {
+		if member.Key.Equals(key) {
+			return member, true
+		}
+	}
+	return SquadsMember{}, false
+}
+
+// DecodeSquadsMultisig decodes a Squads v4 multisig account.
+func DecodeSquadsMultisig(data []byte) (SquadsMultisig, error) {
+	r := borshReader{data: data}
+	if d := r.bytes(8); !bytes.Equal(d, squadsMultisigDiscriminator[:]) {
+		return SquadsMultisig{}, errors.New("squads: account is not a multisig")
+	}
+	var m SquadsMultisig
+	r.bytes(32 + 32) // create_key, config_authority
+	m.Threshold = r.u16()
+	r.u32() // time_lock
+	m.TransactionIndex = r.u64()
+	r.u64() // stale_transaction_index
+	if r.u8() == 1 {
+		r.bytes(32) // rent_collector
+	}
+	r.u8() // bump
+	count := r.u32()
+	if r.err == nil && int(count) > len(r.data)/33 {
+		return SquadsMultisig{}, fmt.Errorf("squads: invalid member count %d", count)
+	}
+	for i := uint32(0); i < count && r.err == nil; i++ {
+		key := solana.PublicKeyFromBytes(r.bytes(32))
+		m.Members = append(m.Members, SquadsMember{Key: key, Permissions: r.u8()})
+	}
+	if r.err != nil {
+		return SquadsMultisig{}, fmt.Errorf("squads: decode multisig: %w", r.err)
+	}
+	return m, nil
+}
+
+// SquadsVaultAddress derives the vault PDA that holds a multisig's funds.
+func SquadsVaultAddress(multisig solana.PublicKey, vaultIndex uint8) (solana.PublicKey, error) {
+	addr, _, err := solana.FindProgramAddress([][]byte{[]byte("multisig"), multisig[:], []byte("vault"), {vaultIndex}}, SquadsProgramID)
+	return addr, err
+}
+
+// ValidateSquadsVault checks that address is the multisig's vault at vaultIndex. Funds
+// sent to the multisig account itself rather than a vault are not spendable by members.
+func ValidateSquadsVault(multisig string, vaultIndex uint8, address string) error {
+	multisigKey, err := solana.PublicKeyFromBase58(multisig)
+	if err != nil {
+		return fmt.Errorf("squads: invalid multisig address: %w", err)
+	}
+	vault, err := SquadsVaultAddress(multisigKey, vaultIndex)
+	if err != nil {
+		return fmt.Errorf("squads: derive vault: %w", err)
+	}
+	if vault.String() != address {
+		return fmt.Errorf("squads: vault %d of multisig %s is %s, not %s", vaultIndex, multisig, vault, address)
+	}
+	return nil
+}
+
+// SquadsTransactionAddress derives the vault transaction PDA for a transaction index.
+func SquadsTransactionAddress(multisig solana.PublicKey, index uint64) (solana.PublicKey, error) {
+	addr, _, err := solana.FindProgramAddress([][]byte{[]byte("multisig"), multisig[:], []byte("transaction"), u64LE(index)}, SquadsProgramID)
+	return addr, err
+}
+
+// SquadsProposalAddress derives the proposal PDA for a transaction index.
+func SquadsProposalAddress(multisig solana.PublicKey, index uint64) (solana.PublicKey, error) {
+	addr, _, err := solana.FindProgramAddress([][]byte{[]byte("multisig"), multisig[:], []byte("transaction"), u64LE(index), []byte("proposal")}, SquadsProgramID)
+	return addr, err
+}
+
+// SquadsProposalParams describes a vault transaction to propose.
+type SquadsProposalParams struct {
+	Multisig         solana.PublicKey
+	VaultIndex       uint8
+	TransactionIndex uint64               // Must be the multisig's TransactionIndex + 1
+	Creator          SquadsMember         // Member creating (and, with Vote permission, approving) the proposal
+	Instructions     []solana.Instruction // Executed by the vault once the proposal passes
+	Memo             string
+}
+
+// NewSquadsProposalInstructions builds the instructions that create a vault transaction,
+// open its proposal, and cast the creator's approval. The creator pays rent for both accounts.
+func NewSquadsProposalInstructions(p SquadsProposalParams) ([]solana.Instruction, error) {
+	if !p.Creator.Has(SquadsPermissionInitiate) {
+		return nil, fmt.Errorf("squads: member %s cannot initiate transactions", p.Creator.Key)
+	}
+	vault, err := SquadsVaultAddress(p.Multisig, p.VaultIndex)
+	if err != nil {
+		return nil, fmt.Errorf("squads: derive vault: %w", err)
+	}
+	transaction, err := SquadsTransactionAddress(p.Multisig, p.TransactionIndex)
+	if err != nil {
+		return nil, fmt.Errorf("squads: derive transaction: %w", err)
+	}
+	proposal, err := SquadsProposalAddress(p.Multisig, p.TransactionIndex)
+	if err != nil {
+		return nil, fmt.Errorf("squads: derive proposal: %w", err)
+	}
+
+	// The vault message is compiled like a regular transaction paid by the vault; the
+	// blockhash is ignored by Squads.
+	inner, err := solana.NewTransaction(p.Instructions, solana.Hash{}, solana.TransactionPayer(vault))
+	if err != nil {
+		return nil, fmt.Errorf("squads: compile vault message: %w", err)
+	}
+	message, err := encodeSquadsMessage(inner.Message)
+	if err != nil {
+		return nil, err
+	}
+
+	var createData bytes.Buffer
+	createData.Write(squadsVaultTxCreate[:])
+	createData.WriteByte(p.VaultIndex)
+	createData.WriteByte(0) // ephemeral_signers
+	writeBorshBytes(&createData, message)
+	writeBorshOptionString(&createData, p.Memo)
+
+	var proposalData bytes.Buffer
+	proposalData.Write(squadsProposalCreate[:])
+	proposalData.Write(u64LE(p.TransactionIndex))
+	proposalData.WriteByte(0) // draft: false, open for voting immediately
+
+	creator := p.Creator.Key
+	instructions := []solana.Instruction{
+		solana.NewInstruction(SquadsProgramID, solana.AccountMetaSlice{
+			solana.Meta(p.Multisig).WRITE(),
+			solana.Meta(transaction).WRITE(),
+			solana.Meta(creator).SIGNER(),
+			solana.Meta(creator).WRITE().SIGNER(), // rent_payer
+			solana.Meta(solana.SystemProgramID),
+		}, createData.Bytes()),
+		solana.NewInstruction(SquadsProgramID, solana.AccountMetaSlice{
+			solana.Meta(p.Multisig),
+			solana.Meta(proposal).WRITE(),
+			solana.Meta(creator).SIGNER(),
+			solana.Meta(creator).WRITE().SIGNER(), // rent_payer
+			solana.Meta(solana.SystemProgramID),
+		}, proposalData.Bytes()),
+	}
+
+	if p.Creator.Has(SquadsPermissionVote) {
+		var approveData bytes.Buffer
+		approveData.Write(squadsProposalApprove[:])
+		writeBorshOptionString(&approveData, "")
+		instructions = append(instructions, solana.NewInstruction(SquadsProgramID, solana.AccountMetaSlice{
+			solana.Meta(p.Multisig),
+			solana.Meta(creator).WRITE().SIGNER(),
+			solana.Meta(proposal).WRITE(),
+		}, approveData.Bytes()))
+	}
+	return instructions, nil
+}
+
+// SquadsProposal is a vault transaction proposal parsed from a signed transaction.
+type SquadsProposal struct {
+	Multisig         solana.PublicKey
+	VaultIndex       uint8
+	TransactionIndex uint64
+	Creator          solana.PublicKey
+	Message          solana.Message // Vault transaction to execute, compiled as a legacy message
+}
+
+// ParseSquadsProposal finds the vault_transaction_create and proposal_create instructions
+// in tx. Both must target the same multisig.
+func ParseSquadsProposal(tx *solana.Transaction) (SquadsProposal, error) {
+	var (
+		proposal                SquadsProposal
+		haveVaultTx, haveCreate bool
+	)
+	for _, inst := range tx.Message.Instructions {
+		if int(inst.ProgramIDIndex) >= len(tx.Message.AccountKeys) || !tx.Message.AccountKeys[inst.ProgramIDIndex].Equals(SquadsProgramID) {
+			continue
+		}
+		accounts, err := inst.ResolveInstructionAccounts(&tx.Message)
+		if err != nil {
+			return SquadsProposal{}, fmt.Errorf("squads: %w", err)
+		}
+		data := []byte(inst.Data)
+		if len(data) < 8 || len(accounts) < 3 {
+			continue
+		}
+		multisig := accounts[0].PublicKey
+		if (haveVaultTx || haveCreate) && !multisig.Equals(proposal.Multisig) {
+			return SquadsProposal{}, errors.New("squads: instructions target different multisigs")
+		}
+		proposal.Multisig = multisig
+
+		r := borshReader{data: data[8:]}
+		switch {
+		case bytes.Equal(data[:8], squadsVaultTxCreate[:]):
+			proposal.VaultIndex = r.u8()
+			r.u8() // ephemeral_signers
+			message := r.bytes(int(r.u32()))
+			if r.err != nil {
+				return SquadsProposal{}, fmt.Errorf("squads: decode vault_transaction_create: %w", r.err)
+			}
+			if proposal.Message, err = decodeSquadsMessage(message); err != nil {
+				return SquadsProposal{}, err
+			}
+			proposal.Creator = accounts[2].PublicKey
+			haveVaultTx = true
+		case bytes.Equal(data[:8], squadsProposalCreate[:]):
+			proposal.TransactionIndex = r.u64()
+			if r.err != nil {
+				return SquadsProposal{}, fmt.Errorf("squads: decode proposal_create: %w", r.err)
+			}
+			haveCreate = true
+		}
+	}
+	if !haveVaultTx || !haveCreate {
+		return SquadsProposal{}, errors.New("squads: transaction does not create a vault transaction proposal")
+	}
+	return proposal, nil
+}
+
+// encodeSquadsMessage converts a compiled legacy message to Squads' TransactionMessage
+// layout, which uses u8 lengths for keys and instructions and a u16 length for data.
+func encodeSquadsMessage(msg solana.Message) ([]byte, error) {
+	h := msg.Header
+	if len(msg.AccountKeys) > 255 || len(msg.Instructions) > 255 {
+		return nil, errors.New("squads: vault message too large")
+	}
+	var buf bytes.Buffer
+	buf.WriteByte(h.NumRequiredSignatures)
+	buf.WriteByte(h.NumRequiredSignatures - h.NumReadonlySignedAccounts)
+	buf.WriteByte(uint8(len(msg.AccountKeys)) - h.NumRequiredSignatures - h.NumReadonlyUnsignedAccounts)
+	buf.WriteByte(uint8(len(msg.AccountKeys)))
+	for _, key := range msg.AccountKeys {
+		buf.Write(key[:])
+	}
+	buf.WriteByte(uint8(len(msg.Instructions)))
+	for _, inst := range msg.Instructions {
+		if len(inst.Accounts) > 255 || len(inst.Data) > 0xffff {
+			return nil, errors.New("squads: vault instruction too large")
+		}
+		buf.WriteByte(uint8(inst.ProgramIDIndex))
+		buf.WriteByte(uint8(len(inst.Accounts)))
+		for _, idx := range inst.Accounts {
+			buf.WriteByte(uint8(idx))
+		}
+		_ = binary.Write(&buf, binary.LittleEndian, uint16(len(inst.Data)))
+		buf.Write(inst.Data)
+	}
+	buf.WriteByte(0) // address_table_lookups
+	return buf.Bytes(), nil
+}
+
+// decodeSquadsMessage is the inverse of encodeSquadsMessage. Messages using address lookup
+// tables are rejected because their accounts cannot be resolved offline.
+func decodeSquadsMessage(data []byte) (solana.Message, error) {
+	r := borshReader{data: data}
+	numSigners, numWritableSigners, numWritableNonSigners := r.u8(), r.u8(), r.u8()
+	var msg solana.Message
+	keyCount := int(r.u8())
+	for i := 0; i < keyCount && r.err == nil; i++ {
+		msg.AccountKeys = append(msg.AccountKeys, solana.PublicKeyFromBytes(r.bytes(32)))
+	}
+	instCount := int(r.u8())
+	for i := 0; i < instCount && r.err == nil; i++ {
+		inst := solana.CompiledInstruction{ProgramIDIndex: uint16(r.u8())}
+		for _, idx := range r.bytes(int(r.u8())) {
+			inst.Accounts = append(inst.Accounts, uint16(idx))
+		}
+		inst.Data = r.bytes(int(r.u16()))
+		msg.Instructions = append(msg.Instructions, inst)
+	}
+	lookups := r.u8()
+	if r.err != nil {
+		return solana.Message{}, fmt.Errorf("squads: decode vault message: %w", r.err)
+	}
+	if lookups != 0 {
+		return solana.Message{}, errors.New("squads: vault messages with address lookup tables are not supported")
+	}
+	if numWritableSigners > numSigners || int(numSigners)+int(numWritableNonSigners) > keyCount {
+		return solana.Message{}, errors.New("squads: invalid vault message header")
+	}
+	msg.Header = solana.MessageHeader{
+		NumRequiredSignatures:       numSigners,
+		NumReadonlySignedAccounts:   numSigners - numWritableSigners,
+		NumReadonlyUnsignedAccounts: uint8(keyCount) - numSigners - numWritableNonSigners,
+	}
+	for _, inst := range msg.Instructions {
+		if int(inst.ProgramIDIndex) >= keyCount {
+			return solana.Message{}, errors.New("squads: vault instruction program index out of range")
+		}
+		for _, idx := range inst.Accounts {
+			if int(idx) >= keyCount {
+				return solana.Message{}, errors.New("squads: vault instruction account index out of range")
+			}
+		}
+	}
+	return msg, nil
+}
+
+func u64LE(v uint64) []byte {
+	return binary.LittleEndian.AppendUint64(nil, v)
+}
+
+func writeBorshBytes(buf *bytes.Buffer, b []byte) {
+	_ = binary.Write(buf, binary.LittleEndian, uint32(len(b)))
+	buf.Write(b)
+}
+
+func writeBorshOptionString(buf *bytes.Buffer, s string) {
+	if s == "" {
+		buf.WriteByte(0)
+		return
+	}
+	buf.WriteByte(1)
+	writeBorshBytes(buf, []byte(s))
+}
+
+// borshReader reads little-endian Borsh values, recording the first short read in err.
+type borshReader struct {
+	data []byte
+	err  error
+}
+
+func (r *borshReader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n > len(r.data) {
+		r.err = errors.New("unexpected end of data")
+		return nil
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *borshReader) u8() uint8 {
+	if b := r.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *borshReader) u16() uint16 {
+	if b := r.bytes(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *borshReader) u32() uint32 {
+	if b := r.bytes(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *borshReader) u64() uint64 {
+	if b := r.bytes(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
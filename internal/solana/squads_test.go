@@ -0,0 +1,172 @@
+package solana
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/token"
+)
+
+func TestDecodeSquadsMultisig(t *testing.T) {
+	alice, bob := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+
+	encode := func(rentCollector bool) []byte {
+		var buf bytes.Buffer
+		buf.Write(squadsMultisigDiscriminator[:])
+		buf.Write(make([]byte, 64)) // create_key, config_authority
+		_ = binary.Write(&buf, binary.LittleEndian, uint16(2))
+		_ = binary.Write(&buf, binary.LittleEndian, uint32(0))
+		_ = binary.Write(&buf, binary.LittleEndian, uint64(41))
+		_ = binary.Write(&buf, binary.LittleEndian, uint64(0))
+		if rentCollector {
+			buf.WriteByte(1)
+			buf.Write(make([]byte, 32))
+		} else {
+			buf.WriteByte(0)
+		}
+		buf.WriteByte(255) // bump
+		_ = binary.Write(&buf, binary.LittleEndian, uint32(2))
+		buf.Write(alice[:])
+		buf.WriteByte(SquadsPermissionInitiate | SquadsPermissionVote | SquadsPermissionExecute)
+		buf.Write(bob[:])
+		buf.WriteByte(SquadsPermissionVote)
+		return buf.Bytes()
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{name: "no rent collector", data: encode(false)},
+		{name: "rent collector", data: encode(true)},
+		{name: "wrong account", data: append([]byte("notmsig!"), encode(false)[8:]...), wantErr: "not a multisig"},
+		{name: "truncated", data: encode(false)[:90], wantErr: "decode multisig"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := DecodeSquadsMultisig(tt.data)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DecodeSquadsMultisig: %v", err)
+			}
+			if m.Threshold != 2 || m.TransactionIndex != 41 || len(m.Members) != 2 {
+				t.Fatalf("multisig = %+v", m)
+			}
+			if member, ok := m.Member(bob); !ok || member.Has(SquadsPermissionInitiate) || !member.Has(SquadsPermissionVote) {
+				t.Errorf("bob = %+v, %v", member, ok)
+			}
+		})
+	}
+}
+
+func TestSquadsProposalRoundTrip(t *testing.T) {
+	multisig := solana.NewWallet().PublicKey()
+	creator := solana.NewWallet().PublicKey()
+	vault, err := SquadsVaultAddress(multisig, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mint, dest, source := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	transfer := token.NewTransferCheckedInstruction(2500000, 6, source, mint, dest, vault, nil).Build()
+
+	tests := []struct {
+		name        string
+		permissions uint8
+		wantInsts   int
+		wantErr     string
+	}{
+		{name: "initiate and vote", permissions: SquadsPermissionInitiate | SquadsPermissionVote, wantInsts: 3},
+		{name: "initiate only", permissions: SquadsPermissionInitiate, wantInsts: 2},
+		{name: "vote only", permissions: SquadsPermissionVote, wantErr: "cannot initiate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instructions, err := NewSquadsProposalInstructions(SquadsProposalParams{
+				Multisig:         multisig,
+				TransactionIndex: 42,
+				Creator:          SquadsMember{Key: creator, Permissions: tt.permissions},
+				Instructions:     []solana.Instruction{transfer},
+				Memo:             "refund:r1",
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewSquadsProposalInstructions: %v", err)
+			}
+			if len(instructions) != tt.wantInsts {
+				t.Fatalf("instructions = %d, want %d", len(instructions), tt.wantInsts)
+			}
+
+			tx, err := solana.NewTransaction(instructions, solana.Hash{1}, solana.TransactionPayer(creator))
+			if err != nil {
+				t.Fatal(err)
+			}
+			proposal, err := ParseSquadsProposal(tx)
+			if err != nil {
+				t.Fatalf("ParseSquadsProposal: %v", err)
+			}
+			if !proposal.Multisig.Equals(multisig) || !proposal.Creator.Equals(creator) || proposal.TransactionIndex != 42 || proposal.VaultIndex != 0 {
+				t.Errorf("proposal = %+v", proposal)
+			}
+
+			// The vault message must decode back to the transfer, signed by the vault
+			msg := proposal.Message
+			if msg.Header.NumRequiredSignatures != 1 || !msg.AccountKeys[0].Equals(vault) || len(msg.Instructions) != 1 {
+				t.Fatalf("vault message = %+v", msg)
+			}
+			accounts, err := msg.Instructions[0].ResolveInstructionAccounts(&msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := token.DecodeInstruction(accounts, msg.Instructions[0].Data)
+			if err != nil {
+				t.Fatalf("decode transfer: %v", err)
+			}
+			ins, ok := decoded.Impl.(*token.TransferChecked)
+			if !ok || *ins.Amount != 2500000 || !ins.GetDestinationAccount().PublicKey.Equals(dest) || !ins.GetOwnerAccount().PublicKey.Equals(vault) {
+				t.Errorf("transfer = %+v", decoded.Impl)
+			}
+			if !ins.GetOwnerAccount().IsSigner || !ins.GetSourceAccount().IsWritable {
+				t.Error("vault message lost signer/writable flags")
+			}
+		})
+	}
+
+	plain, err := solana.NewTransaction([]solana.Instruction{transfer}, solana.Hash{1}, solana.TransactionPayer(creator))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseSquadsProposal(plain); err == nil {
+		t.Error("ParseSquadsProposal accepted a plain transfer")
+	}
+}
+
+func TestValidateSquadsVault(t *testing.T) {
+	multisig := solana.NewWallet().PublicKey()
+	vault, err := SquadsVaultAddress(multisig, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateSquadsVault(multisig.String(), 1, vault.String()); err != nil {
+		t.Errorf("vault 1: %v", err)
+	}
+	if err := ValidateSquadsVault(multisig.String(), 0, vault.String()); err == nil {
+		t.Error("vault 0 accepted for vault 1's address")
+	}
+	if err := ValidateSquadsVault(multisig.String(), 1, multisig.String()); err == nil {
+		t.Error("multisig account accepted as payment address")
+	}
+}
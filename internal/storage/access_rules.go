@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"fmt"
+	"net/netip"
+	"sort"
+	"time"
+)
+
+// Access rule kinds.
+const (
+	AccessRuleWallet = "wallet" // Value is a wallet address
+	AccessRuleIP     = "ip"     // Value is an IP range in CIDR notation
+)
+
+// Access lists.
+const (
+	AccessListAllow = "allow"
+	AccessListDeny  = "deny"
+)
+
+// AccessRule puts a wallet or an IP range on the allow or deny list.
+type AccessRule struct {
+	Kind      string    `json:"kind"`  // wallet or ip
+	Value     string    `json:"value"` // Unique per kind
+	List      string    `json:"list"`  // allow or deny
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// accessRuleKey identifies an access rule in the map-backed stores.
+func accessRuleKey(kind, value string) string {
+	return kind + ":" + value
+}
+
+// validateAccessRule checks an access rule before it is stored. IP ranges must already be in
+// canonical CIDR form, so each range has one key.
+func validateAccessRule(rule AccessRule) error {
+	switch rule.List {
+	case AccessListAllow, AccessListDeny:
+	default:
+		return fmt.Errorf("access rule list %q must be %q or %q", rule.List, AccessListAllow, AccessListDeny)
+	}
+	switch rule.Kind {
+	case AccessRuleWallet:
+		if rule.Value == "" {
+			return fmt.Errorf("access rule wallet required")
+		}
+	case AccessRuleIP:
+		prefix, err := netip.ParsePrefix(rule.Value)
+		if err != nil || prefix.Masked().String() != rule.Value {
+			return fmt.Errorf("access rule IP range %q must be in canonical CIDR notation", rule.Value)
+		}
+	default:
+		return fmt.Errorf("access rule kind %q must be %q or %q", rule.Kind, AccessRuleWallet, AccessRuleIP)
+	}
+	return nil
+}
+
+// saveAccessRuleToMap implements SaveAccessRule for the map-backed stores, keeping the
+// original CreatedAt when a rule is replaced. Callers hold the write lock.
+func saveAccessRuleToMap(rules map[string]AccessRule, rule AccessRule, now time.Time) AccessRule {
+	key := accessRuleKey(rule.Kind, rule.Value)
+	rule.CreatedAt = now
+	if existing, ok := rules[key]; ok {
+		rule.CreatedAt = existing.CreatedAt
+	}
+	rules[key] = rule
+	return rule
+}
+
+// sortAccessRules orders access rules by kind, then value.
+func sortAccessRules(rules []AccessRule) {
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].Kind != rules[j].Kind {
+			return rules[i].Kind < rules[j].Kind
+		}
+		return rules[i].Value < rules[j].Value
+	})
+}
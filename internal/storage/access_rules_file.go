@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// SaveAccessRule adds a wallet or IP range to a list, replacing its rule if it is on one.
+func (s *FileStore) SaveAccessRule(_ context.Context, rule AccessRule) (AccessRule, error) {
+	if err := validateAccessRule(rule); err != nil {
+		return AccessRule{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saved := saveAccessRuleToMap(s.accessRules, rule, time.Now())
+	s.markDirty()
+	return saved, nil
+}
+
+// ListAccessRules returns every access rule, ordered by kind and value.
+func (s *FileStore) ListAccessRules(_ context.Context) ([]AccessRule, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rules := make([]AccessRule, 0, len(s.accessRules))
+	for _, rule := range s.accessRules {
+		rules = append(rules, rule)
+	}
+	sortAccessRules(rules)
+	return rules, nil
+}
+
+// DeleteAccessRule removes a wallet or IP range from whichever list it is on.
+func (s *FileStore) DeleteAccessRule(_ context.Context, kind, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := accessRuleKey(kind, value)
+	if _, ok := s.accessRules[key]; !ok {
+		return ErrNotFound
+	}
+	delete(s.accessRules, key)
+	s.markDirty()
+	return nil
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// SaveAccessRule adds a wallet or IP range to a list, replacing its rule if it is on one.
+func (m *MemoryStore) SaveAccessRule(_ context.Context, rule AccessRule) (AccessRule, error) {
+	if err := validateAccessRule(rule); err != nil {
+		return AccessRule{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return saveAccessRuleToMap(m.accessRules, rule, time.Now()), nil
+}
+
+// ListAccessRules returns every access rule, ordered by kind and value.
+func (m *MemoryStore) ListAccessRules(_ context.Context) ([]AccessRule, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	rules := make([]AccessRule, 0, len(m.accessRules))
+	for _, rule := range m.accessRules {
+		rules = append(rules, rule)
+	}
+	sortAccessRules(rules)
+	return rules, nil
+}
+
+// DeleteAccessRule removes a wallet or IP range from whichever list it is on.
+func (m *MemoryStore) DeleteAccessRule(_ context.Context, kind, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := accessRuleKey(kind, value)
+	if _, ok := m.accessRules[key]; !ok {
+		return ErrNotFound
+	}
+	delete(m.accessRules, key)
+	return nil
+}
@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const accessRulesCollection = "access_rules"
+
+// accessRuleDocument is an access rule keyed by <kind>:<value>.
+type accessRuleDocument struct {
+	ID        string    `bson:"_id"`
+	Kind      string    `bson:"kind"`
+	Value     string    `bson:"value"`
+	List      string    `bson:"list"`
+	Reason    string    `bson:"reason,omitempty"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+// SaveAccessRule adds a wallet or IP range to a list, replacing its rule if it is on one.
+func (s *MongoDBStore) SaveAccessRule(ctx context.Context, rule AccessRule) (AccessRule, error) {
+	if err := validateAccessRule(rule); err != nil {
+		return AccessRule{}, err
+	}
+
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	update := bson.M{
+		"$set": bson.M{
+			"kind":   rule.Kind,
+			"value":  rule.Value,
+			"list":   rule.List,
+			"reason": rule.Reason,
+		},
+		"$setOnInsert": bson.M{"created_at": time.Now()},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var doc accessRuleDocument
+	err := s.db.Collection(accessRulesCollection).FindOneAndUpdate(ctx, bson.M{"_id": accessRuleKey(rule.Kind, rule.Value)}, update, opts).Decode(&doc)
+	if err != nil {
+		return AccessRule{}, fmt.Errorf("save access rule: %w", err)
+	}
+	return doc.accessRule(), nil
+}
+
+// ListAccessRules returns every access rule, ordered by kind and value.
+func (s *MongoDBStore) ListAccessRules(ctx context.Context) ([]AccessRule, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "kind", Value: 1}, {Key: "value", Value: 1}})
+	cursor, err := s.db.Collection(accessRulesCollection).Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("list access rules: %w", err)
+	}
+	var docs []accessRuleDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("decode access rules: %w", err)
+	}
+
+	rules := make([]AccessRule, 0, len(docs))
+	for _, doc := range docs {
+		rules = append(rules, doc.accessRule())
+	}
+	return rules, nil
+}
+
+// DeleteAccessRule removes a wallet or IP range from whichever list it is on.
+func (s *MongoDBStore) DeleteAccessRule(ctx context.Context, kind, value string) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.Collection(accessRulesCollection).DeleteOne(ctx, bson.M{"_id": accessRuleKey(kind, value)})
+	if err != nil {
+		return fmt.Errorf("delete access rule: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (d accessRuleDocument) accessRule() AccessRule {
+	return AccessRule{
+		Kind:      d.Kind,
+		Value:     d.Value,
+		List:      d.List,
+		Reason:    d.Reason,
+		CreatedAt: d.CreatedAt,
+	}
+}
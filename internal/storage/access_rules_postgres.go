@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// SaveAccessRule adds a wallet or IP range to a list, replacing its rule if it is on one.
+func (s *PostgresStore) SaveAccessRule(ctx context.Context, rule AccessRule) (AccessRule, error) {
+	if err := validateAccessRule(rule); err != nil {
+		return AccessRule{}, err
+	}
+
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`
+		INSERT INTO %s (kind, value, list, reason, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (kind, value) DO UPDATE SET
+			list = EXCLUDED.list,
+			reason = EXCLUDED.reason
+		RETURNING created_at
+	`, s.accessRulesTableName)
+	err := s.db.QueryRowContext(ctx, query, rule.Kind, rule.Value, rule.List, rule.Reason, time.Now().UTC()).Scan(&rule.CreatedAt)
+	if err != nil {
+		return AccessRule{}, fmt.Errorf("save access rule: %w", err)
+	}
+	return rule, nil
+}
+
+// ListAccessRules returns every access rule, ordered by kind and value.
+func (s *PostgresStore) ListAccessRules(ctx context.Context) ([]AccessRule, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`
+		SELECT kind, value, list, reason, created_at
+		FROM %s
+		ORDER BY kind, value
+	`, s.accessRulesTableName)
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("list access rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []AccessRule{}
+	for rows.Next() {
+		var rule AccessRule
+		if err := rows.Scan(&rule.Kind, &rule.Value, &rule.List, &rule.Reason, &rule.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan access rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// DeleteAccessRule removes a wallet or IP range from whichever list it is on.
+func (s *PostgresStore) DeleteAccessRule(ctx context.Context, kind, value string) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`DELETE FROM %s WHERE kind = $1 AND value = $2`, s.accessRulesTableName)
+	result, err := s.db.ExecContext(ctx, query, kind, value)
+	if err != nil {
+		return fmt.Errorf("delete access rule: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
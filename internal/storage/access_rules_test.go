@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestAccessRules(t *testing.T) {
+	backends := []struct {
+		name string
+		open func(t *testing.T, path string) Store
+	}{
+		{name: "memory", open: func(t *testing.T, _ string) Store { return NewMemoryStore() }},
+		{
+			name: "file",
+			open: func(t *testing.T, path string) Store {
+				store, err := NewFileStore(path)
+				if err != nil {
+					t.Fatalf("NewFileStore: %v", err)
+				}
+				return store
+			},
+		},
+	}
+
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "store.json")
+			store := backend.open(t, path)
+			ctx := context.Background()
+
+			saves := []struct {
+				name    string
+				rule    AccessRule
+				wantErr bool
+			}{
+				{name: "deny wallet", rule: AccessRule{Kind: AccessRuleWallet, Value: "mallory", List: AccessListDeny, Reason: "chargebacks"}},
+				{name: "deny range", rule: AccessRule{Kind: AccessRuleIP, Value: "203.0.113.0/24", List: AccessListDeny}},
+				{name: "allow wallet", rule: AccessRule{Kind: AccessRuleWallet, Value: "alice", List: AccessListAllow}},
+				{name: "replace", rule: AccessRule{Kind: AccessRuleWallet, Value: "mallory", List: AccessListAllow}},
+				{name: "unknown list", rule: AccessRule{Kind: AccessRuleWallet, Value: "bob", List: "maybe"}, wantErr: true},
+				{name: "unknown kind", rule: AccessRule{Kind: "email", Value: "bob@example.com", List: AccessListDeny}, wantErr: true},
+				{name: "bare IP", rule: AccessRule{Kind: AccessRuleIP, Value: "203.0.113.7", List: AccessListDeny}, wantErr: true},
+				{name: "non-canonical range", rule: AccessRule{Kind: AccessRuleIP, Value: "203.0.113.7/24", List: AccessListDeny}, wantErr: true},
+				{name: "missing wallet", rule: AccessRule{Kind: AccessRuleWallet, List: AccessListDeny}, wantErr: true},
+			}
+			for _, save := range saves {
+				if _, err := store.SaveAccessRule(ctx, save.rule); (err != nil) != save.wantErr {
+					t.Fatalf("%s: SaveAccessRule err = %v, wantErr %v", save.name, err, save.wantErr)
+				}
+			}
+
+			if backend.name == "file" {
+				_ = store.Close()
+				store = backend.open(t, path)
+			}
+			defer store.Close()
+
+			rules, err := store.ListAccessRules(ctx)
+			if err != nil {
+				t.Fatalf("ListAccessRules: %v", err)
+			}
+			want := []string{"ip:203.0.113.0/24", "wallet:alice", "wallet:mallory"}
+			if len(rules) != len(want) {
+				t.Fatalf("rules = %+v, want %v", rules, want)
+			}
+			for i, rule := range rules {
+				if got := accessRuleKey(rule.Kind, rule.Value); got != want[i] {
+					t.Errorf("rule %d = %s, want %s", i, got, want[i])
+				}
+			}
+			if mallory := rules[2]; mallory.List != AccessListAllow || mallory.Reason != "" || mallory.CreatedAt.IsZero() {
+				t.Errorf("replaced rule = %+v, want allowed with no reason", mallory)
+			}
+
+			if err := store.DeleteAccessRule(ctx, AccessRuleIP, "203.0.113.0/24"); err != nil {
+				t.Fatalf("DeleteAccessRule: %v", err)
+			}
+			if err := store.DeleteAccessRule(ctx, AccessRuleIP, "203.0.113.0/24"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("DeleteAccessRule twice: err = %v, want ErrNotFound", err)
+			}
+			if rules, _ := store.ListAccessRules(ctx); len(rules) != 2 {
+				t.Errorf("rules after delete = %+v, want 2", rules)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Admin audit actions.
+const (
+	AdminAuditRefundApprove = "refund.approve" // A refund was approved (quote generated)
+	AdminAuditRefundDeny    = "refund.deny"    // A refund request was denied
+	AdminAuditNonceConsume  = "nonce.consume"  // An admin nonce was spent (pending refunds listing)
+	AdminAuditWebhookRetry  = "webhook.retry"  // A failed webhook was queued for retry, or a DLQ segment re-driven
+	AdminAuditConfigChange  = "config.change"  // A write through the admin API (products, coupons, wallets, ...)
+)
+
+// Admin audit results.
+const (
+	AdminAuditSuccess = "success"
+	AdminAuditFailure = "failure"
+)
+
+// Limits on admin audit listings.
+const (
+	defaultAdminAuditLimit = 100
+	maxAdminAuditLimit     = 1000
+)
+
+// AdminAuditEntry records one admin action. The log is append-only: entries are never
+// updated or deleted.
+type AdminAuditEntry struct {
+	Action      string    `json:"action"`      // One of the AdminAudit* actions
+	Signer      string    `json:"signer"`      // Signing wallet, or "admin-api-key" for bearer-authenticated calls
+	Method      string    `json:"method"`      // HTTP method
+	Path        string    `json:"path"`        // Request path
+	RequestHash string    `json:"requestHash"` // Hex SHA-256 of the request body
+	Status      int       `json:"status"`      // HTTP status of the response
+	Result      string    `json:"result"`      // AdminAuditSuccess or AdminAuditFailure
+	RecordedAt  time.Time `json:"recordedAt"`
+}
+
+// AdminAuditFilter narrows an admin audit listing. Zero fields match everything.
+type AdminAuditFilter struct {
+	Action string
+	Signer string
+	Since  time.Time // Inclusive
+	Until  time.Time // Exclusive
+	Limit  int       // Default 100, max 1000
+}
+
+// validateAdminAuditEntry checks an entry before it is recorded.
+func validateAdminAuditEntry(e AdminAuditEntry) error {
+	if e.Action == "" || e.Signer == "" || e.Result == "" {
+		return fmt.Errorf("admin audit entry action, signer, and result required")
+	}
+	if e.RecordedAt.IsZero() {
+		return fmt.Errorf("admin audit entry %s: recorded time required", e.Action)
+	}
+	return nil
+}
+
+// limit returns the filter's effective entry limit.
+func (f AdminAuditFilter) limit() int {
+	if f.Limit <= 0 {
+		return defaultAdminAuditLimit
+	}
+	return min(f.Limit, maxAdminAuditLimit)
+}
+
+// matches reports whether an entry passes the filter.
+func (f AdminAuditFilter) matches(e AdminAuditEntry) bool {
+	switch {
+	case f.Action != "" && e.Action != f.Action:
+		return false
+	case f.Signer != "" && e.Signer != f.Signer:
+		return false
+	case !f.Since.IsZero() && e.RecordedAt.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && !e.RecordedAt.Before(f.Until):
+		return false
+	}
+	return true
+}
+
+// filterAdminAudit returns the entries passing the filter, newest first.
+func filterAdminAudit(entries []AdminAuditEntry, filter AdminAuditFilter) []AdminAuditEntry {
+	var matched []AdminAuditEntry
+	for _, e := range entries {
+		if filter.matches(e) {
+			matched = append(matched, e)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].RecordedAt.After(matched[j].RecordedAt)
+	})
+	if len(matched) > filter.limit() {
+		matched = matched[:filter.limit()]
+	}
+	return matched
+}
@@ -0,0 +1,25 @@
+package storage
+
+import "context"
+
+// RecordAdminAudit appends an entry to the admin audit log.
+func (s *FileStore) RecordAdminAudit(_ context.Context, entry AdminAuditEntry) error {
+	if err := validateAdminAuditEntry(entry); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.adminAudit = append(s.adminAudit, entry)
+	s.markDirty()
+	return nil
+}
+
+// ListAdminAudit returns the admin audit entries matching filter, newest first.
+func (s *FileStore) ListAdminAudit(_ context.Context, filter AdminAuditFilter) ([]AdminAuditEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return filterAdminAudit(s.adminAudit, filter), nil
+}
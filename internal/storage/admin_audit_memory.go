@@ -0,0 +1,24 @@
+package storage
+
+import "context"
+
+// RecordAdminAudit appends an entry to the admin audit log.
+func (m *MemoryStore) RecordAdminAudit(_ context.Context, entry AdminAuditEntry) error {
+	if err := validateAdminAuditEntry(entry); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.adminAudit = append(m.adminAudit, entry)
+	return nil
+}
+
+// ListAdminAudit returns the admin audit entries matching filter, newest first.
+func (m *MemoryStore) ListAdminAudit(_ context.Context, filter AdminAuditFilter) ([]AdminAuditEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return filterAdminAudit(m.adminAudit, filter), nil
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const adminAuditCollection = "admin_audit"
+
+// adminAuditDocument is one entry in the admin audit log.
+type adminAuditDocument struct {
+	Action      string    `bson:"action"`
+	Signer      string    `bson:"signer"`
+	Method      string    `bson:"method"`
+	Path        string    `bson:"path"`
+	RequestHash string    `bson:"request_hash"`
+	Status      int       `bson:"status"`
+	Result      string    `bson:"result"`
+	RecordedAt  time.Time `bson:"recorded_at"`
+}
+
+// RecordAdminAudit appends an entry to the admin audit log.
+func (s *MongoDBStore) RecordAdminAudit(ctx context.Context, entry AdminAuditEntry) error {
+	if err := validateAdminAuditEntry(entry); err != nil {
+		return err
+	}
+
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.Collection(adminAuditCollection).InsertOne(ctx, adminAuditDocument(entry))
+	if err != nil {
+		return fmt.Errorf("record admin audit: %w", err)
+	}
+	return nil
+}
+
+// ListAdminAudit returns the admin audit entries matching filter, newest first.
+func (s *MongoDBStore) ListAdminAudit(ctx context.Context, filter AdminAuditFilter) ([]AdminAuditEntry, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := bson.M{}
+	if filter.Action != "" {
+		query["action"] = filter.Action
+	}
+	if filter.Signer != "" {
+		query["signer"] = filter.Signer
+	}
+	recorded := bson.M{}
+	if !filter.Since.IsZero() {
+		recorded["$gte"] = filter.Since
+	}
+	if !filter.Until.IsZero() {
+		recorded["$lt"] = filter.Until
+	}
+	if len(recorded) > 0 {
+		query["recorded_at"] = recorded
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "recorded_at", Value: -1}}).
+		SetLimit(int64(filter.limit()))
+	cursor, err := s.db.Collection(adminAuditCollection).Find(ctx, query, opts)
+	if err != nil {
+		return nil, fmt.Errorf("list admin audit: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var docs []adminAuditDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("decode admin audit: %w", err)
+	}
+	entries := make([]AdminAuditEntry, 0, len(docs))
+	for _, doc := range docs {
+		entries = append(entries, AdminAuditEntry(doc))
+	}
+	return entries, nil
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// RecordAdminAudit appends an entry to the admin audit log.
+func (s *PostgresStore) RecordAdminAudit(ctx context.Context, entry AdminAuditEntry) error {
+	if err := validateAdminAuditEntry(entry); err != nil {
+		return err
+	}
+
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`
+		INSERT INTO %s (action, signer, method, path, request_hash, status, result, recorded_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, s.adminAuditTableName)
+	_, err := s.db.ExecContext(ctx, query,
+		entry.Action, entry.Signer, entry.Method, entry.Path, entry.RequestHash,
+		entry.Status, entry.Result, entry.RecordedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("record admin audit: %w", err)
+	}
+	return nil
+}
+
+// ListAdminAudit returns the admin audit entries matching filter, newest first.
+func (s *PostgresStore) ListAdminAudit(ctx context.Context, filter AdminAuditFilter) ([]AdminAuditEntry, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	var conditions []string
+	var args []any
+	addCondition := func(clause string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(clause, len(args)))
+	}
+	if filter.Action != "" {
+		addCondition("action = $%d", filter.Action)
+	}
+	if filter.Signer != "" {
+		addCondition("signer = $%d", filter.Signer)
+	}
+	if !filter.Since.IsZero() {
+		addCondition("recorded_at >= $%d", filter.Since.UTC())
+	}
+	if !filter.Until.IsZero() {
+		addCondition("recorded_at < $%d", filter.Until.UTC())
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.limit())
+
+	query := fmt.Sprintf(`
+		SELECT action, signer, method, path, request_hash, status, result, recorded_at
+		FROM %s %s
+		ORDER BY recorded_at DESC, id DESC
+		LIMIT $%d
+	`, s.adminAuditTableName, where, len(args))
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list admin audit: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []AdminAuditEntry
+	for rows.Next() {
+		var e AdminAuditEntry
+		if err := rows.Scan(&e.Action, &e.Signer, &e.Method, &e.Path, &e.RequestHash, &e.Status, &e.Result, &e.RecordedAt); err != nil {
+			return nil, fmt.Errorf("scan admin audit: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAdminAudit(t *testing.T) {
+	backends := []struct {
+		name string
+		open func(t *testing.T, path string) Store
+	}{
+		{name: "memory", open: func(t *testing.T, _ string) Store { return NewMemoryStore() }},
+		{
+			name: "file",
+			open: func(t *testing.T, path string) Store {
+				store, err := NewFileStore(path)
+				if err != nil {
+					t.Fatalf("NewFileStore: %v", err)
+				}
+				return store
+			},
+		},
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	entry := func(action, signer string, age time.Duration) AdminAuditEntry {
+		return AdminAuditEntry{
+			Action:      action,
+			Signer:      signer,
+			Method:      "POST",
+			Path:        "/paywall/v1/refunds/deny",
+			RequestHash: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+			Status:      200,
+			Result:      AdminAuditSuccess,
+			RecordedAt:  now.Add(-age),
+		}
+	}
+
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "store.json")
+			store := backend.open(t, path)
+			ctx := context.Background()
+
+			records := []struct {
+				name    string
+				entry   AdminAuditEntry
+				wantErr bool
+			}{
+				{name: "deny", entry: entry(AdminAuditRefundDeny, "wallet-admin", 2*time.Hour)},
+				{name: "nonce", entry: entry(AdminAuditNonceConsume, "wallet-admin", time.Hour)},
+				{name: "config", entry: entry(AdminAuditConfigChange, "admin-api-key", 0)},
+				{name: "missing signer", entry: entry(AdminAuditRefundDeny, "", 0), wantErr: true},
+			}
+			for _, record := range records {
+				err := store.RecordAdminAudit(ctx, record.entry)
+				if (err != nil) != record.wantErr {
+					t.Fatalf("%s: RecordAdminAudit err = %v, wantErr %v", record.name, err, record.wantErr)
+				}
+			}
+
+			if backend.name == "file" {
+				_ = store.Close()
+				store = backend.open(t, path)
+			}
+			defer store.Close()
+
+			tests := []struct {
+				name        string
+				filter      AdminAuditFilter
+				wantActions []string
+			}{
+				{name: "all newest first", wantActions: []string{AdminAuditConfigChange, AdminAuditNonceConsume, AdminAuditRefundDeny}},
+				{name: "by action", filter: AdminAuditFilter{Action: AdminAuditRefundDeny}, wantActions: []string{AdminAuditRefundDeny}},
+				{name: "by signer", filter: AdminAuditFilter{Signer: "wallet-admin"}, wantActions: []string{AdminAuditNonceConsume, AdminAuditRefundDeny}},
+				{name: "time window", filter: AdminAuditFilter{Since: now.Add(-90 * time.Minute), Until: now}, wantActions: []string{AdminAuditNonceConsume}},
+				{name: "limit", filter: AdminAuditFilter{Limit: 1}, wantActions: []string{AdminAuditConfigChange}},
+			}
+			for _, tt := range tests {
+				entries, err := store.ListAdminAudit(ctx, tt.filter)
+				if err != nil {
+					t.Fatalf("%s: ListAdminAudit: %v", tt.name, err)
+				}
+				var actions []string
+				for _, e := range entries {
+					actions = append(actions, e.Action)
+				}
+				if len(actions) != len(tt.wantActions) {
+					t.Fatalf("%s: actions = %v, want %v", tt.name, actions, tt.wantActions)
+				}
+				for i := range actions {
+					if actions[i] != tt.wantActions[i] {
+						t.Errorf("%s: actions = %v, want %v", tt.name, actions, tt.wantActions)
+						break
+					}
+				}
+			}
+		})
+	}
+}
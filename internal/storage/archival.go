@@ -122,8 +122,18 @@ func (s *ArchivalService) runArchival() {
 			Msg("archival: cleaned up expired nonces")
 	}
 
+	// Cleanup expired idempotency keys
+	idempotencyCount, err := s.store.CleanupExpiredIdempotencyKeys(ctx)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("archival: failed to cleanup expired idempotency keys")
+	} else if idempotencyCount > 0 {
+		s.logger.Info().
+			Int64("count", idempotencyCount).
+			Msg("archival: cleaned up expired idempotency keys")
+	}
+
 	// Record archival metrics
-	totalRecords := paymentCount + nonceCount
+	totalRecords := paymentCount + nonceCount + idempotencyCount
 	if s.metrics != nil && totalRecords > 0 {
 		s.metrics.ObserveArchival(totalRecords)
 	}
@@ -131,6 +141,7 @@ func (s *ArchivalService) runArchival() {
 	s.logger.Info().
 		Int64("paymentsArchived", paymentCount).
 		Int64("noncesDeleted", nonceCount).
+		Int64("idempotencyKeysDeleted", idempotencyCount).
 		Msg("archival: archival pass completed")
 }
 
@@ -155,8 +166,13 @@ func (s *ArchivalService) RunNow() error {
 		return fmt.Errorf("cleanup expired nonces: %w", err)
 	}
 
+	idempotencyCount, err := s.store.CleanupExpiredIdempotencyKeys(ctx)
+	if err != nil {
+		return fmt.Errorf("cleanup expired idempotency keys: %w", err)
+	}
+
 	// Record archival metrics
-	totalRecords := paymentCount + nonceCount
+	totalRecords := paymentCount + nonceCount + idempotencyCount
 	if s.metrics != nil && totalRecords > 0 {
 		s.metrics.ObserveArchival(totalRecords)
 	}
@@ -164,6 +180,7 @@ func (s *ArchivalService) RunNow() error {
 	s.logger.Info().
 		Int64("paymentsArchived", paymentCount).
 		Int64("noncesDeleted", nonceCount).
+		Int64("idempotencyKeysDeleted", idempotencyCount).
 		Msg("archival: manual archival completed")
 
 	return nil
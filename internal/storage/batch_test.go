@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -181,6 +182,31 @@ func TestMemoryStore_RecordPayments_DuplicateSignature(t *testing.T) {
 	}
 }
 
+func TestFileStore_RecordPayments_Atomic(t *testing.T) {
+	store, err := NewFileStore(filepath.Join(t.TempDir(), "store.json"))
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	usdc := money.MustGetAsset("USDC")
+	if err := store.RecordPayment(ctx, PaymentTransaction{Signature: "sig_existing", ResourceID: "resource1", Wallet: "wallet1", Amount: money.New(usdc, 100)}); err != nil {
+		t.Fatalf("RecordPayment failed: %v", err)
+	}
+
+	txs := []PaymentTransaction{
+		{Signature: "sig_new", ResourceID: "resource2", Wallet: "wallet2", Amount: money.New(usdc, 200)},
+		{Signature: "sig_existing", ResourceID: "resource3", Wallet: "wallet3", Amount: money.New(usdc, 300)},
+	}
+	if err := store.RecordPayments(ctx, txs); err == nil {
+		t.Fatal("Expected error for duplicate signature, got nil")
+	}
+	if processed, _ := store.HasPaymentBeenProcessed(ctx, "sig_new"); processed {
+		t.Error("sig_new was recorded by a failed batch")
+	}
+}
+
 func TestMemoryStore_SaveRefundQuotes(t *testing.T) {
 	store := NewMemoryStore()
 	defer store.Close()
@@ -17,6 +17,7 @@ var ErrCartExpired = errors.New("storage: cart quote expired")
 // CartItem represents a single item in a cart quote.
 type CartItem struct {
 	ResourceID string            // Resource ID from paywall config
+	VariantID  string            // Variant of the resource ("" = the resource itself)
 	Quantity   int64             // Number of this item
 	Price      money.Money       // Price per unit (locked at quote time)
 	Metadata   map[string]string // Per-item custom metadata
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+// ErrContributionExceedsTotal is returned when a partial payment would take a cart past its total.
+var ErrContributionExceedsTotal = errors.New("storage: contribution exceeds cart total")
+
+// CartContribution is one partial payment toward a cart split across several wallets.
+// Contributions are kept with the payment records after the cart itself expires.
+type CartContribution struct {
+	CartID    string      `json:"cartId"`
+	Signature string      `json:"signature"` // Payment transaction signature (unique per cart)
+	Wallet    string      `json:"wallet"`
+	Amount    money.Money `json:"amount"`
+	PaidAt    time.Time   `json:"paidAt"`
+}
+
+// validateCartContribution checks a contribution against the cart total before it is stored.
+func validateCartContribution(c CartContribution, total money.Money) error {
+	if c.CartID == "" || c.Signature == "" {
+		return fmt.Errorf("contribution cart id and signature required")
+	}
+	if !c.Amount.IsPositive() {
+		return fmt.Errorf("contribution %s: amount must be positive", c.Signature)
+	}
+	if c.Amount.Asset.Code != total.Asset.Code {
+		return fmt.Errorf("contribution %s: paid in %s, cart total is in %s", c.Signature, c.Amount.Asset.Code, total.Asset.Code)
+	}
+	return nil
+}
+
+// addContributionToMap implements AddCartContribution for the map-backed stores. Callers hold
+// the write lock. It reports the cart's paid amount and whether the contribution was added.
+func addContributionToMap(contributions map[string][]CartContribution, c CartContribution, total money.Money) (money.Money, bool, error) {
+	existing := contributions[c.CartID]
+	paid, err := sumContributions(existing, total.Asset)
+	if err != nil {
+		return money.Money{}, false, err
+	}
+	for _, e := range existing {
+		if e.Signature == c.Signature {
+			return paid, false, nil // Already recorded
+		}
+	}
+	if paid.Atomic+c.Amount.Atomic > total.Atomic {
+		return paid, false, exceedsTotal(paid, total, c.Amount)
+	}
+	contributions[c.CartID] = append(existing, c)
+	paid.Atomic += c.Amount.Atomic
+	return paid, true, nil
+}
+
+// exceedsTotal builds the error returned when amount would take a cart's paid amount past total.
+func exceedsTotal(paid, total, amount money.Money) error {
+	return fmt.Errorf("%w: %s of %s %s paid, contribution %s", ErrContributionExceedsTotal, paid.ToMajor(), total.ToMajor(), total.Asset.Code, amount.ToMajor())
+}
+
+// sumContributions totals a cart's contributions in asset.
+func sumContributions(contributions []CartContribution, asset money.Asset) (money.Money, error) {
+	paid := money.Zero(asset)
+	for _, c := range contributions {
+		var err error
+		if paid, err = paid.Add(c.Amount); err != nil {
+			return money.Money{}, err
+		}
+	}
+	return paid, nil
+}
@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+// AddCartContribution records a partial payment toward a cart and returns the cart's paid amount.
+func (s *FileStore) AddCartContribution(_ context.Context, contribution CartContribution, total money.Money) (money.Money, error) {
+	if err := validateCartContribution(contribution, total); err != nil {
+		return money.Money{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	paid, added, err := addContributionToMap(s.cartContributions, contribution, total)
+	if added {
+		s.markDirty()
+	}
+	return paid, err
+}
+
+// ListCartContributions returns a cart's contributions in the order they were paid.
+func (s *FileStore) ListCartContributions(_ context.Context, cartID string) ([]CartContribution, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]CartContribution(nil), s.cartContributions[cartID]...), nil
+}
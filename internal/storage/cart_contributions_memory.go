@@ -0,0 +1,28 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+// AddCartContribution records a partial payment toward a cart and returns the cart's paid amount.
+func (m *MemoryStore) AddCartContribution(_ context.Context, contribution CartContribution, total money.Money) (money.Money, error) {
+	if err := validateCartContribution(contribution, total); err != nil {
+		return money.Money{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	paid, _, err := addContributionToMap(m.cartContributions, contribution, total)
+	return paid, err
+}
+
+// ListCartContributions returns a cart's contributions in the order they were paid.
+func (m *MemoryStore) ListCartContributions(_ context.Context, cartID string) ([]CartContribution, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return append([]CartContribution(nil), m.cartContributions[cartID]...), nil
+}
@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+const cartContributionsCollection = "cart_contributions"
+
+// cartContributionsDocument holds a cart's contributions with their running total, so a
+// contribution can be checked against the cart total and added in a single atomic update.
+type cartContributionsDocument struct {
+	CartID        string                 `bson:"_id"`
+	Asset         string                 `bson:"asset"`
+	Paid          int64                  `bson:"paid"`
+	Contributions []contributionDocument `bson:"contributions"`
+}
+
+type contributionDocument struct {
+	Signature string    `bson:"signature"`
+	Wallet    string    `bson:"wallet"`
+	Amount    int64     `bson:"amount"`
+	PaidAt    time.Time `bson:"paid_at"`
+}
+
+// AddCartContribution records a partial payment toward a cart and returns the cart's paid amount.
+func (s *MongoDBStore) AddCartContribution(ctx context.Context, contribution CartContribution, total money.Money) (money.Money, error) {
+	if err := validateCartContribution(contribution, total); err != nil {
+		return money.Money{}, err
+	}
+	if contribution.Amount.Atomic > total.Atomic {
+		return money.Money{}, exceedsTotal(money.Zero(total.Asset), total, contribution.Amount)
+	}
+
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	coll := s.db.Collection(cartContributionsCollection)
+	amount := contribution.Amount.Atomic
+	filter := bson.M{
+		"_id":                     contribution.CartID,
+		"contributions.signature": bson.M{"$ne": contribution.Signature},
+		"$expr":                   bson.M{"$lte": bson.A{bson.M{"$add": bson.A{"$paid", amount}}, total.Atomic}},
+	}
+	update := bson.M{
+		"$inc": bson.M{"paid": amount},
+		"$push": bson.M{"contributions": contributionDocument{
+			Signature: contribution.Signature,
+			Wallet:    contribution.Wallet,
+			Amount:    amount,
+			PaidAt:    contribution.PaidAt,
+		}},
+		"$setOnInsert": bson.M{"asset": total.Asset.Code},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var doc cartContributionsDocument
+	err := coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&doc)
+	if err == nil {
+		return money.New(total.Asset, doc.Paid), nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return money.Money{}, fmt.Errorf("add cart contribution: %w", err)
+	}
+
+	// The cart already has contributions and the filter didn't match: either this signature is
+	// recorded or the contribution would exceed the total
+	if err := coll.FindOne(ctx, bson.M{"_id": contribution.CartID}).Decode(&doc); err != nil {
+		return money.Money{}, fmt.Errorf("get cart contributions: %w", err)
+	}
+	paid := money.New(total.Asset, doc.Paid)
+	for _, c := range doc.Contributions {
+		if c.Signature == contribution.Signature {
+			return paid, nil // Already recorded
+		}
+	}
+	return paid, exceedsTotal(paid, total, contribution.Amount)
+}
+
+// ListCartContributions returns a cart's contributions in the order they were paid.
+func (s *MongoDBStore) ListCartContributions(ctx context.Context, cartID string) ([]CartContribution, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	var doc cartContributionsDocument
+	err := s.db.Collection(cartContributionsCollection).FindOne(ctx, bson.M{"_id": cartID}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get cart contributions: %w", err)
+	}
+	asset, err := money.GetAsset(doc.Asset)
+	if err != nil {
+		return nil, fmt.Errorf("get asset %s: %w", doc.Asset, err)
+	}
+
+	contributions := make([]CartContribution, 0, len(doc.Contributions))
+	for _, c := range doc.Contributions {
+		contributions = append(contributions, CartContribution{
+			CartID:    cartID,
+			Signature: c.Signature,
+			Wallet:    c.Wallet,
+			Amount:    money.New(asset, c.Amount),
+			PaidAt:    c.PaidAt,
+		})
+	}
+	return contributions, nil
+}
@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+// AddCartContribution records a partial payment toward a cart and returns the cart's paid amount.
+// Contributions to the same cart are serialized with a transaction-scoped advisory lock.
+func (s *PostgresStore) AddCartContribution(ctx context.Context, contribution CartContribution, total money.Money) (money.Money, error) {
+	if err := validateCartContribution(contribution, total); err != nil {
+		return money.Money{}, err
+	}
+
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return money.Money{}, fmt.Errorf("begin cart contribution tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, contribution.CartID); err != nil {
+		return money.Money{}, fmt.Errorf("lock cart contributions: %w", err)
+	}
+
+	sumQuery := fmt.Sprintf(`
+		SELECT COALESCE(SUM(amount), 0), COALESCE(BOOL_OR(signature = $2), false)
+		FROM %s WHERE cart_id = $1
+	`, s.cartContributionsTableName)
+	var paidAtomic int64
+	var recorded bool
+	if err := tx.QueryRowContext(ctx, sumQuery, contribution.CartID, contribution.Signature).Scan(&paidAtomic, &recorded); err != nil {
+		return money.Money{}, fmt.Errorf("sum cart contributions: %w", err)
+	}
+	paid := money.New(total.Asset, paidAtomic)
+	if recorded {
+		return paid, nil // Already recorded
+	}
+	if paidAtomic+contribution.Amount.Atomic > total.Atomic {
+		return paid, exceedsTotal(paid, total, contribution.Amount)
+	}
+
+	insertQuery := fmt.Sprintf(`
+		INSERT INTO %s (cart_id, signature, wallet, amount, asset, paid_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, s.cartContributionsTableName)
+	if _, err := tx.ExecContext(ctx, insertQuery,
+		contribution.CartID, contribution.Signature, contribution.Wallet,
+		contribution.Amount.Atomic, contribution.Amount.Asset.Code, contribution.PaidAt.UTC(),
+	); err != nil {
+		return money.Money{}, fmt.Errorf("insert cart contribution: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return money.Money{}, fmt.Errorf("commit cart contribution: %w", err)
+	}
+	return money.New(total.Asset, paidAtomic+contribution.Amount.Atomic), nil
+}
+
+// ListCartContributions returns a cart's contributions in the order they were paid.
+func (s *PostgresStore) ListCartContributions(ctx context.Context, cartID string) ([]CartContribution, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`
+		SELECT signature, wallet, amount, asset, paid_at
+		FROM %s WHERE cart_id = $1
+		ORDER BY paid_at, signature
+	`, s.cartContributionsTableName)
+	rows, err := s.db.QueryContext(ctx, query, cartID)
+	if err != nil {
+		return nil, fmt.Errorf("list cart contributions: %w", err)
+	}
+	defer rows.Close()
+
+	var contributions []CartContribution
+	for rows.Next() {
+		c := CartContribution{CartID: cartID}
+		var amountAtomic int64
+		var assetCode string
+		if err := rows.Scan(&c.Signature, &c.Wallet, &amountAtomic, &assetCode, &c.PaidAt); err != nil {
+			return nil, fmt.Errorf("scan cart contribution: %w", err)
+		}
+		asset, err := money.GetAsset(assetCode)
+		if err != nil {
+			return nil, fmt.Errorf("get asset %s: %w", assetCode, err)
+		}
+		c.Amount = money.New(asset, amountAtomic)
+		contributions = append(contributions, c)
+	}
+	return contributions, rows.Err()
+}
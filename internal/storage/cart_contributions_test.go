@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+func TestCartContributions(t *testing.T) {
+	backends := []struct {
+		name string
+		open func(t *testing.T, path string) Store
+	}{
+		{name: "memory", open: func(t *testing.T, _ string) Store { return NewMemoryStore() }},
+		{
+			name: "file",
+			open: func(t *testing.T, path string) Store {
+				store, err := NewFileStore(path)
+				if err != nil {
+					t.Fatalf("NewFileStore: %v", err)
+				}
+				return store
+			},
+		},
+	}
+
+	usdc := money.MustGetAsset("USDC")
+	total := money.New(usdc, 3000000)
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "store.json")
+			store := backend.open(t, path)
+			ctx := context.Background()
+			now := time.Now()
+
+			steps := []struct {
+				name     string
+				sig      string
+				amount   money.Money
+				wantErr  error
+				wantPaid int64
+			}{
+				{name: "first wallet", sig: "sig-a", amount: money.New(usdc, 1000000), wantPaid: 1000000},
+				{name: "repeated signature", sig: "sig-a", amount: money.New(usdc, 1000000), wantPaid: 1000000},
+				{name: "over the total", sig: "sig-b", amount: money.New(usdc, 2500000), wantErr: ErrContributionExceedsTotal, wantPaid: 1000000},
+				{name: "covers the rest", sig: "sig-c", amount: money.New(usdc, 2000000), wantPaid: 3000000},
+			}
+			for _, step := range steps {
+				paid, err := store.AddCartContribution(ctx, CartContribution{CartID: "cart_a", Signature: step.sig, Wallet: "wallet-" + step.sig, Amount: step.amount, PaidAt: now}, total)
+				if !errors.Is(err, step.wantErr) {
+					t.Fatalf("%s: AddCartContribution err = %v, want %v", step.name, err, step.wantErr)
+				}
+				if err == nil && paid.Atomic != step.wantPaid {
+					t.Errorf("%s: paid = %d, want %d", step.name, paid.Atomic, step.wantPaid)
+				}
+			}
+
+			if _, err := store.AddCartContribution(ctx, CartContribution{CartID: "cart_a", Signature: "sig-d", Amount: money.New(money.MustGetAsset("USDT"), 1)}, total); err == nil {
+				t.Error("contribution in another token accepted")
+			}
+
+			if backend.name == "file" {
+				_ = store.Close()
+				store = backend.open(t, path)
+			}
+			defer store.Close()
+
+			contributions, err := store.ListCartContributions(ctx, "cart_a")
+			if err != nil {
+				t.Fatalf("ListCartContributions: %v", err)
+			}
+			if len(contributions) != 2 || contributions[0].Signature != "sig-a" || contributions[1].Signature != "sig-c" || contributions[1].Amount.Atomic != 2000000 {
+				t.Errorf("contributions = %+v, want sig-a then sig-c", contributions)
+			}
+			if other, _ := store.ListCartContributions(ctx, "cart_b"); len(other) != 0 {
+				t.Errorf("unrelated cart has contributions: %+v", other)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"sort"
+	"time"
+)
+
+// couponCodesKey is the payment and cart quote metadata key listing the applied coupons,
+// comma-separated.
+const couponCodesKey = "coupon_codes"
+
+// appliedCouponsIn reports whether a record created at createdAt with metadata applied coupons
+// and was created in [since, until).
+func appliedCouponsIn(metadata map[string]string, createdAt, since, until time.Time) bool {
+	return metadata[couponCodesKey] != "" && !createdAt.Before(since) && createdAt.Before(until)
+}
+
+// sortPaymentsOldestFirst orders payments by when they were verified.
+func sortPaymentsOldestFirst(payments []PaymentTransaction) {
+	sort.SliceStable(payments, func(i, j int) bool {
+		return payments[i].CreatedAt.Before(payments[j].CreatedAt)
+	})
+}
+
+// sortCartQuotesOldestFirst orders cart quotes by when they were created.
+func sortCartQuotesOldestFirst(quotes []CartQuote) {
+	sort.SliceStable(quotes, func(i, j int) bool {
+		return quotes[i].CreatedAt.Before(quotes[j].CreatedAt)
+	})
+}
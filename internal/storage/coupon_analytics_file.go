@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// ListCouponPayments returns the payments made with coupons in [since, until), oldest first.
+func (s *FileStore) ListCouponPayments(_ context.Context, since, until time.Time) ([]PaymentTransaction, error) {
+	s.mu.RLock()
+	var payments []PaymentTransaction
+	for _, tx := range s.paymentTransactions {
+		if appliedCouponsIn(tx.Metadata, tx.CreatedAt, since, until) {
+			payments = append(payments, tx)
+		}
+	}
+	s.mu.RUnlock()
+
+	sortPaymentsOldestFirst(payments)
+	return payments, nil
+}
+
+// ListCouponCartQuotes returns the cart quotes priced with coupons in [since, until), oldest
+// first, including expired quotes not yet cleaned up.
+func (s *FileStore) ListCouponCartQuotes(_ context.Context, since, until time.Time) ([]CartQuote, error) {
+	s.mu.RLock()
+	var quotes []CartQuote
+	for _, quote := range s.cartQuotes {
+		if appliedCouponsIn(quote.Metadata, quote.CreatedAt, since, until) {
+			quotes = append(quotes, quote)
+		}
+	}
+	s.mu.RUnlock()
+
+	sortCartQuotesOldestFirst(quotes)
+	return quotes, nil
+}
@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// ListCouponPayments returns the payments made with coupons in [since, until), oldest first.
+func (m *MemoryStore) ListCouponPayments(_ context.Context, since, until time.Time) ([]PaymentTransaction, error) {
+	m.mu.RLock()
+	var payments []PaymentTransaction
+	for _, tx := range m.paymentTransactions {
+		if appliedCouponsIn(tx.Metadata, tx.CreatedAt, since, until) {
+			payments = append(payments, tx)
+		}
+	}
+	m.mu.RUnlock()
+
+	sortPaymentsOldestFirst(payments)
+	return payments, nil
+}
+
+// ListCouponCartQuotes returns the cart quotes priced with coupons in [since, until), oldest
+// first, including expired quotes not yet cleaned up.
+func (m *MemoryStore) ListCouponCartQuotes(_ context.Context, since, until time.Time) ([]CartQuote, error) {
+	m.mu.RLock()
+	var quotes []CartQuote
+	for _, quote := range m.cartQuotes {
+		if appliedCouponsIn(quote.Metadata, quote.CreatedAt, since, until) {
+			quotes = append(quotes, quote)
+		}
+	}
+	m.mu.RUnlock()
+
+	sortCartQuotesOldestFirst(quotes)
+	return quotes, nil
+}
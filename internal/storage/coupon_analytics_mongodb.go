@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+// ListCouponPayments returns the payments made with coupons in [since, until), oldest first.
+func (s *MongoDBStore) ListCouponPayments(ctx context.Context, since, until time.Time) ([]PaymentTransaction, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	filter := bson.M{
+		"created_at":            bson.M{"$gte": since, "$lt": until},
+		"metadata.coupon_codes": bson.M{"$exists": true, "$ne": ""},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := s.paymentTransactions.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("mongodb: find coupon payments: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var payments []PaymentTransaction
+	for cursor.Next(ctx) {
+		var mongoTx mongoPaymentTransaction
+		if err := cursor.Decode(&mongoTx); err != nil {
+			return nil, fmt.Errorf("mongodb: decode coupon payment: %w", err)
+		}
+		asset, err := money.GetAsset(mongoTx.Asset)
+		if err != nil {
+			return nil, fmt.Errorf("invalid asset %q: %w", mongoTx.Asset, err)
+		}
+		payments = append(payments, PaymentTransaction{
+			Signature:  mongoTx.Signature,
+			ResourceID: mongoTx.ResourceID,
+			Wallet:     mongoTx.Wallet,
+			Amount:     money.Money{Asset: asset, Atomic: mongoTx.Amount},
+			CreatedAt:  mongoTx.CreatedAt,
+			Metadata:   mongoTx.Metadata,
+		})
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("mongodb: cursor error: %w", err)
+	}
+	return payments, nil
+}
+
+// ListCouponCartQuotes returns the cart quotes priced with coupons in [since, until), oldest
+// first, including expired quotes not yet cleaned up.
+func (s *MongoDBStore) ListCouponCartQuotes(ctx context.Context, since, until time.Time) ([]CartQuote, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	filter := bson.M{
+		"createdat":             bson.M{"$gte": since, "$lt": until},
+		"metadata.coupon_codes": bson.M{"$exists": true, "$ne": ""},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "createdat", Value: 1}})
+	cursor, err := s.cartQuotes.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("mongodb: find coupon cart quotes: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var quotes []CartQuote
+	for cursor.Next(ctx) {
+		var mongoQuote mongoCartQuote
+		if err := cursor.Decode(&mongoQuote); err != nil {
+			return nil, fmt.Errorf("mongodb: decode cart quote: %w", err)
+		}
+		quote, err := convertMongoCartQuote(mongoQuote, mongoQuote.ID)
+		if err != nil {
+			return nil, fmt.Errorf("mongodb: convert cart quote %s: %w", mongoQuote.ID, err)
+		}
+		quotes = append(quotes, quote)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("mongodb: cursor error: %w", err)
+	}
+	return quotes, nil
+}
@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+// ListCouponPayments returns the payments made with coupons in [since, until), oldest first.
+func (s *PostgresStore) ListCouponPayments(ctx context.Context, since, until time.Time) ([]PaymentTransaction, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`
+		SELECT signature, resource_id, wallet, amount, asset, created_at, metadata
+		FROM %s
+		WHERE created_at >= $1 AND created_at < $2 AND COALESCE(metadata->>'coupon_codes', '') <> ''
+		ORDER BY created_at
+	`, s.paymentTransactionsTableName)
+
+	rows, err := s.db.QueryContext(ctx, query, since.UTC(), until.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("query coupon payments: %w", err)
+	}
+	defer rows.Close()
+
+	var payments []PaymentTransaction
+	for rows.Next() {
+		var tx PaymentTransaction
+		var metadataJSON []byte
+		var amountAtomic int64
+		var assetCode string
+		if err := rows.Scan(&tx.Signature, &tx.ResourceID, &tx.Wallet, &amountAtomic, &assetCode, &tx.CreatedAt, &metadataJSON); err != nil {
+			return nil, fmt.Errorf("scan coupon payment: %w", err)
+		}
+		asset, err := money.GetAsset(assetCode)
+		if err != nil {
+			return nil, fmt.Errorf("get asset %s: %w", assetCode, err)
+		}
+		tx.Amount = money.New(asset, amountAtomic)
+		if len(metadataJSON) > 0 {
+			if err := json.Unmarshal(metadataJSON, &tx.Metadata); err != nil {
+				return nil, fmt.Errorf("unmarshal metadata: %w", err)
+			}
+		}
+		payments = append(payments, tx)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate coupon payments: %w", err)
+	}
+	return payments, nil
+}
+
+// ListCouponCartQuotes returns the cart quotes priced with coupons in [since, until), oldest
+// first, including expired quotes not yet cleaned up.
+func (s *PostgresStore) ListCouponCartQuotes(ctx context.Context, since, until time.Time) ([]CartQuote, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`
+		SELECT id, items, total_amount, total_asset, metadata, created_at, expires_at, wallet_paid_by
+		FROM %s
+		WHERE created_at >= $1 AND created_at < $2 AND COALESCE(metadata->>'coupon_codes', '') <> ''
+		ORDER BY created_at
+	`, s.cartQuotesTableName)
+
+	rows, err := s.db.QueryContext(ctx, query, since.UTC(), until.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("query coupon cart quotes: %w", err)
+	}
+	defer rows.Close()
+
+	var quotes []CartQuote
+	for rows.Next() {
+		var quote CartQuote
+		var itemsJSON, metadataJSON []byte
+		var totalAtomic int64
+		var totalAsset string
+		if err := rows.Scan(&quote.ID, &itemsJSON, &totalAtomic, &totalAsset,
+			&metadataJSON, &quote.CreatedAt, &quote.ExpiresAt, &quote.WalletPaidBy); err != nil {
+			return nil, fmt.Errorf("scan coupon cart quote: %w", err)
+		}
+		asset, err := money.GetAsset(totalAsset)
+		if err != nil {
+			return nil, fmt.Errorf("get asset %s: %w", totalAsset, err)
+		}
+		quote.Total = money.New(asset, totalAtomic)
+		if err := json.Unmarshal(itemsJSON, &quote.Items); err != nil {
+			return nil, fmt.Errorf("unmarshal items: %w", err)
+		}
+		if len(metadataJSON) > 0 {
+			if err := json.Unmarshal(metadataJSON, &quote.Metadata); err != nil {
+				return nil, fmt.Errorf("unmarshal metadata: %w", err)
+			}
+		}
+		quotes = append(quotes, quote)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate coupon cart quotes: %w", err)
+	}
+	return quotes, nil
+}
@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+func TestCouponAnalyticsListing(t *testing.T) {
+	backends := []struct {
+		name string
+		open func(t *testing.T, path string) Store
+	}{
+		{name: "memory", open: func(t *testing.T, _ string) Store { return NewMemoryStore() }},
+		{
+			name: "file",
+			open: func(t *testing.T, path string) Store {
+				store, err := NewFileStore(path)
+				if err != nil {
+					t.Fatalf("NewFileStore: %v", err)
+				}
+				return store
+			},
+		},
+	}
+
+	usdc := money.MustGetAsset("USDC")
+	now := time.Now().UTC().Truncate(time.Second)
+	payment := func(signature, codes string, age time.Duration) PaymentTransaction {
+		tx := PaymentTransaction{
+			Signature:  signature,
+			ResourceID: "demo-content",
+			Wallet:     "wallet",
+			Amount:     money.New(usdc, 900000),
+			CreatedAt:  now.Add(-age),
+			Metadata:   map[string]string{"status": "verified"},
+		}
+		if codes != "" {
+			tx.Metadata["coupon_codes"] = codes
+		}
+		return tx
+	}
+	cart := func(id, codes string, age time.Duration) CartQuote {
+		quote := CartQuote{
+			ID:        id,
+			Total:     money.New(usdc, 900000),
+			Metadata:  map[string]string{},
+			CreatedAt: now.Add(-age),
+			ExpiresAt: now.Add(time.Hour),
+		}
+		if codes != "" {
+			quote.Metadata["coupon_codes"] = codes
+		}
+		return quote
+	}
+
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "store.json")
+			store := backend.open(t, path)
+			ctx := context.Background()
+
+			for _, tx := range []PaymentTransaction{
+				payment("sig-new", "SAVE10", time.Minute),
+				payment("sig-old", "SAVE10,SITE5", 2*time.Hour),
+				payment("sig-none", "", time.Minute),
+				payment("sig-outside", "SAVE10", 48*time.Hour),
+			} {
+				if err := store.RecordPayment(ctx, tx); err != nil {
+					t.Fatalf("RecordPayment %s: %v", tx.Signature, err)
+				}
+			}
+			for _, quote := range []CartQuote{
+				cart("cart_new", "SAVE10", time.Minute),
+				cart("cart_old", "SAVE10", 2*time.Hour),
+				cart("cart_none", "", time.Minute),
+			} {
+				if err := store.SaveCartQuote(ctx, quote); err != nil {
+					t.Fatalf("SaveCartQuote %s: %v", quote.ID, err)
+				}
+			}
+
+			if backend.name == "file" {
+				_ = store.Close()
+				store = backend.open(t, path)
+			}
+			defer store.Close()
+
+			since, until := now.Add(-24*time.Hour), now
+			payments, err := store.ListCouponPayments(ctx, since, until)
+			if err != nil {
+				t.Fatalf("ListCouponPayments: %v", err)
+			}
+			if len(payments) != 2 || payments[0].Signature != "sig-old" || payments[1].Signature != "sig-new" {
+				t.Fatalf("payments = %+v, want sig-old then sig-new", payments)
+			}
+			if payments[0].Metadata["coupon_codes"] != "SAVE10,SITE5" {
+				t.Errorf("sig-old coupon_codes = %q, want SAVE10,SITE5", payments[0].Metadata["coupon_codes"])
+			}
+
+			quotes, err := store.ListCouponCartQuotes(ctx, since, until)
+			if err != nil {
+				t.Fatalf("ListCouponCartQuotes: %v", err)
+			}
+			if len(quotes) != 2 || quotes[0].ID != "cart_old" || quotes[1].ID != "cart_new" {
+				t.Fatalf("quotes = %+v, want cart_old then cart_new", quotes)
+			}
+			if recent, _ := store.ListCouponCartQuotes(ctx, now.Add(-time.Hour), until); len(recent) != 1 {
+				t.Errorf("last hour has %d cart quotes, want 1", len(recent))
+			}
+		})
+	}
+}
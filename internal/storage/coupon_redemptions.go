@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrCouponRedemptionLimit indicates a wallet or email has used a coupon as many times as its
+// per-wallet limit allows.
+var ErrCouponRedemptionLimit = errors.New("storage: coupon redemption limit reached")
+
+// CouponRedemption is one use of a coupon by a wallet (x402) or email (Stripe).
+type CouponRedemption struct {
+	Code       string    `json:"code"`
+	Redeemer   string    `json:"redeemer"`  // Paying wallet, or the customer's email for Stripe
+	Signature  string    `json:"signature"` // Payment the coupon was used on
+	RedeemedAt time.Time `json:"redeemedAt"`
+}
+
+// validateCouponRedemption checks a redemption before it is recorded.
+func validateCouponRedemption(r CouponRedemption) error {
+	if r.Code == "" || r.Redeemer == "" || r.Signature == "" {
+		return fmt.Errorf("coupon redemption code, redeemer, and signature required")
+	}
+	return nil
+}
+
+// couponRedemptionKey keys a redeemer's uses of a coupon in the map-backed stores.
+func couponRedemptionKey(code, redeemer string) string {
+	return code + "/" + redeemer
+}
+
+// addRedemptionToMap implements RecordCouponRedemption for the map-backed stores. Callers hold
+// the write lock. It reports whether the redemption was added.
+func addRedemptionToMap(redemptions map[string][]CouponRedemption, r CouponRedemption, limit int) (bool, error) {
+	key := couponRedemptionKey(r.Code, r.Redeemer)
+	for _, existing := range redemptions[key] {
+		if existing.Signature == r.Signature {
+			return false, nil
+		}
+	}
+	if limit > 0 && len(redemptions[key]) >= limit {
+		return false, redemptionLimitReached(r, limit)
+	}
+	redemptions[key] = append(redemptions[key], r)
+	return true, nil
+}
+
+// redemptionLimitReached wraps ErrCouponRedemptionLimit with the coupon and limit.
+func redemptionLimitReached(r CouponRedemption, limit int) error {
+	return fmt.Errorf("%w: %s allows %d per wallet", ErrCouponRedemptionLimit, r.Code, limit)
+}
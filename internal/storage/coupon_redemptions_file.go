@@ -0,0 +1,27 @@
+package storage
+
+import "context"
+
+// RecordCouponRedemption records a use of a coupon unless the redeemer has reached limit.
+func (s *FileStore) RecordCouponRedemption(_ context.Context, redemption CouponRedemption, limit int) error {
+	if err := validateCouponRedemption(redemption); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	added, err := addRedemptionToMap(s.couponRedemptions, redemption, limit)
+	if added {
+		s.markDirty()
+	}
+	return err
+}
+
+// CountCouponRedemptions returns how many times redeemer has used a coupon.
+func (s *FileStore) CountCouponRedemptions(_ context.Context, code, redeemer string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.couponRedemptions[couponRedemptionKey(code, redeemer)]), nil
+}
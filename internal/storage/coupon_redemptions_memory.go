@@ -0,0 +1,24 @@
+package storage
+
+import "context"
+
+// RecordCouponRedemption records a use of a coupon unless the redeemer has reached limit.
+func (m *MemoryStore) RecordCouponRedemption(_ context.Context, redemption CouponRedemption, limit int) error {
+	if err := validateCouponRedemption(redemption); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err := addRedemptionToMap(m.couponRedemptions, redemption, limit)
+	return err
+}
+
+// CountCouponRedemptions returns how many times redeemer has used a coupon.
+func (m *MemoryStore) CountCouponRedemptions(_ context.Context, code, redeemer string) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return len(m.couponRedemptions[couponRedemptionKey(code, redeemer)]), nil
+}
@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const couponRedemptionsCollection = "coupon_redemptions"
+
+// couponRedemptionsDocument holds a redeemer's uses of a coupon with their count, so a use can
+// be checked against the limit and added in a single atomic update.
+type couponRedemptionsDocument struct {
+	ID          string               `bson:"_id"` // <code>/<redeemer>
+	Code        string               `bson:"code"`
+	Redeemer    string               `bson:"redeemer"`
+	Count       int                  `bson:"count"`
+	Redemptions []redemptionDocument `bson:"redemptions"`
+}
+
+type redemptionDocument struct {
+	Signature  string    `bson:"signature"`
+	RedeemedAt time.Time `bson:"redeemed_at"`
+}
+
+// RecordCouponRedemption records a use of a coupon unless the redeemer has reached limit.
+func (s *MongoDBStore) RecordCouponRedemption(ctx context.Context, redemption CouponRedemption, limit int) error {
+	if err := validateCouponRedemption(redemption); err != nil {
+		return err
+	}
+
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	coll := s.db.Collection(couponRedemptionsCollection)
+	id := couponRedemptionKey(redemption.Code, redemption.Redeemer)
+	filter := bson.M{
+		"_id":                   id,
+		"redemptions.signature": bson.M{"$ne": redemption.Signature},
+	}
+	if limit > 0 {
+		filter["count"] = bson.M{"$lt": limit}
+	}
+	update := bson.M{
+		"$inc": bson.M{"count": 1},
+		"$push": bson.M{"redemptions": redemptionDocument{
+			Signature:  redemption.Signature,
+			RedeemedAt: redemption.RedeemedAt,
+		}},
+		"$setOnInsert": bson.M{"code": redemption.Code, "redeemer": redemption.Redeemer},
+	}
+	_, err := coll.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err == nil {
+		return nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("record coupon redemption: %w", err)
+	}
+
+	// The redeemer already has redemptions and the filter didn't match: either this signature is
+	// recorded or the limit is reached
+	var doc couponRedemptionsDocument
+	if err := coll.FindOne(ctx, bson.M{"_id": id}).Decode(&doc); err != nil {
+		return fmt.Errorf("get coupon redemptions: %w", err)
+	}
+	for _, r := range doc.Redemptions {
+		if r.Signature == redemption.Signature {
+			return nil
+		}
+	}
+	return redemptionLimitReached(redemption, limit)
+}
+
+// CountCouponRedemptions returns how many times redeemer has used a coupon.
+func (s *MongoDBStore) CountCouponRedemptions(ctx context.Context, code, redeemer string) (int, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	var doc couponRedemptionsDocument
+	err := s.db.Collection(couponRedemptionsCollection).FindOne(ctx, bson.M{"_id": couponRedemptionKey(code, redeemer)}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("count coupon redemptions: %w", err)
+	}
+	return doc.Count, nil
+}
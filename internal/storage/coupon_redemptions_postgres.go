@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+// RecordCouponRedemption records a use of a coupon unless the redeemer has reached limit.
+// Redemptions by the same redeemer are serialized with a transaction-scoped advisory lock.
+func (s *PostgresStore) RecordCouponRedemption(ctx context.Context, redemption CouponRedemption, limit int) error {
+	if err := validateCouponRedemption(redemption); err != nil {
+		return err
+	}
+
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin coupon redemption tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	key := couponRedemptionKey(redemption.Code, redemption.Redeemer)
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, key); err != nil {
+		return fmt.Errorf("lock coupon redemptions: %w", err)
+	}
+
+	countQuery := fmt.Sprintf(`
+		SELECT COUNT(*), COALESCE(BOOL_OR(signature = $3), false)
+		FROM %s WHERE code = $1 AND redeemer = $2
+	`, s.couponRedemptionsTableName)
+	var count int
+	var recorded bool
+	if err := tx.QueryRowContext(ctx, countQuery, redemption.Code, redemption.Redeemer, redemption.Signature).Scan(&count, &recorded); err != nil {
+		return fmt.Errorf("count coupon redemptions: %w", err)
+	}
+	if recorded {
+		return nil // Already recorded
+	}
+	if limit > 0 && count >= limit {
+		return redemptionLimitReached(redemption, limit)
+	}
+
+	insertQuery := fmt.Sprintf(`
+		INSERT INTO %s (code, redeemer, signature, redeemed_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (code, signature) DO NOTHING
+	`, s.couponRedemptionsTableName)
+	if _, err := tx.ExecContext(ctx, insertQuery,
+		redemption.Code, redemption.Redeemer, redemption.Signature, redemption.RedeemedAt.UTC(),
+	); err != nil {
+		return fmt.Errorf("insert coupon redemption: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit coupon redemption: %w", err)
+	}
+	return nil
+}
+
+// CountCouponRedemptions returns how many times redeemer has used a coupon.
+func (s *PostgresStore) CountCouponRedemptions(ctx context.Context, code, redeemer string) (int, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE code = $1 AND redeemer = $2`, s.couponRedemptionsTableName)
+	var count int
+	if err := s.db.QueryRowContext(ctx, query, code, redeemer).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count coupon redemptions: %w", err)
+	}
+	return count, nil
+}
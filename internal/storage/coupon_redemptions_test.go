@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCouponRedemptions(t *testing.T) {
+	backends := []struct {
+		name string
+		open func(t *testing.T, path string) Store
+	}{
+		{name: "memory", open: func(t *testing.T, _ string) Store { return NewMemoryStore() }},
+		{
+			name: "file",
+			open: func(t *testing.T, path string) Store {
+				store, err := NewFileStore(path)
+				if err != nil {
+					t.Fatalf("NewFileStore: %v", err)
+				}
+				return store
+			},
+		},
+	}
+
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "store.json")
+			store := backend.open(t, path)
+			ctx := context.Background()
+
+			redemptions := []struct {
+				name      string
+				redeemer  string
+				signature string
+				limit     int
+				wantErr   error
+			}{
+				{name: "first use", redeemer: "wallet-a", signature: "sig-1", limit: 2},
+				{name: "same payment again", redeemer: "wallet-a", signature: "sig-1", limit: 2},
+				{name: "second use", redeemer: "wallet-a", signature: "sig-2", limit: 2},
+				{name: "over the limit", redeemer: "wallet-a", signature: "sig-3", limit: 2, wantErr: ErrCouponRedemptionLimit},
+				{name: "other wallet", redeemer: "wallet-b", signature: "sig-4", limit: 2},
+				{name: "unlimited", redeemer: "wallet-a", signature: "sig-5"},
+			}
+			for _, r := range redemptions {
+				err := store.RecordCouponRedemption(ctx, CouponRedemption{Code: "ONCE", Redeemer: r.redeemer, Signature: r.signature, RedeemedAt: time.Now()}, r.limit)
+				if !errors.Is(err, r.wantErr) {
+					t.Fatalf("%s: RecordCouponRedemption err = %v, want %v", r.name, err, r.wantErr)
+				}
+			}
+			if err := store.RecordCouponRedemption(ctx, CouponRedemption{Code: "ONCE", Signature: "sig-6"}, 0); err == nil {
+				t.Fatal("RecordCouponRedemption without a redeemer succeeded, want error")
+			}
+
+			if backend.name == "file" {
+				_ = store.Close()
+				store = backend.open(t, path)
+			}
+			defer store.Close()
+
+			counts := map[string]int{"wallet-a": 3, "wallet-b": 1, "wallet-c": 0}
+			for redeemer, want := range counts {
+				if got, err := store.CountCouponRedemptions(ctx, "ONCE", redeemer); err != nil || got != want {
+					t.Errorf("CountCouponRedemptions(%s) = %d, %v, want %d", redeemer, got, err, want)
+				}
+			}
+		})
+	}
+}
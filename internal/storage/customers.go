@@ -0,0 +1,236 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Customer identity kinds: the ways a payer is known on each payment rail.
+const (
+	IdentityEmail          = "email"           // Stripe receipts and invoices
+	IdentityWallet         = "wallet"          // Solana payer
+	IdentityStripeCustomer = "stripe_customer" // Stripe customer ID (cus_...)
+)
+
+// CustomerIdentity is one email, wallet, or Stripe customer ID a customer is known by.
+type CustomerIdentity struct {
+	Kind     string    `json:"kind"`
+	Value    string    `json:"value"`
+	LinkedAt time.Time `json:"linkedAt"`
+}
+
+// Customer links the identities one payer uses across payment rails, so their payments can be
+// found whether they paid by card or from a wallet. CreatedAt and UpdatedAt are when its first
+// and latest identities were linked.
+type Customer struct {
+	ID         string             `json:"id"`
+	Identities []CustomerIdentity `json:"identities"` // Oldest first
+	CreatedAt  time.Time          `json:"createdAt"`
+	UpdatedAt  time.Time          `json:"updatedAt"`
+}
+
+// Values returns the customer's identities of kind, oldest first.
+func (c Customer) Values(kind string) []string {
+	var values []string
+	for _, identity := range c.Identities {
+		if identity.Kind == kind {
+			values = append(values, identity.Value)
+		}
+	}
+	return values
+}
+
+// NormalizeCustomerIdentity checks an identity's kind and puts its value in the form it is
+// stored under: trimmed, with emails lowercased.
+func NormalizeCustomerIdentity(kind, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", fmt.Errorf("customer %s required", kind)
+	}
+	switch kind {
+	case IdentityEmail:
+		if !strings.Contains(value, "@") {
+			return "", fmt.Errorf("customer email %q is not an email address", value)
+		}
+		return strings.ToLower(value), nil
+	case IdentityWallet, IdentityStripeCustomer:
+		return value, nil
+	default:
+		return "", fmt.Errorf("unknown customer identity kind %q", kind)
+	}
+}
+
+// PayerIdentities returns the identities a payment was made with, skipping those that are empty
+// or invalid (a Stripe payment without a collected email has no usable email).
+func PayerIdentities(email, wallet, stripeCustomerID string) []CustomerIdentity {
+	var identities []CustomerIdentity
+	for _, identity := range []CustomerIdentity{
+		{Kind: IdentityEmail, Value: email},
+		{Kind: IdentityWallet, Value: wallet},
+		{Kind: IdentityStripeCustomer, Value: stripeCustomerID},
+	} {
+		if _, err := NormalizeCustomerIdentity(identity.Kind, identity.Value); err == nil {
+			identities = append(identities, identity)
+		}
+	}
+	return identities
+}
+
+// GenerateCustomerID creates a cryptographically random customer identifier.
+func GenerateCustomerID() (string, error) {
+	b := make([]byte, 16) // 128 bits of randomness
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate customer id: %w", err)
+	}
+	return "cust_" + hex.EncodeToString(b), nil
+}
+
+// prepareCustomerIdentities normalizes identities before they are linked, dropping duplicates
+// and stamping them with now.
+func prepareCustomerIdentities(identities []CustomerIdentity, now time.Time) ([]CustomerIdentity, error) {
+	seen := make(map[string]bool, len(identities))
+	prepared := make([]CustomerIdentity, 0, len(identities))
+	for _, identity := range identities {
+		value, err := NormalizeCustomerIdentity(identity.Kind, identity.Value)
+		if err != nil {
+			return nil, err
+		}
+		key := customerIdentityKey(identity.Kind, value)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		prepared = append(prepared, CustomerIdentity{Kind: identity.Kind, Value: value, LinkedAt: now})
+	}
+	if len(prepared) == 0 {
+		return nil, fmt.Errorf("at least one customer identity required")
+	}
+	return prepared, nil
+}
+
+// customerIdentityKey identifies an identity across kinds.
+func customerIdentityKey(kind, value string) string {
+	return kind + ":" + value
+}
+
+// finishCustomer orders a customer's identities oldest first and derives its timestamps from them.
+func finishCustomer(c *Customer) {
+	sort.SliceStable(c.Identities, func(i, j int) bool {
+		return c.Identities[i].LinkedAt.Before(c.Identities[j].LinkedAt)
+	})
+	if n := len(c.Identities); n > 0 {
+		c.CreatedAt = c.Identities[0].LinkedAt
+		c.UpdatedAt = c.Identities[n-1].LinkedAt
+	}
+}
+
+// indexCustomers maps each identity of customers to the customer it belongs to.
+func indexCustomers(customers map[string]Customer) map[string]string {
+	index := make(map[string]string)
+	for id, c := range customers {
+		for _, identity := range c.Identities {
+			index[customerIdentityKey(identity.Kind, identity.Value)] = id
+		}
+	}
+	return index
+}
+
+// linkCustomerInMaps implements LinkCustomerIdentities for the map-backed stores. Callers hold
+// the write lock. It reports whether anything changed.
+func linkCustomerInMaps(customers map[string]Customer, index map[string]string, identities []CustomerIdentity, now time.Time) (Customer, bool, error) {
+	prepared, err := prepareCustomerIdentities(identities, now)
+	if err != nil {
+		return Customer{}, false, err
+	}
+
+	// The oldest customer the identities already belong to absorbs the others
+	var target Customer
+	owners := make(map[string]bool)
+	for _, identity := range prepared {
+		id, ok := index[customerIdentityKey(identity.Kind, identity.Value)]
+		if !ok || owners[id] {
+			continue
+		}
+		owners[id] = true
+		if owner := customers[id]; target.ID == "" || owner.CreatedAt.Before(target.CreatedAt) {
+			target = owner
+		}
+	}
+	if target.ID == "" {
+		if target.ID, err = GenerateCustomerID(); err != nil {
+			return Customer{}, false, err
+		}
+	}
+	target.Identities = append([]CustomerIdentity(nil), target.Identities...)
+
+	changed := false
+	for id := range owners {
+		if id == target.ID {
+			continue
+		}
+		for _, identity := range customers[id].Identities {
+			index[customerIdentityKey(identity.Kind, identity.Value)] = target.ID
+		}
+		target.Identities = append(target.Identities, customers[id].Identities...)
+		delete(customers, id)
+		changed = true
+	}
+	for _, identity := range prepared {
+		key := customerIdentityKey(identity.Kind, identity.Value)
+		if _, ok := index[key]; ok {
+			continue
+		}
+		index[key] = target.ID
+		target.Identities = append(target.Identities, identity)
+		changed = true
+	}
+
+	finishCustomer(&target)
+	customers[target.ID] = target
+	return copyCustomer(target), changed, nil
+}
+
+// findCustomerInMaps implements FindCustomer for the map-backed stores. Callers hold the read lock.
+func findCustomerInMaps(customers map[string]Customer, index map[string]string, kind, value string) (Customer, error) {
+	value, err := NormalizeCustomerIdentity(kind, value)
+	if err != nil {
+		return Customer{}, err
+	}
+	id, ok := index[customerIdentityKey(kind, value)]
+	if !ok {
+		return Customer{}, ErrNotFound
+	}
+	return copyCustomer(customers[id]), nil
+}
+
+// copyCustomer returns a customer that shares no slices with the stored one.
+func copyCustomer(c Customer) Customer {
+	c.Identities = append([]CustomerIdentity(nil), c.Identities...)
+	return c
+}
+
+// paymentsByPayerFromMap implements ListPaymentsByPayer for the map-backed stores. Callers hold
+// the read lock.
+func paymentsByPayerFromMap(payments map[string]PaymentTransaction, payers []string, limit int) []PaymentTransaction {
+	wanted := make(map[string]bool, len(payers))
+	for _, payer := range payers {
+		wanted[payer] = true
+	}
+	var matched []PaymentTransaction
+	for _, tx := range payments {
+		if tx.Wallet != "" && wanted[tx.Wallet] {
+			matched = append(matched, tx)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].CreatedAt.After(matched[j].CreatedAt)
+	})
+	if limit > 0 && len(matched) > limit {
+		matched = matched[:limit]
+	}
+	return matched
+}
@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// LinkCustomerIdentities links identities to one customer, merging customers as needed.
+func (s *FileStore) LinkCustomerIdentities(_ context.Context, identities []CustomerIdentity) (Customer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	customer, changed, err := linkCustomerInMaps(s.customers, s.customerIndex, identities, time.Now())
+	if changed {
+		s.markDirty()
+	}
+	return customer, err
+}
+
+// GetCustomer returns a customer by ID.
+func (s *FileStore) GetCustomer(_ context.Context, id string) (Customer, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	customer, ok := s.customers[id]
+	if !ok {
+		return Customer{}, ErrNotFound
+	}
+	return copyCustomer(customer), nil
+}
+
+// FindCustomer returns the customer an identity is linked to.
+func (s *FileStore) FindCustomer(_ context.Context, kind, value string) (Customer, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return findCustomerInMaps(s.customers, s.customerIndex, kind, value)
+}
+
+// ListPaymentsByPayer returns the payments made by any of payers, most recent first.
+func (s *FileStore) ListPaymentsByPayer(_ context.Context, payers []string, limit int) ([]PaymentTransaction, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return paymentsByPayerFromMap(s.paymentTransactions, payers, limit), nil
+}
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// LinkCustomerIdentities links identities to one customer, merging customers as needed.
+func (m *MemoryStore) LinkCustomerIdentities(_ context.Context, identities []CustomerIdentity) (Customer, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	customer, _, err := linkCustomerInMaps(m.customers, m.customerIndex, identities, time.Now())
+	return customer, err
+}
+
+// GetCustomer returns a customer by ID.
+func (m *MemoryStore) GetCustomer(_ context.Context, id string) (Customer, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	customer, ok := m.customers[id]
+	if !ok {
+		return Customer{}, ErrNotFound
+	}
+	return copyCustomer(customer), nil
+}
+
+// FindCustomer returns the customer an identity is linked to.
+func (m *MemoryStore) FindCustomer(_ context.Context, kind, value string) (Customer, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return findCustomerInMaps(m.customers, m.customerIndex, kind, value)
+}
+
+// ListPaymentsByPayer returns the payments made by any of payers, most recent first.
+func (m *MemoryStore) ListPaymentsByPayer(_ context.Context, payers []string, limit int) ([]PaymentTransaction, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return paymentsByPayerFromMap(m.paymentTransactions, payers, limit), nil
+}
@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+const customerIdentitiesCollection = "customer_identities"
+
+// customerIdentityDocument is an identity keyed by <kind>:<value>.
+type customerIdentityDocument struct {
+	ID         string    `bson:"_id"`
+	Kind       string    `bson:"kind"`
+	Value      string    `bson:"value"`
+	CustomerID string    `bson:"customer_id"`
+	LinkedAt   time.Time `bson:"linked_at"`
+}
+
+// LinkCustomerIdentities links identities to one customer, merging customers as needed.
+// Concurrent links of overlapping identities can leave them split across two customers; the
+// next link of those identities merges them.
+func (s *MongoDBStore) LinkCustomerIdentities(ctx context.Context, identities []CustomerIdentity) (Customer, error) {
+	prepared, err := prepareCustomerIdentities(identities, time.Now().UTC())
+	if err != nil {
+		return Customer{}, err
+	}
+
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	coll := s.db.Collection(customerIdentitiesCollection)
+	keys := make([]string, 0, len(prepared))
+	for _, identity := range prepared {
+		keys = append(keys, customerIdentityKey(identity.Kind, identity.Value))
+	}
+	owners, err := coll.Distinct(ctx, "customer_id", bson.M{"_id": bson.M{"$in": keys}})
+	if err != nil {
+		return Customer{}, fmt.Errorf("find customer identities: %w", err)
+	}
+
+	// The oldest customer the identities already belong to absorbs the others
+	var targetID string
+	if len(owners) == 0 {
+		if targetID, err = GenerateCustomerID(); err != nil {
+			return Customer{}, err
+		}
+	} else {
+		var oldest customerIdentityDocument
+		opts := options.FindOne().SetSort(bson.D{{Key: "linked_at", Value: 1}, {Key: "customer_id", Value: 1}})
+		if err := coll.FindOne(ctx, bson.M{"customer_id": bson.M{"$in": owners}}, opts).Decode(&oldest); err != nil {
+			return Customer{}, fmt.Errorf("find oldest customer: %w", err)
+		}
+		targetID = oldest.CustomerID
+		_, err := coll.UpdateMany(ctx,
+			bson.M{"customer_id": bson.M{"$in": owners}},
+			bson.M{"$set": bson.M{"customer_id": targetID}},
+		)
+		if err != nil {
+			return Customer{}, fmt.Errorf("merge customers: %w", err)
+		}
+	}
+
+	for _, identity := range prepared {
+		_, err := coll.UpdateOne(ctx,
+			bson.M{"_id": customerIdentityKey(identity.Kind, identity.Value)},
+			bson.M{"$setOnInsert": customerIdentityDocument{
+				ID:         customerIdentityKey(identity.Kind, identity.Value),
+				Kind:       identity.Kind,
+				Value:      identity.Value,
+				CustomerID: targetID,
+				LinkedAt:   identity.LinkedAt,
+			}},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return Customer{}, fmt.Errorf("insert customer identity: %w", err)
+		}
+	}
+	return s.queryCustomer(ctx, targetID)
+}
+
+// GetCustomer returns a customer by ID.
+func (s *MongoDBStore) GetCustomer(ctx context.Context, id string) (Customer, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	return s.queryCustomer(ctx, id)
+}
+
+// FindCustomer returns the customer an identity is linked to.
+func (s *MongoDBStore) FindCustomer(ctx context.Context, kind, value string) (Customer, error) {
+	value, err := NormalizeCustomerIdentity(kind, value)
+	if err != nil {
+		return Customer{}, err
+	}
+
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	var doc customerIdentityDocument
+	err = s.db.Collection(customerIdentitiesCollection).FindOne(ctx, bson.M{"_id": customerIdentityKey(kind, value)}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return Customer{}, ErrNotFound
+	}
+	if err != nil {
+		return Customer{}, fmt.Errorf("find customer identity: %w", err)
+	}
+	return s.queryCustomer(ctx, doc.CustomerID)
+}
+
+// queryCustomer loads a customer's identities (ErrNotFound if it has none).
+func (s *MongoDBStore) queryCustomer(ctx context.Context, id string) (Customer, error) {
+	cursor, err := s.db.Collection(customerIdentitiesCollection).Find(ctx, bson.M{"customer_id": id})
+	if err != nil {
+		return Customer{}, fmt.Errorf("query customer: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var docs []customerIdentityDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return Customer{}, fmt.Errorf("decode customer identities: %w", err)
+	}
+	if len(docs) == 0 {
+		return Customer{}, ErrNotFound
+	}
+	customer := Customer{ID: id, Identities: make([]CustomerIdentity, 0, len(docs))}
+	for _, doc := range docs {
+		customer.Identities = append(customer.Identities, CustomerIdentity{Kind: doc.Kind, Value: doc.Value, LinkedAt: doc.LinkedAt})
+	}
+	finishCustomer(&customer)
+	return customer, nil
+}
+
+// ListPaymentsByPayer returns the payments made by any of payers, most recent first.
+func (s *MongoDBStore) ListPaymentsByPayer(ctx context.Context, payers []string, limit int) ([]PaymentTransaction, error) {
+	if len(payers) == 0 {
+		return nil, nil
+	}
+
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+	cursor, err := s.paymentTransactions.Find(ctx, bson.M{"wallet": bson.M{"$in": payers}}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("mongodb: find payer payments: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var payments []PaymentTransaction
+	for cursor.Next(ctx) {
+		var mongoTx mongoPaymentTransaction
+		if err := cursor.Decode(&mongoTx); err != nil {
+			return nil, fmt.Errorf("mongodb: decode payer payment: %w", err)
+		}
+		asset, err := money.GetAsset(mongoTx.Asset)
+		if err != nil {
+			return nil, fmt.Errorf("invalid asset %q: %w", mongoTx.Asset, err)
+		}
+		payments = append(payments, PaymentTransaction{
+			Signature:  mongoTx.Signature,
+			ResourceID: mongoTx.ResourceID,
+			Wallet:     mongoTx.Wallet,
+			Amount:     money.Money{Asset: asset, Atomic: mongoTx.Amount},
+			CreatedAt:  mongoTx.CreatedAt,
+			Metadata:   mongoTx.Metadata,
+		})
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("mongodb: cursor error: %w", err)
+	}
+	return payments, nil
+}
@@ -0,0 +1,210 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+// LinkCustomerIdentities links identities to one customer, merging customers as needed.
+// Links are serialized with a transaction-scoped advisory lock so concurrent payments by the
+// same payer can't create two customers.
+func (s *PostgresStore) LinkCustomerIdentities(ctx context.Context, identities []CustomerIdentity) (Customer, error) {
+	prepared, err := prepareCustomerIdentities(identities, time.Now().UTC())
+	if err != nil {
+		return Customer{}, err
+	}
+
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Customer{}, fmt.Errorf("begin customer link tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, s.customerIdentitiesTableName); err != nil {
+		return Customer{}, fmt.Errorf("lock customer identities: %w", err)
+	}
+
+	ownerQuery := fmt.Sprintf(`SELECT customer_id FROM %s WHERE kind = $1 AND value = $2`, s.customerIdentitiesTableName)
+	var owners []string
+	for _, identity := range prepared {
+		var id string
+		err := tx.QueryRowContext(ctx, ownerQuery, identity.Kind, identity.Value).Scan(&id)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return Customer{}, fmt.Errorf("find customer identity: %w", err)
+		}
+		owners = append(owners, id)
+	}
+
+	// The oldest customer the identities already belong to absorbs the others
+	var targetID string
+	if len(owners) == 0 {
+		if targetID, err = GenerateCustomerID(); err != nil {
+			return Customer{}, err
+		}
+	} else {
+		oldestQuery := fmt.Sprintf(`
+			SELECT customer_id FROM %s WHERE customer_id = ANY($1)
+			GROUP BY customer_id ORDER BY MIN(linked_at), customer_id LIMIT 1
+		`, s.customerIdentitiesTableName)
+		if err := tx.QueryRowContext(ctx, oldestQuery, pq.Array(owners)).Scan(&targetID); err != nil {
+			return Customer{}, fmt.Errorf("find oldest customer: %w", err)
+		}
+		mergeQuery := fmt.Sprintf(`UPDATE %s SET customer_id = $1 WHERE customer_id = ANY($2)`, s.customerIdentitiesTableName)
+		if _, err := tx.ExecContext(ctx, mergeQuery, targetID, pq.Array(owners)); err != nil {
+			return Customer{}, fmt.Errorf("merge customers: %w", err)
+		}
+	}
+
+	insertQuery := fmt.Sprintf(`
+		INSERT INTO %s (kind, value, customer_id, linked_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (kind, value) DO NOTHING
+	`, s.customerIdentitiesTableName)
+	for _, identity := range prepared {
+		if _, err := tx.ExecContext(ctx, insertQuery, identity.Kind, identity.Value, targetID, identity.LinkedAt); err != nil {
+			return Customer{}, fmt.Errorf("insert customer identity: %w", err)
+		}
+	}
+
+	customer, err := s.queryCustomer(ctx, tx, targetID)
+	if err != nil {
+		return Customer{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return Customer{}, fmt.Errorf("commit customer link: %w", err)
+	}
+	return customer, nil
+}
+
+// GetCustomer returns a customer by ID.
+func (s *PostgresStore) GetCustomer(ctx context.Context, id string) (Customer, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	return s.queryCustomer(ctx, s.db, id)
+}
+
+// FindCustomer returns the customer an identity is linked to.
+func (s *PostgresStore) FindCustomer(ctx context.Context, kind, value string) (Customer, error) {
+	value, err := NormalizeCustomerIdentity(kind, value)
+	if err != nil {
+		return Customer{}, err
+	}
+
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`SELECT customer_id FROM %s WHERE kind = $1 AND value = $2`, s.customerIdentitiesTableName)
+	var id string
+	err = s.db.QueryRowContext(ctx, query, kind, value).Scan(&id)
+	if err == sql.ErrNoRows {
+		return Customer{}, ErrNotFound
+	}
+	if err != nil {
+		return Customer{}, fmt.Errorf("find customer identity: %w", err)
+	}
+	return s.queryCustomer(ctx, s.db, id)
+}
+
+// customerQueryer is satisfied by both *sql.DB and *sql.Tx.
+type customerQueryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// queryCustomer loads a customer's identities (ErrNotFound if it has none).
+func (s *PostgresStore) queryCustomer(ctx context.Context, q customerQueryer, id string) (Customer, error) {
+	query := fmt.Sprintf(`
+		SELECT kind, value, linked_at FROM %s
+		WHERE customer_id = $1
+		ORDER BY linked_at, kind, value
+	`, s.customerIdentitiesTableName)
+	rows, err := q.QueryContext(ctx, query, id)
+	if err != nil {
+		return Customer{}, fmt.Errorf("query customer: %w", err)
+	}
+	defer rows.Close()
+
+	customer := Customer{ID: id}
+	for rows.Next() {
+		var identity CustomerIdentity
+		if err := rows.Scan(&identity.Kind, &identity.Value, &identity.LinkedAt); err != nil {
+			return Customer{}, fmt.Errorf("scan customer identity: %w", err)
+		}
+		customer.Identities = append(customer.Identities, identity)
+	}
+	if err := rows.Err(); err != nil {
+		return Customer{}, fmt.Errorf("iterate customer identities: %w", err)
+	}
+	if len(customer.Identities) == 0 {
+		return Customer{}, ErrNotFound
+	}
+	finishCustomer(&customer)
+	return customer, nil
+}
+
+// ListPaymentsByPayer returns the payments made by any of payers, most recent first.
+func (s *PostgresStore) ListPaymentsByPayer(ctx context.Context, payers []string, limit int) ([]PaymentTransaction, error) {
+	if len(payers) == 0 {
+		return nil, nil
+	}
+
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`
+		SELECT signature, resource_id, wallet, amount, asset, created_at, metadata
+		FROM %s
+		WHERE wallet = ANY($1)
+		ORDER BY created_at DESC
+	`, s.paymentTransactionsTableName)
+	args := []any{pq.Array(payers)}
+	if limit > 0 {
+		query += " LIMIT $2"
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query payer payments: %w", err)
+	}
+	defer rows.Close()
+
+	var payments []PaymentTransaction
+	for rows.Next() {
+		var tx PaymentTransaction
+		var metadataJSON []byte
+		var amountAtomic int64
+		var assetCode string
+		if err := rows.Scan(&tx.Signature, &tx.ResourceID, &tx.Wallet, &amountAtomic, &assetCode, &tx.CreatedAt, &metadataJSON); err != nil {
+			return nil, fmt.Errorf("scan payer payment: %w", err)
+		}
+		asset, err := money.GetAsset(assetCode)
+		if err != nil {
+			return nil, fmt.Errorf("get asset %s: %w", assetCode, err)
+		}
+		tx.Amount = money.New(asset, amountAtomic)
+		if len(metadataJSON) > 0 {
+			if err := json.Unmarshal(metadataJSON, &tx.Metadata); err != nil {
+				return nil, fmt.Errorf("unmarshal metadata: %w", err)
+			}
+		}
+		payments = append(payments, tx)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate payer payments: %w", err)
+	}
+	return payments, nil
+}
@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+func TestCustomers(t *testing.T) {
+	backends := []struct {
+		name string
+		open func(t *testing.T, path string) Store
+	}{
+		{name: "memory", open: func(t *testing.T, _ string) Store { return NewMemoryStore() }},
+		{
+			name: "file",
+			open: func(t *testing.T, path string) Store {
+				store, err := NewFileStore(path)
+				if err != nil {
+					t.Fatalf("NewFileStore: %v", err)
+				}
+				return store
+			},
+		},
+	}
+
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "store.json")
+			store := backend.open(t, path)
+			ctx := context.Background()
+
+			card, err := store.LinkCustomerIdentities(ctx, []CustomerIdentity{
+				{Kind: IdentityEmail, Value: " Alice@Example.com "},
+				{Kind: IdentityStripeCustomer, Value: "cus_1"},
+			})
+			if err != nil {
+				t.Fatalf("LinkCustomerIdentities card: %v", err)
+			}
+			wallet, err := store.LinkCustomerIdentities(ctx, []CustomerIdentity{{Kind: IdentityWallet, Value: "wallet-1"}})
+			if err != nil {
+				t.Fatalf("LinkCustomerIdentities wallet: %v", err)
+			}
+			if card.ID == "" || card.ID == wallet.ID {
+				t.Fatalf("customers %q and %q, want two distinct customers", card.ID, wallet.ID)
+			}
+
+			links := []struct {
+				name       string
+				identities []CustomerIdentity
+				wantErr    bool
+			}{
+				{name: "no identities", wantErr: true},
+				{name: "unknown kind", identities: []CustomerIdentity{{Kind: "phone", Value: "555"}}, wantErr: true},
+				{name: "not an email", identities: []CustomerIdentity{{Kind: IdentityEmail, Value: "alice"}}, wantErr: true},
+				{name: "relink is a no-op", identities: []CustomerIdentity{{Kind: IdentityStripeCustomer, Value: "cus_1"}}},
+				{name: "merge", identities: []CustomerIdentity{{Kind: IdentityWallet, Value: "wallet-1"}, {Kind: IdentityEmail, Value: "alice@example.com"}}},
+			}
+			for _, link := range links {
+				if _, err := store.LinkCustomerIdentities(ctx, link.identities); (err != nil) != link.wantErr {
+					t.Fatalf("%s: LinkCustomerIdentities err = %v, wantErr %v", link.name, err, link.wantErr)
+				}
+			}
+
+			if backend.name == "file" {
+				_ = store.Close()
+				store = backend.open(t, path)
+			}
+			defer store.Close()
+
+			// The older card customer absorbed the wallet customer
+			merged, err := store.FindCustomer(ctx, IdentityWallet, "wallet-1")
+			if err != nil {
+				t.Fatalf("FindCustomer wallet: %v", err)
+			}
+			if merged.ID != card.ID || len(merged.Identities) != 3 {
+				t.Fatalf("merged customer = %+v, want %s with 3 identities", merged, card.ID)
+			}
+			if got := merged.Values(IdentityEmail); len(got) != 1 || got[0] != "alice@example.com" {
+				t.Errorf("emails = %v, want the normalized address", got)
+			}
+			if !merged.CreatedAt.Equal(card.CreatedAt) || merged.UpdatedAt.Before(merged.CreatedAt) {
+				t.Errorf("merged timestamps = %v..%v, want created with the card customer", merged.CreatedAt, merged.UpdatedAt)
+			}
+			if found, err := store.FindCustomer(ctx, IdentityEmail, "ALICE@example.com"); err != nil || found.ID != card.ID {
+				t.Errorf("FindCustomer email = %+v, %v", found, err)
+			}
+			if _, err := store.GetCustomer(ctx, wallet.ID); !errors.Is(err, ErrNotFound) {
+				t.Errorf("GetCustomer absorbed customer: err = %v, want ErrNotFound", err)
+			}
+			if _, err := store.FindCustomer(ctx, IdentityWallet, "wallet-2"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("FindCustomer unlinked: err = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestListPaymentsByPayer(t *testing.T) {
+	store := NewMemoryStore()
+	defer store.Close()
+	ctx := context.Background()
+
+	usd := money.MustGetAsset("USD")
+	now := time.Now()
+	payments := []PaymentTransaction{
+		{Signature: "stripe:cs_1", ResourceID: "tee", Wallet: "alice@example.com", Amount: money.New(usd, 2000), CreatedAt: now.Add(-2 * time.Hour)},
+		{Signature: "sig-1", ResourceID: "mug", Wallet: "wallet-1", Amount: money.New(usd, 1000), CreatedAt: now.Add(-time.Hour)},
+		{Signature: "sig-2", ResourceID: "ebook", Wallet: "wallet-1", Amount: money.New(usd, 500), CreatedAt: now},
+		{Signature: "sig-3", ResourceID: "tee", Wallet: "wallet-2", Amount: money.New(usd, 2000), CreatedAt: now},
+	}
+	for _, tx := range payments {
+		if err := store.RecordPayment(ctx, tx); err != nil {
+			t.Fatalf("RecordPayment %s: %v", tx.Signature, err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		payers []string
+		limit  int
+		want   []string
+	}{
+		{name: "across rails", payers: []string{"alice@example.com", "wallet-1"}, want: []string{"sig-2", "sig-1", "stripe:cs_1"}},
+		{name: "limited", payers: []string{"alice@example.com", "wallet-1"}, limit: 1, want: []string{"sig-2"}},
+		{name: "unknown payer", payers: []string{"wallet-3"}},
+		{name: "no payers"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.ListPaymentsByPayer(ctx, tt.payers, tt.limit)
+			if err != nil {
+				t.Fatalf("ListPaymentsByPayer: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d payments, want %v", len(got), tt.want)
+			}
+			for i, tx := range got {
+				if tx.Signature != tt.want[i] {
+					t.Errorf("payment %d = %s, want %s", i, tx.Signature, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -19,6 +19,21 @@ type FileStore struct {
 	refundQuotes        map[string]RefundQuote
 	paymentTransactions map[string]PaymentTransaction
 	adminNonces         map[string]AdminNonce
+	idempotencyKeys     map[string]IdempotencyRecord
+	stock               map[string]StockLevel
+	stockReservations   map[string]StockReservation
+	cartContributions   map[string][]CartContribution
+	savedCarts          map[string]map[string]SavedCart
+	giftCards           map[string]GiftCard
+	meterAccounts       map[string]MeterAccount
+	referralConversions map[string][]ReferralConversion
+	couponRedemptions   map[string][]CouponRedemption
+	customers           map[string]Customer
+	customerIndex       map[string]string // Rebuilt from customers on load
+	refundAudit         map[string][]RefundAuditEntry
+	adminAudit          []AdminAuditEntry
+	webhookDeliveries   []WebhookDelivery
+	accessRules         map[string]AccessRule
 	stopCleanup         chan struct{}
 	cleanupDone         chan struct{}
 	dirty               bool
@@ -29,11 +44,25 @@ type FileStore struct {
 
 // fileData represents the JSON structure stored in the file.
 type fileData struct {
-	CartQuotes          map[string]CartQuote          `json:"cart_quotes"`
-	RefundQuotes        map[string]RefundQuote        `json:"refund_quotes"`
-	PaymentTransactions map[string]PaymentTransaction `json:"payment_transactions"`
-	AdminNonces         map[string]AdminNonce         `json:"admin_nonces"`
-	WebhookQueue        map[string]PendingWebhook     `json:"webhook_queue"`
+	CartQuotes          map[string]CartQuote            `json:"cart_quotes"`
+	RefundQuotes        map[string]RefundQuote          `json:"refund_quotes"`
+	PaymentTransactions map[string]PaymentTransaction   `json:"payment_transactions"`
+	AdminNonces         map[string]AdminNonce           `json:"admin_nonces"`
+	WebhookQueue        map[string]PendingWebhook       `json:"webhook_queue"`
+	IdempotencyKeys     map[string]IdempotencyRecord    `json:"idempotency_keys"`
+	Stock               map[string]StockLevel           `json:"stock"`
+	StockReservations   map[string]StockReservation     `json:"stock_reservations"`
+	CartContributions   map[string][]CartContribution   `json:"cart_contributions"`
+	SavedCarts          map[string]map[string]SavedCart `json:"saved_carts"`
+	GiftCards           map[string]GiftCard             `json:"gift_cards"`
+	MeterAccounts       map[string]MeterAccount         `json:"meter_accounts"`
+	ReferralConversions map[string][]ReferralConversion `json:"referral_conversions"`
+	CouponRedemptions   map[string][]CouponRedemption   `json:"coupon_redemptions"`
+	Customers           map[string]Customer             `json:"customers"`
+	RefundAudit         map[string][]RefundAuditEntry   `json:"refund_audit"`
+	AdminAudit          []AdminAuditEntry               `json:"admin_audit"`
+	WebhookDeliveries   []WebhookDelivery               `json:"webhook_deliveries"`
+	AccessRules         map[string]AccessRule           `json:"access_rules"`
 }
 
 // NewFileStore creates a new file-backed store.
@@ -82,6 +111,19 @@ func NewFileStore(filePath string) (*FileStore, error) {
 		refundQuotes:        make(map[string]RefundQuote),
 		paymentTransactions: make(map[string]PaymentTransaction),
 		adminNonces:         make(map[string]AdminNonce),
+		idempotencyKeys:     make(map[string]IdempotencyRecord),
+		stock:               make(map[string]StockLevel),
+		stockReservations:   make(map[string]StockReservation),
+		cartContributions:   make(map[string][]CartContribution),
+		savedCarts:          make(map[string]map[string]SavedCart),
+		giftCards:           make(map[string]GiftCard),
+		meterAccounts:       make(map[string]MeterAccount),
+		referralConversions: make(map[string][]ReferralConversion),
+		couponRedemptions:   make(map[string][]CouponRedemption),
+		customers:           make(map[string]Customer),
+		customerIndex:       make(map[string]string),
+		refundAudit:         make(map[string][]RefundAuditEntry),
+		accessRules:         make(map[string]AccessRule),
 		stopCleanup:         make(chan struct{}),
 		cleanupDone:         make(chan struct{}),
 		flushTicker:         time.NewTicker(5 * time.Second),
@@ -139,6 +181,45 @@ func (s *FileStore) load() error {
 	if fileData.AdminNonces != nil {
 		s.adminNonces = fileData.AdminNonces
 	}
+	if fileData.IdempotencyKeys != nil {
+		s.idempotencyKeys = fileData.IdempotencyKeys
+	}
+	if fileData.Stock != nil {
+		s.stock = fileData.Stock
+	}
+	if fileData.StockReservations != nil {
+		s.stockReservations = fileData.StockReservations
+	}
+	if fileData.CartContributions != nil {
+		s.cartContributions = fileData.CartContributions
+	}
+	if fileData.SavedCarts != nil {
+		s.savedCarts = fileData.SavedCarts
+	}
+	if fileData.GiftCards != nil {
+		s.giftCards = fileData.GiftCards
+	}
+	if fileData.MeterAccounts != nil {
+		s.meterAccounts = fileData.MeterAccounts
+	}
+	if fileData.ReferralConversions != nil {
+		s.referralConversions = fileData.ReferralConversions
+	}
+	if fileData.CouponRedemptions != nil {
+		s.couponRedemptions = fileData.CouponRedemptions
+	}
+	if fileData.Customers != nil {
+		s.customers = fileData.Customers
+		s.customerIndex = indexCustomers(s.customers)
+	}
+	if fileData.RefundAudit != nil {
+		s.refundAudit = fileData.RefundAudit
+	}
+	s.adminAudit = fileData.AdminAudit
+	s.webhookDeliveries = fileData.WebhookDeliveries
+	if fileData.AccessRules != nil {
+		s.accessRules = fileData.AccessRules
+	}
 
 	// Load webhook queue into data struct for webhook queue methods
 	s.data = fileData
@@ -157,6 +238,20 @@ func (s *FileStore) save() error {
 		PaymentTransactions: s.paymentTransactions,
 		AdminNonces:         s.adminNonces,
 		WebhookQueue:        s.data.WebhookQueue,
+		IdempotencyKeys:     s.idempotencyKeys,
+		Stock:               s.stock,
+		StockReservations:   s.stockReservations,
+		CartContributions:   s.cartContributions,
+		SavedCarts:          s.savedCarts,
+		GiftCards:           s.giftCards,
+		MeterAccounts:       s.meterAccounts,
+		ReferralConversions: s.referralConversions,
+		CouponRedemptions:   s.couponRedemptions,
+		Customers:           s.customers,
+		RefundAudit:         s.refundAudit,
+		AdminAudit:          s.adminAudit,
+		WebhookDeliveries:   s.webhookDeliveries,
+		AccessRules:         s.accessRules,
 	}
 	return s.saveData(data)
 }
@@ -437,6 +532,22 @@ func (s *FileStore) performCleanup() {
 		}
 	}
 
+	// Remove expired idempotency records
+	for key, record := range s.idempotencyKeys {
+		if record.IsExpiredAt(now) {
+			delete(s.idempotencyKeys, key)
+			modified = true
+		}
+	}
+
+	// Remove lapsed stock reservations
+	for id, r := range s.stockReservations {
+		if r.IsExpiredAt(now) {
+			delete(s.stockReservations, id)
+			modified = true
+		}
+	}
+
 	// NOTE: Refund requests are NOT auto-deleted when expired
 	// They must be explicitly denied by admin via DELETE /refund/:id
 	// ExpiresAt is only used to prevent stale transaction execution
@@ -488,6 +599,17 @@ func (s *FileStore) HasPaymentBeenProcessed(_ context.Context, signature string)
 	return exists, nil
 }
 
+// PaymentSignatures calls fn with every recorded payment signature.
+func (s *FileStore) PaymentSignatures(_ context.Context, fn func(signature string)) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for signature := range s.paymentTransactions {
+		fn(signature)
+	}
+	return nil
+}
+
 // GetPayment retrieves a payment transaction by signature.
 // Returns the original payment record showing which resource it was used for.
 func (s *FileStore) GetPayment(_ context.Context, signature string) (PaymentTransaction, error) {
@@ -501,6 +623,31 @@ func (s *FileStore) GetPayment(_ context.Context, signature string) (PaymentTran
 	return tx, nil
 }
 
+// Flush writes pending changes to disk now instead of on the next periodic flush.
+func (s *FileStore) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.dirty {
+		return nil
+	}
+	if err := s.save(); err != nil {
+		return err
+	}
+	s.dirty = false
+	return nil
+}
+
+// Flush persists writes that store buffers in memory (the file backend flushes every
+// 5 seconds). Wrapped stores are unwrapped; other backends write through and
+// need no flush.
+func Flush(store Store) error {
+	store = unwrapStore(store)
+	if flusher, ok := store.(interface{ Flush() error }); ok {
+		return flusher.Flush()
+	}
+	return nil
+}
+
 // Close closes the file store.
 func (s *FileStore) Close() error {
 	// Signal goroutines to stop first (without holding lock)
@@ -653,12 +800,30 @@ func (s *FileStore) SaveRefundQuotes(ctx context.Context, quotes []RefundQuote)
 	return nil
 }
 
-// RecordPayments saves multiple payment transactions using individual operations.
-func (s *FileStore) RecordPayments(ctx context.Context, txs []PaymentTransaction) error {
+// RecordPayments saves multiple payment transactions atomically: the batch fails, and nothing
+// is stored, if any signature already exists or appears twice.
+func (s *FileStore) RecordPayments(_ context.Context, txs []PaymentTransaction) error {
+	if len(txs) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen := make(map[string]bool, len(txs))
 	for i, tx := range txs {
-		if err := s.RecordPayment(ctx, tx); err != nil {
-			return fmt.Errorf("tx %d: %w", i, err)
+		if _, exists := s.paymentTransactions[tx.Signature]; exists {
+			return fmt.Errorf("tx %d: signature already used: %s", i, tx.Signature)
+		}
+		if seen[tx.Signature] {
+			return fmt.Errorf("tx %d: duplicate signature in batch: %s", i, tx.Signature)
 		}
+		seen[tx.Signature] = true
+	}
+
+	for _, tx := range txs {
+		s.paymentTransactions[tx.Signature] = tx
 	}
+	s.markDirty()
 	return nil
 }
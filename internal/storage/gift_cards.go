@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+// ErrGiftCardBalance is returned when spending from a gift card would take its balance below zero.
+var ErrGiftCardBalance = errors.New("storage: insufficient gift card balance")
+
+// GiftCard is the remaining balance of a stored-value (gift card) coupon. A card has no record
+// until it is first spent from; its balance then starts at the coupon's face value.
+type GiftCard struct {
+	Code      string      `json:"code"`
+	Balance   money.Money `json:"balance"`
+	UpdatedAt time.Time   `json:"updatedAt"`
+}
+
+// validateGiftCardAdjustment checks a balance change before it is applied.
+func validateGiftCardAdjustment(code string, faceValue, delta money.Money) error {
+	if code == "" {
+		return fmt.Errorf("gift card code required")
+	}
+	if faceValue.Atomic < 0 {
+		return fmt.Errorf("gift card %s: face value must not be negative", code)
+	}
+	if delta.Asset.Code != faceValue.Asset.Code {
+		return fmt.Errorf("gift card %s: adjustment in %s, face value is in %s", code, delta.Asset.Code, faceValue.Asset.Code)
+	}
+	return nil
+}
+
+// adjustGiftCardInMap implements AdjustGiftCard for the map-backed stores. Callers hold the
+// write lock.
+func adjustGiftCardInMap(cards map[string]GiftCard, code string, faceValue, delta money.Money, now time.Time) (GiftCard, error) {
+	card, ok := cards[code]
+	if !ok {
+		card = GiftCard{Code: code, Balance: faceValue}
+	}
+	if card.Balance.Asset.Code != delta.Asset.Code {
+		return GiftCard{}, fmt.Errorf("gift card %s: balance is in %s, adjustment in %s", code, card.Balance.Asset.Code, delta.Asset.Code)
+	}
+	if card.Balance.Atomic+delta.Atomic < 0 {
+		return card, insufficientGiftCardBalance(card, delta)
+	}
+	card.Balance.Atomic += delta.Atomic
+	card.UpdatedAt = now
+	cards[code] = card
+	return card, nil
+}
+
+// insufficientGiftCardBalance builds the error returned when delta would overdraw card.
+func insufficientGiftCardBalance(card GiftCard, delta money.Money) error {
+	return fmt.Errorf("%w: %s has %s %s, spending %s", ErrGiftCardBalance, card.Code, card.Balance.ToMajor(), card.Balance.Asset.Code, delta.Negate().ToMajor())
+}
@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+// GetGiftCard returns a gift card's balance.
+func (s *FileStore) GetGiftCard(_ context.Context, code string) (GiftCard, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	card, ok := s.giftCards[code]
+	if !ok {
+		return GiftCard{}, ErrNotFound
+	}
+	return card, nil
+}
+
+// AdjustGiftCard adds delta to a gift card's balance, starting from faceValue on first use.
+func (s *FileStore) AdjustGiftCard(_ context.Context, code string, faceValue, delta money.Money) (GiftCard, error) {
+	if err := validateGiftCardAdjustment(code, faceValue, delta); err != nil {
+		return GiftCard{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	card, err := adjustGiftCardInMap(s.giftCards, code, faceValue, delta, time.Now())
+	if err == nil {
+		s.markDirty()
+	}
+	return card, err
+}
@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+// GetGiftCard returns a gift card's balance.
+func (m *MemoryStore) GetGiftCard(_ context.Context, code string) (GiftCard, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	card, ok := m.giftCards[code]
+	if !ok {
+		return GiftCard{}, ErrNotFound
+	}
+	return card, nil
+}
+
+// AdjustGiftCard adds delta to a gift card's balance, starting from faceValue on first use.
+func (m *MemoryStore) AdjustGiftCard(_ context.Context, code string, faceValue, delta money.Money) (GiftCard, error) {
+	if err := validateGiftCardAdjustment(code, faceValue, delta); err != nil {
+		return GiftCard{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return adjustGiftCardInMap(m.giftCards, code, faceValue, delta, time.Now())
+}
@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+const giftCardsCollection = "gift_cards"
+
+// giftCardDocument is a gift card's balance keyed by code.
+type giftCardDocument struct {
+	Code      string    `bson:"_id"`
+	Balance   int64     `bson:"balance"`
+	Asset     string    `bson:"asset"`
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+// GetGiftCard returns a gift card's balance.
+func (s *MongoDBStore) GetGiftCard(ctx context.Context, code string) (GiftCard, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	var doc giftCardDocument
+	err := s.db.Collection(giftCardsCollection).FindOne(ctx, bson.M{"_id": code}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return GiftCard{}, ErrNotFound
+	}
+	if err != nil {
+		return GiftCard{}, fmt.Errorf("get gift card: %w", err)
+	}
+	return doc.giftCard()
+}
+
+// AdjustGiftCard adds delta to a gift card's balance, starting from faceValue on first use.
+// The balance check and update are a single conditional update, so concurrent spends cannot
+// overdraw the card.
+func (s *MongoDBStore) AdjustGiftCard(ctx context.Context, code string, faceValue, delta money.Money) (GiftCard, error) {
+	if err := validateGiftCardAdjustment(code, faceValue, delta); err != nil {
+		return GiftCard{}, err
+	}
+
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	coll := s.db.Collection(giftCardsCollection)
+	create := bson.M{"$setOnInsert": bson.M{"balance": faceValue.Atomic, "asset": faceValue.Asset.Code, "updated_at": time.Now()}}
+	if _, err := coll.UpdateOne(ctx, bson.M{"_id": code}, create, options.Update().SetUpsert(true)); err != nil {
+		return GiftCard{}, fmt.Errorf("create gift card: %w", err)
+	}
+
+	filter := bson.M{"_id": code, "asset": delta.Asset.Code, "balance": bson.M{"$gte": -delta.Atomic}}
+	update := bson.M{"$inc": bson.M{"balance": delta.Atomic}, "$set": bson.M{"updated_at": time.Now()}}
+	var doc giftCardDocument
+	err := coll.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		current, getErr := s.GetGiftCard(ctx, code)
+		if getErr != nil {
+			return GiftCard{}, getErr
+		}
+		if current.Balance.Asset.Code != delta.Asset.Code {
+			return GiftCard{}, fmt.Errorf("gift card %s: balance is in %s, adjustment in %s", code, current.Balance.Asset.Code, delta.Asset.Code)
+		}
+		return current, insufficientGiftCardBalance(current, delta)
+	}
+	if err != nil {
+		return GiftCard{}, fmt.Errorf("adjust gift card: %w", err)
+	}
+	return doc.giftCard()
+}
+
+func (d giftCardDocument) giftCard() (GiftCard, error) {
+	asset, err := money.GetAsset(d.Asset)
+	if err != nil {
+		return GiftCard{}, fmt.Errorf("gift card %s: %w", d.Code, err)
+	}
+	return GiftCard{Code: d.Code, Balance: money.New(asset, d.Balance), UpdatedAt: d.UpdatedAt}, nil
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+// GetGiftCard returns a gift card's balance.
+func (s *PostgresStore) GetGiftCard(ctx context.Context, code string) (GiftCard, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`SELECT balance, asset, updated_at FROM %s WHERE code = $1`, s.giftCardsTableName)
+	card, err := scanGiftCard(code, s.db.QueryRowContext(ctx, query, code))
+	if errors.Is(err, sql.ErrNoRows) {
+		return GiftCard{}, ErrNotFound
+	}
+	return card, err
+}
+
+// AdjustGiftCard adds delta to a gift card's balance, starting from faceValue on first use.
+// The balance check and update are a single conditional UPDATE, so concurrent spends cannot
+// overdraw the card.
+func (s *PostgresStore) AdjustGiftCard(ctx context.Context, code string, faceValue, delta money.Money) (GiftCard, error) {
+	if err := validateGiftCardAdjustment(code, faceValue, delta); err != nil {
+		return GiftCard{}, err
+	}
+
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	now := time.Now().UTC()
+	insertQuery := fmt.Sprintf(`
+		INSERT INTO %s (code, balance, asset, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (code) DO NOTHING
+	`, s.giftCardsTableName)
+	if _, err := s.db.ExecContext(ctx, insertQuery, code, faceValue.Atomic, faceValue.Asset.Code, now); err != nil {
+		return GiftCard{}, fmt.Errorf("create gift card: %w", err)
+	}
+
+	updateQuery := fmt.Sprintf(`
+		UPDATE %s SET balance = balance + $2, updated_at = $4
+		WHERE code = $1 AND asset = $3 AND balance + $2 >= 0
+		RETURNING balance, asset, updated_at
+	`, s.giftCardsTableName)
+	card, err := scanGiftCard(code, s.db.QueryRowContext(ctx, updateQuery, code, delta.Atomic, delta.Asset.Code, now))
+	if errors.Is(err, sql.ErrNoRows) {
+		current, getErr := s.GetGiftCard(ctx, code)
+		if getErr != nil {
+			return GiftCard{}, getErr
+		}
+		if current.Balance.Asset.Code != delta.Asset.Code {
+			return GiftCard{}, fmt.Errorf("gift card %s: balance is in %s, adjustment in %s", code, current.Balance.Asset.Code, delta.Asset.Code)
+		}
+		return current, insufficientGiftCardBalance(current, delta)
+	}
+	return card, err
+}
+
+// scanGiftCard reads a gift card row selected as balance, asset, updated_at.
+func scanGiftCard(code string, row interface{ Scan(...any) error }) (GiftCard, error) {
+	var balance int64
+	var assetCode string
+	card := GiftCard{Code: code}
+	if err := row.Scan(&balance, &assetCode, &card.UpdatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return GiftCard{}, err
+		}
+		return GiftCard{}, fmt.Errorf("scan gift card: %w", err)
+	}
+	asset, err := money.GetAsset(assetCode)
+	if err != nil {
+		return GiftCard{}, fmt.Errorf("gift card %s: %w", code, err)
+	}
+	card.Balance = money.New(asset, balance)
+	return card, nil
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+func TestGiftCards(t *testing.T) {
+	backends := []struct {
+		name string
+		open func(t *testing.T, path string) Store
+	}{
+		{name: "memory", open: func(t *testing.T, _ string) Store { return NewMemoryStore() }},
+		{
+			name: "file",
+			open: func(t *testing.T, path string) Store {
+				store, err := NewFileStore(path)
+				if err != nil {
+					t.Fatalf("NewFileStore: %v", err)
+				}
+				return store
+			},
+		},
+	}
+
+	usd := money.MustGetAsset("USD")
+	faceValue := money.New(usd, 5000)
+
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "store.json")
+			store := backend.open(t, path)
+			ctx := context.Background()
+
+			if _, err := store.GetGiftCard(ctx, "GIFT50"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("GetGiftCard before first use err = %v, want ErrNotFound", err)
+			}
+
+			adjustments := []struct {
+				name        string
+				delta       money.Money
+				wantBalance int64
+				wantErr     error
+			}{
+				{name: "first spend starts at face value", delta: money.New(usd, -2000), wantBalance: 3000},
+				{name: "partial spend", delta: money.New(usd, -1000), wantBalance: 2000},
+				{name: "overdraw", delta: money.New(usd, -2001), wantBalance: 2000, wantErr: ErrGiftCardBalance},
+				{name: "credit back", delta: money.New(usd, 500), wantBalance: 2500},
+				{name: "spend to zero", delta: money.New(usd, -2500), wantBalance: 0},
+			}
+			for _, adj := range adjustments {
+				card, err := store.AdjustGiftCard(ctx, "GIFT50", faceValue, adj.delta)
+				if !errors.Is(err, adj.wantErr) {
+					t.Fatalf("%s: AdjustGiftCard err = %v, want %v", adj.name, err, adj.wantErr)
+				}
+				if err == nil && card.Balance.Atomic != adj.wantBalance {
+					t.Fatalf("%s: balance = %d, want %d", adj.name, card.Balance.Atomic, adj.wantBalance)
+				}
+			}
+
+			if _, err := store.AdjustGiftCard(ctx, "GIFT50", faceValue, money.New(money.MustGetAsset("USDC"), -1)); err == nil {
+				t.Fatal("AdjustGiftCard in another asset succeeded, want error")
+			}
+			if _, err := store.AdjustGiftCard(ctx, "", faceValue, money.New(usd, -1)); err == nil {
+				t.Fatal("AdjustGiftCard without a code succeeded, want error")
+			}
+
+			if backend.name == "file" {
+				_ = store.Close()
+				store = backend.open(t, path)
+			}
+			defer store.Close()
+
+			card, err := store.GetGiftCard(ctx, "GIFT50")
+			if err != nil {
+				t.Fatalf("GetGiftCard: %v", err)
+			}
+			if card.Balance.Atomic != 0 || card.Balance.Asset.Code != "USD" {
+				t.Errorf("balance = %s %s, want 0 USD", card.Balance.ToMajor(), card.Balance.Asset.Code)
+			}
+		})
+	}
+}
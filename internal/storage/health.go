@@ -0,0 +1,23 @@
+package storage
+
+import "context"
+
+// Ping verifies that store can reach its database. Wrapped stores are unwrapped;
+// in-process backends (memory, file) have nothing to reach and always succeed.
+func Ping(ctx context.Context, store Store) error {
+	store = unwrapStore(store)
+	if pinger, ok := store.(interface{ Ping(context.Context) error }); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+// Ping checks the PostgreSQL connection.
+func (s *PostgresStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Ping checks the MongoDB connection.
+func (s *MongoDBStore) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx, nil)
+}
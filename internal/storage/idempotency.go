@@ -0,0 +1,20 @@
+package storage
+
+import "time"
+
+// IdempotencyRecord is the stored outcome of a request made with an Idempotency-Key header.
+// Retries carrying the same key are answered from this record instead of re-running the handler.
+type IdempotencyRecord struct {
+	Key         string            `json:"key" bson:"_id"`                 // Scoped key (method:path:client key)
+	Fingerprint string            `json:"fingerprint" bson:"fingerprint"` // SHA-256 of the original request body
+	StatusCode  int               `json:"statusCode" bson:"status_code"`  // Original response status
+	Headers     map[string]string `json:"headers" bson:"headers"`         // Original response headers
+	Body        []byte            `json:"body" bson:"body"`               // Original response body
+	CreatedAt   time.Time         `json:"createdAt" bson:"created_at"`    // When the response was recorded
+	ExpiresAt   time.Time         `json:"expiresAt" bson:"expires_at"`    // When the key may be reused
+}
+
+// IsExpiredAt returns true if the record has passed its expiration time at the given moment.
+func (r IdempotencyRecord) IsExpiredAt(now time.Time) bool {
+	return now.After(r.ExpiresAt)
+}
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// SaveIdempotencyKey stores (or replaces) the response recorded for a key.
+func (s *FileStore) SaveIdempotencyKey(_ context.Context, record IdempotencyRecord) error {
+	if err := validateAndPrepareIdempotencyRecord(&record); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.idempotencyKeys[record.Key] = record
+	s.markDirty()
+	return nil
+}
+
+// GetIdempotencyKey retrieves the record for a key.
+func (s *FileStore) GetIdempotencyKey(_ context.Context, key string) (IdempotencyRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	record, ok := s.idempotencyKeys[key]
+	if !ok || record.IsExpiredAt(time.Now()) {
+		return IdempotencyRecord{}, ErrNotFound
+	}
+	return record, nil
+}
+
+// DeleteIdempotencyKey removes the record for a key.
+func (s *FileStore) DeleteIdempotencyKey(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.idempotencyKeys[key]; ok {
+		delete(s.idempotencyKeys, key)
+		s.markDirty()
+	}
+	return nil
+}
+
+// CleanupExpiredIdempotencyKeys deletes expired idempotency records from the file store.
+func (s *FileStore) CleanupExpiredIdempotencyKeys(_ context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	count := int64(0)
+	for key, record := range s.idempotencyKeys {
+		if record.IsExpiredAt(now) {
+			delete(s.idempotencyKeys, key)
+			count++
+		}
+	}
+
+	if count > 0 {
+		s.markDirty()
+	}
+
+	return count, nil
+}
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// SaveIdempotencyKey stores (or replaces) the response recorded for a key.
+func (m *MemoryStore) SaveIdempotencyKey(_ context.Context, record IdempotencyRecord) error {
+	if err := validateAndPrepareIdempotencyRecord(&record); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.idempotencyKeys[record.Key] = record
+	return nil
+}
+
+// GetIdempotencyKey retrieves the record for a key.
+func (m *MemoryStore) GetIdempotencyKey(_ context.Context, key string) (IdempotencyRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	record, ok := m.idempotencyKeys[key]
+	if !ok || record.IsExpiredAt(time.Now()) {
+		return IdempotencyRecord{}, ErrNotFound
+	}
+	return record, nil
+}
+
+// DeleteIdempotencyKey removes the record for a key.
+func (m *MemoryStore) DeleteIdempotencyKey(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.idempotencyKeys, key)
+	return nil
+}
+
+// CleanupExpiredIdempotencyKeys deletes expired idempotency records.
+func (m *MemoryStore) CleanupExpiredIdempotencyKeys(_ context.Context) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	count := int64(0)
+	for key, record := range m.idempotencyKeys {
+		if record.IsExpiredAt(now) {
+			delete(m.idempotencyKeys, key)
+			count++
+		}
+	}
+
+	return count, nil
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const idempotencyKeysCollection = "idempotency_keys"
+
+// SaveIdempotencyKey stores (or replaces) the response recorded for a key.
+func (s *MongoDBStore) SaveIdempotencyKey(ctx context.Context, record IdempotencyRecord) error {
+	if err := validateAndPrepareIdempotencyRecord(&record); err != nil {
+		return err
+	}
+
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	coll := s.db.Collection(idempotencyKeysCollection)
+	opts := options.Replace().SetUpsert(true)
+	if _, err := coll.ReplaceOne(ctx, bson.M{"_id": record.Key}, record, opts); err != nil {
+		return fmt.Errorf("save idempotency record: %w", err)
+	}
+	return nil
+}
+
+// GetIdempotencyKey retrieves the record for a key.
+func (s *MongoDBStore) GetIdempotencyKey(ctx context.Context, key string) (IdempotencyRecord, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	coll := s.db.Collection(idempotencyKeysCollection)
+	filter := bson.M{
+		"_id":        key,
+		"expires_at": bson.M{"$gt": time.Now()},
+	}
+
+	var record IdempotencyRecord
+	err := coll.FindOne(ctx, filter).Decode(&record)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return IdempotencyRecord{}, ErrNotFound
+	}
+	if err != nil {
+		return IdempotencyRecord{}, fmt.Errorf("get idempotency record: %w", err)
+	}
+	return record, nil
+}
+
+// DeleteIdempotencyKey removes the record for a key.
+func (s *MongoDBStore) DeleteIdempotencyKey(ctx context.Context, key string) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	coll := s.db.Collection(idempotencyKeysCollection)
+	if _, err := coll.DeleteOne(ctx, bson.M{"_id": key}); err != nil {
+		return fmt.Errorf("delete idempotency record: %w", err)
+	}
+	return nil
+}
+
+// CleanupExpiredIdempotencyKeys deletes expired idempotency records from the database.
+func (s *MongoDBStore) CleanupExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	coll := s.db.Collection(idempotencyKeysCollection)
+	result, err := coll.DeleteMany(ctx, bson.M{"expires_at": bson.M{"$lt": time.Now()}})
+	if err != nil {
+		return 0, fmt.Errorf("cleanup expired idempotency records: %w", err)
+	}
+
+	return result.DeletedCount, nil
+}
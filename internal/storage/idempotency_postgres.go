@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SaveIdempotencyKey stores (or replaces) the response recorded for a key.
+func (s *PostgresStore) SaveIdempotencyKey(ctx context.Context, record IdempotencyRecord) error {
+	if err := validateAndPrepareIdempotencyRecord(&record); err != nil {
+		return err
+	}
+
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	headersJSON, err := json.Marshal(record.Headers)
+	if err != nil {
+		return fmt.Errorf("marshal headers: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO %s (key, fingerprint, status_code, headers, body, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (key) DO UPDATE SET
+			fingerprint = EXCLUDED.fingerprint,
+			status_code = EXCLUDED.status_code,
+			headers = EXCLUDED.headers,
+			body = EXCLUDED.body,
+			created_at = EXCLUDED.created_at,
+			expires_at = EXCLUDED.expires_at
+	`, s.idempotencyKeysTableName)
+
+	_, err = s.db.ExecContext(ctx, query,
+		record.Key, record.Fingerprint, record.StatusCode, headersJSON, record.Body,
+		record.CreatedAt.UTC(), record.ExpiresAt.UTC())
+	if err != nil {
+		return fmt.Errorf("save idempotency record: %w", err)
+	}
+	return nil
+}
+
+// GetIdempotencyKey retrieves the record for a key.
+func (s *PostgresStore) GetIdempotencyKey(ctx context.Context, key string) (IdempotencyRecord, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`
+		SELECT key, fingerprint, status_code, headers, body, created_at, expires_at
+		FROM %s
+		WHERE key = $1 AND expires_at > $2
+	`, s.idempotencyKeysTableName)
+
+	var record IdempotencyRecord
+	var headersJSON []byte
+	err := s.db.QueryRowContext(ctx, query, key, time.Now().UTC()).Scan(
+		&record.Key, &record.Fingerprint, &record.StatusCode, &headersJSON, &record.Body,
+		&record.CreatedAt, &record.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return IdempotencyRecord{}, ErrNotFound
+	}
+	if err != nil {
+		return IdempotencyRecord{}, fmt.Errorf("get idempotency record: %w", err)
+	}
+
+	if len(headersJSON) > 0 {
+		if err := json.Unmarshal(headersJSON, &record.Headers); err != nil {
+			return IdempotencyRecord{}, fmt.Errorf("unmarshal headers: %w", err)
+		}
+	}
+	return record, nil
+}
+
+// DeleteIdempotencyKey removes the record for a key.
+func (s *PostgresStore) DeleteIdempotencyKey(ctx context.Context, key string) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`DELETE FROM %s WHERE key = $1`, s.idempotencyKeysTableName)
+	if _, err := s.db.ExecContext(ctx, query, key); err != nil {
+		return fmt.Errorf("delete idempotency record: %w", err)
+	}
+	return nil
+}
+
+// CleanupExpiredIdempotencyKeys deletes expired idempotency records from the database.
+func (s *PostgresStore) CleanupExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`DELETE FROM %s WHERE expires_at < $1`, s.idempotencyKeysTableName)
+
+	result, err := s.db.ExecContext(ctx, query, time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("cleanup expired idempotency records: %w", err)
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("get rows affected: %w", err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyKeys(t *testing.T) {
+	backends := []struct {
+		name string
+		open func(t *testing.T) Store
+	}{
+		{name: "memory", open: func(t *testing.T) Store { return NewMemoryStore() }},
+		{
+			name: "file",
+			open: func(t *testing.T) Store {
+				store, err := NewFileStore(filepath.Join(t.TempDir(), "store.json"))
+				if err != nil {
+					t.Fatalf("NewFileStore: %v", err)
+				}
+				return store
+			},
+		},
+	}
+
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			store := backend.open(t)
+			defer store.Close()
+			ctx := context.Background()
+
+			record := IdempotencyRecord{
+				Key:         "POST:/cart/quote:abc",
+				Fingerprint: "f1",
+				StatusCode:  200,
+				Headers:     map[string]string{"Content-Type": "application/json"},
+				Body:        []byte(`{"cartId":"cart_1"}`),
+			}
+			if err := store.SaveIdempotencyKey(ctx, record); err != nil {
+				t.Fatalf("SaveIdempotencyKey: %v", err)
+			}
+
+			got, err := store.GetIdempotencyKey(ctx, record.Key)
+			if err != nil {
+				t.Fatalf("GetIdempotencyKey: %v", err)
+			}
+			if got.StatusCode != 200 || string(got.Body) != string(record.Body) || got.Headers["Content-Type"] != "application/json" {
+				t.Fatalf("unexpected record: %+v", got)
+			}
+			if got.ExpiresAt.Sub(got.CreatedAt) != 24*time.Hour {
+				t.Errorf("default ttl = %v, want 24h", got.ExpiresAt.Sub(got.CreatedAt))
+			}
+
+			expired := IdempotencyRecord{
+				Key:       "POST:/cart/quote:old",
+				CreatedAt: time.Now().Add(-2 * time.Hour),
+				ExpiresAt: time.Now().Add(-time.Hour),
+			}
+			if err := store.SaveIdempotencyKey(ctx, expired); err != nil {
+				t.Fatalf("SaveIdempotencyKey: %v", err)
+			}
+			if _, err := store.GetIdempotencyKey(ctx, expired.Key); !errors.Is(err, ErrNotFound) {
+				t.Errorf("expired record: err = %v, want ErrNotFound", err)
+			}
+
+			count, err := store.CleanupExpiredIdempotencyKeys(ctx)
+			if err != nil || count != 1 {
+				t.Errorf("CleanupExpiredIdempotencyKeys = %d, %v; want 1", count, err)
+			}
+
+			if err := store.DeleteIdempotencyKey(ctx, record.Key); err != nil {
+				t.Fatalf("DeleteIdempotencyKey: %v", err)
+			}
+			if _, err := store.GetIdempotencyKey(ctx, record.Key); !errors.Is(err, ErrNotFound) {
+				t.Errorf("deleted record: err = %v, want ErrNotFound", err)
+			}
+
+			if err := store.SaveIdempotencyKey(ctx, IdempotencyRecord{}); err == nil {
+				t.Error("expected error for record without key")
+			}
+		})
+	}
+}
+
+func TestFileStoreIdempotencyKeysPersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.json")
+	store, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	ctx := context.Background()
+	if err := store.SaveIdempotencyKey(ctx, IdempotencyRecord{Key: "k", StatusCode: 201, Body: []byte("ok")}); err != nil {
+		t.Fatalf("SaveIdempotencyKey: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	reopened, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	defer reopened.Close()
+	got, err := reopened.GetIdempotencyKey(ctx, "k")
+	if err != nil {
+		t.Fatalf("GetIdempotencyKey after reopen: %v", err)
+	}
+	if got.StatusCode != 201 || string(got.Body) != "ok" {
+		t.Fatalf("unexpected record: %+v", got)
+	}
+}
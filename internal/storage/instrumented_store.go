@@ -0,0 +1,645 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/CedrosPay/server/internal/logger"
+	"github.com/CedrosPay/server/internal/metrics"
+	"github.com/CedrosPay/server/internal/money"
+	"github.com/CedrosPay/server/internal/tracing"
+)
+
+// operationTables maps each Store method to the logical table (collection) it touches.
+var operationTables = map[string]string{
+	"SaveCartQuote":                      "cart_quotes",
+	"GetCartQuote":                       "cart_quotes",
+	"MarkCartPaid":                       "cart_quotes",
+	"HasCartAccess":                      "cart_quotes",
+	"SaveCartQuotes":                     "cart_quotes",
+	"GetCartQuotes":                      "cart_quotes",
+	"SaveRefundQuote":                    "refund_quotes",
+	"GetRefundQuote":                     "refund_quotes",
+	"GetRefundQuoteByOriginalPurchaseID": "refund_quotes",
+	"ListPendingRefunds":                 "refund_quotes",
+	"MarkRefundProcessed":                "refund_quotes",
+	"DeleteRefundQuote":                  "refund_quotes",
+	"SaveRefundQuotes":                   "refund_quotes",
+	"RecordPayment":                      "payment_transactions",
+	"HasPaymentBeenProcessed":            "payment_transactions",
+	"GetPayment":                         "payment_transactions",
+	"RecordPayments":                     "payment_transactions",
+	"ArchiveOldPayments":                 "payment_transactions",
+	"CreateNonce":                        "admin_nonces",
+	"ConsumeNonce":                       "admin_nonces",
+	"CleanupExpiredNonces":               "admin_nonces",
+	"EnqueueWebhook":                     "webhook_queue",
+	"DequeueWebhooks":                    "webhook_queue",
+	"MarkWebhookProcessing":              "webhook_queue",
+	"MarkWebhookSuccess":                 "webhook_queue",
+	"MarkWebhookFailed":                  "webhook_queue",
+	"GetWebhook":                         "webhook_queue",
+	"ListWebhooks":                       "webhook_queue",
+	"RetryWebhook":                       "webhook_queue",
+	"DeleteWebhook":                      "webhook_queue",
+	"SaveIdempotencyKey":                 "idempotency_keys",
+	"GetIdempotencyKey":                  "idempotency_keys",
+	"DeleteIdempotencyKey":               "idempotency_keys",
+	"CleanupExpiredIdempotencyKeys":      "idempotency_keys",
+	"SetStock":                           "inventory_stock",
+	"GetStock":                           "inventory_stock",
+	"DeleteStock":                        "inventory_stock",
+	"ReserveStock":                       "stock_reservations",
+	"CommitStockReservation":             "stock_reservations",
+	"ReleaseStockReservation":            "stock_reservations",
+	"AddCartContribution":                "cart_contributions",
+	"ListCartContributions":              "cart_contributions",
+	"SaveSavedCart":                      "saved_carts",
+	"GetSavedCart":                       "saved_carts",
+	"ListSavedCarts":                     "saved_carts",
+	"DeleteSavedCart":                    "saved_carts",
+	"GetGiftCard":                        "gift_cards",
+	"AdjustGiftCard":                     "gift_cards",
+	"GetMeterAccount":                    "meter_accounts",
+	"CreditMeterAccount":                 "meter_accounts",
+	"ChargeMeterAccount":                 "meter_accounts",
+	"ListUnsettledMeterAccounts":         "meter_accounts",
+	"SettleMeterAccount":                 "meter_accounts",
+	"RecordReferralConversion":           "referral_conversions",
+	"ListReferralConversions":            "referral_conversions",
+	"RecordCouponRedemption":             "coupon_redemptions",
+	"CountCouponRedemptions":             "coupon_redemptions",
+	"ListCouponPayments":                 "payment_transactions",
+	"ListCouponCartQuotes":               "cart_quotes",
+	"LinkCustomerIdentities":             "customer_identities",
+	"GetCustomer":                        "customer_identities",
+	"FindCustomer":                       "customer_identities",
+	"ListPaymentsByPayer":                "payment_transactions",
+	"RecordRefundAudit":                  "refund_audit",
+	"ListRefundAudit":                    "refund_audit",
+	"RecordAdminAudit":                   "admin_audit",
+	"SumPayments":                        "payment_transactions",
+	"ListAdminAudit":                     "admin_audit",
+	"SaveAccessRule":                     "access_rules",
+	"ListAccessRules":                    "access_rules",
+	"DeleteAccessRule":                   "access_rules",
+	"RecordWebhookDelivery":              "webhook_deliveries",
+	"ListWebhookDeliveries":              "webhook_deliveries",
+}
+
+// BackendName reports the storage backend behind store ("postgres", "mongodb", "file",
+// "memory", or "custom" for implementations outside this package).
+func BackendName(store Store) string {
+	switch store.(type) {
+	case *PostgresStore:
+		return "postgres"
+	case *MongoDBStore:
+		return "mongodb"
+	case *FileStore:
+		return "file"
+	case *MemoryStore:
+		return "memory"
+	default:
+		return "custom"
+	}
+}
+
+// InstrumentOptions selects what NewInstrumentedStore records.
+type InstrumentOptions struct {
+	Tracing            bool             // Open an OpenTelemetry span per operation
+	Metrics            *metrics.Metrics // Optional: per-operation latency and error metrics
+	SlowQueryThreshold time.Duration    // Log operations slower than this (0 disables)
+	Logger             zerolog.Logger
+}
+
+// instrumentedStore wraps a Store with tracing, metrics, and slow-query logging.
+type instrumentedStore struct {
+	inner   Store
+	backend string
+	system  string // OpenTelemetry db.system.name
+	opts    InstrumentOptions
+}
+
+// NewInstrumentedStore wraps store so every operation is timed and, per opts, traced as a
+// "storage.<Method>" client span, recorded in the cedros_storage_* metrics, and logged when it
+// exceeds the slow-query threshold. Metrics and logs are labeled by backend and logical table.
+func NewInstrumentedStore(store Store, opts InstrumentOptions) Store {
+	backend := BackendName(store)
+	system := backend
+	switch backend {
+	case "postgres":
+		system = semconv.DBSystemNamePostgreSQL.Value.AsString()
+	case "mongodb":
+		system = semconv.DBSystemNameMongoDB.Value.AsString()
+	}
+	return &instrumentedStore{inner: store, backend: backend, system: system, opts: opts}
+}
+
+// begin starts instrumenting op and returns the function that completes it.
+func (s *instrumentedStore) begin(ctx context.Context, op string) (context.Context, func(error)) {
+	table := operationTables[op]
+	var span trace.Span
+	if s.opts.Tracing {
+		ctx, span = tracing.Tracer().Start(ctx, "storage."+op,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemNameKey.String(s.system),
+				semconv.DBOperationName(op),
+				semconv.DBCollectionName(table),
+			),
+		)
+	}
+	start := time.Now()
+
+	return ctx, func(err error) {
+		duration := time.Since(start)
+		// A missing record is an ordinary lookup outcome, not a failure
+		if errors.Is(err, ErrNotFound) {
+			err = nil
+		}
+		if span != nil {
+			tracing.End(span, err)
+		}
+		slow := s.opts.SlowQueryThreshold > 0 && duration >= s.opts.SlowQueryThreshold
+		if s.opts.Metrics != nil {
+			s.opts.Metrics.ObserveStoreOperation(s.backend, table, op, duration, err != nil, slow)
+		}
+		if slow {
+			s.opts.Logger.Warn().
+				Str("backend", s.backend).
+				Str("table", table).
+				Str("operation", op).
+				Dur("duration", duration).
+				Dur("threshold", s.opts.SlowQueryThreshold).
+				Str("request_id", logger.GetRequestID(ctx)).
+				Err(err).
+				Msg("storage.slow_query")
+		}
+	}
+}
+
+func (s *instrumentedStore) SaveCartQuote(ctx context.Context, quote CartQuote) (err error) {
+	ctx, done := s.begin(ctx, "SaveCartQuote")
+	defer func() { done(err) }()
+	return s.inner.SaveCartQuote(ctx, quote)
+}
+
+func (s *instrumentedStore) GetCartQuote(ctx context.Context, cartID string) (_ CartQuote, err error) {
+	ctx, done := s.begin(ctx, "GetCartQuote")
+	defer func() { done(err) }()
+	return s.inner.GetCartQuote(ctx, cartID)
+}
+
+func (s *instrumentedStore) MarkCartPaid(ctx context.Context, cartID, wallet string) (err error) {
+	ctx, done := s.begin(ctx, "MarkCartPaid")
+	defer func() { done(err) }()
+	return s.inner.MarkCartPaid(ctx, cartID, wallet)
+}
+
+func (s *instrumentedStore) HasCartAccess(ctx context.Context, cartID, wallet string) bool {
+	ctx, done := s.begin(ctx, "HasCartAccess")
+	defer done(nil)
+	return s.inner.HasCartAccess(ctx, cartID, wallet)
+}
+
+func (s *instrumentedStore) SaveCartQuotes(ctx context.Context, quotes []CartQuote) (err error) {
+	ctx, done := s.begin(ctx, "SaveCartQuotes")
+	defer func() { done(err) }()
+	return s.inner.SaveCartQuotes(ctx, quotes)
+}
+
+func (s *instrumentedStore) GetCartQuotes(ctx context.Context, cartIDs []string) (_ []CartQuote, err error) {
+	ctx, done := s.begin(ctx, "GetCartQuotes")
+	defer func() { done(err) }()
+	return s.inner.GetCartQuotes(ctx, cartIDs)
+}
+
+func (s *instrumentedStore) SaveRefundQuote(ctx context.Context, quote RefundQuote) (err error) {
+	ctx, done := s.begin(ctx, "SaveRefundQuote")
+	defer func() { done(err) }()
+	return s.inner.SaveRefundQuote(ctx, quote)
+}
+
+func (s *instrumentedStore) GetRefundQuote(ctx context.Context, refundID string) (_ RefundQuote, err error) {
+	ctx, done := s.begin(ctx, "GetRefundQuote")
+	defer func() { done(err) }()
+	return s.inner.GetRefundQuote(ctx, refundID)
+}
+
+func (s *instrumentedStore) GetRefundQuoteByOriginalPurchaseID(ctx context.Context, originalPurchaseID string) (_ RefundQuote, err error) {
+	ctx, done := s.begin(ctx, "GetRefundQuoteByOriginalPurchaseID")
+	defer func() { done(err) }()
+	return s.inner.GetRefundQuoteByOriginalPurchaseID(ctx, originalPurchaseID)
+}
+
+func (s *instrumentedStore) ListPendingRefunds(ctx context.Context) (_ []RefundQuote, err error) {
+	ctx, done := s.begin(ctx, "ListPendingRefunds")
+	defer func() { done(err) }()
+	return s.inner.ListPendingRefunds(ctx)
+}
+
+func (s *instrumentedStore) MarkRefundProcessed(ctx context.Context, refundID, processedBy, signature string) (err error) {
+	ctx, done := s.begin(ctx, "MarkRefundProcessed")
+	defer func() { done(err) }()
+	return s.inner.MarkRefundProcessed(ctx, refundID, processedBy, signature)
+}
+
+func (s *instrumentedStore) DeleteRefundQuote(ctx context.Context, refundID string) (err error) {
+	ctx, done := s.begin(ctx, "DeleteRefundQuote")
+	defer func() { done(err) }()
+	return s.inner.DeleteRefundQuote(ctx, refundID)
+}
+
+func (s *instrumentedStore) SaveRefundQuotes(ctx context.Context, quotes []RefundQuote) (err error) {
+	ctx, done := s.begin(ctx, "SaveRefundQuotes")
+	defer func() { done(err) }()
+	return s.inner.SaveRefundQuotes(ctx, quotes)
+}
+
+func (s *instrumentedStore) RecordPayment(ctx context.Context, tx PaymentTransaction) (err error) {
+	ctx, done := s.begin(ctx, "RecordPayment")
+	defer func() { done(err) }()
+	return s.inner.RecordPayment(ctx, tx)
+}
+
+func (s *instrumentedStore) HasPaymentBeenProcessed(ctx context.Context, signature string) (_ bool, err error) {
+	ctx, done := s.begin(ctx, "HasPaymentBeenProcessed")
+	defer func() { done(err) }()
+	return s.inner.HasPaymentBeenProcessed(ctx, signature)
+}
+
+func (s *instrumentedStore) GetPayment(ctx context.Context, signature string) (_ PaymentTransaction, err error) {
+	ctx, done := s.begin(ctx, "GetPayment")
+	defer func() { done(err) }()
+	return s.inner.GetPayment(ctx, signature)
+}
+
+func (s *instrumentedStore) RecordPayments(ctx context.Context, txs []PaymentTransaction) (err error) {
+	ctx, done := s.begin(ctx, "RecordPayments")
+	defer func() { done(err) }()
+	return s.inner.RecordPayments(ctx, txs)
+}
+
+func (s *instrumentedStore) ArchiveOldPayments(ctx context.Context, olderThan time.Time) (_ int64, err error) {
+	ctx, done := s.begin(ctx, "ArchiveOldPayments")
+	defer func() { done(err) }()
+	return s.inner.ArchiveOldPayments(ctx, olderThan)
+}
+
+func (s *instrumentedStore) CreateNonce(ctx context.Context, nonce AdminNonce) (err error) {
+	ctx, done := s.begin(ctx, "CreateNonce")
+	defer func() { done(err) }()
+	return s.inner.CreateNonce(ctx, nonce)
+}
+
+func (s *instrumentedStore) ConsumeNonce(ctx context.Context, nonceID string) (err error) {
+	ctx, done := s.begin(ctx, "ConsumeNonce")
+	defer func() { done(err) }()
+	return s.inner.ConsumeNonce(ctx, nonceID)
+}
+
+func (s *instrumentedStore) CleanupExpiredNonces(ctx context.Context) (_ int64, err error) {
+	ctx, done := s.begin(ctx, "CleanupExpiredNonces")
+	defer func() { done(err) }()
+	return s.inner.CleanupExpiredNonces(ctx)
+}
+
+func (s *instrumentedStore) EnqueueWebhook(ctx context.Context, webhook PendingWebhook) (_ string, err error) {
+	ctx, done := s.begin(ctx, "EnqueueWebhook")
+	defer func() { done(err) }()
+	return s.inner.EnqueueWebhook(ctx, webhook)
+}
+
+func (s *instrumentedStore) DequeueWebhooks(ctx context.Context, limit int) (_ []PendingWebhook, err error) {
+	ctx, done := s.begin(ctx, "DequeueWebhooks")
+	defer func() { done(err) }()
+	return s.inner.DequeueWebhooks(ctx, limit)
+}
+
+func (s *instrumentedStore) MarkWebhookProcessing(ctx context.Context, webhookID string) (err error) {
+	ctx, done := s.begin(ctx, "MarkWebhookProcessing")
+	defer func() { done(err) }()
+	return s.inner.MarkWebhookProcessing(ctx, webhookID)
+}
+
+func (s *instrumentedStore) MarkWebhookSuccess(ctx context.Context, webhookID string) (err error) {
+	ctx, done := s.begin(ctx, "MarkWebhookSuccess")
+	defer func() { done(err) }()
+	return s.inner.MarkWebhookSuccess(ctx, webhookID)
+}
+
+func (s *instrumentedStore) MarkWebhookFailed(ctx context.Context, webhookID string, errorMsg string, nextAttemptAt time.Time) (err error) {
+	ctx, done := s.begin(ctx, "MarkWebhookFailed")
+	defer func() { done(err) }()
+	return s.inner.MarkWebhookFailed(ctx, webhookID, errorMsg, nextAttemptAt)
+}
+
+func (s *instrumentedStore) GetWebhook(ctx context.Context, webhookID string) (_ PendingWebhook, err error) {
+	ctx, done := s.begin(ctx, "GetWebhook")
+	defer func() { done(err) }()
+	return s.inner.GetWebhook(ctx, webhookID)
+}
+
+func (s *instrumentedStore) ListWebhooks(ctx context.Context, status WebhookStatus, limit int) (_ []PendingWebhook, err error) {
+	ctx, done := s.begin(ctx, "ListWebhooks")
+	defer func() { done(err) }()
+	return s.inner.ListWebhooks(ctx, status, limit)
+}
+
+func (s *instrumentedStore) RetryWebhook(ctx context.Context, webhookID string) (err error) {
+	ctx, done := s.begin(ctx, "RetryWebhook")
+	defer func() { done(err) }()
+	return s.inner.RetryWebhook(ctx, webhookID)
+}
+
+func (s *instrumentedStore) DeleteWebhook(ctx context.Context, webhookID string) (err error) {
+	ctx, done := s.begin(ctx, "DeleteWebhook")
+	defer func() { done(err) }()
+	return s.inner.DeleteWebhook(ctx, webhookID)
+}
+
+func (s *instrumentedStore) SaveIdempotencyKey(ctx context.Context, record IdempotencyRecord) (err error) {
+	ctx, done := s.begin(ctx, "SaveIdempotencyKey")
+	defer func() { done(err) }()
+	return s.inner.SaveIdempotencyKey(ctx, record)
+}
+
+func (s *instrumentedStore) GetIdempotencyKey(ctx context.Context, key string) (_ IdempotencyRecord, err error) {
+	ctx, done := s.begin(ctx, "GetIdempotencyKey")
+	defer func() { done(err) }()
+	return s.inner.GetIdempotencyKey(ctx, key)
+}
+
+func (s *instrumentedStore) DeleteIdempotencyKey(ctx context.Context, key string) (err error) {
+	ctx, done := s.begin(ctx, "DeleteIdempotencyKey")
+	defer func() { done(err) }()
+	return s.inner.DeleteIdempotencyKey(ctx, key)
+}
+
+func (s *instrumentedStore) CleanupExpiredIdempotencyKeys(ctx context.Context) (_ int64, err error) {
+	ctx, done := s.begin(ctx, "CleanupExpiredIdempotencyKeys")
+	defer func() { done(err) }()
+	return s.inner.CleanupExpiredIdempotencyKeys(ctx)
+}
+
+func (s *instrumentedStore) SetStock(ctx context.Context, resourceID string, quantity int64) (err error) {
+	ctx, done := s.begin(ctx, "SetStock")
+	defer func() { done(err) }()
+	return s.inner.SetStock(ctx, resourceID, quantity)
+}
+
+func (s *instrumentedStore) GetStock(ctx context.Context, resourceID string) (_ StockLevel, err error) {
+	ctx, done := s.begin(ctx, "GetStock")
+	defer func() { done(err) }()
+	return s.inner.GetStock(ctx, resourceID)
+}
+
+func (s *instrumentedStore) DeleteStock(ctx context.Context, resourceID string) (err error) {
+	ctx, done := s.begin(ctx, "DeleteStock")
+	defer func() { done(err) }()
+	return s.inner.DeleteStock(ctx, resourceID)
+}
+
+func (s *instrumentedStore) ReserveStock(ctx context.Context, reservation StockReservation) (err error) {
+	ctx, done := s.begin(ctx, "ReserveStock")
+	defer func() { done(err) }()
+	return s.inner.ReserveStock(ctx, reservation)
+}
+
+func (s *instrumentedStore) CommitStockReservation(ctx context.Context, reservationID string, items map[string]int64) (err error) {
+	ctx, done := s.begin(ctx, "CommitStockReservation")
+	defer func() { done(err) }()
+	return s.inner.CommitStockReservation(ctx, reservationID, items)
+}
+
+func (s *instrumentedStore) ReleaseStockReservation(ctx context.Context, reservationID string) (err error) {
+	ctx, done := s.begin(ctx, "ReleaseStockReservation")
+	defer func() { done(err) }()
+	return s.inner.ReleaseStockReservation(ctx, reservationID)
+}
+
+func (s *instrumentedStore) AddCartContribution(ctx context.Context, contribution CartContribution, total money.Money) (paid money.Money, err error) {
+	ctx, done := s.begin(ctx, "AddCartContribution")
+	defer func() { done(err) }()
+	return s.inner.AddCartContribution(ctx, contribution, total)
+}
+
+func (s *instrumentedStore) ListCartContributions(ctx context.Context, cartID string) (contributions []CartContribution, err error) {
+	ctx, done := s.begin(ctx, "ListCartContributions")
+	defer func() { done(err) }()
+	return s.inner.ListCartContributions(ctx, cartID)
+}
+
+func (s *instrumentedStore) SaveSavedCart(ctx context.Context, cart SavedCart) (err error) {
+	ctx, done := s.begin(ctx, "SaveSavedCart")
+	defer func() { done(err) }()
+	return s.inner.SaveSavedCart(ctx, cart)
+}
+
+func (s *instrumentedStore) GetSavedCart(ctx context.Context, wallet, name string) (cart SavedCart, err error) {
+	ctx, done := s.begin(ctx, "GetSavedCart")
+	defer func() { done(err) }()
+	return s.inner.GetSavedCart(ctx, wallet, name)
+}
+
+func (s *instrumentedStore) ListSavedCarts(ctx context.Context, wallet string) (carts []SavedCart, err error) {
+	ctx, done := s.begin(ctx, "ListSavedCarts")
+	defer func() { done(err) }()
+	return s.inner.ListSavedCarts(ctx, wallet)
+}
+
+func (s *instrumentedStore) DeleteSavedCart(ctx context.Context, wallet, name string) (err error) {
+	ctx, done := s.begin(ctx, "DeleteSavedCart")
+	defer func() { done(err) }()
+	return s.inner.DeleteSavedCart(ctx, wallet, name)
+}
+
+func (s *instrumentedStore) GetGiftCard(ctx context.Context, code string) (card GiftCard, err error) {
+	ctx, done := s.begin(ctx, "GetGiftCard")
+	defer func() { done(err) }()
+	return s.inner.GetGiftCard(ctx, code)
+}
+
+func (s *instrumentedStore) AdjustGiftCard(ctx context.Context, code string, faceValue, delta money.Money) (card GiftCard, err error) {
+	ctx, done := s.begin(ctx, "AdjustGiftCard")
+	defer func() { done(err) }()
+	return s.inner.AdjustGiftCard(ctx, code, faceValue, delta)
+}
+
+func (s *instrumentedStore) GetMeterAccount(ctx context.Context, account string) (meter MeterAccount, err error) {
+	ctx, done := s.begin(ctx, "GetMeterAccount")
+	defer func() { done(err) }()
+	return s.inner.GetMeterAccount(ctx, account)
+}
+
+func (s *instrumentedStore) CreditMeterAccount(ctx context.Context, account string, amount money.Money) (meter MeterAccount, err error) {
+	ctx, done := s.begin(ctx, "CreditMeterAccount")
+	defer func() { done(err) }()
+	return s.inner.CreditMeterAccount(ctx, account, amount)
+}
+
+func (s *instrumentedStore) ChargeMeterAccount(ctx context.Context, account string, amount money.Money) (meter MeterAccount, err error) {
+	ctx, done := s.begin(ctx, "ChargeMeterAccount")
+	defer func() { done(err) }()
+	return s.inner.ChargeMeterAccount(ctx, account, amount)
+}
+
+func (s *instrumentedStore) ListUnsettledMeterAccounts(ctx context.Context, limit int) (meters []MeterAccount, err error) {
+	ctx, done := s.begin(ctx, "ListUnsettledMeterAccounts")
+	defer func() { done(err) }()
+	return s.inner.ListUnsettledMeterAccounts(ctx, limit)
+}
+
+func (s *instrumentedStore) SettleMeterAccount(ctx context.Context, account string, amount money.Money, calls int64) (err error) {
+	ctx, done := s.begin(ctx, "SettleMeterAccount")
+	defer func() { done(err) }()
+	return s.inner.SettleMeterAccount(ctx, account, amount, calls)
+}
+
+func (s *instrumentedStore) RecordReferralConversion(ctx context.Context, conversion ReferralConversion) (err error) {
+	ctx, done := s.begin(ctx, "RecordReferralConversion")
+	defer func() { done(err) }()
+	return s.inner.RecordReferralConversion(ctx, conversion)
+}
+
+func (s *instrumentedStore) ListReferralConversions(ctx context.Context, referrerWallet string) (conversions []ReferralConversion, err error) {
+	ctx, done := s.begin(ctx, "ListReferralConversions")
+	defer func() { done(err) }()
+	return s.inner.ListReferralConversions(ctx, referrerWallet)
+}
+
+func (s *instrumentedStore) RecordCouponRedemption(ctx context.Context, redemption CouponRedemption, limit int) (err error) {
+	ctx, done := s.begin(ctx, "RecordCouponRedemption")
+	defer func() { done(err) }()
+	return s.inner.RecordCouponRedemption(ctx, redemption, limit)
+}
+
+func (s *instrumentedStore) CountCouponRedemptions(ctx context.Context, code, redeemer string) (count int, err error) {
+	ctx, done := s.begin(ctx, "CountCouponRedemptions")
+	defer func() { done(err) }()
+	return s.inner.CountCouponRedemptions(ctx, code, redeemer)
+}
+
+func (s *instrumentedStore) ListCouponPayments(ctx context.Context, since, until time.Time) (payments []PaymentTransaction, err error) {
+	ctx, done := s.begin(ctx, "ListCouponPayments")
+	defer func() { done(err) }()
+	return s.inner.ListCouponPayments(ctx, since, until)
+}
+
+func (s *instrumentedStore) ListCouponCartQuotes(ctx context.Context, since, until time.Time) (quotes []CartQuote, err error) {
+	ctx, done := s.begin(ctx, "ListCouponCartQuotes")
+	defer func() { done(err) }()
+	return s.inner.ListCouponCartQuotes(ctx, since, until)
+}
+
+func (s *instrumentedStore) LinkCustomerIdentities(ctx context.Context, identities []CustomerIdentity) (customer Customer, err error) {
+	ctx, done := s.begin(ctx, "LinkCustomerIdentities")
+	defer func() { done(err) }()
+	return s.inner.LinkCustomerIdentities(ctx, identities)
+}
+
+func (s *instrumentedStore) GetCustomer(ctx context.Context, id string) (customer Customer, err error) {
+	ctx, done := s.begin(ctx, "GetCustomer")
+	defer func() { done(err) }()
+	return s.inner.GetCustomer(ctx, id)
+}
+
+func (s *instrumentedStore) FindCustomer(ctx context.Context, kind, value string) (customer Customer, err error) {
+	ctx, done := s.begin(ctx, "FindCustomer")
+	defer func() { done(err) }()
+	return s.inner.FindCustomer(ctx, kind, value)
+}
+
+func (s *instrumentedStore) ListPaymentsByPayer(ctx context.Context, payers []string, limit int) (payments []PaymentTransaction, err error) {
+	ctx, done := s.begin(ctx, "ListPaymentsByPayer")
+	defer func() { done(err) }()
+	return s.inner.ListPaymentsByPayer(ctx, payers, limit)
+}
+
+func (s *instrumentedStore) SumPayments(ctx context.Context, since, until time.Time) (totals []PaymentTotal, err error) {
+	ctx, done := s.begin(ctx, "SumPayments")
+	defer func() { done(err) }()
+	return s.inner.SumPayments(ctx, since, until)
+}
+
+func (s *instrumentedStore) RecordRefundAudit(ctx context.Context, entry RefundAuditEntry) (err error) {
+	ctx, done := s.begin(ctx, "RecordRefundAudit")
+	defer func() { done(err) }()
+	return s.inner.RecordRefundAudit(ctx, entry)
+}
+
+func (s *instrumentedStore) ListRefundAudit(ctx context.Context, refundID string) (entries []RefundAuditEntry, err error) {
+	ctx, done := s.begin(ctx, "ListRefundAudit")
+	defer func() { done(err) }()
+	return s.inner.ListRefundAudit(ctx, refundID)
+}
+
+func (s *instrumentedStore) RecordAdminAudit(ctx context.Context, entry AdminAuditEntry) (err error) {
+	ctx, done := s.begin(ctx, "RecordAdminAudit")
+	defer func() { done(err) }()
+	return s.inner.RecordAdminAudit(ctx, entry)
+}
+
+func (s *instrumentedStore) ListAdminAudit(ctx context.Context, filter AdminAuditFilter) (entries []AdminAuditEntry, err error) {
+	ctx, done := s.begin(ctx, "ListAdminAudit")
+	defer func() { done(err) }()
+	return s.inner.ListAdminAudit(ctx, filter)
+}
+
+func (s *instrumentedStore) RecordWebhookDelivery(ctx context.Context, delivery WebhookDelivery) (err error) {
+	ctx, done := s.begin(ctx, "RecordWebhookDelivery")
+	defer func() { done(err) }()
+	return s.inner.RecordWebhookDelivery(ctx, delivery)
+}
+
+func (s *instrumentedStore) ListWebhookDeliveries(ctx context.Context, filter WebhookDeliveryFilter) (deliveries []WebhookDelivery, err error) {
+	ctx, done := s.begin(ctx, "ListWebhookDeliveries")
+	defer func() { done(err) }()
+	return s.inner.ListWebhookDeliveries(ctx, filter)
+}
+
+func (s *instrumentedStore) SaveAccessRule(ctx context.Context, rule AccessRule) (saved AccessRule, err error) {
+	ctx, done := s.begin(ctx, "SaveAccessRule")
+	defer func() { done(err) }()
+	return s.inner.SaveAccessRule(ctx, rule)
+}
+
+func (s *instrumentedStore) ListAccessRules(ctx context.Context) (rules []AccessRule, err error) {
+	ctx, done := s.begin(ctx, "ListAccessRules")
+	defer func() { done(err) }()
+	return s.inner.ListAccessRules(ctx)
+}
+
+func (s *instrumentedStore) DeleteAccessRule(ctx context.Context, kind, value string) (err error) {
+	ctx, done := s.begin(ctx, "DeleteAccessRule")
+	defer func() { done(err) }()
+	return s.inner.DeleteAccessRule(ctx, kind, value)
+}
+
+// Unwrap returns the underlying store.
+func (s *instrumentedStore) Unwrap() Store {
+	return s.inner
+}
+
+// Close closes the wrapped store.
+func (s *instrumentedStore) Close() error {
+	return s.inner.Close()
+}
+
+var _ Store = (*instrumentedStore)(nil)
+
+// unwrapStore strips wrappers (instrumentation, replay cache) to reach the backend.
+func unwrapStore(store Store) Store {
+	for {
+		wrapped, ok := store.(interface{ Unwrap() Store })
+		if !ok {
+			return store
+		}
+		store = wrapped.Unwrap()
+	}
+}
@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/CedrosPay/server/internal/metrics"
+)
+
+func TestInstrumentedStoreTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	inner := NewMemoryStore()
+	defer inner.Close()
+	store := NewInstrumentedStore(inner, InstrumentOptions{Tracing: true, Logger: zerolog.Nop()})
+	ctx := context.Background()
+
+	if _, err := store.GetPayment(ctx, "missing"); err != ErrNotFound {
+		t.Fatalf("GetPayment err = %v", err)
+	}
+	if err := store.ConsumeNonce(ctx, "missing"); err == nil {
+		t.Fatal("ConsumeNonce should fail for unknown nonce")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+
+	tests := []struct {
+		name      string
+		table     string
+		wantError bool
+	}{
+		{name: "storage.GetPayment", table: "payment_transactions"},
+		{name: "storage.ConsumeNonce", table: "admin_nonces", wantError: true},
+	}
+	for i, tt := range tests {
+		span := spans[i]
+		if span.Name() != tt.name {
+			t.Errorf("span %d name = %q, want %q", i, span.Name(), tt.name)
+		}
+		attrs := map[string]string{}
+		for _, kv := range span.Attributes() {
+			attrs[string(kv.Key)] = kv.Value.Emit()
+		}
+		if attrs["db.system.name"] != "memory" || attrs["db.collection.name"] != tt.table {
+			t.Errorf("%s attributes = %v", tt.name, attrs)
+		}
+		if (span.Status().Code == codes.Error) != tt.wantError {
+			t.Errorf("%s status = %v, want error %v", tt.name, span.Status(), tt.wantError)
+		}
+	}
+}
+
+func TestInstrumentedStoreMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	collector := metrics.New(registry)
+	var logs bytes.Buffer
+
+	inner := NewMemoryStore()
+	defer inner.Close()
+	store := NewInstrumentedStore(inner, InstrumentOptions{
+		Metrics:            collector,
+		SlowQueryThreshold: time.Nanosecond, // Every operation is "slow"
+		Logger:             zerolog.New(&logs),
+	})
+	ctx := context.Background()
+
+	_, _ = store.GetPayment(ctx, "missing")
+	_ = store.ConsumeNonce(ctx, "missing")
+	_ = store.ConsumeNonce(ctx, "missing")
+
+	tests := []struct {
+		name   string
+		metric *prometheus.CounterVec
+		labels []string
+		want   float64
+	}{
+		{name: "not found is not an error", metric: collector.StoreOperationErrorsTotal, labels: []string{"memory", "payment_transactions", "GetPayment"}, want: 0},
+		{name: "errors counted", metric: collector.StoreOperationErrorsTotal, labels: []string{"memory", "admin_nonces", "ConsumeNonce"}, want: 2},
+		{name: "slow operations counted", metric: collector.StoreSlowOperationsTotal, labels: []string{"memory", "admin_nonces", "ConsumeNonce"}, want: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := testutil.ToFloat64(tt.metric.WithLabelValues(tt.labels...)); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := testutil.CollectAndCount(collector.StoreOperationDuration); got != 2 {
+		t.Errorf("duration series = %d, want 2", got)
+	}
+	if !strings.Contains(logs.String(), `"table":"admin_nonces"`) || !strings.Contains(logs.String(), "storage.slow_query") {
+		t.Errorf("slow query not logged: %s", logs.String())
+	}
+}
+
+// TestOperationTablesCoverStore fails when a Store method is added without a table mapping.
+func TestOperationTablesCoverStore(t *testing.T) {
+	storeType := reflect.TypeOf((*Store)(nil)).Elem()
+	for i := 0; i < storeType.NumMethod(); i++ {
+		name := storeType.Method(i).Name
+		if name == "Close" {
+			continue
+		}
+		if _, ok := operationTables[name]; !ok {
+			t.Errorf("operationTables missing %s", name)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrOutOfStock is returned when a reservation asks for more units than are available.
+var ErrOutOfStock = errors.New("storage: out of stock")
+
+// StockLevel is the stock held for one resource. Resources without a stock level are
+// untracked and can be sold without limit.
+type StockLevel struct {
+	ResourceID string    `json:"resourceId"`
+	Quantity   int64     `json:"quantity"`  // Units on hand (decremented when a reservation is committed)
+	Reserved   int64     `json:"reserved"`  // Units held by unexpired reservations
+	UpdatedAt  time.Time `json:"updatedAt"` // When the quantity was last set or decremented
+}
+
+// Available returns the units that can still be reserved.
+func (l StockLevel) Available() int64 {
+	if available := l.Quantity - l.Reserved; available > 0 {
+		return available
+	}
+	return 0
+}
+
+// StockReservation holds units of one or more resources for an unpaid quote. Reservations stop
+// counting against stock once ExpiresAt passes, so an abandoned quote releases its stock on expiry.
+type StockReservation struct {
+	ID        string           `json:"id"`        // Quote ID the units are held for (e.g. cart ID)
+	Items     map[string]int64 `json:"items"`     // Resource ID -> units held
+	ExpiresAt time.Time        `json:"expiresAt"` // When the hold lapses
+}
+
+// IsExpiredAt returns true if the reservation has lapsed at the given moment.
+func (r StockReservation) IsExpiredAt(now time.Time) bool {
+	return !now.Before(r.ExpiresAt)
+}
+
+// validateStockReservation checks a reservation before it is stored.
+func validateStockReservation(r StockReservation) error {
+	if r.ID == "" {
+		return fmt.Errorf("reservation id required")
+	}
+	if r.ExpiresAt.IsZero() {
+		return fmt.Errorf("reservation expiry required")
+	}
+	for resourceID, units := range r.Items {
+		if resourceID == "" || units <= 0 {
+			return fmt.Errorf("reservation %s: invalid item %q x%d", r.ID, resourceID, units)
+		}
+	}
+	return nil
+}
+
+// outOfStock builds the error returned when resourceID cannot cover a reservation.
+func outOfStock(resourceID string, requested, available int64) error {
+	if available < 0 {
+		available = 0
+	}
+	return fmt.Errorf("%w: %s (requested %d, available %d)", ErrOutOfStock, resourceID, requested, available)
+}
+
+// reservedUnits sums the units of resourceID held by unexpired reservations other than excludeID.
+func reservedUnits(reservations map[string]StockReservation, resourceID, excludeID string, now time.Time) int64 {
+	var reserved int64
+	for id, r := range reservations {
+		if id != excludeID && !r.IsExpiredAt(now) {
+			reserved += r.Items[resourceID]
+		}
+	}
+	return reserved
+}
+
+// reserveInMaps implements ReserveStock for the map-backed stores. Callers hold the write lock.
+// Only tracked resources are held; untracked ones are dropped from the stored reservation.
+func reserveInMaps(stock map[string]StockLevel, reservations map[string]StockReservation, r StockReservation, now time.Time) error {
+	held := make(map[string]int64, len(r.Items))
+	for resourceID, units := range r.Items {
+		level, ok := stock[resourceID]
+		if !ok {
+			continue
+		}
+		available := level.Quantity - reservedUnits(reservations, resourceID, r.ID, now)
+		if units > available {
+			return outOfStock(resourceID, units, available)
+		}
+		held[resourceID] = units
+	}
+
+	delete(reservations, r.ID)
+	for id, existing := range reservations {
+		if existing.IsExpiredAt(now) {
+			delete(reservations, id)
+		}
+	}
+	if len(held) > 0 {
+		r.Items = held
+		reservations[r.ID] = r
+	}
+	return nil
+}
+
+// commitInMaps implements CommitStockReservation for the map-backed stores. Callers hold the
+// write lock. It reports whether anything changed.
+func commitInMaps(stock map[string]StockLevel, reservations map[string]StockReservation, reservationID string, items map[string]int64, now time.Time) bool {
+	changed := false
+	if r, ok := reservations[reservationID]; ok {
+		items = r.Items
+		delete(reservations, reservationID)
+		changed = true
+	}
+	for resourceID, units := range items {
+		if level, tracked := stock[resourceID]; tracked && units > 0 {
+			level.Quantity -= units
+			level.UpdatedAt = now
+			stock[resourceID] = level
+			changed = true
+		}
+	}
+	return changed
+}
+
+// stockLevelFromMaps returns resourceID's stock level with its current reservations.
+func stockLevelFromMaps(stock map[string]StockLevel, reservations map[string]StockReservation, resourceID string, now time.Time) (StockLevel, error) {
+	level, ok := stock[resourceID]
+	if !ok {
+		return StockLevel{}, ErrNotFound
+	}
+	level.Reserved = reservedUnits(reservations, resourceID, "", now)
+	return level, nil
+}
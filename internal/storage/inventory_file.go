@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// SetStock sets the units on hand for a resource, starting to track it if needed.
+func (s *FileStore) SetStock(_ context.Context, resourceID string, quantity int64) error {
+	if resourceID == "" || quantity < 0 {
+		return fmt.Errorf("invalid stock %q x%d", resourceID, quantity)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stock[resourceID] = StockLevel{ResourceID: resourceID, Quantity: quantity, UpdatedAt: time.Now()}
+	s.markDirty()
+	return nil
+}
+
+// GetStock returns the stock level for a resource.
+func (s *FileStore) GetStock(_ context.Context, resourceID string) (StockLevel, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return stockLevelFromMaps(s.stock, s.stockReservations, resourceID, time.Now())
+}
+
+// DeleteStock stops tracking stock for a resource.
+func (s *FileStore) DeleteStock(_ context.Context, resourceID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.stock[resourceID]; ok {
+		delete(s.stock, resourceID)
+		s.markDirty()
+	}
+	return nil
+}
+
+// ReserveStock holds units for a quote, replacing any earlier reservation with the same ID.
+func (s *FileStore) ReserveStock(_ context.Context, reservation StockReservation) error {
+	if err := validateStockReservation(reservation); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := reserveInMaps(s.stock, s.stockReservations, reservation, time.Now()); err != nil {
+		return err
+	}
+	s.markDirty()
+	return nil
+}
+
+// CommitStockReservation removes a reservation's units (or items, if it has lapsed) from stock.
+func (s *FileStore) CommitStockReservation(_ context.Context, reservationID string, items map[string]int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if commitInMaps(s.stock, s.stockReservations, reservationID, items, time.Now()) {
+		s.markDirty()
+	}
+	return nil
+}
+
+// ReleaseStockReservation returns a reservation's units to stock.
+func (s *FileStore) ReleaseStockReservation(_ context.Context, reservationID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.stockReservations[reservationID]; ok {
+		delete(s.stockReservations, reservationID)
+		s.markDirty()
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// SetStock sets the units on hand for a resource, starting to track it if needed.
+func (m *MemoryStore) SetStock(_ context.Context, resourceID string, quantity int64) error {
+	if resourceID == "" || quantity < 0 {
+		return fmt.Errorf("invalid stock %q x%d", resourceID, quantity)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.stock[resourceID] = StockLevel{ResourceID: resourceID, Quantity: quantity, UpdatedAt: time.Now()}
+	return nil
+}
+
+// GetStock returns the stock level for a resource.
+func (m *MemoryStore) GetStock(_ context.Context, resourceID string) (StockLevel, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return stockLevelFromMaps(m.stock, m.stockReservations, resourceID, time.Now())
+}
+
+// DeleteStock stops tracking stock for a resource.
+func (m *MemoryStore) DeleteStock(_ context.Context, resourceID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.stock, resourceID)
+	return nil
+}
+
+// ReserveStock holds units for a quote, replacing any earlier reservation with the same ID.
+func (m *MemoryStore) ReserveStock(_ context.Context, reservation StockReservation) error {
+	if err := validateStockReservation(reservation); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return reserveInMaps(m.stock, m.stockReservations, reservation, time.Now())
+}
+
+// CommitStockReservation removes a reservation's units (or items, if it has lapsed) from stock.
+func (m *MemoryStore) CommitStockReservation(_ context.Context, reservationID string, items map[string]int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	commitInMaps(m.stock, m.stockReservations, reservationID, items, time.Now())
+	return nil
+}
+
+// ReleaseStockReservation returns a reservation's units to stock.
+func (m *MemoryStore) ReleaseStockReservation(_ context.Context, reservationID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.stockReservations, reservationID)
+	return nil
+}
+
+// removeExpiredStockReservations deletes lapsed reservations.
+func (m *MemoryStore) removeExpiredStockReservations() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for id, r := range m.stockReservations {
+		if r.IsExpiredAt(now) {
+			delete(m.stockReservations, id)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const stockCollection = "inventory_stock"
+
+// stockDocument is a resource's stock with its reservations embedded, so a reservation can be
+// checked against the quantity and added in a single atomic update.
+type stockDocument struct {
+	ResourceID   string      `bson:"_id"`
+	Quantity     int64       `bson:"quantity"`
+	UpdatedAt    time.Time   `bson:"updated_at"`
+	Reservations []stockHold `bson:"reservations"`
+}
+
+// stockHold is one reservation's units of the document's resource.
+type stockHold struct {
+	ID        string    `bson:"id"`
+	Quantity  int64     `bson:"quantity"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+// SetStock sets the units on hand for a resource, starting to track it if needed.
+func (s *MongoDBStore) SetStock(ctx context.Context, resourceID string, quantity int64) error {
+	if resourceID == "" || quantity < 0 {
+		return fmt.Errorf("invalid stock %q x%d", resourceID, quantity)
+	}
+
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	coll := s.db.Collection(stockCollection)
+	update := bson.M{
+		"$set":         bson.M{"quantity": quantity, "updated_at": time.Now()},
+		"$setOnInsert": bson.M{"reservations": bson.A{}},
+	}
+	if _, err := coll.UpdateOne(ctx, bson.M{"_id": resourceID}, update, options.Update().SetUpsert(true)); err != nil {
+		return fmt.Errorf("set stock: %w", err)
+	}
+	return nil
+}
+
+// GetStock returns the stock level for a resource.
+func (s *MongoDBStore) GetStock(ctx context.Context, resourceID string) (StockLevel, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	var doc stockDocument
+	err := s.db.Collection(stockCollection).FindOne(ctx, bson.M{"_id": resourceID}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return StockLevel{}, ErrNotFound
+	}
+	if err != nil {
+		return StockLevel{}, fmt.Errorf("get stock: %w", err)
+	}
+	return doc.level(time.Now()), nil
+}
+
+// DeleteStock stops tracking stock for a resource.
+func (s *MongoDBStore) DeleteStock(ctx context.Context, resourceID string) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	if _, err := s.db.Collection(stockCollection).DeleteOne(ctx, bson.M{"_id": resourceID}); err != nil {
+		return fmt.Errorf("delete stock: %w", err)
+	}
+	return nil
+}
+
+// ReserveStock holds units for a quote, replacing any earlier reservation with the same ID.
+// Each resource is held with a conditional update; if one is short, the holds already taken
+// are removed again.
+func (s *MongoDBStore) ReserveStock(ctx context.Context, reservation StockReservation) error {
+	if err := validateStockReservation(reservation); err != nil {
+		return err
+	}
+
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	coll := s.db.Collection(stockCollection)
+	now := time.Now()
+
+	resourceIDs := make([]string, 0, len(reservation.Items))
+	for resourceID := range reservation.Items {
+		resourceIDs = append(resourceIDs, resourceID)
+	}
+
+	// Check every resource first so a short resource leaves the earlier reservation in place
+	cursor, err := coll.Find(ctx, bson.M{"_id": bson.M{"$in": resourceIDs}})
+	if err != nil {
+		return fmt.Errorf("find stock: %w", err)
+	}
+	var docs []stockDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return fmt.Errorf("decode stock: %w", err)
+	}
+	for _, doc := range docs {
+		units := reservation.Items[doc.ResourceID]
+		if available := doc.Quantity - doc.reserved(reservation.ID, now); units > available {
+			return outOfStock(doc.ResourceID, units, available)
+		}
+	}
+
+	// Drop the earlier reservation and any lapsed holds on these resources
+	release := bson.M{"$pull": bson.M{"reservations": bson.M{"$or": bson.A{
+		bson.M{"id": reservation.ID},
+		bson.M{"expires_at": bson.M{"$lte": now}},
+	}}}}
+	if _, err := coll.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": resourceIDs}}, release); err != nil {
+		return fmt.Errorf("replace reservation: %w", err)
+	}
+
+	for _, doc := range docs {
+		units := reservation.Items[doc.ResourceID]
+		filter := bson.M{
+			"_id": doc.ResourceID,
+			"$expr": bson.M{"$gte": bson.A{
+				bson.M{"$subtract": bson.A{"$quantity", bson.M{"$sum": "$reservations.quantity"}}},
+				units,
+			}},
+		}
+		hold := bson.M{"$push": bson.M{"reservations": stockHold{ID: reservation.ID, Quantity: units, ExpiresAt: reservation.ExpiresAt}}}
+		result, err := coll.UpdateOne(ctx, filter, hold)
+		if err == nil && result.MatchedCount == 1 {
+			continue
+		}
+		_ = s.ReleaseStockReservation(ctx, reservation.ID)
+		if err != nil {
+			return fmt.Errorf("reserve stock: %w", err)
+		}
+		return outOfStock(doc.ResourceID, units, 0) // Taken by a concurrent reservation
+	}
+	return nil
+}
+
+// CommitStockReservation removes a reservation's units (or items, if it has lapsed) from stock.
+func (s *MongoDBStore) CommitStockReservation(ctx context.Context, reservationID string, items map[string]int64) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	coll := s.db.Collection(stockCollection)
+	cursor, err := coll.Find(ctx, bson.M{"reservations.id": reservationID})
+	if err != nil {
+		return fmt.Errorf("find stock reservation: %w", err)
+	}
+	var docs []stockDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return fmt.Errorf("decode stock: %w", err)
+	}
+	if len(docs) == 0 {
+		// The hold lapsed before the payment landed: the units were still sold
+		for resourceID, units := range items {
+			if units <= 0 {
+				continue
+			}
+			update := bson.M{
+				"$inc": bson.M{"quantity": -units},
+				"$set": bson.M{"updated_at": time.Now()},
+			}
+			if _, err := coll.UpdateOne(ctx, bson.M{"_id": resourceID}, update); err != nil {
+				return fmt.Errorf("commit stock: %w", err)
+			}
+		}
+		return nil
+	}
+
+	for _, doc := range docs {
+		var units int64
+		for _, h := range doc.Reservations {
+			if h.ID == reservationID {
+				units += h.Quantity
+			}
+		}
+		// Matching on the hold makes a repeated commit a no-op
+		filter := bson.M{"_id": doc.ResourceID, "reservations.id": reservationID}
+		update := bson.M{
+			"$inc":  bson.M{"quantity": -units},
+			"$set":  bson.M{"updated_at": time.Now()},
+			"$pull": bson.M{"reservations": bson.M{"id": reservationID}},
+		}
+		if _, err := coll.UpdateOne(ctx, filter, update); err != nil {
+			return fmt.Errorf("commit stock reservation: %w", err)
+		}
+	}
+	return nil
+}
+
+// ReleaseStockReservation returns a reservation's units to stock.
+func (s *MongoDBStore) ReleaseStockReservation(ctx context.Context, reservationID string) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	coll := s.db.Collection(stockCollection)
+	update := bson.M{"$pull": bson.M{"reservations": bson.M{"id": reservationID}}}
+	if _, err := coll.UpdateMany(ctx, bson.M{"reservations.id": reservationID}, update); err != nil {
+		return fmt.Errorf("release stock reservation: %w", err)
+	}
+	return nil
+}
+
+// reserved sums the units held by unexpired reservations other than excludeID.
+func (d stockDocument) reserved(excludeID string, now time.Time) int64 {
+	var reserved int64
+	for _, h := range d.Reservations {
+		if h.ID != excludeID && h.ExpiresAt.After(now) {
+			reserved += h.Quantity
+		}
+	}
+	return reserved
+}
+
+// level converts the document into a StockLevel.
+func (d stockDocument) level(now time.Time) StockLevel {
+	return StockLevel{
+		ResourceID: d.ResourceID,
+		Quantity:   d.Quantity,
+		Reserved:   d.reserved("", now),
+		UpdatedAt:  d.UpdatedAt,
+	}
+}
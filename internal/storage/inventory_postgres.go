@@ -0,0 +1,198 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// SetStock sets the units on hand for a resource, starting to track it if needed.
+func (s *PostgresStore) SetStock(ctx context.Context, resourceID string, quantity int64) error {
+	if resourceID == "" || quantity < 0 {
+		return fmt.Errorf("invalid stock %q x%d", resourceID, quantity)
+	}
+
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`
+		INSERT INTO %s (resource_id, quantity, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (resource_id) DO UPDATE SET
+			quantity = EXCLUDED.quantity,
+			updated_at = EXCLUDED.updated_at
+	`, s.stockTableName)
+
+	if _, err := s.db.ExecContext(ctx, query, resourceID, quantity, time.Now().UTC()); err != nil {
+		return fmt.Errorf("set stock: %w", err)
+	}
+	return nil
+}
+
+// GetStock returns the stock level for a resource.
+func (s *PostgresStore) GetStock(ctx context.Context, resourceID string) (StockLevel, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`
+		SELECT s.resource_id, s.quantity, s.updated_at,
+			COALESCE((SELECT SUM(r.quantity) FROM %s r WHERE r.resource_id = s.resource_id AND r.expires_at > $2), 0)
+		FROM %s s
+		WHERE s.resource_id = $1
+	`, s.stockReservationsTableName, s.stockTableName)
+
+	var level StockLevel
+	err := s.db.QueryRowContext(ctx, query, resourceID, time.Now().UTC()).Scan(
+		&level.ResourceID, &level.Quantity, &level.UpdatedAt, &level.Reserved)
+	if errors.Is(err, sql.ErrNoRows) {
+		return StockLevel{}, ErrNotFound
+	}
+	if err != nil {
+		return StockLevel{}, fmt.Errorf("get stock: %w", err)
+	}
+	return level, nil
+}
+
+// DeleteStock stops tracking stock for a resource.
+func (s *PostgresStore) DeleteStock(ctx context.Context, resourceID string) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`DELETE FROM %s WHERE resource_id = $1`, s.stockTableName)
+	if _, err := s.db.ExecContext(ctx, query, resourceID); err != nil {
+		return fmt.Errorf("delete stock: %w", err)
+	}
+	return nil
+}
+
+// ReserveStock holds units for a quote, replacing any earlier reservation with the same ID.
+// Stock rows are locked in resource order so concurrent reservations cannot oversell.
+func (s *PostgresStore) ReserveStock(ctx context.Context, reservation StockReservation) error {
+	if err := validateStockReservation(reservation); err != nil {
+		return err
+	}
+
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	resourceIDs := make([]string, 0, len(reservation.Items))
+	for resourceID := range reservation.Items {
+		resourceIDs = append(resourceIDs, resourceID)
+	}
+	sort.Strings(resourceIDs)
+
+	now := time.Now().UTC()
+	lockQuery := fmt.Sprintf(`SELECT quantity FROM %s WHERE resource_id = $1 FOR UPDATE`, s.stockTableName)
+	reservedQuery := fmt.Sprintf(`
+		SELECT COALESCE(SUM(quantity), 0) FROM %s
+		WHERE resource_id = $1 AND id <> $2 AND expires_at > $3
+	`, s.stockReservationsTableName)
+	insertQuery := fmt.Sprintf(`INSERT INTO %s (id, resource_id, quantity, expires_at) VALUES ($1, $2, $3, $4)`, s.stockReservationsTableName)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin reserve stock tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	held := make(map[string]int64, len(resourceIDs))
+	for _, resourceID := range resourceIDs {
+		var quantity int64
+		err := tx.QueryRowContext(ctx, lockQuery, resourceID).Scan(&quantity)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue // Untracked resource
+		}
+		if err != nil {
+			return fmt.Errorf("lock stock: %w", err)
+		}
+		var reserved int64
+		if err := tx.QueryRowContext(ctx, reservedQuery, resourceID, reservation.ID, now).Scan(&reserved); err != nil {
+			return fmt.Errorf("sum reservations: %w", err)
+		}
+		units := reservation.Items[resourceID]
+		if units > quantity-reserved {
+			return outOfStock(resourceID, units, quantity-reserved)
+		}
+		held[resourceID] = units
+	}
+
+	deleteQuery := fmt.Sprintf(`DELETE FROM %s WHERE id = $1 OR expires_at <= $2`, s.stockReservationsTableName)
+	if _, err := tx.ExecContext(ctx, deleteQuery, reservation.ID, now); err != nil {
+		return fmt.Errorf("replace reservation: %w", err)
+	}
+	for _, resourceID := range resourceIDs {
+		units, ok := held[resourceID]
+		if !ok {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, insertQuery, reservation.ID, resourceID, units, reservation.ExpiresAt.UTC()); err != nil {
+			return fmt.Errorf("insert reservation: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// CommitStockReservation removes a reservation's units (or items, if it has lapsed) from stock.
+func (s *PostgresStore) CommitStockReservation(ctx context.Context, reservationID string, items map[string]int64) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin commit stock tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Deleting the reservation rows claims them, so concurrent commits decrement them once
+	claimQuery := fmt.Sprintf(`DELETE FROM %s WHERE id = $1 RETURNING resource_id, quantity`, s.stockReservationsTableName)
+	rows, err := tx.QueryContext(ctx, claimQuery, reservationID)
+	if err != nil {
+		return fmt.Errorf("claim stock reservation: %w", err)
+	}
+	held := make(map[string]int64)
+	for rows.Next() {
+		var resourceID string
+		var units int64
+		if err := rows.Scan(&resourceID, &units); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan stock reservation: %w", err)
+		}
+		held[resourceID] += units
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("claim stock reservation: %w", err)
+	}
+	if len(held) > 0 {
+		items = held
+	}
+
+	// Untracked resources have no stock row, so the update leaves them alone
+	now := time.Now().UTC()
+	updateQuery := fmt.Sprintf(`UPDATE %s SET quantity = quantity - $2, updated_at = $3 WHERE resource_id = $1`, s.stockTableName)
+	for resourceID, units := range items {
+		if units <= 0 {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, updateQuery, resourceID, units, now); err != nil {
+			return fmt.Errorf("commit stock reservation: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// ReleaseStockReservation returns a reservation's units to stock.
+func (s *PostgresStore) ReleaseStockReservation(ctx context.Context, reservationID string) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, s.stockReservationsTableName)
+	if _, err := s.db.ExecContext(ctx, query, reservationID); err != nil {
+		return fmt.Errorf("release stock reservation: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStockReservations(t *testing.T) {
+	backends := []struct {
+		name string
+		open func(t *testing.T) Store
+	}{
+		{name: "memory", open: func(t *testing.T) Store { return NewMemoryStore() }},
+		{
+			name: "file",
+			open: func(t *testing.T) Store {
+				store, err := NewFileStore(filepath.Join(t.TempDir(), "store.json"))
+				if err != nil {
+					t.Fatalf("NewFileStore: %v", err)
+				}
+				return store
+			},
+		},
+	}
+
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			store := backend.open(t)
+			defer store.Close()
+			ctx := context.Background()
+			later := time.Now().Add(time.Hour)
+
+			if _, err := store.GetStock(ctx, "tee"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("GetStock untracked: err = %v, want ErrNotFound", err)
+			}
+			if err := store.SetStock(ctx, "tee", 5); err != nil {
+				t.Fatalf("SetStock: %v", err)
+			}
+
+			steps := []struct {
+				name         string
+				reservation  StockReservation
+				wantErr      error
+				wantReserved int64
+			}{
+				{name: "within stock", reservation: StockReservation{ID: "cart_a", Items: map[string]int64{"tee": 3, "ebook": 10}, ExpiresAt: later}, wantReserved: 3},
+				{name: "short", reservation: StockReservation{ID: "cart_b", Items: map[string]int64{"tee": 3}, ExpiresAt: later}, wantErr: ErrOutOfStock, wantReserved: 3},
+				{name: "replaces own hold", reservation: StockReservation{ID: "cart_a", Items: map[string]int64{"tee": 5}, ExpiresAt: later}, wantReserved: 5},
+				{name: "shrinks own hold", reservation: StockReservation{ID: "cart_a", Items: map[string]int64{"tee": 2}, ExpiresAt: later}, wantReserved: 2},
+				{name: "second cart", reservation: StockReservation{ID: "cart_b", Items: map[string]int64{"tee": 3}, ExpiresAt: later}, wantReserved: 5},
+			}
+			for _, step := range steps {
+				err := store.ReserveStock(ctx, step.reservation)
+				if !errors.Is(err, step.wantErr) {
+					t.Fatalf("%s: ReserveStock err = %v, want %v", step.name, err, step.wantErr)
+				}
+				level, err := store.GetStock(ctx, "tee")
+				if err != nil || level.Reserved != step.wantReserved {
+					t.Fatalf("%s: GetStock = %+v, %v, want reserved %d", step.name, level, err, step.wantReserved)
+				}
+			}
+
+			// Paying cart_a takes its held units out of stock
+			if err := store.CommitStockReservation(ctx, "cart_a", map[string]int64{"tee": 2}); err != nil {
+				t.Fatalf("CommitStockReservation: %v", err)
+			}
+			if level, _ := store.GetStock(ctx, "tee"); level.Quantity != 3 || level.Reserved != 3 || level.Available() != 0 {
+				t.Errorf("after commit: %+v", level)
+			}
+
+			// Abandoning cart_b returns its units
+			if err := store.ReleaseStockReservation(ctx, "cart_b"); err != nil {
+				t.Fatalf("ReleaseStockReservation: %v", err)
+			}
+			if level, _ := store.GetStock(ctx, "tee"); level.Quantity != 3 || level.Available() != 3 {
+				t.Errorf("after release: %+v", level)
+			}
+
+			// A sale without a hold still takes its units out of stock
+			if err := store.CommitStockReservation(ctx, "sig_c", map[string]int64{"tee": 1, "ebook": 1}); err != nil {
+				t.Fatalf("CommitStockReservation without hold: %v", err)
+			}
+			if level, _ := store.GetStock(ctx, "tee"); level.Quantity != 2 || level.Available() != 2 {
+				t.Errorf("after unheld commit: %+v", level)
+			}
+
+			// A hold that lapses stops counting against stock, but a late payment still commits it
+			expiring := StockReservation{ID: "cart_d", Items: map[string]int64{"tee": 2}, ExpiresAt: time.Now().Add(50 * time.Millisecond)}
+			if err := store.ReserveStock(ctx, expiring); err != nil {
+				t.Fatalf("ReserveStock: %v", err)
+			}
+			time.Sleep(100 * time.Millisecond)
+			if level, _ := store.GetStock(ctx, "tee"); level.Available() != 2 {
+				t.Errorf("after expiry: %+v", level)
+			}
+			if err := store.CommitStockReservation(ctx, "cart_d", map[string]int64{"tee": 2}); err != nil {
+				t.Fatalf("CommitStockReservation after expiry: %v", err)
+			}
+			if level, _ := store.GetStock(ctx, "tee"); level.Quantity != 0 || level.Available() != 0 {
+				t.Errorf("after late commit: %+v", level)
+			}
+
+			if err := store.DeleteStock(ctx, "tee"); err != nil {
+				t.Fatalf("DeleteStock: %v", err)
+			}
+			if err := store.ReserveStock(ctx, StockReservation{ID: "cart_e", Items: map[string]int64{"tee": 100}, ExpiresAt: later}); err != nil {
+				t.Errorf("untracked resource: ReserveStock err = %v", err)
+			}
+		})
+	}
+}
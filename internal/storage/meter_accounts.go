@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+// ErrMeterBalance is returned when a charge would take a meter account's balance below zero.
+var ErrMeterBalance = errors.New("storage: insufficient meter balance")
+
+// MeterAccount is a prepaid balance that metered API calls are charged against. Unsettled and
+// Calls count the charges not yet recorded by a metering settlement.
+type MeterAccount struct {
+	Account   string      `json:"account"`
+	Balance   money.Money `json:"balance"`
+	Unsettled money.Money `json:"unsettled"`
+	Calls     int64       `json:"calls"`
+	UpdatedAt time.Time   `json:"updatedAt"`
+}
+
+// validateMeterAmount checks an amount credited, charged, or settled on a meter account.
+func validateMeterAmount(account string, amount money.Money) error {
+	if account == "" {
+		return fmt.Errorf("meter account required")
+	}
+	if amount.Atomic < 0 {
+		return fmt.Errorf("meter account %s: amount must not be negative", account)
+	}
+	return nil
+}
+
+// meterAssetMismatch builds the error returned when amount is not in the account's asset.
+func meterAssetMismatch(account MeterAccount, amount money.Money) error {
+	return fmt.Errorf("meter account %s: balance is in %s, amount in %s", account.Account, account.Balance.Asset.Code, amount.Asset.Code)
+}
+
+// insufficientMeterBalance builds the error returned when a charge would overdraw account.
+func insufficientMeterBalance(account MeterAccount, amount money.Money) error {
+	return fmt.Errorf("%w: %s has %s %s, charging %s", ErrMeterBalance, account.Account, account.Balance.ToMajor(), account.Balance.Asset.Code, amount.ToMajor())
+}
+
+// creditMeterInMap implements CreditMeterAccount for the map-backed stores. Callers hold the
+// write lock.
+func creditMeterInMap(accounts map[string]MeterAccount, name string, amount money.Money, now time.Time) (MeterAccount, error) {
+	account, ok := accounts[name]
+	if !ok {
+		account = MeterAccount{Account: name, Balance: money.Zero(amount.Asset), Unsettled: money.Zero(amount.Asset)}
+	}
+	if account.Balance.Asset.Code != amount.Asset.Code {
+		return MeterAccount{}, meterAssetMismatch(account, amount)
+	}
+	account.Balance.Atomic += amount.Atomic
+	account.UpdatedAt = now
+	accounts[name] = account
+	return account, nil
+}
+
+// chargeMeterInMap implements ChargeMeterAccount for the map-backed stores. Callers hold the
+// write lock.
+func chargeMeterInMap(accounts map[string]MeterAccount, name string, amount money.Money, now time.Time) (MeterAccount, error) {
+	account, ok := accounts[name]
+	if !ok {
+		return MeterAccount{}, insufficientMeterBalance(MeterAccount{Account: name, Balance: money.Zero(amount.Asset)}, amount)
+	}
+	if account.Balance.Asset.Code != amount.Asset.Code {
+		return MeterAccount{}, meterAssetMismatch(account, amount)
+	}
+	if account.Balance.Atomic < amount.Atomic {
+		return account, insufficientMeterBalance(account, amount)
+	}
+	account.Balance.Atomic -= amount.Atomic
+	account.Unsettled.Atomic += amount.Atomic
+	account.Calls++
+	account.UpdatedAt = now
+	accounts[name] = account
+	return account, nil
+}
+
+// listUnsettledMetersInMap implements ListUnsettledMeterAccounts for the map-backed stores.
+// Callers hold the read lock.
+func listUnsettledMetersInMap(accounts map[string]MeterAccount, limit int) []MeterAccount {
+	var unsettled []MeterAccount
+	for _, account := range accounts {
+		if account.Calls > 0 {
+			unsettled = append(unsettled, account)
+		}
+	}
+	slices.SortFunc(unsettled, func(a, b MeterAccount) int { return cmp.Compare(a.Account, b.Account) })
+	if limit > 0 && len(unsettled) > limit {
+		unsettled = unsettled[:limit]
+	}
+	return unsettled
+}
+
+// settleMeterInMap implements SettleMeterAccount for the map-backed stores. Callers hold the
+// write lock.
+func settleMeterInMap(accounts map[string]MeterAccount, name string, amount money.Money, calls int64, now time.Time) error {
+	account, ok := accounts[name]
+	if !ok {
+		return ErrNotFound
+	}
+	if account.Unsettled.Asset.Code != amount.Asset.Code {
+		return meterAssetMismatch(account, amount)
+	}
+	account.Unsettled.Atomic -= amount.Atomic
+	account.Calls -= calls
+	account.UpdatedAt = now
+	accounts[name] = account
+	return nil
+}
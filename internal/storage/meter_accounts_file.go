@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+// GetMeterAccount returns a meter account's balance and unsettled usage.
+func (s *FileStore) GetMeterAccount(_ context.Context, account string) (MeterAccount, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	meter, ok := s.meterAccounts[account]
+	if !ok {
+		return MeterAccount{}, ErrNotFound
+	}
+	return meter, nil
+}
+
+// CreditMeterAccount adds amount to a meter account's balance, creating the account.
+func (s *FileStore) CreditMeterAccount(_ context.Context, account string, amount money.Money) (MeterAccount, error) {
+	if err := validateMeterAmount(account, amount); err != nil {
+		return MeterAccount{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	meter, err := creditMeterInMap(s.meterAccounts, account, amount, time.Now())
+	if err == nil {
+		s.markDirty()
+	}
+	return meter, err
+}
+
+// ChargeMeterAccount moves amount from a meter account's balance to its unsettled usage.
+func (s *FileStore) ChargeMeterAccount(_ context.Context, account string, amount money.Money) (MeterAccount, error) {
+	if err := validateMeterAmount(account, amount); err != nil {
+		return MeterAccount{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	meter, err := chargeMeterInMap(s.meterAccounts, account, amount, time.Now())
+	if err == nil {
+		s.markDirty()
+	}
+	return meter, err
+}
+
+// ListUnsettledMeterAccounts returns meter accounts with charges not yet settled.
+func (s *FileStore) ListUnsettledMeterAccounts(_ context.Context, limit int) ([]MeterAccount, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return listUnsettledMetersInMap(s.meterAccounts, limit), nil
+}
+
+// SettleMeterAccount subtracts a settlement's amount and calls from a meter account's unsettled usage.
+func (s *FileStore) SettleMeterAccount(_ context.Context, account string, amount money.Money, calls int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := settleMeterInMap(s.meterAccounts, account, amount, calls, time.Now())
+	if err == nil {
+		s.markDirty()
+	}
+	return err
+}
@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+// GetMeterAccount returns a meter account's balance and unsettled usage.
+func (m *MemoryStore) GetMeterAccount(_ context.Context, account string) (MeterAccount, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	meter, ok := m.meterAccounts[account]
+	if !ok {
+		return MeterAccount{}, ErrNotFound
+	}
+	return meter, nil
+}
+
+// CreditMeterAccount adds amount to a meter account's balance, creating the account.
+func (m *MemoryStore) CreditMeterAccount(_ context.Context, account string, amount money.Money) (MeterAccount, error) {
+	if err := validateMeterAmount(account, amount); err != nil {
+		return MeterAccount{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	meter, err := creditMeterInMap(m.meterAccounts, account, amount, time.Now())
+	return meter, err
+}
+
+// ChargeMeterAccount moves amount from a meter account's balance to its unsettled usage.
+func (m *MemoryStore) ChargeMeterAccount(_ context.Context, account string, amount money.Money) (MeterAccount, error) {
+	if err := validateMeterAmount(account, amount); err != nil {
+		return MeterAccount{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	meter, err := chargeMeterInMap(m.meterAccounts, account, amount, time.Now())
+	return meter, err
+}
+
+// ListUnsettledMeterAccounts returns meter accounts with charges not yet settled.
+func (m *MemoryStore) ListUnsettledMeterAccounts(_ context.Context, limit int) ([]MeterAccount, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return listUnsettledMetersInMap(m.meterAccounts, limit), nil
+}
+
+// SettleMeterAccount subtracts a settlement's amount and calls from a meter account's unsettled usage.
+func (m *MemoryStore) SettleMeterAccount(_ context.Context, account string, amount money.Money, calls int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	err := settleMeterInMap(m.meterAccounts, account, amount, calls, time.Now())
+	return err
+}
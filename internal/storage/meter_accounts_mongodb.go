@@ -0,0 +1,172 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+const meterAccountsCollection = "meter_accounts"
+
+// meterAccountDocument is a meter account's balance and unsettled usage keyed by account.
+type meterAccountDocument struct {
+	Account   string    `bson:"_id"`
+	Balance   int64     `bson:"balance"`
+	Unsettled int64     `bson:"unsettled"`
+	Calls     int64     `bson:"calls"`
+	Asset     string    `bson:"asset"`
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+// GetMeterAccount returns a meter account's balance and unsettled usage.
+func (s *MongoDBStore) GetMeterAccount(ctx context.Context, account string) (MeterAccount, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	var doc meterAccountDocument
+	err := s.db.Collection(meterAccountsCollection).FindOne(ctx, bson.M{"_id": account}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return MeterAccount{}, ErrNotFound
+	}
+	if err != nil {
+		return MeterAccount{}, fmt.Errorf("get meter account: %w", err)
+	}
+	return doc.meterAccount()
+}
+
+// CreditMeterAccount adds amount to a meter account's balance, creating the account.
+func (s *MongoDBStore) CreditMeterAccount(ctx context.Context, account string, amount money.Money) (MeterAccount, error) {
+	if err := validateMeterAmount(account, amount); err != nil {
+		return MeterAccount{}, err
+	}
+
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	coll := s.db.Collection(meterAccountsCollection)
+	create := bson.M{"$setOnInsert": bson.M{"balance": int64(0), "unsettled": int64(0), "calls": int64(0), "asset": amount.Asset.Code, "updated_at": time.Now()}}
+	if _, err := coll.UpdateOne(ctx, bson.M{"_id": account}, create, options.Update().SetUpsert(true)); err != nil {
+		return MeterAccount{}, fmt.Errorf("create meter account: %w", err)
+	}
+
+	filter := bson.M{"_id": account, "asset": amount.Asset.Code}
+	update := bson.M{"$inc": bson.M{"balance": amount.Atomic}, "$set": bson.M{"updated_at": time.Now()}}
+	var doc meterAccountDocument
+	err := coll.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		current, getErr := s.GetMeterAccount(ctx, account)
+		if getErr != nil {
+			return MeterAccount{}, getErr
+		}
+		return MeterAccount{}, meterAssetMismatch(current, amount)
+	}
+	if err != nil {
+		return MeterAccount{}, fmt.Errorf("credit meter account: %w", err)
+	}
+	return doc.meterAccount()
+}
+
+// ChargeMeterAccount moves amount from a meter account's balance to its unsettled usage. The
+// balance check and update are a single conditional update, so concurrent calls cannot
+// overdraw the account.
+func (s *MongoDBStore) ChargeMeterAccount(ctx context.Context, account string, amount money.Money) (MeterAccount, error) {
+	if err := validateMeterAmount(account, amount); err != nil {
+		return MeterAccount{}, err
+	}
+
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	filter := bson.M{"_id": account, "asset": amount.Asset.Code, "balance": bson.M{"$gte": amount.Atomic}}
+	update := bson.M{
+		"$inc": bson.M{"balance": -amount.Atomic, "unsettled": amount.Atomic, "calls": int64(1)},
+		"$set": bson.M{"updated_at": time.Now()},
+	}
+	var doc meterAccountDocument
+	err := s.db.Collection(meterAccountsCollection).FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		current, getErr := s.GetMeterAccount(ctx, account)
+		if errors.Is(getErr, ErrNotFound) {
+			return MeterAccount{}, insufficientMeterBalance(MeterAccount{Account: account, Balance: money.Zero(amount.Asset)}, amount)
+		}
+		if getErr != nil {
+			return MeterAccount{}, getErr
+		}
+		if current.Balance.Asset.Code != amount.Asset.Code {
+			return MeterAccount{}, meterAssetMismatch(current, amount)
+		}
+		return current, insufficientMeterBalance(current, amount)
+	}
+	if err != nil {
+		return MeterAccount{}, fmt.Errorf("charge meter account: %w", err)
+	}
+	return doc.meterAccount()
+}
+
+// ListUnsettledMeterAccounts returns meter accounts with charges not yet settled.
+func (s *MongoDBStore) ListUnsettledMeterAccounts(ctx context.Context, limit int) ([]MeterAccount, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+	cursor, err := s.db.Collection(meterAccountsCollection).Find(ctx, bson.M{"calls": bson.M{"$gt": 0}}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("list unsettled meter accounts: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var docs []meterAccountDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("decode meter accounts: %w", err)
+	}
+	meters := make([]MeterAccount, 0, len(docs))
+	for _, doc := range docs {
+		meter, err := doc.meterAccount()
+		if err != nil {
+			return nil, err
+		}
+		meters = append(meters, meter)
+	}
+	return meters, nil
+}
+
+// SettleMeterAccount subtracts a settlement's amount and calls from a meter account's unsettled usage.
+func (s *MongoDBStore) SettleMeterAccount(ctx context.Context, account string, amount money.Money, calls int64) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	filter := bson.M{"_id": account, "asset": amount.Asset.Code}
+	update := bson.M{"$inc": bson.M{"unsettled": -amount.Atomic, "calls": -calls}, "$set": bson.M{"updated_at": time.Now()}}
+	result, err := s.db.Collection(meterAccountsCollection).UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("settle meter account: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (d meterAccountDocument) meterAccount() (MeterAccount, error) {
+	asset, err := money.GetAsset(d.Asset)
+	if err != nil {
+		return MeterAccount{}, fmt.Errorf("meter account %s: %w", d.Account, err)
+	}
+	return MeterAccount{
+		Account:   d.Account,
+		Balance:   money.New(asset, d.Balance),
+		Unsettled: money.New(asset, d.Unsettled),
+		Calls:     d.Calls,
+		UpdatedAt: d.UpdatedAt,
+	}, nil
+}
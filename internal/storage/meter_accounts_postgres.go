@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+// GetMeterAccount returns a meter account's balance and unsettled usage.
+func (s *PostgresStore) GetMeterAccount(ctx context.Context, account string) (MeterAccount, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`SELECT account, balance, unsettled, calls, asset, updated_at FROM %s WHERE account = $1`, s.meterAccountsTableName)
+	meter, err := scanMeterAccount(s.db.QueryRowContext(ctx, query, account))
+	if errors.Is(err, sql.ErrNoRows) {
+		return MeterAccount{}, ErrNotFound
+	}
+	return meter, err
+}
+
+// CreditMeterAccount adds amount to a meter account's balance, creating the account.
+func (s *PostgresStore) CreditMeterAccount(ctx context.Context, account string, amount money.Money) (MeterAccount, error) {
+	if err := validateMeterAmount(account, amount); err != nil {
+		return MeterAccount{}, err
+	}
+
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`
+		INSERT INTO %s (account, balance, unsettled, calls, asset, updated_at)
+		VALUES ($1, $2, 0, 0, $3, $4)
+		ON CONFLICT (account) DO UPDATE SET balance = %s.balance + EXCLUDED.balance, updated_at = EXCLUDED.updated_at
+		WHERE %s.asset = EXCLUDED.asset
+		RETURNING account, balance, unsettled, calls, asset, updated_at
+	`, s.meterAccountsTableName, s.meterAccountsTableName, s.meterAccountsTableName)
+	meter, err := scanMeterAccount(s.db.QueryRowContext(ctx, query, account, amount.Atomic, amount.Asset.Code, time.Now().UTC()))
+	if errors.Is(err, sql.ErrNoRows) {
+		current, getErr := s.GetMeterAccount(ctx, account)
+		if getErr != nil {
+			return MeterAccount{}, getErr
+		}
+		return MeterAccount{}, meterAssetMismatch(current, amount)
+	}
+	return meter, err
+}
+
+// ChargeMeterAccount moves amount from a meter account's balance to its unsettled usage. The
+// balance check and update are a single conditional UPDATE, so concurrent calls cannot
+// overdraw the account.
+func (s *PostgresStore) ChargeMeterAccount(ctx context.Context, account string, amount money.Money) (MeterAccount, error) {
+	if err := validateMeterAmount(account, amount); err != nil {
+		return MeterAccount{}, err
+	}
+
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`
+		UPDATE %s SET balance = balance - $2, unsettled = unsettled + $2, calls = calls + 1, updated_at = $4
+		WHERE account = $1 AND asset = $3 AND balance >= $2
+		RETURNING account, balance, unsettled, calls, asset, updated_at
+	`, s.meterAccountsTableName)
+	meter, err := scanMeterAccount(s.db.QueryRowContext(ctx, query, account, amount.Atomic, amount.Asset.Code, time.Now().UTC()))
+	if errors.Is(err, sql.ErrNoRows) {
+		current, getErr := s.GetMeterAccount(ctx, account)
+		if errors.Is(getErr, ErrNotFound) {
+			return MeterAccount{}, insufficientMeterBalance(MeterAccount{Account: account, Balance: money.Zero(amount.Asset)}, amount)
+		}
+		if getErr != nil {
+			return MeterAccount{}, getErr
+		}
+		if current.Balance.Asset.Code != amount.Asset.Code {
+			return MeterAccount{}, meterAssetMismatch(current, amount)
+		}
+		return current, insufficientMeterBalance(current, amount)
+	}
+	return meter, err
+}
+
+// ListUnsettledMeterAccounts returns meter accounts with charges not yet settled.
+func (s *PostgresStore) ListUnsettledMeterAccounts(ctx context.Context, limit int) ([]MeterAccount, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`SELECT account, balance, unsettled, calls, asset, updated_at FROM %s WHERE calls > 0 ORDER BY account`, s.meterAccountsTableName)
+	args := []any{}
+	if limit > 0 {
+		query += ` LIMIT $1`
+		args = append(args, limit)
+	}
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list unsettled meter accounts: %w", err)
+	}
+	defer rows.Close()
+
+	var meters []MeterAccount
+	for rows.Next() {
+		meter, err := scanMeterAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+		meters = append(meters, meter)
+	}
+	return meters, rows.Err()
+}
+
+// SettleMeterAccount subtracts a settlement's amount and calls from a meter account's unsettled usage.
+func (s *PostgresStore) SettleMeterAccount(ctx context.Context, account string, amount money.Money, calls int64) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`
+		UPDATE %s SET unsettled = unsettled - $2, calls = calls - $3, updated_at = $5
+		WHERE account = $1 AND asset = $4
+	`, s.meterAccountsTableName)
+	result, err := s.db.ExecContext(ctx, query, account, amount.Atomic, calls, amount.Asset.Code, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("settle meter account: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// scanMeterAccount reads a meter account row selected as account, balance, unsettled, calls,
+// asset, updated_at.
+func scanMeterAccount(row interface{ Scan(...any) error }) (MeterAccount, error) {
+	var balance, unsettled int64
+	var assetCode string
+	var meter MeterAccount
+	if err := row.Scan(&meter.Account, &balance, &unsettled, &meter.Calls, &assetCode, &meter.UpdatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return MeterAccount{}, err
+		}
+		return MeterAccount{}, fmt.Errorf("scan meter account: %w", err)
+	}
+	asset, err := money.GetAsset(assetCode)
+	if err != nil {
+		return MeterAccount{}, fmt.Errorf("meter account %s: %w", meter.Account, err)
+	}
+	meter.Balance = money.New(asset, balance)
+	meter.Unsettled = money.New(asset, unsettled)
+	return meter, nil
+}
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+func TestMeterAccounts(t *testing.T) {
+	backends := []struct {
+		name string
+		open func(t *testing.T, path string) Store
+	}{
+		{name: "memory", open: func(t *testing.T, _ string) Store { return NewMemoryStore() }},
+		{
+			name: "file",
+			open: func(t *testing.T, path string) Store {
+				store, err := NewFileStore(path)
+				if err != nil {
+					t.Fatalf("NewFileStore: %v", err)
+				}
+				return store
+			},
+		},
+	}
+
+	usdc := money.MustGetAsset("USDC")
+
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "store.json")
+			store := backend.open(t, path)
+			ctx := context.Background()
+
+			if _, err := store.GetMeterAccount(ctx, "wallet:alice"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("GetMeterAccount before deposit err = %v, want ErrNotFound", err)
+			}
+			if _, err := store.ChargeMeterAccount(ctx, "wallet:alice", money.New(usdc, 1)); !errors.Is(err, ErrMeterBalance) {
+				t.Fatalf("ChargeMeterAccount before deposit err = %v, want ErrMeterBalance", err)
+			}
+			if _, err := store.CreditMeterAccount(ctx, "wallet:alice", money.New(usdc, 2500)); err != nil {
+				t.Fatalf("CreditMeterAccount: %v", err)
+			}
+
+			charges := []struct {
+				name          string
+				amount        money.Money
+				wantBalance   int64
+				wantUnsettled int64
+				wantErr       error
+			}{
+				{name: "first call", amount: money.New(usdc, 1000), wantBalance: 1500, wantUnsettled: 1000},
+				{name: "second call", amount: money.New(usdc, 1000), wantBalance: 500, wantUnsettled: 2000},
+				{name: "overdraw", amount: money.New(usdc, 1000), wantErr: ErrMeterBalance},
+				{name: "spend to zero", amount: money.New(usdc, 500), wantBalance: 0, wantUnsettled: 2500},
+			}
+			for _, charge := range charges {
+				account, err := store.ChargeMeterAccount(ctx, "wallet:alice", charge.amount)
+				if !errors.Is(err, charge.wantErr) {
+					t.Fatalf("%s: ChargeMeterAccount err = %v, want %v", charge.name, err, charge.wantErr)
+				}
+				if err == nil && (account.Balance.Atomic != charge.wantBalance || account.Unsettled.Atomic != charge.wantUnsettled) {
+					t.Fatalf("%s: balance %d unsettled %d, want %d and %d", charge.name, account.Balance.Atomic, account.Unsettled.Atomic, charge.wantBalance, charge.wantUnsettled)
+				}
+			}
+
+			if _, err := store.ChargeMeterAccount(ctx, "wallet:alice", money.New(money.MustGetAsset("USD"), 0)); err == nil {
+				t.Fatal("ChargeMeterAccount in another asset succeeded, want error")
+			}
+			if _, err := store.CreditMeterAccount(ctx, "wallet:alice", money.New(usdc, -1)); err == nil {
+				t.Fatal("CreditMeterAccount with a negative amount succeeded, want error")
+			}
+			if _, err := store.CreditMeterAccount(ctx, "key:idle", money.New(usdc, 100)); err != nil {
+				t.Fatalf("CreditMeterAccount: %v", err)
+			}
+
+			if backend.name == "file" {
+				_ = store.Close()
+				store = backend.open(t, path)
+			}
+			defer store.Close()
+
+			unsettled, err := store.ListUnsettledMeterAccounts(ctx, 0)
+			if err != nil {
+				t.Fatalf("ListUnsettledMeterAccounts: %v", err)
+			}
+			if len(unsettled) != 1 || unsettled[0].Account != "wallet:alice" || unsettled[0].Calls != 3 || unsettled[0].Unsettled.Atomic != 2500 {
+				t.Fatalf("unsettled accounts = %+v, want wallet:alice with 3 calls and 2500", unsettled)
+			}
+
+			if err := store.SettleMeterAccount(ctx, "wallet:alice", money.New(usdc, 2000), 2); err != nil {
+				t.Fatalf("SettleMeterAccount: %v", err)
+			}
+			account, err := store.GetMeterAccount(ctx, "wallet:alice")
+			if err != nil {
+				t.Fatalf("GetMeterAccount: %v", err)
+			}
+			if account.Unsettled.Atomic != 500 || account.Calls != 1 || account.Balance.Atomic != 0 {
+				t.Fatalf("after partial settlement = %+v, want 500 unsettled over 1 call", account)
+			}
+			if err := store.SettleMeterAccount(ctx, "wallet:alice", money.New(usdc, 500), 1); err != nil {
+				t.Fatalf("SettleMeterAccount: %v", err)
+			}
+			if unsettled, _ := store.ListUnsettledMeterAccounts(ctx, 0); len(unsettled) != 0 {
+				t.Fatalf("unsettled accounts after settlement = %+v, want none", unsettled)
+			}
+			if err := store.SettleMeterAccount(ctx, "wallet:nobody", money.New(usdc, 1), 1); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("SettleMeterAccount unknown account err = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
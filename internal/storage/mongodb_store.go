@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -96,6 +97,53 @@ func (s *MongoDBStore) createIndexes(ctx context.Context) error {
 		return fmt.Errorf("create payment transactions indexes: %w", err)
 	}
 
+	// Idempotency keys are removed by MongoDB once expires_at passes (TTL index)
+	_, err = s.db.Collection(idempotencyKeysCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return fmt.Errorf("create idempotency keys indexes: %w", err)
+	}
+
+	_, err = s.db.Collection(referralConversionsCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "referrer_wallet", Value: 1}, {Key: "converted_at", Value: -1}},
+	})
+	if err != nil {
+		return fmt.Errorf("create referral conversions indexes: %w", err)
+	}
+
+	_, err = s.db.Collection(customerIdentitiesCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "customer_id", Value: 1}},
+	})
+	if err != nil {
+		return fmt.Errorf("create customer identities indexes: %w", err)
+	}
+
+	_, err = s.db.Collection(refundAuditCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "refund_id", Value: 1}, {Key: "recorded_at", Value: 1}},
+	})
+	if err != nil {
+		return fmt.Errorf("create refund audit indexes: %w", err)
+	}
+
+	_, err = s.db.Collection(adminAuditCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "recorded_at", Value: -1}}},
+		{Keys: bson.D{{Key: "action", Value: 1}, {Key: "recorded_at", Value: -1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("create admin audit indexes: %w", err)
+	}
+
+	_, err = s.db.Collection(webhookDeliveriesCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "attempted_at", Value: -1}}},
+		{Keys: bson.D{{Key: "url", Value: 1}, {Key: "attempted_at", Value: -1}}},
+		{Keys: bson.D{{Key: "event_id", Value: 1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("create webhook delivery indexes: %w", err)
+	}
+
 	return nil
 }
 
@@ -429,16 +477,18 @@ type mongoPaymentTransaction struct {
 // mongoRefundQuote is an intermediate struct for MongoDB decoding.
 // RefundQuote stores money.Money as nested document: {asset: {code: "USDC"}, atomic: 123}
 type mongoRefundQuote struct {
-	ID                 string            `bson:"_id"`
-	OriginalPurchaseID string            `bson:"originalpurchaseid"`
-	RecipientWallet    string            `bson:"recipientwallet"`
-	Amount             bson.M            `bson:"amount"` // Nested: {asset: {code: "USDC", ...}, atomic: 640000}
-	Reason             string            `bson:"reason"`
-	Metadata           map[string]string `bson:"metadata"`
-	CreatedAt          time.Time         `bson:"createdat"`
-	ExpiresAt          time.Time         `bson:"expiresat"`
-	ProcessedBy        string            `bson:"processedby"`
-	ProcessedAt        *time.Time        `bson:"processedat"`
+	ID                 string               `bson:"_id"`
+	OriginalPurchaseID string               `bson:"originalpurchaseid"`
+	RecipientWallet    string               `bson:"recipientwallet"`
+	Amount             bson.M               `bson:"amount"`          // Nested: {asset: {code: "USDC", ...}, atomic: 640000}
+	RequestedAmount    bson.M               `bson:"requestedamount"` // Same shape as amount; null on older requests
+	Reason             string               `bson:"reason"`
+	Metadata           map[string]string    `bson:"metadata"`
+	CreatedAt          time.Time            `bson:"createdat"`
+	ExpiresAt          time.Time            `bson:"expiresat"`
+	ProcessedBy        string               `bson:"processedby"`
+	ProcessedAt        *time.Time           `bson:"processedat"`
+	History            []RefundHistoryEntry `bson:"history"`
 }
 
 // mongoCartItem is an intermediate struct for MongoDB decoding.
@@ -446,6 +496,7 @@ type mongoRefundQuote struct {
 // money.Money is stored as nested document: {asset: {...}, atomic: 123}
 type mongoCartItem struct {
 	ResourceID string            `bson:"resourceid"`
+	VariantID  string            `bson:"variantid"`
 	Quantity   int64             `bson:"quantity"`
 	Price      bson.M            `bson:"price"` // Nested document: {asset: {code: "USDC", ...}, atomic: 123}
 	Metadata   map[string]string `bson:"metadata"`
@@ -467,41 +518,60 @@ type mongoCartQuote struct {
 // convertMongoRefundQuote converts a MongoDB refund quote document to RefundQuote struct.
 // Extracts money.Money from nested BSON document: {asset: {code: "USDC", ...}, atomic: 123}
 func convertMongoRefundQuote(mongoQuote mongoRefundQuote, refundID string) (RefundQuote, error) {
-	// Extract amount from nested document (lowercase fields)
-	atomic, ok := mongoQuote.Amount["atomic"].(int64)
-	if !ok {
-		return RefundQuote{}, fmt.Errorf("refund %s: invalid amount.atomic type", refundID)
-	}
-
-	assetDoc, ok := mongoQuote.Amount["asset"].(bson.M)
-	if !ok {
-		return RefundQuote{}, fmt.Errorf("refund %s: invalid amount.asset type", refundID)
-	}
-
-	assetCode, ok := assetDoc["code"].(string)
-	if !ok {
-		return RefundQuote{}, fmt.Errorf("refund %s: invalid amount.asset.code type", refundID)
-	}
-
-	asset, err := money.GetAsset(assetCode)
+	amount, err := convertMongoRefundAmount(mongoQuote.Amount, refundID, "amount")
 	if err != nil {
-		return RefundQuote{}, fmt.Errorf("refund %s: invalid asset %q: %w", refundID, assetCode, err)
+		return RefundQuote{}, err
+	}
+	var requested *money.Money
+	if mongoQuote.RequestedAmount != nil { // Null on requests stored before it was recorded
+		value, err := convertMongoRefundAmount(mongoQuote.RequestedAmount, refundID, "requestedamount")
+		if err != nil {
+			return RefundQuote{}, err
+		}
+		requested = &value
 	}
 
 	return RefundQuote{
 		ID:                 mongoQuote.ID,
 		OriginalPurchaseID: mongoQuote.OriginalPurchaseID,
 		RecipientWallet:    mongoQuote.RecipientWallet,
-		Amount:             money.Money{Asset: asset, Atomic: atomic},
+		Amount:             amount,
+		RequestedAmount:    requested,
 		Reason:             mongoQuote.Reason,
 		Metadata:           mongoQuote.Metadata,
 		CreatedAt:          mongoQuote.CreatedAt,
 		ExpiresAt:          mongoQuote.ExpiresAt,
 		ProcessedBy:        mongoQuote.ProcessedBy,
 		ProcessedAt:        mongoQuote.ProcessedAt,
+		History:            mongoQuote.History,
 	}, nil
 }
 
+// convertMongoRefundAmount extracts money.Money from a nested refund amount document
+// (lowercase fields): {asset: {code: "USDC", ...}, atomic: 640000}.
+func convertMongoRefundAmount(doc bson.M, refundID, field string) (money.Money, error) {
+	atomic, ok := doc["atomic"].(int64)
+	if !ok {
+		return money.Money{}, fmt.Errorf("refund %s: invalid %s.atomic type", refundID, field)
+	}
+
+	assetDoc, ok := doc["asset"].(bson.M)
+	if !ok {
+		return money.Money{}, fmt.Errorf("refund %s: invalid %s.asset type", refundID, field)
+	}
+
+	assetCode, ok := assetDoc["code"].(string)
+	if !ok {
+		return money.Money{}, fmt.Errorf("refund %s: invalid %s.asset.code type", refundID, field)
+	}
+
+	asset, err := money.GetAsset(assetCode)
+	if err != nil {
+		return money.Money{}, fmt.Errorf("refund %s: invalid asset %q: %w", refundID, assetCode, err)
+	}
+	return money.Money{Asset: asset, Atomic: atomic}, nil
+}
+
 // convertMongoCartQuote converts a MongoDB cart quote document to CartQuote struct.
 // Extracts money.Money from nested BSON documents: {asset: {code: "USDC", ...}, atomic: 123}
 func convertMongoCartQuote(mongoQuote mongoCartQuote, cartID string) (CartQuote, error) {
@@ -552,6 +622,7 @@ func convertMongoCartQuote(mongoQuote mongoCartQuote, cartID string) (CartQuote,
 
 		items[i] = CartItem{
 			ResourceID: mongoItem.ResourceID,
+			VariantID:  mongoItem.VariantID,
 			Quantity:   mongoItem.Quantity,
 			Price:      money.Money{Asset: priceAsset, Atomic: priceAtomic},
 			Metadata:   mongoItem.Metadata,
@@ -569,6 +640,27 @@ func convertMongoCartQuote(mongoQuote mongoCartQuote, cartID string) (CartQuote,
 	}, nil
 }
 
+// PaymentSignatures calls fn with every recorded payment signature. It streams the whole
+// collection, so it has no query timeout.
+func (s *MongoDBStore) PaymentSignatures(ctx context.Context, fn func(signature string)) error {
+	cursor, err := s.paymentTransactions.Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"signature": 1, "_id": 0}))
+	if err != nil {
+		return fmt.Errorf("list payment signatures: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var doc struct {
+			Signature string `bson:"signature"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return fmt.Errorf("decode payment signature: %w", err)
+		}
+		fn(doc.Signature)
+	}
+	return cursor.Err()
+}
+
 // GetPayment retrieves a payment transaction by signature.
 // Returns the original payment record showing which resource it was used for.
 func (s *MongoDBStore) GetPayment(ctx context.Context, signature string) (PaymentTransaction, error) {
@@ -811,7 +903,8 @@ func (s *MongoDBStore) SaveRefundQuotes(ctx context.Context, quotes []RefundQuot
 }
 
 // RecordPayments saves multiple payment transactions using MongoDB bulk operations.
-// Note: Uses ordered=true to maintain atomic failure on duplicate signatures.
+// Note: Uses ordered=true so a duplicate signature stops the batch; the payments inserted
+// before it are then deleted again, so the batch is stored in full or not at all.
 func (s *MongoDBStore) RecordPayments(ctx context.Context, txs []PaymentTransaction) error {
 	if len(txs) == 0 {
 		return nil
@@ -826,6 +919,7 @@ func (s *MongoDBStore) RecordPayments(ctx context.Context, txs []PaymentTransact
 
 	_, err := s.paymentTransactions.BulkWrite(ctx, operations, options.BulkWrite().SetOrdered(true))
 	if err != nil {
+		s.undoBulkInsert(ctx, txs, err)
 		// Check for duplicate key error (signature already used)
 		if mongo.IsDuplicateKeyError(err) {
 			return fmt.Errorf("storage: signature already used")
@@ -835,3 +929,20 @@ func (s *MongoDBStore) RecordPayments(ctx context.Context, txs []PaymentTransact
 
 	return nil
 }
+
+// undoBulkInsert deletes the payments an ordered bulk insert stored before it failed.
+func (s *MongoDBStore) undoBulkInsert(ctx context.Context, txs []PaymentTransaction, err error) {
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) || len(bulkErr.WriteErrors) == 0 {
+		return
+	}
+	failed := bulkErr.WriteErrors[0].Index
+	if failed <= 0 || failed > len(txs) {
+		return
+	}
+	signatures := make([]string, 0, failed)
+	for _, tx := range txs[:failed] {
+		signatures = append(signatures, tx.Signature)
+	}
+	_, _ = s.paymentTransactions.DeleteMany(ctx, bson.M{"signature": bson.M{"$in": signatures}})
+}
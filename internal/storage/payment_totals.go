@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+// PaymentTotal sums the payments made in one asset over a window.
+type PaymentTotal struct {
+	Count  int64       `json:"count"`
+	Amount money.Money `json:"amount"`
+}
+
+// sumPayments totals the payments created in [since, until) per asset, ordered by asset code.
+// A total beyond the asset's range fails with money.ErrOverflow.
+func sumPayments(payments map[string]PaymentTransaction, since, until time.Time) ([]PaymentTotal, error) {
+	byAsset := make(map[string]*PaymentTotal)
+	for _, tx := range payments {
+		if tx.CreatedAt.Before(since) || !tx.CreatedAt.Before(until) {
+			continue
+		}
+		total, ok := byAsset[tx.Amount.Asset.Code]
+		if !ok {
+			total = &PaymentTotal{Amount: money.Zero(tx.Amount.Asset)}
+			byAsset[tx.Amount.Asset.Code] = total
+		}
+		sum, err := total.Amount.Add(tx.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("sum %s payments: %w", tx.Amount.Asset.Code, err)
+		}
+		total.Count++
+		total.Amount = sum
+	}
+
+	totals := make([]PaymentTotal, 0, len(byAsset))
+	for _, total := range byAsset {
+		totals = append(totals, *total)
+	}
+	sortPaymentTotals(totals)
+	return totals, nil
+}
+
+// sortPaymentTotals orders totals by asset code.
+func sortPaymentTotals(totals []PaymentTotal) {
+	sort.Slice(totals, func(i, j int) bool {
+		return totals[i].Amount.Asset.Code < totals[j].Amount.Asset.Code
+	})
+}
@@ -0,0 +1,14 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// SumPayments totals the payments recorded in [since, until) per asset.
+func (s *FileStore) SumPayments(_ context.Context, since, until time.Time) ([]PaymentTotal, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return sumPayments(s.paymentTransactions, since, until)
+}
@@ -0,0 +1,14 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// SumPayments totals the payments recorded in [since, until) per asset.
+func (m *MemoryStore) SumPayments(_ context.Context, since, until time.Time) ([]PaymentTotal, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return sumPayments(m.paymentTransactions, since, until)
+}
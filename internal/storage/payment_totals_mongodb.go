@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+// SumPayments totals the payments recorded in [since, until) per asset.
+func (s *MongoDBStore) SumPayments(ctx context.Context, since, until time.Time) ([]PaymentTotal, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"created_at": bson.M{"$gte": since, "$lt": until}}},
+		bson.M{"$group": bson.M{
+			"_id":    "$asset",
+			"count":  bson.M{"$sum": 1},
+			"atomic": bson.M{"$sum": "$amount"},
+		}},
+		bson.M{"$sort": bson.M{"_id": 1}},
+	}
+	cursor, err := s.paymentTransactions.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("mongodb: sum payments: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		Asset  string `bson:"_id"`
+		Count  int64  `bson:"count"`
+		Atomic int64  `bson:"atomic"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("mongodb: decode payment totals: %w", err)
+	}
+	totals := make([]PaymentTotal, 0, len(rows))
+	for _, row := range rows {
+		asset, err := money.GetAsset(row.Asset)
+		if err != nil {
+			return nil, fmt.Errorf("invalid asset %q: %w", row.Asset, err)
+		}
+		totals = append(totals, PaymentTotal{Count: row.Count, Amount: money.New(asset, row.Atomic)})
+	}
+	return totals, nil
+}
@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+// SumPayments totals the payments recorded in [since, until) per asset.
+func (s *PostgresStore) SumPayments(ctx context.Context, since, until time.Time) ([]PaymentTotal, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`
+		SELECT asset, COUNT(*), COALESCE(SUM(amount), 0)
+		FROM %s
+		WHERE created_at >= $1 AND created_at < $2
+		GROUP BY asset
+		ORDER BY asset
+	`, s.paymentTransactionsTableName)
+
+	rows, err := s.db.QueryContext(ctx, query, since.UTC(), until.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("sum payments: %w", err)
+	}
+	defer rows.Close()
+
+	var totals []PaymentTotal
+	for rows.Next() {
+		var assetCode string
+		var count, atomic int64
+		if err := rows.Scan(&assetCode, &count, &atomic); err != nil {
+			return nil, fmt.Errorf("scan payment total: %w", err)
+		}
+		asset, err := money.GetAsset(assetCode)
+		if err != nil {
+			return nil, fmt.Errorf("get asset %s: %w", assetCode, err)
+		}
+		totals = append(totals, PaymentTotal{Count: count, Amount: money.New(asset, atomic)})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate payment totals: %w", err)
+	}
+	return totals, nil
+}
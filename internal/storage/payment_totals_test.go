@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+func TestSumPayments(t *testing.T) {
+	backends := []struct {
+		name string
+		open func(t *testing.T) Store
+	}{
+		{name: "memory", open: func(t *testing.T) Store { return NewMemoryStore() }},
+		{
+			name: "file",
+			open: func(t *testing.T) Store {
+				store, err := NewFileStore(filepath.Join(t.TempDir(), "store.json"))
+				if err != nil {
+					t.Fatalf("NewFileStore: %v", err)
+				}
+				return store
+			},
+		},
+	}
+
+	usdc := money.MustGetAsset("USDC")
+	usd := money.MustGetAsset("USD")
+	now := time.Now().UTC().Truncate(time.Second)
+	payments := []PaymentTransaction{
+		{Signature: "sig-1", ResourceID: "ebook", Wallet: "wallet-1", Amount: money.New(usdc, 1_000000), CreatedAt: now.Add(-time.Hour)},
+		{Signature: "sig-2", ResourceID: "ebook", Wallet: "wallet-2", Amount: money.New(usdc, 2_500000), CreatedAt: now.Add(-2 * time.Hour)},
+		{Signature: "stripe:cs_1", ResourceID: "tee", Wallet: "alice@example.com", Amount: money.New(usd, 2000), CreatedAt: now.Add(-3 * time.Hour)},
+		{Signature: "sig-old", ResourceID: "ebook", Wallet: "wallet-1", Amount: money.New(usdc, 9_000000), CreatedAt: now.Add(-48 * time.Hour)},
+	}
+
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			store := backend.open(t)
+			defer store.Close()
+			ctx := context.Background()
+			if err := store.RecordPayments(ctx, payments); err != nil {
+				t.Fatalf("RecordPayments: %v", err)
+			}
+
+			tests := []struct {
+				name  string
+				since time.Time
+				want  []PaymentTotal
+			}{
+				{name: "last day", since: now.Add(-24 * time.Hour), want: []PaymentTotal{
+					{Count: 1, Amount: money.New(usd, 2000)},
+					{Count: 2, Amount: money.New(usdc, 3_500000)},
+				}},
+				{name: "last 90 minutes", since: now.Add(-90 * time.Minute), want: []PaymentTotal{
+					{Count: 1, Amount: money.New(usdc, 1_000000)},
+				}},
+				{name: "nothing", since: now, want: []PaymentTotal{}},
+			}
+			for _, tt := range tests {
+				totals, err := store.SumPayments(ctx, tt.since, now)
+				if err != nil {
+					t.Fatalf("%s: SumPayments: %v", tt.name, err)
+				}
+				if len(totals) != len(tt.want) {
+					t.Fatalf("%s: totals = %+v, want %+v", tt.name, totals, tt.want)
+				}
+				for i := range totals {
+					if totals[i].Count != tt.want[i].Count || !totals[i].Amount.Equal(tt.want[i].Amount) {
+						t.Errorf("%s: total %d = %+v, want %+v", tt.name, i, totals[i], tt.want[i])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSumPayments_Overflow(t *testing.T) {
+	weth := money.Asset{Code: "WETH", Decimals: 18, Type: money.AssetTypeSPL}
+	now := time.Now()
+	payments := map[string]PaymentTransaction{
+		"sig-1": {Signature: "sig-1", Amount: money.New(weth, 5_000_000_000_000_000_000), CreatedAt: now},
+		"sig-2": {Signature: "sig-2", Amount: money.New(weth, 5_000_000_000_000_000_000), CreatedAt: now},
+	}
+	if _, err := sumPayments(payments, now.Add(-time.Hour), now.Add(time.Hour)); !errors.Is(err, money.ErrOverflow) {
+		t.Fatalf("sumPayments error = %v, want money.ErrOverflow", err)
+	}
+}
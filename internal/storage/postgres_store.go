@@ -23,6 +23,30 @@ type PostgresStore struct {
 	cartQuotesTableName          string // Configurable table name (default: "cart_quotes")
 	refundQuotesTableName        string // Configurable table name (default: "refund_quotes")
 	webhookQueueTableName        string // Configurable table name (default: "webhook_queue")
+	idempotencyKeysTableName     string // Configurable table name (default: "idempotency_keys")
+	stockTableName               string // Table name (default: "inventory_stock")
+	stockReservationsTableName   string // Table name (default: "stock_reservations")
+	cartContributionsTableName   string // Table name (default: "cart_contributions")
+	savedCartsTableName          string // Table name (default: "saved_carts")
+	giftCardsTableName           string // Table name (default: "gift_cards")
+	meterAccountsTableName       string // Table name (default: "meter_accounts")
+	referralConversionsTableName string // Table name (default: "referral_conversions")
+	couponRedemptionsTableName   string // Table name (default: "coupon_redemptions")
+	customerIdentitiesTableName  string // Table name (default: "customer_identities")
+	refundAuditTableName         string // Table name (default: "refund_audit")
+	adminAuditTableName          string // Table name (default: "admin_audit")
+	accessRulesTableName         string // Table name (default: "access_rules")
+	webhookDeliveriesTableName   string // Table name (default: "webhook_deliveries")
+}
+
+// DBStats reports connection pool statistics for stores backed by database/sql.
+// Wrapped stores are unwrapped; other backends return false.
+func DBStats(store Store) (sql.DBStats, bool) {
+	store = unwrapStore(store)
+	if pg, ok := store.(*PostgresStore); ok && pg.db != nil {
+		return pg.db.Stats(), true
+	}
+	return sql.DBStats{}, false
 }
 
 // NewPostgresStore creates a new PostgreSQL-backed store.
@@ -51,6 +75,20 @@ func NewPostgresStore(connectionString string, poolConfig config.PostgresPoolCon
 		cartQuotesTableName:          "cart_quotes",
 		refundQuotesTableName:        "refund_quotes",
 		webhookQueueTableName:        "webhook_queue",
+		idempotencyKeysTableName:     "idempotency_keys",
+		stockTableName:               "inventory_stock",
+		stockReservationsTableName:   "stock_reservations",
+		cartContributionsTableName:   "cart_contributions",
+		savedCartsTableName:          "saved_carts",
+		giftCardsTableName:           "gift_cards",
+		meterAccountsTableName:       "meter_accounts",
+		referralConversionsTableName: "referral_conversions",
+		couponRedemptionsTableName:   "coupon_redemptions",
+		customerIdentitiesTableName:  "customer_identities",
+		refundAuditTableName:         "refund_audit",
+		adminAuditTableName:          "admin_audit",
+		accessRulesTableName:         "access_rules",
+		webhookDeliveriesTableName:   "webhook_deliveries",
 	}
 
 	// Create tables if they don't exist (using default table names)
@@ -74,6 +112,20 @@ func NewPostgresStoreWithDB(db *sql.DB) (*PostgresStore, error) {
 		cartQuotesTableName:          "cart_quotes",
 		refundQuotesTableName:        "refund_quotes",
 		webhookQueueTableName:        "webhook_queue",
+		idempotencyKeysTableName:     "idempotency_keys",
+		stockTableName:               "inventory_stock",
+		stockReservationsTableName:   "stock_reservations",
+		cartContributionsTableName:   "cart_contributions",
+		savedCartsTableName:          "saved_carts",
+		giftCardsTableName:           "gift_cards",
+		meterAccountsTableName:       "meter_accounts",
+		referralConversionsTableName: "referral_conversions",
+		couponRedemptionsTableName:   "coupon_redemptions",
+		customerIdentitiesTableName:  "customer_identities",
+		refundAuditTableName:         "refund_audit",
+		adminAuditTableName:          "admin_audit",
+		accessRulesTableName:         "access_rules",
+		webhookDeliveriesTableName:   "webhook_deliveries",
 	}
 
 	// Create tables if they don't exist (using default table names)
@@ -86,7 +138,7 @@ func NewPostgresStoreWithDB(db *sql.DB) (*PostgresStore, error) {
 
 // WithTableNames sets custom table names (for schema_mapping support).
 // After setting table names, it recreates tables with the new names.
-func (s *PostgresStore) WithTableNames(paymentTransactions, adminNonces, cartQuotes, refundQuotes, webhookQueue string) *PostgresStore {
+func (s *PostgresStore) WithTableNames(paymentTransactions, adminNonces, cartQuotes, refundQuotes, webhookQueue, idempotencyKeys string) *PostgresStore {
 	if paymentTransactions != "" {
 		s.paymentTransactionsTableName = paymentTransactions
 	}
@@ -102,6 +154,9 @@ func (s *PostgresStore) WithTableNames(paymentTransactions, adminNonces, cartQuo
 	if webhookQueue != "" {
 		s.webhookQueueTableName = webhookQueue
 	}
+	if idempotencyKeys != "" {
+		s.idempotencyKeysTableName = idempotencyKeys
+	}
 
 	// Recreate tables with new names (CREATE TABLE IF NOT EXISTS will only create missing tables)
 	_ = s.createPostgresTables()
@@ -133,14 +188,18 @@ func (s *PostgresStore) createPostgresTables() error {
 			recipient_wallet TEXT NOT NULL,
 			amount BIGINT NOT NULL,
 			amount_asset TEXT NOT NULL,
+			requested_amount BIGINT,
 			reason TEXT,
 			metadata JSONB,
 			created_at TIMESTAMP NOT NULL,
 			expires_at TIMESTAMP NOT NULL,
 			processed_by TEXT,
 			processed_at TIMESTAMP,
-			signature TEXT
+			signature TEXT,
+			history JSONB
 		);
+		ALTER TABLE %s ADD COLUMN IF NOT EXISTS requested_amount BIGINT;
+		ALTER TABLE %s ADD COLUMN IF NOT EXISTS history JSONB;
 
 		CREATE TABLE IF NOT EXISTS %s (
 			signature TEXT PRIMARY KEY,
@@ -168,15 +227,161 @@ func (s *PostgresStore) createPostgresTables() error {
 			payload JSONB NOT NULL,
 			headers JSONB,
 			event_type TEXT NOT NULL,
+			priority INTEGER NOT NULL DEFAULT 0,
 			status TEXT NOT NULL,
 			attempts INTEGER NOT NULL DEFAULT 0,
 			max_attempts INTEGER NOT NULL DEFAULT 5,
+			retry_schedule JSONB,
 			last_error TEXT,
 			last_attempt_at TIMESTAMP,
 			next_attempt_at TIMESTAMP NOT NULL,
 			created_at TIMESTAMP NOT NULL,
 			completed_at TIMESTAMP
 		);
+		ALTER TABLE %s ADD COLUMN IF NOT EXISTS priority INTEGER NOT NULL DEFAULT 0;
+		ALTER TABLE %s ADD COLUMN IF NOT EXISTS retry_schedule JSONB;
+
+		CREATE TABLE IF NOT EXISTS %s (
+			key TEXT PRIMARY KEY,
+			fingerprint TEXT NOT NULL,
+			status_code INTEGER NOT NULL,
+			headers JSONB,
+			body BYTEA,
+			created_at TIMESTAMP NOT NULL,
+			expires_at TIMESTAMP NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS %s (
+			resource_id TEXT PRIMARY KEY,
+			quantity BIGINT NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS %s (
+			id TEXT NOT NULL,
+			resource_id TEXT NOT NULL,
+			quantity BIGINT NOT NULL,
+			expires_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (id, resource_id)
+		);
+
+		CREATE TABLE IF NOT EXISTS %s (
+			cart_id TEXT NOT NULL,
+			signature TEXT NOT NULL,
+			wallet TEXT NOT NULL,
+			amount BIGINT NOT NULL,
+			asset TEXT NOT NULL,
+			paid_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (cart_id, signature)
+		);
+
+		CREATE TABLE IF NOT EXISTS %s (
+			wallet TEXT NOT NULL,
+			name TEXT NOT NULL,
+			items JSONB NOT NULL,
+			coupon_code TEXT NOT NULL DEFAULT '',
+			metadata JSONB,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (wallet, name)
+		);
+
+		CREATE TABLE IF NOT EXISTS %s (
+			code TEXT PRIMARY KEY,
+			balance BIGINT NOT NULL,
+			asset TEXT NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS %s (
+			account TEXT PRIMARY KEY,
+			balance BIGINT NOT NULL,
+			unsettled BIGINT NOT NULL DEFAULT 0,
+			calls BIGINT NOT NULL DEFAULT 0,
+			asset TEXT NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS %s (
+			signature TEXT NOT NULL,
+			referrer_wallet TEXT NOT NULL,
+			code TEXT NOT NULL,
+			resource_id TEXT NOT NULL,
+			amount BIGINT NOT NULL,
+			reward BIGINT NOT NULL,
+			asset TEXT NOT NULL,
+			converted_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (signature, referrer_wallet)
+		);
+
+		CREATE TABLE IF NOT EXISTS %s (
+			code TEXT NOT NULL,
+			redeemer TEXT NOT NULL,
+			signature TEXT NOT NULL,
+			redeemed_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (code, signature)
+		);
+
+		CREATE TABLE IF NOT EXISTS %s (
+			kind TEXT NOT NULL,
+			value TEXT NOT NULL,
+			customer_id TEXT NOT NULL,
+			linked_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (kind, value)
+		);
+
+		CREATE TABLE IF NOT EXISTS %s (
+			id BIGSERIAL PRIMARY KEY,
+			refund_id TEXT NOT NULL,
+			action TEXT NOT NULL,
+			actor TEXT NOT NULL,
+			original_purchase_id TEXT NOT NULL,
+			recipient_wallet TEXT NOT NULL,
+			amount BIGINT NOT NULL,
+			asset TEXT NOT NULL,
+			reason TEXT NOT NULL DEFAULT '',
+			metadata JSONB,
+			recorded_at TIMESTAMP NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS %s (
+			id BIGSERIAL PRIMARY KEY,
+			action TEXT NOT NULL,
+			signer TEXT NOT NULL,
+			method TEXT NOT NULL DEFAULT '',
+			path TEXT NOT NULL DEFAULT '',
+			request_hash TEXT NOT NULL DEFAULT '',
+			status INTEGER NOT NULL DEFAULT 0,
+			result TEXT NOT NULL,
+			recorded_at TIMESTAMP NOT NULL
+		);
+		-- The admin audit log is append-only
+		CREATE OR REPLACE RULE admin_audit_no_update AS ON UPDATE TO %s DO INSTEAD NOTHING;
+		CREATE OR REPLACE RULE admin_audit_no_delete AS ON DELETE TO %s DO INSTEAD NOTHING;
+
+		CREATE TABLE IF NOT EXISTS %s (
+			kind TEXT NOT NULL,
+			value TEXT NOT NULL,
+			list TEXT NOT NULL,
+			reason TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (kind, value)
+		);
+
+		CREATE TABLE IF NOT EXISTS %s (
+			id BIGSERIAL PRIMARY KEY,
+			event_id TEXT NOT NULL DEFAULT '',
+			webhook_id TEXT NOT NULL DEFAULT '',
+			event_type TEXT NOT NULL,
+			url TEXT NOT NULL,
+			attempt INTEGER NOT NULL DEFAULT 0,
+			status_code INTEGER NOT NULL DEFAULT 0,
+			latency_ms BIGINT NOT NULL DEFAULT 0,
+			response_body TEXT NOT NULL DEFAULT '',
+			error TEXT NOT NULL DEFAULT '',
+			success BOOLEAN NOT NULL,
+			attempted_at TIMESTAMP NOT NULL
+		);
 
 		CREATE INDEX IF NOT EXISTS idx_cart_quotes_tenant ON %s(tenant_id);
 		CREATE INDEX IF NOT EXISTS idx_cart_quotes_tenant_expires ON %s(tenant_id, expires_at);
@@ -191,16 +396,43 @@ func (s *PostgresStore) createPostgresTables() error {
 		CREATE INDEX IF NOT EXISTS idx_admin_nonces_tenant ON %s(tenant_id);
 		CREATE INDEX IF NOT EXISTS idx_admin_nonces_tenant_expires ON %s(tenant_id, expires_at);
 		CREATE INDEX IF NOT EXISTS idx_webhook_queue_pending ON %s(status, next_attempt_at) WHERE status = 'pending';
+		CREATE INDEX IF NOT EXISTS idx_webhook_queue_priority ON %s(priority DESC, next_attempt_at) WHERE status = 'pending';
 		CREATE INDEX IF NOT EXISTS idx_webhook_queue_status ON %s(status);
 		CREATE INDEX IF NOT EXISTS idx_webhook_queue_created ON %s(created_at DESC);
 		CREATE INDEX IF NOT EXISTS idx_webhook_queue_completed ON %s(completed_at) WHERE completed_at IS NOT NULL;
+		CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires ON %s(expires_at);
+		CREATE INDEX IF NOT EXISTS idx_stock_reservations_resource ON %s(resource_id, expires_at);
+		CREATE INDEX IF NOT EXISTS idx_referral_conversions_referrer ON %s(referrer_wallet, converted_at DESC);
+		CREATE INDEX IF NOT EXISTS idx_coupon_redemptions_redeemer ON %s(code, redeemer);
+		CREATE INDEX IF NOT EXISTS idx_customer_identities_customer ON %s(customer_id);
+		CREATE INDEX IF NOT EXISTS idx_refund_audit_refund ON %s(refund_id, recorded_at);
+		CREATE INDEX IF NOT EXISTS idx_admin_audit_recorded ON %s(recorded_at DESC);
+		CREATE INDEX IF NOT EXISTS idx_admin_audit_action ON %s(action, recorded_at DESC);
+		CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_attempted ON %s(attempted_at DESC);
+		CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_url ON %s(url, attempted_at DESC);
+		CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_event ON %s(event_id);
+		CREATE INDEX IF NOT EXISTS idx_meter_accounts_unsettled ON %s(account) WHERE calls > 0;
 	`,
 		// Table names
 		s.cartQuotesTableName,
-		s.refundQuotesTableName,
+		s.refundQuotesTableName, s.refundQuotesTableName, s.refundQuotesTableName,
 		s.paymentTransactionsTableName,
 		s.adminNoncesTableName,
-		s.webhookQueueTableName,
+		s.webhookQueueTableName, s.webhookQueueTableName, s.webhookQueueTableName,
+		s.idempotencyKeysTableName,
+		s.stockTableName,
+		s.stockReservationsTableName,
+		s.cartContributionsTableName,
+		s.savedCartsTableName,
+		s.giftCardsTableName,
+		s.meterAccountsTableName,
+		s.referralConversionsTableName,
+		s.couponRedemptionsTableName,
+		s.customerIdentitiesTableName,
+		s.refundAuditTableName,
+		s.adminAuditTableName, s.adminAuditTableName, s.adminAuditTableName,
+		s.accessRulesTableName,
+		s.webhookDeliveriesTableName,
 		// Index table references (cart_quotes)
 		s.cartQuotesTableName, s.cartQuotesTableName,
 		// Index table references (refund_quotes)
@@ -210,7 +442,25 @@ func (s *PostgresStore) createPostgresTables() error {
 		// Index table references (admin_nonces)
 		s.adminNoncesTableName, s.adminNoncesTableName,
 		// Index table references (webhook_queue)
-		s.webhookQueueTableName, s.webhookQueueTableName, s.webhookQueueTableName, s.webhookQueueTableName,
+		s.webhookQueueTableName, s.webhookQueueTableName, s.webhookQueueTableName, s.webhookQueueTableName, s.webhookQueueTableName,
+		// Index table references (idempotency_keys)
+		s.idempotencyKeysTableName,
+		// Index table references (stock_reservations)
+		s.stockReservationsTableName,
+		// Index table references (referral_conversions)
+		s.referralConversionsTableName,
+		// Index table references (coupon_redemptions)
+		s.couponRedemptionsTableName,
+		// Index table references (customer_identities)
+		s.customerIdentitiesTableName,
+		// Index table references (refund_audit)
+		s.refundAuditTableName,
+		// Index table references (admin_audit)
+		s.adminAuditTableName, s.adminAuditTableName,
+		// Index table references (webhook_deliveries)
+		s.webhookDeliveriesTableName, s.webhookDeliveriesTableName, s.webhookDeliveriesTableName,
+		// Index table references (meter_accounts)
+		s.meterAccountsTableName,
 	)
 
 	_, err := s.db.Exec(schema)
@@ -486,11 +736,19 @@ func (s *PostgresStore) SaveRefundQuote(ctx context.Context, quote RefundQuote)
 	if err != nil {
 		return fmt.Errorf("marshal metadata: %w", err)
 	}
+	historyJSON, err := json.Marshal(quote.History)
+	if err != nil {
+		return fmt.Errorf("marshal history: %w", err)
+	}
 
 	query := fmt.Sprintf(`
-		INSERT INTO %s (id, original_purchase_id, recipient_wallet, amount, amount_asset, reason, metadata, created_at, expires_at, processed_by, processed_at, signature)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		INSERT INTO %s (id, original_purchase_id, recipient_wallet, amount, amount_asset, requested_amount, reason, metadata, created_at, expires_at, processed_by, processed_at, signature, history)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 		ON CONFLICT (id) DO UPDATE SET
+			amount = EXCLUDED.amount,
+			requested_amount = EXCLUDED.requested_amount,
+			metadata = EXCLUDED.metadata,
+			history = EXCLUDED.history,
 			expires_at = EXCLUDED.expires_at,
 			processed_by = EXCLUDED.processed_by,
 			processed_at = EXCLUDED.processed_at,
@@ -505,20 +763,29 @@ func (s *PostgresStore) SaveRefundQuote(ctx context.Context, quote RefundQuote)
 	}
 
 	_, err = s.db.ExecContext(ctx, query,
-		quote.ID, quote.OriginalPurchaseID, quote.RecipientWallet, quote.Amount.Atomic, quote.Amount.Asset.Code,
+		quote.ID, quote.OriginalPurchaseID, quote.RecipientWallet, quote.Amount.Atomic, quote.Amount.Asset.Code, requestedRefundAtomic(quote),
 		quote.Reason, metadataJSON, quote.CreatedAt.UTC(),
-		quote.ExpiresAt.UTC(), quote.ProcessedBy, processedAt, quote.Signature)
+		quote.ExpiresAt.UTC(), quote.ProcessedBy, processedAt, quote.Signature, historyJSON)
 
 	return err
 }
 
+// requestedRefundAtomic returns the requested_amount column value, NULL when the requested
+// amount was not recorded.
+func requestedRefundAtomic(quote RefundQuote) sql.NullInt64 {
+	if quote.RequestedAmount == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: quote.RequestedAmount.Atomic, Valid: true}
+}
+
 // GetRefundQuote retrieves a refund quote by ID.
 func (s *PostgresStore) GetRefundQuote(ctx context.Context, refundID string) (RefundQuote, error) {
 	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := fmt.Sprintf(`
-		SELECT id, original_purchase_id, recipient_wallet, amount, amount_asset, reason, metadata, created_at, expires_at, processed_by, processed_at, signature
+		SELECT id, original_purchase_id, recipient_wallet, amount, amount_asset, requested_amount, reason, metadata, created_at, expires_at, processed_by, processed_at, signature, history
 		FROM %s
 		WHERE id = $1
 	`, s.refundQuotesTableName)
@@ -527,11 +794,13 @@ func (s *PostgresStore) GetRefundQuote(ctx context.Context, refundID string) (Re
 	var metadataJSON []byte
 	var amountAtomic int64
 	var amountAsset string
+	var requestedAtomic sql.NullInt64
+	var historyJSON []byte
 
 	err := s.db.QueryRowContext(ctx, query, refundID).Scan(
-		&quote.ID, &quote.OriginalPurchaseID, &quote.RecipientWallet, &amountAtomic, &amountAsset,
+		&quote.ID, &quote.OriginalPurchaseID, &quote.RecipientWallet, &amountAtomic, &amountAsset, &requestedAtomic,
 		&quote.Reason, &metadataJSON, &quote.CreatedAt,
-		&quote.ExpiresAt, &quote.ProcessedBy, &quote.ProcessedAt, &quote.Signature)
+		&quote.ExpiresAt, &quote.ProcessedBy, &quote.ProcessedAt, &quote.Signature, &historyJSON)
 
 	if err == sql.ErrNoRows {
 		return RefundQuote{}, ErrNotFound
@@ -546,12 +815,21 @@ func (s *PostgresStore) GetRefundQuote(ctx context.Context, refundID string) (Re
 		return RefundQuote{}, fmt.Errorf("get asset %s: %w", amountAsset, err)
 	}
 	quote.Amount = money.New(asset, amountAtomic)
+	if requestedAtomic.Valid {
+		requested := money.New(asset, requestedAtomic.Int64)
+		quote.RequestedAmount = &requested
+	}
 
 	if len(metadataJSON) > 0 {
 		if err := json.Unmarshal(metadataJSON, &quote.Metadata); err != nil {
 			return RefundQuote{}, fmt.Errorf("unmarshal metadata: %w", err)
 		}
 	}
+	if len(historyJSON) > 0 {
+		if err := json.Unmarshal(historyJSON, &quote.History); err != nil {
+			return RefundQuote{}, fmt.Errorf("unmarshal history: %w", err)
+		}
+	}
 
 	// Refund requests never expire - they remain pending until approved or denied by admin
 	return quote, nil
@@ -564,7 +842,7 @@ func (s *PostgresStore) GetRefundQuoteByOriginalPurchaseID(ctx context.Context,
 	defer cancel()
 
 	query := fmt.Sprintf(`
-		SELECT id, original_purchase_id, recipient_wallet, amount, amount_asset, reason, metadata, created_at, expires_at, processed_by, processed_at, signature
+		SELECT id, original_purchase_id, recipient_wallet, amount, amount_asset, requested_amount, reason, metadata, created_at, expires_at, processed_by, processed_at, signature, history
 		FROM %s
 		WHERE original_purchase_id = $1
 		LIMIT 1
@@ -574,11 +852,13 @@ func (s *PostgresStore) GetRefundQuoteByOriginalPurchaseID(ctx context.Context,
 	var metadataJSON []byte
 	var amountAtomic int64
 	var amountAsset string
+	var requestedAtomic sql.NullInt64
+	var historyJSON []byte
 
 	err := s.db.QueryRowContext(ctx, query, originalPurchaseID).Scan(
-		&quote.ID, &quote.OriginalPurchaseID, &quote.RecipientWallet, &amountAtomic, &amountAsset,
+		&quote.ID, &quote.OriginalPurchaseID, &quote.RecipientWallet, &amountAtomic, &amountAsset, &requestedAtomic,
 		&quote.Reason, &metadataJSON, &quote.CreatedAt,
-		&quote.ExpiresAt, &quote.ProcessedBy, &quote.ProcessedAt, &quote.Signature)
+		&quote.ExpiresAt, &quote.ProcessedBy, &quote.ProcessedAt, &quote.Signature, &historyJSON)
 
 	if err == sql.ErrNoRows {
 		return RefundQuote{}, ErrNotFound
@@ -593,12 +873,21 @@ func (s *PostgresStore) GetRefundQuoteByOriginalPurchaseID(ctx context.Context,
 		return RefundQuote{}, fmt.Errorf("get asset %s: %w", amountAsset, err)
 	}
 	quote.Amount = money.New(asset, amountAtomic)
+	if requestedAtomic.Valid {
+		requested := money.New(asset, requestedAtomic.Int64)
+		quote.RequestedAmount = &requested
+	}
 
 	if len(metadataJSON) > 0 {
 		if err := json.Unmarshal(metadataJSON, &quote.Metadata); err != nil {
 			return RefundQuote{}, fmt.Errorf("unmarshal metadata: %w", err)
 		}
 	}
+	if len(historyJSON) > 0 {
+		if err := json.Unmarshal(historyJSON, &quote.History); err != nil {
+			return RefundQuote{}, fmt.Errorf("unmarshal history: %w", err)
+		}
+	}
 
 	return quote, nil
 }
@@ -609,7 +898,7 @@ func (s *PostgresStore) ListPendingRefunds(ctx context.Context) ([]RefundQuote,
 	defer cancel()
 
 	query := fmt.Sprintf(`
-		SELECT id, original_purchase_id, recipient_wallet, amount, amount_asset, reason, metadata, created_at, expires_at, processed_by, processed_at, signature
+		SELECT id, original_purchase_id, recipient_wallet, amount, amount_asset, requested_amount, reason, metadata, created_at, expires_at, processed_by, processed_at, signature, history
 		FROM %s
 		WHERE processed_at IS NULL
 		ORDER BY created_at ASC
@@ -627,11 +916,13 @@ func (s *PostgresStore) ListPendingRefunds(ctx context.Context) ([]RefundQuote,
 		var metadataJSON []byte
 		var amountAtomic int64
 		var amountAsset string
+		var requestedAtomic sql.NullInt64
+		var historyJSON []byte
 
 		err := rows.Scan(
-			&quote.ID, &quote.OriginalPurchaseID, &quote.RecipientWallet, &amountAtomic, &amountAsset,
+			&quote.ID, &quote.OriginalPurchaseID, &quote.RecipientWallet, &amountAtomic, &amountAsset, &requestedAtomic,
 			&quote.Reason, &metadataJSON, &quote.CreatedAt,
-			&quote.ExpiresAt, &quote.ProcessedBy, &quote.ProcessedAt, &quote.Signature)
+			&quote.ExpiresAt, &quote.ProcessedBy, &quote.ProcessedAt, &quote.Signature, &historyJSON)
 		if err != nil {
 			return nil, err
 		}
@@ -642,12 +933,21 @@ func (s *PostgresStore) ListPendingRefunds(ctx context.Context) ([]RefundQuote,
 			return nil, fmt.Errorf("get asset %s: %w", amountAsset, err)
 		}
 		quote.Amount = money.New(asset, amountAtomic)
+		if requestedAtomic.Valid {
+			requested := money.New(asset, requestedAtomic.Int64)
+			quote.RequestedAmount = &requested
+		}
 
 		if len(metadataJSON) > 0 {
 			if err := json.Unmarshal(metadataJSON, &quote.Metadata); err != nil {
 				return nil, fmt.Errorf("unmarshal metadata: %w", err)
 			}
 		}
+		if len(historyJSON) > 0 {
+			if err := json.Unmarshal(historyJSON, &quote.History); err != nil {
+				return nil, fmt.Errorf("unmarshal history: %w", err)
+			}
+		}
 
 		refunds = append(refunds, quote)
 	}
@@ -701,7 +1001,7 @@ func (s *PostgresStore) SaveRefundQuotes(ctx context.Context, quotes []RefundQuo
 
 	// Build multi-row INSERT query with all values in a single statement
 	baseQuery := fmt.Sprintf(`
-		INSERT INTO %s (id, original_purchase_id, recipient_wallet, amount, amount_asset, reason, metadata, created_at, expires_at, processed_by, processed_at, signature)
+		INSERT INTO %s (id, original_purchase_id, recipient_wallet, amount, amount_asset, requested_amount, reason, metadata, created_at, expires_at, processed_by, processed_at, signature, history)
 		VALUES `, s.refundQuotesTableName)
 	const conflictClause = `
 		ON CONFLICT (id) DO UPDATE SET
@@ -709,29 +1009,35 @@ func (s *PostgresStore) SaveRefundQuotes(ctx context.Context, quotes []RefundQuo
 			recipient_wallet = EXCLUDED.recipient_wallet,
 			amount = EXCLUDED.amount,
 			amount_asset = EXCLUDED.amount_asset,
+			requested_amount = EXCLUDED.requested_amount,
 			reason = EXCLUDED.reason,
 			metadata = EXCLUDED.metadata,
 			created_at = EXCLUDED.created_at,
 			expires_at = EXCLUDED.expires_at,
 			processed_by = EXCLUDED.processed_by,
 			processed_at = EXCLUDED.processed_at,
-			signature = EXCLUDED.signature`
+			signature = EXCLUDED.signature,
+			history = EXCLUDED.history`
 
 	// Build VALUES placeholders and collect args
 	valuePlaceholders := make([]string, 0, len(quotes))
-	args := make([]interface{}, 0, len(quotes)*12)
+	args := make([]interface{}, 0, len(quotes)*14)
 
 	for i, quote := range quotes {
 		metadataJSON, err := json.Marshal(quote.Metadata)
 		if err != nil {
 			return fmt.Errorf("quote %d: marshal metadata: %w", i, err)
 		}
+		historyJSON, err := json.Marshal(quote.History)
+		if err != nil {
+			return fmt.Errorf("quote %d: marshal history: %w", i, err)
+		}
 
-		// Each refund quote needs 12 parameters
-		offset := i * 12
-		placeholder := fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+		// Each refund quote needs 14 parameters
+		offset := i * 14
+		placeholder := fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
 			offset+1, offset+2, offset+3, offset+4, offset+5, offset+6,
-			offset+7, offset+8, offset+9, offset+10, offset+11, offset+12)
+			offset+7, offset+8, offset+9, offset+10, offset+11, offset+12, offset+13, offset+14)
 		valuePlaceholders = append(valuePlaceholders, placeholder)
 
 		args = append(args,
@@ -740,6 +1046,7 @@ func (s *PostgresStore) SaveRefundQuotes(ctx context.Context, quotes []RefundQuo
 			quote.RecipientWallet,
 			quote.Amount.Atomic,
 			quote.Amount.Asset.Code, // Use .Code to get string, not struct
+			requestedRefundAtomic(quote),
 			quote.Reason,
 			metadataJSON,
 			quote.CreatedAt,
@@ -747,6 +1054,7 @@ func (s *PostgresStore) SaveRefundQuotes(ctx context.Context, quotes []RefundQuo
 			quote.ProcessedBy,
 			quote.ProcessedAt,
 			quote.Signature,
+			historyJSON,
 		)
 	}
 
@@ -924,6 +1232,25 @@ func (s *PostgresStore) HasPaymentBeenProcessed(ctx context.Context, signature s
 	return exists, nil
 }
 
+// PaymentSignatures calls fn with every recorded payment signature. It streams the whole
+// table, so it has no query timeout.
+func (s *PostgresStore) PaymentSignatures(ctx context.Context, fn func(signature string)) error {
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`SELECT signature FROM %s`, s.paymentTransactionsTableName))
+	if err != nil {
+		return fmt.Errorf("list payment signatures: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var signature string
+		if err := rows.Scan(&signature); err != nil {
+			return fmt.Errorf("scan payment signature: %w", err)
+		}
+		fn(signature)
+	}
+	return rows.Err()
+}
+
 // GetPayment retrieves a payment transaction by signature.
 // Returns the original payment record showing which resource it was used for.
 func (s *PostgresStore) GetPayment(ctx context.Context, signature string) (PaymentTransaction, error) {
@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+// ReferralConversion is a purchase made with a referral coupon, attributed to the coupon's
+// referrer wallet.
+type ReferralConversion struct {
+	Signature      string      `json:"signature"` // Payment the purchase was made with
+	ReferrerWallet string      `json:"referrerWallet"`
+	Code           string      `json:"code"` // Referral coupon used
+	ResourceID     string      `json:"resourceId"`
+	Amount         money.Money `json:"amount"` // Paid for the purchase
+	Reward         money.Money `json:"reward"` // Owed to the referrer, in the payment's asset
+	ConvertedAt    time.Time   `json:"convertedAt"`
+}
+
+// validateReferralConversion checks a conversion before it is recorded.
+func validateReferralConversion(c ReferralConversion) error {
+	if c.Signature == "" || c.ReferrerWallet == "" || c.Code == "" {
+		return fmt.Errorf("referral conversion signature, referrer wallet, and code required")
+	}
+	if c.Amount.Asset.Code != c.Reward.Asset.Code {
+		return fmt.Errorf("referral conversion %s: reward in %s, amount in %s", c.Signature, c.Reward.Asset.Code, c.Amount.Asset.Code)
+	}
+	if c.Reward.IsNegative() {
+		return fmt.Errorf("referral conversion %s: reward must not be negative", c.Signature)
+	}
+	return nil
+}
+
+// addConversionToMap implements RecordReferralConversion for the map-backed stores. Callers
+// hold the write lock. It reports whether the conversion was added.
+func addConversionToMap(conversions map[string][]ReferralConversion, c ReferralConversion) bool {
+	for _, existing := range conversions[c.ReferrerWallet] {
+		if existing.Signature == c.Signature {
+			return false
+		}
+	}
+	conversions[c.ReferrerWallet] = append(conversions[c.ReferrerWallet], c)
+	return true
+}
+
+// sortReferralConversions orders conversions most recent first.
+func sortReferralConversions(conversions []ReferralConversion) {
+	sort.SliceStable(conversions, func(i, j int) bool {
+		return conversions[i].ConvertedAt.After(conversions[j].ConvertedAt)
+	})
+}
@@ -0,0 +1,28 @@
+package storage
+
+import "context"
+
+// RecordReferralConversion records a purchase attributed to a referrer.
+func (s *FileStore) RecordReferralConversion(_ context.Context, conversion ReferralConversion) error {
+	if err := validateReferralConversion(conversion); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if addConversionToMap(s.referralConversions, conversion) {
+		s.markDirty()
+	}
+	return nil
+}
+
+// ListReferralConversions returns a referrer's conversions, most recent first.
+func (s *FileStore) ListReferralConversions(_ context.Context, referrerWallet string) ([]ReferralConversion, error) {
+	s.mu.RLock()
+	conversions := append([]ReferralConversion(nil), s.referralConversions[referrerWallet]...)
+	s.mu.RUnlock()
+
+	sortReferralConversions(conversions)
+	return conversions, nil
+}
@@ -0,0 +1,26 @@
+package storage
+
+import "context"
+
+// RecordReferralConversion records a purchase attributed to a referrer.
+func (m *MemoryStore) RecordReferralConversion(_ context.Context, conversion ReferralConversion) error {
+	if err := validateReferralConversion(conversion); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	addConversionToMap(m.referralConversions, conversion)
+	return nil
+}
+
+// ListReferralConversions returns a referrer's conversions, most recent first.
+func (m *MemoryStore) ListReferralConversions(_ context.Context, referrerWallet string) ([]ReferralConversion, error) {
+	m.mu.RLock()
+	conversions := append([]ReferralConversion(nil), m.referralConversions[referrerWallet]...)
+	m.mu.RUnlock()
+
+	sortReferralConversions(conversions)
+	return conversions, nil
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+const referralConversionsCollection = "referral_conversions"
+
+// referralConversionDocument is a conversion keyed by <signature>/<referrer wallet>.
+type referralConversionDocument struct {
+	ID             string    `bson:"_id"`
+	Signature      string    `bson:"signature"`
+	ReferrerWallet string    `bson:"referrer_wallet"`
+	Code           string    `bson:"code"`
+	ResourceID     string    `bson:"resource_id"`
+	Amount         int64     `bson:"amount"`
+	Reward         int64     `bson:"reward"`
+	Asset          string    `bson:"asset"`
+	ConvertedAt    time.Time `bson:"converted_at"`
+}
+
+// RecordReferralConversion records a purchase attributed to a referrer.
+func (s *MongoDBStore) RecordReferralConversion(ctx context.Context, conversion ReferralConversion) error {
+	if err := validateReferralConversion(conversion); err != nil {
+		return err
+	}
+
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.Collection(referralConversionsCollection).InsertOne(ctx, referralConversionDocument{
+		ID:             conversion.Signature + "/" + conversion.ReferrerWallet,
+		Signature:      conversion.Signature,
+		ReferrerWallet: conversion.ReferrerWallet,
+		Code:           conversion.Code,
+		ResourceID:     conversion.ResourceID,
+		Amount:         conversion.Amount.Atomic,
+		Reward:         conversion.Reward.Atomic,
+		Asset:          conversion.Amount.Asset.Code,
+		ConvertedAt:    conversion.ConvertedAt,
+	})
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("record referral conversion: %w", err)
+	}
+	return nil
+}
+
+// ListReferralConversions returns a referrer's conversions, most recent first.
+func (s *MongoDBStore) ListReferralConversions(ctx context.Context, referrerWallet string) ([]ReferralConversion, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "converted_at", Value: -1}})
+	cursor, err := s.db.Collection(referralConversionsCollection).Find(ctx, bson.M{"referrer_wallet": referrerWallet}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("list referral conversions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var docs []referralConversionDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("decode referral conversions: %w", err)
+	}
+	conversions := make([]ReferralConversion, 0, len(docs))
+	for _, doc := range docs {
+		asset, err := money.GetAsset(doc.Asset)
+		if err != nil {
+			return nil, fmt.Errorf("referral conversion %s: %w", doc.Signature, err)
+		}
+		conversions = append(conversions, ReferralConversion{
+			Signature:      doc.Signature,
+			ReferrerWallet: doc.ReferrerWallet,
+			Code:           doc.Code,
+			ResourceID:     doc.ResourceID,
+			Amount:         money.New(asset, doc.Amount),
+			Reward:         money.New(asset, doc.Reward),
+			ConvertedAt:    doc.ConvertedAt,
+		})
+	}
+	return conversions, nil
+}
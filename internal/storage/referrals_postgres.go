@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+// RecordReferralConversion records a purchase attributed to a referrer.
+func (s *PostgresStore) RecordReferralConversion(ctx context.Context, conversion ReferralConversion) error {
+	if err := validateReferralConversion(conversion); err != nil {
+		return err
+	}
+
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`
+		INSERT INTO %s (signature, referrer_wallet, code, resource_id, amount, reward, asset, converted_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (signature, referrer_wallet) DO NOTHING
+	`, s.referralConversionsTableName)
+	_, err := s.db.ExecContext(ctx, query,
+		conversion.Signature, conversion.ReferrerWallet, conversion.Code, conversion.ResourceID,
+		conversion.Amount.Atomic, conversion.Reward.Atomic, conversion.Amount.Asset.Code, conversion.ConvertedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("record referral conversion: %w", err)
+	}
+	return nil
+}
+
+// ListReferralConversions returns a referrer's conversions, most recent first.
+func (s *PostgresStore) ListReferralConversions(ctx context.Context, referrerWallet string) ([]ReferralConversion, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`
+		SELECT signature, code, resource_id, amount, reward, asset, converted_at
+		FROM %s WHERE referrer_wallet = $1
+		ORDER BY converted_at DESC
+	`, s.referralConversionsTableName)
+	rows, err := s.db.QueryContext(ctx, query, referrerWallet)
+	if err != nil {
+		return nil, fmt.Errorf("list referral conversions: %w", err)
+	}
+	defer rows.Close()
+
+	var conversions []ReferralConversion
+	for rows.Next() {
+		c := ReferralConversion{ReferrerWallet: referrerWallet}
+		var amount, reward int64
+		var assetCode string
+		if err := rows.Scan(&c.Signature, &c.Code, &c.ResourceID, &amount, &reward, &assetCode, &c.ConvertedAt); err != nil {
+			return nil, fmt.Errorf("scan referral conversion: %w", err)
+		}
+		asset, err := money.GetAsset(assetCode)
+		if err != nil {
+			return nil, fmt.Errorf("referral conversion %s: %w", c.Signature, err)
+		}
+		c.Amount, c.Reward = money.New(asset, amount), money.New(asset, reward)
+		conversions = append(conversions, c)
+	}
+	return conversions, rows.Err()
+}
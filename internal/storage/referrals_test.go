@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+func TestReferralConversions(t *testing.T) {
+	backends := []struct {
+		name string
+		open func(t *testing.T, path string) Store
+	}{
+		{name: "memory", open: func(t *testing.T, _ string) Store { return NewMemoryStore() }},
+		{
+			name: "file",
+			open: func(t *testing.T, path string) Store {
+				store, err := NewFileStore(path)
+				if err != nil {
+					t.Fatalf("NewFileStore: %v", err)
+				}
+				return store
+			},
+		},
+	}
+
+	usdc := money.MustGetAsset("USDC")
+	now := time.Now().UTC().Truncate(time.Second)
+	conversion := func(signature, wallet string, age time.Duration) ReferralConversion {
+		return ReferralConversion{
+			Signature:      signature,
+			ReferrerWallet: wallet,
+			Code:           "FRIEND10",
+			ResourceID:     "demo-content",
+			Amount:         money.New(usdc, 900000),
+			Reward:         money.New(usdc, 45000),
+			ConvertedAt:    now.Add(-age),
+		}
+	}
+
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "store.json")
+			store := backend.open(t, path)
+			ctx := context.Background()
+
+			records := []struct {
+				name       string
+				conversion ReferralConversion
+				wantErr    bool
+			}{
+				{name: "older", conversion: conversion("sig-1", "referrer", time.Hour)},
+				{name: "newer", conversion: conversion("sig-2", "referrer", 0)},
+				{name: "duplicate signature", conversion: conversion("sig-1", "referrer", 0)},
+				{name: "other referrer", conversion: conversion("sig-1", "other", 0)},
+				{name: "missing wallet", conversion: conversion("sig-3", "", 0), wantErr: true},
+				{name: "reward in another asset", conversion: func() ReferralConversion {
+					c := conversion("sig-4", "referrer", 0)
+					c.Reward = money.New(money.MustGetAsset("USD"), 5)
+					return c
+				}(), wantErr: true},
+			}
+			for _, record := range records {
+				err := store.RecordReferralConversion(ctx, record.conversion)
+				if (err != nil) != record.wantErr {
+					t.Fatalf("%s: RecordReferralConversion err = %v, wantErr %v", record.name, err, record.wantErr)
+				}
+			}
+
+			if backend.name == "file" {
+				_ = store.Close()
+				store = backend.open(t, path)
+			}
+			defer store.Close()
+
+			conversions, err := store.ListReferralConversions(ctx, "referrer")
+			if err != nil {
+				t.Fatalf("ListReferralConversions: %v", err)
+			}
+			if len(conversions) != 2 || conversions[0].Signature != "sig-2" || conversions[1].Signature != "sig-1" {
+				t.Fatalf("conversions = %+v, want sig-2 then sig-1", conversions)
+			}
+			if !conversions[1].ConvertedAt.Equal(now.Add(-time.Hour)) || conversions[1].Reward.Atomic != 45000 {
+				t.Errorf("sig-1 = %+v, want converted an hour ago with a 45000 reward", conversions[1])
+			}
+			if others, _ := store.ListReferralConversions(ctx, "other"); len(others) != 1 {
+				t.Errorf("other referrer has %d conversions, want 1", len(others))
+			}
+			if none, _ := store.ListReferralConversions(ctx, "nobody"); len(none) != 0 {
+				t.Errorf("unknown referrer has %d conversions, want 0", len(none))
+			}
+		})
+	}
+}
@@ -12,16 +12,42 @@ import (
 // RefundQuote represents a generated refund quote with payment details.
 type RefundQuote struct {
 	ID                 string
-	OriginalPurchaseID string // Reference to original purchase (resource ID, cart ID, session ID)
-	RecipientWallet    string // Wallet receiving the refund
-	Amount             money.Money
+	OriginalPurchaseID string       // Reference to original purchase (resource ID, cart ID, session ID)
+	RecipientWallet    string       // Wallet receiving the refund
+	Amount             money.Money  // Amount to refund: the requested amount unless an admin approved less
+	RequestedAmount    *money.Money // Amount the customer asked for (nil on requests stored before it was recorded)
 	Reason             string
 	Metadata           map[string]string
 	CreatedAt          time.Time
 	ExpiresAt          time.Time
 	ProcessedBy        string // Wallet that executed the refund
 	ProcessedAt        *time.Time
-	Signature          string // Transaction signature
+	Signature          string               // Transaction signature
+	History            []RefundHistoryEntry // Conversation and status changes, oldest first
+}
+
+// Refund history entry kinds.
+const (
+	RefundHistoryStatus  = "status"  // A state transition
+	RefundHistoryMessage = "message" // A note from the customer or an admin
+)
+
+// Refund statuses recorded in the history.
+const (
+	RefundStatusRequested = "requested"
+	RefundStatusAdjusted  = "adjusted"
+	RefundStatusApproved  = "approved"
+	RefundStatusEscalated = "escalated"
+	RefundStatusProcessed = "processed"
+)
+
+// RefundHistoryEntry is one step in a refund request's thread: a status change or a note.
+type RefundHistoryEntry struct {
+	Kind    string    `json:"kind"`              // RefundHistoryStatus or RefundHistoryMessage
+	Author  string    `json:"author"`            // "customer", "admin", or "policy"
+	Status  string    `json:"status,omitempty"`  // New status, for status entries
+	Message string    `json:"message,omitempty"` // Note text, or context for a status change
+	At      time.Time `json:"at"`
 }
 
 // IsExpiredAt returns true if the refund quote's transaction execution window has passed at the given moment.
@@ -32,6 +58,30 @@ func (r *RefundQuote) IsExpiredAt(now time.Time) bool {
 	return now.After(r.ExpiresAt)
 }
 
+// Requested returns the amount the customer asked for, falling back to Amount for requests
+// stored before the requested amount was recorded.
+func (r *RefundQuote) Requested() money.Money {
+	if r.RequestedAmount == nil {
+		return r.Amount
+	}
+	return *r.RequestedAmount
+}
+
+// IsAdjusted returns true if an admin approved less than the customer asked for.
+func (r *RefundQuote) IsAdjusted() bool {
+	return r.Amount.LessThan(r.Requested())
+}
+
+// LatestStatus returns the most recent status in the refund's history, or "" if none was recorded.
+func (r *RefundQuote) LatestStatus() string {
+	for i := len(r.History) - 1; i >= 0; i-- {
+		if r.History[i].Kind == RefundHistoryStatus {
+			return r.History[i].Status
+		}
+	}
+	return ""
+}
+
 // IsProcessed returns true if the refund has been completed.
 func (r *RefundQuote) IsProcessed() bool {
 	return r.ProcessedAt != nil && r.Signature != ""
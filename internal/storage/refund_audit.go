@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+// Refund audit actions.
+const (
+	RefundAuditDenied     = "denied"      // An admin denied the request
+	RefundAuditAdjusted   = "adjusted"    // An admin approved less than was requested
+	RefundAuditAutoDenied = "auto_denied" // The pending refund policy denied the request
+	RefundAuditEscalated  = "escalated"   // The pending refund policy escalated the request
+)
+
+// RefundAuditEntry records a decision on a refund request. Entries outlive the request: a
+// denied request is deleted, but its audit trail is kept.
+type RefundAuditEntry struct {
+	RefundID           string            `json:"refundId"`
+	Action             string            `json:"action"` // One of the RefundAudit* actions
+	Actor              string            `json:"actor"`  // "admin" or "policy"
+	OriginalPurchaseID string            `json:"originalPurchaseId"`
+	RecipientWallet    string            `json:"recipientWallet"`
+	Amount             money.Money       `json:"amount"`
+	Reason             string            `json:"reason,omitempty"` // Why the action was taken
+	Metadata           map[string]string `json:"metadata,omitempty"`
+	RecordedAt         time.Time         `json:"recordedAt"`
+}
+
+// validateRefundAuditEntry checks an entry before it is recorded.
+func validateRefundAuditEntry(e RefundAuditEntry) error {
+	if e.RefundID == "" || e.Action == "" || e.Actor == "" {
+		return fmt.Errorf("refund audit entry refund id, action, and actor required")
+	}
+	if e.RecordedAt.IsZero() {
+		return fmt.Errorf("refund audit entry %s: recorded time required", e.RefundID)
+	}
+	return nil
+}
+
+// sortRefundAuditEntries orders entries oldest first.
+func sortRefundAuditEntries(entries []RefundAuditEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].RecordedAt.Before(entries[j].RecordedAt)
+	})
+}
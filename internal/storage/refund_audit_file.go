@@ -0,0 +1,27 @@
+package storage
+
+import "context"
+
+// RecordRefundAudit appends an entry to a refund request's audit trail.
+func (s *FileStore) RecordRefundAudit(_ context.Context, entry RefundAuditEntry) error {
+	if err := validateRefundAuditEntry(entry); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.refundAudit[entry.RefundID] = append(s.refundAudit[entry.RefundID], entry)
+	s.markDirty()
+	return nil
+}
+
+// ListRefundAudit returns a refund request's audit trail, oldest first.
+func (s *FileStore) ListRefundAudit(_ context.Context, refundID string) ([]RefundAuditEntry, error) {
+	s.mu.RLock()
+	entries := append([]RefundAuditEntry(nil), s.refundAudit[refundID]...)
+	s.mu.RUnlock()
+
+	sortRefundAuditEntries(entries)
+	return entries, nil
+}
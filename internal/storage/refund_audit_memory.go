@@ -0,0 +1,26 @@
+package storage
+
+import "context"
+
+// RecordRefundAudit appends an entry to a refund request's audit trail.
+func (m *MemoryStore) RecordRefundAudit(_ context.Context, entry RefundAuditEntry) error {
+	if err := validateRefundAuditEntry(entry); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.refundAudit[entry.RefundID] = append(m.refundAudit[entry.RefundID], entry)
+	return nil
+}
+
+// ListRefundAudit returns a refund request's audit trail, oldest first.
+func (m *MemoryStore) ListRefundAudit(_ context.Context, refundID string) ([]RefundAuditEntry, error) {
+	m.mu.RLock()
+	entries := append([]RefundAuditEntry(nil), m.refundAudit[refundID]...)
+	m.mu.RUnlock()
+
+	sortRefundAuditEntries(entries)
+	return entries, nil
+}
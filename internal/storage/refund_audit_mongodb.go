@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+const refundAuditCollection = "refund_audit"
+
+// refundAuditDocument is one entry in a refund request's audit trail.
+type refundAuditDocument struct {
+	RefundID           string            `bson:"refund_id"`
+	Action             string            `bson:"action"`
+	Actor              string            `bson:"actor"`
+	OriginalPurchaseID string            `bson:"original_purchase_id"`
+	RecipientWallet    string            `bson:"recipient_wallet"`
+	Amount             int64             `bson:"amount"`
+	Asset              string            `bson:"asset"`
+	Reason             string            `bson:"reason,omitempty"`
+	Metadata           map[string]string `bson:"metadata,omitempty"`
+	RecordedAt         time.Time         `bson:"recorded_at"`
+}
+
+// RecordRefundAudit appends an entry to a refund request's audit trail.
+func (s *MongoDBStore) RecordRefundAudit(ctx context.Context, entry RefundAuditEntry) error {
+	if err := validateRefundAuditEntry(entry); err != nil {
+		return err
+	}
+
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.Collection(refundAuditCollection).InsertOne(ctx, refundAuditDocument{
+		RefundID:           entry.RefundID,
+		Action:             entry.Action,
+		Actor:              entry.Actor,
+		OriginalPurchaseID: entry.OriginalPurchaseID,
+		RecipientWallet:    entry.RecipientWallet,
+		Amount:             entry.Amount.Atomic,
+		Asset:              entry.Amount.Asset.Code,
+		Reason:             entry.Reason,
+		Metadata:           entry.Metadata,
+		RecordedAt:         entry.RecordedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("record refund audit: %w", err)
+	}
+	return nil
+}
+
+// ListRefundAudit returns a refund request's audit trail, oldest first.
+func (s *MongoDBStore) ListRefundAudit(ctx context.Context, refundID string) ([]RefundAuditEntry, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "recorded_at", Value: 1}})
+	cursor, err := s.db.Collection(refundAuditCollection).Find(ctx, bson.M{"refund_id": refundID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("list refund audit: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var docs []refundAuditDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("decode refund audit: %w", err)
+	}
+	entries := make([]RefundAuditEntry, 0, len(docs))
+	for _, doc := range docs {
+		asset, err := money.GetAsset(doc.Asset)
+		if err != nil {
+			return nil, fmt.Errorf("refund audit %s: %w", doc.RefundID, err)
+		}
+		entries = append(entries, RefundAuditEntry{
+			RefundID:           doc.RefundID,
+			Action:             doc.Action,
+			Actor:              doc.Actor,
+			OriginalPurchaseID: doc.OriginalPurchaseID,
+			RecipientWallet:    doc.RecipientWallet,
+			Amount:             money.New(asset, doc.Amount),
+			Reason:             doc.Reason,
+			Metadata:           doc.Metadata,
+			RecordedAt:         doc.RecordedAt,
+		})
+	}
+	return entries, nil
+}
@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+// RecordRefundAudit appends an entry to a refund request's audit trail.
+func (s *PostgresStore) RecordRefundAudit(ctx context.Context, entry RefundAuditEntry) error {
+	if err := validateRefundAuditEntry(entry); err != nil {
+		return err
+	}
+	metadataJSON, err := json.Marshal(entry.Metadata)
+	if err != nil {
+		return fmt.Errorf("marshal metadata: %w", err)
+	}
+
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`
+		INSERT INTO %s (refund_id, action, actor, original_purchase_id, recipient_wallet, amount, asset, reason, metadata, recorded_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, s.refundAuditTableName)
+	_, err = s.db.ExecContext(ctx, query,
+		entry.RefundID, entry.Action, entry.Actor, entry.OriginalPurchaseID, entry.RecipientWallet,
+		entry.Amount.Atomic, entry.Amount.Asset.Code, entry.Reason, metadataJSON, entry.RecordedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("record refund audit: %w", err)
+	}
+	return nil
+}
+
+// ListRefundAudit returns a refund request's audit trail, oldest first.
+func (s *PostgresStore) ListRefundAudit(ctx context.Context, refundID string) ([]RefundAuditEntry, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`
+		SELECT action, actor, original_purchase_id, recipient_wallet, amount, asset, reason, metadata, recorded_at
+		FROM %s WHERE refund_id = $1
+		ORDER BY recorded_at, id
+	`, s.refundAuditTableName)
+	rows, err := s.db.QueryContext(ctx, query, refundID)
+	if err != nil {
+		return nil, fmt.Errorf("list refund audit: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []RefundAuditEntry
+	for rows.Next() {
+		e := RefundAuditEntry{RefundID: refundID}
+		var amount int64
+		var assetCode string
+		var metadataJSON []byte
+		if err := rows.Scan(&e.Action, &e.Actor, &e.OriginalPurchaseID, &e.RecipientWallet, &amount, &assetCode, &e.Reason, &metadataJSON, &e.RecordedAt); err != nil {
+			return nil, fmt.Errorf("scan refund audit: %w", err)
+		}
+		asset, err := money.GetAsset(assetCode)
+		if err != nil {
+			return nil, fmt.Errorf("refund audit %s: %w", refundID, err)
+		}
+		e.Amount = money.New(asset, amount)
+		if len(metadataJSON) > 0 {
+			if err := json.Unmarshal(metadataJSON, &e.Metadata); err != nil {
+				return nil, fmt.Errorf("unmarshal refund audit metadata: %w", err)
+			}
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+func TestRefundAudit(t *testing.T) {
+	backends := []struct {
+		name string
+		open func(t *testing.T, path string) Store
+	}{
+		{name: "memory", open: func(t *testing.T, _ string) Store { return NewMemoryStore() }},
+		{
+			name: "file",
+			open: func(t *testing.T, path string) Store {
+				store, err := NewFileStore(path)
+				if err != nil {
+					t.Fatalf("NewFileStore: %v", err)
+				}
+				return store
+			},
+		},
+	}
+
+	usdc := money.MustGetAsset("USDC")
+	now := time.Now().UTC().Truncate(time.Second)
+	entry := func(refundID, action, actor string, age time.Duration) RefundAuditEntry {
+		return RefundAuditEntry{
+			RefundID:           refundID,
+			Action:             action,
+			Actor:              actor,
+			OriginalPurchaseID: "sig-purchase",
+			RecipientWallet:    "wallet-1",
+			Amount:             money.New(usdc, 1500000),
+			Reason:             "pending for 7 days",
+			RecordedAt:         now.Add(-age),
+		}
+	}
+
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "store.json")
+			store := backend.open(t, path)
+			ctx := context.Background()
+
+			records := []struct {
+				name    string
+				entry   RefundAuditEntry
+				wantErr bool
+			}{
+				{name: "denied", entry: entry("refund_1", RefundAuditAutoDenied, "policy", 0)},
+				{name: "escalated earlier", entry: entry("refund_1", RefundAuditEscalated, "policy", time.Hour)},
+				{name: "other refund", entry: entry("refund_2", RefundAuditDenied, "admin", 0)},
+				{name: "missing action", entry: entry("refund_3", "", "admin", 0), wantErr: true},
+				{name: "missing time", entry: entry("refund_3", RefundAuditDenied, "admin", 0), wantErr: true},
+			}
+			records[len(records)-1].entry.RecordedAt = time.Time{}
+			for _, record := range records {
+				err := store.RecordRefundAudit(ctx, record.entry)
+				if (err != nil) != record.wantErr {
+					t.Fatalf("%s: RecordRefundAudit err = %v, wantErr %v", record.name, err, record.wantErr)
+				}
+			}
+
+			if backend.name == "file" {
+				_ = store.Close()
+				store = backend.open(t, path)
+			}
+			defer store.Close()
+
+			entries, err := store.ListRefundAudit(ctx, "refund_1")
+			if err != nil {
+				t.Fatalf("ListRefundAudit: %v", err)
+			}
+			if len(entries) != 2 || entries[0].Action != RefundAuditEscalated || entries[1].Action != RefundAuditAutoDenied {
+				t.Fatalf("entries = %+v, want escalated then auto_denied", entries)
+			}
+			if entries[1].Amount.Atomic != 1500000 || !entries[1].RecordedAt.Equal(now) {
+				t.Errorf("auto_denied entry = %+v", entries[1])
+			}
+			if none, _ := store.ListRefundAudit(ctx, "refund_3"); len(none) != 0 {
+				t.Errorf("invalid entries were recorded: %+v", none)
+			}
+		})
+	}
+}
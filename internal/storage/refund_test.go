@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -120,6 +121,79 @@ func TestRefundQuote_IsProcessed(t *testing.T) {
 	}
 }
 
+func TestRefundQuote_Requested(t *testing.T) {
+	usdc := money.MustGetAsset("USDC")
+	requested := money.New(usdc, 10_000000)
+	tests := []struct {
+		name         string
+		amount       money.Money
+		requested    *money.Money
+		wantAtomic   int64
+		wantAdjusted bool
+	}{
+		{name: "requested amount not recorded", amount: money.New(usdc, 10_000000), wantAtomic: 10_000000},
+		{name: "approved as requested", amount: money.New(usdc, 10_000000), requested: &requested, wantAtomic: 10_000000},
+		{name: "approved less", amount: money.New(usdc, 7_500000), requested: &requested, wantAtomic: 10_000000, wantAdjusted: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refund := RefundQuote{Amount: tt.amount, RequestedAmount: tt.requested}
+			if got := refund.Requested(); got.Atomic != tt.wantAtomic {
+				t.Errorf("Requested() = %d, want %d", got.Atomic, tt.wantAtomic)
+			}
+			if got := refund.IsAdjusted(); got != tt.wantAdjusted {
+				t.Errorf("IsAdjusted() = %v, want %v", got, tt.wantAdjusted)
+			}
+		})
+	}
+}
+
+func TestFileStore_RefundQuoteRequestedAmount(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "store.json")
+	store, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+
+	usdc := money.MustGetAsset("USDC")
+	requested := money.New(usdc, 10_000000)
+	quotes := []RefundQuote{
+		{ID: "refund_adjusted", OriginalPurchaseID: "purchase-1", RecipientWallet: "wallet123", Amount: money.New(usdc, 7_500000), RequestedAmount: &requested},
+		{ID: "refund_legacy", OriginalPurchaseID: "purchase-2", RecipientWallet: "wallet123", Amount: money.New(usdc, 5_000000)},
+	}
+	for _, quote := range quotes {
+		if err := store.SaveRefundQuote(ctx, quote); err != nil {
+			t.Fatalf("SaveRefundQuote(%s): %v", quote.ID, err)
+		}
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	reopened, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	t.Cleanup(func() { _ = reopened.Close() })
+
+	adjusted, err := reopened.GetRefundQuote(ctx, "refund_adjusted")
+	if err != nil {
+		t.Fatalf("GetRefundQuote: %v", err)
+	}
+	if adjusted.RequestedAmount == nil || adjusted.RequestedAmount.Atomic != 10_000000 || adjusted.Amount.Atomic != 7_500000 {
+		t.Errorf("adjusted refund = %+v", adjusted)
+	}
+	legacy, err := reopened.GetRefundQuote(ctx, "refund_legacy")
+	if err != nil {
+		t.Fatalf("GetRefundQuote: %v", err)
+	}
+	if legacy.RequestedAmount != nil {
+		t.Errorf("legacy requested amount = %+v, want nil", legacy.RequestedAmount)
+	}
+}
+
 func TestMemoryStore_SaveRefundQuote(t *testing.T) {
 	store := NewMemoryStore()
 	defer store.Stop()
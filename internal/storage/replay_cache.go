@@ -0,0 +1,248 @@
+package storage
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sync"
+)
+
+// bloomFalsePositiveRate is the false positive rate the replay cache's bloom filter is sized for.
+const bloomFalsePositiveRate = 0.01
+
+// ReplayCacheOptions configures NewReplayCacheStore.
+type ReplayCacheOptions struct {
+	Size               int  // Recent payments kept in the LRU (default: 10000)
+	ExpectedSignatures int  // Signatures the bloom filter is sized for (default: 1000000)
+	SingleInstance     bool // This instance is the only writer: warm the filter from the store and trust its misses
+}
+
+// replayCacheStore fronts a Store's replay checks with an LRU of recent payments and a bloom
+// filter of every signature seen. LRU hits answer HasPaymentBeenProcessed and GetPayment, and
+// reject replays in RecordPayment, without a database query. Bloom filter hits are only
+// possible hits and fall through to the store. Misses also fall through, unless SingleInstance
+// is set: then the filter was warmed with every stored signature and a miss means unprocessed.
+type replayCacheStore struct {
+	Store
+	opts ReplayCacheOptions
+
+	mu     sync.Mutex
+	bloom  *bloomFilter
+	recent *list.List               // Front is most recently used; values are *replayEntry
+	index  map[string]*list.Element // Signature -> element in recent
+}
+
+// replayEntry is a signature known to be processed.
+type replayEntry struct {
+	tx    PaymentTransaction
+	final bool // tx is the verified record (not a placeholder) and may be served by GetPayment
+}
+
+// NewReplayCacheStore wraps store with an in-memory replay cache. With opts.SingleInstance the
+// bloom filter is warmed from every signature in store, which must support listing them.
+func NewReplayCacheStore(ctx context.Context, store Store, opts ReplayCacheOptions) (Store, error) {
+	if opts.Size <= 0 {
+		opts.Size = 10000
+	}
+	if opts.ExpectedSignatures <= 0 {
+		opts.ExpectedSignatures = 1000000
+	}
+	s := &replayCacheStore{
+		Store:  store,
+		opts:   opts,
+		bloom:  newBloomFilter(opts.ExpectedSignatures, bloomFalsePositiveRate),
+		recent: list.New(),
+		index:  make(map[string]*list.Element, opts.Size),
+	}
+	if opts.SingleInstance {
+		lister, ok := unwrapStore(store).(interface {
+			PaymentSignatures(ctx context.Context, fn func(signature string)) error
+		})
+		if !ok {
+			return nil, fmt.Errorf("storage: %s backend cannot list payment signatures to warm the replay cache", BackendName(unwrapStore(store)))
+		}
+		if err := lister.PaymentSignatures(ctx, s.bloom.add); err != nil {
+			return nil, fmt.Errorf("storage: warm replay cache: %w", err)
+		}
+	}
+	return s, nil
+}
+
+// RecordPayment rejects signatures whose verified record is cached without querying the store.
+func (s *replayCacheStore) RecordPayment(ctx context.Context, tx PaymentTransaction) error {
+	if s.cachedFinal(tx.Signature) {
+		return errors.New("signature already used: replay attack detected")
+	}
+	if err := s.Store.RecordPayment(ctx, tx); err != nil {
+		return err
+	}
+	s.remember(tx, !isPlaceholderPayment(tx))
+	return nil
+}
+
+// RecordPayments fails fast on a cached verified signature, like the backends do on a stored one.
+func (s *replayCacheStore) RecordPayments(ctx context.Context, txs []PaymentTransaction) error {
+	for i, tx := range txs {
+		if s.cachedFinal(tx.Signature) {
+			return fmt.Errorf("transaction %d: signature already used: %s", i, tx.Signature)
+		}
+	}
+	if err := s.Store.RecordPayments(ctx, txs); err != nil {
+		return err
+	}
+	for _, tx := range txs {
+		s.remember(tx, !isPlaceholderPayment(tx))
+	}
+	return nil
+}
+
+// HasPaymentBeenProcessed answers from the LRU, or from a bloom filter miss in single-instance
+// mode, and otherwise asks the store.
+func (s *replayCacheStore) HasPaymentBeenProcessed(ctx context.Context, signature string) (bool, error) {
+	s.mu.Lock()
+	if _, ok := s.touchLocked(signature); ok {
+		s.mu.Unlock()
+		return true, nil
+	}
+	if s.opts.SingleInstance && !s.bloom.mayContain(signature) {
+		s.mu.Unlock()
+		return false, nil
+	}
+	s.mu.Unlock()
+
+	processed, err := s.Store.HasPaymentBeenProcessed(ctx, signature)
+	if err == nil && processed {
+		s.remember(PaymentTransaction{Signature: signature}, false)
+	}
+	return processed, err
+}
+
+// GetPayment serves cached verified records; placeholders may have been completed by another
+// instance, so they are always read from the store.
+func (s *replayCacheStore) GetPayment(ctx context.Context, signature string) (PaymentTransaction, error) {
+	s.mu.Lock()
+	entry, ok := s.touchLocked(signature)
+	if ok && entry.final {
+		tx := entry.tx
+		tx.Metadata = cloneStringMap(tx.Metadata)
+		s.mu.Unlock()
+		return tx, nil
+	}
+	if s.opts.SingleInstance && !s.bloom.mayContain(signature) {
+		s.mu.Unlock()
+		return PaymentTransaction{}, ErrNotFound
+	}
+	s.mu.Unlock()
+
+	tx, err := s.Store.GetPayment(ctx, signature)
+	if err == nil {
+		s.remember(tx, !isPlaceholderPayment(tx))
+	}
+	return tx, err
+}
+
+// Unwrap returns the wrapped store.
+func (s *replayCacheStore) Unwrap() Store {
+	return s.Store
+}
+
+// cachedFinal reports whether signature's verified record is in the LRU.
+func (s *replayCacheStore) cachedFinal(signature string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.touchLocked(signature)
+	return ok && entry.final
+}
+
+// touchLocked looks signature up in the LRU and marks it most recently used.
+func (s *replayCacheStore) touchLocked(signature string) (*replayEntry, bool) {
+	elem, ok := s.index[signature]
+	if !ok {
+		return nil, false
+	}
+	s.recent.MoveToFront(elem)
+	return elem.Value.(*replayEntry), true
+}
+
+// remember records tx as processed, evicting the least recently used entry when full. A
+// verified record is never downgraded by a later placeholder or record-less sighting.
+func (s *replayCacheStore) remember(tx PaymentTransaction, final bool) {
+	tx.Metadata = cloneStringMap(tx.Metadata)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bloom.add(tx.Signature)
+	if entry, ok := s.touchLocked(tx.Signature); ok {
+		if final || !entry.final {
+			entry.tx, entry.final = tx, final
+		}
+		return
+	}
+	s.index[tx.Signature] = s.recent.PushFront(&replayEntry{tx: tx, final: final})
+	if s.recent.Len() > s.opts.Size {
+		oldest := s.recent.Back()
+		s.recent.Remove(oldest)
+		delete(s.index, oldest.Value.(*replayEntry).tx.Signature)
+	}
+}
+
+// isPlaceholderPayment reports whether tx is the record claimed before verification finished,
+// which RecordPayment may still overwrite.
+func isPlaceholderPayment(tx PaymentTransaction) bool {
+	return tx.Wallet == "" || tx.Metadata["status"] == "verifying"
+}
+
+func cloneStringMap(src map[string]string) map[string]string {
+	if src == nil {
+		return nil
+	}
+	dst := make(map[string]string, len(src))
+	for k, v := range src {
+		dst[k] = v
+	}
+	return dst
+}
+
+// bloomFilter is a fixed-size bloom filter over strings using double hashing.
+type bloomFilter struct {
+	bits   []uint64
+	size   uint64 // Number of bits
+	hashes uint64 // Bit positions set per item
+}
+
+// newBloomFilter sizes a filter for n items at false positive rate p.
+func newBloomFilter(n int, p float64) *bloomFilter {
+	size := uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	hashes := uint64(math.Max(1, math.Round(float64(size)/float64(n)*math.Ln2)))
+	return &bloomFilter{bits: make([]uint64, (size+63)/64), size: size, hashes: hashes}
+}
+
+func (b *bloomFilter) positions(s string) (uint64, uint64) {
+	h1 := fnv.New64a()
+	_, _ = h1.Write([]byte(s))
+	h2 := fnv.New64()
+	_, _ = h2.Write([]byte(s))
+	return h1.Sum64(), h2.Sum64() | 1
+}
+
+func (b *bloomFilter) add(s string) {
+	h1, h2 := b.positions(s)
+	for i := uint64(0); i < b.hashes; i++ {
+		bit := (h1 + i*h2) % b.size
+		b.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+func (b *bloomFilter) mayContain(s string) bool {
+	h1, h2 := b.positions(s)
+	for i := uint64(0); i < b.hashes; i++ {
+		bit := (h1 + i*h2) % b.size
+		if b.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+// countingStore counts the replay-check queries that reach the wrapped store.
+type countingStore struct {
+	Store
+	queries int
+}
+
+func (s *countingStore) HasPaymentBeenProcessed(ctx context.Context, signature string) (bool, error) {
+	s.queries++
+	return s.Store.HasPaymentBeenProcessed(ctx, signature)
+}
+
+func (s *countingStore) GetPayment(ctx context.Context, signature string) (PaymentTransaction, error) {
+	s.queries++
+	return s.Store.GetPayment(ctx, signature)
+}
+
+func (s *countingStore) RecordPayment(ctx context.Context, tx PaymentTransaction) error {
+	s.queries++
+	return s.Store.RecordPayment(ctx, tx)
+}
+
+// Unwrap lets the replay cache warm its bloom filter from the memory store.
+func (s *countingStore) Unwrap() Store {
+	return s.Store
+}
+
+func newCountingStore(t *testing.T) *countingStore {
+	t.Helper()
+	inner := NewMemoryStore()
+	t.Cleanup(func() { _ = inner.Close() })
+	return &countingStore{Store: inner}
+}
+
+func verifiedPayment(signature string) PaymentTransaction {
+	return PaymentTransaction{
+		Signature:  signature,
+		ResourceID: "article",
+		Wallet:     "payer",
+		Amount:     money.Money{Asset: money.MustGetAsset("USDC"), Atomic: 1000000},
+		CreatedAt:  time.Now(),
+		Metadata:   map[string]string{"status": "verified"},
+	}
+}
+
+func TestReplayCacheStore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("verified payments are answered from the LRU", func(t *testing.T) {
+		backend := newCountingStore(t)
+		store, err := NewReplayCacheStore(ctx, backend, ReplayCacheOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		placeholder := PaymentTransaction{Signature: "sig", ResourceID: "article", Metadata: map[string]string{"status": "verifying"}}
+		if err := store.RecordPayment(ctx, placeholder); err != nil {
+			t.Fatalf("record placeholder: %v", err)
+		}
+		if err := store.RecordPayment(ctx, verifiedPayment("sig")); err != nil {
+			t.Fatalf("complete placeholder: %v", err)
+		}
+		backend.queries = 0
+
+		if processed, err := store.HasPaymentBeenProcessed(ctx, "sig"); err != nil || !processed {
+			t.Errorf("HasPaymentBeenProcessed = %v, %v", processed, err)
+		}
+		if tx, err := store.GetPayment(ctx, "sig"); err != nil || tx.Wallet != "payer" {
+			t.Errorf("GetPayment = %+v, %v", tx, err)
+		}
+		if err := store.RecordPayment(ctx, verifiedPayment("sig")); err == nil {
+			t.Error("replayed signature was recorded")
+		}
+		if backend.queries != 0 {
+			t.Errorf("%d queries reached the store, want 0", backend.queries)
+		}
+
+		// Unknown signatures may have been recorded by another instance
+		if processed, _ := store.HasPaymentBeenProcessed(ctx, "other"); processed || backend.queries != 1 {
+			t.Errorf("unknown signature: processed %v after %d queries, want false after 1", processed, backend.queries)
+		}
+	})
+
+	t.Run("placeholders are re-read from the store", func(t *testing.T) {
+		backend := newCountingStore(t)
+		store, _ := NewReplayCacheStore(ctx, backend, ReplayCacheOptions{})
+		_ = store.RecordPayment(ctx, PaymentTransaction{Signature: "sig", Metadata: map[string]string{"status": "verifying"}})
+		// Another instance completes the verification
+		_ = backend.Store.RecordPayment(ctx, verifiedPayment("sig"))
+		backend.queries = 0
+
+		if tx, err := store.GetPayment(ctx, "sig"); err != nil || tx.Wallet != "payer" || backend.queries != 1 {
+			t.Errorf("GetPayment = %+v, %v after %d queries", tx, err, backend.queries)
+		}
+	})
+
+	t.Run("least recently used payments are evicted", func(t *testing.T) {
+		backend := newCountingStore(t)
+		store, _ := NewReplayCacheStore(ctx, backend, ReplayCacheOptions{Size: 2})
+		for i := 0; i < 3; i++ {
+			_ = store.RecordPayment(ctx, verifiedPayment(fmt.Sprintf("sig-%d", i)))
+		}
+		backend.queries = 0
+
+		_, _ = store.HasPaymentBeenProcessed(ctx, "sig-2")
+		_, _ = store.HasPaymentBeenProcessed(ctx, "sig-1")
+		if backend.queries != 0 {
+			t.Errorf("recent payments caused %d queries, want 0", backend.queries)
+		}
+		if processed, _ := store.HasPaymentBeenProcessed(ctx, "sig-0"); !processed || backend.queries != 1 {
+			t.Errorf("evicted payment: processed %v after %d queries, want true after 1", processed, backend.queries)
+		}
+	})
+
+	t.Run("single instance trusts bloom filter misses", func(t *testing.T) {
+		backend := newCountingStore(t)
+		_ = backend.Store.RecordPayment(ctx, verifiedPayment("before-start"))
+		store, err := NewReplayCacheStore(ctx, backend, ReplayCacheOptions{SingleInstance: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		backend.queries = 0
+
+		if processed, _ := store.HasPaymentBeenProcessed(ctx, "never-seen"); processed {
+			t.Error("unseen signature reported processed")
+		}
+		if _, err := store.GetPayment(ctx, "never-seen"); err != ErrNotFound {
+			t.Errorf("GetPayment error = %v, want ErrNotFound", err)
+		}
+		if backend.queries != 0 {
+			t.Errorf("bloom filter misses caused %d queries, want 0", backend.queries)
+		}
+		// Possible hits fall through to the store
+		if processed, _ := store.HasPaymentBeenProcessed(ctx, "before-start"); !processed || backend.queries != 1 {
+			t.Errorf("warmed signature: processed %v after %d queries, want true after 1", processed, backend.queries)
+		}
+	})
+
+	t.Run("single instance requires a listable backend", func(t *testing.T) {
+		opaque := struct{ Store }{newCountingStore(t).Store}
+		if _, err := NewReplayCacheStore(ctx, opaque, ReplayCacheOptions{SingleInstance: true}); err == nil {
+			t.Fatal("expected an error for a backend that cannot list signatures")
+		}
+	})
+}
+
+func TestBloomFilter(t *testing.T) {
+	const n = 10000
+	bloom := newBloomFilter(n, bloomFalsePositiveRate)
+	for i := 0; i < n; i++ {
+		bloom.add(fmt.Sprintf("member-%d", i))
+	}
+	for i := 0; i < n; i++ {
+		if !bloom.mayContain(fmt.Sprintf("member-%d", i)) {
+			t.Fatalf("member-%d missing", i)
+		}
+	}
+	falsePositives := 0
+	for i := 0; i < n; i++ {
+		if bloom.mayContain(fmt.Sprintf("stranger-%d", i)) {
+			falsePositives++
+		}
+	}
+	if rate := float64(falsePositives) / n; rate > 2*bloomFalsePositiveRate {
+		t.Errorf("false positive rate %.4f, want about %.2f", rate, bloomFalsePositiveRate)
+	}
+}
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// SavedCart is a named cart a wallet keeps to re-quote later, such as a wishlist. It holds
+// items and quantities only; prices are locked when the cart is converted into a live quote.
+type SavedCart struct {
+	Wallet     string            `json:"wallet"`
+	Name       string            `json:"name"` // Unique per wallet
+	Items      []SavedCartItem   `json:"items"`
+	CouponCode string            `json:"couponCode,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	CreatedAt  time.Time         `json:"createdAt"`
+	UpdatedAt  time.Time         `json:"updatedAt"`
+}
+
+// SavedCartItem is one resource in a saved cart.
+type SavedCartItem struct {
+	ResourceID string            `json:"resource"`
+	VariantID  string            `json:"variant,omitempty"`
+	Quantity   int64             `json:"quantity"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+}
+
+// validateSavedCart checks a saved cart before it is stored.
+func validateSavedCart(c SavedCart) error {
+	if c.Wallet == "" || c.Name == "" {
+		return fmt.Errorf("saved cart wallet and name required")
+	}
+	for i, item := range c.Items {
+		if item.ResourceID == "" || item.Quantity <= 0 {
+			return fmt.Errorf("saved cart %s: invalid item %d %q x%d", c.Name, i, item.ResourceID, item.Quantity)
+		}
+	}
+	return nil
+}
+
+// saveCartToMap implements SaveSavedCart for the map-backed stores. Callers hold the write lock.
+func saveCartToMap(carts map[string]map[string]SavedCart, c SavedCart, now time.Time) {
+	walletCarts := carts[c.Wallet]
+	if walletCarts == nil {
+		walletCarts = make(map[string]SavedCart)
+		carts[c.Wallet] = walletCarts
+	}
+	c.CreatedAt = now
+	if existing, ok := walletCarts[c.Name]; ok {
+		c.CreatedAt = existing.CreatedAt
+	}
+	c.UpdatedAt = now
+	walletCarts[c.Name] = c
+}
+
+// sortSavedCarts orders saved carts most recently updated first.
+func sortSavedCarts(carts []SavedCart) {
+	sort.Slice(carts, func(i, j int) bool {
+		if !carts[i].UpdatedAt.Equal(carts[j].UpdatedAt) {
+			return carts[i].UpdatedAt.After(carts[j].UpdatedAt)
+		}
+		return carts[i].Name < carts[j].Name
+	})
+}
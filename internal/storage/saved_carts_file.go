@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// SaveSavedCart creates or replaces a wallet's saved cart with the same name.
+func (s *FileStore) SaveSavedCart(_ context.Context, cart SavedCart) error {
+	if err := validateSavedCart(cart); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saveCartToMap(s.savedCarts, cart, time.Now())
+	s.markDirty()
+	return nil
+}
+
+// GetSavedCart returns a wallet's saved cart by name.
+func (s *FileStore) GetSavedCart(_ context.Context, wallet, name string) (SavedCart, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cart, ok := s.savedCarts[wallet][name]
+	if !ok {
+		return SavedCart{}, ErrNotFound
+	}
+	return cart, nil
+}
+
+// ListSavedCarts returns a wallet's saved carts, most recently updated first.
+func (s *FileStore) ListSavedCarts(_ context.Context, wallet string) ([]SavedCart, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	carts := make([]SavedCart, 0, len(s.savedCarts[wallet]))
+	for _, cart := range s.savedCarts[wallet] {
+		carts = append(carts, cart)
+	}
+	sortSavedCarts(carts)
+	return carts, nil
+}
+
+// DeleteSavedCart removes a wallet's saved cart.
+func (s *FileStore) DeleteSavedCart(_ context.Context, wallet, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.savedCarts[wallet][name]; !ok {
+		return ErrNotFound
+	}
+	delete(s.savedCarts[wallet], name)
+	if len(s.savedCarts[wallet]) == 0 {
+		delete(s.savedCarts, wallet)
+	}
+	s.markDirty()
+	return nil
+}
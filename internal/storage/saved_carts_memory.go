@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// SaveSavedCart creates or replaces a wallet's saved cart with the same name.
+func (m *MemoryStore) SaveSavedCart(_ context.Context, cart SavedCart) error {
+	if err := validateSavedCart(cart); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	saveCartToMap(m.savedCarts, cart, time.Now())
+	return nil
+}
+
+// GetSavedCart returns a wallet's saved cart by name.
+func (m *MemoryStore) GetSavedCart(_ context.Context, wallet, name string) (SavedCart, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	cart, ok := m.savedCarts[wallet][name]
+	if !ok {
+		return SavedCart{}, ErrNotFound
+	}
+	return cart, nil
+}
+
+// ListSavedCarts returns a wallet's saved carts, most recently updated first.
+func (m *MemoryStore) ListSavedCarts(_ context.Context, wallet string) ([]SavedCart, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	carts := make([]SavedCart, 0, len(m.savedCarts[wallet]))
+	for _, cart := range m.savedCarts[wallet] {
+		carts = append(carts, cart)
+	}
+	sortSavedCarts(carts)
+	return carts, nil
+}
+
+// DeleteSavedCart removes a wallet's saved cart.
+func (m *MemoryStore) DeleteSavedCart(_ context.Context, wallet, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.savedCarts[wallet][name]; !ok {
+		return ErrNotFound
+	}
+	delete(m.savedCarts[wallet], name)
+	if len(m.savedCarts[wallet]) == 0 {
+		delete(m.savedCarts, wallet)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const savedCartsCollection = "saved_carts"
+
+// savedCartDocument is a saved cart keyed by wallet and name.
+type savedCartDocument struct {
+	ID         string            `bson:"_id"` // wallet/name
+	Wallet     string            `bson:"wallet"`
+	Name       string            `bson:"name"`
+	Items      []SavedCartItem   `bson:"items"`
+	CouponCode string            `bson:"coupon_code,omitempty"`
+	Metadata   map[string]string `bson:"metadata,omitempty"`
+	CreatedAt  time.Time         `bson:"created_at"`
+	UpdatedAt  time.Time         `bson:"updated_at"`
+}
+
+// savedCartID is the document ID of a wallet's saved cart. Wallet addresses are base58, so
+// the separator cannot appear in them.
+func savedCartID(wallet, name string) string {
+	return wallet + "/" + name
+}
+
+// SaveSavedCart creates or replaces a wallet's saved cart with the same name.
+func (s *MongoDBStore) SaveSavedCart(ctx context.Context, cart SavedCart) error {
+	if err := validateSavedCart(cart); err != nil {
+		return err
+	}
+
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"wallet":      cart.Wallet,
+			"name":        cart.Name,
+			"items":       cart.Items,
+			"coupon_code": cart.CouponCode,
+			"metadata":    cart.Metadata,
+			"updated_at":  now,
+		},
+		"$setOnInsert": bson.M{"created_at": now},
+	}
+	_, err := s.db.Collection(savedCartsCollection).UpdateOne(ctx, bson.M{"_id": savedCartID(cart.Wallet, cart.Name)}, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("save saved cart: %w", err)
+	}
+	return nil
+}
+
+// GetSavedCart returns a wallet's saved cart by name.
+func (s *MongoDBStore) GetSavedCart(ctx context.Context, wallet, name string) (SavedCart, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	var doc savedCartDocument
+	err := s.db.Collection(savedCartsCollection).FindOne(ctx, bson.M{"_id": savedCartID(wallet, name)}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return SavedCart{}, ErrNotFound
+	}
+	if err != nil {
+		return SavedCart{}, fmt.Errorf("get saved cart: %w", err)
+	}
+	return doc.savedCart(), nil
+}
+
+// ListSavedCarts returns a wallet's saved carts, most recently updated first.
+func (s *MongoDBStore) ListSavedCarts(ctx context.Context, wallet string) ([]SavedCart, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "updated_at", Value: -1}, {Key: "name", Value: 1}})
+	cursor, err := s.db.Collection(savedCartsCollection).Find(ctx, bson.M{"wallet": wallet}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("list saved carts: %w", err)
+	}
+	var docs []savedCartDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("decode saved carts: %w", err)
+	}
+
+	carts := make([]SavedCart, 0, len(docs))
+	for _, doc := range docs {
+		carts = append(carts, doc.savedCart())
+	}
+	return carts, nil
+}
+
+// DeleteSavedCart removes a wallet's saved cart.
+func (s *MongoDBStore) DeleteSavedCart(ctx context.Context, wallet, name string) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.Collection(savedCartsCollection).DeleteOne(ctx, bson.M{"_id": savedCartID(wallet, name)})
+	if err != nil {
+		return fmt.Errorf("delete saved cart: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (d savedCartDocument) savedCart() SavedCart {
+	return SavedCart{
+		Wallet:     d.Wallet,
+		Name:       d.Name,
+		Items:      d.Items,
+		CouponCode: d.CouponCode,
+		Metadata:   d.Metadata,
+		CreatedAt:  d.CreatedAt,
+		UpdatedAt:  d.UpdatedAt,
+	}
+}
@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SaveSavedCart creates or replaces a wallet's saved cart with the same name.
+func (s *PostgresStore) SaveSavedCart(ctx context.Context, cart SavedCart) error {
+	if err := validateSavedCart(cart); err != nil {
+		return err
+	}
+
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	itemsJSON, err := json.Marshal(cart.Items)
+	if err != nil {
+		return fmt.Errorf("marshal items: %w", err)
+	}
+	metadataJSON, err := json.Marshal(cart.Metadata)
+	if err != nil {
+		return fmt.Errorf("marshal metadata: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO %s (wallet, name, items, coupon_code, metadata, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+		ON CONFLICT (wallet, name) DO UPDATE SET
+			items = EXCLUDED.items,
+			coupon_code = EXCLUDED.coupon_code,
+			metadata = EXCLUDED.metadata,
+			updated_at = EXCLUDED.updated_at
+	`, s.savedCartsTableName)
+	if _, err := s.db.ExecContext(ctx, query, cart.Wallet, cart.Name, itemsJSON, cart.CouponCode, metadataJSON, time.Now().UTC()); err != nil {
+		return fmt.Errorf("save saved cart: %w", err)
+	}
+	return nil
+}
+
+// GetSavedCart returns a wallet's saved cart by name.
+func (s *PostgresStore) GetSavedCart(ctx context.Context, wallet, name string) (SavedCart, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`
+		SELECT wallet, name, items, coupon_code, metadata, created_at, updated_at
+		FROM %s WHERE wallet = $1 AND name = $2
+	`, s.savedCartsTableName)
+	cart, err := scanSavedCart(s.db.QueryRowContext(ctx, query, wallet, name))
+	if errors.Is(err, sql.ErrNoRows) {
+		return SavedCart{}, ErrNotFound
+	}
+	return cart, err
+}
+
+// ListSavedCarts returns a wallet's saved carts, most recently updated first.
+func (s *PostgresStore) ListSavedCarts(ctx context.Context, wallet string) ([]SavedCart, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`
+		SELECT wallet, name, items, coupon_code, metadata, created_at, updated_at
+		FROM %s WHERE wallet = $1
+		ORDER BY updated_at DESC, name
+	`, s.savedCartsTableName)
+	rows, err := s.db.QueryContext(ctx, query, wallet)
+	if err != nil {
+		return nil, fmt.Errorf("list saved carts: %w", err)
+	}
+	defer rows.Close()
+
+	carts := []SavedCart{}
+	for rows.Next() {
+		cart, err := scanSavedCart(rows)
+		if err != nil {
+			return nil, err
+		}
+		carts = append(carts, cart)
+	}
+	return carts, rows.Err()
+}
+
+// DeleteSavedCart removes a wallet's saved cart.
+func (s *PostgresStore) DeleteSavedCart(ctx context.Context, wallet, name string) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`DELETE FROM %s WHERE wallet = $1 AND name = $2`, s.savedCartsTableName)
+	result, err := s.db.ExecContext(ctx, query, wallet, name)
+	if err != nil {
+		return fmt.Errorf("delete saved cart: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// scanSavedCart reads a saved cart row selected as wallet, name, items, coupon_code, metadata,
+// created_at, updated_at.
+func scanSavedCart(row interface{ Scan(...any) error }) (SavedCart, error) {
+	var cart SavedCart
+	var itemsJSON, metadataJSON []byte
+	if err := row.Scan(&cart.Wallet, &cart.Name, &itemsJSON, &cart.CouponCode, &metadataJSON, &cart.CreatedAt, &cart.UpdatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return SavedCart{}, err
+		}
+		return SavedCart{}, fmt.Errorf("scan saved cart: %w", err)
+	}
+	if err := json.Unmarshal(itemsJSON, &cart.Items); err != nil {
+		return SavedCart{}, fmt.Errorf("unmarshal items: %w", err)
+	}
+	if len(metadataJSON) > 0 {
+		if err := json.Unmarshal(metadataJSON, &cart.Metadata); err != nil {
+			return SavedCart{}, fmt.Errorf("unmarshal metadata: %w", err)
+		}
+	}
+	return cart, nil
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestSavedCarts(t *testing.T) {
+	backends := []struct {
+		name string
+		open func(t *testing.T, path string) Store
+	}{
+		{name: "memory", open: func(t *testing.T, _ string) Store { return NewMemoryStore() }},
+		{
+			name: "file",
+			open: func(t *testing.T, path string) Store {
+				store, err := NewFileStore(path)
+				if err != nil {
+					t.Fatalf("NewFileStore: %v", err)
+				}
+				return store
+			},
+		},
+	}
+
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "store.json")
+			store := backend.open(t, path)
+			ctx := context.Background()
+
+			saves := []struct {
+				name    string
+				cart    SavedCart
+				wantErr bool
+			}{
+				{name: "wishlist", cart: SavedCart{Wallet: "alice", Name: "wishlist", Items: []SavedCartItem{{ResourceID: "tee", Quantity: 1}}}},
+				{name: "second cart", cart: SavedCart{Wallet: "alice", Name: "gifts", Items: []SavedCartItem{{ResourceID: "mug", Quantity: 2}}, CouponCode: "SAVE10"}},
+				{name: "other wallet", cart: SavedCart{Wallet: "bob", Name: "wishlist", Items: []SavedCartItem{{ResourceID: "ebook", Quantity: 1}}}},
+				{name: "replace", cart: SavedCart{Wallet: "alice", Name: "wishlist", Items: []SavedCartItem{{ResourceID: "tee", Quantity: 3}}}},
+				{name: "missing name", cart: SavedCart{Wallet: "alice", Items: []SavedCartItem{{ResourceID: "tee", Quantity: 1}}}, wantErr: true},
+				{name: "zero quantity", cart: SavedCart{Wallet: "alice", Name: "bad", Items: []SavedCartItem{{ResourceID: "tee"}}}, wantErr: true},
+			}
+			for _, save := range saves {
+				if err := store.SaveSavedCart(ctx, save.cart); (err != nil) != save.wantErr {
+					t.Fatalf("%s: SaveSavedCart err = %v, wantErr %v", save.name, err, save.wantErr)
+				}
+			}
+
+			if backend.name == "file" {
+				_ = store.Close()
+				store = backend.open(t, path)
+			}
+			defer store.Close()
+
+			carts, err := store.ListSavedCarts(ctx, "alice")
+			if err != nil {
+				t.Fatalf("ListSavedCarts: %v", err)
+			}
+			if len(carts) != 2 || carts[0].Name != "wishlist" || carts[1].Name != "gifts" {
+				t.Fatalf("alice's carts = %+v, want wishlist then gifts", carts)
+			}
+			if carts[0].Items[0].Quantity != 3 || carts[0].UpdatedAt.Before(carts[0].CreatedAt) {
+				t.Errorf("replaced cart = %+v", carts[0])
+			}
+			if cart, err := store.GetSavedCart(ctx, "bob", "wishlist"); err != nil || cart.Items[0].ResourceID != "ebook" {
+				t.Errorf("GetSavedCart bob = %+v, %v", cart, err)
+			}
+
+			if err := store.DeleteSavedCart(ctx, "alice", "gifts"); err != nil {
+				t.Fatalf("DeleteSavedCart: %v", err)
+			}
+			if _, err := store.GetSavedCart(ctx, "alice", "gifts"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("GetSavedCart after delete: err = %v, want ErrNotFound", err)
+			}
+			if err := store.DeleteSavedCart(ctx, "alice", "gifts"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("DeleteSavedCart twice: err = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/money"
 )
 
 // ErrNotFound is returned when a requested entity is missing from the store.
@@ -95,6 +96,135 @@ type Store interface {
 	// DeleteWebhook removes webhook from queue (admin operation)
 	DeleteWebhook(ctx context.Context, webhookID string) error
 
+	// Idempotency key persistence so retried mutating requests replay the original response
+	// SaveIdempotencyKey stores (or replaces) the response recorded for a key
+	SaveIdempotencyKey(ctx context.Context, record IdempotencyRecord) error
+	// GetIdempotencyKey retrieves the record for a key (ErrNotFound if missing or expired)
+	GetIdempotencyKey(ctx context.Context, key string) (IdempotencyRecord, error)
+	// DeleteIdempotencyKey removes the record for a key
+	DeleteIdempotencyKey(ctx context.Context, key string) error
+	// CleanupExpiredIdempotencyKeys deletes expired records (returns count of deleted records)
+	CleanupExpiredIdempotencyKeys(ctx context.Context) (int64, error)
+
+	// Inventory for resources with limited stock; resources without a stock level are unlimited
+	// SetStock sets the units on hand for a resource, starting to track it if needed
+	SetStock(ctx context.Context, resourceID string, quantity int64) error
+	// GetStock returns a resource's stock level (ErrNotFound if the resource is untracked)
+	GetStock(ctx context.Context, resourceID string) (StockLevel, error)
+	// DeleteStock stops tracking a resource so it can be sold without limit
+	DeleteStock(ctx context.Context, resourceID string) error
+	// ReserveStock holds units for a quote until it expires, replacing any reservation with the same ID.
+	// Returns ErrOutOfStock (leaving the earlier reservation in place) if any tracked resource is short
+	ReserveStock(ctx context.Context, reservation StockReservation) error
+	// CommitStockReservation removes a reservation's units from stock once its quote is paid. If the
+	// reservation has lapsed or been cleaned up, the sold items are taken out of stock directly
+	CommitStockReservation(ctx context.Context, reservationID string, items map[string]int64) error
+	// ReleaseStockReservation drops a reservation, returning its units to stock
+	ReleaseStockReservation(ctx context.Context, reservationID string) error
+
+	// Split cart payments: partial x402 payments from several wallets toward one cart total
+	// AddCartContribution records a contribution and returns the cart's paid amount. Recording a
+	// signature twice is a no-op; returns ErrContributionExceedsTotal (recording nothing) if the
+	// contribution would take the paid amount past total
+	AddCartContribution(ctx context.Context, contribution CartContribution, total money.Money) (money.Money, error)
+	// ListCartContributions returns a cart's contributions in the order they were paid
+	ListCartContributions(ctx context.Context, cartID string) ([]CartContribution, error)
+
+	// Saved carts: named carts (and wishlists) a wallet keeps to re-quote later
+	// SaveSavedCart creates or replaces the wallet's saved cart with the same name, keeping its CreatedAt
+	SaveSavedCart(ctx context.Context, cart SavedCart) error
+	// GetSavedCart returns a wallet's saved cart by name (ErrNotFound if missing)
+	GetSavedCart(ctx context.Context, wallet, name string) (SavedCart, error)
+	// ListSavedCarts returns a wallet's saved carts, most recently updated first
+	ListSavedCarts(ctx context.Context, wallet string) ([]SavedCart, error)
+	// DeleteSavedCart removes a wallet's saved cart (ErrNotFound if missing)
+	DeleteSavedCart(ctx context.Context, wallet, name string) error
+
+	// Gift cards: remaining balances of stored-value coupons
+	// GetGiftCard returns a gift card's balance (ErrNotFound if it was never spent from)
+	GetGiftCard(ctx context.Context, code string) (GiftCard, error)
+	// AdjustGiftCard adds delta (negative to spend, positive to credit back) to a gift card's
+	// balance, which starts at faceValue on first use. Returns ErrGiftCardBalance (changing
+	// nothing) if the balance would go below zero
+	AdjustGiftCard(ctx context.Context, code string, faceValue, delta money.Money) (GiftCard, error)
+
+	// Meter accounts: prepaid balances charged per metered API call
+	// GetMeterAccount returns a meter account (ErrNotFound if it was never credited)
+	GetMeterAccount(ctx context.Context, account string) (MeterAccount, error)
+	// CreditMeterAccount adds amount to a meter account's balance, creating the account in
+	// amount's asset on first credit
+	CreditMeterAccount(ctx context.Context, account string, amount money.Money) (MeterAccount, error)
+	// ChargeMeterAccount moves amount from a meter account's balance to its unsettled usage and
+	// counts a call. Returns ErrMeterBalance (changing nothing) if the balance would go below zero
+	ChargeMeterAccount(ctx context.Context, account string, amount money.Money) (MeterAccount, error)
+	// ListUnsettledMeterAccounts returns up to limit accounts with unsettled calls, by account
+	ListUnsettledMeterAccounts(ctx context.Context, limit int) ([]MeterAccount, error)
+	// SettleMeterAccount subtracts amount and calls from a meter account's unsettled usage once
+	// a settlement records them; negative values put usage back
+	SettleMeterAccount(ctx context.Context, account string, amount money.Money, calls int64) error
+
+	// Referrals: purchases attributed to referral coupon referrers
+	// RecordReferralConversion records a conversion (no-op if the referrer already has one for
+	// the same signature)
+	RecordReferralConversion(ctx context.Context, conversion ReferralConversion) error
+	// ListReferralConversions returns a referrer's conversions, most recent first
+	ListReferralConversions(ctx context.Context, referrerWallet string) ([]ReferralConversion, error)
+
+	// Coupon redemptions: per-wallet (or per-email) coupon uses
+	// RecordCouponRedemption records a use of a coupon (no-op if already recorded for the
+	// signature). Returns ErrCouponRedemptionLimit, recording nothing, if the redeemer has
+	// already used the coupon limit times; limit <= 0 means unlimited
+	RecordCouponRedemption(ctx context.Context, redemption CouponRedemption, limit int) error
+	// CountCouponRedemptions returns how many times redeemer has used a coupon
+	CountCouponRedemptions(ctx context.Context, code, redeemer string) (int, error)
+
+	// Coupon analytics: payments and cart quotes whose metadata lists applied coupons
+	// ListCouponPayments returns the payments made with coupons in [since, until), oldest first
+	ListCouponPayments(ctx context.Context, since, until time.Time) ([]PaymentTransaction, error)
+	// ListCouponCartQuotes returns the cart quotes priced with coupons in [since, until), oldest
+	// first, including expired quotes not yet cleaned up
+	ListCouponCartQuotes(ctx context.Context, since, until time.Time) ([]CartQuote, error)
+
+	// Customers: the emails, wallets, and Stripe customer IDs one payer is known by
+	// LinkCustomerIdentities links identities to one customer, creating it if none of them is
+	// known and merging the customers they belong to into the oldest otherwise
+	LinkCustomerIdentities(ctx context.Context, identities []CustomerIdentity) (Customer, error)
+	// GetCustomer returns a customer by ID (ErrNotFound if missing)
+	GetCustomer(ctx context.Context, id string) (Customer, error)
+	// FindCustomer returns the customer an identity is linked to (ErrNotFound if unlinked)
+	FindCustomer(ctx context.Context, kind, value string) (Customer, error)
+	// ListPaymentsByPayer returns the payments made by any of payers (the wallet, or the email
+	// for Stripe payments, recorded on them), most recent first; limit <= 0 returns all
+	ListPaymentsByPayer(ctx context.Context, payers []string, limit int) ([]PaymentTransaction, error)
+	// SumPayments totals the payments recorded in [since, until) per asset, ordered by asset code
+	SumPayments(ctx context.Context, since, until time.Time) ([]PaymentTotal, error)
+
+	// Refund audit: decisions on refund requests, kept after denied requests are deleted
+	// RecordRefundAudit appends an entry to a refund request's audit trail
+	RecordRefundAudit(ctx context.Context, entry RefundAuditEntry) error
+	// ListRefundAudit returns a refund request's audit trail, oldest first
+	ListRefundAudit(ctx context.Context, refundID string) ([]RefundAuditEntry, error)
+
+	// Admin audit: an append-only log of admin actions (no updates or deletes)
+	// RecordAdminAudit appends an entry to the admin audit log
+	RecordAdminAudit(ctx context.Context, entry AdminAuditEntry) error
+	// ListAdminAudit returns the entries matching filter, newest first
+	ListAdminAudit(ctx context.Context, filter AdminAuditFilter) ([]AdminAuditEntry, error)
+
+	// Webhook delivery log: one record per attempt to deliver a webhook
+	// RecordWebhookDelivery appends a delivery attempt to the log
+	RecordWebhookDelivery(ctx context.Context, delivery WebhookDelivery) error
+	// ListWebhookDeliveries returns the attempts matching filter, newest first
+	ListWebhookDeliveries(ctx context.Context, filter WebhookDeliveryFilter) ([]WebhookDelivery, error)
+
+	// Access lists: wallets and IP ranges on the allow or deny list
+	// SaveAccessRule adds a wallet or IP range to a list, replacing its rule if it is on one
+	SaveAccessRule(ctx context.Context, rule AccessRule) (AccessRule, error)
+	// ListAccessRules returns every access rule, ordered by kind and value
+	ListAccessRules(ctx context.Context) ([]AccessRule, error)
+	// DeleteAccessRule removes a wallet or IP range from its list (ErrNotFound if on none)
+	DeleteAccessRule(ctx context.Context, kind, value string) error
+
 	Close() error
 }
 
@@ -116,6 +246,7 @@ type StoreConfig struct {
 	CartQuotesTableName          string // Default: "cart_quotes"
 	RefundQuotesTableName        string // Default: "refund_quotes"
 	WebhookQueueTableName        string // Default: "webhook_queue"
+	IdempotencyKeysTableName     string // Default: "idempotency_keys"
 }
 
 // NewStore creates a Store instance based on the provided configuration.
@@ -155,6 +286,7 @@ func NewStoreWithDB(cfg StoreConfig, sharedDB *sql.DB) (Store, error) {
 				cfg.CartQuotesTableName,
 				cfg.RefundQuotesTableName,
 				cfg.WebhookQueueTableName,
+				cfg.IdempotencyKeysTableName,
 			), nil
 		}
 		if cfg.MongoDBURL != "" {
@@ -194,6 +326,7 @@ func NewStoreWithDB(cfg StoreConfig, sharedDB *sql.DB) (Store, error) {
 			cfg.CartQuotesTableName,
 			cfg.RefundQuotesTableName,
 			cfg.WebhookQueueTableName,
+			cfg.IdempotencyKeysTableName,
 		), nil
 	case "mongodb":
 		if cfg.MongoDBURL == "" {
@@ -216,12 +349,27 @@ func NewStoreWithDB(cfg StoreConfig, sharedDB *sql.DB) (Store, error) {
 // MemoryStore is an in-memory Store implementation suitable for tests and single-instance deployments.
 type MemoryStore struct {
 	mu                       sync.RWMutex
-	cartQuotes               map[string]CartQuote          // cartID -> quote
-	refundQuotes             map[string]RefundQuote        // refundID -> quote
-	refundQuotesByPurchaseID map[string]string             // originalPurchaseID -> refundID (secondary index for O(1) lookups)
-	paymentTransactions      map[string]PaymentTransaction // signature -> transaction (globally unique)
-	adminNonces              map[string]AdminNonce         // nonceID -> nonce (one-time use)
-	webhookQueue             map[string]PendingWebhook     // webhookID -> webhook (persistent delivery queue)
+	cartQuotes               map[string]CartQuote            // cartID -> quote
+	refundQuotes             map[string]RefundQuote          // refundID -> quote
+	refundQuotesByPurchaseID map[string]string               // originalPurchaseID -> refundID (secondary index for O(1) lookups)
+	paymentTransactions      map[string]PaymentTransaction   // signature -> transaction (globally unique)
+	adminNonces              map[string]AdminNonce           // nonceID -> nonce (one-time use)
+	webhookQueue             map[string]PendingWebhook       // webhookID -> webhook (persistent delivery queue)
+	idempotencyKeys          map[string]IdempotencyRecord    // scoped key -> recorded response
+	stock                    map[string]StockLevel           // resourceID -> units on hand (tracked resources only)
+	stockReservations        map[string]StockReservation     // quote ID -> units held for it
+	cartContributions        map[string][]CartContribution   // cartID -> partial payments toward it
+	savedCarts               map[string]map[string]SavedCart // wallet -> name -> saved cart
+	giftCards                map[string]GiftCard             // code -> remaining balance
+	meterAccounts            map[string]MeterAccount         // account -> prepaid balance and unsettled usage
+	referralConversions      map[string][]ReferralConversion // referrer wallet -> conversions
+	couponRedemptions        map[string][]CouponRedemption   // <code>/<redeemer> -> uses
+	customers                map[string]Customer             // customer ID -> customer
+	customerIndex            map[string]string               // <kind>:<value> -> customer ID
+	refundAudit              map[string][]RefundAuditEntry   // refundID -> audit trail
+	adminAudit               []AdminAuditEntry               // Append-only admin action log
+	webhookDeliveries        []WebhookDelivery               // Most recent webhook delivery attempts
+	accessRules              map[string]AccessRule           // <kind>:<value> -> allow or deny rule
 	stopCleanup              chan struct{}
 	cleanupDone              chan struct{}
 }
@@ -235,6 +383,19 @@ func NewMemoryStore() *MemoryStore {
 		paymentTransactions:      make(map[string]PaymentTransaction),
 		adminNonces:              make(map[string]AdminNonce),
 		webhookQueue:             make(map[string]PendingWebhook),
+		idempotencyKeys:          make(map[string]IdempotencyRecord),
+		stock:                    make(map[string]StockLevel),
+		stockReservations:        make(map[string]StockReservation),
+		cartContributions:        make(map[string][]CartContribution),
+		savedCarts:               make(map[string]map[string]SavedCart),
+		giftCards:                make(map[string]GiftCard),
+		meterAccounts:            make(map[string]MeterAccount),
+		referralConversions:      make(map[string][]ReferralConversion),
+		couponRedemptions:        make(map[string][]CouponRedemption),
+		customers:                make(map[string]Customer),
+		customerIndex:            make(map[string]string),
+		refundAudit:              make(map[string][]RefundAuditEntry),
+		accessRules:              make(map[string]AccessRule),
 		stopCleanup:              make(chan struct{}),
 		cleanupDone:              make(chan struct{}),
 	}
@@ -242,7 +403,8 @@ func NewMemoryStore() *MemoryStore {
 	return m
 }
 
-// cleanupExpiredAccess runs periodically and removes expired cart quotes, refund quotes, and admin nonces.
+// cleanupExpiredAccess runs periodically and removes expired cart quotes, refund quotes, admin nonces,
+// idempotency records, and stock reservations.
 func (m *MemoryStore) cleanupExpiredAccess() {
 	ticker := time.NewTicker(CleanupInterval)
 	defer ticker.Stop()
@@ -256,6 +418,8 @@ func (m *MemoryStore) cleanupExpiredAccess() {
 			m.removeExpiredCarts()
 			m.removeExpiredRefunds()
 			m.removeExpiredNonces()
+			_, _ = m.CleanupExpiredIdempotencyKeys(context.Background())
+			m.removeExpiredStockReservations()
 		}
 	}
 }
@@ -488,6 +652,17 @@ func (m *MemoryStore) HasPaymentBeenProcessed(_ context.Context, signature strin
 	return exists, nil
 }
 
+// PaymentSignatures calls fn with every recorded payment signature.
+func (m *MemoryStore) PaymentSignatures(_ context.Context, fn func(signature string)) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for signature := range m.paymentTransactions {
+		fn(signature)
+	}
+	return nil
+}
+
 // GetPayment retrieves a payment transaction by signature.
 // Returns the original payment showing which resource it was used for.
 func (m *MemoryStore) GetPayment(_ context.Context, signature string) (PaymentTransaction, error) {
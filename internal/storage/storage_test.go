@@ -1,8 +1,12 @@
 package storage
 
 import (
+	"context"
+	"path/filepath"
 	"testing"
 	"time"
+
+	"github.com/CedrosPay/server/internal/money"
 )
 
 func TestMemoryStore_Stop(t *testing.T) {
@@ -34,3 +38,31 @@ func TestPtrTime(t *testing.T) {
 		t.Errorf("ptrTime() = %v, want %v", *ptr, now)
 	}
 }
+
+func TestFlushFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.json")
+	store, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	if err := store.RecordPayment(ctx, PaymentTransaction{Signature: "sig-1", ResourceID: "demo", Amount: money.New(money.MustGetAsset("USDC"), 1000), CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("RecordPayment: %v", err)
+	}
+	// Through the instrumented wrapper, as the app holds it
+	if err := Flush(NewInstrumentedStore(store, InstrumentOptions{})); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	// Read the file while the original store is still open, before its periodic flush
+	reopened, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	defer reopened.Close()
+	if _, err := reopened.GetPayment(ctx, "sig-1"); err != nil {
+		t.Fatalf("payment not flushed: %v", err)
+	}
+}
@@ -40,3 +40,17 @@ func validateAndPrepareRefundQuote(quote *RefundQuote, ttl time.Duration) error
 	}
 	return nil
 }
+
+// validateAndPrepareIdempotencyRecord validates required fields and sets default timestamps.
+func validateAndPrepareIdempotencyRecord(record *IdempotencyRecord) error {
+	if record.Key == "" {
+		return fmt.Errorf("idempotency record requires key")
+	}
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now()
+	}
+	if record.ExpiresAt.IsZero() {
+		record.ExpiresAt = record.CreatedAt.Add(24 * time.Hour)
+	}
+	return nil
+}
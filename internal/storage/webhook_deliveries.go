@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxWebhookDeliveryResponse is how much of a receiver's response body a delivery record
+// keeps, in bytes.
+const MaxWebhookDeliveryResponse = 1024
+
+// Limits on webhook delivery listings and on the in-process delivery log.
+const (
+	defaultWebhookDeliveryLimit = 100
+	maxWebhookDeliveryLimit     = 1000
+	// The memory and file stores keep only the most recent attempts
+	maxRetainedWebhookDeliveries = 10000
+)
+
+// WebhookDelivery records one attempt to deliver a webhook, successful or not.
+type WebhookDelivery struct {
+	EventID      string    `json:"eventId,omitempty"`      // Event idempotency key (direct deliveries)
+	WebhookID    string    `json:"webhookId,omitempty"`    // Queue entry (queued deliveries)
+	EventType    string    `json:"eventType"`              // "payment", "payment_failed", "refund", "subscription", or "refund_request"
+	URL          string    `json:"url"`                    // Destination URL or SQS/SNS ARN
+	Attempt      int       `json:"attempt"`                // 1 for the first attempt
+	StatusCode   int       `json:"statusCode"`             // Receiver's HTTP status; 0 when there was no response
+	LatencyMs    int64     `json:"latencyMs"`              // Time until the response (or error)
+	ResponseBody string    `json:"responseBody,omitempty"` // First MaxWebhookDeliveryResponse bytes of the response
+	Error        string    `json:"error,omitempty"`        // Why the attempt failed
+	Success      bool      `json:"success"`
+	AttemptedAt  time.Time `json:"attemptedAt"`
+}
+
+// WebhookDeliveryFilter narrows a webhook delivery listing. Zero fields match everything.
+type WebhookDeliveryFilter struct {
+	URL       string
+	EventType string
+	EventID   string
+	WebhookID string
+	Success   *bool     // Only successful (true) or failed (false) attempts
+	Since     time.Time // Inclusive
+	Until     time.Time // Exclusive
+	Limit     int       // Default 100, max 1000
+}
+
+// validateAndPrepareWebhookDelivery checks a delivery record and truncates its response body.
+func validateAndPrepareWebhookDelivery(d *WebhookDelivery) error {
+	if d.URL == "" || d.EventType == "" {
+		return fmt.Errorf("webhook delivery url and event type required")
+	}
+	if d.AttemptedAt.IsZero() {
+		return fmt.Errorf("webhook delivery to %s: attempt time required", d.URL)
+	}
+	if len(d.ResponseBody) > MaxWebhookDeliveryResponse {
+		d.ResponseBody = strings.ToValidUTF8(d.ResponseBody[:MaxWebhookDeliveryResponse], "")
+	}
+	return nil
+}
+
+// limit returns the filter's effective record limit.
+func (f WebhookDeliveryFilter) limit() int {
+	if f.Limit <= 0 {
+		return defaultWebhookDeliveryLimit
+	}
+	return min(f.Limit, maxWebhookDeliveryLimit)
+}
+
+// matches reports whether a delivery passes the filter.
+func (f WebhookDeliveryFilter) matches(d WebhookDelivery) bool {
+	switch {
+	case f.URL != "" && d.URL != f.URL:
+		return false
+	case f.EventType != "" && d.EventType != f.EventType:
+		return false
+	case f.EventID != "" && d.EventID != f.EventID:
+		return false
+	case f.WebhookID != "" && d.WebhookID != f.WebhookID:
+		return false
+	case f.Success != nil && d.Success != *f.Success:
+		return false
+	case !f.Since.IsZero() && d.AttemptedAt.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && !d.AttemptedAt.Before(f.Until):
+		return false
+	}
+	return true
+}
+
+// appendWebhookDelivery adds d to an in-process delivery log, dropping the oldest records
+// beyond maxRetainedWebhookDeliveries.
+func appendWebhookDelivery(log []WebhookDelivery, d WebhookDelivery) []WebhookDelivery {
+	log = append(log, d)
+	if excess := len(log) - maxRetainedWebhookDeliveries; excess > 0 {
+		log = log[excess:]
+	}
+	return log
+}
+
+// filterWebhookDeliveries returns the deliveries passing the filter, newest first.
+func filterWebhookDeliveries(deliveries []WebhookDelivery, filter WebhookDeliveryFilter) []WebhookDelivery {
+	var matched []WebhookDelivery
+	for _, d := range deliveries {
+		if filter.matches(d) {
+			matched = append(matched, d)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].AttemptedAt.After(matched[j].AttemptedAt)
+	})
+	if len(matched) > filter.limit() {
+		matched = matched[:filter.limit()]
+	}
+	return matched
+}
@@ -0,0 +1,25 @@
+package storage
+
+import "context"
+
+// RecordWebhookDelivery appends a delivery attempt to the webhook delivery log.
+func (s *FileStore) RecordWebhookDelivery(_ context.Context, delivery WebhookDelivery) error {
+	if err := validateAndPrepareWebhookDelivery(&delivery); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.webhookDeliveries = appendWebhookDelivery(s.webhookDeliveries, delivery)
+	s.markDirty()
+	return nil
+}
+
+// ListWebhookDeliveries returns the delivery attempts matching filter, newest first.
+func (s *FileStore) ListWebhookDeliveries(_ context.Context, filter WebhookDeliveryFilter) ([]WebhookDelivery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return filterWebhookDeliveries(s.webhookDeliveries, filter), nil
+}
@@ -0,0 +1,24 @@
+package storage
+
+import "context"
+
+// RecordWebhookDelivery appends a delivery attempt to the webhook delivery log.
+func (m *MemoryStore) RecordWebhookDelivery(_ context.Context, delivery WebhookDelivery) error {
+	if err := validateAndPrepareWebhookDelivery(&delivery); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.webhookDeliveries = appendWebhookDelivery(m.webhookDeliveries, delivery)
+	return nil
+}
+
+// ListWebhookDeliveries returns the delivery attempts matching filter, newest first.
+func (m *MemoryStore) ListWebhookDeliveries(_ context.Context, filter WebhookDeliveryFilter) ([]WebhookDelivery, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return filterWebhookDeliveries(m.webhookDeliveries, filter), nil
+}
@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const webhookDeliveriesCollection = "webhook_deliveries"
+
+// webhookDeliveryDocument is one attempt in the webhook delivery log.
+type webhookDeliveryDocument struct {
+	EventID      string    `bson:"event_id"`
+	WebhookID    string    `bson:"webhook_id"`
+	EventType    string    `bson:"event_type"`
+	URL          string    `bson:"url"`
+	Attempt      int       `bson:"attempt"`
+	StatusCode   int       `bson:"status_code"`
+	LatencyMs    int64     `bson:"latency_ms"`
+	ResponseBody string    `bson:"response_body"`
+	Error        string    `bson:"error"`
+	Success      bool      `bson:"success"`
+	AttemptedAt  time.Time `bson:"attempted_at"`
+}
+
+// RecordWebhookDelivery appends a delivery attempt to the webhook delivery log.
+func (s *MongoDBStore) RecordWebhookDelivery(ctx context.Context, delivery WebhookDelivery) error {
+	if err := validateAndPrepareWebhookDelivery(&delivery); err != nil {
+		return err
+	}
+
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.Collection(webhookDeliveriesCollection).InsertOne(ctx, webhookDeliveryDocument(delivery))
+	if err != nil {
+		return fmt.Errorf("record webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// ListWebhookDeliveries returns the delivery attempts matching filter, newest first.
+func (s *MongoDBStore) ListWebhookDeliveries(ctx context.Context, filter WebhookDeliveryFilter) ([]WebhookDelivery, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := bson.M{}
+	for field, value := range map[string]string{
+		"url":        filter.URL,
+		"event_type": filter.EventType,
+		"event_id":   filter.EventID,
+		"webhook_id": filter.WebhookID,
+	} {
+		if value != "" {
+			query[field] = value
+		}
+	}
+	if filter.Success != nil {
+		query["success"] = *filter.Success
+	}
+	attempted := bson.M{}
+	if !filter.Since.IsZero() {
+		attempted["$gte"] = filter.Since
+	}
+	if !filter.Until.IsZero() {
+		attempted["$lt"] = filter.Until
+	}
+	if len(attempted) > 0 {
+		query["attempted_at"] = attempted
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "attempted_at", Value: -1}}).
+		SetLimit(int64(filter.limit()))
+	cursor, err := s.db.Collection(webhookDeliveriesCollection).Find(ctx, query, opts)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook deliveries: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var docs []webhookDeliveryDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("decode webhook deliveries: %w", err)
+	}
+	deliveries := make([]WebhookDelivery, 0, len(docs))
+	for _, doc := range docs {
+		deliveries = append(deliveries, WebhookDelivery(doc))
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// RecordWebhookDelivery appends a delivery attempt to the webhook delivery log.
+func (s *PostgresStore) RecordWebhookDelivery(ctx context.Context, delivery WebhookDelivery) error {
+	if err := validateAndPrepareWebhookDelivery(&delivery); err != nil {
+		return err
+	}
+
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf(`
+		INSERT INTO %s (event_id, webhook_id, event_type, url, attempt, status_code, latency_ms, response_body, error, success, attempted_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`, s.webhookDeliveriesTableName)
+	_, err := s.db.ExecContext(ctx, query,
+		delivery.EventID, delivery.WebhookID, delivery.EventType, delivery.URL, delivery.Attempt,
+		delivery.StatusCode, delivery.LatencyMs, delivery.ResponseBody, delivery.Error,
+		delivery.Success, delivery.AttemptedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("record webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// ListWebhookDeliveries returns the delivery attempts matching filter, newest first.
+func (s *PostgresStore) ListWebhookDeliveries(ctx context.Context, filter WebhookDeliveryFilter) ([]WebhookDelivery, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	var conditions []string
+	var args []any
+	addCondition := func(clause string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(clause, len(args)))
+	}
+	if filter.URL != "" {
+		addCondition("url = $%d", filter.URL)
+	}
+	if filter.EventType != "" {
+		addCondition("event_type = $%d", filter.EventType)
+	}
+	if filter.EventID != "" {
+		addCondition("event_id = $%d", filter.EventID)
+	}
+	if filter.WebhookID != "" {
+		addCondition("webhook_id = $%d", filter.WebhookID)
+	}
+	if filter.Success != nil {
+		addCondition("success = $%d", *filter.Success)
+	}
+	if !filter.Since.IsZero() {
+		addCondition("attempted_at >= $%d", filter.Since.UTC())
+	}
+	if !filter.Until.IsZero() {
+		addCondition("attempted_at < $%d", filter.Until.UTC())
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.limit())
+
+	query := fmt.Sprintf(`
+		SELECT event_id, webhook_id, event_type, url, attempt, status_code, latency_ms, response_body, error, success, attempted_at
+		FROM %s %s
+		ORDER BY attempted_at DESC, id DESC
+		LIMIT $%d
+	`, s.webhookDeliveriesTableName, where, len(args))
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []WebhookDelivery
+	for rows.Next() {
+		var d WebhookDelivery
+		if err := rows.Scan(&d.EventID, &d.WebhookID, &d.EventType, &d.URL, &d.Attempt, &d.StatusCode,
+			&d.LatencyMs, &d.ResponseBody, &d.Error, &d.Success, &d.AttemptedAt); err != nil {
+			return nil, fmt.Errorf("scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
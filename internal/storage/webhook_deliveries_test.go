@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebhookDeliveries(t *testing.T) {
+	backends := []struct {
+		name string
+		open func(t *testing.T, path string) Store
+	}{
+		{name: "memory", open: func(t *testing.T, _ string) Store { return NewMemoryStore() }},
+		{
+			name: "file",
+			open: func(t *testing.T, path string) Store {
+				store, err := NewFileStore(path)
+				if err != nil {
+					t.Fatalf("NewFileStore: %v", err)
+				}
+				return store
+			},
+		},
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	delivery := func(eventID, url string, status int, age time.Duration) WebhookDelivery {
+		return WebhookDelivery{
+			EventID:     eventID,
+			EventType:   "payment",
+			URL:         url,
+			Attempt:     1,
+			StatusCode:  status,
+			LatencyMs:   42,
+			Success:     status > 0 && status < 400,
+			AttemptedAt: now.Add(-age),
+		}
+	}
+	failed, succeeded := false, true
+
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "store.json")
+			store := backend.open(t, path)
+			ctx := context.Background()
+
+			long := delivery("evt_long", "https://a.example.com/hook", 500, 3*time.Hour)
+			long.ResponseBody = strings.Repeat("x", MaxWebhookDeliveryResponse+10)
+			records := []struct {
+				name     string
+				delivery WebhookDelivery
+				wantErr  bool
+			}{
+				{name: "long response", delivery: long},
+				{name: "failed", delivery: delivery("evt_1", "https://a.example.com/hook", 503, 2*time.Hour)},
+				{name: "retried", delivery: delivery("evt_1", "https://a.example.com/hook", 200, time.Hour)},
+				{name: "other url", delivery: delivery("evt_2", "https://b.example.com/hook", 204, 0)},
+				{name: "missing url", delivery: delivery("evt_3", "", 200, 0), wantErr: true},
+			}
+			for _, record := range records {
+				err := store.RecordWebhookDelivery(ctx, record.delivery)
+				if (err != nil) != record.wantErr {
+					t.Fatalf("%s: RecordWebhookDelivery err = %v, wantErr %v", record.name, err, record.wantErr)
+				}
+			}
+
+			if backend.name == "file" {
+				_ = store.Close()
+				store = backend.open(t, path)
+			}
+			defer store.Close()
+
+			tests := []struct {
+				name       string
+				filter     WebhookDeliveryFilter
+				wantEvents []string
+			}{
+				{name: "all newest first", wantEvents: []string{"evt_2", "evt_1", "evt_1", "evt_long"}},
+				{name: "by url", filter: WebhookDeliveryFilter{URL: "https://b.example.com/hook"}, wantEvents: []string{"evt_2"}},
+				{name: "by event", filter: WebhookDeliveryFilter{EventID: "evt_1"}, wantEvents: []string{"evt_1", "evt_1"}},
+				{name: "failed only", filter: WebhookDeliveryFilter{Success: &failed}, wantEvents: []string{"evt_1", "evt_long"}},
+				{name: "succeeded only", filter: WebhookDeliveryFilter{Success: &succeeded}, wantEvents: []string{"evt_2", "evt_1"}},
+				{name: "time window", filter: WebhookDeliveryFilter{Since: now.Add(-150 * time.Minute), Until: now}, wantEvents: []string{"evt_1", "evt_1"}},
+				{name: "limit", filter: WebhookDeliveryFilter{Limit: 1}, wantEvents: []string{"evt_2"}},
+			}
+			for _, tt := range tests {
+				deliveries, err := store.ListWebhookDeliveries(ctx, tt.filter)
+				if err != nil {
+					t.Fatalf("%s: ListWebhookDeliveries: %v", tt.name, err)
+				}
+				var events []string
+				for _, d := range deliveries {
+					events = append(events, d.EventID)
+				}
+				if !slices.Equal(events, tt.wantEvents) {
+					t.Errorf("%s: events = %v, want %v", tt.name, events, tt.wantEvents)
+				}
+			}
+
+			oldest, err := store.ListWebhookDeliveries(ctx, WebhookDeliveryFilter{EventID: "evt_long"})
+			if err != nil || len(oldest) != 1 {
+				t.Fatalf("ListWebhookDeliveries(evt_long) = %v, %v", oldest, err)
+			}
+			if got := len(oldest[0].ResponseBody); got != MaxWebhookDeliveryResponse {
+				t.Errorf("response body length = %d, want %d", got, MaxWebhookDeliveryResponse)
+			}
+		})
+	}
+}
+
+func TestAppendWebhookDeliveryRetention(t *testing.T) {
+	var log []WebhookDelivery
+	for i := range maxRetainedWebhookDeliveries + 5 {
+		log = appendWebhookDelivery(log, WebhookDelivery{Attempt: i})
+	}
+	if len(log) != maxRetainedWebhookDeliveries {
+		t.Fatalf("len = %d, want %d", len(log), maxRetainedWebhookDeliveries)
+	}
+	if log[0].Attempt != 5 {
+		t.Errorf("oldest kept attempt = %d, want 5", log[0].Attempt)
+	}
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"encoding/json"
+	"sort"
 	"time"
 )
 
@@ -15,6 +16,14 @@ const (
 	WebhookStatusSuccess    WebhookStatus = "success"    // Successfully delivered
 )
 
+// Webhook priorities. Ready webhooks are dequeued highest priority first, then by next attempt
+// time, so payment confirmations aren't held up behind a backlog of low-priority events.
+const (
+	WebhookPriorityHigh   = 10  // Payment success events
+	WebhookPriorityNormal = 0   // Default
+	WebhookPriorityLow    = -10 // Analytics and other best-effort receivers
+)
+
 // PendingWebhook represents a webhook waiting for delivery or retry.
 // This struct is persisted to the database to ensure delivery across server restarts.
 type PendingWebhook struct {
@@ -22,10 +31,12 @@ type PendingWebhook struct {
 	URL           string            `json:"url"`           // Destination URL
 	Payload       json.RawMessage   `json:"payload"`       // JSON payload to send
 	Headers       map[string]string `json:"headers"`       // HTTP headers
-	EventType     string            `json:"eventType"`     // "payment" or "refund"
+	EventType     string            `json:"eventType"`     // "payment", "refund", or "subscription"
+	Priority      int               `json:"priority"`      // Dequeue order, highest first (WebhookPriorityHigh, ...)
 	Status        WebhookStatus     `json:"status"`        // Current status
 	Attempts      int               `json:"attempts"`      // Number of delivery attempts
 	MaxAttempts   int               `json:"maxAttempts"`   // Maximum retry attempts (e.g., 5)
+	RetrySchedule []int64           `json:"retrySchedule"` // Delay before each retry in milliseconds, computed at enqueue
 	LastError     string            `json:"lastError"`     // Error from last attempt
 	LastAttemptAt time.Time         `json:"lastAttemptAt"` // When last attempt was made
 	NextAttemptAt time.Time         `json:"nextAttemptAt"` // When next attempt should be made
@@ -45,3 +56,13 @@ func (w PendingWebhook) IsReadyForDelivery() bool {
 func (w PendingWebhook) IsFinallyFailed() bool {
 	return w.Attempts >= w.MaxAttempts && w.Status == WebhookStatusFailed
 }
+
+// sortForDelivery orders ready webhooks highest priority first, then earliest next attempt.
+func sortForDelivery(webhooks []PendingWebhook) {
+	sort.Slice(webhooks, func(i, j int) bool {
+		if webhooks[i].Priority != webhooks[j].Priority {
+			return webhooks[i].Priority > webhooks[j].Priority
+		}
+		return webhooks[i].NextAttemptAt.Before(webhooks[j].NextAttemptAt)
+	})
+}
@@ -53,10 +53,8 @@ func (s *FileStore) DequeueWebhooks(ctx context.Context, limit int) ([]PendingWe
 		}
 	}
 
-	// Sort by next attempt time (earliest first)
-	sort.Slice(ready, func(i, j int) bool {
-		return ready[i].NextAttemptAt.Before(ready[j].NextAttemptAt)
-	})
+	// Sort by priority (highest first), then next attempt time (earliest first)
+	sortForDelivery(ready)
 
 	// Limit results
 	if limit > 0 && len(ready) > limit {
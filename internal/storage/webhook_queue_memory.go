@@ -49,10 +49,8 @@ func (m *MemoryStore) DequeueWebhooks(ctx context.Context, limit int) ([]Pending
 		}
 	}
 
-	// Sort by next attempt time (earliest first)
-	sort.Slice(ready, func(i, j int) bool {
-		return ready[i].NextAttemptAt.Before(ready[j].NextAttemptAt)
-	})
+	// Sort by priority (highest first), then next attempt time (earliest first)
+	sortForDelivery(ready)
 
 	// Limit results
 	if limit > 0 && len(ready) > limit {
@@ -52,7 +52,7 @@ func (s *MongoDBStore) DequeueWebhooks(ctx context.Context, limit int) ([]Pendin
 	}
 
 	opts := options.Find().
-		SetSort(bson.D{{Key: "nextattemptat", Value: 1}}).
+		SetSort(bson.D{{Key: "priority", Value: -1}, {Key: "nextattemptat", Value: 1}}).
 		SetLimit(int64(limit))
 
 	cursor, err := coll.Find(ctx, filter, opts)
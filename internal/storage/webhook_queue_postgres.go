@@ -34,10 +34,14 @@ func (s *PostgresStore) EnqueueWebhook(ctx context.Context, webhook PendingWebho
 	if err != nil {
 		return "", fmt.Errorf("marshal headers: %w", err)
 	}
+	scheduleJSON, err := json.Marshal(webhook.RetrySchedule)
+	if err != nil {
+		return "", fmt.Errorf("marshal retry schedule: %w", err)
+	}
 
 	query := fmt.Sprintf(`
-		INSERT INTO %s (id, url, payload, headers, event_type, status, attempts, max_attempts, last_error, last_attempt_at, next_attempt_at, created_at, completed_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		INSERT INTO %s (id, url, payload, headers, event_type, priority, status, attempts, max_attempts, retry_schedule, last_error, last_attempt_at, next_attempt_at, created_at, completed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
 	`, s.webhookQueueTableName)
 
 	_, err = s.db.ExecContext(ctx, query,
@@ -46,9 +50,11 @@ func (s *PostgresStore) EnqueueWebhook(ctx context.Context, webhook PendingWebho
 		webhook.Payload,
 		headersJSON,
 		webhook.EventType,
+		webhook.Priority,
 		webhook.Status,
 		webhook.Attempts,
 		webhook.MaxAttempts,
+		scheduleJSON,
 		webhook.LastError,
 		nullTime(webhook.LastAttemptAt),
 		webhook.NextAttemptAt,
@@ -65,10 +71,10 @@ func (s *PostgresStore) EnqueueWebhook(ctx context.Context, webhook PendingWebho
 // DequeueWebhooks retrieves webhooks ready for delivery.
 func (s *PostgresStore) DequeueWebhooks(ctx context.Context, limit int) ([]PendingWebhook, error) {
 	query := fmt.Sprintf(`
-		SELECT id, url, payload, headers, event_type, status, attempts, max_attempts, last_error, last_attempt_at, next_attempt_at, created_at, completed_at
+		SELECT id, url, payload, headers, event_type, priority, status, attempts, max_attempts, retry_schedule, last_error, last_attempt_at, next_attempt_at, created_at, completed_at
 		FROM %s
 		WHERE status = $1 AND next_attempt_at <= $2
-		ORDER BY next_attempt_at ASC
+		ORDER BY priority DESC, next_attempt_at ASC
 		LIMIT $3
 	`, s.webhookQueueTableName)
 
@@ -191,7 +197,7 @@ func (s *PostgresStore) MarkWebhookFailed(ctx context.Context, webhookID string,
 // GetWebhook retrieves a webhook by ID (for admin UI).
 func (s *PostgresStore) GetWebhook(ctx context.Context, webhookID string) (PendingWebhook, error) {
 	query := fmt.Sprintf(`
-		SELECT id, url, payload, headers, event_type, status, attempts, max_attempts, last_error, last_attempt_at, next_attempt_at, created_at, completed_at
+		SELECT id, url, payload, headers, event_type, priority, status, attempts, max_attempts, retry_schedule, last_error, last_attempt_at, next_attempt_at, created_at, completed_at
 		FROM %s
 		WHERE id = $1
 	`, s.webhookQueueTableName)
@@ -215,7 +221,7 @@ func (s *PostgresStore) ListWebhooks(ctx context.Context, status WebhookStatus,
 
 	if status == "" {
 		query = fmt.Sprintf(`
-			SELECT id, url, payload, headers, event_type, status, attempts, max_attempts, last_error, last_attempt_at, next_attempt_at, created_at, completed_at
+			SELECT id, url, payload, headers, event_type, priority, status, attempts, max_attempts, retry_schedule, last_error, last_attempt_at, next_attempt_at, created_at, completed_at
 			FROM %s
 			ORDER BY created_at DESC
 			LIMIT $1
@@ -223,7 +229,7 @@ func (s *PostgresStore) ListWebhooks(ctx context.Context, status WebhookStatus,
 		args = []interface{}{limit}
 	} else {
 		query = fmt.Sprintf(`
-			SELECT id, url, payload, headers, event_type, status, attempts, max_attempts, last_error, last_attempt_at, next_attempt_at, created_at, completed_at
+			SELECT id, url, payload, headers, event_type, priority, status, attempts, max_attempts, retry_schedule, last_error, last_attempt_at, next_attempt_at, created_at, completed_at
 			FROM %s
 			WHERE status = $1
 			ORDER BY created_at DESC
@@ -301,7 +307,7 @@ type scanner interface {
 
 func scanWebhook(s scanner) (PendingWebhook, error) {
 	var webhook PendingWebhook
-	var headersJSON []byte
+	var headersJSON, scheduleJSON []byte
 	var lastAttemptAt sql.NullTime
 	var completedAt sql.NullTime
 
@@ -311,9 +317,11 @@ func scanWebhook(s scanner) (PendingWebhook, error) {
 		&webhook.Payload,
 		&headersJSON,
 		&webhook.EventType,
+		&webhook.Priority,
 		&webhook.Status,
 		&webhook.Attempts,
 		&webhook.MaxAttempts,
+		&scheduleJSON,
 		&webhook.LastError,
 		&lastAttemptAt,
 		&webhook.NextAttemptAt,
@@ -331,6 +339,12 @@ func scanWebhook(s scanner) (PendingWebhook, error) {
 		}
 	}
 
+	if len(scheduleJSON) > 0 {
+		if err := json.Unmarshal(scheduleJSON, &webhook.RetrySchedule); err != nil {
+			return PendingWebhook{}, fmt.Errorf("unmarshal retry schedule: %w", err)
+		}
+	}
+
 	// Convert nullable times
 	if lastAttemptAt.Valid {
 		webhook.LastAttemptAt = lastAttemptAt.Time
@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"encoding/json"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -172,3 +173,83 @@ func TestMemoryStore_WebhookQueueListAndDelete(t *testing.T) {
 		t.Fatalf("Expected 4 webhooks after deletion, got %d", len(webhooks))
 	}
 }
+
+func TestWebhookQueue_DequeueByPriority(t *testing.T) {
+	backends := []struct {
+		name string
+		open func(t *testing.T) Store
+	}{
+		{name: "memory", open: func(t *testing.T) Store { return NewMemoryStore() }},
+		{
+			name: "file",
+			open: func(t *testing.T) Store {
+				store, err := NewFileStore(filepath.Join(t.TempDir(), "store.json"))
+				if err != nil {
+					t.Fatalf("NewFileStore: %v", err)
+				}
+				return store
+			},
+		},
+	}
+
+	now := time.Now().UTC()
+	queued := []struct {
+		id       string
+		priority int
+		age      time.Duration
+	}{
+		{id: "analytics_old", priority: WebhookPriorityLow, age: 3 * time.Minute},
+		{id: "refund", priority: WebhookPriorityNormal, age: 2 * time.Minute},
+		{id: "payment_new", priority: WebhookPriorityHigh, age: time.Minute},
+		{id: "payment_old", priority: WebhookPriorityHigh, age: 2 * time.Minute},
+		{id: "scheduled", priority: WebhookPriorityHigh, age: -time.Hour},
+	}
+	want := []string{"payment_old", "payment_new", "refund", "analytics_old"}
+
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			store := backend.open(t)
+			defer store.Close()
+			ctx := context.Background()
+
+			for _, q := range queued {
+				webhook := PendingWebhook{
+					ID:            q.id,
+					URL:           "https://example.com/webhook",
+					Payload:       json.RawMessage(`{}`),
+					EventType:     "payment",
+					Priority:      q.priority,
+					NextAttemptAt: now.Add(-q.age),
+				}
+				if _, err := store.EnqueueWebhook(ctx, webhook); err != nil {
+					t.Fatalf("EnqueueWebhook(%s): %v", q.id, err)
+				}
+			}
+
+			webhooks, err := store.DequeueWebhooks(ctx, 10)
+			if err != nil {
+				t.Fatalf("DequeueWebhooks: %v", err)
+			}
+			var got []string
+			for _, webhook := range webhooks {
+				got = append(got, webhook.ID)
+			}
+			if len(got) != len(want) {
+				t.Fatalf("dequeued %v, want %v", got, want)
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Fatalf("dequeued %v, want %v", got, want)
+				}
+			}
+
+			limited, err := store.DequeueWebhooks(ctx, 1)
+			if err != nil {
+				t.Fatalf("DequeueWebhooks(1): %v", err)
+			}
+			if len(limited) != 1 || limited[0].ID != "payment_old" {
+				t.Fatalf("DequeueWebhooks(1) = %+v, want payment_old", limited)
+			}
+		})
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	stripeapi "github.com/stripe/stripe-go/v72"
 	"github.com/stripe/stripe-go/v72/checkout/session"
+	"github.com/stripe/stripe-go/v72/coupon"
 	"github.com/stripe/stripe-go/v72/promotioncode"
 
 	"github.com/CedrosPay/server/internal/callbacks"
@@ -18,6 +20,7 @@ import (
 type CartLineItem struct {
 	PriceID     string            `json:"priceId"`
 	Resource    string            `json:"resource,omitempty"` // Optional: backend resource ID for indexing
+	Variant     string            `json:"variant,omitempty"`  // Optional: variant of the resource
 	Quantity    int64             `json:"quantity"`
 	Description string            `json:"description,omitempty"`
 	Metadata    map[string]string `json:"metadata,omitempty"`
@@ -34,6 +37,32 @@ type CreateCartSessionRequest struct {
 	StripeCouponID string            `json:"stripeCouponId,omitempty"` // NEW: Optional Stripe coupon ID
 	OriginalAmount int64             `json:"originalAmount,omitempty"` // NEW: Original total before discount
 	DiscountAmount int64             `json:"discountAmount,omitempty"` // NEW: Total discount applied
+	PaymentMethods []string          `json:"paymentMethods,omitempty"` // Payment method types offered; empty uses stripe.payment_methods
+}
+
+// QuotedCartSessionTTL is how long a quoted cart's checkout session stays open: Stripe's
+// 30-minute minimum plus a minute for clock skew.
+const QuotedCartSessionTTL = 31 * time.Minute
+
+// QuotedCartLine is a line item priced by the server rather than by a Stripe Price.
+type QuotedCartLine struct {
+	Name       string
+	UnitAmount int64 // In the currency's smallest unit (cents)
+	Quantity   int64
+}
+
+// CreateQuotedCartSessionRequest pays a locked cart quote by card.
+type CreateQuotedCartSessionRequest struct {
+	CartID         string
+	Currency       string
+	Lines          []QuotedCartLine
+	Discount       int64 // Taken off the lines with a single-use Stripe coupon
+	CustomerEmail  string
+	Metadata       map[string]string // Returned with the checkout.session.completed webhook
+	SuccessURL     string
+	CancelURL      string
+	ExpiresAt      time.Time
+	PaymentMethods []string // Payment method types offered; empty uses stripe.payment_methods
 }
 
 // CartService handles multi-item Stripe checkout sessions.
@@ -51,7 +80,10 @@ type stripeConfig interface {
 	GetSecretKey() string
 	GetSuccessURL() string
 	GetCancelURL() string
+	GetPaymentMethods() []string
 	GetTaxRateID() string
+	GetAutomaticTax() bool
+	GetTaxBehavior() string
 }
 
 // NewCartService creates a cart service for multi-item checkouts.
@@ -130,6 +162,9 @@ func (c *CartService) CreateCartCheckoutSession(ctx context.Context, req CreateC
 		if item.Resource != "" {
 			metadata[prefix+"resource"] = item.Resource
 		}
+		if item.Variant != "" {
+			metadata[prefix+"variant"] = item.Variant
+		}
 		metadata[prefix+"quantity"] = fmt.Sprintf("%d", item.Quantity)
 		if item.Description != "" {
 			metadata[prefix+"description"] = item.Description
@@ -143,12 +178,15 @@ func (c *CartService) CreateCartCheckoutSession(ctx context.Context, req CreateC
 	// Create checkout session parameters
 	params := &stripeapi.CheckoutSessionParams{
 		Mode:               stripeapi.String(string(stripeapi.CheckoutSessionModePayment)),
-		PaymentMethodTypes: stripeapi.StringSlice([]string{"card"}),
+		PaymentMethodTypes: checkoutPaymentMethodTypes(offeredPaymentMethods(req.PaymentMethods, c.cfg.GetPaymentMethods())),
 		SuccessURL:         stripeapi.String(firstNonEmpty(req.SuccessURL, c.cfg.GetSuccessURL())),
 		CancelURL:          stripeapi.String(firstNonEmpty(req.CancelURL, c.cfg.GetCancelURL())),
 		LineItems:          lineItems,
 	}
 	params.Metadata = metadata
+	if c.cfg.GetAutomaticTax() {
+		applyAutomaticTax(params, c.cfg.GetTaxBehavior())
+	}
 
 	// Apply Stripe promotion code if provided
 	// This uses Stripe's native discount system with promotion codes created in Stripe Dashboard
@@ -170,6 +208,7 @@ func (c *CartService) CreateCartCheckoutSession(ctx context.Context, req CreateC
 	}
 
 	// Create the session with Stripe
+	params.Context = ctx
 	s, err := session.New(params)
 	if err != nil {
 		return nil, fmt.Errorf("stripe cart: create checkout session: %w", err)
@@ -180,6 +219,87 @@ func (c *CartService) CreateCartCheckoutSession(ctx context.Context, req CreateC
 	return s, nil
 }
 
+// CreateQuotedCartSession creates a Stripe checkout session for a cart quote, with one line
+// item per cart line at the price locked in the quote. The session metadata carries the cart ID
+// so the completion webhook can mark the cart paid. Stripe Tax is not applied: the quote's
+// tax is already one of its lines.
+func (c *CartService) CreateQuotedCartSession(ctx context.Context, req CreateQuotedCartSessionRequest) (*stripeapi.CheckoutSession, error) {
+	if req.CartID == "" || len(req.Lines) == 0 {
+		return nil, errors.New("stripe cart: cart id and at least one line required")
+	}
+
+	lineItems := make([]*stripeapi.CheckoutSessionLineItemParams, 0, len(req.Lines))
+	for _, line := range req.Lines {
+		lineItems = append(lineItems, &stripeapi.CheckoutSessionLineItemParams{
+			Quantity: stripeapi.Int64(line.Quantity),
+			PriceData: &stripeapi.CheckoutSessionLineItemPriceDataParams{
+				Currency: stripeapi.String(req.Currency),
+				ProductData: &stripeapi.CheckoutSessionLineItemPriceDataProductDataParams{
+					Name: stripeapi.String(line.Name),
+				},
+				UnitAmount: stripeapi.Int64(line.UnitAmount),
+			},
+		})
+	}
+
+	metadata := make(map[string]string, len(req.Metadata)+2)
+	for k, v := range req.Metadata {
+		metadata[k] = v
+	}
+	metadata["cart_id"] = req.CartID
+	metadata["resource_id"] = req.CartID
+
+	params := &stripeapi.CheckoutSessionParams{
+		Mode:               stripeapi.String(string(stripeapi.CheckoutSessionModePayment)),
+		PaymentMethodTypes: checkoutPaymentMethodTypes(offeredPaymentMethods(req.PaymentMethods, c.cfg.GetPaymentMethods())),
+		SuccessURL:         stripeapi.String(firstNonEmpty(req.SuccessURL, c.cfg.GetSuccessURL())),
+		CancelURL:          stripeapi.String(firstNonEmpty(req.CancelURL, c.cfg.GetCancelURL())),
+		LineItems:          lineItems,
+		ClientReferenceID:  stripeapi.String(req.CartID),
+	}
+	params.Metadata = metadata
+	if !req.ExpiresAt.IsZero() {
+		params.ExpiresAt = stripeapi.Int64(req.ExpiresAt.Unix())
+	}
+	if req.CustomerEmail != "" {
+		params.CustomerEmail = stripeapi.String(req.CustomerEmail)
+	}
+
+	// Checkout coupons discount the cart total, not individual lines
+	if req.Discount > 0 {
+		discountID, err := createCartDiscount(ctx, req.Discount, req.Currency)
+		if err != nil {
+			return nil, err
+		}
+		params.Discounts = []*stripeapi.CheckoutSessionDiscountParams{{Coupon: stripeapi.String(discountID)}}
+	}
+
+	params.Context = ctx
+	s, err := session.New(params)
+	if err != nil {
+		return nil, fmt.Errorf("stripe cart: create checkout session: %w", err)
+	}
+	return s, nil
+}
+
+// createCartDiscount creates a single-use Stripe coupon taking amount off a cart's total and
+// returns its ID.
+func createCartDiscount(ctx context.Context, amount int64, currency string) (string, error) {
+	params := &stripeapi.CouponParams{
+		AmountOff:      stripeapi.Int64(amount),
+		Currency:       stripeapi.String(currency),
+		Duration:       stripeapi.String(string(stripeapi.CouponDurationOnce)),
+		MaxRedemptions: stripeapi.Int64(1),
+		Name:           stripeapi.String("Cart discount"),
+	}
+	params.Context = ctx
+	discount, err := coupon.New(params)
+	if err != nil {
+		return "", fmt.Errorf("stripe cart: create cart discount: %w", err)
+	}
+	return discount.ID, nil
+}
+
 // lookupPromotionCodeID retrieves the Stripe promotion code ID from a code string (e.g., "SAVE20" -> "promo_123")
 func (c *CartService) lookupPromotionCodeID(code string) (string, error) {
 	params := &stripeapi.PromotionCodeListParams{
@@ -5,21 +5,30 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	stripeapi "github.com/stripe/stripe-go/v72"
+	"github.com/stripe/stripe-go/v72/balance"
 	"github.com/stripe/stripe-go/v72/checkout/session"
 	"github.com/stripe/stripe-go/v72/promotioncode"
 	"github.com/stripe/stripe-go/v72/webhook"
 
 	"github.com/CedrosPay/server/internal/callbacks"
 	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/coupons"
+	"github.com/CedrosPay/server/internal/logger"
 	"github.com/CedrosPay/server/internal/metrics"
 	"github.com/CedrosPay/server/internal/money"
 	"github.com/CedrosPay/server/internal/storage"
+	"github.com/CedrosPay/server/internal/tracing"
 )
 
+// stripeHTTPTimeout matches stripe-go's default client timeout.
+const stripeHTTPTimeout = 80 * time.Second
+
 // Client wraps stripe-go operations used by the server.
 type Client struct {
 	cfg     config.StripeConfig
@@ -31,12 +40,15 @@ type Client struct {
 
 // CouponRepository defines the minimal interface needed for coupon tracking.
 type CouponRepository interface {
+	GetCoupon(ctx context.Context, code string) (coupons.Coupon, error)
 	IncrementUsage(ctx context.Context, code string) error
 }
 
 // NewClient sets up stripe-go with the provided credentials.
 func NewClient(cfg config.StripeConfig, store storage.Store, notifier callbacks.Notifier, coupons CouponRepository, metricsCollector *metrics.Metrics) *Client {
 	stripeapi.Key = cfg.SecretKey
+	// Trace Stripe API calls; requests made with params.Context nest under the caller's span
+	stripeapi.SetHTTPClient(&http.Client{Timeout: stripeHTTPTimeout, Transport: tracing.NewTransport(nil)})
 	if notifier == nil {
 		notifier = callbacks.NoopNotifier{}
 	}
@@ -49,6 +61,20 @@ func NewClient(cfg config.StripeConfig, store storage.Store, notifier callbacks.
 	}
 }
 
+// Ping checks that the Stripe API is reachable and accepts the configured secret key
+// by retrieving the account balance (a read-only call).
+func (c *Client) Ping(ctx context.Context) error {
+	if c.cfg.SecretKey == "" {
+		return errors.New("stripe: secret key not configured")
+	}
+	params := &stripeapi.BalanceParams{}
+	params.Context = ctx
+	if _, err := balance.Get(params); err != nil {
+		return fmt.Errorf("stripe: ping: %w", err)
+	}
+	return nil
+}
+
 // CreateSessionRequest captures checkout metadata.
 type CreateSessionRequest struct {
 	ResourceID     string
@@ -60,10 +86,12 @@ type CreateSessionRequest struct {
 	SuccessURL     string
 	CancelURL      string
 	Description    string
-	CouponCode     string // NEW: Coupon code applied (for metadata tracking)
-	OriginalAmount int64  // NEW: Original price before discount (for metadata tracking)
-	DiscountAmount int64  // NEW: Discount amount applied (for metadata tracking)
-	StripeCouponID string // NEW: Optional Stripe coupon ID (if synced to Stripe)
+	CouponCode     string      // NEW: Coupon code applied (for metadata tracking)
+	OriginalAmount int64       // NEW: Original price before discount (for metadata tracking)
+	DiscountAmount int64       // NEW: Discount amount applied (for metadata tracking)
+	StripeCouponID string      // NEW: Optional Stripe coupon ID (if synced to Stripe)
+	PaymentMethods []string    // Payment method types offered (see ResolvePaymentMethods); empty uses stripe.payment_methods
+	Destination    Destination // Connected account the payment is transferred to, if any
 }
 
 // CreateCheckoutSession builds a Stripe Checkout session and persists minimal metadata.
@@ -83,7 +111,7 @@ func (c *Client) CreateCheckoutSession(ctx context.Context, req CreateSessionReq
 
 	params := &stripeapi.CheckoutSessionParams{
 		Mode:               stripeapi.String(string(stripeapi.CheckoutSessionModePayment)),
-		PaymentMethodTypes: stripeapi.StringSlice([]string{"card"}),
+		PaymentMethodTypes: checkoutPaymentMethodTypes(offeredPaymentMethods(req.PaymentMethods, c.cfg.PaymentMethods)),
 		SuccessURL:         stripeapi.String(firstNonEmpty(req.SuccessURL, c.cfg.SuccessURL)),
 		CancelURL:          stripeapi.String(firstNonEmpty(req.CancelURL, c.cfg.CancelURL)),
 	}
@@ -133,7 +161,21 @@ func (c *Client) CreateCheckoutSession(ctx context.Context, req CreateSessionReq
 		}
 		params.LineItems = []*stripeapi.CheckoutSessionLineItemParams{lineItem}
 	}
+	if c.cfg.AutomaticTax {
+		applyAutomaticTax(params, c.cfg.GetTaxBehavior())
+	}
+	if req.Destination.Account != "" {
+		amount := req.AmountCents
+		if req.PriceID != "" {
+			var err error
+			if amount, err = priceAmount(ctx, req.PriceID); err != nil {
+				return nil, err
+			}
+		}
+		applyCheckoutDestination(params, req.Destination, amount)
+	}
 
+	params.Context = ctx
 	s, err := session.New(params)
 	if err != nil {
 		return nil, fmt.Errorf("stripe: create checkout session: %w", err)
@@ -145,13 +187,33 @@ func (c *Client) CreateCheckoutSession(ctx context.Context, req CreateSessionReq
 
 // WebhookEvent wraps the subset of event types we care about.
 type WebhookEvent struct {
-	Type        string
-	SessionID   string
-	ResourceID  string
-	Customer    string
-	Metadata    map[string]string
-	AmountTotal int64
-	Currency    string
+	ID              string // Stripe event ID, the same on every delivery of the event
+	Type            string
+	SessionID       string // Checkout session ID (checkout.session.completed)
+	PaymentIntentID string // PaymentIntent ID (payment_intent.succeeded)
+	InvoiceID       string // One-off invoice ID (invoice.paid)
+	ResourceID      string
+	Customer        string // Customer email, if collected
+	CustomerID      string // Stripe customer ID, if the payment created or used one
+	Metadata        map[string]string
+	AmountTotal     int64
+	TaxAmount       int64 // Tax included in AmountTotal, as computed by Stripe Tax
+	Currency        string
+	FailureCode     string // Why the payment failed (failure events only), e.g. "card_declined"
+	FailureMessage  string
+}
+
+// PaymentID returns the ID of the Stripe object that was paid and the metadata key it is
+// recorded under: the Checkout session, PaymentIntent, or invoice.
+func (e WebhookEvent) PaymentID() (id, key string) {
+	switch {
+	case e.SessionID != "":
+		return e.SessionID, "session_id"
+	case e.PaymentIntentID != "":
+		return e.PaymentIntentID, "payment_intent_id"
+	default:
+		return e.InvoiceID, "invoice_id"
+	}
 }
 
 // ParseWebhook validates event signatures and normalises the payload.
@@ -163,6 +225,16 @@ func (c *Client) ParseWebhook(ctx context.Context, payload []byte, signature str
 	if err != nil {
 		return WebhookEvent{}, fmt.Errorf("stripe: construct event: %w", err)
 	}
+	parsed, err := normalizeEvent(event)
+	if err != nil {
+		return WebhookEvent{}, err
+	}
+	parsed.ID = event.ID
+	return parsed, nil
+}
+
+// normalizeEvent extracts the fields of the event types we handle.
+func normalizeEvent(event stripeapi.Event) (WebhookEvent, error) {
 	switch event.Type {
 	case "checkout.session.completed":
 		var checkout stripeapi.CheckoutSession
@@ -187,10 +259,20 @@ func (c *Client) ParseWebhook(ctx context.Context, payload []byte, signature str
 			SessionID:   checkout.ID,
 			ResourceID:  resourceID,
 			Customer:    checkout.CustomerEmail,
+			CustomerID:  customerID(checkout.Customer),
 			Metadata:    checkout.Metadata,
 			AmountTotal: checkout.AmountTotal,
+			TaxAmount:   sessionTaxAmount(checkout),
 			Currency:    string(checkout.Currency),
 		}, nil
+	case "payment_intent.succeeded":
+		return paymentIntentEvent(event.Type, event.Data.Raw)
+	case "invoice.paid":
+		return invoiceEvent(event.Type, event.Data.Raw)
+	case "payment_intent.payment_failed":
+		return failedPaymentIntentEvent(event.Type, event.Data.Raw)
+	case "checkout.session.async_payment_failed", "checkout.session.expired":
+		return failedCheckoutEvent(event.Type, event.Data.Raw)
 	default:
 		return WebhookEvent{
 			Type: event.Type,
@@ -198,10 +280,12 @@ func (c *Client) ParseWebhook(ctx context.Context, payload []byte, signature str
 	}
 }
 
-// HandleCompletion handles webhook completion and triggers payment succeeded callback.
+// HandleCompletion records a completed Checkout session, succeeded PaymentIntent, or paid
+// one-off invoice and triggers the payment succeeded callback.
 func (c *Client) HandleCompletion(ctx context.Context, event WebhookEvent) error {
-	if event.SessionID == "" {
-		return errors.New("stripe: completion missing session id")
+	paymentID, idKey := event.PaymentID()
+	if paymentID == "" {
+		return errors.New("stripe: completion missing session, payment intent, or invoice id")
 	}
 	now := time.Now()
 
@@ -211,16 +295,40 @@ func (c *Client) HandleCompletion(ctx context.Context, event WebhookEvent) error
 		return fmt.Errorf("stripe: unsupported currency %s: %w", event.Currency, err)
 	}
 	tx := storage.PaymentTransaction{
-		Signature:  fmt.Sprintf("stripe:%s", event.SessionID),
+		Signature:  fmt.Sprintf("stripe:%s", paymentID),
 		ResourceID: event.ResourceID,
 		Wallet:     event.Customer,
 		Amount:     money.New(asset, event.AmountTotal),
 		CreatedAt:  now,
 		Metadata: map[string]string{
-			"status":     "stripe",
-			"session_id": event.SessionID,
+			"status": "stripe",
+			idKey:    paymentID,
 		},
 	}
+	if items := event.Metadata["bundle_items"]; items != "" {
+		tx.Metadata["bundle_items"] = items // Grants access to the bundle's members
+	}
+	if event.CustomerID != "" {
+		tx.Metadata["stripe_customer_id"] = event.CustomerID
+	}
+	if event.TaxAmount > 0 {
+		tx.Metadata["tax_amount"] = money.New(asset, event.TaxAmount).ToMajor()
+	}
+	if account := event.Metadata["connected_account"]; account != "" {
+		tx.Metadata["connected_account"] = account
+		if fee, err := strconv.ParseInt(event.Metadata["application_fee_cents"], 10, 64); err == nil {
+			tx.Metadata["application_fee"] = money.New(asset, fee).ToMajor()
+		}
+	}
+	if code := event.Metadata["coupon_code"]; code != "" {
+		// Recorded as for crypto payments so coupon analytics read both alike
+		tx.Metadata["coupon_codes"] = code
+		if original, err := strconv.ParseInt(event.Metadata["original_amount_cents"], 10, 64); err == nil {
+			discount, _ := strconv.ParseInt(event.Metadata["discount_amount_cents"], 10, 64)
+			tx.Metadata["original_amount"] = money.New(asset, original).ToMajor()
+			tx.Metadata["discounted_amount"] = money.New(asset, original-discount).ToMajor()
+		}
+	}
 	if err := c.store.RecordPayment(ctx, tx); err != nil {
 		if !strings.Contains(err.Error(), "signature already used") {
 			return fmt.Errorf("stripe: record payment: %w", err)
@@ -228,27 +336,123 @@ func (c *Client) HandleCompletion(ctx context.Context, event WebhookEvent) error
 		return nil // duplicate webhook – already processed
 	}
 
+	// Take the sold unit out of stock (a no-op for untracked resources)
+	if err := c.store.CommitStockReservation(ctx, tx.Signature, map[string]int64{event.ResourceID: 1}); err != nil {
+		log := logger.FromContext(ctx)
+		log.Error().
+			Err(err).
+			Str("resource_id", event.ResourceID).
+			Msg("stripe.stock_commit_failed")
+	}
+
 	// Increment coupon usage if a coupon was applied
 	if couponCode := event.Metadata["coupon_code"]; couponCode != "" && c.coupons != nil {
 		if err := c.coupons.IncrementUsage(ctx, couponCode); err != nil {
 			// Log error but don't fail the webhook - payment was successful
 			// Note: YAML coupons will return "read-only" error, which is expected
 		}
+		c.recordReferral(ctx, couponCode, tx)
+		c.recordRedemption(ctx, couponCode, tx)
 	}
+	c.linkCustomer(ctx, event)
 
 	c.notify.PaymentSucceeded(ctx, callbacks.PaymentEvent{
-		ResourceID:      event.ResourceID,
-		Method:          "stripe",
-		StripeSessionID: event.SessionID,
-		StripeCustomer:  event.Customer,
-		FiatAmountCents: event.AmountTotal,
-		FiatCurrency:    event.Currency,
-		Metadata:        event.Metadata,
-		PaidAt:          now.UTC(),
+		ResourceID:            event.ResourceID,
+		Method:                "stripe",
+		StripeSessionID:       event.SessionID,
+		StripePaymentIntentID: event.PaymentIntentID,
+		StripeInvoiceID:       event.InvoiceID,
+		StripeCustomer:        event.Customer,
+		FiatAmountCents:       event.AmountTotal,
+		FiatTaxCents:          event.TaxAmount,
+		FiatCurrency:          event.Currency,
+		Metadata:              event.Metadata,
+		PaidAt:                now.UTC(),
 	})
 	return nil
 }
 
+// recordReferral attributes a Stripe payment made with a referral coupon to its referrer.
+// Failures are logged: the payment already succeeded.
+func (c *Client) recordReferral(ctx context.Context, code string, tx storage.PaymentTransaction) {
+	coupon, err := c.coupons.GetCoupon(ctx, code)
+	if err != nil {
+		return
+	}
+	wallet, _, ok := coupon.Referrer()
+	if !ok {
+		return
+	}
+	reward, err := coupon.ReferralReward(tx.Amount)
+	if err == nil {
+		err = c.store.RecordReferralConversion(ctx, storage.ReferralConversion{
+			Signature:      tx.Signature,
+			ReferrerWallet: wallet,
+			Code:           coupon.Code,
+			ResourceID:     tx.ResourceID,
+			Amount:         tx.Amount,
+			Reward:         reward,
+			ConvertedAt:    tx.CreatedAt,
+		})
+	}
+	if err != nil {
+		log := logger.FromContext(ctx)
+		log.Warn().
+			Err(err).
+			Str("coupon_code", coupon.Code).
+			Str("signature", tx.Signature).
+			Msg("stripe.referral_record_failed")
+	}
+}
+
+// recordRedemption counts a Stripe payment made with a coupon that has a per-wallet limit
+// against the customer's email. The limit was checked when the session was created.
+func (c *Client) recordRedemption(ctx context.Context, code string, tx storage.PaymentTransaction) {
+	coupon, err := c.coupons.GetCoupon(ctx, code)
+	redeemer := coupons.EmailRedeemer(tx.Wallet)
+	if err != nil || coupon.WalletLimit() == 0 || redeemer == "" {
+		return
+	}
+	err = c.store.RecordCouponRedemption(ctx, storage.CouponRedemption{
+		Code:       coupon.Code,
+		Redeemer:   redeemer,
+		Signature:  tx.Signature,
+		RedeemedAt: tx.CreatedAt,
+	}, 0)
+	if err != nil {
+		log := logger.FromContext(ctx)
+		log.Warn().
+			Err(err).
+			Str("coupon_code", coupon.Code).
+			Str("signature", tx.Signature).
+			Msg("stripe.coupon_redemption_record_failed")
+	}
+}
+
+// linkCustomer links the email and Stripe customer ID a payment was made with, so the payer's
+// history spans payment rails. Failures are logged: the payment already succeeded.
+func (c *Client) linkCustomer(ctx context.Context, event WebhookEvent) {
+	identities := storage.PayerIdentities(event.Customer, "", event.CustomerID)
+	if len(identities) == 0 {
+		return
+	}
+	if _, err := c.store.LinkCustomerIdentities(ctx, identities); err != nil {
+		log := logger.FromContext(ctx)
+		log.Warn().
+			Err(err).
+			Str("resource_id", event.ResourceID).
+			Msg("stripe.customer_link_failed")
+	}
+}
+
+// customerID returns the ID of an expandable customer reference, or "" if there is none.
+func customerID(customer *stripeapi.Customer) string {
+	if customer == nil {
+		return ""
+	}
+	return customer.ID
+}
+
 func firstNonEmpty(values ...string) string {
 	for _, v := range values {
 		if strings.TrimSpace(v) != "" {
@@ -0,0 +1,85 @@
+package stripe
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+
+	stripeapi "github.com/stripe/stripe-go/v72"
+	"github.com/stripe/stripe-go/v72/price"
+)
+
+// Destination routes a Stripe payment to a seller's connected account as a destination
+// charge: the platform account charges the customer, keeps FeePercent as its application
+// fee, and Stripe transfers the rest to Account.
+type Destination struct {
+	Account    string  // Connected account ID (acct_...); empty keeps the payment on the platform
+	FeePercent float64 // Application fee, percent of the amount charged
+}
+
+// applicationFee is FeePercent of amount, rounded to the nearest cent.
+func (d Destination) applicationFee(amount int64) int64 {
+	return int64(math.Round(float64(amount) * d.FeePercent / 100))
+}
+
+// recordDestination notes the connected account and application fee in session metadata, so
+// the completed payment records where its funds went.
+func (d Destination) recordDestination(metadata map[string]string, fee int64) {
+	metadata["connected_account"] = d.Account
+	metadata["application_fee_cents"] = strconv.FormatInt(fee, 10)
+}
+
+// applyCheckoutDestination routes a one-time Checkout session's payment to d. amount is what
+// the session charges before Stripe promotion codes, which the fee is taken from; Stripe caps
+// the fee at the amount actually paid.
+func applyCheckoutDestination(params *stripeapi.CheckoutSessionParams, d Destination, amount int64) {
+	fee := d.applicationFee(amount)
+	params.PaymentIntentData = &stripeapi.CheckoutSessionPaymentIntentDataParams{
+		ApplicationFeeAmount: stripeapi.Int64(fee),
+		TransferData: &stripeapi.CheckoutSessionPaymentIntentDataTransferDataParams{
+			Destination: stripeapi.String(d.Account),
+		},
+	}
+	d.recordDestination(params.Metadata, fee)
+}
+
+// applyPaymentIntentDestination routes a PaymentIntent's payment to d.
+func applyPaymentIntentDestination(params *stripeapi.PaymentIntentParams, d Destination, amount int64) {
+	fee := d.applicationFee(amount)
+	params.ApplicationFeeAmount = stripeapi.Int64(fee)
+	params.TransferData = &stripeapi.PaymentIntentTransferDataParams{
+		Destination: stripeapi.String(d.Account),
+	}
+	d.recordDestination(params.Metadata, fee)
+}
+
+// applySubscriptionDestination routes every invoice of a subscription Checkout session to d,
+// keeping FeePercent of each.
+func applySubscriptionDestination(params *stripeapi.CheckoutSessionParams, d Destination) {
+	if params.SubscriptionData == nil {
+		params.SubscriptionData = &stripeapi.CheckoutSessionSubscriptionDataParams{}
+	}
+	params.SubscriptionData.TransferData = &stripeapi.CheckoutSessionSubscriptionDataTransferDataParams{
+		Destination: stripeapi.String(d.Account),
+	}
+	if d.FeePercent > 0 {
+		params.SubscriptionData.ApplicationFeePercent = stripeapi.Float64(d.FeePercent)
+	}
+	params.Metadata["connected_account"] = d.Account
+}
+
+// priceAmount returns the unit amount of a Stripe Price, needed to take an application fee
+// from a session priced by PriceID.
+func priceAmount(ctx context.Context, priceID string) (int64, error) {
+	params := &stripeapi.PriceParams{}
+	params.Context = ctx
+	p, err := price.Get(priceID, params)
+	if err != nil {
+		return 0, fmt.Errorf("stripe: get price %s: %w", priceID, err)
+	}
+	if p.UnitAmount <= 0 {
+		return 0, fmt.Errorf("stripe: price %s has no unit amount to take an application fee from", priceID)
+	}
+	return p.UnitAmount, nil
+}
@@ -0,0 +1,78 @@
+package stripe
+
+import (
+	"context"
+	"testing"
+
+	stripeapi "github.com/stripe/stripe-go/v72"
+
+	"github.com/CedrosPay/server/internal/callbacks"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func TestDestinationApplicationFee(t *testing.T) {
+	tests := []struct {
+		percent float64
+		amount  int64
+		want    int64
+	}{
+		{percent: 10, amount: 1000, want: 100},
+		{percent: 2.9, amount: 1999, want: 58},
+		{percent: 0, amount: 1000, want: 0},
+		{percent: 100, amount: 1000, want: 1000},
+	}
+	for _, tt := range tests {
+		if got := (Destination{Account: "acct_1", FeePercent: tt.percent}).applicationFee(tt.amount); got != tt.want {
+			t.Errorf("%v%% of %d = %d, want %d", tt.percent, tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestApplyDestination(t *testing.T) {
+	d := Destination{Account: "acct_seller", FeePercent: 5}
+
+	payment := &stripeapi.CheckoutSessionParams{}
+	payment.Metadata = map[string]string{}
+	applyCheckoutDestination(payment, d, 2000)
+	if data := payment.PaymentIntentData; data == nil || *data.ApplicationFeeAmount != 100 || *data.TransferData.Destination != "acct_seller" {
+		t.Errorf("payment intent data = %+v, want 100 fee to acct_seller", data)
+	}
+	if payment.Metadata["connected_account"] != "acct_seller" || payment.Metadata["application_fee_cents"] != "100" {
+		t.Errorf("metadata = %v, want acct_seller and 100", payment.Metadata)
+	}
+
+	subscription := &stripeapi.CheckoutSessionParams{
+		SubscriptionData: &stripeapi.CheckoutSessionSubscriptionDataParams{TrialPeriodDays: stripeapi.Int64(14)},
+	}
+	subscription.Metadata = map[string]string{}
+	applySubscriptionDestination(subscription, d)
+	data := subscription.SubscriptionData
+	if *data.TrialPeriodDays != 14 || *data.ApplicationFeePercent != 5 || *data.TransferData.Destination != "acct_seller" {
+		t.Errorf("subscription data = %+v, want the trial kept and 5%% to acct_seller", data)
+	}
+}
+
+func TestHandleCompletion_ConnectedAccount(t *testing.T) {
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	client := &Client{store: store, notify: callbacks.NoopNotifier{}}
+
+	err := client.HandleCompletion(context.Background(), WebhookEvent{
+		Type:        "checkout.session.completed",
+		SessionID:   "cs_connect",
+		ResourceID:  "article-1",
+		Metadata:    map[string]string{"resource_id": "article-1", "connected_account": "acct_seller", "application_fee_cents": "150"},
+		AmountTotal: 1500,
+		Currency:    "usd",
+	})
+	if err != nil {
+		t.Fatalf("HandleCompletion error: %v", err)
+	}
+	tx, err := store.GetPayment(context.Background(), "stripe:cs_connect")
+	if err != nil {
+		t.Fatalf("GetPayment error: %v", err)
+	}
+	if tx.Metadata["connected_account"] != "acct_seller" || tx.Metadata["application_fee"] != "1.50" {
+		t.Errorf("metadata = %v, want acct_seller with a 1.50 fee", tx.Metadata)
+	}
+}
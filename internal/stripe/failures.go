@@ -0,0 +1,102 @@
+package stripe
+
+import (
+	"context"
+	"time"
+
+	stripeapi "github.com/stripe/stripe-go/v72"
+
+	"github.com/CedrosPay/server/internal/callbacks"
+)
+
+// Failure codes reported for Checkout sessions, which carry no Stripe error of their own.
+const (
+	failureCheckoutExpired    = "checkout_expired"
+	failureAsyncPaymentFailed = "async_payment_failed"
+)
+
+// IsFailureEvent reports whether eventType is a Stripe payment failure that HandleFailure
+// reports: a declined Payment Element charge, a delayed payment method that failed, or a
+// Checkout session abandoned until it expired.
+func IsFailureEvent(eventType string) bool {
+	switch eventType {
+	case "payment_intent.payment_failed", "checkout.session.async_payment_failed", "checkout.session.expired":
+		return true
+	default:
+		return false
+	}
+}
+
+// failedPaymentIntentEvent normalises a payment_intent.payment_failed event. As for succeeded
+// intents, only those created by CreatePaymentIntent get a ResourceID; Checkout reports its
+// declines inline and the session expires if the customer gives up.
+func failedPaymentIntentEvent(eventType string, raw []byte) (WebhookEvent, error) {
+	var intent stripeapi.PaymentIntent
+	if err := jsonExtract(raw, &intent); err != nil {
+		return WebhookEvent{}, err
+	}
+	resourceID := intent.Metadata["resource_id"]
+	if resourceID == "" {
+		return WebhookEvent{Type: eventType}, nil
+	}
+	event := WebhookEvent{
+		Type:            eventType,
+		PaymentIntentID: intent.ID,
+		ResourceID:      resourceID,
+		Customer:        intent.ReceiptEmail,
+		CustomerID:      customerID(intent.Customer),
+		Metadata:        intent.Metadata,
+		AmountTotal:     intent.Amount,
+		Currency:        string(intent.Currency),
+		FailureCode:     "payment_failed",
+	}
+	if lastErr := intent.LastPaymentError; lastErr != nil {
+		event.FailureCode = firstNonEmpty(string(lastErr.DeclineCode), string(lastErr.Code), event.FailureCode)
+		event.FailureMessage = lastErr.Msg
+	}
+	return event, nil
+}
+
+// failedCheckoutEvent normalises a checkout.session.async_payment_failed or
+// checkout.session.expired event. Sessions for a cart are reported under the cart ID.
+func failedCheckoutEvent(eventType string, raw []byte) (WebhookEvent, error) {
+	var checkout stripeapi.CheckoutSession
+	if err := jsonExtract(raw, &checkout); err != nil {
+		return WebhookEvent{}, err
+	}
+	resourceID := firstNonEmpty(checkout.Metadata["resource_id"], checkout.Metadata["resourceId"], checkout.Metadata["cart_id"])
+	if resourceID == "" {
+		return WebhookEvent{Type: eventType}, nil
+	}
+	event := WebhookEvent{
+		Type:        eventType,
+		SessionID:   checkout.ID,
+		ResourceID:  resourceID,
+		Customer:    checkout.CustomerEmail,
+		CustomerID:  customerID(checkout.Customer),
+		Metadata:    checkout.Metadata,
+		AmountTotal: checkout.AmountTotal,
+		Currency:    string(checkout.Currency),
+		FailureCode: failureAsyncPaymentFailed,
+	}
+	if eventType == "checkout.session.expired" {
+		event.FailureCode = failureCheckoutExpired
+	}
+	return event, nil
+}
+
+// HandleFailure triggers the payment failed callback for a failed or abandoned Stripe payment.
+// Nothing is recorded, so a later successful attempt for the same resource is unaffected.
+func (c *Client) HandleFailure(ctx context.Context, event WebhookEvent) {
+	c.notify.PaymentFailed(ctx, callbacks.PaymentFailedEvent{
+		ResourceID:            event.ResourceID,
+		Method:                "stripe",
+		ErrorCode:             event.FailureCode,
+		ErrorMessage:          event.FailureMessage,
+		StripeSessionID:       event.SessionID,
+		StripePaymentIntentID: event.PaymentIntentID,
+		StripeCustomer:        event.Customer,
+		Metadata:              event.Metadata,
+		FailedAt:              time.Now().UTC(),
+	})
+}
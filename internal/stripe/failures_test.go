@@ -0,0 +1,100 @@
+package stripe
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stripe/stripe-go/v72/webhook"
+
+	"github.com/CedrosPay/server/internal/callbacks"
+	"github.com/CedrosPay/server/internal/config"
+)
+
+// failureRecorder records payment failure callbacks.
+type failureRecorder struct {
+	callbacks.NoopNotifier
+	failures []callbacks.PaymentFailedEvent
+}
+
+func (r *failureRecorder) PaymentFailed(_ context.Context, event callbacks.PaymentFailedEvent) {
+	r.failures = append(r.failures, event)
+}
+
+func TestParseWebhook_PaymentFailed(t *testing.T) {
+	const secret = "whsec_test"
+
+	tests := []struct {
+		name       string
+		eventType  string
+		object     string
+		wantCode   string
+		wantID     string
+		wantIgnore bool
+	}{
+		{
+			name:      "declined payment element intent",
+			eventType: "payment_intent.payment_failed",
+			object:    `{"id":"pi_123","object":"payment_intent","amount":900,"currency":"usd","metadata":{"resource_id":"article-1"},"last_payment_error":{"code":"card_declined","decline_code":"insufficient_funds","message":"Your card has insufficient funds."}}`,
+			wantCode:  "insufficient_funds",
+			wantID:    "article-1",
+		},
+		{
+			name:       "checkout session intent",
+			eventType:  "payment_intent.payment_failed",
+			object:     `{"id":"pi_456","object":"payment_intent","amount":500,"currency":"usd","metadata":{}}`,
+			wantIgnore: true,
+		},
+		{
+			name:      "expired cart checkout",
+			eventType: "checkout.session.expired",
+			object:    `{"id":"cs_123","object":"checkout.session","amount_total":1500,"currency":"usd","metadata":{"cart_id":"cart_1"}}`,
+			wantCode:  failureCheckoutExpired,
+			wantID:    "cart_1",
+		},
+		{
+			name:      "failed delayed payment",
+			eventType: "checkout.session.async_payment_failed",
+			object:    `{"id":"cs_456","object":"checkout.session","amount_total":900,"currency":"usd","metadata":{"resource_id":"article-1"}}`,
+			wantCode:  failureAsyncPaymentFailed,
+			wantID:    "article-1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &failureRecorder{}
+			client := &Client{cfg: config.StripeConfig{WebhookSecret: secret}, notify: recorder}
+			payload := []byte(fmt.Sprintf(`{"id":"evt_1","object":"event","type":%q,"data":{"object":%s}}`, tt.eventType, tt.object))
+			now := time.Now()
+			header := fmt.Sprintf("t=%d,v1=%s", now.Unix(), hex.EncodeToString(webhook.ComputeSignature(now, payload, secret)))
+
+			event, err := client.ParseWebhook(context.Background(), payload, header)
+			if err != nil {
+				t.Fatalf("ParseWebhook error: %v", err)
+			}
+			if !IsFailureEvent(event.Type) {
+				t.Fatalf("IsFailureEvent(%q) = false", event.Type)
+			}
+			if tt.wantIgnore {
+				if event.ResourceID != "" {
+					t.Errorf("event = %+v, want only the type", event)
+				}
+				return
+			}
+			if event.ResourceID != tt.wantID || event.FailureCode != tt.wantCode {
+				t.Fatalf("event = %+v, want %s failed with %s", event, tt.wantID, tt.wantCode)
+			}
+
+			client.HandleFailure(context.Background(), event)
+			if len(recorder.failures) != 1 {
+				t.Fatalf("callbacks = %d, want 1", len(recorder.failures))
+			}
+			got := recorder.failures[0]
+			if got.Method != "stripe" || got.ResourceID != tt.wantID || got.ErrorCode != tt.wantCode {
+				t.Errorf("callback = %+v, want stripe failure of %s with %s", got, tt.wantID, tt.wantCode)
+			}
+		})
+	}
+}
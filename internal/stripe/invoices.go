@@ -0,0 +1,198 @@
+package stripe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	stripeapi "github.com/stripe/stripe-go/v72"
+	"github.com/stripe/stripe-go/v72/customer"
+	"github.com/stripe/stripe-go/v72/invoice"
+	"github.com/stripe/stripe-go/v72/invoiceitem"
+)
+
+// DefaultInvoiceDaysUntilDue is how long a customer has to pay an invoice by default.
+const DefaultInvoiceDaysUntilDue = 30
+
+// InvoiceLine is a line of a one-off invoice, priced by the server.
+type InvoiceLine struct {
+	Description string
+	UnitAmount  int64 // In the currency's smallest unit (cents)
+	Quantity    int64
+}
+
+// CreateInvoiceRequest bills a resource or a cart quote with a Stripe invoice emailed to the
+// customer, instead of a Checkout session.
+type CreateInvoiceRequest struct {
+	ResourceID     string // Resource, or cart ID when CartID is set
+	CartID         string // Cart quote the invoice pays, if any
+	CustomerEmail  string // Required: the invoice is sent to this address
+	Currency       string
+	Lines          []InvoiceLine
+	Discount       int64 // Taken off the total with a single-use Stripe coupon
+	DaysUntilDue   int64 // Zero uses DefaultInvoiceDaysUntilDue
+	Metadata       map[string]string
+	Description    string
+	CouponCode     string      // Coupon applied (for metadata tracking)
+	OriginalAmount int64       // Price before coupons (for metadata tracking)
+	DiscountAmount int64       // Discount taken off the lines (for metadata tracking)
+	Destination    Destination // Connected account the payment is transferred to, if any
+}
+
+// CreateInvoice creates, finalizes, and sends a one-off invoice. Its metadata carries
+// resource_id (and cart_id for carts), so the invoice.paid webhook records the payment the same
+// way a completed Checkout session is.
+func (c *Client) CreateInvoice(ctx context.Context, req CreateInvoiceRequest) (*stripeapi.Invoice, error) {
+	if req.CustomerEmail == "" {
+		return nil, errors.New("stripe: invoice requires a customer email")
+	}
+	if len(req.Lines) == 0 {
+		return nil, errors.New("stripe: invoice requires at least one line")
+	}
+	var total int64
+	for _, line := range req.Lines {
+		total += line.UnitAmount * line.Quantity
+	}
+	total -= req.Discount
+	if total <= 0 {
+		return nil, errors.New("stripe: invoice amount must be positive")
+	}
+
+	customerID, err := c.invoiceCustomer(ctx, req.CustomerEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := convertMetadata(req.Metadata, req.ResourceID)
+	if req.CartID != "" {
+		metadata["cart_id"] = req.CartID
+	}
+	if req.CouponCode != "" {
+		metadata["coupon_code"] = req.CouponCode
+	}
+	if req.OriginalAmount > 0 {
+		metadata["original_amount_cents"] = fmt.Sprintf("%d", req.OriginalAmount)
+	}
+	if req.DiscountAmount > 0 {
+		metadata["discount_amount_cents"] = fmt.Sprintf("%d", req.DiscountAmount)
+	}
+
+	daysUntilDue := req.DaysUntilDue
+	if daysUntilDue <= 0 {
+		daysUntilDue = DefaultInvoiceDaysUntilDue
+	}
+	params := &stripeapi.InvoiceParams{
+		Customer:                    stripeapi.String(customerID),
+		Currency:                    stripeapi.String(req.Currency),
+		CollectionMethod:            stripeapi.String(string(stripeapi.InvoiceCollectionMethodSendInvoice)),
+		DaysUntilDue:                stripeapi.Int64(daysUntilDue),
+		AutoAdvance:                 stripeapi.Bool(false),
+		PendingInvoiceItemsBehavior: stripeapi.String("exclude"),
+	}
+	params.Metadata = metadata
+	if req.Description != "" {
+		params.Description = stripeapi.String(req.Description)
+	}
+	if c.cfg.AutomaticTax {
+		params.AutomaticTax = &stripeapi.InvoiceAutomaticTaxParams{Enabled: stripeapi.Bool(true)}
+	}
+	if req.Destination.Account != "" {
+		fee := req.Destination.applicationFee(total)
+		params.ApplicationFeeAmount = stripeapi.Int64(fee)
+		params.TransferData = &stripeapi.InvoiceTransferDataParams{
+			Destination: stripeapi.String(req.Destination.Account),
+		}
+		req.Destination.recordDestination(params.Metadata, fee)
+	}
+	if req.Discount > 0 {
+		discountID, err := createCartDiscount(ctx, req.Discount, req.Currency)
+		if err != nil {
+			return nil, err
+		}
+		params.Discounts = []*stripeapi.InvoiceDiscountParams{{Coupon: stripeapi.String(discountID)}}
+	}
+
+	params.Context = ctx
+	draft, err := invoice.New(params)
+	if err != nil {
+		return nil, fmt.Errorf("stripe: create invoice: %w", err)
+	}
+	for _, line := range req.Lines {
+		itemParams := &stripeapi.InvoiceItemParams{
+			Customer:    stripeapi.String(customerID),
+			Invoice:     stripeapi.String(draft.ID),
+			Currency:    stripeapi.String(req.Currency),
+			Description: stripeapi.String(line.Description),
+			UnitAmount:  stripeapi.Int64(line.UnitAmount),
+			Quantity:    stripeapi.Int64(line.Quantity),
+		}
+		if c.cfg.TaxRateID != "" {
+			itemParams.TaxRates = []*string{stripeapi.String(c.cfg.TaxRateID)}
+		}
+		itemParams.Context = ctx
+		if _, err := invoiceitem.New(itemParams); err != nil {
+			return nil, fmt.Errorf("stripe: add invoice line: %w", err)
+		}
+	}
+
+	finalizeParams := &stripeapi.InvoiceFinalizeParams{}
+	finalizeParams.Context = ctx
+	if _, err := invoice.FinalizeInvoice(draft.ID, finalizeParams); err != nil {
+		return nil, fmt.Errorf("stripe: finalize invoice: %w", err)
+	}
+	sendParams := &stripeapi.InvoiceSendParams{}
+	sendParams.Context = ctx
+	sent, err := invoice.SendInvoice(draft.ID, sendParams)
+	if err != nil {
+		return nil, fmt.Errorf("stripe: send invoice: %w", err)
+	}
+	return sent, nil
+}
+
+// invoiceCustomer returns the Stripe customer with email, creating one if there is none.
+// Invoices, unlike Checkout sessions, must belong to a customer.
+func (c *Client) invoiceCustomer(ctx context.Context, email string) (string, error) {
+	listParams := &stripeapi.CustomerListParams{Email: stripeapi.String(email)}
+	listParams.Filters.AddFilter("limit", "", "1")
+	listParams.Context = ctx
+	iter := customer.List(listParams)
+	if iter.Next() {
+		return iter.Customer().ID, nil
+	}
+	if err := iter.Err(); err != nil {
+		return "", fmt.Errorf("stripe: find customer: %w", err)
+	}
+
+	params := &stripeapi.CustomerParams{Email: stripeapi.String(email)}
+	params.Context = ctx
+	created, err := customer.New(params)
+	if err != nil {
+		return "", fmt.Errorf("stripe: create customer: %w", err)
+	}
+	return created.ID, nil
+}
+
+// invoiceEvent normalises an invoice.paid event. Invoices without resource_id metadata were
+// not created by CreateInvoice (subscription renewals have their own) and come back without a
+// ResourceID to be ignored.
+func invoiceEvent(eventType string, raw []byte) (WebhookEvent, error) {
+	var inv stripeapi.Invoice
+	if err := jsonExtract(raw, &inv); err != nil {
+		return WebhookEvent{}, err
+	}
+	resourceID := inv.Metadata["resource_id"]
+	if resourceID == "" {
+		return WebhookEvent{Type: eventType}, nil
+	}
+	return WebhookEvent{
+		Type:        eventType,
+		InvoiceID:   inv.ID,
+		ResourceID:  resourceID,
+		Customer:    inv.CustomerEmail,
+		CustomerID:  customerID(inv.Customer),
+		Metadata:    inv.Metadata,
+		AmountTotal: inv.AmountPaid,
+		TaxAmount:   inv.Tax,
+		Currency:    string(inv.Currency),
+	}, nil
+}
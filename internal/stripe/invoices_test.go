@@ -0,0 +1,133 @@
+package stripe
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stripe/stripe-go/v72/webhook"
+
+	"github.com/CedrosPay/server/internal/callbacks"
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func TestParseWebhook_InvoicePaid(t *testing.T) {
+	const secret = "whsec_test"
+	client := &Client{cfg: config.StripeConfig{WebhookSecret: secret}}
+
+	tests := []struct {
+		name       string
+		invoice    string
+		want       WebhookEvent
+		wantIgnore bool
+	}{
+		{
+			name:    "one-off invoice",
+			invoice: `{"id":"in_123","object":"invoice","amount_paid":1080,"tax":80,"currency":"usd","customer":"cus_1","customer_email":"a@example.com","metadata":{"resource_id":"article-1","coupon_code":"SAVE10"}}`,
+			want: WebhookEvent{
+				Type:        "invoice.paid",
+				InvoiceID:   "in_123",
+				ResourceID:  "article-1",
+				Customer:    "a@example.com",
+				CustomerID:  "cus_1",
+				AmountTotal: 1080,
+				TaxAmount:   80,
+				Currency:    "usd",
+			},
+		},
+		{
+			name:       "subscription invoice",
+			invoice:    `{"id":"in_456","object":"invoice","amount_paid":500,"currency":"usd","subscription":"sub_1","metadata":{}}`,
+			wantIgnore: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := []byte(fmt.Sprintf(`{"id":"evt_1","object":"event","type":"invoice.paid","data":{"object":%s}}`, tt.invoice))
+			now := time.Now()
+			header := fmt.Sprintf("t=%d,v1=%s", now.Unix(), hex.EncodeToString(webhook.ComputeSignature(now, payload, secret)))
+
+			event, err := client.ParseWebhook(context.Background(), payload, header)
+			if err != nil {
+				t.Fatalf("ParseWebhook error: %v", err)
+			}
+			if tt.wantIgnore {
+				if event.ResourceID != "" || event.InvoiceID != "" {
+					t.Errorf("event = %+v, want only the type", event)
+				}
+				return
+			}
+			if event.Type != tt.want.Type || event.InvoiceID != tt.want.InvoiceID || event.ResourceID != tt.want.ResourceID ||
+				event.Customer != tt.want.Customer || event.CustomerID != tt.want.CustomerID || event.AmountTotal != tt.want.AmountTotal || event.TaxAmount != tt.want.TaxAmount ||
+				event.Currency != tt.want.Currency {
+				t.Errorf("event = %+v, want %+v", event, tt.want)
+			}
+			if event.Metadata["coupon_code"] != "SAVE10" {
+				t.Errorf("metadata = %v, want coupon_code SAVE10", event.Metadata)
+			}
+		})
+	}
+}
+
+func TestHandleCompletion_Invoice(t *testing.T) {
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	client := &Client{store: store, notify: callbacks.NoopNotifier{}}
+
+	event := WebhookEvent{
+		Type:        "invoice.paid",
+		InvoiceID:   "in_123",
+		ResourceID:  "article-1",
+		Customer:    "a@example.com",
+		CustomerID:  "cus_1",
+		Metadata:    map[string]string{"resource_id": "article-1"},
+		AmountTotal: 1000,
+		Currency:    "usd",
+	}
+	if err := client.HandleCompletion(context.Background(), event); err != nil {
+		t.Fatalf("HandleCompletion error: %v", err)
+	}
+	// Webhook retries are ignored
+	if err := client.HandleCompletion(context.Background(), event); err != nil {
+		t.Fatalf("repeated HandleCompletion error: %v", err)
+	}
+
+	tx, err := store.GetPayment(context.Background(), "stripe:in_123")
+	if err != nil {
+		t.Fatalf("GetPayment error: %v", err)
+	}
+	if tx.ResourceID != "article-1" || tx.Amount.Atomic != 1000 || tx.Metadata["invoice_id"] != "in_123" {
+		t.Errorf("payment = %+v, want article-1 for 1000 cents with its invoice", tx)
+	}
+
+	// The payer's email and Stripe customer are linked to one customer
+	customer, err := store.FindCustomer(context.Background(), storage.IdentityStripeCustomer, "cus_1")
+	if err != nil {
+		t.Fatalf("FindCustomer error: %v", err)
+	}
+	if emails := customer.Values(storage.IdentityEmail); len(emails) != 1 || emails[0] != "a@example.com" {
+		t.Errorf("customer emails = %v, want a@example.com", emails)
+	}
+}
+
+func TestCreateInvoice_Validation(t *testing.T) {
+	client := &Client{}
+	tests := []struct {
+		name string
+		req  CreateInvoiceRequest
+	}{
+		{name: "no email", req: CreateInvoiceRequest{Lines: []InvoiceLine{{Description: "a", UnitAmount: 100, Quantity: 1}}}},
+		{name: "no lines", req: CreateInvoiceRequest{CustomerEmail: "a@example.com"}},
+		{name: "discount covers total", req: CreateInvoiceRequest{CustomerEmail: "a@example.com", Lines: []InvoiceLine{{Description: "a", UnitAmount: 100, Quantity: 1}}, Discount: 100}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := client.CreateInvoice(context.Background(), tt.req); err == nil {
+				t.Error("CreateInvoice error = nil, want a validation error")
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package stripe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	stripeapi "github.com/stripe/stripe-go/v72"
+	"github.com/stripe/stripe-go/v72/paymentintent"
+)
+
+// CreatePaymentIntentRequest captures a PaymentIntent for one resource, charged through a
+// Payment Element embedded in the merchant's own UI.
+type CreatePaymentIntentRequest struct {
+	ResourceID     string
+	AmountCents    int64 // Amount charged, after coupons
+	Currency       string
+	CustomerEmail  string // Receipt email and coupon redeemer, if given
+	Metadata       map[string]string
+	Description    string
+	CouponCode     string      // Coupon applied (for metadata tracking)
+	OriginalAmount int64       // Price before coupons (for metadata tracking)
+	DiscountAmount int64       // Discount taken off AmountCents (for metadata tracking)
+	PaymentMethods []string    // Payment method types offered; empty uses stripe.payment_methods
+	Destination    Destination // Connected account the payment is transferred to, if any
+}
+
+// CreatePaymentIntent creates a PaymentIntent whose client secret a Payment Element confirms.
+// Its metadata carries resource_id, so the payment_intent.succeeded webhook records the payment
+// the same way a completed Checkout session is.
+func (c *Client) CreatePaymentIntent(ctx context.Context, req CreatePaymentIntentRequest) (*stripeapi.PaymentIntent, error) {
+	if req.AmountCents <= 0 {
+		return nil, errors.New("stripe: payment intent amount must be positive")
+	}
+	metadata := convertMetadata(req.Metadata, req.ResourceID)
+	if req.CouponCode != "" {
+		metadata["coupon_code"] = req.CouponCode
+	}
+	if req.OriginalAmount > 0 {
+		metadata["original_amount_cents"] = fmt.Sprintf("%d", req.OriginalAmount)
+	}
+	if req.DiscountAmount > 0 {
+		metadata["discount_amount_cents"] = fmt.Sprintf("%d", req.DiscountAmount)
+	}
+
+	params := &stripeapi.PaymentIntentParams{
+		Amount:   stripeapi.Int64(req.AmountCents),
+		Currency: stripeapi.String(req.Currency),
+	}
+	setPaymentIntentMethods(params, offeredPaymentMethods(req.PaymentMethods, c.cfg.PaymentMethods))
+	params.Metadata = metadata
+	if req.Destination.Account != "" {
+		applyPaymentIntentDestination(params, req.Destination, req.AmountCents)
+	}
+	if req.Description != "" {
+		params.Description = stripeapi.String(req.Description)
+	}
+	if req.CustomerEmail != "" {
+		params.ReceiptEmail = stripeapi.String(req.CustomerEmail)
+	}
+
+	params.Context = ctx
+	intent, err := paymentintent.New(params)
+	if err != nil {
+		return nil, fmt.Errorf("stripe: create payment intent: %w", err)
+	}
+	return intent, nil
+}
+
+// paymentIntentEvent normalises a payment_intent.succeeded event. PaymentIntents without
+// resource_id metadata were not created by CreatePaymentIntent (Checkout sessions and
+// subscription invoices create their own) and come back without a ResourceID to be ignored.
+func paymentIntentEvent(eventType string, raw []byte) (WebhookEvent, error) {
+	var intent stripeapi.PaymentIntent
+	if err := jsonExtract(raw, &intent); err != nil {
+		return WebhookEvent{}, err
+	}
+	resourceID := intent.Metadata["resource_id"]
+	if resourceID == "" {
+		return WebhookEvent{Type: eventType}, nil
+	}
+	return WebhookEvent{
+		Type:            eventType,
+		PaymentIntentID: intent.ID,
+		ResourceID:      resourceID,
+		Customer:        intent.ReceiptEmail,
+		CustomerID:      customerID(intent.Customer),
+		Metadata:        intent.Metadata,
+		AmountTotal:     intent.AmountReceived,
+		Currency:        string(intent.Currency),
+	}, nil
+}
@@ -0,0 +1,104 @@
+package stripe
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stripe/stripe-go/v72/webhook"
+
+	"github.com/CedrosPay/server/internal/callbacks"
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func TestParseWebhook_PaymentIntentSucceeded(t *testing.T) {
+	const secret = "whsec_test"
+	client := &Client{cfg: config.StripeConfig{WebhookSecret: secret}}
+
+	tests := []struct {
+		name       string
+		intent     string
+		want       WebhookEvent
+		wantIgnore bool
+	}{
+		{
+			name:   "payment element intent",
+			intent: `{"id":"pi_123","object":"payment_intent","amount":900,"amount_received":900,"currency":"usd","receipt_email":"a@example.com","metadata":{"resource_id":"article-1","coupon_code":"SAVE10"}}`,
+			want: WebhookEvent{
+				Type:            "payment_intent.succeeded",
+				PaymentIntentID: "pi_123",
+				ResourceID:      "article-1",
+				Customer:        "a@example.com",
+				AmountTotal:     900,
+				Currency:        "usd",
+			},
+		},
+		{
+			name:       "checkout session intent",
+			intent:     `{"id":"pi_456","object":"payment_intent","amount_received":500,"currency":"usd","metadata":{}}`,
+			wantIgnore: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := []byte(fmt.Sprintf(`{"id":"evt_1","object":"event","type":"payment_intent.succeeded","data":{"object":%s}}`, tt.intent))
+			now := time.Now()
+			header := fmt.Sprintf("t=%d,v1=%s", now.Unix(), hex.EncodeToString(webhook.ComputeSignature(now, payload, secret)))
+
+			event, err := client.ParseWebhook(context.Background(), payload, header)
+			if err != nil {
+				t.Fatalf("ParseWebhook error: %v", err)
+			}
+			if tt.wantIgnore {
+				if event.ResourceID != "" || event.PaymentIntentID != "" {
+					t.Errorf("event = %+v, want only the type", event)
+				}
+				return
+			}
+			if event.Type != tt.want.Type || event.PaymentIntentID != tt.want.PaymentIntentID || event.ResourceID != tt.want.ResourceID ||
+				event.Customer != tt.want.Customer || event.AmountTotal != tt.want.AmountTotal || event.Currency != tt.want.Currency {
+				t.Errorf("event = %+v, want %+v", event, tt.want)
+			}
+			if event.Metadata["coupon_code"] != "SAVE10" {
+				t.Errorf("metadata = %v, want coupon_code SAVE10", event.Metadata)
+			}
+		})
+	}
+}
+
+func TestHandleCompletion_PaymentIntent(t *testing.T) {
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	client := &Client{store: store, notify: callbacks.NoopNotifier{}}
+
+	event := WebhookEvent{
+		Type:            "payment_intent.succeeded",
+		PaymentIntentID: "pi_123",
+		ResourceID:      "article-1",
+		Customer:        "a@example.com",
+		Metadata:        map[string]string{"resource_id": "article-1", "coupon_code": "SAVE10", "original_amount_cents": "1000", "discount_amount_cents": "100"},
+		AmountTotal:     900,
+		Currency:        "usd",
+	}
+	if err := client.HandleCompletion(context.Background(), event); err != nil {
+		t.Fatalf("HandleCompletion error: %v", err)
+	}
+	// Webhook retries are ignored
+	if err := client.HandleCompletion(context.Background(), event); err != nil {
+		t.Fatalf("repeated HandleCompletion error: %v", err)
+	}
+
+	tx, err := store.GetPayment(context.Background(), "stripe:pi_123")
+	if err != nil {
+		t.Fatalf("GetPayment error: %v", err)
+	}
+	if tx.ResourceID != "article-1" || tx.Amount.Atomic != 900 || tx.Metadata["payment_intent_id"] != "pi_123" {
+		t.Errorf("payment = %+v, want article-1 for 900 cents with its payment intent", tx)
+	}
+	if tx.Metadata["coupon_codes"] != "SAVE10" || tx.Metadata["discounted_amount"] != "9.00" {
+		t.Errorf("metadata = %v, want SAVE10 discounted to 9.00", tx.Metadata)
+	}
+}
@@ -0,0 +1,75 @@
+package stripe
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	stripeapi "github.com/stripe/stripe-go/v72"
+
+	"github.com/CedrosPay/server/internal/config"
+)
+
+// ErrPaymentMethodNotAllowed indicates a checkout asked for a payment method its resource or
+// the server configuration does not offer.
+var ErrPaymentMethodNotAllowed = errors.New("stripe: payment method not allowed")
+
+// defaultPaymentMethods are offered when neither the resource nor stripe.payment_methods lists any.
+var defaultPaymentMethods = []string{"card"}
+
+// ResolvePaymentMethods picks the payment methods a checkout offers: the resource's list, else
+// the configured one, else card. requested narrows them to a subset; any known method is a
+// subset of "automatic".
+func ResolvePaymentMethods(configured, resource, requested []string) ([]string, error) {
+	allowed := offeredPaymentMethods(resource, configured)
+	if len(requested) == 0 {
+		return allowed, nil
+	}
+	if err := config.ValidateStripePaymentMethods(requested); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPaymentMethodNotAllowed, err)
+	}
+	if isAutomatic(allowed) {
+		return requested, nil
+	}
+	for _, method := range requested {
+		if !slices.Contains(allowed, method) {
+			return nil, fmt.Errorf("%w: %s (offered: %v)", ErrPaymentMethodNotAllowed, method, allowed)
+		}
+	}
+	return requested, nil
+}
+
+// offeredPaymentMethods returns the first non-empty list, or the default.
+func offeredPaymentMethods(lists ...[]string) []string {
+	for _, methods := range lists {
+		if len(methods) > 0 {
+			return methods
+		}
+	}
+	return defaultPaymentMethods
+}
+
+func isAutomatic(methods []string) bool {
+	return len(methods) == 1 && methods[0] == config.StripePaymentMethodsAutomatic
+}
+
+// checkoutPaymentMethodTypes returns a Checkout session's payment_method_types for methods,
+// or nil for "automatic" so Stripe offers the methods enabled in the Dashboard.
+func checkoutPaymentMethodTypes(methods []string) []*string {
+	if isAutomatic(methods) {
+		return nil
+	}
+	return stripeapi.StringSlice(methods)
+}
+
+// setPaymentIntentMethods offers methods on a PaymentIntent, using automatic payment methods
+// for "automatic".
+func setPaymentIntentMethods(params *stripeapi.PaymentIntentParams, methods []string) {
+	if isAutomatic(methods) {
+		params.AutomaticPaymentMethods = &stripeapi.PaymentIntentAutomaticPaymentMethodsParams{
+			Enabled: stripeapi.Bool(true),
+		}
+		return
+	}
+	params.PaymentMethodTypes = stripeapi.StringSlice(methods)
+}
@@ -0,0 +1,64 @@
+package stripe
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	stripeapi "github.com/stripe/stripe-go/v72"
+)
+
+func TestResolvePaymentMethods(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured []string
+		resource   []string
+		requested  []string
+		want       []string
+		wantErr    bool
+	}{
+		{name: "default card", want: []string{"card"}},
+		{name: "configured", configured: []string{"card", "sepa_debit"}, want: []string{"card", "sepa_debit"}},
+		{name: "resource overrides configured", configured: []string{"card"}, resource: []string{"ideal", "card"}, want: []string{"ideal", "card"}},
+		{name: "requested subset", configured: []string{"card", "sepa_debit"}, requested: []string{"sepa_debit"}, want: []string{"sepa_debit"}},
+		{name: "requested not offered", configured: []string{"card"}, requested: []string{"pix"}, wantErr: true},
+		{name: "requested not offered by resource", configured: []string{"card", "pix"}, resource: []string{"card"}, requested: []string{"pix"}, wantErr: true},
+		{name: "automatic allows known methods", configured: []string{"automatic"}, requested: []string{"us_bank_account"}, want: []string{"us_bank_account"}},
+		{name: "unknown method", configured: []string{"automatic"}, requested: []string{"bitcoin"}, wantErr: true},
+		{name: "automatic mixed with methods", configured: []string{"automatic"}, requested: []string{"automatic", "card"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolvePaymentMethods(tt.configured, tt.resource, tt.requested)
+			if tt.wantErr {
+				if !errors.Is(err, ErrPaymentMethodNotAllowed) {
+					t.Fatalf("error = %v, want ErrPaymentMethodNotAllowed", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("methods = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetPaymentIntentMethods(t *testing.T) {
+	automatic := &stripeapi.PaymentIntentParams{}
+	setPaymentIntentMethods(automatic, []string{"automatic"})
+	if automatic.AutomaticPaymentMethods == nil || !*automatic.AutomaticPaymentMethods.Enabled || automatic.PaymentMethodTypes != nil {
+		t.Errorf("automatic params = %+v, want automatic payment methods only", automatic)
+	}
+
+	listed := &stripeapi.PaymentIntentParams{}
+	setPaymentIntentMethods(listed, []string{"card", "sepa_debit"})
+	if listed.AutomaticPaymentMethods != nil || len(listed.PaymentMethodTypes) != 2 || *listed.PaymentMethodTypes[1] != "sepa_debit" {
+		t.Errorf("listed params = %+v, want card and sepa_debit", listed)
+	}
+	if checkoutPaymentMethodTypes([]string{"automatic"}) != nil {
+		t.Error("automatic checkout should leave payment_method_types unset")
+	}
+}
@@ -11,8 +11,10 @@ import (
 	stripeapi "github.com/stripe/stripe-go/v72"
 	portalsession "github.com/stripe/stripe-go/v72/billingportal/session"
 	checkoutsession "github.com/stripe/stripe-go/v72/checkout/session"
+	"github.com/stripe/stripe-go/v72/invoice"
 	stripesub "github.com/stripe/stripe-go/v72/sub"
 
+	"github.com/CedrosPay/server/internal/callbacks"
 	"github.com/CedrosPay/server/internal/subscriptions"
 )
 
@@ -27,13 +29,16 @@ type SubscriptionRepository interface {
 
 // CreateSubscriptionRequest contains parameters for creating a subscription checkout.
 type CreateSubscriptionRequest struct {
-	ProductID     string
-	PriceID       string            // Stripe recurring price ID
-	CustomerEmail string
-	Metadata      map[string]string
-	SuccessURL    string
-	CancelURL     string
-	TrialDays     int
+	ProductID      string
+	PriceID        string // Stripe recurring price ID
+	CustomerEmail  string
+	Metadata       map[string]string
+	SuccessURL     string
+	CancelURL      string
+	TrialDays      int
+	PaymentMethods []string    // Payment method types offered; empty uses stripe.payment_methods
+	Destination    Destination // Connected account each invoice is transferred to, if any
+	Quantity       int64       // Seats for per-seat prices; 0 means 1
 }
 
 // CreateSubscriptionCheckout creates a Stripe Checkout session for a subscription.
@@ -42,22 +47,34 @@ func (c *Client) CreateSubscriptionCheckout(ctx context.Context, req CreateSubsc
 		return nil, errors.New("stripe: subscription price_id is required")
 	}
 
+	if req.Quantity < 0 {
+		return nil, errors.New("stripe: subscription quantity must be positive")
+	}
+	quantity := req.Quantity
+	if quantity == 0 {
+		quantity = 1
+	}
+
 	metadata := convertMetadata(req.Metadata, req.ProductID)
 	metadata["subscription"] = "true"
 
 	params := &stripeapi.CheckoutSessionParams{
 		Mode:               stripeapi.String(string(stripeapi.CheckoutSessionModeSubscription)),
-		PaymentMethodTypes: stripeapi.StringSlice([]string{"card"}),
+		PaymentMethodTypes: checkoutPaymentMethodTypes(offeredPaymentMethods(req.PaymentMethods, c.cfg.PaymentMethods)),
 		SuccessURL:         stripeapi.String(firstNonEmpty(req.SuccessURL, c.cfg.SuccessURL)),
 		CancelURL:          stripeapi.String(firstNonEmpty(req.CancelURL, c.cfg.CancelURL)),
 		LineItems: []*stripeapi.CheckoutSessionLineItemParams{
 			{
 				Price:    stripeapi.String(req.PriceID),
-				Quantity: stripeapi.Int64(1),
+				Quantity: stripeapi.Int64(quantity),
 			},
 		},
 	}
 	params.Metadata = metadata
+	if c.cfg.AutomaticTax {
+		// Also taxes the subscription's renewal invoices
+		applyAutomaticTax(params, c.cfg.GetTaxBehavior())
+	}
 
 	if req.CustomerEmail != "" {
 		params.CustomerEmail = stripeapi.String(req.CustomerEmail)
@@ -69,7 +86,11 @@ func (c *Client) CreateSubscriptionCheckout(ctx context.Context, req CreateSubsc
 			TrialPeriodDays: stripeapi.Int64(int64(req.TrialDays)),
 		}
 	}
+	if req.Destination.Account != "" {
+		applySubscriptionDestination(params, req.Destination)
+	}
 
+	params.Context = ctx
 	s, err := checkoutsession.New(params)
 	if err != nil {
 		return nil, fmt.Errorf("stripe: create subscription checkout: %w", err)
@@ -112,8 +133,10 @@ func (c *Client) GetSubscription(ctx context.Context, stripeSubID string) (*stri
 // UpdateSubscriptionRequest contains parameters for updating a Stripe subscription.
 type UpdateSubscriptionRequest struct {
 	SubscriptionID    string
-	NewPriceID        string // New price ID for plan change
+	NewPriceID        string // New price ID for plan change (empty keeps the current price)
+	Quantity          int64  // New seat count (0 keeps the current one)
 	ProrationBehavior string // "create_prorations", "none", "always_invoice"
+	ProrationDate     int64  // Optional: Unix time to prorate from, as returned by PreviewProration
 	Metadata          map[string]string
 }
 
@@ -129,6 +152,9 @@ func (c *Client) UpdateSubscription(ctx context.Context, req UpdateSubscriptionR
 	if req.SubscriptionID == "" {
 		return nil, errors.New("stripe: subscription_id is required")
 	}
+	if req.NewPriceID == "" && req.Quantity <= 0 {
+		return nil, errors.New("stripe: new price or quantity is required")
+	}
 
 	// First, get the current subscription to find the subscription item ID
 	currentSub, err := stripesub.Get(req.SubscriptionID, nil)
@@ -145,10 +171,7 @@ func (c *Client) UpdateSubscription(ctx context.Context, req UpdateSubscriptionR
 	// Build update params
 	params := &stripeapi.SubscriptionParams{
 		Items: []*stripeapi.SubscriptionItemsParams{
-			{
-				ID:    stripeapi.String(itemID),
-				Price: stripeapi.String(req.NewPriceID),
-			},
+			subscriptionItemChange(itemID, req.NewPriceID, req.Quantity),
 		},
 	}
 
@@ -162,6 +185,10 @@ func (c *Client) UpdateSubscription(ctx context.Context, req UpdateSubscriptionR
 		// Default to create_prorations
 		params.ProrationBehavior = stripeapi.String(string(stripeapi.SubscriptionProrationBehaviorCreateProrations))
 	}
+	// Prorating from the previewed time charges exactly the previewed amount
+	if req.ProrationDate > 0 {
+		params.ProrationDate = stripeapi.Int64(req.ProrationDate)
+	}
 
 	// Add metadata if provided
 	if req.Metadata != nil {
@@ -185,10 +212,32 @@ func (c *Client) UpdateSubscription(ctx context.Context, req UpdateSubscriptionR
 	return result, nil
 }
 
-// PreviewProration calculates the proration amount for a plan change without applying it.
-func (c *Client) PreviewProration(ctx context.Context, subscriptionID, newPriceID string) (*ProrationPreview, error) {
+// subscriptionItemChange updates a subscription item's price and seat count; an empty price or
+// zero quantity leaves that part unchanged.
+func subscriptionItemChange(itemID, priceID string, quantity int64) *stripeapi.SubscriptionItemsParams {
+	item := &stripeapi.SubscriptionItemsParams{ID: stripeapi.String(itemID)}
+	if priceID != "" {
+		item.Price = stripeapi.String(priceID)
+	}
+	if quantity > 0 {
+		item.Quantity = stripeapi.Int64(quantity)
+	}
+	return item
+}
+
+// PreviewProration calculates the proration amount for a plan or seat change without applying
+// it, from the subscription's upcoming invoice as it would be after the change. A zero quantity
+// keeps the current seat count. Passing the returned EffectiveDate to UpdateSubscription as
+// ProrationDate charges exactly the previewed amount.
+func (c *Client) PreviewProration(ctx context.Context, subscriptionID, newPriceID string, quantity int64) (*ProrationPreview, error) {
+	if subscriptionID == "" || newPriceID == "" {
+		return nil, errors.New("stripe: subscription_id and new price are required")
+	}
+
 	// Get current subscription
-	currentSub, err := stripesub.Get(subscriptionID, nil)
+	getParams := &stripeapi.SubscriptionParams{}
+	getParams.Context = ctx
+	currentSub, err := stripesub.Get(subscriptionID, getParams)
 	if err != nil {
 		return nil, fmt.Errorf("stripe: get subscription: %w", err)
 	}
@@ -196,52 +245,54 @@ func (c *Client) PreviewProration(ctx context.Context, subscriptionID, newPriceI
 	if currentSub.Items == nil || len(currentSub.Items.Data) == 0 {
 		return nil, errors.New("stripe: subscription has no items")
 	}
+	if currentSub.Customer == nil {
+		return nil, errors.New("stripe: subscription has no customer")
+	}
 	itemID := currentSub.Items.Data[0].ID
 
-	// Create an invoice preview to see what the proration would be
-	// Note: This uses Stripe's upcoming invoice API
+	// Stripe prorates to the second; pin the time so the update can reuse it
+	prorationDate := time.Now().Unix()
 	params := &stripeapi.InvoiceParams{
 		Customer:     stripeapi.String(currentSub.Customer.ID),
 		Subscription: stripeapi.String(subscriptionID),
 		SubscriptionItems: []*stripeapi.SubscriptionItemsParams{
-			{
-				ID:    stripeapi.String(itemID),
-				Price: stripeapi.String(newPriceID),
-			},
+			subscriptionItemChange(itemID, newPriceID, quantity),
 		},
 		SubscriptionProrationBehavior: stripeapi.String(string(stripeapi.SubscriptionProrationBehaviorCreateProrations)),
+		SubscriptionProrationDate:     stripeapi.Int64(prorationDate),
 	}
+	params.Context = ctx
 
-	// Get upcoming invoice preview
-	invoice, err := c.previewInvoice(params)
+	upcoming, err := invoice.GetNext(params)
 	if err != nil {
 		return nil, fmt.Errorf("stripe: preview invoice: %w", err)
 	}
+	return prorationPreview(upcoming, prorationDate), nil
+}
 
-	// Calculate proration from invoice lines
+// prorationPreview sums the proration lines of an upcoming invoice: the credit for the unused
+// time on the old price and the charge for the remaining time on the new one.
+func prorationPreview(upcoming *stripeapi.Invoice, prorationDate int64) *ProrationPreview {
 	var prorationAmount int64
-	for _, line := range invoice.Lines.Data {
-		if line.Proration {
-			prorationAmount += line.Amount
+	if upcoming.Lines != nil {
+		for _, line := range upcoming.Lines.Data {
+			if line.Proration {
+				prorationAmount += line.Amount
+			}
 		}
 	}
 
+	var nextBilling time.Time
+	if upcoming.NextPaymentAttempt > 0 {
+		nextBilling = time.Unix(upcoming.NextPaymentAttempt, 0).UTC()
+	}
 	return &ProrationPreview{
 		ProrationAmount: prorationAmount,
-		Currency:        string(invoice.Currency),
-		EffectiveDate:   time.Now(),
-		InvoiceTotal:    invoice.Total,
-	}, nil
-}
-
-// previewInvoice creates an invoice preview (helper method).
-// Note: This method is a placeholder - proration preview requires the
-// invoice.upcoming API which needs specific SDK handling.
-func (c *Client) previewInvoice(_ *stripeapi.InvoiceParams) (*stripeapi.Invoice, error) {
-	// Note: For actual preview, we'd use stripe.Invoice.Upcoming
-	// but the v72 SDK requires a different approach
-	// This is a simplified version that creates a preview
-	return nil, errors.New("stripe: invoice preview not implemented in this SDK version - use UpdateSubscription with create_prorations")
+		Currency:        string(upcoming.Currency),
+		EffectiveDate:   time.Unix(prorationDate, 0).UTC(),
+		InvoiceTotal:    upcoming.Total,
+		NextBillingDate: nextBilling,
+	}
 }
 
 // ProrationPreview contains the preview of proration for a plan change.
@@ -250,6 +301,7 @@ type ProrationPreview struct {
 	Currency        string    // Currency code (e.g., "usd")
 	EffectiveDate   time.Time // When the change would take effect
 	InvoiceTotal    int64     // Total invoice amount after proration
+	NextBillingDate time.Time // When the upcoming invoice is charged, if known
 }
 
 // ReactivateSubscription reactivates a cancelled Stripe subscription (if still within period).
@@ -266,6 +318,41 @@ func (c *Client) ReactivateSubscription(ctx context.Context, stripeSubID string)
 	return sub, nil
 }
 
+// PauseSubscription pauses payment collection for a Stripe subscription. behavior says what
+// happens to invoices raised while paused: "void" (the default), "keep_as_draft", or
+// "mark_uncollectible".
+func (c *Client) PauseSubscription(ctx context.Context, stripeSubID, behavior string) (*stripeapi.Subscription, error) {
+	if behavior == "" {
+		behavior = string(stripeapi.SubscriptionPauseCollectionBehaviorVoid)
+	}
+	params := &stripeapi.SubscriptionParams{
+		PauseCollection: &stripeapi.SubscriptionPauseCollectionParams{
+			Behavior: stripeapi.String(behavior),
+		},
+	}
+
+	sub, err := stripesub.Update(stripeSubID, params)
+	if err != nil {
+		return nil, fmt.Errorf("stripe: pause subscription: %w", err)
+	}
+
+	return sub, nil
+}
+
+// ResumeSubscription resumes payment collection for a paused Stripe subscription.
+func (c *Client) ResumeSubscription(ctx context.Context, stripeSubID string) (*stripeapi.Subscription, error) {
+	params := &stripeapi.SubscriptionParams{}
+	// An empty pause_collection clears it
+	params.AddExtra("pause_collection", "")
+
+	sub, err := stripesub.Update(stripeSubID, params)
+	if err != nil {
+		return nil, fmt.Errorf("stripe: resume subscription: %w", err)
+	}
+
+	return sub, nil
+}
+
 // CreateBillingPortalSession creates a Stripe billing portal session for self-service.
 func (c *Client) CreateBillingPortalSession(ctx context.Context, customerID, returnURL string) (*stripeapi.BillingPortalSession, error) {
 	params := &stripeapi.BillingPortalSessionParams{
@@ -291,11 +378,14 @@ type SubscriptionWebhookEvent struct {
 	CurrentPeriodEnd     time.Time
 	CancelAtPeriodEnd    bool
 	CancelledAt          *time.Time
+	PausedCollection     bool   // Payment collection is paused (Stripe keeps the status active)
+	BillingReason        string // Invoices: "subscription_create" for the first, "subscription_cycle" for renewals
 	Metadata             map[string]string
 	// Plan change fields
 	PriceID         string // Current price ID
 	BillingPeriod   string // e.g., "month", "year"
 	BillingInterval int    // Interval count
+	Quantity        int64  // Seat count
 }
 
 // ParseSubscriptionWebhook parses subscription-related webhook events.
@@ -332,8 +422,10 @@ func (c *Client) ParseSubscriptionWebhook(payload []byte, eventType string) (*Su
 		// Extract price and billing info from subscription items
 		var priceID, billingPeriod string
 		var billingInterval int
+		var quantity int64
 		if sub.Items != nil && len(sub.Items.Data) > 0 {
 			item := sub.Items.Data[0]
+			quantity = item.Quantity
 			if item.Price != nil {
 				priceID = item.Price.ID
 				if item.Price.Recurring != nil {
@@ -353,10 +445,12 @@ func (c *Client) ParseSubscriptionWebhook(payload []byte, eventType string) (*Su
 			CurrentPeriodEnd:     time.Unix(sub.CurrentPeriodEnd, 0),
 			CancelAtPeriodEnd:    sub.CancelAtPeriodEnd,
 			CancelledAt:          cancelledAt,
+			PausedCollection:     sub.PauseCollection.Behavior != "",
 			Metadata:             sub.Metadata,
 			PriceID:              priceID,
 			BillingPeriod:        billingPeriod,
 			BillingInterval:      billingInterval,
+			Quantity:             quantity,
 		}, nil
 
 	case "invoice.paid", "invoice.payment_failed":
@@ -375,6 +469,7 @@ func (c *Client) ParseSubscriptionWebhook(payload []byte, eventType string) (*Su
 			Type:                 eventType,
 			StripeSubscriptionID: stripeSubID,
 			StripeCustomerID:     invoice.Customer.ID,
+			BillingReason:        string(invoice.BillingReason),
 		}, nil
 
 	case "checkout.session.completed":
@@ -451,8 +546,12 @@ func (c *Client) HandleSubscriptionWebhook(ctx context.Context, event *Subscript
 			UpdatedAt:            time.Now(),
 		}
 
-		// Determine billing period from Stripe price
+		// Determine billing period and seats from the subscription item
+		sub.Quantity = 1
 		if stripeSub.Items != nil && len(stripeSub.Items.Data) > 0 {
+			if q := stripeSub.Items.Data[0].Quantity; q > 0 {
+				sub.Quantity = int(q)
+			}
 			price := stripeSub.Items.Data[0].Price
 			if price != nil && price.Recurring != nil {
 				sub.BillingPeriod = mapStripeBillingPeriod(string(price.Recurring.Interval))
@@ -476,6 +575,7 @@ func (c *Client) HandleSubscriptionWebhook(ctx context.Context, event *Subscript
 			}
 			return fmt.Errorf("stripe: create subscription record: %w", err)
 		}
+		c.notify.SubscriptionChanged(ctx, sub.CallbackEvent(callbacks.SubscriptionCreated, ""))
 		return nil
 
 	case "customer.subscription.updated":
@@ -485,8 +585,14 @@ func (c *Client) HandleSubscriptionWebhook(ctx context.Context, event *Subscript
 			return nil // Subscription not tracked by us
 		}
 
-		// Update status
-		existing.Status = mapStripeStatus(event.Status)
+		// Update status (Stripe reports paused collection as active)
+		previous := existing.Status
+		cancelScheduled := event.CancelAtPeriodEnd && !existing.CancelAtPeriodEnd
+		status := mapStripeStatus(event.Status)
+		if event.PausedCollection && status == subscriptions.StatusActive {
+			status = subscriptions.StatusPaused
+		}
+		existing.SetStatus(status, time.Now())
 		existing.CurrentPeriodStart = event.CurrentPeriodStart
 		existing.CurrentPeriodEnd = event.CurrentPeriodEnd
 		existing.CancelAtPeriodEnd = event.CancelAtPeriodEnd
@@ -510,8 +616,21 @@ func (c *Client) HandleSubscriptionWebhook(ctx context.Context, event *Subscript
 		if event.BillingInterval > 0 {
 			existing.BillingInterval = event.BillingInterval
 		}
+		if event.Quantity > 0 {
+			existing.Quantity = int(event.Quantity)
+		}
 
-		return subRepo.Update(ctx, existing)
+		if err := subRepo.Update(ctx, existing); err != nil {
+			return err
+		}
+		eventType := subscriptions.TransitionEventType(previous, existing.Status)
+		if cancelScheduled && existing.Status == previous {
+			eventType = callbacks.SubscriptionCancelled
+		}
+		if eventType != "" {
+			c.notify.SubscriptionChanged(ctx, existing.CallbackEvent(eventType, previous))
+		}
+		return nil
 
 	case "customer.subscription.deleted":
 		// Subscription cancelled or expired
@@ -520,7 +639,15 @@ func (c *Client) HandleSubscriptionWebhook(ctx context.Context, event *Subscript
 			return nil // Subscription not tracked by us
 		}
 
-		return subRepo.UpdateStatus(ctx, existing.ID, subscriptions.StatusCancelled)
+		if err := subRepo.UpdateStatus(ctx, existing.ID, subscriptions.StatusCancelled); err != nil {
+			return err
+		}
+		if previous := existing.Status; previous != subscriptions.StatusCancelled {
+			existing.Status = subscriptions.StatusCancelled
+			existing.UpdatedAt = time.Now()
+			c.notify.SubscriptionChanged(ctx, existing.CallbackEvent(callbacks.SubscriptionCancelled, previous))
+		}
+		return nil
 
 	case "invoice.paid":
 		// Successful renewal payment
@@ -540,13 +667,24 @@ func (c *Client) HandleSubscriptionWebhook(ctx context.Context, event *Subscript
 		}
 
 		// Extend the period
-		return subRepo.ExtendPeriod(ctx, existing.ID,
+		err = subRepo.ExtendPeriod(ctx, existing.ID,
 			time.Unix(stripeSub.CurrentPeriodStart, 0),
 			time.Unix(stripeSub.CurrentPeriodEnd, 0),
 		)
+		if err != nil {
+			return err
+		}
+
+		// The first invoice is paid at checkout, already reported as subscription.created
+		if event.BillingReason != string(stripeapi.InvoiceBillingReasonSubscriptionCreate) {
+			if renewed, err := subRepo.GetByStripeSubscriptionID(ctx, event.StripeSubscriptionID); err == nil {
+				c.notify.SubscriptionChanged(ctx, renewed.CallbackEvent(callbacks.SubscriptionRenewed, existing.Status))
+			}
+		}
+		return nil
 
 	case "invoice.payment_failed":
-		// Payment failed - mark as past due
+		// Payment failed - mark as past due, starting the grace period on the first failure
 		if event.StripeSubscriptionID == "" {
 			return nil
 		}
@@ -556,7 +694,16 @@ func (c *Client) HandleSubscriptionWebhook(ctx context.Context, event *Subscript
 			return nil // Subscription not tracked by us
 		}
 
-		return subRepo.UpdateStatus(ctx, existing.ID, subscriptions.StatusPastDue)
+		previous := existing.Status
+		existing.SetStatus(subscriptions.StatusPastDue, time.Now())
+		existing.UpdatedAt = time.Now()
+		if err := subRepo.Update(ctx, existing); err != nil {
+			return err
+		}
+		if previous != subscriptions.StatusPastDue {
+			c.notify.SubscriptionChanged(ctx, existing.CallbackEvent(callbacks.SubscriptionPastDue, previous))
+		}
+		return nil
 	}
 
 	return nil
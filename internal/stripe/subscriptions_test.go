@@ -0,0 +1,202 @@
+package stripe
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	stripeapi "github.com/stripe/stripe-go/v72"
+
+	"github.com/CedrosPay/server/internal/callbacks"
+	"github.com/CedrosPay/server/internal/subscriptions"
+)
+
+func TestProrationPreview(t *testing.T) {
+	const prorationDate = 1767225600 // 2026-01-01T00:00:00Z
+	tests := []struct {
+		name          string
+		upcoming      *stripeapi.Invoice
+		wantAmount    int64
+		wantTotal     int64
+		wantNextBills time.Time
+	}{
+		{
+			name: "upgrade",
+			upcoming: &stripeapi.Invoice{
+				Currency:           "usd",
+				Total:              3500,
+				NextPaymentAttempt: 1769904000,
+				Lines: &stripeapi.InvoiceLineList{Data: []*stripeapi.InvoiceLine{
+					{Amount: -500, Proration: true},  // Unused time on the old price
+					{Amount: 1500, Proration: true},  // Remaining time on the new price
+					{Amount: 2500, Proration: false}, // Next period on the new price
+				}},
+			},
+			wantAmount:    1000,
+			wantTotal:     3500,
+			wantNextBills: time.Unix(1769904000, 0).UTC(),
+		},
+		{
+			name: "downgrade credit",
+			upcoming: &stripeapi.Invoice{
+				Currency: "usd",
+				Total:    200,
+				Lines: &stripeapi.InvoiceLineList{Data: []*stripeapi.InvoiceLine{
+					{Amount: -1500, Proration: true},
+					{Amount: 700, Proration: true},
+					{Amount: 1000},
+				}},
+			},
+			wantAmount: -800,
+			wantTotal:  200,
+		},
+		{
+			name:     "no lines",
+			upcoming: &stripeapi.Invoice{Currency: "eur"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := prorationPreview(tt.upcoming, prorationDate)
+			if got.ProrationAmount != tt.wantAmount || got.InvoiceTotal != tt.wantTotal || got.Currency != string(tt.upcoming.Currency) {
+				t.Errorf("preview = %+v, want proration %d and total %d", got, tt.wantAmount, tt.wantTotal)
+			}
+			if got.EffectiveDate.Unix() != prorationDate {
+				t.Errorf("EffectiveDate = %s, want the proration date", got.EffectiveDate)
+			}
+			if !got.NextBillingDate.Equal(tt.wantNextBills) {
+				t.Errorf("NextBillingDate = %s, want %s", got.NextBillingDate, tt.wantNextBills)
+			}
+		})
+	}
+}
+
+func TestSubscriptionItemChange(t *testing.T) {
+	tests := []struct {
+		name         string
+		priceID      string
+		quantity     int64
+		wantPrice    *string
+		wantQuantity *int64
+	}{
+		{name: "plan change", priceID: "price_pro", wantPrice: stripeapi.String("price_pro")},
+		{name: "seat change", quantity: 5, wantQuantity: stripeapi.Int64(5)},
+		{name: "plan and seats", priceID: "price_team", quantity: 3, wantPrice: stripeapi.String("price_team"), wantQuantity: stripeapi.Int64(3)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := subscriptionItemChange("si_123", tt.priceID, tt.quantity)
+			if got.ID == nil || *got.ID != "si_123" {
+				t.Errorf("ID = %v, want si_123", got.ID)
+			}
+			if (got.Price == nil) != (tt.wantPrice == nil) || (got.Price != nil && *got.Price != *tt.wantPrice) {
+				t.Errorf("Price = %v, want %v", got.Price, tt.wantPrice)
+			}
+			if (got.Quantity == nil) != (tt.wantQuantity == nil) || (got.Quantity != nil && *got.Quantity != *tt.wantQuantity) {
+				t.Errorf("Quantity = %v, want %v", got.Quantity, tt.wantQuantity)
+			}
+		})
+	}
+}
+
+type subscriptionEventRecorder struct {
+	callbacks.NoopNotifier
+	events []callbacks.SubscriptionEvent
+}
+
+func (r *subscriptionEventRecorder) SubscriptionChanged(_ context.Context, event callbacks.SubscriptionEvent) {
+	r.events = append(r.events, event)
+}
+
+func TestHandleSubscriptionWebhookCallbacks(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	tests := []struct {
+		name         string
+		events       []SubscriptionWebhookEvent
+		wantTypes    []string
+		wantStatus   subscriptions.Status
+		wantPrevious string
+	}{
+		{
+			name: "payment failed twice",
+			events: []SubscriptionWebhookEvent{
+				{Type: "invoice.payment_failed", StripeSubscriptionID: "sub_stripe"},
+				{Type: "invoice.payment_failed", StripeSubscriptionID: "sub_stripe"},
+			},
+			wantTypes:    []string{callbacks.SubscriptionPastDue},
+			wantStatus:   subscriptions.StatusPastDue,
+			wantPrevious: "active",
+		},
+		{
+			name: "cancellation scheduled",
+			events: []SubscriptionWebhookEvent{
+				{Type: "customer.subscription.updated", StripeSubscriptionID: "sub_stripe", Status: "active", CancelAtPeriodEnd: true, CurrentPeriodStart: now.Add(-time.Hour), CurrentPeriodEnd: now.Add(time.Hour)},
+			},
+			wantTypes:    []string{callbacks.SubscriptionCancelled},
+			wantStatus:   subscriptions.StatusActive,
+			wantPrevious: "active",
+		},
+		{
+			name: "updated without a status change",
+			events: []SubscriptionWebhookEvent{
+				{Type: "customer.subscription.updated", StripeSubscriptionID: "sub_stripe", Status: "active", CurrentPeriodStart: now.Add(-time.Hour), CurrentPeriodEnd: now.Add(time.Hour)},
+			},
+			wantStatus: subscriptions.StatusActive,
+		},
+		{
+			name: "deleted",
+			events: []SubscriptionWebhookEvent{
+				{Type: "customer.subscription.deleted", StripeSubscriptionID: "sub_stripe"},
+			},
+			wantTypes:    []string{callbacks.SubscriptionCancelled},
+			wantStatus:   subscriptions.StatusCancelled,
+			wantPrevious: "active",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := subscriptions.NewMemoryRepository()
+			recorder := &subscriptionEventRecorder{}
+			client := &Client{notify: recorder}
+
+			err := repo.Create(ctx, subscriptions.Subscription{
+				ID:                   "sub_local",
+				ProductID:            "plan-pro",
+				StripeSubscriptionID: "sub_stripe",
+				PaymentMethod:        subscriptions.PaymentMethodStripe,
+				Status:               subscriptions.StatusActive,
+				CurrentPeriodStart:   now.Add(-time.Hour),
+				CurrentPeriodEnd:     now.Add(time.Hour),
+			})
+			if err != nil {
+				t.Fatalf("create: %v", err)
+			}
+
+			for i := range tt.events {
+				if err := client.HandleSubscriptionWebhook(ctx, &tt.events[i], repo); err != nil {
+					t.Fatalf("HandleSubscriptionWebhook(%s): %v", tt.events[i].Type, err)
+				}
+			}
+
+			sub, err := repo.Get(ctx, "sub_local")
+			if err != nil {
+				t.Fatalf("get: %v", err)
+			}
+			if sub.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", sub.Status, tt.wantStatus)
+			}
+			if len(recorder.events) != len(tt.wantTypes) {
+				t.Fatalf("got %d callbacks, want %d", len(recorder.events), len(tt.wantTypes))
+			}
+			for i, want := range tt.wantTypes {
+				event := recorder.events[i]
+				if event.EventType != want || event.PreviousStatus != tt.wantPrevious || event.SubscriptionID != "sub_local" {
+					t.Errorf("callback %d = %+v, want %s from %q", i, event, want, tt.wantPrevious)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package stripe
+
+import (
+	stripeapi "github.com/stripe/stripe-go/v72"
+)
+
+// applyAutomaticTax has Stripe Tax calculate a Checkout session's tax, which makes Checkout
+// collect the billing address it needs. Stripe Tax requires a tax behavior on inline prices,
+// so line items priced with PriceData get taxBehavior; Stripe Prices carry their own.
+func applyAutomaticTax(params *stripeapi.CheckoutSessionParams, taxBehavior string) {
+	params.AutomaticTax = &stripeapi.CheckoutSessionAutomaticTaxParams{
+		Enabled: stripeapi.Bool(true),
+	}
+	for _, item := range params.LineItems {
+		if item.PriceData != nil {
+			item.PriceData.TaxBehavior = stripeapi.String(taxBehavior)
+		}
+	}
+}
+
+// sessionTaxAmount returns the tax Stripe computed for a completed Checkout session.
+func sessionTaxAmount(checkout stripeapi.CheckoutSession) int64 {
+	if checkout.TotalDetails == nil {
+		return 0
+	}
+	return checkout.TotalDetails.AmountTax
+}
@@ -0,0 +1,63 @@
+package stripe
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"testing"
+	"time"
+
+	stripeapi "github.com/stripe/stripe-go/v72"
+	"github.com/stripe/stripe-go/v72/webhook"
+
+	"github.com/CedrosPay/server/internal/callbacks"
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/storage"
+)
+
+func TestApplyAutomaticTax(t *testing.T) {
+	params := &stripeapi.CheckoutSessionParams{
+		LineItems: []*stripeapi.CheckoutSessionLineItemParams{
+			{Price: stripeapi.String("price_123"), Quantity: stripeapi.Int64(1)},
+			{Quantity: stripeapi.Int64(1), PriceData: &stripeapi.CheckoutSessionLineItemPriceDataParams{UnitAmount: stripeapi.Int64(1000)}},
+		},
+	}
+	applyAutomaticTax(params, config.StripeTaxBehaviorInclusive)
+
+	if params.AutomaticTax == nil || !*params.AutomaticTax.Enabled {
+		t.Fatal("automatic tax not enabled")
+	}
+	if got := params.LineItems[1].PriceData.TaxBehavior; got == nil || *got != "inclusive" {
+		t.Errorf("inline price tax behavior = %v, want inclusive", got)
+	}
+}
+
+func TestCheckoutSessionTax(t *testing.T) {
+	const secret = "whsec_test"
+	store := storage.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close() })
+	client := &Client{cfg: config.StripeConfig{WebhookSecret: secret}, store: store, notify: callbacks.NoopNotifier{}}
+
+	session := `{"id":"cs_123","object":"checkout.session","amount_total":1190,"currency":"eur","customer_email":"a@example.com","metadata":{"resource_id":"article-1"},"total_details":{"amount_discount":0,"amount_shipping":0,"amount_tax":190}}`
+	payload := []byte(fmt.Sprintf(`{"id":"evt_1","object":"event","type":"checkout.session.completed","data":{"object":%s}}`, session))
+	now := time.Now()
+	header := fmt.Sprintf("t=%d,v1=%s", now.Unix(), hex.EncodeToString(webhook.ComputeSignature(now, payload, secret)))
+
+	event, err := client.ParseWebhook(context.Background(), payload, header)
+	if err != nil {
+		t.Fatalf("ParseWebhook error: %v", err)
+	}
+	if event.TaxAmount != 190 || event.AmountTotal != 1190 {
+		t.Fatalf("event = %+v, want 190 tax of 1190", event)
+	}
+	if err := client.HandleCompletion(context.Background(), event); err != nil {
+		t.Fatalf("HandleCompletion error: %v", err)
+	}
+	tx, err := store.GetPayment(context.Background(), "stripe:cs_123")
+	if err != nil {
+		t.Fatalf("GetPayment error: %v", err)
+	}
+	if tx.Metadata["tax_amount"] != "1.90" {
+		t.Errorf("tax_amount = %q, want 1.90", tx.Metadata["tax_amount"])
+	}
+}
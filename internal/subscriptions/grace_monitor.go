@@ -0,0 +1,92 @@
+package subscriptions
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// GraceMonitorConfig holds configuration for past-due grace period notices.
+type GraceMonitorConfig struct {
+	NoticeBefore time.Duration // How long before a grace period ends to notify (default: 24 hours)
+	RunInterval  time.Duration // How often to check past-due subscriptions (default: 1 hour)
+}
+
+// GraceMonitor periodically sends subscription.grace_period_expiring callbacks for past-due
+// subscriptions whose grace period is about to end.
+type GraceMonitor struct {
+	service  *Service
+	config   GraceMonitorConfig
+	logger   zerolog.Logger
+	stopChan chan struct{}
+	doneChan chan struct{}
+}
+
+// NewGraceMonitor creates a grace period monitor for the service's past-due subscriptions.
+func NewGraceMonitor(service *Service, config GraceMonitorConfig, logger zerolog.Logger) *GraceMonitor {
+	if config.NoticeBefore <= 0 {
+		config.NoticeBefore = 24 * time.Hour
+	}
+	if config.RunInterval <= 0 {
+		config.RunInterval = time.Hour
+	}
+	return &GraceMonitor{
+		service:  service,
+		config:   config,
+		logger:   logger,
+		stopChan: make(chan struct{}),
+		doneChan: make(chan struct{}),
+	}
+}
+
+// Start begins the monitor's background loop.
+func (m *GraceMonitor) Start() {
+	m.logger.Info().
+		Dur("gracePeriod", m.service.pastDueGrace).
+		Dur("noticeBefore", m.config.NoticeBefore).
+		Dur("runInterval", m.config.RunInterval).
+		Msg("subscriptions.grace_monitor_started")
+
+	go m.run()
+}
+
+// Stop stops the monitor and waits for a pass in progress to finish.
+func (m *GraceMonitor) Stop() {
+	close(m.stopChan)
+	<-m.doneChan
+}
+
+// run is the main monitor loop.
+func (m *GraceMonitor) run() {
+	defer close(m.doneChan)
+
+	m.check()
+
+	ticker := time.NewTicker(m.config.RunInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.check()
+		case <-m.stopChan:
+			return
+		}
+	}
+}
+
+// check performs a single pass over past-due subscriptions.
+func (m *GraceMonitor) check() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	count, err := m.service.NotifyGracePeriodsExpiring(ctx, m.config.NoticeBefore)
+	if err != nil {
+		m.logger.Error().Err(err).Msg("subscriptions.grace_check_failed")
+		return
+	}
+	if count > 0 {
+		m.logger.Info().Int("count", count).Msg("subscriptions.grace_period_expiring_sent")
+	}
+}
@@ -200,6 +200,20 @@ func (r *MemoryRepository) GetByStripeCustomerID(_ context.Context, customerID s
 	return result, nil
 }
 
+// ListByWallet returns all subscriptions for a wallet.
+func (r *MemoryRepository) ListByWallet(_ context.Context, wallet string) ([]Subscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []Subscription
+	for _, sub := range r.subs {
+		if sub.Wallet == wallet {
+			result = append(result, sub)
+		}
+	}
+	return result, nil
+}
+
 // ListByProduct returns all subscriptions for a product.
 func (r *MemoryRepository) ListByProduct(_ context.Context, productID string) ([]Subscription, error) {
 	r.mu.RLock()
@@ -246,6 +260,20 @@ func (r *MemoryRepository) ListExpiring(_ context.Context, before time.Time) ([]
 	return result, nil
 }
 
+// ListByStatus returns all subscriptions with the given status.
+func (r *MemoryRepository) ListByStatus(_ context.Context, status Status) ([]Subscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []Subscription
+	for _, sub := range r.subs {
+		if sub.Status == status {
+			result = append(result, sub)
+		}
+	}
+	return result, nil
+}
+
 // UpdateStatus changes a subscription's status.
 func (r *MemoryRepository) UpdateStatus(_ context.Context, id string, status Status) error {
 	r.mu.Lock()
@@ -83,9 +83,13 @@ func (r *PostgresRepository) createTable() error {
 			cancel_at_period_end   BOOLEAN NOT NULL DEFAULT FALSE,
 			metadata               JSONB,
 			created_at             TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-			updated_at             TIMESTAMPTZ NOT NULL DEFAULT NOW()
+			updated_at             TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			quantity               INTEGER NOT NULL DEFAULT 1
 		);
 
+		-- Tables created before per-seat pricing
+		ALTER TABLE %s ADD COLUMN IF NOT EXISTS quantity INTEGER NOT NULL DEFAULT 1;
+
 		CREATE INDEX IF NOT EXISTS idx_%s_wallet_product
 			ON %s(wallet, product_id) WHERE wallet IS NOT NULL;
 		CREATE INDEX IF NOT EXISTS idx_%s_stripe_customer
@@ -94,7 +98,7 @@ func (r *PostgresRepository) createTable() error {
 			ON %s(status);
 		CREATE INDEX IF NOT EXISTS idx_%s_period_end
 			ON %s(current_period_end);
-	`, r.tableName,
+	`, r.tableName, r.tableName,
 		r.tableName, r.tableName,
 		r.tableName, r.tableName,
 		r.tableName, r.tableName,
@@ -126,8 +130,8 @@ func (r *PostgresRepository) Create(ctx context.Context, sub Subscription) error
 			id, product_id, wallet, stripe_customer_id, stripe_subscription_id,
 			payment_method, billing_period, billing_interval, status,
 			current_period_start, current_period_end, trial_end, cancelled_at,
-			cancel_at_period_end, metadata, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+			cancel_at_period_end, metadata, created_at, updated_at, quantity
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
 	`, r.tableName)
 
 	_, err = r.db.ExecContext(ctx, query,
@@ -135,7 +139,7 @@ func (r *PostgresRepository) Create(ctx context.Context, sub Subscription) error
 		nullString(sub.StripeSubscriptionID), sub.PaymentMethod, sub.BillingPeriod,
 		sub.BillingInterval, sub.Status, sub.CurrentPeriodStart, sub.CurrentPeriodEnd,
 		nullTime(sub.TrialEnd), nullTime(sub.CancelledAt), sub.CancelAtPeriodEnd,
-		metadata, sub.CreatedAt, sub.UpdatedAt,
+		metadata, sub.CreatedAt, sub.UpdatedAt, seatQuantity(sub.Quantity),
 	)
 
 	if err != nil {
@@ -151,7 +155,7 @@ func (r *PostgresRepository) Get(ctx context.Context, id string) (Subscription,
 		SELECT id, product_id, wallet, stripe_customer_id, stripe_subscription_id,
 			payment_method, billing_period, billing_interval, status,
 			current_period_start, current_period_end, trial_end, cancelled_at,
-			cancel_at_period_end, metadata, created_at, updated_at
+			cancel_at_period_end, metadata, created_at, updated_at, quantity
 		FROM %s WHERE id = $1
 	`, r.tableName)
 
@@ -173,7 +177,8 @@ func (r *PostgresRepository) Update(ctx context.Context, sub Subscription) error
 			stripe_subscription_id = $5, payment_method = $6, billing_period = $7,
 			billing_interval = $8, status = $9, current_period_start = $10,
 			current_period_end = $11, trial_end = $12, cancelled_at = $13,
-			cancel_at_period_end = $14, metadata = $15, updated_at = $16
+			cancel_at_period_end = $14, metadata = $15, updated_at = $16,
+			quantity = $17
 		WHERE id = $1
 	`, r.tableName)
 
@@ -182,7 +187,7 @@ func (r *PostgresRepository) Update(ctx context.Context, sub Subscription) error
 		nullString(sub.StripeSubscriptionID), sub.PaymentMethod, sub.BillingPeriod,
 		sub.BillingInterval, sub.Status, sub.CurrentPeriodStart, sub.CurrentPeriodEnd,
 		nullTime(sub.TrialEnd), nullTime(sub.CancelledAt), sub.CancelAtPeriodEnd,
-		metadata, sub.UpdatedAt,
+		metadata, sub.UpdatedAt, seatQuantity(sub.Quantity),
 	)
 
 	if err != nil {
@@ -220,7 +225,7 @@ func (r *PostgresRepository) GetByWallet(ctx context.Context, wallet, productID
 		SELECT id, product_id, wallet, stripe_customer_id, stripe_subscription_id,
 			payment_method, billing_period, billing_interval, status,
 			current_period_start, current_period_end, trial_end, cancelled_at,
-			cancel_at_period_end, metadata, created_at, updated_at
+			cancel_at_period_end, metadata, created_at, updated_at, quantity
 		FROM %s
 		WHERE wallet = $1 AND product_id = $2
 		ORDER BY created_at DESC
@@ -236,7 +241,7 @@ func (r *PostgresRepository) GetByStripeSubscriptionID(ctx context.Context, stri
 		SELECT id, product_id, wallet, stripe_customer_id, stripe_subscription_id,
 			payment_method, billing_period, billing_interval, status,
 			current_period_start, current_period_end, trial_end, cancelled_at,
-			cancel_at_period_end, metadata, created_at, updated_at
+			cancel_at_period_end, metadata, created_at, updated_at, quantity
 		FROM %s WHERE stripe_subscription_id = $1
 	`, r.tableName)
 
@@ -249,7 +254,7 @@ func (r *PostgresRepository) GetByStripeCustomerID(ctx context.Context, customer
 		SELECT id, product_id, wallet, stripe_customer_id, stripe_subscription_id,
 			payment_method, billing_period, billing_interval, status,
 			current_period_start, current_period_end, trial_end, cancelled_at,
-			cancel_at_period_end, metadata, created_at, updated_at
+			cancel_at_period_end, metadata, created_at, updated_at, quantity
 		FROM %s WHERE stripe_customer_id = $1
 		ORDER BY created_at DESC
 	`, r.tableName)
@@ -257,13 +262,27 @@ func (r *PostgresRepository) GetByStripeCustomerID(ctx context.Context, customer
 	return r.scanMany(ctx, query, customerID)
 }
 
+// ListByWallet returns all subscriptions for a wallet.
+func (r *PostgresRepository) ListByWallet(ctx context.Context, wallet string) ([]Subscription, error) {
+	query := fmt.Sprintf(`
+		SELECT id, product_id, wallet, stripe_customer_id, stripe_subscription_id,
+			payment_method, billing_period, billing_interval, status,
+			current_period_start, current_period_end, trial_end, cancelled_at,
+			cancel_at_period_end, metadata, created_at, updated_at, quantity
+		FROM %s WHERE wallet = $1
+		ORDER BY created_at DESC
+	`, r.tableName)
+
+	return r.scanMany(ctx, query, wallet)
+}
+
 // ListByProduct returns all subscriptions for a product.
 func (r *PostgresRepository) ListByProduct(ctx context.Context, productID string) ([]Subscription, error) {
 	query := fmt.Sprintf(`
 		SELECT id, product_id, wallet, stripe_customer_id, stripe_subscription_id,
 			payment_method, billing_period, billing_interval, status,
 			current_period_start, current_period_end, trial_end, cancelled_at,
-			cancel_at_period_end, metadata, created_at, updated_at
+			cancel_at_period_end, metadata, created_at, updated_at, quantity
 		FROM %s WHERE product_id = $1
 		ORDER BY created_at DESC
 	`, r.tableName)
@@ -281,7 +300,7 @@ func (r *PostgresRepository) ListActive(ctx context.Context, productID string) (
 			SELECT id, product_id, wallet, stripe_customer_id, stripe_subscription_id,
 				payment_method, billing_period, billing_interval, status,
 				current_period_start, current_period_end, trial_end, cancelled_at,
-				cancel_at_period_end, metadata, created_at, updated_at
+				cancel_at_period_end, metadata, created_at, updated_at, quantity
 			FROM %s
 			WHERE product_id = $1 AND status IN ('active', 'trialing', 'past_due')
 				AND current_period_end > NOW()
@@ -293,7 +312,7 @@ func (r *PostgresRepository) ListActive(ctx context.Context, productID string) (
 			SELECT id, product_id, wallet, stripe_customer_id, stripe_subscription_id,
 				payment_method, billing_period, billing_interval, status,
 				current_period_start, current_period_end, trial_end, cancelled_at,
-				cancel_at_period_end, metadata, created_at, updated_at
+				cancel_at_period_end, metadata, created_at, updated_at, quantity
 			FROM %s
 			WHERE status IN ('active', 'trialing', 'past_due')
 				AND current_period_end > NOW()
@@ -310,7 +329,7 @@ func (r *PostgresRepository) ListExpiring(ctx context.Context, before time.Time)
 		SELECT id, product_id, wallet, stripe_customer_id, stripe_subscription_id,
 			payment_method, billing_period, billing_interval, status,
 			current_period_start, current_period_end, trial_end, cancelled_at,
-			cancel_at_period_end, metadata, created_at, updated_at
+			cancel_at_period_end, metadata, created_at, updated_at, quantity
 		FROM %s
 		WHERE status = 'active' AND current_period_end < $1
 		ORDER BY current_period_end ASC
@@ -319,6 +338,21 @@ func (r *PostgresRepository) ListExpiring(ctx context.Context, before time.Time)
 	return r.scanMany(ctx, query, before)
 }
 
+// ListByStatus returns all subscriptions with the given status.
+func (r *PostgresRepository) ListByStatus(ctx context.Context, status Status) ([]Subscription, error) {
+	query := fmt.Sprintf(`
+		SELECT id, product_id, wallet, stripe_customer_id, stripe_subscription_id,
+			payment_method, billing_period, billing_interval, status,
+			current_period_start, current_period_end, trial_end, cancelled_at,
+			cancel_at_period_end, metadata, created_at, updated_at, quantity
+		FROM %s
+		WHERE status = $1
+		ORDER BY updated_at ASC
+	`, r.tableName)
+
+	return r.scanMany(ctx, query, string(status))
+}
+
 // UpdateStatus changes a subscription's status.
 func (r *PostgresRepository) UpdateStatus(ctx context.Context, id string, status Status) error {
 	query := fmt.Sprintf(`
@@ -407,7 +441,7 @@ func (r *PostgresRepository) scanRow(row *sql.Row) (Subscription, error) {
 		&sub.ID, &sub.ProductID, &wallet, &stripeCustomerID, &stripeSubID,
 		&sub.PaymentMethod, &sub.BillingPeriod, &sub.BillingInterval, &sub.Status,
 		&sub.CurrentPeriodStart, &sub.CurrentPeriodEnd, &trialEnd, &cancelledAt,
-		&sub.CancelAtPeriodEnd, &metadata, &sub.CreatedAt, &sub.UpdatedAt,
+		&sub.CancelAtPeriodEnd, &metadata, &sub.CreatedAt, &sub.UpdatedAt, &sub.Quantity,
 	)
 
 	if err == sql.ErrNoRows {
@@ -444,7 +478,7 @@ func (r *PostgresRepository) scanRows(rows *sql.Rows) (Subscription, error) {
 		&sub.ID, &sub.ProductID, &wallet, &stripeCustomerID, &stripeSubID,
 		&sub.PaymentMethod, &sub.BillingPeriod, &sub.BillingInterval, &sub.Status,
 		&sub.CurrentPeriodStart, &sub.CurrentPeriodEnd, &trialEnd, &cancelledAt,
-		&sub.CancelAtPeriodEnd, &metadata, &sub.CreatedAt, &sub.UpdatedAt,
+		&sub.CancelAtPeriodEnd, &metadata, &sub.CreatedAt, &sub.UpdatedAt, &sub.Quantity,
 	)
 
 	if err != nil {
@@ -0,0 +1,80 @@
+package subscriptions
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+// ErrPriceAssetMismatch is returned when two plans are priced in different assets.
+var ErrPriceAssetMismatch = errors.New("plans are priced in different assets")
+
+// PlanChangeProration is the prorated cost of switching plans for the rest of the current period.
+type PlanChangeProration struct {
+	Credit    money.Money // Unused time on the current plan
+	Charge    money.Money // Remaining time on the new plan
+	AmountDue money.Money // Charge less credit, never negative
+	Remaining time.Duration
+	Period    time.Duration
+}
+
+// ProratePlanChange prices a mid-period change from currentPrice to newPrice (per-seat prices)
+// at the given time: the subscriber is credited for the unused part of the current period and
+// charged for the same span on the new plan. A zero newQuantity keeps the current seat count.
+// A credit larger than the charge (a downgrade) is forfeited rather than refunded, since crypto
+// subscriptions hold no balance to carry it.
+func ProratePlanChange(sub Subscription, currentPrice, newPrice money.Money, newQuantity int, at time.Time) (PlanChangeProration, error) {
+	if currentPrice.Asset.Code != newPrice.Asset.Code {
+		return PlanChangeProration{}, ErrPriceAssetMismatch
+	}
+	if newQuantity < 0 {
+		return PlanChangeProration{}, ErrInvalidQuantity
+	}
+	if newQuantity == 0 {
+		newQuantity = seatQuantity(sub.Quantity)
+	}
+
+	result := PlanChangeProration{
+		Credit:    money.Zero(currentPrice.Asset),
+		Charge:    money.Zero(newPrice.Asset),
+		AmountDue: money.Zero(newPrice.Asset),
+		Period:    sub.CurrentPeriodEnd.Sub(sub.CurrentPeriodStart),
+		Remaining: sub.CurrentPeriodEnd.Sub(at),
+	}
+	if result.Period < time.Second || result.Remaining <= 0 {
+		return result, nil
+	}
+	if result.Remaining > result.Period {
+		result.Remaining = result.Period
+	}
+
+	// Share of the period left, in basis points (whole seconds keep the product within int64)
+	basisPoints := int64(result.Remaining/time.Second) * 10000 / int64(result.Period/time.Second)
+
+	current, err := currentPrice.Mul(int64(seatQuantity(sub.Quantity)))
+	if err != nil {
+		return PlanChangeProration{}, fmt.Errorf("current plan total: %w", err)
+	}
+	next, err := newPrice.Mul(int64(newQuantity))
+	if err != nil {
+		return PlanChangeProration{}, fmt.Errorf("new plan total: %w", err)
+	}
+
+	if result.Credit, err = current.MulBasisPointsWithRounding(basisPoints, money.RoundingStandard); err != nil {
+		return PlanChangeProration{}, fmt.Errorf("prorate credit: %w", err)
+	}
+	if result.Charge, err = next.MulBasisPointsWithRounding(basisPoints, money.RoundingCeiling); err != nil {
+		return PlanChangeProration{}, fmt.Errorf("prorate charge: %w", err)
+	}
+
+	due, err := result.Charge.Sub(result.Credit)
+	if err != nil {
+		return PlanChangeProration{}, fmt.Errorf("prorate amount due: %w", err)
+	}
+	if due.IsPositive() {
+		result.AmountDue = due.RoundUpToCents()
+	}
+	return result, nil
+}
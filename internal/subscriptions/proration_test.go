@@ -0,0 +1,123 @@
+package subscriptions
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/CedrosPay/server/internal/money"
+)
+
+func TestProratePlanChange(t *testing.T) {
+	usdc := money.MustGetAsset("USDC")
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	sub := Subscription{
+		CurrentPeriodStart: start,
+		CurrentPeriodEnd:   start.AddDate(0, 0, 30),
+	}
+	seats := sub
+	seats.Quantity = 2
+
+	tests := []struct {
+		name       string
+		sub        Subscription
+		current    money.Money
+		next       money.Money
+		quantity   int
+		at         time.Time
+		wantCredit int64
+		wantCharge int64
+		wantDue    int64
+		wantErr    error
+	}{
+		{
+			name:       "upgrade halfway through the period",
+			sub:        sub,
+			current:    money.New(usdc, 10_000000),
+			next:       money.New(usdc, 20_000000),
+			at:         start.AddDate(0, 0, 15),
+			wantCredit: 5_000000,
+			wantCharge: 10_000000,
+			wantDue:    5_000000,
+		},
+		{
+			name:       "downgrade forfeits the excess credit",
+			sub:        sub,
+			current:    money.New(usdc, 20_000000),
+			next:       money.New(usdc, 10_000000),
+			at:         start.AddDate(0, 0, 15),
+			wantCredit: 10_000000,
+			wantCharge: 5_000000,
+			wantDue:    0,
+		},
+		{
+			name:       "amount due rounds up to the cent",
+			sub:        sub,
+			current:    money.New(usdc, 10_000000),
+			next:       money.New(usdc, 20_000000),
+			at:         start.AddDate(0, 0, 20),
+			wantCredit: 3_333000,
+			wantCharge: 6_666000,
+			wantDue:    3_340000,
+		},
+		{
+			name:       "seat change on the same plan",
+			sub:        seats,
+			current:    money.New(usdc, 10_000000),
+			next:       money.New(usdc, 10_000000),
+			quantity:   3,
+			at:         start.AddDate(0, 0, 15),
+			wantCredit: 10_000000,
+			wantCharge: 15_000000,
+			wantDue:    5_000000,
+		},
+		{
+			name:    "period already over",
+			sub:     sub,
+			current: money.New(usdc, 10_000000),
+			next:    money.New(usdc, 20_000000),
+			at:      start.AddDate(0, 0, 31),
+		},
+		{
+			name:    "plans priced in different assets",
+			sub:     sub,
+			current: money.New(usdc, 10_000000),
+			next:    money.New(money.MustGetAsset("USDT"), 20_000000),
+			at:      start.AddDate(0, 0, 15),
+			wantErr: ErrPriceAssetMismatch,
+		},
+		{
+			name:     "negative quantity",
+			sub:      sub,
+			current:  money.New(usdc, 10_000000),
+			next:     money.New(usdc, 20_000000),
+			quantity: -1,
+			at:       start.AddDate(0, 0, 15),
+			wantErr:  ErrInvalidQuantity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ProratePlanChange(tt.sub, tt.current, tt.next, tt.quantity, tt.at)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ProratePlanChange: %v", err)
+			}
+			if got.Credit.Atomic != tt.wantCredit {
+				t.Errorf("credit = %d, want %d", got.Credit.Atomic, tt.wantCredit)
+			}
+			if got.Charge.Atomic != tt.wantCharge {
+				t.Errorf("charge = %d, want %d", got.Charge.Atomic, tt.wantCharge)
+			}
+			if got.AmountDue.Atomic != tt.wantDue {
+				t.Errorf("amount due = %d, want %d", got.AmountDue.Atomic, tt.wantDue)
+			}
+		})
+	}
+}
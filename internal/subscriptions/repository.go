@@ -37,6 +37,9 @@ type Repository interface {
 	// GetByStripeCustomerID finds all subscriptions for a Stripe customer.
 	GetByStripeCustomerID(ctx context.Context, customerID string) ([]Subscription, error)
 
+	// ListByWallet returns all subscriptions for a wallet, across products.
+	ListByWallet(ctx context.Context, wallet string) ([]Subscription, error)
+
 	// ListByProduct returns all subscriptions for a product.
 	ListByProduct(ctx context.Context, productID string) ([]Subscription, error)
 
@@ -46,6 +49,9 @@ type Repository interface {
 	// ListExpiring returns subscriptions expiring before the given time.
 	ListExpiring(ctx context.Context, before time.Time) ([]Subscription, error)
 
+	// ListByStatus returns all subscriptions with the given status.
+	ListByStatus(ctx context.Context, status Status) ([]Subscription, error)
+
 	// UpdateStatus changes a subscription's status.
 	UpdateStatus(ctx context.Context, id string, status Status) error
 
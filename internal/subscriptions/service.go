@@ -2,16 +2,35 @@ package subscriptions
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/CedrosPay/server/internal/callbacks"
+)
+
+// Errors returned when a subscription can't make the requested status transition.
+var (
+	ErrNotPausable = errors.New("only active or trialing subscriptions can be paused")
+	ErrNotPaused   = errors.New("subscription is not paused")
 )
 
+// ErrInvalidQuantity is returned for a seat count below one.
+var ErrInvalidQuantity = errors.New("quantity must be at least 1")
+
 // Service provides subscription management operations.
 type Service struct {
 	repo             Repository
 	gracePeriodHours int
+	pastDueGrace     time.Duration // Access kept after a failed payment (0 cuts it off)
+	notifier         callbacks.Notifier
+
+	// Renewal reminders for x402 subscriptions (disabled while scheduler is nil)
+	scheduler     callbacks.Scheduler
+	reminderAhead time.Duration
 }
 
 // NewService creates a new subscription service.
@@ -19,9 +38,38 @@ func NewService(repo Repository, gracePeriodHours int) *Service {
 	return &Service{
 		repo:             repo,
 		gracePeriodHours: gracePeriodHours,
+		notifier:         callbacks.NoopNotifier{},
 	}
 }
 
+// SetNotifier sets where subscription status transitions (pause, resume) are reported.
+func (s *Service) SetNotifier(notifier callbacks.Notifier) {
+	if notifier == nil {
+		notifier = callbacks.NoopNotifier{}
+	}
+	s.notifier = notifier
+}
+
+// SetPastDueGracePeriod sets how long a past-due subscription keeps granting access after
+// its payment failed. Zero, the default, cuts access off as soon as the period ends.
+func (s *Service) SetPastDueGracePeriod(grace time.Duration) {
+	if grace < 0 {
+		grace = 0
+	}
+	s.pastDueGrace = grace
+}
+
+// SetRenewalReminders schedules a subscription.renewal_reminder callback this long before
+// each x402 subscription's period ends, whenever the period is set. Stripe sends its own
+// renewal notices, so Stripe subscriptions are skipped.
+func (s *Service) SetRenewalReminders(scheduler callbacks.Scheduler, ahead time.Duration) {
+	if ahead <= 0 {
+		scheduler = nil
+	}
+	s.scheduler = scheduler
+	s.reminderAhead = ahead
+}
+
 // CreateStripeSubscription creates a new Stripe-backed subscription.
 func (s *Service) CreateStripeSubscription(ctx context.Context, req CreateStripeSubscriptionRequest) (Subscription, error) {
 	if req.ProductID == "" {
@@ -34,6 +82,14 @@ func (s *Service) CreateStripeSubscription(ctx context.Context, req CreateStripe
 		return Subscription{}, fmt.Errorf("stripe_subscription_id is required")
 	}
 
+	if req.Quantity < 0 {
+		return Subscription{}, ErrInvalidQuantity
+	}
+	quantity := req.Quantity
+	if quantity == 0 {
+		quantity = 1
+	}
+
 	now := time.Now()
 	periodEnd := req.CurrentPeriodEnd
 	if periodEnd.IsZero() {
@@ -48,6 +104,7 @@ func (s *Service) CreateStripeSubscription(ctx context.Context, req CreateStripe
 		PaymentMethod:        PaymentMethodStripe,
 		BillingPeriod:        req.BillingPeriod,
 		BillingInterval:      req.BillingInterval,
+		Quantity:             quantity,
 		Status:               StatusActive,
 		CurrentPeriodStart:   now,
 		CurrentPeriodEnd:     periodEnd,
@@ -65,6 +122,7 @@ func (s *Service) CreateStripeSubscription(ctx context.Context, req CreateStripe
 		return Subscription{}, fmt.Errorf("create subscription: %w", err)
 	}
 
+	s.notifyTransition(ctx, callbacks.SubscriptionCreated, sub, "")
 	return sub, nil
 }
 
@@ -94,6 +152,7 @@ func (s *Service) CreateX402Subscription(ctx context.Context, req CreateX402Subs
 		PaymentMethod:      PaymentMethodX402,
 		BillingPeriod:      req.BillingPeriod,
 		BillingInterval:    req.BillingInterval,
+		Quantity:           1,
 		Status:             StatusActive,
 		CurrentPeriodStart: now,
 		CurrentPeriodEnd:   periodEnd,
@@ -106,6 +165,8 @@ func (s *Service) CreateX402Subscription(ctx context.Context, req CreateX402Subs
 		return Subscription{}, fmt.Errorf("create subscription: %w", err)
 	}
 
+	s.notifyTransition(ctx, callbacks.SubscriptionCreated, sub, "")
+	s.scheduleRenewalReminder(ctx, sub)
 	return sub, nil
 }
 
@@ -126,6 +187,7 @@ func (s *Service) ExtendX402Subscription(ctx context.Context, id string, period
 	newEnd := CalculatePeriodEnd(newStart, period, interval)
 
 	// Update the subscription
+	previous := sub.Status
 	sub.CurrentPeriodStart = newStart
 	sub.CurrentPeriodEnd = newEnd
 	sub.Status = StatusActive
@@ -135,9 +197,29 @@ func (s *Service) ExtendX402Subscription(ctx context.Context, id string, period
 		return Subscription{}, fmt.Errorf("update subscription: %w", err)
 	}
 
+	s.notifyTransition(ctx, callbacks.SubscriptionRenewed, sub, previous)
+	s.scheduleRenewalReminder(ctx, sub)
 	return sub, nil
 }
 
+// scheduleRenewalReminder queues the renewal reminder for sub's current period, unless that
+// period ends too soon for one. A reminder scheduled for an earlier period is still sent;
+// its currentPeriodEnd tells receivers it is stale.
+func (s *Service) scheduleRenewalReminder(ctx context.Context, sub Subscription) {
+	if s.scheduler == nil || sub.PaymentMethod != PaymentMethodX402 {
+		return
+	}
+	at := sub.CurrentPeriodEnd.Add(-s.reminderAhead)
+	if !at.After(time.Now()) {
+		return
+	}
+
+	event := sub.CallbackEvent(callbacks.SubscriptionRenewalReminder, sub.Status)
+	// One ID per period, so scheduling the same reminder twice is deduplicated downstream
+	event.EventID = fmt.Sprintf("evt_renewal_reminder_%s_%d", sub.ID, sub.CurrentPeriodEnd.Unix())
+	_ = s.scheduler.ScheduleSubscription(ctx, event, at) // The scheduler logs failures; the renewal stands
+}
+
 // HasAccess checks if a wallet has active subscription access to a product.
 func (s *Service) HasAccess(ctx context.Context, wallet, productID string) (bool, *Subscription, error) {
 	sub, err := s.repo.GetByWallet(ctx, wallet, productID)
@@ -148,8 +230,8 @@ func (s *Service) HasAccess(ctx context.Context, wallet, productID string) (bool
 		return false, nil, fmt.Errorf("get subscription: %w", err)
 	}
 
-	// Check if subscription is active
-	if sub.IsActive() {
+	// Check if subscription is active, or past due within its grace window
+	if s.grantsAccess(sub, time.Now()) {
 		return true, &sub, nil
 	}
 
@@ -174,7 +256,21 @@ func (s *Service) HasStripeAccess(ctx context.Context, stripeSubID string) (bool
 		return false, nil, fmt.Errorf("get subscription: %w", err)
 	}
 
-	return sub.IsActive(), &sub, nil
+	return s.grantsAccess(sub, time.Now()), &sub, nil
+}
+
+// grantsAccess reports whether sub grants access at now: while it is active, and while it
+// is past due until the past-due grace period ends.
+func (s *Service) grantsAccess(sub Subscription, now time.Time) bool {
+	if sub.IsActiveAt(now) {
+		return true
+	}
+	if s.pastDueGrace > 0 {
+		if end, ok := sub.GracePeriodEnd(s.pastDueGrace); ok && now.Before(end) {
+			return true
+		}
+	}
+	return false
 }
 
 // Cancel cancels a subscription.
@@ -184,15 +280,26 @@ func (s *Service) Cancel(ctx context.Context, id string, atPeriodEnd bool) error
 		return fmt.Errorf("get subscription: %w", err)
 	}
 
+	previous := sub.Status
 	if atPeriodEnd {
-		// Mark to cancel at end of period
+		// Mark to cancel at end of period; access continues until then
 		sub.CancelAtPeriodEnd = true
 		sub.UpdatedAt = time.Now()
-		return s.repo.Update(ctx, sub)
+		if err := s.repo.Update(ctx, sub); err != nil {
+			return err
+		}
+		s.notifyTransition(ctx, callbacks.SubscriptionCancelled, sub, previous)
+		return nil
 	}
 
 	// Cancel immediately
-	return s.repo.UpdateStatus(ctx, id, StatusCancelled)
+	if err := s.repo.UpdateStatus(ctx, id, StatusCancelled); err != nil {
+		return err
+	}
+	sub.Status = StatusCancelled
+	sub.UpdatedAt = time.Now()
+	s.notifyStatusChange(ctx, sub, previous)
+	return nil
 }
 
 // HandleStripeRenewal processes a successful Stripe subscription renewal.
@@ -203,17 +310,38 @@ func (s *Service) HandleStripeRenewal(ctx context.Context, stripeSubID string, p
 	}
 
 	// Update period and ensure status is active
+	now := time.Now()
+	previous := sub.Status
 	sub.CurrentPeriodStart = periodStart
 	sub.CurrentPeriodEnd = periodEnd
-	sub.Status = StatusActive
-	sub.UpdatedAt = time.Now()
+	sub.SetStatus(StatusActive, now)
+	sub.UpdatedAt = now
 
-	return s.repo.Update(ctx, sub)
+	if err := s.repo.Update(ctx, sub); err != nil {
+		return err
+	}
+	s.notifyTransition(ctx, callbacks.SubscriptionRenewed, sub, previous)
+	return nil
 }
 
-// HandleStripePaymentFailed marks a subscription as past due.
+// HandleStripePaymentFailed marks a subscription as past due, starting its grace period
+// unless it already was.
 func (s *Service) HandleStripePaymentFailed(ctx context.Context, stripeSubID string) error {
-	return s.repo.UpdateStatus(ctx, stripeSubID, StatusPastDue)
+	sub, err := s.repo.GetByStripeSubscriptionID(ctx, stripeSubID)
+	if err != nil {
+		return fmt.Errorf("get subscription: %w", err)
+	}
+
+	now := time.Now()
+	previous := sub.Status
+	sub.SetStatus(StatusPastDue, now)
+	sub.UpdatedAt = now
+
+	if err := s.repo.Update(ctx, sub); err != nil {
+		return err
+	}
+	s.notifyStatusChange(ctx, sub, previous)
+	return nil
 }
 
 // HandleStripeCancelled marks a subscription as cancelled.
@@ -223,7 +351,14 @@ func (s *Service) HandleStripeCancelled(ctx context.Context, stripeSubID string)
 		return fmt.Errorf("get subscription: %w", err)
 	}
 
-	return s.repo.UpdateStatus(ctx, sub.ID, StatusCancelled)
+	if err := s.repo.UpdateStatus(ctx, sub.ID, StatusCancelled); err != nil {
+		return err
+	}
+	previous := sub.Status
+	sub.Status = StatusCancelled
+	sub.UpdatedAt = time.Now()
+	s.notifyStatusChange(ctx, sub, previous)
+	return nil
 }
 
 // Get retrieves a subscription by ID.
@@ -241,6 +376,16 @@ func (s *Service) GetByStripeSubscriptionID(ctx context.Context, stripeSubID str
 	return s.repo.GetByStripeSubscriptionID(ctx, stripeSubID)
 }
 
+// GetByStripeCustomerID retrieves all subscriptions for a Stripe customer.
+func (s *Service) GetByStripeCustomerID(ctx context.Context, customerID string) ([]Subscription, error) {
+	return s.repo.GetByStripeCustomerID(ctx, customerID)
+}
+
+// ListByWallet retrieves all subscriptions for a wallet, across products.
+func (s *Service) ListByWallet(ctx context.Context, wallet string) ([]Subscription, error) {
+	return s.repo.ListByWallet(ctx, wallet)
+}
+
 // ListExpiring returns subscriptions expiring within the given duration.
 func (s *Service) ListExpiring(ctx context.Context, within time.Duration) ([]Subscription, error) {
 	return s.repo.ListExpiring(ctx, time.Now().Add(within))
@@ -269,6 +414,46 @@ func (s *Service) ExpireOverdue(ctx context.Context) (int, error) {
 	return count, nil
 }
 
+// NotifyGracePeriodsExpiring sends a subscription.grace_period_expiring callback for each
+// past-due subscription whose grace period ends within the given duration, once per time
+// it becomes past due. It returns how many callbacks were sent.
+func (s *Service) NotifyGracePeriodsExpiring(ctx context.Context, within time.Duration) (int, error) {
+	if s.pastDueGrace <= 0 {
+		return 0, nil
+	}
+
+	pastDue, err := s.repo.ListByStatus(ctx, StatusPastDue)
+	if err != nil {
+		return 0, fmt.Errorf("list past due: %w", err)
+	}
+
+	now := time.Now()
+	count := 0
+	for _, sub := range pastDue {
+		end, ok := sub.GracePeriodEnd(s.pastDueGrace)
+		if !ok || !now.Before(end) || end.After(now.Add(within)) {
+			continue
+		}
+		if sub.Metadata["grace_expiring_notified_at"] != "" {
+			continue
+		}
+
+		// Record the notice first so a failed update can't send it twice
+		sub.Metadata["grace_expiring_notified_at"] = now.UTC().Format(time.RFC3339)
+		sub.UpdatedAt = now
+		if err := s.repo.Update(ctx, sub); err != nil {
+			continue // Retried on the next pass
+		}
+
+		event := sub.CallbackEvent(callbacks.SubscriptionGracePeriodExpiring, StatusPastDue)
+		event.GracePeriodEnd = &end
+		s.notifier.SubscriptionChanged(ctx, event)
+		count++
+	}
+
+	return count, nil
+}
+
 // ChangeSubscription changes a subscription to a different plan (upgrade/downgrade).
 // For Stripe subscriptions, this should be called after the Stripe API update.
 // For x402 subscriptions, this handles the local database update.
@@ -296,6 +481,12 @@ func (s *Service) ChangeSubscription(ctx context.Context, req ChangeSubscription
 	if req.NewBillingInterval > 0 {
 		sub.BillingInterval = req.NewBillingInterval
 	}
+	if req.NewQuantity < 0 {
+		return nil, ErrInvalidQuantity
+	}
+	if req.NewQuantity > 0 {
+		sub.Quantity = req.NewQuantity
+	}
 
 	// Merge metadata
 	if sub.Metadata == nil {
@@ -321,6 +512,33 @@ func (s *Service) ChangeSubscription(ctx context.Context, req ChangeSubscription
 	}, nil
 }
 
+// UpdateQuantity changes a subscription's seat count mid-period.
+// For Stripe subscriptions, this should be called after the Stripe API update, which prorates it.
+func (s *Service) UpdateQuantity(ctx context.Context, id string, quantity int) (Subscription, error) {
+	if quantity < 1 {
+		return Subscription{}, ErrInvalidQuantity
+	}
+
+	sub, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return Subscription{}, fmt.Errorf("get subscription: %w", err)
+	}
+
+	if sub.Metadata == nil {
+		sub.Metadata = make(map[string]string)
+	}
+	sub.Metadata["previous_quantity"] = strconv.Itoa(seatQuantity(sub.Quantity))
+	sub.Metadata["quantity_changed_at"] = time.Now().UTC().Format(time.RFC3339)
+	sub.Quantity = quantity
+	sub.UpdatedAt = time.Now()
+
+	if err := s.repo.Update(ctx, sub); err != nil {
+		return Subscription{}, fmt.Errorf("update subscription: %w", err)
+	}
+
+	return sub, nil
+}
+
 // ReactivateSubscription reactivates a cancelled subscription (if still within period).
 func (s *Service) ReactivateSubscription(ctx context.Context, id string) (Subscription, error) {
 	sub, err := s.repo.Get(ctx, id)
@@ -349,6 +567,122 @@ func (s *Service) ReactivateSubscription(ctx context.Context, id string) (Subscr
 	return sub, nil
 }
 
+// Pause suspends an active or trialing subscription: it grants no access until resumed.
+// For Stripe subscriptions, this should be called after collection is paused in Stripe.
+func (s *Service) Pause(ctx context.Context, id string) (Subscription, error) {
+	sub, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return Subscription{}, fmt.Errorf("get subscription: %w", err)
+	}
+	if sub.Status != StatusActive && sub.Status != StatusTrialing {
+		return Subscription{}, ErrNotPausable
+	}
+
+	previous := sub.Status
+	now := time.Now()
+	if sub.Metadata == nil {
+		sub.Metadata = make(map[string]string)
+	}
+	sub.Metadata["paused_at"] = now.UTC().Format(time.RFC3339)
+	sub.Status = StatusPaused
+	sub.UpdatedAt = now
+
+	if err := s.repo.Update(ctx, sub); err != nil {
+		return Subscription{}, fmt.Errorf("update subscription: %w", err)
+	}
+
+	s.notifyTransition(ctx, callbacks.SubscriptionPaused, sub, previous)
+	return sub, nil
+}
+
+// Resume reactivates a paused subscription, returning it to trialing if its trial hasn't
+// ended. The current period is left as is: Stripe moves it on the next invoice, and an
+// x402 subscription whose period ended while paused expires as usual.
+// For Stripe subscriptions, this should be called after collection is resumed in Stripe.
+func (s *Service) Resume(ctx context.Context, id string) (Subscription, error) {
+	sub, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return Subscription{}, fmt.Errorf("get subscription: %w", err)
+	}
+	if sub.Status != StatusPaused {
+		return Subscription{}, ErrNotPaused
+	}
+
+	now := time.Now()
+	sub.Status = StatusActive
+	if sub.TrialEnd != nil && now.Before(*sub.TrialEnd) {
+		sub.Status = StatusTrialing
+	}
+	if sub.Metadata == nil {
+		sub.Metadata = make(map[string]string)
+	}
+	delete(sub.Metadata, "paused_at")
+	sub.Metadata["resumed_at"] = now.UTC().Format(time.RFC3339)
+	sub.UpdatedAt = now
+
+	if err := s.repo.Update(ctx, sub); err != nil {
+		return Subscription{}, fmt.Errorf("update subscription: %w", err)
+	}
+
+	s.notifyTransition(ctx, callbacks.SubscriptionResumed, sub, StatusPaused)
+	return sub, nil
+}
+
+// notifyTransition reports a subscription's move from previous to its current status.
+func (s *Service) notifyTransition(ctx context.Context, eventType string, sub Subscription, previous Status) {
+	s.notifier.SubscriptionChanged(ctx, sub.CallbackEvent(eventType, previous))
+}
+
+// notifyStatusChange reports sub's move from previous to its current status, if it is one
+// merchants are told about.
+func (s *Service) notifyStatusChange(ctx context.Context, sub Subscription, previous Status) {
+	if eventType := TransitionEventType(previous, sub.Status); eventType != "" {
+		s.notifyTransition(ctx, eventType, sub, previous)
+	}
+}
+
+// CallbackEvent builds the callback for sub having reached its current status from previous
+// (empty when it was just created).
+func (sub Subscription) CallbackEvent(eventType string, previous Status) callbacks.SubscriptionEvent {
+	return callbacks.SubscriptionEvent{
+		EventType:            eventType,
+		SubscriptionID:       sub.ID,
+		ProductID:            sub.ProductID,
+		PaymentMethod:        string(sub.PaymentMethod),
+		Wallet:               sub.Wallet,
+		StripeCustomerID:     sub.StripeCustomerID,
+		StripeSubscriptionID: sub.StripeSubscriptionID,
+		PreviousStatus:       string(previous),
+		Status:               string(sub.Status),
+		CurrentPeriodStart:   sub.CurrentPeriodStart,
+		CurrentPeriodEnd:     sub.CurrentPeriodEnd,
+		CancelAtPeriodEnd:    sub.CancelAtPeriodEnd,
+		Metadata:             sub.Metadata,
+		ChangedAt:            sub.UpdatedAt.UTC(),
+	}
+}
+
+// TransitionEventType returns the callback event type for a status change, or "" if the
+// change isn't reported.
+func TransitionEventType(previous, current Status) string {
+	if previous == current {
+		return ""
+	}
+	switch current {
+	case StatusCancelled:
+		return callbacks.SubscriptionCancelled
+	case StatusPastDue:
+		return callbacks.SubscriptionPastDue
+	case StatusPaused:
+		return callbacks.SubscriptionPaused
+	case StatusActive, StatusTrialing:
+		if previous == StatusPaused {
+			return callbacks.SubscriptionResumed
+		}
+	}
+	return ""
+}
+
 // HandleStripeSubscriptionUpdated handles subscription update events from Stripe.
 // This is more comprehensive than HandleStripeRenewal - it handles plan changes too.
 func (s *Service) HandleStripeSubscriptionUpdated(ctx context.Context, stripeSubID string, update StripeSubscriptionUpdate) error {
@@ -358,8 +692,9 @@ func (s *Service) HandleStripeSubscriptionUpdated(ctx context.Context, stripeSub
 	}
 
 	// Update status
+	previous := sub.Status
 	if update.Status != "" {
-		sub.Status = update.Status
+		sub.SetStatus(update.Status, time.Now())
 	}
 
 	// Update period dates
@@ -371,6 +706,7 @@ func (s *Service) HandleStripeSubscriptionUpdated(ctx context.Context, stripeSub
 	}
 
 	// Update cancel state
+	cancelScheduled := update.CancelAtPeriodEnd && !sub.CancelAtPeriodEnd
 	sub.CancelAtPeriodEnd = update.CancelAtPeriodEnd
 	if update.CancelledAt != nil {
 		sub.CancelledAt = update.CancelledAt
@@ -393,10 +729,21 @@ func (s *Service) HandleStripeSubscriptionUpdated(ctx context.Context, stripeSub
 	if update.BillingInterval > 0 {
 		sub.BillingInterval = update.BillingInterval
 	}
+	if update.Quantity > 0 {
+		sub.Quantity = update.Quantity
+	}
 
 	sub.UpdatedAt = time.Now()
 
-	return s.repo.Update(ctx, sub)
+	if err := s.repo.Update(ctx, sub); err != nil {
+		return err
+	}
+	if cancelScheduled && sub.Status == previous {
+		s.notifyTransition(ctx, callbacks.SubscriptionCancelled, sub, previous)
+	} else {
+		s.notifyStatusChange(ctx, sub, previous)
+	}
+	return nil
 }
 
 // StripeSubscriptionUpdate contains fields that can be updated from Stripe webhooks.
@@ -409,6 +756,7 @@ type StripeSubscriptionUpdate struct {
 	NewProductID       string        // Set if plan changed
 	BillingPeriod      BillingPeriod // Set if billing interval changed
 	BillingInterval    int
+	Quantity           int // Set if the seat count changed
 }
 
 // CreateStripeSubscriptionRequest contains parameters for creating a Stripe subscription.
@@ -420,6 +768,7 @@ type CreateStripeSubscriptionRequest struct {
 	BillingInterval      int
 	CurrentPeriodEnd     time.Time
 	TrialEnd             *time.Time
+	Quantity             int // Seats for per-seat prices (0 means 1)
 	Metadata             map[string]string
 }
 
@@ -439,6 +788,7 @@ type ChangeSubscriptionRequest struct {
 	NewPriceID      string            // New Stripe price ID (for Stripe subscriptions)
 	NewBillingPeriod   BillingPeriod  // New billing period
 	NewBillingInterval int            // New billing interval
+	NewQuantity        int            // New seat count (0 keeps the current one)
 	ProrationBehavior  string         // "create_prorations", "none", "always_invoice"
 	Metadata        map[string]string // Updated metadata
 }
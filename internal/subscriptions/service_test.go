@@ -0,0 +1,408 @@
+package subscriptions
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/CedrosPay/server/internal/callbacks"
+)
+
+type recordingNotifier struct {
+	callbacks.NoopNotifier
+	events []callbacks.SubscriptionEvent
+}
+
+func (n *recordingNotifier) SubscriptionChanged(_ context.Context, event callbacks.SubscriptionEvent) {
+	n.events = append(n.events, event)
+}
+
+type scheduledEvent struct {
+	event callbacks.SubscriptionEvent
+	at    time.Time
+}
+
+type recordingScheduler struct {
+	scheduled []scheduledEvent
+}
+
+func (s *recordingScheduler) SchedulePayment(context.Context, callbacks.PaymentEvent, time.Time) error {
+	return nil
+}
+
+func (s *recordingScheduler) ScheduleSubscription(_ context.Context, event callbacks.SubscriptionEvent, at time.Time) error {
+	s.scheduled = append(s.scheduled, scheduledEvent{event: event, at: at})
+	return nil
+}
+
+func TestService_PauseResume(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	trialEnd := now.Add(72 * time.Hour)
+
+	tests := []struct {
+		name        string
+		sub         Subscription
+		wantPaused  error
+		wantResumed Status
+	}{
+		{
+			name:        "active subscription",
+			sub:         Subscription{ID: "sub_active", ProductID: "plan-pro", Wallet: "wallet-1", PaymentMethod: PaymentMethodX402, Status: StatusActive},
+			wantResumed: StatusActive,
+		},
+		{
+			name:        "trial resumes as trialing",
+			sub:         Subscription{ID: "sub_trial", ProductID: "plan-pro", StripeSubscriptionID: "sub_stripe", PaymentMethod: PaymentMethodStripe, Status: StatusTrialing, TrialEnd: &trialEnd},
+			wantResumed: StatusTrialing,
+		},
+		{
+			name:       "cancelled subscription",
+			sub:        Subscription{ID: "sub_cancelled", ProductID: "plan-pro", Wallet: "wallet-2", PaymentMethod: PaymentMethodX402, Status: StatusCancelled},
+			wantPaused: ErrNotPausable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewMemoryRepository()
+			notifier := &recordingNotifier{}
+			svc := NewService(repo, 0)
+			svc.SetNotifier(notifier)
+
+			tt.sub.CurrentPeriodStart = now.Add(-time.Hour)
+			tt.sub.CurrentPeriodEnd = now.Add(30 * 24 * time.Hour)
+			if err := repo.Create(ctx, tt.sub); err != nil {
+				t.Fatalf("create: %v", err)
+			}
+
+			paused, err := svc.Pause(ctx, tt.sub.ID)
+			if !errors.Is(err, tt.wantPaused) {
+				t.Fatalf("Pause() error = %v, want %v", err, tt.wantPaused)
+			}
+			if err != nil {
+				if len(notifier.events) != 0 {
+					t.Errorf("expected no callbacks for a rejected pause, got %d", len(notifier.events))
+				}
+				return
+			}
+			if paused.Status != StatusPaused || paused.IsActive() {
+				t.Errorf("paused subscription: status %q, active %v", paused.Status, paused.IsActive())
+			}
+			if _, err := svc.Pause(ctx, tt.sub.ID); !errors.Is(err, ErrNotPausable) {
+				t.Errorf("pausing twice: error = %v, want ErrNotPausable", err)
+			}
+
+			resumed, err := svc.Resume(ctx, tt.sub.ID)
+			if err != nil {
+				t.Fatalf("Resume(): %v", err)
+			}
+			if resumed.Status != tt.wantResumed || !resumed.IsActive() {
+				t.Errorf("resumed subscription: status %q, active %v, want %q", resumed.Status, resumed.IsActive(), tt.wantResumed)
+			}
+			if _, ok := resumed.Metadata["paused_at"]; ok {
+				t.Error("paused_at should be cleared on resume")
+			}
+			if _, err := svc.Resume(ctx, tt.sub.ID); !errors.Is(err, ErrNotPaused) {
+				t.Errorf("resuming twice: error = %v, want ErrNotPaused", err)
+			}
+
+			if len(notifier.events) != 2 {
+				t.Fatalf("expected 2 callbacks, got %d", len(notifier.events))
+			}
+			pausedEvent, resumedEvent := notifier.events[0], notifier.events[1]
+			if pausedEvent.EventType != callbacks.SubscriptionPaused || pausedEvent.PreviousStatus != string(tt.sub.Status) || pausedEvent.Status != string(StatusPaused) {
+				t.Errorf("pause callback = %+v", pausedEvent)
+			}
+			if resumedEvent.EventType != callbacks.SubscriptionResumed || resumedEvent.PreviousStatus != string(StatusPaused) || resumedEvent.Status != string(tt.wantResumed) {
+				t.Errorf("resume callback = %+v", resumedEvent)
+			}
+			if pausedEvent.SubscriptionID != tt.sub.ID || pausedEvent.ProductID != tt.sub.ProductID {
+				t.Errorf("pause callback identifies %q/%q, want %q/%q", pausedEvent.SubscriptionID, pausedEvent.ProductID, tt.sub.ID, tt.sub.ProductID)
+			}
+		})
+	}
+}
+
+func TestService_Quantity(t *testing.T) {
+	ctx := context.Background()
+	svc := NewService(NewMemoryRepository(), 0)
+
+	sub, err := svc.CreateStripeSubscription(ctx, CreateStripeSubscriptionRequest{
+		ProductID:            "plan-team",
+		StripeCustomerID:     "cus_123",
+		StripeSubscriptionID: "sub_stripe_team",
+		BillingPeriod:        PeriodMonth,
+		BillingInterval:      1,
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if sub.Quantity != 1 {
+		t.Errorf("default quantity = %d, want 1", sub.Quantity)
+	}
+
+	tests := []struct {
+		name     string
+		quantity int
+		wantErr  error
+		want     int
+		previous string
+	}{
+		{name: "add seats", quantity: 5, want: 5, previous: "1"},
+		{name: "remove seats", quantity: 3, want: 3, previous: "5"},
+		{name: "zero seats", quantity: 0, wantErr: ErrInvalidQuantity, want: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated, err := svc.UpdateQuantity(ctx, sub.ID, tt.quantity)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpdateQuantity(%d) error = %v, want %v", tt.quantity, err, tt.wantErr)
+			}
+			stored, _ := svc.Get(ctx, sub.ID)
+			if stored.Quantity != tt.want {
+				t.Errorf("stored quantity = %d, want %d", stored.Quantity, tt.want)
+			}
+			if err == nil && updated.Metadata["previous_quantity"] != tt.previous {
+				t.Errorf("previous_quantity = %q, want %q", updated.Metadata["previous_quantity"], tt.previous)
+			}
+		})
+	}
+
+	// A plan change without a quantity keeps the seats
+	result, err := svc.ChangeSubscription(ctx, ChangeSubscriptionRequest{SubscriptionID: sub.ID, NewProductID: "plan-business"})
+	if err != nil {
+		t.Fatalf("change: %v", err)
+	}
+	if result.Subscription.Quantity != 3 {
+		t.Errorf("quantity after plan change = %d, want 3", result.Subscription.Quantity)
+	}
+	result, err = svc.ChangeSubscription(ctx, ChangeSubscriptionRequest{SubscriptionID: sub.ID, NewProductID: "plan-team", NewQuantity: 8})
+	if err != nil {
+		t.Fatalf("change with seats: %v", err)
+	}
+	if result.Subscription.Quantity != 8 {
+		t.Errorf("quantity after plan change with seats = %d, want 8", result.Subscription.Quantity)
+	}
+}
+
+func TestService_PastDueGracePeriod(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	tests := []struct {
+		name         string
+		grace        time.Duration
+		failedAgo    time.Duration
+		wantAccess   bool
+		wantNotified bool
+	}{
+		{name: "no grace period", failedAgo: time.Hour},
+		{name: "early in grace period", grace: 72 * time.Hour, failedAgo: time.Hour, wantAccess: true},
+		{name: "grace period ending", grace: 72 * time.Hour, failedAgo: 60 * time.Hour, wantAccess: true, wantNotified: true},
+		{name: "grace period over", grace: 72 * time.Hour, failedAgo: 80 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewMemoryRepository()
+			notifier := &recordingNotifier{}
+			svc := NewService(repo, 0)
+			svc.SetNotifier(notifier)
+			svc.SetPastDueGracePeriod(tt.grace)
+
+			// The renewal failed: the period has ended and the subscription is past due
+			sub := Subscription{
+				ID:                   "sub_past_due",
+				ProductID:            "plan-pro",
+				Wallet:               "wallet-1",
+				StripeSubscriptionID: "sub_stripe",
+				PaymentMethod:        PaymentMethodStripe,
+				Status:               StatusActive,
+				CurrentPeriodStart:   now.Add(-30 * 24 * time.Hour),
+				CurrentPeriodEnd:     now.Add(-tt.failedAgo),
+			}
+			sub.SetStatus(StatusPastDue, now.Add(-tt.failedAgo))
+			if err := repo.Create(ctx, sub); err != nil {
+				t.Fatalf("create: %v", err)
+			}
+
+			hasAccess, _, err := svc.HasAccess(ctx, "wallet-1", "plan-pro")
+			if err != nil {
+				t.Fatalf("HasAccess(): %v", err)
+			}
+			if hasAccess != tt.wantAccess {
+				t.Errorf("HasAccess() = %v, want %v", hasAccess, tt.wantAccess)
+			}
+
+			for pass := 0; pass < 2; pass++ {
+				if _, err := svc.NotifyGracePeriodsExpiring(ctx, 24*time.Hour); err != nil {
+					t.Fatalf("NotifyGracePeriodsExpiring(): %v", err)
+				}
+			}
+			if !tt.wantNotified {
+				if len(notifier.events) != 0 {
+					t.Errorf("expected no callbacks, got %d", len(notifier.events))
+				}
+				return
+			}
+			if len(notifier.events) != 1 {
+				t.Fatalf("expected 1 callback across two passes, got %d", len(notifier.events))
+			}
+			event := notifier.events[0]
+			if event.EventType != callbacks.SubscriptionGracePeriodExpiring || event.Status != string(StatusPastDue) {
+				t.Errorf("grace callback = %+v", event)
+			}
+			if event.GracePeriodEnd == nil || event.GracePeriodEnd.Sub(now.Add(tt.grace-tt.failedAgo)).Abs() > time.Second {
+				t.Errorf("grace callback ends at %v, want %v", event.GracePeriodEnd, now.Add(tt.grace-tt.failedAgo))
+			}
+		})
+	}
+}
+
+func TestService_LifecycleCallbacks(t *testing.T) {
+	ctx := context.Background()
+	notifier := &recordingNotifier{}
+	svc := NewService(NewMemoryRepository(), 0)
+	svc.SetNotifier(notifier)
+
+	x402, err := svc.CreateX402Subscription(ctx, CreateX402SubscriptionRequest{
+		ProductID:       "plan-pro",
+		Wallet:          "wallet-1",
+		BillingPeriod:   PeriodMonth,
+		BillingInterval: 1,
+	})
+	if err != nil {
+		t.Fatalf("create x402: %v", err)
+	}
+	// Paying again while active renews the same subscription
+	if _, err := svc.CreateX402Subscription(ctx, CreateX402SubscriptionRequest{
+		ProductID:       "plan-pro",
+		Wallet:          "wallet-1",
+		BillingPeriod:   PeriodMonth,
+		BillingInterval: 1,
+	}); err != nil {
+		t.Fatalf("renew x402: %v", err)
+	}
+	if err := svc.Cancel(ctx, x402.ID, true); err != nil {
+		t.Fatalf("schedule cancel: %v", err)
+	}
+	if err := svc.Cancel(ctx, x402.ID, false); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+
+	card, err := svc.CreateStripeSubscription(ctx, CreateStripeSubscriptionRequest{
+		ProductID:            "plan-pro",
+		StripeCustomerID:     "cus_123",
+		StripeSubscriptionID: "sub_stripe",
+		BillingPeriod:        PeriodMonth,
+		BillingInterval:      1,
+	})
+	if err != nil {
+		t.Fatalf("create stripe: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := svc.HandleStripePaymentFailed(ctx, "sub_stripe"); err != nil {
+			t.Fatalf("payment failed: %v", err)
+		}
+	}
+	if err := svc.HandleStripeRenewal(ctx, "sub_stripe", time.Now(), time.Now().Add(30*24*time.Hour)); err != nil {
+		t.Fatalf("renewal: %v", err)
+	}
+	if err := svc.HandleStripeCancelled(ctx, "sub_stripe"); err != nil {
+		t.Fatalf("cancelled: %v", err)
+	}
+
+	want := []struct {
+		eventType      string
+		subscriptionID string
+		previous       Status
+		status         Status
+	}{
+		{callbacks.SubscriptionCreated, x402.ID, "", StatusActive},
+		{callbacks.SubscriptionRenewed, x402.ID, StatusActive, StatusActive},
+		{callbacks.SubscriptionCancelled, x402.ID, StatusActive, StatusActive},
+		{callbacks.SubscriptionCancelled, x402.ID, StatusActive, StatusCancelled},
+		{callbacks.SubscriptionCreated, card.ID, "", StatusActive},
+		{callbacks.SubscriptionPastDue, card.ID, StatusActive, StatusPastDue},
+		{callbacks.SubscriptionRenewed, card.ID, StatusPastDue, StatusActive},
+		{callbacks.SubscriptionCancelled, card.ID, StatusActive, StatusCancelled},
+	}
+	if len(notifier.events) != len(want) {
+		t.Fatalf("got %d callbacks, want %d", len(notifier.events), len(want))
+	}
+	for i, w := range want {
+		got := notifier.events[i]
+		if got.EventType != w.eventType || got.SubscriptionID != w.subscriptionID || got.PreviousStatus != string(w.previous) || got.Status != string(w.status) {
+			t.Errorf("callback %d = %s %s %q->%q, want %s %s %q->%q", i,
+				got.EventType, got.SubscriptionID, got.PreviousStatus, got.Status,
+				w.eventType, w.subscriptionID, w.previous, w.status)
+		}
+	}
+	if !notifier.events[2].CancelAtPeriodEnd {
+		t.Error("scheduled cancellation should set cancelAtPeriodEnd")
+	}
+}
+
+func TestService_RenewalReminders(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		period BillingPeriod
+		ahead  time.Duration
+		want   int
+	}{
+		{name: "monthly subscription", period: PeriodMonth, ahead: 72 * time.Hour, want: 2},
+		{name: "period shorter than the lead time", period: PeriodDay, ahead: 72 * time.Hour, want: 0},
+		{name: "disabled", period: PeriodMonth, ahead: 0, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheduler := &recordingScheduler{}
+			svc := NewService(NewMemoryRepository(), 0)
+			svc.SetRenewalReminders(scheduler, tt.ahead)
+
+			sub, err := svc.CreateX402Subscription(ctx, CreateX402SubscriptionRequest{
+				ProductID:       "plan-pro",
+				Wallet:          "wallet-1",
+				BillingPeriod:   tt.period,
+				BillingInterval: 1,
+			})
+			if err != nil {
+				t.Fatalf("create: %v", err)
+			}
+			if sub, err = svc.ExtendX402Subscription(ctx, sub.ID, tt.period, 1); err != nil {
+				t.Fatalf("extend: %v", err)
+			}
+			if _, err := svc.CreateStripeSubscription(ctx, CreateStripeSubscriptionRequest{
+				ProductID:            "plan-pro",
+				StripeCustomerID:     "cus_123",
+				StripeSubscriptionID: "sub_stripe",
+				BillingPeriod:        tt.period,
+				BillingInterval:      1,
+			}); err != nil {
+				t.Fatalf("create stripe: %v", err)
+			}
+
+			if len(scheduler.scheduled) != tt.want {
+				t.Fatalf("scheduled %d reminders, want %d", len(scheduler.scheduled), tt.want)
+			}
+			if tt.want == 0 {
+				return
+			}
+			last := scheduler.scheduled[len(scheduler.scheduled)-1]
+			if last.event.EventType != callbacks.SubscriptionRenewalReminder || last.event.SubscriptionID != sub.ID {
+				t.Errorf("reminder = %+v, want %s for %s", last.event, callbacks.SubscriptionRenewalReminder, sub.ID)
+			}
+			if want := sub.CurrentPeriodEnd.Add(-tt.ahead); !last.at.Equal(want) {
+				t.Errorf("reminder at %v, want %v", last.at, want)
+			}
+			if first := scheduler.scheduled[0]; first.event.EventID == last.event.EventID {
+				t.Errorf("both periods share event ID %s", last.event.EventID)
+			}
+		})
+	}
+}
@@ -22,6 +22,10 @@ const (
 
 	// StatusTrialing indicates the subscription is in a free trial period.
 	StatusTrialing Status = "trialing"
+
+	// StatusPaused indicates the subscription was paused: it grants no access and, for
+	// Stripe, no payments are collected until it is resumed.
+	StatusPaused Status = "paused"
 )
 
 // BillingPeriod represents the unit of time for subscription billing.
@@ -56,6 +60,7 @@ type Subscription struct {
 	PaymentMethod   PaymentMethod `json:"paymentMethod"`
 	BillingPeriod   BillingPeriod `json:"billingPeriod"`
 	BillingInterval int           `json:"billingInterval"` // e.g., 1 month, 3 months
+	Quantity        int           `json:"quantity"`        // Seats billed for per-seat prices (1 otherwise)
 
 	// Status and dates
 	Status             Status     `json:"status"`
@@ -90,6 +95,37 @@ func (s Subscription) IsActiveAt(t time.Time) bool {
 	}
 }
 
+// SetStatus moves the subscription to status, recording in its metadata when it became past
+// due (past_due_at) so access can continue through a grace window after a failed payment.
+func (s *Subscription) SetStatus(status Status, now time.Time) {
+	if status == StatusPastDue && s.Status != StatusPastDue {
+		if s.Metadata == nil {
+			s.Metadata = make(map[string]string)
+		}
+		s.Metadata["past_due_at"] = now.UTC().Format(time.RFC3339)
+		delete(s.Metadata, "grace_expiring_notified_at")
+	}
+	if status != StatusPastDue {
+		delete(s.Metadata, "past_due_at")
+		delete(s.Metadata, "grace_expiring_notified_at")
+	}
+	s.Status = status
+}
+
+// GracePeriodEnd returns when a past-due subscription's grace window of the given length
+// ends. It reports false if the subscription isn't past due or when it became past due
+// wasn't recorded.
+func (s Subscription) GracePeriodEnd(grace time.Duration) (time.Time, bool) {
+	if s.Status != StatusPastDue {
+		return time.Time{}, false
+	}
+	since, err := time.Parse(time.RFC3339, s.Metadata["past_due_at"])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return since.Add(grace), true
+}
+
 // IsTrialing checks if the subscription is currently in a trial period.
 func (s Subscription) IsTrialing() bool {
 	if s.Status != StatusTrialing {
@@ -108,6 +144,14 @@ func (s Subscription) DaysUntilExpiration() int {
 	return int(duration.Hours() / 24)
 }
 
+// seatQuantity treats records created without a seat count as one seat.
+func seatQuantity(quantity int) int {
+	if quantity < 1 {
+		return 1
+	}
+	return quantity
+}
+
 // NextPeriodEnd calculates what the next period end would be after renewal.
 func (s Subscription) NextPeriodEnd() time.Time {
 	return CalculatePeriodEnd(s.CurrentPeriodEnd, s.BillingPeriod, s.BillingInterval)
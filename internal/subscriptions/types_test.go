@@ -40,6 +40,15 @@ func TestSubscription_IsActive(t *testing.T) {
 			},
 			want: false,
 		},
+		{
+			name: "paused subscription within period",
+			sub: Subscription{
+				Status:             StatusPaused,
+				CurrentPeriodStart: now.Add(-24 * time.Hour),
+				CurrentPeriodEnd:   now.Add(24 * time.Hour),
+			},
+			want: false,
+		},
 		{
 			name: "past due subscription within period",
 			sub: Subscription{
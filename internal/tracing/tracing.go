@@ -0,0 +1,130 @@
+// Package tracing wires OpenTelemetry distributed tracing: OTLP export, W3C trace context
+// propagation, and small helpers the HTTP server, storage, Solana, and Stripe layers use to
+// open spans. When tracing is disabled the global no-op provider makes every helper free.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/CedrosPay/server/internal/config"
+)
+
+// instrumentationName identifies spans created by this server.
+const instrumentationName = "github.com/CedrosPay/server"
+
+// shutdownTimeout bounds how long Close waits to flush buffered spans.
+const shutdownTimeout = 5 * time.Second
+
+// Tracer returns the tracer used for all Cedros spans.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Provider owns the exporting tracer provider installed by Setup.
+type Provider struct {
+	tp *sdktrace.TracerProvider
+}
+
+// Setup installs a global tracer provider that batches spans to an OTLP collector and enables
+// W3C traceparent/baggage propagation. Call Close on shutdown to flush pending spans.
+func Setup(ctx context.Context, cfg config.TracingConfig, environment string) (*Provider, error) {
+	exporter, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("create otlp exporter: %w", err)
+	}
+
+	attrs := resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(cfg.ServiceName))
+	if environment != "" {
+		attrs, err = resource.Merge(attrs, resource.NewWithAttributes(semconv.SchemaURL, semconv.DeploymentEnvironmentName(environment)))
+		if err != nil {
+			return nil, fmt.Errorf("build resource: %w", err)
+		}
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(attrs),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return &Provider{tp: tp}, nil
+}
+
+// Close flushes buffered spans and stops the exporter.
+func (p *Provider) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return p.tp.Shutdown(ctx)
+}
+
+func newExporter(ctx context.Context, cfg config.TracingConfig) (sdktrace.SpanExporter, error) {
+	// An empty endpoint leaves the exporter to OTEL_EXPORTER_OTLP_* environment variables
+	switch cfg.Protocol {
+	case "http":
+		var opts []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlptracehttp.WithHeaders(cfg.Headers))
+		}
+		return otlptracehttp.New(ctx, opts...)
+	case "grpc", "":
+		var opts []otlptracegrpc.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlptracegrpc.WithHeaders(cfg.Headers))
+		}
+		return otlptracegrpc.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unsupported protocol %q", cfg.Protocol)
+	}
+}
+
+// End records err on the span (if any) and ends it.
+func End(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, context.Canceled) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Inject adds the trace context carried by ctx to headers, returning a copy so shared
+// header maps (e.g. configured webhook headers) are never mutated.
+func Inject(ctx context.Context, headers map[string]string) map[string]string {
+	out := make(map[string]string, len(headers)+2)
+	for k, v := range headers {
+		out[k] = v
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(out))
+	return out
+}
+
+// Extract returns ctx carrying the trace context stored in headers by Inject.
+func Extract(ctx context.Context, headers map[string]string) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(headers))
+}
@@ -0,0 +1,167 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newRecorder installs an in-memory tracer provider for the duration of the test.
+func newRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	prevTP, prevProp := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevTP)
+		otel.SetTextMapPropagator(prevProp)
+		_ = tp.Shutdown(context.Background())
+	})
+	return recorder
+}
+
+func attr(span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestMiddleware(t *testing.T) {
+	recorder := newRecorder(t)
+
+	router := chi.NewRouter()
+	router.Use(Middleware)
+	router.Get("/payments/{signature}", func(w http.ResponseWriter, r *http.Request) {
+		if !trace.SpanContextFromContext(r.Context()).IsValid() {
+			t.Error("handler context has no span")
+		}
+		w.WriteHeader(http.StatusTeapot)
+	})
+	router.Get("/boom", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	parent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	tests := []struct {
+		name        string
+		path        string
+		traceparent string
+		wantName    string
+		wantStatus  int64
+		wantError   bool
+	}{
+		{name: "route pattern", path: "/payments/abc", wantName: "GET /payments/{signature}", wantStatus: http.StatusTeapot},
+		{name: "continues caller trace", path: "/payments/abc", traceparent: parent, wantName: "GET /payments/{signature}", wantStatus: http.StatusTeapot},
+		{name: "server error", path: "/boom", wantName: "GET /boom", wantStatus: http.StatusBadGateway, wantError: true},
+		{name: "unmatched route", path: "/missing", wantName: "GET", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder.Reset()
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.traceparent != "" {
+				req.Header.Set("traceparent", tt.traceparent)
+			}
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			spans := recorder.Ended()
+			if len(spans) != 1 {
+				t.Fatalf("expected 1 span, got %d", len(spans))
+			}
+			span := spans[0]
+			if span.Name() != tt.wantName {
+				t.Errorf("name = %q, want %q", span.Name(), tt.wantName)
+			}
+			if span.SpanKind() != trace.SpanKindServer {
+				t.Errorf("kind = %v", span.SpanKind())
+			}
+			if got := attr(span, "http.response.status_code").AsInt64(); got != tt.wantStatus {
+				t.Errorf("status = %d, want %d", got, tt.wantStatus)
+			}
+			if (span.Status().Code == codes.Error) != tt.wantError {
+				t.Errorf("span status = %v", span.Status())
+			}
+			if tt.traceparent != "" && span.Parent().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+				t.Errorf("parent trace = %s", span.Parent().TraceID())
+			}
+		})
+	}
+}
+
+func TestTransport(t *testing.T) {
+	recorder := newRecorder(t)
+
+	var gotTraceparent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotTraceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: NewTransport(nil)}
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/hook?token=secret", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	resp.Body.Close()
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+	if gotTraceparent == "" || gotTraceparent[3:35] != span.SpanContext().TraceID().String() {
+		t.Errorf("traceparent = %q, span trace = %s", gotTraceparent, span.SpanContext().TraceID())
+	}
+	if req.Header.Get("traceparent") != "" {
+		t.Error("caller's request was mutated")
+	}
+	if span.Status().Code != codes.Error {
+		t.Errorf("span status = %v", span.Status())
+	}
+	for _, kv := range span.Attributes() {
+		if v := kv.Value.Emit(); v != "" && (strings.Contains(v, "secret") || strings.Contains(v, "/hook")) {
+			t.Errorf("attribute %s leaks url: %s", kv.Key, v)
+		}
+	}
+}
+
+func TestInjectExtract(t *testing.T) {
+	newRecorder(t)
+
+	ctx, span := Tracer().Start(context.Background(), "enqueue")
+	defer span.End()
+
+	shared := map[string]string{"X-Custom": "1"}
+	headers := Inject(ctx, shared)
+	if _, ok := shared["traceparent"]; ok {
+		t.Fatal("Inject mutated the shared header map")
+	}
+	if headers["X-Custom"] != "1" || headers["traceparent"] == "" {
+		t.Fatalf("headers = %v", headers)
+	}
+
+	got := trace.SpanContextFromContext(Extract(context.Background(), headers))
+	if got.TraceID() != span.SpanContext().TraceID() || got.SpanID() != span.SpanContext().SpanID() {
+		t.Errorf("extracted %v, want %v", got, span.SpanContext())
+	}
+}
@@ -0,0 +1,262 @@
+package verification
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Status is the lifecycle state of an asynchronous verification job.
+type Status string
+
+const (
+	StatusPending    Status = "pending"    // Queued, waiting for a worker
+	StatusProcessing Status = "processing" // A worker is confirming the payment
+	StatusSucceeded  Status = "succeeded"  // Payment verified; see Job.Result
+	StatusFailed     Status = "failed"     // Verification failed; see Job.ErrorCode and Job.Error
+)
+
+// Terminal reports whether the job has finished.
+func (s Status) Terminal() bool {
+	return s == StatusSucceeded || s == StatusFailed
+}
+
+var (
+	// ErrQueueFull is returned by Submit when all workers are busy and the queue is at capacity.
+	ErrQueueFull = errors.New("verification: queue is full")
+	// ErrClosed is returned by Submit after the pool has been closed.
+	ErrClosed = errors.New("verification: pool is closed")
+)
+
+// Job is a snapshot of an asynchronous verification.
+type Job struct {
+	ID           string         `json:"verificationId"`
+	Status       Status         `json:"status"`
+	ResourceID   string         `json:"resource"`
+	ResourceType string         `json:"resourceType"`
+	Result       map[string]any `json:"result,omitempty"`
+	ErrorCode    string         `json:"errorCode,omitempty"`
+	Error        string         `json:"error,omitempty"`
+	CreatedAt    time.Time      `json:"createdAt"`
+	UpdatedAt    time.Time      `json:"updatedAt"`
+}
+
+// Outcome is what a job function reports back to the pool.
+type Outcome struct {
+	Result    map[string]any // Response payload on success
+	ErrorCode string         // Machine-readable error code on failure
+	Error     string         // User-facing message on failure
+}
+
+// Func performs the verification work for a job. Returning a non-empty ErrorCode or
+// Error marks the job failed; otherwise it succeeds with Result.
+type Func func(ctx context.Context) Outcome
+
+// Options configures a Pool.
+type Options struct {
+	Workers   int           // Concurrent verifications (default: 4)
+	QueueSize int           // Jobs waiting for a worker before Submit fails (default: 100)
+	ResultTTL time.Duration // How long finished jobs remain queryable (default: 10m)
+	Timeout   time.Duration // Per-job deadline (default: 90s)
+}
+
+type task struct {
+	id  string
+	ctx context.Context
+	fn  Func
+}
+
+// Pool runs verification jobs on a fixed set of workers and keeps their results in
+// memory for polling. Results are per-instance; deployments behind a load balancer
+// need sticky routing for GET /verifications/{id}.
+type Pool struct {
+	opts  Options
+	queue chan task
+
+	mu        sync.Mutex
+	jobs      map[string]*Job
+	closed    bool
+	lastSweep time.Time
+
+	wg sync.WaitGroup
+}
+
+// NewPool creates a pool and starts its workers.
+func NewPool(opts Options) *Pool {
+	if opts.Workers <= 0 {
+		opts.Workers = 4
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 100
+	}
+	if opts.ResultTTL <= 0 {
+		opts.ResultTTL = 10 * time.Minute
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 90 * time.Second
+	}
+
+	p := &Pool{
+		opts:      opts,
+		queue:     make(chan task, opts.QueueSize),
+		jobs:      make(map[string]*Job),
+		lastSweep: time.Now(),
+	}
+	p.wg.Add(opts.Workers)
+	for i := 0; i < opts.Workers; i++ {
+		go p.worker()
+	}
+	return p
+}
+
+// Submit enqueues fn and returns the pending job immediately.
+// ctx supplies request-scoped values (logger, tenant); its cancellation is ignored so the
+// job survives the HTTP request that created it.
+func (p *Pool) Submit(ctx context.Context, resourceID, resourceType string, fn Func) (Job, error) {
+	now := time.Now().UTC()
+	job := &Job{
+		ID:           newJobID(),
+		Status:       StatusPending,
+		ResourceID:   resourceID,
+		ResourceType: resourceType,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return Job{}, ErrClosed
+	}
+	p.sweepLocked(now)
+
+	select {
+	case p.queue <- task{id: job.ID, ctx: context.WithoutCancel(ctx), fn: fn}:
+	default:
+		return Job{}, ErrQueueFull
+	}
+	p.jobs[job.ID] = job
+	return *job, nil
+}
+
+// Get returns a snapshot of the job with the given ID.
+func (p *Pool) Get(id string) (Job, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	job, ok := p.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// Close stops accepting jobs, lets workers finish everything already queued, and waits for them.
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	close(p.queue)
+	p.mu.Unlock()
+
+	p.wg.Wait()
+	return nil
+}
+
+// Shutdown is Close bounded by ctx: it stops accepting jobs and waits for queued and
+// running ones until ctx is done. Jobs still running at the deadline keep going in the
+// background, and a later Close returns without waiting for them.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *Pool) worker() {
+	defer p.wg.Done()
+	for t := range p.queue {
+		p.run(t)
+	}
+}
+
+func (p *Pool) run(t task) {
+	p.update(t.id, func(job *Job) { job.Status = StatusProcessing })
+
+	ctx, cancel := context.WithTimeout(t.ctx, p.opts.Timeout)
+	defer cancel()
+
+	var outcome Outcome
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				outcome = Outcome{ErrorCode: "internal_error", Error: "verification failed unexpectedly"}
+			}
+		}()
+		outcome = t.fn(ctx)
+	}()
+
+	p.update(t.id, func(job *Job) {
+		if outcome.ErrorCode != "" || outcome.Error != "" {
+			job.Status = StatusFailed
+			job.ErrorCode = outcome.ErrorCode
+			job.Error = outcome.Error
+			return
+		}
+		job.Status = StatusSucceeded
+		job.Result = outcome.Result
+	})
+}
+
+func (p *Pool) update(id string, fn func(*Job)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	job, ok := p.jobs[id]
+	if !ok {
+		return
+	}
+	fn(job)
+	job.UpdatedAt = time.Now().UTC()
+}
+
+// sweepLocked drops finished jobs older than the result TTL, at most once per TTL.
+// Caller must hold p.mu.
+func (p *Pool) sweepLocked(now time.Time) {
+	if now.Sub(p.lastSweep) < p.opts.ResultTTL {
+		return
+	}
+	p.lastSweep = now
+	cutoff := now.Add(-p.opts.ResultTTL)
+	for id, job := range p.jobs {
+		if job.Status.Terminal() && job.UpdatedAt.Before(cutoff) {
+			delete(p.jobs, id)
+		}
+	}
+}
+
+func newJobID() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "ver_" + time.Now().UTC().Format("20060102150405.000000000")
+	}
+	return "ver_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,176 @@
+package verification
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func waitForStatus(t *testing.T, p *Pool, id string) Job {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		job, ok := p.Get(id)
+		if !ok {
+			t.Fatalf("job %s not found", id)
+		}
+		if job.Status.Terminal() {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("job %s did not finish", id)
+	return Job{}
+}
+
+func TestPoolOutcomes(t *testing.T) {
+	tests := []struct {
+		name       string
+		outcome    Outcome
+		wantStatus Status
+	}{
+		{
+			name:       "success",
+			outcome:    Outcome{Result: map[string]any{"granted": true}},
+			wantStatus: StatusSucceeded,
+		},
+		{
+			name:       "failure",
+			outcome:    Outcome{ErrorCode: "amount_mismatch", Error: "wrong amount"},
+			wantStatus: StatusFailed,
+		},
+	}
+
+	p := NewPool(Options{Workers: 2})
+	defer p.Close()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outcome := tt.outcome
+			job, err := p.Submit(context.Background(), "res-1", "regular", func(context.Context) Outcome { return outcome })
+			if err != nil {
+				t.Fatalf("Submit: %v", err)
+			}
+			if job.Status != StatusPending {
+				t.Fatalf("initial status = %s, want pending", job.Status)
+			}
+
+			done := waitForStatus(t, p, job.ID)
+			if done.Status != tt.wantStatus {
+				t.Fatalf("status = %s, want %s", done.Status, tt.wantStatus)
+			}
+			if done.ErrorCode != tt.outcome.ErrorCode {
+				t.Errorf("error code = %q, want %q", done.ErrorCode, tt.outcome.ErrorCode)
+			}
+			if done.ResourceID != "res-1" {
+				t.Errorf("resource = %q, want res-1", done.ResourceID)
+			}
+		})
+	}
+}
+
+func TestPoolIgnoresRequestCancellation(t *testing.T) {
+	p := NewPool(Options{Workers: 1})
+	defer p.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	release := make(chan struct{})
+	job, err := p.Submit(ctx, "res", "regular", func(ctx context.Context) Outcome {
+		<-release
+		if ctx.Err() != nil {
+			return Outcome{ErrorCode: "internal_error", Error: ctx.Err().Error()}
+		}
+		return Outcome{Result: map[string]any{}}
+	})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	cancel()
+	close(release)
+
+	if done := waitForStatus(t, p, job.ID); done.Status != StatusSucceeded {
+		t.Fatalf("status = %s (%s), want succeeded", done.Status, done.Error)
+	}
+}
+
+func TestPoolQueueFullAndClosed(t *testing.T) {
+	p := NewPool(Options{Workers: 1, QueueSize: 1})
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	blocking := func(context.Context) Outcome {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+		return Outcome{}
+	}
+
+	if _, err := p.Submit(context.Background(), "a", "regular", blocking); err != nil {
+		t.Fatalf("first Submit: %v", err)
+	}
+	<-started // Worker busy; the queue is now empty
+	if _, err := p.Submit(context.Background(), "b", "regular", blocking); err != nil {
+		t.Fatalf("second Submit: %v", err)
+	}
+	if _, err := p.Submit(context.Background(), "c", "regular", blocking); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("third Submit error = %v, want ErrQueueFull", err)
+	}
+
+	close(release)
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := p.Submit(context.Background(), "d", "regular", blocking); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Submit after Close error = %v, want ErrClosed", err)
+	}
+}
+
+func TestPoolShutdown(t *testing.T) {
+	p := NewPool(Options{Workers: 1})
+	release := make(chan struct{})
+	job, err := p.Submit(context.Background(), "res", "regular", func(context.Context) Outcome {
+		<-release
+		return Outcome{}
+	})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := p.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown with running job = %v, want deadline exceeded", err)
+	}
+
+	close(release)
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if done, _ := p.Get(job.ID); done.Status != StatusSucceeded {
+		t.Fatalf("status = %s, want succeeded", done.Status)
+	}
+}
+
+func TestPoolRecoversFromPanic(t *testing.T) {
+	p := NewPool(Options{Workers: 1})
+	defer p.Close()
+
+	job, err := p.Submit(context.Background(), "res", "regular", func(context.Context) Outcome { panic("boom") })
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if done := waitForStatus(t, p, job.ID); done.Status != StatusFailed {
+		t.Fatalf("status = %s, want failed", done.Status)
+	}
+}
+
+func TestPoolGetUnknown(t *testing.T) {
+	p := NewPool(Options{})
+	defer p.Close()
+	if _, ok := p.Get("ver_missing"); ok {
+		t.Fatal("expected unknown job to be missing")
+	}
+}
@@ -0,0 +1,10 @@
+-- Migration 009: Add usage_limit_per_wallet column to coupons table
+-- Caps how many times one wallet (x402) or customer email (Stripe) may redeem a coupon.
+-- Redemptions are counted in the coupon_redemptions table, which the storage backend creates.
+
+-- NULL keeps existing coupons unlimited per wallet (backward compatible)
+ALTER TABLE coupons
+ADD COLUMN IF NOT EXISTS usage_limit_per_wallet INTEGER
+CHECK (usage_limit_per_wallet IS NULL OR usage_limit_per_wallet > 0);
+
+COMMENT ON COLUMN coupons.usage_limit_per_wallet IS 'Max redemptions per wallet (x402) or customer email (Stripe); NULL for unlimited';
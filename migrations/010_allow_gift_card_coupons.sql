@@ -0,0 +1,9 @@
+-- Migration 010: Allow gift_card coupons in the coupons table
+-- Gift cards can now be created through the coupon admin API, so the discount_type check
+-- from migration 002 must accept them.
+
+ALTER TABLE coupons DROP CONSTRAINT IF EXISTS coupons_discount_type_check;
+
+ALTER TABLE coupons
+ADD CONSTRAINT coupons_discount_type_check
+CHECK (discount_type IN ('percentage', 'fixed', 'gift_card'));
@@ -0,0 +1,10 @@
+-- Migration 011: Add requested_amount column to refund_quotes
+-- An admin may approve less than the customer asked for; amount then holds the approved
+-- amount and requested_amount what was requested. The storage backend adds the column on
+-- startup as well.
+
+-- NULL on existing requests means the requested amount equals amount (backward compatible)
+ALTER TABLE refund_quotes
+ADD COLUMN IF NOT EXISTS requested_amount BIGINT;
+
+COMMENT ON COLUMN refund_quotes.requested_amount IS 'Amount the customer requested, in atomic units of amount_asset; NULL when not recorded';
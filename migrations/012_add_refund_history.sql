@@ -0,0 +1,10 @@
+-- Migration 012: Add history column to refund_quotes
+-- Refund requests carry a thread of customer and admin notes along with their status changes
+-- (requested, adjusted, approved, escalated, processed), each timestamped. The storage backend
+-- adds the column on startup as well.
+
+-- NULL on existing requests means no history was recorded (backward compatible)
+ALTER TABLE refund_quotes
+ADD COLUMN IF NOT EXISTS history JSONB;
+
+COMMENT ON COLUMN refund_quotes.history IS 'Notes and status changes on the refund request, oldest first (JSON array)';
@@ -0,0 +1,25 @@
+-- Migration 013: Create admin_audit table
+-- An append-only log of admin actions: refund approvals and denials, admin nonce use,
+-- webhook retries, and writes through the admin API. The storage backend creates the table
+-- on startup as well.
+
+CREATE TABLE IF NOT EXISTS admin_audit (
+    id BIGSERIAL PRIMARY KEY,
+    action TEXT NOT NULL,
+    signer TEXT NOT NULL,
+    method TEXT NOT NULL DEFAULT '',
+    path TEXT NOT NULL DEFAULT '',
+    request_hash TEXT NOT NULL DEFAULT '',
+    status INTEGER NOT NULL DEFAULT 0,
+    result TEXT NOT NULL,
+    recorded_at TIMESTAMP NOT NULL
+);
+
+-- Entries are never changed or removed
+CREATE OR REPLACE RULE admin_audit_no_update AS ON UPDATE TO admin_audit DO INSTEAD NOTHING;
+CREATE OR REPLACE RULE admin_audit_no_delete AS ON DELETE TO admin_audit DO INSTEAD NOTHING;
+
+CREATE INDEX IF NOT EXISTS idx_admin_audit_recorded ON admin_audit(recorded_at DESC);
+CREATE INDEX IF NOT EXISTS idx_admin_audit_action ON admin_audit(action, recorded_at DESC);
+
+COMMENT ON TABLE admin_audit IS 'Append-only admin action log (signer, request hash, result)';
@@ -0,0 +1,14 @@
+-- Migration 014: Create access_rules table
+-- Wallets and IP ranges on the allow or deny list, managed through the admin API. The
+-- storage backend creates the table on startup as well.
+
+CREATE TABLE IF NOT EXISTS access_rules (
+    kind TEXT NOT NULL,
+    value TEXT NOT NULL,
+    list TEXT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (kind, value)
+);
+
+COMMENT ON TABLE access_rules IS 'Wallet and IP range (CIDR) allow and deny lists';
@@ -0,0 +1,16 @@
+-- Migration 015: Create rate_limit_counters table
+-- Request counts per rate limit key and window when rate_limit.backend is "postgres", shared by
+-- every instance. Rows expire after two windows and are swept by the server. The server creates
+-- the table on startup as well.
+
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limit_counters (
+    key TEXT NOT NULL,
+    window_start TIMESTAMPTZ NOT NULL,
+    count INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (key, window_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_limit_counters_expires_at ON rate_limit_counters (expires_at);
+
+COMMENT ON TABLE rate_limit_counters IS 'Cluster-wide rate limit counts (unlogged; safe to truncate)';
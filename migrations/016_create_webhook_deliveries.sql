@@ -0,0 +1,24 @@
+-- Migration 016: Create webhook_deliveries table
+-- One row per attempt to deliver a webhook: destination, receiver status, latency, and the
+-- start of the response body. The storage backend creates the table on startup as well.
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    event_id TEXT NOT NULL DEFAULT '',
+    webhook_id TEXT NOT NULL DEFAULT '',
+    event_type TEXT NOT NULL,
+    url TEXT NOT NULL,
+    attempt INTEGER NOT NULL DEFAULT 0,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    latency_ms BIGINT NOT NULL DEFAULT 0,
+    response_body TEXT NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    success BOOLEAN NOT NULL,
+    attempted_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_attempted ON webhook_deliveries(attempted_at DESC);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_url ON webhook_deliveries(url, attempted_at DESC);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_event ON webhook_deliveries(event_id);
+
+COMMENT ON TABLE webhook_deliveries IS 'Webhook delivery attempts (status code, latency, truncated response)';
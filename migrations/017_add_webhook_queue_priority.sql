@@ -0,0 +1,10 @@
+-- Migration 017: Add priority to webhook_queue
+-- Ready webhooks are delivered highest priority first, then by next_attempt_at, so payment
+-- success events (10) go out before normal (0) and low-priority analytics (-10) events.
+-- The storage backend adds the column on startup as well.
+
+ALTER TABLE webhook_queue ADD COLUMN IF NOT EXISTS priority INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_webhook_queue_priority ON webhook_queue(priority DESC, next_attempt_at) WHERE status = 'pending';
+
+COMMENT ON COLUMN webhook_queue.priority IS 'Delivery priority, highest first (10 high, 0 normal, -10 low)';
@@ -0,0 +1,9 @@
+-- Migration 018: Add retry_schedule to webhook_queue
+-- The delay before each retry, in milliseconds, computed when the webhook is enqueued from its
+-- destination's retry settings (exponential with jitter, or a fixed schedule). The worker
+-- schedules retries from it, so later config changes don't move queued webhooks.
+-- The storage backend adds the column on startup as well.
+
+ALTER TABLE webhook_queue ADD COLUMN IF NOT EXISTS retry_schedule JSONB;
+
+COMMENT ON COLUMN webhook_queue.retry_schedule IS 'Delay before each retry in milliseconds, computed at enqueue';
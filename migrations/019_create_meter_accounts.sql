@@ -0,0 +1,18 @@
+-- Migration 019: Create meter_accounts table
+-- Prepaid balances for per-request metering, keyed by "wallet:<address>" or "key:<hash>".
+-- Each metered call moves its price from balance to unsettled and counts a call; the settlement
+-- monitor records the unsettled total as one payment and clears it. The server creates the
+-- table on startup as well.
+
+CREATE TABLE IF NOT EXISTS meter_accounts (
+    account TEXT PRIMARY KEY,
+    balance BIGINT NOT NULL,
+    unsettled BIGINT NOT NULL DEFAULT 0,
+    calls BIGINT NOT NULL DEFAULT 0,
+    asset TEXT NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_meter_accounts_unsettled ON meter_accounts (account) WHERE calls > 0;
+
+COMMENT ON TABLE meter_accounts IS 'Prepaid metering balances and usage awaiting settlement';
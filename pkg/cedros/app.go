@@ -5,23 +5,40 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
+	gosolana "github.com/gagliardetto/solana-go"
 	"github.com/go-chi/chi/v5"
 	"github.com/rs/zerolog/log"
 
 	"github.com/CedrosPay/server/internal/callbacks"
+	"github.com/CedrosPay/server/internal/circuitbreaker"
 	"github.com/CedrosPay/server/internal/config"
 	"github.com/CedrosPay/server/internal/coupons"
+	"github.com/CedrosPay/server/internal/dbpool"
+	"github.com/CedrosPay/server/internal/eventbus"
+	"github.com/CedrosPay/server/internal/geoip"
+	"github.com/CedrosPay/server/internal/grpcserver"
 	"github.com/CedrosPay/server/internal/httpserver"
 	"github.com/CedrosPay/server/internal/idempotency"
 	"github.com/CedrosPay/server/internal/lifecycle"
 	"github.com/CedrosPay/server/internal/logger"
 	"github.com/CedrosPay/server/internal/metrics"
+	"github.com/CedrosPay/server/internal/money"
+	"github.com/CedrosPay/server/internal/monitoring"
+	"github.com/CedrosPay/server/internal/paymentstatus"
 	"github.com/CedrosPay/server/internal/paywall"
 	"github.com/CedrosPay/server/internal/products"
+	"github.com/CedrosPay/server/internal/ratelimit"
+	"github.com/CedrosPay/server/internal/rates"
+	"github.com/CedrosPay/server/internal/rpcutil"
+	"github.com/CedrosPay/server/internal/screening"
+	solanaHelpers "github.com/CedrosPay/server/internal/solana"
 	"github.com/CedrosPay/server/internal/storage"
 	stripesvc "github.com/CedrosPay/server/internal/stripe"
 	"github.com/CedrosPay/server/internal/subscriptions"
+	"github.com/CedrosPay/server/internal/tracing"
+	"github.com/CedrosPay/server/internal/verification"
 	"github.com/CedrosPay/server/pkg/x402"
 	"github.com/CedrosPay/server/pkg/x402/solana"
 	"github.com/prometheus/client_golang/prometheus"
@@ -36,13 +53,21 @@ type App struct {
 	Paywall          *paywall.Service
 	Stripe           *stripesvc.Client
 	CartService      *stripesvc.CartService   // Cart service for multi-item checkouts
+	Products         products.Repository    // Product repository (paywall resources)
 	Coupons          coupons.Repository       // Coupon repository
 	Subscriptions    *subscriptions.Service   // Subscription management service
-	IdempotencyStore *idempotency.MemoryStore
+	IdempotencyStore idempotency.Store      // Idempotency-Key responses, persisted in Store
+	EventBus         *eventbus.Bus          // Merchant event bus (nil unless merchant_events is enabled)
+	Verifications    *verification.Pool     // Async verification workers (nil unless async_verification is enabled)
+	GRPC             *grpcserver.Server     // Machine-to-machine gRPC API (nil unless grpc is enabled)
 
 	router           chi.Router
 	resourceManager  *lifecycle.Manager
+	dlq              callbacks.DLQStore     // Failed webhook store (nil unless callbacks.dlq_enabled)
+	dlqRedriver      *callbacks.DLQRedriver // Re-drives rotated DLQ segments (nil unless dlq_rotation or dlq_offload is set)
+	geoDatabase      *geoip.Database        // IP-to-country database (nil unless geo_restriction.database is set)
 	metricsCollector *metrics.Metrics
+	rateLimits       ratelimit.CounterFunc // Shared rate limit counters (nil unless rate_limit.backend is postgres)
 }
 
 // Option configures App construction.
@@ -53,6 +78,7 @@ type options struct {
 	notifier callbacks.Notifier
 	verifier x402.Verifier
 	router   chi.Router
+	screener screening.Screener
 }
 
 // WithStore sets a custom storage backend.
@@ -76,6 +102,14 @@ func WithVerifier(verifier x402.Verifier) Option {
 	}
 }
 
+// WithScreener injects a custom compliance screener for payers and refund recipients, in place
+// of the paywall.screening provider. paywall.screening.fail_mode still applies to its errors.
+func WithScreener(screener screening.Screener) Option {
+	return func(o *options) {
+		o.screener = screener
+	}
+}
+
 // WithRouter allows callers to provide an existing chi.Router to register routes onto.
 func WithRouter(router chi.Router) Option {
 	return func(o *options) {
@@ -99,6 +133,18 @@ func NewApp(cfg *config.Config, opts ...Option) (*App, error) {
 		resourceManager: lifecycle.NewManager(),
 	}
 
+	// Registered first so it closes last, flushing spans from every other resource's shutdown
+	if cfg.Tracing.Enabled {
+		provider, err := tracing.Setup(context.Background(), cfg.Tracing, cfg.Logging.Environment)
+		if err != nil {
+			return nil, fmt.Errorf("init tracing: %w", err)
+		}
+		app.resourceManager.Register("tracing", provider)
+	}
+
+	// Keeps Vault leases behind config values renewed until everything using them has closed
+	app.resourceManager.Register("config-secrets", cfg)
+
 	if optState.store != nil {
 		app.Store = optState.store
 	} else {
@@ -112,51 +158,216 @@ func NewApp(cfg *config.Config, opts ...Option) (*App, error) {
 	metricsCollector := metrics.New(prometheus.DefaultRegisterer)
 	app.metricsCollector = metricsCollector
 
+	app.Store = storage.NewInstrumentedStore(app.Store, storage.InstrumentOptions{
+		Tracing:            cfg.Tracing.Enabled,
+		Metrics:            metricsCollector,
+		SlowQueryThreshold: cfg.Storage.SlowQueryThreshold.Duration,
+		Logger:             log.Logger.With().Str("component", "storage").Logger(),
+	})
+	if cfg.Storage.ReplayCache.Enabled {
+		// Outermost, so cache hits skip the storage metrics along with the query
+		store, err := storage.NewReplayCacheStore(context.Background(), app.Store, storage.ReplayCacheOptions{
+			Size:               cfg.Storage.ReplayCache.Size,
+			ExpectedSignatures: cfg.Storage.ReplayCache.ExpectedSignatures,
+			SingleInstance:     cfg.Storage.ReplayCache.SingleInstance,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("init replay cache: %w", err)
+		}
+		app.Store = store
+	}
+
+	if cfg.MerchantEvents.Enabled {
+		app.EventBus = eventbus.New(cfg.MerchantEvents.BufferSize)
+	}
+
+	// Delayed callbacks (subscription renewal reminders) need the persistent webhook queue
+	var scheduler callbacks.Scheduler
 	if optState.notifier != nil {
 		app.Notifier = optState.notifier
+		scheduler, _ = optState.notifier.(callbacks.Scheduler)
 	} else {
 		// Initialize DLQ store for failed webhooks (if enabled)
-		var dlqStore callbacks.DLQStore
+		var dlqStore *callbacks.FileDLQStore
 		if cfg.Callbacks.DLQEnabled {
-			var err error
-			dlqStore, err = callbacks.NewFileDLQStore(cfg.Callbacks.DLQPath)
+			archive, err := callbacks.NewDLQArchive(context.Background(), cfg.Callbacks)
+			if err != nil {
+				return nil, fmt.Errorf("init DLQ archive: %w", err)
+			}
+			dlqStore, err = callbacks.NewFileDLQStore(cfg.Callbacks.DLQPath,
+				callbacks.WithDLQRotation(cfg.Callbacks.DLQRotation),
+				callbacks.WithDLQArchive(archive),
+				callbacks.WithDLQLogger(log.Logger),
+			)
 			if err != nil {
 				return nil, fmt.Errorf("init DLQ store: %w", err)
 			}
+			app.dlq = dlqStore
 		}
 
 		// Convert config retry settings to callbacks.RetryConfig
-		retryConfig := callbacks.RetryConfig{
-			MaxAttempts:     cfg.Callbacks.Retry.MaxAttempts,
-			InitialInterval: cfg.Callbacks.Retry.InitialInterval.Duration,
-			MaxInterval:     cfg.Callbacks.Retry.MaxInterval.Duration,
-			Multiplier:      cfg.Callbacks.Retry.Multiplier,
-			Timeout:         cfg.Callbacks.Timeout.Duration,
-		}
+		retryConfig := callbacks.NewRetryConfig(cfg.Callbacks.Retry, cfg.Callbacks.Timeout.Duration)
 
 		// Create retryable callback notifier with metrics and optional DLQ
 		callbackOpts := []callbacks.RetryOption{
 			callbacks.WithRetryConfig(retryConfig),
 			callbacks.WithMetrics(metricsCollector), // Add metrics for webhook observability
+			callbacks.WithDeliveryLog(app.Store),    // Queryable record of every delivery attempt
 		}
 		if dlqStore != nil {
 			callbackOpts = append(callbackOpts, callbacks.WithDLQStore(dlqStore))
 		}
-		app.Notifier = callbacks.NewRetryableClient(cfg.Callbacks, callbackOpts...)
+		if app.EventBus != nil {
+			callbackOpts = append(callbackOpts, callbacks.WithEventBus(app.EventBus))
+		}
+		var awsTransport *callbacks.AWSTransport
+		if callbacks.HasAWSTarget(cfg.Callbacks) {
+			var err error
+			awsTransport, err = callbacks.NewAWSTransport(context.Background(), cfg.Callbacks.AWS)
+			if err != nil {
+				return nil, fmt.Errorf("init aws webhook transport: %w", err)
+			}
+			callbackOpts = append(callbackOpts, callbacks.WithAWSTransport(awsTransport))
+		}
+		tlsConfigs, err := callbacks.LoadTLS(cfg.Callbacks)
+		if err != nil {
+			return nil, fmt.Errorf("load webhook tls settings: %w", err)
+		}
+		callbackOpts = append(callbackOpts, callbacks.WithTLS(tlsConfigs))
+		if dlqStore != nil {
+			app.dlqRedriver = callbacks.NewDLQRedriver(dlqStore.Archive(), callbacks.DLQRedriverOptions{
+				Timeout:     cfg.Callbacks.Timeout.Duration,
+				TLS:         tlsConfigs,
+				AWS:         awsTransport,
+				DeliveryLog: app.Store,
+				Logger:      log.Logger,
+			})
+		}
+		app.Notifier = callbacks.NewDestinationNotifier(cfg.Callbacks, callbackOpts...)
+		if cfg.Subscriptions.Enabled && cfg.Subscriptions.RenewalReminderHours > 0 {
+			queue := callbacks.NewPersistentCallbackClient(callbacks.PersistentCallbackOptions{
+				Store:       app.Store,
+				Config:      cfg.Callbacks,
+				RetryConfig: retryConfig,
+				Logger:      log.Logger,
+				Metrics:     metricsCollector,
+				AWS:         awsTransport,
+				EventBus:    app.EventBus,
+				TLS:         tlsConfigs,
+			})
+			if queue != nil {
+				app.resourceManager.Register("webhook-queue", queue)
+				scheduler = queue
+			}
+		}
+
+		// Optional NATS JetStream sink alongside HTTP webhooks
+		if cfg.Callbacks.NATS.URL != "" {
+			natsNotifier, err := callbacks.NewNATSNotifier(cfg.Callbacks.NATS, callbacks.WithNATSLogger(log.Logger))
+			if err != nil {
+				return nil, fmt.Errorf("init nats event sink: %w", err)
+			}
+			app.resourceManager.Register("nats-event-sink", natsNotifier)
+			app.Notifier = callbacks.NewMultiNotifier(app.Notifier, natsNotifier)
+		}
+
+		// Optional Google Cloud Pub/Sub sink
+		if cfg.Callbacks.PubSub.ProjectID != "" {
+			pubsubNotifier, err := callbacks.NewPubSubNotifier(context.Background(), cfg.Callbacks.PubSub, callbacks.WithPubSubLogger(log.Logger))
+			if err != nil {
+				return nil, fmt.Errorf("init pubsub event sink: %w", err)
+			}
+			app.resourceManager.Register("pubsub-event-sink", pubsubNotifier)
+			app.Notifier = callbacks.NewMultiNotifier(app.Notifier, pubsubNotifier)
+		}
+	}
+
+	// Mirror payment/refund events to merchant dashboards
+	if app.EventBus != nil {
+		app.Notifier = callbacks.NewMultiNotifier(app.Notifier, callbacks.NewBusNotifier(app.EventBus))
+	}
+
+	// Payments must land in the multisig's vault, which members spend through proposals
+	if cfg.X402.SquadsMultisig != "" {
+		if err := solanaHelpers.ValidateSquadsVault(cfg.X402.SquadsMultisig, uint8(cfg.X402.SquadsVaultIndex), cfg.X402.PaymentAddress); err != nil {
+			return nil, fmt.Errorf("x402.payment_address: %w", err)
+		}
 	}
 
 	if optState.verifier != nil {
 		app.Verifier = optState.verifier
 	} else {
-		verifier, err := solana.NewSolanaVerifier(cfg.X402.RPCURL, cfg.X402.WSURL)
-		if err != nil {
-			return nil, err
+		var (
+			verifier *solana.SolanaVerifier
+			err      error
+		)
+		if len(cfg.X402.RPCURLs) > 0 {
+			// Several endpoints: route each call to the fastest healthy one and fail over
+			poolOpts := rpcutil.PoolOptions{
+				HealthCheckInterval: cfg.X402.RPCHealthCheckInterval.Duration,
+				Metrics:             metricsCollector,
+			}
+			if cfg.CircuitBreaker.Enabled {
+				breaker := circuitbreaker.FromServiceConfig(cfg.CircuitBreaker.SolanaRPC)
+				poolOpts.Breaker = &breaker
+			}
+			pool, err := rpcutil.NewPool(append([]string{cfg.X402.RPCURL}, cfg.X402.RPCURLs...), poolOpts)
+			if err != nil {
+				return nil, err
+			}
+			verifier, err = solana.NewSolanaVerifierWithPool(pool, cfg.X402.WSURL)
+			if err != nil {
+				_ = pool.Close()
+				return nil, err
+			}
+		} else {
+			verifier, err = solana.NewSolanaVerifier(cfg.X402.RPCURL, cfg.X402.WSURL)
+			if err != nil {
+				return nil, err
+			}
 		}
+		verifier.WithMetrics(metricsCollector, cfg.X402.Network)
 		app.Verifier = verifier
 		app.resourceManager.RegisterFunc("solana-verifier", func() error {
 			verifier.Close()
 			return nil
 		})
+
+		if cfg.X402.GaslessEnabled || cfg.X402.AutoCreateTokenAccount || cfg.X402.PrewarmTokenAccounts {
+			// KMS-held keys are contacted here, so a bad key ID or missing permission fails startup
+			wallets, err := solanaHelpers.ParseSigners(context.Background(), cfg.X402.ServerWalletKeys)
+			if err != nil {
+				return nil, fmt.Errorf("init server wallets: %w", err)
+			}
+			verifier.SetServerWallets(wallets)
+			if cfg.X402.GaslessEnabled {
+				verifier.EnableGasless()
+			}
+			if cfg.X402.GaslessEnabled && cfg.X402.PriorityFee.Enabled {
+				estimator := solana.NewPriorityFeeEstimator(verifier.RPCClient(), solana.PriorityFeeOptions{
+					Percentile:       cfg.X402.PriorityFee.Percentile,
+					MinMicroLamports: cfg.X402.PriorityFee.MinMicroLamports,
+					MaxMicroLamports: cfg.X402.PriorityFee.MaxMicroLamports,
+					RefreshInterval:  cfg.X402.PriorityFee.RefreshInterval.Duration,
+				})
+				verifier.SetPriorityFeeEstimator(estimator.WithMetrics(metricsCollector, cfg.X402.Network))
+			}
+			if cfg.X402.GaslessEnabled && cfg.X402.GaslessDailyBudgetSOL > 0 {
+				monitor := monitoring.NewBalanceMonitor(cfg, verifier.RPCClient(), wallets)
+				budget := uint64(cfg.X402.GaslessDailyBudgetSOL * 1e9)
+				verifier.SetGaslessDailyBudget(budget, func(wallet gosolana.PublicKey, spent, budget uint64) {
+					// Delivered in the background so the payment being verified isn't held up
+					go monitor.SendGaslessBudgetAlert(context.Background(), wallet.String(), float64(spent)/1e9, float64(budget)/1e9)
+				})
+			}
+			if cfg.X402.AutoCreateTokenAccount {
+				verifier.EnableAutoCreateTokenAccounts()
+			}
+			if cfg.X402.PrewarmTokenAccounts {
+				// Registered after the verifier so it is cancelled before the verifier closes
+				app.resourceManager.RegisterFunc("token-account-prewarm", prewarmTokenAccounts(cfg, verifier))
+			}
+		}
 	}
 
 	// Initialize product repository based on config
@@ -165,6 +376,7 @@ func NewApp(cfg *config.Config, opts ...Option) (*App, error) {
 		return nil, err
 	}
 	app.resourceManager.Register("product-repository", productRepository)
+	app.Products = productRepository
 
 	// Initialize coupon repository based on config
 	couponRepository, err := coupons.NewRepository(cfg.Coupons)
@@ -175,6 +387,30 @@ func NewApp(cfg *config.Config, opts ...Option) (*App, error) {
 
 	// Use the metrics collector created earlier (for consistency across all services)
 	app.Paywall = paywall.NewService(cfg, app.Store, app.Verifier, app.Notifier, productRepository, couponRepository, metricsCollector)
+	// Track verification progress for the payment status SSE stream (default 10m retention)
+	app.Paywall.SetStatusTracker(paymentstatus.NewTracker(0))
+	// Exchange-rate oracle for fiat-priced resources (optional); also converts mixed-token carts
+	rateProvider, err := rates.NewProvider(cfg.X402.RateOracle)
+	if err != nil {
+		return nil, err
+	}
+	if rateProvider != nil {
+		app.Paywall.SetRateProvider(rateProvider)
+		// Reports convert totals to USD at the same rates
+		money.SetRateSource(rateProvider, cfg.X402.RateOracle.CacheTTL.Duration)
+	}
+	// JSON number amounts stay beside the exact decimal strings unless turned off
+	money.SetLegacyFloats(cfg.Server.FloatAmounts)
+	// Compliance screening of payers and refund recipients (optional)
+	screener := optState.screener
+	if screener == nil {
+		if screener, err = screening.NewScreener(cfg.Paywall.Screening); err != nil {
+			return nil, err
+		}
+	}
+	if screener != nil {
+		app.Paywall.SetScreener(screener)
+	}
 	app.Stripe = stripesvc.NewClient(cfg.Stripe, app.Store, app.Notifier, couponRepository, metricsCollector)
 
 	// NEW: Create cart service for multi-item checkouts
@@ -195,9 +431,56 @@ func NewApp(cfg *config.Config, opts ...Option) (*App, error) {
 		}
 		app.resourceManager.Register("subscriptions-repository", subRepo)
 		app.Subscriptions = subscriptions.NewService(subRepo, cfg.Subscriptions.GracePeriodHours)
+		// Pause, resume, and grace period notices are reported through the payment callbacks
+		app.Subscriptions.SetNotifier(app.Notifier)
+		if scheduler != nil {
+			app.Subscriptions.SetRenewalReminders(scheduler, time.Duration(cfg.Subscriptions.RenewalReminderHours)*time.Hour)
+		}
+		if cfg.Subscriptions.PastDueGraceHours > 0 {
+			app.Subscriptions.SetPastDueGracePeriod(time.Duration(cfg.Subscriptions.PastDueGraceHours) * time.Hour)
+			graceMonitor := subscriptions.NewGraceMonitor(app.Subscriptions, subscriptions.GraceMonitorConfig{
+				NoticeBefore: time.Duration(cfg.Subscriptions.GraceNoticeHours) * time.Hour,
+			}, log.Logger.With().Str("component", "subscriptions").Logger())
+			graceMonitor.Start()
+			app.resourceManager.RegisterFunc("subscriptions-grace-monitor", func() error {
+				graceMonitor.Stop()
+				return nil
+			})
+		}
 
 		// Wire subscription checker into paywall for unified access control
 		app.Paywall.SetSubscriptionChecker(app.Subscriptions)
+		app.Paywall.SetSubscriptionChanger(app.Subscriptions)
+	}
+
+	// Pending refund monitor: keeps the pending refund gauges current and, when
+	// paywall.refunds.pending_expiry_days is set, denies or escalates stale requests
+	refundMonitor := paywall.NewRefundExpiryMonitor(app.Paywall, cfg.Paywall.Refunds.CheckInterval.Duration, log.Logger.With().Str("component", "refunds").Logger())
+	refundMonitor.Start()
+	app.resourceManager.RegisterFunc("refund-expiry-monitor", func() error {
+		refundMonitor.Stop()
+		return nil
+	})
+
+	// Metering settlement monitor: records each meter account's usage as one payment
+	if cfg.Metering.Enabled {
+		meteringMonitor := paywall.NewMeteringSettlementMonitor(app.Paywall, cfg.Metering.SettlementInterval.Duration, log.Logger.With().Str("component", "metering").Logger())
+		meteringMonitor.Start()
+		app.resourceManager.RegisterFunc("metering-settlement-monitor", func() error {
+			meteringMonitor.Stop()
+			return nil
+		})
+	}
+
+	// Worker pool for async x402 verification (closed before storage so queued jobs finish)
+	if cfg.AsyncVerify.Enabled {
+		app.Verifications = verification.NewPool(verification.Options{
+			Workers:   cfg.AsyncVerify.Workers,
+			QueueSize: cfg.AsyncVerify.QueueSize,
+			ResultTTL: cfg.AsyncVerify.ResultTTL.Duration,
+			Timeout:   cfg.AsyncVerify.Timeout.Duration,
+		})
+		app.resourceManager.Register("verification-pool", app.Verifications)
 	}
 
 	if optState.router != nil {
@@ -206,17 +489,35 @@ func NewApp(cfg *config.Config, opts ...Option) (*App, error) {
 		app.router = chi.NewRouter()
 	}
 
+	// IP-to-country database for geo_restriction (the country header is used without one)
+	if cfg.GeoRestriction.Enabled && cfg.GeoRestriction.Database != "" {
+		app.geoDatabase, err = geoip.Open(cfg.GeoRestriction.Database)
+		if err != nil {
+			return nil, fmt.Errorf("init geo restriction: %w", err)
+		}
+		log.Info().Int("ranges", app.geoDatabase.Len()).Msg("geoip.database_loaded")
+	}
+
+	// Cluster-wide rate limit counters (the limits are per instance without them)
+	if cfg.RateLimit.Backend == "postgres" {
+		pool, err := dbpool.NewSharedPool(cfg.RateLimit.PostgresURL, cfg.Storage.PostgresPool)
+		if err != nil {
+			return nil, fmt.Errorf("init rate limit counters: %w", err)
+		}
+		app.resourceManager.Register("rate-limit-postgres", pool)
+		counters, err := ratelimit.NewPostgresCounters(context.Background(), pool.DB())
+		if err != nil {
+			return nil, fmt.Errorf("init rate limit counters: %w", err)
+		}
+		app.rateLimits = counters.Counter
+	}
+
 	// Create RPC proxy handlers for frontend endpoints
 	rpcProxy := httpserver.NewRPCProxyHandlers(cfg)
 
-	// Create shared idempotency store (single goroutine for cleanup)
-	app.IdempotencyStore = idempotency.NewMemoryStore()
-
-	// Register cleanup for idempotency store
-	app.resourceManager.RegisterFunc("idempotency-store", func() error {
-		app.IdempotencyStore.Stop()
-		return nil
-	})
+	// Persist Idempotency-Key responses in the storage backend so retries survive restarts
+	// and are recognised by every instance (expired keys are removed by storage cleanup)
+	app.IdempotencyStore = idempotency.NewStorageStore(app.Store)
 
 	// Create logger for HTTP server
 	appLogger := logger.New(logger.Config{
@@ -226,11 +527,28 @@ func NewApp(cfg *config.Config, opts ...Option) (*App, error) {
 		Environment: cfg.Logging.Environment,
 	})
 
-	httpserver.ConfigureRouter(app.router, cfg, app.Paywall, app.Stripe, app.Verifier, rpcProxy, app.CartService, app.Coupons, app.IdempotencyStore, metricsCollector, app.Subscriptions, appLogger)
+	if err := httpserver.ConfigureRouter(app.router, cfg, app.Paywall, app.Stripe, app.Verifier, rpcProxy, app.CartService, app.Coupons, app.IdempotencyStore, metricsCollector, app.Subscriptions, appLogger, httpserver.WithEventBus(app.EventBus), httpserver.WithVerificationPool(app.Verifications), httpserver.WithStore(app.Store), httpserver.WithDLQStore(app.dlq), httpserver.WithDLQRedriver(app.dlqRedriver), httpserver.WithGeoDatabase(app.geoDatabase), httpserver.WithRateLimitCounters(app.rateLimits)); err != nil {
+		return nil, fmt.Errorf("configure routes: %w", err)
+	}
+
+	// gRPC API (registered last so in-flight RPCs drain before the services they use close)
+	if cfg.GRPC.Enabled {
+		app.GRPC = grpcserver.New(cfg, app.Paywall, app.Subscriptions, appLogger.With().Str("component", "grpc").Logger())
+		app.resourceManager.Register("grpc-server", app.GRPC)
+	}
 
 	return app, nil
 }
 
+// ServeGRPC listens on grpc.address and serves the gRPC API until the app is closed.
+// Run it in its own goroutine alongside the HTTP server.
+func (a *App) ServeGRPC() error {
+	if a.GRPC == nil {
+		return errors.New("cedros: grpc is not enabled")
+	}
+	return a.GRPC.ListenAndServe()
+}
+
 // Router returns the chi router with Cedros routes registered.
 func (a *App) Router() chi.Router {
 	return a.router
@@ -246,10 +564,104 @@ func (a *App) Close() error {
 	return a.resourceManager.Close()
 }
 
+// BeginDrain stops issuing new quotes and fails /readyz so load balancers stop routing
+// here. Payments against quotes already issued are still accepted.
+func (a *App) BeginDrain() {
+	a.Paywall.BeginDrain()
+}
+
+// Shutdown drains in-flight payment work and then closes the app. It stops new quotes,
+// waits for async verifications, queued gasless transactions, and webhook and event sink
+// deliveries (bounded by server.drain_timeout and ctx), flushes the storage backend, and calls Close.
+//
+// On SIGTERM, call BeginDrain, then http.Server.Shutdown so in-flight requests finish,
+// then Shutdown.
+func (a *App) Shutdown(ctx context.Context) error {
+	a.BeginDrain()
+	log.Info().Dur("drain_timeout", a.Config.Server.DrainTimeout.Duration).Msg("cedros.drain_started")
+
+	if timeout := a.Config.Server.DrainTimeout.Duration; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// Verifications first: they can enqueue gasless transactions and trigger webhooks
+	var errs []error
+	if a.Verifications != nil {
+		errs = append(errs, wrapDrainErr("verifications", a.Verifications.Shutdown(ctx)))
+	}
+	if queue, ok := a.Verifier.(interface{ DrainTxQueue(context.Context) error }); ok {
+		errs = append(errs, wrapDrainErr("transaction queue", queue.DrainTxQueue(ctx)))
+	}
+	errs = append(errs, wrapDrainErr("webhooks", callbacks.Drain(ctx, a.Notifier)))
+	if err := storage.Flush(a.Store); err != nil {
+		errs = append(errs, fmt.Errorf("flush storage: %w", err))
+	}
+
+	drainErr := errors.Join(errs...)
+	if drainErr != nil {
+		log.Warn().Err(drainErr).Msg("cedros.drain_incomplete")
+	} else {
+		log.Info().Msg("cedros.drain_complete")
+	}
+	return errors.Join(drainErr, a.Close())
+}
+
+// prewarmTokenAccounts creates payment_address's missing token accounts for token_mint and
+// each allowed token in the background, so the first payment in a new token doesn't wait on
+// account creation. The returned function cancels the job if it is still running.
+func prewarmTokenAccounts(cfg *config.Config, verifier *solana.SolanaVerifier) (stop func() error) {
+	owner, err := gosolana.PublicKeyFromBase58(cfg.X402.PaymentAddress)
+	if err != nil {
+		log.Warn().Err(err).Msg("token_account.prewarm_skipped")
+		return func() error { return nil }
+	}
+	mintAddresses := []string{cfg.X402.TokenMint}
+	for _, token := range cfg.X402.AllowedTokens {
+		if asset, err := money.GetAsset(token); err == nil && asset.Metadata.SolanaMint != "" {
+			mintAddresses = append(mintAddresses, asset.Metadata.SolanaMint)
+		}
+	}
+	var mints []gosolana.PublicKey
+	for _, address := range mintAddresses {
+		mint, err := gosolana.PublicKeyFromBase58(address)
+		if err != nil {
+			log.Warn().Err(err).Str("mint", address).Msg("token_account.prewarm_invalid_mint")
+			continue
+		}
+		mints = append(mints, mint)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		created, err := verifier.EnsureTokenAccounts(ctx, []gosolana.PublicKey{owner}, mints)
+		if err != nil {
+			log.Error().Err(err).Int("created", len(created)).Msg("token_account.prewarm_incomplete")
+			return
+		}
+		log.Info().Int("mints", len(mints)).Int("created", len(created)).Msg("token_account.prewarm_complete")
+	}()
+	return func() error {
+		cancel()
+		<-done
+		return nil
+	}
+}
+
+func wrapDrainErr(component string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("drain %s: %w", component, err)
+}
+
 // RegisterRoutes attaches Cedros endpoints to the provided router using an existing App.
-func RegisterRoutes(router chi.Router, app *App) {
+func RegisterRoutes(router chi.Router, app *App) error {
 	if router == nil || app == nil {
-		return
+		return nil
 	}
 	// Create RPC proxy handlers for frontend endpoints
 	rpcProxy := httpserver.NewRPCProxyHandlers(app.Config)
@@ -269,7 +681,7 @@ func RegisterRoutes(router chi.Router, app *App) {
 	}
 
 	// Reuse the app's idempotency store (already created and managed by app lifecycle)
-	httpserver.ConfigureRouter(router, app.Config, app.Paywall, app.Stripe, app.Verifier, rpcProxy, app.CartService, app.Coupons, app.IdempotencyStore, collector, app.Subscriptions, appLogger)
+	return httpserver.ConfigureRouter(router, app.Config, app.Paywall, app.Stripe, app.Verifier, rpcProxy, app.CartService, app.Coupons, app.IdempotencyStore, collector, app.Subscriptions, appLogger, httpserver.WithEventBus(app.EventBus), httpserver.WithVerificationPool(app.Verifications), httpserver.WithStore(app.Store), httpserver.WithDLQStore(app.dlq), httpserver.WithDLQRedriver(app.dlqRedriver), httpserver.WithGeoDatabase(app.geoDatabase), httpserver.WithRateLimitCounters(app.rateLimits))
 }
 
 // NewHandler is a convenience that constructs an App and returns its handler.
@@ -278,8 +690,8 @@ func NewHandler(cfg *config.Config, opts ...Option) (http.Handler, func(context.
 	if err != nil {
 		return nil, nil, err
 	}
-	shutdown := func(context.Context) error {
-		return app.Close()
+	shutdown := func(ctx context.Context) error {
+		return app.Shutdown(ctx)
 	}
 	return app.Handler(), shutdown, nil
 }
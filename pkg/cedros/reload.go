@@ -0,0 +1,71 @@
+package cedros
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/CedrosPay/server/internal/config"
+	"github.com/CedrosPay/server/internal/coupons"
+	"github.com/CedrosPay/server/internal/products"
+)
+
+// Reload applies the paywall resources and coupons from cfg to the running app, so price
+// and coupon changes in YAML take effect without a restart. Only YAML sources are
+// reloaded (database sources already serve live data); every other setting requires a
+// restart and is ignored. App.Config keeps the values it was started with.
+func (a *App) Reload(cfg *config.Config) error {
+	if cfg.Paywall.ProductSource != a.Config.Paywall.ProductSource {
+		return fmt.Errorf("cedros: product_source changed from %q to %q; restart to apply", a.Config.Paywall.ProductSource, cfg.Paywall.ProductSource)
+	}
+	if cfg.Coupons.CouponSource != a.Config.Coupons.CouponSource {
+		return fmt.Errorf("cedros: coupon_source changed from %q to %q; restart to apply", a.Config.Coupons.CouponSource, cfg.Coupons.CouponSource)
+	}
+
+	event := log.Info()
+	if products.ReloadYAML(a.Products, cfg.Paywall.Resources) {
+		event = event.Int("resources", len(cfg.Paywall.Resources))
+	}
+	if coupons.ReloadYAML(a.Coupons, cfg.Coupons.Coupons) {
+		event = event.Int("coupons", len(cfg.Coupons.Coupons))
+	}
+	event.Msg("cedros.config_reloaded")
+	return nil
+}
+
+// ReloadOnSIGHUP re-reads the config file at path on every SIGHUP and applies it with
+// Reload. An invalid file is logged and the running config is kept. Call the returned
+// function to stop listening.
+func (a *App) ReloadOnSIGHUP(path string) (stop func()) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-signals:
+				cfg, err := config.Load(path)
+				if err != nil {
+					log.Error().Err(err).Str("path", path).Msg("cedros.config_reload_failed")
+					continue
+				}
+				if err := a.Reload(cfg); err != nil {
+					log.Error().Err(err).Str("path", path).Msg("cedros.config_reload_failed")
+				}
+				// Only values are copied out; the reloaded config's secret leases aren't needed
+				_ = cfg.Close()
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
@@ -0,0 +1,1021 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        v5.29.3
+// source: cedros/v1/paywall.proto
+
+// Cedros Pay machine-to-machine API.
+//
+// The Go client is published as github.com/CedrosPay/server/pkg/grpc/cedrosv1
+// (regenerate with `make proto`). Generate other clients (e.g. TypeScript via
+// ts-proto or protobuf-es) from this file.
+//
+// Calls must carry an API key from api_key.keys in the "x-api-key" metadata
+// (or "authorization: Bearer <key>") unless grpc.allow_unauthenticated is set.
+// Failures use standard gRPC status codes; the Cedros error code (e.g.
+// "amount_mismatch") is attached as a google.rpc.ErrorInfo detail with
+// domain "cedrospay".
+
+package cedrosv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type GetQuoteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ResourceId    string                 `protobuf:"bytes,1,opt,name=resource_id,json=resourceId,proto3" json:"resource_id,omitempty"`
+	CouponCode    string                 `protobuf:"bytes,2,opt,name=coupon_code,json=couponCode,proto3" json:"coupon_code,omitempty"` // Optional; invalid coupons are ignored
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetQuoteRequest) Reset() {
+	*x = GetQuoteRequest{}
+	mi := &file_cedros_v1_paywall_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetQuoteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetQuoteRequest) ProtoMessage() {}
+
+func (x *GetQuoteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cedros_v1_paywall_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetQuoteRequest.ProtoReflect.Descriptor instead.
+func (*GetQuoteRequest) Descriptor() ([]byte, []int) {
+	return file_cedros_v1_paywall_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GetQuoteRequest) GetResourceId() string {
+	if x != nil {
+		return x.ResourceId
+	}
+	return ""
+}
+
+func (x *GetQuoteRequest) GetCouponCode() string {
+	if x != nil {
+		return x.CouponCode
+	}
+	return ""
+}
+
+type Quote struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ResourceId    string                 `protobuf:"bytes,1,opt,name=resource_id,json=resourceId,proto3" json:"resource_id,omitempty"`
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	Stripe        *StripeOption          `protobuf:"bytes,3,opt,name=stripe,proto3" json:"stripe,omitempty"` // Unset if the resource has no fiat price
+	Crypto        *CryptoQuote           `protobuf:"bytes,4,opt,name=crypto,proto3" json:"crypto,omitempty"` // Unset if the resource has no crypto price
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Quote) Reset() {
+	*x = Quote{}
+	mi := &file_cedros_v1_paywall_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Quote) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Quote) ProtoMessage() {}
+
+func (x *Quote) ProtoReflect() protoreflect.Message {
+	mi := &file_cedros_v1_paywall_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Quote.ProtoReflect.Descriptor instead.
+func (*Quote) Descriptor() ([]byte, []int) {
+	return file_cedros_v1_paywall_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Quote) GetResourceId() string {
+	if x != nil {
+		return x.ResourceId
+	}
+	return ""
+}
+
+func (x *Quote) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *Quote) GetStripe() *StripeOption {
+	if x != nil {
+		return x.Stripe
+	}
+	return nil
+}
+
+func (x *Quote) GetCrypto() *CryptoQuote {
+	if x != nil {
+		return x.Crypto
+	}
+	return nil
+}
+
+type StripeOption struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PriceId       string                 `protobuf:"bytes,1,opt,name=price_id,json=priceId,proto3" json:"price_id,omitempty"`
+	AmountCents   int64                  `protobuf:"varint,2,opt,name=amount_cents,json=amountCents,proto3" json:"amount_cents,omitempty"`
+	Currency      string                 `protobuf:"bytes,3,opt,name=currency,proto3" json:"currency,omitempty"`
+	Description   string                 `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StripeOption) Reset() {
+	*x = StripeOption{}
+	mi := &file_cedros_v1_paywall_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StripeOption) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StripeOption) ProtoMessage() {}
+
+func (x *StripeOption) ProtoReflect() protoreflect.Message {
+	mi := &file_cedros_v1_paywall_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StripeOption.ProtoReflect.Descriptor instead.
+func (*StripeOption) Descriptor() ([]byte, []int) {
+	return file_cedros_v1_paywall_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *StripeOption) GetPriceId() string {
+	if x != nil {
+		return x.PriceId
+	}
+	return ""
+}
+
+func (x *StripeOption) GetAmountCents() int64 {
+	if x != nil {
+		return x.AmountCents
+	}
+	return 0
+}
+
+func (x *StripeOption) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *StripeOption) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+// CryptoQuote mirrors the x402 paymentRequirements object.
+type CryptoQuote struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Scheme            string                 `protobuf:"bytes,1,opt,name=scheme,proto3" json:"scheme,omitempty"`
+	Network           string                 `protobuf:"bytes,2,opt,name=network,proto3" json:"network,omitempty"`
+	MaxAmountRequired string                 `protobuf:"bytes,3,opt,name=max_amount_required,json=maxAmountRequired,proto3" json:"max_amount_required,omitempty"` // Atomic units
+	Resource          string                 `protobuf:"bytes,4,opt,name=resource,proto3" json:"resource,omitempty"`
+	Description       string                 `protobuf:"bytes,5,opt,name=description,proto3" json:"description,omitempty"`
+	PayTo             string                 `protobuf:"bytes,6,opt,name=pay_to,json=payTo,proto3" json:"pay_to,omitempty"`
+	Asset             string                 `protobuf:"bytes,7,opt,name=asset,proto3" json:"asset,omitempty"` // Token mint
+	MaxTimeoutSeconds int32                  `protobuf:"varint,8,opt,name=max_timeout_seconds,json=maxTimeoutSeconds,proto3" json:"max_timeout_seconds,omitempty"`
+	Extra             *structpb.Struct       `protobuf:"bytes,9,opt,name=extra,proto3" json:"extra,omitempty"` // recipientTokenAccount, memo, decimals, coupon details
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *CryptoQuote) Reset() {
+	*x = CryptoQuote{}
+	mi := &file_cedros_v1_paywall_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CryptoQuote) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CryptoQuote) ProtoMessage() {}
+
+func (x *CryptoQuote) ProtoReflect() protoreflect.Message {
+	mi := &file_cedros_v1_paywall_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CryptoQuote.ProtoReflect.Descriptor instead.
+func (*CryptoQuote) Descriptor() ([]byte, []int) {
+	return file_cedros_v1_paywall_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CryptoQuote) GetScheme() string {
+	if x != nil {
+		return x.Scheme
+	}
+	return ""
+}
+
+func (x *CryptoQuote) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *CryptoQuote) GetMaxAmountRequired() string {
+	if x != nil {
+		return x.MaxAmountRequired
+	}
+	return ""
+}
+
+func (x *CryptoQuote) GetResource() string {
+	if x != nil {
+		return x.Resource
+	}
+	return ""
+}
+
+func (x *CryptoQuote) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *CryptoQuote) GetPayTo() string {
+	if x != nil {
+		return x.PayTo
+	}
+	return ""
+}
+
+func (x *CryptoQuote) GetAsset() string {
+	if x != nil {
+		return x.Asset
+	}
+	return ""
+}
+
+func (x *CryptoQuote) GetMaxTimeoutSeconds() int32 {
+	if x != nil {
+		return x.MaxTimeoutSeconds
+	}
+	return 0
+}
+
+func (x *CryptoQuote) GetExtra() *structpb.Struct {
+	if x != nil {
+		return x.Extra
+	}
+	return nil
+}
+
+type VerifyPaymentRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Base64-encoded x402 payment payload, as sent in the X-PAYMENT header.
+	PaymentHeader string `protobuf:"bytes,1,opt,name=payment_header,json=paymentHeader,proto3" json:"payment_header,omitempty"`
+	// Resource to verify against. Defaults to payload.resource from the proof.
+	ResourceId    string `protobuf:"bytes,2,opt,name=resource_id,json=resourceId,proto3" json:"resource_id,omitempty"`
+	CouponCode    string `protobuf:"bytes,3,opt,name=coupon_code,json=couponCode,proto3" json:"coupon_code,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyPaymentRequest) Reset() {
+	*x = VerifyPaymentRequest{}
+	mi := &file_cedros_v1_paywall_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyPaymentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyPaymentRequest) ProtoMessage() {}
+
+func (x *VerifyPaymentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cedros_v1_paywall_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyPaymentRequest.ProtoReflect.Descriptor instead.
+func (*VerifyPaymentRequest) Descriptor() ([]byte, []int) {
+	return file_cedros_v1_paywall_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *VerifyPaymentRequest) GetPaymentHeader() string {
+	if x != nil {
+		return x.PaymentHeader
+	}
+	return ""
+}
+
+func (x *VerifyPaymentRequest) GetResourceId() string {
+	if x != nil {
+		return x.ResourceId
+	}
+	return ""
+}
+
+func (x *VerifyPaymentRequest) GetCouponCode() string {
+	if x != nil {
+		return x.CouponCode
+	}
+	return ""
+}
+
+type VerifyPaymentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Granted       bool                   `protobuf:"varint,1,opt,name=granted,proto3" json:"granted,omitempty"`
+	Method        string                 `protobuf:"bytes,2,opt,name=method,proto3" json:"method,omitempty"` // "x402" or "subscription"
+	Wallet        string                 `protobuf:"bytes,3,opt,name=wallet,proto3" json:"wallet,omitempty"`
+	Signature     string                 `protobuf:"bytes,4,opt,name=signature,proto3" json:"signature,omitempty"`
+	ResourceId    string                 `protobuf:"bytes,5,opt,name=resource_id,json=resourceId,proto3" json:"resource_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyPaymentResponse) Reset() {
+	*x = VerifyPaymentResponse{}
+	mi := &file_cedros_v1_paywall_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyPaymentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyPaymentResponse) ProtoMessage() {}
+
+func (x *VerifyPaymentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_cedros_v1_paywall_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyPaymentResponse.ProtoReflect.Descriptor instead.
+func (*VerifyPaymentResponse) Descriptor() ([]byte, []int) {
+	return file_cedros_v1_paywall_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *VerifyPaymentResponse) GetGranted() bool {
+	if x != nil {
+		return x.Granted
+	}
+	return false
+}
+
+func (x *VerifyPaymentResponse) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *VerifyPaymentResponse) GetWallet() string {
+	if x != nil {
+		return x.Wallet
+	}
+	return ""
+}
+
+func (x *VerifyPaymentResponse) GetSignature() string {
+	if x != nil {
+		return x.Signature
+	}
+	return ""
+}
+
+func (x *VerifyPaymentResponse) GetResourceId() string {
+	if x != nil {
+		return x.ResourceId
+	}
+	return ""
+}
+
+type CheckEntitlementRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	ResourceId string                 `protobuf:"bytes,1,opt,name=resource_id,json=resourceId,proto3" json:"resource_id,omitempty"`
+	// At least one of wallet or signature is required. A signature is checked
+	// against recorded payments; a wallet is checked against subscriptions.
+	Wallet        string `protobuf:"bytes,2,opt,name=wallet,proto3" json:"wallet,omitempty"`
+	Signature     string `protobuf:"bytes,3,opt,name=signature,proto3" json:"signature,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckEntitlementRequest) Reset() {
+	*x = CheckEntitlementRequest{}
+	mi := &file_cedros_v1_paywall_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckEntitlementRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckEntitlementRequest) ProtoMessage() {}
+
+func (x *CheckEntitlementRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cedros_v1_paywall_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckEntitlementRequest.ProtoReflect.Descriptor instead.
+func (*CheckEntitlementRequest) Descriptor() ([]byte, []int) {
+	return file_cedros_v1_paywall_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *CheckEntitlementRequest) GetResourceId() string {
+	if x != nil {
+		return x.ResourceId
+	}
+	return ""
+}
+
+func (x *CheckEntitlementRequest) GetWallet() string {
+	if x != nil {
+		return x.Wallet
+	}
+	return ""
+}
+
+func (x *CheckEntitlementRequest) GetSignature() string {
+	if x != nil {
+		return x.Signature
+	}
+	return ""
+}
+
+type CheckEntitlementResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Entitled       bool                   `protobuf:"varint,1,opt,name=entitled,proto3" json:"entitled,omitempty"`
+	Method         string                 `protobuf:"bytes,2,opt,name=method,proto3" json:"method,omitempty"` // "payment" or "subscription" when entitled
+	Signature      string                 `protobuf:"bytes,3,opt,name=signature,proto3" json:"signature,omitempty"`
+	SubscriptionId string                 `protobuf:"bytes,4,opt,name=subscription_id,json=subscriptionId,proto3" json:"subscription_id,omitempty"`
+	ExpiresAt      *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"` // Subscription period end, if any
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CheckEntitlementResponse) Reset() {
+	*x = CheckEntitlementResponse{}
+	mi := &file_cedros_v1_paywall_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckEntitlementResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckEntitlementResponse) ProtoMessage() {}
+
+func (x *CheckEntitlementResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_cedros_v1_paywall_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckEntitlementResponse.ProtoReflect.Descriptor instead.
+func (*CheckEntitlementResponse) Descriptor() ([]byte, []int) {
+	return file_cedros_v1_paywall_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *CheckEntitlementResponse) GetEntitled() bool {
+	if x != nil {
+		return x.Entitled
+	}
+	return false
+}
+
+func (x *CheckEntitlementResponse) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *CheckEntitlementResponse) GetSignature() string {
+	if x != nil {
+		return x.Signature
+	}
+	return ""
+}
+
+func (x *CheckEntitlementResponse) GetSubscriptionId() string {
+	if x != nil {
+		return x.SubscriptionId
+	}
+	return ""
+}
+
+func (x *CheckEntitlementResponse) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+type RequestRefundRequest struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	OriginalPurchaseId string                 `protobuf:"bytes,1,opt,name=original_purchase_id,json=originalPurchaseId,proto3" json:"original_purchase_id,omitempty"` // Signature of the verified payment
+	RecipientWallet    string                 `protobuf:"bytes,2,opt,name=recipient_wallet,json=recipientWallet,proto3" json:"recipient_wallet,omitempty"`            // Must match the paying wallet
+	Amount             string                 `protobuf:"bytes,3,opt,name=amount,proto3" json:"amount,omitempty"`                                                     // Major units (e.g. "1.50"); must not exceed the payment
+	Token              string                 `protobuf:"bytes,4,opt,name=token,proto3" json:"token,omitempty"`                                                       // Must match the payment token (e.g. "USDC")
+	Reason             string                 `protobuf:"bytes,5,opt,name=reason,proto3" json:"reason,omitempty"`
+	Metadata           map[string]string      `protobuf:"bytes,6,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *RequestRefundRequest) Reset() {
+	*x = RequestRefundRequest{}
+	mi := &file_cedros_v1_paywall_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RequestRefundRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestRefundRequest) ProtoMessage() {}
+
+func (x *RequestRefundRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cedros_v1_paywall_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestRefundRequest.ProtoReflect.Descriptor instead.
+func (*RequestRefundRequest) Descriptor() ([]byte, []int) {
+	return file_cedros_v1_paywall_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *RequestRefundRequest) GetOriginalPurchaseId() string {
+	if x != nil {
+		return x.OriginalPurchaseId
+	}
+	return ""
+}
+
+func (x *RequestRefundRequest) GetRecipientWallet() string {
+	if x != nil {
+		return x.RecipientWallet
+	}
+	return ""
+}
+
+func (x *RequestRefundRequest) GetAmount() string {
+	if x != nil {
+		return x.Amount
+	}
+	return ""
+}
+
+func (x *RequestRefundRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *RequestRefundRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *RequestRefundRequest) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+type GetRefundRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RefundId      string                 `protobuf:"bytes,1,opt,name=refund_id,json=refundId,proto3" json:"refund_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRefundRequest) Reset() {
+	*x = GetRefundRequest{}
+	mi := &file_cedros_v1_paywall_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRefundRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRefundRequest) ProtoMessage() {}
+
+func (x *GetRefundRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cedros_v1_paywall_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRefundRequest.ProtoReflect.Descriptor instead.
+func (*GetRefundRequest) Descriptor() ([]byte, []int) {
+	return file_cedros_v1_paywall_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetRefundRequest) GetRefundId() string {
+	if x != nil {
+		return x.RefundId
+	}
+	return ""
+}
+
+type Refund struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Id                 string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Status             string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"` // "pending" or "processed"
+	OriginalPurchaseId string                 `protobuf:"bytes,3,opt,name=original_purchase_id,json=originalPurchaseId,proto3" json:"original_purchase_id,omitempty"`
+	RecipientWallet    string                 `protobuf:"bytes,4,opt,name=recipient_wallet,json=recipientWallet,proto3" json:"recipient_wallet,omitempty"`
+	Amount             string                 `protobuf:"bytes,5,opt,name=amount,proto3" json:"amount,omitempty"` // Major units
+	Token              string                 `protobuf:"bytes,6,opt,name=token,proto3" json:"token,omitempty"`
+	Reason             string                 `protobuf:"bytes,7,opt,name=reason,proto3" json:"reason,omitempty"`
+	Signature          string                 `protobuf:"bytes,8,opt,name=signature,proto3" json:"signature,omitempty"` // Refund transaction signature once processed
+	CreatedAt          *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	ProcessedAt        *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=processed_at,json=processedAt,proto3" json:"processed_at,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *Refund) Reset() {
+	*x = Refund{}
+	mi := &file_cedros_v1_paywall_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Refund) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Refund) ProtoMessage() {}
+
+func (x *Refund) ProtoReflect() protoreflect.Message {
+	mi := &file_cedros_v1_paywall_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Refund.ProtoReflect.Descriptor instead.
+func (*Refund) Descriptor() ([]byte, []int) {
+	return file_cedros_v1_paywall_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Refund) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Refund) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Refund) GetOriginalPurchaseId() string {
+	if x != nil {
+		return x.OriginalPurchaseId
+	}
+	return ""
+}
+
+func (x *Refund) GetRecipientWallet() string {
+	if x != nil {
+		return x.RecipientWallet
+	}
+	return ""
+}
+
+func (x *Refund) GetAmount() string {
+	if x != nil {
+		return x.Amount
+	}
+	return ""
+}
+
+func (x *Refund) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *Refund) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *Refund) GetSignature() string {
+	if x != nil {
+		return x.Signature
+	}
+	return ""
+}
+
+func (x *Refund) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Refund) GetProcessedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ProcessedAt
+	}
+	return nil
+}
+
+var File_cedros_v1_paywall_proto protoreflect.FileDescriptor
+
+const file_cedros_v1_paywall_proto_rawDesc = "" +
+	"\n" +
+	"\x17cedros/v1/paywall.proto\x12\tcedros.v1\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"S\n" +
+	"\x0fGetQuoteRequest\x12\x1f\n" +
+	"\vresource_id\x18\x01 \x01(\tR\n" +
+	"resourceId\x12\x1f\n" +
+	"\vcoupon_code\x18\x02 \x01(\tR\n" +
+	"couponCode\"\xc4\x01\n" +
+	"\x05Quote\x12\x1f\n" +
+	"\vresource_id\x18\x01 \x01(\tR\n" +
+	"resourceId\x129\n" +
+	"\n" +
+	"expires_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12/\n" +
+	"\x06stripe\x18\x03 \x01(\v2\x17.cedros.v1.StripeOptionR\x06stripe\x12.\n" +
+	"\x06crypto\x18\x04 \x01(\v2\x16.cedros.v1.CryptoQuoteR\x06crypto\"\x8a\x01\n" +
+	"\fStripeOption\x12\x19\n" +
+	"\bprice_id\x18\x01 \x01(\tR\apriceId\x12!\n" +
+	"\famount_cents\x18\x02 \x01(\x03R\vamountCents\x12\x1a\n" +
+	"\bcurrency\x18\x03 \x01(\tR\bcurrency\x12 \n" +
+	"\vdescription\x18\x04 \x01(\tR\vdescription\"\xb9\x02\n" +
+	"\vCryptoQuote\x12\x16\n" +
+	"\x06scheme\x18\x01 \x01(\tR\x06scheme\x12\x18\n" +
+	"\anetwork\x18\x02 \x01(\tR\anetwork\x12.\n" +
+	"\x13max_amount_required\x18\x03 \x01(\tR\x11maxAmountRequired\x12\x1a\n" +
+	"\bresource\x18\x04 \x01(\tR\bresource\x12 \n" +
+	"\vdescription\x18\x05 \x01(\tR\vdescription\x12\x15\n" +
+	"\x06pay_to\x18\x06 \x01(\tR\x05payTo\x12\x14\n" +
+	"\x05asset\x18\a \x01(\tR\x05asset\x12.\n" +
+	"\x13max_timeout_seconds\x18\b \x01(\x05R\x11maxTimeoutSeconds\x12-\n" +
+	"\x05extra\x18\t \x01(\v2\x17.google.protobuf.StructR\x05extra\"\x7f\n" +
+	"\x14VerifyPaymentRequest\x12%\n" +
+	"\x0epayment_header\x18\x01 \x01(\tR\rpaymentHeader\x12\x1f\n" +
+	"\vresource_id\x18\x02 \x01(\tR\n" +
+	"resourceId\x12\x1f\n" +
+	"\vcoupon_code\x18\x03 \x01(\tR\n" +
+	"couponCode\"\xa0\x01\n" +
+	"\x15VerifyPaymentResponse\x12\x18\n" +
+	"\agranted\x18\x01 \x01(\bR\agranted\x12\x16\n" +
+	"\x06method\x18\x02 \x01(\tR\x06method\x12\x16\n" +
+	"\x06wallet\x18\x03 \x01(\tR\x06wallet\x12\x1c\n" +
+	"\tsignature\x18\x04 \x01(\tR\tsignature\x12\x1f\n" +
+	"\vresource_id\x18\x05 \x01(\tR\n" +
+	"resourceId\"p\n" +
+	"\x17CheckEntitlementRequest\x12\x1f\n" +
+	"\vresource_id\x18\x01 \x01(\tR\n" +
+	"resourceId\x12\x16\n" +
+	"\x06wallet\x18\x02 \x01(\tR\x06wallet\x12\x1c\n" +
+	"\tsignature\x18\x03 \x01(\tR\tsignature\"\xd0\x01\n" +
+	"\x18CheckEntitlementResponse\x12\x1a\n" +
+	"\bentitled\x18\x01 \x01(\bR\bentitled\x12\x16\n" +
+	"\x06method\x18\x02 \x01(\tR\x06method\x12\x1c\n" +
+	"\tsignature\x18\x03 \x01(\tR\tsignature\x12'\n" +
+	"\x0fsubscription_id\x18\x04 \x01(\tR\x0esubscriptionId\x129\n" +
+	"\n" +
+	"expires_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\"\xc1\x02\n" +
+	"\x14RequestRefundRequest\x120\n" +
+	"\x14original_purchase_id\x18\x01 \x01(\tR\x12originalPurchaseId\x12)\n" +
+	"\x10recipient_wallet\x18\x02 \x01(\tR\x0frecipientWallet\x12\x16\n" +
+	"\x06amount\x18\x03 \x01(\tR\x06amount\x12\x14\n" +
+	"\x05token\x18\x04 \x01(\tR\x05token\x12\x16\n" +
+	"\x06reason\x18\x05 \x01(\tR\x06reason\x12I\n" +
+	"\bmetadata\x18\x06 \x03(\v2-.cedros.v1.RequestRefundRequest.MetadataEntryR\bmetadata\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"/\n" +
+	"\x10GetRefundRequest\x12\x1b\n" +
+	"\trefund_id\x18\x01 \x01(\tR\brefundId\"\xeb\x02\n" +
+	"\x06Refund\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x120\n" +
+	"\x14original_purchase_id\x18\x03 \x01(\tR\x12originalPurchaseId\x12)\n" +
+	"\x10recipient_wallet\x18\x04 \x01(\tR\x0frecipientWallet\x12\x16\n" +
+	"\x06amount\x18\x05 \x01(\tR\x06amount\x12\x14\n" +
+	"\x05token\x18\x06 \x01(\tR\x05token\x12\x16\n" +
+	"\x06reason\x18\a \x01(\tR\x06reason\x12\x1c\n" +
+	"\tsignature\x18\b \x01(\tR\tsignature\x129\n" +
+	"\n" +
+	"created_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12=\n" +
+	"\fprocessed_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\vprocessedAt2\xfd\x02\n" +
+	"\x0ePaywallService\x128\n" +
+	"\bGetQuote\x12\x1a.cedros.v1.GetQuoteRequest\x1a\x10.cedros.v1.Quote\x12R\n" +
+	"\rVerifyPayment\x12\x1f.cedros.v1.VerifyPaymentRequest\x1a .cedros.v1.VerifyPaymentResponse\x12[\n" +
+	"\x10CheckEntitlement\x12\".cedros.v1.CheckEntitlementRequest\x1a#.cedros.v1.CheckEntitlementResponse\x12C\n" +
+	"\rRequestRefund\x12\x1f.cedros.v1.RequestRefundRequest\x1a\x11.cedros.v1.Refund\x12;\n" +
+	"\tGetRefund\x12\x1b.cedros.v1.GetRefundRequest\x1a\x11.cedros.v1.RefundB8Z6github.com/CedrosPay/server/pkg/grpc/cedrosv1;cedrosv1b\x06proto3"
+
+var (
+	file_cedros_v1_paywall_proto_rawDescOnce sync.Once
+	file_cedros_v1_paywall_proto_rawDescData []byte
+)
+
+func file_cedros_v1_paywall_proto_rawDescGZIP() []byte {
+	file_cedros_v1_paywall_proto_rawDescOnce.Do(func() {
+		file_cedros_v1_paywall_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_cedros_v1_paywall_proto_rawDesc), len(file_cedros_v1_paywall_proto_rawDesc)))
+	})
+	return file_cedros_v1_paywall_proto_rawDescData
+}
+
+var file_cedros_v1_paywall_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_cedros_v1_paywall_proto_goTypes = []any{
+	(*GetQuoteRequest)(nil),          // 0: cedros.v1.GetQuoteRequest
+	(*Quote)(nil),                    // 1: cedros.v1.Quote
+	(*StripeOption)(nil),             // 2: cedros.v1.StripeOption
+	(*CryptoQuote)(nil),              // 3: cedros.v1.CryptoQuote
+	(*VerifyPaymentRequest)(nil),     // 4: cedros.v1.VerifyPaymentRequest
+	(*VerifyPaymentResponse)(nil),    // 5: cedros.v1.VerifyPaymentResponse
+	(*CheckEntitlementRequest)(nil),  // 6: cedros.v1.CheckEntitlementRequest
+	(*CheckEntitlementResponse)(nil), // 7: cedros.v1.CheckEntitlementResponse
+	(*RequestRefundRequest)(nil),     // 8: cedros.v1.RequestRefundRequest
+	(*GetRefundRequest)(nil),         // 9: cedros.v1.GetRefundRequest
+	(*Refund)(nil),                   // 10: cedros.v1.Refund
+	nil,                              // 11: cedros.v1.RequestRefundRequest.MetadataEntry
+	(*timestamppb.Timestamp)(nil),    // 12: google.protobuf.Timestamp
+	(*structpb.Struct)(nil),          // 13: google.protobuf.Struct
+}
+var file_cedros_v1_paywall_proto_depIdxs = []int32{
+	12, // 0: cedros.v1.Quote.expires_at:type_name -> google.protobuf.Timestamp
+	2,  // 1: cedros.v1.Quote.stripe:type_name -> cedros.v1.StripeOption
+	3,  // 2: cedros.v1.Quote.crypto:type_name -> cedros.v1.CryptoQuote
+	13, // 3: cedros.v1.CryptoQuote.extra:type_name -> google.protobuf.Struct
+	12, // 4: cedros.v1.CheckEntitlementResponse.expires_at:type_name -> google.protobuf.Timestamp
+	11, // 5: cedros.v1.RequestRefundRequest.metadata:type_name -> cedros.v1.RequestRefundRequest.MetadataEntry
+	12, // 6: cedros.v1.Refund.created_at:type_name -> google.protobuf.Timestamp
+	12, // 7: cedros.v1.Refund.processed_at:type_name -> google.protobuf.Timestamp
+	0,  // 8: cedros.v1.PaywallService.GetQuote:input_type -> cedros.v1.GetQuoteRequest
+	4,  // 9: cedros.v1.PaywallService.VerifyPayment:input_type -> cedros.v1.VerifyPaymentRequest
+	6,  // 10: cedros.v1.PaywallService.CheckEntitlement:input_type -> cedros.v1.CheckEntitlementRequest
+	8,  // 11: cedros.v1.PaywallService.RequestRefund:input_type -> cedros.v1.RequestRefundRequest
+	9,  // 12: cedros.v1.PaywallService.GetRefund:input_type -> cedros.v1.GetRefundRequest
+	1,  // 13: cedros.v1.PaywallService.GetQuote:output_type -> cedros.v1.Quote
+	5,  // 14: cedros.v1.PaywallService.VerifyPayment:output_type -> cedros.v1.VerifyPaymentResponse
+	7,  // 15: cedros.v1.PaywallService.CheckEntitlement:output_type -> cedros.v1.CheckEntitlementResponse
+	10, // 16: cedros.v1.PaywallService.RequestRefund:output_type -> cedros.v1.Refund
+	10, // 17: cedros.v1.PaywallService.GetRefund:output_type -> cedros.v1.Refund
+	13, // [13:18] is the sub-list for method output_type
+	8,  // [8:13] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_cedros_v1_paywall_proto_init() }
+func file_cedros_v1_paywall_proto_init() {
+	if File_cedros_v1_paywall_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_cedros_v1_paywall_proto_rawDesc), len(file_cedros_v1_paywall_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   12,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_cedros_v1_paywall_proto_goTypes,
+		DependencyIndexes: file_cedros_v1_paywall_proto_depIdxs,
+		MessageInfos:      file_cedros_v1_paywall_proto_msgTypes,
+	}.Build()
+	File_cedros_v1_paywall_proto = out.File
+	file_cedros_v1_paywall_proto_goTypes = nil
+	file_cedros_v1_paywall_proto_depIdxs = nil
+}
@@ -0,0 +1,301 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: cedros/v1/paywall.proto
+
+// Cedros Pay machine-to-machine API.
+//
+// The Go client is published as github.com/CedrosPay/server/pkg/grpc/cedrosv1
+// (regenerate with `make proto`). Generate other clients (e.g. TypeScript via
+// ts-proto or protobuf-es) from this file.
+//
+// Calls must carry an API key from api_key.keys in the "x-api-key" metadata
+// (or "authorization: Bearer <key>") unless grpc.allow_unauthenticated is set.
+// Failures use standard gRPC status codes; the Cedros error code (e.g.
+// "amount_mismatch") is attached as a google.rpc.ErrorInfo detail with
+// domain "cedrospay".
+
+package cedrosv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	PaywallService_GetQuote_FullMethodName         = "/cedros.v1.PaywallService/GetQuote"
+	PaywallService_VerifyPayment_FullMethodName    = "/cedros.v1.PaywallService/VerifyPayment"
+	PaywallService_CheckEntitlement_FullMethodName = "/cedros.v1.PaywallService/CheckEntitlement"
+	PaywallService_RequestRefund_FullMethodName    = "/cedros.v1.PaywallService/RequestRefund"
+	PaywallService_GetRefund_FullMethodName        = "/cedros.v1.PaywallService/GetRefund"
+)
+
+// PaywallServiceClient is the client API for PaywallService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type PaywallServiceClient interface {
+	// GetQuote returns Stripe and x402 payment options for a resource.
+	GetQuote(ctx context.Context, in *GetQuoteRequest, opts ...grpc.CallOption) (*Quote, error)
+	// VerifyPayment verifies an x402 payment proof and records the payment.
+	// Blocks until the transaction is confirmed on-chain.
+	VerifyPayment(ctx context.Context, in *VerifyPaymentRequest, opts ...grpc.CallOption) (*VerifyPaymentResponse, error)
+	// CheckEntitlement reports whether a wallet or payment signature grants
+	// access to a resource, via a recorded payment or an active subscription.
+	CheckEntitlement(ctx context.Context, in *CheckEntitlementRequest, opts ...grpc.CallOption) (*CheckEntitlementResponse, error)
+	// RequestRefund creates a pending refund request for a verified payment.
+	// The refund is executed by an admin through the REST refund flow.
+	RequestRefund(ctx context.Context, in *RequestRefundRequest, opts ...grpc.CallOption) (*Refund, error)
+	// GetRefund returns a refund request by ID.
+	GetRefund(ctx context.Context, in *GetRefundRequest, opts ...grpc.CallOption) (*Refund, error)
+}
+
+type paywallServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPaywallServiceClient(cc grpc.ClientConnInterface) PaywallServiceClient {
+	return &paywallServiceClient{cc}
+}
+
+func (c *paywallServiceClient) GetQuote(ctx context.Context, in *GetQuoteRequest, opts ...grpc.CallOption) (*Quote, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Quote)
+	err := c.cc.Invoke(ctx, PaywallService_GetQuote_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paywallServiceClient) VerifyPayment(ctx context.Context, in *VerifyPaymentRequest, opts ...grpc.CallOption) (*VerifyPaymentResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VerifyPaymentResponse)
+	err := c.cc.Invoke(ctx, PaywallService_VerifyPayment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paywallServiceClient) CheckEntitlement(ctx context.Context, in *CheckEntitlementRequest, opts ...grpc.CallOption) (*CheckEntitlementResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CheckEntitlementResponse)
+	err := c.cc.Invoke(ctx, PaywallService_CheckEntitlement_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paywallServiceClient) RequestRefund(ctx context.Context, in *RequestRefundRequest, opts ...grpc.CallOption) (*Refund, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Refund)
+	err := c.cc.Invoke(ctx, PaywallService_RequestRefund_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paywallServiceClient) GetRefund(ctx context.Context, in *GetRefundRequest, opts ...grpc.CallOption) (*Refund, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Refund)
+	err := c.cc.Invoke(ctx, PaywallService_GetRefund_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PaywallServiceServer is the server API for PaywallService service.
+// All implementations must embed UnimplementedPaywallServiceServer
+// for forward compatibility.
+type PaywallServiceServer interface {
+	// GetQuote returns Stripe and x402 payment options for a resource.
+	GetQuote(context.Context, *GetQuoteRequest) (*Quote, error)
+	// VerifyPayment verifies an x402 payment proof and records the payment.
+	// Blocks until the transaction is confirmed on-chain.
+	VerifyPayment(context.Context, *VerifyPaymentRequest) (*VerifyPaymentResponse, error)
+	// CheckEntitlement reports whether a wallet or payment signature grants
+	// access to a resource, via a recorded payment or an active subscription.
+	CheckEntitlement(context.Context, *CheckEntitlementRequest) (*CheckEntitlementResponse, error)
+	// RequestRefund creates a pending refund request for a verified payment.
+	// The refund is executed by an admin through the REST refund flow.
+	RequestRefund(context.Context, *RequestRefundRequest) (*Refund, error)
+	// GetRefund returns a refund request by ID.
+	GetRefund(context.Context, *GetRefundRequest) (*Refund, error)
+	mustEmbedUnimplementedPaywallServiceServer()
+}
+
+// UnimplementedPaywallServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedPaywallServiceServer struct{}
+
+func (UnimplementedPaywallServiceServer) GetQuote(context.Context, *GetQuoteRequest) (*Quote, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetQuote not implemented")
+}
+func (UnimplementedPaywallServiceServer) VerifyPayment(context.Context, *VerifyPaymentRequest) (*VerifyPaymentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyPayment not implemented")
+}
+func (UnimplementedPaywallServiceServer) CheckEntitlement(context.Context, *CheckEntitlementRequest) (*CheckEntitlementResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckEntitlement not implemented")
+}
+func (UnimplementedPaywallServiceServer) RequestRefund(context.Context, *RequestRefundRequest) (*Refund, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RequestRefund not implemented")
+}
+func (UnimplementedPaywallServiceServer) GetRefund(context.Context, *GetRefundRequest) (*Refund, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRefund not implemented")
+}
+func (UnimplementedPaywallServiceServer) mustEmbedUnimplementedPaywallServiceServer() {}
+func (UnimplementedPaywallServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafePaywallServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PaywallServiceServer will
+// result in compilation errors.
+type UnsafePaywallServiceServer interface {
+	mustEmbedUnimplementedPaywallServiceServer()
+}
+
+func RegisterPaywallServiceServer(s grpc.ServiceRegistrar, srv PaywallServiceServer) {
+	// If the following call pancis, it indicates UnimplementedPaywallServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&PaywallService_ServiceDesc, srv)
+}
+
+func _PaywallService_GetQuote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetQuoteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaywallServiceServer).GetQuote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaywallService_GetQuote_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaywallServiceServer).GetQuote(ctx, req.(*GetQuoteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaywallService_VerifyPayment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyPaymentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaywallServiceServer).VerifyPayment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaywallService_VerifyPayment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaywallServiceServer).VerifyPayment(ctx, req.(*VerifyPaymentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaywallService_CheckEntitlement_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckEntitlementRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaywallServiceServer).CheckEntitlement(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaywallService_CheckEntitlement_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaywallServiceServer).CheckEntitlement(ctx, req.(*CheckEntitlementRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaywallService_RequestRefund_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequestRefundRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaywallServiceServer).RequestRefund(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaywallService_RequestRefund_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaywallServiceServer).RequestRefund(ctx, req.(*RequestRefundRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaywallService_GetRefund_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRefundRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaywallServiceServer).GetRefund(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaywallService_GetRefund_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaywallServiceServer).GetRefund(ctx, req.(*GetRefundRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// PaywallService_ServiceDesc is the grpc.ServiceDesc for PaywallService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PaywallService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "cedros.v1.PaywallService",
+	HandlerType: (*PaywallServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetQuote",
+			Handler:    _PaywallService_GetQuote_Handler,
+		},
+		{
+			MethodName: "VerifyPayment",
+			Handler:    _PaywallService_VerifyPayment_Handler,
+		},
+		{
+			MethodName: "CheckEntitlement",
+			Handler:    _PaywallService_CheckEntitlement_Handler,
+		},
+		{
+			MethodName: "RequestRefund",
+			Handler:    _PaywallService_RequestRefund_Handler,
+		},
+		{
+			MethodName: "GetRefund",
+			Handler:    _PaywallService_GetRefund_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "cedros/v1/paywall.proto",
+}
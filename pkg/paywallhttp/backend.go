@@ -0,0 +1,175 @@
+package paywallhttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	apierrors "github.com/CedrosPay/server/internal/errors"
+	"github.com/CedrosPay/server/internal/paywall"
+)
+
+// Remote checks payments with a running Cedros Pay server over its HTTP API.
+type Remote struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewRemote creates a backend for the server at baseURL, including the route prefix (for
+// example "https://pay.example.com/api"). A nil httpClient uses one with a 60s timeout, as
+// verification waits for on-chain confirmation.
+func NewRemote(baseURL string, httpClient *http.Client) *Remote {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 60 * time.Second}
+	}
+	return &Remote{baseURL: strings.TrimRight(baseURL, "/"), httpClient: httpClient}
+}
+
+// Quote fetches the resource's 402 body from POST /paywall/v1/quote.
+func (b *Remote) Quote(ctx context.Context, resource, couponCode string) (json.RawMessage, error) {
+	body := map[string]any{"resource": resource}
+	if couponCode != "" {
+		body["couponCode"] = couponCode
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("paywallhttp: encode quote request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.baseURL+"/paywall/v1/quote", bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("paywallhttp: new quote request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	quote, _, err := b.do(req, http.StatusPaymentRequired)
+	return quote, err
+}
+
+// Verify submits the payment to POST /paywall/v1/verify. The coupon is read from the payment
+// itself there, so couponCode is not sent.
+func (b *Remote) Verify(ctx context.Context, resource, _, paymentHeader string) (Payment, error) {
+	query := url.Values{"resource": {resource}, "resourceType": {"regular"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.baseURL+"/paywall/v1/verify?"+query.Encode(), nil)
+	if err != nil {
+		return Payment{}, fmt.Errorf("paywallhttp: new verify request: %w", err)
+	}
+	req.Header.Set("X-PAYMENT", paymentHeader)
+	data, header, err := b.do(req, http.StatusOK)
+	if err != nil {
+		return Payment{}, err
+	}
+	var verified struct {
+		Wallet    string `json:"wallet"`
+		Signature string `json:"signature"`
+	}
+	if err := json.Unmarshal(data, &verified); err != nil {
+		return Payment{}, fmt.Errorf("paywallhttp: decode verify response: %w", err)
+	}
+	return Payment{
+		Resource:   resource,
+		Wallet:     verified.Wallet,
+		Signature:  verified.Signature,
+		Settlement: header.Get("X-PAYMENT-RESPONSE"),
+	}, nil
+}
+
+// do sends req and returns the body when the server answers with want, else the server's error.
+func (b *Remote) do(req *http.Request, want int) (json.RawMessage, http.Header, error) {
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("paywallhttp: %s %s: %w", req.Method, req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("paywallhttp: read response: %w", err)
+	}
+	if resp.StatusCode == want {
+		return data, resp.Header, nil
+	}
+
+	var errResp struct {
+		Error *Error `json:"error"`
+	}
+	if json.Unmarshal(data, &errResp) != nil || errResp.Error == nil {
+		errResp.Error = &Error{Code: string(apierrors.ErrCodeInternalError), Message: strings.TrimSpace(string(data))}
+	}
+	errResp.Error.Status = resp.StatusCode
+	return nil, nil, errResp.Error
+}
+
+// Embedded checks payments with the paywall service of an in-process cedros.App.
+type Embedded struct {
+	service *paywall.Service
+}
+
+// NewEmbedded creates a backend over an embedded app's paywall service (cedros.App.Paywall).
+func NewEmbedded(service *paywall.Service) *Embedded {
+	return &Embedded{service: service}
+}
+
+// Quote builds the resource's 402 body.
+func (b *Embedded) Quote(ctx context.Context, resource, couponCode string) (json.RawMessage, error) {
+	quote, err := b.service.GenerateQuote(ctx, resource, couponCode)
+	if err != nil {
+		return nil, embeddedError(err)
+	}
+	if quote.Crypto == nil {
+		return nil, &Error{Status: http.StatusInternalServerError, Code: string(apierrors.ErrCodeInternalError), Message: "resource has no crypto quote"}
+	}
+	data, err := json.Marshal(b.service.PaymentRequired(quote.Crypto))
+	if err != nil {
+		return nil, fmt.Errorf("paywallhttp: encode quote: %w", err)
+	}
+	return data, nil
+}
+
+// Verify verifies and settles the payment in process.
+func (b *Embedded) Verify(ctx context.Context, resource, couponCode, paymentHeader string) (Payment, error) {
+	result, err := b.service.Authorize(ctx, resource, "", paymentHeader, couponCode)
+	if err != nil {
+		return Payment{}, embeddedError(err)
+	}
+	if !result.Granted {
+		return Payment{}, &Error{Status: http.StatusPaymentRequired, Code: string(apierrors.ErrCodeTransactionFailed), Message: "payment verification failed"}
+	}
+	payment := Payment{Resource: resource, Wallet: result.Wallet}
+	if settlement := result.Settlement; settlement != nil {
+		if settlement.TxHash != nil {
+			payment.Signature = *settlement.TxHash
+		}
+		if data, err := json.Marshal(settlement); err == nil {
+			payment.Settlement = base64.StdEncoding.EncodeToString(data)
+		}
+	}
+	return payment, nil
+}
+
+// embeddedError maps a paywall service error to the status the server's own endpoints use.
+func embeddedError(err error) *Error {
+	code := apierrors.ErrCodeTransactionFailed
+	switch {
+	case errors.Is(err, paywall.ErrResourceNotConfigured):
+		code = apierrors.ErrCodeResourceNotFound
+	case errors.Is(err, paywall.ErrVelocityLimit):
+		code = apierrors.ErrCodeVelocityLimitExceeded
+	case errors.Is(err, paywall.ErrAccessDenied):
+		code = apierrors.ErrCodeAccessDenied
+	case errors.Is(err, paywall.ErrWalletFlagged):
+		code = apierrors.ErrCodeComplianceBlocked
+	case errors.Is(err, paywall.ErrScreeningUnavailable):
+		code = apierrors.ErrCodeScreeningUnavailable
+	case errors.Is(err, paywall.ErrCouponWalletLimit):
+		code = apierrors.ErrCodeCouponUsageLimitReached
+	case errors.Is(err, paywall.ErrDraining):
+		code = apierrors.ErrCodeServiceUnavailable
+	}
+	return &Error{Status: code.HTTPStatus(), Code: string(code), Message: err.Error(), Retryable: code.IsRetryable()}
+}
@@ -0,0 +1,101 @@
+// Package paywallhttp gates any net/http handler behind an x402 payment. Requests without an
+// X-PAYMENT header get a 402 quote; requests with one are verified and settled before the
+// handler runs. Payments are checked by a running Cedros Pay server (Remote) or by the paywall
+// service of an embedded cedros.App (Embedded).
+package paywallhttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/CedrosPay/server/pkg/responders"
+)
+
+// Backend issues quotes and verifies payments for the middleware.
+type Backend interface {
+	// Quote returns the 402 Payment Required body for resource.
+	Quote(ctx context.Context, resource, couponCode string) (json.RawMessage, error)
+	// Verify verifies and settles an X-PAYMENT header paying for resource.
+	Verify(ctx context.Context, resource, couponCode, paymentHeader string) (Payment, error)
+}
+
+// Payment is a verified payment, available to the wrapped handler via PaymentFromContext.
+type Payment struct {
+	Resource   string
+	Wallet     string
+	Signature  string
+	Settlement string // X-PAYMENT-RESPONSE header value, if the backend reported one
+}
+
+// Error is a failure the middleware answers with Status, in the Cedros error format.
+type Error struct {
+	Status    int    `json:"-"`
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	Retryable bool   `json:"retryable"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("paywallhttp: %s: %s", e.Code, e.Message)
+}
+
+// ResourceFunc names the paywall resource a request pays for.
+type ResourceFunc func(*http.Request) string
+
+// Resource is a ResourceFunc for a route that always sells the same resource.
+func Resource(id string) ResourceFunc {
+	return func(*http.Request) string { return id }
+}
+
+type contextKey struct{}
+
+// PaymentFromContext returns the payment the middleware verified for the request.
+func PaymentFromContext(ctx context.Context) (Payment, bool) {
+	payment, ok := ctx.Value(contextKey{}).(Payment)
+	return payment, ok
+}
+
+// Middleware requires a payment for resource before calling the wrapped handler. Clients
+// may pass a couponCode query parameter to have the quote and payment priced with it.
+func Middleware(backend Backend, resource ResourceFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			resourceID := resource(r)
+			couponCode := r.URL.Query().Get("couponCode")
+			paymentHeader := strings.TrimSpace(r.Header.Get("X-PAYMENT"))
+
+			if paymentHeader == "" {
+				body, err := backend.Quote(r.Context(), resourceID, couponCode)
+				if err != nil {
+					writeError(w, err)
+					return
+				}
+				responders.JSON(w, http.StatusPaymentRequired, body)
+				return
+			}
+
+			payment, err := backend.Verify(r.Context(), resourceID, couponCode, paymentHeader)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			if payment.Settlement != "" {
+				w.Header().Set("X-PAYMENT-RESPONSE", payment.Settlement)
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, payment)))
+		})
+	}
+}
+
+// writeError answers with a backend Error, or a 502 when the backend could not be reached.
+func writeError(w http.ResponseWriter, err error) {
+	var pErr *Error
+	if !errors.As(err, &pErr) {
+		pErr = &Error{Status: http.StatusBadGateway, Code: "payment_backend_unavailable", Message: err.Error(), Retryable: true}
+	}
+	responders.JSON(w, pErr.Status, map[string]any{"error": pErr})
+}
@@ -0,0 +1,125 @@
+package paywallhttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fakeBackend answers every call with its fields and records the last request.
+type fakeBackend struct {
+	quote      json.RawMessage
+	payment    Payment
+	err        error
+	resource   string
+	couponCode string
+}
+
+func (b *fakeBackend) Quote(_ context.Context, resource, couponCode string) (json.RawMessage, error) {
+	b.resource, b.couponCode = resource, couponCode
+	return b.quote, b.err
+}
+
+func (b *fakeBackend) Verify(_ context.Context, resource, couponCode, _ string) (Payment, error) {
+	b.resource, b.couponCode = resource, couponCode
+	return b.payment, b.err
+}
+
+func TestMiddleware(t *testing.T) {
+	paid := Payment{Resource: "report", Wallet: "payer", Signature: "sig", Settlement: "c2V0dGxlZA=="}
+
+	tests := []struct {
+		name           string
+		header         string
+		backend        *fakeBackend
+		wantStatus     int
+		wantCode       string
+		wantSettlement string
+	}{
+		{name: "no payment gets quote", backend: &fakeBackend{quote: json.RawMessage(`{"x402Version":0,"accepts":[]}`)}, wantStatus: http.StatusPaymentRequired},
+		{name: "paid", header: "proof", backend: &fakeBackend{payment: paid}, wantStatus: http.StatusOK, wantSettlement: paid.Settlement},
+		{name: "rejected payment", header: "proof", backend: &fakeBackend{err: &Error{Status: http.StatusPaymentRequired, Code: "amount_mismatch"}}, wantStatus: http.StatusPaymentRequired, wantCode: "amount_mismatch"},
+		{name: "unknown resource", backend: &fakeBackend{err: &Error{Status: http.StatusNotFound, Code: "resource_not_found"}}, wantStatus: http.StatusNotFound, wantCode: "resource_not_found"},
+		{name: "backend unreachable", header: "proof", backend: &fakeBackend{err: errors.New("connection refused")}, wantStatus: http.StatusBadGateway, wantCode: "payment_backend_unavailable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Payment
+			handler := Middleware(tt.backend, Resource("report"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got, _ = PaymentFromContext(r.Context())
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/report?couponCode=SAVE10", nil)
+			if tt.header != "" {
+				req.Header.Set("X-PAYMENT", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.backend.resource != "report" || tt.backend.couponCode != "SAVE10" {
+				t.Errorf("backend called for %q with coupon %q", tt.backend.resource, tt.backend.couponCode)
+			}
+			if tt.wantCode != "" {
+				var body struct {
+					Error Error `json:"error"`
+				}
+				if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Error.Code != tt.wantCode {
+					t.Fatalf("error body = %s, want code %s", rec.Body, tt.wantCode)
+				}
+			}
+			if rec.Header().Get("X-PAYMENT-RESPONSE") != tt.wantSettlement {
+				t.Errorf("X-PAYMENT-RESPONSE = %q, want %q", rec.Header().Get("X-PAYMENT-RESPONSE"), tt.wantSettlement)
+			}
+			if tt.wantStatus == http.StatusOK && got != paid {
+				t.Errorf("context payment = %+v, want %+v", got, paid)
+			}
+		})
+	}
+}
+
+func TestRemote(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/paywall/v1/quote", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusPaymentRequired)
+		_, _ = w.Write([]byte(`{"x402Version":0,"accepts":[{"resource":"report"}]}`))
+	})
+	mux.HandleFunc("POST /api/paywall/v1/verify", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("resource") != "report" || r.Header.Get("X-PAYMENT") == "" {
+			w.WriteHeader(http.StatusPaymentRequired)
+			_, _ = w.Write([]byte(`{"error":{"code":"invalid_payment_proof","message":"missing payment","retryable":false}}`))
+			return
+		}
+		w.Header().Set("X-PAYMENT-RESPONSE", "c2V0dGxlZA==")
+		_, _ = w.Write([]byte(`{"resource":"report","granted":true,"method":"x402","wallet":"payer","signature":"sig"}`))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	backend := NewRemote(server.URL+"/api/", nil)
+
+	quote, err := backend.Quote(t.Context(), "report", "")
+	if err != nil || !json.Valid(quote) {
+		t.Fatalf("Quote = %s, %v", quote, err)
+	}
+
+	payment, err := backend.Verify(t.Context(), "report", "", "proof")
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	want := Payment{Resource: "report", Wallet: "payer", Signature: "sig", Settlement: "c2V0dGxlZA=="}
+	if payment != want {
+		t.Errorf("payment = %+v, want %+v", payment, want)
+	}
+
+	_, err = backend.Verify(t.Context(), "other", "", "proof")
+	var pErr *Error
+	if !errors.As(err, &pErr) || pErr.Status != http.StatusPaymentRequired || pErr.Code != "invalid_payment_proof" {
+		t.Fatalf("err = %v, want a 402 invalid_payment_proof", err)
+	}
+}
@@ -25,3 +25,19 @@ const (
 	// This accounts for floating point precision issues.
 	AmountTolerance = 1e-9
 )
+
+// Payment schemes accepted in X-PAYMENT headers
+const (
+	// SchemeSolanaSPLTransfer is the scheme Cedros quotes advertise by default.
+	SchemeSolanaSPLTransfer = "solana-spl-transfer"
+
+	// SchemeSolana is an older alias of SchemeSolanaSPLTransfer.
+	SchemeSolana = "solana"
+
+	// SchemeExact is the published x402 v1 scheme. On Solana its payload carries the signed
+	// transfer transaction, like SchemeSolanaSPLTransfer.
+	SchemeExact = "exact"
+)
+
+// Version1 is the published x402 protocol version (x402Version 1). Cedros payloads use 0.
+const Version1 = 1
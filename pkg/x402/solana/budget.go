@@ -0,0 +1,181 @@
+package solana
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gagliardetto/solana-go"
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
+
+	"github.com/CedrosPay/server/internal/logger"
+	solanaHelpers "github.com/CedrosPay/server/internal/solana"
+)
+
+const (
+	// lamportsPerSignature is the base fee Solana charges for each transaction signature.
+	lamportsPerSignature = 5000
+
+	// defaultComputeUnitsPerInstruction is the compute limit Solana applies per instruction
+	// when a transaction does not set one.
+	defaultComputeUnitsPerInstruction = 200000
+)
+
+// ErrGaslessBudgetExhausted is returned when every server wallet has spent its daily gasless budget.
+var ErrGaslessBudgetExhausted = errors.New("gasless budget exhausted for today")
+
+// gaslessSpend is a server wallet's gasless fee spending for one UTC day.
+type gaslessSpend struct {
+	day      string // UTC date (YYYY-MM-DD) the spending applies to
+	lamports uint64
+}
+
+// SetGaslessDailyBudget caps the network fees each server wallet pays for gasless transactions
+// per UTC day (0 = unlimited). Once a wallet reaches it the wallet is no longer selected or
+// advertised as a fee payer; when all have, quotes fall back to non-gasless and gasless
+// transactions are refused until midnight UTC. onExhausted is called once per wallet per day.
+func (s *SolanaVerifier) SetGaslessDailyBudget(lamports uint64, onExhausted func(wallet solana.PublicKey, spent, budget uint64)) {
+	s.walletsMu.Lock()
+	defer s.walletsMu.Unlock()
+	s.gaslessBudget = lamports
+	s.onBudgetExhausted = onExhausted
+}
+
+// GaslessAvailable reports whether gasless is enabled and at least one server wallet has
+// budget left today.
+func (s *SolanaVerifier) GaslessAvailable() bool {
+	if !s.gaslessEnabled {
+		return false
+	}
+	s.walletsMu.RLock()
+	defer s.walletsMu.RUnlock()
+	for _, wallet := range s.serverWallets {
+		if s.withinBudgetLocked(wallet.PublicKey().String()) {
+			return true
+		}
+	}
+	return false
+}
+
+// getNextGaslessWallet returns the next healthy server wallet that has gasless budget left.
+func (s *SolanaVerifier) getNextGaslessWallet() solanaHelpers.Signer {
+	s.walletsMu.RLock()
+	attempts := len(s.serverWallets)
+	s.walletsMu.RUnlock()
+
+	for range attempts {
+		wallet := s.getNextWallet()
+		if wallet == nil {
+			return nil
+		}
+		if s.withinBudget(wallet.PublicKey()) {
+			return wallet
+		}
+	}
+	return nil
+}
+
+// withinBudget reports whether wallet may pay for another gasless transaction today.
+func (s *SolanaVerifier) withinBudget(pubkey solana.PublicKey) bool {
+	s.walletsMu.RLock()
+	defer s.walletsMu.RUnlock()
+	return s.withinBudgetLocked(pubkey.String())
+}
+
+func (s *SolanaVerifier) withinBudgetLocked(address string) bool {
+	if s.gaslessBudget == 0 {
+		return true
+	}
+	spend, ok := s.gaslessSpend[address]
+	return !ok || spend.day != s.budgetDay() || spend.lamports < s.gaslessBudget
+}
+
+// recordGaslessFee adds a submitted gasless transaction's fee to the wallet's spending for
+// today, alerting the first time the wallet reaches its budget.
+func (s *SolanaVerifier) recordGaslessFee(ctx context.Context, pubkey solana.PublicKey, lamports uint64) {
+	address := pubkey.String()
+	if s.metrics != nil {
+		s.metrics.ObserveGaslessFee(s.network, address, lamports)
+	}
+
+	s.walletsMu.Lock()
+	if s.gaslessBudget == 0 {
+		s.walletsMu.Unlock()
+		return
+	}
+	if s.gaslessSpend == nil {
+		s.gaslessSpend = make(map[string]*gaslessSpend)
+	}
+	day := s.budgetDay()
+	spend, ok := s.gaslessSpend[address]
+	if !ok || spend.day != day {
+		spend = &gaslessSpend{day: day}
+		s.gaslessSpend[address] = spend
+	}
+	before := spend.lamports
+	spend.lamports += lamports
+	spent, budget, onExhausted := spend.lamports, s.gaslessBudget, s.onBudgetExhausted
+	s.walletsMu.Unlock()
+
+	if before >= budget || spent < budget {
+		return
+	}
+	log := logger.FromContext(ctx)
+	log.Warn().
+		Str("wallet", logger.TruncateAddress(address)).
+		Uint64("spent_lamports", spent).
+		Uint64("budget_lamports", budget).
+		Msg("gasless.budget_exhausted")
+	if s.metrics != nil {
+		s.metrics.ObserveGaslessBudgetExhausted(s.network, address)
+	}
+	if onExhausted != nil {
+		onExhausted(pubkey, spent, budget)
+	}
+}
+
+// budgetDay returns the UTC date budgets are currently tracked against.
+func (s *SolanaVerifier) budgetDay() string {
+	return s.clock().UTC().Format("2006-01-02")
+}
+
+// estimateTransactionFee returns the lamports the fee payer is charged for tx: the base fee per
+// signature plus the priority fee (compute unit price × limit).
+func estimateTransactionFee(tx *solana.Transaction) uint64 {
+	fee := uint64(tx.Message.Header.NumRequiredSignatures) * lamportsPerSignature
+
+	unitLimit, unitPrice, instructions := computeBudget(tx)
+	if unitLimit == 0 {
+		unitLimit = uint32(min(instructions*defaultComputeUnitsPerInstruction, computebudget.MAX_COMPUTE_UNIT_LIMIT))
+	}
+
+	// Price is in micro-lamports per compute unit; Solana rounds the total up
+	fee += (unitPrice*uint64(unitLimit) + 999999) / 1000000
+	return fee
+}
+
+// computeBudget returns the compute unit limit and price tx sets (0 when unset) and its number
+// of other instructions.
+func computeBudget(tx *solana.Transaction) (unitLimit uint32, unitPrice uint64, instructions int) {
+	for _, compiled := range tx.Message.Instructions {
+		programID, err := tx.Message.Program(compiled.ProgramIDIndex)
+		if err != nil || !programID.Equals(solana.ComputeBudget) {
+			instructions++
+			continue
+		}
+		accounts, err := compiled.ResolveInstructionAccounts(&tx.Message)
+		if err != nil {
+			continue
+		}
+		decoded, err := computebudget.DecodeInstruction(accounts, compiled.Data)
+		if err != nil {
+			continue
+		}
+		switch inst := decoded.Impl.(type) {
+		case *computebudget.SetComputeUnitLimit:
+			unitLimit = inst.Units
+		case *computebudget.SetComputeUnitPrice:
+			unitPrice = inst.MicroLamports
+		}
+	}
+	return unitLimit, unitPrice, instructions
+}
@@ -0,0 +1,115 @@
+package solana
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
+	"github.com/gagliardetto/solana-go/programs/memo"
+
+	solanaHelpers "github.com/CedrosPay/server/internal/solana"
+)
+
+func TestEstimateTransactionFee(t *testing.T) {
+	payer := solana.NewWallet().PublicKey()
+	memoIx := memo.NewMemoInstruction([]byte("x"), payer).Build()
+
+	tests := []struct {
+		name         string
+		instructions []solana.Instruction
+		want         uint64
+	}{
+		{
+			name:         "base fee only",
+			instructions: []solana.Instruction{memoIx},
+			want:         5000,
+		},
+		{
+			name: "priority fee rounds up",
+			instructions: []solana.Instruction{
+				computebudget.NewSetComputeUnitLimitInstruction(20000).Build(),
+				computebudget.NewSetComputeUnitPriceInstruction(1).Build(),
+				memoIx,
+			},
+			want: 5000 + 1, // 20000 CU × 1 µlamport = 0.02 lamports, rounded up
+		},
+		{
+			name: "default compute limit per instruction",
+			instructions: []solana.Instruction{
+				computebudget.NewSetComputeUnitPriceInstruction(1000000).Build(),
+				memoIx,
+				memoIx,
+			},
+			want: 5000 + 400000,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx, err := solana.NewTransaction(tt.instructions, solana.Hash{1}, solana.TransactionPayer(payer))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := estimateTransactionFee(tx); got != tt.want {
+				t.Errorf("estimateTransactionFee = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGaslessDailyBudget(t *testing.T) {
+	now := time.Date(2025, 12, 2, 23, 0, 0, 0, time.UTC)
+	v := &SolanaVerifier{rpcClient: fundedRPC(t), clock: func() time.Time { return now }}
+	defer v.Close()
+	v.EnableGasless()
+
+	first := solanaHelpers.NewLocalSigner(solana.NewWallet().PrivateKey)
+	second := solanaHelpers.NewLocalSigner(solana.NewWallet().PrivateKey)
+	v.SetServerWallets([]solanaHelpers.Signer{first, second})
+
+	var alerts []string
+	v.SetGaslessDailyBudget(10000, func(wallet solana.PublicKey, spent, budget uint64) {
+		alerts = append(alerts, wallet.String())
+	})
+
+	ctx := context.Background()
+	v.recordGaslessFee(ctx, first.PublicKey(), 5000)
+	if !v.withinBudget(first.PublicKey()) || len(alerts) != 0 {
+		t.Fatalf("first wallet exhausted early: alerts=%v", alerts)
+	}
+
+	v.recordGaslessFee(ctx, first.PublicKey(), 5000)
+	v.recordGaslessFee(ctx, first.PublicKey(), 5000) // In-flight transactions may overshoot; alert once
+	if v.withinBudget(first.PublicKey()) {
+		t.Fatal("first wallet should be over budget")
+	}
+	if len(alerts) != 1 || alerts[0] != first.PublicKey().String() {
+		t.Fatalf("alerts = %v, want one for the first wallet", alerts)
+	}
+	if got := v.FeePayerPublicKey(); got != second.PublicKey().String() {
+		t.Errorf("FeePayerPublicKey = %s, want the wallet with budget left", got)
+	}
+	for range 3 {
+		if got := v.getNextGaslessWallet(); got == nil || !got.PublicKey().Equals(second.PublicKey()) {
+			t.Fatalf("getNextGaslessWallet = %v, want the second wallet", got)
+		}
+	}
+
+	v.recordGaslessFee(ctx, second.PublicKey(), 10000)
+	if v.GaslessAvailable() {
+		t.Error("gasless should be unavailable once every wallet is over budget")
+	}
+	if got := v.getNextGaslessWallet(); got != nil {
+		t.Errorf("getNextGaslessWallet = %s, want nil", got.PublicKey())
+	}
+	if _, err := v.BuildGaslessTransaction(ctx, GaslessTxRequest{PayerWallet: solana.NewWallet().PublicKey()}); err != ErrGaslessBudgetExhausted {
+		t.Errorf("BuildGaslessTransaction error = %v, want ErrGaslessBudgetExhausted", err)
+	}
+
+	// Budgets reset at midnight UTC
+	now = now.Add(time.Hour)
+	if !v.GaslessAvailable() || !v.withinBudget(first.PublicKey()) {
+		t.Error("budgets should reset on a new UTC day")
+	}
+}
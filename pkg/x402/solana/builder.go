@@ -10,6 +10,9 @@ import (
 	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
 	"github.com/gagliardetto/solana-go/programs/memo"
 	"github.com/gagliardetto/solana-go/programs/token"
+
+	"github.com/CedrosPay/server/internal/logger"
+	solanaHelpers "github.com/CedrosPay/server/internal/solana"
 )
 
 // GaslessTxRequest contains the parameters needed to build a gasless transaction.
@@ -50,21 +53,30 @@ func (s *SolanaVerifier) BuildGaslessTransaction(ctx context.Context, req Gasles
 	}
 
 	// Get server wallet to act as fee payer
-	var wallet *solana.PrivateKey
+	var wallet solanaHelpers.Signer
 	if req.FeePayer != nil {
 		// Use specific fee payer if provided
 		wallet = s.findWalletByPublicKey(*req.FeePayer)
 		if wallet == nil {
 			return GaslessTxResponse{}, fmt.Errorf("specified fee payer not found in server wallets: %s", req.FeePayer.String())
 		}
+		if !s.withinBudget(*req.FeePayer) {
+			return GaslessTxResponse{}, fmt.Errorf("fee payer %s: %w", req.FeePayer.String(), ErrGaslessBudgetExhausted)
+		}
 	} else {
 		// Round-robin if not specified
-		wallet = s.getNextWallet()
+		wallet = s.getNextGaslessWallet()
 		if wallet == nil {
+			if s.FeePayerPublicKey() != "" && !s.GaslessAvailable() {
+				return GaslessTxResponse{}, ErrGaslessBudgetExhausted
+			}
 			return GaslessTxResponse{}, errors.New("no server wallets configured for gasless")
 		}
 	}
 
+	// The user may take a while to sign; keep a retiring wallet alive until this could have expired
+	s.noteWalletAssigned(wallet.PublicKey())
+
 	// Use provided blockhash (should be from cache)
 	// Caller should fetch from /recent-blockhash endpoint to benefit from caching
 	blockhash := req.Blockhash
@@ -75,6 +87,21 @@ func (s *SolanaVerifier) BuildGaslessTransaction(ctx context.Context, req Gasles
 		return GaslessTxResponse{}, fmt.Errorf("derive user token account: %w", err)
 	}
 
+	// Price the transaction against recent fees for the recipient's token account, which every
+	// payment writes to; the configured price is kept if the estimate can't be fetched
+	if s.priorityFees != nil {
+		price, err := s.priorityFees.Estimate(ctx, []solana.PublicKey{req.RecipientTokenAccount})
+		if err != nil {
+			log := logger.FromContext(ctx)
+			log.Warn().
+				Err(err).
+				Uint64("fallback_micro_lamports", req.ComputeUnitPrice).
+				Msg("gasless.priority_fee_estimate_failed")
+		} else {
+			req.ComputeUnitPrice = price
+		}
+	}
+
 	// Build instructions in order:
 	// 1. Compute unit limit
 	// 2. Compute unit price (priority fee)
@@ -9,16 +9,34 @@ import (
 
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
+	"github.com/CedrosPay/server/internal/tracing"
 	"github.com/CedrosPay/server/pkg/x402"
 )
 
+// finalStatusCheckTimeout bounds the last status check made after the confirmation deadline.
+const finalStatusCheckTimeout = 5 * time.Second
+
 // awaitConfirmation waits for transaction confirmation using WebSocket (fast) or RPC polling (fallback).
-func (s *SolanaVerifier) awaitConfirmation(ctx context.Context, signature solana.Signature, commitment rpc.CommitmentType) error {
-	// Try WebSocket first (faster)
-	err := s.awaitConfirmationViaWebSocket(ctx, signature, commitment)
-	if err == nil {
-		return nil
+func (s *SolanaVerifier) awaitConfirmation(ctx context.Context, signature solana.Signature, commitment rpc.CommitmentType) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "solana.confirm", trace.WithAttributes(
+		attribute.String("cedros.signature", signature.String()),
+		attribute.String("solana.commitment", string(commitment)),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	// Try WebSocket first (faster) unless it is down and reconnecting
+	if client := s.ws.Client(); client != nil {
+		err = s.awaitConfirmationViaWebSocket(ctx, client, signature, commitment)
+		if err == nil {
+			return nil
+		}
+		span.AddEvent("websocket confirmation failed, polling rpc")
+	} else {
+		span.AddEvent("websocket unavailable, polling rpc")
 	}
 
 	// WebSocket failed - fall back to RPC polling to check if transaction actually succeeded
@@ -28,15 +46,20 @@ func (s *SolanaVerifier) awaitConfirmation(ctx context.Context, signature solana
 }
 
 // awaitConfirmationViaWebSocket uses WebSocket subscription for fast confirmation.
-func (s *SolanaVerifier) awaitConfirmationViaWebSocket(ctx context.Context, signature solana.Signature, commitment rpc.CommitmentType) error {
-	sub, err := s.wsClient.SignatureSubscribe(signature, commitment)
+// A subscription failure not caused by ctx means the socket dropped, which starts a reconnect.
+func (s *SolanaVerifier) awaitConfirmationViaWebSocket(ctx context.Context, client *ws.Client, signature solana.Signature, commitment rpc.CommitmentType) error {
+	sub, err := client.SignatureSubscribe(signature, commitment)
 	if err != nil {
+		s.ws.markBroken(client, err)
 		return fmt.Errorf("x402 solana: subscribe signature: %w", err)
 	}
 	defer sub.Unsubscribe()
 
 	res, err := sub.Recv(ctx)
 	if err != nil {
+		if ctx.Err() == nil {
+			s.ws.markBroken(client, err)
+		}
 		return fmt.Errorf("x402 solana: wait confirmation: %w", err)
 	}
 	if res == nil {
@@ -62,8 +85,10 @@ func (s *SolanaVerifier) awaitConfirmationViaRPC(ctx context.Context, signature
 	for {
 		select {
 		case <-ctx.Done():
-			// Context timeout - but still check one last time
-			return s.checkTransactionStatus(ctx, signature, commitment)
+			// Context timeout - but still check one last time, on a context that is not done
+			finalCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), finalStatusCheckTimeout)
+			defer cancel()
+			return s.checkTransactionStatus(finalCtx, signature, commitment)
 		case <-ticker.C:
 			// Check if blockhash has expired
 			if time.Now().After(maxValidTime) {
@@ -0,0 +1,122 @@
+package solana
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/memo"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+const (
+	// nonceAccountSize is the size of a nonce account: version, state, authority, nonce, and fee calculator.
+	nonceAccountSize = 4 + 4 + 32 + 32 + 8
+
+	// nonceStateInitialized is the state of a nonce account that holds a usable nonce.
+	nonceStateInitialized = 1
+)
+
+// DurableTransferRequest contains the parameters for an SPL transfer built against a durable
+// nonce instead of a recent blockhash.
+type DurableTransferRequest struct {
+	NonceAccount          solana.PublicKey // Durable nonce account
+	Authority             solana.PublicKey // Nonce authority; also the transfer authority and fee payer
+	RecipientTokenAccount solana.PublicKey // Destination token account
+	TokenMint             solana.PublicKey // Token mint address (e.g., USDC)
+	Amount                uint64           // Amount in atomic units
+	Decimals              uint8            // Token decimals (e.g., 6 for USDC)
+	Memo                  string           // Transfer memo
+}
+
+// DurableTransferResponse contains the unsigned durable nonce transaction.
+type DurableTransferResponse struct {
+	Transaction  string `json:"transaction"`  // Base64-encoded unsigned transaction
+	NonceAccount string `json:"nonceAccount"` // Durable nonce account advanced by the transaction
+	Nonce        string `json:"nonce"`        // Nonce used in place of a recent blockhash
+}
+
+// BuildDurableTransfer builds a transfer from the authority's token account that does not
+// expire with a blockhash: it uses the nonce stored in req.NonceAccount and advances it as its
+// first instruction. The transaction is NOT signed; the authority can sign it offline and
+// submit it as the x402 payment at any time until the nonce is advanced by another transaction.
+func (s *SolanaVerifier) BuildDurableTransfer(ctx context.Context, req DurableTransferRequest) (DurableTransferResponse, error) {
+	nonce, err := s.fetchNonce(ctx, req.NonceAccount, req.Authority)
+	if err != nil {
+		return DurableTransferResponse{}, err
+	}
+	fromTokenAccount, _, err := solana.FindAssociatedTokenAddress(req.Authority, req.TokenMint)
+	if err != nil {
+		return DurableTransferResponse{}, fmt.Errorf("derive source token account: %w", err)
+	}
+
+	instructions := []solana.Instruction{
+		// Must come first for the runtime to treat the transaction as a durable nonce transaction
+		system.NewAdvanceNonceAccountInstruction(req.NonceAccount, solana.SysVarRecentBlockHashesPubkey, req.Authority).Build(),
+		token.NewTransferCheckedInstruction(
+			req.Amount,
+			req.Decimals,
+			fromTokenAccount,
+			req.TokenMint,
+			req.RecipientTokenAccount,
+			req.Authority,
+			[]solana.PublicKey{},
+		).Build(),
+	}
+	if req.Memo != "" {
+		instructions = append(instructions, memo.NewMemoInstruction([]byte(req.Memo), req.Authority).Build())
+	}
+
+	tx, err := solana.NewTransaction(instructions, nonce, solana.TransactionPayer(req.Authority))
+	if err != nil {
+		return DurableTransferResponse{}, fmt.Errorf("build transaction: %w", err)
+	}
+	txBytes, err := tx.MarshalBinary()
+	if err != nil {
+		return DurableTransferResponse{}, fmt.Errorf("serialize transaction: %w", err)
+	}
+
+	return DurableTransferResponse{
+		Transaction:  base64.StdEncoding.EncodeToString(txBytes),
+		NonceAccount: req.NonceAccount.String(),
+		Nonce:        nonce.String(),
+	}, nil
+}
+
+// fetchNonce returns the nonce currently stored in account, checking that it is an initialized
+// nonce account controlled by authority.
+func (s *SolanaVerifier) fetchNonce(ctx context.Context, account, authority solana.PublicKey) (solana.Hash, error) {
+	start := time.Now()
+	info, err := s.rpcClient.GetAccountInfoWithOpts(ctx, account, &rpc.GetAccountInfoOpts{
+		Commitment: rpc.CommitmentFinalized,
+	})
+	if s.metrics != nil {
+		s.metrics.ObserveRPCCall("GetAccountInfo", s.network, time.Since(start), err)
+	}
+	if err != nil {
+		return solana.Hash{}, fmt.Errorf("fetch nonce account %s: %w", account, err)
+	}
+	if info == nil || info.Value == nil {
+		return solana.Hash{}, fmt.Errorf("nonce account %s not found", account)
+	}
+	if !info.Value.Owner.Equals(solana.SystemProgramID) {
+		return solana.Hash{}, fmt.Errorf("account %s is not a nonce account", account)
+	}
+
+	data := info.Value.Data.GetBinary()
+	if len(data) != nonceAccountSize {
+		return solana.Hash{}, fmt.Errorf("account %s is not a nonce account", account)
+	}
+	if state := binary.LittleEndian.Uint32(data[4:8]); state != nonceStateInitialized {
+		return solana.Hash{}, fmt.Errorf("nonce account %s is not initialized", account)
+	}
+	if nonceAuthority := solana.PublicKeyFromBytes(data[8:40]); !nonceAuthority.Equals(authority) {
+		return solana.Hash{}, fmt.Errorf("nonce account %s is controlled by %s, not %s", account, nonceAuthority, authority)
+	}
+	return solana.HashFromBytes(data[40:72]), nil
+}
@@ -0,0 +1,115 @@
+package solana
+
+import (
+	"context"
+	"encoding/binary"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+
+	"github.com/CedrosPay/server/pkg/x402"
+)
+
+// nonceAccountData encodes a nonce account in the given state.
+func nonceAccountData(state uint32, authority solana.PublicKey, nonce solana.Hash) []byte {
+	data := make([]byte, nonceAccountSize)
+	binary.LittleEndian.PutUint32(data[0:], 1) // Current version
+	binary.LittleEndian.PutUint32(data[4:], state)
+	copy(data[8:], authority[:])
+	copy(data[40:], nonce[:])
+	binary.LittleEndian.PutUint64(data[72:], 5000)
+	return data
+}
+
+func TestBuildDurableTransfer(t *testing.T) {
+	authority := solana.NewWallet().PublicKey()
+	nonceAccount := solana.NewWallet().PublicKey()
+	mint := solana.NewWallet().PublicKey()
+	destination := solana.NewWallet().PublicKey()
+	nonce := solana.Hash{7}
+	req := DurableTransferRequest{
+		NonceAccount:          nonceAccount,
+		Authority:             authority,
+		RecipientTokenAccount: destination,
+		TokenMint:             mint,
+		Amount:                2500000,
+		Decimals:              6,
+		Memo:                  "cedros:refund:refund_123",
+	}
+
+	tests := []struct {
+		name    string
+		owner   solana.PublicKey
+		data    []byte
+		wantErr bool
+	}{
+		{name: "initialized nonce account", owner: solana.SystemProgramID, data: nonceAccountData(nonceStateInitialized, authority, nonce)},
+		{name: "uninitialized", owner: solana.SystemProgramID, data: nonceAccountData(0, authority, nonce), wantErr: true},
+		{name: "different authority", owner: solana.SystemProgramID, data: nonceAccountData(nonceStateInitialized, solana.NewWallet().PublicKey(), nonce), wantErr: true},
+		{name: "not a system account", owner: solana.TokenProgramID, data: nonceAccountData(nonceStateInitialized, authority, nonce), wantErr: true},
+		{name: "wrong size", owner: solana.SystemProgramID, data: make([]byte, 10), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			v := &SolanaVerifier{rpcClient: lookupTableRPC(t, tt.owner, tt.data, &calls)}
+			resp, err := v.BuildDurableTransfer(context.Background(), req)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BuildDurableTransfer: %v", err)
+			}
+			if resp.Nonce != nonce.String() || resp.NonceAccount != nonceAccount.String() {
+				t.Errorf("response = %+v, want nonce %s of %s", resp, nonce, nonceAccount)
+			}
+
+			tx, err := solana.TransactionFromBase64(resp.Transaction)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tx.Message.RecentBlockhash != nonce {
+				t.Errorf("blockhash = %s, want the nonce %s", tx.Message.RecentBlockhash, nonce)
+			}
+			if !tx.Message.AccountKeys[0].Equals(authority) {
+				t.Errorf("fee payer = %s, want the authority", tx.Message.AccountKeys[0])
+			}
+
+			// The runtime only honours the nonce when advancing it is the first instruction
+			first := tx.Message.Instructions[0]
+			accounts, err := first.ResolveInstructionAccounts(&tx.Message)
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := system.DecodeInstruction(accounts, first.Data)
+			if err != nil {
+				t.Fatalf("first instruction is not a system instruction: %v", err)
+			}
+			advance, ok := decoded.Impl.(*system.AdvanceNonceAccount)
+			if !ok {
+				t.Fatalf("first instruction = %T, want AdvanceNonceAccount", decoded.Impl)
+			}
+			if !advance.GetNonceAccount().PublicKey.Equals(nonceAccount) {
+				t.Errorf("advanced nonce account = %s, want %s", advance.GetNonceAccount().PublicKey, nonceAccount)
+			}
+
+			// The transfer passes the same validation as any refund payment
+			amount, owner, err := validateTransferInstructionAndExtractAuthority(tx, x402.Requirement{
+				RecipientTokenAccount: destination.String(),
+				TokenMint:             mint.String(),
+				TokenDecimals:         6,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if amount != 2.5 || !owner.Equals(authority) {
+				t.Errorf("transfer = %v from %s, want 2.5 from the authority", amount, owner)
+			}
+		})
+	}
+}
@@ -2,10 +2,12 @@ package solana
 
 import (
 	"context"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/CedrosPay/server/internal/logger"
+	solanaHelpers "github.com/CedrosPay/server/internal/solana"
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
 	"github.com/rs/zerolog"
@@ -46,7 +48,7 @@ type WalletHealth struct {
 type WalletHealthChecker struct {
 	mu         sync.RWMutex
 	rpcClient  *rpc.Client
-	wallets    []solana.PrivateKey
+	wallets    []solanaHelpers.Signer
 	health     map[string]*WalletHealth // pubkey string -> health
 	ctx        context.Context
 	cancel     context.CancelFunc
@@ -56,7 +58,7 @@ type WalletHealthChecker struct {
 }
 
 // NewWalletHealthChecker creates a new health checker.
-func NewWalletHealthChecker(rpcClient *rpc.Client, wallets []solana.PrivateKey) *WalletHealthChecker {
+func NewWalletHealthChecker(rpcClient *rpc.Client, wallets []solanaHelpers.Signer) *WalletHealthChecker {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	// Create logger with task context
@@ -138,13 +140,40 @@ func (w *WalletHealthChecker) healthCheckLoop() {
 
 // CheckAll checks the balance of all wallets and updates their health status.
 func (w *WalletHealthChecker) CheckAll() {
-	for _, wallet := range w.wallets {
+	w.mu.RLock()
+	wallets := append([]solanaHelpers.Signer(nil), w.wallets...)
+	w.mu.RUnlock()
+
+	for _, wallet := range wallets {
 		w.checkWallet(wallet)
 	}
 }
 
+// AddWallet starts monitoring wallet, checking its balance immediately so it can be
+// selected as soon as it is funded.
+func (w *WalletHealthChecker) AddWallet(wallet solanaHelpers.Signer) {
+	pubkey := wallet.PublicKey()
+	w.mu.Lock()
+	w.wallets = append(w.wallets, wallet)
+	w.health[pubkey.String()] = &WalletHealth{PublicKey: pubkey, IsCritical: true}
+	w.mu.Unlock()
+
+	w.checkWallet(wallet)
+}
+
+// RemoveWallet stops monitoring the wallet and excludes it from selection.
+func (w *WalletHealthChecker) RemoveWallet(pubkey solana.PublicKey) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.wallets = slices.DeleteFunc(w.wallets, func(wallet solanaHelpers.Signer) bool {
+		return wallet.PublicKey().Equals(pubkey)
+	})
+	delete(w.health, pubkey.String())
+}
+
 // checkWallet checks a single wallet's balance and updates its health.
-func (w *WalletHealthChecker) checkWallet(wallet solana.PrivateKey) {
+func (w *WalletHealthChecker) checkWallet(wallet solanaHelpers.Signer) {
 	ctx, cancel := context.WithTimeout(w.ctx, HealthCheckTimeout)
 	defer cancel()
 
@@ -178,9 +207,8 @@ func (w *WalletHealthChecker) checkWallet(wallet solana.PrivateKey) {
 
 	health, ok := w.health[pubkeyStr]
 	if !ok {
-		// Shouldn't happen, but handle gracefully
-		health = &WalletHealth{PublicKey: pubkey}
-		w.health[pubkeyStr] = health
+		// Removed while its balance was being fetched
+		return
 	}
 
 	// Track previous state for change detection
@@ -242,7 +270,7 @@ func (w *WalletHealthChecker) checkWallet(wallet solana.PrivateKey) {
 
 // GetHealthyWallet returns the next healthy wallet using round-robin selection.
 // Returns nil if no healthy wallets are available.
-func (w *WalletHealthChecker) GetHealthyWallet(currentIndex *uint64) *solana.PrivateKey {
+func (w *WalletHealthChecker) GetHealthyWallet(currentIndex *uint64) solanaHelpers.Signer {
 	w.mu.RLock()
 	defer w.mu.RUnlock()
 
@@ -261,7 +289,7 @@ func (w *WalletHealthChecker) GetHealthyWallet(currentIndex *uint64) *solana.Pri
 		if health, ok := w.health[pubkeyStr]; ok && health.IsHealthy {
 			// Update index for next call
 			*currentIndex = uint64(idx + 1)
-			return &wallet
+			return wallet
 		}
 	}
 
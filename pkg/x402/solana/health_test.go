@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/gagliardetto/solana-go"
+
+	solanaHelpers "github.com/CedrosPay/server/internal/solana"
 )
 
 func TestWalletHealthChecker_HealthyWalletSelection(t *testing.T) {
@@ -12,10 +14,10 @@ func TestWalletHealthChecker_HealthyWalletSelection(t *testing.T) {
 	wallet2 := solana.NewWallet()
 	wallet3 := solana.NewWallet()
 
-	wallets := []solana.PrivateKey{
-		wallet1.PrivateKey,
-		wallet2.PrivateKey,
-		wallet3.PrivateKey,
+	wallets := []solanaHelpers.Signer{
+		solanaHelpers.NewLocalSigner(wallet1.PrivateKey),
+		solanaHelpers.NewLocalSigner(wallet2.PrivateKey),
+		solanaHelpers.NewLocalSigner(wallet3.PrivateKey),
 	}
 
 	// Create checker (without RPC, we'll manually set health)
@@ -102,9 +104,9 @@ func TestWalletHealthChecker_AllUnhealthy(t *testing.T) {
 	wallet1 := solana.NewWallet()
 	wallet2 := solana.NewWallet()
 
-	wallets := []solana.PrivateKey{
-		wallet1.PrivateKey,
-		wallet2.PrivateKey,
+	wallets := []solanaHelpers.Signer{
+		solanaHelpers.NewLocalSigner(wallet1.PrivateKey),
+		solanaHelpers.NewLocalSigner(wallet2.PrivateKey),
 	}
 
 	checker := &WalletHealthChecker{
@@ -141,11 +143,11 @@ func TestWalletHealthChecker_Summary(t *testing.T) {
 	wallet3 := solana.NewWallet()
 	wallet4 := solana.NewWallet()
 
-	wallets := []solana.PrivateKey{
-		wallet1.PrivateKey,
-		wallet2.PrivateKey,
-		wallet3.PrivateKey,
-		wallet4.PrivateKey,
+	wallets := []solanaHelpers.Signer{
+		solanaHelpers.NewLocalSigner(wallet1.PrivateKey),
+		solanaHelpers.NewLocalSigner(wallet2.PrivateKey),
+		solanaHelpers.NewLocalSigner(wallet3.PrivateKey),
+		solanaHelpers.NewLocalSigner(wallet4.PrivateKey),
 	}
 
 	checker := &WalletHealthChecker{
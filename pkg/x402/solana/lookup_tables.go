@@ -0,0 +1,100 @@
+package solana
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	addresslookuptable "github.com/gagliardetto/solana-go/programs/address-lookup-table"
+)
+
+// maxCachedLookupTables bounds the address lookup table cache.
+const maxCachedLookupTables = 1024
+
+// lookupTableCache holds the addresses of recently seen address lookup tables. Entries in a
+// table are never modified once written (tables can only be extended), so a cached table is
+// valid for every index it already covers.
+type lookupTableCache struct {
+	mu     sync.Mutex
+	tables map[solana.PublicKey]solana.PublicKeySlice
+}
+
+func (c *lookupTableCache) get(table solana.PublicKey) (solana.PublicKeySlice, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	addresses, ok := c.tables[table]
+	return addresses, ok
+}
+
+func (c *lookupTableCache) put(table solana.PublicKey, addresses solana.PublicKeySlice) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.tables == nil || len(c.tables) >= maxCachedLookupTables {
+		c.tables = make(map[solana.PublicKey]solana.PublicKeySlice)
+	}
+	c.tables[table] = addresses
+}
+
+// resolveAddressTables loads the address lookup tables a v0 transaction references so its
+// instructions can be resolved against the full account list. Legacy transactions are left
+// untouched.
+func (s *SolanaVerifier) resolveAddressTables(ctx context.Context, tx *solana.Transaction) error {
+	if !tx.Message.IsVersioned() || tx.Message.NumLookups() == 0 {
+		return nil
+	}
+
+	lookups := tx.Message.GetAddressTableLookups()
+	tables := make(map[solana.PublicKey]solana.PublicKeySlice, len(lookups))
+	for _, lookup := range lookups {
+		if _, ok := tables[lookup.AccountKey]; ok {
+			continue
+		}
+		addresses, err := s.lookupTableAddresses(ctx, lookup)
+		if err != nil {
+			return err
+		}
+		tables[lookup.AccountKey] = addresses
+	}
+	return tx.Message.SetAddressTables(tables)
+}
+
+// lookupTableAddresses returns the addresses stored in the table lookup references, fetching
+// it when the cached copy does not cover every index the lookup uses.
+func (s *SolanaVerifier) lookupTableAddresses(ctx context.Context, lookup solana.MessageAddressTableLookup) (solana.PublicKeySlice, error) {
+	highest := 0
+	for _, indexes := range [][]uint8{lookup.WritableIndexes, lookup.ReadonlyIndexes} {
+		for _, idx := range indexes {
+			highest = max(highest, int(idx))
+		}
+	}
+	if addresses, ok := s.lookupTables.get(lookup.AccountKey); ok && highest < len(addresses) {
+		return addresses, nil
+	}
+
+	start := time.Now()
+	info, err := s.rpcClient.GetAccountInfo(ctx, lookup.AccountKey)
+	if s.metrics != nil {
+		s.metrics.ObserveRPCCall("GetAccountInfo", s.network, time.Since(start), err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetch address lookup table %s: %w", lookup.AccountKey, err)
+	}
+	if info == nil || info.Value == nil {
+		return nil, fmt.Errorf("address lookup table %s not found", lookup.AccountKey)
+	}
+	if !info.Value.Owner.Equals(solana.AddressLookupTableProgramID) {
+		return nil, fmt.Errorf("account %s is not an address lookup table", lookup.AccountKey)
+	}
+
+	state, err := addresslookuptable.DecodeAddressLookupTableState(info.Value.Data.GetBinary())
+	if err != nil {
+		return nil, fmt.Errorf("decode address lookup table %s: %w", lookup.AccountKey, err)
+	}
+	if highest >= len(state.Addresses) {
+		return nil, fmt.Errorf("address lookup table %s has %d addresses, transaction references index %d", lookup.AccountKey, len(state.Addresses), highest)
+	}
+	s.lookupTables.put(lookup.AccountKey, state.Addresses)
+	return state.Addresses, nil
+}
@@ -0,0 +1,136 @@
+package solana
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	addresslookuptable "github.com/gagliardetto/solana-go/programs/address-lookup-table"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/CedrosPay/server/pkg/x402"
+)
+
+// lookupTableData encodes an active address lookup table holding addresses.
+func lookupTableData(addresses ...solana.PublicKey) []byte {
+	data := make([]byte, addresslookuptable.LOOKUP_TABLE_META_SIZE)
+	binary.LittleEndian.PutUint32(data[0:], 1)              // Lookup table account type
+	binary.LittleEndian.PutUint64(data[4:], math.MaxUint64) // Not deactivated
+	for _, address := range addresses {
+		data = append(data, address[:]...)
+	}
+	return data
+}
+
+// lookupTableRPC serves getAccountInfo for a single account with the given owner and data.
+func lookupTableRPC(t *testing.T, owner solana.PublicKey, data []byte, calls *atomic.Int32) *rpc.Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":{"context":{"slot":1},"value":{"lamports":1,"owner":%q,"data":[%q,"base64"],"executable":false,"rentEpoch":0}}}`,
+			owner, base64.StdEncoding.EncodeToString(data))
+	}))
+	t.Cleanup(srv.Close)
+	return rpc.New(srv.URL)
+}
+
+func TestVersionedTransactionWithLookupTable(t *testing.T) {
+	payer := solana.NewWallet()
+	source := solana.NewWallet().PublicKey()
+	mint := solana.NewWallet().PublicKey()
+	destination := solana.NewWallet().PublicKey()
+	table := solana.NewWallet().PublicKey()
+
+	// The wallet moves the mint and destination into a lookup table, so neither is a static key
+	built, err := solana.NewTransaction(
+		[]solana.Instruction{token.NewTransferCheckedInstruction(2500000, 6, source, mint, destination, payer.PublicKey(), nil).Build()},
+		solana.Hash{1},
+		solana.TransactionPayer(payer.PublicKey()),
+		solana.TransactionAddressTables(map[solana.PublicKey]solana.PublicKeySlice{table: {mint, destination}}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := built.Sign(func(key solana.PublicKey) *solana.PrivateKey {
+		if key.Equals(payer.PublicKey()) {
+			return &payer.PrivateKey
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := built.ToBase64()
+	if err != nil {
+		t.Fatal(err)
+	}
+	requirement := x402.Requirement{RecipientTokenAccount: destination.String(), TokenMint: mint.String(), TokenDecimals: 6}
+
+	tests := []struct {
+		name    string
+		owner   solana.PublicKey
+		data    []byte
+		wantErr bool
+	}{
+		{name: "lookup table", owner: solana.AddressLookupTableProgramID, data: lookupTableData(mint, destination)},
+		{name: "not owned by the lookup table program", owner: solana.SystemProgramID, data: lookupTableData(mint, destination), wantErr: true},
+		{name: "index out of range", owner: solana.AddressLookupTableProgramID, data: lookupTableData(mint), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			v := &SolanaVerifier{rpcClient: lookupTableRPC(t, tt.owner, tt.data, &calls)}
+			tx, err := solana.TransactionFromBase64(encoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tx.Message.IsVersioned() {
+				t.Fatal("expected a v0 transaction")
+			}
+
+			err = v.resolveAddressTables(context.Background(), tx)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveAddressTables: %v", err)
+			}
+
+			amount, authority, err := validateTransferInstructionAndExtractAuthority(tx, requirement)
+			if err != nil {
+				t.Fatalf("validate transfer: %v", err)
+			}
+			if amount != 2.5 || !authority.Equals(payer.PublicKey()) {
+				t.Errorf("got (%v, %s), want (2.5, %s)", amount, authority, payer.PublicKey())
+			}
+
+			// Co-signing re-serializes the message; it must stay a v0 message with the same signature
+			if err := tx.VerifySignatures(); err != nil {
+				t.Errorf("signatures no longer verify: %v", err)
+			}
+			if reencoded, err := tx.ToBase64(); err != nil || reencoded != encoded {
+				t.Errorf("re-encoded transaction differs (err=%v)", err)
+			}
+
+			// Tables are cached
+			again, _ := solana.TransactionFromBase64(encoded)
+			if err := v.resolveAddressTables(context.Background(), again); err != nil {
+				t.Fatal(err)
+			}
+			if calls.Load() != 1 {
+				t.Errorf("RPC calls = %d, want 1", calls.Load())
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package solana
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	apierrors "github.com/CedrosPay/server/internal/errors"
+)
+
+// tokenAccountAmountOffset is where the balance sits in an SPL token account.
+const tokenAccountAmountOffset = 64
+
+// PayerCheckRequest describes the payment a wallet is about to make.
+type PayerCheckRequest struct {
+	Wallet           solana.PublicKey // Payer's wallet
+	TokenMint        solana.PublicKey // Token mint address (e.g., USDC)
+	Amount           uint64           // Amount in atomic units
+	Gasless          bool             // Server wallet pays the network fees
+	ComputeUnitLimit uint32           // Compute units the payment transaction requests
+	ComputeUnitPrice uint64           // Priority fee in microlamports per compute unit
+}
+
+// PayerCheck reports whether a wallet can make a payment, and what is missing if not.
+type PayerCheck struct {
+	TokenAccount       string         `json:"tokenAccount"`       // Payer's associated token account
+	TokenAccountExists bool           `json:"tokenAccountExists"` // Whether the token account exists
+	TokenBalance       uint64         `json:"tokenBalance"`       // Token balance in atomic units
+	RequiredAmount     uint64         `json:"requiredAmount"`     // Token amount the payment needs, in atomic units
+	SolBalance         uint64         `json:"solBalance"`         // Wallet balance in lamports
+	RequiredLamports   uint64         `json:"requiredLamports"`   // Lamports the payer needs for network fees (0 when gasless)
+	Gasless            bool           `json:"gasless"`            // Whether a server wallet pays the network fees
+	Ready              bool           `json:"ready"`              // Whether the payment should go through
+	Issues             []PayerProblem `json:"issues,omitempty"`   // What stops the payment, if anything
+}
+
+// PayerProblem is one reason a payment would fail, using the error code the payment would fail with.
+type PayerProblem struct {
+	Code    apierrors.ErrorCode `json:"code"`
+	Message string              `json:"message"`
+}
+
+// CheckPayer reads the payer's SOL balance and token account in one call and reports whether
+// the payment described by req would go through.
+func (s *SolanaVerifier) CheckPayer(ctx context.Context, req PayerCheckRequest) (PayerCheck, error) {
+	tokenAccount, _, err := solana.FindAssociatedTokenAddress(req.Wallet, req.TokenMint)
+	if err != nil {
+		return PayerCheck{}, fmt.Errorf("derive token account: %w", err)
+	}
+
+	start := time.Now()
+	resp, err := s.rpcClient.GetMultipleAccountsWithOpts(ctx, []solana.PublicKey{req.Wallet, tokenAccount}, &rpc.GetMultipleAccountsOpts{
+		Commitment: rpc.CommitmentConfirmed,
+	})
+	if s.metrics != nil {
+		s.metrics.ObserveRPCCall("GetMultipleAccounts", s.network, time.Since(start), err)
+	}
+	if err != nil {
+		return PayerCheck{}, fmt.Errorf("fetch payer accounts: %w", err)
+	}
+	if resp == nil || len(resp.Value) != 2 {
+		return PayerCheck{}, fmt.Errorf("fetch payer accounts: unexpected response")
+	}
+
+	check := PayerCheck{
+		TokenAccount:   tokenAccount.String(),
+		RequiredAmount: req.Amount,
+		Gasless:        req.Gasless,
+	}
+	if wallet := resp.Value[0]; wallet != nil {
+		check.SolBalance = wallet.Lamports
+	}
+	if account := resp.Value[1]; account != nil {
+		data := account.Data.GetBinary()
+		if len(data) < tokenAccountAmountOffset+8 {
+			return PayerCheck{}, fmt.Errorf("token account %s is malformed", tokenAccount)
+		}
+		check.TokenAccountExists = true
+		check.TokenBalance = binary.LittleEndian.Uint64(data[tokenAccountAmountOffset:])
+	}
+	if !req.Gasless {
+		// Price is in micro-lamports per compute unit; Solana rounds the total up
+		priorityFee := (uint64(req.ComputeUnitLimit)*req.ComputeUnitPrice + 999999) / 1000000
+		check.RequiredLamports = lamportsPerSignature + priorityFee
+	}
+
+	switch {
+	case !check.TokenAccountExists:
+		check.Issues = append(check.Issues, PayerProblem{
+			Code:    apierrors.ErrCodeMissingTokenAccount,
+			Message: "wallet has no token account for this token; fund it with the token first",
+		})
+	case check.TokenBalance < req.Amount:
+		check.Issues = append(check.Issues, PayerProblem{
+			Code:    apierrors.ErrCodeInsufficientFundsToken,
+			Message: fmt.Sprintf("token balance %d is below the required %d", check.TokenBalance, req.Amount),
+		})
+	}
+	if check.SolBalance < check.RequiredLamports {
+		check.Issues = append(check.Issues, PayerProblem{
+			Code:    apierrors.ErrCodeInsufficientFunds,
+			Message: fmt.Sprintf("SOL balance %d lamports is below the %d lamports needed for network fees", check.SolBalance, check.RequiredLamports),
+		})
+	}
+	check.Ready = len(check.Issues) == 0
+	return check, nil
+}
@@ -0,0 +1,86 @@
+package solana
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	apierrors "github.com/CedrosPay/server/internal/errors"
+)
+
+// payerRPC answers getMultipleAccounts with a wallet holding lamports and, unless tokenBalance
+// is negative, a token account holding tokenBalance.
+func payerRPC(t *testing.T, lamports uint64, tokenBalance int64) *rpc.Client {
+	t.Helper()
+	walletJSON := fmt.Sprintf(`{"lamports":%d,"owner":%q,"data":["","base64"],"executable":false,"rentEpoch":0}`, lamports, solana.SystemProgramID)
+	tokenJSON := "null"
+	if tokenBalance >= 0 {
+		data := make([]byte, 165)
+		binary.LittleEndian.PutUint64(data[tokenAccountAmountOffset:], uint64(tokenBalance))
+		tokenJSON = fmt.Sprintf(`{"lamports":2039280,"owner":%q,"data":[%q,"base64"],"executable":false,"rentEpoch":0}`, solana.TokenProgramID, base64.StdEncoding.EncodeToString(data))
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":{"context":{"slot":1},"value":[%s,%s]}}`, walletJSON, tokenJSON)
+	}))
+	t.Cleanup(srv.Close)
+	return rpc.New(srv.URL)
+}
+
+func TestCheckPayer(t *testing.T) {
+	tests := []struct {
+		name         string
+		lamports     uint64
+		tokenBalance int64 // Negative: no token account
+		gasless      bool
+		wantCodes    []apierrors.ErrorCode
+	}{
+		{name: "ready", lamports: 1000000, tokenBalance: 5000000},
+		{name: "no token account", lamports: 1000000, tokenBalance: -1, wantCodes: []apierrors.ErrorCode{apierrors.ErrCodeMissingTokenAccount}},
+		{name: "low token balance", lamports: 1000000, tokenBalance: 999999, wantCodes: []apierrors.ErrorCode{apierrors.ErrCodeInsufficientFundsToken}},
+		{name: "no SOL for fees", lamports: 5000, tokenBalance: 5000000, wantCodes: []apierrors.ErrorCode{apierrors.ErrCodeInsufficientFunds}},
+		{name: "no SOL but gasless", lamports: 0, tokenBalance: 5000000, gasless: true},
+		{name: "nothing", lamports: 0, tokenBalance: -1, wantCodes: []apierrors.ErrorCode{apierrors.ErrCodeMissingTokenAccount, apierrors.ErrCodeInsufficientFunds}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &SolanaVerifier{rpcClient: payerRPC(t, tt.lamports, tt.tokenBalance)}
+			check, err := v.CheckPayer(context.Background(), PayerCheckRequest{
+				Wallet:           solana.NewWallet().PublicKey(),
+				TokenMint:        solana.NewWallet().PublicKey(),
+				Amount:           1000000,
+				Gasless:          tt.gasless,
+				ComputeUnitLimit: 200000,
+				ComputeUnitPrice: 1000, // 200 lamports of priority fee on top of the 5000 base fee
+			})
+			if err != nil {
+				t.Fatalf("CheckPayer: %v", err)
+			}
+
+			var codes []apierrors.ErrorCode
+			for _, issue := range check.Issues {
+				codes = append(codes, issue.Code)
+			}
+			if fmt.Sprint(codes) != fmt.Sprint(tt.wantCodes) {
+				t.Errorf("issues = %v, want %v", codes, tt.wantCodes)
+			}
+			if check.Ready != (len(tt.wantCodes) == 0) {
+				t.Errorf("ready = %v with issues %v", check.Ready, codes)
+			}
+			wantLamports := uint64(5200)
+			if tt.gasless {
+				wantLamports = 0
+			}
+			if check.RequiredLamports != wantLamports {
+				t.Errorf("required lamports = %d, want %d", check.RequiredLamports, wantLamports)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package solana
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/CedrosPay/server/internal/metrics"
+)
+
+const (
+	// congestedRatio is the share of recent slots with prioritization fees above which the
+	// network is treated as congested.
+	congestedRatio = 0.5
+
+	// congestedPercentile is the fee percentile paid while the network is congested.
+	congestedPercentile = 90
+)
+
+// PriorityFeeOptions configures a PriorityFeeEstimator.
+type PriorityFeeOptions struct {
+	Percentile       int           // Percentile of recent prioritization fees to pay (1-100)
+	MinMicroLamports uint64        // Lower bound on the estimate
+	MaxMicroLamports uint64        // Upper bound on the estimate
+	RefreshInterval  time.Duration // How long an estimate is reused
+}
+
+// PriorityFeeEstimator picks a compute unit price from the prioritization fees recently paid
+// by transactions that wrote to the same accounts. When more than half of recent slots carried
+// fees, the network is considered congested and the 90th percentile is paid instead.
+type PriorityFeeEstimator struct {
+	rpcClient *rpc.Client
+	opts      PriorityFeeOptions
+	clock     func() time.Time
+	metrics   *metrics.Metrics
+	network   string
+
+	mu    sync.Mutex
+	cache map[string]priorityFeeEstimate // Keyed by the sorted account list
+}
+
+type priorityFeeEstimate struct {
+	microLamports uint64
+	congestion    float64
+	at            time.Time
+}
+
+// NewPriorityFeeEstimator creates an estimator that queries rpcClient.
+func NewPriorityFeeEstimator(rpcClient *rpc.Client, opts PriorityFeeOptions) *PriorityFeeEstimator {
+	return &PriorityFeeEstimator{
+		rpcClient: rpcClient,
+		opts:      opts,
+		clock:     time.Now,
+		cache:     make(map[string]priorityFeeEstimate),
+	}
+}
+
+// WithMetrics records each new estimate and the observed congestion.
+func (e *PriorityFeeEstimator) WithMetrics(m *metrics.Metrics, network string) *PriorityFeeEstimator {
+	e.metrics = m
+	e.network = network
+	return e
+}
+
+// Estimate returns the compute unit price, in micro-lamports, for a transaction that writes
+// to accounts. Estimates are cached for RefreshInterval.
+func (e *PriorityFeeEstimator) Estimate(ctx context.Context, accounts []solana.PublicKey) (uint64, error) {
+	keys := make([]string, len(accounts))
+	for i, account := range accounts {
+		keys[i] = account.String()
+	}
+	slices.Sort(keys)
+	cacheKey := strings.Join(keys, ",")
+
+	now := e.clock()
+	e.mu.Lock()
+	cached, ok := e.cache[cacheKey]
+	e.mu.Unlock()
+	if ok && now.Sub(cached.at) < e.opts.RefreshInterval {
+		return cached.microLamports, nil
+	}
+
+	start := time.Now()
+	fees, err := e.rpcClient.GetRecentPrioritizationFees(ctx, accounts)
+	if e.metrics != nil {
+		e.metrics.ObserveRPCCall("GetRecentPrioritizationFees", e.network, time.Since(start), err)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("get recent prioritization fees: %w", err)
+	}
+
+	price, congestion := priorityFeeFromSamples(fees, e.opts.Percentile)
+	price = min(max(price, e.opts.MinMicroLamports), e.opts.MaxMicroLamports)
+
+	e.mu.Lock()
+	e.cache[cacheKey] = priorityFeeEstimate{microLamports: price, congestion: congestion, at: now}
+	e.mu.Unlock()
+	if e.metrics != nil {
+		e.metrics.ObservePriorityFeeEstimate(e.network, price, congestion)
+	}
+	return price, nil
+}
+
+// priorityFeeFromSamples returns the fee at percentile across recent slots, and the share of
+// slots that carried a fee. Under congestion the higher of percentile and congestedPercentile
+// is used.
+func priorityFeeFromSamples(samples []rpc.PriorizationFeeResult, percentile int) (uint64, float64) {
+	if len(samples) == 0 {
+		return 0, 0
+	}
+
+	fees := make([]uint64, len(samples))
+	withFee := 0
+	for i, sample := range samples {
+		fees[i] = sample.PrioritizationFee
+		if sample.PrioritizationFee > 0 {
+			withFee++
+		}
+	}
+	slices.Sort(fees)
+
+	congestion := float64(withFee) / float64(len(samples))
+	if congestion > congestedRatio {
+		percentile = max(percentile, congestedPercentile)
+	}
+
+	// Nearest-rank percentile
+	rank := (percentile*len(fees) + 99) / 100
+	return fees[max(rank, 1)-1], congestion
+}
+
+// computeUnitPrice returns the compute unit price tx sets, in micro-lamports.
+func computeUnitPrice(tx *solana.Transaction) uint64 {
+	_, price, _ := computeBudget(tx)
+	return price
+}
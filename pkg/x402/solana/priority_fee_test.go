@@ -0,0 +1,102 @@
+package solana
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+func feeSamples(fees ...uint64) []rpc.PriorizationFeeResult {
+	samples := make([]rpc.PriorizationFeeResult, len(fees))
+	for i, fee := range fees {
+		samples[i] = rpc.PriorizationFeeResult{Slot: uint64(i + 1), PrioritizationFee: fee}
+	}
+	return samples
+}
+
+func TestPriorityFeeFromSamples(t *testing.T) {
+	tests := []struct {
+		name           string
+		samples        []rpc.PriorizationFeeResult
+		percentile     int
+		wantFee        uint64
+		wantCongestion float64
+	}{
+		{name: "no samples", samples: nil, percentile: 75, wantFee: 0, wantCongestion: 0},
+		{name: "quiet network", samples: feeSamples(0, 0, 0, 0, 0, 0, 100, 200), percentile: 75, wantFee: 0, wantCongestion: 0.25},
+		{name: "75th percentile", samples: feeSamples(0, 0, 0, 0, 0, 10, 20, 30), percentile: 75, wantFee: 10, wantCongestion: 0.375},
+		{name: "congested uses 90th", samples: feeSamples(10, 20, 30, 40, 50, 60, 70, 80, 90, 100), percentile: 50, wantFee: 90, wantCongestion: 1},
+		{name: "configured above congested percentile", samples: feeSamples(10, 20, 30, 40, 50, 60, 70, 80, 90, 100), percentile: 100, wantFee: 100, wantCongestion: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fee, congestion := priorityFeeFromSamples(tt.samples, tt.percentile)
+			if fee != tt.wantFee || congestion != tt.wantCongestion {
+				t.Errorf("got (%d, %v), want (%d, %v)", fee, congestion, tt.wantFee, tt.wantCongestion)
+			}
+		})
+	}
+}
+
+func TestPriorityFeeEstimator(t *testing.T) {
+	var (
+		calls atomic.Int32
+		fee   atomic.Uint64
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		samples := make([]string, 4)
+		for i := range samples {
+			samples[i] = fmt.Sprintf(`{"slot":%d,"prioritizationFee":%d}`, i+1, fee.Load())
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":[%s]}`, strings.Join(samples, ","))
+	}))
+	defer srv.Close()
+
+	now := time.Unix(1700000000, 0)
+	estimator := NewPriorityFeeEstimator(rpc.New(srv.URL), PriorityFeeOptions{
+		Percentile:       75,
+		MinMicroLamports: 10,
+		MaxMicroLamports: 5000,
+		RefreshInterval:  10 * time.Second,
+	})
+	estimator.clock = func() time.Time { return now }
+	account := solana.NewWallet().PublicKey()
+	ctx := context.Background()
+
+	estimate := func(want uint64) {
+		t.Helper()
+		got, err := estimator.Estimate(ctx, []solana.PublicKey{account})
+		if err != nil {
+			t.Fatalf("Estimate: %v", err)
+		}
+		if got != want {
+			t.Errorf("Estimate = %d, want %d", got, want)
+		}
+	}
+
+	fee.Store(1000)
+	estimate(1000)
+
+	fee.Store(100000)
+	estimate(1000) // Cached within the refresh interval
+	if calls.Load() != 1 {
+		t.Errorf("RPC calls = %d, want 1", calls.Load())
+	}
+
+	now = now.Add(10 * time.Second)
+	estimate(5000) // Clamped to the maximum
+
+	fee.Store(0)
+	now = now.Add(10 * time.Second)
+	estimate(10) // Raised to the minimum
+}
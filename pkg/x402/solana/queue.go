@@ -284,6 +284,29 @@ func isRateLimitError(err error) bool {
 		strings.Contains(msg, "throttle")
 }
 
+// Drain waits until every queued transaction has been sent and confirmed (or has failed),
+// or until ctx is done. Callers should stop enqueueing first and call Shutdown afterwards.
+func (q *TransactionQueue) Drain(ctx context.Context) error {
+	ticker := time.NewTicker(QueuePollInterval)
+	defer ticker.Stop()
+
+	for {
+		q.mu.Lock()
+		pending := q.queue.Len() + q.inFlight
+		q.mu.Unlock()
+		if pending == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Warn().Int("pending", pending).Msg("transaction_queue.drain_timeout")
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
 // Shutdown stops the queue.
 func (q *TransactionQueue) Shutdown() {
 	log.Info().Msg("transaction_queue.shutting_down")
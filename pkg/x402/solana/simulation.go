@@ -0,0 +1,153 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	apierrors "github.com/CedrosPay/server/internal/errors"
+	"github.com/CedrosPay/server/internal/logger"
+)
+
+// SPL Token program custom error codes surfaced during simulation.
+const (
+	tokenErrInsufficientFunds = 1
+	tokenErrMintMismatch      = 3
+)
+
+// simulateTransaction runs tx against current chain state before it is broadcast and turns
+// program failures (insufficient balance, missing token account, ...) into VerificationErrors.
+// A failed simulation RPC call is not fatal: the send that follows still runs its own preflight.
+func (s *SolanaVerifier) simulateTransaction(ctx context.Context, tx *solana.Transaction, commitment rpc.CommitmentType, gasless bool) error {
+	start := time.Now()
+	resp, err := s.rpcClient.SimulateTransactionWithOpts(ctx, tx, &rpc.SimulateTransactionOpts{
+		Commitment: commitment,
+	})
+	if s.metrics != nil {
+		s.metrics.ObserveRPCCall("SimulateTransaction", s.network, time.Since(start), err)
+	}
+	if err != nil {
+		log := logger.FromContext(ctx)
+		log.Warn().Err(err).Msg("payment.simulation_unavailable")
+		return nil
+	}
+	if resp == nil || resp.Value == nil || resp.Value.Err == nil {
+		return nil
+	}
+
+	code := s.simulationErrorCode(tx, resp.Value.Err, resp.Value.Logs, gasless)
+	if code == "" {
+		return nil
+	}
+	return newVerificationError(code, newSimulationError(resp.Value.Err, resp.Value.Logs))
+}
+
+// simulationErrorCode maps a simulated transaction error to an error code. An empty code means
+// the failure should be left to the send path, which can recover from it.
+func (s *SolanaVerifier) simulationErrorCode(tx *solana.Transaction, txErr any, logs []string, gasless bool) apierrors.ErrorCode {
+	switch txErr {
+	case "AccountNotFound", "InsufficientFundsForFee", "InsufficientFundsForRent":
+		// The fee payer cannot cover the fee. In gasless mode that is the server wallet, not the user
+		if gasless {
+			return apierrors.ErrCodeInternalError
+		}
+		return apierrors.ErrCodeInsufficientFunds
+	case "BlockhashNotFound":
+		return apierrors.ErrCodeTransactionExpired
+	}
+
+	index, detail, ok := instructionError(txErr)
+	if !ok {
+		return apierrors.ErrCodeTransactionFailed
+	}
+	if custom, ok := customProgramError(detail); ok && isTokenInstruction(tx, index) {
+		switch custom {
+		case tokenErrInsufficientFunds:
+			return apierrors.ErrCodeInsufficientFundsToken
+		case tokenErrMintMismatch:
+			return apierrors.ErrCodeInvalidTokenMint
+		}
+	}
+	switch detail {
+	case "InvalidAccountData", "UninitializedAccount", "InvalidAccountOwner":
+		// Destination token account missing; the send path creates it when auto-create is on
+		if s.autoCreateTokenAccounts {
+			return ""
+		}
+		return apierrors.ErrCodeMissingTokenAccount
+	case "InsufficientFunds":
+		return apierrors.ErrCodeInsufficientFundsToken
+	}
+	for _, line := range logs {
+		if strings.Contains(strings.ToLower(line), "error: insufficient funds") {
+			return apierrors.ErrCodeInsufficientFundsToken
+		}
+	}
+	return apierrors.ErrCodeTransactionFailed
+}
+
+// instructionError unpacks {"InstructionError": [index, detail]}.
+func instructionError(txErr any) (int, any, bool) {
+	m, ok := txErr.(map[string]any)
+	if !ok {
+		return 0, nil, false
+	}
+	pair, ok := m["InstructionError"].([]any)
+	if !ok || len(pair) != 2 {
+		return 0, nil, false
+	}
+	index, ok := jsonInt(pair[0])
+	if !ok {
+		return 0, nil, false
+	}
+	return int(index), pair[1], true
+}
+
+// customProgramError unpacks {"Custom": n}.
+func customProgramError(detail any) (int64, bool) {
+	m, ok := detail.(map[string]any)
+	if !ok {
+		return 0, false
+	}
+	return jsonInt(m["Custom"])
+}
+
+// jsonInt converts a decoded JSON number to an integer.
+func jsonInt(v any) (int64, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		i, err := n.Int64()
+		return i, err == nil
+	case float64:
+		return int64(n), true
+	}
+	return 0, false
+}
+
+// isTokenInstruction reports whether the instruction at index calls an SPL Token program.
+func isTokenInstruction(tx *solana.Transaction, index int) bool {
+	if index < 0 || index >= len(tx.Message.Instructions) {
+		return false
+	}
+	programID, err := tx.Message.Program(tx.Message.Instructions[index].ProgramIDIndex)
+	return err == nil && (programID.Equals(solana.TokenProgramID) || programID.Equals(solana.Token2022ProgramID))
+}
+
+// newSimulationError describes a failed simulation for logs, including the last program log line.
+func newSimulationError(txErr any, logs []string) error {
+	detail, _ := json.Marshal(txErr)
+	msg := fmt.Sprintf("simulation failed: %s", detail)
+	for i := len(logs) - 1; i >= 0; i-- {
+		if strings.HasPrefix(logs[i], "Program log: ") {
+			msg += " (" + strings.TrimPrefix(logs[i], "Program log: ") + ")"
+			break
+		}
+	}
+	return errors.New(msg)
+}
@@ -0,0 +1,96 @@
+package solana
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/memo"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	apierrors "github.com/CedrosPay/server/internal/errors"
+	"github.com/CedrosPay/server/pkg/x402"
+)
+
+// simulationRPC answers simulateTransaction with the given err and logs JSON.
+func simulationRPC(t *testing.T, errJSON, logsJSON string) *rpc.Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":{"context":{"slot":1},"value":{"err":%s,"logs":%s,"accounts":null}}}`, errJSON, logsJSON)
+	}))
+	t.Cleanup(srv.Close)
+	return rpc.New(srv.URL)
+}
+
+func TestSimulateTransaction(t *testing.T) {
+	payer := solana.NewWallet().PublicKey()
+	tx, err := solana.NewTransaction([]solana.Instruction{
+		memo.NewMemoInstruction([]byte("x"), payer).Build(),
+		token.NewTransferCheckedInstruction(1000000, 6, solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey(), payer, nil).Build(),
+	}, solana.Hash{1}, solana.TransactionPayer(payer))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		err        string
+		logs       string
+		gasless    bool
+		autoCreate bool
+		wantCode   apierrors.ErrorCode // Empty when simulation should not fail the payment
+	}{
+		{name: "success", err: "null", logs: `["Program log: ok"]`},
+		{name: "token insufficient funds", err: `{"InstructionError":[1,{"Custom":1}]}`, logs: `["Program log: Error: insufficient funds"]`, wantCode: apierrors.ErrCodeInsufficientFundsToken},
+		{name: "custom 1 outside token program", err: `{"InstructionError":[0,{"Custom":1}]}`, logs: `[]`, wantCode: apierrors.ErrCodeTransactionFailed},
+		{name: "mint mismatch", err: `{"InstructionError":[1,{"Custom":3}]}`, logs: `[]`, wantCode: apierrors.ErrCodeInvalidTokenMint},
+		{name: "missing token account", err: `{"InstructionError":[1,"InvalidAccountData"]}`, logs: `[]`, wantCode: apierrors.ErrCodeMissingTokenAccount},
+		{name: "missing token account with auto-create", err: `{"InstructionError":[1,"UninitializedAccount"]}`, logs: `[]`, autoCreate: true},
+		{name: "payer has no SOL", err: `"AccountNotFound"`, logs: `[]`, wantCode: apierrors.ErrCodeInsufficientFunds},
+		{name: "server wallet has no SOL", err: `"InsufficientFundsForFee"`, logs: `[]`, gasless: true, wantCode: apierrors.ErrCodeInternalError},
+		{name: "expired blockhash", err: `"BlockhashNotFound"`, logs: `null`, wantCode: apierrors.ErrCodeTransactionExpired},
+		{name: "other program error", err: `{"InstructionError":[1,"InvalidArgument"]}`, logs: `[]`, wantCode: apierrors.ErrCodeTransactionFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &SolanaVerifier{rpcClient: simulationRPC(t, tt.err, tt.logs), autoCreateTokenAccounts: tt.autoCreate}
+			err := v.simulateTransaction(context.Background(), tx, rpc.CommitmentConfirmed, tt.gasless)
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("simulateTransaction: %v", err)
+				}
+				return
+			}
+			var verr x402.VerificationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("error = %v, want a VerificationError", err)
+			}
+			if verr.Code != tt.wantCode {
+				t.Errorf("code = %s, want %s (%v)", verr.Code, tt.wantCode, verr.Err)
+			}
+		})
+	}
+}
+
+func TestSimulateTransactionRPCFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	payer := solana.NewWallet().PublicKey()
+	tx, err := solana.NewTransaction([]solana.Instruction{memo.NewMemoInstruction([]byte("x"), payer).Build()}, solana.Hash{1}, solana.TransactionPayer(payer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := &SolanaVerifier{rpcClient: rpc.New(srv.URL)}
+	if err := v.simulateTransaction(context.Background(), tx, rpc.CommitmentConfirmed, false); err != nil {
+		t.Errorf("an unavailable simulation should defer to the send: %v", err)
+	}
+}
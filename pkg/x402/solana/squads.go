@@ -0,0 +1,175 @@
+package solana
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/memo"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	apierrors "github.com/CedrosPay/server/internal/errors"
+	solanaHelpers "github.com/CedrosPay/server/internal/solana"
+	"github.com/CedrosPay/server/pkg/x402"
+)
+
+// SquadsProposalRequest contains the parameters for proposing a transfer out of a Squads vault.
+type SquadsProposalRequest struct {
+	Multisig              solana.PublicKey // Squads multisig account
+	VaultIndex            uint8            // Vault holding the funds (usually 0)
+	Member                solana.PublicKey // Member creating the proposal; signs and pays fees
+	RecipientTokenAccount solana.PublicKey // Destination token account
+	TokenMint             solana.PublicKey // Token mint address (e.g., USDC)
+	Amount                uint64           // Amount in atomic units
+	Decimals              uint8            // Token decimals (e.g., 6 for USDC)
+	Memo                  string           // Memo recorded in the vault transaction
+}
+
+// SquadsProposalResponse contains the unsigned proposal transaction for the member to sign.
+type SquadsProposalResponse struct {
+	Transaction      string `json:"transaction"`      // Base64-encoded unsigned transaction
+	Blockhash        string `json:"blockhash"`        // Recent blockhash used
+	Multisig         string `json:"multisig"`         // Squads multisig account
+	TransactionIndex uint64 `json:"transactionIndex"` // Index of the proposed vault transaction
+	Proposal         string `json:"proposal"`         // Proposal account members vote on
+	Threshold        uint16 `json:"threshold"`        // Approvals required before execution
+	Approved         bool   `json:"approved"`         // Whether the transaction also casts the member's approval
+}
+
+// FetchSquadsMultisig loads a Squads multisig account.
+func (s *SolanaVerifier) FetchSquadsMultisig(ctx context.Context, multisig solana.PublicKey) (solanaHelpers.SquadsMultisig, error) {
+	start := time.Now()
+	info, err := s.rpcClient.GetAccountInfo(ctx, multisig)
+	if s.metrics != nil {
+		s.metrics.ObserveRPCCall("GetAccountInfo", s.network, time.Since(start), err)
+	}
+	if err != nil {
+		return solanaHelpers.SquadsMultisig{}, fmt.Errorf("fetch multisig %s: %w", multisig, err)
+	}
+	if info == nil || info.Value == nil {
+		return solanaHelpers.SquadsMultisig{}, fmt.Errorf("multisig %s not found", multisig)
+	}
+	if !info.Value.Owner.Equals(solanaHelpers.SquadsProgramID) {
+		return solanaHelpers.SquadsMultisig{}, fmt.Errorf("account %s is not owned by the Squads program", multisig)
+	}
+	return solanaHelpers.DecodeSquadsMultisig(info.Value.Data.GetBinary())
+}
+
+// BuildSquadsProposal builds a transaction that proposes an SPL transfer out of the vault.
+// The transaction is NOT signed; the member signs it and submits it as the x402 payment.
+// Once the multisig threshold approves, any member with execute permission runs it
+// (e.g. from the Squads app).
+func (s *SolanaVerifier) BuildSquadsProposal(ctx context.Context, req SquadsProposalRequest) (SquadsProposalResponse, error) {
+	multisig, err := s.FetchSquadsMultisig(ctx, req.Multisig)
+	if err != nil {
+		return SquadsProposalResponse{}, err
+	}
+	member, ok := multisig.Member(req.Member)
+	if !ok {
+		return SquadsProposalResponse{}, fmt.Errorf("%s is not a member of multisig %s", req.Member, req.Multisig)
+	}
+
+	vault, err := solanaHelpers.SquadsVaultAddress(req.Multisig, req.VaultIndex)
+	if err != nil {
+		return SquadsProposalResponse{}, fmt.Errorf("derive vault: %w", err)
+	}
+	fromTokenAccount, _, err := solana.FindAssociatedTokenAddress(vault, req.TokenMint)
+	if err != nil {
+		return SquadsProposalResponse{}, fmt.Errorf("derive vault token account: %w", err)
+	}
+
+	transfer := []solana.Instruction{
+		token.NewTransferCheckedInstruction(
+			req.Amount,
+			req.Decimals,
+			fromTokenAccount,
+			req.TokenMint,
+			req.RecipientTokenAccount,
+			vault, // Vault signs via the Squads program on execution
+			[]solana.PublicKey{},
+		).Build(),
+	}
+	if req.Memo != "" {
+		transfer = append(transfer, memo.NewMemoInstruction([]byte(req.Memo), vault).Build())
+	}
+
+	index := multisig.TransactionIndex + 1
+	instructions, err := solanaHelpers.NewSquadsProposalInstructions(solanaHelpers.SquadsProposalParams{
+		Multisig:         req.Multisig,
+		VaultIndex:       req.VaultIndex,
+		TransactionIndex: index,
+		Creator:          member,
+		Instructions:     transfer,
+		Memo:             req.Memo,
+	})
+	if err != nil {
+		return SquadsProposalResponse{}, err
+	}
+
+	start := time.Now()
+	latest, err := s.rpcClient.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
+	if s.metrics != nil {
+		s.metrics.ObserveRPCCall("GetLatestBlockhash", s.network, time.Since(start), err)
+	}
+	if err != nil {
+		return SquadsProposalResponse{}, fmt.Errorf("get blockhash: %w", err)
+	}
+	blockhash := latest.Value.Blockhash
+
+	tx, err := solana.NewTransaction(instructions, blockhash, solana.TransactionPayer(req.Member))
+	if err != nil {
+		return SquadsProposalResponse{}, fmt.Errorf("build transaction: %w", err)
+	}
+	txBytes, err := tx.MarshalBinary()
+	if err != nil {
+		return SquadsProposalResponse{}, fmt.Errorf("serialize transaction: %w", err)
+	}
+	proposal, err := solanaHelpers.SquadsProposalAddress(req.Multisig, index)
+	if err != nil {
+		return SquadsProposalResponse{}, fmt.Errorf("derive proposal: %w", err)
+	}
+
+	return SquadsProposalResponse{
+		Transaction:      base64.StdEncoding.EncodeToString(txBytes),
+		Blockhash:        blockhash.String(),
+		Multisig:         req.Multisig.String(),
+		TransactionIndex: index,
+		Proposal:         proposal.String(),
+		Threshold:        multisig.Threshold,
+		Approved:         member.Has(solanaHelpers.SquadsPermissionVote),
+	}, nil
+}
+
+// validateSquadsProposal checks that tx proposes a vault transaction on the required
+// multisig containing the expected transfer, and returns the amount and the vault that
+// will send it.
+func validateSquadsProposal(tx *solana.Transaction, requirement x402.Requirement) (float64, solana.PublicKey, error) {
+	multisig, err := solana.PublicKeyFromBase58(requirement.SquadsMultisig)
+	if err != nil {
+		return 0, solana.PublicKey{}, newVerificationError(apierrors.ErrCodeInvalidRecipient, fmt.Errorf("invalid squads multisig: %w", err))
+	}
+	proposal, err := solanaHelpers.ParseSquadsProposal(tx)
+	if err != nil {
+		return 0, solana.PublicKey{}, newVerificationError(apierrors.ErrCodeInvalidTransaction, err)
+	}
+	if !proposal.Multisig.Equals(multisig) {
+		return 0, solana.PublicKey{}, newVerificationError(apierrors.ErrCodeInvalidTransaction, fmt.Errorf("proposal is for multisig %s, expected %s", proposal.Multisig, multisig))
+	}
+
+	amount, authority, err := validateTransferInstructionAndExtractAuthority(&solana.Transaction{Message: proposal.Message}, requirement)
+	if err != nil {
+		return 0, solana.PublicKey{}, err
+	}
+	vault, err := solanaHelpers.SquadsVaultAddress(multisig, proposal.VaultIndex)
+	if err != nil {
+		return 0, solana.PublicKey{}, newVerificationError(apierrors.ErrCodeInternalError, err)
+	}
+	if !authority.Equals(vault) {
+		return 0, solana.PublicKey{}, newVerificationError(apierrors.ErrCodeInvalidTransaction, errors.New("proposed transfer is not signed by the multisig vault"))
+	}
+	return amount, vault, nil
+}
@@ -0,0 +1,93 @@
+package solana
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/token"
+
+	solanaHelpers "github.com/CedrosPay/server/internal/solana"
+	"github.com/CedrosPay/server/pkg/x402"
+)
+
+func TestValidateSquadsProposal(t *testing.T) {
+	const usdcMint = "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"
+	mint := solana.MustPublicKeyFromBase58(usdcMint)
+	multisig := solana.NewWallet().PublicKey()
+	member := solana.NewWallet().PublicKey()
+	customer := solana.NewWallet().PublicKey()
+	customerATA, _, _ := solana.FindAssociatedTokenAddress(customer, mint)
+
+	vault, err := solanaHelpers.SquadsVaultAddress(multisig, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vaultATA, _, _ := solana.FindAssociatedTokenAddress(vault, mint)
+
+	// proposalTx proposes a transfer of amount from owner's token account to the customer
+	proposalTx := func(t *testing.T, owner solana.PublicKey, amount uint64) *solana.Transaction {
+		t.Helper()
+		source, _, _ := solana.FindAssociatedTokenAddress(owner, mint)
+		instructions, err := solanaHelpers.NewSquadsProposalInstructions(solanaHelpers.SquadsProposalParams{
+			Multisig:         multisig,
+			TransactionIndex: 7,
+			Creator:          solanaHelpers.SquadsMember{Key: member, Permissions: solanaHelpers.SquadsPermissionInitiate},
+			Instructions:     []solana.Instruction{token.NewTransferCheckedInstruction(amount, 6, source, mint, customerATA, owner, nil).Build()},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx, err := solana.NewTransaction(instructions, solana.Hash{1}, solana.TransactionPayer(member))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tx
+	}
+	directTx, err := solana.NewTransaction(
+		[]solana.Instruction{token.NewTransferCheckedInstruction(5000000, 6, vaultATA, mint, customerATA, vault, nil).Build()},
+		solana.Hash{1},
+		solana.TransactionPayer(member),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requirement := x402.Requirement{
+		RecipientOwner: customer.String(),
+		TokenMint:      usdcMint,
+		TokenDecimals:  6,
+		Amount:         5,
+		SquadsMultisig: multisig.String(),
+	}
+	otherMultisig := requirement
+	otherMultisig.SquadsMultisig = solana.NewWallet().PublicKey().String()
+
+	tests := []struct {
+		name        string
+		tx          *solana.Transaction
+		requirement x402.Requirement
+		wantErr     bool
+	}{
+		{name: "vault transfer proposal", tx: proposalTx(t, vault, 5000000), requirement: requirement},
+		{name: "direct transfer", tx: directTx, requirement: requirement, wantErr: true},
+		{name: "different multisig", tx: proposalTx(t, vault, 5000000), requirement: otherMultisig, wantErr: true},
+		{name: "transfer not from vault", tx: proposalTx(t, member, 5000000), requirement: requirement, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amount, wallet, err := validateSquadsProposal(tt.tx, tt.requirement)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("validateSquadsProposal: %v", err)
+			}
+			if amount != 5 || !wallet.Equals(vault) {
+				t.Errorf("amount, wallet = %v, %s; want 5, %s", amount, wallet, vault)
+			}
+		})
+	}
+}
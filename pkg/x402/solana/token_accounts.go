@@ -0,0 +1,98 @@
+package solana
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/CedrosPay/server/internal/logger"
+	solanaHelpers "github.com/CedrosPay/server/internal/solana"
+)
+
+// maxAccountsPerRequest is the most accounts getMultipleAccounts returns in one call.
+const maxAccountsPerRequest = 100
+
+// EnsureTokenAccounts creates every missing associated token account for each owner and mint,
+// paid for by the server wallets, and returns the accounts it created. Existence is checked in
+// batched getMultipleAccounts calls, so running it when every account exists costs no fees.
+// Accounts that fail to create are reported in the joined error; the rest are still created.
+func (s *SolanaVerifier) EnsureTokenAccounts(ctx context.Context, owners, mints []solana.PublicKey) ([]solana.PublicKey, error) {
+	type pair struct{ owner, mint solana.PublicKey }
+	var (
+		pairs    []pair
+		accounts []solana.PublicKey
+		seen     = make(map[solana.PublicKey]bool)
+	)
+	for _, owner := range owners {
+		for _, mint := range mints {
+			ata, _, err := solana.FindAssociatedTokenAddress(owner, mint)
+			if err != nil {
+				return nil, fmt.Errorf("derive token account for %s: %w", owner, err)
+			}
+			if seen[ata] {
+				continue
+			}
+			seen[ata] = true
+			pairs = append(pairs, pair{owner: owner, mint: mint})
+			accounts = append(accounts, ata)
+		}
+	}
+
+	var missing []int
+	for start := 0; start < len(accounts); start += maxAccountsPerRequest {
+		end := min(start+maxAccountsPerRequest, len(accounts))
+		getStart := time.Now()
+		resp, err := s.rpcClient.GetMultipleAccountsWithOpts(ctx, accounts[start:end], &rpc.GetMultipleAccountsOpts{
+			Commitment: rpc.CommitmentConfirmed,
+		})
+		if s.metrics != nil {
+			s.metrics.ObserveRPCCall("GetMultipleAccounts", s.network, time.Since(getStart), err)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("fetch token accounts: %w", err)
+		}
+		if resp == nil || len(resp.Value) != end-start {
+			return nil, errors.New("fetch token accounts: unexpected response")
+		}
+		for i, account := range resp.Value {
+			if account == nil {
+				missing = append(missing, start+i)
+			}
+		}
+	}
+	if len(missing) == 0 {
+		return nil, nil
+	}
+
+	confirm := func(ctx context.Context, signature solana.Signature) error {
+		return s.awaitConfirmation(ctx, signature, rpc.CommitmentConfirmed)
+	}
+	log := logger.FromContext(ctx)
+	var (
+		created []solana.PublicKey
+		errs    []error
+	)
+	for _, i := range missing {
+		wallet := s.getNextWallet()
+		if wallet == nil {
+			return created, errors.Join(append(errs, errors.New("no healthy server wallet to pay for token accounts"))...)
+		}
+		release := s.walletInUse(wallet.PublicKey())
+		_, err := solanaHelpers.CreateAssociatedTokenAccount(ctx, s.rpcClient, confirm, wallet, pairs[i].owner, pairs[i].mint)
+		release()
+		if err != nil {
+			log.Warn().
+				Err(err).
+				Str("ata", logger.TruncateAddress(accounts[i].String())).
+				Msg("token_account.prewarm_failed")
+			errs = append(errs, fmt.Errorf("create token account %s: %w", accounts[i], err))
+			continue
+		}
+		created = append(created, accounts[i])
+	}
+	return created, errors.Join(errs...)
+}
@@ -0,0 +1,102 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	solanaHelpers "github.com/CedrosPay/server/internal/solana"
+)
+
+// tokenAccountRPC reports the first account of every getMultipleAccounts batch as existing
+// and the rest as missing, funds every server wallet, and accepts and confirms every
+// transaction sent.
+func tokenAccountRPC(t *testing.T, sent *atomic.Int32) *rpc.Client {
+	t.Helper()
+	existing := fmt.Sprintf(`{"lamports":2039280,"owner":%q,"data":["","base64"],"executable":false,"rentEpoch":0}`, solana.TokenProgramID)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		var result string
+		switch req.Method {
+		case "getMultipleAccounts":
+			var accounts []string
+			_ = json.Unmarshal(req.Params[0], &accounts)
+			values := existing
+			for range accounts[1:] {
+				values += ",null"
+			}
+			result = fmt.Sprintf(`{"context":{"slot":1},"value":[%s]}`, values)
+		case "getBalance":
+			result = `{"context":{"slot":1},"value":1000000000}`
+		case "getLatestBlockhash":
+			result = fmt.Sprintf(`{"context":{"slot":1},"value":{"blockhash":%q,"lastValidBlockHeight":100}}`, solana.Hash{1})
+		case "sendTransaction":
+			sent.Add(1)
+			result = fmt.Sprintf("%q", solana.Signature{1})
+		case "getSignatureStatuses":
+			result = `{"context":{"slot":1},"value":[{"slot":1,"confirmations":null,"err":null,"confirmationStatus":"confirmed"}]}`
+		default:
+			t.Errorf("unexpected RPC method %s", req.Method)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":%s}`, result)
+	}))
+	t.Cleanup(srv.Close)
+	return rpc.New(srv.URL)
+}
+
+func TestEnsureTokenAccounts(t *testing.T) {
+	owner := solana.NewWallet().PublicKey()
+	usdc := solana.NewWallet().PublicKey()
+	pyusd := solana.NewWallet().PublicKey()
+	pyusdAccount, _, _ := solana.FindAssociatedTokenAddress(owner, pyusd)
+
+	tests := []struct {
+		name        string
+		mints       []solana.PublicKey
+		wallets     int
+		wantCreated []solana.PublicKey
+		wantSent    int32
+		wantErr     bool
+	}{
+		{name: "all exist", mints: []solana.PublicKey{usdc, usdc}, wallets: 1},
+		{name: "creates missing", mints: []solana.PublicKey{usdc, pyusd}, wallets: 1, wantCreated: []solana.PublicKey{pyusdAccount}, wantSent: 1},
+		{name: "no server wallet", mints: []solana.PublicKey{usdc, pyusd}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent atomic.Int32
+			v := &SolanaVerifier{rpcClient: tokenAccountRPC(t, &sent)}
+			var wallets []solanaHelpers.Signer
+			for i := 0; i < tt.wallets; i++ {
+				wallets = append(wallets, solanaHelpers.NewLocalSigner(solana.NewWallet().PrivateKey))
+			}
+			v.SetServerWallets(wallets)
+			defer v.Close()
+
+			created, err := v.EnsureTokenAccounts(context.Background(), []solana.PublicKey{owner}, tt.mints)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EnsureTokenAccounts error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if fmt.Sprint(created) != fmt.Sprint(tt.wantCreated) {
+				t.Errorf("created = %v, want %v", created, tt.wantCreated)
+			}
+			if sent.Load() != tt.wantSent {
+				t.Errorf("sent %d transactions, want %d", sent.Load(), tt.wantSent)
+			}
+		})
+	}
+}
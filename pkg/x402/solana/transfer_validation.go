@@ -107,6 +107,31 @@ func validateTransferInstructionAndExtractAuthority(tx *solana.Transaction, requ
 	return 0, solana.PublicKey{}, newVerificationError(apierrors.ErrCodeNotSPLTransfer, fmt.Errorf("token transfer to %s not found in transaction", expectedAccount.String()))
 }
 
+// legacyMemoProgramID is SPL Memo v1, which some wallets still attach memos with.
+var legacyMemoProgramID = solana.MustPublicKeyFromBase58("Memo1UhkJRfHyvLMcVucJwxXeuD728EqVDDwQDxFMNo")
+
+// validateMemo checks that a transaction carries a memo instruction whose text is exactly expected.
+func validateMemo(tx *solana.Transaction, expected string) error {
+	var found []string
+	for _, inst := range tx.Message.Instructions {
+		if int(inst.ProgramIDIndex) >= len(tx.Message.AccountKeys) {
+			continue
+		}
+		programID := tx.Message.AccountKeys[inst.ProgramIDIndex]
+		if !programID.Equals(solana.MemoProgramID) && !programID.Equals(legacyMemoProgramID) {
+			continue
+		}
+		if string(inst.Data) == expected {
+			return nil
+		}
+		found = append(found, string(inst.Data))
+	}
+	if len(found) == 0 {
+		return newVerificationError(apierrors.ErrCodeMissingMemo, fmt.Errorf("memo %q not found in transaction", expected))
+	}
+	return newVerificationError(apierrors.ErrCodeInvalidMemo, fmt.Errorf("memo %q does not match expected %q", found[0], expected))
+}
+
 // extractTokenTransfer extracts the transfer amount from a parsed transaction.
 func extractTokenTransfer(tx *rpc.GetParsedTransactionResult, destination solana.PublicKey, mint solana.PublicKey, decimals uint8, minAmount float64) (float64, error) {
 	if tx.Transaction == nil || tx.Meta == nil {
@@ -456,3 +456,64 @@ func TestScanParsedInstructions(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateMemo(t *testing.T) {
+	payer := solana.NewWallet().PublicKey()
+	memoInstruction := func(program solana.PublicKey, text string) solana.Instruction {
+		return solana.NewInstruction(program, solana.AccountMetaSlice{solana.Meta(payer).SIGNER()}, []byte(text))
+	}
+
+	tests := []struct {
+		name         string
+		instructions []solana.Instruction
+		wantCode     apierrors.ErrorCode // Empty: memo accepted
+	}{
+		{
+			name:         "exact memo",
+			instructions: []solana.Instruction{memoInstruction(solana.MemoProgramID, "cedros:article")},
+		},
+		{
+			name:         "legacy memo program",
+			instructions: []solana.Instruction{memoInstruction(legacyMemoProgramID, "cedros:article")},
+		},
+		{
+			name: "matching memo after another",
+			instructions: []solana.Instruction{
+				memoInstruction(solana.MemoProgramID, "wallet note"),
+				memoInstruction(solana.MemoProgramID, "cedros:article"),
+			},
+		},
+		{
+			name:         "memo for another resource",
+			instructions: []solana.Instruction{memoInstruction(solana.MemoProgramID, "cedros:other")},
+			wantCode:     apierrors.ErrCodeInvalidMemo,
+		},
+		{
+			name:         "memo with extra suffix",
+			instructions: []solana.Instruction{memoInstruction(solana.MemoProgramID, "cedros:article:abc")},
+			wantCode:     apierrors.ErrCodeInvalidMemo,
+		},
+		{
+			name:         "no memo",
+			instructions: []solana.Instruction{memoInstruction(solana.SystemProgramID, "cedros:article")},
+			wantCode:     apierrors.ErrCodeMissingMemo,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx, err := solana.NewTransaction(tt.instructions, solana.Hash{1}, solana.TransactionPayer(payer))
+			if err != nil {
+				t.Fatalf("NewTransaction: %v", err)
+			}
+			err = validateMemo(tx, "cedros:article")
+			var verr x402.VerificationError
+			switch {
+			case tt.wantCode == "" && err != nil:
+				t.Errorf("validateMemo() error = %v, want nil", err)
+			case tt.wantCode != "" && (!errors.As(err, &verr) || verr.Code != tt.wantCode):
+				t.Errorf("validateMemo() error = %v, want code %s", err, tt.wantCode)
+			}
+		})
+	}
+}
@@ -4,27 +4,41 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
-	"github.com/gagliardetto/solana-go/rpc/ws"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
+	"github.com/CedrosPay/server/internal/circuitbreaker"
 	apierrors "github.com/CedrosPay/server/internal/errors"
 	"github.com/CedrosPay/server/internal/logger"
 	"github.com/CedrosPay/server/internal/metrics"
+	"github.com/CedrosPay/server/internal/rpcutil"
 	solanaHelpers "github.com/CedrosPay/server/internal/solana"
+	"github.com/CedrosPay/server/internal/tracing"
 	"github.com/CedrosPay/server/pkg/x402"
 )
 
 // SolanaVerifier confirms x402 payments against the Solana blockchain.
 type SolanaVerifier struct {
 	rpcClient               *rpc.Client
-	wsClient                *ws.Client
+	rpcPool                 *rpcutil.Pool // Optional: endpoint pool behind rpcClient, closed with the verifier
+	ws                      *wsConnection // Reconnecting WebSocket used for confirmations; nil falls back to RPC polling
 	clock                   func() time.Time
-	serverWallets           []solana.PrivateKey // Server wallets for gasless and token account creation
-	walletIndex             atomic.Uint64       // Round-robin counter for wallet selection
+	walletsMu               sync.RWMutex
+	serverWallets           []solanaHelpers.Signer     // Active server wallets for gasless and token account creation
+	retiringWallets         map[string]*retiringWallet // Wallets being drained; still co-sign transactions built for them
+	walletUsage             map[string]*walletUsage    // In-flight tracking used to decide when a retiring wallet is drained
+	walletIndex             atomic.Uint64              // Round-robin counter for wallet selection
+	gaslessBudget           uint64                     // Daily gasless fee budget per wallet in lamports (0 = unlimited)
+	gaslessSpend            map[string]*gaslessSpend   // Today's gasless fee spending per wallet
+	onBudgetExhausted       func(wallet solana.PublicKey, spent, budget uint64)
+	priorityFees            *PriorityFeeEstimator // Optional: prices gasless transactions from recent fees
+	lookupTables            lookupTableCache      // Address lookup tables referenced by v0 transactions
 	gaslessEnabled          bool
 	autoCreateTokenAccounts bool
 	txQueue                 *TransactionQueue    // Transaction queue for rate limiting
@@ -46,14 +60,32 @@ func NewSolanaVerifier(rpcURL, wsURL string) (*SolanaVerifier, error) {
 		wsURL = derived
 	}
 
-	wsClient, err := ws.Connect(context.Background(), wsURL)
+	return newSolanaVerifier(rpcutil.NewClient(rpcURL), wsURL)
+}
+
+// NewSolanaVerifierWithPool creates a verifier whose RPC calls are spread over the pool's
+// endpoints with failover. WebSocket subscriptions use wsURL only. The verifier closes the pool.
+func NewSolanaVerifierWithPool(pool *rpcutil.Pool, wsURL string) (*SolanaVerifier, error) {
+	if wsURL == "" {
+		return nil, errors.New("x402 solana: websocket url required")
+	}
+	verifier, err := newSolanaVerifier(pool.Client(), wsURL)
+	if err != nil {
+		return nil, err
+	}
+	verifier.rpcPool = pool
+	return verifier, nil
+}
+
+func newSolanaVerifier(rpcClient *rpc.Client, wsURL string) (*SolanaVerifier, error) {
+	wsConn, err := newWSConnection(wsURL, wsReconnectMinBackoff, wsReconnectMaxBackoff)
 	if err != nil {
-		return nil, fmt.Errorf("x402 solana: connect websocket: %w", err)
+		return nil, err
 	}
 
 	verifier := &SolanaVerifier{
-		rpcClient: rpc.New(rpcURL),
-		wsClient:  wsClient,
+		rpcClient: rpcClient,
+		ws:        wsConn,
 		clock:     time.Now,
 	}
 
@@ -62,11 +94,12 @@ func NewSolanaVerifier(rpcURL, wsURL string) (*SolanaVerifier, error) {
 
 // Close releases underlying websocket resources and stops health checker.
 func (s *SolanaVerifier) Close() {
-	if s.healthChecker != nil {
-		s.healthChecker.Stop()
+	if checker := s.GetHealthChecker(); checker != nil {
+		checker.Stop()
 	}
-	if s.wsClient != nil {
-		s.wsClient.Close()
+	s.ws.Close()
+	if s.rpcPool != nil {
+		_ = s.rpcPool.Close()
 	}
 }
 
@@ -75,15 +108,37 @@ func (s *SolanaVerifier) RPCClient() *rpc.Client {
 	return s.rpcClient
 }
 
+// RPCEndpoints reports the health and circuit breaker state of each RPC endpoint when the
+// verifier spreads calls over a pool; nil with a single endpoint.
+func (s *SolanaVerifier) RPCEndpoints() []rpcutil.EndpointStatus {
+	if s.rpcPool == nil {
+		return nil
+	}
+	return s.rpcPool.Endpoints()
+}
+
+// RPCBreakers returns the circuit breakers of the verifier's RPC endpoints, for the admin
+// API; nil without a pool or with breakers disabled.
+func (s *SolanaVerifier) RPCBreakers() []*circuitbreaker.Breaker {
+	if s.rpcPool == nil {
+		return nil
+	}
+	return s.rpcPool.Breakers()
+}
+
 // GetHealthChecker returns the wallet health checker for monitoring.
 func (s *SolanaVerifier) GetHealthChecker() *WalletHealthChecker {
+	s.walletsMu.RLock()
+	defer s.walletsMu.RUnlock()
 	return s.healthChecker
 }
 
 // SetServerWallets configures the server wallets for gasless transactions and token account creation.
 // Wallets are used in round-robin fashion to distribute load and avoid rate limits.
 // This also initializes and starts the wallet health checker.
-func (s *SolanaVerifier) SetServerWallets(wallets []solana.PrivateKey) {
+func (s *SolanaVerifier) SetServerWallets(wallets []solanaHelpers.Signer) {
+	s.walletsMu.Lock()
+	defer s.walletsMu.Unlock()
 	s.serverWallets = wallets
 
 	// Initialize health checker if wallets are provided
@@ -112,12 +167,37 @@ func (s *SolanaVerifier) EnableAutoCreateTokenAccounts() {
 	s.autoCreateTokenAccounts = true
 }
 
+// SetPriorityFeeEstimator prices gasless transactions from recent prioritization fees instead
+// of the caller's static compute unit price.
+func (s *SolanaVerifier) SetPriorityFeeEstimator(estimator *PriorityFeeEstimator) {
+	s.priorityFees = estimator
+}
+
 // SetupTxQueue initializes the transaction queue with the given rate limiting settings.
 func (s *SolanaVerifier) SetupTxQueue(minTimeBetween time.Duration, maxInFlight int) {
 	s.txQueue = NewTransactionQueue(s.rpcClient, s, minTimeBetween, maxInFlight)
 	s.txQueue.Start()
 }
 
+// TxQueueStats returns the transaction queue's queued and in-flight counts (nil when no queue is configured).
+func (s *SolanaVerifier) TxQueueStats() map[string]int {
+	if s.txQueue == nil {
+		return nil
+	}
+	return s.txQueue.Stats()
+}
+
+// DrainTxQueue waits for queued and in-flight transactions to finish (bounded by ctx),
+// then stops the queue.
+func (s *SolanaVerifier) DrainTxQueue(ctx context.Context) error {
+	if s.txQueue == nil {
+		return nil
+	}
+	err := s.txQueue.Drain(ctx)
+	s.txQueue.Shutdown()
+	return err
+}
+
 // ShutdownTxQueue stops the transaction queue gracefully.
 func (s *SolanaVerifier) ShutdownTxQueue() {
 	if s.txQueue != nil {
@@ -125,9 +205,27 @@ func (s *SolanaVerifier) ShutdownTxQueue() {
 	}
 }
 
+// FeePayerPublicKey returns the first server wallet with gasless budget left, which quotes
+// advertise as the gasless fee payer, or "" when no server wallets are configured.
+func (s *SolanaVerifier) FeePayerPublicKey() string {
+	s.walletsMu.RLock()
+	defer s.walletsMu.RUnlock()
+	if len(s.serverWallets) == 0 {
+		return ""
+	}
+	for _, wallet := range s.serverWallets {
+		if s.withinBudgetLocked(wallet.PublicKey().String()) {
+			return wallet.PublicKey().String()
+		}
+	}
+	return s.serverWallets[0].PublicKey().String()
+}
+
 // getNextWallet returns the next healthy server wallet using round-robin selection.
 // Returns nil if no wallets are configured or all wallets are unhealthy.
-func (s *SolanaVerifier) getNextWallet() *solana.PrivateKey {
+func (s *SolanaVerifier) getNextWallet() solanaHelpers.Signer {
+	s.walletsMu.RLock()
+	defer s.walletsMu.RUnlock()
 	if len(s.serverWallets) == 0 {
 		return nil
 	}
@@ -142,21 +240,41 @@ func (s *SolanaVerifier) getNextWallet() *solana.PrivateKey {
 
 	// Fallback: no health checker, use simple round-robin
 	idx := s.walletIndex.Add(1) % uint64(len(s.serverWallets))
-	return &s.serverWallets[idx]
+	return s.serverWallets[idx]
 }
 
 // findWalletByPublicKey returns the wallet matching the given public key, or nil if not found.
-func (s *SolanaVerifier) findWalletByPublicKey(pubkey solana.PublicKey) *solana.PrivateKey {
-	for i := range s.serverWallets {
-		if s.serverWallets[i].PublicKey().Equals(pubkey) {
-			return &s.serverWallets[i]
+// Wallets that are still draining match so transactions built before retirement can complete.
+func (s *SolanaVerifier) findWalletByPublicKey(pubkey solana.PublicKey) solanaHelpers.Signer {
+	s.walletsMu.RLock()
+	defer s.walletsMu.RUnlock()
+	for _, wallet := range s.serverWallets {
+		if wallet.PublicKey().Equals(pubkey) {
+			return wallet
 		}
 	}
+	if retiring, ok := s.retiringWallets[pubkey.String()]; ok && retiring.retiredAt.IsZero() {
+		return retiring.signer
+	}
 	return nil
 }
 
 // Verify inspects the signed transaction, submits it, and waits for finalised confirmation.
+// With requirement.VerifyOnly it stops after the checks and a simulation, sending nothing.
 func (s *SolanaVerifier) Verify(ctx context.Context, proof x402.PaymentProof, requirement x402.Requirement) (x402.VerificationResult, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "solana.verify", trace.WithAttributes(
+		attribute.String("cedros.resource_id", requirement.ResourceID),
+		attribute.String("cedros.network", requirement.Network),
+	))
+	result, err := s.verify(ctx, proof, requirement)
+	if err == nil {
+		span.SetAttributes(attribute.String("cedros.signature", result.Signature))
+	}
+	tracing.End(span, err)
+	return result, err
+}
+
+func (s *SolanaVerifier) verify(ctx context.Context, proof x402.PaymentProof, requirement x402.Requirement) (x402.VerificationResult, error) {
 	if requirement.RecipientOwner == "" {
 		return x402.VerificationResult{}, newVerificationError(apierrors.ErrCodeInvalidRecipient, errors.New("recipient owner not configured"))
 	}
@@ -171,6 +289,11 @@ func (s *SolanaVerifier) Verify(ctx context.Context, proof x402.PaymentProof, re
 	if err != nil {
 		return x402.VerificationResult{}, newVerificationError(apierrors.ErrCodeInvalidTransaction, err)
 	}
+	// v0 transactions reference accounts through lookup tables; load them before any
+	// instruction is resolved
+	if err := s.resolveAddressTables(ctx, tx); err != nil {
+		return x402.VerificationResult{}, newVerificationError(apierrors.ErrCodeInvalidTransaction, err)
+	}
 	// Note: We don't validate tx.Signatures here because the actual signature
 	// is returned by SendTransactionWithOpts after the transaction is broadcast
 
@@ -192,18 +315,47 @@ func (s *SolanaVerifier) Verify(ctx context.Context, proof x402.PaymentProof, re
 
 	// Extract user wallet (transfer authority) from the transaction by validating the transfer instruction
 	// This returns the amount AND validates that the transfer is properly structured
-	amount, userWallet, err := validateTransferInstructionAndExtractAuthority(tx, requirement)
+	// For a Squads multisig, the transfer is inside the proposed vault transaction and the
+	// authority is the vault; it executes once enough members approve
+	var (
+		amount     float64
+		userWallet solana.PublicKey
+	)
+	if requirement.SquadsMultisig != "" {
+		amount, userWallet, err = validateSquadsProposal(tx, requirement)
+	} else {
+		amount, userWallet, err = validateTransferInstructionAndExtractAuthority(tx, requirement)
+	}
 	if err != nil {
 		return x402.VerificationResult{}, err
 	}
+	if requirement.Memo != "" {
+		if err := validateMemo(tx, requirement.Memo); err != nil {
+			return x402.VerificationResult{}, err
+		}
+	}
 	if amount+x402.AmountTolerance < requirement.Amount {
 		return x402.VerificationResult{}, newVerificationError(apierrors.ErrCodeAmountBelowMinimum, fmt.Errorf("amount %.8f < %.8f", amount, requirement.Amount))
 	}
+	if requirement.CheckAmount != nil {
+		if err := requirement.CheckAmount(ctx, amount); err != nil {
+			return x402.VerificationResult{}, err
+		}
+	}
+	if requirement.CheckPayer != nil {
+		if err := requirement.CheckPayer(ctx, userWallet.String()); err != nil {
+			return x402.VerificationResult{}, err
+		}
+	}
 
 	// If this is a gasless transaction (feePayer provided in proof), co-sign with the server wallet
 	// IMPORTANT: Only co-sign if proof.FeePayer is set, even if gasless is globally enabled
 	// This allows non-gasless transactions (like refunds) to work when gasless mode is configured
+	var gaslessFeePayer *solana.PublicKey
 	if s.gaslessEnabled && proof.FeePayer != "" {
+		if requirement.Unsponsored {
+			return x402.VerificationResult{}, newVerificationError(apierrors.ErrCodeGaslessUnavailable, fmt.Errorf("server wallets do not pay fees for payments to %s", requirement.RecipientOwner))
+		}
 		// Extract the fee payer from the transaction (first signer)
 		// The fee payer was set when we built the transaction, so we need to use the SAME wallet
 		if len(tx.Message.AccountKeys) == 0 {
@@ -217,14 +369,16 @@ func (s *SolanaVerifier) Verify(ctx context.Context, proof x402.PaymentProof, re
 			return x402.VerificationResult{}, newVerificationError(apierrors.ErrCodeInvalidTransaction, fmt.Errorf("transaction fee payer %s does not match any configured server wallet", feePayer.String()))
 		}
 
+		if !s.withinBudget(feePayer) {
+			return x402.VerificationResult{}, newVerificationError(apierrors.ErrCodeGaslessUnavailable, fmt.Errorf("fee payer %s: %w", feePayer, ErrGaslessBudgetExhausted))
+		}
+		gaslessFeePayer = &feePayer
+
+		release := s.walletInUse(feePayer)
+		defer release()
+
 		// Partial sign with the server wallet (transaction already has user's signature)
-		_, err := tx.PartialSign(func(key solana.PublicKey) *solana.PrivateKey {
-			if key.Equals(matchingWallet.PublicKey()) {
-				return matchingWallet
-			}
-			return nil
-		})
-		if err != nil {
+		if err := solanaHelpers.SignTransaction(ctx, tx, matchingWallet); err != nil {
 			return x402.VerificationResult{}, newVerificationError(apierrors.ErrCodeInternalError, fmt.Errorf("failed to co-sign transaction: %w", err))
 		}
 	}
@@ -236,6 +390,26 @@ func (s *SolanaVerifier) Verify(ctx context.Context, proof x402.PaymentProof, re
 		PreflightCommitment: commitment,
 	}
 
+	// Simulate first so program failures come back as specific errors rather than an opaque
+	// preflight failure
+	if requirement.SimulateTransaction || requirement.VerifyOnly {
+		if err := s.simulateTransaction(ctx, tx, commitment, gaslessFeePayer != nil); err != nil {
+			return x402.VerificationResult{}, err
+		}
+	}
+	if requirement.VerifyOnly {
+		// The fee payer's signature is the transaction ID once it is sent
+		var signature string
+		if len(tx.Signatures) > 0 && !tx.Signatures[0].IsZero() {
+			signature = tx.Signatures[0].String()
+		}
+		return x402.VerificationResult{
+			Wallet:    userWallet.String(),
+			Amount:    amount,
+			Signature: signature,
+		}, nil
+	}
+
 	// Track RPC call metrics
 	rpcStart := time.Now()
 	actualSignature, sendErr := s.rpcClient.SendTransactionWithOpts(ctx, tx, sendOpts)
@@ -260,13 +434,18 @@ func (s *SolanaVerifier) Verify(ctx context.Context, proof x402.PaymentProof, re
 
 			// Check if this is an account-not-found error and we can auto-create
 			if isAccountNotFoundError(sendErr) {
+				if requirement.Unsponsored {
+					return x402.VerificationResult{}, newVerificationError(apierrors.ErrCodeMissingTokenAccount, fmt.Errorf("recipient %s has no token account: %w", requirement.RecipientOwner, sendErr))
+				}
 				if s.autoCreateTokenAccounts {
 					wallet := s.getNextWallet()
 					if wallet == nil {
 						return x402.VerificationResult{}, newVerificationError(apierrors.ErrCodeTransactionFailed, fmt.Errorf("auto-create enabled but no server wallets configured (original error: %w)", sendErr))
 					}
+					release := s.walletInUse(wallet.PublicKey())
+					defer release()
 					// Try to create the missing token account
-					if err := s.handleMissingTokenAccount(ctx, requirement, *wallet); err != nil {
+					if err := s.handleMissingTokenAccount(ctx, requirement, wallet); err != nil {
 						return x402.VerificationResult{}, newVerificationError(apierrors.ErrCodeTransactionFailed, fmt.Errorf("failed to create token account: %w (original error: %w)", err, sendErr))
 					}
 					// Poll for account existence with exponential backoff instead of fixed sleep
@@ -298,6 +477,22 @@ func (s *SolanaVerifier) Verify(ctx context.Context, proof x402.PaymentProof, re
 		}
 	}
 
+	if requirement.OnSubmitted != nil && !actualSignature.IsZero() {
+		requirement.OnSubmitted(actualSignature.String())
+	}
+
+	// The network has accepted the transaction, so its fee is charged to the server wallet
+	if gaslessFeePayer != nil && sendErr == nil {
+		s.recordGaslessFee(ctx, *gaslessFeePayer, estimateTransactionFee(tx))
+	}
+	if s.metrics != nil && sendErr == nil {
+		payer := "user"
+		if gaslessFeePayer != nil {
+			payer = "server"
+		}
+		s.metrics.ObservePriorityFee(s.network, payer, computeUnitPrice(tx))
+	}
+
 	waitCtx, cancel := context.WithTimeout(ctx, maxDuration(requirement.QuoteTTL, x402.DefaultConfirmationTimeout))
 	defer cancel()
 
@@ -337,7 +532,7 @@ func (s *SolanaVerifier) Verify(ctx context.Context, proof x402.PaymentProof, re
 
 // handleMissingTokenAccount creates the associated token account for the recipient.
 // This is called when a transaction fails due to a missing token account.
-func (s *SolanaVerifier) handleMissingTokenAccount(ctx context.Context, requirement x402.Requirement, wallet solana.PrivateKey) error {
+func (s *SolanaVerifier) handleMissingTokenAccount(ctx context.Context, requirement x402.Requirement, wallet solanaHelpers.Signer) error {
 	// Parse the owner and mint
 	owner, err := solana.PublicKeyFromBase58(requirement.RecipientOwner)
 	if err != nil {
@@ -350,7 +545,10 @@ func (s *SolanaVerifier) handleMissingTokenAccount(ctx context.Context, requirem
 	}
 
 	// Create the associated token account using the provided wallet
-	_, err = solanaHelpers.CreateAssociatedTokenAccount(ctx, s.rpcClient, s.wsClient, wallet, owner, mint)
+	confirm := func(ctx context.Context, signature solana.Signature) error {
+		return s.awaitConfirmation(ctx, signature, rpc.CommitmentConfirmed)
+	}
+	_, err = solanaHelpers.CreateAssociatedTokenAccount(ctx, s.rpcClient, confirm, wallet, owner, mint)
 	if err != nil {
 		return fmt.Errorf("create ATA: %w", err)
 	}
@@ -0,0 +1,192 @@
+package solana
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+
+	solanaHelpers "github.com/CedrosPay/server/internal/solana"
+)
+
+// WalletDrainGrace is how long after a wallet was last handed out as a gasless fee payer it
+// stays available for co-signing. It covers the user's signing time and the ~60-90s a
+// blockhash remains valid; after that the transaction can no longer land.
+const WalletDrainGrace = 2 * time.Minute
+
+// Server wallet lifecycle states reported by ServerWallets.
+const (
+	WalletStateActive   = "active"   // Selected for new gasless transactions and token account creation
+	WalletStateDraining = "draining" // Only co-signs transactions already built for it
+	WalletStateRetired  = "retired"  // Drained; safe to sweep its remaining SOL
+)
+
+// ErrWalletNotFound is returned when rotating a wallet the verifier does not know.
+var ErrWalletNotFound = errors.New("server wallet not found")
+
+// walletUsage tracks outstanding work for a server wallet.
+type walletUsage struct {
+	inFlight     int       // Transactions being co-signed or submitted
+	lastAssigned time.Time // Last time a gasless transaction was built with it as fee payer
+}
+
+// retiringWallet is a wallet removed from selection.
+type retiringWallet struct {
+	signer    solanaHelpers.Signer
+	since     time.Time
+	retiredAt time.Time // Zero while draining
+}
+
+// WalletStatus describes a server wallet for the rotation admin API.
+type WalletStatus struct {
+	Address   string     `json:"address"`
+	State     string     `json:"state"`
+	InFlight  int        `json:"inFlight"`
+	Since     *time.Time `json:"since,omitempty"`     // When draining started
+	RetiredAt *time.Time `json:"retiredAt,omitempty"` // When draining finished
+}
+
+// AddServerWallet registers a new server wallet, making it available for selection once its
+// balance check passes. A retired wallet may be re-added.
+func (s *SolanaVerifier) AddServerWallet(wallet solanaHelpers.Signer) error {
+	pubkey := wallet.PublicKey()
+
+	s.walletsMu.Lock()
+	for _, existing := range s.serverWallets {
+		if existing.PublicKey().Equals(pubkey) {
+			s.walletsMu.Unlock()
+			return fmt.Errorf("server wallet %s is already active", pubkey)
+		}
+	}
+	if retiring, ok := s.retiringWallets[pubkey.String()]; ok && retiring.retiredAt.IsZero() {
+		s.walletsMu.Unlock()
+		return fmt.Errorf("server wallet %s is still draining", pubkey)
+	}
+	delete(s.retiringWallets, pubkey.String())
+	s.serverWallets = append(s.serverWallets, wallet)
+
+	checker := s.healthChecker
+	startChecker := checker == nil
+	if startChecker {
+		checker = NewWalletHealthChecker(s.rpcClient, nil)
+		s.healthChecker = checker
+	}
+	s.walletsMu.Unlock()
+
+	// Balance check runs outside walletsMu: it is an RPC round trip
+	checker.AddWallet(wallet)
+	if startChecker {
+		checker.Start()
+	}
+	return nil
+}
+
+// RetireServerWallet stops selecting the wallet for new transactions. It keeps co-signing
+// gasless transactions already built for it until none are in flight and WalletDrainGrace
+// has passed since it was last handed out; ServerWallets then reports it retired.
+func (s *SolanaVerifier) RetireServerWallet(pubkey solana.PublicKey) error {
+	s.walletsMu.Lock()
+	defer s.walletsMu.Unlock()
+
+	idx := slices.IndexFunc(s.serverWallets, func(wallet solanaHelpers.Signer) bool {
+		return wallet.PublicKey().Equals(pubkey)
+	})
+	if idx < 0 {
+		if _, ok := s.retiringWallets[pubkey.String()]; ok {
+			return fmt.Errorf("server wallet %s is already retiring", pubkey)
+		}
+		return fmt.Errorf("%w: %s", ErrWalletNotFound, pubkey)
+	}
+	if len(s.serverWallets) == 1 && (s.gaslessEnabled || s.autoCreateTokenAccounts) {
+		return errors.New("cannot retire the last active server wallet; add its replacement first")
+	}
+
+	wallet := s.serverWallets[idx]
+	s.serverWallets = slices.Delete(slices.Clone(s.serverWallets), idx, idx+1)
+	if s.retiringWallets == nil {
+		s.retiringWallets = make(map[string]*retiringWallet)
+	}
+	s.retiringWallets[pubkey.String()] = &retiringWallet{signer: wallet, since: s.clock()}
+	if s.healthChecker != nil {
+		s.healthChecker.RemoveWallet(pubkey)
+	}
+	return nil
+}
+
+// ServerWallets reports every active, draining, and retired server wallet.
+func (s *SolanaVerifier) ServerWallets() []WalletStatus {
+	s.walletsMu.Lock()
+	defer s.walletsMu.Unlock()
+
+	now := s.clock()
+	statuses := make([]WalletStatus, 0, len(s.serverWallets)+len(s.retiringWallets))
+	for _, wallet := range s.serverWallets {
+		address := wallet.PublicKey().String()
+		statuses = append(statuses, WalletStatus{Address: address, State: WalletStateActive, InFlight: s.inFlightLocked(address)})
+	}
+	for address, retiring := range s.retiringWallets {
+		status := WalletStatus{Address: address, State: WalletStateDraining, InFlight: s.inFlightLocked(address), Since: &retiring.since}
+		if retiring.retiredAt.IsZero() && s.drainedLocked(address, now) {
+			retiring.retiredAt = now
+			delete(s.walletUsage, address)
+		}
+		if !retiring.retiredAt.IsZero() {
+			status.State = WalletStateRetired
+			status.RetiredAt = &retiring.retiredAt
+		}
+		statuses = append(statuses, status)
+	}
+	slices.SortFunc(statuses[len(s.serverWallets):], func(a, b WalletStatus) int {
+		return a.Since.Compare(*b.Since)
+	})
+	return statuses
+}
+
+// noteWalletAssigned records that a gasless transaction was built with wallet as fee payer.
+func (s *SolanaVerifier) noteWalletAssigned(pubkey solana.PublicKey) {
+	s.walletsMu.Lock()
+	defer s.walletsMu.Unlock()
+	s.usageLocked(pubkey.String()).lastAssigned = s.clock()
+}
+
+// walletInUse marks wallet as busy until the returned release func is called.
+func (s *SolanaVerifier) walletInUse(pubkey solana.PublicKey) (release func()) {
+	address := pubkey.String()
+	s.walletsMu.Lock()
+	s.usageLocked(address).inFlight++
+	s.walletsMu.Unlock()
+
+	return func() {
+		s.walletsMu.Lock()
+		defer s.walletsMu.Unlock()
+		if usage, ok := s.walletUsage[address]; ok && usage.inFlight > 0 {
+			usage.inFlight--
+		}
+	}
+}
+
+func (s *SolanaVerifier) usageLocked(address string) *walletUsage {
+	if s.walletUsage == nil {
+		s.walletUsage = make(map[string]*walletUsage)
+	}
+	usage, ok := s.walletUsage[address]
+	if !ok {
+		usage = &walletUsage{}
+		s.walletUsage[address] = usage
+	}
+	return usage
+}
+
+func (s *SolanaVerifier) inFlightLocked(address string) int {
+	if usage, ok := s.walletUsage[address]; ok {
+		return usage.inFlight
+	}
+	return 0
+}
+
+func (s *SolanaVerifier) drainedLocked(address string, now time.Time) bool {
+	usage, ok := s.walletUsage[address]
+	return !ok || (usage.inFlight == 0 && now.Sub(usage.lastAssigned) >= WalletDrainGrace)
+}
@@ -0,0 +1,100 @@
+package solana
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	solanaHelpers "github.com/CedrosPay/server/internal/solana"
+)
+
+// fundedRPC answers getBalance with 1 SOL for any wallet.
+func fundedRPC(t *testing.T) *rpc.Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"context":{"slot":1},"value":1000000000}}`))
+	}))
+	t.Cleanup(srv.Close)
+	return rpc.New(srv.URL)
+}
+
+func TestServerWalletRotation(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	v := &SolanaVerifier{rpcClient: fundedRPC(t), clock: func() time.Time { return now }}
+	defer v.Close()
+	v.EnableGasless()
+
+	oldWallet := solanaHelpers.NewLocalSigner(solana.NewWallet().PrivateKey)
+	newWallet := solanaHelpers.NewLocalSigner(solana.NewWallet().PrivateKey)
+	v.SetServerWallets([]solanaHelpers.Signer{oldWallet})
+
+	if err := v.RetireServerWallet(oldWallet.PublicKey()); err == nil {
+		t.Fatal("retiring the only wallet should fail while gasless is enabled")
+	}
+	if err := v.AddServerWallet(newWallet); err != nil {
+		t.Fatalf("AddServerWallet: %v", err)
+	}
+	if err := v.AddServerWallet(newWallet); err == nil {
+		t.Error("adding an active wallet twice should fail")
+	}
+	if h, ok := v.GetHealthChecker().GetWalletHealth(newWallet.PublicKey()); !ok || !h.IsHealthy {
+		t.Fatalf("new wallet health = %+v, %v", h, ok)
+	}
+
+	// A gasless transaction is built for the old wallet and is mid-verification when it retires
+	v.noteWalletAssigned(oldWallet.PublicKey())
+	release := v.walletInUse(oldWallet.PublicKey())
+	if err := v.RetireServerWallet(oldWallet.PublicKey()); err != nil {
+		t.Fatalf("RetireServerWallet: %v", err)
+	}
+	if err := v.RetireServerWallet(solana.NewWallet().PublicKey()); !errors.Is(err, ErrWalletNotFound) {
+		t.Errorf("unknown wallet error = %v", err)
+	}
+
+	for i := 0; i < 4; i++ {
+		if got := v.getNextWallet(); got == nil || !got.PublicKey().Equals(newWallet.PublicKey()) {
+			t.Fatalf("getNextWallet = %v, want only the new wallet", got)
+		}
+	}
+	if v.findWalletByPublicKey(oldWallet.PublicKey()) == nil {
+		t.Fatal("draining wallet must still co-sign transactions built for it")
+	}
+	if _, ok := v.GetHealthChecker().GetWalletHealth(oldWallet.PublicKey()); ok {
+		t.Error("draining wallet should no longer be health checked")
+	}
+
+	wantState := func(state string, inFlight int) {
+		t.Helper()
+		for _, status := range v.ServerWallets() {
+			if status.Address == oldWallet.PublicKey().String() {
+				if status.State != state || status.InFlight != inFlight {
+					t.Fatalf("old wallet = %+v, want %s with %d in flight", status, state, inFlight)
+				}
+				return
+			}
+		}
+		t.Fatal("old wallet missing from ServerWallets")
+	}
+
+	wantState(WalletStateDraining, 1)
+	release()
+	wantState(WalletStateDraining, 0) // Still within the grace period of its last assignment
+
+	now = now.Add(WalletDrainGrace)
+	wantState(WalletStateRetired, 0)
+	if v.findWalletByPublicKey(oldWallet.PublicKey()) != nil {
+		t.Error("retired wallet should not co-sign")
+	}
+
+	// A retired wallet can be brought back
+	if err := v.AddServerWallet(oldWallet); err != nil {
+		t.Fatalf("re-adding retired wallet: %v", err)
+	}
+	wantState(WalletStateActive, 0)
+}
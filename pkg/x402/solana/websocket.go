@@ -0,0 +1,193 @@
+package solana
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+	"github.com/rs/zerolog"
+
+	"github.com/CedrosPay/server/internal/logger"
+)
+
+const (
+	// wsDialTimeout bounds a single WebSocket connection attempt.
+	wsDialTimeout = 10 * time.Second
+
+	// wsReconnectMinBackoff and wsReconnectMaxBackoff bound the delay between reconnect attempts.
+	wsReconnectMinBackoff = time.Second
+	wsReconnectMaxBackoff = 30 * time.Second
+)
+
+// wsConnection keeps the verifier's WebSocket client connected. The ws package does not
+// reconnect on its own: once the socket drops, every subscription on it fails. A dropped
+// connection is redialed in the background with exponential backoff; until it is back, Client
+// returns nil and confirmations poll the RPC instead.
+type wsConnection struct {
+	url        string
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	mu           sync.Mutex
+	client       *ws.Client // nil while disconnected
+	reconnecting bool
+	closed       bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	log    zerolog.Logger
+}
+
+// newWSConnection connects to url, waiting between minBackoff and maxBackoff between reconnect
+// attempts. The first connection must succeed.
+func newWSConnection(url string, minBackoff, maxBackoff time.Duration) (*wsConnection, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &wsConnection{
+		url:        url,
+		minBackoff: minBackoff,
+		maxBackoff: maxBackoff,
+		ctx:        ctx,
+		cancel:     cancel,
+		log: logger.FromContext(ctx).With().
+			Str("component", "solana_websocket").
+			Logger(),
+	}
+
+	client, sub, err := c.dial()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	c.mu.Lock()
+	c.setClientLocked(client, sub)
+	c.mu.Unlock()
+	return c, nil
+}
+
+// Client returns the connected client, or nil while reconnecting.
+func (c *wsConnection) Client() *ws.Client {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.client
+}
+
+// markBroken reports that client's connection failed. If it is still the current client it is
+// dropped and a reconnect starts.
+func (c *wsConnection) markBroken(client *ws.Client, err error) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed || client == nil || c.client != client {
+		return
+	}
+	c.client = nil
+	go client.Close()
+
+	c.log.Warn().Err(err).Msg("websocket.disconnected")
+	if !c.reconnecting {
+		c.reconnecting = true
+		c.wg.Add(1)
+		go c.reconnectLoop()
+	}
+}
+
+// Close stops reconnecting and closes the current client.
+func (c *wsConnection) Close() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.closed = true
+	c.cancel()
+	client := c.client
+	c.client = nil
+	c.mu.Unlock()
+
+	c.wg.Wait()
+	if client != nil {
+		client.Close()
+	}
+}
+
+// reconnectLoop redials until it succeeds or the connection is closed.
+func (c *wsConnection) reconnectLoop() {
+	defer c.wg.Done()
+
+	backoff := c.minBackoff
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		client, sub, err := c.dial()
+		if err != nil {
+			backoff = min(backoff*2, c.maxBackoff)
+			c.log.Warn().
+				Err(err).
+				Int("attempt", attempt).
+				Dur("retry_in", backoff).
+				Msg("websocket.reconnect_failed")
+			continue
+		}
+
+		c.mu.Lock()
+		c.reconnecting = false
+		if c.closed {
+			c.mu.Unlock()
+			client.Close()
+			return
+		}
+		c.setClientLocked(client, sub)
+		c.mu.Unlock()
+		c.log.Info().Int("attempts", attempt).Msg("websocket.reconnected")
+		return
+	}
+}
+
+// setClientLocked installs client and watches it for disconnects. c.mu must be held.
+func (c *wsConnection) setClientLocked(client *ws.Client, sub *ws.SignatureSubscription) {
+	c.client = client
+	c.wg.Add(1)
+	go c.watch(client, sub)
+}
+
+// watch waits on the client's sentinel subscription, so a dropped socket is noticed as soon as
+// the ws package fails its subscriptions rather than on the next confirmation.
+func (c *wsConnection) watch(client *ws.Client, sub *ws.SignatureSubscription) {
+	defer c.wg.Done()
+
+	select {
+	case <-c.ctx.Done():
+	case err := <-sub.Err():
+		c.markBroken(client, err)
+	}
+}
+
+// dial connects and immediately opens a subscription that never fires. The ws package only
+// reports a dropped socket to open subscriptions, so it has to exist before the socket can drop.
+func (c *wsConnection) dial() (*ws.Client, *ws.SignatureSubscription, error) {
+	ctx, cancel := context.WithTimeout(c.ctx, wsDialTimeout)
+	defer cancel()
+	client, err := ws.Connect(ctx, c.url)
+	if err != nil {
+		return nil, nil, fmt.Errorf("x402 solana: connect websocket: %w", err)
+	}
+	sub, err := client.SignatureSubscribe(solana.Signature{}, rpc.CommitmentProcessed)
+	if err != nil {
+		client.Close()
+		return nil, nil, fmt.Errorf("x402 solana: watch websocket: %w", err)
+	}
+	return client, sub, nil
+}
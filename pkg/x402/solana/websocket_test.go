@@ -0,0 +1,90 @@
+package solana
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gorilla/websocket"
+)
+
+func TestWSConnectionReconnects(t *testing.T) {
+	var (
+		connections atomic.Int32
+		watched     = make(chan struct{})
+		drop        = make(chan struct{})
+		upgrader    websocket.Upgrader
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if connections.Add(1) == 1 {
+			// First connection: wait for the watch subscription, then hold it until the test drops it
+			if _, _, err := conn.ReadMessage(); err == nil {
+				close(watched)
+			}
+			<-drop
+			return
+		}
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	c, err := newWSConnection("ws"+strings.TrimPrefix(srv.URL, "http"), 10*time.Millisecond, 50*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	first := c.Client()
+	if first == nil {
+		t.Fatal("expected a connected client")
+	}
+
+	select {
+	case <-watched:
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection was not watched")
+	}
+	close(drop)
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if client := c.Client(); client != nil && client != first {
+			if connections.Load() != 2 {
+				t.Errorf("connections = %d, want 2", connections.Load())
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("websocket did not reconnect after the connection dropped")
+}
+
+func TestAwaitConfirmationWithoutWebSocket(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"context":{"slot":1},"value":[{"slot":1,"confirmations":null,"err":null,"confirmationStatus":"confirmed"}]}}`))
+	}))
+	defer srv.Close()
+
+	// No WebSocket (disconnected) and a deadline that passes before the first poll: the final
+	// status check must still run
+	v := &SolanaVerifier{rpcClient: rpc.New(srv.URL)}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := v.awaitConfirmation(ctx, solana.Signature{1}, rpc.CommitmentConfirmed); err != nil {
+		t.Errorf("awaitConfirmation: %v", err)
+	}
+}
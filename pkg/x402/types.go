@@ -10,7 +10,8 @@ import (
 	"time"
 )
 
-// PaymentPayload follows the x402 specification for the X-PAYMENT header.
+// PaymentPayload follows the x402 specification for the X-PAYMENT header. Both Cedros
+// payloads (x402Version 0) and published v1 payloads (scheme "exact") are accepted.
 // Reference: https://github.com/coinbase/x402
 type PaymentPayload struct {
 	X402Version int    `json:"x402Version"`
@@ -41,7 +42,7 @@ type SolanaPayload struct {
 type PaymentProof struct {
 	X402Version int
 	Scheme      string
-	Network     string
+	Network     string // Solana cluster; v1 network names are mapped ("solana" → "mainnet-beta")
 	Signature   string
 	Payer       string
 	Transaction string
@@ -69,7 +70,24 @@ type Requirement struct {
 	AllowedTokens         []string
 	QuoteTTL              time.Duration
 	SkipPreflight         bool
+	SimulateTransaction   bool // Simulate before sending to report program failures as specific errors
 	Commitment            string
+	SquadsMultisig        string // When set, the transaction must propose the transfer from this Squads multisig's vault
+	Memo                  string // When set, the transaction must carry a memo instruction with exactly this text
+	VerifyOnly            bool   // Check and simulate the transaction without submitting it
+	Unsponsored           bool   // The recipient is not ours: server wallets neither co-sign as fee payer nor create its token account
+
+	// CheckPayer, when set, is called with the paying wallet once the transaction is decoded and
+	// before it is sent; an error rejects the payment with nothing submitted.
+	CheckPayer func(ctx context.Context, wallet string) error
+
+	// CheckAmount, when set, is called with the transferred amount once the transaction is decoded
+	// and before it is sent; an error rejects the payment with nothing submitted.
+	CheckAmount func(ctx context.Context, amount float64) error
+
+	// OnSubmitted, when set, is called with the transaction signature once the network has
+	// accepted the transaction, before waiting for confirmation. It is not called with VerifyOnly.
+	OnSubmitted func(signature string)
 }
 
 // VerificationResult captures the verifier outcome.
@@ -117,7 +135,7 @@ func ParsePaymentProof(header string) (PaymentProof, error) {
 	proof := PaymentProof{
 		X402Version: payload.X402Version,
 		Scheme:      payload.Scheme,
-		Network:     payload.Network,
+		Network:     ClusterForNetwork(payload.Network),
 	}
 
 	// Extract scheme-specific payload
@@ -127,7 +145,8 @@ func ParsePaymentProof(header string) (PaymentProof, error) {
 	}
 
 	switch payload.Scheme {
-	case "solana-spl-transfer", "solana":
+	case SchemeSolanaSPLTransfer, SchemeSolana, SchemeExact:
+		// v1 "exact" payloads carry only the transaction; the extensions stay empty
 		var solPayload SolanaPayload
 		if err := json.Unmarshal(payloadJSON, &solPayload); err != nil {
 			return proof, fmt.Errorf("x402: parse solana payload: %w", err)
@@ -143,7 +162,7 @@ func ParsePaymentProof(header string) (PaymentProof, error) {
 		proof.ResourceType = solPayload.ResourceType
 
 	default:
-		return proof, fmt.Errorf("x402: unsupported scheme %q (supported: solana-spl-transfer, solana)", payload.Scheme)
+		return proof, fmt.Errorf("x402: unsupported scheme %q (supported: %s, %s, %s)", payload.Scheme, SchemeSolanaSPLTransfer, SchemeSolana, SchemeExact)
 	}
 
 	// Validation
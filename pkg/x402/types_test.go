@@ -0,0 +1,70 @@
+package x402
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestParsePaymentProof(t *testing.T) {
+	tests := []struct {
+		name        string
+		header      string
+		wantScheme  string
+		wantNetwork string
+		wantSig     string
+		wantErr     string
+	}{
+		{
+			name:        "cedros payload",
+			header:      `{"x402Version":0,"scheme":"solana-spl-transfer","network":"mainnet-beta","payload":{"signature":"sig","transaction":"dHg=","resource":"demo"}}`,
+			wantScheme:  SchemeSolanaSPLTransfer,
+			wantNetwork: "mainnet-beta",
+			wantSig:     "sig",
+		},
+		{
+			name:        "v1 exact payload",
+			header:      base64.StdEncoding.EncodeToString([]byte(`{"x402Version":1,"scheme":"exact","network":"solana-devnet","payload":{"transaction":"dHg="}}`)),
+			wantScheme:  SchemeExact,
+			wantNetwork: "devnet",
+		},
+		{
+			name:    "unsupported scheme",
+			header:  `{"x402Version":1,"scheme":"upto","network":"solana","payload":{"transaction":"dHg="}}`,
+			wantErr: "unsupported scheme",
+		},
+		{
+			name:    "missing transaction",
+			header:  `{"x402Version":1,"scheme":"exact","network":"solana","payload":{}}`,
+			wantErr: "missing transaction",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proof, err := ParsePaymentProof(tt.header)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParsePaymentProof error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePaymentProof error: %v", err)
+			}
+			if proof.Scheme != tt.wantScheme || proof.Network != tt.wantNetwork || proof.Signature != tt.wantSig || proof.Transaction != "dHg=" {
+				t.Errorf("proof = %+v, want %s on %s (signature %q)", proof, tt.wantScheme, tt.wantNetwork, tt.wantSig)
+			}
+		})
+	}
+}
+
+func TestV1Network(t *testing.T) {
+	for cluster, v1 := range map[string]string{"mainnet-beta": "solana", "devnet": "solana-devnet", "localnet": "localnet"} {
+		if got := V1Network(cluster); got != v1 {
+			t.Errorf("V1Network(%q) = %q, want %q", cluster, got, v1)
+		}
+		if got := ClusterForNetwork(v1); got != cluster {
+			t.Errorf("ClusterForNetwork(%q) = %q, want %q", v1, got, cluster)
+		}
+	}
+}
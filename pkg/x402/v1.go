@@ -0,0 +1,37 @@
+package x402
+
+// v1Networks maps Solana clusters to the network names of the published x402 v1 spec.
+var v1Networks = map[string]string{
+	"mainnet-beta": "solana",
+	"devnet":       "solana-devnet",
+	"testnet":      "solana-testnet",
+}
+
+// SupportedScheme reports whether scheme is a Solana transfer scheme the verifier accepts.
+func SupportedScheme(scheme string) bool {
+	switch scheme {
+	case SchemeSolanaSPLTransfer, SchemeSolana, SchemeExact:
+		return true
+	}
+	return false
+}
+
+// V1Network returns the x402 v1 network name for a Solana cluster, e.g. "solana" for
+// "mainnet-beta". Unknown clusters are returned unchanged.
+func V1Network(cluster string) string {
+	if network, ok := v1Networks[cluster]; ok {
+		return network
+	}
+	return cluster
+}
+
+// ClusterForNetwork returns the Solana cluster an x402 network name refers to, accepting
+// both cluster names and v1 names ("solana-devnet" → "devnet").
+func ClusterForNetwork(network string) string {
+	for cluster, v1 := range v1Networks {
+		if network == v1 {
+			return cluster
+		}
+	}
+	return network
+}
@@ -0,0 +1,308 @@
+// Package x402client pays for Cedros Pay resources from Go: it fetches a resource's x402 quote,
+// builds and signs the SPL token transfer, and submits it to the server's verify endpoint,
+// retrying transient failures without ever paying twice.
+package x402client
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Client pays for resources on one Cedros Pay server.
+type Client struct {
+	baseURL    string
+	signer     Signer
+	httpClient *http.Client
+	rpcClient  *rpc.Client
+	attempts   int
+	backoff    time.Duration
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used to call the server (default: a client with a 60s
+// timeout, as verification waits for on-chain confirmation).
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) { c.httpClient = httpClient }
+}
+
+// WithRPCClient sets the Solana RPC client that supplies recent blockhashes (default: the public
+// endpoint of the quote's network).
+func WithRPCClient(rpcClient *rpc.Client) Option {
+	return func(c *Client) { c.rpcClient = rpcClient }
+}
+
+// WithRetry sets how many times a request is attempted and the wait before the first retry,
+// which doubles on each further retry (default: 3 attempts, 500ms).
+func WithRetry(attempts int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.attempts = max(attempts, 1)
+		c.backoff = backoff
+	}
+}
+
+// New creates a client for the server at baseURL, including the route prefix (for example
+// "https://pay.example.com/api"), paying from signer's wallet.
+func New(baseURL string, signer Signer, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		signer:     signer,
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+		attempts:   3,
+		backoff:    500 * time.Millisecond,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Requirement is an x402 payment requirement from a 402 response's accepts list.
+type Requirement struct {
+	Scheme            string           `json:"scheme"`
+	Network           string           `json:"network"`
+	MaxAmountRequired string           `json:"maxAmountRequired"` // in atomic units
+	Resource          string           `json:"resource"`
+	Description       string           `json:"description"`
+	PayTo             string           `json:"payTo"`
+	MaxTimeoutSeconds int              `json:"maxTimeoutSeconds"`
+	Asset             string           `json:"asset"` // token mint
+	Extra             RequirementExtra `json:"extra"`
+}
+
+// RequirementExtra holds the Solana details Cedros Pay adds to a requirement.
+type RequirementExtra struct {
+	RecipientTokenAccount string `json:"recipientTokenAccount,omitempty"`
+	Decimals              uint8  `json:"decimals"`
+	TokenSymbol           string `json:"tokenSymbol,omitempty"`
+	Memo                  string `json:"memo,omitempty"`
+	FeePayer              string `json:"feePayer,omitempty"`
+}
+
+// paymentRequired is the body of a 402 response.
+type paymentRequired struct {
+	X402Version int            `json:"x402Version"`
+	Accepts     []*Requirement `json:"accepts"`
+}
+
+// Receipt is a verified payment.
+type Receipt struct {
+	Signature  string      `json:"signature"`
+	Wallet     string      `json:"wallet"`
+	Settlement *Settlement `json:"-"` // from the X-PAYMENT-RESPONSE header
+}
+
+// Settlement is the on-chain settlement the server reports for a payment.
+type Settlement struct {
+	Success     bool   `json:"success"`
+	TxHash      string `json:"txHash"`
+	NetworkID   string `json:"networkId"`
+	Transaction string `json:"transaction,omitempty"`
+	Network     string `json:"network,omitempty"`
+	Payer       string `json:"payer,omitempty"`
+}
+
+// Quote fetches the payment requirement for a resource, priced with couponCode when given.
+func (c *Client) Quote(ctx context.Context, resource, couponCode string) (*Requirement, error) {
+	body := map[string]any{"resource": resource, "wallet": c.signer.PublicKey().String()}
+	if couponCode != "" {
+		body["couponCode"] = couponCode
+	}
+	var quote paymentRequired
+	if err := c.retry(ctx, func() error {
+		_, err := c.do(ctx, http.MethodPost, "/paywall/v1/quote", body, nil, &quote)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	if len(quote.Accepts) == 0 || quote.Accepts[0] == nil {
+		return nil, fmt.Errorf("x402client: quote for %s has no payment requirement", resource)
+	}
+	return quote.Accepts[0], nil
+}
+
+// Pay quotes a resource, pays for it, and returns the verified payment. Transient failures
+// resubmit the same signed transaction, so it cannot be paid twice; a new transaction is only
+// signed when the server reports the quote or transaction expired, which keeps the old one from
+// ever landing.
+func (c *Client) Pay(ctx context.Context, resource, couponCode string) (*Receipt, error) {
+	var lastErr error
+	for range c.attempts {
+		requirement, err := c.Quote(ctx, resource, couponCode)
+		if err != nil {
+			return nil, err
+		}
+		payment, err := c.BuildPayment(ctx, requirement)
+		if err != nil {
+			return nil, err
+		}
+		receipt, err := c.Submit(ctx, payment)
+		if err == nil {
+			return receipt, nil
+		}
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || !apiErr.Expired() {
+			return nil, err
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// Submit sends a signed payment to the verify endpoint, resubmitting it on transient failures.
+// When a resubmission is refused as already used, the earlier attempt went through and its
+// recorded payment is returned.
+func (c *Client) Submit(ctx context.Context, payment *Payment) (*Receipt, error) {
+	query := url.Values{"resource": {payment.Resource}, "resourceType": {"regular"}}
+	headers := map[string]string{"X-PAYMENT": payment.Header}
+
+	var (
+		receipt   Receipt
+		submitted bool
+	)
+	err := c.retry(ctx, func() error {
+		respHeader, err := c.do(ctx, http.MethodPost, "/paywall/v1/verify?"+query.Encode(), nil, headers, &receipt)
+		var apiErr *APIError
+		if submitted && errors.As(err, &apiErr) && apiErr.Code == "payment_already_used" {
+			return c.lookup(ctx, payment.Signature, &receipt)
+		}
+		submitted = true
+		if err != nil {
+			return err
+		}
+		receipt.Settlement, err = DecodeSettlement(respHeader.Get("X-PAYMENT-RESPONSE"))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &receipt, nil
+}
+
+// lookup reads a payment the server already verified.
+func (c *Client) lookup(ctx context.Context, signature string, receipt *Receipt) error {
+	var verified struct {
+		Verified bool   `json:"verified"`
+		Wallet   string `json:"wallet"`
+	}
+	path := "/paywall/v1/x402-transaction/verify?" + url.Values{"signature": {signature}}.Encode()
+	if _, err := c.do(ctx, http.MethodGet, path, nil, nil, &verified); err != nil {
+		return err
+	}
+	if !verified.Verified {
+		return fmt.Errorf("x402client: payment %s was not verified", signature)
+	}
+	*receipt = Receipt{Signature: signature, Wallet: verified.Wallet}
+	return nil
+}
+
+// retry calls fn until it succeeds, fails permanently, or runs out of attempts.
+func (c *Client) retry(ctx context.Context, fn func() error) error {
+	wait := c.backoff
+	var err error
+	for attempt := range c.attempts {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+			wait *= 2
+		}
+		if err = fn(); err == nil || !retryable(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// retryable reports whether a failed request may succeed when sent again.
+func retryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Retryable || apiErr.Status == http.StatusTooManyRequests || apiErr.Status >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// do sends a request to the server, decodes the JSON response into out, and returns the
+// response headers. A 402 without an error object is decoded too, as the quote endpoint answers
+// with one.
+func (c *Client) do(ctx context.Context, method, path string, body any, headers map[string]string, out any) (http.Header, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("x402client: encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("x402client: new request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("x402client: %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("x402client: read response: %w", err)
+	}
+
+	var errResp struct {
+		Error *APIError `json:"error"`
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		decoded := json.Unmarshal(data, &errResp) == nil && errResp.Error != nil
+		if !decoded && resp.StatusCode != http.StatusPaymentRequired {
+			errResp.Error = &APIError{Message: strings.TrimSpace(string(data))}
+		}
+		if errResp.Error != nil {
+			errResp.Error.Status = resp.StatusCode
+			return nil, errResp.Error
+		}
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return nil, fmt.Errorf("x402client: decode response: %w", err)
+	}
+	return resp.Header, nil
+}
+
+// DecodeSettlement decodes an X-PAYMENT-RESPONSE header. An empty header decodes to nil.
+func DecodeSettlement(header string) (*Settlement, error) {
+	if header == "" {
+		return nil, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(header)
+	if err != nil {
+		return nil, fmt.Errorf("x402client: decode X-PAYMENT-RESPONSE: %w", err)
+	}
+	var settlement Settlement
+	if err := json.Unmarshal(data, &settlement); err != nil {
+		return nil, fmt.Errorf("x402client: parse X-PAYMENT-RESPONSE: %w", err)
+	}
+	return &settlement, nil
+}
@@ -0,0 +1,192 @@
+package x402client
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/CedrosPay/server/pkg/x402"
+)
+
+const usdcMint = "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"
+
+// verifyReply is one scripted answer of the fake verify endpoint.
+type verifyReply struct {
+	status int
+	code   string
+}
+
+func TestPay(t *testing.T) {
+	recipient := solana.NewWallet().PublicKey()
+	ok := verifyReply{status: http.StatusOK}
+
+	tests := []struct {
+		name         string
+		replies      []verifyReply
+		wantErrCode  string
+		wantVerifies int
+		wantQuotes   int
+		wantLookup   bool
+	}{
+		{name: "paid first time", replies: []verifyReply{ok}, wantVerifies: 1, wantQuotes: 1},
+		{name: "transient failure resubmits", replies: []verifyReply{{status: http.StatusServiceUnavailable, code: "service_unavailable"}, ok}, wantVerifies: 2, wantQuotes: 1},
+		{name: "resubmission already used", replies: []verifyReply{{status: http.StatusBadGateway, code: "rpc_error"}, {status: http.StatusPaymentRequired, code: "payment_already_used"}}, wantVerifies: 2, wantQuotes: 1, wantLookup: true},
+		{name: "expired quote is requoted", replies: []verifyReply{{status: http.StatusPaymentRequired, code: "quote_expired"}, ok}, wantVerifies: 2, wantQuotes: 2},
+		{name: "permanent failure", replies: []verifyReply{{status: http.StatusPaymentRequired, code: "amount_mismatch"}}, wantErrCode: "amount_mismatch", wantVerifies: 1, wantQuotes: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu      sync.Mutex
+				quotes  int
+				headers []string
+			)
+			mux := http.NewServeMux()
+			mux.HandleFunc("POST /api/paywall/v1/quote", func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				quotes++
+				mu.Unlock()
+				w.WriteHeader(http.StatusPaymentRequired)
+				_ = json.NewEncoder(w).Encode(map[string]any{
+					"x402Version": 0,
+					"accepts": []map[string]any{{
+						"scheme":            x402.SchemeSolanaSPLTransfer,
+						"network":           "mainnet-beta",
+						"maxAmountRequired": "1500000",
+						"resource":          "report",
+						"payTo":             recipient.String(),
+						"asset":             usdcMint,
+						"extra":             map[string]any{"decimals": 6, "memo": "cedros:report"},
+					}},
+				})
+			})
+			mux.HandleFunc("POST /api/paywall/v1/verify", func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				reply := tt.replies[min(len(headers), len(tt.replies)-1)]
+				headers = append(headers, r.Header.Get("X-PAYMENT"))
+				mu.Unlock()
+				signature := checkPayment(t, r.Header.Get("X-PAYMENT"), recipient)
+				if reply.status != http.StatusOK {
+					w.WriteHeader(reply.status)
+					_ = json.NewEncoder(w).Encode(map[string]any{"error": map[string]any{"code": reply.code, "message": reply.code}})
+					return
+				}
+				settlement, _ := json.Marshal(map[string]any{"success": true, "txHash": signature})
+				w.Header().Set("X-PAYMENT-RESPONSE", base64.StdEncoding.EncodeToString(settlement))
+				_ = json.NewEncoder(w).Encode(map[string]any{"granted": true, "wallet": "payer", "signature": signature})
+			})
+			mux.HandleFunc("GET /api/paywall/v1/x402-transaction/verify", func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewEncoder(w).Encode(map[string]any{"verified": true, "wallet": "payer"})
+			})
+			server := httptest.NewServer(mux)
+			t.Cleanup(server.Close)
+
+			client := New(server.URL+"/api", KeypairSigner(solana.NewWallet().PrivateKey), WithRPCClient(blockhashRPC(t)), WithRetry(3, time.Millisecond))
+			receipt, err := client.Pay(t.Context(), "report", "")
+
+			var apiErr *APIError
+			switch {
+			case tt.wantErrCode != "":
+				if !errors.As(err, &apiErr) || apiErr.Code != tt.wantErrCode {
+					t.Fatalf("err = %v, want %s", err, tt.wantErrCode)
+				}
+			case err != nil:
+				t.Fatalf("Pay: %v", err)
+			case receipt.Signature == "" || receipt.Wallet != "payer":
+				t.Fatalf("receipt = %+v, want the payer's payment", receipt)
+			case tt.wantLookup != (receipt.Settlement == nil):
+				t.Fatalf("receipt settlement = %+v, looked up = %v", receipt.Settlement, tt.wantLookup)
+			}
+			if len(headers) != tt.wantVerifies || quotes != tt.wantQuotes {
+				t.Fatalf("verify calls = %d, quotes = %d, want %d and %d", len(headers), quotes, tt.wantVerifies, tt.wantQuotes)
+			}
+			// Only a requote signs a new transaction; retries resend the same one
+			if tt.wantQuotes == 1 && len(headers) > 1 && headers[0] != headers[1] {
+				t.Error("resubmission signed a new transaction")
+			}
+		})
+	}
+}
+
+// checkPayment decodes an X-PAYMENT header and checks its transaction transfers the quoted
+// amount to recipient's token account, returning the signature.
+func checkPayment(t *testing.T, header string, recipient solana.PublicKey) string {
+	t.Helper()
+	proof, err := x402.ParsePaymentProof(header)
+	if err != nil {
+		t.Errorf("parse payment: %v", err)
+		return ""
+	}
+	if proof.Resource != "report" || proof.Memo != "cedros:report" {
+		t.Errorf("payment resource = %q, memo = %q", proof.Resource, proof.Memo)
+	}
+	tx, err := solana.TransactionFromBase64(proof.Transaction)
+	if err != nil {
+		t.Errorf("decode transaction: %v", err)
+		return ""
+	}
+	if tx.Signatures[0].String() != proof.Signature {
+		t.Errorf("signature = %s, want the transaction's %s", proof.Signature, tx.Signatures[0])
+	}
+	if err := tx.VerifySignatures(); err != nil {
+		t.Errorf("verify signatures: %v", err)
+	}
+	accounts, err := tx.Message.Instructions[0].ResolveInstructionAccounts(&tx.Message)
+	if err != nil {
+		t.Fatalf("resolve accounts: %v", err)
+	}
+	decoded, err := token.DecodeInstruction(accounts, tx.Message.Instructions[0].Data)
+	if err != nil {
+		t.Fatalf("decode transfer: %v", err)
+	}
+	transfer, ok := decoded.Impl.(*token.TransferChecked)
+	if !ok {
+		t.Fatalf("instruction = %T, want TransferChecked", decoded.Impl)
+	}
+	wantAccount, _, _ := solana.FindAssociatedTokenAddress(recipient, solana.MustPublicKeyFromBase58(usdcMint))
+	if *transfer.Amount != 1500000 || *transfer.Decimals != 6 || !transfer.GetDestinationAccount().PublicKey.Equals(wantAccount) {
+		t.Errorf("transfer = %d (%d decimals) to %s, want 1500000 to %s", *transfer.Amount, *transfer.Decimals, transfer.GetDestinationAccount().PublicKey, wantAccount)
+	}
+	return proof.Signature
+}
+
+// blockhashRPC is an RPC endpoint that answers getLatestBlockhash with a new hash each call.
+func blockhashRPC(t *testing.T) *rpc.Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID json.RawMessage `json:"id"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		hash := solana.HashFromBytes(solana.NewWallet().PublicKey().Bytes())
+		_, _ = fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":{"context":{"slot":1},"value":{"blockhash":%q,"lastValidBlockHeight":100}}}`, req.ID, hash)
+	}))
+	t.Cleanup(srv.Close)
+	return rpc.New(srv.URL)
+}
+
+func TestAPIErrorDecoding(t *testing.T) {
+	payload := base64.StdEncoding.EncodeToString([]byte("{}"))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte("slow down"))
+	}))
+	t.Cleanup(server.Close)
+
+	client := New(server.URL, KeypairSigner(solana.NewWallet().PrivateKey), WithRetry(2, time.Millisecond))
+	_, err := client.Submit(t.Context(), &Payment{Resource: "report", Header: payload})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusTooManyRequests || apiErr.Message != "slow down" {
+		t.Fatalf("err = %v, want a 429 APIError with the body as message", err)
+	}
+}
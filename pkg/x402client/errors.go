@@ -0,0 +1,25 @@
+package x402client
+
+import "fmt"
+
+// APIError is an error response from the server.
+type APIError struct {
+	Status    int            `json:"-"`
+	Code      string         `json:"code"`
+	Message   string         `json:"message"`
+	Retryable bool           `json:"retryable"`
+	Details   map[string]any `json:"details,omitempty"`
+}
+
+func (e *APIError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("x402client: server returned %d: %s", e.Status, e.Message)
+	}
+	return fmt.Sprintf("x402client: %s: %s", e.Code, e.Message)
+}
+
+// Expired reports whether the payment was refused because its quote or transaction expired,
+// so a freshly quoted and signed payment may succeed.
+func (e *APIError) Expired() bool {
+	return e.Code == "quote_expired" || e.Code == "transaction_expired"
+}
@@ -0,0 +1,158 @@
+package x402client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/memo"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	solanaHelpers "github.com/CedrosPay/server/internal/solana"
+	"github.com/CedrosPay/server/pkg/x402"
+)
+
+// Signer signs transactions for the paying wallet. Implement it to keep the key in a hardware
+// wallet or KMS; KeypairSigner holds it in process.
+type Signer = solanaHelpers.Signer
+
+// KeypairSigner signs with an in-process private key.
+func KeypairSigner(key solana.PrivateKey) Signer {
+	return solanaHelpers.NewLocalSigner(key)
+}
+
+// Payment is a signed payment ready to submit.
+type Payment struct {
+	Resource  string
+	Signature string // transaction signature, known before submission
+	Header    string // X-PAYMENT header value
+}
+
+// BuildPayment builds the SPL token transfer a requirement asks for, with its memo, signs it,
+// and encodes it as an X-PAYMENT header. The header can also be sent to any route the server's
+// paywall middleware protects.
+func (c *Client) BuildPayment(ctx context.Context, requirement *Requirement) (*Payment, error) {
+	tx, err := c.buildTransfer(ctx, requirement)
+	if err != nil {
+		return nil, err
+	}
+	if err := solanaHelpers.SignTransaction(ctx, tx, c.signer); err != nil {
+		return nil, fmt.Errorf("x402client: sign transaction: %w", err)
+	}
+	encoded, err := tx.ToBase64()
+	if err != nil {
+		return nil, fmt.Errorf("x402client: encode transaction: %w", err)
+	}
+	signature := tx.Signatures[0].String()
+
+	payload, err := json.Marshal(x402.PaymentPayload{
+		X402Version: 0,
+		Scheme:      x402.SchemeSolanaSPLTransfer,
+		Network:     x402.ClusterForNetwork(requirement.Network),
+		Payload: x402.SolanaPayload{
+			Signature:             signature,
+			Transaction:           encoded,
+			Resource:              requirement.Resource,
+			ResourceType:          "regular",
+			Memo:                  requirement.Extra.Memo,
+			RecipientTokenAccount: requirement.Extra.RecipientTokenAccount,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("x402client: encode payment payload: %w", err)
+	}
+	return &Payment{
+		Resource:  requirement.Resource,
+		Signature: signature,
+		Header:    base64.StdEncoding.EncodeToString(payload),
+	}, nil
+}
+
+// buildTransfer builds the unsigned transfer from the signer's token account, paying its own fees.
+func (c *Client) buildTransfer(ctx context.Context, requirement *Requirement) (*solana.Transaction, error) {
+	if !x402.SupportedScheme(requirement.Scheme) {
+		return nil, fmt.Errorf("x402client: unsupported scheme %q", requirement.Scheme)
+	}
+	amount, err := strconv.ParseUint(requirement.MaxAmountRequired, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("x402client: invalid maxAmountRequired %q: %w", requirement.MaxAmountRequired, err)
+	}
+	mint, err := solana.PublicKeyFromBase58(requirement.Asset)
+	if err != nil {
+		return nil, fmt.Errorf("x402client: invalid asset %q: %w", requirement.Asset, err)
+	}
+	destination, err := recipientTokenAccount(requirement, mint)
+	if err != nil {
+		return nil, err
+	}
+	payer := c.signer.PublicKey()
+	source, _, err := solana.FindAssociatedTokenAddress(payer, mint)
+	if err != nil {
+		return nil, fmt.Errorf("x402client: derive payer token account: %w", err)
+	}
+
+	instructions := []solana.Instruction{
+		token.NewTransferCheckedInstruction(amount, requirement.Extra.Decimals, source, mint, destination, payer, nil).Build(),
+	}
+	if requirement.Extra.Memo != "" {
+		memoInst, err := memo.NewMemoInstruction([]byte(requirement.Extra.Memo), payer).ValidateAndBuild()
+		if err != nil {
+			return nil, fmt.Errorf("x402client: memo instruction: %w", err)
+		}
+		instructions = append(instructions, memoInst)
+	}
+
+	blockhash, err := c.rpcFor(requirement.Network).GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
+	if err != nil {
+		return nil, fmt.Errorf("x402client: latest blockhash: %w", err)
+	}
+	if blockhash == nil || blockhash.Value == nil {
+		return nil, errors.New("x402client: latest blockhash: empty response")
+	}
+	tx, err := solana.NewTransaction(instructions, blockhash.Value.Blockhash, solana.TransactionPayer(payer))
+	if err != nil {
+		return nil, fmt.Errorf("x402client: build transaction: %w", err)
+	}
+	return tx, nil
+}
+
+// recipientTokenAccount is the token account the requirement pays: the one Cedros Pay names in
+// extra, else payTo's associated token account.
+func recipientTokenAccount(requirement *Requirement, mint solana.PublicKey) (solana.PublicKey, error) {
+	if requirement.Extra.RecipientTokenAccount != "" {
+		account, err := solana.PublicKeyFromBase58(requirement.Extra.RecipientTokenAccount)
+		if err != nil {
+			return solana.PublicKey{}, fmt.Errorf("x402client: invalid recipientTokenAccount: %w", err)
+		}
+		return account, nil
+	}
+	owner, err := solana.PublicKeyFromBase58(requirement.PayTo)
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("x402client: invalid payTo %q: %w", requirement.PayTo, err)
+	}
+	account, _, err := solana.FindAssociatedTokenAddress(owner, mint)
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("x402client: derive recipient token account: %w", err)
+	}
+	return account, nil
+}
+
+// rpcFor returns the configured RPC client, or the public endpoint of network.
+func (c *Client) rpcFor(network string) *rpc.Client {
+	if c.rpcClient != nil {
+		return c.rpcClient
+	}
+	switch x402.ClusterForNetwork(network) {
+	case "devnet":
+		return rpc.New(rpc.DevNet_RPC)
+	case "testnet":
+		return rpc.New(rpc.TestNet_RPC)
+	default:
+		return rpc.New(rpc.MainNetBeta_RPC)
+	}
+}